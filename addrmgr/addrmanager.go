@@ -17,6 +17,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -46,6 +47,7 @@ type AddrManager struct {
 	nNew           int
 	lamtx          sync.Mutex
 	localAddresses map[string]*localAddress
+	anchors        []string // NetAddressKey of anchor addresses
 }
 
 type serializedKnownAddress struct {
@@ -55,6 +57,9 @@ type serializedKnownAddress struct {
 	TimeStamp   int64
 	LastAttempt int64
 	LastSuccess int64
+	// The following fields were added in version 2.
+	Services wire.ServiceFlag
+	Latency  int64 // nanoseconds
 	// no refcount or tried, that is available from context.
 }
 
@@ -64,6 +69,7 @@ type serializedAddrManager struct {
 	Addresses    []*serializedKnownAddress
 	NewBuckets   [newBucketCount][]string // string is NetAddressKey
 	TriedBuckets [triedBucketCount][]string
+	Anchors      []string // string is NetAddressKey, added in version 2
 }
 
 type localAddress struct {
@@ -154,8 +160,18 @@ const (
 	// will share with a call to AddressCache.
 	getAddrPercent = 23
 
+	// maxAnchors is the maximum number of anchor addresses that are
+	// persisted to be reconnected to first on the next start.
+	maxAnchors = 2
+
+	// qualityLatencyHalving is the latency for every multiple of which the
+	// quality of a known address is halved.
+	qualityLatencyHalving = 250 * time.Millisecond
+
 	// serialisationVersion is the current version of the on-disk format.
-	serialisationVersion = 1
+	// Version 2 added the services and latency of addresses and the anchor
+	// addresses.
+	serialisationVersion = 2
 )
 
 // updateAddress is a helper function to either update an address already known
@@ -377,6 +393,8 @@ func (a *AddrManager) savePeers() {
 		ska.Attempts = v.attempts
 		ska.LastAttempt = v.lastattempt.Unix()
 		ska.LastSuccess = v.lastsuccess.Unix()
+		ska.Services = v.na.Services
+		ska.Latency = int64(v.latency)
 		// Tried and refs are implicit in the rest of the structure
 		// and will be worked out from context on unserialisation.
 		sam.Addresses[i] = ska
@@ -399,6 +417,7 @@ func (a *AddrManager) savePeers() {
 			j++
 		}
 	}
+	sam.Anchors = a.anchors
 
 	w, err := os.Create(a.peersFile)
 	if err != nil {
@@ -453,7 +472,9 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 		return fmt.Errorf("error reading %s: %v", filePath, err)
 	}
 
-	if sam.Version != serialisationVersion {
+	// Version 1 files are still accepted since the fields added in version
+	// 2 default to unknown services, no measured latency, and no anchors.
+	if sam.Version < 1 || sam.Version > serialisationVersion {
 		return fmt.Errorf("unknown version %v in serialized "+
 			"addrmanager", sam.Version)
 	}
@@ -474,6 +495,10 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 		ka.attempts = v.Attempts
 		ka.lastattempt = time.Unix(v.LastAttempt, 0)
 		ka.lastsuccess = time.Unix(v.LastSuccess, 0)
+		if v.Services != 0 {
+			ka.na.Services = v.Services
+		}
+		ka.latency = time.Duration(v.Latency)
 		a.addrIndex[NetAddressKey(ka.na)] = ka
 	}
 
@@ -519,6 +544,17 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 		}
 	}
 
+	// Anchors that are no longer known are simply dropped since they might
+	// have been evicted before the file was saved.
+	for _, val := range sam.Anchors {
+		if len(a.anchors) == maxAnchors {
+			break
+		}
+		if _, ok := a.addrIndex[val]; ok {
+			a.anchors = append(a.anchors, val)
+		}
+	}
+
 	return nil
 }
 
@@ -668,6 +704,34 @@ func (a *AddrManager) AddressCache() []*wire.NetAddress {
 	return allAddr[0:numAddresses]
 }
 
+// KnownAddresses returns a copy of all addresses known to the address manager
+// ordered by their address key.  Unlike AddressCache, which returns a random
+// subset to share with peers, it is intended for inspecting the state of the
+// address manager.
+func (a *AddrManager) KnownAddresses() []*KnownAddress {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	keys := make([]string, 0, len(a.addrIndex))
+	for k := range a.addrIndex {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	addrs := make([]*KnownAddress, 0, len(keys))
+	for _, k := range keys {
+		ka := *a.addrIndex[k]
+		na := *ka.na
+		ka.na = &na
+		if ka.srcAddr != nil {
+			srcAddr := *ka.srcAddr
+			ka.srcAddr = &srcAddr
+		}
+		addrs = append(addrs, &ka)
+	}
+	return addrs
+}
+
 // reset resets the address manager by reinitialising the random source
 // and allocating fresh empty bucket storage.
 func (a *AddrManager) reset() {
@@ -682,14 +746,20 @@ func (a *AddrManager) reset() {
 	for i := range a.addrTried {
 		a.addrTried[i] = list.New()
 	}
+	a.anchors = nil
 }
 
 // HostToNetAddress returns a netaddress given a host address. If the address is
 // a tor .onion address this will be taken care of. else if the host is not an
-// IP address it will be resolved (via tor if required).
+// IP address it will be resolved (via tor if required).  Only version 2 onion
+// addresses are supported since they are the only ones that can be encoded as
+// an IPv6 address in the OnionCat range.
 func (a *AddrManager) HostToNetAddress(host string, port uint16, services wire.ServiceFlag) (*wire.NetAddress, error) {
 	// tor address is 16 char base32 + ".onion"
 	var ip net.IP
+	if strings.HasSuffix(host, ".onion") && len(host) != 22 {
+		return nil, fmt.Errorf("unsupported onion address %s", host)
+	}
 	if len(host) == 22 && host[16:] == ".onion" {
 		// go base32 encoding uses capitals (as does the rfc
 		// but tor and bitcoind tend to user lowercase, so we switch
@@ -851,6 +921,132 @@ func (a *AddrManager) Connected(addr *wire.NetAddress) {
 	}
 }
 
+// SetServices sets the services for the given address to the provided value.
+// The address must already be known to AddrManager else it will be ignored.
+func (a *AddrManager) SetServices(addr *wire.NetAddress, services wire.ServiceFlag) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	ka := a.find(addr)
+	if ka == nil {
+		return
+	}
+
+	// Update the services if needed.
+	if ka.na.Services != services {
+		// ka.na is immutable, so replace it.
+		naCopy := *ka.na
+		naCopy.Services = services
+		ka.na = &naCopy
+	}
+}
+
+// SetLatency records the round trip time measured for the given address so it
+// persists across restarts.  The address must already be known to AddrManager
+// else it will be ignored.
+func (a *AddrManager) SetLatency(addr *wire.NetAddress, latency time.Duration) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	ka := a.find(addr)
+	if ka == nil {
+		return
+	}
+	ka.latency = latency
+}
+
+// qualityAddress pairs a known address with its quality.
+type qualityAddress struct {
+	ka      *KnownAddress
+	quality float64
+}
+
+// addrsByQuality implements sort.Interface to sort known addresses by
+// descending quality and then by address key.
+type addrsByQuality []qualityAddress
+
+func (s addrsByQuality) Len() int      { return len(s) }
+func (s addrsByQuality) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s addrsByQuality) Less(i, j int) bool {
+	if s[i].quality != s[j].quality {
+		return s[i].quality > s[j].quality
+	}
+	return NetAddressKey(s[i].ka.na) < NetAddressKey(s[j].ka.na)
+}
+
+// PreferredAddresses returns copies of up to max tried addresses with a
+// measured latency, ordered by descending quality.  The quality favours low
+// latency, a recent successful connection and few failed attempts since then,
+// which makes the addresses good candidates for long-lived outbound connections
+// that relay new blocks and votes quickly.
+func (a *AddrManager) PreferredAddresses(max int) []*KnownAddress {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	now := time.Now()
+	addrs := make([]qualityAddress, 0, a.nTried)
+	for _, ka := range a.addrIndex {
+		if !ka.tried || ka.latency <= 0 {
+			continue
+		}
+		addrs = append(addrs, qualityAddress{ka, ka.quality(now)})
+	}
+	sort.Sort(addrsByQuality(addrs))
+	if len(addrs) > max {
+		addrs = addrs[:max]
+	}
+
+	preferred := make([]*KnownAddress, 0, len(addrs))
+	for _, qa := range addrs {
+		ka := *qa.ka
+		na := *ka.na
+		ka.na = &na
+		if ka.srcAddr != nil {
+			srcAddr := *ka.srcAddr
+			ka.srcAddr = &srcAddr
+		}
+		preferred = append(preferred, &ka)
+	}
+	return preferred
+}
+
+// SetAnchors sets the addresses that are reconnected to first on the next
+// start, which makes it harder for an attacker to eclipse the node by
+// filling the address manager with its own addresses while the node is
+// restarting.  The addresses are expected to be ordered by preference and at
+// most maxAnchors of them that are known to the address manager are kept.
+// The anchors are persisted along with the other addresses.
+func (a *AddrManager) SetAnchors(addrs []*wire.NetAddress) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	a.anchors = nil
+	for _, addr := range addrs {
+		if len(a.anchors) == maxAnchors {
+			break
+		}
+		if ka := a.find(addr); ka != nil {
+			a.anchors = append(a.anchors, NetAddressKey(ka.na))
+		}
+	}
+}
+
+// Anchors returns the anchor addresses that were persisted by the previous
+// run, ordered by preference.  Only addresses that are still known to the
+// address manager are returned.
+func (a *AddrManager) Anchors() []*KnownAddress {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	anchors := make([]*KnownAddress, 0, len(a.anchors))
+	for _, key := range a.anchors {
+		if ka, ok := a.addrIndex[key]; ok {
+			anchors = append(anchors, ka)
+		}
+	}
+	return anchors
+}
+
 // Good marks the given address as good.  To be called after a successful
 // connection and version exchange.  If the address is unknown to the address
 // manager it will be ignored.
@@ -965,6 +1161,20 @@ func (a *AddrManager) AddLocalAddress(na *wire.NetAddress, priority AddressPrior
 	return nil
 }
 
+// RemoveLocalAddress removes na from the list of known local addresses to
+// advertise.  It returns whether or not the address was known.
+func (a *AddrManager) RemoveLocalAddress(na *wire.NetAddress) bool {
+	a.lamtx.Lock()
+	defer a.lamtx.Unlock()
+
+	key := NetAddressKey(na)
+	if _, ok := a.localAddresses[key]; !ok {
+		return false
+	}
+	delete(a.localAddresses, key)
+	return true
+}
+
 // getReachabilityFrom returns the relative reachability of the provided local
 // address to the provided remote address.
 func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
@@ -8,7 +8,9 @@ package addrmgr_test
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
 	"reflect"
 	"testing"
 	"time"
@@ -209,6 +211,35 @@ func TestAddLocalAddress(t *testing.T) {
 	}
 }
 
+func TestRemoveLocalAddress(t *testing.T) {
+	amgr := addrmgr.New("testremovelocaladdress", nil)
+	localAddr := wire.NewNetAddressIPPort(net.ParseIP("204.124.1.1"), 8333,
+		wire.SFNodeNetwork)
+	remoteAddr := wire.NewNetAddressIPPort(net.ParseIP("204.124.8.100"),
+		8333, wire.SFNodeNetwork)
+	if err := amgr.AddLocalAddress(localAddr, addrmgr.BoundPrio); err != nil {
+		t.Fatalf("AddLocalAddress failed: %v", err)
+	}
+	got := amgr.GetBestLocalAddress(remoteAddr)
+	if !got.IP.Equal(localAddr.IP) {
+		t.Fatalf("GetBestLocalAddress: got %v, want %v", got.IP,
+			localAddr.IP)
+	}
+
+	// The address must no longer be advertised once removed.
+	if !amgr.RemoveLocalAddress(localAddr) {
+		t.Fatalf("RemoveLocalAddress: %v was not known", localAddr.IP)
+	}
+	if amgr.RemoveLocalAddress(localAddr) {
+		t.Fatalf("RemoveLocalAddress: %v still known", localAddr.IP)
+	}
+	got = amgr.GetBestLocalAddress(remoteAddr)
+	if !got.IP.Equal(net.IPv4zero) {
+		t.Fatalf("GetBestLocalAddress: got %v, want %v", got.IP,
+			net.IPv4zero)
+	}
+}
+
 func TestAttempt(t *testing.T) {
 	n := addrmgr.New("testattempt", lookupFunc)
 
@@ -315,6 +346,162 @@ func TestGood(t *testing.T) {
 	}
 }
 
+// TestKnownAddresses ensures all known addresses are returned in order of
+// their address key and the returned addresses are copies.
+func TestKnownAddresses(t *testing.T) {
+	n := addrmgr.New("testknownaddresses", lookupFunc)
+	if addrs := n.KnownAddresses(); len(addrs) != 0 {
+		t.Fatalf("got %d known addresses, want 0", len(addrs))
+	}
+
+	srcAddr := wire.NewNetAddressIPPort(net.IPv4(173, 144, 173, 111), 8333, 0)
+	addrs := []*wire.NetAddress{
+		wire.NewNetAddressIPPort(net.IPv4(173, 194, 115, 66), 8333, 0),
+		wire.NewNetAddressIPPort(net.IPv4(12, 1, 2, 3), 8333, 0),
+		wire.NewNetAddressIPPort(net.IPv4(173, 194, 115, 66), 8334, 0),
+	}
+	n.AddAddresses(addrs, srcAddr)
+	n.Good(addrs[1])
+
+	known := n.KnownAddresses()
+	want := []string{"12.1.2.3:8333", "173.194.115.66:8333",
+		"173.194.115.66:8334"}
+	if len(known) != len(want) {
+		t.Fatalf("got %d known addresses, want %d", len(known), len(want))
+	}
+	for i, ka := range known {
+		if key := addrmgr.NetAddressKey(ka.NetAddress()); key != want[i] {
+			t.Errorf("known address #%d: got %s, want %s", i, key,
+				want[i])
+		}
+	}
+	if !known[0].Tried() || known[1].Tried() {
+		t.Errorf("unexpected tried state %v %v", known[0].Tried(),
+			known[1].Tried())
+	}
+
+	// Modifying the returned addresses must not affect the manager.
+	known[1].NetAddress().Port = 1
+	if key := addrmgr.NetAddressKey(n.KnownAddresses()[1].NetAddress()); key != want[1] {
+		t.Errorf("returned address aliases the manager, got %s", key)
+	}
+}
+
+// TestPersistQuality ensures the services, latency, and anchors of addresses
+// survive saving and reloading the peers file.
+func TestPersistQuality(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testpersistquality")
+	if err != nil {
+		t.Fatalf("TempDir: unexpected error %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	n := addrmgr.New(dir, lookupFunc)
+	n.Start()
+	var addrs []*wire.NetAddress
+	for i := 0; i < 3; i++ {
+		s := fmt.Sprintf("173.%d.115.66:8333", 194+i)
+		addr, err := n.DeserializeNetAddress(s)
+		if err != nil {
+			t.Fatalf("Failed to turn %s into an address: %v", s, err)
+		}
+		n.AddAddress(addr, addr)
+		n.Good(addr)
+		addrs = append(addrs, addr)
+	}
+
+	const services = wire.SFNodeNetwork | wire.SFNodeBloom
+	n.SetServices(addrs[1], services)
+	n.SetLatency(addrs[1], 150*time.Millisecond)
+
+	// Only the maximum number of anchors that are known are kept.
+	unknown := wire.NewNetAddressIPPort(net.IPv4(1, 2, 3, 4), 8333, 0)
+	n.SetAnchors([]*wire.NetAddress{addrs[1], unknown, addrs[0], addrs[2]})
+	if err := n.Stop(); err != nil {
+		t.Fatalf("Address Manager failed to stop: %v", err)
+	}
+
+	n = addrmgr.New(dir, lookupFunc)
+	n.Start()
+	defer n.Stop()
+	if n.NumAddresses() != len(addrs) {
+		t.Fatalf("got %d addresses after reload, want %d",
+			n.NumAddresses(), len(addrs))
+	}
+	anchors := n.Anchors()
+	if len(anchors) != 2 {
+		t.Fatalf("got %d anchors after reload, want 2", len(anchors))
+	}
+	wantKeys := []string{addrmgr.NetAddressKey(addrs[1]),
+		addrmgr.NetAddressKey(addrs[0])}
+	for i, ka := range anchors {
+		if key := addrmgr.NetAddressKey(ka.NetAddress()); key != wantKeys[i] {
+			t.Errorf("anchor %d: got %s, want %s", i, key, wantKeys[i])
+		}
+	}
+	if got := anchors[0].NetAddress().Services; got != services {
+		t.Errorf("got services %v after reload, want %v", got, services)
+	}
+	if got := anchors[0].Latency(); got != 150*time.Millisecond {
+		t.Errorf("got latency %v after reload, want %v", got,
+			150*time.Millisecond)
+	}
+	if anchors[0].LastSuccess().IsZero() {
+		t.Error("last success was not persisted")
+	}
+}
+
+func TestPreferredAddresses(t *testing.T) {
+	n := addrmgr.New("testpreferredaddresses", lookupFunc)
+	var addrs []*wire.NetAddress
+	for i := 0; i < 5; i++ {
+		s := fmt.Sprintf("173.%d.115.66:8333", 194+i)
+		addr, err := n.DeserializeNetAddress(s)
+		if err != nil {
+			t.Fatalf("Failed to turn %s into an address: %v", s, err)
+		}
+		n.AddAddress(addr, addr)
+		addrs = append(addrs, addr)
+	}
+
+	// The address without a measured latency and the one which was never
+	// connected to are not preferred, while failed attempts since the last
+	// successful connection outweigh a lower latency.
+	latencies := []time.Duration{300, 100, 0, 200, 50}
+	for i, addr := range addrs {
+		if i != 4 {
+			n.Good(addr)
+		}
+		n.SetLatency(addr, latencies[i]*time.Millisecond)
+	}
+	n.Attempt(addrs[1])
+
+	tests := []struct {
+		max  int
+		want []*wire.NetAddress
+	}{
+		{10, []*wire.NetAddress{addrs[3], addrs[0], addrs[1]}},
+		{2, []*wire.NetAddress{addrs[3], addrs[0]}},
+		{0, nil},
+	}
+	for i, test := range tests {
+		preferred := n.PreferredAddresses(test.max)
+		if len(preferred) != len(test.want) {
+			t.Errorf("PreferredAddresses #%d: got %d addresses, want %d",
+				i, len(preferred), len(test.want))
+			continue
+		}
+		for j, ka := range preferred {
+			got := addrmgr.NetAddressKey(ka.NetAddress())
+			want := addrmgr.NetAddressKey(test.want[j])
+			if got != want {
+				t.Errorf("PreferredAddresses #%d: got %s at index %d, "+
+					"want %s", i, got, j, want)
+			}
+		}
+	}
+}
+
 func TestGetAddress(t *testing.T) {
 	n := addrmgr.New("testgetaddress", lookupFunc)
 
@@ -458,6 +645,45 @@ func TestGetBestLocalAddress(t *testing.T) {
 	*/
 }
 
+// TestHostToNetAddressOnion ensures tor onion addresses are encoded into and
+// decoded from the OnionCat range without lookups.
+func TestHostToNetAddressOnion(t *testing.T) {
+	n := addrmgr.New("testhosttonetaddressonion", lookupFunc)
+
+	const host = "aaaqeayeaudaocaj.onion"
+	na, err := n.HostToNetAddress(host, 8333, wire.SFNodeNetwork)
+	if err != nil {
+		t.Fatalf("HostToNetAddress: unexpected error %v", err)
+	}
+	if !addrmgr.IsOnionCatTor(na) {
+		t.Fatalf("HostToNetAddress: %v is not an OnionCat address", na.IP)
+	}
+	wantIP := net.ParseIP("fd87:d87e:eb43:1:203:405:607:809")
+	if !na.IP.Equal(wantIP) {
+		t.Errorf("HostToNetAddress: got ip %v, want %v", na.IP, wantIP)
+	}
+	if key := addrmgr.NetAddressKey(na); key != host+":8333" {
+		t.Errorf("NetAddressKey: got %s, want %s:8333", key, host)
+	}
+
+	// Uppercase addresses are accepted while malformed and unsupported
+	// onion addresses are rejected.
+	_, err = n.HostToNetAddress("AAAQEAYEAUDAOCAJ.onion", 8333,
+		wire.SFNodeNetwork)
+	if err != nil {
+		t.Errorf("HostToNetAddress: unexpected error %v for uppercase "+
+			"address", err)
+	}
+	for _, host := range []string{"aaaqeayeaudaoca1.onion",
+		"aaaqeayeaudaocajaaaqeayeaudaocajaaaqeayeaudaocajaaaqeaye.onion"} {
+
+		_, err := n.HostToNetAddress(host, 8333, wire.SFNodeNetwork)
+		if err == nil {
+			t.Errorf("HostToNetAddress: expected error for %s", host)
+		}
+	}
+}
+
 func TestNetAddressKey(t *testing.T) {
 	addNaTests()
 
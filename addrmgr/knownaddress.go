@@ -6,6 +6,7 @@
 package addrmgr
 
 import (
+	"math"
 	"time"
 
 	"github.com/HcashOrg/hcashd/wire"
@@ -19,6 +20,7 @@ type KnownAddress struct {
 	attempts    int
 	lastattempt time.Time
 	lastsuccess time.Time
+	latency     time.Duration
 	tried       bool
 	refs        int // reference count of new buckets
 }
@@ -34,6 +36,30 @@ func (ka *KnownAddress) LastAttempt() time.Time {
 	return ka.lastattempt
 }
 
+// LastSuccess returns the last time a connection to the known address was
+// successfully established.
+func (ka *KnownAddress) LastSuccess() time.Time {
+	return ka.lastsuccess
+}
+
+// Attempts returns the number of connection attempts to the known address
+// since the last successful connection.
+func (ka *KnownAddress) Attempts() int {
+	return ka.attempts
+}
+
+// Tried returns whether or not a connection to the known address was
+// successfully established, which moves it to the tried buckets.
+func (ka *KnownAddress) Tried() bool {
+	return ka.tried
+}
+
+// Latency returns the last round trip time measured for the known address.  It
+// is zero when no latency has been measured.
+func (ka *KnownAddress) Latency() time.Duration {
+	return ka.latency
+}
+
 // chance returns the selection probability for a known address.  The priority
 // depends upon how recently the address has been seen, how recently it was last
 // attempted and how often attempts to connect to it have failed.
@@ -64,6 +90,27 @@ func (ka *KnownAddress) chance() float64 {
 	return c
 }
 
+// quality returns how well suited a known address is for a long-lived outbound
+// connection, where higher is better.  The quality of addresses that were never
+// connected to or have no measured latency is zero.  Otherwise it is halved for
+// every qualityLatencyHalving of latency, for every failed attempt since the
+// last successful connection, and when that connection was over a day ago.
+func (ka *KnownAddress) quality(now time.Time) float64 {
+	if !ka.tried || ka.latency <= 0 {
+		return 0
+	}
+
+	q := math.Pow(0.5, float64(ka.latency)/float64(qualityLatencyHalving))
+	for i := ka.attempts; i > 0; i-- {
+		q /= 2
+	}
+	if now.Sub(ka.lastsuccess) > 24*time.Hour {
+		q /= 2
+	}
+
+	return q
+}
+
 // isBad returns true if the address in question has not been tried in the last
 // minute and meets one of the following criteria:
 // 1) It claims to be from the future
@@ -0,0 +1,191 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/HcashOrg/hcashutil"
+)
+
+// errAmountOverflow is returned by the checked amount helpers when the result
+// can not be represented as an amount.
+var errAmountOverflow = errors.New("amount overflows the range of an int64")
+
+// amountUnits maps the denomination labels accepted by parseAmount to their
+// units.  Both the short labels and the ones produced by hcashutil are
+// accepted.
+var amountUnits = map[string]hcashutil.AmountUnit{
+	"HC":     hcashutil.AmountCoin,
+	"HCASH":  hcashutil.AmountCoin,
+	"mHC":    hcashutil.AmountMilliCoin,
+	"mHCASH": hcashutil.AmountMilliCoin,
+	"µHC":    hcashutil.AmountMicroCoin,
+	"μHC":    hcashutil.AmountMicroCoin,
+	"μHCASH": hcashutil.AmountMicroCoin,
+	"uHC":    hcashutil.AmountMicroCoin,
+	"atom":   hcashutil.AmountAtom,
+	"atoms":  hcashutil.AmountAtom,
+	"Atom":   hcashutil.AmountAtom,
+	"Atoms":  hcashutil.AmountAtom,
+}
+
+// amountUnitLabels are the labels formatAmount appends for the supported
+// denominations.
+var amountUnitLabels = map[hcashutil.AmountUnit]string{
+	hcashutil.AmountCoin:      "HC",
+	hcashutil.AmountMilliCoin: "mHC",
+	hcashutil.AmountMicroCoin: "µHC",
+	hcashutil.AmountAtom:      "atoms",
+}
+
+// addAmounts returns the sum of the passed amounts or errAmountOverflow when
+// it does not fit in an amount.
+func addAmounts(a, b hcashutil.Amount) (hcashutil.Amount, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, errAmountOverflow
+	}
+	return sum, nil
+}
+
+// subAmounts returns the difference of the passed amounts or
+// errAmountOverflow when it does not fit in an amount.
+func subAmounts(a, b hcashutil.Amount) (hcashutil.Amount, error) {
+	diff := a - b
+	if (b > 0 && diff > a) || (b < 0 && diff < a) {
+		return 0, errAmountOverflow
+	}
+	return diff, nil
+}
+
+// mulAmountF64 multiplies an amount by a floating point value and rounds the
+// result to the nearest atom, half away from zero.  An error is returned when
+// the factor is NaN or infinite or the result does not fit in an amount.
+func mulAmountF64(a hcashutil.Amount, f float64) (hcashutil.Amount, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("invalid amount factor %v", f)
+	}
+	product := math.Round(float64(a) * f)
+	// Every int64 is strictly less than 2^63, which is exactly representable
+	// as a float64 unlike math.MaxInt64.
+	if product >= math.Exp2(63) || product < -math.Exp2(63) {
+		return 0, errAmountOverflow
+	}
+	return hcashutil.Amount(product), nil
+}
+
+// atomsPerUnit returns the number of atoms in one of the passed unit along
+// with its number of decimal places.  Only the supported denominations of
+// amountUnitLabels are valid.
+func atomsPerUnit(u hcashutil.AmountUnit) (int64, int, error) {
+	if _, ok := amountUnitLabels[u]; !ok {
+		return 0, 0, fmt.Errorf("unsupported amount unit %d", u)
+	}
+	decimals := int(u - hcashutil.AmountAtom)
+	per := int64(1)
+	for i := 0; i < decimals; i++ {
+		per *= 10
+	}
+	return per, decimals, nil
+}
+
+// formatAmount formats an amount in the passed denomination using exact
+// integer arithmetic, so every atom is kept regardless of the magnitude of
+// the amount.  The number of decimal places is the precision of the unit and
+// the label of the unit is appended.
+func formatAmount(a hcashutil.Amount, u hcashutil.AmountUnit) (string, error) {
+	per, decimals, err := atomsPerUnit(u)
+	if err != nil {
+		return "", err
+	}
+
+	// Work with the magnitude as an unsigned value so the minimum amount
+	// does not overflow when negated.
+	sign := ""
+	magnitude := uint64(a)
+	if a < 0 {
+		sign = "-"
+		magnitude = -magnitude
+	}
+
+	whole := strconv.FormatUint(magnitude/uint64(per), 10)
+	label := amountUnitLabels[u]
+	if decimals == 0 {
+		return sign + whole + " " + label, nil
+	}
+	frac := strconv.FormatUint(magnitude%uint64(per), 10)
+	frac = strings.Repeat("0", decimals-len(frac)) + frac
+	return sign + whole + "." + frac + " " + label, nil
+}
+
+// parseAmount parses a decimal amount with an optional denomination label
+// separated by white space, such as "1.5 HC", "250 mHC" or "100 atoms".
+// Amounts without a label are in coins.  The value is parsed exactly, so an
+// error is returned instead of rounding when it has more decimal places than
+// the denomination allows or does not fit in an amount.
+func parseAmount(s string) (hcashutil.Amount, error) {
+	fields := strings.Fields(s)
+	unit := hcashutil.AmountCoin
+	switch len(fields) {
+	case 1:
+	case 2:
+		var ok bool
+		unit, ok = amountUnits[fields[1]]
+		if !ok {
+			return 0, fmt.Errorf("unknown amount denomination %q",
+				fields[1])
+		}
+	default:
+		return 0, fmt.Errorf("malformed amount %q", s)
+	}
+	_, decimals, err := atomsPerUnit(unit)
+	if err != nil {
+		return 0, err
+	}
+
+	value := fields[0]
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+	whole, frac := value, ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		whole, frac = value[:i], value[i+1:]
+	}
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("malformed amount %q", s)
+	}
+	if len(frac) > decimals {
+		return 0, fmt.Errorf("amount %q has more than %d decimal "+
+			"places", s, decimals)
+	}
+	for _, digits := range []string{whole, frac} {
+		if strings.Trim(digits, "0123456789") != "" {
+			return 0, fmt.Errorf("malformed amount %q", s)
+		}
+	}
+
+	// Accumulate the magnitude in atoms with overflow checks.  The minimum
+	// amount is one atom larger in magnitude than the maximum one.
+	limit := uint64(math.MaxInt64)
+	if negative {
+		limit++
+	}
+	digits := whole + frac + strings.Repeat("0", decimals-len(frac))
+	var atoms uint64
+	for _, d := range digits {
+		if atoms > (limit-uint64(d-'0'))/10 {
+			return 0, errAmountOverflow
+		}
+		atoms = atoms*10 + uint64(d-'0')
+	}
+	if negative {
+		return hcashutil.Amount(-atoms), nil
+	}
+	return hcashutil.Amount(atoms), nil
+}
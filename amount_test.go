@@ -0,0 +1,201 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// TestAmountArithmetic ensures the checked amount helpers return the exact
+// result or errAmountOverflow when it does not fit in an amount.
+func TestAmountArithmetic(t *testing.T) {
+	const (
+		maxAmt = hcashutil.Amount(math.MaxInt64)
+		minAmt = hcashutil.Amount(math.MinInt64)
+	)
+
+	tests := []struct {
+		name     string
+		op       func(a, b hcashutil.Amount) (hcashutil.Amount, error)
+		a, b     hcashutil.Amount
+		want     hcashutil.Amount
+		overflow bool
+	}{
+		{"add", addAmounts, 5e8, 25e7, 75e7, false},
+		{"add negative", addAmounts, 5e8, -6e8, -1e8, false},
+		{"add to max", addAmounts, maxAmt - 1, 1, maxAmt, false},
+		{"add past max", addAmounts, maxAmt, 1, 0, true},
+		{"add past min", addAmounts, minAmt, -1, 0, true},
+		{"sub", subAmounts, 5e8, 25e7, 25e7, false},
+		{"sub to min", subAmounts, minAmt + 1, 1, minAmt, false},
+		{"sub past min", subAmounts, minAmt, 1, 0, true},
+		{"sub past max", subAmounts, maxAmt, -1, 0, true},
+		{"sub min from zero", subAmounts, 0, minAmt, 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := test.op(test.a, test.b)
+		if test.overflow {
+			if err != errAmountOverflow {
+				t.Errorf("%s: got err %v, want %v", test.name, err,
+					errAmountOverflow)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+// TestMulAmountF64 ensures amounts are multiplied by a factor with rounding
+// half away from zero and invalid or overflowing results are rejected.
+func TestMulAmountF64(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       hcashutil.Amount
+		f       float64
+		want    hcashutil.Amount
+		wantErr bool
+	}{
+		{"identity", 1e8, 1, 1e8, false},
+		{"percentage", 1e8, 0.015, 15e5, false},
+		{"round half up", 5, 0.5, 3, false},
+		{"round half down", -5, 0.5, -3, false},
+		{"zero", 1e8, 0, 0, false},
+		{"overflow", math.MaxInt64 / 2, 3, 0, true},
+		{"negative overflow", math.MinInt64 / 2, 3, 0, true},
+		{"nan", 1e8, math.NaN(), 0, true},
+		{"infinity", 1e8, math.Inf(1), 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := mulAmountF64(test.a, test.f)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got err %v, want error %v", test.name, err,
+				test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+// TestFormatAmount ensures amounts are formatted exactly in every supported
+// denomination.
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		name string
+		a    hcashutil.Amount
+		unit hcashutil.AmountUnit
+		want string
+	}{
+		{"coin", 150000000, hcashutil.AmountCoin, "1.50000000 HC"},
+		{"milli", 150000000, hcashutil.AmountMilliCoin, "1500.00000 mHC"},
+		{"micro", 150000001, hcashutil.AmountMicroCoin, "1500000.01 µHC"},
+		{"atom", 150000001, hcashutil.AmountAtom, "150000001 atoms"},
+		{"fraction", 1, hcashutil.AmountCoin, "0.00000001 HC"},
+		{"negative", -150000000, hcashutil.AmountCoin, "-1.50000000 HC"},
+		{"max", math.MaxInt64, hcashutil.AmountCoin,
+			"92233720368.54775807 HC"},
+		{"min", math.MinInt64, hcashutil.AmountCoin,
+			"-92233720368.54775808 HC"},
+	}
+
+	for _, test := range tests {
+		got, err := formatAmount(test.a, test.unit)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	if _, err := formatAmount(1, hcashutil.AmountKiloCoin); err == nil {
+		t.Error("formatted an amount in an unsupported unit")
+	}
+}
+
+// TestParseAmount ensures amounts are parsed exactly from every supported
+// denomination and malformed, overly precise or overflowing amounts are
+// rejected.
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    hcashutil.Amount
+		wantErr bool
+	}{
+		{"1.5", 150000000, false},
+		{"1.5 HC", 150000000, false},
+		{"1.5 HCASH", 150000000, false},
+		{".5 HC", 50000000, false},
+		{"2. HC", 200000000, false},
+		{"+1 HC", 100000000, false},
+		{"-1.5 HC", -150000000, false},
+		{"250 mHC", 25000000, false},
+		{"1.00001 mHC", 100001, false},
+		{"1.01 µHC", 101, false},
+		{"1.01 μHC", 101, false},
+		{"1.01 uHC", 101, false},
+		{"100 atoms", 100, false},
+		{"1 Atom", 1, false},
+		{"0.1 HC", 10000000, false},
+		{"92233720368.54775807 HC", math.MaxInt64, false},
+		{"-92233720368.54775808 HC", math.MinInt64, false},
+		{"92233720368.54775808 HC", 0, true},
+		{"0.000000001 HC", 0, true},
+		{"1.5 atoms", 0, true},
+		{"1.5 XHC", 0, true},
+		{"1 HC extra", 0, true},
+		{"", 0, true},
+		{".", 0, true},
+		{"1e8 atoms", 0, true},
+		{"--1 HC", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := parseAmount(test.in)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: got err %v, want error %v", test.in, err,
+				test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%q: got %d, want %d", test.in, got, test.want)
+		}
+	}
+}
+
+// TestCalcFee ensures the fees reported by the RPC server are derived from
+// the exact input and output amounts of a transaction.
+func TestCalcFee(t *testing.T) {
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(&wire.TxIn{ValueIn: 30000000})
+	tx.AddTxIn(&wire.TxIn{ValueIn: 70000001})
+	tx.AddTxOut(wire.NewTxOut(99990000, nil))
+	if got, want := calcFee(tx), 0.00010001; got != want {
+		t.Fatalf("got fee %v, want %v", got, want)
+	}
+
+	// Overflowing inputs must not wrap around to a bogus fee.
+	tx.AddTxIn(&wire.TxIn{ValueIn: math.MaxInt64})
+	if _, err := txFee(tx); err != errAmountOverflow {
+		t.Fatalf("got err %v, want %v", err, errAmountOverflow)
+	}
+	if got := calcFee(tx); got != 0 {
+		t.Fatalf("got fee %v for overflowing inputs, want 0", got)
+	}
+}
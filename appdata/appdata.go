@@ -0,0 +1,183 @@
+// Copyright (c) 2013-2014 The btcsuite developers
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package appdata provides the operating system specific application data
+directories used by hcashd and its utilities.
+
+The directory of an application is selected in the following order:
+
+  - The directory named by the <APPNAME>_APPDATA environment variable, such as
+    HCASHD_APPDATA for hcashd
+  - On Windows, the ProgramData directory when the process runs as a service
+    under a system profile, such as the LocalSystem account, since the
+    profile directories of those accounts are inside the Windows directory
+  - On Windows, the LOCALAPPDATA or APPDATA directory
+  - On macOS, ~/Library/Application Support/<Appname>
+  - On POSIX systems, the legacy ~/.<appname> directory when it exists, and
+    otherwise the XDG data directory ($XDG_DATA_HOME/<appname>) when
+    XDG_DATA_HOME is set, falling back to ~/.<appname>
+*/
+package appdata
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// EnvVar returns the name of the environment variable which overrides the
+// application data directory of the passed application.  It is the upper
+// case application name with any leading period removed and the _APPDATA
+// suffix, for example HCASHD_APPDATA.
+func EnvVar(appName string) string {
+	return strings.ToUpper(strings.TrimPrefix(appName, ".")) + "_APPDATA"
+}
+
+// env houses the operating system state the application data directory is
+// selected from so the selection can be tested independently of the host.
+type env struct {
+	goos    string
+	getenv  func(string) string
+	homeDir string
+	exists  func(string) bool
+}
+
+// fileExists reports whether the named file or directory exists.
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// HomeDir returns the home directory of the current user, or an empty string
+// when it can not be determined.
+func HomeDir() string {
+	if usr, err := user.Current(); err == nil && usr.HomeDir != "" {
+		return usr.HomeDir
+	}
+	return os.Getenv("HOME")
+}
+
+// isSystemProfile returns whether the passed Windows application data
+// directory belongs to a system profile, which is used by services running as
+// the LocalSystem, LocalService, or NetworkService accounts.
+func isSystemProfile(dir string) bool {
+	dir = strings.ToLower(filepath.ToSlash(dir))
+	return strings.Contains(dir, "/system32/config/systemprofile") ||
+		strings.Contains(dir, "/serviceprofiles/")
+}
+
+// appDataDir returns the application data directory for the passed
+// environment.  See Dir for more details.
+func appDataDir(e *env, appName string, roaming bool) string {
+	if appName == "" || appName == "." {
+		return "."
+	}
+
+	// An explicitly configured directory always takes precedence.
+	if dir := e.getenv(EnvVar(appName)); dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			return abs
+		}
+		return filepath.Clean(dir)
+	}
+
+	// The caller really shouldn't prepend the appName with a period, but
+	// if they do, handle it gracefully by trimming it.
+	appName = strings.TrimPrefix(appName, ".")
+	appNameUpper := string(unicode.ToUpper(rune(appName[0]))) + appName[1:]
+	appNameLower := string(unicode.ToLower(rune(appName[0]))) + appName[1:]
+
+	switch e.goos {
+	case "windows":
+		// Windows XP and before didn't have a LOCALAPPDATA, so fallback
+		// to regular APPDATA when LOCALAPPDATA is not set.
+		appData := e.getenv("LOCALAPPDATA")
+		if roaming || appData == "" {
+			appData = e.getenv("APPDATA")
+		}
+
+		// The system profiles used by services are inside the Windows
+		// directory, so use the machine wide ProgramData directory for
+		// them instead.
+		if programData := e.getenv("ProgramData"); programData != "" &&
+			(appData == "" || isSystemProfile(appData)) {
+
+			return filepath.Join(programData, appNameUpper)
+		}
+		if appData != "" {
+			return filepath.Join(appData, appNameUpper)
+		}
+
+	case "darwin":
+		if e.homeDir != "" {
+			return filepath.Join(e.homeDir, "Library",
+				"Application Support", appNameUpper)
+		}
+
+	case "plan9":
+		if e.homeDir != "" {
+			return filepath.Join(e.homeDir, appNameLower)
+		}
+
+	default:
+		// Keep using the legacy directory of existing installs so
+		// setting XDG_DATA_HOME does not hide existing data.
+		var legacyDir string
+		if e.homeDir != "" {
+			legacyDir = filepath.Join(e.homeDir, "."+appNameLower)
+			if e.exists(legacyDir) {
+				return legacyDir
+			}
+		}
+		if xdgDataHome := e.getenv("XDG_DATA_HOME"); xdgDataHome != "" &&
+			filepath.IsAbs(xdgDataHome) {
+
+			return filepath.Join(xdgDataHome, appNameLower)
+		}
+		if legacyDir != "" {
+			return legacyDir
+		}
+	}
+
+	// Fall back to the current directory if all else fails.
+	return "."
+}
+
+// Dir returns an operating system specific directory to be used for storing
+// application data for an application.  See the package documentation for
+// the order the directory is selected in.
+//
+// The appName parameter is the name of the application the data directory is
+// being requested for.  This function will prepend a period to the appName for
+// POSIX style operating systems since that is standard practice.  An empty
+// appName or one with a single dot is treated as requesting the current
+// directory so only "." will be returned.  Further, the first character of
+// appName will be made lowercase for POSIX style operating systems and
+// uppercase for Mac and Windows since that is standard practice.
+//
+// The roaming parameter only applies to Windows where it specifies the roaming
+// application data profile (%APPDATA%) should be used instead of the local one
+// (%LOCALAPPDATA%) that is used by default.
+//
+// Example results:
+//
+//	dir := Dir("myapp", false)
+//	 POSIX (Linux/BSD): ~/.myapp, or $XDG_DATA_HOME/myapp
+//	 Mac OS: $HOME/Library/Application Support/Myapp
+//	 Windows: %LOCALAPPDATA%\Myapp
+//	 Windows service: %ProgramData%\Myapp
+//	 Plan 9: $home/myapp
+func Dir(appName string, roaming bool) string {
+	return appDataDir(&env{
+		goos:    runtime.GOOS,
+		getenv:  os.Getenv,
+		homeDir: HomeDir(),
+		exists:  fileExists,
+	}, appName, roaming)
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package appdata
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestAppDataDir ensures the application data directory is selected from the
+// override environment variable, the Windows service profiles, the XDG data
+// directory, and the legacy directories in the documented order.
+func TestAppDataDir(t *testing.T) {
+	home := filepath.Join("/", "home", "user")
+	legacy := filepath.Join(home, ".hcashd")
+
+	tests := []struct {
+		name     string
+		goos     string
+		vars     map[string]string
+		home     string
+		existing string
+		roaming  bool
+		want     string
+	}{
+		{
+			name: "override",
+			goos: "linux",
+			vars: map[string]string{"HCASHD_APPDATA": "/srv/hcashd",
+				"XDG_DATA_HOME": "/home/user/.local/share"},
+			home: home,
+			want: filepath.Join("/", "srv", "hcashd"),
+		},
+		{
+			name: "posix default",
+			goos: "linux",
+			home: home,
+			want: legacy,
+		},
+		{
+			name: "posix xdg",
+			goos: "freebsd",
+			vars: map[string]string{"XDG_DATA_HOME": "/home/user/.local/share"},
+			home: home,
+			want: filepath.Join(home, ".local", "share", "hcashd"),
+		},
+		{
+			name: "posix relative xdg ignored",
+			goos: "linux",
+			vars: map[string]string{"XDG_DATA_HOME": "share"},
+			home: home,
+			want: legacy,
+		},
+		{
+			name:     "posix legacy preferred over xdg",
+			goos:     "linux",
+			vars:     map[string]string{"XDG_DATA_HOME": "/home/user/.local/share"},
+			home:     home,
+			existing: legacy,
+			want:     legacy,
+		},
+		{
+			name: "posix without home",
+			goos: "linux",
+			want: ".",
+		},
+		{
+			name: "darwin",
+			goos: "darwin",
+			home: home,
+			want: filepath.Join(home, "Library", "Application Support",
+				"Hcashd"),
+		},
+		{
+			name: "plan9",
+			goos: "plan9",
+			home: home,
+			want: filepath.Join(home, "hcashd"),
+		},
+		{
+			name: "windows local",
+			goos: "windows",
+			vars: map[string]string{
+				"LOCALAPPDATA": `C:\Users\user\AppData\Local`,
+				"APPDATA":      `C:\Users\user\AppData\Roaming`,
+				"ProgramData":  `C:\ProgramData`,
+			},
+			want: filepath.Join(`C:\Users\user\AppData\Local`, "Hcashd"),
+		},
+		{
+			name: "windows roaming",
+			goos: "windows",
+			vars: map[string]string{
+				"LOCALAPPDATA": `C:\Users\user\AppData\Local`,
+				"APPDATA":      `C:\Users\user\AppData\Roaming`,
+			},
+			roaming: true,
+			want:    filepath.Join(`C:\Users\user\AppData\Roaming`, "Hcashd"),
+		},
+		{
+			name: "windows system profile",
+			goos: "windows",
+			vars: map[string]string{
+				"LOCALAPPDATA": `C:/Windows/System32/config/systemprofile/AppData/Local`,
+				"ProgramData":  `C:\ProgramData`,
+			},
+			want: filepath.Join(`C:\ProgramData`, "Hcashd"),
+		},
+		{
+			name: "windows service profile",
+			goos: "windows",
+			vars: map[string]string{
+				"LOCALAPPDATA": `C:/Windows/ServiceProfiles/NetworkService/AppData/Local`,
+				"ProgramData":  `C:\ProgramData`,
+			},
+			want: filepath.Join(`C:\ProgramData`, "Hcashd"),
+		},
+	}
+
+	for _, test := range tests {
+		e := &env{
+			goos: test.goos,
+			getenv: func(key string) string {
+				return test.vars[key]
+			},
+			homeDir: test.home,
+			exists: func(name string) bool {
+				return name == test.existing
+			},
+		}
+		got := appDataDir(e, "hcashd", test.roaming)
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+
+		// A leading period must be handled gracefully.
+		got = appDataDir(e, ".hcashd", test.roaming)
+		if got != test.want {
+			t.Errorf("%s (leading period): got %q, want %q",
+				test.name, got, test.want)
+		}
+	}
+}
+
+// TestEnvVar ensures the override environment variable names are derived
+// from the application names.
+func TestEnvVar(t *testing.T) {
+	tests := []struct {
+		appName string
+		want    string
+	}{
+		{"hcashd", "HCASHD_APPDATA"},
+		{".hcashctl", "HCASHCTL_APPDATA"},
+		{"Hcashwallet", "HCASHWALLET_APPDATA"},
+	}
+
+	for _, test := range tests {
+		if got := EnvVar(test.appName); got != test.want {
+			t.Errorf("EnvVar(%q): got %q, want %q", test.appName,
+				got, test.want)
+		}
+	}
+}
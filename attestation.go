@@ -0,0 +1,112 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/hcashec/secp256k1"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+// signedMessageMagic is the prefix of the messages signed by the signmessage
+// command and checked by the verifymessage command.
+const signedMessageMagic = "Hypercash Signed Message:\n"
+
+// signedMessageHash returns the hash of the passed message which is signed by
+// the signmessage command and checked by the verifymessage command.
+func signedMessageHash(message string) []byte {
+	var buf bytes.Buffer
+	wire.WriteVarString(&buf, 0, signedMessageMagic)
+	wire.WriteVarString(&buf, 0, message)
+	return chainhash.HashB(buf.Bytes())
+}
+
+// keyBlockAttestation describes the main chain key block at a key height along
+// with the cumulative proof of work of the main chain up to and including it
+// as seen by the node at the time of the attestation.  Independent node
+// operators publish signed attestations so services cross-checking them are
+// able to detect chain splits.
+type keyBlockAttestation struct {
+	network   string
+	keyHeight int64
+	height    int64
+	hash      chainhash.Hash
+	chainWork *big.Int
+	timestamp int64
+}
+
+// message returns the message signed for the attestation.  Every field is on
+// its own line so the message is easy to both read and parse.
+func (a *keyBlockAttestation) message() string {
+	return fmt.Sprintf("hcashd key block attestation\nnetwork: %s\n"+
+		"keyheight: %d\nheight: %d\nhash: %v\nchainwork: %064x\n"+
+		"time: %d", a.network, a.keyHeight, a.height, a.hash,
+		a.chainWork, a.timestamp)
+}
+
+// sign returns the compact signature of the attestation message by the passed
+// key.  The signature commits to the compressed public key of the key, so the
+// message may be checked with the verifymessage command and the
+// pay-to-pubkey-hash address of the key.
+func (a *keyBlockAttestation) sign(key *secp256k1.PrivateKey) ([]byte, error) {
+	return secp256k1.SignCompact(secp256k1.S256(), key,
+		signedMessageHash(a.message()), true)
+}
+
+// loadAttestationKey loads the private key used to sign key block attestations
+// from the passed file, which contains the hex-encoded key.  A new key is
+// generated and written to the file when it does not exist yet, which is
+// reported by the second return value.
+func loadAttestationKey(keyFile string) (*secp256k1.PrivateKey, bool, error) {
+	keyHex, err := ioutil.ReadFile(keyFile)
+	if os.IsNotExist(err) {
+		key, err := genAttestationKey(keyFile)
+		return key, err == nil, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	keyBytes, err := hex.DecodeString(strings.TrimSpace(string(keyHex)))
+	if err != nil {
+		return nil, false, fmt.Errorf("malformed attestation key %s: %v",
+			keyFile, err)
+	}
+	if len(keyBytes) != secp256k1.PrivKeyBytesLen {
+		return nil, false, fmt.Errorf("malformed attestation key %s: "+
+			"got %d bytes, want %d", keyFile, len(keyBytes),
+			secp256k1.PrivKeyBytesLen)
+	}
+	key, _ := secp256k1.PrivKeyFromBytes(secp256k1.S256(), keyBytes)
+	return key, false, nil
+}
+
+// genAttestationKey generates a new private key used to sign key block
+// attestations and writes it to the passed file.
+func genAttestationKey(keyFile string) (*secp256k1.PrivateKey, error) {
+	key, err := secp256k1.GeneratePrivateKey(secp256k1.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, err
+	}
+	keyHex := hex.EncodeToString(key.Serialize()) + "\n"
+	if err := ioutil.WriteFile(keyFile, []byte(keyHex), 0600); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
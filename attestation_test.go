@@ -0,0 +1,103 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/hcashec/secp256k1"
+)
+
+// TestKeyBlockAttestation ensures attestations are signed in a way that allows
+// recovering the attestation key from the signature the same way the
+// verifymessage command does.
+func TestKeyBlockAttestation(t *testing.T) {
+	key, err := secp256k1.GeneratePrivateKey(secp256k1.S256())
+	if err != nil {
+		t.Fatalf("GeneratePrivateKey: %v", err)
+	}
+
+	attestation := &keyBlockAttestation{
+		network:   "mainnet",
+		keyHeight: 400,
+		height:    1200,
+		hash:      chainhash.Hash{0x01},
+		chainWork: big.NewInt(0x0a31f3c2),
+		timestamp: 1510000300,
+	}
+	wantMsg := "hcashd key block attestation\nnetwork: mainnet\n" +
+		"keyheight: 400\nheight: 1200\nhash: " +
+		"0000000000000000000000000000000000000000000000000000000000000001\n" +
+		"chainwork: " +
+		"000000000000000000000000000000000000000000000000000000000a31f3c2\n" +
+		"time: 1510000300"
+	if msg := attestation.message(); msg != wantMsg {
+		t.Fatalf("got message %q, want %q", msg, wantMsg)
+	}
+
+	sig, err := attestation.sign(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	pubKey, compressed, err := secp256k1.RecoverCompact(secp256k1.S256(),
+		sig, signedMessageHash(wantMsg))
+	if err != nil {
+		t.Fatalf("RecoverCompact: %v", err)
+	}
+	wantPubKey := secp256k1.PublicKey(key.PublicKey)
+	if !compressed || !pubKey.IsEqual(&wantPubKey) {
+		t.Fatal("recovered public key does not match attestation key")
+	}
+
+	// The signature must not verify for a different attestation.
+	attestation.height++
+	pubKey, _, err = secp256k1.RecoverCompact(secp256k1.S256(), sig,
+		signedMessageHash(attestation.message()))
+	if err == nil && pubKey.IsEqual(&wantPubKey) {
+		t.Fatal("signature verified for a different attestation")
+	}
+}
+
+// TestLoadAttestationKey ensures the attestation key is generated when it does
+// not exist and the same key is loaded afterwards.
+func TestLoadAttestationKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "attestationkey")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "mainnet", "attestation.key")
+	key, generated, err := loadAttestationKey(keyFile)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	if !generated {
+		t.Fatal("missing key was not reported as generated")
+	}
+	loaded, generated, err := loadAttestationKey(keyFile)
+	if err != nil {
+		t.Fatalf("unable to load key: %v", err)
+	}
+	if generated {
+		t.Fatal("existing key was reported as generated")
+	}
+	if loaded.D.Cmp(key.D) != 0 {
+		t.Fatal("loaded key does not match generated key")
+	}
+
+	// Malformed keys are rejected.
+	if err := ioutil.WriteFile(keyFile, []byte("0102"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, _, err := loadAttestationKey(keyFile); err == nil {
+		t.Fatal("loaded malformed key")
+	}
+}
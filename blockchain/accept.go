@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/txscript"
 	"github.com/HcashOrg/hcashutil"
@@ -20,16 +21,11 @@ import (
 
 // checkCoinbaseUniqueHeight checks to ensure that for all blocks height > 1
 // that the coinbase contains the height encoding to make coinbase hash collisions
-// impossible.
-func checkCoinbaseUniqueHeight(blockHeight, blockKeyHeight int64, block *hcashutil.Block) error {
+// impossible.  Key blocks carry the height in their second coinbase.
+func checkCoinbaseUniqueHeight(blockHeight, blockKeyHeight int64, block *hcashutil.Block, isKeyBlock bool) error {
 	// Coinbase TxOut[0] is always tax, TxOut[1] is always
 	// height + extranonce, so at least two outputs must
 	// exist.
-	isKeyBlock := false
-	if HashToBig(block.Hash()).Cmp(CompactToBig(block.MsgBlock().Header.Bits)) <= 0 {
-		isKeyBlock = true
-	}
-
 	if isKeyBlock {
 		if len(block.MsgBlock().Transactions[0].TxOut) < 2 ||
 			len(block.MsgBlock().Transactions[1].TxOut) < 2{
@@ -197,8 +193,16 @@ func (b *BlockChain) checkBlockContext(block *hcashutil.Block, prevNode *blockNo
 
 		// Check that the coinbase contains at minimum the block
 		// height in output 1.
+		//
+		// Blocks which skip the proof of work check are templates of
+		// key blocks, which are checked like key blocks just like the
+		// block sanity checks do.
 		if blockHeight > 1 {
-			err := checkCoinbaseUniqueHeight(blockHeight, blockKeyHeight, block)
+			isKeyBlock := flags&BFNoPoWCheck == BFNoPoWCheck ||
+				standalone.HashToBig(block.Hash()).Cmp(
+					standalone.CompactToBig(header.Bits)) <= 0
+			err := checkCoinbaseUniqueHeight(blockHeight, blockKeyHeight,
+				block, isKeyBlock)
 			if err != nil {
 				return err
 			}
@@ -277,7 +281,7 @@ func (b *BlockChain) maybeAcceptBlock(block *hcashutil.Block, flags BehaviorFlag
 
 	//check if the continues microblock exceeds the limit of 31
 	blockHash := block.Hash()
-	isKeyBlock := HashToBig(blockHash).Cmp(CompactToBig(block.MsgBlock().Header.Bits)) <= 0
+	isKeyBlock := standalone.HashToBig(blockHash).Cmp(standalone.CompactToBig(block.MsgBlock().Header.Bits)) <= 0
 
 	if !isKeyBlock {
 		var prevKeyBlockHeight int64
@@ -345,6 +349,13 @@ func (b *BlockChain) maybeAcceptBlock(block *hcashutil.Block, flags BehaviorFlag
 		return false, err
 	}
 
+	// Prune old block data and move it to cold storage as needed now that
+	// the main chain was extended.
+	if isMainChain && !dryRun {
+		b.maybePruneBlocks()
+		b.maybeArchiveBlocks()
+	}
+
 	// Notify the caller that the new block was accepted into the block
 	// chain.  The caller would typically want to react by relaying the
 	// inventory to other peers.
@@ -0,0 +1,292 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// AuditCheck identifies an invariant that may be verified by AuditChain.
+type AuditCheck string
+
+const (
+	// AuditSubsidy verifies that the sum of the subsidy added by every
+	// block in the main chain matches the total subsidy recorded in the
+	// best chain state.  Since the supply is cumulative, this check always
+	// scans from the first block regardless of the requested start height
+	// and the totals are only compared when the audit ends at the best
+	// block.
+	AuditSubsidy AuditCheck = "subsidy"
+
+	// AuditTicketPool verifies that the pool size committed to by each key
+	// block header matches the number of tickets in the live ticket treap
+	// of its parent stake node.
+	AuditTicketPool AuditCheck = "ticketpool"
+
+	// AuditKeyHeights verifies that the key height of the main chain is
+	// monotonic, advancing by exactly one after each key block and staying
+	// the same otherwise.
+	AuditKeyHeights AuditCheck = "keyheights"
+)
+
+// AuditChecks is the list of all checks supported by AuditChain.
+var AuditChecks = []AuditCheck{AuditSubsidy, AuditTicketPool, AuditKeyHeights}
+
+// ErrAuditInterrupted is returned by AuditChain when the audit is stopped
+// through its quit channel before completing.
+var ErrAuditInterrupted = errors.New("chain audit interrupted")
+
+// AuditViolation describes a single invariant that did not hold.
+type AuditViolation struct {
+	Check       AuditCheck
+	Height      int64
+	Hash        chainhash.Hash
+	Description string
+}
+
+// String returns the violation in a human-readable form.
+func (v AuditViolation) String() string {
+	return fmt.Sprintf("%s: block %v (height %d): %s", v.Check, v.Hash,
+		v.Height, v.Description)
+}
+
+// AuditResult houses the outcome of AuditChain.
+type AuditResult struct {
+	StartHeight  int64
+	EndHeight    int64
+	TotalSubsidy int64
+	Violations   []AuditViolation
+}
+
+// AuditProgressFunc is invoked by AuditChain after each height has been
+// audited.
+type AuditProgressFunc func(height int64)
+
+// auditKeyBlock returns whether the passed header is a key block.
+func auditKeyBlock(header *wire.BlockHeader) bool {
+	hash := header.BlockHash()
+	return standalone.HashToBig(&hash).Cmp(standalone.CompactToBig(header.Bits)) <= 0
+}
+
+// auditNodeAtHeight returns the main chain block node at the given height.
+// The previously audited node is used as a hint so that walking the chain in
+// ascending order does not have to traverse back from the best node for every
+// height.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) auditNodeAtHeight(hint *blockNode, height int64) (*blockNode, error) {
+	if hint != nil && hint.height == height-1 {
+		for _, child := range hint.children {
+			if child.inMainChain {
+				return child, nil
+			}
+		}
+	}
+
+	node, err := b.ancestorNode(b.bestNode, height)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, fmt.Errorf("no main chain block at height %d", height)
+	}
+	return node, nil
+}
+
+// auditTicketPool verifies the pool size committed to by the main chain key
+// block at the passed height.  The returned node may be used as the hint for
+// the next height.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) auditTicketPool(hint *blockNode, height int64) (*blockNode, *AuditViolation, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node, err := b.auditNodeAtHeight(hint, height)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !node.isKeyBlock || node.parent == nil {
+		return node, nil, nil
+	}
+
+	parentStakeNode, err := b.fetchStakeNode(node.parent)
+	if err != nil {
+		return nil, nil, err
+	}
+	if parentStakeNode == nil {
+		return node, nil, nil
+	}
+
+	if int(node.header.PoolSize) != parentStakeNode.PoolSize() {
+		return node, &AuditViolation{
+			Check:  AuditTicketPool,
+			Height: height,
+			Hash:   node.hash,
+			Description: fmt.Sprintf("header commits to a pool "+
+				"size of %d, but the live ticket treap "+
+				"contains %d tickets", node.header.PoolSize,
+				parentStakeNode.PoolSize()),
+		}, nil
+	}
+	return node, nil, nil
+}
+
+// AuditChain verifies the requested invariants over the main chain blocks in
+// the range [startHeight, endHeight].  An endHeight that is negative or beyond
+// the best block is clamped to the best block.  The progress function, when
+// non-nil, is called after each height is audited, and the audit stops with
+// ErrAuditInterrupted when quit is closed.
+//
+// Violations do not stop the audit; they are collected and returned in the
+// result.  An error is only returned when the audit itself could not be
+// carried out.
+//
+// This function is safe for concurrent access.  The chain lock is only held
+// for short periods so blocks continue to be processed during the audit.
+func (b *BlockChain) AuditChain(checks []AuditCheck, startHeight, endHeight int64, progress AuditProgressFunc, quit <-chan struct{}) (*AuditResult, error) {
+	var checkSubsidy, checkTickets, checkKeyHeights bool
+	for _, check := range checks {
+		switch check {
+		case AuditSubsidy:
+			checkSubsidy = true
+		case AuditTicketPool:
+			checkTickets = true
+		case AuditKeyHeights:
+			checkKeyHeights = true
+		default:
+			return nil, fmt.Errorf("unknown audit check %q", check)
+		}
+	}
+
+	best := b.BestSnapshot()
+	if endHeight < 0 || endHeight > best.Height {
+		endHeight = best.Height
+	}
+	if startHeight < 0 {
+		startHeight = 0
+	}
+	if startHeight > endHeight {
+		return nil, fmt.Errorf("start height %d is after end height %d",
+			startHeight, endHeight)
+	}
+
+	result := &AuditResult{
+		StartHeight: startHeight,
+		EndHeight:   endHeight,
+	}
+
+	// The subsidy is cumulative, so it must be summed from the beginning
+	// of the chain.
+	scanFrom := startHeight
+	if checkSubsidy {
+		scanFrom = 0
+	}
+
+	var parent *hcashutil.Block
+	var prevHeader *wire.BlockHeader
+	var hint *blockNode
+	for height := scanFrom; height <= endHeight; height++ {
+		select {
+		case <-quit:
+			return nil, ErrAuditInterrupted
+		default:
+		}
+
+		inRange := height >= startHeight
+
+		var header *wire.BlockHeader
+		if checkSubsidy {
+			block, err := b.BlockByHeight(height)
+			if err != nil {
+				return nil, err
+			}
+			if parent != nil {
+				result.TotalSubsidy += CalculateAddedSubsidy(block,
+					parent)
+			}
+			parent = block
+			header = &block.MsgBlock().Header
+		} else if checkKeyHeights {
+			var err error
+			header, err = b.HeaderByHeight(height)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if checkKeyHeights && inRange && height > 0 {
+			if prevHeader == nil {
+				var err error
+				prevHeader, err = b.HeaderByHeight(height - 1)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			// The key height only advances by one when the parent is a
+			// key block.
+			prevKeyHeight := int64(prevHeader.KeyHeight)
+			keyHeight := int64(header.KeyHeight)
+			wantKeyHeight := prevKeyHeight
+			if auditKeyBlock(prevHeader) {
+				wantKeyHeight++
+			}
+			if keyHeight != wantKeyHeight {
+				result.Violations = append(result.Violations,
+					AuditViolation{
+						Check:  AuditKeyHeights,
+						Height: height,
+						Hash:   header.BlockHash(),
+						Description: fmt.Sprintf("key "+
+							"height is %d, but "+
+							"expected %d from "+
+							"parent key height %d",
+							keyHeight, wantKeyHeight,
+							prevKeyHeight),
+					})
+			}
+		}
+		prevHeader = header
+
+		if checkTickets && inRange && height > 0 {
+			var violation *AuditViolation
+			var err error
+			hint, violation, err = b.auditTicketPool(hint, height)
+			if err != nil {
+				return nil, err
+			}
+			if violation != nil {
+				result.Violations = append(result.Violations,
+					*violation)
+			}
+		}
+
+		if progress != nil && inRange {
+			progress(height)
+		}
+	}
+
+	if checkSubsidy && endHeight == best.Height &&
+		result.TotalSubsidy != best.TotalSubsidy {
+
+		result.Violations = append(result.Violations, AuditViolation{
+			Check:  AuditSubsidy,
+			Height: best.Height,
+			Hash:   *best.Hash,
+			Description: fmt.Sprintf("sum of block subsidies is %d, "+
+				"but the chain state records a total subsidy "+
+				"of %d", result.TotalSubsidy, best.TotalSubsidy),
+		})
+	}
+
+	return result, nil
+}
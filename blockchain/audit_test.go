@@ -0,0 +1,123 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/blockchain/chaingen"
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// TestAuditChain ensures the audit of a valid chain reports no violations,
+// reports progress for the requested heights only and rejects invalid
+// requests.
+func TestAuditChain(t *testing.T) {
+	params := &chaincfg.SimNetParams
+	g, err := chaingen.MakeGenerator(params)
+	if err != nil {
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+	chain, teardownFunc, err := blockchain.SetupTestChain("audittest", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	accepted := func() {
+		msgBlock := g.Tip()
+		block := hcashutil.NewBlock(msgBlock)
+		_, _, err := chain.ProcessBlock(block, blockchain.BFNone)
+		if err != nil {
+			t.Fatalf("block %q (hash %s, height %d) should have "+
+				"been accepted: %v", g.TipName(), block.Hash(),
+				msgBlock.Header.Height, err)
+		}
+	}
+
+	// Build a chain which matures coinbases and then purchases tickets so
+	// every check has something to verify.
+	//
+	//   genesis -> bp -> bm0 -> ... -> bm# -> bse0 -> ... -> bse#
+	g.CreatePremineBlock("bp", 0)
+	accepted()
+	for i := uint16(0); i < params.CoinbaseMaturity; i++ {
+		g.NextBlock(fmt.Sprintf("bm%d", i), nil, nil)
+		g.SaveTipCoinbaseOuts()
+		accepted()
+	}
+	for i := 0; int64(g.Tip().Header.Height) < params.StakeEnabledHeight+2; i++ {
+		outs := g.OldestCoinbaseOuts()
+		g.NextBlock(fmt.Sprintf("bse%d", i), nil, outs[1:])
+		g.SaveTipCoinbaseOuts()
+		accepted()
+	}
+	tipHeight := int64(g.Tip().Header.Height)
+
+	var audited []int64
+	progress := func(height int64) {
+		audited = append(audited, height)
+	}
+	result, err := chain.AuditChain(blockchain.AuditChecks, 0, -1, progress,
+		nil)
+	if err != nil {
+		t.Fatalf("AuditChain: unexpected error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Fatalf("AuditChain: unexpected violations: %v",
+			result.Violations)
+	}
+	if result.StartHeight != 0 || result.EndHeight != tipHeight {
+		t.Fatalf("AuditChain: got heights %d-%d, want 0-%d",
+			result.StartHeight, result.EndHeight, tipHeight)
+	}
+	if result.TotalSubsidy != chain.TotalSubsidy() {
+		t.Fatalf("AuditChain: got total subsidy %d, want %d",
+			result.TotalSubsidy, chain.TotalSubsidy())
+	}
+	if int64(len(audited)) != tipHeight+1 {
+		t.Fatalf("AuditChain: progress reported for %d heights, want %d",
+			len(audited), tipHeight+1)
+	}
+
+	// The subsidy is summed from the first block even when the audit starts
+	// later, but progress is only reported for the requested heights.
+	audited = nil
+	result, err = chain.AuditChain(blockchain.AuditChecks, 5, 10, progress,
+		nil)
+	if err != nil {
+		t.Fatalf("AuditChain(5, 10): unexpected error: %v", err)
+	}
+	if len(result.Violations) != 0 {
+		t.Fatalf("AuditChain(5, 10): unexpected violations: %v",
+			result.Violations)
+	}
+	if len(audited) != 6 || audited[0] != 5 || audited[5] != 10 {
+		t.Fatalf("AuditChain(5, 10): progress reported for %v", audited)
+	}
+
+	// Invalid requests are rejected.
+	_, err = chain.AuditChain([]blockchain.AuditCheck{"unknown"}, 0, -1,
+		nil, nil)
+	if err == nil {
+		t.Fatal("AuditChain: unknown check accepted")
+	}
+	_, err = chain.AuditChain(blockchain.AuditChecks, tipHeight, 1, nil, nil)
+	if err == nil {
+		t.Fatal("AuditChain: start height after end height accepted")
+	}
+
+	// A closed quit channel interrupts the audit.
+	quit := make(chan struct{})
+	close(quit)
+	_, err = chain.AuditChain(blockchain.AuditChecks, 0, -1, nil, quit)
+	if err != blockchain.ErrAuditInterrupted {
+		t.Fatalf("AuditChain: got error %v, want %v", err,
+			blockchain.ErrAuditInterrupted)
+	}
+}
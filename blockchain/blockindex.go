@@ -0,0 +1,134 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"sort"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// BlockIndexFlags is a bitmask describing the status of a block in the block
+// index.
+type BlockIndexFlags uint8
+
+const (
+	// BIFMainChain indicates the block is part of the main chain.
+	BIFMainChain BlockIndexFlags = 1 << iota
+
+	// BIFKeyBlock indicates the block is a key block.
+	BIFKeyBlock
+
+	// BIFStakeNode indicates the stake node of the block is loaded in
+	// memory, so the lottery data for the block can be obtained without
+	// reconstructing it.
+	BIFStakeNode
+
+	// BIFNone is a convenience value to specifically indicate no flags.
+	BIFNone BlockIndexFlags = 0
+)
+
+// BlockIndexEntry is a read-only snapshot of a node in the block index.  It
+// describes the position of the block in the block tree without the block
+// body so external tools can cheaply reconstruct the fork topology.
+type BlockIndexEntry struct {
+	Hash         chainhash.Hash
+	Height       int64
+	KeyHeight    int64
+	PrevBlock    chainhash.Hash
+	PrevKeyBlock chainhash.Hash
+	Flags        BlockIndexFlags
+	Voters       uint16
+	Votes        []VoteVersionTuple
+}
+
+// blockIndexEntry returns a snapshot of the passed node.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func blockIndexEntry(node *blockNode) BlockIndexEntry {
+	entry := BlockIndexEntry{
+		Hash:         node.hash,
+		Height:       node.height,
+		KeyHeight:    node.keyHeight,
+		PrevBlock:    node.header.PrevBlock,
+		PrevKeyBlock: node.header.PrevKeyBlock,
+		Voters:       node.header.Voters,
+	}
+	if node.inMainChain {
+		entry.Flags |= BIFMainChain
+	}
+	if node.isKeyBlock {
+		entry.Flags |= BIFKeyBlock
+	}
+	node.stakeDataLock.Lock()
+	if node.stakeNode != nil {
+		entry.Flags |= BIFStakeNode
+	}
+	node.stakeDataLock.Unlock()
+	if len(node.votes) > 0 {
+		entry.Votes = make([]VoteVersionTuple, len(node.votes))
+		copy(entry.Votes, node.votes)
+	}
+	return entry
+}
+
+// blockNodesByHeight implements sort.Interface to sort block nodes by height
+// and then by hash so the order is stable across calls.
+type blockNodesByHeight []*blockNode
+
+func (s blockNodesByHeight) Len() int      { return len(s) }
+func (s blockNodesByHeight) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s blockNodesByHeight) Less(i, j int) bool {
+	if s[i].height != s[j].height {
+		return s[i].height < s[j].height
+	}
+	return chainhashLess(&s[i].hash, &s[j].hash)
+}
+
+// chainhashLess returns whether the first hash sorts before the second one when
+// compared byte by byte.
+func chainhashLess(a, b *chainhash.Hash) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// BlockIndex returns a read-only snapshot of the blocks in the memory block
+// index, including side chain blocks, with a height of at least startHeight.
+// The entries are ordered by height and then by hash.  At most maxEntries
+// entries are returned, except that all blocks at the height of the last
+// returned entry are always included, so callers can page through the whole
+// index by passing the height after the last returned entry.  A maxEntries of
+// zero or less returns all matching entries.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BlockIndex(startHeight int64, maxEntries int) []BlockIndexEntry {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	nodes := make([]*blockNode, 0, len(b.index))
+	for _, node := range b.index {
+		if node.height >= startHeight {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Sort(blockNodesByHeight(nodes))
+	if maxEntries > 0 && len(nodes) > maxEntries {
+		end := maxEntries
+		for end < len(nodes) && nodes[end].height == nodes[end-1].height {
+			end++
+		}
+		nodes = nodes[:end]
+	}
+
+	entries := make([]BlockIndexEntry, 0, len(nodes))
+	for _, node := range nodes {
+		entries = append(entries, blockIndexEntry(node))
+	}
+	return entries
+}
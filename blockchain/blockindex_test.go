@@ -0,0 +1,86 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// TestBlockIndex ensures the block index snapshot reports the expected entries
+// in the expected order and pages through blocks sharing a height together.
+func TestBlockIndex(t *testing.T) {
+	bc := newFakeChain(&chaincfg.SimNetParams)
+
+	// Create a main chain of three blocks with a side chain block forking
+	// off the first block.
+	addNode := func(id byte, height int64, parent *blockNode, mainChain, keyBlock bool) *blockNode {
+		node := &blockNode{
+			hash:        chainhash.Hash{id},
+			height:      height,
+			keyHeight:   height,
+			parent:      parent,
+			inMainChain: mainChain,
+			isKeyBlock:  keyBlock,
+		}
+		if parent != nil {
+			node.header.PrevBlock = parent.hash
+		}
+		bc.index[node.hash] = node
+		return node
+	}
+	genesis := addNode(0x10, 0, nil, true, true)
+	first := addNode(0x20, 1, genesis, true, true)
+	second := addNode(0x40, 2, first, true, false)
+	second.votes = []VoteVersionTuple{{Version: 1, Bits: 0x01}}
+	second.header.Voters = 1
+	side := addNode(0x30, 2, first, false, true)
+	addNode(0x50, 3, second, true, true)
+
+	entries := bc.BlockIndex(0, 0)
+	var hashes []chainhash.Hash
+	for _, entry := range entries {
+		hashes = append(hashes, entry.Hash)
+	}
+	wantHashes := []chainhash.Hash{{0x10}, {0x20}, {0x30}, {0x40}, {0x50}}
+	if !reflect.DeepEqual(hashes, wantHashes) {
+		t.Fatalf("unexpected order %v", hashes)
+	}
+
+	sideEntry := entries[2]
+	if sideEntry.Hash != side.hash || sideEntry.PrevBlock != first.hash ||
+		sideEntry.Flags != BIFKeyBlock {
+
+		t.Errorf("unexpected side chain entry %+v", sideEntry)
+	}
+	entry := entries[3]
+	if entry.Flags != BIFMainChain || entry.Voters != 1 ||
+		!reflect.DeepEqual(entry.Votes, second.votes) {
+
+		t.Errorf("unexpected main chain entry %+v", entry)
+	}
+
+	// The snapshot must not alias the vote summaries of the index.
+	entry.Votes[0].Bits = 0
+	if second.votes[0].Bits != 0x01 {
+		t.Error("snapshot aliases the votes of the block index")
+	}
+
+	// Paging must include all blocks at the height of the last entry.
+	entries = bc.BlockIndex(1, 2)
+	if len(entries) != 3 || entries[2].Hash != second.hash {
+		t.Errorf("unexpected page %+v", entries)
+	}
+	entries = bc.BlockIndex(3, 2)
+	if len(entries) != 1 || entries[0].Height != 3 {
+		t.Errorf("unexpected last page %+v", entries)
+	}
+	if entries := bc.BlockIndex(4, 2); len(entries) != 0 {
+		t.Errorf("unexpected entries past the tip %+v", entries)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/database"
+)
+
+const (
+	// MinPruneDepth is the minimum number of key blocks below the best
+	// chain tip whose block data is retained when pruning.  It is the
+	// number of key blocks whose nodes are kept in memory to perform all
+	// necessary validation, so block nodes which may have to be reloaded
+	// from the database are never pruned.
+	MinPruneDepth = minMemoryNodes
+
+	// pruneInterval is the number of blocks that must be connected to the
+	// main chain between two attempts to prune block data.
+	pruneInterval = 256
+)
+
+// pruneKeepHeight returns the height of the first main chain block whose data
+// must be retained when pruning.  It is the first block that is either less
+// than the prune depth below the best chain tip in key blocks or after the
+// latest checkpoint, since blocks after the latest checkpoint may still be
+// reorganized.  It returns -1 when no blocks may be pruned.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) pruneKeepHeight(dbTx database.Tx) (int64, error) {
+	checkpoint := b.latestCheckpoint()
+	if checkpoint == nil {
+		return -1, nil
+	}
+	keyHeight := b.bestNode.keyHeight - b.pruneDepth
+	if keyHeight <= 0 {
+		return -1, nil
+	}
+
+	height, err := b.firstHeightAtKeyHeight(dbTx, keyHeight)
+	if err != nil {
+		return -1, err
+	}
+	if height > checkpoint.Height {
+		height = checkpoint.Height
+	}
+	return height, nil
+}
+
+// firstHeightAtKeyHeight returns the height of the first main chain block at
+// the passed key height, or the height of the best chain tip when the key
+// height is after it.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) firstHeightAtKeyHeight(dbTx database.Tx, keyHeight int64) (int64, error) {
+	// Blocks are ordered by key height, so binary search for the first
+	// block at the key height.  The headers of pruned blocks remain in the
+	// database, so they may be loaded as well.
+	low, high := int64(0), b.bestNode.height
+	for low < high {
+		mid := low + (high-low)/2
+		header, err := dbFetchHeaderByHeight(dbTx, mid)
+		if err != nil {
+			return -1, err
+		}
+		if int64(header.KeyHeight) < keyHeight {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	return low, nil
+}
+
+// maybePruneBlocks deletes the data of old blocks from the database when
+// pruning is enabled and pruneInterval blocks were connected to the main chain
+// since the last attempt.  The block data is deleted until the configured
+// target size is reached, while the headers, the utxo set and the stake undo
+// data of pruned blocks are retained.  Failures are only logged since they do
+// not affect the validity of the chain.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) maybePruneBlocks() {
+	if b.pruneTarget == 0 || b.bestNode.height < b.lastPruneHeight+pruneInterval {
+		return
+	}
+	b.lastPruneHeight = b.bestNode.height
+
+	var keepHeight int64
+	var keepHash *chainhash.Hash
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		keepHeight, err = b.pruneKeepHeight(dbTx)
+		if err != nil || keepHeight <= 0 {
+			return err
+		}
+		keepHash, err = dbFetchHashByHeight(dbTx, keepHeight)
+		return err
+	})
+	if err != nil {
+		log.Warnf("Unable to determine the blocks to prune: %v", err)
+		return
+	}
+	if keepHash == nil {
+		return
+	}
+
+	freed, err := b.db.PruneBlocks(keepHash, b.pruneTarget)
+	if err != nil {
+		log.Warnf("Unable to prune block data: %v", err)
+		return
+	}
+	if freed > 0 {
+		log.Infof("Pruned %d MiB of data of blocks before height %d",
+			freed/(1024*1024), keepHeight)
+	}
+}
+
+// maybeArchiveBlocks moves the data of blocks which are more than the cold
+// storage depth in key blocks below the best chain tip to the cold storage of
+// the database when moving is enabled and pruneInterval blocks were connected
+// to the main chain since the last attempt.  Moved blocks remain available, so
+// unlike pruning this is not limited to blocks before the latest checkpoint.
+// Failures are only logged since the data remains available either way.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) maybeArchiveBlocks() {
+	if b.coldStorageDepth == 0 ||
+		b.bestNode.height < b.lastArchiveHeight+pruneInterval {
+
+		return
+	}
+	b.lastArchiveHeight = b.bestNode.height
+
+	keyHeight := b.bestNode.keyHeight - b.coldStorageDepth
+	if keyHeight <= 0 {
+		return
+	}
+	var keepHeight int64
+	var keepHash *chainhash.Hash
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		keepHeight, err = b.firstHeightAtKeyHeight(dbTx, keyHeight)
+		if err != nil || keepHeight <= 0 {
+			return err
+		}
+		keepHash, err = dbFetchHashByHeight(dbTx, keepHeight)
+		return err
+	})
+	if err != nil {
+		log.Warnf("Unable to determine the blocks to move to cold "+
+			"storage: %v", err)
+		return
+	}
+	if keepHash == nil {
+		return
+	}
+
+	moved, err := b.db.ArchiveBlocks(keepHash)
+	if err != nil {
+		log.Warnf("Unable to move block data to cold storage: %v", err)
+		return
+	}
+	if moved > 0 {
+		log.Infof("Moved %d MiB of data of blocks before height %d to "+
+			"cold storage", moved/(1024*1024), keepHeight)
+	}
+}
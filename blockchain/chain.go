@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
@@ -112,6 +113,10 @@ type blockNode struct {
 
 	// isKeyBlock indicates whether the block is a key block.
 	isKeyBlock bool
+
+	// invalid indicates the block violated the consensus rules when it was
+	// validated for connecting it to the main chain during a reorganize.
+	invalid bool
 }
 
 // newBlockNode returns a new block node for the given block header.  It is
@@ -124,14 +129,14 @@ func newBlockNode(block *hcashutil.Block, ticketsSpent []chainhash.Hash, tickets
 	// collected.
 	blockHeader := block.MsgBlock().Header
 	blockHash := blockHeader.BlockHash()
-	blockHashBigInt := HashToBig(&blockHash)
+	blockHashBigInt := standalone.HashToBig(&blockHash)
 	workSum := big.NewInt(0)
 	isKeyBlock := false
 	totalFees := int64(0)
 
-	if blockHashBigInt.Cmp(CompactToBig(blockHeader.Bits)) <= 0 {
+	if blockHashBigInt.Cmp(standalone.CompactToBig(blockHeader.Bits)) <= 0 {
 		isKeyBlock = true
-		workSum = CalcWork(blockHeader.Bits)
+		workSum = standalone.CalcWork(blockHeader.Bits)
 		isKeyBlock = true
 	}
 
@@ -253,14 +258,47 @@ type BlockChain struct {
 	dbInfo              *databaseInfo
 	chainParams         *chaincfg.Params
 	timeSource          MedianTimeSource
-	notifications       NotificationCallback
 	sigCache            *txscript.SigCache
 	indexManager        IndexManager
 
+	// sigVerifyConcurrency is the maximum number of goroutines used to
+	// validate the input scripts of a block.
+	sigVerifyConcurrency int
+
+	// sigHashOptimization and checkForDuplicateHashes are the experimental
+	// and safety options of block validation.  See the fields of the same
+	// name in Config.
+	sigHashOptimization     bool
+	checkForDuplicateHashes bool
+
+	// pruneTarget is the size in bytes the block data in the database is
+	// pruned to, while pruneDepth is the number of key blocks below the
+	// best chain tip whose data is always retained.  Pruning is disabled
+	// when the target is zero.
+	pruneTarget uint64
+	pruneDepth  int64
+
+	// coldStorageDepth is the number of key blocks below the best chain
+	// tip whose data is kept out of the cold storage of the database.
+	// Moving block data to cold storage is disabled when it is zero.
+	coldStorageDepth int64
+
+	// maxReorgDepth is the maximum number of blocks a reorganization may
+	// disconnect from the main chain.  The depth is not limited when it is
+	// zero.
+	maxReorgDepth int64
+
 	// subsidyCache is the cache that provides quick lookup of subsidy
 	// values.
 	subsidyCache *SubsidyCache
 
+	// subscribers houses the callbacks registered via Subscribe along with
+	// the notification types they are interested in.  It is protected by
+	// its own lock since notifications are sent without holding the chain
+	// lock.
+	subscribersLock sync.RWMutex
+	subscribers     []*notificationSubscriber
+
 	// chainLock protects concurrent access to the vast majority of the
 	// fields in this struct below this point.
 	chainLock sync.RWMutex
@@ -276,6 +314,19 @@ type BlockChain struct {
 	index    map[chainhash.Hash]*blockNode
 	depNodes map[chainhash.Hash][]*blockNode
 
+	// lastPruneHeight is the height of the best chain tip the last time
+	// block data was pruned.  It is protected by the chain lock.
+	lastPruneHeight int64
+
+	// lastArchiveHeight is the height of the best chain tip the last time
+	// block data was moved to cold storage.  It is protected by the chain
+	// lock.
+	lastArchiveHeight int64
+
+	// stakeNodeStats tracks how requested stake nodes were obtained.  It
+	// is protected by the chain lock.
+	stakeNodeStats StakeNodeCacheStats
+
 	// These fields are related to handling of orphan blocks.  They are
 	// protected by a combination of the chain lock and the orphan lock.
 	orphanLock     sync.RWMutex
@@ -320,6 +371,14 @@ type BlockChain struct {
 	// blocks in each of the actively defined deployments.
 	deploymentCaches map[uint32][]thresholdStateCache
 
+	// maxBlockSizeCache caches the maximum block size permitted by the max
+	// block size agenda for the blocks following each key block, keyed by
+	// the hash of the key block.  Since the threshold state only changes
+	// at key blocks, this avoids walking back to the most recent key block
+	// and looking up its threshold state for every block.  It is protected
+	// by the chain lock.
+	maxBlockSizeCache map[chainhash.Hash]int64
+
 	// pruner is the automatic pruner for block nodes and stake nodes,
 	// so that the memory may be restored by the garbage collector if
 	// it is unlikely to be referenced in the future.
@@ -335,6 +394,12 @@ type BlockChain struct {
 	calcPriorStakeVersionCache    map[[chainhash.HashSize]byte]uint32
 	calcVoterVersionIntervalCache map[[chainhash.HashSize]byte]uint32
 	calcStakeVersionCache         map[[chainhash.HashSize]byte]uint32
+
+	// unknownVoteVersionWarning describes the upgrade required warning
+	// raised when a stake majority of recent votes carry a vote version
+	// newer than the latest one known to this software.  It is empty when
+	// no upgrade is required and is protected by the chain lock.
+	unknownVoteVersionWarning string
 }
 
 const (
@@ -444,7 +509,7 @@ func (b *BlockChain) GetVoteInfo(hash *chainhash.Hash, version uint32) (*VoteInf
 	}
 	for _, deployment := range deployments {
 		vi.Agendas = append(vi.Agendas, deployment)
-		status, err := b.ThresholdState(hash, version, deployment.Vote.Id)
+		status, err := b.NextThresholdState(hash, version, deployment.Vote.Id)
 		if err != nil {
 			return nil, err
 		}
@@ -1572,7 +1637,7 @@ func (b *BlockChain) getForkKeyNodes(node *blockNode) (*list.List, *list.List, e
 	// later.
 	ancestor := node
 	for ; ancestor.parent != nil; ancestor = ancestor.parent {
-		if HashToBig(&(ancestor.hash)).Cmp(CompactToBig(ancestor.header.Bits)) > 0 {
+		if standalone.HashToBig(&(ancestor.hash)).Cmp(standalone.CompactToBig(ancestor.header.Bits)) > 0 {
 			continue
 		}
 
@@ -1595,7 +1660,7 @@ func (b *BlockChain) getForkKeyNodes(node *blockNode) (*list.List, *list.List, e
 		if n.hash == ancestor.hash {
 			break
 		}
-		if HashToBig(&(n.hash)).Cmp(CompactToBig(n.header.Bits)) <= 0 {
+		if standalone.HashToBig(&(n.hash)).Cmp(standalone.CompactToBig(n.header.Bits)) <= 0 {
 			detachNodes.PushBack(n)
 		}
 
@@ -1785,6 +1850,10 @@ func (b *BlockChain) connectBlock(node *blockNode, block *hcashutil.Block, view
 
 
 
+	// Warn the operator when a stake majority of recent votes are cast by
+	// software newer than this one.
+	b.checkUnknownVoteVersions(node)
+
 	// Assemble the current block and the parent into a slice.
 	blockAndParent := []*hcashutil.Block{block, parent}
 
@@ -2026,7 +2095,7 @@ func countSpentOutputs(block *hcashutil.Block, parent *hcashutil.Block) int {
 	var numSpent int
 
 	startTx := 1
-	if HashToBig(parent.Hash()).Cmp(CompactToBig(parent.MsgBlock().Header.Bits)) <= 0 {
+	if standalone.HashToBig(parent.Hash()).Cmp(standalone.CompactToBig(parent.MsgBlock().Header.Bits)) <= 0 {
 		startTx = 2
 	}
 
@@ -2038,7 +2107,7 @@ func countSpentOutputs(block *hcashutil.Block, parent *hcashutil.Block) int {
 		}
 	}
 
-	if HashToBig(block.Hash()).Cmp(CompactToBig(block.MsgBlock().Header.Bits)) > 0 {
+	if standalone.HashToBig(block.Hash()).Cmp(standalone.CompactToBig(block.MsgBlock().Header.Bits)) > 0 {
 		return numSpent
 	}
 
@@ -2074,6 +2143,15 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List,
 	formerBestHash := b.bestNode.hash
 	formerBestHeight := b.bestNode.height
 
+	// Reject reorganizations deeper than the configured maximum.
+	if b.maxReorgDepth > 0 && int64(detachNodes.Len()) > b.maxReorgDepth {
+		str := fmt.Sprintf("reorganization would disconnect %d "+
+			"blocks from the main chain which exceeds the maximum "+
+			"reorganization depth of %d", detachNodes.Len(),
+			b.maxReorgDepth)
+		return ruleError(ErrReorgTooDeep, str)
+	}
+
 	// Ensure all of the needed side chain blocks are in the cache.
 	for e := attachNodes.Front(); e != nil; e = e.Next() {
 		n := e.Value.(*blockNode)
@@ -2175,6 +2253,9 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List,
 		// not needed.
 		err := b.checkConnectBlock(n, block, view, nil, false, keyHeightCache)
 		if err != nil {
+			if _, ok := err.(RuleError); ok && flags&BFDryRun == 0 {
+				n.invalid = true
+			}
 			return err
 		}
 		topBlock = n
@@ -2191,12 +2272,15 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List,
 	}
 
 	// Send a notification that a blockchain reorganization is in progress.
-	reorgData := &ReorganizationNtfnsData{
-		formerBestHash,
-		formerBestHeight,
-		newHash,
-		newHeight,
+	attachBlocks := make([]*hcashutil.Block, 0, attachNodes.Len())
+	b.blockCacheLock.RLock()
+	for e := attachNodes.Front(); e != nil; e = e.Next() {
+		n := e.Value.(*blockNode)
+		attachBlocks = append(attachBlocks, b.blockCache[n.hash])
 	}
+	b.blockCacheLock.RUnlock()
+	reorgData := newReorganizationNtfnsData(formerBestHash,
+		formerBestHeight, newHash, newHeight, detachBlocks, attachBlocks)
 	b.chainLock.Unlock()
 	b.sendNotification(NTReorganization, reorgData)
 	b.chainLock.Lock()
@@ -2660,33 +2744,68 @@ func (b *BlockChain) BestRealKeyHeight() int64 {
 	return keyHeight
 }
 
-// MaximumBlockSize returns the maximum permitted block size for the block
-// AFTER the given node.
+// BestChainWork returns the total amount of proof of work contained in the
+// current best chain.
 //
-// This function MUST be called with the chain state lock held (for reads).
+// This function is safe for concurrent access.
+func (b *BlockChain) BestChainWork() *big.Int {
+	b.chainLock.RLock()
+	workSum := new(big.Int).Set(b.bestNode.workSum)
+	b.chainLock.RUnlock()
+	return workSum
+}
+
+// maxBlockSize returns the maximum permitted block size for the block AFTER
+// the given node.
+//
+// This function MUST be called with the chain state lock held (for writes).
 func (b *BlockChain) maxBlockSize(prevNode *blockNode) (int64, error) {
-	// Hard fork voting on block size is only enabled on testnet v1 and
-	// simnet.
-	if b.chainParams.Net != wire.SimNet {
-		return int64(b.chainParams.MaximumBlockSizes[0]), nil
+	// Hard fork voting on block size is only possible on networks which
+	// define both a larger block size and the max block size agenda.
+	maxSize := int64(b.chainParams.MaximumBlockSizes[0])
+	if len(b.chainParams.MaximumBlockSizes) < 2 {
+		return maxSize, nil
+	}
+	version, ok := b.deploymentVersion(chaincfg.VoteIDMaxBlockSize)
+	if !ok {
+		return maxSize, nil
+	}
+
+	// The threshold state is the same for all blocks following a key
+	// block, so look it up for the most recent key block.  The agenda can
+	// not be active before the first key block after the genesis block.
+	keyNode := prevNode
+	if !keyNode.isKeyBlock {
+		var err error
+		keyNode, err = b.getPrevKeyNodeFromNode(keyNode)
+		if err != nil {
+			return maxSize, err
+		}
+	}
+	if keyNode == nil {
+		return maxSize, nil
+	}
+	if cachedSize, ok := b.maxBlockSizeCache[keyNode.hash]; ok {
+		return cachedSize, nil
 	}
 
-	// Return the larger block size if the version 4 stake vote for the max
-	// block size increase agenda is active.
+	// Use the larger block size if the stake vote for the max block size
+	// increase agenda is active.
 	//
 	// NOTE: The choice field of the return threshold state is not examined
 	// here because there is only one possible choice that can be active
 	// for the agenda, which is yes, so there is no need to check it.
-	maxSize := int64(b.chainParams.MaximumBlockSizes[0])
-	state, err := b.deploymentState(prevNode, 4, chaincfg.VoteIDMaxBlockSize)
+	state, err := b.deploymentState(keyNode, version,
+		chaincfg.VoteIDMaxBlockSize)
 	if err != nil {
 		return maxSize, err
 	}
 	if state.State == ThresholdActive {
-		return int64(b.chainParams.MaximumBlockSizes[1]), nil
+		maxSize = int64(b.chainParams.MaximumBlockSizes[1])
 	}
 
 	// The max block size is not changed in any other cases.
+	b.maxBlockSizeCache[keyNode.hash] = maxSize
 	return maxSize, nil
 }
 
@@ -2706,8 +2825,11 @@ func (b *BlockChain) MaxBlockSize() (int64, error) {
 // purpose of supporting optional indexes.
 type IndexManager interface {
 	// Init is invoked during chain initialize in order to allow the index
-	// manager to initialize itself and any indexes it is managing.
-	Init(*BlockChain) error
+	// manager to initialize itself and any indexes it is managing.  The
+	// channel parameter specifies a channel the caller can close to signal
+	// that the process should be interrupted.  It can be nil if that
+	// behavior is not desired.
+	Init(*BlockChain, <-chan struct{}) error
 
 	// ConnectBlock is invoked when a new block has been connected to the
 	// main chain.
@@ -2743,7 +2865,9 @@ type Config struct {
 	// Notifications defines a callback to which notifications will be sent
 	// when various events take place.  See the documentation for
 	// Notification and NotificationType for details on the types and
-	// contents of notifications.
+	// contents of notifications.  The callback receives all types of
+	// notifications.  Additional callbacks which are only interested in
+	// some types may be registered via BlockChain.Subscribe.
 	//
 	// This field can be nil if the caller is not interested in receiving
 	// notifications.
@@ -2758,12 +2882,78 @@ type Config struct {
 	// signature cache.
 	SigCache *txscript.SigCache
 
+	// SigVerifyConcurrency defines the maximum number of goroutines used to
+	// validate the input scripts of a block concurrently.
+	//
+	// This field can be zero to use the default returned by
+	// DefaultSigVerifyConcurrency.
+	SigVerifyConcurrency int
+
+	// SigHashOptimization defines whether the cached hash of transactions
+	// is used as the prefix hash when verifying the SIGHASH_ALL signatures
+	// of blocks.  Although there should be no consequences to daemons that
+	// are simply running a node, the cached hash is stale when a
+	// transaction is mutated after it was hashed, which could cause
+	// database corruption.  It should therefore be disabled when mining or
+	// running a wallet with the node.
+	//
+	// This feature is considered EXPERIMENTAL, enable at your own risk!
+	SigHashOptimization bool
+
+	// CheckForDuplicateHashes defines whether blocks are checked for
+	// transactions which overwrite unspent transactions with the same
+	// hash.  Because of the rule inserting the height into the coinbase,
+	// there should never be such a duplicate, however, because there is a
+	// 2^128 chance of a collision, the paranoid user may wish to enable
+	// it.
+	CheckForDuplicateHashes bool
+
+	// PruneTarget defines the size in bytes the block data stored in the
+	// database is pruned to once blocks are before the latest checkpoint
+	// and at least PruneDepth key blocks below the best chain tip.  The
+	// headers, the utxo set, and the stake undo data of pruned blocks are
+	// retained.
+	//
+	// This field can be zero to disable pruning.
+	PruneTarget uint64
+
+	// PruneDepth defines the number of key blocks below the best chain tip
+	// whose data is never pruned.
+	//
+	// Values below MinPruneDepth, including zero, are raised to
+	// MinPruneDepth.
+	PruneDepth int64
+
+	// ColdStorageDepth defines the number of key blocks below the best
+	// chain tip whose data is kept in the primary storage of the database.
+	// The data of older blocks is moved to the cold storage the database
+	// was opened with, from which it is still read transparently.
+	//
+	// This field can be zero to keep all block data in primary storage.
+	ColdStorageDepth int64
+
+	// MaxReorgDepth defines the maximum number of blocks a reorganization
+	// may disconnect from the main chain.  Blocks which would cause a
+	// deeper reorganization are rejected with ErrReorgTooDeep, which
+	// protects against long range attacks on nodes that were already in
+	// sync with the network.
+	//
+	// This field can be zero to not limit the depth of reorganizations.
+	MaxReorgDepth int64
+
 	// IndexManager defines an index manager to use when initializing the
 	// chain and connecting and disconnecting blocks.
 	//
 	// This field can be nil if the caller does not wish to make use of an
 	// index manager.
 	IndexManager IndexManager
+
+	// Interrupt specifies a channel the caller can close to signal that
+	// long running operations, such as catching up the indexes of the index
+	// manager, should be interrupted.
+	//
+	// This field can be nil if the caller does not desire the behavior.
+	Interrupt <-chan struct{}
 }
 
 // New returns a BlockChain instance using the provided configuration details.
@@ -2787,13 +2977,24 @@ func New(config *Config) (*BlockChain, error) {
 		}
 	}
 
+	pruneDepth := config.PruneDepth
+	if pruneDepth < MinPruneDepth {
+		pruneDepth = MinPruneDepth
+	}
+
 	b := BlockChain{
 		checkpointsByHeight:           checkpointsByHeight,
 		db:                            config.DB,
 		chainParams:                   params,
 		timeSource:                    config.TimeSource,
-		notifications:                 config.Notifications,
 		sigCache:                      config.SigCache,
+		sigVerifyConcurrency:          config.SigVerifyConcurrency,
+		sigHashOptimization:           config.SigHashOptimization,
+		checkForDuplicateHashes:       config.CheckForDuplicateHashes,
+		pruneTarget:                   config.PruneTarget,
+		pruneDepth:                    pruneDepth,
+		coldStorageDepth:              config.ColdStorageDepth,
+		maxReorgDepth:                 config.MaxReorgDepth,
 		indexManager:                  config.IndexManager,
 		bestNode:                      nil,
 		index:                         make(map[chainhash.Hash]*blockNode),
@@ -2804,12 +3005,16 @@ func New(config *Config) (*BlockChain, error) {
 		mainchainBlockCache:           make(map[chainhash.Hash]*hcashutil.Block),
 		mainchainBlockCacheSize:       mainchainBlockCacheSize,
 		deploymentCaches:              newThresholdCaches(params),
+		maxBlockSizeCache:             make(map[chainhash.Hash]int64),
 		isVoterMajorityVersionCache:   make(map[[stakeMajorityCacheKeySize]byte]bool),
 		isStakeMajorityVersionCache:   make(map[[stakeMajorityCacheKeySize]byte]bool),
 		calcPriorStakeVersionCache:    make(map[[chainhash.HashSize]byte]uint32),
 		calcVoterVersionIntervalCache: make(map[[chainhash.HashSize]byte]uint32),
 		calcStakeVersionCache:         make(map[[chainhash.HashSize]byte]uint32),
 	}
+	if config.Notifications != nil {
+		b.Subscribe(config.Notifications)
+	}
 
 	// Initialize the chain state from the passed database.  When the db
 	// does not yet contain any chain state, both it and the chain state
@@ -2821,7 +3026,8 @@ func New(config *Config) (*BlockChain, error) {
 	// Initialize and catch up all of the currently active optional indexes
 	// as needed.
 	if config.IndexManager != nil {
-		if err := config.IndexManager.Init(&b); err != nil {
+		err := config.IndexManager.Init(&b, config.Interrupt)
+		if err != nil {
 			return nil, err
 		}
 	}
@@ -2831,6 +3037,11 @@ func New(config *Config) (*BlockChain, error) {
 		return nil, err
 	}
 
+	// Create the utxo set statistics when the database predates them.
+	if err := b.initUtxoSetStats(config.Interrupt); err != nil {
+		return nil, err
+	}
+
 	b.subsidyCache = NewSubsidyCache(b.bestNode.keyHeight, b.chainParams)
 	b.pruner = newChainPruner(&b)
 
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/txscript"
@@ -1207,7 +1208,7 @@ func solveBlock(header *wire.BlockHeader) bool {
 
 	// solver accepts a block header and a nonce range to test. It is
 	// intended to be run as a goroutine.
-	targetDifficulty := blockchain.CompactToBig(header.Bits)
+	targetDifficulty := standalone.CompactToBig(header.Bits)
 	quit := make(chan bool)
 	results := make(chan sbResult)
 	solver := func(hdr wire.BlockHeader, startNonce, stopNonce uint32) {
@@ -1221,7 +1222,7 @@ func solveBlock(header *wire.BlockHeader) bool {
 			default:
 				hdr.Nonce = i
 				hash := hdr.BlockHash()
-				if blockchain.HashToBig(&hash).Cmp(
+				if standalone.HashToBig(&hash).Cmp(
 					targetDifficulty) <= 0 {
 
 					results <- sbResult{true, i}
@@ -1376,6 +1377,43 @@ func ReplaceVotes(voteBits uint16, newVersion uint32) func(*wire.MsgBlock) {
 	}
 }
 
+// WithBadStakeRoot returns a function that itself takes a block and modifies it
+// by replacing the stake root of the header with one that does not commit to
+// the stake transactions of the block.  Since the stake root is manually
+// changed, it will not be recalculated by 'NextBlock' while all other fields,
+// including the merkle root and size, still are.
+func WithBadStakeRoot() func(*wire.MsgBlock) {
+	return func(b *wire.MsgBlock) {
+		stakeRoot := calcMerkleRoot(b.STransactions)
+		stakeRoot[0] ^= 0x01
+		b.Header.StakeRoot = stakeRoot
+	}
+}
+
+// WithExtraVote returns a function that itself takes a block and modifies it by
+// adding a vote for the next winning ticket to the stake tree, so the block
+// contains one more vote than allowed per block.  The number of voters in the
+// header and the coinbase subsidy are updated to account for the additional
+// vote.
+//
+// NOTE: This must only be used as a munger to the 'NextBlock' function or it
+// will lead to an invalid live ticket pool.  To help safeguard against improper
+// usage, it will panic if called with a block that does not connect to the
+// current tip block.
+func (g *Generator) WithExtraVote() func(*wire.MsgBlock) {
+	return g.ReplaceWithNVotes(g.params.TicketsPerBlock + 1)
+}
+
+// WithWrongKeyHeight returns a function that itself takes a block and modifies
+// it by replacing the key height of the header with one that is not one more
+// than the key height of the key block its votes are for.  The votes are left
+// untouched so they still vote on the previous key block.
+func WithWrongKeyHeight() func(*wire.MsgBlock) {
+	return func(b *wire.MsgBlock) {
+		b.Header.KeyHeight++
+	}
+}
+
 // CreateSpendTx creates a transaction that spends from the provided spendable
 // output and includes an additional unique OP_RETURN output to ensure the
 // transaction ends up with a unique hash.  The public key script is a simple
@@ -16,6 +16,7 @@ import (
 
 	"github.com/HcashOrg/hcashd/blockchain/internal/dbnamespace"
 	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
@@ -546,7 +547,7 @@ func dbFetchSpendJournalEntry(dbTx database.Tx, block *hcashutil.Block,
 	regularTxTreeValid := hcashutil.IsFlagSet16(block.MsgBlock().Header.VoteBits,
 		hcashutil.BlockValid)
 	startTx := 1
-	if HashToBig(parent.Hash()).Cmp(CompactToBig(parent.MsgBlock().Header.Bits)) <= 0 {
+	if standalone.HashToBig(parent.Hash()).Cmp(standalone.CompactToBig(parent.MsgBlock().Header.Bits)) <= 0 {
 		startTx = 2
 	}
 
@@ -924,6 +925,13 @@ func dbFetchUtxoEntry(dbTx database.Tx, hash *chainhash.Hash) (*UtxoEntry, error
 		return nil, nil
 	}
 
+	return dbDeserializeUtxoEntry(hash, serializedUtxo)
+}
+
+// dbDeserializeUtxoEntry decodes the passed utxo entry which was loaded from the
+// utxo set for the transaction with the passed hash.  Deserialization errors
+// are returned as database corruption errors.
+func dbDeserializeUtxoEntry(hash *chainhash.Hash, serializedUtxo []byte) (*UtxoEntry, error) {
 	// A non-nil zero-length entry means there is an entry in the database
 	// for a fully spent transaction which should never be the case.
 	if len(serializedUtxo) == 0 {
@@ -953,8 +961,14 @@ func dbFetchUtxoEntry(dbTx database.Tx, hash *chainhash.Hash) (*UtxoEntry, error
 // dbPutUtxoView uses an existing database transaction to update the utxo set
 // in the database based on the provided utxo view contents and state.  In
 // particular, only the entries that have been marked as modified are written
-// to the database.
+// to the database.  The utxo set statistics are updated accordingly when they
+// exist.
 func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
+	stats, err := dbFetchUtxoSetStats(dbTx)
+	if err != nil {
+		return err
+	}
+
 	utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
 	for txHashIter, entry := range view.entries {
 		// No need to update the database if the entry was not modified.
@@ -974,6 +988,22 @@ func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 		// data to change out from under the put/delete funcs below.
 		txHash := txHashIter
 
+		// Update the statistics with the difference between the entry
+		// in the database and the one replacing it.
+		if stats != nil {
+			var oldEntry *UtxoEntry
+			oldSerialized := utxoBucket.Get(txHash[:])
+			if oldSerialized != nil {
+				oldEntry, err = dbDeserializeUtxoEntry(&txHash,
+					oldSerialized)
+				if err != nil {
+					return err
+				}
+			}
+			stats.updateEntry(&txHash, oldEntry, oldSerialized, entry,
+				serialized)
+		}
+
 		// Remove the utxo entry if it is now fully spent.
 		if serialized == nil {
 			if err := utxoBucket.Delete(txHash[:]); err != nil {
@@ -991,7 +1021,10 @@ func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 		}
 	}
 
-	return nil
+	if stats == nil {
+		return nil
+	}
+	return dbPutUtxoSetStats(dbTx, stats)
 }
 
 // -----------------------------------------------------------------------------
@@ -1371,6 +1404,12 @@ func (b *BlockChain) createChainState() error {
 			return err
 		}
 
+		// Start maintaining the statistics of the empty utxo set.
+		err = dbPutUtxoSetStats(dbTx, newUtxoSetStats())
+		if err != nil {
+			return err
+		}
+
 		// Add the genesis block hash to height and height to hash
 		// mappings to the index.
 		err = dbPutBlockIndex(dbTx, &b.bestNode.hash, b.bestNode.height)
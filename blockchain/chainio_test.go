@@ -13,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
 	"github.com/HcashOrg/hcashd/wire"
@@ -1144,7 +1145,7 @@ func TestBestChainStateSerialization(t *testing.T) {
 				totalTxns:    1,
 				totalSubsidy: 0,
 				workSum: func() *big.Int {
-					workSum.Add(workSum, CalcWork(486604799))
+					workSum.Add(workSum, standalone.CalcWork(486604799))
 					return new(big.Int).Set(workSum)
 				}(), // 0x0100010001
 			},
@@ -1158,7 +1159,7 @@ func TestBestChainStateSerialization(t *testing.T) {
 				totalTxns:    2,
 				totalSubsidy: 123456789,
 				workSum: func() *big.Int {
-					workSum.Add(workSum, CalcWork(486604799))
+					workSum.Add(workSum, standalone.CalcWork(486604799))
 					return new(big.Int).Set(workSum)
 				}(), // 0x0200020002,
 			},
@@ -0,0 +1,154 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"sort"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/database"
+)
+
+// ChainTipStatus describes the state of the branch of the block tree that ends
+// in a chain tip.
+type ChainTipStatus int
+
+// These constants define the possible chain tip statuses.
+const (
+	// ChainTipActive indicates the tip is the tip of the main chain.
+	ChainTipActive ChainTipStatus = iota
+
+	// ChainTipValidFork indicates the tip is the end of a side chain whose
+	// block data is available and none of whose blocks is known to violate
+	// the consensus rules.
+	ChainTipValidFork
+
+	// ChainTipInvalid indicates a block of the side chain that ends in the
+	// tip violated the consensus rules when it was attempted to connect it
+	// to the main chain.
+	ChainTipInvalid
+
+	// ChainTipHeadersOnly indicates the data of a block of the side chain
+	// that ends in the tip is not available, such as due to pruning, so
+	// only the headers of the side chain are known.
+	ChainTipHeadersOnly
+)
+
+// chainTipStatusStrings is a map of chain tip statuses back to their constant
+// names for pretty printing.
+var chainTipStatusStrings = map[ChainTipStatus]string{
+	ChainTipActive:      "active",
+	ChainTipValidFork:   "valid-fork",
+	ChainTipInvalid:     "invalid",
+	ChainTipHeadersOnly: "headers-only",
+}
+
+// String returns the ChainTipStatus as a human-readable name.
+func (s ChainTipStatus) String() string {
+	if str, ok := chainTipStatusStrings[s]; ok {
+		return str
+	}
+	return "unknown"
+}
+
+// ChainTip describes a block in the memory block index that no other block in
+// the index builds on, along with the main chain tip.
+type ChainTip struct {
+	Hash   chainhash.Hash
+	Height int64
+
+	// BranchLen is the number of blocks between the tip and the main
+	// chain.  It is zero for the main chain tip.
+	BranchLen int64
+
+	Status ChainTipStatus
+}
+
+// chainTipsByHeight implements sort.Interface to sort chain tips by descending
+// height and then by hash.
+type chainTipsByHeight []ChainTip
+
+func (s chainTipsByHeight) Len() int      { return len(s) }
+func (s chainTipsByHeight) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s chainTipsByHeight) Less(i, j int) bool {
+	if s[i].Height != s[j].Height {
+		return s[i].Height > s[j].Height
+	}
+	return chainhashLess(&s[i].Hash, &s[j].Hash)
+}
+
+// blockDataAvailable returns whether or not the data of the passed block is
+// available, either from the side chain block cache or from the database.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) blockDataAvailable(dbTx database.Tx, hash *chainhash.Hash) bool {
+	b.blockCacheLock.RLock()
+	_, ok := b.blockCache[*hash]
+	b.blockCacheLock.RUnlock()
+	if ok {
+		return true
+	}
+
+	// Fetching a single byte detects pruned and missing blocks without
+	// loading the whole block.
+	region := database.BlockRegion{Hash: hash, Offset: 0, Len: 1}
+	_, err := dbTx.FetchBlockRegion(&region)
+	return err == nil
+}
+
+// sideChainTip classifies the side chain which ends in the passed tip by
+// walking back to the main chain.  The nodes of side chains are always linked
+// to their parents, so no nodes have to be loaded from the database.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) sideChainTip(dbTx database.Tx, tip *blockNode) ChainTip {
+	chainTip := ChainTip{
+		Hash:   tip.hash,
+		Height: tip.height,
+		Status: ChainTipValidFork,
+	}
+	for node := tip; node != nil && !node.inMainChain; node = node.parent {
+		chainTip.BranchLen++
+		switch {
+		case node.invalid:
+			chainTip.Status = ChainTipInvalid
+		case chainTip.Status == ChainTipValidFork &&
+			!b.blockDataAvailable(dbTx, &node.hash):
+			chainTip.Status = ChainTipHeadersOnly
+		}
+	}
+
+	return chainTip
+}
+
+// ChainTips returns the main chain tip and the tips of all side chains in the
+// memory block index, ordered by descending height.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ChainTips() ([]ChainTip, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	tips := []ChainTip{{
+		Hash:   b.bestNode.hash,
+		Height: b.bestNode.height,
+		Status: ChainTipActive,
+	}}
+	err := b.db.View(func(dbTx database.Tx) error {
+		for _, node := range b.index {
+			if node.inMainChain || len(node.children) != 0 {
+				continue
+			}
+			tips = append(tips, b.sideChainTip(dbTx, node))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(chainTipsByHeight(tips))
+	return tips, nil
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// TestSideChainTip ensures side chains are classified as expected and their
+// branch lengths are measured up to the main chain.
+func TestSideChainTip(t *testing.T) {
+	bc := newFakeChain(&chaincfg.SimNetParams)
+	bc.blockCache = make(map[chainhash.Hash]*hcashutil.Block)
+
+	// Create a main chain block with two side chains of two blocks forking
+	// off it.  The side chain blocks are in the side chain block cache, so
+	// their data is available without a database.
+	addNode := func(id byte, height int64, parent *blockNode) *blockNode {
+		node := &blockNode{
+			hash:        chainhash.Hash{id},
+			height:      height,
+			parent:      parent,
+			inMainChain: parent == nil,
+		}
+		if parent != nil {
+			bc.blockCache[node.hash] = hcashutil.NewBlock(&wire.MsgBlock{})
+		}
+		return node
+	}
+	mainNode := addNode(0x10, 1, nil)
+	valid := addNode(0x21, 3, addNode(0x20, 2, mainNode))
+	invalidParent := addNode(0x30, 2, mainNode)
+	invalidParent.invalid = true
+	invalid := addNode(0x31, 3, invalidParent)
+
+	tests := []struct {
+		name      string
+		tip       *blockNode
+		branchLen int64
+		status    ChainTipStatus
+	}{
+		{"valid fork", valid, 2, ChainTipValidFork},
+		{"invalid fork", invalid, 2, ChainTipInvalid},
+	}
+	for _, test := range tests {
+		tip := bc.sideChainTip(nil, test.tip)
+		if tip.Hash != test.tip.hash || tip.Height != test.tip.height {
+			t.Errorf("%s: unexpected tip %v (height %d)", test.name,
+				tip.Hash, tip.Height)
+		}
+		if tip.BranchLen != test.branchLen {
+			t.Errorf("%s: got branch length %d, want %d", test.name,
+				tip.BranchLen, test.branchLen)
+		}
+		if tip.Status != test.status {
+			t.Errorf("%s: got status %v, want %v", test.name,
+				tip.Status, test.status)
+		}
+	}
+}
+
+// TestChainTipStatusStringer tests the stringized output for the
+// ChainTipStatus type.
+func TestChainTipStatusStringer(t *testing.T) {
+	tests := []struct {
+		in   ChainTipStatus
+		want string
+	}{
+		{ChainTipActive, "active"},
+		{ChainTipValidFork, "valid-fork"},
+		{ChainTipInvalid, "invalid"},
+		{ChainTipHeadersOnly, "headers-only"},
+		{0xff, "unknown"},
+	}
+
+	for i, test := range tests {
+		result := test.in.String()
+		if result != test.want {
+			t.Errorf("String #%d\n got: %s want: %s", i, result,
+				test.want)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/wire"
@@ -36,133 +37,34 @@ const maxShift = uint(256)
 
 // HashToBig converts a chainhash.Hash into a big.Int that can be used to
 // perform math comparisons.
+//
+// Deprecated: Use standalone.HashToBig instead.
 func HashToBig(hash *chainhash.Hash) *big.Int {
-	// A Hash is in little-endian, but the big package wants the bytes in
-	// big-endian, so reverse them.
-	buf := *hash
-	blen := len(buf)
-	for i := 0; i < blen/2; i++ {
-		buf[i], buf[blen-1-i] = buf[blen-1-i], buf[i]
-	}
-
-	return new(big.Int).SetBytes(buf[:])
+	return standalone.HashToBig(hash)
 }
 
 // CompactToBig converts a compact representation of a whole number N to an
-// unsigned 32-bit number.  The representation is similar to IEEE754 floating
-// point numbers.
-//
-// Like IEEE754 floating point, there are three basic components: the sign,
-// the exponent, and the mantissa.  They are broken out as follows:
-//
-//	* the most significant 8 bits represent the unsigned base 256 exponent
-// 	* bit 23 (the 24th bit) represents the sign bit
-//	* the least significant 23 bits represent the mantissa
-//
-//	-------------------------------------------------
-//	|   Exponent     |    Sign    |    Mantissa     |
-//	-------------------------------------------------
-//	| 8 bits [31-24] | 1 bit [23] | 23 bits [22-00] |
-//	-------------------------------------------------
-//
-// The formula to calculate N is:
-// 	N = (-1^sign) * mantissa * 256^(exponent-3)
+// unsigned 32-bit number.  See standalone.CompactToBig for details.
 //
-// This compact form is only used in hypercash to encode unsigned 256-bit numbers
-// which represent difficulty targets, thus there really is not a need for a
-// sign bit, but it is implemented here to stay consistent with bitcoind.
+// Deprecated: Use standalone.CompactToBig instead.
 func CompactToBig(compact uint32) *big.Int {
-	// Extract the mantissa, sign bit, and exponent.
-	mantissa := compact & 0x007fffff
-	isNegative := compact&0x00800000 != 0
-	exponent := uint(compact >> 24)
-
-	// Since the base for the exponent is 256, the exponent can be treated
-	// as the number of bytes to represent the full 256-bit number.  So,
-	// treat the exponent as the number of bytes and shift the mantissa
-	// right or left accordingly.  This is equivalent to:
-	// N = mantissa * 256^(exponent-3)
-	var bn *big.Int
-	if exponent <= 3 {
-		mantissa >>= 8 * (3 - exponent)
-		bn = big.NewInt(int64(mantissa))
-	} else {
-		bn = big.NewInt(int64(mantissa))
-		bn.Lsh(bn, 8*(exponent-3))
-	}
-
-	// Make it negative if the sign bit is set.
-	if isNegative {
-		bn = bn.Neg(bn)
-	}
-
-	return bn
+	return standalone.CompactToBig(compact)
 }
 
 // BigToCompact converts a whole number N to a compact representation using
-// an unsigned 32-bit number.  The compact representation only provides 23 bits
-// of precision, so values larger than (2^23 - 1) only encode the most
-// significant digits of the number.  See CompactToBig for details.
+// an unsigned 32-bit number.  See standalone.BigToCompact for details.
+//
+// Deprecated: Use standalone.BigToCompact instead.
 func BigToCompact(n *big.Int) uint32 {
-	// No need to do any work if it's zero.
-	if n.Sign() == 0 {
-		return 0
-	}
-
-	// Since the base for the exponent is 256, the exponent can be treated
-	// as the number of bytes.  So, shift the number right or left
-	// accordingly.  This is equivalent to:
-	// mantissa = mantissa / 256^(exponent-3)
-	var mantissa uint32
-	exponent := uint(len(n.Bytes()))
-	if exponent <= 3 {
-		mantissa = uint32(n.Bits()[0])
-		mantissa <<= 8 * (3 - exponent)
-	} else {
-		// Use a copy to avoid modifying the caller's original number.
-		tn := new(big.Int).Set(n)
-		mantissa = uint32(tn.Rsh(tn, 8*(exponent-3)).Bits()[0])
-	}
-
-	// When the mantissa already has the sign bit set, the number is too
-	// large to fit into the available 23-bits, so divide the number by 256
-	// and increment the exponent accordingly.
-	if mantissa&0x00800000 != 0 {
-		mantissa >>= 8
-		exponent++
-	}
-
-	// Pack the exponent, sign bit, and mantissa into an unsigned 32-bit
-	// int and return it.
-	compact := uint32(exponent<<24) | mantissa
-	if n.Sign() < 0 {
-		compact |= 0x00800000
-	}
-	return compact
+	return standalone.BigToCompact(n)
 }
 
-// CalcWork calculates a work value from difficulty bits.  Hypercash increases
-// the difficulty for generating a block by decreasing the value which the
-// generated hash must be less than.  This difficulty target is stored in each
-// block header using a compact representation as described in the documentation
-// for CompactToBig.  The main chain is selected by choosing the chain that has
-// the most proof of work (highest difficulty).  Since a lower target difficulty
-// value equates to higher actual difficulty, the work value which will be
-// accumulated must be the inverse of the difficulty.  Also, in order to avoid
-// potential division by zero and really small floating point numbers, the
-// result adds 1 to the denominator and multiplies the numerator by 2^256.
+// CalcWork calculates a work value from difficulty bits.  See
+// standalone.CalcWork for details.
+//
+// Deprecated: Use standalone.CalcWork instead.
 func CalcWork(bits uint32) *big.Int {
-	// Return a work value of zero if the passed difficulty bits represent
-	// a negative number. Note this should not happen in practice with valid
-	// blocks, but an invalid block could trigger it.
-	difficultyNum := CompactToBig(bits)
-	if difficultyNum.Sign() <= 0 {
-		return big.NewInt(0)
-	}
-
-	// (1 << 256) / (difficultyNum + 1)
-	denominator := new(big.Int).Add(difficultyNum, bigOne)
-	return new(big.Int).Div(oneLsh256, denominator)
+	return standalone.CalcWork(bits)
 }
 
 // calcEasiestDifficulty calculates the easiest possible difficulty that a block
@@ -189,7 +91,7 @@ func (b *BlockChain) calcEasiestDifficulty(bits uint32,
 	// difficulty for a given duration is the largest value possible given
 	// the number of retargets for the duration and starting difficulty
 	// multiplied by the max adjustment factor.
-	newTarget := CompactToBig(bits)
+	newTarget := standalone.CompactToBig(bits)
 	for durationVal > 0 && newTarget.Cmp(b.chainParams.PowLimit) < 0 {
 		newTarget.Mul(newTarget, adjustmentFactor)
 		durationVal -= maxRetargetTimespan
@@ -200,7 +102,7 @@ func (b *BlockChain) calcEasiestDifficulty(bits uint32,
 		newTarget.Set(b.chainParams.PowLimit)
 	}
 
-	return BigToCompact(newTarget)
+	return standalone.BigToCompact(newTarget)
 }
 
 // findPrevTestNetDifficulty returns the difficulty of the previous block which
@@ -268,7 +170,7 @@ func (b *BlockChain) calcNextRequiredDifficulty(curNode *blockNode,					//tsy
 	// Get the old difficulty; if we aren't at a block height where it changes,
 	// just return this.
 	oldDiff := newCurNode.header.Bits
-	oldDiffBig := CompactToBig(newCurNode.header.Bits)
+	oldDiffBig := standalone.CompactToBig(newCurNode.header.Bits)
 
 	// We're not at a retarget point, return the oldDiff.
 	if (newCurNode.keyHeight+1)%b.chainParams.WorkDiffWindowSize != 0 {
@@ -289,7 +191,7 @@ func (b *BlockChain) calcNextRequiredDifficulty(curNode *blockNode,					//tsy
 				shifts := uint((timePassed / b.chainParams.TargetTimePerBlock) + 1)
 
 				// Scale the difficulty with time passed.
-				oldTarget := CompactToBig(newCurNode.header.Bits)
+				oldTarget := standalone.CompactToBig(newCurNode.header.Bits)
 				newTarget := new(big.Int)
 				if shifts < maxShift {
 					newTarget.Lsh(oldTarget, shifts)
@@ -302,7 +204,7 @@ func (b *BlockChain) calcNextRequiredDifficulty(curNode *blockNode,					//tsy
 					newTarget.Set(b.chainParams.PowLimit)
 				}
 
-				return BigToCompact(newTarget), nil
+				return standalone.BigToCompact(newTarget), nil
 			}
 
 			// The block was mined within the desired timeframe, so
@@ -320,9 +222,9 @@ func (b *BlockChain) calcNextRequiredDifficulty(curNode *blockNode,					//tsy
 
 	// Declare some useful variables.
 	RAFBig := big.NewInt(b.chainParams.RetargetAdjustmentFactor)
-	nextDiffBigMin := CompactToBig(newCurNode.header.Bits)
+	nextDiffBigMin := standalone.CompactToBig(newCurNode.header.Bits)
 	nextDiffBigMin.Div(nextDiffBigMin, RAFBig)
-	nextDiffBigMax := CompactToBig(newCurNode.header.Bits)
+	nextDiffBigMax := standalone.CompactToBig(newCurNode.header.Bits)
 	nextDiffBigMax.Mul(nextDiffBigMax, RAFBig)
 
 	alpha := b.chainParams.WorkDiffAlpha
@@ -442,10 +344,10 @@ func (b *BlockChain) calcNextRequiredDifficulty(curNode *blockNode,					//tsy
 	// intentionally converting the bits back to a number instead of using
 	// newTarget since conversion to the compact representation loses
 	// precision.
-	nextDiffBits := BigToCompact(nextDiffBig)
+	nextDiffBits := standalone.BigToCompact(nextDiffBig)
 	log.Debugf("Difficulty retarget at block height %d", curNode.height+1)
 	log.Debugf("Old target %08x (%064x)", curNode.header.Bits, oldDiffBig)
-	log.Debugf("New target %08x (%064x)", nextDiffBits, CompactToBig(nextDiffBits))
+	log.Debugf("New target %08x (%064x)", nextDiffBits, standalone.CompactToBig(nextDiffBits))
 
 	return nextDiffBits, nil
 }
@@ -0,0 +1,53 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// TestCheckDupTxs ensures transactions which overwrite unspent transactions
+// with the same hash are only rejected when the chain is configured to check
+// for duplicate hashes, while overwriting fully spent ones is always allowed.
+func TestCheckDupTxs(t *testing.T) {
+	msgTx := wire.NewMsgTx()
+	msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1e8, []byte{0x51}))
+	tx := hcashutil.NewTx(msgTx)
+
+	unspent := NewUtxoViewpoint()
+	unspent.AddTxOuts(tx, 1, 0)
+	spent := NewUtxoViewpoint()
+	spent.AddTxOuts(tx, 1, 0)
+	spent.LookupEntry(tx.Hash()).SpendOutput(0)
+
+	tests := []struct {
+		name    string
+		check   bool
+		view    *UtxoViewpoint
+		wantErr bool
+	}{
+		{"unspent duplicate unchecked", false, unspent, false},
+		{"unspent duplicate", true, unspent, true},
+		{"spent duplicate", true, spent, false},
+	}
+	for _, test := range tests {
+		b := &BlockChain{checkForDuplicateHashes: test.check}
+		err := b.checkDupTxs([]*hcashutil.Tx{tx}, test.view)
+		if !test.wantErr {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrOverwriteTx {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				ErrOverwriteTx)
+		}
+	}
+}
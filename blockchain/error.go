@@ -461,6 +461,14 @@ const (
 	//ErrNoSuchBlock indicates that can not find a block hash from index.
 	ErrNoSuchBlockHash
 
+	// ErrReorgTooDeep indicates a block would cause a reorganization which
+	// disconnects more blocks from the main chain than the configured
+	// maximum reorganization depth.
+	ErrReorgTooDeep
+
+	// ErrInvalidTemplateParent indicates that a block template does not
+	// build on the current tip of the main chain.
+	ErrInvalidTemplateParent
 )
 
 // Map of ErrorCode values back to their constant names for pretty printing.
@@ -566,6 +574,9 @@ var errorCodeStrings = map[ErrorCode]string{
 
 	ErrNoSuchBlockHash:		   "ErrNoSuchBlockHash",
 
+	ErrReorgTooDeep: "ErrReorgTooDeep",
+
+	ErrInvalidTemplateParent: "ErrInvalidTemplateParent",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -54,6 +54,8 @@ func TestErrorCodeStringer(t *testing.T) {
 		{blockchain.ErrBadCoinbaseValue, "ErrBadCoinbaseValue"},
 		{blockchain.ErrScriptMalformed, "ErrScriptMalformed"},
 		{blockchain.ErrScriptValidation, "ErrScriptValidation"},
+		{blockchain.ErrReorgTooDeep, "ErrReorgTooDeep"},
+		{blockchain.ErrInvalidTemplateParent, "ErrInvalidTemplateParent"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
@@ -0,0 +1,245 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg"
+)
+
+const (
+	// finalityWindow is the number of most recent main chain key blocks
+	// the vote participation and the key block interval used to estimate
+	// the time to finality are measured over.
+	finalityWindow = 144
+
+	// maxFinalityKeyBlocks is the maximum number of key blocks the time to
+	// finality is estimated up to.  Reaching a reorg probability requiring
+	// more key blocks is reported as an error.
+	maxFinalityKeyBlocks = 10000
+)
+
+// FinalityEstimate describes the estimated number of key blocks and the
+// wall-clock time a transaction has to be buried under by the main chain for
+// the probability of an attacker reorganizing it out to drop below a target.
+type FinalityEstimate struct {
+	// KeyBlocks is the number of key blocks, including the one the
+	// transaction is mined in, which are required to reach the target
+	// reorg probability.
+	KeyBlocks int64
+
+	// Duration is the expected wall-clock time it takes to mine the
+	// required key blocks.
+	Duration time.Duration
+
+	// ReorgProbability is the probability of the attacker reorganizing
+	// the transaction out after the required number of key blocks, which
+	// is at most the target probability.
+	ReorgProbability float64
+
+	// Participation is the fraction of the tickets selected to vote which
+	// the estimate assumes to vote on the blocks of the honest chain.
+	Participation float64
+
+	// AttackerShare is the fraction of the key blocks the attacker is
+	// expected to find once the votes required for each key block are
+	// accounted for.
+	AttackerShare float64
+}
+
+// majorityProbability returns the probability that at least a majority of
+// the passed number of tickets selected to vote are each voting with the
+// passed probability, which is the number of votes a key block requires.
+func majorityProbability(tickets int, voteProbability float64) float64 {
+	majority := tickets/2 + 1
+	var prob float64
+	for k := majority; k <= tickets; k++ {
+		prob += binomialProbability(tickets, k, voteProbability)
+	}
+	return prob
+}
+
+// binomialProbability returns the probability of exactly k successes out of
+// n trials which each succeed with probability p.
+func binomialProbability(n, k int, p float64) float64 {
+	if p <= 0 {
+		if k == 0 {
+			return 1
+		}
+		return 0
+	}
+	if p >= 1 {
+		if k == n {
+			return 1
+		}
+		return 0
+	}
+	lnChoose := lgamma(n+1) - lgamma(k+1) - lgamma(n-k+1)
+	return math.Exp(lnChoose + float64(k)*math.Log(p) +
+		float64(n-k)*math.Log(1-p))
+}
+
+// lgamma returns the natural logarithm of the gamma function of n, which is
+// the logarithm of (n-1)! for positive integers.
+func lgamma(n int) float64 {
+	v, _ := math.Lgamma(float64(n))
+	return v
+}
+
+// reorgProbability returns the probability that an attacker finding the
+// passed share of the blocks ever catches up with the honest chain once it is
+// the passed number of blocks ahead of the fork point.  It is the analysis
+// from section 11 of the Bitcoin whitepaper, which models the progress of the
+// attacker while the honest chain finds its blocks as a Poisson distribution.
+func reorgProbability(attackerShare float64, blocks int64) float64 {
+	if attackerShare <= 0 {
+		return 0
+	}
+	ratio := attackerShare / (1 - attackerShare)
+	lambda := float64(blocks) * ratio
+	prob := 1.0
+	for k := int64(0); k <= blocks; k++ {
+		poisson := math.Exp(-lambda + float64(k)*math.Log(lambda) -
+			lgamma(int(k)+1))
+		prob -= poisson * (1 - math.Pow(ratio, float64(blocks-k)))
+	}
+	if prob < 0 {
+		return 0
+	}
+	return prob
+}
+
+// EstimateFinality estimates the number of key blocks and the wall-clock time
+// a transaction needs to be buried under for the probability of reorganizing
+// it out to drop to the passed target probability.  The attacker is assumed
+// to control the passed fractions of the hash rate and the live ticket pool,
+// and to vote with all of its tickets on its own chain only.  The tickets of
+// the honest stakeholders vote on the honest chain with the passed
+// participation, which is the fraction of the selected tickets that vote.
+// Since every key block requires a majority of the tickets selected to vote
+// on it, both chains grow at the rate of their hash rate weighted by the
+// probability of their tickets providing the majority.  The key blocks are
+// assumed to be found at the passed interval.
+func EstimateFinality(params *chaincfg.Params, hashFraction, stakeFraction,
+	participation, targetProbability float64,
+	keyBlockInterval time.Duration) (*FinalityEstimate, error) {
+
+	switch {
+	case hashFraction < 0 || hashFraction >= 1:
+		return nil, errors.New("hash rate fraction must be at least 0 " +
+			"and less than 1")
+	case stakeFraction < 0 || stakeFraction >= 1:
+		return nil, errors.New("ticket pool fraction must be at least " +
+			"0 and less than 1")
+	case participation <= 0 || participation > 1:
+		return nil, errors.New("participation must be greater than 0 " +
+			"and at most 1")
+	case targetProbability <= 0 || targetProbability >= 1:
+		return nil, errors.New("target probability must be greater " +
+			"than 0 and less than 1")
+	}
+
+	tickets := int(params.TicketsPerBlock)
+	attackerRate := hashFraction * majorityProbability(tickets,
+		stakeFraction)
+	honestRate := (1 - hashFraction) * majorityProbability(tickets,
+		(1-stakeFraction)*participation)
+	if honestRate == 0 {
+		return nil, errors.New("the honest chain can not find key blocks")
+	}
+	attackerShare := attackerRate / (attackerRate + honestRate)
+	if attackerShare >= 0.5 {
+		return nil, errors.New("the attacker is expected to find at " +
+			"least half of the key blocks")
+	}
+
+	for keyBlocks := int64(1); keyBlocks <= maxFinalityKeyBlocks; keyBlocks++ {
+		prob := reorgProbability(attackerShare, keyBlocks)
+		if prob > targetProbability {
+			continue
+		}
+		return &FinalityEstimate{
+			KeyBlocks:        keyBlocks,
+			Duration:         time.Duration(keyBlocks) * keyBlockInterval,
+			ReorgProbability: prob,
+			Participation:    participation,
+			AttackerShare:    attackerShare,
+		}, nil
+	}
+
+	return nil, errors.New("the target probability is not reached " +
+		"within a reasonable number of key blocks")
+}
+
+// EstimateTimeToFinality estimates the number of key blocks and the wall-clock
+// time a transaction needs to be buried under for the probability of
+// reorganizing it out to drop to the passed target probability against an
+// attacker controlling the passed fractions of the hash rate and the live
+// ticket pool.  The vote participation and the key block interval are
+// measured over the most recent key blocks of the main chain.  See
+// EstimateFinality for more details.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) EstimateTimeToFinality(hashFraction, stakeFraction,
+	targetProbability float64) (*FinalityEstimate, error) {
+
+	participation, interval, err := b.recentKeyBlockStats()
+	if err != nil {
+		return nil, err
+	}
+	return EstimateFinality(b.chainParams, hashFraction, stakeFraction,
+		participation, targetProbability, interval)
+}
+
+// recentKeyBlockStats returns the vote participation and the average interval
+// between the most recent main chain key blocks.  The participation is 1 when
+// none of them required votes, and the interval is the target time per block
+// when there are not enough key blocks to measure it.
+func (b *BlockChain) recentKeyBlockStats() (float64, time.Duration, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node := b.bestNode
+	if !node.isKeyBlock {
+		var err error
+		node, err = b.getPrevKeyNodeFromNode(node)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	var keyBlocks, voters, votingKeyBlocks int64
+	var newest, oldest time.Time
+	for ; node != nil && keyBlocks < finalityWindow; keyBlocks++ {
+		if keyBlocks == 0 {
+			newest = node.header.Timestamp
+		}
+		oldest = node.header.Timestamp
+		if node.keyHeight+1 >= b.chainParams.StakeValidationHeight {
+			voters += int64(node.header.Voters)
+			votingKeyBlocks++
+		}
+
+		var err error
+		node, err = b.getPrevKeyNodeFromNode(node)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	participation := 1.0
+	if votingKeyBlocks > 0 {
+		participation = float64(voters) / float64(votingKeyBlocks*
+			int64(b.chainParams.TicketsPerBlock))
+	}
+	interval := b.chainParams.TargetTimePerBlock
+	if keyBlocks > 1 && newest.After(oldest) {
+		interval = newest.Sub(oldest) / time.Duration(keyBlocks-1)
+	}
+	return participation, interval, nil
+}
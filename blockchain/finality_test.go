@@ -0,0 +1,133 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg"
+)
+
+// TestReorgProbability ensures the probability of an attacker catching up
+// matches the results published in the Bitcoin whitepaper.
+func TestReorgProbability(t *testing.T) {
+	tests := []struct {
+		share  float64
+		blocks int64
+		want   float64
+	}{
+		{share: 0.1, blocks: 1, want: 0.2045873},
+		{share: 0.1, blocks: 5, want: 0.0009137},
+		{share: 0.1, blocks: 10, want: 0.0000012},
+		{share: 0.3, blocks: 5, want: 0.1773523},
+		{share: 0.3, blocks: 10, want: 0.0416605},
+		{share: 0, blocks: 1, want: 0},
+	}
+
+	for _, test := range tests {
+		got := reorgProbability(test.share, test.blocks)
+		if math.Abs(got-test.want) > 5e-8 {
+			t.Errorf("reorgProbability(%v, %d): got %.7f, want %.7f",
+				test.share, test.blocks, got, test.want)
+		}
+	}
+}
+
+// TestEstimateFinality ensures the estimated number of key blocks is the
+// smallest one reaching the target probability and that invalid and hopeless
+// parameters are rejected.
+func TestEstimateFinality(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	interval := 5 * time.Minute
+
+	tests := []struct {
+		name          string
+		hashFraction  float64
+		stakeFraction float64
+		participation float64
+		target        float64
+		keyBlocks     int64
+		err           bool
+	}{
+		{
+			name:          "attacker without stake",
+			hashFraction:  0.4,
+			participation: 1,
+			target:        0.001,
+			keyBlocks:     1,
+		},
+		{
+			name:          "minority attacker",
+			hashFraction:  0.3,
+			stakeFraction: 0.3,
+			participation: 0.95,
+			target:        0.001,
+			keyBlocks:     5,
+		},
+		{
+			name:          "majority attacker",
+			hashFraction:  0.5,
+			stakeFraction: 0.5,
+			participation: 1,
+			target:        0.001,
+			err:           true,
+		},
+		{
+			name:          "invalid hash fraction",
+			hashFraction:  1,
+			participation: 1,
+			target:        0.001,
+			err:           true,
+		},
+		{
+			name:         "invalid participation",
+			hashFraction: 0.1,
+			target:       0.001,
+			err:          true,
+		},
+		{
+			name:          "invalid target",
+			hashFraction:  0.1,
+			participation: 1,
+			err:           true,
+		},
+	}
+
+	for _, test := range tests {
+		est, err := EstimateFinality(params, test.hashFraction,
+			test.stakeFraction, test.participation, test.target,
+			interval)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got %+v", test.name, est)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if est.KeyBlocks != test.keyBlocks {
+			t.Errorf("%s: got %d key blocks, want %d", test.name,
+				est.KeyBlocks, test.keyBlocks)
+			continue
+		}
+		if est.ReorgProbability > test.target {
+			t.Errorf("%s: reorg probability %v exceeds target %v",
+				test.name, est.ReorgProbability, test.target)
+		}
+		if est.KeyBlocks > 1 && reorgProbability(est.AttackerShare,
+			est.KeyBlocks-1) <= test.target {
+			t.Errorf("%s: %d key blocks already reach the target",
+				test.name, est.KeyBlocks-1)
+		}
+		if want := time.Duration(est.KeyBlocks) * interval; est.Duration != want {
+			t.Errorf("%s: got duration %v, want %v", test.name,
+				est.Duration, want)
+		}
+	}
+}
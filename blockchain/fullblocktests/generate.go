@@ -15,6 +15,7 @@ import (
 
 	"github.com/HcashOrg/hcashd/blockchain"
 	"github.com/HcashOrg/hcashd/blockchain/chaingen"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg/chainec"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/hcashec/secp256k1"
@@ -1054,7 +1055,7 @@ func Generate(includeLargeReorg bool) (tests [][]TestInstance, err error) {
 	//   ... -> b36(8)
 	//                \-> bv3(9)
 	g.SetTip("b36")
-	g.NextBlock("bv3", outs[9], ticketOuts[9], g.ReplaceWithNVotes(ticketsPerBlock+1))
+	g.NextBlock("bv3", outs[9], ticketOuts[9], g.WithExtraVote())
 	rejected(blockchain.ErrTooManyVotes)
 
 	// Attempt to add block with too few votes.
@@ -1426,7 +1427,7 @@ func Generate(includeLargeReorg bool) (tests [][]TestInstance, err error) {
 			// a uint256 is higher than the limit.
 			b49.Header.Nonce += 1
 			hash := b49.BlockHash()
-			hashNum := blockchain.HashToBig(&hash)
+			hashNum := standalone.HashToBig(&hash)
 			if hashNum.Cmp(g.Params().PowLimit) >= 0 {
 				break
 			}
@@ -1458,6 +1459,23 @@ func Generate(includeLargeReorg bool) (tests [][]TestInstance, err error) {
 	g.AssertTipBlockMerkleRoot(chainhash.Hash{})
 	rejected(blockchain.ErrBadMerkleRoot)
 
+	// Create block with an invalid stake root.
+	//
+	//   ... -> b46(14)
+	//                 \-> b51a(15)
+	g.SetTip("b46")
+	g.NextBlock("b51a", outs[15], ticketOuts[15], chaingen.WithBadStakeRoot())
+	rejected(blockchain.ErrBadMerkleRoot)
+
+	// Create block with a key height which does not match the key block
+	// its votes are for.
+	//
+	//   ... -> b46(14)
+	//                 \-> b51b(15)
+	g.SetTip("b46")
+	g.NextBlock("b51b", outs[15], ticketOuts[15], chaingen.WithWrongKeyHeight())
+	rejected(blockchain.ErrVotesOnWrongBlock)
+
 	// Create block with an invalid proof-of-work limit.
 	//
 	//   ... -> b46(14)
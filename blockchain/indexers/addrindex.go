@@ -342,6 +342,21 @@ func dbFetchAddrIndexEntries(bucket internalBucket, addrKey [addrKeySize]byte, n
 	return results, numToSkip, nil
 }
 
+// dbCountAddrIndexEntries returns the number of entries for the given address
+// key in the address index.
+func dbCountAddrIndexEntries(bucket internalBucket, addrKey [addrKeySize]byte) uint32 {
+	var numEntries uint32
+	for level := uint8(0); ; level++ {
+		curLevelKey := keyForLevel(addrKey, level)
+		levelData := bucket.Get(curLevelKey[:])
+		if levelData == nil {
+			break
+		}
+		numEntries += uint32(len(levelData) / txEntrySize)
+	}
+	return numEntries
+}
+
 // minEntriesToReachLevel returns the minimum number of entries that are
 // required to reach the given address index level.
 func minEntriesToReachLevel(level uint8) int {
@@ -954,6 +969,26 @@ func (idx *AddrIndex) TxRegionsForAddress(dbTx database.Tx, addr hcashutil.Addre
 	return regions, skipped, err
 }
 
+// NumTxnsForAddress returns the number of transactions involving the passed
+// address that are confirmed in blocks, which is the number of block regions
+// TxRegionsForAddress can return for the address.
+//
+// This function is safe for concurrent access.
+func (idx *AddrIndex) NumTxnsForAddress(addr hcashutil.Address) (uint32, error) {
+	addrKey, err := addrToKey(addr, idx.chainParams)
+	if err != nil {
+		return 0, err
+	}
+
+	var numTxns uint32
+	err = idx.db.View(func(dbTx database.Tx) error {
+		addrIdxBucket := dbTx.Metadata().Bucket(addrIndexKey)
+		numTxns = dbCountAddrIndexEntries(addrIdxBucket, addrKey)
+		return nil
+	})
+	return numTxns, err
+}
+
 // indexUnconfirmedAddresses modifies the unconfirmed (memory-only) address
 // index to include mappings for the addresses encoded by the passed public key
 // script to the transaction.
@@ -271,6 +271,15 @@ nextTest:
 					test.name, numDelete, err)
 				continue nextTest
 			}
+
+			// Ensure the entries are counted across all levels.
+			numCounted := dbCountAddrIndexEntries(bucket, test.key)
+			if numCounted != uint32(numExpected) {
+				t.Errorf("dbCountAddrIndexEntries (%s) delete %d: "+
+					"got %d entries, want %d", test.name,
+					numDelete, numCounted, numExpected)
+				continue nextTest
+			}
 		}
 	}
 }
@@ -0,0 +1,280 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"fmt"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/database"
+	"github.com/HcashOrg/hcashd/gcs"
+	"github.com/HcashOrg/hcashd/gcs/blockcf"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+const (
+	// cfIndexName is the human-readable name for the index.
+	cfIndexName = "committed filter index"
+
+	// cfEntryKeySize is the size of the keys of the committed filter index
+	// entries.  Each key is made up of the record type, the filter type,
+	// and the block hash.
+	cfEntryKeySize = 2 + chainhash.HashSize
+
+	// cfRecordFilter and cfRecordHeader are the record types of the
+	// committed filter index entries that respectively house the
+	// serialized filters and the filter headers of blocks.
+	cfRecordFilter = 0x00
+	cfRecordHeader = 0x01
+)
+
+var (
+	// cfIndexKey is the key of the committed filter index and the db
+	// bucket used to house it.
+	cfIndexKey = []byte("cfindex")
+
+	// cfFilterTypes are the filter types maintained by the committed filter
+	// index.
+	cfFilterTypes = []wire.FilterType{wire.GCSFilterRegular,
+		wire.GCSFilterExtended}
+)
+
+// -----------------------------------------------------------------------------
+// The committed filter index consists of entries for every block in the main
+// chain.  For every filter type there is an entry that houses the filter of
+// the block and an entry that houses the filter header of the block.  The
+// filter header commits to the filter and the header of the parent block so
+// that a client can verify a chain of filters against a single header.
+//
+// The serialized key format is:
+//
+//   <record type><filter type><block hash>
+//
+//   Field          Type              Size
+//   record type    byte              1
+//   filter type    byte              1
+//   block hash     chainhash.Hash    32
+//
+// The filter entries hold the filter serialized with its N prefix and the
+// header entries hold the 32 byte filter header.
+// -----------------------------------------------------------------------------
+
+// cfEntryKey returns the key of the committed filter index entry of the
+// passed record type for the filter of the passed type and block hash.
+func cfEntryKey(record byte, filterType wire.FilterType, hash *chainhash.Hash) []byte {
+	key := make([]byte, cfEntryKeySize)
+	key[0] = record
+	key[1] = byte(filterType)
+	copy(key[2:], hash[:])
+	return key
+}
+
+// dbStoreFilter uses an existing database transaction to store the filter and
+// filter header of the passed type for the block with the passed hash.
+func dbStoreFilter(dbTx database.Tx, filterType wire.FilterType, hash *chainhash.Hash, filter *gcs.Filter, header *chainhash.Hash) error {
+	bucket := dbTx.Metadata().Bucket(cfIndexKey)
+	err := bucket.Put(cfEntryKey(cfRecordFilter, filterType, hash),
+		filter.NBytes())
+	if err != nil {
+		return err
+	}
+	return bucket.Put(cfEntryKey(cfRecordHeader, filterType, hash),
+		header[:])
+}
+
+// dbDeleteFilter uses an existing database transaction to remove the filter
+// and filter header of the passed type for the block with the passed hash.
+func dbDeleteFilter(dbTx database.Tx, filterType wire.FilterType, hash *chainhash.Hash) error {
+	bucket := dbTx.Metadata().Bucket(cfIndexKey)
+	err := bucket.Delete(cfEntryKey(cfRecordFilter, filterType, hash))
+	if err != nil {
+		return err
+	}
+	return bucket.Delete(cfEntryKey(cfRecordHeader, filterType, hash))
+}
+
+// dbFetchFilterEntry uses an existing database transaction to fetch a copy of
+// the committed filter index entry of the passed record type for the filter of
+// the passed type and block hash.  Nil is returned when there is no entry.
+func dbFetchFilterEntry(dbTx database.Tx, record byte, filterType wire.FilterType, hash *chainhash.Hash) []byte {
+	bucket := dbTx.Metadata().Bucket(cfIndexKey)
+	entry := bucket.Get(cfEntryKey(record, filterType, hash))
+	if entry == nil {
+		return nil
+	}
+
+	// The returned slice is only valid during the database transaction, so
+	// make a copy of it.
+	entryCopy := make([]byte, len(entry))
+	copy(entryCopy, entry)
+	return entryCopy
+}
+
+// CFIndex implements a committed filter (cf) by hash index.  The regular and
+// extended filters of every block in the main chain are stored along with
+// their filter headers so they can be served to light clients.
+type CFIndex struct {
+	db          database.DB
+	chainParams *chaincfg.Params
+}
+
+// Ensure the CFIndex type implements the Indexer interface.
+var _ Indexer = (*CFIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *CFIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *CFIndex) Key() []byte {
+	return cfIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *CFIndex) Name() string {
+	return cfIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs to be
+// created for the first time.  It creates the bucket for the committed filter
+// index and stores the filters of the genesis block, since the index manager
+// considers the genesis block already indexed.
+//
+// This is part of the Indexer interface.
+func (idx *CFIndex) Create(dbTx database.Tx) error {
+	if _, err := dbTx.Metadata().CreateBucket(cfIndexKey); err != nil {
+		return err
+	}
+
+	genesis := idx.chainParams.GenesisBlock
+	genesisHash := genesis.BlockHash()
+	for _, filterType := range cfFilterTypes {
+		filter, err := blockcf.Build(filterType, genesis)
+		if err != nil {
+			return err
+		}
+		header := gcs.MakeHeaderForFilter(filter, &chainhash.Hash{})
+		err = dbStoreFilter(dbTx, filterType, &genesisHash, filter,
+			&header)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer stores the filters of the block
+// and their filter headers, which are chained to the filter headers of the
+// parent block.
+//
+// This is part of the Indexer interface.
+func (idx *CFIndex) ConnectBlock(dbTx database.Tx, block, parent *hcashutil.Block, view *blockchain.UtxoViewpoint) error {
+	for _, filterType := range cfFilterTypes {
+		filter, err := blockcf.Build(filterType, block.MsgBlock())
+		if err != nil {
+			return err
+		}
+
+		prevHeaderBytes := dbFetchFilterEntry(dbTx, cfRecordHeader,
+			filterType, parent.Hash())
+		if prevHeaderBytes == nil {
+			return AssertError(fmt.Sprintf("missing %v filter header "+
+				"for parent block %v", filterType, parent.Hash()))
+		}
+		prevHeader, err := chainhash.NewHash(prevHeaderBytes)
+		if err != nil {
+			return err
+		}
+
+		header := gcs.MakeHeaderForFilter(filter, prevHeader)
+		err = dbStoreFilter(dbTx, filterType, block.Hash(), filter,
+			&header)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the filters and
+// filter headers of the block.
+//
+// This is part of the Indexer interface.
+func (idx *CFIndex) DisconnectBlock(dbTx database.Tx, block, parent *hcashutil.Block, view *blockchain.UtxoViewpoint) error {
+	for _, filterType := range cfFilterTypes {
+		err := dbDeleteFilter(dbTx, filterType, block.Hash())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FilterByBlockHash returns the serialized contents of the filter of the passed
+// type for the block with the passed hash.  The filter is serialized with its N
+// prefix.  When there is no entry for the block, nil will be returned for both
+// the filter and the error.
+//
+// This function is safe for concurrent access.
+func (idx *CFIndex) FilterByBlockHash(hash *chainhash.Hash, filterType wire.FilterType) ([]byte, error) {
+	var filter []byte
+	err := idx.db.View(func(dbTx database.Tx) error {
+		filter = dbFetchFilterEntry(dbTx, cfRecordFilter, filterType, hash)
+		return nil
+	})
+	return filter, err
+}
+
+// FilterHeaderByBlockHash returns the filter header of the filter of the passed
+// type for the block with the passed hash.  When there is no entry for the
+// block, nil will be returned for both the header and the error.
+//
+// This function is safe for concurrent access.
+func (idx *CFIndex) FilterHeaderByBlockHash(hash *chainhash.Hash, filterType wire.FilterType) (*chainhash.Hash, error) {
+	var header *chainhash.Hash
+	err := idx.db.View(func(dbTx database.Tx) error {
+		headerBytes := dbFetchFilterEntry(dbTx, cfRecordHeader,
+			filterType, hash)
+		if headerBytes == nil {
+			return nil
+		}
+
+		var err error
+		header, err = chainhash.NewHash(headerBytes)
+		return err
+	})
+	return header, err
+}
+
+// NewCfIndex returns a new instance of an indexer that is used to create a
+// mapping of the hashes of all blocks in the blockchain to their committed
+// filters and filter headers.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewCfIndex(db database.DB, chainParams *chaincfg.Params) *CFIndex {
+	return &CFIndex{db: db, chainParams: chainParams}
+}
+
+// DropCfIndex drops the committed filter index from the provided database if it
+// exists.
+func DropCfIndex(db database.DB) error {
+	return dropIndex(db, cfIndexKey, cfIndexName)
+}
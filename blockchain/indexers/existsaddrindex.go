@@ -184,10 +184,9 @@ func (idx *ExistsAddrIndex) ExistsAddresses(addrs []hcashutil.Address) ([]bool,
 	}
 
 	err := idx.db.View(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		existsAddrIndex := meta.Bucket(existsAddrIndexKey)
 		for i := range addrKeys {
-			meta := dbTx.Metadata()
-			existsAddrIndex := meta.Bucket(existsAddrIndexKey)
-
 			exists[i] = existsAddrIndex.Get(addrKeys[i][:]) != nil
 		}
 
@@ -230,8 +230,12 @@ func (m *Manager) maybeCreateIndexes(dbTx database.Tx) error {
 // time new blocks are being downloaded would lead to an overall longer time to
 // catch up due to the I/O contention.
 //
+// Catching up is aborted with an error when the passed interrupt channel is
+// closed.  Each block is indexed in its own database transaction, so the
+// indexes resume from where they were interrupted on the next start.
+//
 // This is part of the blockchain.IndexManager interface.
-func (m *Manager) Init(chain *blockchain.BlockChain) error {
+func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{}) error {
 	// Nothing to do when no indexes are enabled.
 	if len(m.enabledIndexes) == 0 {
 		return nil
@@ -400,6 +404,12 @@ func (m *Manager) Init(chain *blockchain.BlockChain) error {
 
 	var cachedParent *hcashutil.Block
 	for height := lowestHeight + 1; height <= bestHeight; height++ {
+		if interruptRequested(interrupt) {
+			log.Infof("Interrupted catching up indexes at height %d",
+				height-1)
+			return errInterruptRequested
+		}
+
 		var block, parent *hcashutil.Block
 		err = m.db.Update(func(dbTx database.Tx) error {
 			// Get the parent of the block, unless it's already cached.
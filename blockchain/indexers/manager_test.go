@@ -0,0 +1,114 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/blockchain/chaingen"
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/database"
+	_ "github.com/HcashOrg/hcashd/database/ffldb"
+	"github.com/HcashOrg/hcashd/txscript"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// TestManagerInitInterrupt ensures catching up the indexes stops before
+// indexing any further block once the interrupt channel is closed, and resumes
+// from there on the next initialization.
+func TestManagerInitInterrupt(t *testing.T) {
+	if interruptRequested(nil) {
+		t.Fatal("interruptRequested: nil channel reported as closed")
+	}
+
+	dbPath, err := ioutil.TempDir("", "indexinterrupt")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	params := chaincfg.SimNetParams
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		params.Net)
+	if err != nil {
+		t.Fatalf("error creating db: %v", err)
+	}
+	defer db.Close()
+
+	chain, err := blockchain.New(&blockchain.Config{
+		DB:          db,
+		ChainParams: &params,
+		TimeSource:  blockchain.NewMedianTime(),
+		SigCache:    txscript.NewSigCache(1000),
+	})
+	if err != nil {
+		t.Fatalf("failed to create chain instance: %v", err)
+	}
+
+	// Extend the chain without any indexes.
+	//
+	//   genesis -> bp -> bm0 -> bm1 -> bm2
+	g, err := chaingen.MakeGenerator(&params)
+	if err != nil {
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+	g.CreatePremineBlock("bp", 0)
+	for i := 0; i <= 3; i++ {
+		if i > 0 {
+			g.NextBlock(fmt.Sprintf("bm%d", i-1), nil, nil)
+		}
+		_, _, err := chain.ProcessBlock(hcashutil.NewBlock(g.Tip()),
+			blockchain.BFNone)
+		if err != nil {
+			t.Fatalf("block %q should have been accepted: %v",
+				g.TipName(), err)
+		}
+	}
+	best := chain.BestSnapshot()
+
+	// indexTip returns the height and hash of the tip of the passed index.
+	indexTip := func(indexer Indexer) (int32, string) {
+		var height int32
+		var hash string
+		err := db.View(func(dbTx database.Tx) error {
+			tipHash, tipHeight, err := dbFetchIndexerTip(dbTx,
+				indexer.Key())
+			height = tipHeight
+			hash = tipHash.String()
+			return err
+		})
+		if err != nil {
+			t.Fatalf("dbFetchIndexerTip: %v", err)
+		}
+		return height, hash
+	}
+
+	interrupt := make(chan struct{})
+	close(interrupt)
+	txIndex := NewTxIndex(db)
+	m := NewManager(db, []Indexer{txIndex}, &params)
+	if err := m.Init(chain, interrupt); err != errInterruptRequested {
+		t.Fatalf("Init: got error %v, want %v", err,
+			errInterruptRequested)
+	}
+	if height, _ := indexTip(txIndex); height != 0 {
+		t.Fatalf("interrupted Init: index tip at height %d, want 0",
+			height)
+	}
+
+	if err := m.Init(chain, nil); err != nil {
+		t.Fatalf("Init: unexpected error: %v", err)
+	}
+	height, hash := indexTip(txIndex)
+	if int64(height) != best.Height || hash != best.Hash.String() {
+		t.Fatalf("Init: index tip %v (height %d), want %v (height %d)",
+			hash, height, best.Hash, best.Height)
+	}
+}
@@ -481,6 +481,26 @@ func (idx *TxIndex) TxBlockRegion(hash chainhash.Hash) (*database.BlockRegion, e
 	return region, err
 }
 
+// TxBlockRegions returns the block regions for the provided transaction hashes
+// from the transaction index in the same order using a single database
+// transaction.  The entry for a hash without an index entry is nil.
+//
+// This function is safe for concurrent access.
+func (idx *TxIndex) TxBlockRegions(hashes []chainhash.Hash) ([]*database.BlockRegion, error) {
+	regions := make([]*database.BlockRegion, len(hashes))
+	err := idx.db.View(func(dbTx database.Tx) error {
+		for i := range hashes {
+			var err error
+			regions[i], err = dbFetchTxIndexEntry(dbTx, &hashes[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return regions, err
+}
+
 // NewTxIndex returns a new instance of an indexer that is used to create a
 // mapping of the hashes of all transactions in the blockchain to the respective
 // block, location within the block, and size of the transaction.
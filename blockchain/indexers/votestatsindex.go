@@ -0,0 +1,376 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"fmt"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/database"
+	"github.com/HcashOrg/hcashd/txscript"
+	"github.com/HcashOrg/hcashutil"
+)
+
+const (
+	// voteStatsIndexName is the human-readable name for the index.
+	voteStatsIndexName = "vote stats index"
+
+	// voteStatsEntrySize is the size of the serialized vote statistics of
+	// a voting address.
+	voteStatsEntrySize = 4 + 4 + 4 + 8
+)
+
+var (
+	// voteStatsIndexKey is the key of the vote stats index and the db
+	// bucket used to house it.
+	voteStatsIndexKey = []byte("votestatsidx")
+)
+
+// -----------------------------------------------------------------------------
+// The vote stats index consists of an entry for every voting address, which
+// is the address the first output of a ticket commits to and therefore the
+// address of the wallet voting on behalf of the ticket, such as the one of a
+// stake pool.  The entries are keyed by the address key of the voting address
+// as used by the address index.
+//
+// The serialized value format is:
+//
+//   <votes><missed><expired><total latency>
+//
+//   Field           Type      Size
+//   votes           uint32    4
+//   missed          uint32    4
+//   expired         uint32    4
+//   total latency   uint64    8
+//
+// Votes is the number of votes cast by tickets of the address, and missed and
+// expired are the number of revoked tickets of the address which respectively
+// missed their vote or expired.  The total latency is the sum of the number of
+// key blocks between each ticket maturing and the key block it voted on.
+// -----------------------------------------------------------------------------
+
+// VoteStats houses the vote statistics of a voting address.
+type VoteStats struct {
+	// Votes is the number of votes cast by the tickets of the address.
+	Votes uint32
+
+	// Missed and Expired are the number of revoked tickets of the address
+	// that missed their vote or expired before being selected to vote.
+	// Tickets are only accounted for once they are revoked.
+	Missed  uint32
+	Expired uint32
+
+	// TotalLatency is the total number of key blocks between each voting
+	// ticket maturing and the key block it voted on.
+	TotalLatency uint64
+}
+
+// InclusionRatio returns the fraction of the tickets of the address that
+// voted out of all of its tickets which either voted or were revoked.  It is
+// zero when there are no such tickets.
+func (s *VoteStats) InclusionRatio() float64 {
+	total := s.Votes + s.Missed + s.Expired
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Votes) / float64(total)
+}
+
+// AverageLatency returns the average number of key blocks between a ticket of
+// the address maturing and the key block it voted on.  It is zero when there
+// are no votes.
+func (s *VoteStats) AverageLatency() float64 {
+	if s.Votes == 0 {
+		return 0
+	}
+	return float64(s.TotalLatency) / float64(s.Votes)
+}
+
+// serializeVoteStats returns the serialized vote statistics.
+func serializeVoteStats(stats *VoteStats) []byte {
+	serialized := make([]byte, voteStatsEntrySize)
+	byteOrder.PutUint32(serialized[0:4], stats.Votes)
+	byteOrder.PutUint32(serialized[4:8], stats.Missed)
+	byteOrder.PutUint32(serialized[8:12], stats.Expired)
+	byteOrder.PutUint64(serialized[12:20], stats.TotalLatency)
+	return serialized
+}
+
+// deserializeVoteStats decodes the passed serialized vote statistics.
+func deserializeVoteStats(serialized []byte) (*VoteStats, error) {
+	if len(serialized) != voteStatsEntrySize {
+		return nil, errDeserialize(fmt.Sprintf("unexpected vote stats "+
+			"entry size %d", len(serialized)))
+	}
+	return &VoteStats{
+		Votes:        byteOrder.Uint32(serialized[0:4]),
+		Missed:       byteOrder.Uint32(serialized[4:8]),
+		Expired:      byteOrder.Uint32(serialized[8:12]),
+		TotalLatency: byteOrder.Uint64(serialized[12:20]),
+	}, nil
+}
+
+// dbFetchVoteStats uses an existing database transaction to fetch the vote
+// statistics of the passed address key.  Empty statistics are returned when
+// there is no entry for the address.
+func dbFetchVoteStats(dbTx database.Tx, addrKey [addrKeySize]byte) (*VoteStats, error) {
+	bucket := dbTx.Metadata().Bucket(voteStatsIndexKey)
+	serialized := bucket.Get(addrKey[:])
+	if serialized == nil {
+		return &VoteStats{}, nil
+	}
+	return deserializeVoteStats(serialized)
+}
+
+// dbPutVoteStats uses an existing database transaction to store the vote
+// statistics of the passed address key.  The entry is removed when all of the
+// statistics are zero.
+func dbPutVoteStats(dbTx database.Tx, addrKey [addrKeySize]byte, stats *VoteStats) error {
+	bucket := dbTx.Metadata().Bucket(voteStatsIndexKey)
+	if *stats == (VoteStats{}) {
+		return bucket.Delete(addrKey[:])
+	}
+	return bucket.Put(addrKey[:], serializeVoteStats(stats))
+}
+
+// VoteStatsIndex implements an index of the vote statistics of every voting
+// address, which allows stakers to check how reliably a stake pool votes with
+// their tickets.
+type VoteStatsIndex struct {
+	db          database.DB
+	chainParams *chaincfg.Params
+}
+
+// Ensure the VoteStatsIndex type implements the Indexer interface.
+var _ Indexer = (*VoteStatsIndex)(nil)
+
+// Ensure the VoteStatsIndex type implements the NeedsInputser interface.
+var _ NeedsInputser = (*VoteStatsIndex)(nil)
+
+// NeedsInputs signals that the index requires the referenced inputs in order
+// to properly create the index.  The tickets spent by the votes and
+// revocations of a block are needed to determine the voting addresses.
+//
+// This implements the NeedsInputser interface.
+func (idx *VoteStatsIndex) NeedsInputs() bool {
+	return true
+}
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *VoteStatsIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *VoteStatsIndex) Key() []byte {
+	return voteStatsIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *VoteStatsIndex) Name() string {
+	return voteStatsIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs to be
+// created for the first time.  It creates the bucket for the vote stats index.
+//
+// This is part of the Indexer interface.
+func (idx *VoteStatsIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(voteStatsIndexKey)
+	return err
+}
+
+// ticketVotingAddrKey returns the address key of the voting address of the
+// ticket spent by the passed input of a transaction in the passed block along
+// with the key height of the block the ticket was mined in.  False is
+// returned when the ticket is not in the view or does not commit to a
+// supported voting address.
+func (idx *VoteStatsIndex) ticketVotingAddrKey(dbTx database.Tx, tx *hcashutil.Tx, inIdx int, block *hcashutil.Block, view *blockchain.UtxoViewpoint) ([addrKeySize]byte, int64, bool, error) {
+	// The view should always have the input since the index contract
+	// requires it, however, be safe and simply ignore any missing
+	// entries.
+	origin := &tx.MsgTx().TxIn[inIdx].PreviousOutPoint
+	entry := view.LookupEntry(&origin.Hash)
+	if entry == nil {
+		log.Warnf("Missing ticket %v for tx %v while indexing block "+
+			"%v (height %v)", origin.Hash, tx.Hash(), block.Hash(),
+			block.Height())
+		return [addrKeySize]byte{}, 0, false, nil
+	}
+
+	version := entry.ScriptVersionByIndex(origin.Index)
+	pkScript := entry.PkScriptByIndex(origin.Index)
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(version, pkScript,
+		idx.chainParams)
+	if err != nil || len(addrs) == 0 {
+		return [addrKeySize]byte{}, 0, false, nil
+	}
+	addrKey, err := addrToKey(addrs[0], idx.chainParams)
+	if err != nil {
+		return [addrKeySize]byte{}, 0, false, nil
+	}
+
+	header, err := blockchain.DBFetchHeaderByHeight(dbTx,
+		entry.BlockHeight())
+	if err != nil {
+		return [addrKeySize]byte{}, 0, false, err
+	}
+	return addrKey, int64(header.KeyHeight), true, nil
+}
+
+// indexBlock adds the votes and revocations of the passed block to the vote
+// statistics of the voting addresses of their tickets, or removes them when
+// the remove flag is set.
+func (idx *VoteStatsIndex) indexBlock(dbTx database.Tx, block *hcashutil.Block, view *blockchain.UtxoViewpoint, remove bool) error {
+	ticketMaturity := int64(idx.chainParams.TicketMaturity)
+	ticketExpiry := int64(idx.chainParams.TicketExpiry)
+	blockKeyHeight := int64(block.MsgBlock().Header.KeyHeight)
+
+	// Accumulate the changes per address first since multiple tickets of
+	// the same address commonly vote in the same block.
+	changes := make(map[[addrKeySize]byte]*VoteStats)
+	changeFor := func(addrKey [addrKeySize]byte) *VoteStats {
+		change, ok := changes[addrKey]
+		if !ok {
+			change = &VoteStats{}
+			changes[addrKey] = change
+		}
+		return change
+	}
+	for _, stx := range block.STransactions() {
+		msgTx := stx.MsgTx()
+		switch stake.DetermineTxType(msgTx) {
+		case stake.TxTypeSSGen:
+			// The first input of a vote is the stakebase and the
+			// second one spends the ticket.
+			addrKey, ticketKeyHeight, ok, err := idx.ticketVotingAddrKey(
+				dbTx, stx, 1, block, view)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			_, _, votedOnKeyHeight, err := stake.SSGenBlockVotedOn(msgTx)
+			if err != nil {
+				return err
+			}
+			latency := int64(votedOnKeyHeight) - ticketKeyHeight -
+				ticketMaturity
+			if latency < 0 {
+				latency = 0
+			}
+
+			change := changeFor(addrKey)
+			change.Votes++
+			change.TotalLatency += uint64(latency)
+
+		case stake.TxTypeSSRtx:
+			addrKey, ticketKeyHeight, ok, err := idx.ticketVotingAddrKey(
+				dbTx, stx, 0, block, view)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			change := changeFor(addrKey)
+			if blockKeyHeight >= ticketKeyHeight+ticketMaturity+
+				ticketExpiry {
+
+				change.Expired++
+			} else {
+				change.Missed++
+			}
+		}
+	}
+
+	for addrKey, change := range changes {
+		stats, err := dbFetchVoteStats(dbTx, addrKey)
+		if err != nil {
+			return err
+		}
+		if remove {
+			stats.Votes -= change.Votes
+			stats.Missed -= change.Missed
+			stats.Expired -= change.Expired
+			stats.TotalLatency -= change.TotalLatency
+		} else {
+			stats.Votes += change.Votes
+			stats.Missed += change.Missed
+			stats.Expired += change.Expired
+			stats.TotalLatency += change.TotalLatency
+		}
+		if err := dbPutVoteStats(dbTx, addrKey, stats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds the votes and revocations of
+// the block to the statistics of the voting addresses of their tickets.
+//
+// This is part of the Indexer interface.
+func (idx *VoteStatsIndex) ConnectBlock(dbTx database.Tx, block, parent *hcashutil.Block, view *blockchain.UtxoViewpoint) error {
+	return idx.indexBlock(dbTx, block, view, false)
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the votes and
+// revocations of the block from the statistics of the voting addresses of
+// their tickets.
+//
+// This is part of the Indexer interface.
+func (idx *VoteStatsIndex) DisconnectBlock(dbTx database.Tx, block, parent *hcashutil.Block, view *blockchain.UtxoViewpoint) error {
+	return idx.indexBlock(dbTx, block, view, true)
+}
+
+// VoteStats returns the vote statistics of the passed voting address.  Empty
+// statistics are returned when no ticket of the address voted or was revoked.
+//
+// This function is safe for concurrent access.
+func (idx *VoteStatsIndex) VoteStats(addr hcashutil.Address) (*VoteStats, error) {
+	addrKey, err := addrToKey(addr, idx.chainParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats *VoteStats
+	err = idx.db.View(func(dbTx database.Tx) error {
+		var err error
+		stats, err = dbFetchVoteStats(dbTx, addrKey)
+		return err
+	})
+	return stats, err
+}
+
+// NewVoteStatsIndex returns a new instance of an indexer that is used to
+// create a mapping of all voting addresses to their vote statistics.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewVoteStatsIndex(db database.DB, chainParams *chaincfg.Params) *VoteStatsIndex {
+	return &VoteStatsIndex{db: db, chainParams: chainParams}
+}
+
+// DropVoteStatsIndex drops the vote stats index from the provided database if
+// it exists.
+func DropVoteStatsIndex(db database.DB) error {
+	return dropIndex(db, voteStatsIndexKey, voteStatsIndexName)
+}
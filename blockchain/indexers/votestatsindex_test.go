@@ -0,0 +1,80 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestVoteStatsSerialization ensures vote statistics round trip through their
+// serialization and that the derived ratios are calculated as expected.
+func TestVoteStatsSerialization(t *testing.T) {
+	tests := []struct {
+		name      string
+		stats     VoteStats
+		inclusion float64
+		latency   float64
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "votes only",
+			stats: VoteStats{
+				Votes:        4,
+				TotalLatency: 10,
+			},
+			inclusion: 1,
+			latency:   2.5,
+		},
+		{
+			name: "votes and revocations",
+			stats: VoteStats{
+				Votes:        6,
+				Missed:       1,
+				Expired:      1,
+				TotalLatency: 1 << 40,
+			},
+			inclusion: 0.75,
+			latency:   float64(1<<40) / 6,
+		},
+		{
+			name: "revocations only",
+			stats: VoteStats{
+				Missed:  2,
+				Expired: 3,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		serialized := serializeVoteStats(&test.stats)
+		stats, err := deserializeVoteStats(serialized)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(*stats, test.stats) {
+			t.Errorf("%s: got %+v, want %+v", test.name, *stats,
+				test.stats)
+			continue
+		}
+		if got := stats.InclusionRatio(); got != test.inclusion {
+			t.Errorf("%s: got inclusion ratio %v, want %v",
+				test.name, got, test.inclusion)
+		}
+		if got := stats.AverageLatency(); got != test.latency {
+			t.Errorf("%s: got average latency %v, want %v",
+				test.name, got, test.latency)
+		}
+	}
+
+	// Ensure entries of the wrong size are rejected.
+	_, err := deserializeVoteStats(make([]byte, voteStatsEntrySize-1))
+	if !isDeserializeErr(err) {
+		t.Errorf("unexpected error for short entry: %v", err)
+	}
+}
@@ -36,4 +36,8 @@ var (
 	// UtxoSetBucketName is the name of the db bucket used to house the
 	// unspent transaction output set.
 	UtxoSetBucketName = []byte("utxoset")
+
+	// UtxoSetStatsKeyName is the name of the db key used to store the
+	// statistics of the unspent transaction output set.
+	UtxoSetStatsKeyName = []byte("utxosetstats")
 )
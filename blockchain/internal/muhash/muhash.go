@@ -0,0 +1,158 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package muhash implements an incremental multiset hash which is based on
+// multiplication in the multiplicative group of integers modulo a 3072-bit
+// safe prime.
+//
+// Each element of the set is mapped to a number in the group and the set is
+// represented by the product of those numbers, so elements can be added and
+// removed in any order and the hash of the same set is always the same.  In
+// order to avoid a modular inversion for every removal, the numbers of removed
+// elements are multiplied into a separate denominator which is only divided
+// out when the hash is finalized.
+package muhash
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+)
+
+const (
+	// ElementSize is the size in bytes of the numbers the elements of the
+	// set are mapped to.
+	ElementSize = 384
+
+	// SerializedSize is the size in bytes of a serialized MuHash.
+	SerializedSize = 2 * ElementSize
+
+	// HashSize is the size in bytes of a finalized hash.
+	HashSize = sha256.Size
+)
+
+// primeDiff is the difference between 2^3072 and the modulus.
+const primeDiff = 1103717
+
+// prime is the modulus of the group, 2^3072 - 1103717, which is the largest
+// 3072-bit safe prime.
+var prime = func() *big.Int {
+	p := new(big.Int).Lsh(big.NewInt(1), ElementSize*8)
+	return p.Sub(p, big.NewInt(primeDiff))
+}()
+
+// ErrInvalidSerialization is returned by Deserialize when the passed bytes are
+// not a serialized MuHash.
+var ErrInvalidSerialization = errors.New("invalid serialized muhash")
+
+// MuHash is an incremental hash of a multiset.  The zero value is not usable;
+// use New to create an empty set.
+type MuHash struct {
+	numerator   big.Int
+	denominator big.Int
+}
+
+// New returns a new MuHash of the empty set.
+func New() *MuHash {
+	var h MuHash
+	h.numerator.SetInt64(1)
+	h.denominator.SetInt64(1)
+	return &h
+}
+
+// element maps the passed data to a number in the group.  The data is hashed
+// and the digest is expanded to the size of the group with SHA-512 in counter
+// mode.
+func element(data []byte) *big.Int {
+	seed := sha256.Sum256(data)
+	var expanded [ElementSize]byte
+	var input [sha256.Size + 1]byte
+	copy(input[:], seed[:])
+	for i := 0; i*sha512.Size < ElementSize; i++ {
+		input[sha256.Size] = byte(i)
+		digest := sha512.Sum512(input[:])
+		copy(expanded[i*sha512.Size:], digest[:])
+	}
+
+	e := new(big.Int).SetBytes(expanded[:])
+	if e.Cmp(prime) >= 0 {
+		e.Sub(e, prime)
+	}
+	return e
+}
+
+// Add adds the passed data to the set.
+func (h *MuHash) Add(data []byte) {
+	h.numerator.Mul(&h.numerator, element(data))
+	h.numerator.Mod(&h.numerator, prime)
+}
+
+// Remove removes the passed data from the set.  Removing data which is not in
+// the set results in a hash that does not match any set, so callers must only
+// remove data they previously added.
+func (h *MuHash) Remove(data []byte) {
+	h.denominator.Mul(&h.denominator, element(data))
+	h.denominator.Mod(&h.denominator, prime)
+}
+
+// Combine adds all of the elements of the passed set to the set.
+func (h *MuHash) Combine(other *MuHash) {
+	h.numerator.Mul(&h.numerator, &other.numerator)
+	h.numerator.Mod(&h.numerator, prime)
+	h.denominator.Mul(&h.denominator, &other.denominator)
+	h.denominator.Mod(&h.denominator, prime)
+}
+
+// normalize divides the denominator out of the numerator.
+func (h *MuHash) normalize() {
+	if h.denominator.Cmp(big.NewInt(1)) == 0 {
+		return
+	}
+	inverse := new(big.Int).ModInverse(&h.denominator, prime)
+	h.numerator.Mul(&h.numerator, inverse)
+	h.numerator.Mod(&h.numerator, prime)
+	h.denominator.SetInt64(1)
+}
+
+// putNumber serializes the passed number in the group into the passed element
+// sized slice as a big-endian integer.
+func putNumber(target []byte, n *big.Int) {
+	b := n.Bytes()
+	copy(target[ElementSize-len(b):], b)
+}
+
+// Finalize returns the hash of the set.
+func (h *MuHash) Finalize() [HashSize]byte {
+	h.normalize()
+	var serialized [ElementSize]byte
+	putNumber(serialized[:], &h.numerator)
+	return sha256.Sum256(serialized[:])
+}
+
+// Serialize returns the state of the set so it can be restored with
+// Deserialize.
+func (h *MuHash) Serialize() []byte {
+	serialized := make([]byte, SerializedSize)
+	putNumber(serialized[:ElementSize], &h.numerator)
+	putNumber(serialized[ElementSize:], &h.denominator)
+	return serialized
+}
+
+// Deserialize restores a set from the passed state returned by Serialize.
+func Deserialize(serialized []byte) (*MuHash, error) {
+	if len(serialized) != SerializedSize {
+		return nil, ErrInvalidSerialization
+	}
+
+	var h MuHash
+	h.numerator.SetBytes(serialized[:ElementSize])
+	h.denominator.SetBytes(serialized[ElementSize:])
+	if h.numerator.Sign() == 0 || h.numerator.Cmp(prime) >= 0 ||
+		h.denominator.Sign() == 0 || h.denominator.Cmp(prime) >= 0 {
+
+		return nil, ErrInvalidSerialization
+	}
+	return &h, nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package muhash
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestMuHashOrder ensures the hash of a set does not depend on the order its
+// elements are added and removed in.
+func TestMuHashOrder(t *testing.T) {
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+
+	h1 := New()
+	h1.Add(a)
+	h1.Add(b)
+	h1.Add(c)
+	h1.Remove(b)
+
+	h2 := New()
+	h2.Add(c)
+	h2.Add(a)
+
+	h3 := New()
+	h3.Remove(b)
+	h3.Add(c)
+	h3.Add(b)
+	h3.Add(a)
+
+	want := h2.Finalize()
+	if got := h1.Finalize(); got != want {
+		t.Fatalf("hash depends on add order: got %x, want %x", got, want)
+	}
+	if got := h3.Finalize(); got != want {
+		t.Fatalf("hash depends on remove order: got %x, want %x", got,
+			want)
+	}
+
+	// Removing all of the elements again must result in the hash of the
+	// empty set.
+	h1.Remove(a)
+	h1.Remove(c)
+	if got, want := h1.Finalize(), New().Finalize(); got != want {
+		t.Fatalf("hash of emptied set: got %x, want %x", got, want)
+	}
+
+	// Different sets must have different hashes, including multisets with
+	// repeated elements.
+	h4 := New()
+	h4.Add(a)
+	h4.Add(a)
+	h4.Add(c)
+	if h4.Finalize() == want {
+		t.Fatal("hash of a multiset with a repeated element matches " +
+			"the set without it")
+	}
+}
+
+// TestMuHashCombine ensures combining two sets results in the hash of the
+// union of their elements.
+func TestMuHashCombine(t *testing.T) {
+	h1 := New()
+	h1.Add([]byte("a"))
+	h1.Remove([]byte("x"))
+	h2 := New()
+	h2.Add([]byte("b"))
+	h2.Add([]byte("x"))
+	h1.Combine(h2)
+
+	want := New()
+	want.Add([]byte("b"))
+	want.Add([]byte("a"))
+	if got, want := h1.Finalize(), want.Finalize(); got != want {
+		t.Fatalf("hash of combined sets: got %x, want %x", got, want)
+	}
+}
+
+// TestMuHashSerialize ensures a set can be restored from its serialization
+// and that invalid serializations are rejected.
+func TestMuHashSerialize(t *testing.T) {
+	h := New()
+	h.Add([]byte("a"))
+	h.Remove([]byte("b"))
+	serialized := h.Serialize()
+	if len(serialized) != SerializedSize {
+		t.Fatalf("serialized size: got %d, want %d", len(serialized),
+			SerializedSize)
+	}
+
+	restored, err := Deserialize(serialized)
+	if err != nil {
+		t.Fatalf("Deserialize: unexpected error: %v", err)
+	}
+	if !bytes.Equal(restored.Serialize(), serialized) {
+		t.Fatal("restored set serializes differently")
+	}
+	restored.Add([]byte("b"))
+	h.Add([]byte("b"))
+	if restored.Finalize() != h.Finalize() {
+		t.Fatal("restored set hashes differently")
+	}
+
+	tests := []struct {
+		name       string
+		serialized []byte
+	}{
+		{name: "short", serialized: serialized[:SerializedSize-1]},
+		{name: "zero numerator", serialized: make([]byte, SerializedSize)},
+		{name: "modulus", serialized: append(prime.Bytes(),
+			serialized[ElementSize:]...)},
+	}
+	for _, test := range tests {
+		if _, err := Deserialize(test.serialized); err != ErrInvalidSerialization {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				ErrInvalidSerialization)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/database"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+// MicroBlockInfo houses information about a main chain microblock anchored by
+// a key block.
+type MicroBlockInfo struct {
+	Hash         chainhash.Hash
+	Height       int64
+	Timestamp    time.Time
+	NumTxns      int
+	NumStakeTxns int
+}
+
+// KeyBlockInfo houses information about a main chain key block along with the
+// microblocks it anchors, which are the blocks following it up to the next key
+// block.
+type KeyBlockInfo struct {
+	Hash         chainhash.Hash
+	Height       int64
+	KeyHeight    int64
+	Timestamp    time.Time
+	NumTxns      int
+	NumStakeTxns int
+	MicroBlocks  []MicroBlockInfo
+
+	// NextKeyBlock is the next key block in the main chain, or nil when
+	// the key block is the most recent one.
+	NextKeyBlock *KeyBlockInfo
+}
+
+// isKeyBlockHeader returns whether the passed header is the header of a key
+// block, which is a block whose hash satisfies its proof of work target.
+func isKeyBlockHeader(header *wire.BlockHeader) bool {
+	hash := header.BlockHash()
+	return standalone.HashToBig(&hash).Cmp(
+		standalone.CompactToBig(header.Bits)) <= 0
+}
+
+// KeyBlockInfo returns information about the main chain key block with the
+// passed hash along with the microblocks it anchors.  The hash may also
+// identify a main chain microblock, in which case the information about the
+// key block anchoring it is returned.  An error is returned when the block is
+// not in the main chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) KeyBlockInfo(hash *chainhash.Hash) (*KeyBlockInfo, error) {
+	var info *KeyBlockInfo
+	err := b.db.View(func(dbTx database.Tx) error {
+		height, err := dbFetchHeightByHash(dbTx, hash)
+		if err != nil {
+			return err
+		}
+
+		// Find the key block anchoring the block when it is a
+		// microblock.
+		header, err := dbFetchHeaderByHeight(dbTx, height)
+		if err != nil {
+			return err
+		}
+		for height > 0 && !isKeyBlockHeader(header) {
+			height--
+			header, err = dbFetchHeaderByHeight(dbTx, height)
+			if err != nil {
+				return err
+			}
+		}
+
+		block, err := dbFetchBlockByHeight(dbTx, height)
+		if err != nil {
+			return err
+		}
+		info = &KeyBlockInfo{
+			Hash:         *block.Hash(),
+			Height:       height,
+			KeyHeight:    int64(header.KeyHeight),
+			Timestamp:    header.Timestamp,
+			NumTxns:      len(block.MsgBlock().Transactions),
+			NumStakeTxns: len(block.MsgBlock().STransactions),
+		}
+
+		// Collect the microblocks following the key block until the
+		// next key block or the end of the main chain is reached.
+		for {
+			height++
+			block, err := dbFetchBlockByHeight(dbTx, height)
+			if isNotInMainChainErr(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			msgBlock := block.MsgBlock()
+			if isKeyBlockHeader(&msgBlock.Header) {
+				info.NextKeyBlock = &KeyBlockInfo{
+					Hash:         *block.Hash(),
+					Height:       height,
+					KeyHeight:    int64(msgBlock.Header.KeyHeight),
+					Timestamp:    msgBlock.Header.Timestamp,
+					NumTxns:      len(msgBlock.Transactions),
+					NumStakeTxns: len(msgBlock.STransactions),
+				}
+				return nil
+			}
+			info.MicroBlocks = append(info.MicroBlocks, MicroBlockInfo{
+				Hash:         *block.Hash(),
+				Height:       height,
+				Timestamp:    msgBlock.Header.Timestamp,
+				NumTxns:      len(msgBlock.Transactions),
+				NumStakeTxns: len(msgBlock.STransactions),
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// KeyBlockWork houses the identity of a main chain key block along with the
+// total amount of proof of work contained in the main chain up to and including
+// it.
+type KeyBlockWork struct {
+	Hash      chainhash.Hash
+	Height    int64
+	KeyHeight int64
+	WorkSum   *big.Int
+}
+
+// MainChainKeyBlockWork returns the main chain key block with the passed key
+// height along with the cumulative proof of work of the main chain up to and
+// including it.  An error is returned when there is no such key block.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) MainChainKeyBlockWork(keyHeight int64) (*KeyBlockWork, error) {
+	// Hold the chain lock for the duration so the main chain can't change
+	// between looking up the key block and calculating its work sum.
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	if keyHeight < 0 || keyHeight > b.bestNode.keyHeight {
+		str := fmt.Sprintf("no key block at key height %d exists in the "+
+			"main chain", keyHeight)
+		return nil, errNotInMainChain(str)
+	}
+
+	// The key heights of main chain blocks never decrease, so the blocks
+	// with the requested key height, which include the key block, are found
+	// by searching for the bounds of the range they occupy.
+	height := int64(-1)
+	err := b.db.View(func(dbTx database.Tx) error {
+		var searchErr error
+		search := func(target int64) int64 {
+			return int64(sort.Search(int(b.bestNode.height+1), func(i int) bool {
+				if searchErr != nil {
+					return true
+				}
+				header, err := dbFetchHeaderByHeight(dbTx, int64(i))
+				if err != nil {
+					searchErr = err
+					return true
+				}
+				return int64(header.KeyHeight) >= target
+			}))
+		}
+		start, end := search(keyHeight), search(keyHeight+1)
+		if searchErr != nil {
+			return searchErr
+		}
+
+		for i := start; i < end; i++ {
+			header, err := dbFetchHeaderByHeight(dbTx, i)
+			if err != nil {
+				return err
+			}
+			if isKeyBlockHeader(header) {
+				height = i
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if height < 0 {
+		str := fmt.Sprintf("no key block at key height %d exists in the "+
+			"main chain", keyHeight)
+		return nil, errNotInMainChain(str)
+	}
+
+	node, err := b.ancestorNode(b.bestNode, height)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		str := fmt.Sprintf("no block at height %d exists in the main "+
+			"chain", height)
+		return nil, errNotInMainChain(str)
+	}
+
+	return &KeyBlockWork{
+		Hash:      node.hash,
+		Height:    node.height,
+		KeyHeight: node.keyHeight,
+		WorkSum:   new(big.Int).Set(node.workSum),
+	}, nil
+}
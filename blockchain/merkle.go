@@ -7,9 +7,11 @@
 package blockchain
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
 	"github.com/HcashOrg/hcashutil"
 )
 
@@ -143,3 +145,127 @@ func BuildMerkleTreeStore(transactions []*hcashutil.Tx, compressed bool) []*chai
 
 	return merkles
 }
+
+// MerkleProof houses the merkle branch that proves a transaction is included
+// in one of the transaction trees of a block.
+type MerkleProof struct {
+	// Tree is the transaction tree the transaction is located in.
+	Tree int8
+
+	// Index is the position of the transaction hash among the leaves of
+	// the merkle tree.  It differs from the index of the transaction in
+	// the block when the tree has additional leaves.
+	Index uint32
+
+	// Branch houses the sibling of each node on the path from the leaf of
+	// the transaction to the merkle root, starting with the leaf level.
+	Branch []chainhash.Hash
+}
+
+// merkleBranch returns the merkle branch for the leaf at the passed index of
+// a merkle tree store as created by BuildMerkleTreeStore.  A node without a
+// right sibling is its own sibling since its parent is calculated by hashing
+// the node with itself.
+func merkleBranch(merkles []*chainhash.Hash, leafIndex int) []chainhash.Hash {
+	var branch []chainhash.Hash
+	levelOffset := 0
+	for levelSize := (len(merkles) + 1) / 2; levelSize > 1; levelSize /= 2 {
+		sibling := merkles[levelOffset+(leafIndex^1)]
+		if sibling == nil {
+			sibling = merkles[levelOffset+leafIndex]
+		}
+		branch = append(branch, *sibling)
+
+		levelOffset += levelSize
+		leafIndex /= 2
+	}
+	return branch
+}
+
+// BuildMerkleProof returns a proof that the transaction at the passed index of
+// the passed transaction tree is included in the block.  The proof commits to
+// the merkle root of the regular transaction tree or the stake root of the
+// block header depending on the tree.
+//
+// Since only key blocks commit to the whole set of regular transactions, the
+// regular tree of other blocks has the commitment of the coinbase as an
+// additional first leaf.  The stake root is only validated for key blocks, so
+// an error is returned when a stake tree proof is requested for any other
+// block.
+func BuildMerkleProof(block *hcashutil.Block, tree int8, txIndex int) (*MerkleProof, error) {
+	isKeyBlock := auditKeyBlock(&block.MsgBlock().Header)
+
+	var txns []*hcashutil.Tx
+	var compressed bool
+	switch tree {
+	case wire.TxTreeRegular:
+		txns = block.Transactions()
+		compressed = !isKeyBlock
+	case wire.TxTreeStake:
+		if !isKeyBlock {
+			return nil, fmt.Errorf("block %v is not a key block and "+
+				"does not commit to its stake tree", block.Hash())
+		}
+		txns = block.STransactions()
+	default:
+		return nil, fmt.Errorf("invalid transaction tree %d", tree)
+	}
+	if txIndex < 0 || txIndex >= len(txns) {
+		return nil, fmt.Errorf("transaction index %d is out of range "+
+			"for a tree of %d transactions", txIndex, len(txns))
+	}
+
+	merkles := BuildMerkleTreeStore(txns, compressed)
+	leafIndex := txIndex
+	if compressed {
+		leafIndex++
+	}
+	if leafIndex >= len(merkles) || merkles[leafIndex] == nil {
+		return nil, fmt.Errorf("unable to build the merkle tree of "+
+			"block %v", block.Hash())
+	}
+
+	return &MerkleProof{
+		Tree:   tree,
+		Index:  uint32(leafIndex),
+		Branch: merkleBranch(merkles, leafIndex),
+	}, nil
+}
+
+// VerifyMerkleProof returns whether the passed proof shows that the transaction
+// is included in the block with the passed header.  The transaction is
+// identified by its full hash, which commits to its witness data, as is done
+// when building the merkle trees of the block.
+//
+// Proofs for the stake tree are only accepted for key blocks, since the stake
+// root of any other block is not validated.
+func VerifyMerkleProof(header *wire.BlockHeader, tx *wire.MsgTx, proof *MerkleProof) bool {
+	var root *chainhash.Hash
+	switch proof.Tree {
+	case wire.TxTreeRegular:
+		root = &header.MerkleRoot
+	case wire.TxTreeStake:
+		if !auditKeyBlock(header) {
+			return false
+		}
+		root = &header.StakeRoot
+	default:
+		return false
+	}
+
+	hash := tx.TxHashFull()
+	node := &hash
+	index := proof.Index
+	for i := range proof.Branch {
+		if index&1 == 0 {
+			node = HashMerkleBranches(node, &proof.Branch[i])
+		} else {
+			node = HashMerkleBranches(&proof.Branch[i], node)
+		}
+		index >>= 1
+	}
+
+	// Every bit of the index must have been consumed by the branch so the
+	// same proof can't be claimed for multiple positions.
+	return index == 0 && node.IsEqual(root)
+}
@@ -5,7 +5,142 @@
 
 package blockchain_test
 
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
 // TODO Make tests for merkle root calculation. Merkle root calculation and
 // corruption is already well tested in the blockchain error unit tests and
 // reorganization unit tests, but it'd be nice to have a specific test for
 // these functions and their error paths.
+
+// merkleTestTx returns a unique transaction for use in the merkle proof tests.
+// The first output script is long enough to serve as the commitment of a
+// coinbase in a compressed merkle tree.
+func merkleTestTx(id byte) *wire.MsgTx {
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: uint32(id)}, []byte{id}))
+	pkScript := make([]byte, 38)
+	for i := range pkScript {
+		pkScript[i] = id + byte(i)
+	}
+	tx.AddTxOut(wire.NewTxOut(int64(id), pkScript))
+	tx.AddTxOut(wire.NewTxOut(0, []byte{id}))
+	return tx
+}
+
+// merkleTestBlock returns a block with the passed number of regular and stake
+// transactions whose merkle roots commit to them.  The block is a key block
+// when requested.
+func merkleTestBlock(numRegular, numStake int, keyBlock bool) *hcashutil.Block {
+	msgBlock := &wire.MsgBlock{}
+	for i := 0; i < numRegular; i++ {
+		msgBlock.AddTransaction(merkleTestTx(byte(i)))
+	}
+	for i := 0; i < numStake; i++ {
+		msgBlock.AddSTransaction(merkleTestTx(byte(0x80 + i)))
+	}
+
+	// Use the easiest possible target for key blocks and an unreachable
+	// one otherwise, and search for a nonce that results in the requested
+	// block type.
+	msgBlock.Header.Bits = 0x01010000
+	if keyBlock {
+		msgBlock.Header.Bits = 0x207fffff
+	}
+	target := standalone.CompactToBig(msgBlock.Header.Bits)
+	for {
+		block := hcashutil.NewBlock(msgBlock)
+		merkles := blockchain.BuildMerkleTreeStore(block.Transactions(),
+			!keyBlock)
+		merklesStake := blockchain.BuildMerkleTreeStore(block.STransactions(),
+			false)
+		msgBlock.Header.MerkleRoot = *merkles[len(merkles)-1]
+		msgBlock.Header.StakeRoot = *merklesStake[len(merklesStake)-1]
+
+		hash := msgBlock.BlockHash()
+		isKeyBlock := standalone.HashToBig(&hash).Cmp(target) <= 0
+		if isKeyBlock == keyBlock {
+			return hcashutil.NewBlock(msgBlock)
+		}
+		msgBlock.Header.Nonce++
+	}
+}
+
+// TestMerkleProofs ensures merkle proofs can be built and verified for the
+// transactions of both transaction trees.
+func TestMerkleProofs(t *testing.T) {
+	tests := []struct {
+		name       string
+		numRegular int
+		numStake   int
+		keyBlock   bool
+	}{
+		{"key block single txns", 1, 1, true},
+		{"key block uneven trees", 5, 3, true},
+		{"key block even trees", 8, 4, true},
+		{"non-key block", 6, 0, false},
+	}
+
+	for _, test := range tests {
+		block := merkleTestBlock(test.numRegular, test.numStake,
+			test.keyBlock)
+		header := &block.MsgBlock().Header
+		trees := []struct {
+			tree int8
+			txns []*wire.MsgTx
+		}{
+			{wire.TxTreeRegular, block.MsgBlock().Transactions},
+			{wire.TxTreeStake, block.MsgBlock().STransactions},
+		}
+		for _, tree := range trees {
+			for i, tx := range tree.txns {
+				proof, err := blockchain.BuildMerkleProof(block,
+					tree.tree, i)
+				if err != nil {
+					t.Fatalf("%s: tree %d tx %d: unexpected "+
+						"error %v", test.name, tree.tree, i,
+						err)
+				}
+				if !blockchain.VerifyMerkleProof(header, tx, proof) {
+					t.Fatalf("%s: tree %d tx %d: proof did "+
+						"not verify", test.name, tree.tree, i)
+				}
+
+				// The proof must not verify for another
+				// transaction or the other tree.
+				other := merkleTestTx(0x7f)
+				if blockchain.VerifyMerkleProof(header, other, proof) {
+					t.Fatalf("%s: tree %d tx %d: proof "+
+						"verified for another transaction",
+						test.name, tree.tree, i)
+				}
+				proof.Tree ^= 1
+				if blockchain.VerifyMerkleProof(header, tx, proof) {
+					t.Fatalf("%s: tree %d tx %d: proof "+
+						"verified for the other tree",
+						test.name, tree.tree, i)
+				}
+			}
+		}
+
+		_, err := blockchain.BuildMerkleProof(block, wire.TxTreeRegular,
+			test.numRegular)
+		if err == nil {
+			t.Fatalf("%s: expected error for out of range index",
+				test.name)
+		}
+	}
+
+	// Stake tree proofs are refused for blocks that are not key blocks.
+	block := merkleTestBlock(2, 1, false)
+	_, err := blockchain.BuildMerkleProof(block, wire.TxTreeStake, 0)
+	if err == nil {
+		t.Fatal("expected error for stake tree proof of non-key block")
+	}
+}
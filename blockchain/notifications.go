@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
 	"github.com/HcashOrg/hcashutil"
 )
 
@@ -75,11 +76,77 @@ type BlockAcceptedNtfnsData struct {
 
 // ReorganizationNtfnsData is the structure for data indicating information
 // about a reorganization.
+//
+// DetachedBlocks houses the hashes of the blocks disconnected from the main
+// chain starting with the former best block, while AttachedBlocks houses the
+// hashes of the blocks connected to the main chain in the order they are
+// connected.  DetachedTxns houses the hashes of the transactions of both trees
+// of the detached blocks which are not part of any attached block, and thus no
+// longer mined, while AttachedTxns houses the hashes of the transactions of the
+// attached blocks which were not part of any detached block.
 type ReorganizationNtfnsData struct {
 	OldHash   chainhash.Hash
 	OldHeight int64
 	NewHash   chainhash.Hash
 	NewHeight int64
+
+	DetachedBlocks []chainhash.Hash
+	AttachedBlocks []chainhash.Hash
+	DetachedTxns   []chainhash.Hash
+	AttachedTxns   []chainhash.Hash
+}
+
+// newReorganizationNtfnsData returns the reorganization notification data for
+// a reorganization from the passed former best block to the passed new best
+// block which disconnects and connects the passed blocks.
+func newReorganizationNtfnsData(oldHash chainhash.Hash, oldHeight int64, newHash chainhash.Hash, newHeight int64, detachBlocks, attachBlocks []*hcashutil.Block) *ReorganizationNtfnsData {
+	// blockTxns returns the hashes of the transactions of both trees of
+	// the passed blocks in order along with a set of them.
+	blockTxns := func(blocks []*hcashutil.Block) ([]chainhash.Hash, map[chainhash.Hash]struct{}) {
+		var hashes []chainhash.Hash
+		set := make(map[chainhash.Hash]struct{})
+		for _, block := range blocks {
+			msgBlock := block.MsgBlock()
+			for _, txns := range [][]*wire.MsgTx{msgBlock.Transactions,
+				msgBlock.STransactions} {
+
+				for _, tx := range txns {
+					hash := tx.TxHash()
+					hashes = append(hashes, hash)
+					set[hash] = struct{}{}
+				}
+			}
+		}
+		return hashes, set
+	}
+	detachedTxns, detachedSet := blockTxns(detachBlocks)
+	attachedTxns, attachedSet := blockTxns(attachBlocks)
+
+	rd := &ReorganizationNtfnsData{
+		OldHash:        oldHash,
+		OldHeight:      oldHeight,
+		NewHash:        newHash,
+		NewHeight:      newHeight,
+		DetachedBlocks: make([]chainhash.Hash, 0, len(detachBlocks)),
+		AttachedBlocks: make([]chainhash.Hash, 0, len(attachBlocks)),
+	}
+	for _, block := range detachBlocks {
+		rd.DetachedBlocks = append(rd.DetachedBlocks, *block.Hash())
+	}
+	for _, block := range attachBlocks {
+		rd.AttachedBlocks = append(rd.AttachedBlocks, *block.Hash())
+	}
+	for _, hash := range detachedTxns {
+		if _, ok := attachedSet[hash]; !ok {
+			rd.DetachedTxns = append(rd.DetachedTxns, hash)
+		}
+	}
+	for _, hash := range attachedTxns {
+		if _, ok := detachedSet[hash]; !ok {
+			rd.AttachedTxns = append(rd.AttachedTxns, hash)
+		}
+	}
+	return rd
 }
 
 // TicketNotificationsData is the structure for new/spent/missed ticket
@@ -94,29 +161,81 @@ type TicketNotificationsData struct {
 }
 
 // Notification defines notification that is sent to the caller via the callback
-// function provided during the call to New and consists of a notification type
-// as well as associated data that depends on the type as follows:
-// 	- NTBlockAccepted:         *BlockAcceptedNtfnsData
-// 	- NTBlockConnected:        []*hcashutil.Block of len 2
-// 	- NTBlockDisconnected:     []*hcashutil.Block of len 2
-//  - NTReorganization:        *ReorganizationNtfnsData
-//  - NTSpentAndMissedTickets: *TicketNotificationsData
-//  - NTNewTickets:            *TicketNotificationsData
+// function provided during the call to New or registered via Subscribe and
+// consists of a notification type as well as associated data that depends on
+// the type as follows:
+//   - NTBlockAccepted:         *BlockAcceptedNtfnsData
+//   - NTBlockConnected:        []*hcashutil.Block of len 2
+//   - NTBlockDisconnected:     []*hcashutil.Block of len 2
+//   - NTReorganization:        *ReorganizationNtfnsData
+//   - NTSpentAndMissedTickets: *TicketNotificationsData
+//   - NTNewTickets:            *TicketNotificationsData
 type Notification struct {
 	Type NotificationType
 	Data interface{}
 }
 
-// sendNotification sends a notification with the passed type and data if the
-// caller requested notifications by providing a callback function in the call
-// to New.
+// notificationSubscriber houses a callback registered via Subscribe along with
+// the set of notification types it is interested in.  A nil set means the
+// callback is interested in all types.
+type notificationSubscriber struct {
+	callback NotificationCallback
+	types    map[NotificationType]struct{}
+}
+
+// wants returns whether the subscriber is interested in notifications of the
+// passed type.
+func (s *notificationSubscriber) wants(typ NotificationType) bool {
+	if s.types == nil {
+		return true
+	}
+	_, ok := s.types[typ]
+	return ok
+}
+
+// Subscribe registers the passed callback to be invoked for the notifications
+// of the passed types, such as NTBlockConnected, NTBlockDisconnected,
+// NTReorganization, or NTNewTickets.  The callback receives notifications of
+// all types when no types are passed.  Callbacks are invoked sequentially in
+// the order they were registered.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) Subscribe(callback NotificationCallback, types ...NotificationType) {
+	sub := &notificationSubscriber{callback: callback}
+	if len(types) > 0 {
+		sub.types = make(map[NotificationType]struct{}, len(types))
+		for _, typ := range types {
+			sub.types[typ] = struct{}{}
+		}
+	}
+
+	b.subscribersLock.Lock()
+	b.subscribers = append(b.subscribers, sub)
+	b.subscribersLock.Unlock()
+}
+
+// sendNotification sends a notification with the passed type and data to all
+// subscribers which are interested in notifications of the type.
 func (b *BlockChain) sendNotification(typ NotificationType, data interface{}) {
-	// Ignore it if the caller didn't request notifications.
-	if b.notifications == nil {
+	// Copy the interested subscribers so the callbacks may subscribe
+	// themselves without deadlocking.
+	var subscribers []*notificationSubscriber
+	b.subscribersLock.RLock()
+	for _, sub := range b.subscribers {
+		if sub.wants(typ) {
+			subscribers = append(subscribers, sub)
+		}
+	}
+	b.subscribersLock.RUnlock()
+
+	// Ignore it if nobody is interested in the notification.
+	if len(subscribers) == 0 {
 		return
 	}
 
 	// Generate and send the notification.
 	n := Notification{Type: typ, Data: data}
-	b.notifications(&n)
+	for _, sub := range subscribers {
+		sub.callback(&n)
+	}
 }
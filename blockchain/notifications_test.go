@@ -0,0 +1,113 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// TestReorganizationNtfnsData ensures the reorganization notification data
+// reports the detached and attached blocks and only the transactions which
+// are not part of both sides of the reorganization.
+func TestReorganizationNtfnsData(t *testing.T) {
+	// newTx returns a distinct transaction for the passed lock time.
+	newTx := func(lockTime uint32) *wire.MsgTx {
+		tx := wire.NewMsgTx()
+		tx.LockTime = lockTime
+		return tx
+	}
+	shared, detachedOnly, attachedOnly := newTx(1), newTx(2), newTx(3)
+	ticket := newTx(4)
+
+	detached := hcashutil.NewBlock(&wire.MsgBlock{
+		Header:        wire.BlockHeader{Nonce: 1},
+		Transactions:  []*wire.MsgTx{shared, detachedOnly},
+		STransactions: []*wire.MsgTx{ticket},
+	})
+	attached1 := hcashutil.NewBlock(&wire.MsgBlock{
+		Header:       wire.BlockHeader{Nonce: 2},
+		Transactions: []*wire.MsgTx{attachedOnly},
+	})
+	attached2 := hcashutil.NewBlock(&wire.MsgBlock{
+		Header:        wire.BlockHeader{Nonce: 3},
+		Transactions:  []*wire.MsgTx{shared},
+		STransactions: []*wire.MsgTx{ticket},
+	})
+
+	rd := newReorganizationNtfnsData(*detached.Hash(), 10,
+		*attached2.Hash(), 11, []*hcashutil.Block{detached},
+		[]*hcashutil.Block{attached1, attached2})
+
+	if rd.OldHash != *detached.Hash() || rd.OldHeight != 10 ||
+		rd.NewHash != *attached2.Hash() || rd.NewHeight != 11 {
+
+		t.Fatalf("unexpected best blocks %+v", rd)
+	}
+	wantDetachedBlocks := []chainhash.Hash{*detached.Hash()}
+	if !reflect.DeepEqual(rd.DetachedBlocks, wantDetachedBlocks) {
+		t.Errorf("got detached blocks %v, want %v", rd.DetachedBlocks,
+			wantDetachedBlocks)
+	}
+	wantAttachedBlocks := []chainhash.Hash{*attached1.Hash(),
+		*attached2.Hash()}
+	if !reflect.DeepEqual(rd.AttachedBlocks, wantAttachedBlocks) {
+		t.Errorf("got attached blocks %v, want %v", rd.AttachedBlocks,
+			wantAttachedBlocks)
+	}
+	wantDetachedTxns := []chainhash.Hash{detachedOnly.TxHash()}
+	if !reflect.DeepEqual(rd.DetachedTxns, wantDetachedTxns) {
+		t.Errorf("got detached transactions %v, want %v",
+			rd.DetachedTxns, wantDetachedTxns)
+	}
+	wantAttachedTxns := []chainhash.Hash{attachedOnly.TxHash()}
+	if !reflect.DeepEqual(rd.AttachedTxns, wantAttachedTxns) {
+		t.Errorf("got attached transactions %v, want %v",
+			rd.AttachedTxns, wantAttachedTxns)
+	}
+}
+
+// TestSubscribe ensures notifications are only delivered to the subscribers
+// which are interested in their type.
+func TestSubscribe(t *testing.T) {
+	var all, connected, reorgs []NotificationType
+	b := &BlockChain{}
+	b.Subscribe(func(n *Notification) {
+		all = append(all, n.Type)
+	})
+	b.Subscribe(func(n *Notification) {
+		connected = append(connected, n.Type)
+	}, NTBlockConnected, NTBlockDisconnected)
+	b.Subscribe(func(n *Notification) {
+		reorgs = append(reorgs, n.Type)
+	}, NTReorganization)
+
+	sent := []NotificationType{NTBlockAccepted, NTBlockConnected,
+		NTReorganization, NTBlockDisconnected, NTNewTickets}
+	for _, typ := range sent {
+		b.sendNotification(typ, nil)
+	}
+
+	tests := []struct {
+		name string
+		got  []NotificationType
+		want []NotificationType
+	}{
+		{"all", all, sent},
+		{"connected", connected, []NotificationType{NTBlockConnected,
+			NTBlockDisconnected}},
+		{"reorgs", reorgs, []NotificationType{NTReorganization}},
+	}
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("%s: got notifications %v, want %v", test.name,
+				test.got, test.want)
+		}
+	}
+}
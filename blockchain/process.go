@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
 	"github.com/HcashOrg/hcashd/wire"
@@ -93,9 +94,9 @@ func (b *BlockChain) prevKeyHashExists(prevHash, keyHash *chainhash.Hash) (bool,
 		blockHeader = prevBlockNode.header
 	}
 
-	blockHashBigInt := HashToBig(prevHash)
+	blockHashBigInt := standalone.HashToBig(prevHash)
 
-	if blockHashBigInt.Cmp(CompactToBig(blockHeader.Bits)) <= 0 {
+	if blockHashBigInt.Cmp(standalone.CompactToBig(blockHeader.Bits)) <= 0 {
 		if *prevHash != *keyHash {
 			return false, fmt.Errorf("block : %v  has an wrong preKeyHash: %v", *prevHash, keyHash)
 		}
@@ -247,9 +248,9 @@ func (b *BlockChain) ProcessBlock(block *hcashutil.Block, flags BehaviorFlags) (
 			// maximum adjustment allowed by the retarget rules.
 			duration := blockHeader.Timestamp.Sub(checkpointTime)
 
-			requiredTarget := CompactToBig(b.calcEasiestDifficulty(
+			requiredTarget := standalone.CompactToBig(b.calcEasiestDifficulty(
 				checkpointHeader.Bits, duration))
-			currentTarget := CompactToBig(blockHeader.Bits)
+			currentTarget := standalone.CompactToBig(blockHeader.Bits)
 			if currentTarget.Cmp(requiredTarget) > 0 {
 				str := fmt.Sprintf("block target difficulty of %064x "+
 					"is too low when compared to the previous "+
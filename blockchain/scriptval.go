@@ -6,10 +6,14 @@
 package blockchain
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"runtime"
+	"sync"
 
+	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/chaincfg/chainec"
 	"github.com/HcashOrg/hcashd/txscript"
 	"github.com/HcashOrg/hcashd/wire"
 	"github.com/HcashOrg/hcashutil"
@@ -20,177 +24,183 @@ type txValidateItem struct {
 	txInIndex int
 	txIn      *wire.TxIn
 	tx        *hcashutil.Tx
+
+	// sigAggregator, when set, collects the signatures of the input which
+	// the script engine defers instead of verifying them individually.
+	sigAggregator *blockSigAggregator
+}
+
+// DefaultSigVerifyConcurrency returns the default maximum number of goroutines
+// used to validate input scripts, which is based on the number of processor
+// cores.  Script validation is CPU bound, so using a few goroutines per core
+// keeps all cores busy while signature cache lookups and other short stalls
+// occur without making the system unresponsive under heavy load.
+func DefaultSigVerifyConcurrency() int {
+	concurrency := runtime.NumCPU() * 3
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return concurrency
 }
 
 // txValidator provides a type which asynchronously validates transaction
-// inputs.  It provides several channels for communication and a processing
-// function that is intended to be in run multiple goroutines.
+// inputs across a bounded pool of worker goroutines.  The first validation
+// error cancels the context shared by the workers so the remaining inputs are
+// not validated needlessly.
 type txValidator struct {
-	validateChan chan *txValidateItem
-	quitChan     chan struct{}
-	resultChan   chan error
-	utxoView     *UtxoViewpoint
-	flags        txscript.ScriptFlags
-	sigCache     *txscript.SigCache
+	utxoView    *UtxoViewpoint
+	flags       txscript.ScriptFlags
+	sigCache    *txscript.SigCache
+	concurrency int
+
+	// The following fields record the first validation error.  The error
+	// is protected by the once.
+	errOnce  sync.Once
+	firstErr error
+	cancel   context.CancelFunc
 }
 
-// sendResult sends the result of a script pair validation on the internal
-// result channel while respecting the quit channel.  The allows orderly
-// shutdown when the validation process is aborted early due to a validation
-// error in one of the other goroutines.
-func (v *txValidator) sendResult(result error) {
-	select {
-	case v.resultChan <- result:
-	case <-v.quitChan:
+// fail records the passed validation error when it is the first one and
+// cancels the remaining validation.  It is safe for concurrent access.
+func (v *txValidator) fail(err error) {
+	v.errOnce.Do(func() {
+		v.firstErr = err
+		v.cancel()
+	})
+}
+
+// validateItem validates the script pair of the passed transaction input.
+func (v *txValidator) validateItem(txVI *txValidateItem) error {
+	// Ensure the referenced input transaction is available.
+	txIn := txVI.txIn
+	originTxHash := &txIn.PreviousOutPoint.Hash
+	originTxIndex := txIn.PreviousOutPoint.Index
+	txEntry := v.utxoView.LookupEntry(originTxHash)
+	if txEntry == nil {
+		str := fmt.Sprintf("unable to find input transaction "+
+			"%v referenced from transaction %v", originTxHash,
+			txVI.tx.Hash())
+		return ruleError(ErrMissingTx, str)
+	}
+
+	// Ensure the referenced input transaction public key script is
+	// available.
+	pkScript := txEntry.PkScriptByIndex(originTxIndex)
+	if pkScript == nil {
+		str := fmt.Sprintf("unable to find unspent output %v script "+
+			"referenced from transaction %s:%d",
+			txIn.PreviousOutPoint, txVI.tx.Hash(), txVI.txInIndex)
+		return ruleError(ErrBadTxInput, str)
+	}
+
+	// Create a new script engine for the script pair.
+	sigScript := txIn.SignatureScript
+	version := txEntry.ScriptVersionByIndex(originTxIndex)
+	vm, err := txscript.NewEngine(pkScript, txVI.tx.MsgTx(),
+		txVI.txInIndex, v.flags, version, v.sigCache)
+	if err != nil {
+		str := fmt.Sprintf("failed to parse input "+
+			"%s:%d which references output %s:%d - "+
+			"%v (input script bytes %x, prev output "+
+			"script bytes %x)", txVI.tx.Hash(),
+			txVI.txInIndex, originTxHash,
+			originTxIndex, err, sigScript, pkScript)
+		return ruleError(ErrScriptMalformed, str)
 	}
+	if txVI.sigAggregator != nil {
+		vm.SetSigAggregator(txVI.sigAggregator)
+	}
+
+	// Execute the script pair.
+	if err := vm.Execute(); err != nil {
+		str := fmt.Sprintf("failed to validate input "+
+			"%s:%d which references output %s:%d - "+
+			"%v (input script bytes %x, prev output "+
+			"script bytes %x)", txVI.tx.Hash(),
+			txVI.txInIndex, originTxHash,
+			originTxIndex, err, sigScript, pkScript)
+		return ruleError(ErrScriptValidation, str)
+	}
+
+	return nil
 }
 
-// validateHandler consumes items to validate from the internal validate channel
-// and returns the result of the validation on the internal result channel. It
-// must be run as a goroutine.
-func (v *txValidator) validateHandler() {
-out:
+// validateHandler consumes items to validate from the passed channel until it
+// is closed or the passed context is canceled.  It must be run as a goroutine.
+func (v *txValidator) validateHandler(ctx context.Context, items <-chan *txValidateItem, wg *sync.WaitGroup) {
+	defer wg.Done()
 	for {
 		select {
-		case txVI := <-v.validateChan:
-			// Ensure the referenced input transaction is available.
-			txIn := txVI.txIn
-			originTxHash := &txIn.PreviousOutPoint.Hash
-			originTxIndex := txIn.PreviousOutPoint.Index
-			txEntry := v.utxoView.LookupEntry(originTxHash)
-			if txEntry == nil {
-				str := fmt.Sprintf("unable to find input "+
-					"transaction %v referenced from "+
-					"transaction %v", originTxHash,
-					txVI.tx.Hash())
-				err := ruleError(ErrMissingTx, str)
-				v.sendResult(err)
-				break out
+		case txVI, ok := <-items:
+			if !ok {
+				return
 			}
-
-			// Ensure the referenced input transaction public key
-			// script is available.
-			pkScript := txEntry.PkScriptByIndex(originTxIndex)
-			if pkScript == nil {
-				str := fmt.Sprintf("unable to find unspent "+
-					"output %v script referenced from "+
-					"transaction %s:%d",
-					txIn.PreviousOutPoint, txVI.tx.Hash(),
-					txVI.txInIndex)
-				err := ruleError(ErrBadTxInput, str)
-				v.sendResult(err)
-				break out
-			}
-
-			// Create a new script engine for the script pair.
-			sigScript := txIn.SignatureScript
-			
-			version := txEntry.ScriptVersionByIndex(originTxIndex)
-
-			vm, err := txscript.NewEngine(pkScript, txVI.tx.MsgTx(),
-				txVI.txInIndex, v.flags, version, v.sigCache)
-			if err != nil {
-				str := fmt.Sprintf("failed to parse input "+
-					"%s:%d which references output %s:%d - "+
-					"%v (input script bytes %x, prev output "+
-					"script bytes %x)", txVI.tx.Hash(),
-					txVI.txInIndex, originTxHash,
-					originTxIndex, err, sigScript, pkScript)
-				err := ruleError(ErrScriptMalformed, str)
-				v.sendResult(err)
-				break out
-			}
-
-			// Execute the script pair.
-			if err := vm.Execute(); err != nil {
-				str := fmt.Sprintf("failed to validate input "+
-					"%s:%d which references output %s:%d - "+
-					"%v (input script bytes %x, prev output "+
-					"script bytes %x)", txVI.tx.Hash(),
-					txVI.txInIndex, originTxHash,
-					originTxIndex, err, sigScript, pkScript)
-				err := ruleError(ErrScriptValidation, str)
-				v.sendResult(err)
-				break out
+			if err := v.validateItem(txVI); err != nil {
+				v.fail(err)
+				return
 			}
 
-			// Validation succeeded.
-			v.sendResult(nil)
-
-		case <-v.quitChan:
-			break out
+		case <-ctx.Done():
+			return
 		}
 	}
 }
 
 // Validate validates the scripts for all of the passed transaction inputs using
-// multiple goroutines.
+// multiple goroutines.  It returns the first validation error, if any, once
+// all of the goroutines have exited.
 func (v *txValidator) Validate(items []*txValidateItem) error {
 	if len(items) == 0 {
 		return nil
 	}
 
-	// Limit the number of goroutines to do script validation based on the
-	// number of processor cores.  This help ensure the system stays
-	// reasonably responsive under heavy load.
-	maxGoRoutines := runtime.NumCPU() * 3
-	if maxGoRoutines <= 0 {
-		maxGoRoutines = 1
+	// Limit the number of goroutines to do script validation to the
+	// configured concurrency.  There is no point in starting more
+	// goroutines than inputs.
+	numWorkers := v.concurrency
+	if numWorkers <= 0 {
+		numWorkers = DefaultSigVerifyConcurrency()
 	}
-	if maxGoRoutines > len(items) {
-		maxGoRoutines = len(items)
+	if numWorkers > len(items) {
+		numWorkers = len(items)
 	}
 
-	// Start up validation handlers that are used to asynchronously
-	// validate each transaction input.
-	for i := 0; i < maxGoRoutines; i++ {
-		go v.validateHandler()
+	// Start up the validation handlers and feed them the inputs until
+	// they are all validated or the validation is canceled due to an
+	// error in one of the handlers.
+	var ctx context.Context
+	ctx, v.cancel = context.WithCancel(context.Background())
+	defer v.cancel()
+	itemChan := make(chan *txValidateItem)
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go v.validateHandler(ctx, itemChan, &wg)
 	}
-
-	// Validate each of the inputs.  The quit channel is closed when any
-	// errors occur so all processing goroutines exit regardless of which
-	// input had the validation error.
-	numInputs := len(items)
-	currentItem := 0
-	processedItems := 0
-	for processedItems < numInputs {
-		// Only send items while there are still items that need to
-		// be processed.  The select statement will never select a nil
-		// channel.
-		var validateChan chan *txValidateItem
-		var item *txValidateItem
-		if currentItem < numInputs {
-			validateChan = v.validateChan
-			item = items[currentItem]
-		}
-
+out:
+	for _, item := range items {
 		select {
-		case validateChan <- item:
-			currentItem++
-
-		case err := <-v.resultChan:
-			processedItems++
-			if err != nil {
-				close(v.quitChan)
-				return err
-			}
+		case itemChan <- item:
+		case <-ctx.Done():
+			break out
 		}
 	}
+	close(itemChan)
+	wg.Wait()
 
-	close(v.quitChan)
-	return nil
+	return v.firstErr
 }
 
 // newTxValidator returns a new instance of txValidator to be used for
-// validating transaction scripts asynchronously.
-func newTxValidator(utxoView *UtxoViewpoint, flags txscript.ScriptFlags, sigCache *txscript.SigCache) *txValidator {
+// validating transaction scripts asynchronously with at most the passed number
+// of goroutines.  A concurrency of zero selects the default returned by
+// DefaultSigVerifyConcurrency.
+func newTxValidator(utxoView *UtxoViewpoint, flags txscript.ScriptFlags, sigCache *txscript.SigCache, concurrency int) *txValidator {
 	return &txValidator{
-		validateChan: make(chan *txValidateItem),
-		quitChan:     make(chan struct{}),
-		resultChan:   make(chan error),
-		utxoView:     utxoView,
-		sigCache:     sigCache,
-		flags:        flags,
+		utxoView:    utxoView,
+		sigCache:    sigCache,
+		flags:       flags,
+		concurrency: concurrency,
 	}
 }
 
@@ -216,15 +226,24 @@ func ValidateTransactionScripts(tx *hcashutil.Tx, utxoView *UtxoViewpoint, flags
 	}
 
 	// Validate all of the inputs.
-	return newTxValidator(utxoView, flags, sigCache).Validate(txValItems)
+	return newTxValidator(utxoView, flags, sigCache, 0).Validate(txValItems)
 
 }
 
 // checkBlockScripts executes and validates the scripts for all transactions in
-// the passed block using multiple goroutines.
+// the passed block using at most the passed number of goroutines, where zero
+// selects the default concurrency.
 // txTree = true is TxTreeRegular, txTree = false is TxTreeStake.
+//
+// The secp256k1 Schnorr signatures which the script engine defers are batch
+// verified once all of the scripts were executed instead of one by one.  The
+// result of the batch verification only differs from the individual one with
+// negligible probability.  When aggregateVotes is set, the signatures of the
+// votes of the stake tree of all of the suites which support aggregation,
+// including Ed25519, are verified together as well.
 func checkBlockScripts(block *hcashutil.Block, utxoView *UtxoViewpoint, txTree bool,
-	scriptFlags txscript.ScriptFlags, sigCache *txscript.SigCache) error {
+	scriptFlags txscript.ScriptFlags, sigCache *txscript.SigCache,
+	concurrency int, aggregateVotes bool) error {
 
 	// Collect all of the transaction inputs and required information for
 	// validation for all transactions in the block into a single slice.
@@ -242,7 +261,18 @@ func checkBlockScripts(block *hcashutil.Block, utxoView *UtxoViewpoint, txTree b
 		numInputs += len(tx.MsgTx().TxIn)
 	}
 	txValItems := make([]*txValidateItem, 0, numInputs)
+	batch := newBlockSigAggregator(chainec.ECTypeSecSchnorr)
+	var votes *blockSigAggregator
+	if aggregateVotes && !txTree {
+		votes = newBlockSigAggregator()
+	}
 	for _, tx := range txs {
+		aggregator := batch
+		if votes != nil {
+			if isVote, _ := stake.IsSSGen(tx.MsgTx()); isVote {
+				aggregator = votes
+			}
+		}
 		for txInIdx, txIn := range tx.MsgTx().TxIn {
 			// Skip coinbases.
 			if txIn.PreviousOutPoint.Index == math.MaxUint32 {
@@ -250,14 +280,55 @@ func checkBlockScripts(block *hcashutil.Block, utxoView *UtxoViewpoint, txTree b
 			}
 
 			txVI := &txValidateItem{
-				txInIndex: txInIdx,
-				txIn:      txIn,
-				tx:        tx,
+				txInIndex:     txInIdx,
+				txIn:          txIn,
+				tx:            tx,
+				sigAggregator: aggregator,
 			}
 			txValItems = append(txValItems, txVI)
 		}
 	}
 
 	// Validate all of the inputs.
-	return newTxValidator(utxoView, scriptFlags, sigCache).Validate(txValItems)
+	err := newTxValidator(utxoView, scriptFlags, sigCache,
+		concurrency).Validate(txValItems)
+	if err != nil {
+		return err
+	}
+	batchValid := batch.Verify()
+	votesValid := votes == nil || votes.Verify()
+	if batchValid && votesValid {
+		return nil
+	}
+
+	// At least one of the batch verified signatures is invalid, so
+	// validate the inputs of the failed batches again with their
+	// signatures verified one by one to find out which one.
+	var numSigs int
+	failedItems := make([]*txValidateItem, 0, len(txValItems))
+	for _, txVI := range txValItems {
+		if (txVI.sigAggregator == batch && !batchValid) ||
+			(txVI.sigAggregator == votes && !votesValid) {
+
+			failedItems = append(failedItems, &txValidateItem{
+				txInIndex: txVI.txInIndex,
+				txIn:      txVI.txIn,
+				tx:        txVI.tx,
+			})
+		}
+	}
+	if !batchValid {
+		numSigs += batch.Len()
+	}
+	if !votesValid {
+		numSigs += votes.Len()
+	}
+	err = newTxValidator(utxoView, scriptFlags, sigCache,
+		concurrency).Validate(failedItems)
+	if err != nil {
+		return err
+	}
+	str := fmt.Sprintf("block %v contains an invalid batch of %d "+
+		"signatures", block.Hash(), numSigs)
+	return ruleError(ErrScriptValidation, str)
 }
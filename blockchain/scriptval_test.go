@@ -9,6 +9,11 @@ package blockchain_test
 //	"runtime"
 import (
 	"testing"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
 )
 
 //	"github.com/HcashOrg/hcashd/blockchain"
@@ -55,3 +60,38 @@ func TestCheckBlockScripts(t *testing.T) {
 		}
 	*/
 }
+
+// TestValidateTransactionScriptsCancel ensures the validation of the inputs of
+// a transaction stops at the first invalid input and reports its error without
+// leaving any validation goroutines blocked, and that transactions without any
+// inputs to validate are accepted.
+func TestValidateTransactionScriptsCancel(t *testing.T) {
+	// Create a transaction with more inputs than validation goroutines
+	// that all reference outputs missing from the empty view.
+	numInputs := blockchain.DefaultSigVerifyConcurrency()*2 + 1
+	msgTx := wire.NewMsgTx()
+	for i := 0; i < numInputs; i++ {
+		var hash chainhash.Hash
+		hash[0], hash[1] = byte(i), byte(i>>8)
+		prevOut := wire.NewOutPoint(&hash, 0, wire.TxTreeRegular)
+		msgTx.AddTxIn(wire.NewTxIn(prevOut, nil))
+	}
+	view := blockchain.NewUtxoViewpoint()
+	err := blockchain.ValidateTransactionScripts(hcashutil.NewTx(msgTx),
+		view, 0, nil)
+	rerr, ok := err.(blockchain.RuleError)
+	if !ok || rerr.ErrorCode != blockchain.ErrMissingTx {
+		t.Fatalf("unexpected error validating missing inputs: %v", err)
+	}
+
+	// Coinbase inputs are not validated, so no error is expected.
+	coinbase := wire.NewMsgTx()
+	prevOut := wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex,
+		wire.TxTreeRegular)
+	coinbase.AddTxIn(wire.NewTxIn(prevOut, nil))
+	err = blockchain.ValidateTransactionScripts(hcashutil.NewTx(coinbase),
+		view, 0, nil)
+	if err != nil {
+		t.Fatalf("unexpected error validating coinbase: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"sync"
+
+	"github.com/HcashOrg/hcashd/chaincfg"
+	hcashcrypto "github.com/HcashOrg/hcashd/crypto"
+)
+
+// blockSigAggregator collects the signatures of the inputs of a block which the
+// script engine defers to it, keeping an aggregate per signature type, so they
+// can be batch verified once all of the scripts were executed.  It implements
+// the txscript.SigAggregator interface and is safe for concurrent access.
+type blockSigAggregator struct {
+	mtx        sync.Mutex
+	sigTypes   map[int]struct{}
+	aggregates map[int]hcashcrypto.Aggregate
+}
+
+// newBlockSigAggregator returns a new empty signature aggregator which accepts
+// the signatures of the passed signature types, or of all of the signature
+// types which support aggregation when none are passed.
+func newBlockSigAggregator(sigTypes ...int) *blockSigAggregator {
+	a := &blockSigAggregator{
+		aggregates: make(map[int]hcashcrypto.Aggregate),
+	}
+	if len(sigTypes) > 0 {
+		a.sigTypes = make(map[int]struct{}, len(sigTypes))
+		for _, sigType := range sigTypes {
+			a.sigTypes[sigType] = struct{}{}
+		}
+	}
+	return a
+}
+
+// AddSig adds the signature to the aggregate for its signature type and returns
+// whether it was accepted.  Signatures of types which are not accepted by the
+// aggregator or of suites which do not support aggregation are not accepted.
+//
+// This is part of the txscript.SigAggregator interface implementation.
+func (a *blockSigAggregator) AddSig(sigType int, pubKey hcashcrypto.PublicKey,
+	hash []byte, sig hcashcrypto.Signature) bool {
+
+	a.mtx.Lock()
+	if a.sigTypes != nil {
+		if _, ok := a.sigTypes[sigType]; !ok {
+			a.mtx.Unlock()
+			return false
+		}
+	}
+	agg, ok := a.aggregates[sigType]
+	if !ok {
+		var err error
+		agg, err = hcashcrypto.NewAggregate(sigType)
+		if err != nil {
+			a.mtx.Unlock()
+			return false
+		}
+		a.aggregates[sigType] = agg
+	}
+	a.mtx.Unlock()
+
+	return agg.Add(pubKey, hash, sig) == nil
+}
+
+// Len returns the number of signatures added to all of the aggregates.
+func (a *blockSigAggregator) Len() int {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	var n int
+	for _, agg := range a.aggregates {
+		n += agg.Len()
+	}
+	return n
+}
+
+// Verify returns whether all of the signatures added to the aggregates are
+// valid.
+func (a *blockSigAggregator) Verify() bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	for _, agg := range a.aggregates {
+		if !agg.Verify() {
+			return false
+		}
+	}
+	return true
+}
+
+// isVoteSigAggregationActive returns whether the vote signatures of the block
+// after the passed node are verified as aggregates, which is the case once the
+// stake vote for the experimental vote signature aggregation agenda is active.
+// The agenda is inactive on networks which do not define it.
+//
+// Unlike the secp256k1 Schnorr signatures, the Ed25519 vote signatures are only
+// batch verified once the agenda is active, because the batch verification
+// accepts signatures with components of small order which the individual
+// verification rejects.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) isVoteSigAggregationActive(prevNode *blockNode) (bool, error) {
+	version, ok := b.deploymentVersion(chaincfg.VoteIDVoteSigAggregation)
+	if !ok {
+		return false, nil
+	}
+
+	// NOTE: The choice field of the return threshold state is not examined
+	// here because there is only one possible choice that can be active
+	// for the agenda, which is yes, so there is no need to check it.
+	state, err := b.deploymentState(prevNode, version,
+		chaincfg.VoteIDVoteSigAggregation)
+	if err != nil {
+		return false, err
+	}
+	return state.State == ThresholdActive, nil
+}
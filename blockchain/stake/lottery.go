@@ -132,6 +132,14 @@ func intInSlice(i int, sl []int) bool {
 
 // findTicketIdxs finds n many unique index numbers for a list length size.
 func findTicketIdxs(size int, n uint16, prng *Hash256PRNG) ([]int, error) {
+	return traceTicketIdxs(size, n, prng, nil)
+}
+
+// traceTicketIdxs finds n many unique index numbers for a list length size
+// like findTicketIdxs.  When draws is not nil, every number drawn from the
+// PRNG is also appended to it, including those which were discarded because
+// they repeat an index that was already selected.
+func traceTicketIdxs(size int, n uint16, prng *Hash256PRNG, draws *[]uint32) ([]int, error) {
 	if size < int(n) {
 		return nil, fmt.Errorf("list size too small: %v < %v",
 			size, n)
@@ -148,6 +156,9 @@ func findTicketIdxs(size int, n uint16, prng *Hash256PRNG) ([]int, error) {
 	var listLen uint16
 	for listLen < n {
 		r := int(prng.uniformRandom(sz))
+		if draws != nil {
+			*draws = append(*draws, uint32(r))
+		}
 		if !intInSlice(r, list) {
 			list = append(list, r)
 			listLen++
@@ -0,0 +1,275 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stake
+
+import (
+	"fmt"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+// WinnerProof proves that a winning ticket is located at the index selected
+// by the lottery in the live ticket pool.
+type WinnerProof struct {
+	// Ticket is the hash of the winning ticket.
+	Ticket chainhash.Hash
+
+	// Index is the position of the ticket in the live ticket pool ordered
+	// by ticket hash.
+	Index int
+
+	// MerkleBranch holds the sibling hashes from the leaf of the ticket up
+	// to, but not including, the pool root.
+	MerkleBranch []chainhash.Hash
+}
+
+// LotteryProof houses the inputs and intermediate values of the ticket
+// lottery run for a key block.  It allows the selection of the winning tickets
+// to be checked independently with Verify.
+//
+// The lottery PRNG is seeded by the serialized header of the key block, which
+// commits to the previous block, and draws indexes into the live ticket pool
+// after the key block is connected.  The live ticket pool is committed to by
+// the merkle root of its ticket hashes in ascending order.  The final state
+// is committed to by the header of every child of the key block.
+type LotteryProof struct {
+	// Seed is the serialized key block header used to seed the PRNG.
+	Seed []byte
+
+	// PoolSize is the number of live tickets the winners were drawn from.
+	PoolSize int
+
+	// PoolRoot is the merkle root of the live ticket hashes.
+	PoolRoot chainhash.Hash
+
+	// Draws are all numbers drawn from the PRNG in order, including those
+	// discarded for repeating an index that was already selected.
+	Draws []uint32
+
+	// Winners are the selected tickets in selection order.
+	Winners []WinnerProof
+
+	// StateHash is the hash of the PRNG state after the last draw.
+	StateHash chainhash.Hash
+
+	// FinalState is the final state checksum of the lottery.
+	FinalState [6]byte
+}
+
+// hashTicketPair returns the hash of the concatenation of the passed hashes,
+// which is the parent of the two nodes in the ticket pool merkle tree.
+func hashTicketPair(left, right *chainhash.Hash) chainhash.Hash {
+	var buf [chainhash.HashSize * 2]byte
+	copy(buf[:chainhash.HashSize], left[:])
+	copy(buf[chainhash.HashSize:], right[:])
+	return chainhash.HashH(buf[:])
+}
+
+// buildTicketMerkleTree returns every level of the merkle tree for the passed
+// ticket hashes, starting with the leaves and ending with the root.  When a
+// level has an odd number of nodes, the last node is paired with itself.
+func buildTicketMerkleTree(tickets []chainhash.Hash) [][]chainhash.Hash {
+	levels := [][]chainhash.Hash{tickets}
+	for level := tickets; len(level) > 1; {
+		next := make([]chainhash.Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			right := i + 1
+			if right == len(level) {
+				right = i
+			}
+			next = append(next, hashTicketPair(&level[i], &level[right]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// ticketMerkleBranch returns the sibling hashes needed to connect the leaf at
+// the passed index to the root of the merkle tree.
+func ticketMerkleBranch(levels [][]chainhash.Hash, idx int) []chainhash.Hash {
+	branch := make([]chainhash.Hash, 0, len(levels)-1)
+	for _, level := range levels[:len(levels)-1] {
+		sibling := idx ^ 1
+		if sibling >= len(level) {
+			sibling = idx
+		}
+		branch = append(branch, level[sibling])
+		idx >>= 1
+	}
+	return branch
+}
+
+// ticketMerkleDepth returns the number of levels below the root of the merkle
+// tree for a pool of the passed size.
+func ticketMerkleDepth(size int) int {
+	depth := 0
+	for ; size > 1; size = (size + 1) / 2 {
+		depth++
+	}
+	return depth
+}
+
+// TicketPoolMerkleRoot returns the merkle root of the passed ticket hashes,
+// which must be sorted in ascending order for the result to match the
+// PoolRoot of a LotteryProof.
+func TicketPoolMerkleRoot(tickets []chainhash.Hash) chainhash.Hash {
+	if len(tickets) == 0 {
+		return chainhash.Hash{}
+	}
+	levels := buildTicketMerkleTree(tickets)
+	return levels[len(levels)-1][0]
+}
+
+// lotteryFinalState returns the final state checksum for the passed winners
+// and PRNG state hash.
+func lotteryFinalState(winners []chainhash.Hash, stateHash chainhash.Hash) [6]byte {
+	stateBuffer := make([]byte, 0, (len(winners)+1)*chainhash.HashSize)
+	for _, ticketHash := range winners {
+		stateBuffer = append(stateBuffer, ticketHash[:]...)
+	}
+	stateBuffer = append(stateBuffer, stateHash[:]...)
+
+	var finalState [6]byte
+	copy(finalState[:], chainhash.HashB(stateBuffer)[0:6])
+	return finalState
+}
+
+// LotteryProof returns the lottery proof for the winners of the node, which
+// must be the stake node of the key block with the passed header.  An error is
+// returned when the node did not select any winners.
+func (sn *Node) LotteryProof(header *wire.BlockHeader) (*LotteryProof, error) {
+	if len(sn.nextWinners) == 0 {
+		return nil, fmt.Errorf("no tickets were selected by block %v",
+			header.BlockHash())
+	}
+
+	seed, err := header.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	prng := NewHash256PRNG(seed)
+	var draws []uint32
+	idxs, err := traceTicketIdxs(sn.liveTickets.Len(),
+		sn.params.TicketsPerBlock, prng, &draws)
+	if err != nil {
+		return nil, err
+	}
+
+	tickets := sn.LiveTickets()
+	levels := buildTicketMerkleTree(tickets)
+	winners := make([]WinnerProof, 0, len(idxs))
+	for i, idx := range idxs {
+		// The winners are carried over from the parent for blocks which
+		// are not key blocks, so they will not match the draws.
+		if i >= len(sn.nextWinners) || tickets[idx] != sn.nextWinners[i] {
+			return nil, fmt.Errorf("winners of block %v were not "+
+				"selected by its lottery", header.BlockHash())
+		}
+		winners = append(winners, WinnerProof{
+			Ticket:       tickets[idx],
+			Index:        idx,
+			MerkleBranch: ticketMerkleBranch(levels, idx),
+		})
+	}
+
+	stateHash := prng.StateHash()
+	if lotteryFinalState(sn.nextWinners, stateHash) != sn.finalState {
+		return nil, fmt.Errorf("final state of block %v does not match "+
+			"its lottery", header.BlockHash())
+	}
+
+	return &LotteryProof{
+		Seed:       seed,
+		PoolSize:   len(tickets),
+		PoolRoot:   levels[len(levels)-1][0],
+		Draws:      draws,
+		Winners:    winners,
+		StateHash:  stateHash,
+		FinalState: sn.finalState,
+	}, nil
+}
+
+// Verify checks that the proof is internally consistent for a network which
+// selects ticketsPerBlock winners per key block.  That is, the draws, the
+// selected indexes and the final state all follow from the seed and the pool
+// size, and every winning ticket is located at its index in the pool committed
+// to by the pool root.
+//
+// Verify does not check that the seed is the header of a key block in the
+// main chain, that the pool root matches the live tickets of that block, nor
+// that the final state matches the headers of its children.  Those must be
+// checked against independently obtained data.
+func (p *LotteryProof) Verify(ticketsPerBlock uint16) error {
+	if len(p.Winners) != int(ticketsPerBlock) {
+		return fmt.Errorf("proof has %d winners instead of %d",
+			len(p.Winners), ticketsPerBlock)
+	}
+
+	// Copy the seed since seeding the PRNG appends to it.
+	seed := make([]byte, len(p.Seed))
+	copy(seed, p.Seed)
+	prng := NewHash256PRNG(seed)
+	var draws []uint32
+	idxs, err := traceTicketIdxs(p.PoolSize, ticketsPerBlock, prng, &draws)
+	if err != nil {
+		return err
+	}
+
+	if len(draws) != len(p.Draws) {
+		return fmt.Errorf("proof has %d draws instead of %d",
+			len(p.Draws), len(draws))
+	}
+	for i := range draws {
+		if draws[i] != p.Draws[i] {
+			return fmt.Errorf("draw %d is %d instead of %d", i,
+				p.Draws[i], draws[i])
+		}
+	}
+
+	depth := ticketMerkleDepth(p.PoolSize)
+	winners := make([]chainhash.Hash, 0, len(p.Winners))
+	for i, winner := range p.Winners {
+		if winner.Index != idxs[i] {
+			return fmt.Errorf("winner %d has index %d instead of %d",
+				i, winner.Index, idxs[i])
+		}
+		if len(winner.MerkleBranch) != depth {
+			return fmt.Errorf("merkle branch of winner %d has %d "+
+				"hashes instead of %d", i, len(winner.MerkleBranch),
+				depth)
+		}
+
+		hash := winner.Ticket
+		idx := winner.Index
+		for j := range winner.MerkleBranch {
+			if idx&1 == 0 {
+				hash = hashTicketPair(&hash, &winner.MerkleBranch[j])
+			} else {
+				hash = hashTicketPair(&winner.MerkleBranch[j], &hash)
+			}
+			idx >>= 1
+		}
+		if hash != p.PoolRoot {
+			return fmt.Errorf("ticket %v of winner %d is not at index "+
+				"%d of the pool", winner.Ticket, i, winner.Index)
+		}
+		winners = append(winners, winner.Ticket)
+	}
+
+	stateHash := prng.StateHash()
+	if stateHash != p.StateHash {
+		return fmt.Errorf("PRNG state hash is %v instead of %v",
+			p.StateHash, stateHash)
+	}
+	finalState := lotteryFinalState(winners, stateHash)
+	if finalState != p.FinalState {
+		return fmt.Errorf("final state is %x instead of %x",
+			p.FinalState, finalState)
+	}
+
+	return nil
+}
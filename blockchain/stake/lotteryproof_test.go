@@ -0,0 +1,170 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stake
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcashd/blockchain/stake/internal/tickettreap"
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+// lotteryTestNode returns a stake node with a live ticket pool of the passed
+// size whose winners and final state were selected by the lottery seeded by
+// the passed header, the same way connectNode does for key blocks.
+func lotteryTestNode(t *testing.T, header *wire.BlockHeader, poolSize int) *Node {
+	params := &chaincfg.SimNetParams
+	treap := new(tickettreap.Immutable)
+	for i := 0; i < poolSize; i++ {
+		h := chainhash.HashH([]byte{byte(i), byte(i >> 8)})
+		treap = treap.Put(tickettreap.Key(h), &tickettreap.Value{
+			Height: uint32(i),
+		})
+	}
+
+	hB, err := header.Bytes()
+	if err != nil {
+		t.Fatalf("header.Bytes: unexpected error %v", err)
+	}
+	prng := NewHash256PRNG(hB)
+	idxs, err := findTicketIdxs(treap.Len(), params.TicketsPerBlock, prng)
+	if err != nil {
+		t.Fatalf("findTicketIdxs: unexpected error %v", err)
+	}
+	keys, err := fetchWinners(idxs, treap)
+	if err != nil {
+		t.Fatalf("fetchWinners: unexpected error %v", err)
+	}
+	winners := make([]chainhash.Hash, 0, len(keys))
+	for _, key := range keys {
+		winners = append(winners, chainhash.Hash(*key))
+	}
+
+	return &Node{
+		liveTickets: treap,
+		nextWinners: winners,
+		finalState:  lotteryFinalState(winners, prng.StateHash()),
+		params:      params,
+	}
+}
+
+// TestTicketPoolMerkleBranch ensures the merkle branches of every leaf connect
+// to the root for pools with both even and odd numbers of tickets.
+func TestTicketPoolMerkleBranch(t *testing.T) {
+	for _, size := range []int{1, 2, 3, 5, 8, 13} {
+		tickets := make([]chainhash.Hash, size)
+		for i := range tickets {
+			tickets[i] = chainhash.HashH([]byte{byte(i)})
+		}
+		root := TicketPoolMerkleRoot(tickets)
+		levels := buildTicketMerkleTree(tickets)
+		for idx := range tickets {
+			branch := ticketMerkleBranch(levels, idx)
+			if len(branch) != ticketMerkleDepth(size) {
+				t.Errorf("size %d idx %d: got branch length %d, "+
+					"want %d", size, idx, len(branch),
+					ticketMerkleDepth(size))
+				continue
+			}
+
+			hash := tickets[idx]
+			pos := idx
+			for i := range branch {
+				if pos&1 == 0 {
+					hash = hashTicketPair(&hash, &branch[i])
+				} else {
+					hash = hashTicketPair(&branch[i], &hash)
+				}
+				pos >>= 1
+			}
+			if hash != root {
+				t.Errorf("size %d idx %d: branch connects to %v, "+
+					"want %v", size, idx, hash, root)
+			}
+		}
+	}
+}
+
+// TestLotteryProof ensures lottery proofs generated for a stake node verify
+// and that tampering with any part of a proof is detected.
+func TestLotteryProof(t *testing.T) {
+	ticketsPerBlock := chaincfg.SimNetParams.TicketsPerBlock
+	header := &wire.BlockHeader{Height: 1234, KeyHeight: 99, Nonce: 7}
+	node := lotteryTestNode(t, header, 300)
+
+	proof, err := node.LotteryProof(header)
+	if err != nil {
+		t.Fatalf("LotteryProof: unexpected error %v", err)
+	}
+	if err := proof.Verify(ticketsPerBlock); err != nil {
+		t.Fatalf("Verify: unexpected error %v", err)
+	}
+	if proof.PoolRoot != TicketPoolMerkleRoot(node.LiveTickets()) {
+		t.Errorf("unexpected pool root %v", proof.PoolRoot)
+	}
+	if len(proof.Draws) < int(ticketsPerBlock) {
+		t.Errorf("got %d draws, want at least %d", len(proof.Draws),
+			ticketsPerBlock)
+	}
+	for i, winner := range proof.Winners {
+		if winner.Ticket != node.nextWinners[i] {
+			t.Errorf("winner %d: got %v, want %v", i, winner.Ticket,
+				node.nextWinners[i])
+		}
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(p *LotteryProof)
+	}{
+		{"seed", func(p *LotteryProof) { p.Seed[0] ^= 0x01 }},
+		{"pool size", func(p *LotteryProof) { p.PoolSize++ }},
+		{"pool root", func(p *LotteryProof) { p.PoolRoot[0] ^= 0x01 }},
+		{"draw", func(p *LotteryProof) { p.Draws[0]++ }},
+		{"winner ticket", func(p *LotteryProof) {
+			p.Winners[1].Ticket = p.Winners[0].Ticket
+		}},
+		{"winner index", func(p *LotteryProof) { p.Winners[0].Index++ }},
+		{"merkle branch", func(p *LotteryProof) {
+			p.Winners[0].MerkleBranch[0][0] ^= 0x01
+		}},
+		{"short merkle branch", func(p *LotteryProof) {
+			p.Winners[0].MerkleBranch = p.Winners[0].MerkleBranch[1:]
+		}},
+		{"state hash", func(p *LotteryProof) { p.StateHash[0] ^= 0x01 }},
+		{"final state", func(p *LotteryProof) { p.FinalState[0] ^= 0x01 }},
+		{"missing winner", func(p *LotteryProof) {
+			p.Winners = p.Winners[1:]
+		}},
+	}
+	for _, test := range tests {
+		proof, err := node.LotteryProof(header)
+		if err != nil {
+			t.Fatalf("%s: LotteryProof: unexpected error %v", test.name,
+				err)
+		}
+		test.tamper(proof)
+		if err := proof.Verify(ticketsPerBlock); err == nil {
+			t.Errorf("%s: Verify: expected error for tampered proof",
+				test.name)
+		}
+	}
+
+	// Ensure a proof is not produced for a header that did not select the
+	// winners of the node.
+	otherHeader := *header
+	otherHeader.Nonce++
+	if _, err := node.LotteryProof(&otherHeader); err == nil {
+		t.Errorf("LotteryProof: expected error for mismatched header")
+	}
+
+	// Ensure a proof is not produced for a node without winners.
+	node.nextWinners = nil
+	if _, err := node.LotteryProof(header); err == nil {
+		t.Errorf("LotteryProof: expected error for node without winners")
+	}
+}
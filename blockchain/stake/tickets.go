@@ -7,10 +7,12 @@ package stake
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/HcashOrg/hcashd/blockchain/stake/internal/dbnamespace"
 	"github.com/HcashOrg/hcashd/blockchain/stake/internal/ticketdb"
 	"github.com/HcashOrg/hcashd/blockchain/stake/internal/tickettreap"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
@@ -18,7 +20,6 @@ import (
 	//"github.com/HcashOrg/hcashd/blockchain"
 	//"github.com/HcashOrg/hcashd/blockchain"
 	//"math/big"
-	"math/big"
 )
 
 // UndoTicketDataSlice is a pass through for ticketdb's UndoTicketData, which is
@@ -182,6 +183,23 @@ func (sn *Node) ExistsExpiredTicket(ticket chainhash.Hash) bool {
 	return false
 }
 
+// ExpiredTickets returns the list of tickets which expired from the perspective
+// of this stake node, which includes both the expired tickets which are not
+// yet revoked and those which are.
+func (sn *Node) ExpiredTickets() []chainhash.Hash {
+	var tickets []chainhash.Hash
+	collectExpired := func(k tickettreap.Key, v *tickettreap.Value) bool {
+		if v.Expired {
+			tickets = append(tickets, chainhash.Hash(k))
+		}
+		return true
+	}
+	sn.missedTickets.ForEach(collectExpired)
+	sn.revokedTickets.ForEach(collectExpired)
+
+	return tickets
+}
+
 // Winners returns the current list of winners for this stake node, which
 // can vote on this node.
 func (sn *Node) Winners() []chainhash.Hash {
@@ -331,7 +349,7 @@ func LoadBestNode(dbTx database.Tx, height uint32, keyHeight uint32, blockHash c
 	node.nextWinners = make([]chainhash.Hash, 0)
 
 	realKeyHeight := header.KeyHeight
-	if HashToBig(&blockHash).Cmp(CompactToBig(header.Bits)) <= 0 {
+	if standalone.HashToBig(&blockHash).Cmp(standalone.CompactToBig(header.Bits)) <= 0 {
 		realKeyHeight++
 	}
 	if realKeyHeight >= uint32(node.params.StakeValidationHeight-1) {
@@ -1101,66 +1119,16 @@ func WriteDisconnectedBestNode(dbTx database.Tx, node *Node, hash chainhash.Hash
 
 // HashToBig converts a chainhash.Hash into a big.Int that can be used to
 // perform math comparisons.
+//
+// Deprecated: Use standalone.HashToBig instead.
 func HashToBig(hash *chainhash.Hash) *big.Int {
-	// A Hash is in little-endian, but the big package wants the bytes in
-	// big-endian, so reverse them.
-	buf := *hash
-	blen := len(buf)
-	for i := 0; i < blen/2; i++ {
-		buf[i], buf[blen-1-i] = buf[blen-1-i], buf[i]
-	}
-
-	return new(big.Int).SetBytes(buf[:])
+	return standalone.HashToBig(hash)
 }
 
 // CompactToBig converts a compact representation of a whole number N to an
-// unsigned 32-bit number.  The representation is similar to IEEE754 floating
-// point numbers.
-//
-// Like IEEE754 floating point, there are three basic components: the sign,
-// the exponent, and the mantissa.  They are broken out as follows:
-//
-//	* the most significant 8 bits represent the unsigned base 256 exponent
-// 	* bit 23 (the 24th bit) represents the sign bit
-//	* the least significant 23 bits represent the mantissa
-//
-//	-------------------------------------------------
-//	|   Exponent     |    Sign    |    Mantissa     |
-//	-------------------------------------------------
-//	| 8 bits [31-24] | 1 bit [23] | 23 bits [22-00] |
-//	-------------------------------------------------
-//
-// The formula to calculate N is:
-// 	N = (-1^sign) * mantissa * 256^(exponent-3)
+// unsigned 32-bit number.  See standalone.CompactToBig for details.
 //
-// This compact form is only used in hypercash to encode unsigned 256-bit numbers
-// which represent difficulty targets, thus there really is not a need for a
-// sign bit, but it is implemented here to stay consistent with bitcoind.
+// Deprecated: Use standalone.CompactToBig instead.
 func CompactToBig(compact uint32) *big.Int {
-	// Extract the mantissa, sign bit, and exponent.
-	mantissa := compact & 0x007fffff
-	isNegative := compact&0x00800000 != 0
-	exponent := uint(compact >> 24)
-
-	// Since the base for the exponent is 256, the exponent can be treated
-	// as the number of bytes to represent the full 256-bit number.  So,
-	// treat the exponent as the number of bytes and shift the mantissa
-	// right or left accordingly.  This is equivalent to:
-	// N = mantissa * 256^(exponent-3)
-	var bn *big.Int
-	if exponent <= 3 {
-		mantissa >>= 8 * (3 - exponent)
-		bn = big.NewInt(int64(mantissa))
-	} else {
-		bn = big.NewInt(int64(mantissa))
-		bn.Lsh(bn, 8*(exponent-3))
-	}
-
-	// Make it negative if the sign bit is set.
-	if isNegative {
-		bn = bn.Neg(bn)
-	}
-
-	return bn
+	return standalone.CompactToBig(compact)
 }
-
@@ -177,6 +177,10 @@ func nodesEqual(a *Node, b *Node) error {
 		return fmt.Errorf("revoked tickets were not equal between nodes; "+
 			"a: %v, b: %v", len(a.RevokedTickets()), len(b.RevokedTickets()))
 	}
+	if !reflect.DeepEqual(a.ExpiredTickets(), b.ExpiredTickets()) {
+		return fmt.Errorf("expired tickets were not equal between nodes; "+
+			"a: %v, b: %v", len(a.ExpiredTickets()), len(b.ExpiredTickets()))
+	}
 	if !reflect.DeepEqual(a.NewTickets(), b.NewTickets()) {
 		return fmt.Errorf("new tickets were not equal between nodes; "+
 			"a: %v, b: %v", len(a.NewTickets()), len(b.NewTickets()))
@@ -7,6 +7,9 @@
 package blockchain
 
 import (
+	"fmt"
+
+	"github.com/HcashOrg/hcashd/blockchain/stake"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
 	"github.com/HcashOrg/hcashd/txscript"
@@ -87,9 +90,39 @@ func (b *BlockChain) LotteryDataForBlock(hash *chainhash.Hash) ([]chainhash.Hash
 	return b.lotteryDataForBlock(hash)
 }
 
+// LotteryProofForBlock returns the inputs and intermediate values of the
+// ticket lottery seeded by the key block with the given hash, including side
+// chain blocks, so the selection of its winning tickets can be audited.  An
+// error is returned when the block is not a key block or did not select any
+// winners.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) LotteryProofForBlock(hash *chainhash.Hash) (*stake.LotteryProof, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node, exists := b.index[*hash]
+	if !exists {
+		var err error
+		node, err = b.findNode(hash, maxSearchDepth)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !node.isKeyBlock {
+		return nil, fmt.Errorf("block %v is not a key block", hash)
+	}
+
+	stakeNode, err := b.fetchStakeNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return stakeNode.LotteryProof(&node.header)
+}
+
 // LiveTickets returns all currently live tickets from the stake database.
 //
-// This function is NOT safe for concurrent access.
+// This function is safe for concurrent access.
 func (b *BlockChain) LiveTickets() ([]chainhash.Hash, error) {
 	b.chainLock.RLock()
 	sn := b.bestNode.stakeNode
@@ -100,7 +133,7 @@ func (b *BlockChain) LiveTickets() ([]chainhash.Hash, error) {
 
 // MissedTickets returns all currently missed tickets from the stake database.
 //
-// This function is NOT safe for concurrent access.
+// This function is safe for concurrent access.
 func (b *BlockChain) MissedTickets() ([]chainhash.Hash, error) {
 	b.chainLock.RLock()
 	sn := b.bestNode.stakeNode
@@ -109,6 +142,18 @@ func (b *BlockChain) MissedTickets() ([]chainhash.Hash, error) {
 	return sn.MissedTickets(), nil
 }
 
+// ExpiredTickets returns all tickets which expired as of the best node from
+// the stake database, regardless of whether or not they were revoked.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ExpiredTickets() ([]chainhash.Hash, error) {
+	b.chainLock.RLock()
+	sn := b.bestNode.stakeNode
+	b.chainLock.RUnlock()
+
+	return sn.ExpiredTickets(), nil
+}
+
 // TicketsWithAddress returns a slice of ticket hashes that are currently live
 // corresponding to the given address.
 //
@@ -134,7 +179,8 @@ func (b *BlockChain) TicketsWithAddress(address hcashutil.Address) ([]chainhash.
 			if err != nil {
 				return err
 			}
-			if addrs[0].EncodeAddress() == address.EncodeAddress() {
+			if len(addrs) > 0 &&
+				addrs[0].EncodeAddress() == address.EncodeAddress() {
 				ticketsWithAddr = append(ticketsWithAddr, hash)
 			}
 		}
@@ -8,8 +8,10 @@ package blockchain
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
 )
@@ -33,7 +35,7 @@ func (b *BlockChain) nodeAtHeightFromTopNode(node *blockNode,
 			return nil, fmt.Errorf("unable to obtain previous node; " +
 				"ancestor is genesis block")
 		}
-		if HashToBig(&(oldNode.hash)).Cmp(CompactToBig(oldNode.header.Bits)) <= 0 {
+		if standalone.HashToBig(&(oldNode.hash)).Cmp(standalone.CompactToBig(oldNode.header.Bits)) <= 0 {
 			count++
 		}
 		if count >= toTraverse {
@@ -120,6 +122,7 @@ func (b *BlockChain) fetchStakeNode(node *blockNode) (*stake.Node, error) {
 	// If we already have the stake node fetched, returned the cached result.
 	// Stake nodes are immutable.
 	if node.stakeNode != nil {
+		b.stakeNodeStats.Hits++
 		return node.stakeNode, nil
 	}
 
@@ -148,10 +151,24 @@ func (b *BlockChain) fetchStakeNode(node *blockNode) (*stake.Node, error) {
 				return nil, err
 			}
 
+			b.stakeNodeStats.ParentConnects++
 			return node.stakeNode, nil
 		}
 	}
 
+	// Track the cost of regenerating the stake node.
+	regen := stakeNodeRegeneration{start: time.Now()}
+	defer func() {
+		if b.stakeNodeStats.recordRegeneration(&regen, time.Now()) {
+			log.Debugf("Regenerating the stake node of block %v "+
+				"(height %d) replayed %d disconnects and %d "+
+				"connects with %d database lookups in %v",
+				node.hash, node.height, regen.disconnects,
+				regen.connects, regen.dbLookups,
+				time.Since(regen.start))
+		}
+	}()
+
 	// We need to generate a path to the stake node and restore it
 	// it through the entire path.  The bestNode stake node must
 	// always be filled in, so assume it is safe to begin working
@@ -170,6 +187,10 @@ func (b *BlockChain) fetchStakeNode(node *blockNode) (*stake.Node, error) {
 		for e := detachNodes.Front(); e != nil; e = e.Next() {
 			n := e.Value.(*blockNode)
 			if n.stakeNode == nil {
+				regen.disconnects++
+				if n.stakeUndoData == nil || n.newTickets == nil {
+					regen.dbLookups++
+				}
 				var errLocal error
 				n.stakeNode, errLocal =
 					current.stakeNode.DisconnectNode(n.header,
@@ -191,6 +212,11 @@ func (b *BlockChain) fetchStakeNode(node *blockNode) (*stake.Node, error) {
 	// point.
 	err = b.db.View(func(dbTx database.Tx) error {
 		if current.parent.stakeNode == nil {
+			regen.disconnects++
+			if current.parent.stakeUndoData == nil ||
+				current.parent.newTickets == nil {
+				regen.dbLookups++
+			}
 			var errLocal error
 			current.parent.stakeNode, errLocal =
 				current.stakeNode.DisconnectNode(current.parent.header,
@@ -237,6 +263,7 @@ func (b *BlockChain) fetchStakeNode(node *blockNode) (*stake.Node, error) {
 				}
 			}
 
+			regen.connects++
 			n.stakeNode, err = current.stakeNode.ConnectNode(n.header,
 				n.ticketsSpent, n.ticketsRevoked, n.newTickets, n.isKeyBlock)
 			if err != nil {
@@ -256,7 +283,7 @@ func (b *BlockChain) fetchStakeNode(node *blockNode) (*stake.Node, error) {
 	if node.stakeNode != nil {
 		return node.stakeNode, nil
 	}
-	if HashToBig(&(node.hash)).Cmp(CompactToBig(node.header.Bits)) >= 0 && node.height > 0{
+	if standalone.HashToBig(&(node.hash)).Cmp(standalone.CompactToBig(node.header.Bits)) >= 0 && node.height > 0{
 		return nil, nil
 	}
 
@@ -265,7 +292,7 @@ func (b *BlockChain) fetchStakeNode(node *blockNode) (*stake.Node, error) {
 	if node.keyHeight > 0 {
 		for {
 			if parentNode != nil {
-				if HashToBig(&(parentNode.hash)).Cmp(CompactToBig(parentNode.header.Bits)) <= 0 {
+				if standalone.HashToBig(&(parentNode.hash)).Cmp(standalone.CompactToBig(parentNode.header.Bits)) <= 0 {
 					break
 				}
 				parentNode = parentNode.parent
@@ -395,4 +422,4 @@ func (b *BlockChain) fetchStakeNode(node *blockNode) (*stake.Node, error) {
 
 	return current.stakeNode, nil
 }
-*/
\ No newline at end of file
+*/
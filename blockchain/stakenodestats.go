@@ -0,0 +1,97 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"time"
+)
+
+// slowStakeNodeReplays is the number of stake node connect and disconnect
+// replays a single stake node regeneration must exceed to be logged.
+const slowStakeNodeReplays = 100
+
+// StakeNodeCacheStats describes how stake nodes requested by the chain were
+// obtained since the chain instance was created.  A stake node is either
+// already cached in its block node, connected from the cached stake node of
+// its parent, or regenerated by replaying the ticket changes of every block
+// between the best chain tip and the requested node.  Regenerations are
+// expensive for nodes deep in side chains, so their replays and the time they
+// take are tracked separately.
+type StakeNodeCacheStats struct {
+	// Hits is the number of requests for stake nodes that were cached.
+	Hits uint64
+
+	// ParentConnects is the number of requests for stake nodes that were
+	// connected from the cached stake node of their parent.
+	ParentConnects uint64
+
+	// Regenerations is the number of requests for stake nodes that had
+	// to be regenerated from the best chain tip.
+	Regenerations uint64
+
+	// ConnectReplays and DisconnectReplays are the total numbers of
+	// blocks that were connected to and disconnected from stake nodes
+	// during regenerations.
+	ConnectReplays    uint64
+	DisconnectReplays uint64
+
+	// DBLookups is the total number of disconnect replays during
+	// regenerations that had to load the undo data or the new tickets of
+	// a block from the database.
+	DBLookups uint64
+
+	// MaxReplays is the largest number of connect and disconnect replays
+	// of a single regeneration.
+	MaxReplays uint64
+
+	// RegenerationTime is the total time spent regenerating stake nodes.
+	RegenerationTime time.Duration
+}
+
+// HitRate returns the ratio of requests for stake nodes that were satisfied by
+// the cache, either directly or by connecting the cached stake node of the
+// parent, to all requests.  It returns zero when there were no requests.
+func (s *StakeNodeCacheStats) HitRate() float64 {
+	total := s.Hits + s.ParentConnects + s.Regenerations
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.ParentConnects) / float64(total)
+}
+
+// stakeNodeRegeneration tracks the cost of a single stake node regeneration.
+type stakeNodeRegeneration struct {
+	start       time.Time
+	connects    uint64
+	disconnects uint64
+	dbLookups   uint64
+}
+
+// recordRegeneration adds the passed stake node regeneration, which finished at
+// the passed time, to the stats and returns whether or not it replayed enough
+// blocks to be worth logging.
+func (s *StakeNodeCacheStats) recordRegeneration(r *stakeNodeRegeneration, now time.Time) bool {
+	replays := r.connects + r.disconnects
+	s.Regenerations++
+	s.ConnectReplays += r.connects
+	s.DisconnectReplays += r.disconnects
+	s.DBLookups += r.dbLookups
+	if replays > s.MaxReplays {
+		s.MaxReplays = replays
+	}
+	s.RegenerationTime += now.Sub(r.start)
+	return replays > slowStakeNodeReplays
+}
+
+// StakeNodeCacheStats returns the statistics on how stake nodes requested by
+// the chain were obtained.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) StakeNodeCacheStats() StakeNodeCacheStats {
+	b.chainLock.RLock()
+	stats := b.stakeNodeStats
+	b.chainLock.RUnlock()
+	return stats
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestStakeNodeCacheStats ensures stake node regenerations are accumulated
+// into the stats and the hit rate accounts for all kinds of requests.
+func TestStakeNodeCacheStats(t *testing.T) {
+	var stats StakeNodeCacheStats
+	if rate := stats.HitRate(); rate != 0 {
+		t.Fatalf("got hit rate %v without requests, want 0", rate)
+	}
+
+	start := time.Unix(1500000000, 0)
+	small := stakeNodeRegeneration{start: start, connects: 2,
+		disconnects: 3, dbLookups: 1}
+	if stats.recordRegeneration(&small, start.Add(time.Millisecond)) {
+		t.Fatal("small regeneration reported as slow")
+	}
+	large := stakeNodeRegeneration{start: start, connects: 50,
+		disconnects: 60, dbLookups: 60}
+	if !stats.recordRegeneration(&large, start.Add(time.Second)) {
+		t.Fatal("large regeneration not reported as slow")
+	}
+
+	want := StakeNodeCacheStats{
+		Regenerations:     2,
+		ConnectReplays:    52,
+		DisconnectReplays: 63,
+		DBLookups:         61,
+		MaxReplays:        110,
+		RegenerationTime:  time.Second + time.Millisecond,
+	}
+	if stats != want {
+		t.Fatalf("got stats %+v, want %+v", stats, want)
+	}
+
+	stats.Hits = 5
+	stats.ParentConnects = 1
+	if rate := stats.HitRate(); rate != 0.75 {
+		t.Fatalf("got hit rate %v, want 0.75", rate)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 )
 
@@ -448,3 +449,114 @@ func (b *BlockChain) CalcStakeVersionByHash(hash *chainhash.Hash) (uint32, error
 
 	return b.calcStakeVersionByHash(hash)
 }
+
+// latestVoteVersion returns the newest vote version defined by the
+// deployments in the passed chain parameters.  Votes carrying a version newer
+// than this are cast by software that understands consensus changes this node
+// does not.
+func latestVoteVersion(params *chaincfg.Params) uint32 {
+	var latest uint32
+	for version := range params.Deployments {
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// countUnknownVoteVersions tallies the votes contained in the most recent
+// StakeVersionInterval key blocks ending with the passed node and returns the
+// total number of votes along with the number of them that carry a vote version
+// newer than the latest one known to this software.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) countUnknownVoteVersions(node *blockNode) (int32, int32, error) {
+	ourVersion := latestVoteVersion(b.chainParams)
+
+	var total, unknown int32
+	iterNode := node
+	if !iterNode.isKeyBlock {
+		var err error
+		iterNode, err = b.getPrevKeyNodeFromNode(iterNode)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	for i := int64(0); i < b.chainParams.StakeVersionInterval && iterNode != nil; i++ {
+		total += int32(len(iterNode.votes))
+		for _, v := range iterNode.votes {
+			if v.Version > ourVersion {
+				unknown++
+			}
+		}
+
+		var err error
+		iterNode, err = b.getPrevKeyNodeFromNode(iterNode)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return total, unknown, nil
+}
+
+// checkUnknownVoteVersions updates the upgrade warning exposed by
+// UnknownVoteVersionWarning when the fraction of recent votes cast with a vote
+// version newer than the latest one known to this software reaches the stake
+// majority threshold.  A warning is logged whenever the threshold is first
+// crossed.  This mirrors the block version majority checks, but for the
+// versions carried by votes, since a stake majority running newer software is
+// a strong indication new consensus rules are about to activate.
+//
+// The check is skipped while the chain is not current since the warning is
+// only meaningful for the tip and walking the interval for every key block
+// during the initial sync would be wasteful.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) checkUnknownVoteVersions(node *blockNode) {
+	if !node.isKeyBlock ||
+		node.keyHeight < b.chainParams.StakeValidationHeight ||
+		!b.isCurrent() {
+		return
+	}
+
+	total, unknown, err := b.countUnknownVoteVersions(node)
+	if err != nil {
+		log.Debugf("Unable to tally vote versions at %v: %v", node.hash,
+			err)
+		return
+	}
+	if total == 0 {
+		return
+	}
+
+	numRequired := total * b.chainParams.StakeMajorityMultiplier /
+		b.chainParams.StakeMajorityDivisor
+	if unknown < numRequired {
+		b.unknownVoteVersionWarning = ""
+		return
+	}
+
+	warning := fmt.Sprintf("%d of the last %d votes (%.2f%%) were cast "+
+		"with a vote version newer than %d -- an upgrade is "+
+		"required to follow new consensus rules", unknown, total,
+		float64(unknown)*100/float64(total),
+		latestVoteVersion(b.chainParams))
+	if b.unknownVoteVersionWarning == "" {
+		log.Warnf("UPGRADE REQUIRED: %s", warning)
+	}
+	b.unknownVoteVersionWarning = warning
+}
+
+// UnknownVoteVersionWarning returns a description of the upgrade warning
+// raised when a stake majority of recent votes carry a vote version newer than
+// the latest one known to this software.  An empty string is returned when no
+// upgrade is required.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) UnknownVoteVersionWarning() string {
+	b.chainLock.RLock()
+	warning := b.unknownVoteVersionWarning
+	b.chainLock.RUnlock()
+	return warning
+}
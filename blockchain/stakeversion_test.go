@@ -11,6 +11,7 @@ import (
 
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
 	"github.com/HcashOrg/hcashutil"
 )
 
@@ -19,6 +20,7 @@ func newFakeChain(params *chaincfg.Params) *BlockChain {
 	return &BlockChain{
 		chainParams:      params,
 		deploymentCaches: newThresholdCaches(params),
+		maxBlockSizeCache: make(map[chainhash.Hash]int64),
 		index:            make(map[chainhash.Hash]*blockNode),
 		isVoterMajorityVersionCache:   make(map[[stakeMajorityCacheKeySize]byte]bool),
 		isStakeMajorityVersionCache:   make(map[[stakeMajorityCacheKeySize]byte]bool),
@@ -1033,3 +1035,224 @@ func TestLarge(t *testing.T) {
 		}
 	}
 }
+
+// TestLatestVoteVersion ensures the latest vote version is the newest version
+// with deployments, regardless of the order of the deployments.
+func TestLatestVoteVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		deployments map[uint32][]chaincfg.ConsensusDeployment
+		want        uint32
+	}{
+		{
+			name: "no deployments",
+			want: 0,
+		},
+		{
+			name: "single version",
+			deployments: map[uint32][]chaincfg.ConsensusDeployment{
+				4: nil,
+			},
+			want: 4,
+		},
+		{
+			name: "multiple versions",
+			deployments: map[uint32][]chaincfg.ConsensusDeployment{
+				7: nil,
+				4: nil,
+				5: nil,
+			},
+			want: 7,
+		},
+	}
+
+	for _, test := range tests {
+		params := chaincfg.SimNetParams
+		params.Deployments = test.deployments
+		if got := latestVoteVersion(&params); got != test.want {
+			t.Errorf("%v: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// TestCheckUnknownVoteVersions ensures the upgrade warning is raised once the
+// stake majority of the votes in the most recent StakeVersionInterval key
+// blocks carry an unknown vote version, is cleared once that is no longer the
+// case, and is left untouched when the check does not apply.
+func TestCheckUnknownVoteVersions(t *testing.T) {
+	params := &chaincfg.SimNetParams
+	svh := params.StakeValidationHeight
+	interval := params.StakeVersionInterval
+	perBlock := int32(params.TicketsPerBlock)
+	total := int32(interval) * perBlock
+	threshold := total * params.StakeMajorityMultiplier /
+		params.StakeMajorityDivisor
+	unknownVersion := latestVoteVersion(params) + 1
+	const previous = "previous warning"
+
+	tests := []struct {
+		name        string
+		numNodes    int64 // key blocks after genesis
+		unknown     int32 // unknown votes in the window, newest first
+		beyond      bool  // unknown votes in the block before the window
+		microTip    bool  // extend the key blocks by a microblock
+		stale       bool  // tip timestamp older than 24 hours
+		wantUnknown int32
+		wantWarning string // "" cleared, previous untouched, else set
+	}{
+		{
+			name:        "no unknown votes",
+			numNodes:    svh + interval + 1,
+			wantWarning: "",
+		},
+		{
+			name:        "one below threshold",
+			numNodes:    svh + interval + 1,
+			unknown:     threshold - 1,
+			wantUnknown: threshold - 1,
+			wantWarning: "",
+		},
+		{
+			name:        "at threshold",
+			numNodes:    svh + interval + 1,
+			unknown:     threshold,
+			wantUnknown: threshold,
+			wantWarning: "set",
+		},
+		{
+			name:        "all unknown",
+			numNodes:    svh + interval + 1,
+			unknown:     total,
+			wantUnknown: total,
+			wantWarning: "set",
+		},
+		{
+			name:        "unknown votes before the window",
+			numNodes:    svh + interval + 1,
+			beyond:      true,
+			wantWarning: "",
+		},
+		{
+			name:        "at threshold below a microblock",
+			numNodes:    svh + interval + 1,
+			unknown:     threshold,
+			microTip:    true,
+			wantUnknown: threshold,
+			wantWarning: previous,
+		},
+		{
+			name:        "before stake validation height",
+			numNodes:    svh,
+			unknown:     total,
+			wantUnknown: total,
+			wantWarning: previous,
+		},
+		{
+			name:        "not current",
+			numNodes:    svh + interval + 1,
+			unknown:     total,
+			stale:       true,
+			wantUnknown: total,
+			wantWarning: previous,
+		},
+	}
+
+	for _, test := range tests {
+		bc := newFakeChain(params)
+		bc.timeSource = NewMedianTime()
+		bc.unknownVoteVersionWarning = previous
+		currentNode := genesisBlockNode(params)
+		bc.index[currentNode.hash] = currentNode
+		timestamp := time.Now()
+		if test.stale {
+			timestamp = timestamp.Add(-48 * time.Hour)
+		}
+
+		for height := int64(1); height <= test.numNodes; height++ {
+			header := &wire.BlockHeader{
+				PrevBlock:    currentNode.hash,
+				PrevKeyBlock: currentNode.hash,
+				Height:       uint32(height),
+				KeyHeight:    uint32(height - 1),
+				Timestamp:    timestamp,
+			}
+			node := newBlockNode(FakeBlockFromHeader(header), nil, nil,
+				nil)
+			node.isKeyBlock = true
+			node.keyHeight = height - 1
+			node.height = height
+			node.parent = currentNode
+
+			// Fill the unknown votes from the tip backwards.
+			depth := test.numNodes - height
+			unknown := test.unknown - int32(depth)*perBlock
+			if unknown > perBlock {
+				unknown = perBlock
+			}
+			if depth >= interval && test.beyond {
+				unknown = perBlock
+			}
+			for x := int32(0); x < perBlock; x++ {
+				version := latestVoteVersion(params)
+				if x < unknown {
+					version = unknownVersion
+				}
+				node.votes = append(node.votes,
+					VoteVersionTuple{Version: version})
+			}
+
+			currentNode = node
+			bc.bestNode = currentNode
+			bc.index[node.hash] = node
+		}
+
+		if test.microTip {
+			header := &wire.BlockHeader{
+				PrevBlock:    currentNode.hash,
+				PrevKeyBlock: currentNode.hash,
+				Height:       uint32(test.numNodes + 1),
+				KeyHeight:    uint32(test.numNodes),
+				Timestamp:    timestamp,
+			}
+			node := newBlockNode(FakeBlockFromHeader(header), nil, nil,
+				nil)
+			node.isKeyBlock = false
+			node.keyHeight = test.numNodes
+			node.height = test.numNodes + 1
+			node.parent = currentNode
+			currentNode = node
+			bc.bestNode = currentNode
+			bc.index[node.hash] = node
+		}
+
+		gotTotal, gotUnknown, err := bc.countUnknownVoteVersions(currentNode)
+		if err != nil {
+			t.Fatalf("%v: countUnknownVoteVersions: unexpected error: %v",
+				test.name, err)
+		}
+		wantTotal := total
+		if test.numNodes < interval {
+			wantTotal = int32(test.numNodes) * perBlock
+		}
+		if gotTotal != wantTotal || gotUnknown != test.wantUnknown {
+			t.Fatalf("%v: countUnknownVoteVersions: got %v of %v, want "+
+				"%v of %v", test.name, gotUnknown, gotTotal,
+				test.wantUnknown, wantTotal)
+		}
+
+		bc.checkUnknownVoteVersions(currentNode)
+		got := bc.unknownVoteVersionWarning
+		switch test.wantWarning {
+		case "", previous:
+			if got != test.wantWarning {
+				t.Fatalf("%v: got warning %q, want %q", test.name,
+					got, test.wantWarning)
+			}
+		default:
+			if got == "" || got == previous {
+				t.Fatalf("%v: got warning %q, want a new one",
+					test.name, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package standalone provides standalone functions useful for working with the
+Hypercash blockchain consensus rules.
+
+The primary goal of this package is to provide a single, well-tested home for
+the proof-of-work helpers that both the blockchain and stake packages need.
+The stake package can not import blockchain, so keeping the helpers here
+avoids maintaining separate copies that may drift apart.
+
+The functions fall into the following categories:
+
+  - Conversion between the compact "bits" target representation and big
+    integers (CompactToBig, BigToCompact)
+  - Conversion of block hashes to big integers for target comparisons
+    (HashToBig)
+  - Work and difficulty calculations (CalcWork, DifficultyRatio,
+    HashCount)
+*/
+package standalone
@@ -0,0 +1,154 @@
+// Copyright (c) 2014 The btcsuite developers
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package standalone
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+func TestBigToCompact(t *testing.T) {
+	tests := []struct {
+		in  int64
+		out uint32
+	}{
+		{0, 0},
+		{-1, 25231360},
+	}
+
+	for x, test := range tests {
+		n := big.NewInt(test.in)
+		r := BigToCompact(n)
+		if r != test.out {
+			t.Errorf("TestBigToCompact test #%d failed: got %d want %d\n",
+				x, r, test.out)
+			return
+		}
+	}
+}
+
+func TestCompactToBig(t *testing.T) {
+	tests := []struct {
+		in  uint32
+		out int64
+	}{
+		{10000000, 0},
+	}
+
+	for x, test := range tests {
+		n := CompactToBig(test.in)
+		want := big.NewInt(test.out)
+		if n.Cmp(want) != 0 {
+			t.Errorf("TestCompactToBig test #%d failed: got %d want %d\n",
+				x, n.Int64(), want.Int64())
+			return
+		}
+	}
+}
+
+// TestCompactRoundTrip ensures targets survive a round trip through the
+// compact representation.
+func TestCompactRoundTrip(t *testing.T) {
+	tests := []uint32{
+		0x1d00ffff, // bitcoin genesis
+		0x1b0404cb,
+		0x1900896c,
+		0x207fffff, // regression test limit
+		0x01003456,
+		0x02008000,
+		0x04923456,
+	}
+
+	for x, bits := range tests {
+		n := CompactToBig(bits)
+		got := BigToCompact(n)
+		if CompactToBig(got).Cmp(n) != 0 {
+			t.Errorf("TestCompactRoundTrip test #%d failed: %08x "+
+				"round tripped to %08x", x, bits, got)
+		}
+	}
+}
+
+func TestHashToBig(t *testing.T) {
+	hash, err := chainhash.NewHashFromStr("00000000000000000000000000" +
+		"00000000000000000000000000000000000001")
+	if err != nil {
+		t.Fatalf("NewHashFromStr: %v", err)
+	}
+	if got := HashToBig(hash); got.Cmp(bigOne) != 0 {
+		t.Fatalf("HashToBig: got %v, want 1", got)
+	}
+
+	// The hash must not be modified.
+	if hash[0] != 0x01 {
+		t.Fatalf("HashToBig modified the passed hash")
+	}
+}
+
+func TestCalcWork(t *testing.T) {
+	tests := []struct {
+		in  uint32
+		out int64
+	}{
+		{10000000, 0},
+	}
+
+	for x, test := range tests {
+		bits := uint32(test.in)
+
+		r := CalcWork(bits)
+		if r.Int64() != test.out {
+			t.Errorf("TestCalcWork test #%d failed: got %v want %d\n",
+				x, r.Int64(), test.out)
+			return
+		}
+	}
+}
+
+func TestDifficultyRatio(t *testing.T) {
+	const powLimitBits = 0x1d00ffff
+
+	tests := []struct {
+		bits uint32
+		want float64
+	}{
+		{powLimitBits, 1},
+		{0x1c7fff80, 2},
+		{0x1b0404cb, 16307.42093852},
+	}
+
+	for x, test := range tests {
+		got, err := DifficultyRatio(test.bits, powLimitBits)
+		if err != nil {
+			t.Errorf("TestDifficultyRatio test #%d: unexpected "+
+				"error: %v", x, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("TestDifficultyRatio test #%d failed: got %v "+
+				"want %v", x, got, test.want)
+		}
+	}
+
+	if _, err := DifficultyRatio(0, powLimitBits); err == nil {
+		t.Errorf("TestDifficultyRatio: expected error for zero target")
+	}
+}
+
+func TestHashCount(t *testing.T) {
+	// A target of 2^255 is met by half of all hashes.
+	bits := BigToCompact(new(big.Int).Lsh(bigOne, 255))
+	got, err := HashCount(bits)
+	if err != nil {
+		t.Fatalf("HashCount: unexpected error: %v", err)
+	}
+	if got != 2 {
+		t.Fatalf("HashCount: got %v, want 2", got)
+	}
+}
@@ -508,11 +508,62 @@ func (b *BlockChain) deploymentState(prevNode *blockNode, version uint32, deploy
 	return invalidState, DeploymentError(deploymentID)
 }
 
+// deploymentVersion returns the highest stake version which defines a
+// deployment with the given ID.  The second return value is false when no stake
+// version defines the deployment.
+func (b *BlockChain) deploymentVersion(deploymentID string) (uint32, bool) {
+	var version uint32
+	var found bool
+	for v, deployments := range b.chainParams.Deployments {
+		if found && v < version {
+			continue
+		}
+		for k := range deployments {
+			if deployments[k].Vote.Id == deploymentID {
+				version = v
+				found = true
+				break
+			}
+		}
+	}
+	return version, found
+}
+
 // ThresholdState returns the current rule change threshold state of the given
-// deployment ID for the block AFTER the provided block hash.
+// deployment ID for the block at the end of the current best chain.  The
+// deployment is looked up in the highest stake version that defines it.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ThresholdState(deploymentID string) (ThresholdStateTuple, error) {
+	invalidState := ThresholdStateTuple{
+		State:  ThresholdInvalid,
+		Choice: invalidChoice,
+	}
+	version, ok := b.deploymentVersion(deploymentID)
+	if !ok {
+		return invalidState, DeploymentError(deploymentID)
+	}
+
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	// The state of the best block is the state for the block after its
+	// parent.  The genesis block has the defined state by definition.
+	prevNode, err := b.getPrevNodeFromNode(b.bestNode)
+	if err != nil {
+		return invalidState, err
+	}
+	if prevNode == nil {
+		return newThresholdState(ThresholdDefined, invalidChoice), nil
+	}
+	return b.deploymentState(prevNode, version, deploymentID)
+}
+
+// NextThresholdState returns the current rule change threshold state of the
+// given deployment ID for the block AFTER the provided block hash.
 //
 // This function is safe for concurrent access.
-func (b *BlockChain) ThresholdState(hash *chainhash.Hash, version uint32, deploymentID string) (ThresholdStateTuple, error) {
+func (b *BlockChain) NextThresholdState(hash *chainhash.Hash, version uint32, deploymentID string) (ThresholdStateTuple, error) {
 	b.chainLock.Lock()
 	node, ok := b.index[*hash]
 	b.chainLock.Unlock()
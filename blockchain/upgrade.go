@@ -9,6 +9,7 @@ package blockchain
 import (
 	"github.com/HcashOrg/hcashd/blockchain/internal/progresslog"
 	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
 )
@@ -60,7 +61,7 @@ func (b *BlockChain) upgradeToVersion2() error {
 			// Iteratively connect the stake nodes in memory.
 			header := block.MsgBlock().Header
 			headerHash := header.BlockHash()
-			isKeyBlock := HashToBig(&headerHash).Cmp(CompactToBig(header.Bits)) <= 0
+			isKeyBlock := standalone.HashToBig(&headerHash).Cmp(standalone.CompactToBig(header.Bits)) <= 0
 			bestStakeNode, errLocal = bestStakeNode.ConnectNode(header,
 				ticketsSpentInBlock(block), ticketsRevokedInBlock(block),
 				newTickets, isKeyBlock)
@@ -0,0 +1,314 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/HcashOrg/hcashd/blockchain/internal/dbnamespace"
+	"github.com/HcashOrg/hcashd/blockchain/internal/muhash"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/database"
+)
+
+// errInterruptRequested indicates that an operation was cancelled due to a
+// user-requested interrupt.
+var errInterruptRequested = errors.New("interrupt requested")
+
+// -----------------------------------------------------------------------------
+// The utxo set statistics are maintained in a single key of the metadata bucket
+// which is updated in the same database transaction as the utxo set whenever a
+// block is connected or disconnected, so they always describe the utxo set of
+// the best chain state.
+//
+// The serialized format is:
+//
+//   <num txns><num utxos><total amount><serialized size><set hash state>
+//
+//   Field             Type     Size
+//   num txns          uint64   8 bytes
+//   num utxos         uint64   8 bytes
+//   total amount      int64    8 bytes
+//   serialized size   uint64   8 bytes
+//   set hash state    []byte   muhash.SerializedSize
+//
+// The set hash is a MuHash of the serializations of every unspent output as
+// created by utxoSetElement, so it only depends on the contents of the utxo set
+// and not on the order the outputs were added and removed in.
+// -----------------------------------------------------------------------------
+
+// utxoSetStatsSize is the size of the serialized utxo set statistics.
+const utxoSetStatsSize = 8 + 8 + 8 + 8 + muhash.SerializedSize
+
+// UtxoSetStats describes the unspent transaction output set of the main chain
+// at a block.
+type UtxoSetStats struct {
+	Height         int64
+	Hash           chainhash.Hash
+	Transactions   uint64
+	Utxos          uint64
+	TotalAmount    int64
+	SerializedSize uint64
+	SetHash        chainhash.Hash
+}
+
+// utxoSetStats houses the incrementally maintained utxo set statistics.
+type utxoSetStats struct {
+	numTxns        uint64
+	numUtxos       uint64
+	totalAmount    int64
+	serializedSize uint64
+	setHash        *muhash.MuHash
+}
+
+// newUtxoSetStats returns the statistics of an empty utxo set.
+func newUtxoSetStats() *utxoSetStats {
+	return &utxoSetStats{setHash: muhash.New()}
+}
+
+// serializeUtxoSetStats returns the passed statistics serialized according to
+// the format described above.
+func serializeUtxoSetStats(stats *utxoSetStats) []byte {
+	serialized := make([]byte, utxoSetStatsSize)
+	byteOrder.PutUint64(serialized[0:8], stats.numTxns)
+	byteOrder.PutUint64(serialized[8:16], stats.numUtxos)
+	byteOrder.PutUint64(serialized[16:24], uint64(stats.totalAmount))
+	byteOrder.PutUint64(serialized[24:32], stats.serializedSize)
+	copy(serialized[32:], stats.setHash.Serialize())
+	return serialized
+}
+
+// deserializeUtxoSetStats decodes the passed serialized statistics according
+// to the format described above.
+func deserializeUtxoSetStats(serialized []byte) (*utxoSetStats, error) {
+	if len(serialized) != utxoSetStatsSize {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt utxo set statistics "+
+				"size; want %d got %d", utxoSetStatsSize,
+				len(serialized)),
+		}
+	}
+
+	setHash, err := muhash.Deserialize(serialized[32:])
+	if err != nil {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt utxo set hash: %v",
+				err),
+		}
+	}
+	return &utxoSetStats{
+		numTxns:        byteOrder.Uint64(serialized[0:8]),
+		numUtxos:       byteOrder.Uint64(serialized[8:16]),
+		totalAmount:    int64(byteOrder.Uint64(serialized[16:24])),
+		serializedSize: byteOrder.Uint64(serialized[24:32]),
+		setHash:        setHash,
+	}, nil
+}
+
+// dbFetchUtxoSetStats uses an existing database transaction to fetch the utxo
+// set statistics.  Nil is returned for both the statistics and the error when
+// they were not created yet.
+func dbFetchUtxoSetStats(dbTx database.Tx) (*utxoSetStats, error) {
+	serialized := dbTx.Metadata().Get(dbnamespace.UtxoSetStatsKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+	return deserializeUtxoSetStats(serialized)
+}
+
+// dbPutUtxoSetStats uses an existing database transaction to store the passed
+// utxo set statistics.
+func dbPutUtxoSetStats(dbTx database.Tx, stats *utxoSetStats) error {
+	return dbTx.Metadata().Put(dbnamespace.UtxoSetStatsKeyName,
+		serializeUtxoSetStats(stats))
+}
+
+// utxoSetElement returns the serialization of the passed unspent output which
+// is added to the utxo set hash.  The script is always serialized decompressed
+// so the element does not depend on how the output was loaded.
+func utxoSetElement(txHash *chainhash.Hash, entry *UtxoEntry, outputIndex uint32, output *utxoOutput) []byte {
+	pkScript := output.pkScript
+	if output.compressed {
+		pkScript = decompressScript(pkScript, currentCompressionVersion)
+	}
+
+	element := make([]byte, chainhash.HashSize+4+2+4+4+1+8+2+len(pkScript))
+	copy(element, txHash[:])
+	offset := chainhash.HashSize
+	byteOrder.PutUint32(element[offset:], outputIndex)
+	offset += 4
+	byteOrder.PutUint16(element[offset:], entry.txVersion)
+	offset += 2
+	byteOrder.PutUint32(element[offset:], entry.height)
+	offset += 4
+	byteOrder.PutUint32(element[offset:], entry.index)
+	offset += 4
+	element[offset] = encodeFlags(entry.isCoinBase, entry.hasExpiry,
+		entry.txType, false)
+	offset++
+	byteOrder.PutUint64(element[offset:], uint64(output.amount))
+	offset += 8
+	byteOrder.PutUint16(element[offset:], output.scriptVersion)
+	offset += 2
+	copy(element[offset:], pkScript)
+	return element
+}
+
+// addEntry adds the unspent outputs of the passed utxo entry, which has the
+// passed serialization, to the statistics.
+func (s *utxoSetStats) addEntry(txHash *chainhash.Hash, entry *UtxoEntry, serialized []byte) {
+	s.numTxns++
+	s.serializedSize += uint64(len(serialized))
+	for outputIndex, output := range entry.sparseOutputs {
+		if output.spent {
+			continue
+		}
+		s.numUtxos++
+		s.totalAmount += output.amount
+		s.setHash.Add(utxoSetElement(txHash, entry, outputIndex, output))
+	}
+}
+
+// updateEntry updates the statistics for the utxo entry of the passed
+// transaction changing from the passed old entry and serialization to the
+// passed new entry and serialization.  Either entry may be nil when the
+// transaction had or has no unspent outputs.  Outputs which are the same in
+// both entries are left alone.
+func (s *utxoSetStats) updateEntry(txHash *chainhash.Hash, oldEntry *UtxoEntry, oldSerialized []byte, newEntry *UtxoEntry, newSerialized []byte) {
+	if oldEntry != nil {
+		s.numTxns--
+		s.serializedSize -= uint64(len(oldSerialized))
+	}
+	if newEntry != nil && newSerialized != nil {
+		s.numTxns++
+		s.serializedSize += uint64(len(newSerialized))
+	}
+
+	// Collect the elements of the unspent outputs after the update.
+	newElements := make(map[uint32][]byte)
+	if newEntry != nil {
+		for outputIndex, output := range newEntry.sparseOutputs {
+			if output.spent {
+				continue
+			}
+			newElements[outputIndex] = utxoSetElement(txHash, newEntry,
+				outputIndex, output)
+		}
+	}
+
+	// Remove the outputs which were spent or changed.
+	if oldEntry != nil {
+		for outputIndex, output := range oldEntry.sparseOutputs {
+			if output.spent {
+				continue
+			}
+			element := utxoSetElement(txHash, oldEntry, outputIndex,
+				output)
+			if newElement, ok := newElements[outputIndex]; ok &&
+				bytes.Equal(element, newElement) {
+
+				delete(newElements, outputIndex)
+				continue
+			}
+			s.numUtxos--
+			s.totalAmount -= output.amount
+			s.setHash.Remove(element)
+		}
+	}
+
+	// Add the outputs which were created or changed.
+	for outputIndex, element := range newElements {
+		s.numUtxos++
+		s.totalAmount += newEntry.sparseOutputs[outputIndex].amount
+		s.setHash.Add(element)
+	}
+}
+
+// initUtxoSetStats creates the utxo set statistics by scanning the whole utxo
+// set when the database does not contain them yet, such as when it was created
+// by an older version.  The scan can be interrupted through the passed channel
+// in which case it is done again on the next start.
+func (b *BlockChain) initUtxoSetStats(interrupt <-chan struct{}) error {
+	var exists bool
+	err := b.db.View(func(dbTx database.Tx) error {
+		exists = dbTx.Metadata().Get(dbnamespace.UtxoSetStatsKeyName) != nil
+		return nil
+	})
+	if err != nil || exists {
+		return err
+	}
+
+	log.Infof("Creating the utxo set statistics.  This might take a while...")
+	return b.db.Update(func(dbTx database.Tx) error {
+		stats := newUtxoSetStats()
+		utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
+		err := utxoBucket.ForEach(func(k, v []byte) error {
+			select {
+			case <-interrupt:
+				return errInterruptRequested
+			default:
+			}
+
+			var txHash chainhash.Hash
+			copy(txHash[:], k)
+			entry, err := deserializeUtxoEntry(v)
+			if err != nil {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt utxo "+
+						"entry for %v: %v", txHash, err),
+				}
+			}
+			stats.addEntry(&txHash, entry, v)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Infof("Created the utxo set statistics for %d unspent "+
+			"outputs of %d transactions", stats.numUtxos,
+			stats.numTxns)
+		return dbPutUtxoSetStats(dbTx, stats)
+	})
+}
+
+// UtxoSetStats returns the statistics of the utxo set of the current best
+// block of the main chain.  The statistics are maintained as blocks are
+// connected and disconnected, so the utxo set does not need to be scanned.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) UtxoSetStats() (*UtxoSetStats, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	var stats *utxoSetStats
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		stats, err = dbFetchUtxoSetStats(dbTx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		return nil, AssertError("utxo set statistics are not initialized")
+	}
+
+	best := b.BestSnapshot()
+	return &UtxoSetStats{
+		Height:         best.Height,
+		Hash:           *best.Hash,
+		Transactions:   stats.numTxns,
+		Utxos:          stats.numUtxos,
+		TotalAmount:    stats.totalAmount,
+		SerializedSize: stats.serializedSize,
+		SetHash:        stats.setHash.Finalize(),
+	}, nil
+}
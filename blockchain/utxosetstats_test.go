@@ -0,0 +1,97 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// checkUtxoSetStats ensures the passed statistics match the expected ones.
+func checkUtxoSetStats(t *testing.T, name string, got, want *utxoSetStats) {
+	if got.numTxns != want.numTxns || got.numUtxos != want.numUtxos ||
+		got.totalAmount != want.totalAmount ||
+		got.serializedSize != want.serializedSize {
+
+		t.Fatalf("%s: got %d txns, %d utxos, amount %d, size %d, want "+
+			"%d txns, %d utxos, amount %d, size %d", name,
+			got.numTxns, got.numUtxos, got.totalAmount,
+			got.serializedSize, want.numTxns, want.numUtxos,
+			want.totalAmount, want.serializedSize)
+	}
+	if got.setHash.Finalize() != want.setHash.Finalize() {
+		t.Fatalf("%s: mismatched set hash", name)
+	}
+}
+
+// TestUtxoSetStatsUpdate ensures incrementally updating the utxo set
+// statistics as outputs are spent results in the same statistics as adding
+// the resulting entries from scratch, and that they survive a serialization
+// round trip.
+func TestUtxoSetStatsUpdate(t *testing.T) {
+	pkScript, _ := hex.DecodeString("76a9146edbc6c4d31bae9f1ccc38538a114bf" +
+		"42de65e8688ac")
+	txHash := chainhash.HashH([]byte("utxosetstats"))
+	entry := newUtxoEntry(1, 100, 2, false, false, stake.TxTypeRegular)
+	entry.sparseOutputs[0] = &utxoOutput{amount: 5000, pkScript: pkScript}
+	entry.sparseOutputs[1] = &utxoOutput{amount: 7000, pkScript: pkScript}
+
+	// Add the new entry.
+	serialized, err := serializeUtxoEntry(entry)
+	if err != nil {
+		t.Fatalf("serializeUtxoEntry: unexpected error: %v", err)
+	}
+	stats := newUtxoSetStats()
+	stats.updateEntry(&txHash, nil, nil, entry, serialized)
+	want := newUtxoSetStats()
+	want.addEntry(&txHash, entry, serialized)
+	checkUtxoSetStats(t, "add", stats, want)
+
+	// Spend one of the outputs.  The old entry is loaded from the database
+	// serialization, so its scripts are compressed.
+	oldEntry, err := deserializeUtxoEntry(serialized)
+	if err != nil {
+		t.Fatalf("deserializeUtxoEntry: unexpected error: %v", err)
+	}
+	entry.SpendOutput(0)
+	spentSerialized, err := serializeUtxoEntry(entry)
+	if err != nil {
+		t.Fatalf("serializeUtxoEntry: unexpected error: %v", err)
+	}
+	stats.updateEntry(&txHash, oldEntry, serialized, entry, spentSerialized)
+	spentEntry, err := deserializeUtxoEntry(spentSerialized)
+	if err != nil {
+		t.Fatalf("deserializeUtxoEntry: unexpected error: %v", err)
+	}
+	want = newUtxoSetStats()
+	want.addEntry(&txHash, spentEntry, spentSerialized)
+	checkUtxoSetStats(t, "spend one", stats, want)
+
+	// Spending the remaining output must result in the statistics of the
+	// empty set.
+	entry.SpendOutput(1)
+	stats.updateEntry(&txHash, spentEntry, spentSerialized, entry, nil)
+	checkUtxoSetStats(t, "spend all", stats, newUtxoSetStats())
+
+	// Ensure the statistics survive a serialization round trip.
+	stats.updateEntry(&txHash, nil, nil, spentEntry, spentSerialized)
+	restored, err := deserializeUtxoSetStats(serializeUtxoSetStats(stats))
+	if err != nil {
+		t.Fatalf("deserializeUtxoSetStats: unexpected error: %v", err)
+	}
+	checkUtxoSetStats(t, "round trip", restored, want)
+	if !bytes.Equal(serializeUtxoSetStats(restored),
+		serializeUtxoSetStats(stats)) {
+
+		t.Fatal("restored statistics serialize differently")
+	}
+	if _, err := deserializeUtxoSetStats(nil); err == nil {
+		t.Fatal("deserializeUtxoSetStats: accepted empty serialization")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 
 	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
 	"github.com/HcashOrg/hcashd/txscript"
@@ -499,7 +500,7 @@ func (b *BlockChain) connectTransactions(view *UtxoViewpoint, block *hcashutil.B
 		votebits := mBlock.Header.VoteBits
 		regularTxTreeValid := hcashutil.IsFlagSet16(votebits, hcashutil.BlockValid)
 		if !regularTxTreeValid{
-			regularTxTreeValid = HashToBig(parent.Hash()).Cmp(CompactToBig(parent.MsgBlock().Header.Bits)) > 0
+			regularTxTreeValid = standalone.HashToBig(parent.Hash()).Cmp(standalone.CompactToBig(parent.MsgBlock().Header.Bits)) > 0
 		}
 
 		if regularTxTreeValid {
@@ -656,7 +657,7 @@ func (b *BlockChain) disconnectTransactions(view *UtxoViewpoint,
 		// validated. Otherwise, these transactions were never in the blockchain's
 		// history in the first place.
 		if !regularTxTreeValid {
-			regularTxTreeValid = HashToBig(parent.Hash()).Cmp(CompactToBig(parent.MsgBlock().Header.Bits)) > 0
+			regularTxTreeValid = standalone.HashToBig(parent.Hash()).Cmp(standalone.CompactToBig(parent.MsgBlock().Header.Bits)) > 0
 		}
 
 
@@ -668,7 +669,7 @@ func (b *BlockChain) disconnectTransactions(view *UtxoViewpoint,
 			}
 
 			isPrevKeyBlock := false
-			if HashToBig(parent.Hash()).Cmp(CompactToBig(parent.MsgBlock().Header.Bits)) <= 0 {
+			if standalone.HashToBig(parent.Hash()).Cmp(standalone.CompactToBig(parent.MsgBlock().Header.Bits)) <= 0 {
 				isPrevKeyBlock = true
 			}
 
@@ -966,7 +967,7 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB,
 	// added so we can validate that.
 	if viewpoint == ViewpointPrevRegular {
 		offset := 1
-		if HashToBig(parent.Hash()).Cmp(CompactToBig(parent.MsgBlock().Header.Bits)) <= 0{
+		if standalone.HashToBig(parent.Hash()).Cmp(standalone.CompactToBig(parent.MsgBlock().Header.Bits)) <= 0 {
 			offset = 2
 		}
 
@@ -1069,7 +1070,7 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB,
 			txInFlight[*tx.Hash()] = i
 		}
 		offset := 1
-		if HashToBig(block.Hash()).Cmp(CompactToBig(block.MsgBlock().Header.Bits)) <= 0{
+		if standalone.HashToBig(block.Hash()).Cmp(standalone.CompactToBig(block.MsgBlock().Header.Bits)) <= 0 {
 			offset = 2
 		}
 		// Loop through all of the transaction inputs (except for the coinbase
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
@@ -320,7 +321,7 @@ func checkProofOfWork(chain *BlockChain, header *wire.BlockHeader, powLimit *big
 	microBlockValidationHeight uint32, flags BehaviorFlags) error {
 	// The target difficulty must be larger than zero.
 
-	target := CompactToBig(header.Bits)
+	target := standalone.CompactToBig(header.Bits)
 	if target.Sign() <= 0 {
 		str := fmt.Sprintf("block target difficulty of %064x is too "+
 			"low", target)
@@ -341,7 +342,7 @@ func checkProofOfWork(chain *BlockChain, header *wire.BlockHeader, powLimit *big
 	if flags&BFNoPoWCheck != BFNoPoWCheck {
 		// The block hash must be less than the claimed target.
 		hash := header.BlockHash()
-		hashNum := HashToBig(&hash)
+		hashNum := standalone.HashToBig(&hash)
 		//prevKeyHash := header.PrevKeyBlock
 		//prevKeyBlock, err := chain.fetchBlockFromHash(&prevKeyHash)
 		//prevKeyBlockHeight := int64(0)
@@ -501,8 +502,8 @@ func checkBlockSanity(chain *BlockChain, block *hcashutil.Block, timeSource Medi
 		}
 		txStart = 2
 	}else {
-		targetDifficulty := CompactToBig(header.Bits)
-		if HashToBig(block.Hash()).Cmp(targetDifficulty) <= 0 {
+		targetDifficulty := standalone.CompactToBig(header.Bits)
+		if standalone.HashToBig(block.Hash()).Cmp(targetDifficulty) <= 0 {
 			//is key block
 			if !IsCoinBaseTx(transactions[1].MsgTx()) {
 				return ruleError(ErrFirstTxNotCoinbase, "first transaction in "+
@@ -542,7 +543,7 @@ func checkBlockSanity(chain *BlockChain, block *hcashutil.Block, timeSource Medi
 	totalTickets := 0
 	totalVotes := 0
 	totalRevocations := 0
-	//isKeyBlock := HashToBig(block.Hash()).Cmp(CompactToBig(block.MsgBlock().Header.Bits)) <= 0
+	//isKeyBlock := standalone.HashToBig(block.Hash()).Cmp(standalone.CompactToBig(block.MsgBlock().Header.Bits)) <= 0
 
 	if isKeyBlock {
 		for _, stx := range block.MsgBlock().STransactions {
@@ -765,7 +766,7 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 	// Ensure the votebits & 0x1 is same as the parent block if the parent block is a microblock
 
 	if header.Height > 1 && (header.VoteBits & hcashutil.BlockValid) != (prevNode.header.VoteBits & hcashutil.BlockValid) {
-		if HashToBig(&(prevNode.hash)).Cmp(CompactToBig(prevNode.header.Bits)) > 0 {
+		if standalone.HashToBig(&(prevNode.hash)).Cmp(standalone.CompactToBig(prevNode.header.Bits)) > 0 {
 			str := fmt.Sprintf("VoteBits of block %v is not same as the parent micro block %v", header.BlockHash(), prevNode.hash)
 			return ruleError(ErrVoteBitsNotCompatible, str)
 		}
@@ -868,7 +869,7 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 // Hypercash: Check the stake transactions to make sure they don't have this txid
 // too.
 func (b *BlockChain) checkDupTxs(txSet []*hcashutil.Tx, view *UtxoViewpoint) error {
-	if !chaincfg.CheckForDuplicateHashes {
+	if !b.checkForDuplicateHashes {
 		return nil
 	}
 
@@ -2553,6 +2554,21 @@ func (b *BlockChain) checkTransactionsAndConnect(subsidyCache *SubsidyCache, inp
 	return nil
 }
 
+// blockScriptFlags returns the script flags used to validate the input scripts
+// of the transactions in blocks.
+func (b *BlockChain) blockScriptFlags() txscript.ScriptFlags {
+	scriptFlags := txscript.ScriptBip16 |
+		txscript.ScriptVerifyDERSignatures |
+		txscript.ScriptVerifyStrictEncoding |
+		txscript.ScriptVerifyMinimalData |
+		txscript.ScriptVerifyCleanStack |
+		txscript.ScriptVerifyCheckLockTimeVerify
+	if b.sigHashOptimization {
+		scriptFlags |= txscript.ScriptSigHashOptimization
+	}
+	return scriptFlags
+}
+
 // checkConnectBlock performs several checks to confirm connecting the passed
 // block to the chain represented by the passed view does not violate any
 // rules.  In addition, the passed view is updated to spend all of the
@@ -2632,12 +2648,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *hcashutil.Block,
 	}
 	var scriptFlags txscript.ScriptFlags
 	if runScripts {
-		scriptFlags |= txscript.ScriptBip16
-		scriptFlags |= txscript.ScriptVerifyDERSignatures
-		scriptFlags |= txscript.ScriptVerifyStrictEncoding
-		scriptFlags |= txscript.ScriptVerifyMinimalData
-		scriptFlags |= txscript.ScriptVerifyCleanStack
-		scriptFlags |= txscript.ScriptVerifyCheckLockTimeVerify
+		scriptFlags = b.blockScriptFlags()
 	}
 
 	// The number of signature operations must be less than the maximum
@@ -2653,7 +2664,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *hcashutil.Block,
 	//thisNodeRegularViewpoint := ViewpointCurrentRegular
 
 	if !regularTxTreeValid {
-		regularTxTreeValid = HashToBig(parentBlock.Hash()).Cmp(CompactToBig(parentBlock.MsgBlock().Header.Bits)) > 0
+		regularTxTreeValid = standalone.HashToBig(parentBlock.Hash()).Cmp(standalone.CompactToBig(parentBlock.MsgBlock().Header.Bits)) > 0
 	}
 
 	if regularTxTreeValid {
@@ -2705,8 +2716,15 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *hcashutil.Block,
 	}
 
 	if runScripts {
+		aggregateVotes := false
+		if node.isKeyBlock {
+			aggregateVotes, err = b.isVoteSigAggregationActive(node.parent)
+			if err != nil {
+				return err
+			}
+		}
 		err = checkBlockScripts(block, utxoView, false, scriptFlags,
-			b.sigCache)
+			b.sigCache, b.sigVerifyConcurrency, aggregateVotes)
 		if err != nil {
 			log.Tracef("checkBlockScripts failed; error returned "+
 				"on txtreestake of cur block: %v", err)
@@ -2739,7 +2757,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *hcashutil.Block,
 
 	if runScripts {
 		err = checkBlockScripts(block, utxoView, true,
-			scriptFlags, b.sigCache)
+			scriptFlags, b.sigCache, b.sigVerifyConcurrency, false)
 		if err != nil {
 			log.Tracef("checkBlockScripts failed; error returned "+
 				"on txtreeregular of cur block: %v", err)
@@ -2777,6 +2795,61 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *hcashutil.Block,
 	return nil
 }
 
+// CheckConnectBlockTemplate fully validates that connecting the passed block to
+// the current tip of the main chain does not violate any consensus rules aside
+// from the proof of work requirement, without connecting it.  Since there is
+// no proof of work, the block is validated as the template of a key block just
+// like the block templates generated for mining.  A rule error with
+// ErrInvalidTemplateParent is returned when the block does not build on the
+// current tip.
+//
+// This is intended to allow miners to sanity check the blocks they are about
+// to mine, such as the proposals of the getblocktemplate RPC, before expending
+// any hash power on them.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CheckConnectBlockTemplate(block *hcashutil.Block) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	// Skip the proof of work check as this is just a block template.
+	flags := BFNoPoWCheck
+
+	// This only checks whether the block can be connected to the tip of
+	// the current chain.
+	tip := b.bestNode
+	header := &block.MsgBlock().Header
+	if header.PrevBlock != tip.hash {
+		str := fmt.Sprintf("previous block must be the current chain "+
+			"tip %v, but got %v", tip.hash, header.PrevBlock)
+		return ruleError(ErrInvalidTemplateParent, str)
+	}
+
+	err := checkBlockSanity(b, block, b.timeSource, flags, b.chainParams)
+	if err != nil {
+		return err
+	}
+	err = b.checkBlockContext(block, tip, flags)
+	if err != nil {
+		return err
+	}
+
+	keyHeightCache := make(map[int64]int64)
+	keyHeightCache[block.Height()] = int64(header.KeyHeight)
+
+	newNode := newBlockNode(block,
+		ticketsSpentInBlock(block),
+		ticketsRevokedInBlock(block),
+		voteBitsInBlock(block))
+	newNode.parent = tip
+	newNode.workSum.Add(tip.workSum, newNode.workSum)
+
+	view := NewUtxoViewpoint()
+	view.SetBestHash(&tip.hash)
+	return b.checkConnectBlock(newNode, block, view, nil, true,
+		keyHeightCache)
+}
+
 // CheckConnectBlock performs several checks to confirm connecting the passed
 // block to the main chain does not violate any rules.  An example of some of
 // the checks performed are ensuring connecting the block would not cause any
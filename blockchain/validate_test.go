@@ -9,6 +9,7 @@ import (
 	"compress/bzip2"
 	"encoding/gob"
 	"encoding/hex"
+	"fmt"
 	"math"
 	"math/big"
 	"os"
@@ -17,6 +18,7 @@ import (
 	"time"
 
 	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/blockchain/chaingen"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
@@ -2395,3 +2397,94 @@ var badBlock = wire.MsgBlock{
 	Transactions:  []*wire.MsgTx{},
 	STransactions: []*wire.MsgTx{},
 }
+
+// TestCheckConnectBlockTemplate ensures a valid template for the next block is
+// accepted without being connected, and templates which do not build on the
+// current tip or violate the consensus rules are rejected.
+func TestCheckConnectBlockTemplate(t *testing.T) {
+	params := &chaincfg.SimNetParams
+	g, err := chaingen.MakeGenerator(params)
+	if err != nil {
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+	chain, teardownFunc, err := blockchain.SetupTestChain("connecttemplate",
+		params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// accepted processes the tip block of the generator and expects it to
+	// be accepted.
+	accepted := func() {
+		msgBlock := g.Tip()
+		block := hcashutil.NewBlock(msgBlock)
+		_, _, err := chain.ProcessBlock(block, blockchain.BFNone)
+		if err != nil {
+			t.Fatalf("block %q (hash %s, height %d) should have "+
+				"been accepted: %v", g.TipName(), block.Hash(),
+				msgBlock.Header.Height, err)
+		}
+	}
+
+	// rejected checks the tip block of the generator as a template and
+	// expects it to be rejected with the passed error code.
+	rejected := func(code blockchain.ErrorCode) {
+		block := hcashutil.NewBlock(g.Tip())
+		err := chain.CheckConnectBlockTemplate(block)
+		rerr, ok := err.(blockchain.RuleError)
+		if !ok || rerr.ErrorCode != code {
+			t.Fatalf("template %q: got error %v, want %v", g.TipName(),
+				err, code)
+		}
+	}
+
+	//   genesis -> bp -> bm0 -> ... -> bm#
+	g.CreatePremineBlock("bp", 0)
+	accepted()
+	for i := uint16(0); i < params.CoinbaseMaturity; i++ {
+		g.NextBlock(fmt.Sprintf("bm%d", i), nil, nil)
+		g.SaveTipCoinbaseOuts()
+		accepted()
+	}
+	tipName := g.TipName()
+	best := chain.BestSnapshot()
+
+	// A template for the next block is valid, but not connected.
+	//
+	//   ... -> bm# -> bt0
+	g.NextBlock("bt0", nil, nil)
+	err = chain.CheckConnectBlockTemplate(hcashutil.NewBlock(g.Tip()))
+	if err != nil {
+		t.Fatalf("template bt0: unexpected error: %v", err)
+	}
+	if got := chain.BestSnapshot(); *got.Hash != *best.Hash {
+		t.Fatalf("template bt0: best block changed from %v to %v",
+			best.Hash, got.Hash)
+	}
+
+	// A template on the tip which violates the consensus rules is rejected.
+	//
+	//   ... -> bm# -> bt1
+	g.SetTip(tipName)
+	g.NextBlock("bt1", nil, nil, func(b *wire.MsgBlock) {
+		b.Header.Height++
+	})
+	rejected(blockchain.ErrBadBlockHeight)
+
+	//   ... -> bm# -> bt2
+	g.SetTip(tipName)
+	g.NextBlock("bt2", nil, nil, func(b *wire.MsgBlock) {
+		// The first coinbase output pays the organization.
+		b.Transactions[0].TxOut[0].Value++
+	})
+	rejected(blockchain.ErrNoTax)
+
+	// A template which does not build on the tip is rejected even though
+	// it is otherwise valid.
+	//
+	//   ... -> bm0 -> bstale
+	g.SetTip("bm0")
+	g.NextBlock("bstale", nil, nil)
+	rejected(blockchain.ErrInvalidTemplateParent)
+}
@@ -0,0 +1,374 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/database"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// VerifyLevel defines how thorough the checks performed by VerifyChain are.
+// Each level also performs the checks of all of the levels below it.
+type VerifyLevel int
+
+const (
+	// VerifyLoad ensures each block can be loaded from the database.
+	VerifyLoad VerifyLevel = iota
+
+	// VerifyHeaderSanity performs the context-free sanity checks on the
+	// header of each block, such as the proof of work and timestamp
+	// checks.
+	VerifyHeaderSanity
+
+	// VerifyBlockSanity performs the context-free sanity checks on each
+	// whole block, such as the merkle root and transaction sanity checks.
+	VerifyBlockSanity
+
+	// VerifyUndoData ensures the spend journal entry of each block, which
+	// is the data used to undo the block during a reorganize, can be
+	// loaded and is consistent with the outputs the block spends.
+	VerifyUndoData
+
+	// VerifyScripts executes the input scripts of the transactions of each
+	// block again against the outputs they spend, which are restored from
+	// the spend journal.
+	VerifyScripts
+)
+
+// MaxVerifyLevel is the most thorough verification level.  Higher levels
+// passed to VerifyChain are treated as this one.
+const MaxVerifyLevel = VerifyScripts
+
+// verifyLevelStrings is a map of verification levels back to their constant
+// names for pretty printing.
+var verifyLevelStrings = map[VerifyLevel]string{
+	VerifyLoad:         "VerifyLoad",
+	VerifyHeaderSanity: "VerifyHeaderSanity",
+	VerifyBlockSanity:  "VerifyBlockSanity",
+	VerifyUndoData:     "VerifyUndoData",
+	VerifyScripts:      "VerifyScripts",
+}
+
+// String returns the VerifyLevel as a human-readable name.
+func (l VerifyLevel) String() string {
+	if s := verifyLevelStrings[l]; s != "" {
+		return s
+	}
+	return fmt.Sprintf("Unknown VerifyLevel (%d)", int(l))
+}
+
+// ErrVerifyInterrupted is returned by VerifyChain when the verification is
+// stopped through its quit channel before completing.
+var ErrVerifyInterrupted = errors.New("chain verification interrupted")
+
+// VerifyProgress describes the progress of VerifyChain after a block has been
+// verified.
+type VerifyProgress struct {
+	Level   VerifyLevel
+	Height  int64
+	Hash    chainhash.Hash
+	Checked int64
+	Total   int64
+}
+
+// VerifyProgressFunc is invoked by VerifyChain after each block has been
+// verified.
+type VerifyProgressFunc func(progress *VerifyProgress)
+
+// verifyFinishHeight returns the lowest main chain height VerifyChain checks
+// the blocks down to from the passed best height for the passed depth.  A
+// depth that is not positive or exceeds the chain selects all of the blocks
+// after the genesis block, which is not verified since it is hard coded.
+func verifyFinishHeight(bestHeight, depth int64) int64 {
+	if depth <= 0 || depth > bestHeight {
+		return 1
+	}
+	return bestHeight - depth + 1
+}
+
+// verifySpendJournal ensures the passed spent txouts, which were loaded from
+// the spend journal entry of the passed block, are consistent with the outputs
+// spent by the transactions the entry covers.
+func verifySpendJournal(block, parent *hcashutil.Block, stxos []spentTxOut) error {
+	if len(stxos) != countSpentOutputs(block, parent) {
+		return fmt.Errorf("spend journal contains %d spent outputs, "+
+			"but the block spends %d", len(stxos),
+			countSpentOutputs(block, parent))
+	}
+
+	for i := range stxos {
+		stxo := &stxos[i]
+		if int64(stxo.height) > block.Height() {
+			return fmt.Errorf("spent output %d was created at "+
+				"height %d after the block", i, stxo.height)
+		}
+		if len(stxo.pkScript) == 0 {
+			return fmt.Errorf("spent output %d has no public key "+
+				"script", i)
+		}
+		if stxo.txFullySpent && stxo.txType == stake.TxTypeSStx &&
+			len(stxo.stakeExtra) == 0 {
+
+			return fmt.Errorf("spent output %d fully spends a "+
+				"ticket without its stake extra data", i)
+		}
+	}
+
+	return nil
+}
+
+// spendJournalTxns returns the transactions whose inputs are recorded in the
+// spend journal entry of the passed block in the order they are recorded,
+// which is the regular transactions of the parent when the block approves
+// them followed by the stake transactions of the block.
+func spendJournalTxns(block, parent *hcashutil.Block) []*hcashutil.Tx {
+	var txns []*hcashutil.Tx
+	regularTxTreeValid := hcashutil.IsFlagSet16(block.MsgBlock().Header.VoteBits,
+		hcashutil.BlockValid)
+	if regularTxTreeValid {
+		startTx := 1
+		parentHeader := &parent.MsgBlock().Header
+		if standalone.HashToBig(parent.Hash()).Cmp(
+			standalone.CompactToBig(parentHeader.Bits)) <= 0 {
+			startTx = 2
+		}
+		if len(parent.Transactions()) > startTx {
+			txns = append(txns, parent.Transactions()[startTx:]...)
+		}
+	}
+	return append(txns, block.STransactions()...)
+}
+
+// verifyJournalScripts executes the input scripts of the transactions covered
+// by the spend journal entry of the passed block against the outputs they
+// spend as recorded in the entry.
+func (b *BlockChain) verifyJournalScripts(block, parent *hcashutil.Block, stxos []spentTxOut) error {
+	// Restore the spent outputs into a view which only contains them.
+	// The spent status is irrelevant for script validation, so outputs
+	// created and spent by the covered transactions are restored like any
+	// other.
+	view := NewUtxoViewpoint()
+	txns := spendJournalTxns(block, parent)
+	items := make([]*txValidateItem, 0, len(stxos))
+	var stxoIdx int
+	for _, tx := range txns {
+		msgTx := tx.MsgTx()
+		isSSGen := stake.DetermineTxType(msgTx) == stake.TxTypeSSGen
+		for txInIdx, txIn := range msgTx.TxIn {
+			// Skip vote stakebases which do not spend anything.
+			if txInIdx == 0 && isSSGen {
+				continue
+			}
+			if stxoIdx >= len(stxos) {
+				return AssertError("spend journal entry is " +
+					"missing spent outputs")
+			}
+			stxo := &stxos[stxoIdx]
+			stxoIdx++
+
+			originHash := &txIn.PreviousOutPoint.Hash
+			entry := view.entries[*originHash]
+			if entry == nil {
+				entry = newUtxoEntry(stxo.txVersion, stxo.height,
+					stxo.index, stxo.isCoinBase, stxo.hasExpiry,
+					stxo.txType)
+				view.entries[*originHash] = entry
+			}
+			entry.sparseOutputs[txIn.PreviousOutPoint.Index] = &utxoOutput{
+				pkScript:      stxo.pkScript,
+				amount:        stxo.amount,
+				scriptVersion: stxo.scriptVersion,
+				compressed:    stxo.compressed,
+			}
+
+			if txIn.PreviousOutPoint.Index == math.MaxUint32 {
+				continue
+			}
+			items = append(items, &txValidateItem{
+				txInIndex: txInIdx,
+				txIn:      txIn,
+				tx:        tx,
+			})
+		}
+	}
+
+	return newTxValidator(view, b.blockScriptFlags(), b.sigCache,
+		b.sigVerifyConcurrency).Validate(items)
+}
+
+// verifyTipScripts executes the input scripts of the regular transactions of
+// the passed block, which must be the current tip of the main chain, against
+// the outputs they spend in the current utxo set.  The regular transactions of
+// the tip are not covered by any spend journal entry yet since they are only
+// connected once the next block approves them.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) verifyTipScripts(block *hcashutil.Block) error {
+	txSet := make(map[chainhash.Hash]struct{})
+	for _, tx := range block.Transactions() {
+		for _, txIn := range tx.MsgTx().TxIn {
+			txSet[txIn.PreviousOutPoint.Hash] = struct{}{}
+		}
+	}
+	view := NewUtxoViewpoint()
+	if err := view.fetchUtxos(b.db, txSet); err != nil {
+		return err
+	}
+
+	// Transactions may spend the outputs of earlier transactions in the
+	// same block which are not in the utxo set.
+	for i, tx := range block.Transactions() {
+		if _, ok := txSet[*tx.Hash()]; ok {
+			view.AddTxOuts(tx, block.Height(), uint32(i))
+		}
+	}
+
+	return checkBlockScripts(block, view, true, b.blockScriptFlags(),
+		b.sigCache, b.sigVerifyConcurrency, false)
+}
+
+// verifyBlock performs the checks of the passed level on the passed main chain
+// block.  The parent of the block is only needed for the VerifyUndoData level
+// and above.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) verifyBlock(level VerifyLevel, block, parent *hcashutil.Block) error {
+	if level >= VerifyHeaderSanity {
+		err := checkBlockHeaderSanity(b, block, b.timeSource, BFNone,
+			b.chainParams)
+		if err != nil {
+			return fmt.Errorf("header sanity: %v", err)
+		}
+	}
+
+	if level >= VerifyBlockSanity {
+		err := checkBlockSanity(b, block, b.timeSource, BFNone,
+			b.chainParams)
+		if err != nil {
+			return fmt.Errorf("block sanity: %v", err)
+		}
+	}
+
+	if level < VerifyUndoData {
+		return nil
+	}
+
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	var stxos []spentTxOut
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		stxos, err = dbFetchSpendJournalEntry(dbTx, block, parent)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("undo data: %v", err)
+	}
+	if err := verifySpendJournal(block, parent, stxos); err != nil {
+		return fmt.Errorf("undo data: %v", err)
+	}
+
+	if level < VerifyScripts {
+		return nil
+	}
+
+	if err := b.verifyJournalScripts(block, parent, stxos); err != nil {
+		return fmt.Errorf("scripts: %v", err)
+	}
+	if b.bestNode.hash == *block.Hash() {
+		if err := b.verifyTipScripts(block); err != nil {
+			return fmt.Errorf("scripts: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// VerifyChain verifies the depth most recent blocks of the main chain with the
+// checks of the passed level, starting from the best block.  A depth that is
+// not positive or exceeds the chain verifies all of the blocks after the
+// genesis block.  The progress function, when non-nil, is called after each
+// block is verified, and the verification stops with ErrVerifyInterrupted
+// when quit is closed.
+//
+// An error describing the block is returned for the first block which fails
+// verification.
+//
+// This function is safe for concurrent access.  The chain lock is only held
+// while each block is verified so blocks continue to be processed during the
+// verification.
+func (b *BlockChain) VerifyChain(level VerifyLevel, depth int64, progress VerifyProgressFunc, quit <-chan struct{}) error {
+	if level < VerifyLoad {
+		level = VerifyLoad
+	}
+	if level > MaxVerifyLevel {
+		level = MaxVerifyLevel
+	}
+
+	best := b.BestSnapshot()
+	finishHeight := verifyFinishHeight(best.Height, depth)
+	total := best.Height - finishHeight + 1
+	if total < 0 {
+		total = 0
+	}
+	log.Infof("Verifying chain for %d blocks at level %v", total, level)
+
+	var block *hcashutil.Block
+	for height := best.Height; height >= finishHeight; height-- {
+		select {
+		case <-quit:
+			return ErrVerifyInterrupted
+		default:
+		}
+
+		// Walking the chain backwards, the parent of the previous
+		// block is the current block.
+		if block == nil {
+			var err error
+			block, err = b.BlockByHeight(height)
+			if err != nil {
+				return fmt.Errorf("unable to fetch block at "+
+					"height %d: %v", height, err)
+			}
+		}
+		var parent *hcashutil.Block
+		if level >= VerifyUndoData {
+			var err error
+			parent, err = b.BlockByHeight(height - 1)
+			if err != nil {
+				return fmt.Errorf("unable to fetch block at "+
+					"height %d: %v", height-1, err)
+			}
+		}
+
+		if err := b.verifyBlock(level, block, parent); err != nil {
+			return fmt.Errorf("block %v (height %d) failed "+
+				"verification: %v", block.Hash(), height, err)
+		}
+
+		if progress != nil {
+			progress(&VerifyProgress{
+				Level:   level,
+				Height:  height,
+				Hash:    *block.Hash(),
+				Checked: best.Height - height + 1,
+				Total:   total,
+			})
+		}
+
+		block = parent
+	}
+	log.Infof("Chain verify completed successfully")
+
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+)
+
+// TestVerifyFinishHeight ensures the lowest height verified for a depth covers
+// exactly depth blocks and that out of range depths select the whole chain
+// except the genesis block.
+func TestVerifyFinishHeight(t *testing.T) {
+	tests := []struct {
+		bestHeight int64
+		depth      int64
+		want       int64
+	}{
+		{bestHeight: 1000, depth: 288, want: 713},
+		{bestHeight: 1000, depth: 1, want: 1000},
+		{bestHeight: 1000, depth: 1000, want: 1},
+		{bestHeight: 1000, depth: 1001, want: 1},
+		{bestHeight: 1000, depth: 0, want: 1},
+		{bestHeight: 1000, depth: -5, want: 1},
+		{bestHeight: 0, depth: 288, want: 1},
+	}
+
+	for _, test := range tests {
+		got := verifyFinishHeight(test.bestHeight, test.depth)
+		if got != test.want {
+			t.Errorf("verifyFinishHeight(%d, %d): got %d, want %d",
+				test.bestHeight, test.depth, got, test.want)
+		}
+	}
+}
+
+// TestVerifyLevelStringer tests the stringized output for the VerifyLevel
+// type.
+func TestVerifyLevelStringer(t *testing.T) {
+	tests := []struct {
+		in   VerifyLevel
+		want string
+	}{
+		{VerifyLoad, "VerifyLoad"},
+		{VerifyHeaderSanity, "VerifyHeaderSanity"},
+		{VerifyBlockSanity, "VerifyBlockSanity"},
+		{VerifyUndoData, "VerifyUndoData"},
+		{VerifyScripts, "VerifyScripts"},
+		{MaxVerifyLevel + 1, "Unknown VerifyLevel (5)"},
+	}
+
+	for i, test := range tests {
+		result := test.in.String()
+		if result != test.want {
+			t.Errorf("String #%d\n got: %s want: %s", i, result,
+				test.want)
+		}
+	}
+}
@@ -194,9 +194,9 @@ func TestNoQuorum(t *testing.T) {
 		currentTimestamp = currentTimestamp.Add(time.Second)
 	}
 	t.Logf("Height %v", currentNode.height)
-	ts, err := bc.ThresholdState(&currentNode.hash, posVersion, pedro.Id)
+	ts, err := bc.NextThresholdState(&currentNode.hash, posVersion, pedro.Id)
 	if err != nil {
-		t.Fatalf("ThresholdState(SVI): %v", err)
+		t.Fatalf("NextThresholdState(SVI): %v", err)
 	}
 	tse := ThresholdStateTuple{
 		State:  ThresholdDefined,
@@ -246,9 +246,9 @@ func TestNoQuorum(t *testing.T) {
 	}
 
 	t.Logf("Height %v", currentNode.height)
-	ts, err = bc.ThresholdState(&currentNode.hash, posVersion, pedro.Id)
+	ts, err = bc.NextThresholdState(&currentNode.hash, posVersion, pedro.Id)
 	if err != nil {
-		t.Fatalf("ThresholdState(started): %v", err)
+		t.Fatalf("NextThresholdState(started): %v", err)
 	}
 	tse = ThresholdStateTuple{
 		State:  ThresholdStarted,
@@ -302,9 +302,9 @@ func TestNoQuorum(t *testing.T) {
 	}
 
 	t.Logf("Height %v", currentNode.height)
-	ts, err = bc.ThresholdState(&currentNode.hash, posVersion, pedro.Id)
+	ts, err = bc.NextThresholdState(&currentNode.hash, posVersion, pedro.Id)
 	if err != nil {
-		t.Fatalf("ThresholdState(quorum-1): %v", err)
+		t.Fatalf("NextThresholdState(quorum-1): %v", err)
 	}
 	tse = ThresholdStateTuple{
 		State:  ThresholdStarted,
@@ -370,9 +370,9 @@ func TestNoQuorum(t *testing.T) {
 	}
 
 	t.Logf("Height %v", currentNode.height)
-	ts, err = bc.ThresholdState(&currentNode.hash, posVersion, pedro.Id)
+	ts, err = bc.NextThresholdState(&currentNode.hash, posVersion, pedro.Id)
 	if err != nil {
-		t.Fatalf("ThresholdState(quorum 75%%-1): %v", err)
+		t.Fatalf("NextThresholdState(quorum 75%%-1): %v", err)
 	}
 	tse = ThresholdStateTuple{
 		State:  ThresholdStarted,
@@ -438,9 +438,9 @@ func TestNoQuorum(t *testing.T) {
 	}
 
 	t.Logf("Height %v", currentNode.height)
-	ts, err = bc.ThresholdState(&currentNode.hash, posVersion, pedro.Id)
+	ts, err = bc.NextThresholdState(&currentNode.hash, posVersion, pedro.Id)
 	if err != nil {
-		t.Fatalf("ThresholdState(quorum 75%%): %v", err)
+		t.Fatalf("NextThresholdState(quorum 75%%): %v", err)
 	}
 	tse = ThresholdStateTuple{
 		State:  ThresholdFailed,
@@ -493,9 +493,9 @@ func TestYesQuorum(t *testing.T) {
 		currentTimestamp = currentTimestamp.Add(time.Second)
 	}
 	t.Logf("Height %v", currentNode.height)
-	ts, err := bc.ThresholdState(&currentNode.hash, posVersion, pedro.Id)
+	ts, err := bc.NextThresholdState(&currentNode.hash, posVersion, pedro.Id)
 	if err != nil {
-		t.Fatalf("ThresholdState(SVI): %v", err)
+		t.Fatalf("NextThresholdState(SVI): %v", err)
 	}
 	tse := ThresholdStateTuple{
 		State:  ThresholdDefined,
@@ -544,9 +544,9 @@ func TestYesQuorum(t *testing.T) {
 	}
 
 	t.Logf("Height %v", currentNode.height)
-	ts, err = bc.ThresholdState(&currentNode.hash, posVersion, pedro.Id)
+	ts, err = bc.NextThresholdState(&currentNode.hash, posVersion, pedro.Id)
 	if err != nil {
-		t.Fatalf("ThresholdState(started): %v", err)
+		t.Fatalf("NextThresholdState(started): %v", err)
 	}
 	tse = ThresholdStateTuple{
 		State:  ThresholdStarted,
@@ -602,9 +602,9 @@ func TestYesQuorum(t *testing.T) {
 	}
 
 	t.Logf("Height %v", currentNode.height)
-	ts, err = bc.ThresholdState(&currentNode.hash, posVersion, pedro.Id)
+	ts, err = bc.NextThresholdState(&currentNode.hash, posVersion, pedro.Id)
 	if err != nil {
-		t.Fatalf("ThresholdState(quorum-1): %v", err)
+		t.Fatalf("NextThresholdState(quorum-1): %v", err)
 	}
 	tse = ThresholdStateTuple{
 		State:  ThresholdStarted,
@@ -670,9 +670,9 @@ func TestYesQuorum(t *testing.T) {
 	}
 
 	t.Logf("Height %v", currentNode.height)
-	ts, err = bc.ThresholdState(&currentNode.hash, posVersion, pedro.Id)
+	ts, err = bc.NextThresholdState(&currentNode.hash, posVersion, pedro.Id)
 	if err != nil {
-		t.Fatalf("ThresholdState(quorum 75%%-1): %v", err)
+		t.Fatalf("NextThresholdState(quorum 75%%-1): %v", err)
 	}
 	tse = ThresholdStateTuple{
 		State:  ThresholdStarted,
@@ -738,9 +738,9 @@ func TestYesQuorum(t *testing.T) {
 	}
 
 	t.Logf("Height %v", currentNode.height)
-	ts, err = bc.ThresholdState(&currentNode.hash, posVersion, pedro.Id)
+	ts, err = bc.NextThresholdState(&currentNode.hash, posVersion, pedro.Id)
 	if err != nil {
-		t.Fatalf("ThresholdState(quorum 75%%): %v", err)
+		t.Fatalf("NextThresholdState(quorum 75%%): %v", err)
 	}
 	tse = ThresholdStateTuple{
 		State:  ThresholdLockedIn,
@@ -1935,10 +1935,10 @@ func TestVoting(t *testing.T) {
 				currentNode.header.Timestamp.Unix(),
 				currentNode.header.Timestamp.Unix()-
 					int64(params.Deployments[4][0].StartTime))
-			ts, err := bc.ThresholdState(&currentNode.hash,
+			ts, err := bc.NextThresholdState(&currentNode.hash,
 				posVersion, test.vote.Id)
 			if err != nil {
-				t.Fatalf("ThresholdState(%v): %v", k, err)
+				t.Fatalf("NextThresholdState(%v): %v", k, err)
 			}
 			if ts != test.expectedState[k] {
 				t.Fatalf("%v.%v (%v) got state %v wanted state"+
@@ -2203,10 +2203,10 @@ func TestParallelVoting(t *testing.T) {
 			}
 			t.Logf("Height %v", currentNode.height)
 			for i := range test.vote {
-				ts, err := bc.ThresholdState(&currentNode.hash,
+				ts, err := bc.NextThresholdState(&currentNode.hash,
 					posVersion, test.vote[i].Id)
 				if err != nil {
-					t.Fatalf("ThresholdState(%v): %v", k, err)
+					t.Fatalf("NextThresholdState(%v): %v", k, err)
 				}
 
 				if ts != test.expectedState[i][k] {
@@ -2222,3 +2222,161 @@ func TestParallelVoting(t *testing.T) {
 		}
 	}
 }
+
+// TestDeploymentVersion ensures deployments are looked up in the stake version
+// that defines them.
+func TestDeploymentVersion(t *testing.T) {
+	params := chaincfg.SimNetParams
+	params.Deployments = map[uint32][]chaincfg.ConsensusDeployment{
+		4: {{Vote: chaincfg.Vote{Id: "first"}}},
+		5: {{Vote: chaincfg.Vote{Id: "first"}},
+			{Vote: chaincfg.Vote{Id: "second"}}},
+	}
+	bc := newFakeChain(&params)
+
+	tests := []struct {
+		id      string
+		version uint32
+		found   bool
+	}{
+		{"first", 5, true},
+		{"second", 5, true},
+		{"unknown", 0, false},
+	}
+	for _, test := range tests {
+		version, found := bc.deploymentVersion(test.id)
+		if version != test.version || found != test.found {
+			t.Errorf("deploymentVersion(%s): got %d, %v want %d, %v",
+				test.id, version, found, test.version, test.found)
+		}
+	}
+
+	_, err := bc.ThresholdState("unknown")
+	if _, ok := err.(DeploymentError); !ok {
+		t.Errorf("ThresholdState: got error %v, want DeploymentError", err)
+	}
+}
+
+// TestMaxBlockSize ensures the maximum block size is the first simnet size
+// until the max block size agenda is active and the second one afterwards,
+// and that networks with a single size never change it.
+func TestMaxBlockSize(t *testing.T) {
+	maxBlockSizeVote := pedro
+	maxBlockSizeVote.Id = chaincfg.VoteIDMaxBlockSize
+	params := defaultParams(maxBlockSizeVote)
+	if len(params.MaximumBlockSizes) != 2 ||
+		params.MaximumBlockSizes[1] <= params.MaximumBlockSizes[0] {
+		t.Fatalf("simnet does not increase the block size with the "+
+			"agenda: %v", params.MaximumBlockSizes)
+	}
+	bc := newFakeChain(&params)
+	currentNode := genesisBlockNode(&params)
+	currentNode.header.StakeVersion = posVersion
+	currentTimestamp := time.Now()
+	currentHeight := uint32(1)
+
+	// addNodes extends the chain by the passed number of key blocks which
+	// all vote yes on the agenda.
+	addNodes := func(numNodes uint32) {
+		for i := uint32(0); i < numNodes; i++ {
+			header := &wire.BlockHeader{
+				Version:      powVersion,
+				PrevBlock:    currentNode.hash,
+				PrevKeyBlock: currentNode.hash,
+				Height:       currentHeight,
+				StakeVersion: posVersion,
+				Timestamp:    currentTimestamp,
+			}
+			node := newBlockNode(FakeBlockFromHeader(header), nil, nil,
+				nil)
+			node.isKeyBlock = true
+			node.keyHeight = int64(currentHeight) - 1
+			node.height = int64(currentHeight)
+			node.parent = currentNode
+			for x := 0; x < int(params.TicketsPerBlock); x++ {
+				node.votes = append(node.votes, VoteVersionTuple{
+					Version: posVersion,
+					Bits:    0x03, // vote yes
+				})
+			}
+
+			currentNode = node
+			bc.bestNode = currentNode
+			bc.index[node.hash] = node
+			currentHeight++
+			currentTimestamp = currentTimestamp.Add(time.Second)
+		}
+	}
+
+	// checkSize ensures the maximum block size after the current tip
+	// matches the state of the agenda, and returns whether it is active.
+	checkSize := func() bool {
+		state, err := bc.NextThresholdState(&currentNode.hash, posVersion,
+			chaincfg.VoteIDMaxBlockSize)
+		if err != nil {
+			t.Fatalf("NextThresholdState(height %d): %v",
+				currentNode.height, err)
+		}
+		size, err := bc.maxBlockSize(currentNode)
+		if err != nil {
+			t.Fatalf("maxBlockSize(height %d): %v", currentNode.height,
+				err)
+		}
+		active := state.State == ThresholdActive
+		want := int64(params.MaximumBlockSizes[0])
+		if active {
+			want = int64(params.MaximumBlockSizes[1])
+		}
+		if size != want {
+			t.Fatalf("maxBlockSize(height %d): got %d, want %d in "+
+				"state %v", currentNode.height, size, want,
+				state.State)
+		}
+		return active
+	}
+
+	addNodes(uint32(params.StakeValidationHeight))
+	if checkSize() {
+		t.Fatal("agenda active at the stake validation height")
+	}
+	var active bool
+	for i := 0; i < 4 && !active; i++ {
+		addNodes(params.RuleChangeActivationInterval)
+		active = checkSize()
+	}
+	if !active {
+		t.Fatalf("agenda not active at height %d", currentNode.height)
+	}
+
+	// Microblocks following an active key block use the larger size too.
+	header := &wire.BlockHeader{
+		PrevBlock:    currentNode.hash,
+		PrevKeyBlock: currentNode.hash,
+		Height:       currentHeight,
+		KeyHeight:    uint32(currentNode.keyHeight),
+		Bits:         0x0100000,
+	}
+	microNode := newBlockNode(FakeBlockFromHeader(header), nil, nil, nil)
+	microNode.parent = currentNode
+	if microNode.isKeyBlock {
+		t.Fatal("fake microblock is a key block")
+	}
+	size, err := bc.maxBlockSize(microNode)
+	if err != nil {
+		t.Fatalf("maxBlockSize(microblock): %v", err)
+	}
+	if size != int64(params.MaximumBlockSizes[1]) {
+		t.Fatalf("maxBlockSize(microblock): got %d, want %d", size,
+			params.MaximumBlockSizes[1])
+	}
+
+	// A single size is never changed, even with the agenda active.
+	params.MaximumBlockSizes = []int{1000000}
+	size, err = bc.maxBlockSize(currentNode)
+	if err != nil {
+		t.Fatalf("maxBlockSize(single size): %v", err)
+	}
+	if size != 1000000 {
+		t.Fatalf("maxBlockSize(single size): got %d, want 1000000", size)
+	}
+}
@@ -19,10 +19,12 @@ import (
 
 	"github.com/HcashOrg/hcashd/blockchain"
 	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
 	"github.com/HcashOrg/hcashd/mempool"
+	"github.com/HcashOrg/hcashd/mining"
 	"github.com/HcashOrg/hcashd/wire"
 	"github.com/HcashOrg/hcashutil"
 )
@@ -88,6 +90,24 @@ type newPeerMsg struct {
 type blockMsg struct {
 	block *hcashutil.Block
 	peer  *serverPeer
+
+	// lightBlock indicates the block was reconstructed from a light block
+	// announcement, which is not preceded by a request for the block.
+	lightBlock bool
+}
+
+// lightBlockMsg packages a hypercash lightblock message and the peer it came
+// from together so the block handler has access to that information.
+type lightBlockMsg struct {
+	lightBlock *wire.MsgLightBlock
+	peer       *serverPeer
+}
+
+// missedTxsMsg packages a hypercash missedtxs message and the peer it came
+// from together so the block handler has access to that information.
+type missedTxsMsg struct {
+	missedTxs *wire.MsgMissedTxs
+	peer      *serverPeer
 }
 
 // invMsg packages a hypercash inv message and the peer it came from together
@@ -314,13 +334,13 @@ type getCurrentTemplateMsg struct {
 // getCurrentTemplateResponse is a response sent to the reply channel of a
 // getCurrentTemplateMsg.
 type getCurrentTemplateResponse struct {
-	Template *BlockTemplate
+	Template *mining.BlockTemplate
 }
 
 // setCurrentTemplateMsg handles a request to change the current mining block
 // template.
 type setCurrentTemplateMsg struct {
-	Template *BlockTemplate
+	Template *mining.BlockTemplate
 	reply    chan setCurrentTemplateResponse
 }
 
@@ -338,13 +358,13 @@ type getParentTemplateMsg struct {
 // getParentTemplateResponse is a response sent to the reply channel of a
 // getParentTemplateMsg.
 type getParentTemplateResponse struct {
-	Template *BlockTemplate
+	Template *mining.BlockTemplate
 }
 
 // setParentTemplateMsg handles a request to change the parent mining block
 // template.
 type setParentTemplateMsg struct {
-	Template *BlockTemplate
+	Template *mining.BlockTemplate
 	reply    chan setParentTemplateResponse
 }
 
@@ -465,10 +485,17 @@ type blockManager struct {
 	quit                chan struct{}
 
 	// The following fields are used for headers-first mode.
-	headersFirstMode bool
-	headerList       *list.List
-	startHeader      *list.Element
-	nextCheckpoint   *chaincfg.Checkpoint
+	headersFirstMode        bool
+	headerList              *list.List
+	headerIndex             map[chainhash.Hash]*list.Element
+	startHeader             *list.Element
+	nextCheckpoint          *chaincfg.Checkpoint
+	checkpointBlockReceived bool
+	blockScheduler          *blockScheduler
+
+	// missedTxs recovers the transactions of light blocks which are not in
+	// the memory pool.
+	missedTxs *missedTxsRecovery
 
 	// lotteryDataBroadcastMutex is a mutex protecting the map
 	// that checks if block lottery data has been broadcasted
@@ -477,8 +504,8 @@ type blockManager struct {
 	lotteryDataBroadcast      map[chainhash.Hash]struct{}
 	lotteryDataBroadcastMutex sync.Mutex
 
-	cachedCurrentTemplate *BlockTemplate
-	cachedParentTemplate  *BlockTemplate
+	cachedCurrentTemplate *mining.BlockTemplate
+	cachedParentTemplate  *mining.BlockTemplate
 	AggressiveMining      bool
 }
 
@@ -487,7 +514,10 @@ type blockManager struct {
 func (b *blockManager) resetHeaderState(newestHash *chainhash.Hash, newestHeight int64) {
 	b.headersFirstMode = false
 	b.headerList.Init()
+	b.headerIndex = make(map[chainhash.Hash]*list.Element)
 	b.startHeader = nil
+	b.checkpointBlockReceived = false
+	b.blockScheduler.reset()
 
 	// When there is a next checkpoint, add an entry for the latest known
 	// block into the header pool.  This allows the next downloaded header
@@ -588,7 +618,7 @@ func (b *blockManager) startSync(peers *list.List) {
 		//}
 
 		realKeyHeight := best.KeyHeight
-		if blockchain.HashToBig(best.Hash).Cmp(blockchain.CompactToBig(best.Bits)) <= 0 {
+		if standalone.HashToBig(best.Hash).Cmp(standalone.CompactToBig(best.Bits)) <= 0 {
 			realKeyHeight++
 		}
 
@@ -707,8 +737,10 @@ func (b *blockManager) handleNewPeerMsg(peers *list.List, sp *serverPeer) {
 		return
 	}
 
-	// Add the peer as a candidate to sync from.
+	// Add the peer as a candidate to sync from and to download the blocks
+	// of headers-first mode from.
 	peers.PushBack(sp)
+	b.blockScheduler.addPeer(sp)
 
 	// Start syncing by choosing the best candidate if needed.
 	b.startSync(peers)
@@ -748,6 +780,14 @@ func (b *blockManager) handleDonePeerMsg(peers *list.List, sp *serverPeer) {
 		delete(b.requestedBlocks, k)
 	}
 
+	// Reassign the blocks of headers-first mode that were requested from
+	// the peer to the remaining peers.
+	requeued := b.blockScheduler.removePeer(sp)
+
+	// Request the missing transactions of light blocks that were requested
+	// from the peer from the other peers that announced the blocks.
+	b.handleMissedTxsActions(b.missedTxs.removePeer(sp, time.Now()))
+
 	// Attempt to find a new peer to sync from if the quitting peer is the
 	// sync peer.  Also, reset the headers-first state if in headers-first
 	// mode so
@@ -758,6 +798,13 @@ func (b *blockManager) handleDonePeerMsg(peers *list.List, sp *serverPeer) {
 			b.resetHeaderState(best.Hash, best.Height)
 		}
 		b.startSync(peers)
+		return
+	}
+
+	if b.headersFirstMode && len(requeued) > 0 {
+		bmgrLog.Debugf("Reassigning %d blocks requested from %s",
+			len(requeued), sp)
+		b.fetchHeaderBlocks()
 	}
 }
 
@@ -783,10 +830,17 @@ func (b *blockManager) handleTxMsg(tmsg *txMsg) {
 	}
 
 	// Process the transaction to include validation, insertion in the
-	// memory pool, orphan handling, etc.
-	allowOrphans := cfg.MaxOrphanTxs > 0
+	// memory pool, orphan handling, etc.  Transactions from whitelisted
+	// peers are not subject to the per-peer rate limits.
+	allowOrphans := b.server.txMemPool.Policy().MaxOrphanTxs > 0
+	limiter := tmsg.peer.txLimiter
+	if tmsg.peer.isWhitelisted {
+		limiter = nil
+	}
+	start := time.Now()
 	acceptedTxs, err := b.server.txMemPool.ProcessTransaction(b.chain, tmsg.tx,
-		allowOrphans, true, true)
+		allowOrphans, true, true, limiter)
+	b.server.metrics.observeTxValidation(start)
 
 	// Remove transaction from request maps. Either the mempool/chain
 	// already knows about it and as such we shouldn't have any more
@@ -845,7 +899,7 @@ func (b *blockManager) current() bool {
 	//}
 	best := b.chain.BestSnapshot()
 	realKeyHeight := best.KeyHeight
-	if blockchain.HashToBig(best.Hash).Cmp(blockchain.CompactToBig(best.Bits)) <= 0{
+	if standalone.HashToBig(best.Hash).Cmp(standalone.CompactToBig(best.Bits)) <= 0 {
 		realKeyHeight++
 	}
 
@@ -879,7 +933,7 @@ func (b *blockManager) checkBlockForHiddenVotes(block *hcashutil.Block) {
 	// Identify the cached parent template; it's possible that
 	// the parent template hasn't yet been updated, so we may
 	// need to use the current template.
-	var template *BlockTemplate
+	var template *mining.BlockTemplate
 	if b.cachedCurrentTemplate != nil {
 		if b.cachedCurrentTemplate.Height ==
 			block.Height() {
@@ -993,7 +1047,7 @@ func (b *blockManager) checkBlockForHiddenVotes(block *hcashutil.Block) {
 		return
 	}
 	height := block.MsgBlock().Header.Height
-	opReturnPkScript, err := standardCoinbaseOpReturn(height,
+	opReturnPkScript, err := mining.StandardCoinbaseOpReturn(height,
 		[]uint64{0, 0, 0, random})
 	if err != nil {
 		// Stopping at this step will lead to a corrupted block template
@@ -1003,7 +1057,7 @@ func (b *blockManager) checkBlockForHiddenVotes(block *hcashutil.Block) {
 			"block with extra found voters")
 		return
 	}
-	extraCoinbase, err := createExtraCoinbaseTx(b.chain.FetchSubsidyCache(),
+	extraCoinbase, err := mining.CreateExtraCoinbaseTx(b.chain.FetchSubsidyCache(),
 		template.Block.Transactions[0].TxIn[0].SignatureScript,
 		opReturnPkScript,
 		int64(template.Block.Header.Height),
@@ -1018,7 +1072,7 @@ func (b *blockManager) checkBlockForHiddenVotes(block *hcashutil.Block) {
 	}
 	template.Block.Transactions[0] = extraCoinbase.MsgTx()
 
-	coinbase, err := createCoinbaseTx(template.Block.Transactions[0].TxIn[0].SignatureScript,
+	coinbase, err := mining.CreateCoinbaseTx(template.Block.Transactions[0].TxIn[0].SignatureScript,
 		opReturnPkScript,
 		cfg.miningAddrs[rand.Intn(len(cfg.miningAddrs))])
 
@@ -1052,9 +1106,11 @@ func (b *blockManager) checkBlockForHiddenVotes(block *hcashutil.Block) {
 
 // handleBlockMsg handles block messages from all peers.
 func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
-	// If we didn't ask for this block then the peer is misbehaving.
+	// If we didn't ask for this block then the peer is misbehaving.  Blocks
+	// announced via light blocks are never requested.
 	blockHash := bmsg.block.Hash()
-	if _, exists := bmsg.peer.requestedBlocks[*blockHash]; !exists {
+	_, requested := bmsg.peer.requestedBlocks[*blockHash]
+	if !requested && !bmsg.lightBlock {
 		// Check to see if we ever requested this block, since it may
 		// have been accidentally sent in duplicate. If it was,
 		// increment the counter in the ever requested map and make
@@ -1077,26 +1133,28 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 		}
 	}
 
-	// When in headers-first mode, if the block matches the hash of the
-	// first header in the list of headers that are being fetched, it's
+	// Stop recovering the missing transactions of the block when it was
+	// announced via a light block but received in full.
+	b.missedTxs.removeBlock(blockHash)
+
+	// When in headers-first mode, if the block matches the hash of one of
+	// the headers in the list of headers that are being fetched, it's
 	// eligible for less validation since the headers have already been
 	// verified to link together and are valid up to the next checkpoint.
-	// Also, remove the list entry for all blocks except the checkpoint
-	// since it is needed to verify the next round of headers links
-	// properly.
-	isCheckpointBlock := false
+	// The blocks are fetched from several peers at once, so they may
+	// arrive in any order.  Remove the list entry for all blocks except
+	// the checkpoint since it is needed to verify the next round of
+	// headers links properly.
 	behaviorFlags := blockchain.BFNone
 	if b.headersFirstMode {
-		firstNodeEl := b.headerList.Front()
-		if firstNodeEl != nil {
-			firstNode := firstNodeEl.Value.(*headerNode)
-			if blockHash.IsEqual(firstNode.hash) {
-				behaviorFlags |= blockchain.BFFastAdd
-				if firstNode.hash.IsEqual(b.nextCheckpoint.Hash) {
-					isCheckpointBlock = true
-				} else {
-					b.headerList.Remove(firstNodeEl)
-				}
+		b.blockScheduler.received(blockHash, bmsg.peer)
+		if nodeEl, exists := b.headerIndex[*blockHash]; exists {
+			behaviorFlags |= blockchain.BFFastAdd
+			if blockHash.IsEqual(b.nextCheckpoint.Hash) {
+				b.checkpointBlockReceived = true
+			} else {
+				b.headerList.Remove(nodeEl)
+				delete(b.headerIndex, *blockHash)
 			}
 		}
 	}
@@ -1109,16 +1167,29 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 
 	// Process the block to include validation, best chain selection, orphan
 	// handling, etc.
+	start := time.Now()
 	onMainChain, isOrphan, err := b.chain.ProcessBlock(bmsg.block,
 		behaviorFlags)
+	b.server.metrics.observeBlockValidation(start)
 	if err != nil {
 		// When the error is a rule error, it means the block was simply
 		// rejected as opposed to something actually going wrong, so log
 		// it as such.  Otherwise, something really did go wrong, so log
 		// it as an actual error.
-		if _, ok := err.(blockchain.RuleError); ok {
+		if rErr, ok := err.(blockchain.RuleError); ok {
 			bmgrLog.Infof("Rejected block %v from %s: %v", blockHash,
 				bmsg.peer, err)
+
+			// Blocks that are merely stale or have a timestamp ahead
+			// of the local clock are not a sign of misbehavior, but
+			// a decaying ban score increase is applied for all other
+			// invalid blocks so peers repeatedly sending malformed
+			// blocks are banned.
+			switch rErr.ErrorCode {
+			case blockchain.ErrDuplicateBlock, blockchain.ErrTimeTooNew:
+			default:
+				bmsg.peer.addBanScore(0, 50, "invalid block")
+			}
 		} else {
 			bmgrLog.Errorf("Failed to process block %v: %v",
 				blockHash, err)
@@ -1162,18 +1233,21 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 
 		keyHeightUpdate = int64(header.KeyHeight)
 		hash := header.BlockHash()
-		if blockchain.HashToBig(&hash).Cmp(blockchain.CompactToBig(header.Bits)) <= 0 {
+		if standalone.HashToBig(&hash).Cmp(standalone.CompactToBig(header.Bits)) <= 0 {
 			keyHeightUpdate++
 		}
 
 		blkHashUpdate = blockHash
 
+		// The parents of blocks received out of order in headers-first
+		// mode are already being fetched, so there is no need to ask
+		// for them.
 		orphanRoot := b.chain.GetOrphanRoot(blockHash)
 		locator, err := b.chain.LatestBlockLocator()
 		if err != nil {
 			bmgrLog.Warnf("Failed to get block locator for the "+
 				"latest block: %v", err)
-		} else {
+		} else if !b.headersFirstMode {
 			err = bmsg.peer.PushGetBlocksMsg(locator, orphanRoot)
 			if err != nil {
 				bmgrLog.Warnf("Failed to push getblocksmsg for the "+
@@ -1201,7 +1275,7 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 			realKeyHeight := bmsg.block.MsgBlock().Header.KeyHeight
 
 			isKeyBlock := false
-			if blockchain.HashToBig(bmsg.block.Hash()).Cmp(blockchain.CompactToBig(bmsg.block.MsgBlock().Header.Bits)) <= 0{
+			if standalone.HashToBig(bmsg.block.Hash()).Cmp(standalone.CompactToBig(bmsg.block.MsgBlock().Header.Bits)) <= 0 {
 				realKeyHeight++
 				isKeyBlock = true
 			}
@@ -1296,7 +1370,7 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 			heightUpdate = best.Height
 
 			keyHeightUpdate = best.KeyHeight
-			if blockchain.HashToBig(best.Hash).Cmp(blockchain.CompactToBig(best.Bits)) <= 0 {
+			if standalone.HashToBig(best.Hash).Cmp(standalone.CompactToBig(best.Bits)) <= 0 {
 				keyHeightUpdate++
 			}
 
@@ -1363,7 +1437,7 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 			heightUpdate = best.Height
 
 			keyHeightUpdate = best.KeyHeight
-			if blockchain.HashToBig(best.Hash).Cmp(blockchain.CompactToBig(best.Bits)) <= 0 {
+			if standalone.HashToBig(best.Hash).Cmp(standalone.CompactToBig(best.Bits)) <= 0 {
 				keyHeightUpdate++
 			}
 
@@ -1401,30 +1475,33 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 		return
 	}
 
-	// This is headers-first mode, so if the block is not a checkpoint
-	// request more blocks using the header list when the request queue is
-	// getting short.
-	if !isCheckpointBlock {
-		if b.startHeader != nil &&
-			len(bmsg.peer.requestedBlocks) < minInFlightBlocks {
+	// This is headers-first mode, so until all of the blocks up to and
+	// including the checkpoint have been received, request more blocks
+	// from this peer using the header list when its request queue is
+	// getting short.  The checkpoint is the only entry left in the header
+	// list once all of the blocks have been received.
+	if !b.checkpointBlockReceived || b.headerList.Len() > 1 {
+		if b.blockScheduler.load(bmsg.peer) < minInFlightBlocks {
 			b.fetchHeaderBlocks()
 		}
 		return
 	}
 
-	// This is headers-first mode and the block is a checkpoint.  When
-	// there is a next checkpoint, get the next round of headers by asking
-	// for headers starting from the block after this one up to the next
+	// This is headers-first mode and all of the blocks up to the
+	// checkpoint have been received.  When there is a next checkpoint, get
+	// the next round of headers by asking the sync peer for headers
+	// starting from the block after the checkpoint up to the next
 	// checkpoint.
+	b.checkpointBlockReceived = false
 	prevHeight := b.nextCheckpoint.Height
 	prevHash := b.nextCheckpoint.Hash
 	b.nextCheckpoint = b.findNextHeaderCheckpoint(prevHeight)
 	if b.nextCheckpoint != nil {
 		locator := blockchain.BlockLocator([]*chainhash.Hash{prevHash})
-		err := bmsg.peer.PushGetHeadersMsg(locator, b.nextCheckpoint.Hash)
+		err := b.syncPeer.PushGetHeadersMsg(locator, b.nextCheckpoint.Hash)
 		if err != nil {
 			bmgrLog.Warnf("Failed to send getheaders message to "+
-				"peer %s: %v", bmsg.peer.Addr(), err)
+				"peer %s: %v", b.syncPeer.Addr(), err)
 			return
 		}
 		bmgrLog.Infof("Downloading headers for blocks %d to %d from "+
@@ -1433,35 +1510,40 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 		return
 	}
 
-	// This is headers-first mode, the block is a checkpoint, and there are
-	// no more checkpoints, so switch to normal mode by requesting blocks
-	// from the block after this one up to the end of the chain (zero hash).
+	// This is headers-first mode, all of the blocks up to the checkpoint
+	// have been received, and there are no more checkpoints, so switch to
+	// normal mode by requesting blocks from the sync peer from the block
+	// after the checkpoint up to the end of the chain (zero hash).
 	b.headersFirstMode = false
 	b.headerList.Init()
+	b.headerIndex = make(map[chainhash.Hash]*list.Element)
+	b.blockScheduler.reset()
 	bmgrLog.Infof("Reached the final checkpoint -- switching to normal mode")
-	locator := blockchain.BlockLocator([]*chainhash.Hash{blockHash})
-	err = bmsg.peer.PushGetBlocksMsg(locator, &zeroHash)
+	locator := blockchain.BlockLocator([]*chainhash.Hash{prevHash})
+	err = b.syncPeer.PushGetBlocksMsg(locator, &zeroHash)
 	if err != nil {
 		bmgrLog.Warnf("Failed to send getblocks message to peer %s: %v",
-			bmsg.peer.Addr(), err)
+			b.syncPeer.Addr(), err)
 		return
 	}
 }
 
-// fetchHeaderBlocks creates and sends a request to the syncPeer for the next
-// list of blocks to be downloaded based on the current list of headers.
-func (b *blockManager) fetchHeaderBlocks() {
-	// Nothing to do if there is no start header.
-	if b.startHeader == nil {
-		bmgrLog.Warnf("fetchHeaderBlocks called with no start header")
-		return
+// isBlockDownloadPeer returns whether or not the blocks of headers-first mode
+// may be requested from the passed peer.  That is the case for the sync peer
+// and for other connected peers that claim to have the blocks up to the next
+// checkpoint.
+func (b *blockManager) isBlockDownloadPeer(sp *serverPeer) bool {
+	if sp == b.syncPeer {
+		return true
 	}
+	return sp.Connected() && b.nextCheckpoint != nil &&
+		sp.LastBlock() >= b.nextCheckpoint.Height
+}
 
-	// Build up a getdata request for the list of blocks the headers
-	// describe.  The size hint will be limited to wire.MaxInvPerMsg by
-	// the function, so no need to double check it here.
-	gdmsg := wire.NewMsgGetDataSizeHint(uint(b.headerList.Len()))
-	numRequested := 0
+// fetchHeaderBlocks schedules the blocks described by the headers that have
+// been added to the list of headers since the last call and sends requests
+// for the scheduled blocks to the peers the block scheduler assigns them to.
+func (b *blockManager) fetchHeaderBlocks() {
 	for e := b.startHeader; e != nil; e = e.Next() {
 		node, ok := e.Value.(*headerNode)
 		if !ok {
@@ -1478,24 +1560,168 @@ func (b *blockManager) fetchHeaderBlocks() {
 			continue
 		}
 		if !haveInv {
-			b.requestedBlocks[*node.hash] = struct{}{}
-			b.requestedEverBlocks[*node.hash] = 0
-			b.syncPeer.requestedBlocks[*node.hash] = struct{}{}
-			err = gdmsg.AddInvVect(iv)
+			b.blockScheduler.queue(node.hash)
+		}
+	}
+	b.startHeader = nil
+
+	// Build up a getdata request for the blocks assigned to each peer.
+	// The number of blocks assigned to a peer is limited by the in-flight
+	// limit, which is far lower than wire.MaxInvPerMsg, so no need to
+	// double check it here.
+	assigned := b.blockScheduler.assign(time.Now(), b.isBlockDownloadPeer)
+	for sp, hashes := range assigned {
+		gdmsg := wire.NewMsgGetDataSizeHint(uint(len(hashes)))
+		for i := range hashes {
+			hash := &hashes[i]
+			b.requestedBlocks[*hash] = struct{}{}
+			b.requestedEverBlocks[*hash] = 0
+			sp.requestedBlocks[*hash] = struct{}{}
+			err := gdmsg.AddInvVect(wire.NewInvVect(wire.InvTypeBlock, hash))
 			if err != nil {
 				bmgrLog.Warnf("Failed to add invvect while fetching "+
 					"block headers: %v", err)
 			}
-			numRequested++
 		}
-		b.startHeader = e.Next()
-		if numRequested >= wire.MaxInvPerMsg {
-			break
+		sp.QueueMessage(gdmsg, nil)
+	}
+}
+
+// handleBlockRequestTimeouts reassigns the blocks of headers-first mode that
+// have not been received within the block request timeout to other peers.
+func (b *blockManager) handleBlockRequestTimeouts() {
+	if !b.headersFirstMode {
+		return
+	}
+
+	expired := b.blockScheduler.expire(time.Now())
+	if len(expired) == 0 {
+		return
+	}
+	for sp, hashes := range expired {
+		bmgrLog.Debugf("Requests for %d blocks from %s timed out -- "+
+			"reassigning", len(hashes), sp)
+		for i := range hashes {
+			delete(sp.requestedBlocks, hashes[i])
+			delete(b.requestedBlocks, hashes[i])
+		}
+	}
+	b.fetchHeaderBlocks()
+}
+
+// requestFullBlock requests the full block with the passed hash from the passed
+// peer the same way as when the peer announced it via an inventory vector.  It
+// is used when a block announced via a light block can not be reconstructed.
+func (b *blockManager) requestFullBlock(hash *chainhash.Hash, sp *serverPeer) {
+	inv := wire.NewMsgInvSizeHint(1)
+	inv.AddInvVect(wire.NewInvVect(wire.InvTypeBlock, hash))
+	b.handleInvMsg(&invMsg{inv: inv, peer: sp})
+}
+
+// handleMissedTxsActions sends the requests for missing transactions, requests
+// the full blocks, and processes the reconstructed blocks which resulted from
+// updating the missing transaction recovery state.
+func (b *blockManager) handleMissedTxsActions(actions *missedTxsActions) {
+	for _, req := range actions.requests {
+		req.peer.QueueMessage(req.msg(), nil)
+	}
+	for _, fb := range actions.fullBlocks {
+		bmgrLog.Debugf("Unable to recover the missing transactions of "+
+			"light block %v -- requesting the full block from %s",
+			fb.hash, fb.peer)
+		b.requestFullBlock(&fb.hash, fb.peer)
+	}
+	for _, rb := range actions.recovered {
+		b.processLightBlock(rb.block, rb.peer)
+	}
+}
+
+// processLightBlock processes the passed block reconstructed from a light block
+// announced by the passed peer.  The full block is requested from the peer
+// instead when the reconstructed transactions do not match the merkle roots of
+// the header, since that typically means a memory pool transaction only shares
+// the prefix hash with the transaction of the block, which is not the fault of
+// the peer.
+func (b *blockManager) processLightBlock(block *wire.MsgBlock, sp *serverPeer) {
+	if !merkleRootsMatch(block) {
+		hash := block.BlockHash()
+		bmgrLog.Debugf("Transactions of light block %v do not match its "+
+			"merkle roots -- requesting the full block from %s", hash,
+			sp)
+		b.requestFullBlock(&hash, sp)
+		return
+	}
+	b.handleBlockMsg(&blockMsg{
+		block:      hcashutil.NewBlock(block),
+		peer:       sp,
+		lightBlock: true,
+	})
+}
+
+// handleLightBlockMsg handles lightblock messages from all peers.  The block is
+// reconstructed from the prefilled transactions and the memory pool, and the
+// transactions which are still missing are recovered from the peers that
+// announced the block.
+func (b *blockManager) handleLightBlockMsg(lmsg *lightBlockMsg) {
+	msg := lmsg.lightBlock
+	sp := lmsg.peer
+	hash := msg.BlockHash()
+
+	// Add the peer as an alternate source of the missing transactions when
+	// the block is already being reconstructed and ignore the light block
+	// when the block is already known.
+	if b.missedTxs.announce(&hash, sp) {
+		return
+	}
+	haveBlock, err := b.chain.HaveBlock(&hash)
+	if err != nil || haveBlock {
+		return
+	}
+
+	// Blocks are synced via the regular block messages until the chain is
+	// current, so treat the light block as a block announcement then.
+	if !b.current() || b.missedTxs.full() {
+		b.requestFullBlock(&hash, sp)
+		return
+	}
+
+	txMemPool := b.server.txMemPool
+	lb := newPendingLightBlock(msg, func(txHash *chainhash.Hash) *wire.MsgTx {
+		tx, err := txMemPool.FetchTransaction(txHash, false)
+		if err != nil {
+			return nil
 		}
+		return tx.MsgTx()
+	})
+	if block := lb.block(); block != nil {
+		b.processLightBlock(block, sp)
+		return
 	}
-	if len(gdmsg.InvList) > 0 {
-		b.syncPeer.QueueMessage(gdmsg, nil)
+
+	requests := b.missedTxs.add(lb, sp, time.Now())
+	bmgrLog.Debugf("Requesting %d missing transactions of light block %v "+
+		"from %s in %d batches", len(lb.missingTxns()), hash, sp,
+		len(requests))
+	b.handleMissedTxsActions(&missedTxsActions{requests: requests})
+}
+
+// handleMissedTxsMsg handles missedtxs messages from all peers.
+func (b *blockManager) handleMissedTxsMsg(mmsg *missedTxsMsg) {
+	msg := mmsg.missedTxs
+	actions, ok := b.missedTxs.received(mmsg.peer, msg, time.Now())
+	if !ok {
+		bmgrLog.Debugf("Ignoring unrequested missedtxs message for "+
+			"block %v from %s", msg.BlockHash, mmsg.peer)
+		return
 	}
+	b.handleMissedTxsActions(actions)
+}
+
+// handleMissedTxsTimeouts requests the missing transactions of light blocks
+// which have not been received within the request timeout from alternate
+// peers, or requests the full blocks once the retries are exhausted.
+func (b *blockManager) handleMissedTxsTimeouts() {
+	b.handleMissedTxsActions(b.missedTxs.expire(time.Now()))
 }
 
 // handleHeadersMsg handles headers messages from all peers.
@@ -1543,6 +1769,7 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 			// judgeed prevBlock's type (KeyBlock or MicroBlock)
 			node.height = prevNode.height + 1
 			e := b.headerList.PushBack(&node)
+			b.headerIndex[blockHash] = e
 			if b.startHeader == nil {
 				b.startHeader = e
 			}
@@ -1568,6 +1795,7 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 					"disconnecting", node.height,
 					node.hash, hmsg.peer.Addr(),
 					b.nextCheckpoint.Hash)
+				hmsg.peer.addBanScore(100, 0, "checkpoint mismatch")
 				hmsg.peer.Disconnect()
 				return
 			}
@@ -1582,7 +1810,8 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 		// that is already in the database and is only used to ensure
 		// the next header links properly, it must be removed before
 		// fetching the blocks.
-		b.headerList.Remove(b.headerList.Front())
+		firstNode := b.headerList.Remove(b.headerList.Front()).(*headerNode)
+		delete(b.headerIndex, *firstNode.hash)
 		bmgrLog.Infof("Received %v block headers: Fetching blocks",
 			b.headerList.Len())
 		b.progressLogger.SetLastLogTime(time.Now())
@@ -1844,6 +2073,10 @@ func (b *blockManager) limitMap(m map[chainhash.Hash]struct{}, limit int) {
 // the fetching should proceed.
 func (b *blockManager) blockHandler() {
 	candidatePeers := list.New()
+	requestTicker := time.NewTicker(blockRequestCheckInterval)
+	defer requestTicker.Stop()
+	missedTxsTicker := time.NewTicker(missedTxsCheckInterval)
+	defer missedTxsTicker.Stop()
 out:
 	for {
 		select {
@@ -1860,6 +2093,14 @@ out:
 				b.handleBlockMsg(msg)
 				msg.peer.blockProcessed <- struct{}{}
 
+			case *lightBlockMsg:
+				b.handleLightBlockMsg(msg)
+				msg.peer.blockProcessed <- struct{}{}
+
+			case *missedTxsMsg:
+				b.handleMissedTxsMsg(msg)
+				msg.peer.blockProcessed <- struct{}{}
+
 			case *invMsg:
 				b.handleInvMsg(msg)
 
@@ -1993,8 +2234,10 @@ out:
 				}
 
 			case processBlockMsg:
+				start := time.Now()
 				onMainChain, isOrphan, err := b.chain.ProcessBlock(
 					msg.block, msg.flags)
+				b.server.metrics.observeBlockValidation(start)
 				if err != nil {
 					msg.reply <- processBlockResponse{
 						onMainChain: onMainChain,
@@ -2019,7 +2262,7 @@ out:
 					realKeyHeight := msg.block.MsgBlock().Header.KeyHeight
 
 					isKeyBlock := false
-					if blockchain.HashToBig(msg.block.Hash()).Cmp(blockchain.CompactToBig(msg.block.MsgBlock().Header.Bits)) <= 0{
+					if standalone.HashToBig(msg.block.Hash()).Cmp(standalone.CompactToBig(msg.block.MsgBlock().Header.Bits)) <= 0 {
 						realKeyHeight++
 						isKeyBlock = true
 					}
@@ -2182,8 +2425,10 @@ out:
 				}
 
 			case processTransactionMsg:
+				start := time.Now()
 				acceptedTxs, err := b.server.txMemPool.ProcessTransaction(b.chain, msg.tx,
-					msg.allowOrphans, msg.rateLimit, msg.allowHighFees)
+					msg.allowOrphans, msg.rateLimit, msg.allowHighFees, nil)
+				b.server.metrics.observeTxValidation(start)
 				msg.reply <- processTransactionResponse{
 					acceptedTxs: acceptedTxs,
 					err:         err,
@@ -2197,23 +2442,23 @@ out:
 				<-msg.unpause
 
 			case getCurrentTemplateMsg:
-				cur := deepCopyBlockTemplate(b.cachedCurrentTemplate)
+				cur := mining.DeepCopyBlockTemplate(b.cachedCurrentTemplate)
 				msg.reply <- getCurrentTemplateResponse{
 					Template: cur,
 				}
 
 			case setCurrentTemplateMsg:
-				b.cachedCurrentTemplate = deepCopyBlockTemplate(msg.Template)
+				b.cachedCurrentTemplate = mining.DeepCopyBlockTemplate(msg.Template)
 				msg.reply <- setCurrentTemplateResponse{}
 
 			case getParentTemplateMsg:
-				par := deepCopyBlockTemplate(b.cachedParentTemplate)
+				par := mining.DeepCopyBlockTemplate(b.cachedParentTemplate)
 				msg.reply <- getParentTemplateResponse{
 					Template: par,
 				}
 
 			case setParentTemplateMsg:
-				b.cachedParentTemplate = deepCopyBlockTemplate(msg.Template)
+				b.cachedParentTemplate = mining.DeepCopyBlockTemplate(msg.Template)
 				msg.reply <- setParentTemplateResponse{}
 
 			default:
@@ -2221,6 +2466,12 @@ out:
 					"handler: %T", msg)
 			}
 
+		case <-requestTicker.C:
+			b.handleBlockRequestTimeouts()
+
+		case <-missedTxsTicker.C:
+			b.handleMissedTxsTimeouts()
+
 		case <-b.quit:
 			break out
 		}
@@ -2262,7 +2513,7 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 		tooOldForLotteryData := blockKeyHeight <= (bestKeyHeight - maxLotteryDataBlockDelta)
 		realKeyHeight := block.MsgBlock().Header.KeyHeight
 		isKeyBlock := false
-		if blockchain.HashToBig(block.Hash()).Cmp(blockchain.CompactToBig(block.MsgBlock().Header.Bits)) <= 0{
+		if standalone.HashToBig(block.Hash()).Cmp(standalone.CompactToBig(block.MsgBlock().Header.Bits)) <= 0 {
 			realKeyHeight++
 			isKeyBlock = true
 		}
@@ -2307,8 +2558,10 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 		}
 
 		// Generate the inventory vector and relay it.
+		// The block itself is relayed so it can be announced to peers
+		// which support light blocks.
 		iv := wire.NewInvVect(wire.InvTypeBlock, block.Hash())
-		b.server.RelayInventory(iv, block.MsgBlock().Header)
+		b.server.RelayInventory(iv, block)
 
 	// A block has been connected to the main block chain.
 	case blockchain.NTBlockConnected:
@@ -2326,9 +2579,9 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 		block := blockSlice[0]
 		parentBlock := blockSlice[1]
 
-		isKeyBlock := blockchain.HashToBig(block.Hash()).Cmp(blockchain.CompactToBig(block.MsgBlock().Header.Bits)) <= 0
+		isKeyBlock := standalone.HashToBig(block.Hash()).Cmp(standalone.CompactToBig(block.MsgBlock().Header.Bits)) <= 0
 		isParentKeyBlock :=
-			blockchain.HashToBig(parentBlock.Hash()).Cmp(blockchain.CompactToBig(parentBlock.MsgBlock().Header.Bits)) <= 0
+			standalone.HashToBig(parentBlock.Hash()).Cmp(standalone.CompactToBig(parentBlock.MsgBlock().Header.Bits)) <= 0
 
 		// Check and see if the regular tx tree of the previous block was
 		// invalid or not. If it wasn't, then we need to restore all the tx
@@ -2387,6 +2640,12 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 				acceptedTxs := b.server.txMemPool.ProcessOrphans(b.chain, stx.Hash())
 				b.server.AnnounceNewTransactions(acceptedTxs)
 			}
+
+			// Votes on key blocks before the parent of the new key
+			// block can no longer be mined, so evict them rather
+			// than relaying them until they expire.
+			b.server.txMemPool.PruneStaleVotes(
+				int64(block.MsgBlock().Header.KeyHeight))
 		}
 
 		if r := b.server.rpcServer; r != nil {
@@ -2416,6 +2675,17 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			r.ntfnMgr.NotifyBlockConnected(block)
 		}
 
+		// Notify gRPC block notification streams.
+		if g := b.server.grpcServer; g != nil {
+			g.notifyBlock(block, false)
+		}
+
+		// Allow the ticket revoker to revoke any tickets that were
+		// missed or expired in the new block.
+		if b.server.revoker != nil {
+			b.server.revoker.NotifyBlockConnected()
+		}
+
 	// Stake tickets are spent or missed from the most recently connected block.
 	case blockchain.NTSpentAndMissedTickets:
 		tnd, ok := notification.Data.(*blockchain.TicketNotificationsData)
@@ -2457,9 +2727,9 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 
 		block := blockSlice[0]
 		parentBlock := blockSlice[1]
-		isKeyBlock := blockchain.HashToBig(block.Hash()).Cmp(blockchain.CompactToBig(block.MsgBlock().Header.Bits)) <= 0
+		isKeyBlock := standalone.HashToBig(block.Hash()).Cmp(standalone.CompactToBig(block.MsgBlock().Header.Bits)) <= 0
 		isParentKeyBlock :=
-			blockchain.HashToBig(parentBlock.Hash()).Cmp(blockchain.CompactToBig(parentBlock.MsgBlock().Header.Bits)) <= 0
+			standalone.HashToBig(parentBlock.Hash()).Cmp(standalone.CompactToBig(parentBlock.MsgBlock().Header.Bits)) <= 0
 
 
 
@@ -2519,6 +2789,11 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			r.ntfnMgr.NotifyBlockDisconnected(block)
 		}
 
+		// Notify gRPC block notification streams.
+		if g := b.server.grpcServer; g != nil {
+			g.notifyBlock(block, true)
+		}
+
 	// The blockchain is reorganizing.
 	case blockchain.NTReorganization:
 		rd, ok := notification.Data.(*blockchain.ReorganizationNtfnsData)
@@ -2526,6 +2801,12 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			bmgrLog.Warnf("Chain reorganization notification is malformed")
 			break
 		}
+		bmgrLog.Debugf("Reorganizing from block %v (height %d) to block "+
+			"%v (height %d): %d blocks and %d transactions detached, %d "+
+			"blocks and %d transactions attached", rd.OldHash,
+			rd.OldHeight, rd.NewHash, rd.NewHeight,
+			len(rd.DetachedBlocks), len(rd.DetachedTxns),
+			len(rd.AttachedBlocks), len(rd.AttachedTxns))
 
 		// Notify registered websocket clients.
 		if r := b.server.rpcServer; r != nil {
@@ -2570,6 +2851,30 @@ func (b *blockManager) QueueBlock(block *hcashutil.Block, sp *serverPeer) {
 	b.msgChan <- &blockMsg{block: block, peer: sp}
 }
 
+// QueueLightBlock adds the passed lightblock message and peer to the block
+// handling queue.
+func (b *blockManager) QueueLightBlock(msg *wire.MsgLightBlock, sp *serverPeer) {
+	// Don't accept more blocks if we're shutting down.
+	if atomic.LoadInt32(&b.shutdown) != 0 {
+		sp.blockProcessed <- struct{}{}
+		return
+	}
+
+	b.msgChan <- &lightBlockMsg{lightBlock: msg, peer: sp}
+}
+
+// QueueMissedTxs adds the passed missedtxs message and peer to the block
+// handling queue.
+func (b *blockManager) QueueMissedTxs(msg *wire.MsgMissedTxs, sp *serverPeer) {
+	// Don't accept more blocks if we're shutting down.
+	if atomic.LoadInt32(&b.shutdown) != 0 {
+		sp.blockProcessed <- struct{}{}
+		return
+	}
+
+	b.msgChan <- &missedTxsMsg{missedTxs: msg, peer: sp}
+}
+
 // QueueInv adds the passed inv message and peer to the block handling queue.
 func (b *blockManager) QueueInv(inv *wire.MsgInv, sp *serverPeer) {
 	// No channel handling here because peers do not need to block on inv
@@ -2872,7 +3177,7 @@ func (b *blockManager) TicketPoolValue() (hcashutil.Amount, error) {
 }
 
 // GetCurrentTemplate gets the current block template for mining.
-func (b *blockManager) GetCurrentTemplate() *BlockTemplate {
+func (b *blockManager) GetCurrentTemplate() *mining.BlockTemplate {
 	reply := make(chan getCurrentTemplateResponse)
 	b.msgChan <- getCurrentTemplateMsg{reply: reply}
 	response := <-reply
@@ -2880,7 +3185,7 @@ func (b *blockManager) GetCurrentTemplate() *BlockTemplate {
 }
 
 // SetCurrentTemplate sets the current block template for mining.
-func (b *blockManager) SetCurrentTemplate(bt *BlockTemplate) {
+func (b *blockManager) SetCurrentTemplate(bt *mining.BlockTemplate) {
 	reply := make(chan setCurrentTemplateResponse)
 	b.msgChan <- setCurrentTemplateMsg{Template: bt, reply: reply}
 	<-reply
@@ -2888,7 +3193,7 @@ func (b *blockManager) SetCurrentTemplate(bt *BlockTemplate) {
 }
 
 // GetParentTemplate gets the current parent block template for mining.
-func (b *blockManager) GetParentTemplate() *BlockTemplate {
+func (b *blockManager) GetParentTemplate() *mining.BlockTemplate {
 	reply := make(chan getParentTemplateResponse)
 	b.msgChan <- getParentTemplateMsg{reply: reply}
 	response := <-reply
@@ -2896,7 +3201,7 @@ func (b *blockManager) GetParentTemplate() *BlockTemplate {
 }
 
 // SetParentTemplate sets the current parent block template for mining.
-func (b *blockManager) SetParentTemplate(bt *BlockTemplate) {
+func (b *blockManager) SetParentTemplate(bt *mining.BlockTemplate) {
 	reply := make(chan setParentTemplateResponse)
 	b.msgChan <- setParentTemplateMsg{Template: bt, reply: reply}
 	<-reply
@@ -2905,7 +3210,7 @@ func (b *blockManager) SetParentTemplate(bt *BlockTemplate) {
 
 // newBlockManager returns a new hypercash block manager.
 // Use Start to begin processing asynchronous block and inv updates.
-func newBlockManager(s *server, indexManager blockchain.IndexManager) (*blockManager, error) {
+func newBlockManager(s *server, indexManager blockchain.IndexManager, interrupt <-chan struct{}) (*blockManager, error) {
 	bm := blockManager{
 		server:              s,
 		rejectedTxns:        make(map[chainhash.Hash]struct{}),
@@ -2916,19 +3221,39 @@ func newBlockManager(s *server, indexManager blockchain.IndexManager) (*blockMan
 		progressLogger:      newBlockProgressLogger("Processed", bmgrLog),
 		msgChan:             make(chan interface{}, cfg.MaxPeers*3),
 		headerList:          list.New(),
+		headerIndex:         make(map[chainhash.Hash]*list.Element),
 		AggressiveMining:    !cfg.NonAggressive,
 		quit:                make(chan struct{}),
 	}
+	bm.blockScheduler = newBlockScheduler(maxInFlightBlocksPerPeer,
+		blockDownloadWindow, blockRequestTimeout)
+	bm.missedTxs = newMissedTxsRecovery(maxMissedTxsPerRequest,
+		maxMissedTxsAttempts, maxRecoveringBlocks, missedTxsRequestTimeout)
+
+	// Old block data is only moved when there is cold storage to move it
+	// to.
+	var coldStorageDepth int64
+	if cfg.ColdDataDir != "" {
+		coldStorageDepth = int64(cfg.ColdDepth)
+	}
 
 	// Create a new block chain instance with the appropriate configuration.
 	var err error
 	bm.chain, err = blockchain.New(&blockchain.Config{
-		DB:            s.db,
-		ChainParams:   s.chainParams,
-		TimeSource:    s.timeSource,
-		Notifications: bm.handleNotifyMsg,
-		SigCache:      s.sigCache,
-		IndexManager:  indexManager,
+		DB:                      s.db,
+		ChainParams:             s.chainParams,
+		TimeSource:              s.timeSource,
+		Notifications:           bm.handleNotifyMsg,
+		SigCache:                s.sigCache,
+		SigVerifyConcurrency:    int(cfg.SigVerifyConcurrency),
+		SigHashOptimization:     cfg.SigHashOptimization,
+		CheckForDuplicateHashes: cfg.CheckDuplicateHashes,
+		PruneTarget:             uint64(cfg.Prune) * 1024 * 1024,
+		PruneDepth:              int64(cfg.PruneDepth),
+		ColdStorageDepth:        coldStorageDepth,
+		MaxReorgDepth:           int64(cfg.MaxReorgDepth),
+		IndexManager:            indexManager,
+		Interrupt:               interrupt,
 	})
 	if err != nil {
 		return nil, err
@@ -3057,8 +3382,18 @@ func loadBlockDB() (database.DB, error) {
 	// The database name is based on the database type.
 	dbPath := blockDbPath(cfg.DbType)
 
+	// Old block files are moved to the same database directory within the
+	// cold storage directory when it is configured.
+	dbArgs := []interface{}{dbPath, activeNetParams.Net}
+	if cfg.ColdDataDir != "" {
+		coldPath := filepath.Join(cfg.ColdDataDir, filepath.Base(dbPath))
+		hcashdLog.Infof("Using cold storage for old blocks in '%s'",
+			coldPath)
+		dbArgs = append(dbArgs, coldPath)
+	}
+
 	hcashdLog.Infof("Loading block database from '%s'", dbPath)
-	db, err := database.Open(cfg.DbType, dbPath, activeNetParams.Net)
+	db, err := database.Open(cfg.DbType, dbArgs...)
 	if err != nil {
 		// Return the error if it's not because the database doesn't
 		// exist.
@@ -3073,7 +3408,7 @@ func loadBlockDB() (database.DB, error) {
 		if err != nil {
 			return nil, err
 		}
-		db, err = database.Create(cfg.DbType, dbPath, activeNetParams.Net)
+		db, err = database.Create(cfg.DbType, dbArgs...)
 		if err != nil {
 			return nil, err
 		}
@@ -3083,6 +3418,41 @@ func loadBlockDB() (database.DB, error) {
 	return db, nil
 }
 
+// checkBlockDB verifies the integrity of all blocks stored in the passed block
+// database, repairs the block index as needed, and logs the results.  Corrupt
+// and missing blocks are logged rather than returned as an error.
+func checkBlockDB(db database.DB, interrupt <-chan struct{}) error {
+	hcashdLog.Infof("Checking the integrity of the block database (this " +
+		"might take a while)...")
+	report, err := db.CheckIntegrity(true, interrupt)
+	if err != nil {
+		hcashdLog.Errorf("Unable to check the block database: %v", err)
+		return err
+	}
+	if report.Interrupted {
+		hcashdLog.Infof("Block database check interrupted")
+		return nil
+	}
+
+	for _, hash := range report.CorruptBlocks {
+		hcashdLog.Warnf("Block %v is corrupt", hash)
+	}
+	for _, hash := range report.MissingBlocks {
+		hcashdLog.Warnf("Block %v is missing", hash)
+	}
+	for _, hash := range report.UnindexedBlocks {
+		hcashdLog.Infof("Block %v was not indexed", hash)
+	}
+	hcashdLog.Infof("Checked %d blocks (%d pruned, %d corrupt, %d missing, "+
+		"%d unindexed)", report.CheckedBlocks, report.PrunedBlocks,
+		len(report.CorruptBlocks), len(report.MissingBlocks),
+		len(report.UnindexedBlocks))
+	if report.Repaired {
+		hcashdLog.Infof("Block index rebuilt and database compacted")
+	}
+	return nil
+}
+
 // dumpBlockChain dumps a map of the blockchain blocks as serialized bytes.
 func dumpBlockChain(b *blockchain.BlockChain, height int64) error {
 	bmgrLog.Infof("Writing the blockchain to disk as a flat file, " +
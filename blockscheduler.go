@@ -0,0 +1,303 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+const (
+	// maxInFlightBlocksPerPeer is the maximum number of blocks that are
+	// requested from a single peer at once in headers-first mode.
+	maxInFlightBlocksPerPeer = 32
+
+	// blockDownloadWindow is the maximum distance in the header list
+	// between the first block that has not been received yet and any
+	// block that is requested in headers-first mode.  Blocks received out
+	// of order are held as orphans until their parents arrive, so the
+	// window must be well below the maximum number of orphan blocks to
+	// avoid them being evicted before they can be connected.
+	blockDownloadWindow = 256
+
+	// blockRequestTimeout is the duration after which a block requested
+	// in headers-first mode that has not been received is reassigned to
+	// another peer.
+	blockRequestTimeout = 30 * time.Second
+
+	// blockRequestCheckInterval is the interval at which block requests
+	// are checked for timeouts.
+	blockRequestCheckInterval = 5 * time.Second
+)
+
+// scheduledBlock is a block to be downloaded in headers-first mode.  The
+// sequence number preserves the order of the blocks in the header list so
+// reassigned blocks are requested again before later ones.
+type scheduledBlock struct {
+	hash chainhash.Hash
+	seq  uint64
+}
+
+// blockRequest describes an outstanding request for a scheduled block.
+type blockRequest struct {
+	block     scheduledBlock
+	peer      *serverPeer
+	requested time.Time
+}
+
+// scheduledBlocks implements sort.Interface to sort scheduled blocks by their
+// sequence number.
+type scheduledBlocks []scheduledBlock
+
+func (s scheduledBlocks) Len() int           { return len(s) }
+func (s scheduledBlocks) Less(i, j int) bool { return s[i].seq < s[j].seq }
+func (s scheduledBlocks) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// blockScheduler distributes the requests for the blocks described by the
+// headers downloaded in headers-first mode across the peers that are able to
+// provide them.  Each peer has a limit on the number of blocks it has in
+// flight, and blocks are always assigned to the peer with the fewest blocks in
+// flight so the download is striped across all peers rather than depending on
+// a single one.  Requests that are not fulfilled within the request timeout
+// are reassigned and the in-flight limit of the peer that failed to deliver
+// them is halved, so slow peers are given proportionally less work.  The limit
+// is raised again by one for every block the peer delivers.
+//
+// The scheduler only does the bookkeeping and is not safe for concurrent
+// access.  It is only used from the block handler goroutine.
+type blockScheduler struct {
+	maxInFlight int
+	window      uint64
+	timeout     time.Duration
+	nextSeq     uint64
+
+	// peers houses the peers blocks may be assigned to in the order they
+	// were added, which makes assignments deterministic when several
+	// peers have the same number of blocks in flight.
+	peers  []*serverPeer
+	limits map[*serverPeer]int
+	loads  map[*serverPeer]int
+
+	pending  scheduledBlocks
+	queued   map[chainhash.Hash]struct{}
+	inFlight map[chainhash.Hash]*blockRequest
+}
+
+// newBlockScheduler returns a new block scheduler with the passed per-peer
+// in-flight limit, download window, and request timeout.
+func newBlockScheduler(maxInFlight int, window uint64, timeout time.Duration) *blockScheduler {
+	return &blockScheduler{
+		maxInFlight: maxInFlight,
+		window:      window,
+		timeout:     timeout,
+		limits:      make(map[*serverPeer]int),
+		loads:       make(map[*serverPeer]int),
+		queued:      make(map[chainhash.Hash]struct{}),
+		inFlight:    make(map[chainhash.Hash]*blockRequest),
+	}
+}
+
+// addPeer adds the passed peer to the set of peers blocks may be assigned to.
+func (s *blockScheduler) addPeer(sp *serverPeer) {
+	if _, exists := s.limits[sp]; exists {
+		return
+	}
+	s.peers = append(s.peers, sp)
+	s.limits[sp] = s.maxInFlight
+}
+
+// removePeer removes the passed peer from the set of peers blocks may be
+// assigned to and schedules the blocks it has in flight to be requested
+// again.  It returns the hashes of those blocks.
+func (s *blockScheduler) removePeer(sp *serverPeer) []chainhash.Hash {
+	if _, exists := s.limits[sp]; !exists {
+		return nil
+	}
+	for i, p := range s.peers {
+		if p == sp {
+			s.peers = append(s.peers[:i], s.peers[i+1:]...)
+			break
+		}
+	}
+	delete(s.limits, sp)
+	delete(s.loads, sp)
+
+	var requeued []chainhash.Hash
+	for hash, req := range s.inFlight {
+		if req.peer == sp {
+			requeued = append(requeued, hash)
+			s.requeue(req)
+		}
+	}
+	sort.Sort(s.pending)
+	return requeued
+}
+
+// requeue removes the passed request from the in-flight requests and schedules
+// its block to be requested again.  The pending blocks must be sorted again
+// afterwards.
+func (s *blockScheduler) requeue(req *blockRequest) {
+	delete(s.inFlight, req.block.hash)
+	s.pending = append(s.pending, req.block)
+	s.queued[req.block.hash] = struct{}{}
+}
+
+// queue schedules the block with the passed hash to be requested.  Blocks are
+// requested in the order they are queued.  Blocks that are already scheduled
+// are ignored.
+func (s *blockScheduler) queue(hash *chainhash.Hash) {
+	if _, exists := s.queued[*hash]; exists {
+		return
+	}
+	if _, exists := s.inFlight[*hash]; exists {
+		return
+	}
+	s.pending = append(s.pending, scheduledBlock{hash: *hash, seq: s.nextSeq})
+	s.queued[*hash] = struct{}{}
+	s.nextSeq++
+}
+
+// assign assigns pending blocks to the peers for which the passed function
+// returns true until either there are no more pending blocks within the
+// download window or every such peer has reached its in-flight limit.  The
+// window starts at the earliest block that is either pending or in flight.
+// The blocks are assigned one at a time to the peer with the fewest blocks in
+// flight.  It returns the hashes of the newly assigned blocks keyed by the peer
+// they must be requested from.
+func (s *blockScheduler) assign(now time.Time, eligible func(*serverPeer) bool) map[*serverPeer][]chainhash.Hash {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	candidates := make([]*serverPeer, 0, len(s.peers))
+	for _, sp := range s.peers {
+		if eligible(sp) {
+			candidates = append(candidates, sp)
+		}
+	}
+
+	windowStart := s.pending[0].seq
+	for _, req := range s.inFlight {
+		if req.block.seq < windowStart {
+			windowStart = req.block.seq
+		}
+	}
+
+	assigned := make(map[*serverPeer][]chainhash.Hash)
+	for len(s.pending) > 0 && s.pending[0].seq < windowStart+s.window {
+		var best *serverPeer
+		for _, sp := range candidates {
+			load := s.loads[sp]
+			if load >= s.limits[sp] {
+				continue
+			}
+			if best == nil || load < s.loads[best] {
+				best = sp
+			}
+		}
+		if best == nil {
+			break
+		}
+
+		block := s.pending[0]
+		s.pending = s.pending[1:]
+		delete(s.queued, block.hash)
+		s.inFlight[block.hash] = &blockRequest{
+			block:     block,
+			peer:      best,
+			requested: now,
+		}
+		s.loads[best]++
+		assigned[best] = append(assigned[best], block.hash)
+	}
+
+	return assigned
+}
+
+// received marks the block with the passed hash as received from the passed
+// peer.  When the peer is the one the block was assigned to, its in-flight
+// limit is raised by one up to the maximum.  Blocks received from other peers,
+// such as a peer a timed out request was originally assigned to, are also
+// removed from the schedule since they no longer need to be downloaded.
+func (s *blockScheduler) received(hash *chainhash.Hash, sp *serverPeer) {
+	if _, exists := s.queued[*hash]; exists {
+		delete(s.queued, *hash)
+		for i := range s.pending {
+			if s.pending[i].hash == *hash {
+				s.pending = append(s.pending[:i], s.pending[i+1:]...)
+				break
+			}
+		}
+	}
+
+	req, exists := s.inFlight[*hash]
+	if !exists {
+		return
+	}
+	delete(s.inFlight, *hash)
+	if _, exists := s.loads[req.peer]; exists {
+		s.loads[req.peer]--
+	}
+	if req.peer == sp {
+		if limit, exists := s.limits[sp]; exists && limit < s.maxInFlight {
+			s.limits[sp] = limit + 1
+		}
+	}
+}
+
+// expire schedules every block that was requested more than the request
+// timeout before the passed time to be requested again and halves the
+// in-flight limit of the peers that failed to deliver them, which never drops
+// below one.  It returns the hashes of the expired blocks keyed by the peer
+// they were requested from.
+func (s *blockScheduler) expire(now time.Time) map[*serverPeer][]chainhash.Hash {
+	expired := make(map[*serverPeer][]chainhash.Hash)
+	for hash, req := range s.inFlight {
+		if now.Sub(req.requested) < s.timeout {
+			continue
+		}
+		expired[req.peer] = append(expired[req.peer], hash)
+		s.loads[req.peer]--
+		s.requeue(req)
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	sort.Sort(s.pending)
+
+	for sp := range expired {
+		limit := s.limits[sp] / 2
+		if limit < 1 {
+			limit = 1
+		}
+		s.limits[sp] = limit
+	}
+
+	return expired
+}
+
+// load returns the number of blocks the passed peer has in flight.
+func (s *blockScheduler) load(sp *serverPeer) int {
+	return s.loads[sp]
+}
+
+// numPending returns the number of blocks that have not been assigned to a
+// peer yet.
+func (s *blockScheduler) numPending() int {
+	return len(s.pending)
+}
+
+// reset removes all scheduled blocks and restores the in-flight limits of all
+// peers to the maximum.
+func (s *blockScheduler) reset() {
+	s.pending = nil
+	s.queued = make(map[chainhash.Hash]struct{})
+	s.inFlight = make(map[chainhash.Hash]*blockRequest)
+	for _, sp := range s.peers {
+		s.limits[sp] = s.maxInFlight
+		s.loads[sp] = 0
+	}
+}
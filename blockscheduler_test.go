@@ -0,0 +1,157 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// testBlockHashes returns the passed number of distinct block hashes.
+func testBlockHashes(n int) []chainhash.Hash {
+	hashes := make([]chainhash.Hash, n)
+	for i := range hashes {
+		hashes[i][0] = byte(i)
+		hashes[i][1] = byte(i >> 8)
+	}
+	return hashes
+}
+
+// allPeersEligible is an eligibility function for block scheduler assignments
+// that accepts every peer.
+func allPeersEligible(*serverPeer) bool {
+	return true
+}
+
+// TestBlockSchedulerAssign ensures blocks are striped across peers in order,
+// the per-peer in-flight limits and the download window are honored, and
+// ineligible peers are skipped.
+func TestBlockSchedulerAssign(t *testing.T) {
+	sp1, sp2, sp3 := &serverPeer{}, &serverPeer{}, &serverPeer{}
+	s := newBlockScheduler(2, 5, time.Minute)
+	s.addPeer(sp1)
+	s.addPeer(sp2)
+	s.addPeer(sp3)
+
+	hashes := testBlockHashes(8)
+	for i := range hashes {
+		s.queue(&hashes[i])
+	}
+	s.queue(&hashes[0])
+	if s.numPending() != len(hashes) {
+		t.Fatalf("got %d pending blocks, want %d", s.numPending(),
+			len(hashes))
+	}
+
+	// Only the first five blocks are within the download window, and they
+	// must be assigned round-robin to the eligible peers.
+	assigned := s.assign(time.Now(), func(sp *serverPeer) bool {
+		return sp != sp3
+	})
+	if len(assigned[sp3]) != 0 {
+		t.Fatalf("ineligible peer was assigned %d blocks",
+			len(assigned[sp3]))
+	}
+	want1 := []chainhash.Hash{hashes[0], hashes[2]}
+	want2 := []chainhash.Hash{hashes[1], hashes[3]}
+	if !equalHashes(assigned[sp1], want1) || !equalHashes(assigned[sp2], want2) {
+		t.Fatalf("unexpected assignments %v, %v", assigned[sp1],
+			assigned[sp2])
+	}
+
+	// The fifth block goes to the remaining peer once it is eligible, but
+	// the sixth is outside of the window.
+	assigned = s.assign(time.Now(), allPeersEligible)
+	if !equalHashes(assigned[sp3], hashes[4:5]) || len(assigned) != 1 {
+		t.Fatalf("unexpected assignments %v", assigned)
+	}
+
+	// Receiving the first block moves the window.
+	s.received(&hashes[0], sp1)
+	assigned = s.assign(time.Now(), allPeersEligible)
+	if !equalHashes(assigned[sp1], hashes[5:6]) || len(assigned) != 1 {
+		t.Fatalf("unexpected assignments %v", assigned)
+	}
+	if s.load(sp1) != 2 || s.load(sp2) != 2 || s.load(sp3) != 1 {
+		t.Fatalf("unexpected loads %d, %d, %d", s.load(sp1),
+			s.load(sp2), s.load(sp3))
+	}
+}
+
+// TestBlockSchedulerReassign ensures blocks of removed peers and timed out
+// requests are requested again before later blocks and peers that time out
+// have their in-flight limit reduced.
+func TestBlockSchedulerReassign(t *testing.T) {
+	sp1, sp2 := &serverPeer{}, &serverPeer{}
+	s := newBlockScheduler(4, 100, time.Minute)
+	s.addPeer(sp1)
+	s.addPeer(sp2)
+
+	hashes := testBlockHashes(10)
+	for i := range hashes {
+		s.queue(&hashes[i])
+	}
+	start := time.Now()
+	s.assign(start, allPeersEligible)
+	if s.numPending() != 2 {
+		t.Fatalf("got %d pending blocks, want 2", s.numPending())
+	}
+
+	// Removing the second peer requeues its blocks ahead of the pending
+	// ones.
+	requeued := s.removePeer(sp2)
+	if len(requeued) != 4 {
+		t.Fatalf("got %d requeued blocks, want 4", len(requeued))
+	}
+	s.received(&hashes[0], sp1)
+	assigned := s.assign(start, allPeersEligible)
+	if !equalHashes(assigned[sp1], hashes[1:2]) {
+		t.Fatalf("unexpected assignments %v", assigned)
+	}
+
+	// Nothing expires before the timeout.
+	if expired := s.expire(start.Add(time.Second)); expired != nil {
+		t.Fatalf("unexpected expired requests %v", expired)
+	}
+
+	// All requests of the remaining peer expire after the timeout and its
+	// limit is halved.
+	expired := s.expire(start.Add(time.Minute))
+	if len(expired[sp1]) != 4 || s.load(sp1) != 0 {
+		t.Fatalf("unexpected expired requests %v", expired)
+	}
+	assigned = s.assign(start.Add(time.Minute), allPeersEligible)
+	if !equalHashes(assigned[sp1], []chainhash.Hash{hashes[1], hashes[2]}) {
+		t.Fatalf("unexpected assignments %v", assigned)
+	}
+
+	// Delivered blocks raise the limit again.
+	s.received(&hashes[1], sp1)
+	s.received(&hashes[2], sp1)
+	assigned = s.assign(start.Add(time.Minute), allPeersEligible)
+	if len(assigned[sp1]) != 4 {
+		t.Fatalf("got %d assigned blocks, want 4", len(assigned[sp1]))
+	}
+
+	s.reset()
+	if s.numPending() != 0 || s.load(sp1) != 0 {
+		t.Fatal("scheduler not reset")
+	}
+}
+
+// equalHashes returns whether or not the passed hash slices are equal.
+func equalHashes(a, b []chainhash.Hash) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -40,28 +40,6 @@ var (
 	VoteBitsNotFound = fmt.Errorf("vote bits not found")
 )
 
-// SigHashOptimization is an optimization for verification of transactions that
-// do CHECKSIG operations with hashType SIGHASH_ALL. Although there should be no
-// consequences to daemons that are simply running a node, it may be the case
-// that you could cause database corruption if you turn this code on, create and
-// manipulate your own MsgTx, then include them in blocks. For safety, if you're
-// using the daemon with wallet or mining with the daemon this should be disabled.
-// If you believe that any MsgTxs in your daemon will be used mutably, do NOT
-// turn on this feature. It is disabled by default.
-// This feature is considered EXPERIMENTAL, enable at your own risk!
-var SigHashOptimization = false
-
-// CheckForDuplicateHashes checks for duplicate hashes when validating blocks.
-// Because of the rule inserting the height into the second (nonce) txOut, there
-// should never be a duplicate transaction hash that overwrites another. However,
-// because there is a 2^128 chance of a collision, the paranoid user may wish to
-// turn this feature on.
-var CheckForDuplicateHashes = false
-
-// CPUMinerThreads is the default number of threads to utilize with the
-// CPUMiner when mining.
-var CPUMinerThreads = 1
-
 // Checkpoint identifies a known good point in the block chain.  Using
 // checkpoints allows a few optimizations for old blocks during initial download
 // and also prevents forks from old blocks.
@@ -169,6 +147,11 @@ const (
 	// VoteIDLNSupport is the vote ID for determining if the developers
 	// should work on integrating Lightning Network support.
 	VoteIDLNSupport = "lnsupport"
+
+	// VoteIDVoteSigAggregation is the vote ID for the experimental agenda
+	// which verifies the aggregatable signatures of the votes of a key block
+	// in a single operation.
+	VoteIDVoteSigAggregation = "votesigaggregation"
 )
 
 // ConsensusDeployment defines details related to a specific consensus rule
@@ -463,6 +446,11 @@ type Params struct {
 	// block height 1. If there are no payouts to be given, set this
 	// to an empty slice.
 	BlockOneLedger []*TokenPayout
+
+	// NoticeKeys are the serialized secp256k1 public keys that are allowed
+	// to sign network notices.  Notices are neither accepted nor relayed
+	// when no keys are configured.
+	NoticeKeys [][]byte
 }
 
 // MainNetParams defines the network parameters for the main Hypercash network.
@@ -756,7 +744,7 @@ var TestNet2Params = Params{
 	TicketPoolSize:          8192,
 	TicketsPerBlock:         5,
 	TicketMaturity:          256,
-	TicketExpiry:            40960, // 6*TicketPoolSize
+	TicketExpiry:            40960, // 5*TicketPoolSize
 	CoinbaseMaturity:        256,
 	SStxChangeMaturity:      1,
 	TicketPoolSizeWeight:    4,
@@ -801,7 +789,7 @@ var SimNetParams = Params{
 	ReduceMinDifficulty:      false,
 	MinDiffReductionTime:     0, // Does not apply since ReduceMinDifficulty false
 	GenerateSupported:        true,
-	MaximumBlockSizes:        []int{2048000},
+	MaximumBlockSizes:        []int{2048000, 2560000},
 	MaxTxSize:                2048000,
 	TargetTimePerBlock:       time.Second,
 	WorkDiffAlpha:            1,
@@ -834,7 +822,7 @@ var SimNetParams = Params{
 		4: {{
 			Vote: Vote{
 				Id:          VoteIDMaxBlockSize,
-				Description: "Change maximum allowed block size from 1MiB to 1.25MB",
+				Description: "Change maximum allowed block size from 2.048MB to 2.56MB",
 				Mask:        0x0006, // Bits 1 and 2
 				Choices: []Choice{{
 					Id:          "abstain",
@@ -887,6 +875,34 @@ var SimNetParams = Params{
 			StartTime:  0,             // Always available for vote
 			ExpireTime: math.MaxInt64, // Never expires
 		}},
+		6: {{
+			Vote: Vote{
+				Id:          VoteIDVoteSigAggregation,
+				Description: "Verify the aggregatable vote signatures of key blocks in a single operation (experimental)",
+				Mask:        0x0006, // Bits 1 and 2
+				Choices: []Choice{{
+					Id:          "abstain",
+					Description: "abstain voting for change",
+					Bits:        0x0000,
+					IsAbstain:   true,
+					IsNo:        false,
+				}, {
+					Id:          "no",
+					Description: "keep verifying vote signatures individually",
+					Bits:        0x0002, // Bit 1
+					IsAbstain:   false,
+					IsNo:        true,
+				}, {
+					Id:          "yes",
+					Description: "verify vote signatures in aggregate",
+					Bits:        0x0004, // Bit 2
+					IsAbstain:   false,
+					IsNo:        false,
+				}},
+			},
+			StartTime:  0,             // Always available for vote
+			ExpireTime: math.MaxInt64, // Never expires
+		}},
 	},
 
 	// Enforce current block version once majority of the network has
@@ -1006,6 +1022,9 @@ var (
 // due to a previous Register call, or the network being one of the default
 // networks).
 //
+// The parameters are checked with Validate before they are registered and the
+// resulting error is returned when they are inconsistent.
+//
 // Network parameters should be registered into this package by a main package
 // as early as possible.  Then, library packages may lookup networks or network
 // parameters based on inputs and work regardless of the network being standard
@@ -1014,6 +1033,9 @@ func Register(params *Params) error {
 	if _, ok := registeredNets[params.Net]; ok {
 		return ErrDuplicateNet
 	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
 	registeredNets[params.Net] = struct{}{}
 	pubKeyAddrIDs[params.PubKeyAddrID] = struct{}{}
 	pubKeyHashAddrIDs[params.PubKeyHashAddrID] = struct{}{}
@@ -1139,6 +1161,48 @@ func (p *Params) TotalSubsidyProportions() uint16 {
 	return p.WorkRewardProportion + p.StakeRewardProportion + p.BlockTaxProportion
 }
 
+// Validate checks the interdependencies between the parameters that must hold
+// for the consensus rules to work as intended and returns an error describing
+// the first violation, if any.  Since several parameters are derived from
+// others, this catches values that silently diverged when a network was
+// modified.
+func (p *Params) Validate() error {
+	// Tickets must not be able to expire before the first votes are
+	// required.
+	minTicketExpiry := p.StakeEnabledHeight + p.StakeValidationHeight
+	if int64(p.TicketExpiry) < minTicketExpiry {
+		return fmt.Errorf("%s: ticket expiry %d is less than the stake "+
+			"enabled height plus the stake validation height %d",
+			p.Name, p.TicketExpiry, minTicketExpiry)
+	}
+
+	// The difficulty retarget timespan must cover exactly one work
+	// difficulty window.
+	timespan := p.TargetTimePerBlock * time.Duration(p.WorkDiffWindowSize)
+	if p.TargetTimespan != timespan {
+		return fmt.Errorf("%s: target timespan %v does not match the "+
+			"target time per block times the work difficulty window "+
+			"size %v", p.Name, p.TargetTimespan, timespan)
+	}
+
+	// The first ticket can only mature once the coins used to purchase it
+	// are spendable.
+	stakeEnabledHeight := int64(p.CoinbaseMaturity) + int64(p.TicketMaturity)
+	if p.StakeEnabledHeight != stakeEnabledHeight {
+		return fmt.Errorf("%s: stake enabled height %d does not match the "+
+			"coinbase maturity plus the ticket maturity %d", p.Name,
+			p.StakeEnabledHeight, stakeEnabledHeight)
+	}
+
+	// The subsidy is split between the work, stake and tax proportions.
+	if total := p.TotalSubsidyProportions(); total != 100 {
+		return fmt.Errorf("%s: subsidy proportions sum to %d instead of "+
+			"100", p.Name, total)
+	}
+
+	return nil
+}
+
 // LatestCheckpointHeight is the height of the latest checkpoint block in the
 // parameters.
 func (p *Params) LatestCheckpointHeight() int64 {
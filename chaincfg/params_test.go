@@ -5,7 +5,10 @@
 
 package chaincfg
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 // TestMustRegisterPanic ensures the mustRegister function panics when used to
 // register an invalid network.
@@ -23,3 +26,43 @@ func TestMustRegisterPanic(t *testing.T) {
 	// Intentionally try to register duplicate params to force a panic.
 	mustRegister(&MainNetParams)
 }
+
+// TestValidate ensures the default networks pass validation and parameters
+// that violate the documented invariants are rejected.
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, params := range []*Params{&MainNetParams, &TestNet2Params,
+		&SimNetParams} {
+
+		if err := params.Validate(); err != nil {
+			t.Errorf("%s: unexpected error %v", params.Name, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(p *Params)
+	}{
+		{"ticket expiry too low", func(p *Params) {
+			p.TicketExpiry = uint32(p.StakeEnabledHeight +
+				p.StakeValidationHeight - 1)
+		}},
+		{"target timespan mismatch", func(p *Params) {
+			p.TargetTimespan += time.Second
+		}},
+		{"stake enabled height mismatch", func(p *Params) {
+			p.TicketMaturity++
+		}},
+		{"subsidy proportions mismatch", func(p *Params) {
+			p.BlockTaxProportion++
+		}},
+	}
+	for _, test := range tests {
+		params := SimNetParams
+		test.mutate(&params)
+		if err := params.Validate(); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
@@ -22,6 +22,10 @@ var mockNetParams = Params{
 	ScriptHashAddrID: [2]byte{0xf9},
 	HDPrivateKeyID:   [4]byte{0x01, 0x02, 0x03, 0x04},
 	HDPublicKeyID:    [4]byte{0x05, 0x06, 0x07, 0x08},
+
+	WorkRewardProportion:  45,
+	StakeRewardProportion: 45,
+	BlockTaxProportion:    10,
 }
 
 func TestRegister(t *testing.T) {
@@ -10,11 +10,11 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/HcashOrg/hcashd/appdata"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/database"
 	_ "github.com/HcashOrg/hcashd/database/ffldb"
 	"github.com/HcashOrg/hcashd/wire"
-	"github.com/HcashOrg/hcashutil"
 	flags "github.com/jessevdk/go-flags"
 )
 
@@ -25,7 +25,7 @@ const (
 )
 
 var (
-	hcashdHomeDir     = hcashutil.AppDataDir("hcashd", false)
+	hcashdHomeDir   = appdata.Dir("hcashd", false)
 	defaultDataDir  = filepath.Join(hcashdHomeDir, "data")
 	knownDbTypes    = database.SupportedDrivers()
 	activeNetParams = &chaincfg.MainNetParams
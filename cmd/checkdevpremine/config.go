@@ -12,14 +12,14 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/HcashOrg/hcashutil"
+	"github.com/HcashOrg/hcashd/appdata"
 
 	flags "github.com/jessevdk/go-flags"
 )
 
 var (
-	hcashdHomeDir        = hcashutil.AppDataDir("hcashd", false)
-	appHomeDir         = hcashutil.AppDataDir("checkdevpremine", false)
+	hcashdHomeDir      = appdata.Dir("hcashd", false)
+	appHomeDir         = appdata.Dir("checkdevpremine", false)
 	defaultConfigFile  = filepath.Join(appHomeDir, "checkdevpremine.conf")
 	defaultRPCServer   = "localhost"
 	defaultRPCCertFile = filepath.Join(hcashdHomeDir, "rpc.cert")
@@ -54,7 +54,7 @@ func normalizeAddress(addr string) string {
 func cleanAndExpandPath(path string) string {
 	// Expand initial ~ to OS specific home directory.
 	if strings.HasPrefix(path, "~") {
-		homeDir := filepath.Dir(appHomeDir)
+		homeDir := appdata.HomeDir()
 		path = strings.Replace(path, "~", homeDir, 1)
 	}
 
@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/HcashOrg/hcashd/appdata"
 	"github.com/HcashOrg/hcashutil"
 	flags "github.com/jessevdk/go-flags"
 )
@@ -83,8 +84,7 @@ func main() {
 func cleanAndExpandPath(path string) string {
 	// Expand initial ~ to OS specific home directory.
 	if strings.HasPrefix(path, "~") {
-		appHomeDir := hcashutil.AppDataDir("gencerts", false)
-		homeDir := filepath.Dir(appHomeDir)
+		homeDir := appdata.HomeDir()
 		path = strings.Replace(path, "~", homeDir, 1)
 	}
 
@@ -15,8 +15,8 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/HcashOrg/hcashd/appdata"
 	"github.com/HcashOrg/hcashd/hcashjson"
-	"github.com/HcashOrg/hcashutil"
 
 	flags "github.com/jessevdk/go-flags"
 )
@@ -29,9 +29,9 @@ const (
 )
 
 var (
-	hcashdHomeDir            = hcashutil.AppDataDir("hcashd", false)
-	hcashctlHomeDir          = hcashutil.AppDataDir("hcashctl", false)
-	hcashwalletHomeDir       = hcashutil.AppDataDir("hcashwallet", false)
+	hcashdHomeDir          = appdata.Dir("hcashd", false)
+	hcashctlHomeDir        = appdata.Dir("hcashctl", false)
+	hcashwalletHomeDir     = appdata.Dir("hcashwallet", false)
 	defaultConfigFile      = filepath.Join(hcashctlHomeDir, "hcashctl.conf")
 	defaultRPCServer       = "localhost"
 	defaultWalletRPCServer = "localhost"
@@ -152,7 +152,7 @@ func normalizeAddress(addr string, useTestNet, useSimNet, useWallet bool) string
 func cleanAndExpandPath(path string) string {
 	// Expand initial ~ to OS specific home directory.
 	if strings.HasPrefix(path, "~") {
-		homeDir := filepath.Dir(hcashctlHomeDir)
+		homeDir := appdata.HomeDir()
 		path = strings.Replace(path, "~", homeDir, 1)
 	}
 
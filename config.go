@@ -19,49 +19,32 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 
-	"github.com/btcsuite/btclog"
-	"github.com/btcsuite/go-socks/socks"
+	"github.com/HcashOrg/hcashd/appdata"
+	"github.com/HcashOrg/hcashd/blockchain"
 	"github.com/HcashOrg/hcashd/connmgr"
 	"github.com/HcashOrg/hcashd/database"
 	_ "github.com/HcashOrg/hcashd/database/ffldb"
-	"github.com/HcashOrg/hcashd/mempool"
+	"github.com/HcashOrg/hcashd/hcashjson"
+	"github.com/HcashOrg/hcashd/mining"
 	"github.com/HcashOrg/hcashd/sampleconfig"
 	"github.com/HcashOrg/hcashutil"
+	"github.com/btcsuite/btclog"
+	"github.com/btcsuite/go-socks/socks"
 	flags "github.com/jessevdk/go-flags"
 )
 
 const (
-	defaultConfigFilename        = "hcashd.conf"
-	defaultDataDirname           = "data"
-	defaultLogLevel              = "info"
-	defaultLogDirname            = "logs"
-	defaultLogFilename           = "hcashd.log"
-	defaultMaxPeers              = 125
-	defaultBanDuration           = time.Hour * 24
-	defaultBanThreshold          = 100
-	defaultMaxRPCClients         = 10
-	defaultMaxRPCWebsockets      = 25
-	defaultMaxRPCConcurrentReqs  = 20
-	defaultDbType                = "ffldb"
-	defaultFreeTxRelayLimit      = 15.0
-	defaultBlockMinSize          = 0
-	defaultBlockMaxSize          = 2000000
-	blockMaxSizeMin              = 1000
-	defaultAddrIndex             = false
-	defaultGenerate              = false
-	defaultNoMiningStateSync     = false
-	defaultAllowOldVotes         = false
-	defaultMaxOrphanTransactions = 1000
-	defaultMaxOrphanTxSize       = 5000
-	defaultSigCacheMaxSize       = 100000
-	defaultTxIndex               = false
-	defaultNoExistsAddrIndex     = false
+	defaultConfigFilename  = "hcashd.conf"
+	defaultDataDirname     = "data"
+	defaultLogDirname      = "logs"
+	defaultLogFilename     = "hcashd.log"
+	blockMaxSizeMin        = 1000
+	defaultMaxOrphanTxSize = 5000
 )
 
 var (
-	defaultHomeDir     = hcashutil.AppDataDir("hcashd", false)
+	defaultHomeDir     = appdata.Dir("hcashd", false)
 	defaultConfigFile  = filepath.Join(defaultHomeDir, defaultConfigFilename)
 	defaultDataDir     = filepath.Join(defaultHomeDir, defaultDataDirname)
 	knownDbTypes       = database.SupportedDrivers()
@@ -83,91 +66,21 @@ func minUint32(a, b uint32) uint32 {
 	return b
 }
 
-// config defines the configuration options for hcashd.
+// config defines the configuration options for hcashd along with the settings
+// derived from them.
 //
 // See loadConfig for details on the configuration load process.
 type config struct {
-	HomeDir              string        `short:"A" long:"appdata" description:"Path to application home directory"`
-	ShowVersion          bool          `short:"V" long:"version" description:"Display version information and exit"`
-	ConfigFile           string        `short:"C" long:"configfile" description:"Path to configuration file"`
-	DataDir              string        `short:"b" long:"datadir" description:"Directory to store data"`
-	LogDir               string        `long:"logdir" description:"Directory to log output."`
-	AddPeers             []string      `short:"a" long:"addpeer" description:"Add a peer to connect with at startup"`
-	ConnectPeers         []string      `long:"connect" description:"Connect only to the specified peers at startup"`
-	DisableListen        bool          `long:"nolisten" description:"Disable listening for incoming connections -- NOTE: Listening is automatically disabled if the --connect or --proxy options are used without also specifying listen interfaces via --listen"`
-	Listeners            []string      `long:"listen" description:"Add an interface/port to listen for connections (default all interfaces port: 14008, testnet: 14008)"`
-	MaxPeers             int           `long:"maxpeers" description:"Max number of inbound and outbound peers"`
-	DisableBanning       bool          `long:"nobanning" description:"Disable banning of misbehaving peers"`
-	BanDuration          time.Duration `long:"banduration" description:"How long to ban misbehaving peers.  Valid time units are {s, m, h}.  Minimum 1 second"`
-	BanThreshold         uint32        `long:"banthreshold" description:"Maximum allowed ban score before disconnecting and banning misbehaving peers."`
-	Whitelists           []string      `long:"whitelist" description:"Add an IP network or IP that will not be banned. (eg. 192.168.1.0/24 or ::1)"`
-	RPCUser              string        `short:"u" long:"rpcuser" description:"Username for RPC connections"`
-	RPCPass              string        `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
-	RPCLimitUser         string        `long:"rpclimituser" description:"Username for limited RPC connections"`
-	RPCLimitPass         string        `long:"rpclimitpass" default-mask:"-" description:"Password for limited RPC connections"`
-	RPCListeners         []string      `long:"rpclisten" description:"Add an interface/port to listen for RPC connections (default port: 14009, testnet: 12009)"`
-	RPCCert              string        `long:"rpccert" description:"File containing the certificate file"`
-	RPCKey               string        `long:"rpckey" description:"File containing the certificate key"`
-	RPCMaxClients        int           `long:"rpcmaxclients" description:"Max number of RPC clients for standard connections"`
-	RPCMaxWebsockets     int           `long:"rpcmaxwebsockets" description:"Max number of RPC websocket connections"`
-	RPCMaxConcurrentReqs int           `long:"rpcmaxconcurrentreqs" description:"Max number of concurrent RPC requests that may be processed concurrently"`
-	DisableRPC           bool          `long:"norpc" description:"Disable built-in RPC server -- NOTE: The RPC server is disabled by default if no rpcuser/rpcpass or rpclimituser/rpclimitpass is specified"`
-	DisableTLS           bool          `long:"notls" description:"Disable TLS for the RPC server -- NOTE: This is only allowed if the RPC server is bound to localhost"`
-	DisableDNSSeed       bool          `long:"nodnsseed" description:"Disable DNS seeding for peers"`
-	ExternalIPs          []string      `long:"externalip" description:"Add an ip to the list of local addresses we claim to listen on to peers"`
-	Proxy                string        `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
-	ProxyUser            string        `long:"proxyuser" description:"Username for proxy server"`
-	ProxyPass            string        `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
-	OnionProxy           string        `long:"onion" description:"Connect to tor hidden services via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
-	OnionProxyUser       string        `long:"onionuser" description:"Username for onion proxy server"`
-	OnionProxyPass       string        `long:"onionpass" default-mask:"-" description:"Password for onion proxy server"`
-	NoOnion              bool          `long:"noonion" description:"Disable connecting to tor hidden services"`
-	TorIsolation         bool          `long:"torisolation" description:"Enable Tor stream isolation by randomizing user credentials for each connection."`
-	TestNet              bool          `long:"testnet" description:"Use the test network"`
-	SimNet               bool          `long:"simnet" description:"Use the simulation test network"`
-	DisableCheckpoints   bool          `long:"nocheckpoints" description:"Disable built-in checkpoints.  Don't do this unless you know what you're doing."`
-	DbType               string        `long:"dbtype" description:"Database backend to use for the Block Chain"`
-	Profile              string        `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
-	CPUProfile           string        `long:"cpuprofile" description:"Write CPU profile to the specified file"`
-	MemProfile           string        `long:"memprofile" description:"Write mem profile to the specified file"`
-	DumpBlockchain       string        `long:"dumpblockchain" description:"Write blockchain as a flat file of blocks for use with addblock, to the specified filename"`
-	MiningTimeOffset     int           `long:"miningtimeoffset" description:"Offset the mining timestamp of a block by this many seconds (positive values are in the past)"`
-	DebugLevel           string        `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
-	Upnp                 bool          `long:"upnp" description:"Use UPnP to map our listening port outside of NAT"`
-	MinRelayTxFee        float64       `long:"minrelaytxfee" description:"The minimum transaction fee in HCASH/kB to be considered a non-zero fee."`
-	FreeTxRelayLimit     float64       `long:"limitfreerelay" description:"Limit relay of transactions with no transaction fee to the given amount in thousands of bytes per minute"`
-	NoRelayPriority      bool          `long:"norelaypriority" description:"Do not require free or low-fee transactions to have high priority for relaying"`
-	MaxOrphanTxs         int           `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
-	Generate             bool          `long:"generate" description:"Generate (mine) coins using the CPU"`
-	MiningAddrs          []string      `long:"miningaddr" description:"Add the specified payment address to the list of addresses to use for generated blocks -- At least one address is required if the generate option is set"`
-	BlockMinSize         uint32        `long:"blockminsize" description:"Mininum block size in bytes to be used when creating a block"`
-	BlockMaxSize         uint32        `long:"blockmaxsize" description:"Maximum block size in bytes to be used when creating a block"`
-	BlockPrioritySize    uint32        `long:"blockprioritysize" description:"Size in bytes for high-priority/low-fee transactions when creating a block"`
-	GetWorkKeys          []string      `long:"getworkkey" description:"DEPRECATED -- Use the --miningaddr option instead"`
-	NoPeerBloomFilters   bool          `long:"nopeerbloomfilters" description:"Disable bloom filtering support"`
-	SigCacheMaxSize      uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
-	NonAggressive        bool          `long:"nonaggressive" description:"Disable mining off of the parent block of the blockchain if there aren't enough voters"`
-	NoMiningStateSync    bool          `long:"nominingstatesync" description:"Disable synchronizing the mining state with other nodes"`
-	AllowOldVotes        bool          `long:"allowoldvotes" description:"Enable the addition of very old votes to the mempool"`
-	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
-	RelayNonStd          bool          `long:"relaynonstd" description:"Relay non-standard transactions regardless of the default settings for the active network."`
-	RejectNonStd         bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
-	TxIndex              bool          `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
-	DropTxIndex          bool          `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
-	AddrIndex            bool          `long:"addrindex" description:"Maintain a full address-based transaction index which makes the searchrawtransactions RPC available"`
-	DropAddrIndex        bool          `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
-	NoExistsAddrIndex    bool          `long:"noexistsaddrindex" description:"Disable the exists address index, which tracks whether or not an address has even been used."`
-	DropExistsAddrIndex  bool          `long:"dropexistsaddrindex" description:"Deletes the exists address index from the database on start up and then exits."`
-	PipeRx               uint          `long:"piperx" description:"File descriptor of read end pipe to enable parent -> child process communication"`
-	PipeTx               uint          `long:"pipetx" description:"File descriptor of write end pipe to enable parent <- child process communication"`
-	LifetimeEvents       bool          `long:"lifetimeevents" description:"Send lifetime notifications over the TX pipe"`
-	onionlookup          func(string) ([]net.IP, error)
-	lookup               func(string) ([]net.IP, error)
-	oniondial            func(string, string) (net.Conn, error)
-	dial                 func(string, string) (net.Conn, error)
-	miningAddrs          []hcashutil.Address
-	minRelayTxFee        hcashutil.Amount
-	whitelists           []*net.IPNet
+	sampleconfig.Config
+
+	onionlookup   func(string) ([]net.IP, error)
+	lookup        func(string) ([]net.IP, error)
+	oniondial     func(string, string) (net.Conn, error)
+	dial          func(string, string) (net.Conn, error)
+	miningAddrs   []hcashutil.Address
+	miningPayouts []mining.CoinbasePayout
+	minRelayTxFee hcashutil.Amount
+	whitelists    []*net.IPNet
 }
 
 // serviceOptions defines the configuration options for the daemon as a service on
@@ -176,12 +89,36 @@ type serviceOptions struct {
 	ServiceCommand string `short:"s" long:"service" description:"Service command {install, remove, start, stop}"`
 }
 
+// parseMiningPayout parses a mining payout specified as address:weight on the
+// command line or in the config file.
+func parseMiningPayout(strPayout string) (mining.CoinbasePayout, error) {
+	sep := strings.LastIndex(strPayout, ":")
+	if sep == -1 {
+		return mining.CoinbasePayout{}, errors.New("the weight is " +
+			"missing -- specify as address:weight")
+	}
+	addr, err := hcashutil.DecodeAddress(strPayout[:sep])
+	if err != nil {
+		return mining.CoinbasePayout{}, err
+	}
+	if !addr.IsForNet(activeNetParams.Params) {
+		return mining.CoinbasePayout{}, errors.New("the address is on " +
+			"the wrong network")
+	}
+	weight, err := strconv.ParseUint(strPayout[sep+1:], 10, 32)
+	if err != nil {
+		return mining.CoinbasePayout{}, fmt.Errorf("invalid weight: %v",
+			err)
+	}
+	return mining.CoinbasePayout{Address: addr, Weight: uint32(weight)}, nil
+}
+
 // cleanAndExpandPath expands environment variables and leading ~ in the
 // passed path, cleans the result, and returns it.
 func cleanAndExpandPath(path string) string {
 	// Expand initial ~ to OS specific home directory.
 	if strings.HasPrefix(path, "~") {
-		homeDir := filepath.Dir(defaultHomeDir)
+		homeDir := appdata.HomeDir()
 		path = strings.Replace(path, "~", homeDir, 1)
 	}
 
@@ -374,43 +311,33 @@ func createDefaultConfigFile(destPath string) error {
 //
 // The configuration proceeds as follows:
 // 	1) Start with a default config with sane settings
-// 	2) Pre-parse the command line to check for an alternative config file
-// 	3) Load configuration file overwriting defaults with any specified options
-// 	4) Parse CLI options and overwrite/add any specified options
+// 	2) Apply the HCASHD_<OPTION> environment variables
+// 	3) Pre-parse the command line to check for an alternative config file
+// 	4) Load configuration file overwriting defaults with any specified options
+// 	5) Apply the environment variables again to overwrite the config file
+// 	6) Parse CLI options and overwrite/add any specified options
 //
 // The above results in hcashd functioning properly without any config settings
-// while still allowing the user to override settings with config files and
-// command line options.  Command line options always take precedence.
+// while still allowing the user to override settings with config files,
+// environment variables and command line options.  Command line options always
+// take precedence.
 func loadConfig() (*config, []string, error) {
-	// Default config.
-	cfg := config{
-		HomeDir:              defaultHomeDir,
-		ConfigFile:           defaultConfigFile,
-		DebugLevel:           defaultLogLevel,
-		MaxPeers:             defaultMaxPeers,
-		BanDuration:          defaultBanDuration,
-		BanThreshold:         defaultBanThreshold,
-		RPCMaxClients:        defaultMaxRPCClients,
-		RPCMaxWebsockets:     defaultMaxRPCWebsockets,
-		RPCMaxConcurrentReqs: defaultMaxRPCConcurrentReqs,
-		DataDir:              defaultDataDir,
-		LogDir:               defaultLogDir,
-		DbType:               defaultDbType,
-		RPCKey:               defaultRPCKeyFile,
-		RPCCert:              defaultRPCCertFile,
-		MinRelayTxFee:        mempool.DefaultMinRelayTxFee.ToCoin(),
-		FreeTxRelayLimit:     defaultFreeTxRelayLimit,
-		BlockMinSize:         defaultBlockMinSize,
-		BlockMaxSize:         defaultBlockMaxSize,
-		BlockPrioritySize:    mempool.DefaultBlockPrioritySize,
-		MaxOrphanTxs:         defaultMaxOrphanTransactions,
-		SigCacheMaxSize:      defaultSigCacheMaxSize,
-		Generate:             defaultGenerate,
-		NoMiningStateSync:    defaultNoMiningStateSync,
-		TxIndex:              defaultTxIndex,
-		AddrIndex:            defaultAddrIndex,
-		AllowOldVotes:        defaultAllowOldVotes,
-		NoExistsAddrIndex:    defaultNoExistsAddrIndex,
+	// Default config.  The default config file changes along with the home
+	// directory, so it is kept when the configuration is loaded again.
+	cfg := config{Config: *sampleconfig.DefaultConfig()}
+	cfg.ConfigFile = defaultConfigFile
+
+	// Environment variables override the defaults.  They are applied
+	// before the pre-parse so they may select the config file as well.
+	funcName := "loadConfig"
+	appName := filepath.Base(os.Args[0])
+	appName = strings.TrimSuffix(appName, filepath.Ext(appName))
+	usageMessage := fmt.Sprintf("Use %s -h to show usage", appName)
+	if err := cfg.ApplyEnv(); err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
 	}
 
 	// Service options which are only added on Windows.
@@ -434,9 +361,6 @@ func loadConfig() (*config, []string, error) {
 	}
 
 	// Show the version and exit if the version flag was specified.
-	appName := filepath.Base(os.Args[0])
-	appName = strings.TrimSuffix(appName, filepath.Ext(appName))
-	usageMessage := fmt.Sprintf("Use %s -h to show usage", appName)
 	if preCfg.ShowVersion {
 		fmt.Printf("%s version %s (Go version %s)\n", appName, version(), runtime.Version())
 		os.Exit(0)
@@ -517,6 +441,15 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// Apply the environment variables again so they take precedence over
+	// the config file.
+	if err := cfg.ApplyEnv(); err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Parse command line options again to ensure they take precedence.
 	remainingArgs, err := parser.Parse()
 	if err != nil {
@@ -527,7 +460,6 @@ func loadConfig() (*config, []string, error) {
 	}
 
 	// Create the home directory if it doesn't already exist.
-	funcName := "loadConfig"
 	err = os.MkdirAll(cfg.HomeDir, 0700)
 	if err != nil {
 		// Show a nicer error message if it's because a symlink is
@@ -546,29 +478,23 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	// Multiple networks can't be selected simultaneously.
-	numNets := 0
+	// Validate the options which do not depend on the active network.
+	if err := cfg.Validate(); err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
 
-	// Count number of network flags passed; assign active network params
-	// while we're at it
+	// Assign the active network params.
 	if cfg.TestNet {
-		numNets++
 		activeNetParams = &testNet2Params
 	}
 	if cfg.SimNet {
-		numNets++
 		// Also disable dns seeding on the simulation test network.
 		activeNetParams = &simNetParams
 		cfg.DisableDNSSeed = true
 	}
-	if numNets > 1 {
-		str := "%s: the testnet and simnet params can't be " +
-			"used together -- choose one of the three"
-		err := fmt.Errorf(str, funcName)
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, usageMessage)
-		return nil, nil, err
-	}
 
 	// Set the default policy for relaying non-standard transactions
 	// according to the default of the active network. The set
@@ -576,13 +502,6 @@ func loadConfig() (*config, []string, error) {
 	// selected network.
 	relayNonStd := activeNetParams.RelayNonStdTxs
 	switch {
-	case cfg.RelayNonStd && cfg.RejectNonStd:
-		str := "%s: rejectnonstd and relaynonstd cannot be used " +
-			"together -- choose only one"
-		err := fmt.Errorf(str, funcName)
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, usageMessage)
-		return nil, nil, err
 	case cfg.RejectNonStd:
 		relayNonStd = false
 	case cfg.RelayNonStd:
@@ -603,21 +522,60 @@ func loadConfig() (*config, []string, error) {
 	var oldTestNets []string
 	oldTestNets = append(oldTestNets, filepath.Join(cfg.DataDir, "testnet"))
 	cfg.DataDir = filepath.Join(cfg.DataDir, netName(activeNetParams))
+	if cfg.ColdDataDir != "" {
+		cfg.ColdDataDir = cleanAndExpandPath(cfg.ColdDataDir)
+		cfg.ColdDataDir = filepath.Join(cfg.ColdDataDir,
+			netName(activeNetParams))
+	}
 
 	// Append the network type to the log directory so it is "namespaced"
 	// per network in the same fashion as the data directory.
 	cfg.LogDir = cleanAndExpandPath(cfg.LogDir)
 	cfg.LogDir = filepath.Join(cfg.LogDir, netName(activeNetParams))
 
-	// Special show command to list supported subsystems and exit.
-	if cfg.DebugLevel == "show" {
+	// The gRPC root key and tokens are stored in the network specific data
+	// directory by default.
+	if cfg.GRPCTokenDir == "" {
+		cfg.GRPCTokenDir = filepath.Join(cfg.DataDir, "grpc")
+	}
+	cfg.GRPCTokenDir = cleanAndExpandPath(cfg.GRPCTokenDir)
+
+	// The key block attestation key is also specific to the network and
+	// stored in the network specific data directory by default.
+	if cfg.AttestationKey == "" {
+		cfg.AttestationKey = filepath.Join(cfg.DataDir, "attestation.key")
+	}
+	cfg.AttestationKey = cleanAndExpandPath(cfg.AttestationKey)
+
+	// Special show command to list supported subsystems and exit.  It is
+	// only honored on startup, so reloading a configuration requesting it
+	// fails the debug level validation below instead of exiting.
+	if cfg.DebugLevel == "show" && logRotator == nil {
 		fmt.Println("Supported subsystems", supportedSubsystems())
 		os.Exit(0)
 	}
 
-	// Initialize log rotation.  After log rotation has been initialized, the
-	// logger variables may be used.
-	initLogRotator(filepath.Join(cfg.LogDir, defaultLogFilename))
+	// Validate the subsystems which are logged separately.
+	for _, subsystemID := range cfg.LogSeparate {
+		if _, ok := subsystemLoggers[subsystemID]; !ok {
+			str := "%s: the logseparate option specifies the " +
+				"invalid subsystem [%v] -- supported subsystems %v"
+			err := fmt.Errorf(str, funcName, subsystemID,
+				supportedSubsystems())
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
+	// Initialize log rotation unless it was already initialized by loading
+	// the configuration on startup.  After log rotation has been
+	// initialized, the logger variables may be used.
+	if logRotator == nil {
+		initLogRotator(filepath.Join(cfg.LogDir, defaultLogFilename),
+			cfg.LogFormat, cfg.LogMaxSize, cfg.LogMaxRolls,
+			cfg.LogSeparate)
+	}
 
 	// Parse, validate, and set debug log level(s).
 	if err := parseAndSetDebugLevels(cfg.DebugLevel); err != nil {
@@ -637,68 +595,9 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	// Validate profile port number
-	if cfg.Profile != "" {
-		profilePort, err := strconv.Atoi(cfg.Profile)
-		if err != nil || profilePort < 1024 || profilePort > 65535 {
-			str := "%s: The profile port must be between 1024 and 65535"
-			err := fmt.Errorf(str, funcName)
-			fmt.Fprintln(os.Stderr, err)
-			fmt.Fprintln(os.Stderr, usageMessage)
-			return nil, nil, err
-		}
-	}
-
-	// Don't allow ban durations that are too short.
-	if cfg.BanDuration < time.Second {
-		str := "%s: the banduration option may not be less than 1s -- parsed [%v]"
-		err := fmt.Errorf(str, funcName, cfg.BanDuration)
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, usageMessage)
-		return nil, nil, err
-	}
-
-	// Validate any given whitelisted IP addresses and networks.
-	if len(cfg.Whitelists) > 0 {
-		var ip net.IP
-		cfg.whitelists = make([]*net.IPNet, 0, len(cfg.Whitelists))
-
-		for _, addr := range cfg.Whitelists {
-			_, ipnet, err := net.ParseCIDR(addr)
-			if err != nil {
-				ip = net.ParseIP(addr)
-				if ip == nil {
-					str := "%s: the whitelist value of '%s' is invalid"
-					err = fmt.Errorf(str, funcName, addr)
-					fmt.Fprintln(os.Stderr, err)
-					fmt.Fprintln(os.Stderr, usageMessage)
-					return nil, nil, err
-				}
-				var bits int
-				if ip.To4() == nil {
-					// IPv6
-					bits = 128
-				} else {
-					bits = 32
-				}
-				ipnet = &net.IPNet{
-					IP:   ip,
-					Mask: net.CIDRMask(bits, bits),
-				}
-			}
-			cfg.whitelists = append(cfg.whitelists, ipnet)
-		}
-	}
-
-	// --addPeer and --connect do not mix.
-	if len(cfg.AddPeers) > 0 && len(cfg.ConnectPeers) > 0 {
-		str := "%s: the --addpeer and --connect options can not be " +
-			"mixed"
-		err := fmt.Errorf(str, funcName)
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, usageMessage)
-		return nil, nil, err
-	}
+	// Parse the whitelisted IP addresses and networks, which have already
+	// been validated.
+	cfg.whitelists, _ = cfg.ParseWhitelists()
 
 	// --proxy or --connect without --listen disables listening.
 	if (cfg.Proxy != "" || len(cfg.ConnectPeers) > 0) &&
@@ -720,29 +619,28 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
-	// Check to make sure limited and admin users don't have the same username
-	if cfg.RPCUser == cfg.RPCLimitUser && cfg.RPCUser != "" {
-		str := "%s: --rpcuser and --rpclimituser must not specify the " +
-			"same username"
-		err := fmt.Errorf(str, funcName)
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, usageMessage)
-		return nil, nil, err
+	// Ensure the methods available to limited users exist.
+	for _, method := range cfg.RPCLimitMethods {
+		if _, err := hcashjson.MethodUsageFlags(method); err != nil {
+			str := "%s: the rpclimitmethod option specifies the " +
+				"unknown RPC method %q"
+			err := fmt.Errorf(str, funcName, method)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
 	}
 
-	// Check to make sure limited and admin users don't have the same password
-	if cfg.RPCPass == cfg.RPCLimitPass && cfg.RPCPass != "" {
-		str := "%s: --rpcpass and --rpclimitpass must not specify the " +
-			"same password"
-		err := fmt.Errorf(str, funcName)
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, usageMessage)
-		return nil, nil, err
+	// Expand the path of the RPC client certificate authorities.
+	if cfg.RPCClientCA != "" {
+		cfg.RPCClientCA = cleanAndExpandPath(cfg.RPCClientCA)
 	}
 
-	// The RPC server is disabled if no username or password is provided.
+	// The RPC server is disabled if no username or password is provided
+	// and client certificates are not accepted either.
 	if (cfg.RPCUser == "" || cfg.RPCPass == "") &&
-		(cfg.RPCLimitUser == "" || cfg.RPCLimitPass == "") {
+		(cfg.RPCLimitUser == "" || cfg.RPCLimitPass == "") &&
+		cfg.RPCClientCA == "" {
 		cfg.DisableRPC = true
 	}
 
@@ -759,15 +657,6 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
-	if cfg.RPCMaxConcurrentReqs < 0 {
-		str := "%s: the rpcmaxwebsocketconcurrentrequests option may " +
-			"not be less than 0 -- parsed [%d]"
-		err := fmt.Errorf(str, funcName, cfg.RPCMaxConcurrentReqs)
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, usageMessage)
-		return nil, nil, err
-	}
-
 	// Validate the the minrelaytxfee.
 	cfg.minRelayTxFee, err = hcashutil.NewAmount(cfg.MinRelayTxFee)
 	if err != nil {
@@ -779,8 +668,15 @@ func loadConfig() (*config, []string, error) {
 	}
 
 	// Ensure the specified max block size is not larger than the network will
-	// allow.  1000 bytes is subtracted from the max to account for overhead.
-	blockMaxSizeMax := uint32(activeNetParams.MaximumBlockSizes[0]) - 1000
+	// ever allow.  1000 bytes is subtracted from the max to account for
+	// overhead.  The block templates are further limited to the size allowed
+	// by the current state of the max block size agenda.
+	var blockMaxSizeMax uint32
+	for _, size := range activeNetParams.MaximumBlockSizes {
+		if uint32(size)-1000 > blockMaxSizeMax {
+			blockMaxSizeMax = uint32(size) - 1000
+		}
+	}
 	if cfg.BlockMaxSize < blockMaxSizeMin || cfg.BlockMaxSize >
 		blockMaxSizeMax {
 
@@ -793,56 +689,27 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	// Limit the max orphan count to a sane vlue.
-	if cfg.MaxOrphanTxs < 0 {
-		str := "%s: the maxorphantx option may not be less than 0 " +
-			"-- parsed [%d]"
-		err := fmt.Errorf(str, funcName, cfg.MaxOrphanTxs)
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, usageMessage)
-		return nil, nil, err
-	}
-
 	// Limit the block priority and minimum block sizes to max block size.
 	cfg.BlockPrioritySize = minUint32(cfg.BlockPrioritySize, cfg.BlockMaxSize)
 	cfg.BlockMinSize = minUint32(cfg.BlockMinSize, cfg.BlockMaxSize)
 
-	// --txindex and --droptxindex do not mix.
-	if cfg.TxIndex && cfg.DropTxIndex {
-		err := fmt.Errorf("%s: the --txindex and --droptxindex "+
-			"options may  not be activated at the same time",
-			funcName)
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, usageMessage)
-		return nil, nil, err
-	}
-
-	// --addrindex and --dropaddrindex do not mix.
-	if cfg.AddrIndex && cfg.DropAddrIndex {
-		err := fmt.Errorf("%s: the --addrindex and --dropaddrindex "+
-			"options may not be activated at the same time",
-			funcName)
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, usageMessage)
-		return nil, nil, err
-	}
-
-	// --addrindex and --droptxindex do not mix.
-	if cfg.AddrIndex && cfg.DropTxIndex {
-		err := fmt.Errorf("%s: the --addrindex and --droptxindex "+
-			"options may not be activated at the same time "+
-			"because the address index relies on the transaction "+
-			"index",
-			funcName)
+	// Validate the pruning depth.
+	if cfg.PruneDepth < blockchain.MinPruneDepth {
+		str := "%s: the prune depth must be at least %d -- parsed [%d]"
+		err := fmt.Errorf(str, funcName, blockchain.MinPruneDepth,
+			cfg.PruneDepth)
 		fmt.Fprintln(os.Stderr, err)
 		fmt.Fprintln(os.Stderr, usageMessage)
 		return nil, nil, err
 	}
 
-	// !--noexistsaddrindex and --dropexistsaddrindex do not mix.
-	if !cfg.NoExistsAddrIndex && cfg.DropExistsAddrIndex {
-		err := fmt.Errorf("dropexistsaddrindex cannot be activated when " +
-			"existsaddressindex is on (try setting --noexistsaddrindex)")
+	// The minimum protocol version of peers must not exceed the version
+	// supported by the server.
+	if cfg.MinProtocolVersion > maxProtocolVersion {
+		str := "%s: the minimum protocol version must be at most %d " +
+			"-- parsed [%d]"
+		err := fmt.Errorf(str, funcName, maxProtocolVersion,
+			cfg.MinProtocolVersion)
 		fmt.Fprintln(os.Stderr, err)
 		fmt.Fprintln(os.Stderr, usageMessage)
 		return nil, nil, err
@@ -890,12 +757,24 @@ func loadConfig() (*config, []string, error) {
 		cfg.miningAddrs = append(cfg.miningAddrs, addr)
 	}
 
-	// Ensure there is at least one mining address when the generate flag is
-	// set.
-	if cfg.Generate && len(cfg.MiningAddrs) == 0 {
-		str := "%s: the generate flag is set, but there are no mining " +
-			"addresses specified "
-		err := fmt.Errorf(str, funcName)
+	// Check the mining payouts are valid and save parsed versions.  The
+	// payout addresses are also used as mining addresses so the payouts
+	// alone are enough to enable mining.
+	for _, strPayout := range cfg.MiningPayouts {
+		payout, err := parseMiningPayout(strPayout)
+		if err != nil {
+			str := "%s: mining payout '%s' is invalid: %v"
+			err := fmt.Errorf(str, funcName, strPayout, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.miningPayouts = append(cfg.miningPayouts, payout)
+		cfg.miningAddrs = append(cfg.miningAddrs, payout.Address)
+	}
+	if err := mining.CheckCoinbasePayouts(cfg.miningPayouts); err != nil {
+		str := "%s: invalid mining payouts: %v"
+		err := fmt.Errorf(str, funcName, err)
 		fmt.Fprintln(os.Stderr, err)
 		fmt.Fprintln(os.Stderr, usageMessage)
 		return nil, nil, err
@@ -911,6 +790,11 @@ func loadConfig() (*config, []string, error) {
 	cfg.RPCListeners = normalizeAddresses(cfg.RPCListeners,
 		activeNetParams.rpcPort)
 
+	// Add default port to all gRPC listener addresses if needed and remove
+	// duplicate addresses.
+	cfg.GRPCListeners = normalizeAddresses(cfg.GRPCListeners,
+		activeNetParams.grpcPort)
+
 	// Only allow TLS to be disabled if the RPC is bound to localhost
 	// addresses.
 	if !cfg.DisableRPC && cfg.DisableTLS {
@@ -948,16 +832,6 @@ func loadConfig() (*config, []string, error) {
 	cfg.ConnectPeers = normalizeAddresses(cfg.ConnectPeers,
 		activeNetParams.DefaultPort)
 
-	// Tor stream isolation requires either proxy or onion proxy to be set.
-	if cfg.TorIsolation && cfg.Proxy == "" && cfg.OnionProxy == "" {
-		str := "%s: Tor stream isolation requires either proxy or " +
-			"onionproxy to be set"
-		err := fmt.Errorf(str, funcName)
-		fmt.Fprintln(os.Stderr, err)
-		fmt.Fprintln(os.Stderr, usageMessage)
-		return nil, nil, err
-	}
-
 	// Setup dial and DNS resolution (lookup) functions depending on the
 	// specified options.  The default is to use the standard net.Dial
 	// function as well as the system DNS resolver.  When a proxy is
@@ -1075,7 +949,7 @@ func loadConfig() (*config, []string, error) {
 // could itself use a proxy or not).
 func hcashdDial(addr net.Addr) (net.Conn, error) {
 	if strings.Contains(addr.String(), ".onion:") {
-		return cfg.oniondial(addr.Network(), addr.String())
+		return cfg.oniondial("tcp", addr.String())
 	}
 	return cfg.dial(addr.Network(), addr.String())
 }
@@ -0,0 +1,50 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/mempool"
+	"github.com/HcashOrg/hcashd/sampleconfig"
+)
+
+// TestDefaultConfig ensures the defaults of the configuration, most of which the
+// sampleconfig package defines without importing the packages they belong to,
+// agree with the ones of those packages and pass the validation of loadConfig.
+func TestDefaultConfig(t *testing.T) {
+	cfg := sampleconfig.DefaultConfig()
+	if cfg.MinRelayTxFee != mempool.DefaultMinRelayTxFee.ToCoin() {
+		t.Errorf("minrelaytxfee: got %v, want %v", cfg.MinRelayTxFee,
+			mempool.DefaultMinRelayTxFee.ToCoin())
+	}
+	if cfg.BlockPrioritySize != mempool.DefaultBlockPrioritySize {
+		t.Errorf("blockprioritysize: got %v, want %v",
+			cfg.BlockPrioritySize, mempool.DefaultBlockPrioritySize)
+	}
+	if cfg.MaxMempoolSize != mempool.DefaultMaxPoolSize {
+		t.Errorf("maxmempoolsize: got %v, want %v", cfg.MaxMempoolSize,
+			mempool.DefaultMaxPoolSize)
+	}
+	if cfg.PruneDepth != blockchain.MinPruneDepth {
+		t.Errorf("prunedepth: got %v, want %v", cfg.PruneDepth,
+			blockchain.MinPruneDepth)
+	}
+	if cfg.LogFormat != logFormatText {
+		t.Errorf("logformat: got %v, want %v", cfg.LogFormat,
+			logFormatText)
+	}
+	if cfg.HomeDir != defaultHomeDir || cfg.DataDir != defaultDataDir ||
+		cfg.LogDir != defaultLogDir || cfg.RPCKey != defaultRPCKeyFile ||
+		cfg.RPCCert != defaultRPCCertFile {
+
+		t.Errorf("paths: got %+v, want the ones in %v", cfg,
+			defaultHomeDir)
+	}
+	if !validDbType(cfg.DbType) {
+		t.Errorf("dbtype: unknown default type %v", cfg.DbType)
+	}
+}
@@ -23,6 +23,22 @@ var (
 	//ErrDialNil is used to indicate that Dial cannot be nil in the configuration.
 	ErrDialNil = errors.New("Config: Dial cannot be nil")
 
+	// ErrListenerExists is used to indicate that a listener for an address
+	// that is already being listened on was added.
+	ErrListenerExists = errors.New("already listening on the address")
+
+	// ErrListenerNotFound is used to indicate that no listener for the
+	// address passed to RemoveListener exists.
+	ErrListenerNotFound = errors.New("not listening on the address")
+
+	// ErrNoAcceptHandler is used to indicate that a listener was added to a
+	// connection manager that was not configured with an OnAccept handler.
+	ErrNoAcceptHandler = errors.New("Config: OnAccept is nil")
+
+	// ErrStopped is used to indicate that a listener was added to a
+	// connection manager that has been stopped.
+	ErrStopped = errors.New("connection manager is stopped")
+
 	// maxRetryDuration is the max duration of time retrying of a persistent
 	// connection is allowed to grow to.  This is necessary since the retry
 	// logic uses a backoff mechanism which increases the interval base times
@@ -102,7 +118,8 @@ type Config struct {
 	// connection is accepted, the OnAccept handler will be invoked with the
 	// connection.  Since the connection manager takes ownership of these
 	// listeners, they will be closed when the connection manager is
-	// stopped.
+	// stopped.  Listeners may also be added and removed while the
+	// connection manager is running with AddListener and RemoveListener.
 	//
 	// This field will not have any effect if the OnAccept field is not
 	// also specified.  It may be nil if the caller does not wish to listen
@@ -174,6 +191,13 @@ type ConnManager struct {
 	failedAttempts uint64
 	requests       chan interface{}
 	quit           chan struct{}
+
+	// listeners houses the listeners connections are currently accepted
+	// from and listening is set once their handlers have been started.
+	// They are protected by the listeners mutex.
+	listenersMtx sync.Mutex
+	listeners    []net.Listener
+	listening    bool
 }
 
 // handleFailedConn handles a connection failed due to a disconnect or any
@@ -341,6 +365,12 @@ func (cm *ConnManager) listenHandler(listener net.Listener) {
 	for atomic.LoadInt32(&cm.stop) == 0 {
 		conn, err := listener.Accept()
 		if err != nil {
+			// The listener is closed when it is removed, so stop
+			// accepting connections from it in that case.
+			if !cm.hasListener(listener) {
+				break
+			}
+
 			// Only log the error if not forcibly shutting down.
 			if atomic.LoadInt32(&cm.stop) == 0 {
 				log.Errorf("Can't accept connection: %v", err)
@@ -354,6 +384,90 @@ func (cm *ConnManager) listenHandler(listener net.Listener) {
 	log.Tracef("Listener handler done for %s", listener.Addr())
 }
 
+// hasListener returns whether or not the passed listener is one of the
+// listeners connections are accepted from.
+func (cm *ConnManager) hasListener(listener net.Listener) bool {
+	cm.listenersMtx.Lock()
+	defer cm.listenersMtx.Unlock()
+
+	for _, l := range cm.listeners {
+		if l == listener {
+			return true
+		}
+	}
+	return false
+}
+
+// AddListener adds the passed listener to the listeners connections are
+// accepted from and takes ownership of it.  When the connection manager is
+// running, connections are accepted from the listener immediately, otherwise
+// once it is started.
+//
+// ErrListenerExists is returned when a listener for the same address already
+// exists, in which case the passed listener is not used or closed.
+func (cm *ConnManager) AddListener(listener net.Listener) error {
+	if cm.cfg.OnAccept == nil {
+		return ErrNoAcceptHandler
+	}
+
+	cm.listenersMtx.Lock()
+	defer cm.listenersMtx.Unlock()
+
+	if atomic.LoadInt32(&cm.stop) != 0 {
+		return ErrStopped
+	}
+	addr := listener.Addr().String()
+	for _, l := range cm.listeners {
+		if l.Addr().String() == addr {
+			return ErrListenerExists
+		}
+	}
+
+	cm.listeners = append(cm.listeners, listener)
+	if cm.listening {
+		cm.wg.Add(1)
+		go cm.listenHandler(listener)
+	}
+	return nil
+}
+
+// RemoveListener stops accepting connections from the listener for the passed
+// address and closes it.  Connections that were already accepted from the
+// listener are not affected, so they drain as their peers disconnect.
+//
+// ErrListenerNotFound is returned when there is no listener for the address.
+func (cm *ConnManager) RemoveListener(addr string) error {
+	cm.listenersMtx.Lock()
+	var listener net.Listener
+	for i, l := range cm.listeners {
+		if l.Addr().String() == addr {
+			listener = l
+			cm.listeners = append(cm.listeners[:i], cm.listeners[i+1:]...)
+			break
+		}
+	}
+	cm.listenersMtx.Unlock()
+	if listener == nil {
+		return ErrListenerNotFound
+	}
+
+	log.Infof("Server no longer listening on %s", addr)
+	return listener.Close()
+}
+
+// ListenAddrs returns the addresses of the listeners connections are currently
+// accepted from.
+func (cm *ConnManager) ListenAddrs() []net.Addr {
+	cm.listenersMtx.Lock()
+	defer cm.listenersMtx.Unlock()
+
+	addrs := make([]net.Addr, 0, len(cm.listeners))
+	for _, listener := range cm.listeners {
+		addrs = append(addrs, listener.Addr())
+	}
+	return addrs
+}
+
 // Start launches the connection manager and begins connecting to the network.
 func (cm *ConnManager) Start() {
 	// Already started?
@@ -368,10 +482,13 @@ func (cm *ConnManager) Start() {
 	// Start all the listeners so long as the caller requested them and
 	// provided a callback to be invoked when connections are accepted.
 	if cm.cfg.OnAccept != nil {
-		for _, listner := range cm.cfg.Listeners {
+		cm.listenersMtx.Lock()
+		for _, listner := range cm.listeners {
 			cm.wg.Add(1)
 			go cm.listenHandler(listner)
 		}
+		cm.listening = true
+		cm.listenersMtx.Unlock()
 	}
 
 	for i := atomic.LoadUint64(&cm.connReqCount); i < uint64(cm.cfg.TargetOutbound); i++ {
@@ -393,11 +510,13 @@ func (cm *ConnManager) Stop() {
 
 	// Stop all the listeners.  There will not be any listeners if
 	// listening is disabled.
-	for _, listener := range cm.cfg.Listeners {
+	cm.listenersMtx.Lock()
+	for _, listener := range cm.listeners {
 		// Ignore the error since this is shutdown and there is no way
 		// to recover anyways.
 		_ = listener.Close()
 	}
+	cm.listenersMtx.Unlock()
 
 	close(cm.quit)
 	log.Trace("Connection manager stopped")
@@ -417,9 +536,10 @@ func New(cfg *Config) (*ConnManager, error) {
 		cfg.TargetOutbound = defaultTargetOutbound
 	}
 	cm := ConnManager{
-		cfg:      *cfg, // Copy so caller can't mutate
-		requests: make(chan interface{}),
-		quit:     make(chan struct{}),
+		cfg:       *cfg, // Copy so caller can't mutate
+		requests:  make(chan interface{}),
+		quit:      make(chan struct{}),
+		listeners: append([]net.Listener(nil), cfg.Listeners...),
 	}
 	return &cm, nil
 }
@@ -533,3 +533,69 @@ out:
 	cmgr.Stop()
 	cmgr.Wait()
 }
+
+// TestAddRemoveListener ensures listeners can be added to and removed from a
+// running connection manager.
+func TestAddRemoveListener(t *testing.T) {
+	receivedConns := make(chan net.Conn)
+	listener1 := newMockListener("127.0.0.1:8333")
+	cmgr, err := New(&Config{
+		Listeners: []net.Listener{listener1},
+		OnAccept: func(conn net.Conn) {
+			receivedConns <- conn
+		},
+		Dial: mockDialer,
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	cmgr.Start()
+
+	// Add a listener while running and ensure connections are accepted
+	// from it.
+	listener2 := newMockListener("127.0.0.1:9333")
+	if err := cmgr.AddListener(listener2); err != nil {
+		t.Fatalf("AddListener error: %v", err)
+	}
+	go listener2.Connect("127.0.0.1", 10000)
+	select {
+	case conn := <-receivedConns:
+		if conn.LocalAddr().String() != listener2.localAddr {
+			t.Fatalf("connection accepted on %v, want %v",
+				conn.LocalAddr(), listener2.localAddr)
+		}
+	case <-time.After(time.Millisecond * 50):
+		t.Fatal("Timeout waiting for connection to added listener")
+	}
+
+	// Adding a listener for an address that is already listened on must
+	// fail.
+	err = cmgr.AddListener(newMockListener("127.0.0.1:9333"))
+	if err != ErrListenerExists {
+		t.Fatalf("AddListener: got error %v, want %v", err,
+			ErrListenerExists)
+	}
+
+	// Remove the first listener and ensure only the second one remains.
+	if err := cmgr.RemoveListener("127.0.0.1:8333"); err != nil {
+		t.Fatalf("RemoveListener error: %v", err)
+	}
+	addrs := cmgr.ListenAddrs()
+	if len(addrs) != 1 || addrs[0].String() != listener2.localAddr {
+		t.Fatalf("unexpected listen addresses %v", addrs)
+	}
+	err = cmgr.RemoveListener("127.0.0.1:8333")
+	if err != ErrListenerNotFound {
+		t.Fatalf("RemoveListener: got error %v, want %v", err,
+			ErrListenerNotFound)
+	}
+
+	cmgr.Stop()
+	cmgr.Wait()
+
+	// No listeners may be added once stopped.
+	err = cmgr.AddListener(newMockListener("127.0.0.1:8333"))
+	if err != ErrStopped {
+		t.Fatalf("AddListener: got error %v, want %v", err, ErrStopped)
+	}
+}
@@ -9,14 +9,16 @@ package main
 import (
 	"errors"
 	"fmt"
-	"math/rand"
 	"math/big"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/HcashOrg/hcashd/blockchain"
-	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/hcashjson"
 	"github.com/HcashOrg/hcashd/mining"
 	"github.com/HcashOrg/hcashd/wire"
 	"github.com/HcashOrg/hcashutil"
@@ -47,13 +49,6 @@ const (
 	maxSimnetToMine uint8 = 4
 )
 
-var (
-	// defaultNumWorkers is the default number of workers to use for mining
-	// and is based on the number of processor cores.  This helps ensure the
-	// system stays reasonably responsive under heavy load.
-	defaultNumWorkers = uint32(chaincfg.CPUMinerThreads)
-)
-
 // CPUMiner provides facilities for solving blocks (mining) using the CPU in
 // a concurrency-safe manner.  It consists of two main goroutines -- a speed
 // monitor and a controller for worker goroutines which generate and solve
@@ -62,7 +57,6 @@ var (
 // system which is typically sufficient.
 type CPUMiner struct {
 	sync.Mutex
-	policy            *mining.Policy
 	txSource          mining.TxSource
 	server            *server
 	numWorkers        uint32
@@ -76,6 +70,15 @@ type CPUMiner struct {
 	updateHashes      chan uint64
 	speedMonitorQuit  chan struct{}
 	quit              chan struct{}
+	stats             *cpuMinerStats
+
+	// defaultNumWorkers is the number of workers used unless a number is
+	// requested via SetNumWorkers.
+	defaultNumWorkers uint32
+
+	// miningAddrIndex is incremented atomically for every block template
+	// to rotate through the configured mining addresses.
+	miningAddrIndex uint32
 
 	// This is a map that keeps track of how many blocks have
 	// been mined on each parent by the CPUMiner. It is only
@@ -172,7 +175,7 @@ func (m *CPUMiner) submitBlock(block *hcashutil.Block) bool {
 	// The block was accepted.
 	coinbaseTxGenerated := int64(0)
 
-	if blockchain.HashToBig(block.Hash()).Cmp(blockchain.CompactToBig(block.MsgBlock().Header.Bits)) < 0 {
+	if standalone.HashToBig(block.Hash()).Cmp(standalone.CompactToBig(block.MsgBlock().Header.Bits)) < 0 {
 
 		excoinbaseTxOuts := block.MsgBlock().Transactions[0].TxOut
 		for i, out := range excoinbaseTxOuts {
@@ -208,8 +211,8 @@ func (m *CPUMiner) submitBlock(block *hcashutil.Block) bool {
 // This function will return early with false when conditions that trigger a
 // stale block such as a new block showing up or periodically when there are
 // new transactions and enough time has elapsed without finding a solution.
-func (m *CPUMiner) solveBlock(template *BlockTemplate, ticker *time.Ticker,
-	quit chan struct{}) bool {
+func (m *CPUMiner) solveBlock(workerID uint32, template *mining.BlockTemplate,
+	ticker *time.Ticker, quit chan struct{}) bool {
 	msgBlock := template.Block
 	chain := m.server.blockManager.chain
 	blockHeight := int64(msgBlock.Header.Height)
@@ -226,7 +229,7 @@ func (m *CPUMiner) solveBlock(template *BlockTemplate, ticker *time.Ticker,
 
 	// Create a couple of convenience variables.
 	header := &msgBlock.Header
-	targetDifficulty := blockchain.CompactToBig(header.Bits)
+	targetDifficulty := standalone.CompactToBig(header.Bits)
 	prevKeyHash := header.PrevKeyBlock
 	prevKeyBlock, err := chain.FetchBlockFromHash(&prevKeyHash)
 	prevKeyBlockHeight := int64(0)
@@ -240,7 +243,7 @@ func (m *CPUMiner) solveBlock(template *BlockTemplate, ticker *time.Ticker,
 	//}
 	heightDiff := header.Height - uint32(prevKeyBlockHeight)
 
-	hardTargetDifficulty := blockchain.CompactToBig(header.Bits)
+	hardTargetDifficulty := standalone.CompactToBig(header.Bits)
 
 	if heightDiff <= activeNetParams.MaxMicroPerKey && blockHeight > activeNetParams.MicroBlockValidationHeight &&
 		msgBlock.Transactions[1].TxOut[1].Value > 0 && !template.GenerateKey{
@@ -259,13 +262,13 @@ func (m *CPUMiner) solveBlock(template *BlockTemplate, ticker *time.Ticker,
 	// provided by the Go spec.
 	for extraNonce := uint64(0); extraNonce < maxExtraNonce; extraNonce++ {
 		// Get the old nonce values.
-		ens := getCoinbaseExtranonces(msgBlock)
+		ens := mining.CoinbaseExtranonces(msgBlock)
 		ens[2] = extraNonce + enOffset
 
 		// Update the extra nonce in the block template with the
 		// new value by regenerating the coinbase script and
 		// setting the merkle root to the new value.  The
-		err := UpdateExtraNonce(msgBlock, uint32(blockHeight), blockKeyHeight, ens)
+		err := mining.UpdateExtraNonce(msgBlock, uint32(blockHeight), blockKeyHeight, ens)
 		if err != nil {
 			minrLog.Warnf("Unable to update CPU miner extranonce: %v",
 				err)
@@ -285,6 +288,8 @@ func (m *CPUMiner) solveBlock(template *BlockTemplate, ticker *time.Ticker,
 				return false
 
 			case <-ticker.C:
+				m.stats.addHashes(workerID, hashesCompleted,
+					time.Now())
 				m.updateHashes <- hashesCompleted
 				hashesCompleted = 0
 
@@ -299,7 +304,7 @@ func (m *CPUMiner) solveBlock(template *BlockTemplate, ticker *time.Ticker,
 					return false
 				}
 
-				err = UpdateBlockTime(msgBlock, m.server.blockManager)
+				err = m.server.blkTmplGenerator.UpdateBlockTime(msgBlock)
 				if err != nil {
 					minrLog.Warnf("CPU miner unable to update block template "+
 						"time: %v", err)
@@ -317,10 +322,12 @@ func (m *CPUMiner) solveBlock(template *BlockTemplate, ticker *time.Ticker,
 
 			// The block is solved when the new block hash is less
 			// than the target difficulty.  Yay!
-			if blockchain.HashToBig(&hash).Cmp(targetDifficulty) <= 0 {
+			if standalone.HashToBig(&hash).Cmp(targetDifficulty) <= 0 {
+				m.stats.addHashes(workerID, hashesCompleted,
+					time.Now())
 				m.updateHashes <- hashesCompleted
 
-				if blockchain.HashToBig(&hash).Cmp(hardTargetDifficulty) > 0 {
+				if standalone.HashToBig(&hash).Cmp(hardTargetDifficulty) > 0 {
 					//Microblock delete extracoinbase
 					transactions := make([]*wire.MsgTx, len(msgBlock.Transactions) - 1)
 					for i, tx := range msgBlock.Transactions {
@@ -341,6 +348,16 @@ func (m *CPUMiner) solveBlock(template *BlockTemplate, ticker *time.Ticker,
 	return false
 }
 
+// nextMiningAddress returns the next of the configured mining addresses in
+// round robin order, so the blocks mined by all workers are spread evenly
+// across the addresses.
+//
+// This function is safe for concurrent access.
+func (m *CPUMiner) nextMiningAddress() hcashutil.Address {
+	i := atomic.AddUint32(&m.miningAddrIndex, 1) - 1
+	return cfg.miningAddrs[i%uint32(len(cfg.miningAddrs))]
+}
+
 // generateBlocks is a worker that is controlled by the miningWorkerController.
 // It is self contained in that it creates block templates and attempts to solve
 // them while detecting when it is performing stale work and reacting
@@ -350,6 +367,7 @@ func (m *CPUMiner) solveBlock(template *BlockTemplate, ticker *time.Ticker,
 // It must be run as a goroutine.
 func (m *CPUMiner) generateBlocks(quit chan struct{}) {
 	minrLog.Tracef("Starting generate blocks worker")
+	workerID := m.stats.addWorker(time.Now())
 
 	// Start a ticker which is used to signal checks for stale work and
 	// updates to the speed monitor.
@@ -388,14 +406,13 @@ out:
 			}
 		}
 
-		// Choose a payment address at random.
-		rand.Seed(time.Now().UnixNano())
-		payToAddr := cfg.miningAddrs[rand.Intn(len(cfg.miningAddrs))]
+		// Rotate through the payment addresses.
+		payToAddr := m.nextMiningAddress()
 
 		// Create a new block template using the available transactions
 		// in the memory pool as a source of transactions to potentially
 		// include in the block.
-		template, err := NewBlockTemplate(m.policy, m.server, payToAddr)
+		template, err := m.server.blkTmplGenerator.NewBlockTemplate(payToAddr)
 		m.submitBlockLock.Unlock()
 		if err != nil {
 			errStr := fmt.Sprintf("Failed to create new block "+
@@ -425,13 +442,22 @@ out:
 		// with false when conditions that trigger a stale block, so
 		// a new block template can be generated.  When the return is
 		// true a solution was found, so submit the solved block.
-		if m.solveBlock(template, ticker, quit) {
+		prevBlock := template.Block.Header.PrevBlock
+		m.stats.startWork(&prevBlock, time.Now())
+		if m.solveBlock(workerID, template, ticker, quit) {
 			block := hcashutil.NewBlock(template.Block)
-			m.submitBlock(block)
-			m.minedOnParents[template.Block.Header.PrevBlock]++
+			accepted := m.submitBlock(block)
+			m.stats.submitted(workerID, accepted)
+			if accepted {
+				isKeyBlock := standalone.HashToBig(block.Hash()).Cmp(standalone.CompactToBig(block.MsgBlock().Header.Bits)) <= 0
+				m.stats.solved(&prevBlock, isKeyBlock,
+					time.Now())
+			}
+			m.minedOnParents[prevBlock]++
 		}
 	}
 
+	m.stats.removeWorker(workerID)
 	m.workerWg.Done()
 	minrLog.Tracef("Generate blocks worker done")
 }
@@ -455,33 +481,85 @@ func (m *CPUMiner) miningWorkerController() {
 		}
 	}
 
-	// Launch the current number of workers by default.
-	runningWorkers = make([]chan struct{}, 0, m.numWorkers)
-	launchWorkers(m.numWorkers)
+	// setRunningWorkers launches or stops workers as needed so the
+	// specified number of workers is running.
+	setRunningWorkers := func(numWorkers uint32) {
+		// No change.
+		numRunning := uint32(len(runningWorkers))
+		if numWorkers == numRunning {
+			return
+		}
+
+		// Add new workers.
+		if numWorkers > numRunning {
+			launchWorkers(numWorkers - numRunning)
+			return
+		}
+
+		// Signal the most recently created goroutines to exit.
+		for i := numRunning - 1; i >= numWorkers; i-- {
+			close(runningWorkers[i])
+			runningWorkers[i] = nil
+			runningWorkers = runningWorkers[:i]
+		}
+	}
+
+	// The number of active workers is tuned against the CPU budget when
+	// one is configured, in which case the configured number of workers is
+	// the maximum.
+	maxWorkers := m.numWorkers
+	activeWorkers := maxWorkers
+	var tuner *cpuTuner
+	var tuneTicker <-chan time.Time
+	if cfg.MinerCPUBudget > 0 {
+		cpuTime, err := processCPUTime()
+		if err != nil {
+			minrLog.Warnf("Unable to tune CPU miner workers: %v", err)
+		} else {
+			tuner = newCPUTuner(cfg.MinerCPUBudget, runtime.NumCPU(),
+				cpuTime, time.Now())
+			activeWorkers = tuner.initialWorkers(maxWorkers)
+			ticker := time.NewTicker(cpuTuneInterval)
+			defer ticker.Stop()
+			tuneTicker = ticker.C
+		}
+	}
+
+	// Launch the active number of workers by default.
+	runningWorkers = make([]chan struct{}, 0, maxWorkers)
+	launchWorkers(activeWorkers)
+	m.stats.setTuning(activeWorkers, 0)
 
 out:
 	for {
 		select {
 		// Update the number of running workers.
 		case <-m.updateNumWorkers:
-			// No change.
-			numRunning := uint32(len(runningWorkers))
-			if m.numWorkers == numRunning {
-				continue
+			maxWorkers = m.numWorkers
+			if tuner == nil || activeWorkers > maxWorkers {
+				activeWorkers = maxWorkers
 			}
-
-			// Add new workers.
-			if m.numWorkers > numRunning {
-				launchWorkers(m.numWorkers - numRunning)
+			setRunningWorkers(activeWorkers)
+			m.stats.setTuning(activeWorkers, 0)
+
+		// Tune the number of running workers against the CPU budget.
+		case now := <-tuneTicker:
+			cpuTime, err := processCPUTime()
+			if err != nil {
+				minrLog.Warnf("Unable to tune CPU miner workers: %v",
+					err)
 				continue
 			}
-
-			// Signal the most recently created goroutines to exit.
-			for i := numRunning - 1; i >= m.numWorkers; i-- {
-				close(runningWorkers[i])
-				runningWorkers[i] = nil
-				runningWorkers = runningWorkers[:i]
+			var cpuUsage float64
+			activeWorkers, cpuUsage = tuner.tune(activeWorkers,
+				maxWorkers, cpuTime, now)
+			if uint32(len(runningWorkers)) != activeWorkers {
+				minrLog.Debugf("CPU usage %.1f%% (budget %.1f%%), "+
+					"running %d CPU miner workers", cpuUsage,
+					cfg.MinerCPUBudget, activeWorkers)
 			}
+			setRunningWorkers(activeWorkers)
+			m.stats.setTuning(activeWorkers, cpuUsage)
 
 		case <-m.quit:
 			for _, quit := range runningWorkers {
@@ -494,6 +572,7 @@ out:
 	// Wait until all workers shut down to stop the speed monitor since
 	// they rely on being able to send updates to it.
 	m.workerWg.Wait()
+	m.stats.setTuning(0, 0)
 	close(m.speedMonitorQuit)
 	m.wg.Done()
 }
@@ -572,9 +651,9 @@ func (m *CPUMiner) HashesPerSecond() float64 {
 }
 
 // SetNumWorkers sets the number of workers to create which solve blocks.  Any
-// negative values will cause a default number of workers to be used which is
-// based on the number of processor cores in the system.  A value of 0 will
-// cause all CPU mining to be stopped.
+// negative values will cause the default number of workers the miner was
+// created with to be used.  A value of 0 will cause all CPU mining to be
+// stopped.
 //
 // This function is safe for concurrent access.
 func (m *CPUMiner) SetNumWorkers(numWorkers int32) {
@@ -589,7 +668,7 @@ func (m *CPUMiner) SetNumWorkers(numWorkers int32) {
 
 	// Use default if provided value is negative.
 	if numWorkers < 0 {
-		m.numWorkers = defaultNumWorkers
+		m.numWorkers = m.defaultNumWorkers
 	} else {
 		m.numWorkers = uint32(numWorkers)
 	}
@@ -611,6 +690,47 @@ func (m *CPUMiner) NumWorkers() int32 {
 	return int32(m.numWorkers)
 }
 
+// Stats returns the block discovery latency histograms of the miner along with
+// the state of the tuning of the number of active workers.
+//
+// This function is safe for concurrent access.
+func (m *CPUMiner) Stats() *hcashjson.CPUMinerStatsResult {
+	return m.stats.result(cfg.MinerCPUBudget)
+}
+
+// Info returns the state of the miner along with the hashing speed of every
+// running worker and the number of accepted and rejected blocks.
+//
+// This function is safe for concurrent access.
+func (m *CPUMiner) Info() *hcashjson.GetCPUMinerInfoResult {
+	m.Lock()
+	generating := m.started
+	numWorkers := int32(m.numWorkers)
+	m.Unlock()
+
+	miningAddrs := make([]string, 0, len(cfg.miningAddrs))
+	for _, addr := range cfg.miningAddrs {
+		miningAddrs = append(miningAddrs, addr.EncodeAddress())
+	}
+	var nextMiningAddr string
+	if len(miningAddrs) > 0 {
+		i := atomic.LoadUint32(&m.miningAddrIndex)
+		nextMiningAddr = miningAddrs[i%uint32(len(miningAddrs))]
+	}
+
+	workers, accepted, rejected := m.stats.workerResults(time.Now())
+	return &hcashjson.GetCPUMinerInfoResult{
+		Generating:     generating,
+		NumWorkers:     numWorkers,
+		HashesPerSec:   m.HashesPerSecond(),
+		Accepted:       accepted,
+		Rejected:       rejected,
+		MiningAddrs:    miningAddrs,
+		NextMiningAddr: nextMiningAddr,
+		Workers:        workers,
+	}
+}
+
 // GenerateNBlocks generates the requested number of blocks. It is self
 // contained in that it creates block templates and attempts to solve them while
 // detecting when it is performing stale work and reacting accordingly by
@@ -644,6 +764,7 @@ func (m *CPUMiner) GenerateNBlocks(n uint32) ([]*chainhash.Hash, error) {
 	m.Unlock()
 
 	minrLog.Tracef("Generating %d blocks", n)
+	workerID := m.stats.addWorker(time.Now())
 
 	i := uint32(0)
 	blockHashes := make([]*chainhash.Hash, n)
@@ -667,14 +788,13 @@ func (m *CPUMiner) GenerateNBlocks(n uint32) ([]*chainhash.Hash, error) {
 		// template on a block that is in the process of becoming stale.
 		m.submitBlockLock.Lock()
 
-		// Choose a payment address at random.
-		rand.Seed(time.Now().UnixNano())
-		payToAddr := cfg.miningAddrs[rand.Intn(len(cfg.miningAddrs))]
+		// Rotate through the payment addresses.
+		payToAddr := m.nextMiningAddress()
 
 		// Create a new block template using the available transactions
 		// in the memory pool as a source of transactions to potentially
 		// include in the block.
-		template, err := NewBlockTemplate(m.policy, m.server, payToAddr)
+		template, err := m.server.blkTmplGenerator.NewBlockTemplate(payToAddr)
 		m.submitBlockLock.Unlock()
 		if err != nil {
 			errStr := fmt.Sprintf("Failed to create new block "+
@@ -693,13 +813,22 @@ func (m *CPUMiner) GenerateNBlocks(n uint32) ([]*chainhash.Hash, error) {
 		// with false when conditions that trigger a stale block, so
 		// a new block template can be generated.  When the return is
 		// true a solution was found, so submit the solved block.
-		if m.solveBlock(template, ticker, nil) {
+		prevBlock := template.Block.Header.PrevBlock
+		m.stats.startWork(&prevBlock, time.Now())
+		if m.solveBlock(workerID, template, ticker, nil) {
 			block := hcashutil.NewBlock(template.Block)
-			m.submitBlock(block)
+			accepted := m.submitBlock(block)
+			m.stats.submitted(workerID, accepted)
+			if accepted {
+				isKeyBlock := standalone.HashToBig(block.Hash()).Cmp(standalone.CompactToBig(block.MsgBlock().Header.Bits)) <= 0
+				m.stats.solved(&prevBlock, isKeyBlock,
+					time.Now())
+			}
 			blockHashes[i] = block.Hash()
 			i++
 			if i == n {
 				minrLog.Tracef("Generated %d blocks", i)
+				m.stats.removeWorker(workerID)
 				m.Lock()
 				close(m.speedMonitorQuit)
 				m.wg.Wait()
@@ -712,18 +841,19 @@ func (m *CPUMiner) GenerateNBlocks(n uint32) ([]*chainhash.Hash, error) {
 	}
 }
 
-// newCPUMiner returns a new instance of a CPU miner for the provided server.
-// Use Start to begin the mining process.  See the documentation for CPUMiner
-// type for more details.
-func newCPUMiner(policy *mining.Policy, s *server) *CPUMiner {
+// newCPUMiner returns a new instance of a CPU miner for the provided server
+// which uses the passed default number of workers.  Use Start to begin the
+// mining process.  See the documentation for CPUMiner type for more details.
+func newCPUMiner(s *server, defaultNumWorkers uint32) *CPUMiner {
 	return &CPUMiner{
-		policy:            policy,
 		txSource:          s.txMemPool,
 		server:            s,
 		numWorkers:        defaultNumWorkers,
+		defaultNumWorkers: defaultNumWorkers,
 		updateNumWorkers:  make(chan struct{}),
 		queryHashesPerSec: make(chan float64),
 		updateHashes:      make(chan uint64),
 		minedOnParents:    make(map[chainhash.Hash]uint8),
+		stats:             newCPUMinerStats(),
 	}
 }
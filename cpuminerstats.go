@@ -0,0 +1,385 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/hcashjson"
+)
+
+const (
+	// cpuTuneInterval is the interval at which the number of active CPU
+	// mining workers is tuned against the CPU budget.
+	cpuTuneInterval = 30 * time.Second
+)
+
+// miningLatencyBounds are the upper bounds of the buckets of the block
+// discovery latency histograms.  Latencies above the last bound are counted in
+// a final unbounded bucket.
+var miningLatencyBounds = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	15 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	30 * time.Minute,
+}
+
+// latencyHistogram houses the distribution of block discovery latencies.
+type latencyHistogram struct {
+	counts []uint64
+	total  time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+// newLatencyHistogram returns an empty histogram with a bucket for every bound
+// in miningLatencyBounds and a final unbounded bucket.
+func newLatencyHistogram() *latencyHistogram {
+	return &latencyHistogram{
+		counts: make([]uint64, len(miningLatencyBounds)+1),
+	}
+}
+
+// count returns the number of latencies added to the histogram.
+func (h *latencyHistogram) count() uint64 {
+	var n uint64
+	for _, c := range h.counts {
+		n += c
+	}
+	return n
+}
+
+// add adds the passed latency to the histogram.
+func (h *latencyHistogram) add(latency time.Duration) {
+	if h.count() == 0 || latency < h.min {
+		h.min = latency
+	}
+	if latency > h.max {
+		h.max = latency
+	}
+	h.total += latency
+
+	bucket := len(miningLatencyBounds)
+	for i, bound := range miningLatencyBounds {
+		if latency <= bound {
+			bucket = i
+			break
+		}
+	}
+	h.counts[bucket]++
+}
+
+// result returns the histogram as a JSON-RPC result with all latencies in
+// seconds.
+func (h *latencyHistogram) result() hcashjson.MiningLatencyResult {
+	count := h.count()
+	result := hcashjson.MiningLatencyResult{
+		Count:   count,
+		Min:     h.min.Seconds(),
+		Max:     h.max.Seconds(),
+		Buckets: make([]hcashjson.MiningLatencyBucket, 0, len(h.counts)),
+	}
+	if count > 0 {
+		result.Mean = h.total.Seconds() / float64(count)
+	}
+	for i, c := range h.counts {
+		upperBound := float64(-1)
+		if i < len(miningLatencyBounds) {
+			upperBound = miningLatencyBounds[i].Seconds()
+		}
+		result.Buckets = append(result.Buckets,
+			hcashjson.MiningLatencyBucket{
+				UpperBound: upperBound,
+				Count:      c,
+			})
+	}
+	return result
+}
+
+// cpuWorkerStats tracks the hashing speed of a single CPU mining worker along
+// with the outcome of the submission of the blocks it solved.
+type cpuWorkerStats struct {
+	started      time.Time
+	hashes       uint64
+	hashesPerSec float64
+	windowStart  time.Time
+	windowHashes uint64
+	accepted     uint64
+	rejected     uint64
+}
+
+// addHashes adds the passed number of hashes to the worker and updates its
+// speed once every hpsUpdateSecs the same way the speed monitor of the miner
+// does.
+func (w *cpuWorkerStats) addHashes(hashes uint64, now time.Time) {
+	w.hashes += hashes
+	w.windowHashes += hashes
+	elapsed := now.Sub(w.windowStart)
+	if elapsed < hpsUpdateSecs*time.Second {
+		return
+	}
+
+	curHashesPerSec := float64(w.windowHashes) / elapsed.Seconds()
+	if w.hashesPerSec == 0 {
+		w.hashesPerSec = curHashesPerSec
+	}
+	w.hashesPerSec = (w.hashesPerSec + curHashesPerSec) / 2
+	w.windowHashes = 0
+	w.windowStart = now
+}
+
+// cpuMinerStats tracks the time the CPU miner takes to solve blocks along with
+// the state of the tuning of the number of active workers.  The discovery
+// latency of a block is the time from when any worker started to work on a
+// block building on its parent until the block was solved, so the latency
+// includes the time spent on block templates that became stale.
+type cpuMinerStats struct {
+	mtx         sync.Mutex
+	parent      chainhash.Hash
+	parentStart time.Time
+	micro       *latencyHistogram
+	key         *latencyHistogram
+
+	// These fields report the tuning of the number of active workers.
+	activeWorkers uint32
+	cpuUsage      float64
+
+	// These fields track the running workers and the outcome of the
+	// submission of all solved blocks since the server started.
+	workers      map[uint32]*cpuWorkerStats
+	nextWorkerID uint32
+	accepted     uint64
+	rejected     uint64
+}
+
+// newCPUMinerStats returns a new CPU miner statistics tracker.
+func newCPUMinerStats() *cpuMinerStats {
+	return &cpuMinerStats{
+		micro:   newLatencyHistogram(),
+		key:     newLatencyHistogram(),
+		workers: make(map[uint32]*cpuWorkerStats),
+	}
+}
+
+// addWorker starts tracking a new worker and returns its id.
+//
+// This function is safe for concurrent access.
+func (s *cpuMinerStats) addWorker(now time.Time) uint32 {
+	s.mtx.Lock()
+	id := s.nextWorkerID
+	s.nextWorkerID++
+	s.workers[id] = &cpuWorkerStats{started: now, windowStart: now}
+	s.mtx.Unlock()
+	return id
+}
+
+// removeWorker stops tracking the worker with the passed id.
+//
+// This function is safe for concurrent access.
+func (s *cpuMinerStats) removeWorker(id uint32) {
+	s.mtx.Lock()
+	delete(s.workers, id)
+	s.mtx.Unlock()
+}
+
+// addHashes records that the worker with the passed id performed the passed
+// number of hashes.
+//
+// This function is safe for concurrent access.
+func (s *cpuMinerStats) addHashes(id uint32, hashes uint64, now time.Time) {
+	s.mtx.Lock()
+	if w, ok := s.workers[id]; ok {
+		w.addHashes(hashes, now)
+	}
+	s.mtx.Unlock()
+}
+
+// submitted records whether a block solved by the worker with the passed id
+// was accepted.
+//
+// This function is safe for concurrent access.
+func (s *cpuMinerStats) submitted(id uint32, accepted bool) {
+	s.mtx.Lock()
+	w := s.workers[id]
+	if accepted {
+		s.accepted++
+		if w != nil {
+			w.accepted++
+		}
+	} else {
+		s.rejected++
+		if w != nil {
+			w.rejected++
+		}
+	}
+	s.mtx.Unlock()
+}
+
+// workerResults returns the statistics of the running workers ordered by id
+// along with the number of accepted and rejected blocks of all workers since
+// the server started.
+//
+// This function is safe for concurrent access.
+func (s *cpuMinerStats) workerResults(now time.Time) ([]hcashjson.CPUMinerWorkerResult, uint64, uint64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	results := make([]hcashjson.CPUMinerWorkerResult, 0, len(s.workers))
+	for id, w := range s.workers {
+		results = append(results, hcashjson.CPUMinerWorkerResult{
+			ID:           id,
+			Uptime:       int64(now.Sub(w.started).Seconds()),
+			Hashes:       w.hashes,
+			HashesPerSec: w.hashesPerSec,
+			Accepted:     w.accepted,
+			Rejected:     w.rejected,
+		})
+	}
+	sort.Sort(cpuMinerWorkersByID(results))
+	return results, s.accepted, s.rejected
+}
+
+// cpuMinerWorkersByID implements sort.Interface to sort worker results by id.
+type cpuMinerWorkersByID []hcashjson.CPUMinerWorkerResult
+
+func (s cpuMinerWorkersByID) Len() int           { return len(s) }
+func (s cpuMinerWorkersByID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s cpuMinerWorkersByID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+
+// startWork records that a worker started to work on a block building on the
+// passed parent.  Only the first worker to start on a parent starts the
+// discovery latency measurement.
+//
+// This function is safe for concurrent access.
+func (s *cpuMinerStats) startWork(parent *chainhash.Hash, now time.Time) {
+	s.mtx.Lock()
+	if s.parentStart.IsZero() || s.parent != *parent {
+		s.parent = *parent
+		s.parentStart = now
+	}
+	s.mtx.Unlock()
+}
+
+// solved records that a micro or key block building on the passed parent was
+// solved and accepted.
+//
+// This function is safe for concurrent access.
+func (s *cpuMinerStats) solved(parent *chainhash.Hash, keyBlock bool, now time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// Ignore solutions for blocks that were not started as tracked, such
+	// as when another worker already solved a block on the same parent.
+	if s.parentStart.IsZero() || s.parent != *parent {
+		return
+	}
+
+	latency := now.Sub(s.parentStart)
+	if keyBlock {
+		s.key.add(latency)
+	} else {
+		s.micro.add(latency)
+	}
+	s.parentStart = time.Time{}
+}
+
+// setTuning records the number of active workers and the most recently
+// measured CPU usage of the process.
+//
+// This function is safe for concurrent access.
+func (s *cpuMinerStats) setTuning(activeWorkers uint32, cpuUsage float64) {
+	s.mtx.Lock()
+	s.activeWorkers = activeWorkers
+	s.cpuUsage = cpuUsage
+	s.mtx.Unlock()
+}
+
+// result returns the statistics as a JSON-RPC result.
+//
+// This function is safe for concurrent access.
+func (s *cpuMinerStats) result(cpuBudget float64) *hcashjson.CPUMinerStatsResult {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return &hcashjson.CPUMinerStatsResult{
+		ActiveWorkers: int32(s.activeWorkers),
+		CPUBudget:     cpuBudget,
+		CPUUsage:      s.cpuUsage,
+		MicroBlocks:   s.micro.result(),
+		KeyBlocks:     s.key.result(),
+	}
+}
+
+// cpuTuner tunes the number of active CPU mining workers so the CPU usage of
+// the process stays within a budget, which is a percentage of the total CPU
+// capacity of the system.  A worker is removed when the usage exceeds the
+// budget and a worker is added when the usage with one more worker is
+// expected to remain within the budget.
+type cpuTuner struct {
+	budget   float64
+	numCPU   int
+	lastCPU  time.Duration
+	lastWall time.Time
+}
+
+// newCPUTuner returns a new tuner for the passed budget percentage and number
+// of CPUs which measures the CPU usage starting from the passed process CPU
+// time and wall clock time.
+func newCPUTuner(budget float64, numCPU int, cpuTime time.Duration, now time.Time) *cpuTuner {
+	return &cpuTuner{
+		budget:   budget,
+		numCPU:   numCPU,
+		lastCPU:  cpuTime,
+		lastWall: now,
+	}
+}
+
+// initialWorkers returns the number of workers to start with, which is the
+// share of the budget of the CPUs limited to the passed maximum.
+func (t *cpuTuner) initialWorkers(maxWorkers uint32) uint32 {
+	workers := uint32(t.budget / 100 * float64(t.numCPU))
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > maxWorkers {
+		workers = maxWorkers
+	}
+	return workers
+}
+
+// tune measures the CPU usage of the process since the last measurement from
+// the passed process CPU time and wall clock time and returns the number of
+// workers to run along with the measured usage as a percentage of the total
+// CPU capacity.
+func (t *cpuTuner) tune(active, maxWorkers uint32, cpuTime time.Duration, now time.Time) (uint32, float64) {
+	wall := now.Sub(t.lastWall)
+	used := cpuTime - t.lastCPU
+	t.lastCPU, t.lastWall = cpuTime, now
+	if wall <= 0 || t.numCPU <= 0 {
+		return active, 0
+	}
+
+	usage := 100 * float64(used) / (float64(wall) * float64(t.numCPU))
+	switch {
+	case usage > t.budget && active > 1:
+		active--
+
+	case active > 0 && active < maxWorkers &&
+		usage+usage/float64(active) <= t.budget:
+		active++
+	}
+	if active > maxWorkers {
+		active = maxWorkers
+	}
+	return active, usage
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// TestCPUMinerStats ensures the block discovery latencies are measured from
+// the first work on a parent and added to the histogram of the block type.
+func TestCPUMinerStats(t *testing.T) {
+	s := newCPUMinerStats()
+	now := time.Unix(1500000000, 0)
+	parent := chainhash.Hash{0x01}
+
+	// A later worker starting on the same parent does not restart the
+	// measurement.
+	s.startWork(&parent, now)
+	s.startWork(&parent, now.Add(20*time.Second))
+	s.solved(&parent, false, now.Add(40*time.Second))
+
+	// A second solution on the same parent is not measured.
+	s.solved(&parent, false, now.Add(50*time.Second))
+
+	next := chainhash.Hash{0x02}
+	s.startWork(&next, now.Add(40*time.Second))
+	s.solved(&next, true, now.Add(40*time.Second+500*time.Millisecond))
+	s.startWork(&parent, now.Add(60*time.Second))
+	s.solved(&parent, false, now.Add(61*time.Hour))
+
+	result := s.result(50)
+	if result.CPUBudget != 50 {
+		t.Fatalf("got budget %v, want 50", result.CPUBudget)
+	}
+	micro := result.MicroBlocks
+	if micro.Count != 2 || micro.Min != 40 || micro.Max != 61*3600-60 {
+		t.Fatalf("unexpected micro block latencies %+v", micro)
+	}
+	if micro.Mean != (40+61*3600-60)/2.0 {
+		t.Fatalf("got micro block mean %v", micro.Mean)
+	}
+	if len(micro.Buckets) != len(miningLatencyBounds)+1 {
+		t.Fatalf("got %d buckets, want %d", len(micro.Buckets),
+			len(miningLatencyBounds)+1)
+	}
+	last := micro.Buckets[len(micro.Buckets)-1]
+	if micro.Buckets[4].UpperBound != 60 || micro.Buckets[4].Count != 1 ||
+		last.UpperBound != -1 || last.Count != 1 {
+
+		t.Fatalf("unexpected micro block buckets %+v", micro.Buckets)
+	}
+	key := result.KeyBlocks
+	if key.Count != 1 || key.Buckets[0].Count != 1 || key.Mean != 0.5 {
+		t.Fatalf("unexpected key block latencies %+v", key)
+	}
+}
+
+// TestCPUTuner ensures the number of active workers is tuned against the CPU
+// budget.
+func TestCPUTuner(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	tuner := newCPUTuner(50, 4, 0, now)
+	if workers := tuner.initialWorkers(8); workers != 2 {
+		t.Fatalf("initialWorkers: got %d, want 2", workers)
+	}
+	if workers := tuner.initialWorkers(1); workers != 1 {
+		t.Fatalf("initialWorkers: got %d, want 1", workers)
+	}
+
+	tests := []struct {
+		active  uint32        // number of active workers
+		cpuUsed time.Duration // CPU time used during the interval
+		want    uint32        // expected number of active workers
+		usage   float64       // expected CPU usage percentage
+	}{
+		// One worker using one of the four CPUs leaves room for one
+		// more worker.
+		{1, 10 * time.Second, 2, 25},
+		// Two workers at the budget are kept.
+		{2, 20 * time.Second, 2, 50},
+		// Three workers exceed the budget.
+		{3, 30 * time.Second, 2, 75},
+		// The maximum number of workers is never exceeded.
+		{4, 0, 4, 0},
+		// At least one worker is kept.
+		{1, 40 * time.Second, 1, 100},
+	}
+
+	var cpuTime time.Duration
+	for i, test := range tests {
+		now = now.Add(10 * time.Second)
+		cpuTime += test.cpuUsed
+		active, usage := tuner.tune(test.active, 4, cpuTime, now)
+		if active != test.want || usage != test.usage {
+			t.Errorf("tune #%d: got %d workers at %v%%, want %d "+
+				"workers at %v%%", i, active, usage, test.want,
+				test.usage)
+		}
+	}
+}
+
+// TestCPUMinerWorkerStats ensures the hashing speed and submitted blocks are
+// tracked per worker and stopped workers are no longer reported.
+func TestCPUMinerWorkerStats(t *testing.T) {
+	s := newCPUMinerStats()
+	now := time.Unix(1500000000, 0)
+	first := s.addWorker(now)
+	second := s.addWorker(now)
+	if first == second {
+		t.Fatalf("workers share id %d", first)
+	}
+
+	// The speed is only updated once per update interval.
+	s.addHashes(first, 5000, now.Add(time.Second))
+	s.addHashes(first, 5000, now.Add(hpsUpdateSecs*time.Second))
+	s.addHashes(second, 300, now.Add(time.Second))
+	s.submitted(first, true)
+	s.submitted(second, false)
+	s.submitted(second, true)
+
+	workers, accepted, rejected := s.workerResults(now.Add(20 * time.Second))
+	if accepted != 2 || rejected != 1 {
+		t.Fatalf("got %d accepted and %d rejected, want 2 and 1",
+			accepted, rejected)
+	}
+	if len(workers) != 2 || workers[0].ID != first || workers[1].ID != second {
+		t.Fatalf("unexpected workers %+v", workers)
+	}
+	w := workers[0]
+	if w.Uptime != 20 || w.Hashes != 10000 || w.HashesPerSec != 1000 ||
+		w.Accepted != 1 || w.Rejected != 0 {
+
+		t.Fatalf("unexpected first worker %+v", w)
+	}
+	w = workers[1]
+	if w.Hashes != 300 || w.HashesPerSec != 0 || w.Accepted != 1 ||
+		w.Rejected != 1 {
+
+		t.Fatalf("unexpected second worker %+v", w)
+	}
+
+	// Stopped workers are no longer reported, but their blocks still
+	// count towards the totals.
+	s.removeWorker(first)
+	s.submitted(first, true)
+	workers, accepted, _ = s.workerResults(now)
+	if len(workers) != 1 || workers[0].ID != second || accepted != 3 {
+		t.Fatalf("unexpected workers %+v after stop (%d accepted)",
+			workers, accepted)
+	}
+}
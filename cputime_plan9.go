@@ -0,0 +1,16 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// processCPUTime returns an error on Plan 9 due to the lack of process
+// accounting.
+func processCPUTime() (time.Duration, error) {
+	return 0, errors.New("process CPU time is not available on plan9")
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"syscall"
+	"time"
+)
+
+// filetimeDuration converts the passed FILETIME, which counts 100-nanosecond
+// intervals, to a duration.
+func filetimeDuration(ft *syscall.Filetime) time.Duration {
+	return time.Duration(int64(ft.HighDateTime)<<32|int64(ft.LowDateTime)) * 100
+}
+
+// processCPUTime returns the total user and kernel CPU time used by the
+// process.
+func processCPUTime() (time.Duration, error) {
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0, err
+	}
+	var creation, exit, kernel, user syscall.Filetime
+	err = syscall.GetProcessTimes(process, &creation, &exit, &kernel, &user)
+	if err != nil {
+		return 0, err
+	}
+	return filetimeDuration(&kernel) + filetimeDuration(&user), nil
+}
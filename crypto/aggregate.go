@@ -0,0 +1,203 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package crypto
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/hcashec/edwards"
+	"github.com/HcashOrg/hcashd/hcashec/secp256k1"
+	"github.com/HcashOrg/hcashd/hcashec/secp256k1/schnorr"
+)
+
+// ErrNoAggregation describes an error where the signature suite registered
+// for the requested signature type does not support verifying aggregates of
+// signatures.
+var ErrNoAggregation = errors.New("signature suite does not support " +
+	"aggregation")
+
+// Aggregate collects signatures of different messages by different public keys
+// of a single signature suite in order to verify all of them in one operation.
+//
+// Aggregates are used to batch verify the signatures of blocks, and as an
+// experimental research hook for compressing the vote signatures of key
+// blocks.  Implementations are safe for concurrent access.
+type Aggregate interface {
+	// Add adds the signature of hash by pub to the aggregate.  An error is
+	// returned when the signature or public key can not be aggregated,
+	// in which case the signature must be verified individually.
+	Add(pub PublicKey, hash []byte, sig Signature) error
+
+	// Len returns the number of signatures added to the aggregate.
+	Len() int
+
+	// Verify returns whether all of the signatures added to the aggregate
+	// are valid.  An empty aggregate is valid.
+	Verify() bool
+}
+
+// AggregateDSA is implemented by the signature suites which support verifying
+// aggregates of signatures.
+type AggregateDSA interface {
+	DSA
+
+	// NewAggregate returns a new empty aggregate of signatures of the
+	// suite.
+	NewAggregate() Aggregate
+}
+
+// NewAggregate returns a new empty aggregate for the built-in elliptic curve
+// suite of sigType.  Aggregates are verified in place of the signatures of
+// blocks, so registered suites are not consulted.  An error with ErrUnknownDSA
+// is returned if sigType is not an elliptic curve type, and ErrNoAggregation if
+// the suite does not support aggregation.
+func NewAggregate(sigType int) (Aggregate, error) {
+	dsa, err := ECDSAByType(sigType)
+	if err != nil {
+		return nil, err
+	}
+	aggDSA, ok := dsa.(AggregateDSA)
+	if !ok {
+		return nil, ErrNoAggregation
+	}
+	return aggDSA.NewAggregate(), nil
+}
+
+// schnorrDSA adds support for aggregates to the secp256k1 Schnorr suite.
+type schnorrDSA struct {
+	ecDSA
+}
+
+// NewAggregate returns a new empty aggregate of secp256k1 Schnorr signatures.
+func (schnorrDSA) NewAggregate() Aggregate {
+	return &schnorrAggregate{curve: secp256k1.S256()}
+}
+
+// schnorrAggregate is an aggregate of secp256k1 Schnorr signatures which are
+// verified with schnorr.BatchVerify.  Its result only differs from verifying
+// the signatures one by one with negligible probability.
+type schnorrAggregate struct {
+	curve *secp256k1.KoblitzCurve
+
+	mtx    sync.Mutex
+	pubs   []*secp256k1.PublicKey
+	hashes [][]byte
+	sigs   []*schnorr.Signature
+}
+
+// Add adds the signature of hash by pub to the aggregate.
+//
+// This is part of the Aggregate interface implementation.
+func (a *schnorrAggregate) Add(pub PublicKey, hash []byte, sig Signature) error {
+	// The public keys of the suite are plain secp256k1 public keys, so only
+	// the signature reveals the suite.
+	if sig.GetType() != chainec.ECTypeSecSchnorr {
+		return errors.New("not a secp256k1 Schnorr signature")
+	}
+	if len(hash) != chainhash.HashSize {
+		return errors.New("invalid message size")
+	}
+	if !a.curve.IsOnCurve(pub.GetX(), pub.GetY()) {
+		return errors.New("public key is not on the curve")
+	}
+
+	a.mtx.Lock()
+	a.pubs = append(a.pubs, secp256k1.NewPublicKey(a.curve, pub.GetX(),
+		pub.GetY()))
+	a.hashes = append(a.hashes, hash)
+	a.sigs = append(a.sigs, schnorr.NewSignature(sig.GetR(), sig.GetS()))
+	a.mtx.Unlock()
+	return nil
+}
+
+// Len returns the number of signatures added to the aggregate.
+//
+// This is part of the Aggregate interface implementation.
+func (a *schnorrAggregate) Len() int {
+	a.mtx.Lock()
+	n := len(a.sigs)
+	a.mtx.Unlock()
+	return n
+}
+
+// Verify returns whether all of the signatures added to the aggregate are valid.
+//
+// This is part of the Aggregate interface implementation.
+func (a *schnorrAggregate) Verify() bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	return schnorr.BatchVerify(a.curve, a.pubs, a.hashes, a.sigs)
+}
+
+// edwardsDSA adds support for aggregates to the Ed25519 suite.
+type edwardsDSA struct {
+	ecDSA
+}
+
+// NewAggregate returns a new empty aggregate of Ed25519 signatures.
+func (edwardsDSA) NewAggregate() Aggregate {
+	return &edwardsAggregate{curve: edwards.Edwards()}
+}
+
+// edwardsAggregate is an aggregate of Ed25519 signatures which are verified
+// with edwards.BatchVerify.
+//
+// NOTE: Unlike the individual verification, the batch verification accepts
+// signatures with components of small order, so the aggregates must only be
+// used where that is part of the consensus rules.
+type edwardsAggregate struct {
+	curve *edwards.TwistedEdwardsCurve
+
+	mtx    sync.Mutex
+	pubs   []*edwards.PublicKey
+	hashes [][]byte
+	sigs   []*edwards.Signature
+}
+
+// Add adds the signature of hash by pub to the aggregate.
+//
+// This is part of the Aggregate interface implementation.
+func (a *edwardsAggregate) Add(pub PublicKey, hash []byte, sig Signature) error {
+	if sig.GetType() != chainec.ECTypeEdwards ||
+		pub.GetType() != chainec.ECTypeEdwards {
+
+		return errors.New("not an Ed25519 signature")
+	}
+	if hash == nil {
+		return errors.New("nil message")
+	}
+
+	a.mtx.Lock()
+	a.pubs = append(a.pubs, edwards.NewPublicKey(a.curve, pub.GetX(),
+		pub.GetY()))
+	a.hashes = append(a.hashes, hash)
+	a.sigs = append(a.sigs, edwards.NewSignature(sig.GetR(), sig.GetS()))
+	a.mtx.Unlock()
+	return nil
+}
+
+// Len returns the number of signatures added to the aggregate.
+//
+// This is part of the Aggregate interface implementation.
+func (a *edwardsAggregate) Len() int {
+	a.mtx.Lock()
+	n := len(a.sigs)
+	a.mtx.Unlock()
+	return n
+}
+
+// Verify returns whether all of the signatures added to the aggregate are valid.
+//
+// This is part of the Aggregate interface implementation.
+func (a *edwardsAggregate) Verify() bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	return edwards.BatchVerify(a.pubs, a.hashes, a.sigs)
+}
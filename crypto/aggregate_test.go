@@ -0,0 +1,109 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package crypto
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// TestAggregate ensures aggregates of secp256k1 Schnorr and Ed25519 signatures
+// are only valid when all of their signatures are valid.
+func TestAggregate(t *testing.T) {
+	testAggregate(t, chainec.ECTypeSecSchnorr, chainec.SecSchnorr)
+	testAggregate(t, chainec.ECTypeEdwards, chainec.Edwards)
+}
+
+// testAggregate runs the aggregate tests for the signature suite registered for
+// the passed signature type.
+func testAggregate(t *testing.T, sigType int, ecDSA chainec.DSA) {
+	dsa, err := DSAByType(sigType)
+	if err != nil {
+		t.Fatalf("DSAByType: %v", err)
+	}
+	const numSigs = 5
+	pubs := make([]PublicKey, numSigs)
+	hashes := make([][]byte, numSigs)
+	sigs := make([]Signature, numSigs)
+	for i := range sigs {
+		priv, pub, err := dsa.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey: %v", err)
+		}
+		pubs[i] = pub
+		hashes[i] = chainhash.HashB([]byte{byte(i)})
+		sigs[i], err = dsa.Sign(priv, hashes[i])
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+	}
+
+	// newAggregate returns an aggregate of the signatures with the
+	// signature at the passed index replaced by the passed one.
+	newAggregate := func(idx int, sig Signature) Aggregate {
+		agg, err := NewAggregate(sigType)
+		if err != nil {
+			t.Fatalf("NewAggregate: %v", err)
+		}
+		for i := range sigs {
+			s := sigs[i]
+			if i == idx {
+				s = sig
+			}
+			if err := agg.Add(pubs[i], hashes[i], s); err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+		}
+		return agg
+	}
+
+	agg := newAggregate(-1, nil)
+	if agg.Len() != numSigs {
+		t.Fatalf("Len: got %d, want %d", agg.Len(), numSigs)
+	}
+	if !agg.Verify() {
+		t.Fatal("Verify: valid aggregate rejected")
+	}
+	for i := range sigs {
+		// Use the signature of another message.
+		agg := newAggregate(i, sigs[(i+1)%numSigs])
+		if agg.Verify() {
+			t.Fatalf("Verify: aggregate of type %d with invalid "+
+				"signature %d accepted", sigType, i)
+		}
+
+		// Tweak the s value of the signature.
+		s := new(big.Int).Add(sigs[i].GetS(), big.NewInt(1))
+		agg = newAggregate(i, ecDSA.NewSignature(sigs[i].GetR(), s))
+		if agg.Verify() {
+			t.Fatalf("Verify: aggregate of type %d with tweaked "+
+				"signature %d accepted", sigType, i)
+		}
+	}
+
+	empty, err := NewAggregate(sigType)
+	if err != nil {
+		t.Fatalf("NewAggregate: %v", err)
+	}
+	if !empty.Verify() {
+		t.Fatal("Verify: empty aggregate rejected")
+	}
+}
+
+// TestNewAggregateUnsupported ensures aggregates are only created for the
+// signature suites which support them.
+func TestNewAggregateUnsupported(t *testing.T) {
+	_, err := NewAggregate(chainec.ECTypeSecp256k1)
+	if err != ErrNoAggregation {
+		t.Fatalf("NewAggregate: got %v, want %v", err, ErrNoAggregation)
+	}
+	if _, err := NewAggregate(200); err != ErrUnknownDSA {
+		t.Fatalf("NewAggregate: got %v, want %v", err, ErrUnknownDSA)
+	}
+}
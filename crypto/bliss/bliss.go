@@ -6,7 +6,6 @@ import (
 	"github.com/LoCCS/bliss/sampler"
 	"github.com/LoCCS/bliss"
 	hcashcrypto "github.com/HcashOrg/hcashd/crypto"
-	"crypto/rand"
 )
 
 var pqcTypeBliss = 4
@@ -263,8 +262,10 @@ func newBlissDSA() DSA {
 		},
 
 		generateKey: func(rand io.Reader) (hcashcrypto.PrivateKey, hcashcrypto.PublicKey, error) {
-			seed := make([]byte, sampler.SHA_512_DIGEST_LENGTH)
-			rand.Read(seed)
+			seed, err := randomSeed(rand)
+			if err != nil {
+				return nil, nil, err
+			}
 			entropy, err := sampler.NewEntropy(seed)
 			if err != nil{
 				return nil, nil, err
@@ -283,28 +284,15 @@ func newBlissDSA() DSA {
 		},
 
 		sign: func(priv hcashcrypto.PrivateKey, hash []byte) (hcashcrypto.Signature, error) {
-			seed := make([]byte, sampler.SHA_512_DIGEST_LENGTH)
-			rand.Read(seed)
-			entropy, err := sampler.NewEntropy(seed)
-			if err != nil{
+			seed, err := signingSeed(priv, hash)
+			if err != nil {
 				return nil, err
 			}
-			var sig *bliss.Signature
-			switch priv.(type){
-			case PrivateKey:
-				pv := priv.(PrivateKey)
-				sig, err = pv.Sign(hash, entropy)
-			case *PrivateKey:
-				pvptr := priv.(*PrivateKey)
-				sig, err = pvptr.Sign(hash, entropy)
-			}
-
-			if err != nil{
+			sig, err := SignWithEntropy(priv, hash, seed)
+			if err != nil {
 				return nil, err
 			}
-			return &Signature{
-				Signature: *sig,
-			}, nil
+			return sig, nil
 		},
 
 		verify: func(pub hcashcrypto.PublicKey, hash []byte, sig hcashcrypto.Signature) bool {
@@ -316,4 +304,4 @@ func newBlissDSA() DSA {
 	}
 
 	return bliss.(DSA)
-}
\ No newline at end of file
+}
@@ -80,4 +80,8 @@ const (
 )
 
 // Secp256k1 is the secp256k1 curve and ECDSA system used in Bitcoin.
-var Bliss = newBlissDSA()
\ No newline at end of file
+var Bliss = newBlissDSA()
+
+func init() {
+	hcashcrypto.RegisterDSA(BSTypeBliss, Bliss)
+}
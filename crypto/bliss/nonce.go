@@ -0,0 +1,166 @@
+package bliss
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	hcashcrypto "github.com/HcashOrg/hcashd/crypto"
+	"github.com/LoCCS/bliss"
+	"github.com/LoCCS/bliss/sampler"
+)
+
+var (
+	// ErrInvalidPrivateKey is returned when a signing function is handed a
+	// private key that is not a bliss private key.
+	ErrInvalidPrivateKey = errors.New("bliss: private key is not a bliss key")
+
+	// ErrInvalidSeedLen is returned when the seed passed to SignWithEntropy
+	// does not match the sampler seed length.
+	ErrInvalidSeedLen = errors.New("bliss: invalid entropy seed length")
+)
+
+// SignAuditHook is invoked after every signing operation with the length of
+// the signed message and the time spent producing the signature.  It is
+// intended for test harnesses that check signing time does not depend on
+// the secret key or the message.
+type SignAuditHook func(hashLen int, elapsed time.Duration)
+
+var (
+	signModeMtx   sync.RWMutex
+	deterministic bool
+	auditHook     SignAuditHook
+)
+
+// SetDeterministic toggles deterministic signing for Bliss.Sign and
+// SignCompact.  When enabled, the sampler seed is derived from the private
+// key and message hash (see DeterministicSeed) instead of crypto/rand, so the
+// same key and message always produce the same signature.
+func SetDeterministic(enable bool) {
+	signModeMtx.Lock()
+	deterministic = enable
+	signModeMtx.Unlock()
+}
+
+// IsDeterministic returns whether deterministic signing is enabled.
+func IsDeterministic() bool {
+	signModeMtx.RLock()
+	defer signModeMtx.RUnlock()
+	return deterministic
+}
+
+// SetSignAuditHook installs a hook that is called after every signature.
+// Passing nil removes the hook.
+func SetSignAuditHook(hook SignAuditHook) {
+	signModeMtx.Lock()
+	auditHook = hook
+	signModeMtx.Unlock()
+}
+
+// DeterministicSeed derives a sampler seed from the serialized private key
+// and the message hash using the HMAC-SHA512 construction from RFC6979
+// section 3.2.  The returned seed is suitable for SignWithEntropy.
+func DeterministicSeed(key hcashcrypto.PrivateKey, hash []byte) []byte {
+	x := key.Serialize()
+	size := sha512.Size
+
+	v := make([]byte, size)
+	k := make([]byte, size)
+	for i := range v {
+		v[i] = 0x01
+	}
+
+	// Step d, e.
+	k = mac(k, v, []byte{0x00}, x, hash)
+	v = mac(k, v)
+
+	// Step f, g.
+	k = mac(k, v, []byte{0x01}, x, hash)
+	v = mac(k, v)
+
+	// Step h.  A single block is enough since the sampler seed length is
+	// the SHA512 digest length.
+	seed := make([]byte, 0, sampler.SHA_512_DIGEST_LENGTH)
+	for len(seed) < int(sampler.SHA_512_DIGEST_LENGTH) {
+		v = mac(k, v)
+		seed = append(seed, v...)
+	}
+	return seed[:sampler.SHA_512_DIGEST_LENGTH]
+}
+
+// mac returns HMAC-SHA512(k, m...).
+func mac(k []byte, m ...[]byte) []byte {
+	h := hmac.New(sha512.New, k)
+	for _, b := range m {
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+// SignWithEntropy produces a Bliss signature of hash using the caller
+// supplied sampler seed.  The seed must be sampler.SHA_512_DIGEST_LENGTH bytes
+// long.  Signing the same hash with the same key and seed always yields the
+// same signature.
+func SignWithEntropy(priv hcashcrypto.PrivateKey, hash, seed []byte) (*Signature, error) {
+	if len(seed) != int(sampler.SHA_512_DIGEST_LENGTH) {
+		return nil, ErrInvalidSeedLen
+	}
+	entropy, err := sampler.NewEntropy(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	signModeMtx.RLock()
+	hook := auditHook
+	signModeMtx.RUnlock()
+
+	start := time.Now()
+	var sig *bliss.Signature
+	switch pv := priv.(type) {
+	case PrivateKey:
+		sig, err = pv.Sign(hash, entropy)
+	case *PrivateKey:
+		sig, err = pv.Sign(hash, entropy)
+	default:
+		return nil, ErrInvalidPrivateKey
+	}
+	if hook != nil {
+		hook(len(hash), time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signature{
+		Signature: *sig,
+	}, nil
+}
+
+// SignDeterministic produces a Bliss signature of hash using a seed derived
+// from the private key and the hash, regardless of the package signing mode.
+func SignDeterministic(priv hcashcrypto.PrivateKey, hash []byte) (*Signature, error) {
+	return SignWithEntropy(priv, hash, DeterministicSeed(priv, hash))
+}
+
+// signingSeed returns the sampler seed to use for signing hash with priv
+// under the current signing mode.  Errors from the system random number
+// generator are returned rather than silently signing with a zero seed.
+func signingSeed(priv hcashcrypto.PrivateKey, hash []byte) ([]byte, error) {
+	if IsDeterministic() {
+		return DeterministicSeed(priv, hash), nil
+	}
+	return randomSeed(rand.Reader)
+}
+
+// randomSeed reads a full sampler seed from r.
+func randomSeed(r io.Reader) ([]byte, error) {
+	seed := make([]byte, sampler.SHA_512_DIGEST_LENGTH)
+	if _, err := io.ReadFull(r, seed); err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
@@ -3,8 +3,6 @@ package bliss
 import (
 	"github.com/LoCCS/bliss"
 	hcashcrypto "github.com/HcashOrg/hcashd/crypto"
-	"github.com/LoCCS/bliss/sampler"
-	"crypto/rand"
 )
 
 type Signature struct{
@@ -20,28 +18,20 @@ func (s Signature) Serialize() []byte{
 	return s.Signature.Serialize()
 }
 
+// SignCompact signs hash with key and returns the serialized signature.  The
+// seed is taken from crypto/rand, or derived from the key and hash when
+// deterministic signing is enabled.
 func SignCompact(key hcashcrypto.PrivateKey, hash []byte)([]byte, error) {
-
-	seed := make([]byte, sampler.SHA_512_DIGEST_LENGTH)
-	rand.Read(seed)
-	entropy, err := sampler.NewEntropy(seed)
-	if err != nil{
+	seed, err := signingSeed(key, hash)
+	if err != nil {
 		return nil, err
 	}
-	var sig *bliss.Signature
-	switch pv := key.(type){
-	case PrivateKey:
-		sig, err = pv.Sign(hash, entropy)
-	case *PrivateKey:
-		sig, err = pv.Sign(hash, entropy)
-	}
-
-	if err != nil{
+	sig, err := SignWithEntropy(key, hash, seed)
+	if err != nil {
 		return nil, err
 	}
 
-	result := sig.Serialize()
-	return result, err
+	return sig.Serialize(), nil
 }
 
 func VerifyCompact(key hcashcrypto.PublicKey, messageHash, sign []byte) (bool, error){
@@ -50,4 +40,4 @@ func VerifyCompact(key hcashcrypto.PublicKey, messageHash, sign []byte) (bool, e
 	result, err := key.(*PublicKey).Verify(messageHash, sig)
 
 	return result, err
-}
\ No newline at end of file
+}
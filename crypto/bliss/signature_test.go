@@ -8,6 +8,8 @@ import (
 	"bytes"
 	"golang.org/x/crypto/sha3"
 	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	"io"
+	"time"
 )
 
 func TestSignature(t *testing.T) {
@@ -45,3 +47,96 @@ func TestSignature(t *testing.T) {
 	}
 
 }
+
+func TestSignDeterministic(t *testing.T) {
+	sk, pk, err := Bliss.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("Error in Generate keys")
+	}
+
+	hash := sha3.Sum512([]byte("deterministic bliss"))
+
+	sig1, err := SignDeterministic(sk, hash[:])
+	if err != nil {
+		t.Fatalf("SignDeterministic: %v", err)
+	}
+	sig2, err := SignDeterministic(sk, hash[:])
+	if err != nil {
+		t.Fatalf("SignDeterministic: %v", err)
+	}
+	if !bytes.Equal(sig1.Serialize(), sig2.Serialize()) {
+		t.Fatal("deterministic signatures do not match")
+	}
+	if !Bliss.Verify(pk, hash[:], sig1) {
+		t.Fatal("deterministic signature failed to verify")
+	}
+
+	// Enabling deterministic mode must make Sign and SignCompact produce
+	// the same signature as SignDeterministic.
+	SetDeterministic(true)
+	defer SetDeterministic(false)
+	sig3, err := Bliss.Sign(sk, hash[:])
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if !bytes.Equal(sig1.Serialize(), sig3.Serialize()) {
+		t.Fatal("Sign in deterministic mode does not match SignDeterministic")
+	}
+	compact, err := SignCompact(sk, hash[:])
+	if err != nil {
+		t.Fatalf("SignCompact: %v", err)
+	}
+	if !bytes.Equal(sig1.Serialize(), compact) {
+		t.Fatal("SignCompact in deterministic mode does not match SignDeterministic")
+	}
+}
+
+func TestSignWithEntropy(t *testing.T) {
+	sk, _, err := Bliss.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("Error in Generate keys")
+	}
+	hash := sha3.Sum512([]byte("bliss entropy"))
+
+	if _, err := SignWithEntropy(sk, hash[:], make([]byte, 1)); err != ErrInvalidSeedLen {
+		t.Fatalf("short seed: want ErrInvalidSeedLen, got %v", err)
+	}
+
+	var calls int
+	SetSignAuditHook(func(hashLen int, elapsed time.Duration) {
+		calls++
+		if hashLen != len(hash) {
+			t.Errorf("audit hook hash length: got %d, want %d",
+				hashLen, len(hash))
+		}
+	})
+	defer SetSignAuditHook(nil)
+
+	seed := DeterministicSeed(sk, []byte("seed"))
+	sig1, err := SignWithEntropy(sk, hash[:], seed)
+	if err != nil {
+		t.Fatalf("SignWithEntropy: %v", err)
+	}
+	sig2, err := SignWithEntropy(sk, hash[:], seed)
+	if err != nil {
+		t.Fatalf("SignWithEntropy: %v", err)
+	}
+	if !bytes.Equal(sig1.Serialize(), sig2.Serialize()) {
+		t.Fatal("signatures with the same entropy do not match")
+	}
+	if calls != 2 {
+		t.Fatalf("audit hook called %d times, want 2", calls)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestGenerateKeyEntropyError(t *testing.T) {
+	if _, _, err := Bliss.GenerateKey(failingReader{}); err == nil {
+		t.Fatal("GenerateKey did not return the entropy error")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package crypto
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"sync"
+)
+
+// DSA is the set of operations every signature suite registered with
+// RegisterDSA provides, regardless of whether it is an elliptic curve or a
+// post-quantum scheme.
+type DSA interface {
+	// PrivKeyFromBytes calculates the public key from serialized bytes,
+	// and returns both it and the private key.
+	PrivKeyFromBytes(pk []byte) (PrivateKey, PublicKey)
+
+	// PrivKeyBytesLen returns the length of a serialized private key.
+	PrivKeyBytesLen() int
+
+	// ParsePubKey parses a serialized public key and returns it.
+	ParsePubKey(pubKeyStr []byte) (PublicKey, error)
+
+	// PubKeyBytesLen returns the length of the default serialization
+	// method for a public key.
+	PubKeyBytesLen() int
+
+	// ParseDERSignature parses a DER encoded signature.  If the suite
+	// doesn't support DER signatures, it parses with the default method.
+	ParseDERSignature(sigStr []byte) (Signature, error)
+
+	// ParseSignature parses a default encoded signature.
+	ParseSignature(sigStr []byte) (Signature, error)
+
+	// RecoverCompact recovers a public key from an encoded signature
+	// and message, then verifies the signature against the public
+	// key.
+	RecoverCompact(signature, hash []byte) (PublicKey, bool, error)
+
+	// GenerateKey generates a new private and public keypair from the
+	// given reader.
+	GenerateKey(rand io.Reader) (PrivateKey, PublicKey, error)
+
+	// Sign produces a signature of hash using the private key.
+	Sign(priv PrivateKey, hash []byte) (Signature, error)
+
+	// Verify verifies a signature against a given message and public key.
+	Verify(pub PublicKey, hash []byte, sig Signature) bool
+}
+
+var (
+	// ErrDuplicateDSA describes an error where a signature suite is
+	// registered for a signature type that is already taken.
+	ErrDuplicateDSA = errors.New("duplicate signature suite")
+
+	// ErrUnknownDSA describes an error where no signature suite is
+	// registered for the requested signature type.
+	ErrUnknownDSA = errors.New("unknown signature suite")
+)
+
+var (
+	dsaMtx         sync.RWMutex
+	registeredDSAs = make(map[int]DSA)
+)
+
+// RegisterDSA registers the signature suite dsa under the numeric signature
+// type sigType, which is the value stored in scripts and addresses.  An error
+// with ErrDuplicateDSA is returned if sigType is already registered.
+//
+// Signature suites in this module register themselves when their package is
+// imported.  Third party suites may be added by calling RegisterDSA from an
+// init function with an unused signature type.
+func RegisterDSA(sigType int, dsa DSA) error {
+	dsaMtx.Lock()
+	defer dsaMtx.Unlock()
+
+	if _, ok := registeredDSAs[sigType]; ok {
+		return ErrDuplicateDSA
+	}
+	registeredDSAs[sigType] = dsa
+	return nil
+}
+
+// DSAByType returns the signature suite registered for sigType, or an error
+// with ErrUnknownDSA if none is registered.
+func DSAByType(sigType int) (DSA, error) {
+	dsaMtx.RLock()
+	defer dsaMtx.RUnlock()
+
+	dsa, ok := registeredDSAs[sigType]
+	if !ok {
+		return nil, ErrUnknownDSA
+	}
+	return dsa, nil
+}
+
+// RegisteredDSATypes returns the signature types of all registered suites in
+// ascending order.
+func RegisteredDSATypes() []int {
+	dsaMtx.RLock()
+	types := make([]int, 0, len(registeredDSAs))
+	for sigType := range registeredDSAs {
+		types = append(types, sigType)
+	}
+	dsaMtx.RUnlock()
+
+	sort.Ints(types)
+	return types
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package crypto
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// TestRegisteredECDSA ensures the elliptic curve suites are registered and
+// can sign and verify through the registry.
+func TestRegisteredECDSA(t *testing.T) {
+	hash := chainhash.HashB([]byte("registry"))
+	for _, sigType := range []int{chainec.ECTypeSecp256k1,
+		chainec.ECTypeEdwards, chainec.ECTypeSecSchnorr} {
+
+		dsa, err := DSAByType(sigType)
+		if err != nil {
+			t.Fatalf("DSAByType(%d): %v", sigType, err)
+		}
+		priv, pub, err := dsa.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey(%d): %v", sigType, err)
+		}
+		if priv.GetType() != sigType {
+			t.Fatalf("private key type: got %d, want %d",
+				priv.GetType(), sigType)
+		}
+		sig, err := dsa.Sign(priv, hash)
+		if err != nil {
+			t.Fatalf("Sign(%d): %v", sigType, err)
+		}
+		if !dsa.Verify(pub, hash, sig) {
+			t.Fatalf("Verify(%d) failed", sigType)
+		}
+		if !dsa.Verify(priv.PublicKey(), hash, sig) {
+			t.Fatalf("Verify(%d) with derived public key failed",
+				sigType)
+		}
+	}
+}
+
+// TestECDSAByType ensures the built-in elliptic curve suites are returned for
+// their types regardless of the registry.
+func TestECDSAByType(t *testing.T) {
+	for _, sigType := range []int{chainec.ECTypeSecp256k1,
+		chainec.ECTypeEdwards, chainec.ECTypeSecSchnorr} {
+
+		dsa, err := ECDSAByType(sigType)
+		if err != nil {
+			t.Fatalf("ECDSAByType(%d): %v", sigType, err)
+		}
+		priv, _, err := dsa.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey(%d): %v", sigType, err)
+		}
+		if priv.GetType() != sigType {
+			t.Fatalf("private key type: got %d, want %d",
+				priv.GetType(), sigType)
+		}
+	}
+	if _, err := ECDSAByType(201); err != ErrUnknownDSA {
+		t.Fatalf("unknown type: got %v, want %v", err, ErrUnknownDSA)
+	}
+}
+
+// TestRegisterDSA ensures duplicate and unknown signature types are rejected.
+func TestRegisterDSA(t *testing.T) {
+	err := RegisterDSA(chainec.ECTypeSecp256k1, ecDSA{chainec.ECTypeSecp256k1, chainec.Secp256k1})
+	if err != ErrDuplicateDSA {
+		t.Fatalf("duplicate registration: got %v, want %v", err,
+			ErrDuplicateDSA)
+	}
+	if _, err := DSAByType(200); err != ErrUnknownDSA {
+		t.Fatalf("unknown type: got %v, want %v", err, ErrUnknownDSA)
+	}
+
+	const testType = 201
+	if err := RegisterDSA(testType, ecDSA{testType, chainec.Edwards}); err != nil {
+		t.Fatalf("RegisterDSA: %v", err)
+	}
+	defer func() {
+		dsaMtx.Lock()
+		delete(registeredDSAs, testType)
+		dsaMtx.Unlock()
+	}()
+	types := RegisteredDSATypes()
+	if types[len(types)-1] != testType {
+		t.Fatalf("RegisteredDSATypes: %v does not end with %d", types,
+			testType)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package crypto
+
+import (
+	"io"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+)
+
+// ecPrivateKey wraps a chainec private key so that it satisfies PrivateKey.
+// The secp256k1 Schnorr suite shares its private keys with the secp256k1 one,
+// so the type of the suite the key was created by is kept along with it.
+type ecPrivateKey struct {
+	chainec.PrivateKey
+	sigType int
+	dsa     chainec.DSA
+}
+
+// GetType returns the signature type of the suite the private key belongs to.
+func (p ecPrivateKey) GetType() int {
+	return p.sigType
+}
+
+// PublicKey returns the public key corresponding to the private key.
+func (p ecPrivateKey) PublicKey() PublicKey {
+	return p.dsa.NewPublicKey(p.Public())
+}
+
+// ecDSA adapts a chainec.DSA to the DSA interface so the elliptic curve
+// suites can be looked up through the same registry as the post-quantum
+// ones.
+type ecDSA struct {
+	sigType int
+	dsa     chainec.DSA
+}
+
+// unwrap returns the underlying chainec private key of priv.
+func unwrap(priv PrivateKey) chainec.PrivateKey {
+	if p, ok := priv.(ecPrivateKey); ok {
+		return p.PrivateKey
+	}
+	return priv
+}
+
+func (e ecDSA) PrivKeyFromBytes(pk []byte) (PrivateKey, PublicKey) {
+	priv, pub := e.dsa.PrivKeyFromBytes(pk)
+	if priv == nil {
+		return nil, nil
+	}
+	return ecPrivateKey{priv, e.sigType, e.dsa}, pub
+}
+
+func (e ecDSA) PrivKeyBytesLen() int {
+	return e.dsa.PrivKeyBytesLen()
+}
+
+func (e ecDSA) ParsePubKey(pubKeyStr []byte) (PublicKey, error) {
+	return e.dsa.ParsePubKey(pubKeyStr)
+}
+
+func (e ecDSA) PubKeyBytesLen() int {
+	return e.dsa.PubKeyBytesLen()
+}
+
+func (e ecDSA) ParseDERSignature(sigStr []byte) (Signature, error) {
+	return e.dsa.ParseDERSignature(sigStr)
+}
+
+func (e ecDSA) ParseSignature(sigStr []byte) (Signature, error) {
+	return e.dsa.ParseSignature(sigStr)
+}
+
+func (e ecDSA) RecoverCompact(signature, hash []byte) (PublicKey, bool, error) {
+	return e.dsa.RecoverCompact(signature, hash)
+}
+
+func (e ecDSA) GenerateKey(rand io.Reader) (PrivateKey, PublicKey, error) {
+	privBytes, _, _, err := e.dsa.GenerateKey(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	priv, pub := e.PrivKeyFromBytes(privBytes)
+	return priv, pub, nil
+}
+
+func (e ecDSA) Sign(priv PrivateKey, hash []byte) (Signature, error) {
+	r, s, err := e.dsa.Sign(unwrap(priv), hash)
+	if err != nil {
+		return nil, err
+	}
+	return e.dsa.NewSignature(r, s), nil
+}
+
+func (e ecDSA) Verify(pub PublicKey, hash []byte, sig Signature) bool {
+	return e.dsa.Verify(pub, hash, sig.GetR(), sig.GetS())
+}
+
+// ECDSAByType returns the built-in elliptic curve signature suite for sigType,
+// or an error with ErrUnknownDSA if sigType is not an elliptic curve type.
+// Unlike DSAByType, the result can't be changed by registering suites, so it
+// is the one to use for consensus rules.
+func ECDSAByType(sigType int) (DSA, error) {
+	switch sigType {
+	case chainec.ECTypeSecp256k1:
+		return ecDSA{sigType, chainec.Secp256k1}, nil
+	case chainec.ECTypeEdwards:
+		return edwardsDSA{ecDSA{sigType, chainec.Edwards}}, nil
+	case chainec.ECTypeSecSchnorr:
+		return schnorrDSA{ecDSA{sigType, chainec.SecSchnorr}}, nil
+	}
+	return nil, ErrUnknownDSA
+}
+
+func init() {
+	for _, sigType := range []int{chainec.ECTypeSecp256k1,
+		chainec.ECTypeEdwards, chainec.ECTypeSecSchnorr} {
+
+		dsa, _ := ECDSAByType(sigType)
+		RegisterDSA(sigType, dsa)
+	}
+}
@@ -78,4 +78,8 @@ const (
 	LMSPrivKeyLen = 4691
 )
 
-var LMS = newLMSDSA()
\ No newline at end of file
+var LMS = newLMSDSA()
+
+func init() {
+	hcashcrypto.RegisterDSA(LMSTypeLMS, LMS)
+}
@@ -0,0 +1,292 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package lms
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	hcashcrypto "github.com/HcashOrg/hcashd/crypto"
+	"github.com/LoCCS/lms"
+)
+
+// LMS is a stateful signature scheme.  Every signature consumes one of the
+// one-time keys of the merkle tree of the private key, and signing two messages
+// with the same one-time key reveals enough of it to forge signatures.  The
+// state of a private key therefore has to be persisted after every signature,
+// before the signature is released, so that a crash or a restart can never
+// roll the key back to an already used one-time key.
+//
+// The serialized key state is:
+//
+//   <version><height><used><agent len><agent><secret len><secret>
+//
+//   Field        Type     Size
+//   version      uint8    1 byte
+//   height       uint8    1 byte
+//   used         uint32   4 bytes
+//   agent len    uint32   4 bytes
+//   agent        []byte   agent len
+//   secret len   uint32   4 bytes
+//   secret       []byte   secret len
+
+const (
+	// keyStateVersion is the current version of the serialized key state.
+	keyStateVersion = 1
+
+	// MaxTreeHeight is the maximum height of the merkle tree of a private
+	// key managed by a KeyState.
+	MaxTreeHeight = 25
+)
+
+var (
+	// ErrKeyExhausted is returned by KeyState.Sign when all of the one-time
+	// keys of the private key were used.
+	ErrKeyExhausted = errors.New("lms: all one-time keys of the private " +
+		"key are used")
+
+	// ErrKeyStateNotPersisted is returned by KeyState.Sign when the state
+	// of the private key could not be persisted after an earlier
+	// signature.  No further signatures are made, since the persisted
+	// state is behind the state in memory.
+	ErrKeyStateNotPersisted = errors.New("lms: the private key state " +
+		"could not be persisted")
+
+	// ErrInvalidKeyState is returned when a serialized key state is
+	// malformed.
+	ErrInvalidKeyState = errors.New("lms: invalid serialized key state")
+)
+
+// KeyStateStore is the interface for persisting the state of a private key.
+type KeyStateStore interface {
+	// StoreKeyState replaces the persisted state with the passed one.  It
+	// MUST be atomic and durable: once it returns nil, the new state is
+	// the one loaded even after a crash, and when it returns an error,
+	// either the old or the new state is loaded.
+	StoreKeyState(state []byte) error
+
+	// LoadKeyState returns the persisted state.
+	LoadKeyState() ([]byte, error)
+}
+
+// FileKeyStateStore is a KeyStateStore which persists the state in a file.
+type FileKeyStateStore struct {
+	path string
+}
+
+// NewFileKeyStateStore returns a store which persists the state in the file at
+// the passed path.
+func NewFileKeyStateStore(path string) *FileKeyStateStore {
+	return &FileKeyStateStore{path: path}
+}
+
+// StoreKeyState atomically replaces the state in the file by writing the new
+// state to a temporary file in the same directory, syncing it to disk, and
+// renaming it over the old file.
+//
+// This is part of the KeyStateStore interface.
+func (s *FileKeyStateStore) StoreKeyState(state []byte) error {
+	dir := filepath.Dir(s.path)
+	tmp, err := ioutil.TempFile(dir, filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(state); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, s.path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	// Sync the directory so the rename itself is durable.  Not all
+	// platforms support syncing directories, so failures are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// LoadKeyState returns the state in the file.
+//
+// This is part of the KeyStateStore interface.
+func (s *FileKeyStateStore) LoadKeyState() ([]byte, error) {
+	return ioutil.ReadFile(s.path)
+}
+
+// KeyState manages a private key along with the number of its one-time keys
+// which were used, and persists it to a store after every signature.
+//
+// It is safe for concurrent access.
+type KeyState struct {
+	mtx    sync.Mutex
+	key    *PrivateKey
+	height uint8
+	used   uint32
+	store  KeyStateStore
+	failed bool
+}
+
+// NewKeyState returns a key state for the passed merkle agent, whose tree has
+// the passed height and none of whose one-time keys were used, and persists it
+// to the passed store.
+func NewKeyState(agent *lms.MerkleAgent, height uint8, store KeyStateStore) (*KeyState, error) {
+	if height == 0 || height > MaxTreeHeight {
+		return nil, fmt.Errorf("lms: invalid merkle tree height %d",
+			height)
+	}
+
+	k := &KeyState{
+		key:    &PrivateKey{MerkleAgent: *agent},
+		height: height,
+		store:  store,
+	}
+	if err := store.StoreKeyState(k.serialize()); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// LoadKeyState returns the key state which was persisted to the passed store.
+func LoadKeyState(store KeyStateStore) (*KeyState, error) {
+	state, err := store.LoadKeyState()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(state) < 10 || state[0] != keyStateVersion {
+		return nil, ErrInvalidKeyState
+	}
+	height := state[1]
+	used := binary.LittleEndian.Uint32(state[2:6])
+	if height == 0 || height > MaxTreeHeight || used > 1<<height {
+		return nil, ErrInvalidKeyState
+	}
+	agent, remaining, err := readKeyStateField(state[6:])
+	if err != nil {
+		return nil, err
+	}
+	secret, remaining, err := readKeyStateField(remaining)
+	if err != nil {
+		return nil, err
+	}
+	if len(remaining) != 0 {
+		return nil, ErrInvalidKeyState
+	}
+
+	merkleAgent := lms.RebuildMerkleAgent(agent, secret)
+	if merkleAgent == nil {
+		return nil, ErrInvalidKeyState
+	}
+	return &KeyState{
+		key:    &PrivateKey{MerkleAgent: *merkleAgent},
+		height: height,
+		used:   used,
+		store:  store,
+	}, nil
+}
+
+// readKeyStateField reads a length prefixed field of a serialized key state
+// and returns it along with the remaining bytes.
+func readKeyStateField(b []byte) ([]byte, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, ErrInvalidKeyState
+	}
+	fieldLen := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+	if uint64(len(b)) < uint64(fieldLen) {
+		return nil, nil, ErrInvalidKeyState
+	}
+	return b[:fieldLen], b[fieldLen:], nil
+}
+
+// serialize returns the key state serialized according to the format described
+// above.
+func (k *KeyState) serialize() []byte {
+	agent := k.key.MerkleAgent.Serialize()
+	secret := k.key.MerkleAgent.SerializeSecretKey()
+
+	state := make([]byte, 14+len(agent)+len(secret))
+	state[0] = keyStateVersion
+	state[1] = k.height
+	binary.LittleEndian.PutUint32(state[2:6], k.used)
+	binary.LittleEndian.PutUint32(state[6:10], uint32(len(agent)))
+	copy(state[10:], agent)
+	offset := 10 + len(agent)
+	binary.LittleEndian.PutUint32(state[offset:], uint32(len(secret)))
+	copy(state[offset+4:], secret)
+	return state
+}
+
+// Sign signs the passed hash with the next one-time key of the private key.
+// The advanced state is persisted before the signature is returned, and no
+// signature is returned when that fails.  Once persisting failed, all further
+// calls return ErrKeyStateNotPersisted, and once the last one-time key was
+// used, all further calls return ErrKeyExhausted.
+func (k *KeyState) Sign(hash []byte) (hcashcrypto.Signature, error) {
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+
+	if k.failed {
+		return nil, ErrKeyStateNotPersisted
+	}
+	if k.used >= 1<<k.height {
+		return nil, ErrKeyExhausted
+	}
+
+	// The one-time key is considered used even when signing fails since
+	// the merkle agent might have advanced regardless.
+	sig, signErr := LMS.Sign(k.key, hash)
+	k.used++
+	if err := k.store.StoreKeyState(k.serialize()); err != nil {
+		k.failed = true
+		return nil, fmt.Errorf("%v: %v", ErrKeyStateNotPersisted, err)
+	}
+	if sig == nil {
+		return nil, signErr
+	}
+
+	// A signature made with the last one-time key is valid, the key is
+	// just exhausted afterwards, which the used count above records.
+	return sig, nil
+}
+
+// Remaining returns the number of signatures which can still be made with the
+// private key.
+func (k *KeyState) Remaining() uint32 {
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+
+	if k.failed {
+		return 0
+	}
+	return 1<<k.height - k.used
+}
+
+// PublicKey returns the public key of the private key.
+func (k *KeyState) PublicKey() hcashcrypto.PublicKey {
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+
+	return k.key.PublicKey()
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package lms
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LoCCS/lmots"
+	"github.com/LoCCS/lmots/rand"
+	"github.com/LoCCS/lms"
+)
+
+// failingKeyStateStore is a KeyStateStore which fails to store the state once
+// fail is set.
+type failingKeyStateStore struct {
+	state []byte
+	fail  bool
+}
+
+func (s *failingKeyStateStore) StoreKeyState(state []byte) error {
+	if s.fail {
+		return errors.New("disk full")
+	}
+	s.state = state
+	return nil
+}
+
+func (s *failingKeyStateStore) LoadKeyState() ([]byte, error) {
+	return s.state, nil
+}
+
+// newTestMerkleAgent returns a merkle agent with a tree of height 2.
+func newTestMerkleAgent(t *testing.T) *lms.MerkleAgent {
+	seed := make([]byte, lmots.N)
+	rand.Reader.Read(seed)
+	agent, err := lms.NewMerkleAgent(2, seed)
+	if err != nil {
+		t.Fatalf("NewMerkleAgent: unexpected error: %v", err)
+	}
+	return agent
+}
+
+// TestKeyState ensures the key state is persisted after every signature, can
+// be restored from the store with the number of remaining signatures, and
+// refuses to sign once the key is exhausted.
+func TestKeyState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lmskeystate")
+	if err != nil {
+		t.Fatalf("TempDir: unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	store := NewFileKeyStateStore(filepath.Join(dir, "key"))
+
+	k, err := NewKeyState(newTestMerkleAgent(t), 2, store)
+	if err != nil {
+		t.Fatalf("NewKeyState: unexpected error: %v", err)
+	}
+	message := []byte("message")
+	if _, err := k.Sign(message); err != nil {
+		t.Fatalf("Sign: unexpected error: %v", err)
+	}
+	if got := k.Remaining(); got != 3 {
+		t.Fatalf("Remaining: got %d, want 3", got)
+	}
+
+	// The restored state continues after the used one-time key.
+	k, err = LoadKeyState(store)
+	if err != nil {
+		t.Fatalf("LoadKeyState: unexpected error: %v", err)
+	}
+	if got := k.Remaining(); got != 3 {
+		t.Fatalf("Remaining after load: got %d, want 3", got)
+	}
+	for i := 0; i < 3; i++ {
+		sig, err := k.Sign(message)
+		if err != nil {
+			t.Fatalf("Sign #%d: unexpected error: %v", i, err)
+		}
+		if !LMS.Verify(k.PublicKey(), message, sig) {
+			t.Fatalf("Sign #%d: signature does not verify", i)
+		}
+	}
+	if got := k.Remaining(); got != 0 {
+		t.Fatalf("Remaining exhausted key: got %d, want 0", got)
+	}
+	k, err = LoadKeyState(store)
+	if err != nil {
+		t.Fatalf("LoadKeyState exhausted key: unexpected error: %v", err)
+	}
+	if _, err := k.Sign(message); err != ErrKeyExhausted {
+		t.Fatalf("Sign exhausted key: got error %v, want %v", err,
+			ErrKeyExhausted)
+	}
+
+	// Malformed states are rejected.
+	state, _ := store.LoadKeyState()
+	corrupt := &failingKeyStateStore{state: state[:len(state)-1]}
+	if _, err := LoadKeyState(corrupt); err != ErrInvalidKeyState {
+		t.Fatalf("LoadKeyState truncated: got error %v, want %v", err,
+			ErrInvalidKeyState)
+	}
+}
+
+// TestKeyStatePersistFailure ensures no signature is returned when the state
+// can't be persisted and that signing is refused afterwards.
+func TestKeyStatePersistFailure(t *testing.T) {
+	store := &failingKeyStateStore{}
+	k, err := NewKeyState(newTestMerkleAgent(t), 2, store)
+	if err != nil {
+		t.Fatalf("NewKeyState: unexpected error: %v", err)
+	}
+
+	store.fail = true
+	if sig, err := k.Sign([]byte("message")); err == nil || sig != nil {
+		t.Fatalf("Sign: got signature %v and error %v, want failure",
+			sig, err)
+	}
+	store.fail = false
+	if _, err := k.Sign([]byte("message")); err != ErrKeyStateNotPersisted {
+		t.Fatalf("Sign after failure: got error %v, want %v", err,
+			ErrKeyStateNotPersisted)
+	}
+	if got := k.Remaining(); got != 0 {
+		t.Fatalf("Remaining after failure: got %d, want 0", got)
+	}
+}
@@ -1,6 +1,7 @@
 package lms
 
 import (
+	"errors"
 	"io"
 	hcashcrypto "github.com/HcashOrg/hcashd/crypto"
 	"github.com/LoCCS/lms"
@@ -10,6 +11,10 @@ import (
 
 var pqcTypeLMS = 5
 
+// ErrInvalidPrivateKey is returned when LMS.Sign is handed a private key that
+// is not a LMS private key.
+var ErrInvalidPrivateKey = errors.New("lms: private key is not a lms key")
+
 type lmsDSA struct {
 
 	// Private keys
@@ -131,12 +136,32 @@ func newLMSDSA() DSA {
 			sha3.New256()
 			messageHash := sha3.Sum256(hash)
 
-			lmsPrv := priv.(PrivateKey).MerkleAgent
-			_, sig, err := lms.Sign(&lmsPrv, messageHash[:])
+			// Signing consumes a one-time key of the merkle agent, so
+			// a pointer must be passed for the advanced state to be
+			// kept by the caller.  A key passed by value is signed
+			// with a copy whose state is discarded.
+			var lmsPrv *lms.MerkleAgent
+			switch pv := priv.(type) {
+			case *PrivateKey:
+				lmsPrv = &pv.MerkleAgent
+			case PrivateKey:
+				lmsPrv = &pv.MerkleAgent
+			default:
+				return nil, ErrInvalidPrivateKey
+			}
+			// The merkle agent returns a warning along with a valid
+			// signature when the next one-time key is the last one.
+			// This is not a failure, so only the signature is
+			// returned and the exhaustion is left to be reported by
+			// PrivateKey.Remaining.
+			_, sig, err := lms.Sign(lmsPrv, messageHash[:])
+			if sig == nil {
+				return nil, err
+			}
 
 			return &Signature{
 				MerkleSig: *sig,
-			}, err
+			}, nil
 		},
 
 		verify: func(pub hcashcrypto.PublicKey, hash []byte, sig hcashcrypto.Signature) bool {
@@ -151,4 +176,4 @@ func newLMSDSA() DSA {
 	}
 
 	return lms.(DSA)
-}
\ No newline at end of file
+}
@@ -102,3 +102,30 @@ func TestLMS(t *testing.T) {
 	fmt.Printf("Max verify time: %v\n", maxver)
 
 }
+
+// TestSignLastKey ensures the last one-time key of a private key signs like
+// the others, the remaining keys are reported by the private key, and signing
+// fails once all of them are used.
+func TestSignLastKey(t *testing.T) {
+	priv := &PrivateKey{MerkleAgent: *newTestMerkleAgent(t)}
+	message := []byte("message")
+	for i := 0; i < 4; i++ {
+		if got := priv.Remaining(); got != uint32(4-i) {
+			t.Fatalf("Remaining #%d: got %d, want %d", i, got, 4-i)
+		}
+		sig, err := LMS.Sign(priv, message)
+		if err != nil {
+			t.Fatalf("Sign #%d: unexpected error: %v", i, err)
+		}
+		if !LMS.Verify(priv.PublicKey(), message, sig) {
+			t.Fatalf("Sign #%d: signature does not verify", i)
+		}
+	}
+	if got := priv.Remaining(); got != 0 {
+		t.Fatalf("Remaining: got %d, want 0", got)
+	}
+	if sig, err := LMS.Sign(priv, message); err == nil || sig != nil {
+		t.Fatalf("Sign exhausted key: got signature %v and error %v, "+
+			"want failure", sig, err)
+	}
+}
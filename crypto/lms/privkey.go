@@ -25,6 +25,12 @@ func (p PrivateKey) GetType() int {
 	return pqcTypeLMS
 }
 
+// Remaining returns the number of one-time keys of the private key which were
+// not used for a signature yet.
+func (p PrivateKey) Remaining() uint32 {
+	return 1<<p.MerkleAgent.H - p.MerkleAgent.GetLeaf()
+}
+
 func (p PrivateKey) Serialize() []byte{
 	return p.MerkleAgent.SerializeSecretKey()
 }
@@ -11,14 +11,14 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/HcashOrg/hcashd/appdata"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/database"
 	_ "github.com/HcashOrg/hcashd/database/ffldb"
-	"github.com/HcashOrg/hcashutil"
 )
 
 var (
-	hcashdHomeDir     = hcashutil.AppDataDir("hcashd", false)
+	hcashdHomeDir   = appdata.Dir("hcashd", false)
 	knownDbTypes    = database.SupportedDrivers()
 	activeNetParams = &chaincfg.MainNetParams
 
@@ -14,9 +14,11 @@ import (
 	"fmt"
 	"hash/crc32"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
@@ -108,6 +110,11 @@ type writeCursor struct {
 // blockStore houses information used to handle reading and writing blocks (and
 // part of blocks) into flat files with support for multiple concurrent readers.
 type blockStore struct {
+	// firstFileNum is the number of the first flat file that has not been
+	// pruned.  All files before it have been deleted along with the blocks
+	// they contained.  It must only be accessed atomically.
+	firstFileNum uint32
+
 	// network is the specific network to use in the flat files for each
 	// block.
 	network wire.CurrencyNet
@@ -115,6 +122,12 @@ type blockStore struct {
 	// basePath is the base path used for the flat block files and metadata.
 	basePath string
 
+	// coldPath is the path old flat block files are moved to so they may
+	// be kept on slower and cheaper storage than recent ones.  Files which
+	// do not exist in the base path are read from it.  It is empty when
+	// no cold storage is configured.
+	coldPath string
+
 	// maxBlockFileSize is the maximum size for each file used to store
 	// blocks.  It is defined on the store so the whitebox tests can
 	// override the value.
@@ -227,6 +240,23 @@ func blockFilePath(dbPath string, fileNum uint32) string {
 	return filepath.Join(dbPath, fileName)
 }
 
+// filePath returns the path of the flat file for the passed file number.  It
+// is the path in cold storage when the file has been moved there and the path
+// in the base path otherwise.
+func (s *blockStore) filePath(fileNum uint32) string {
+	filePath := blockFilePath(s.basePath, fileNum)
+	if s.coldPath == "" {
+		return filePath
+	}
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		coldFilePath := blockFilePath(s.coldPath, fileNum)
+		if _, err := os.Stat(coldFilePath); err == nil {
+			return coldFilePath
+		}
+	}
+	return filePath
+}
+
 // openWriteFile returns a file handle for the passed flat file number in
 // read/write mode.  The file will be created if needed.  It is typically used
 // for the current file that will have all new data appended.  Unlike openFile,
@@ -255,7 +285,7 @@ func (s *blockStore) openWriteFile(fileNum uint32) (filer, error) {
 // for WRITES.
 func (s *blockStore) openFile(fileNum uint32) (*lockableFile, error) {
 	// Open the appropriate file as read-only.
-	filePath := blockFilePath(s.basePath, fileNum)
+	filePath := s.filePath(fileNum)
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, makeDbErr(database.ErrDriverSpecific, err.Error(),
@@ -299,12 +329,23 @@ func (s *blockStore) openFile(fileNum uint32) (*lockableFile, error) {
 	return blockFile, nil
 }
 
-// deleteFile removes the block file for the passed flat file number.  The file
-// must already be closed and it is the responsibility of the caller to do any
-// other state cleanup necessary.
+// deleteFile removes the block file for the passed flat file number, including
+// its copy in cold storage.  The file must already be closed and it is the
+// responsibility of the caller to do any other state cleanup necessary.
 func (s *blockStore) deleteFile(fileNum uint32) error {
+	var removedCold bool
+	if s.coldPath != "" {
+		err := os.Remove(blockFilePath(s.coldPath, fileNum))
+		if err != nil && !os.IsNotExist(err) {
+			return makeDbErr(database.ErrDriverSpecific, err.Error(),
+				err)
+		}
+		removedCold = err == nil
+	}
+
 	filePath := blockFilePath(s.basePath, fileNum)
-	if err := os.Remove(filePath); err != nil {
+	err := os.Remove(filePath)
+	if err != nil && !(removedCold && os.IsNotExist(err)) {
 		return makeDbErr(database.ErrDriverSpecific, err.Error(), err)
 	}
 
@@ -322,6 +363,12 @@ func (s *blockStore) deleteFile(fileNum uint32) error {
 // separate goroutine to close the file after it is returned from here, but
 // before the caller has acquired a read lock.
 func (s *blockStore) blockFile(fileNum uint32) (*lockableFile, error) {
+	// The blocks in files before the first file have been pruned.
+	if fileNum < atomic.LoadUint32(&s.firstFileNum) {
+		str := fmt.Sprintf("block file %d has been pruned", fileNum)
+		return nil, makeDbErr(database.ErrBlockPruned, str, nil)
+	}
+
 	// When the requested block file is open for writes, return it.
 	wc := s.writeCursor
 	wc.RLock()
@@ -717,15 +764,216 @@ func (s *blockStore) handleRollback(oldBlockFileNum, oldBlockOffset uint32) {
 	return
 }
 
+// closeFile closes the read-only file handle for the passed flat file number
+// if it is open and removes it from the least recently used tracking.  It
+// waits for any readers of the file to finish before closing it.
+func (s *blockStore) closeFile(fileNum uint32) {
+	s.obfMutex.Lock()
+	s.closeOpenFile(fileNum)
+	s.obfMutex.Unlock()
+}
+
+// closeOpenFile is the implementation of closeFile.
+//
+// This function MUST be called with the overall files mutex (s.obfMutex) locked
+// for WRITES.
+func (s *blockStore) closeOpenFile(fileNum uint32) {
+	blockFile, ok := s.openBlockFiles[fileNum]
+	if !ok {
+		return
+	}
+
+	s.lruMutex.Lock()
+	if elem, ok := s.fileNumToLRUElem[fileNum]; ok {
+		s.openBlocksLRU.Remove(elem)
+		delete(s.fileNumToLRUElem, fileNum)
+	}
+	s.lruMutex.Unlock()
+
+	blockFile.Lock()
+	_ = blockFile.file.Close()
+	blockFile.Unlock()
+	delete(s.openBlockFiles, fileNum)
+}
+
+// pruneFiles deletes the flat files before the passed file number, oldest
+// first, until the total size of the remaining files is at most targetSize
+// bytes.  The file currently being written to is never deleted.  It returns
+// the number of bytes freed.
+//
+// Readers of blocks in the deleted files receive errors with ErrBlockPruned
+// from then on.  Since the file is marked as pruned before it is deleted, a
+// failure to delete a file only leaks the disk space it occupies.
+func (s *blockStore) pruneFiles(keepFileNum uint32, targetSize uint64) (uint64, error) {
+	wc := s.writeCursor
+	wc.RLock()
+	curFileNum := wc.curFileNum
+	wc.RUnlock()
+	if keepFileNum > curFileNum {
+		keepFileNum = curFileNum
+	}
+
+	// Determine the sizes of the remaining files.
+	firstFileNum := atomic.LoadUint32(&s.firstFileNum)
+	sizes := make(map[uint32]uint64)
+	var totalSize uint64
+	for fileNum := firstFileNum; fileNum <= curFileNum; fileNum++ {
+		st, err := os.Stat(s.filePath(fileNum))
+		if err != nil {
+			continue
+		}
+		sizes[fileNum] = uint64(st.Size())
+		totalSize += uint64(st.Size())
+	}
+
+	var freed uint64
+	for fileNum := firstFileNum; fileNum < keepFileNum; fileNum++ {
+		if totalSize <= targetSize {
+			break
+		}
+
+		atomic.StoreUint32(&s.firstFileNum, fileNum+1)
+		s.closeFile(fileNum)
+		size, ok := sizes[fileNum]
+		if !ok {
+			continue
+		}
+		if err := s.deleteFileFunc(fileNum); err != nil {
+			return freed, err
+		}
+		log.Debugf("Pruned block file %d (%d bytes)", fileNum, size)
+		totalSize -= size
+		freed += size
+	}
+
+	return freed, nil
+}
+
+// copyFile copies the file at the passed source path to the passed destination
+// path.  The data is written to a temporary file which is synced and renamed to
+// the destination, so the destination never holds a partial copy.
+func copyFile(srcPath, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmpPath := dstPath + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, dstPath)
+}
+
+// moveFilesToCold moves the flat files before the passed file number from the
+// base path to cold storage, oldest first.  The file currently being written
+// to is never moved.  It returns the number of bytes moved, which is zero when
+// no cold storage is configured.
+//
+// Each file is copied before the original is removed, so the blocks remain
+// readable throughout and an interrupted move only leaves a redundant copy,
+// which is moved again by the next call.
+func (s *blockStore) moveFilesToCold(keepFileNum uint32) (uint64, error) {
+	if s.coldPath == "" {
+		return 0, nil
+	}
+
+	wc := s.writeCursor
+	wc.RLock()
+	curFileNum := wc.curFileNum
+	wc.RUnlock()
+	if keepFileNum > curFileNum {
+		keepFileNum = curFileNum
+	}
+
+	var moved uint64
+	firstFileNum := atomic.LoadUint32(&s.firstFileNum)
+	for fileNum := firstFileNum; fileNum < keepFileNum; fileNum++ {
+		hotPath := blockFilePath(s.basePath, fileNum)
+		st, err := os.Stat(hotPath)
+		if err != nil {
+			continue
+		}
+		coldPath := blockFilePath(s.coldPath, fileNum)
+		if err := copyFile(hotPath, coldPath); err != nil {
+			str := fmt.Sprintf("failed to copy file %q to %q: %v",
+				hotPath, coldPath, err)
+			return moved, makeDbErr(database.ErrDriverSpecific, str,
+				err)
+		}
+
+		// Remove the original under the overall files write lock so a
+		// reader opening it concurrently opens the copy afterwards.
+		s.obfMutex.Lock()
+		s.closeOpenFile(fileNum)
+		err = os.Remove(hotPath)
+		s.obfMutex.Unlock()
+		if err != nil {
+			return moved, makeDbErr(database.ErrDriverSpecific,
+				err.Error(), err)
+		}
+		log.Debugf("Moved block file %d (%d bytes) to cold storage",
+			fileNum, st.Size())
+		moved += uint64(st.Size())
+	}
+
+	return moved, nil
+}
+
+// firstBlockFile returns the number of the first flat block file in the
+// passed directory, which is only after the first possible file when old
+// files have been pruned or moved to cold storage.  It returns -1 when there
+// are no block files.
+func firstBlockFile(dbPath string) int {
+	entries, err := ioutil.ReadDir(dbPath)
+	if err != nil {
+		return 0
+	}
+
+	first := -1
+	for _, entry := range entries {
+		var fileNum uint32
+		_, err := fmt.Sscanf(entry.Name(), blockFilenameTemplate, &fileNum)
+		if err != nil || fmt.Sprintf(blockFilenameTemplate, fileNum) !=
+			entry.Name() {
+			continue
+		}
+		if first == -1 || int(fileNum) < first {
+			first = int(fileNum)
+		}
+	}
+	return first
+}
+
 // scanBlockFiles searches the database directory for all flat block files to
 // find the end of the most recent file.  This position is considered the
 // current write cursor which is also stored in the metadata.  Thus, it is used
 // to detect unexpected shutdowns in the middle of writes so the block files
 // can be reconciled.
 func scanBlockFiles(dbPath string) (int, uint32) {
+	firstFile := firstBlockFile(dbPath)
+	if firstFile == -1 {
+		firstFile = 0
+	}
 	lastFile := -1
 	fileLen := uint32(0)
-	for i := 0; ; i++ {
+	for i := firstFile; ; i++ {
 		filePath := blockFilePath(dbPath, uint32(i))
 		st, err := os.Stat(filePath)
 		if err != nil {
@@ -742,8 +990,9 @@ func scanBlockFiles(dbPath string) (int, uint32) {
 }
 
 // newBlockStore returns a new block store with the current block file number
-// and offset set and all fields initialized.
-func newBlockStore(basePath string, network wire.CurrencyNet) *blockStore {
+// and offset set and all fields initialized.  The cold path may be empty to
+// keep all flat files in the base path.
+func newBlockStore(basePath, coldPath string, network wire.CurrencyNet) *blockStore {
 	// Look for the end of the latest block to file to determine what the
 	// write cursor position is from the viewpoing of the block files on
 	// disk.
@@ -753,9 +1002,26 @@ func newBlockStore(basePath string, network wire.CurrencyNet) *blockStore {
 		fileOff = 0
 	}
 
+	// Old files may have been moved to cold storage, in which case the
+	// first file is found there.
+	firstFileNum := firstBlockFile(basePath)
+	if coldPath != "" {
+		coldFileNum := firstBlockFile(coldPath)
+		if coldFileNum != -1 && (firstFileNum == -1 ||
+			coldFileNum < firstFileNum) {
+
+			firstFileNum = coldFileNum
+		}
+	}
+	if firstFileNum == -1 {
+		firstFileNum = 0
+	}
+
 	store := &blockStore{
+		firstFileNum:     uint32(firstFileNum),
 		network:          network,
 		basePath:         basePath,
+		coldPath:         coldPath,
 		maxBlockFileSize: maxBlockFileSize,
 		openBlockFiles:   make(map[uint32]*lockableFile),
 		openBlocksLRU:    list.New(),
@@ -1804,6 +1804,69 @@ func (db *db) Type() string {
 	return dbType
 }
 
+// PruneBlocks deletes the flat files holding the blocks that were stored before
+// the block identified by the passed hash, oldest first, until the total size
+// of the remaining flat files is at most targetSize bytes.  The block index is
+// left untouched, so the headers of pruned blocks remain available while
+// fetching their data returns ErrBlockPruned.  It returns the number of bytes
+// freed.
+//
+// This function is part of the database.DB interface implementation.
+func (db *db) PruneBlocks(keep *chainhash.Hash, targetSize uint64) (uint64, error) {
+	var keepFileNum uint32
+	err := db.View(func(dbTx database.Tx) error {
+		blockRow, err := dbTx.(*transaction).fetchBlockRow(keep)
+		if err != nil {
+			return err
+		}
+		keepFileNum = deserializeBlockLoc(blockRow).blockFileNum
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Ensure the database is not closed while the files are deleted.
+	db.closeLock.RLock()
+	defer db.closeLock.RUnlock()
+	if db.closed {
+		return 0, makeDbErr(database.ErrDbNotOpen, errDbNotOpenStr, nil)
+	}
+
+	return db.store.pruneFiles(keepFileNum, targetSize)
+}
+
+// ArchiveBlocks moves the flat files holding the blocks that were stored before
+// the block identified by the passed hash to the cold storage the database was
+// opened with, oldest first.  Reads of the moved blocks transparently use the
+// files in cold storage.  It returns the number of bytes moved, which is zero
+// when the database was opened without cold storage.
+//
+// This function is part of the database.DB interface implementation.
+func (db *db) ArchiveBlocks(keep *chainhash.Hash) (uint64, error) {
+	var keepFileNum uint32
+	err := db.View(func(dbTx database.Tx) error {
+		blockRow, err := dbTx.(*transaction).fetchBlockRow(keep)
+		if err != nil {
+			return err
+		}
+		keepFileNum = deserializeBlockLoc(blockRow).blockFileNum
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Ensure the database is not closed while the files are moved.
+	db.closeLock.RLock()
+	defer db.closeLock.RUnlock()
+	if db.closed {
+		return 0, makeDbErr(database.ErrDbNotOpen, errDbNotOpenStr, nil)
+	}
+
+	return db.store.moveFilesToCold(keepFileNum)
+}
+
 // begin is the implementation function for the Begin database method.  See its
 // documentation for more details.
 //
@@ -2041,7 +2104,9 @@ func initDB(ldb *leveldb.DB) error {
 
 // openDB opens the database at the provided path.  database.ErrDbDoesNotExist
 // is returned if the database doesn't exist and the create flag is not set.
-func openDB(dbPath string, network wire.CurrencyNet, create bool) (database.DB, error) {
+// The cold path is the path old flat block files are moved to by ArchiveBlocks
+// and may be empty to disable cold storage.
+func openDB(dbPath, coldPath string, network wire.CurrencyNet, create bool) (database.DB, error) {
 	// Error if the database doesn't exist and the create flag is not set.
 	metadataDbPath := filepath.Join(dbPath, metadataDbName)
 	dbExists := fileExists(metadataDbPath)
@@ -2057,6 +2122,13 @@ func openDB(dbPath string, network wire.CurrencyNet, create bool) (database.DB,
 		// created.
 		_ = os.MkdirAll(dbPath, 0700)
 	}
+	if coldPath != "" {
+		if err := os.MkdirAll(coldPath, 0700); err != nil {
+			str := fmt.Sprintf("failed to create cold storage %q: %v",
+				coldPath, err)
+			return nil, makeDbErr(database.ErrDriverSpecific, str, err)
+		}
+	}
 
 	// Open the metadata database (will create it if needed).
 	opts := opt.Options{
@@ -2075,11 +2147,21 @@ func openDB(dbPath string, network wire.CurrencyNet, create bool) (database.DB,
 	// according to the data that is actually on disk.  Also create the
 	// database cache which wraps the underlying leveldb database to provide
 	// write caching.
-	store := newBlockStore(dbPath, network)
+	store := newBlockStore(dbPath, coldPath, network)
 	cache := newDbCache(ldb, store, defaultCacheSize, defaultFlushSecs)
 	pdb := &db{store: store, cache: cache}
 
 	// Perform any reconciliation needed between the block and metadata as
 	// well as database initialization, if needed.
-	return reconcileDB(pdb, create)
+	if _, err := reconcileDB(pdb, create); err != nil {
+		return nil, err
+	}
+
+	// Warm the block index and the most recent block files so the initial
+	// block lookups after a restart do not stall on disk reads.  There is
+	// nothing to warm for a newly created database.
+	if dbExists {
+		pdb.warmCaches()
+	}
+	return pdb, nil
 }
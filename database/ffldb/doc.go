@@ -26,5 +26,16 @@ database path as a string and the block network:
 	if err != nil {
 		// Handle error
 	}
+
+The path of a cold storage directory may be passed as an additional string
+parameter.  Old flat block files are moved there by ArchiveBlocks, which allows
+keeping the history on slower and cheaper storage than the recent blocks, while
+reads of moved blocks transparently use the files in cold storage:
+
+	db, err := database.Open("ffldb", "path/to/database", wire.MainNet,
+		"path/to/cold/storage")
+	if err != nil {
+		// Handle error
+	}
 */
 package ffldb
@@ -0,0 +1,285 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// This file contains the implementation of the integrity check which verifies
+// the block data in the flat files against the block index.
+
+package ffldb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/database"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/btcsuite/goleveldb/leveldb/util"
+)
+
+// storedBlock describes a valid block record found while scanning the flat
+// block files.
+type storedBlock struct {
+	hash chainhash.Hash
+	loc  blockLocation
+	hdr  []byte
+}
+
+// interruptRequested returns true when the passed channel has been closed.  A
+// nil channel is never closed.
+func interruptRequested(interrupt <-chan struct{}) bool {
+	select {
+	case <-interrupt:
+		return true
+	default:
+	}
+
+	return false
+}
+
+// scanBlockFile reads the block records of the passed flat file up to the
+// passed end offset and returns the valid ones along with the size of the file.
+// A size of -1 indicates the file does not exist.  Scanning stops at the first
+// record that can't be for a block, such as a record for the wrong network or
+// one that extends past the end of the file, while records that fail the
+// checksum are skipped.
+//
+// Format: <network><block length><serialized block><checksum>
+func (s *blockStore) scanBlockFile(fileNum uint32, end int64) ([]storedBlock, int64, error) {
+	file, err := os.Open(s.filePath(fileNum))
+	if os.IsNotExist(err) {
+		return nil, -1, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	size := fi.Size()
+	if end < 0 || end > size {
+		end = size
+	}
+
+	var blocks []storedBlock
+	var prefix [8]byte
+	for offset := int64(0); offset+int64(len(prefix)) <= end; {
+		if _, err := file.ReadAt(prefix[:], offset); err != nil {
+			break
+		}
+		if byteOrder.Uint32(prefix[0:4]) != uint32(s.network) {
+			break
+		}
+		blockLen := int64(byteOrder.Uint32(prefix[4:8]))
+		fullLen := blockLen + 12
+		if blockLen < blockHdrSize || blockLen > wire.MaxBlockPayload ||
+			offset+fullLen > end {
+			break
+		}
+
+		record := make([]byte, fullLen)
+		if _, err := file.ReadAt(record, offset); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, 0, err
+		}
+		loc := blockLocation{
+			blockFileNum: fileNum,
+			fileOffset:   uint32(offset),
+			blockLen:     uint32(fullLen),
+		}
+		offset += fullLen
+
+		serializedChecksum := binary.BigEndian.Uint32(record[fullLen-4:])
+		checksum := crc32.Checksum(record[:fullLen-4], castagnoli)
+		if serializedChecksum != checksum {
+			continue
+		}
+
+		var header wire.BlockHeader
+		hdr := record[8 : 8+blockHdrSize]
+		if err := header.Deserialize(bytes.NewReader(hdr)); err != nil {
+			continue
+		}
+		blocks = append(blocks, storedBlock{
+			hash: header.BlockHash(),
+			loc:  loc,
+			hdr:  hdr,
+		})
+	}
+
+	return blocks, size, nil
+}
+
+// checkIndexedBlock verifies the block data referenced by the passed block
+// index entry and records the result in the passed report.  The passed file
+// sizes are the ones found while scanning the flat files.
+func (s *blockStore) checkIndexedBlock(hash *chainhash.Hash, loc blockLocation, fileSizes map[uint32]int64, report *database.IntegrityReport) {
+	if loc.blockFileNum < atomic.LoadUint32(&s.firstFileNum) {
+		report.PrunedBlocks++
+		return
+	}
+
+	size, ok := fileSizes[loc.blockFileNum]
+	if !ok || size < int64(loc.fileOffset)+int64(loc.blockLen) {
+		report.MissingBlocks = append(report.MissingBlocks, *hash)
+		return
+	}
+
+	rawBlock, err := s.readBlock(hash, loc)
+	if err == nil && len(rawBlock) >= blockHdrSize {
+		var header wire.BlockHeader
+		hdr := bytes.NewReader(rawBlock[:blockHdrSize])
+		if header.Deserialize(hdr) == nil && header.BlockHash() == *hash {
+			report.CheckedBlocks++
+			return
+		}
+	}
+	report.CorruptBlocks = append(report.CorruptBlocks, *hash)
+}
+
+// CheckIntegrity scans all stored block data, verifies the data of every block
+// in the block index, and looks for valid blocks that are missing from the
+// block index.
+//
+// Blocks whose data is missing, such as due to truncated or deleted flat files,
+// are reported as missing, and blocks whose data fails verification are
+// reported as corrupt.  When repair is true, their entries are removed from the
+// block index and the entries for the unindexed blocks are added, after which
+// the metadata database is flushed and compacted.
+//
+// NOTE: Truncation of the current write file is already detected when the
+// database is opened since it invalidates the write cursor.
+//
+// This function is part of the database.DB interface implementation.
+func (db *db) CheckIntegrity(repair bool, interrupt <-chan struct{}) (*database.IntegrityReport, error) {
+	report := &database.IntegrityReport{}
+	var unindexed []storedBlock
+	err := db.View(func(dbTx database.Tx) error {
+		tx := dbTx.(*transaction)
+
+		wc := db.store.writeCursor
+		wc.RLock()
+		curFileNum, curOffset := wc.curFileNum, wc.curOffset
+		wc.RUnlock()
+
+		// Scan the flat files for valid block records and mark the
+		// blocks whose index entries reference them as checked.
+		fileSizes := make(map[uint32]int64)
+		checked := make(map[chainhash.Hash]struct{})
+		firstFileNum := atomic.LoadUint32(&db.store.firstFileNum)
+		for fileNum := firstFileNum; fileNum <= curFileNum; fileNum++ {
+			if interruptRequested(interrupt) {
+				report.Interrupted = true
+				return nil
+			}
+
+			end := int64(-1)
+			if fileNum == curFileNum {
+				end = int64(curOffset)
+			}
+			blocks, size, err := db.store.scanBlockFile(fileNum, end)
+			if err != nil {
+				str := "failed to scan block files"
+				return makeDbErr(database.ErrDriverSpecific, str, err)
+			}
+			if size < 0 {
+				continue
+			}
+			fileSizes[fileNum] = size
+
+			for _, block := range blocks {
+				blockRow := tx.blockIdxBucket.Get(block.hash[:])
+				if blockRow == nil {
+					unindexed = append(unindexed, block)
+					continue
+				}
+				if deserializeBlockLoc(blockRow) == block.loc {
+					checked[block.hash] = struct{}{}
+				}
+			}
+		}
+		report.CheckedBlocks = uint64(len(checked))
+
+		// Verify the data of the remaining blocks in the block index.
+		return tx.blockIdxBucket.ForEach(func(k, v []byte) error {
+			if interruptRequested(interrupt) {
+				report.Interrupted = true
+				return nil
+			}
+
+			var hash chainhash.Hash
+			copy(hash[:], k)
+			if _, ok := checked[hash]; ok {
+				return nil
+			}
+			loc := deserializeBlockLoc(v)
+			db.store.checkIndexedBlock(&hash, loc, fileSizes, report)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range unindexed {
+		report.UnindexedBlocks = append(report.UnindexedBlocks, block.hash)
+	}
+	if !repair || report.Interrupted {
+		return report, nil
+	}
+
+	// Rebuild the block index.
+	err = db.Update(func(dbTx database.Tx) error {
+		tx := dbTx.(*transaction)
+		for i := range report.CorruptBlocks {
+			hash := report.CorruptBlocks[i][:]
+			if err := tx.blockIdxBucket.Delete(hash); err != nil {
+				return err
+			}
+		}
+		for i := range report.MissingBlocks {
+			hash := report.MissingBlocks[i][:]
+			if err := tx.blockIdxBucket.Delete(hash); err != nil {
+				return err
+			}
+		}
+		for _, block := range unindexed {
+			blockRow := serializeBlockRow(block.loc, block.hdr)
+			err := tx.blockIdxBucket.Put(block.hash[:], blockRow)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Repaired = true
+
+	// Flush the cache and compact the underlying leveldb database while
+	// holding the write lock to ensure no changes are made concurrently.
+	db.closeLock.RLock()
+	defer db.closeLock.RUnlock()
+	if db.closed {
+		return nil, makeDbErr(database.ErrDbNotOpen, errDbNotOpenStr, nil)
+	}
+	db.writeLock.Lock()
+	defer db.writeLock.Unlock()
+	if err := db.cache.flush(); err != nil {
+		return nil, err
+	}
+	if err := db.cache.ldb.CompactRange(util.Range{}); err != nil {
+		return nil, convertErr("failed to compact metadata", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ffldb
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/goleveldb/leveldb/util"
+)
+
+const (
+	// warmBlockFileBytes is the number of bytes at the end of the most
+	// recent flat block files which are read on startup to warm the page
+	// cache of the operating system.  These are the blocks that are the
+	// most likely to be read again shortly after a restart while the node
+	// is catching up.
+	warmBlockFileBytes = 64 * 1024 * 1024 // 64 MiB
+
+	// warmReadSize is the size of the reads used to warm the page cache
+	// for the flat block files.
+	warmReadSize = 1024 * 1024 // 1 MiB
+
+	// warmProgressInterval is the minimum amount of time between the
+	// progress messages logged while warming the caches.
+	warmProgressInterval = 10 * time.Second
+)
+
+// warmBlockIndex iterates all entries of the block index bucket so the
+// underlying leveldb tables are loaded into the block cache and the page cache
+// of the operating system.  It returns the number of entries visited.
+func (db *db) warmBlockIndex() (int, error) {
+	iter := db.cache.ldb.NewIterator(util.BytesPrefix(blockIdxBucketID[:]),
+		nil)
+	defer iter.Release()
+
+	var entries int
+	lastLog := time.Now()
+	for iter.Next() {
+		entries++
+		if entries%1024 == 0 && time.Since(lastLog) >= warmProgressInterval {
+			log.Infof("Warming block index: %d entries loaded",
+				entries)
+			lastLog = time.Now()
+		}
+	}
+	return entries, iter.Error()
+}
+
+// warmBlockFiles sequentially reads up to warmBlockFileBytes from the end of
+// the most recent flat block files, starting with the one the write cursor
+// points to, so the page cache of the operating system holds the most recent
+// blocks.  It returns the number of bytes read.
+//
+// The files are opened directly rather than through the open file tracking of
+// the block store since this runs before the database is handed out.
+func (s *blockStore) warmBlockFiles() (int64, error) {
+	var read int64
+	buf := make([]byte, warmReadSize)
+	lastLog := time.Now()
+	fileNum := int64(s.writeCursor.curFileNum)
+	for ; fileNum >= int64(s.firstFileNum) && read < warmBlockFileBytes; fileNum-- {
+		file, err := os.Open(blockFilePath(s.basePath, uint32(fileNum)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return read, err
+		}
+
+		st, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return read, err
+		}
+
+		// Only read the tail of the file when it contains more than
+		// the remaining number of bytes to read.
+		offset := st.Size() - (warmBlockFileBytes - read)
+		if offset < 0 {
+			offset = 0
+		}
+		for offset < st.Size() {
+			n, err := file.ReadAt(buf, offset)
+			offset += int64(n)
+			read += int64(n)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				file.Close()
+				return read, err
+			}
+			if time.Since(lastLog) >= warmProgressInterval {
+				log.Infof("Warming block files: %d MiB read",
+					read/(1024*1024))
+				lastLog = time.Now()
+			}
+		}
+		file.Close()
+	}
+	return read, nil
+}
+
+// warmCaches concurrently warms the block index and the most recent flat block
+// files on startup so the blocks needed shortly after a restart are served
+// from memory rather than requiring random reads from disk.  Failing to warm
+// the caches is not fatal since it only affects performance, so any errors
+// are logged rather than returned.
+func (db *db) warmCaches() {
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	var entries int
+	var bytesRead int64
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		var err error
+		entries, err = db.warmBlockIndex()
+		if err != nil {
+			log.Warnf("Unable to warm block index: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		var err error
+		bytesRead, err = db.store.warmBlockFiles()
+		if err != nil {
+			log.Warnf("Unable to warm block files: %v", err)
+		}
+	}()
+	wg.Wait()
+
+	log.Infof("Warmed %d block index entries and %d MiB of block files "+
+		"in %v", entries, bytesRead/(1024*1024), time.Since(start))
+}
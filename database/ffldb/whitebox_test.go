@@ -21,6 +21,7 @@ import (
 
 	"github.com/btcsuite/goleveldb/leveldb"
 	ldberrors "github.com/btcsuite/goleveldb/leveldb/errors"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
 	"github.com/HcashOrg/hcashd/wire"
 	"github.com/HcashOrg/hcashutil"
@@ -156,7 +157,7 @@ func TestCornerCases(t *testing.T) {
 	// directory is needed.
 	testName := "openDB: fail due to file at target location"
 	wantErrCode := database.ErrDriverSpecific
-	idb, err := openDB(dbPath, blockDataNet, true)
+	idb, err := openDB(dbPath, "", blockDataNet, true)
 	if !checkDbError(t, testName, err, wantErrCode) {
 		if err == nil {
 			idb.Close()
@@ -168,7 +169,7 @@ func TestCornerCases(t *testing.T) {
 	// Remove the file and create the database to run tests against.  It
 	// should be successful this time.
 	_ = os.RemoveAll(dbPath)
-	idb, err = openDB(dbPath, blockDataNet, true)
+	idb, err = openDB(dbPath, "", blockDataNet, true)
 	if err != nil {
 		t.Errorf("openDB: unexpected error: %v", err)
 		return
@@ -681,3 +682,463 @@ func TestFailureScenarios(t *testing.T) {
 	// Test various corruption scenarios.
 	testCorruption(tc)
 }
+
+// TestPruneBlocks ensures pruning deletes the flat files of old blocks while
+// retaining their headers, never deletes the kept block, and persists across
+// reopening the database.
+func TestPruneBlocks(t *testing.T) {
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Errorf("loadBlocks: Unexpected error: %v", err)
+		return
+	}
+	blocks = blocks[:10]
+
+	dbPath := filepath.Join(os.TempDir(), "ffldb-pruneblocks")
+	_ = os.RemoveAll(dbPath)
+	idb, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+
+	// Store every block in its own flat file.
+	idb.(*db).store.maxBlockFileSize = 1
+	for _, block := range blocks {
+		err := idb.Update(func(tx database.Tx) error {
+			return tx.StoreBlock(block)
+		})
+		if err != nil {
+			idb.Close()
+			t.Errorf("StoreBlock: unexpected error: %v", err)
+			return
+		}
+	}
+
+	keep := blocks[5].Hash()
+	freed, err := idb.PruneBlocks(keep, 0)
+	if err != nil {
+		idb.Close()
+		t.Errorf("PruneBlocks: unexpected error: %v", err)
+		return
+	}
+	if freed == 0 {
+		t.Errorf("PruneBlocks: no bytes freed")
+	}
+
+	// checkPruned ensures the blocks before the kept block are pruned with
+	// their headers intact and the remaining blocks are available.
+	checkPruned := func(idb database.DB) {
+		err := idb.View(func(tx database.Tx) error {
+			for i, block := range blocks {
+				hash := block.Hash()
+				if _, err := tx.FetchBlockHeader(hash); err != nil {
+					t.Errorf("FetchBlockHeader #%d: unexpected "+
+						"error: %v", i, err)
+				}
+				_, err := tx.FetchBlock(hash)
+				if i < 5 {
+					checkDbError(t, "FetchBlock", err,
+						database.ErrBlockPruned)
+					continue
+				}
+				if err != nil {
+					t.Errorf("FetchBlock #%d: unexpected error: "+
+						"%v", i, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("View: unexpected error: %v", err)
+		}
+	}
+	checkPruned(idb)
+
+	// Ensure the pruned state is detected when the database is reopened.
+	idb.Close()
+	idb, err = database.Open(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to open test database (%s) %v", dbType, err)
+		return
+	}
+	defer idb.Close()
+	checkPruned(idb)
+
+	// Ensure pruning an unknown block fails.
+	_, err = idb.PruneBlocks(&chainhash.Hash{}, 0)
+	checkDbError(t, "PruneBlocks", err, database.ErrBlockNotFound)
+}
+
+// TestArchiveBlocks ensures archiving moves the flat files of old blocks to
+// cold storage, never moves the kept block, and that all blocks remain
+// readable, including after reopening the database.
+func TestArchiveBlocks(t *testing.T) {
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Errorf("loadBlocks: Unexpected error: %v", err)
+		return
+	}
+	blocks = blocks[:10]
+
+	dbPath := filepath.Join(os.TempDir(), "ffldb-archiveblocks")
+	coldPath := filepath.Join(os.TempDir(), "ffldb-archiveblocks-cold")
+	_ = os.RemoveAll(dbPath)
+	_ = os.RemoveAll(coldPath)
+	idb, err := database.Create(dbType, dbPath, blockDataNet, coldPath)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+	defer os.RemoveAll(coldPath)
+
+	// Store every block in its own flat file.
+	idb.(*db).store.maxBlockFileSize = 1
+	for _, block := range blocks {
+		err := idb.Update(func(tx database.Tx) error {
+			return tx.StoreBlock(block)
+		})
+		if err != nil {
+			idb.Close()
+			t.Errorf("StoreBlock: unexpected error: %v", err)
+			return
+		}
+	}
+	var locs []blockLocation
+	err = idb.View(func(tx database.Tx) error {
+		for _, block := range blocks {
+			blockRow, err := tx.(*transaction).fetchBlockRow(
+				block.Hash())
+			if err != nil {
+				return err
+			}
+			locs = append(locs, deserializeBlockLoc(blockRow))
+		}
+		return nil
+	})
+	if err != nil {
+		idb.Close()
+		t.Errorf("View: unexpected error: %v", err)
+		return
+	}
+
+	keep := blocks[5].Hash()
+	moved, err := idb.ArchiveBlocks(keep)
+	if err != nil {
+		idb.Close()
+		t.Errorf("ArchiveBlocks: unexpected error: %v", err)
+		return
+	}
+	if moved == 0 {
+		t.Errorf("ArchiveBlocks: no bytes moved")
+	}
+
+	// checkArchived ensures the flat files of the blocks before the kept
+	// block are only in cold storage and all blocks are available.
+	checkArchived := func(idb database.DB) {
+		for i, loc := range locs {
+			_, errHot := os.Stat(blockFilePath(dbPath, loc.blockFileNum))
+			_, errCold := os.Stat(blockFilePath(coldPath, loc.blockFileNum))
+			archived := os.IsNotExist(errHot) && errCold == nil
+			if archived != (i < 5) {
+				t.Errorf("block #%d: got archived %v, want %v", i,
+					archived, i < 5)
+			}
+		}
+
+		err := idb.View(func(tx database.Tx) error {
+			for i, block := range blocks {
+				if _, err := tx.FetchBlock(block.Hash()); err != nil {
+					t.Errorf("FetchBlock #%d: unexpected error: "+
+						"%v", i, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("View: unexpected error: %v", err)
+		}
+	}
+	checkArchived(idb)
+
+	// Ensure the archived blocks are found when the database is reopened.
+	idb.Close()
+	idb, err = database.Open(dbType, dbPath, blockDataNet, coldPath)
+	if err != nil {
+		t.Errorf("Failed to open test database (%s) %v", dbType, err)
+		return
+	}
+	defer idb.Close()
+	checkArchived(idb)
+
+	// Ensure archiving an unknown block fails.
+	_, err = idb.ArchiveBlocks(&chainhash.Hash{})
+	checkDbError(t, "ArchiveBlocks", err, database.ErrBlockNotFound)
+}
+
+// TestCheckIntegrity ensures the integrity check detects corrupt, missing, and
+// unindexed blocks and that repairing the database rebuilds the block index.
+func TestCheckIntegrity(t *testing.T) {
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Errorf("loadBlocks: Unexpected error: %v", err)
+		return
+	}
+	blocks = blocks[:10]
+
+	dbPath := filepath.Join(os.TempDir(), "ffldb-checkintegrity")
+	_ = os.RemoveAll(dbPath)
+	idb, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+	defer idb.Close()
+
+	// Store every block in its own flat file.
+	idb.(*db).store.maxBlockFileSize = 1
+	for _, block := range blocks {
+		err := idb.Update(func(tx database.Tx) error {
+			return tx.StoreBlock(block)
+		})
+		if err != nil {
+			t.Errorf("StoreBlock: unexpected error: %v", err)
+			return
+		}
+	}
+
+	// Ensure an interrupted check is reported as such.
+	interrupt := make(chan struct{})
+	close(interrupt)
+	report, err := idb.CheckIntegrity(true, interrupt)
+	if err != nil {
+		t.Errorf("CheckIntegrity: unexpected error: %v", err)
+		return
+	}
+	if !report.Interrupted || report.Repaired {
+		t.Errorf("CheckIntegrity: unexpected interrupted report %+v",
+			report)
+	}
+
+	// Truncate the file of the third block, corrupt the data of the fifth
+	// block, and remove the seventh block from the block index.
+	var locs [10]blockLocation
+	err = idb.View(func(tx database.Tx) error {
+		for i, block := range blocks {
+			blockRow, err := tx.(*transaction).fetchBlockRow(block.Hash())
+			if err != nil {
+				return err
+			}
+			locs[i] = deserializeBlockLoc(blockRow)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("View: unexpected error: %v", err)
+		return
+	}
+	basePath := idb.(*db).store.basePath
+	err = os.Truncate(blockFilePath(basePath, locs[3].blockFileNum), 100)
+	if err != nil {
+		t.Errorf("Truncate: unexpected error: %v", err)
+		return
+	}
+	file, err := os.OpenFile(blockFilePath(basePath, locs[5].blockFileNum),
+		os.O_RDWR, 0)
+	if err != nil {
+		t.Errorf("OpenFile: unexpected error: %v", err)
+		return
+	}
+	_, err = file.WriteAt([]byte{0xff}, int64(locs[5].fileOffset)+8+
+		blockHdrSize+1)
+	file.Close()
+	if err != nil {
+		t.Errorf("WriteAt: unexpected error: %v", err)
+		return
+	}
+	err = idb.Update(func(tx database.Tx) error {
+		return tx.(*transaction).blockIdxBucket.Delete(blocks[7].Hash()[:])
+	})
+	if err != nil {
+		t.Errorf("Update: unexpected error: %v", err)
+		return
+	}
+
+	// checkReport ensures the passed report describes the passed blocks.
+	checkReport := func(report *database.IntegrityReport, checked uint64,
+		corrupt, missing, unindexed []int) {
+
+		if report.Interrupted {
+			t.Errorf("CheckIntegrity: unexpected interrupted report")
+		}
+		if report.CheckedBlocks != checked {
+			t.Errorf("CheckIntegrity: got %d checked blocks, want %d",
+				report.CheckedBlocks, checked)
+		}
+		tests := []struct {
+			name   string
+			hashes []chainhash.Hash
+			want   []int
+		}{
+			{"corrupt", report.CorruptBlocks, corrupt},
+			{"missing", report.MissingBlocks, missing},
+			{"unindexed", report.UnindexedBlocks, unindexed},
+		}
+		for _, test := range tests {
+			if len(test.hashes) != len(test.want) {
+				t.Errorf("CheckIntegrity: got %d %s blocks, want %d",
+					len(test.hashes), test.name, len(test.want))
+				continue
+			}
+			for i, idx := range test.want {
+				if test.hashes[i] != *blocks[idx].Hash() {
+					t.Errorf("CheckIntegrity: %s block #%d is %v, "+
+						"want %v", test.name, i, test.hashes[i],
+						blocks[idx].Hash())
+				}
+			}
+		}
+	}
+
+	report, err = idb.CheckIntegrity(false, nil)
+	if err != nil {
+		t.Errorf("CheckIntegrity: unexpected error: %v", err)
+		return
+	}
+	checkReport(report, 7, []int{5}, []int{3}, []int{7})
+	if report.Repaired {
+		t.Errorf("CheckIntegrity: repaired without request")
+	}
+
+	// Repair the database and ensure the block index no longer references
+	// the corrupt and missing blocks while the unindexed block is available.
+	report, err = idb.CheckIntegrity(true, nil)
+	if err != nil {
+		t.Errorf("CheckIntegrity: unexpected error: %v", err)
+		return
+	}
+	checkReport(report, 7, []int{5}, []int{3}, []int{7})
+	if !report.Repaired {
+		t.Errorf("CheckIntegrity: not repaired")
+	}
+	report, err = idb.CheckIntegrity(false, nil)
+	if err != nil {
+		t.Errorf("CheckIntegrity: unexpected error: %v", err)
+		return
+	}
+	checkReport(report, 8, nil, nil, nil)
+
+	err = idb.View(func(tx database.Tx) error {
+		for i, block := range blocks {
+			has, err := tx.HasBlock(block.Hash())
+			if err != nil {
+				return err
+			}
+			if want := i != 3 && i != 5; has != want {
+				t.Errorf("HasBlock #%d: got %v, want %v", i, has,
+					want)
+			}
+		}
+		_, err := tx.FetchBlock(blocks[7].Hash())
+		return err
+	})
+	if err != nil {
+		t.Errorf("View: unexpected error: %v", err)
+	}
+}
+
+// TestWarmCaches ensures warming the caches visits every block index entry and
+// reads the most recent flat block files while skipping any removed ones.
+func TestWarmCaches(t *testing.T) {
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Errorf("loadBlocks: Unexpected error: %v", err)
+		return
+	}
+	blocks = blocks[:10]
+
+	dbPath := filepath.Join(os.TempDir(), "ffldb-warmcaches")
+	_ = os.RemoveAll(dbPath)
+	idb, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+
+	// Store every block in its own flat file.
+	idb.(*db).store.maxBlockFileSize = 1
+	for _, block := range blocks {
+		err := idb.Update(func(tx database.Tx) error {
+			return tx.StoreBlock(block)
+		})
+		if err != nil {
+			idb.Close()
+			t.Errorf("StoreBlock: unexpected error: %v", err)
+			return
+		}
+	}
+	idb.Close()
+
+	// Reopening the database warms the caches.
+	idb, err = database.Open(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to open test database (%s) %v", dbType, err)
+		return
+	}
+	defer idb.Close()
+	pdb := idb.(*db)
+
+	entries, err := pdb.warmBlockIndex()
+	if err != nil {
+		t.Errorf("warmBlockIndex: unexpected error: %v", err)
+		return
+	}
+	if entries != len(blocks) {
+		t.Errorf("warmBlockIndex: got %d entries, want %d", entries,
+			len(blocks))
+	}
+
+	// fileSizes returns the total size of the flat block files starting
+	// with the passed one.
+	store := pdb.store
+	fileSizes := func(firstFileNum uint32) int64 {
+		var size int64
+		for fileNum := firstFileNum; fileNum <= store.writeCursor.curFileNum; fileNum++ {
+			st, err := os.Stat(blockFilePath(store.basePath, fileNum))
+			if err != nil {
+				t.Fatalf("Stat: unexpected error: %v", err)
+			}
+			size += st.Size()
+		}
+		return size
+	}
+	read, err := store.warmBlockFiles()
+	if err != nil {
+		t.Errorf("warmBlockFiles: unexpected error: %v", err)
+		return
+	}
+	if want := fileSizes(store.firstFileNum); read != want {
+		t.Errorf("warmBlockFiles: got %d bytes read, want %d", read,
+			want)
+	}
+
+	// Removed block files are skipped.
+	err = os.Remove(blockFilePath(store.basePath, store.firstFileNum))
+	if err != nil {
+		t.Errorf("Remove: unexpected error: %v", err)
+		return
+	}
+	read, err = store.warmBlockFiles()
+	if err != nil {
+		t.Errorf("warmBlockFiles: unexpected error: %v", err)
+		return
+	}
+	if want := fileSizes(store.firstFileNum + 1); read != want {
+		t.Errorf("warmBlockFiles: got %d bytes read after removing a "+
+			"file, want %d", read, want)
+	}
+}
@@ -204,6 +204,39 @@ type BlockRegion struct {
 	Len    uint32
 }
 
+// IntegrityReport describes the result of verifying the integrity of the
+// stored blocks with CheckIntegrity.
+type IntegrityReport struct {
+	// CheckedBlocks is the number of blocks in the block index whose data
+	// was verified successfully.
+	CheckedBlocks uint64
+
+	// PrunedBlocks is the number of blocks in the block index whose data
+	// has been pruned and thus could not be verified.
+	PrunedBlocks uint64
+
+	// CorruptBlocks houses the hashes of the blocks in the block index
+	// whose data fails verification, such as due to a checksum mismatch.
+	CorruptBlocks []chainhash.Hash
+
+	// MissingBlocks houses the hashes of the blocks in the block index
+	// whose data does not exist, such as due to a truncated block file.
+	MissingBlocks []chainhash.Hash
+
+	// UnindexedBlocks houses the hashes of valid blocks found in the
+	// stored block data that are not in the block index.
+	UnindexedBlocks []chainhash.Hash
+
+	// Repaired is set when the block index was rebuilt to remove the
+	// corrupt and missing blocks and add the unindexed blocks.
+	Repaired bool
+
+	// Interrupted is set when the check was interrupted before it
+	// completed, in which case the report is incomplete and nothing was
+	// repaired.
+	Interrupted bool
+}
+
 // Tx represents a database transaction.  It can either by read-only or
 // read-write.  The transaction provides a metadata bucket against which all
 // read and writes occur.
@@ -460,6 +493,58 @@ type DB interface {
 	// user-supplied function will result in a panic.
 	Update(fn func(tx Tx) error) error
 
+	// PruneBlocks deletes the stored data of blocks that were stored
+	// before the block identified by the passed hash, oldest first, until
+	// the total size of the remaining block data is at most targetSize
+	// bytes.  The data is deleted in the storage units of the backend, so
+	// more data than needed to reach the target may remain.  The block
+	// identified by the hash and all blocks stored after it are never
+	// deleted.  It returns the number of bytes freed.
+	//
+	// The metadata, including the headers of the blocks, is not modified.
+	// Thus, HasBlock continues to report pruned blocks and their headers
+	// can still be fetched, while fetching their data or regions of it
+	// returns ErrBlockPruned.
+	//
+	// The following errors are possible:
+	//   - ErrBlockNotFound if the block identified by the hash does not
+	//     exist
+	//   - ErrDbNotOpen if the database is not open
+	PruneBlocks(keep *chainhash.Hash, targetSize uint64) (uint64, error)
+
+	// ArchiveBlocks moves the stored data of blocks that were stored
+	// before the block identified by the passed hash to the cold storage
+	// configured for the backend, oldest first, so old blocks may be kept
+	// on slower and cheaper storage than recent ones.  The block
+	// identified by the hash and all blocks stored after it are never
+	// moved.  Reading moved blocks is transparent to callers.  It returns
+	// the number of bytes moved, which is zero when the backend has no
+	// cold storage configured.
+	//
+	// The following errors are possible:
+	//   - ErrBlockNotFound if the block identified by the hash does not
+	//     exist
+	//   - ErrDbNotOpen if the database is not open
+	ArchiveBlocks(keep *chainhash.Hash) (uint64, error)
+
+	// CheckIntegrity scans all stored block data, verifies the data of
+	// every block in the block index, and looks for valid blocks that are
+	// missing from the block index.  The results are returned as a report
+	// rather than as an error, so checking a corrupted database never
+	// fails due to the corruption itself.
+	//
+	// When repair is true and the check completes, the block index is
+	// rebuilt so blocks with corrupt or missing data, such as blocks in
+	// truncated files, are removed from it and the unindexed blocks are
+	// added to it, and the metadata storage is compacted afterwards.
+	//
+	// Closing the passed interrupt channel stops the check early, which
+	// is reported by the Interrupted field of the report.  It may be nil.
+	//
+	// The following errors are possible:
+	//   - ErrDbNotOpen if the database is not open
+	CheckIntegrity(repair bool, interrupt <-chan struct{}) (*IntegrityReport, error)
+
 	// Close cleanly shuts down the database and syncs all data.  It will
 	// block until all database transactions have been finalized (rolled
 	// back or committed).
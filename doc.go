@@ -19,6 +19,12 @@ parsed when hcashd starts up.  By default, the configuration file is located at
 on Windows.  The -C (--configfile) flag, as shown below, can be used to override
 this location.
 
+The default application data directory holding the configuration file, data,
+and logs may also be set with the HCASHD_APPDATA environment variable.  When it
+is not set, new installs on POSIX-style operating systems use
+$XDG_DATA_HOME/hcashd when XDG_DATA_HOME is set, and hcashd running as a Windows
+service under a system account uses %ProgramData%\Hcashd.
+
 Usage:
   hcashd [OPTIONS]
 
@@ -27,6 +33,17 @@ Application Options:
   -C, --configfile=         Path to configuration file
   -b, --datadir=            Directory to store data
       --logdir=             Directory to log output.
+      --logformat=          Format of the log output {text, json} -- The json
+                            format writes each entry as a JSON object with the
+                            time, level, subsystem and message along with the
+                            peer, hash and height the message refers to (text)
+      --logmaxsize=         Maximum size in MiB of a log file before it is
+                            rotated (10)
+      --logmaxrolls=        Maximum number of rotated log files to keep -- 0
+                            keeps all of them (3)
+      --logseparate=        Add a subsystem which is logged to a separate,
+                            independently rotated, log file named after it in
+                            the log directory instead of the main log file
   -a, --addpeer=            Add a peer to connect with at startup
       --connect=            Connect only to the specified peers at startup
       --nolisten            Disable listening for incoming connections -- NOTE:
@@ -47,16 +64,37 @@ Application Options:
   -P, --rpcpass=            Password for RPC connections
       --rpclimituser=       Username for limited RPC connections
       --rpclimitpass=       Password for limited RPC connections
+      --rpclimitmethod=     Add an RPC method to the methods available to
+                            limited RPC connections -- Replaces the default
+                            set of methods which do not change the state of
+                            the server when specified
       --rpclisten=          Add an interface/port to listen for RPC connections
                             (default port: 11009, testnet: 12009)
       --rpccert=            File containing the certificate file
       --rpckey=             File containing the certificate key
+      --rpcclientca=        File containing the certificates of the authorities
+                            which sign the client certificates accepted for RPC
+                            connections -- Clients presenting a valid
+                            certificate are authenticated as the admin user
+                            without a username and password
+      --attestationkey=     File containing the key used to sign key block
+                            attestations, which is generated when it does not
+                            exist (default: attestation.key in the data
+                            directory)
       --rpcmaxclients=      Max number of RPC clients for standard connections
                             (10)
       --rpcmaxwebsockets=   Max number of RPC websocket connections (25)
+      --rpcsse              Enable the server-sent events endpoint (/events) of
+                            the RPC server
+      --rpcsseorigin=       Add an origin allowed to make cross-origin requests
+                            to the server-sent events endpoint, or * to allow
+                            any origin
+      --rpcmaxsseclients=   Max number of RPC server-sent events connections
+                            (25)
       --norpc               Disable built-in RPC server -- NOTE: The RPC server
-                            is disabled by default if no rpcuser/rpcpass or
-                            rpclimituser/rpclimitpass is specified
+                            is disabled by default if no rpcuser/rpcpass,
+                            rpclimituser/rpclimitpass or rpcclientca is
+                            specified
       --notls               Disable TLS for the RPC server -- NOTE: This is only
                             allowed if the RPC server is bound to localhost
       --nodnsseed           Disable DNS seeding for peers
@@ -77,8 +115,30 @@ Application Options:
       --nocheckpoints       Disable built-in checkpoints.  Don't do this unless
                             you know what you're doing.
       --dbtype=             Database backend to use for the Block Chain (ffldb)
+      --dbcheck             Verify the integrity of all stored blocks, rebuild
+                            the block index to exclude corrupt or missing
+                            blocks, compact the database on start up and then
+                            exit.
+      --prune=              Delete the data of old blocks which are before the
+                            latest checkpoint until the block data is at most
+                            the given size in MiB -- Headers and the utxo set
+                            are retained (0: disabled, minimum 1024)
+      --prunedepth=         The number of key blocks below the best chain tip
+                            whose data is never pruned (2880)
+      --colddatadir=        Directory to move the data of old blocks to, such
+                            as one on slower and cheaper storage than the data
+                            directory -- Moved blocks are still served
+      --colddepth=          The number of key blocks below the best chain tip
+                            whose data is kept in the data directory when
+                            --colddatadir is set (8640)
+      --maxreorgdepth=      Reject blocks which would cause a reorganization
+                            that disconnects more than the given number of
+                            blocks from the main chain -- 0 to disable
       --profile=            Enable HTTP profiling on given port -- NOTE port
                             must be between 1024 and 65536
+      --metricslisten=      Add an interface:port to serve the Prometheus
+                            metrics endpoint (/metrics) on, which enables it --
+                            NOTE: The endpoint is not authenticated
       --cpuprofile=         Write CPU profile to the specified file
       --memprofile=         Write mem profile to the specified file
       --dumpblockchain=     Write blockchain as a gob-encoded map to the
@@ -96,15 +156,37 @@ Application Options:
       --limitfreerelay=     Limit relay of transactions with no transaction fee
                             to the given amount in thousands of bytes per
                             minute (15)
+      --limitpeerrelay=     Limit the transactions accepted from a single peer
+                            to the given amount in thousands of bytes per
+                            minute -- 0 to disable (1000)
+      --limitpeerfreerelay= Limit the free and low-fee transactions accepted
+                            from a single peer to the given amount in
+                            thousands of bytes per minute -- 0 to disable (5)
       --norelaypriority     Do not require free or low-fee transactions to have
                             high priority for relaying
       --maxorphantx=        Max number of orphan transactions to keep in memory
                             (1000)
+      --persistmempool      Save the transactions in the memory pool, except
+                            votes, to the data directory on shutdown and
+                            restore them on startup
+      --maxmempoolsize=     Maximum size in bytes of the transactions in the
+                            memory pool -- The transactions paying the lowest
+                            fee rates are evicted once it is exceeded -- 0 to
+                            disable (300000000)
       --generate            Generate (mine) bitcoins using the CPU
       --miningaddr=         Add the specified payment address to the list of
                             addresses to use for generated blocks -- At least
                             one address is required if the generate option is
                             set
+      --miningpayout=       Split the coinbase work reward of generated blocks
+                            across multiple addresses by weight -- Specify as
+                            address:weight, one address per option
+      --minercpubudget=     Tune the number of active CPU mining threads so
+                            hcashd uses at most the given percentage of the
+                            total CPU capacity -- 0 to disable
+      --cpuminerthreads=    The number of CPU mining threads to use unless the
+                            number is specified via the setgenerate RPC
+                            (default: 1)
       --blockminsize=       Mininum block size in bytes to be used when creating
                             a block
       --blockmaxsize=       Maximum block size in bytes to be used when creating
@@ -116,10 +198,27 @@ Application Options:
                             if there aren't enough voters
       --nominingstatesync   Disable synchronizing the mining state with other nodes
       --allowoldvotes       Enable the addition of very old votes to the mempool
+      --autorevoke          Automatically create and broadcast revocations for
+                            missed and expired tickets whose voting rights are
+                            held by a script registered via addrevocationscript
 
       --nopeerbloomfilters  Disable bloom filtering support.
+      --nolightblocks       Disable support for light blocks, which announce
+                            new blocks without the transactions peers already
+                            have
+      --minprotocolversion= Reject peers advertising a protocol version below
+                            the given version
       --sigcachemaxsize=    The maximum number of entries in the signature
                             verification cache.
+      --sigverifyconcurrency= The maximum number of goroutines used to
+                            validate the input scripts of a block (0: based
+                            on the number of processor cores)
+      --sighashoptimization EXPERIMENTAL: Reuse the cached transaction hash
+                            when verifying the SIGHASH_ALL signatures of
+                            blocks -- Do not enable when mining or running a
+                            wallet with the node
+      --checkduplicatehashes Reject blocks with transactions that overwrite
+                            unspent transactions with the same hash
       --blocksonly          Do not accept transactions from remote peers.
       --relaynonstd         Relay non-standard transactions regardless of the
                             default settings for the active network.
@@ -0,0 +1,93 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gcs
+
+import "io"
+
+// bitWriter writes bits to a byte slice, starting with the most significant
+// bit of each byte.
+type bitWriter struct {
+	bytes []byte
+	next  byte // mask of the next bit to write in the last byte
+}
+
+// writeBit appends a single bit.
+func (w *bitWriter) writeBit(bit bool) {
+	if w.next == 0 {
+		w.bytes = append(w.bytes, 0)
+		w.next = 1 << 7
+	}
+	if bit {
+		w.bytes[len(w.bytes)-1] |= w.next
+	}
+	w.next >>= 1
+}
+
+// writeNBits appends the nbits least significant bits of data, starting with
+// the most significant of them.
+func (w *bitWriter) writeNBits(data uint64, nbits uint) {
+	for nbits > 0 {
+		nbits--
+		w.writeBit(data>>nbits&1 == 1)
+	}
+}
+
+// bitReader reads bits from a byte slice written by a bitWriter.
+type bitReader struct {
+	bytes []byte
+	next  byte // mask of the next bit to read in the first byte
+}
+
+// newBitReader returns a bitReader positioned at the first bit of b.
+func newBitReader(b []byte) bitReader {
+	return bitReader{bytes: b, next: 1 << 7}
+}
+
+// readBit reads a single bit.  io.EOF is returned when no bits remain.
+func (r *bitReader) readBit() (bool, error) {
+	if len(r.bytes) == 0 {
+		return false, io.EOF
+	}
+	bit := r.bytes[0]&r.next != 0
+	r.next >>= 1
+	if r.next == 0 {
+		r.bytes = r.bytes[1:]
+		r.next = 1 << 7
+	}
+	return bit, nil
+}
+
+// readUnary reads a unary coded value, which is a run of set bits terminated
+// by an unset bit, and returns the number of set bits.
+func (r *bitReader) readUnary() (uint64, error) {
+	var n uint64
+	for {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if !bit {
+			return n, nil
+		}
+		n++
+	}
+}
+
+// readNBits reads nbits bits and returns them as the least significant bits
+// of the result.
+func (r *bitReader) readNBits(nbits uint) (uint64, error) {
+	var v uint64
+	for ; nbits > 0; nbits-- {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		v <<= 1
+		if bit {
+			v |= 1
+		}
+	}
+	return v, nil
+}
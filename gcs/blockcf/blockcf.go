@@ -0,0 +1,125 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package blockcf provides functions to build committed filters for blocks.
+
+Two filters are built for each block using the first 16 bytes of the block
+hash as the key.  The regular filter contains every outpoint spent by and
+every output script created by the transactions of both transaction trees, so
+a wallet can detect payments to its addresses and spends of its outputs.  The
+extended filter contains the hash of every transaction and every input
+signature script.
+
+Outpoints are added in their serialized form: the 32 byte transaction hash,
+the output index as a little-endian uint32, and the tree byte.
+*/
+package blockcf
+
+import (
+	"encoding/binary"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/gcs"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+// P is the collision probability used for block committed filters (2^-20).
+const P = 20
+
+// Key returns the key used to build the committed filters of the block with
+// the passed hash.
+func Key(hash *chainhash.Hash) [gcs.KeySize]byte {
+	var key [gcs.KeySize]byte
+	copy(key[:], hash[:])
+	return key
+}
+
+// OutPointBytes returns the serialized form of the passed outpoint that is
+// added to regular filters.
+func OutPointBytes(outPoint *wire.OutPoint) []byte {
+	b := make([]byte, chainhash.HashSize+5)
+	copy(b, outPoint.Hash[:])
+	binary.LittleEndian.PutUint32(b[chainhash.HashSize:], outPoint.Index)
+	b[chainhash.HashSize+4] = byte(outPoint.Tree)
+	return b
+}
+
+// isNullOutPoint returns whether the outpoint is the null outpoint referenced
+// by the inputs of coinbase and stakebase transactions, which do not spend an
+// existing output.
+func isNullOutPoint(outPoint *wire.OutPoint) bool {
+	return outPoint.Index == wire.MaxPrevOutIndex &&
+		outPoint.Hash == chainhash.Hash{}
+}
+
+// Regular builds a regular GCS filter from a block.
+func Regular(block *wire.MsgBlock) (*gcs.Filter, error) {
+	var data [][]byte
+	for _, txns := range [][]*wire.MsgTx{block.Transactions,
+		block.STransactions} {
+
+		for _, tx := range txns {
+			for _, txIn := range tx.TxIn {
+				if isNullOutPoint(&txIn.PreviousOutPoint) {
+					continue
+				}
+				data = append(data,
+					OutPointBytes(&txIn.PreviousOutPoint))
+			}
+			for _, txOut := range tx.TxOut {
+				if len(txOut.PkScript) != 0 {
+					data = append(data, txOut.PkScript)
+				}
+			}
+		}
+	}
+
+	blockHash := block.BlockHash()
+	return gcs.NewFilter(P, Key(&blockHash), data)
+}
+
+// Extended builds an extended GCS filter from a block.
+func Extended(block *wire.MsgBlock) (*gcs.Filter, error) {
+	var data [][]byte
+	for _, txns := range [][]*wire.MsgTx{block.Transactions,
+		block.STransactions} {
+
+		for _, tx := range txns {
+			txHash := tx.TxHash()
+			data = append(data, txHash[:])
+			for _, txIn := range tx.TxIn {
+				if isNullOutPoint(&txIn.PreviousOutPoint) {
+					continue
+				}
+				if len(txIn.SignatureScript) != 0 {
+					data = append(data, txIn.SignatureScript)
+				}
+			}
+		}
+	}
+
+	blockHash := block.BlockHash()
+	return gcs.NewFilter(P, Key(&blockHash), data)
+}
+
+// Build builds the filter of the passed type from a block.
+func Build(filterType wire.FilterType, block *wire.MsgBlock) (*gcs.Filter, error) {
+	switch filterType {
+	case wire.GCSFilterRegular:
+		return Regular(block)
+	case wire.GCSFilterExtended:
+		return Extended(block)
+	}
+	return nil, errUnknownFilterType(filterType)
+}
+
+// errUnknownFilterType is returned when a filter of an unsupported type is
+// requested.
+type errUnknownFilterType wire.FilterType
+
+// Error implements the error interface.
+func (e errUnknownFilterType) Error() string {
+	return "unknown filter type " + wire.FilterType(e).String()
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockcf
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+// TestBlockFilters ensures the regular and extended filters of a block
+// contain the expected members.
+func TestBlockFilters(t *testing.T) {
+	coinbase := wire.NewMsgTx()
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		SignatureScript:  []byte{0x51, 0x52},
+	})
+	coinbase.AddTxOut(wire.NewTxOut(5000, []byte{0x76, 0xa9, 0x01}))
+
+	spentOutPoint := wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 2}
+	spend := wire.NewMsgTx()
+	spend.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: spentOutPoint,
+		SignatureScript:  []byte{0x47, 0x30, 0x44},
+	})
+	spend.AddTxOut(wire.NewTxOut(1000, []byte{0xa9, 0x14, 0x02}))
+	spend.AddTxOut(wire.NewTxOut(0, nil))
+
+	block := &wire.MsgBlock{
+		Header:       wire.BlockHeader{Height: 1},
+		Transactions: []*wire.MsgTx{coinbase, spend},
+	}
+	blockHash := block.BlockHash()
+	key := Key(&blockHash)
+
+	regular, err := Build(wire.GCSFilterRegular, block)
+	if err != nil {
+		t.Fatalf("Regular: unexpected error %v", err)
+	}
+	if regular.N() != 3 {
+		t.Errorf("Regular: got %d members, want 3", regular.N())
+	}
+	for _, member := range [][]byte{OutPointBytes(&spentOutPoint),
+		{0x76, 0xa9, 0x01}, {0xa9, 0x14, 0x02}} {
+
+		if !regular.Match(key, member) {
+			t.Errorf("Regular: member %x not matched", member)
+		}
+	}
+	nullOutPoint := coinbase.TxIn[0].PreviousOutPoint
+	if regular.Match(key, OutPointBytes(&nullOutPoint)) {
+		t.Error("Regular: coinbase outpoint matched")
+	}
+
+	extended, err := Build(wire.GCSFilterExtended, block)
+	if err != nil {
+		t.Fatalf("Extended: unexpected error %v", err)
+	}
+	if extended.N() != 3 {
+		t.Errorf("Extended: got %d members, want 3", extended.N())
+	}
+	coinbaseHash := coinbase.TxHash()
+	spendHash := spend.TxHash()
+	for _, member := range [][]byte{coinbaseHash[:], spendHash[:],
+		{0x47, 0x30, 0x44}} {
+
+		if !extended.Match(key, member) {
+			t.Errorf("Extended: member %x not matched", member)
+		}
+	}
+
+	if _, err := Build(wire.FilterType(0xff), block); err == nil {
+		t.Error("Build: expected error for unknown filter type")
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package gcs provides an API for building and using Golomb-coded set filters.
+
+A Golomb-coded set (GCS) is a probabilistic data structure used to test set
+membership with a tunable false positive rate and no false negatives.  Each
+member is hashed with SipHash-2-4 under a 16 byte key, and the hashes of all N
+members are reduced modulo N*2^P, sorted, and stored as the Golomb-Rice coded
+differences between consecutive values.  The resulting filter is close to the
+theoretical minimum size for a false positive rate of 1/2^P.
+
+Filters are used by light clients to determine whether a block may contain
+transactions of interest without downloading it.  The blockcf subpackage
+builds the filters committed to for Hypercash blocks.
+
+The serialized form of a filter is the coded data.  Since the data does not
+encode the number of members, NBytes and FromNBytes provide a form prefixed by
+N as a big-endian uint32.
+*/
+package gcs
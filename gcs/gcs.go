@@ -0,0 +1,255 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gcs
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+const (
+	// KeySize is the size of the key used to hash the members of a
+	// filter.
+	KeySize = 16
+
+	// MaxP is the maximum supported false positive rate parameter.
+	MaxP = 32
+)
+
+var (
+	// ErrNTooBig signifies that the filter can't handle N items.
+	ErrNTooBig = errors.New("N is too big to fit in uint32")
+
+	// ErrPTooBig signifies that the filter can't handle 1/2**P collision
+	// probability.
+	ErrPTooBig = errors.New("P is too big")
+
+	// ErrMisserialized signifies a filter was misserialized and is missing
+	// the N prefix.
+	ErrMisserialized = errors.New("misserialized filter")
+)
+
+// uint64s implements sort.Interface for a slice of uint64 values.
+type uint64s []uint64
+
+func (s uint64s) Len() int           { return len(s) }
+func (s uint64s) Less(i, j int) bool { return s[i] < s[j] }
+func (s uint64s) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// Filter describes an immutable filter that can be built from a set of data
+// elements, serialized, deserialized, and queried in a thread-safe manner.
+// The serialized form is compressed as a Golomb Coded Set (GCS), but does
+// not include N, so N must be stored separately or the NBytes form used.
+type Filter struct {
+	n          uint32
+	p          uint8
+	modulusNP  uint64
+	filterData []byte
+}
+
+// NewFilter builds a new GCS filter with the collision probability of
+// 1/(2**P), key key, and including every []byte in data as a member of the
+// set.
+func NewFilter(P uint8, key [KeySize]byte, data [][]byte) (*Filter, error) {
+	// Some initial parameter checks: make sure we have data from which to
+	// build the filter, and make sure our parameters will fit the hash
+	// function we're using.
+	if uint64(len(data)) > math.MaxUint32 {
+		return nil, ErrNTooBig
+	}
+	if P > MaxP {
+		return nil, ErrPTooBig
+	}
+
+	f := &Filter{
+		n: uint32(len(data)),
+		p: P,
+	}
+	f.modulusNP = uint64(f.n) << P
+	if f.n == 0 {
+		return f, nil
+	}
+
+	// Reduce the hash of each member to the range of the filter and sort
+	// the results.
+	k0 := binary.LittleEndian.Uint64(key[0:8])
+	k1 := binary.LittleEndian.Uint64(key[8:16])
+	values := make([]uint64, 0, len(data))
+	for _, d := range data {
+		values = append(values, siphash(k0, k1, d)%f.modulusNP)
+	}
+	sort.Sort(uint64s(values))
+
+	// Write the sorted values as the Golomb-Rice coded differences between
+	// consecutive values.  The quotient is unary coded and the remainder
+	// is written in P bits.
+	var w bitWriter
+	var lastValue uint64
+	for _, v := range values {
+		delta := v - lastValue
+		for q := delta >> P; q > 0; q-- {
+			w.writeBit(true)
+		}
+		w.writeBit(false)
+		w.writeNBits(delta, uint(P))
+		lastValue = v
+	}
+	f.filterData = w.bytes
+
+	return f, nil
+}
+
+// FromBytes deserializes a GCS filter from a known N, P, and serialized
+// filter as returned by Bytes().
+func FromBytes(N uint32, P uint8, d []byte) (*Filter, error) {
+	if P > MaxP {
+		return nil, ErrPTooBig
+	}
+
+	f := &Filter{
+		n:          N,
+		p:          P,
+		modulusNP:  uint64(N) << P,
+		filterData: make([]byte, len(d)),
+	}
+	copy(f.filterData, d)
+	return f, nil
+}
+
+// FromNBytes deserializes a GCS filter from a known P, and serialized N and
+// filter as returned by NBytes().
+func FromNBytes(P uint8, d []byte) (*Filter, error) {
+	if len(d) < 4 {
+		return nil, ErrMisserialized
+	}
+	return FromBytes(binary.BigEndian.Uint32(d[:4]), P, d[4:])
+}
+
+// Bytes returns the serialized format of the GCS filter, which does not
+// include N.
+func (f *Filter) Bytes() []byte {
+	filterData := make([]byte, len(f.filterData))
+	copy(filterData, f.filterData)
+	return filterData
+}
+
+// NBytes returns the serialized format of the GCS filter with N prefixed as a
+// big-endian uint32.
+func (f *Filter) NBytes() []byte {
+	filterData := make([]byte, 4+len(f.filterData))
+	binary.BigEndian.PutUint32(filterData, f.n)
+	copy(filterData[4:], f.filterData)
+	return filterData
+}
+
+// P returns the filter's collision probability as a negative power of 2 (that
+// is, a collision probability of `1/2**20` is represented as 20).
+func (f *Filter) P() uint8 {
+	return f.p
+}
+
+// N returns the size of the data set used to build the filter.
+func (f *Filter) N() uint32 {
+	return f.n
+}
+
+// readValueDelta reads the next Golomb-Rice coded difference from r.
+func (f *Filter) readValueDelta(r *bitReader) (uint64, error) {
+	q, err := r.readUnary()
+	if err != nil {
+		return 0, err
+	}
+	rem, err := r.readNBits(uint(f.p))
+	if err != nil {
+		return 0, err
+	}
+	return q<<f.p | rem, nil
+}
+
+// Match checks whether a []byte value is likely (within collision
+// probability) to be a member of the set represented by the filter.
+func (f *Filter) Match(key [KeySize]byte, data []byte) bool {
+	if f.n == 0 {
+		return false
+	}
+
+	k0 := binary.LittleEndian.Uint64(key[0:8])
+	k1 := binary.LittleEndian.Uint64(key[8:16])
+	term := siphash(k0, k1, data) % f.modulusNP
+
+	r := newBitReader(f.filterData)
+	var value uint64
+	for i := uint32(0); i < f.n; i++ {
+		delta, err := f.readValueDelta(&r)
+		if err != nil {
+			return false
+		}
+		value += delta
+		if value >= term {
+			return value == term
+		}
+	}
+	return false
+}
+
+// MatchAny checks whether any []byte value is likely (within collision
+// probability) to be a member of the set represented by the filter faster
+// than calling Match() for each value individually.
+func (f *Filter) MatchAny(key [KeySize]byte, data [][]byte) bool {
+	if f.n == 0 || len(data) == 0 {
+		return false
+	}
+
+	k0 := binary.LittleEndian.Uint64(key[0:8])
+	k1 := binary.LittleEndian.Uint64(key[8:16])
+	terms := make([]uint64, 0, len(data))
+	for _, d := range data {
+		terms = append(terms, siphash(k0, k1, d)%f.modulusNP)
+	}
+	sort.Sort(uint64s(terms))
+
+	// Walk the sorted filter values and terms in lockstep, returning as
+	// soon as a match is found.
+	r := newBitReader(f.filterData)
+	var value uint64
+	i := 0
+	for n := uint32(0); n < f.n; n++ {
+		delta, err := f.readValueDelta(&r)
+		if err != nil {
+			return false
+		}
+		value += delta
+		for terms[i] < value {
+			i++
+			if i == len(terms) {
+				return false
+			}
+		}
+		if terms[i] == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Hash returns the BLAKE256 hash of the filter in its NBytes form.
+func (f *Filter) Hash() chainhash.Hash {
+	return chainhash.HashH(f.NBytes())
+}
+
+// MakeHeaderForFilter returns the filter header of the passed filter, which
+// commits to the filter and, through the previous header, to the filters of
+// all earlier blocks.
+func MakeHeaderForFilter(filter *Filter, prevHeader *chainhash.Hash) chainhash.Hash {
+	filterHash := filter.Hash()
+	var buf [chainhash.HashSize * 2]byte
+	copy(buf[:chainhash.HashSize], filterHash[:])
+	copy(buf[chainhash.HashSize:], prevHeader[:])
+	return chainhash.HashH(buf[:])
+}
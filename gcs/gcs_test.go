@@ -0,0 +1,147 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gcs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// TestSipHash ensures the SipHash-2-4 implementation matches the reference
+// test vectors for the key 00..0f and messages 00..(n-1).
+func TestSipHash(t *testing.T) {
+	tests := []struct {
+		n    int
+		want uint64
+	}{
+		{0, 0x726fdb47dd0e0e31},
+		{1, 0x74f839c593dc67fd},
+		{7, 0xab0200f58b01d137},
+		{8, 0x93f5f5799a932462},
+		{15, 0xa129ca6149be45e5},
+		{63, 0x958a324ceb064572},
+	}
+
+	var key [KeySize]byte
+	for i := range key {
+		key[i] = byte(i)
+	}
+	k0 := binary.LittleEndian.Uint64(key[0:8])
+	k1 := binary.LittleEndian.Uint64(key[8:16])
+	for _, test := range tests {
+		msg := make([]byte, test.n)
+		for i := range msg {
+			msg[i] = byte(i)
+		}
+		if got := siphash(k0, k1, msg); got != test.want {
+			t.Errorf("siphash len %d: got %x, want %x", test.n, got,
+				test.want)
+		}
+	}
+}
+
+// TestFilter ensures filters match all of their members, rarely match other
+// data, and survive serialization.
+func TestFilter(t *testing.T) {
+	const P = 20
+	var key [KeySize]byte
+	copy(key[:], "hypercash filter")
+
+	members := make([][]byte, 0, 500)
+	for i := 0; i < cap(members); i++ {
+		members = append(members, []byte{byte(i), byte(i >> 8), 0x01})
+	}
+	// Duplicate members must not break matching of later members.
+	members = append(members, members[0])
+
+	f, err := NewFilter(P, key, members)
+	if err != nil {
+		t.Fatalf("NewFilter: unexpected error %v", err)
+	}
+	if f.N() != uint32(len(members)) || f.P() != P {
+		t.Fatalf("unexpected N %d or P %d", f.N(), f.P())
+	}
+
+	// Deserialize the filter in both forms.
+	f2, err := FromBytes(f.N(), P, f.Bytes())
+	if err != nil {
+		t.Fatalf("FromBytes: unexpected error %v", err)
+	}
+	f3, err := FromNBytes(P, f.NBytes())
+	if err != nil {
+		t.Fatalf("FromNBytes: unexpected error %v", err)
+	}
+	if !bytes.Equal(f2.NBytes(), f.NBytes()) || f3.Hash() != f.Hash() {
+		t.Fatal("deserialized filter does not match")
+	}
+
+	for _, filter := range []*Filter{f, f2, f3} {
+		for i, member := range members {
+			if !filter.Match(key, member) {
+				t.Fatalf("Match: member %d not matched", i)
+			}
+		}
+	}
+
+	// Non-members must rarely match.  The false positive rate is 1/2^20,
+	// so any match among a few thousand items is effectively a failure.
+	nonMembers := make([][]byte, 0, 2000)
+	for i := 0; i < cap(nonMembers); i++ {
+		nonMember := []byte{byte(i), byte(i >> 8), 0x02}
+		if f.Match(key, nonMember) {
+			t.Errorf("Match: non-member %x matched", nonMember)
+		}
+		nonMembers = append(nonMembers, nonMember)
+	}
+	if f.MatchAny(key, nonMembers) {
+		t.Error("MatchAny: matched only non-members")
+	}
+	if !f.MatchAny(key, append(nonMembers, members[250])) {
+		t.Error("MatchAny: member not matched")
+	}
+
+	// A different key must produce a different filter.
+	var otherKey [KeySize]byte
+	f4, err := NewFilter(P, otherKey, members)
+	if err != nil {
+		t.Fatalf("NewFilter: unexpected error %v", err)
+	}
+	if f4.Hash() == f.Hash() {
+		t.Error("filters with different keys have the same hash")
+	}
+}
+
+// TestFilterEdgeCases ensures empty filters and invalid parameters are
+// handled.
+func TestFilterEdgeCases(t *testing.T) {
+	var key [KeySize]byte
+	f, err := NewFilter(20, key, nil)
+	if err != nil {
+		t.Fatalf("NewFilter: unexpected error %v", err)
+	}
+	if f.Match(key, []byte{0x00}) || f.MatchAny(key, [][]byte{{0x00}}) {
+		t.Error("empty filter matched")
+	}
+	if !bytes.Equal(f.NBytes(), []byte{0, 0, 0, 0}) {
+		t.Errorf("unexpected empty filter serialization %x", f.NBytes())
+	}
+
+	if _, err := NewFilter(MaxP+1, key, nil); err != ErrPTooBig {
+		t.Errorf("NewFilter: got %v, want %v", err, ErrPTooBig)
+	}
+	if _, err := FromNBytes(20, []byte{0x00}); err != ErrMisserialized {
+		t.Errorf("FromNBytes: got %v, want %v", err, ErrMisserialized)
+	}
+
+	// Truncated filter data must not match rather than panic.
+	f, err = FromBytes(10, 20, []byte{0xff})
+	if err != nil {
+		t.Fatalf("FromBytes: unexpected error %v", err)
+	}
+	if f.Match(key, []byte{0x00}) {
+		t.Error("truncated filter matched")
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gcs
+
+import "encoding/binary"
+
+// rotl returns x rotated left by b bits.
+func rotl(x uint64, b uint) uint64 {
+	return x<<b | x>>(64-b)
+}
+
+// siphash returns the SipHash-2-4 of p keyed by the 128-bit key formed by k0
+// and k1.
+func siphash(k0, k1 uint64, p []byte) uint64 {
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+
+	round := func() {
+		v0 += v1
+		v1 = rotl(v1, 13)
+		v1 ^= v0
+		v0 = rotl(v0, 32)
+		v2 += v3
+		v3 = rotl(v3, 16)
+		v3 ^= v2
+		v0 += v3
+		v3 = rotl(v3, 21)
+		v3 ^= v0
+		v2 += v1
+		v1 = rotl(v1, 17)
+		v1 ^= v2
+		v2 = rotl(v2, 32)
+	}
+
+	// Compress all full 8 byte blocks.
+	b := uint64(len(p)) << 56
+	for ; len(p) >= 8; p = p[8:] {
+		m := binary.LittleEndian.Uint64(p)
+		v3 ^= m
+		round()
+		round()
+		v0 ^= m
+	}
+
+	// Compress the final block, which holds the remaining bytes and the
+	// low byte of the message length in its most significant byte.
+	var last [8]byte
+	copy(last[:], p)
+	b |= binary.LittleEndian.Uint64(last[:])
+	v3 ^= b
+	round()
+	round()
+	v0 ^= b
+
+	// Finalize.
+	v2 ^= 0xff
+	round()
+	round()
+	round()
+	round()
+	return v0 ^ v1 ^ v2 ^ v3
+}
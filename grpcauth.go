@@ -0,0 +1,207 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// grpcRootKeySize is the size of the root key the gRPC tokens are
+	// derived from.
+	grpcRootKeySize = 32
+
+	// grpcRootKeyFilename is the name of the file the root key is stored
+	// in.  Removing it revokes all tokens.
+	grpcRootKeyFilename = "root.key"
+
+	// grpcAdminTokenFilename and grpcReadOnlyTokenFilename are the names of
+	// the files the tokens with full and with read-only access are written
+	// to.
+	grpcAdminTokenFilename    = "admin.token"
+	grpcReadOnlyTokenFilename = "readonly.token"
+
+	// grpcTokenMetadataKey is the gRPC request metadata key clients pass
+	// their token with.
+	grpcTokenMetadataKey = "token"
+
+	// grpcTokenID is the identifier of the tokens issued by the server.
+	grpcTokenID = "hcashd"
+)
+
+// Caveats restrict the access granted by a gRPC token.
+const (
+	// grpcCaveatReadOnly restricts a token to methods that do not modify
+	// the state of the server.
+	grpcCaveatReadOnly = "readonly"
+
+	// grpcCaveatExpiresPrefix is the prefix of caveats which restrict a
+	// token to be used before the Unix time following the prefix.
+	grpcCaveatExpiresPrefix = "expires="
+)
+
+// grpcToken is a macaroon-style bearer token used to authenticate gRPC
+// clients.  The signature of a token is the HMAC of its identifier keyed by
+// the root key, which is chained through the HMACs of all of its caveats.
+// Anybody holding a token can therefore attenuate it by adding caveats, such
+// as to create a read-only or expiring token for a less trusted client, but
+// only the server can verify the token or remove any of its caveats.
+type grpcToken struct {
+	id      []byte
+	caveats []string
+	sig     []byte
+}
+
+// newGRPCToken returns a new token without caveats with the passed identifier
+// which is signed by the passed root key.
+func newGRPCToken(rootKey, id []byte) *grpcToken {
+	mac := hmac.New(sha256.New, rootKey)
+	mac.Write(id)
+	return &grpcToken{id: id, sig: mac.Sum(nil)}
+}
+
+// addCaveat restricts the token by the passed caveat.
+func (t *grpcToken) addCaveat(caveat string) {
+	mac := hmac.New(sha256.New, t.sig)
+	mac.Write([]byte(caveat))
+	t.caveats = append(t.caveats, caveat)
+	t.sig = mac.Sum(nil)
+}
+
+// String returns the token encoded as the URL-safe base64 encodings of its
+// identifier, caveats, and signature separated by periods.
+func (t *grpcToken) String() string {
+	parts := make([]string, 0, len(t.caveats)+2)
+	parts = append(parts, base64.RawURLEncoding.EncodeToString(t.id))
+	for _, caveat := range t.caveats {
+		parts = append(parts,
+			base64.RawURLEncoding.EncodeToString([]byte(caveat)))
+	}
+	parts = append(parts, base64.RawURLEncoding.EncodeToString(t.sig))
+	return strings.Join(parts, ".")
+}
+
+// parseGRPCToken decodes a token encoded by the String method.
+func parseGRPCToken(s string) (*grpcToken, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return nil, errors.New("malformed token")
+	}
+
+	decoded := make([][]byte, len(parts))
+	for i, part := range parts {
+		b, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return nil, errors.New("malformed token")
+		}
+		decoded[i] = b
+	}
+
+	t := &grpcToken{
+		id:  decoded[0],
+		sig: decoded[len(decoded)-1],
+	}
+	for _, caveat := range decoded[1 : len(decoded)-1] {
+		t.caveats = append(t.caveats, string(caveat))
+	}
+	return t, nil
+}
+
+// verify ensures the token was derived from the passed root key and that all
+// of its caveats are satisfied at the passed time for a method which modifies
+// the state of the server when write is true.  Unknown caveats are never
+// satisfied.
+func (t *grpcToken) verify(rootKey []byte, now time.Time, write bool) error {
+	expected := newGRPCToken(rootKey, t.id)
+	for _, caveat := range t.caveats {
+		expected.addCaveat(caveat)
+	}
+	if !hmac.Equal(expected.sig, t.sig) {
+		return errors.New("invalid token signature")
+	}
+
+	for _, caveat := range t.caveats {
+		switch {
+		case caveat == grpcCaveatReadOnly:
+			if write {
+				return errors.New("token is read-only")
+			}
+
+		case strings.HasPrefix(caveat, grpcCaveatExpiresPrefix):
+			expires, err := strconv.ParseInt(
+				caveat[len(grpcCaveatExpiresPrefix):], 10, 64)
+			if err != nil {
+				return fmt.Errorf("malformed caveat %q", caveat)
+			}
+			if now.Unix() >= expires {
+				return errors.New("token expired")
+			}
+
+		default:
+			return fmt.Errorf("unknown caveat %q", caveat)
+		}
+	}
+
+	return nil
+}
+
+// loadGRPCRootKey loads the root key of the gRPC tokens from the passed
+// directory, generating a new one when it does not exist yet, and writes the
+// admin and read-only tokens derived from it to the directory.
+func loadGRPCRootKey(dir string) ([]byte, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	keyFile := filepath.Join(dir, grpcRootKeyFilename)
+	rootKey, err := ioutil.ReadFile(keyFile)
+	switch {
+	case os.IsNotExist(err):
+		rpcsLog.Infof("Generating gRPC root key")
+		rootKey = make([]byte, grpcRootKeySize)
+		if _, err := rand.Read(rootKey); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(keyFile, rootKey, 0600); err != nil {
+			return nil, err
+		}
+
+	case err != nil:
+		return nil, err
+
+	case len(rootKey) != grpcRootKeySize:
+		return nil, fmt.Errorf("gRPC root key %s has an invalid size of "+
+			"%d bytes", keyFile, len(rootKey))
+	}
+
+	// The tokens are derived deterministically from the root key, so they
+	// are the same as the ones written on previous starts.
+	adminToken := newGRPCToken(rootKey, []byte(grpcTokenID))
+	err = ioutil.WriteFile(filepath.Join(dir, grpcAdminTokenFilename),
+		[]byte(adminToken.String()), 0600)
+	if err != nil {
+		return nil, err
+	}
+	readOnlyToken := newGRPCToken(rootKey, []byte(grpcTokenID))
+	readOnlyToken.addCaveat(grpcCaveatReadOnly)
+	err = ioutil.WriteFile(filepath.Join(dir, grpcReadOnlyTokenFilename),
+		[]byte(readOnlyToken.String()), 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return rootKey, nil
+}
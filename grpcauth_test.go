@@ -0,0 +1,77 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestGRPCToken ensures gRPC tokens survive encoding, can be attenuated by
+// adding caveats but not by removing them, and are only verified when all of
+// their caveats are satisfied.
+func TestGRPCToken(t *testing.T) {
+	rootKey := bytes.Repeat([]byte{0x01}, grpcRootKeySize)
+	otherKey := bytes.Repeat([]byte{0x02}, grpcRootKeySize)
+	now := time.Unix(1500000000, 0)
+
+	admin := newGRPCToken(rootKey, []byte(grpcTokenID))
+	readOnly := newGRPCToken(rootKey, []byte(grpcTokenID))
+	readOnly.addCaveat(grpcCaveatReadOnly)
+	expiring := newGRPCToken(rootKey, []byte(grpcTokenID))
+	expiring.addCaveat(grpcCaveatExpiresPrefix +
+		strconv.FormatInt(now.Unix()+60, 10))
+	unknown := newGRPCToken(rootKey, []byte(grpcTokenID))
+	unknown.addCaveat("ip=127.0.0.1")
+
+	// Removing the caveat of the read-only token must invalidate it.
+	stripped := *readOnly
+	stripped.caveats = nil
+
+	tests := []struct {
+		name    string
+		token   *grpcToken
+		rootKey []byte
+		now     time.Time
+		write   bool
+		valid   bool
+	}{
+		{"admin read", admin, rootKey, now, false, true},
+		{"admin write", admin, rootKey, now, true, true},
+		{"wrong root key", admin, otherKey, now, false, false},
+		{"read-only read", readOnly, rootKey, now, false, true},
+		{"read-only write", readOnly, rootKey, now, true, false},
+		{"stripped caveat", &stripped, rootKey, now, true, false},
+		{"not expired", expiring, rootKey, now.Add(59 * time.Second),
+			true, true},
+		{"expired", expiring, rootKey, now.Add(60 * time.Second),
+			false, false},
+		{"unknown caveat", unknown, rootKey, now, false, false},
+	}
+
+	for _, test := range tests {
+		token, err := parseGRPCToken(test.token.String())
+		if err != nil {
+			t.Errorf("%s: failed to parse token: %v", test.name, err)
+			continue
+		}
+		err = token.verify(test.rootKey, test.now, test.write)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: token was verified", test.name)
+		}
+	}
+
+	malformed := []string{"", "aGNhc2hk", "aGNhc2hk.!!!"}
+	for _, s := range malformed {
+		if _, err := parseGRPCToken(s); err == nil {
+			t.Errorf("parsed malformed token %q", s)
+		}
+	}
+}
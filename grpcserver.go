@@ -0,0 +1,564 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/database"
+	"github.com/HcashOrg/hcashd/mempool"
+	pb "github.com/HcashOrg/hcashd/rpc/hcashdrpc"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// grpcStreamBufferSize is the number of notifications that may be queued for a
+// gRPC notification stream before it is considered too slow and closed.
+const grpcStreamBufferSize = 100
+
+// grpcWriteMethods are the gRPC methods which modify the state of the server
+// and therefore may not be called with a read-only token.
+var grpcWriteMethods = map[string]struct{}{
+	"/hcashdrpc.TransactionService/PublishTransaction": {},
+}
+
+// grpcNotifier relays notifications to the gRPC notification streams of one
+// kind.  Notifications are queued for every stream without blocking, so a slow
+// client can not delay the block manager or the other clients.
+type grpcNotifier struct {
+	mtx     sync.Mutex
+	streams map[chan interface{}]struct{}
+}
+
+// newGRPCNotifier returns a new notifier without streams.
+func newGRPCNotifier() *grpcNotifier {
+	return &grpcNotifier{streams: make(map[chan interface{}]struct{})}
+}
+
+// subscribe registers a new stream and returns the channel its notifications
+// are queued on, which is closed when the stream falls too far behind.  Nil is
+// returned when the passed maximum number of streams already exist among all
+// notifiers sharing the passed counter.
+//
+// This function is safe for concurrent access.
+func (n *grpcNotifier) subscribe(count *int32, max int) chan interface{} {
+	if atomic.AddInt32(count, 1) > int32(max) {
+		atomic.AddInt32(count, -1)
+		return nil
+	}
+
+	c := make(chan interface{}, grpcStreamBufferSize)
+	n.mtx.Lock()
+	n.streams[c] = struct{}{}
+	n.mtx.Unlock()
+	return c
+}
+
+// unsubscribe unregisters the stream with the passed channel.
+//
+// This function is safe for concurrent access.
+func (n *grpcNotifier) unsubscribe(c chan interface{}, count *int32) {
+	n.mtx.Lock()
+	if _, ok := n.streams[c]; ok {
+		delete(n.streams, c)
+		close(c)
+	}
+	n.mtx.Unlock()
+	atomic.AddInt32(count, -1)
+}
+
+// notify queues the passed notification for all streams.  Streams whose queue
+// is full are closed.
+//
+// This function is safe for concurrent access.
+func (n *grpcNotifier) notify(ntfn interface{}) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	for c := range n.streams {
+		select {
+		case c <- ntfn:
+		default:
+			rpcsLog.Debugf("Closing slow gRPC notification stream")
+			delete(n.streams, c)
+			close(c)
+		}
+	}
+}
+
+// grpcServer provides typed access to the chain, the memory pool, and the
+// ticket pool over gRPC.  It implements the services defined by the hcashdrpc
+// package.
+type grpcServer struct {
+	started  int32
+	shutdown int32
+
+	server     *server
+	chain      *blockchain.BlockChain
+	rootKey    []byte
+	listeners  []net.Listener
+	grpcServer *grpc.Server
+
+	blockNtfns *grpcNotifier
+	txNtfns    *grpcNotifier
+	numStreams int32
+	wg         sync.WaitGroup
+	quit       chan struct{}
+}
+
+// newGRPCServer returns a new gRPC server listening on the passed addresses
+// which serves the passed server.  It uses the TLS certificate of the RPC
+// server, generating it if needed, and authenticates clients with tokens
+// derived from the root key in the token directory.
+func newGRPCServer(listenAddrs []string, s *server) (*grpcServer, error) {
+	if !fileExists(cfg.RPCKey) && !fileExists(cfg.RPCCert) {
+		err := genCertPair(cfg.RPCCert, cfg.RPCKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	keypair, err := tls.LoadX509KeyPair(cfg.RPCCert, cfg.RPCKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{keypair},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	rootKey, err := loadGRPCRootKey(cfg.GRPCTokenDir)
+	if err != nil {
+		return nil, err
+	}
+
+	ipv4ListenAddrs, ipv6ListenAddrs, _, err := parseListeners(listenAddrs)
+	if err != nil {
+		return nil, err
+	}
+	listeners := make([]net.Listener, 0,
+		len(ipv6ListenAddrs)+len(ipv4ListenAddrs))
+	for _, addr := range ipv4ListenAddrs {
+		listener, err := net.Listen("tcp4", addr)
+		if err != nil {
+			rpcsLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	for _, addr := range ipv6ListenAddrs {
+		listener, err := net.Listen("tcp6", addr)
+		if err != nil {
+			rpcsLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		return nil, errors.New("gRPC: no valid listen address")
+	}
+
+	g := &grpcServer{
+		server:     s,
+		chain:      s.blockManager.chain,
+		rootKey:    rootKey,
+		listeners:  listeners,
+		blockNtfns: newGRPCNotifier(),
+		txNtfns:    newGRPCNotifier(),
+		quit:       make(chan struct{}),
+	}
+	g.grpcServer = grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.UnaryInterceptor(g.unaryInterceptor),
+		grpc.StreamInterceptor(g.streamInterceptor),
+	)
+	pb.RegisterBlockServiceServer(g.grpcServer, g)
+	pb.RegisterTransactionServiceServer(g.grpcServer, g)
+	pb.RegisterMempoolServiceServer(g.grpcServer, g)
+	pb.RegisterStakeServiceServer(g.grpcServer, g)
+
+	return g, nil
+}
+
+// Start begins serving gRPC requests on all listeners.
+func (g *grpcServer) Start() {
+	if atomic.AddInt32(&g.started, 1) != 1 {
+		return
+	}
+
+	rpcsLog.Trace("Starting gRPC server")
+	for _, listener := range g.listeners {
+		g.wg.Add(1)
+		go func(listener net.Listener) {
+			rpcsLog.Infof("gRPC server listening on %s", listener.Addr())
+			g.grpcServer.Serve(listener)
+			rpcsLog.Tracef("gRPC listener done for %s", listener.Addr())
+			g.wg.Done()
+		}(listener)
+	}
+}
+
+// Stop closes all notification streams and listeners and waits for the
+// requests in progress to finish.
+func (g *grpcServer) Stop() {
+	if atomic.AddInt32(&g.shutdown, 1) != 1 {
+		rpcsLog.Infof("gRPC server is already in the process of shutting " +
+			"down")
+		return
+	}
+
+	rpcsLog.Warnf("gRPC server shutting down")
+	close(g.quit)
+	g.grpcServer.GracefulStop()
+	g.wg.Wait()
+	rpcsLog.Infof("gRPC server shutdown complete")
+}
+
+// authorize ensures the request for the passed method carries a valid token in
+// its metadata which grants access to the method.
+func (g *grpcServer) authorize(ctx context.Context, method string) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md[grpcTokenMetadataKey]) != 1 {
+		return status.Error(codes.Unauthenticated,
+			"missing authentication token")
+	}
+	token, err := parseGRPCToken(md[grpcTokenMetadataKey][0])
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	_, write := grpcWriteMethods[method]
+	if err := token.verify(g.rootKey, time.Now(), write); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+// unaryInterceptor authorizes unary requests before they are handled.
+func (g *grpcServer) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := g.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor authorizes streaming requests before they are handled.
+func (g *grpcServer) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := g.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// notifyBlock notifies the block notification streams about the
+// passed block which was connected to or disconnected from the main chain.
+//
+// This function is safe for concurrent access.
+func (g *grpcServer) notifyBlock(block *hcashutil.Block, disconnected bool) {
+	header, err := block.MsgBlock().Header.Bytes()
+	if err != nil {
+		rpcsLog.Errorf("Failed to serialize header of block %v: %v",
+			block.Hash(), err)
+		return
+	}
+	g.blockNtfns.notify(&pb.BlockNotificationsResponse{
+		Hash:         block.Hash()[:],
+		Height:       block.Height(),
+		Header:       header,
+		Disconnected: disconnected,
+	})
+}
+
+// notifyTxAccepted notifies the transaction notification streams about the
+// passed transaction which was accepted to the memory pool.
+//
+// This function is safe for concurrent access.
+func (g *grpcServer) notifyTxAccepted(tx *hcashutil.Tx) {
+	serializedTx, err := tx.MsgTx().Bytes()
+	if err != nil {
+		rpcsLog.Errorf("Failed to serialize transaction %v: %v",
+			tx.Hash(), err)
+		return
+	}
+	g.txNtfns.notify(&pb.TransactionNotificationsResponse{
+		TransactionHash: tx.Hash()[:],
+		Transaction:     serializedTx,
+	})
+}
+
+// streamNotifications sends the notifications of the passed notifier with the
+// passed send function until the client goes away, falls too far behind, or
+// the server shuts down.
+func (g *grpcServer) streamNotifications(ctx context.Context, n *grpcNotifier, send func(ntfn interface{}) error) error {
+	c := n.subscribe(&g.numStreams, cfg.GRPCMaxStreams)
+	if c == nil {
+		return status.Error(codes.ResourceExhausted,
+			"too many notification streams")
+	}
+	defer n.unsubscribe(c, &g.numStreams)
+
+	for {
+		select {
+		case ntfn, ok := <-c:
+			if !ok {
+				return status.Error(codes.ResourceExhausted,
+					"notification stream fell too far behind")
+			}
+			if err := send(ntfn); err != nil {
+				return err
+			}
+
+		case <-ctx.Done():
+			return nil
+
+		case <-g.quit:
+			return status.Error(codes.Unavailable,
+				"server is shutting down")
+		}
+	}
+}
+
+// hashFromBytes returns the hash with the passed raw bytes or an invalid
+// argument error when they are not a hash.
+func hashFromBytes(b []byte) (*chainhash.Hash, error) {
+	hash, err := chainhash.NewHash(b)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return hash, nil
+}
+
+// BestBlock returns the hash and height of the best block.
+//
+// This is part of the hcashdrpc.BlockServiceServer interface.
+func (g *grpcServer) BestBlock(ctx context.Context, req *pb.BestBlockRequest) (*pb.BestBlockResponse, error) {
+	best := g.chain.BestSnapshot()
+	return &pb.BestBlockResponse{
+		Hash:      best.Hash[:],
+		Height:    best.Height,
+		KeyHeight: best.KeyHeight,
+	}, nil
+}
+
+// GetBlock returns the block with the requested hash.
+//
+// This is part of the hcashdrpc.BlockServiceServer interface.
+func (g *grpcServer) GetBlock(ctx context.Context, req *pb.GetBlockRequest) (*pb.GetBlockResponse, error) {
+	hash, err := hashFromBytes(req.Hash)
+	if err != nil {
+		return nil, err
+	}
+	block, err := g.chain.FetchBlockFromHash(hash)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "block not found: %v",
+			hash)
+	}
+	blockBytes, err := block.Bytes()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	mainChain, err := g.chain.MainChainHasBlock(hash)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.GetBlockResponse{
+		Block:     blockBytes,
+		Height:    block.Height(),
+		MainChain: mainChain,
+	}, nil
+}
+
+// BlockNotifications streams a notification for every block connected to or
+// disconnected from the main chain.
+//
+// This is part of the hcashdrpc.BlockServiceServer interface.
+func (g *grpcServer) BlockNotifications(req *pb.BlockNotificationsRequest, stream pb.BlockService_BlockNotificationsServer) error {
+	return g.streamNotifications(stream.Context(), g.blockNtfns,
+		func(ntfn interface{}) error {
+			return stream.Send(ntfn.(*pb.BlockNotificationsResponse))
+		})
+}
+
+// GetTransaction returns the transaction with the requested hash from the
+// memory pool or, using the transaction index, the main chain.
+//
+// This is part of the hcashdrpc.TransactionServiceServer interface.
+func (g *grpcServer) GetTransaction(ctx context.Context, req *pb.GetTransactionRequest) (*pb.GetTransactionResponse, error) {
+	txHash, err := hashFromBytes(req.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := g.server.txMemPool.FetchTransaction(txHash, true)
+	if err == nil {
+		txBytes, err := tx.MsgTx().Bytes()
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return &pb.GetTransactionResponse{Transaction: txBytes}, nil
+	}
+
+	txIndex := g.server.txIndex
+	if txIndex == nil {
+		return nil, status.Error(codes.FailedPrecondition, "the "+
+			"transaction index must be enabled to query the "+
+			"blockchain (specify --txindex)")
+	}
+	blockRegion, err := txIndex.TxBlockRegion(*txHash)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if blockRegion == nil {
+		return nil, status.Errorf(codes.NotFound, "transaction not "+
+			"found: %v", txHash)
+	}
+	var txBytes []byte
+	err = g.server.db.View(func(dbTx database.Tx) error {
+		var err error
+		txBytes, err = dbTx.FetchBlockRegion(blockRegion)
+		return err
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "transaction not "+
+			"found: %v", txHash)
+	}
+
+	// The confirmations are counted in key blocks like the ones of the
+	// JSON-RPC API.
+	blockHeight, err := g.chain.BlockHeightByHash(blockRegion.Hash)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	keyHeight, err := g.chain.KeyHeightByHeight(blockHeight, nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.GetTransactionResponse{
+		Transaction:   txBytes,
+		BlockHash:     blockRegion.Hash[:],
+		BlockHeight:   blockHeight,
+		Confirmations: g.chain.BestRealKeyHeight() - keyHeight,
+	}, nil
+}
+
+// PublishTransaction submits a signed transaction to the memory pool and
+// relays it to the network.
+//
+// This is part of the hcashdrpc.TransactionServiceServer interface.
+func (g *grpcServer) PublishTransaction(ctx context.Context, req *pb.PublishTransactionRequest) (*pb.PublishTransactionResponse, error) {
+	msgTx := wire.NewMsgTx()
+	err := msgTx.Deserialize(bytes.NewReader(req.SignedTransaction))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "could not "+
+			"decode transaction: %v", err)
+	}
+
+	tx := hcashutil.NewTx(msgTx)
+	acceptedTxs, err := g.server.blockManager.ProcessTransaction(tx, false,
+		false, req.AllowHighFees)
+	if err != nil {
+		if _, ok := err.(mempool.RuleError); ok {
+			rpcsLog.Debugf("Rejected transaction %v: %v", tx.Hash(),
+				err)
+			return nil, status.Errorf(codes.InvalidArgument,
+				"transaction rejected: %v", err)
+		}
+		rpcsLog.Errorf("Failed to process transaction %v: %v",
+			tx.Hash(), err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	g.server.AnnounceNewTransactions(acceptedTxs)
+
+	// Rebroadcast the transaction until it is included in a block when the
+	// rebroadcast handler is running, which it only does along with the
+	// RPC server.
+	if g.server.rpcServer != nil {
+		iv := wire.NewInvVect(wire.InvTypeTx, tx.Hash())
+		g.server.AddRebroadcastInventory(iv, tx)
+	}
+
+	return &pb.PublishTransactionResponse{TransactionHash: tx.Hash()[:]}, nil
+}
+
+// GetMempool returns the hashes of all transactions in the memory pool.
+//
+// This is part of the hcashdrpc.MempoolServiceServer interface.
+func (g *grpcServer) GetMempool(ctx context.Context, req *pb.GetMempoolRequest) (*pb.GetMempoolResponse, error) {
+	txHashes := g.server.txMemPool.TxHashes()
+	resp := &pb.GetMempoolResponse{
+		TransactionHashes: make([][]byte, 0, len(txHashes)),
+	}
+	for _, txHash := range txHashes {
+		resp.TransactionHashes = append(resp.TransactionHashes,
+			txHash[:])
+	}
+	return resp, nil
+}
+
+// TransactionNotifications streams a notification for every new transaction
+// accepted to the memory pool.
+//
+// This is part of the hcashdrpc.MempoolServiceServer interface.
+func (g *grpcServer) TransactionNotifications(req *pb.TransactionNotificationsRequest, stream pb.MempoolService_TransactionNotificationsServer) error {
+	return g.streamNotifications(stream.Context(), g.txNtfns,
+		func(ntfn interface{}) error {
+			return stream.Send(
+				ntfn.(*pb.TransactionNotificationsResponse))
+		})
+}
+
+// StakeDifficulty returns the current and next ticket prices.
+//
+// This is part of the hcashdrpc.StakeServiceServer interface.
+func (g *grpcServer) StakeDifficulty(ctx context.Context, req *pb.StakeDifficultyRequest) (*pb.StakeDifficultyResponse, error) {
+	best := g.chain.BestSnapshot()
+	header, err := g.chain.HeaderByHeight(best.Height)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	nextStakeDiff, err := g.server.blockManager.CalcNextRequiredStakeDifficulty()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.StakeDifficultyResponse{
+		CurrentStakeDifficulty: header.SBits,
+		NextStakeDifficulty:    nextStakeDiff,
+	}, nil
+}
+
+// LiveTickets returns the hashes of all live tickets.
+//
+// This is part of the hcashdrpc.StakeServiceServer interface.
+func (g *grpcServer) LiveTickets(ctx context.Context, req *pb.LiveTicketsRequest) (*pb.LiveTicketsResponse, error) {
+	tickets, err := g.chain.LiveTickets()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &pb.LiveTicketsResponse{
+		TicketHashes: make([][]byte, 0, len(tickets)),
+	}
+	for i := range tickets {
+		resp.TicketHashes = append(resp.TicketHashes, tickets[i][:])
+	}
+	return resp, nil
+}
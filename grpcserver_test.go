@@ -0,0 +1,128 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/HcashOrg/hcashd/rpc/hcashdrpc"
+)
+
+// TestGRPCAuthorize ensures requests are only handled when their metadata
+// carries exactly one valid token which grants access to the method.
+func TestGRPCAuthorize(t *testing.T) {
+	const (
+		readMethod  = "/hcashdrpc.BlockService/BestBlock"
+		writeMethod = "/hcashdrpc.TransactionService/PublishTransaction"
+	)
+	rootKey := bytes.Repeat([]byte{0x01}, grpcRootKeySize)
+	otherKey := bytes.Repeat([]byte{0x02}, grpcRootKeySize)
+	admin := newGRPCToken(rootKey, []byte(grpcTokenID)).String()
+	readOnly := newGRPCToken(rootKey, []byte(grpcTokenID))
+	readOnly.addCaveat(grpcCaveatReadOnly)
+	forged := newGRPCToken(otherKey, []byte(grpcTokenID)).String()
+
+	tests := []struct {
+		name   string
+		tokens []string
+		method string
+		code   codes.Code
+	}{
+		{"admin read", []string{admin}, readMethod, codes.OK},
+		{"admin write", []string{admin}, writeMethod, codes.OK},
+		{"read-only read", []string{readOnly.String()}, readMethod,
+			codes.OK},
+		{"read-only write", []string{readOnly.String()}, writeMethod,
+			codes.PermissionDenied},
+		{"forged", []string{forged}, readMethod, codes.PermissionDenied},
+		{"malformed", []string{"hcashd"}, readMethod,
+			codes.Unauthenticated},
+		{"missing", nil, readMethod, codes.Unauthenticated},
+		{"multiple", []string{admin, admin}, readMethod,
+			codes.Unauthenticated},
+	}
+
+	g := &grpcServer{rootKey: rootKey}
+	for _, test := range tests {
+		ctx := context.Background()
+		if test.tokens != nil {
+			md := metadata.MD{grpcTokenMetadataKey: test.tokens}
+			ctx = metadata.NewIncomingContext(ctx, md)
+		}
+
+		var handled bool
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			handled = true
+			return req, nil
+		}
+		info := &grpc.UnaryServerInfo{FullMethod: test.method}
+		_, err := g.unaryInterceptor(ctx, nil, info, handler)
+		if code := status.Code(err); code != test.code {
+			t.Errorf("%s: got code %v, want %v (err %v)", test.name,
+				code, test.code, err)
+			continue
+		}
+		if handled != (test.code == codes.OK) {
+			t.Errorf("%s: handler called %v with code %v", test.name,
+				handled, test.code)
+		}
+	}
+}
+
+// TestGRPCGetBlock ensures the gRPC block service returns the best block and
+// rejects requests for malformed or unknown hashes.
+func TestGRPCGetBlock(t *testing.T) {
+	chain, teardown := newTestRPCChain(t, "grpcgetblock")
+	defer teardown()
+
+	g := &grpcServer{chain: chain}
+	ctx := context.Background()
+	best, err := g.BestBlock(ctx, &pb.BestBlockRequest{})
+	if err != nil {
+		t.Fatalf("BestBlock: unexpected error: %v", err)
+	}
+	snapshot := chain.BestSnapshot()
+	if !bytes.Equal(best.Hash, snapshot.Hash[:]) ||
+		best.Height != snapshot.Height {
+		t.Fatalf("BestBlock: got %x at height %d, want %v at height %d",
+			best.Hash, best.Height, snapshot.Hash, snapshot.Height)
+	}
+
+	resp, err := g.GetBlock(ctx, &pb.GetBlockRequest{Hash: best.Hash})
+	if err != nil {
+		t.Fatalf("GetBlock: unexpected error: %v", err)
+	}
+	block, err := chain.FetchBlockFromHash(snapshot.Hash)
+	if err != nil {
+		t.Fatalf("FetchBlockFromHash: unexpected error: %v", err)
+	}
+	blockBytes, err := block.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: unexpected error: %v", err)
+	}
+	if !bytes.Equal(resp.Block, blockBytes) || !resp.MainChain ||
+		resp.Height != snapshot.Height {
+		t.Fatalf("GetBlock: got block at height %d in the main chain "+
+			"%v, want the best block", resp.Height, resp.MainChain)
+	}
+
+	_, err = g.GetBlock(ctx, &pb.GetBlockRequest{Hash: []byte{0x01}})
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Fatalf("GetBlock(malformed hash): got code %v, want %v", code,
+			codes.InvalidArgument)
+	}
+	_, err = g.GetBlock(ctx, &pb.GetBlockRequest{Hash: make([]byte, 32)})
+	if code := status.Code(err); code != codes.NotFound {
+		t.Fatalf("GetBlock(unknown hash): got code %v, want %v", code,
+			codes.NotFound)
+	}
+}
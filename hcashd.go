@@ -27,6 +27,11 @@ var cfg *config
 // as a service and reacts accordingly.
 var winServiceMain func() (bool, error)
 
+// mockRPCMain is only set when hcashd is built with the rpcmock build tag.  It
+// serves canned responses to RPC clients instead of running a node until the
+// passed channel is closed.
+var mockRPCMain func(interrupt <-chan struct{}) error
+
 // hcashdMain is the real main function for hcashd.  It is necessary to work around
 // the fact that deferred functions do not run when os.Exit() is called.  The
 // optional serverChan parameter is mainly used by the service code to be
@@ -40,11 +45,7 @@ func hcashdMain(serverChan chan<- *server) error {
 		return err
 	}
 	cfg = tcfg
-	defer func() {
-		if logRotator != nil {
-			logRotator.Close()
-		}
-	}()
+	defer closeLogRotators()
 
 	// Get a channel that will be closed when a shutdown signal has been
 	// triggered either from an OS signal such as SIGINT (Ctrl+C) or from
@@ -99,6 +100,12 @@ func hcashdMain(serverChan chan<- *server) error {
 		}()
 	}
 
+	// Serve canned RPC responses instead of running a node when built as a
+	// mock RPC server.
+	if mockRPCMain != nil {
+		return mockRPCMain(interruptedChan)
+	}
+
 	var lifetimeNotifier lifetimeEventServer
 	if cfg.LifetimeEvents {
 		lifetimeNotifier = newLifetimeEventServer(outgoingPipeMessages)
@@ -119,12 +126,14 @@ func hcashdMain(serverChan chan<- *server) error {
 	}
 
 	// Load the block database.
+	startupTimings.phaseDone("initialization")
 	lifetimeNotifier.notifyStartupEvent(lifetimeEventDBOpen)
 	db, err := loadBlockDB()
 	if err != nil {
 		hcashdLog.Errorf("%v", err)
 		return err
 	}
+	startupTimings.phaseDone("database open")
 	defer func() {
 		// Ensure the database is sync'd and closed on shutdown.
 		lifetimeNotifier.notifyShutdownEvent(lifetimeEventDBOpen)
@@ -137,6 +146,11 @@ func hcashdMain(serverChan chan<- *server) error {
 		return nil
 	}
 
+	// Check the integrity of the database and exit if requested.
+	if cfg.DBCheck {
+		return checkBlockDB(db, interruptedChan)
+	}
+
 	// Drop indexes and exit if requested.
 	//
 	// NOTE: The order is important here because dropping the tx index also
@@ -165,25 +179,56 @@ func hcashdMain(serverChan chan<- *server) error {
 
 		return nil
 	}
+	if cfg.DropCfIndex {
+		if err := indexers.DropCfIndex(db); err != nil {
+			hcashdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropVoteStatsIndex {
+		if err := indexers.DropVoteStatsIndex(db); err != nil {
+			hcashdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
 
 	// Create server and start it.
 	lifetimeNotifier.notifyStartupEvent(lifetimeEventP2PServer)
-	server, err := newServer(cfg.Listeners, db, activeNetParams.Params)
+	server, err := newServer(cfg.Listeners, db, activeNetParams.Params,
+		interruptedChan)
 	if err != nil {
+		// Catching up the optional indexes is aborted when an interrupt
+		// is requested, which is not an error.
+		if interruptRequested(interruptedChan) {
+			return nil
+		}
+
 		// TODO(oga) this logging could do with some beautifying.
 		hcashdLog.Errorf("Unable to start server on %v: %v",
 			cfg.Listeners, err)
 		return err
 	}
+	startupTimings.phaseDone("chain and server creation")
 	defer func() {
 		lifetimeNotifier.notifyShutdownEvent(lifetimeEventP2PServer)
 		hcashdLog.Infof("Gracefully shutting down the server...")
 		server.Stop()
 		server.WaitForShutdown()
+		if cfg.PersistMempool {
+			if err := server.saveMempool(); err != nil {
+				srvrLog.Errorf("Unable to save the mempool: %v", err)
+			}
+		}
 		srvrLog.Infof("Server shutdown complete")
 	}()
 
 	server.Start()
+	reloadListener(server, interruptedChan)
+	startupTimings.phaseDone("server start")
 	if serverChan != nil {
 		serverChan <- server
 	}
@@ -193,6 +238,7 @@ func hcashdMain(serverChan chan<- *server) error {
 	}
 
 	lifetimeNotifier.notifyStartupComplete()
+	startupTimings.done()
 
 	// Wait until the interrupt signal is received from an OS signal or
 	// shutdown is requested through one of the subsystems such as the RPC
@@ -235,4 +281,13 @@ func main() {
 	if err := hcashdMain(nil); err != nil {
 		os.Exit(1)
 	}
+
+	// Start a new instance now that the running one was shut down when a
+	// restart was requested.
+	if restartRequested() {
+		if err := restartProcess(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to restart: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
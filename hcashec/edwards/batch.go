@@ -0,0 +1,183 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// identityEncoded is the encoding of the neutral element of the group.
+var identityEncoded = [32]byte{1}
+
+// multiScalarMult sets r to the sum of scalars[i]*points[i], where the scalars
+// are 32 byte little endian numbers.  All of the products share the doublings
+// (the interleaving method by Straus), so the sum is considerably cheaper than
+// the separate products.  This is variable time.
+func multiScalarMult(r *edwards25519.ExtendedGroupElement, scalars []*[32]byte,
+	points []*edwards25519.ExtendedGroupElement) {
+
+	cached := make([]cachedGroupElement, len(points))
+	for i, p := range points {
+		toCached(&cached[i], p)
+	}
+
+	var c edwards25519.CompletedGroupElement
+	r.Zero()
+	for bit := 255; bit >= 0; bit-- {
+		r.Double(&c)
+		c.ToExtended(r)
+		for i, s := range scalars {
+			if (s[bit>>3]>>uint(bit&7))&1 == 1 {
+				geAdd(&c, r, &cached[i])
+				c.ToExtended(r)
+			}
+		}
+	}
+}
+
+// batchWeights returns the 128 bit weights of the signatures of a batch as
+// little endian scalars, which are derived from the hash of all of the public
+// keys, signatures and messages.  The first weight is one.
+func batchWeights(pubs, sigs, msgs [][]byte) []*[32]byte {
+	h := sha512.New()
+	var msgLen [4]byte
+	for i := range sigs {
+		h.Write(pubs[i])
+		h.Write(sigs[i])
+		binary.LittleEndian.PutUint32(msgLen[:], uint32(len(msgs[i])))
+		h.Write(msgLen[:])
+		h.Write(msgs[i])
+	}
+	seed := h.Sum(nil)
+
+	weights := make([]*[32]byte, len(sigs))
+	weights[0] = &[32]byte{1}
+	input := make([]byte, len(seed)+4)
+	copy(input, seed)
+	for i := 1; i < len(weights); i++ {
+		binary.LittleEndian.PutUint32(input[len(seed):], uint32(i))
+		digest := sha512.Sum512(input)
+		weight := new([32]byte)
+		copy(weight[:16], digest[:16])
+		weights[i] = weight
+	}
+	return weights
+}
+
+// BatchVerify returns whether all of the passed Ed25519 signatures of the
+// passed messages by the passed public keys are valid.  The slices must be of
+// the same length, and an empty batch is valid.
+//
+// A signature (R, s) of message m by public key A is valid when
+// s*B = R + h*A, where h = SHA512(R || A || m).  All of the signatures are
+// verified together by checking that
+//
+//	8*(sum(a_i*s_i)*B - sum(a_i*R_i) - sum(a_i*h_i*A_i)) = 0
+//
+// where the weights a_i are derived from the hash of all of the signatures,
+// public keys and messages, so a set of invalid signatures can not be crafted
+// to cancel each other out.  All of the products are computed together with
+// one multi-scalar multiplication, which is considerably cheaper than
+// verifying the signatures one by one.
+//
+// NOTE: The check is multiplied by the cofactor, since components of small
+// order could otherwise make the result depend on the weights.  Every batch of
+// signatures which are accepted by Verify is accepted, but signatures with R or
+// A points which have a component of small order can be accepted even though
+// Verify rejects them.  BatchVerify must therefore not replace Verify where the
+// results have to agree.
+func BatchVerify(pubs []*PublicKey, msgs [][]byte, sigs []*Signature) bool {
+	if len(pubs) != len(sigs) || len(msgs) != len(sigs) {
+		return false
+	}
+	if len(sigs) == 0 {
+		return true
+	}
+
+	// Decode the points and enforce the same bounds on the inputs as the
+	// individual verification.  Non canonical encodings of R are rejected
+	// since the individual verification compares the encoding of the
+	// calculated point to the given one.
+	pubBytes := make([][]byte, len(sigs))
+	sigBytes := make([][]byte, len(sigs))
+	points := make([]*edwards25519.ExtendedGroupElement, 0, 2*len(sigs))
+	for i, sig := range sigs {
+		pub := pubs[i]
+		if pub == nil || msgs[i] == nil || sig == nil || sig.R == nil ||
+			sig.S == nil {
+			return false
+		}
+		pubBytes[i] = pub.Serialize()
+		sigBytes[i] = sig.Serialize()
+		if sigBytes[i][63]&224 != 0 {
+			return false
+		}
+
+		a := new(edwards25519.ExtendedGroupElement)
+		if !a.FromBytes(copyBytes(pubBytes[i])) {
+			return false
+		}
+		rBytes := copyBytes(sigBytes[i][:32])
+		r := new(edwards25519.ExtendedGroupElement)
+		if !r.FromBytes(rBytes) {
+			return false
+		}
+		var encoded [32]byte
+		r.ToBytes(&encoded)
+		if encoded != *rBytes {
+			return false
+		}
+
+		// Negate the points so all of the products are added.
+		edwards25519.FeNeg(&a.X, &a.X)
+		edwards25519.FeNeg(&a.T, &a.T)
+		edwards25519.FeNeg(&r.X, &r.X)
+		edwards25519.FeNeg(&r.T, &r.T)
+		points = append(points, r, a)
+	}
+
+	// Calculate the scalars a_i for -R_i and a_i*h_i for -A_i along with
+	// the sum of a_i*s_i.
+	weights := batchWeights(pubBytes, sigBytes, msgs)
+	scalars := make([]*[32]byte, 0, len(points))
+	var zero, sumS [32]byte
+	for i, weight := range weights {
+		h := sha512.New()
+		h.Write(sigBytes[i][:32])
+		h.Write(pubBytes[i])
+		h.Write(msgs[i])
+		var digest [64]byte
+		h.Sum(digest[:0])
+		var hReduced, weightedH [32]byte
+		edwards25519.ScReduce(&hReduced, &digest)
+		edwards25519.ScMulAdd(&weightedH, weight, &hReduced, &zero)
+
+		s := copyBytes(sigBytes[i][32:])
+		edwards25519.ScMulAdd(&sumS, weight, s, &sumS)
+		scalars = append(scalars, weight, &weightedH)
+	}
+
+	// Add sum(a_i*s_i)*B to the sum of the products and multiply the
+	// result by the cofactor.
+	var sum, sB edwards25519.ExtendedGroupElement
+	multiScalarMult(&sum, scalars, points)
+	edwards25519.GeScalarMultBase(&sB, &sumS)
+	var cachedSB cachedGroupElement
+	toCached(&cachedSB, &sB)
+	var c edwards25519.CompletedGroupElement
+	geAdd(&c, &sum, &cachedSB)
+	c.ToExtended(&sum)
+	for i := 0; i < 3; i++ {
+		sum.Double(&c)
+		c.ToExtended(&sum)
+	}
+
+	var encoded [32]byte
+	sum.ToBytes(&encoded)
+	return encoded == identityEncoded
+}
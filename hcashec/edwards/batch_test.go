@@ -0,0 +1,62 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestBatchVerify ensures batches of signatures are only valid when all of
+// their signatures are valid.
+func TestBatchVerify(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	const numSigs = 8
+	pubs := make([]*PublicKey, numSigs)
+	msgs := make([][]byte, numSigs)
+	sigs := make([]*Signature, numSigs)
+	for i, sk := range mockUpSecKeysByScalars(curve, numSigs) {
+		msgs[i] = []byte{byte(i), 'm', 's', 'g'}
+		r, s, err := Sign(curve, sk, msgs[i])
+		if err != nil {
+			t.Fatalf("unexpected signing error: %v", err)
+		}
+		pkX, pkY := sk.Public()
+		pubs[i] = NewPublicKey(curve, pkX, pkY)
+		sigs[i] = NewSignature(r, s)
+	}
+	if !BatchVerify(pubs, msgs, sigs) {
+		t.Fatal("valid batch rejected")
+	}
+	if !BatchVerify(nil, nil, nil) {
+		t.Fatal("empty batch rejected")
+	}
+	if BatchVerify(pubs, msgs[1:], sigs) {
+		t.Fatal("batch with mismatched lengths accepted")
+	}
+
+	// replaced returns a copy of the signatures with the signature at the
+	// passed index replaced by the passed one.
+	replaced := func(idx int, sig *Signature) []*Signature {
+		cpy := make([]*Signature, len(sigs))
+		copy(cpy, sigs)
+		cpy[idx] = sig
+		return cpy
+	}
+	for i := range sigs {
+		// Use the signature of another message.
+		if BatchVerify(pubs, msgs, replaced(i, sigs[(i+1)%numSigs])) {
+			t.Fatalf("batch with invalid signature %d accepted", i)
+		}
+
+		// Tweak the s value of the signature.
+		s := new(big.Int).Add(sigs[i].S, big.NewInt(1))
+		if BatchVerify(pubs, msgs, replaced(i, NewSignature(sigs[i].R, s))) {
+			t.Fatalf("batch with tweaked signature %d accepted", i)
+		}
+	}
+}
@@ -213,6 +213,24 @@ func toCached(r *cachedGroupElement, p *edwards25519.ExtendedGroupElement) {
 	edwards25519.FeMul(&r.T2d, &p.T, &fed2)
 }
 
+// geAdd sets r = p + q, where q is a cached extended group element.
+func geAdd(r *edwards25519.CompletedGroupElement,
+	p *edwards25519.ExtendedGroupElement, q *cachedGroupElement) {
+	var t0 edwards25519.FieldElement
+
+	edwards25519.FeAdd(&r.X, &p.Y, &p.X)
+	edwards25519.FeSub(&r.Y, &p.Y, &p.X)
+	edwards25519.FeMul(&r.Z, &r.X, &q.yPlusX)
+	edwards25519.FeMul(&r.Y, &r.Y, &q.yMinusX)
+	edwards25519.FeMul(&r.T, &q.T2d, &p.T)
+	edwards25519.FeMul(&r.X, &p.Z, &q.Z)
+	edwards25519.FeAdd(&t0, &r.X, &r.X)
+	edwards25519.FeSub(&r.X, &r.Z, &r.Y)
+	edwards25519.FeAdd(&r.Y, &r.Z, &r.Y)
+	edwards25519.FeAdd(&r.Z, &t0, &r.T)
+	edwards25519.FeSub(&r.T, &t0, &r.T)
+}
+
 // Add adds two points represented by pairs of big integers on the elliptical
 // curve.
 func (curve *TwistedEdwardsCurve) Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
@@ -229,26 +247,8 @@ func (curve *TwistedEdwardsCurve) Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
 	bCached := new(cachedGroupElement)
 	toCached(bCached, bEGE)
 
-	p := aEGE
-	q := bCached
-
-	// geAdd(r*CompletedGroupElement, p*ExtendedGroupElement,
-	//   q*CachedGroupElement)
-	// r is the result.
 	r := new(edwards25519.CompletedGroupElement)
-	var t0 edwards25519.FieldElement
-
-	edwards25519.FeAdd(&r.X, &p.Y, &p.X)
-	edwards25519.FeSub(&r.Y, &p.Y, &p.X)
-	edwards25519.FeMul(&r.Z, &r.X, &q.yPlusX)
-	edwards25519.FeMul(&r.Y, &r.Y, &q.yMinusX)
-	edwards25519.FeMul(&r.T, &q.T2d, &p.T)
-	edwards25519.FeMul(&r.X, &p.Z, &q.Z)
-	edwards25519.FeAdd(&t0, &r.X, &r.X)
-	edwards25519.FeSub(&r.X, &r.Z, &r.Y)
-	edwards25519.FeAdd(&r.Y, &r.Z, &r.Y)
-	edwards25519.FeAdd(&r.Z, &t0, &r.T)
-	edwards25519.FeSub(&r.T, &t0, &r.T)
+	geAdd(r, aEGE, bCached)
 
 	rEGE := new(edwards25519.ExtendedGroupElement)
 	r.ToExtended(rEGE)
@@ -0,0 +1,155 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/hcashec/secp256k1"
+)
+
+// liftX returns the y coordinate of the point on the curve with the passed x
+// coordinate and an even y coordinate.  The second return value is false when
+// there is no such point.
+func liftX(curve *secp256k1.KoblitzCurve, x *big.Int) (*big.Int, bool) {
+	p := curve.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 {
+		return nil, false
+	}
+
+	// y^2 = x^3 + 7 (mod p)
+	ySquared := new(big.Int).Mul(x, x)
+	ySquared.Mul(ySquared, x)
+	ySquared.Add(ySquared, curve.B)
+	ySquared.Mod(ySquared, p)
+	y := new(big.Int).Exp(ySquared, curve.QPlus1Div4(), p)
+	if new(big.Int).Exp(y, big.NewInt(2), p).Cmp(ySquared) != 0 {
+		return nil, false
+	}
+	if y.Bit(0) == 1 {
+		y.Sub(p, y)
+	}
+	return y, true
+}
+
+// batchWeights returns the weights of the signatures of a batch, which are
+// derived from the hash of all of the public keys, signatures and messages.
+// All weights except for the first one, which is one, are 128 bits.
+func batchWeights(pubkeys []*secp256k1.PublicKey, msgs [][]byte,
+	sigs []*Signature) []*big.Int {
+
+	buf := make([]byte, 0, len(sigs)*(33+SignatureSize+scalarSize))
+	for i, sig := range sigs {
+		buf = append(buf, pubkeys[i].SerializeCompressed()...)
+		buf = append(buf, sig.Serialize()...)
+		buf = append(buf, msgs[i]...)
+	}
+	seed := chainhash.HashB(buf)
+
+	weights := make([]*big.Int, len(sigs))
+	weights[0] = big.NewInt(1)
+	var input [chainhash.HashSize + 4]byte
+	copy(input[:], seed)
+	for i := 1; i < len(weights); i++ {
+		binary.LittleEndian.PutUint32(input[chainhash.HashSize:], uint32(i))
+		weights[i] = new(big.Int).SetBytes(chainhash.HashB(input[:])[:16])
+	}
+	return weights
+}
+
+// BatchVerify returns whether all of the passed secp256k1 Schnorr signatures of
+// the passed messages by the passed public keys are valid.  BLAKE256 is used as
+// the hashing function just like Verify.  The slices must be of the same
+// length, and an empty batch is valid.
+//
+// A signature (r, s) of message m by public key Q is valid when the point
+// R = h*Q + s*G, where h = BLAKE256(r || m), has an even y coordinate and the
+// x coordinate r.  Lifting r to the point R with an even y coordinate, all of
+// the signatures are verified together by checking that
+//
+//	sum(a_i*s_i)*G + sum(a_i*h_i*Q_i) = sum(a_i*R_i)
+//
+// where the weights a_i are derived from the hash of all of the signatures,
+// public keys and messages, so a set of invalid signatures can not be crafted
+// to cancel each other out.  Since the group has prime order, the result
+// only differs from verifying the signatures one by one with negligible
+// probability.  The base point multiplications of all of the signatures are
+// combined into one and the weights are only 128 bits, which saves work
+// compared to verifying the signatures one by one.
+//
+// The result does not reveal which signature is invalid, so callers have to
+// verify the signatures one by one for that.
+func BatchVerify(curve *secp256k1.KoblitzCurve, pubkeys []*secp256k1.PublicKey,
+	msgs [][]byte, sigs []*Signature) bool {
+
+	if len(pubkeys) != len(sigs) || len(msgs) != len(sigs) {
+		return false
+	}
+	if len(sigs) == 0 {
+		return true
+	}
+
+	// Enforce the same bounds on the inputs as the individual verification
+	// before serializing them for the weights.  An r value of at least the
+	// curve prime can never equal the x coordinate of a point.
+	for i, sig := range sigs {
+		pub := pubkeys[i]
+		if pub == nil || sig == nil || sig.R == nil || sig.S == nil ||
+			len(msgs[i]) != scalarSize {
+			return false
+		}
+		if !curve.IsOnCurve(pub.GetX(), pub.GetY()) {
+			return false
+		}
+		if sig.R.Sign() < 0 || sig.R.Cmp(curve.P) >= 0 ||
+			sig.S.Sign() < 0 || sig.S.Cmp(curve.N) >= 0 {
+
+			return false
+		}
+	}
+
+	n := curve.N
+	weights := batchWeights(pubkeys, msgs, sigs)
+	sumS := new(big.Int)
+	var lhsX, lhsY, rhsX, rhsY *big.Int
+	for i, sig := range sigs {
+		rBytes := BigIntToEncodedBytes(sig.R)
+		toHash := append(rBytes[:], msgs[i]...)
+		h := new(big.Int).SetBytes(chainhash.HashB(toHash))
+		if h.Sign() == 0 || h.Cmp(n) >= 0 {
+			return false
+		}
+		ry, ok := liftX(curve, sig.R)
+		if !ok {
+			return false
+		}
+
+		// Accumulate a_i*s_i, a_i*h_i*Q_i and a_i*R_i.
+		weight := weights[i]
+		sumS.Add(sumS, new(big.Int).Mul(weight, sig.S))
+		h.Mul(h, weight)
+		h.Mod(h, n)
+		x, y := curve.ScalarMult(pubkeys[i].GetX(), pubkeys[i].GetY(),
+			h.Bytes())
+		if lhsX == nil {
+			lhsX, lhsY = x, y
+		} else {
+			lhsX, lhsY = curve.Add(lhsX, lhsY, x, y)
+		}
+		x, y = curve.ScalarMult(sig.R, ry, weight.Bytes())
+		if rhsX == nil {
+			rhsX, rhsY = x, y
+		} else {
+			rhsX, rhsY = curve.Add(rhsX, rhsY, x, y)
+		}
+	}
+	sumS.Mod(sumS, n)
+	x, y := curve.ScalarBaseMult(sumS.Bytes())
+	lhsX, lhsY = curve.Add(lhsX, lhsY, x, y)
+
+	return lhsX.Cmp(rhsX) == 0 && lhsY.Cmp(rhsY) == 0
+}
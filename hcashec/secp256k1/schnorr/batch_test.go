@@ -0,0 +1,60 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/hcashec/secp256k1"
+)
+
+// TestBatchVerify ensures batches of signatures are only valid when all of
+// their signatures are valid.
+func TestBatchVerify(t *testing.T) {
+	curve := secp256k1.S256()
+
+	const numSigs = 8
+	sigList := randSigList(curve, numSigs)
+	pubs := make([]*secp256k1.PublicKey, numSigs)
+	msgs := make([][]byte, numSigs)
+	sigs := make([]*Signature, numSigs)
+	for i, params := range sigList {
+		pubs[i] = params.pubkey
+		msgs[i] = params.msg
+		sigs[i] = params.sig
+	}
+	if !BatchVerify(curve, pubs, msgs, sigs) {
+		t.Fatal("valid batch rejected")
+	}
+	if !BatchVerify(curve, nil, nil, nil) {
+		t.Fatal("empty batch rejected")
+	}
+	if BatchVerify(curve, pubs, msgs[1:], sigs) {
+		t.Fatal("batch with mismatched lengths accepted")
+	}
+
+	// replaced returns a copy of the signatures with the signature at the
+	// passed index replaced by the passed one.
+	replaced := func(idx int, sig *Signature) []*Signature {
+		cpy := make([]*Signature, len(sigs))
+		copy(cpy, sigs)
+		cpy[idx] = sig
+		return cpy
+	}
+	for i := range sigs {
+		// Use the signature of another message.
+		if BatchVerify(curve, pubs, msgs, replaced(i, sigs[(i+1)%numSigs])) {
+			t.Fatalf("batch with invalid signature %d accepted", i)
+		}
+
+		// Tweak the s value of the signature.
+		s := new(big.Int).Add(sigs[i].S, big.NewInt(1))
+		sig := NewSignature(sigs[i].R, s)
+		if BatchVerify(curve, pubs, msgs, replaced(i, sig)) {
+			t.Fatalf("batch with tweaked signature %d accepted", i)
+		}
+	}
+}
@@ -425,10 +425,31 @@ func RecoverCompact(curve *KoblitzCurve, signature,
 // signRFC6979 generates a deterministic ECDSA signature according to RFC 6979
 // and BIP 62.
 func signRFC6979(privateKey *PrivateKey, hash []byte) (*Signature, error) {
+	return signRFC6979WithExtra(privateKey, hash, nil)
+}
+
+// SignRFC6979WithExtra generates a deterministic ECDSA signature according to
+// RFC 6979 and BIP 62 just like PrivateKey.Sign, but with the passed 32 bytes
+// of extra data mixed into the derivation of the nonce as described in section
+// 3.6 of RFC 6979.  Signatures of the same hash with different extra data use
+// unrelated nonces, which allows for domain separated nonces, such as those
+// used by adaptor signatures and anti-exfiltration protocols.  An empty extra
+// yields the same signature as PrivateKey.Sign.
+func SignRFC6979WithExtra(privateKey *PrivateKey, hash, extra []byte) (*Signature, error) {
+	if len(extra) != 0 && len(extra) != 32 {
+		return nil, fmt.Errorf("extra data must be 32 bytes, got %d",
+			len(extra))
+	}
+	return signRFC6979WithExtra(privateKey, hash, extra)
+}
 
+// signRFC6979WithExtra generates a deterministic ECDSA signature according to
+// RFC 6979 and BIP 62 with the passed extra data, which must either be empty or
+// 32 bytes, mixed into the derivation of the nonce.
+func signRFC6979WithExtra(privateKey *PrivateKey, hash, extra []byte) (*Signature, error) {
 	privkey := privateKey.ToECDSA()
 	N := order
-	k := NonceRFC6979(privkey.D, hash, nil, nil)
+	k := NonceRFC6979(privkey.D, hash, extra, nil)
 	inv := new(big.Int).ModInverse(k, N)
 	r, _ := privkey.Curve.ScalarBaseMult(k.Bytes())
 	if r.Cmp(N) == 1 {
@@ -588,6 +588,60 @@ func TestRFC6979(t *testing.T) {
 	}
 }
 
+// TestSignRFC6979WithExtra ensures signatures with extra nonce data are
+// deterministic, valid, use different nonces for different extra data, and
+// match the signatures of Sign without extra data.
+func TestSignRFC6979WithExtra(t *testing.T) {
+	privKey, pubKey := PrivKeyFromBytes(S256(), decodeHex("cca9fbcc1b41e"+
+		"5a95d369eaa6ddcff73b61a4efaa279cfc6567e8daa39cbaf50"))
+	hash := sha256.Sum256([]byte("sample"))
+
+	sig, err := privKey.Sign(hash[:])
+	if err != nil {
+		t.Fatalf("Sign: unexpected error: %v", err)
+	}
+	noExtraSig, err := SignRFC6979WithExtra(privKey, hash[:], nil)
+	if err != nil {
+		t.Fatalf("SignRFC6979WithExtra: unexpected error: %v", err)
+	}
+	if !noExtraSig.IsEqual(sig) {
+		t.Fatalf("SignRFC6979WithExtra without extra data: got %x, "+
+			"want %x", noExtraSig.Serialize(), sig.Serialize())
+	}
+
+	extra1 := bytes.Repeat([]byte{0x01}, 32)
+	extra2 := bytes.Repeat([]byte{0x02}, 32)
+	sig1, err := SignRFC6979WithExtra(privKey, hash[:], extra1)
+	if err != nil {
+		t.Fatalf("SignRFC6979WithExtra: unexpected error: %v", err)
+	}
+	again, err := SignRFC6979WithExtra(privKey, hash[:], extra1)
+	if err != nil {
+		t.Fatalf("SignRFC6979WithExtra: unexpected error: %v", err)
+	}
+	if !sig1.IsEqual(again) {
+		t.Fatal("SignRFC6979WithExtra: signature is not deterministic")
+	}
+	sig2, err := SignRFC6979WithExtra(privKey, hash[:], extra2)
+	if err != nil {
+		t.Fatalf("SignRFC6979WithExtra: unexpected error: %v", err)
+	}
+	if sig1.R.Cmp(sig.R) == 0 || sig1.R.Cmp(sig2.R) == 0 {
+		t.Fatal("SignRFC6979WithExtra: extra data does not change the " +
+			"nonce")
+	}
+	for i, s := range []*Signature{sig1, sig2} {
+		if !s.Verify(hash[:], pubKey) {
+			t.Errorf("SignRFC6979WithExtra #%d: signature does not "+
+				"verify", i)
+		}
+	}
+
+	if _, err := SignRFC6979WithExtra(privKey, hash[:], extra1[:31]); err == nil {
+		t.Fatal("SignRFC6979WithExtra: accepted extra data of 31 bytes")
+	}
+}
+
 func TestSignatureIsEqual(t *testing.T) {
 	sig1 := &Signature{
 		R: fromHex("0082235e21a2300022738dabb8e1bbd9d19cfb1e7ab8c30a23b0afbb8d178abcf3"),
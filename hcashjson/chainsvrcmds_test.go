@@ -97,10 +97,28 @@ func TestChainSvrCmds(t *testing.T) {
 				return hcashjson.NewCmd("decodescript", "00")
 			},
 			staticCmd: func() interface{} {
-				return hcashjson.NewDecodeScriptCmd("00")
+				return hcashjson.NewDecodeScriptCmd("00", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"decodescript","params":["00"],"id":1}`,
+			unmarshalled: &hcashjson.DecodeScriptCmd{
+				HexScript: "00",
+				Verbose:   hcashjson.Bool(false),
+			},
+		},
+		{
+			name: "decodescript verbose",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("decodescript", "00", true)
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewDecodeScriptCmd("00",
+					hcashjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"decodescript","params":["00",true],"id":1}`,
+			unmarshalled: &hcashjson.DecodeScriptCmd{
+				HexScript: "00",
+				Verbose:   hcashjson.Bool(true),
 			},
-			marshalled:   `{"jsonrpc":"1.0","method":"decodescript","params":["00"],"id":1}`,
-			unmarshalled: &hcashjson.DecodeScriptCmd{HexScript: "00"},
 		},
 		{
 			name: "getaddednodeinfo",
@@ -656,6 +674,17 @@ func TestChainSvrCmds(t *testing.T) {
 				Command: hcashjson.String("getblock"),
 			},
 		},
+		{
+			name: "listbanned",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("listbanned")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewListBannedCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"listbanned","params":[],"id":1}`,
+			unmarshalled: &hcashjson.ListBannedCmd{},
+		},
 		{
 			name: "ping",
 			newCmd: func() (interface{}, error) {
@@ -836,6 +865,39 @@ func TestChainSvrCmds(t *testing.T) {
 				AllowHighFees: hcashjson.Bool(false),
 			},
 		},
+		{
+			name: "setban",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("setban", "1.2.3.4", hcashjson.SBAdd)
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewSetBanCmd("1.2.3.4", hcashjson.SBAdd, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setban","params":["1.2.3.4","add"],"id":1}`,
+			unmarshalled: &hcashjson.SetBanCmd{
+				Addr:     "1.2.3.4",
+				SubCmd:   hcashjson.SBAdd,
+				BanTime:  hcashjson.Int64(0),
+				Absolute: hcashjson.Bool(false),
+			},
+		},
+		{
+			name: "setban optional",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("setban", "1.2.3.4", hcashjson.SBAdd, 1500000000, true)
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewSetBanCmd("1.2.3.4", hcashjson.SBAdd,
+					hcashjson.Int64(1500000000), hcashjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setban","params":["1.2.3.4","add",1500000000,true],"id":1}`,
+			unmarshalled: &hcashjson.SetBanCmd{
+				Addr:     "1.2.3.4",
+				SubCmd:   hcashjson.SBAdd,
+				BanTime:  hcashjson.Int64(1500000000),
+				Absolute: hcashjson.Bool(true),
+			},
+		},
 		{
 			name: "setgenerate",
 			newCmd: func() (interface{}, error) {
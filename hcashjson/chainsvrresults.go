@@ -80,11 +80,13 @@ type CreateMultiSigResult struct {
 
 // DecodeScriptResult models the data returned from the decodescript command.
 type DecodeScriptResult struct {
-	Asm       string   `json:"asm"`
-	ReqSigs   int32    `json:"reqSigs,omitempty"`
-	Type      string   `json:"type"`
-	Addresses []string `json:"addresses,omitempty"`
-	P2sh      string   `json:"p2sh"`
+	Asm          string   `json:"asm"`
+	AsmAnnotated string   `json:"asmannotated,omitempty"`
+	ReqSigs      int32    `json:"reqSigs,omitempty"`
+	Type         string   `json:"type"`
+	DetailedType string   `json:"detailedtype"`
+	Addresses    []string `json:"addresses,omitempty"`
+	P2sh         string   `json:"p2sh"`
 }
 
 // GetAddedNodeInfoResultAddr models the data of the addresses portion of the
@@ -101,16 +103,29 @@ type GetAddedNodeInfoResult struct {
 	Addresses *[]GetAddedNodeInfoResultAddr `json:"addresses,omitempty"`
 }
 
+// AgendaInfo models the consensus deployment data of an agenda returned from
+// the getblockchaininfo command.
+type AgendaInfo struct {
+	StakeVersion uint32 `json:"stakeversion"`
+	Status       string `json:"status"`
+	StartTime    uint64 `json:"starttime"`
+	ExpireTime   uint64 `json:"expiretime"`
+}
+
 // GetBlockChainInfoResult models the data returned from the getblockchaininfo
 // command.
 type GetBlockChainInfoResult struct {
-	Chain                string  `json:"chain"`
-	Blocks               int32   `json:"blocks"`
-	Headers              int32   `json:"headers"`
-	BestBlockHash        string  `json:"bestblockhash"`
-	Difficulty           float64 `json:"difficulty"`
-	VerificationProgress float64 `json:"verificationprogress"`
-	ChainWork            string  `json:"chainwork"`
+	Chain                string                `json:"chain"`
+	Blocks               int32                 `json:"blocks"`
+	Headers              int32                 `json:"headers"`
+	BestBlockHash        string                `json:"bestblockhash"`
+	Difficulty           float64               `json:"difficulty"`
+	VerificationProgress float64               `json:"verificationprogress"`
+	ChainWork            string                `json:"chainwork"`
+	Deployments          map[string]AgendaInfo `json:"deployments"`
+	SigHashOptimization  bool                  `json:"sighashoptimization"`
+	CheckDuplicateHashes bool                  `json:"checkduplicatehashes"`
+	Warnings             string                `json:"warnings"`
 }
 
 // GetBlockSubsidyResult models the data returned from the getblocksubsidy
@@ -177,11 +192,37 @@ type GetBlockTemplateResult struct {
 	RejectReasion string   `json:"reject-reason,omitempty"`
 }
 
+// GetChainTipsResult models the data of a chain tip returned from the
+// getchaintips command.
+type GetChainTipsResult struct {
+	Height    int64  `json:"height"`
+	Hash      string `json:"hash"`
+	BranchLen int64  `json:"branchlen"`
+	Status    string `json:"status"`
+}
+
+// GetDifficultyResult models the data returned from the getdifficulty command.
+//
+// The Difficulty field houses the proof-of-work difficulty that was
+// previously returned as the only result.
+type GetDifficultyResult struct {
+	Difficulty           float64 `json:"difficulty"`
+	MicroDifficulty      float64 `json:"microdifficulty"`
+	DifficultyRate       uint32  `json:"difficultyrate"`
+	StakeDifficulty      float64 `json:"stakedifficulty"`
+	MinStakeDifficulty   float64 `json:"minstakedifficulty"`
+	StakeDifficultyRatio float64 `json:"stakedifficultyratio"`
+}
+
 // GetMempoolInfoResult models the data returned from the getmempoolinfo
 // command.
 type GetMempoolInfoResult struct {
-	Size  int64 `json:"size"`
-	Bytes int64 `json:"bytes"`
+	Size              int64   `json:"size"`
+	Bytes             int64   `json:"bytes"`
+	MaxMempool        int64   `json:"maxmempool"`
+	MempoolMinFee     float64 `json:"mempoolminfee"`
+	SizeEvicted       uint64  `json:"sizeevicted"`
+	StaleVotesEvicted uint64  `json:"stalevotesevicted"`
 }
 
 // GetNetworkInfoResult models the data returned from the getnetworkinfo
@@ -198,26 +239,31 @@ type GetNetworkInfoResult struct {
 
 // GetPeerInfoResult models the data returned from the getpeerinfo command.
 type GetPeerInfoResult struct {
-	ID             int32   `json:"id"`
-	Addr           string  `json:"addr"`
-	AddrLocal      string  `json:"addrlocal,omitempty"`
-	Services       string  `json:"services"`
-	LastSend       int64   `json:"lastsend"`
-	LastRecv       int64   `json:"lastrecv"`
-	BytesSent      uint64  `json:"bytessent"`
-	BytesRecv      uint64  `json:"bytesrecv"`
-	ConnTime       int64   `json:"conntime"`
-	TimeOffset     int64   `json:"timeoffset"`
-	PingTime       float64 `json:"pingtime"`
-	PingWait       float64 `json:"pingwait,omitempty"`
-	Version        uint32  `json:"version"`
-	SubVer         string  `json:"subver"`
-	Inbound        bool    `json:"inbound"`
-	StartingHeight int64   `json:"startingheight"`
-	CurrentHeight  int64   `json:"currentheight,omitempty"`
-	CurrentRealKeyHeight int64 `json:"currentrealkeyheight,omitempty"`
-	BanScore       int32   `json:"banscore"`
-	SyncNode       bool    `json:"syncnode"`
+	ID                   int32             `json:"id"`
+	Addr                 string            `json:"addr"`
+	AddrLocal            string            `json:"addrlocal,omitempty"`
+	Services             string            `json:"services"`
+	LastSend             int64             `json:"lastsend"`
+	LastRecv             int64             `json:"lastrecv"`
+	BytesSent            uint64            `json:"bytessent"`
+	BytesRecv            uint64            `json:"bytesrecv"`
+	ConnTime             int64             `json:"conntime"`
+	TimeOffset           int64             `json:"timeoffset"`
+	PingTime             float64           `json:"pingtime"`
+	PingWait             float64           `json:"pingwait,omitempty"`
+	Version              uint32            `json:"version"`
+	SubVer               string            `json:"subver"`
+	Inbound              bool              `json:"inbound"`
+	StartingHeight       int64             `json:"startingheight"`
+	StartingKeyHeight    int64             `json:"startingkeyheight"`
+	CurrentHeight        int64             `json:"currentheight,omitempty"`
+	CurrentRealKeyHeight int64             `json:"currentrealkeyheight,omitempty"`
+	BanScore             int32             `json:"banscore"`
+	SyncNode             bool              `json:"syncnode"`
+	LimitedTxns          uint64            `json:"limitedtxns"`
+	LimitedLowFeeTxns    uint64            `json:"limitedlowfeetxns"`
+	BytesSentPerMsg      map[string]uint64 `json:"bytessent_per_msg"`
+	BytesRecvPerMsg      map[string]uint64 `json:"bytesrecv_per_msg"`
 }
 
 // GetRawMempoolVerboseResult models the data returned from the getrawmempool
@@ -231,6 +277,10 @@ type GetRawMempoolVerboseResult struct {
 	StartingPriority float64  `json:"startingpriority"`
 	CurrentPriority  float64  `json:"currentpriority"`
 	Depends          []string `json:"depends"`
+	Type             string   `json:"type"`
+	VoteBlockHash    string   `json:"voteblockhash,omitempty"`
+	VoteBlockHeight  uint32   `json:"voteblockheight,omitempty"`
+	TicketPrice      float64  `json:"ticketprice,omitempty"`
 }
 
 // ScriptPubKeyResult models the scriptPubKey data of a tx script.  It is
@@ -254,6 +304,17 @@ type GetTxOutResult struct {
 	Coinbase      bool               `json:"coinbase"`
 }
 
+// GetTxOutSetInfoResult models the data from the gettxoutsetinfo command.
+type GetTxOutSetInfoResult struct {
+	Height         int64   `json:"height"`
+	BestBlock      string  `json:"bestblock"`
+	Transactions   uint64  `json:"transactions"`
+	TxOuts         uint64  `json:"txouts"`
+	SerializedSize uint64  `json:"serializedsize"`
+	SetHash        string  `json:"sethash"`
+	TotalAmount    float64 `json:"totalamount"`
+}
+
 // GetNetTotalsResult models the data returned from the getnettotals command.
 type GetNetTotalsResult struct {
 	TotalBytesRecv uint64 `json:"totalbytesrecv"`
@@ -403,21 +464,57 @@ type Vout struct {
 	ScriptPubKey ScriptPubKeyResult `json:"scriptPubKey"`
 }
 
+// ListBannedResult models the data of a banned host returned by the listbanned
+// command.
+type ListBannedResult struct {
+	Address     string `json:"address"`
+	BannedUntil int64  `json:"banneduntil"`
+}
+
+// MiningLatencyBucket models a bucket of a block discovery latency histogram
+// returned as part of the getmininginfo command.  The upper bound is in
+// seconds and is -1 for the final unbounded bucket.
+type MiningLatencyBucket struct {
+	UpperBound float64 `json:"upperbound"`
+	Count      uint64  `json:"count"`
+}
+
+// MiningLatencyResult models a block discovery latency histogram returned as
+// part of the getmininginfo command.  All latencies are in seconds.
+type MiningLatencyResult struct {
+	Count   uint64                `json:"count"`
+	Mean    float64               `json:"mean"`
+	Min     float64               `json:"min"`
+	Max     float64               `json:"max"`
+	Buckets []MiningLatencyBucket `json:"buckets"`
+}
+
+// CPUMinerStatsResult models the CPU miner statistics returned as part of the
+// getmininginfo command.
+type CPUMinerStatsResult struct {
+	ActiveWorkers int32               `json:"activeworkers"`
+	CPUBudget     float64             `json:"cpubudget"`
+	CPUUsage      float64             `json:"cpuusage"`
+	MicroBlocks   MiningLatencyResult `json:"microblocks"`
+	KeyBlocks     MiningLatencyResult `json:"keyblocks"`
+}
+
 // GetMiningInfoResult models the data from the getmininginfo command.
 // Contains Hypercash additions.
 type GetMiningInfoResult struct {
-	Blocks           int64   `json:"blocks"`
-	CurrentBlockSize uint64  `json:"currentblocksize"`
-	CurrentBlockTx   uint64  `json:"currentblocktx"`
-	Difficulty       float64 `json:"difficulty"`
-	StakeDifficulty  int64   `json:"stakedifficulty"`
-	Errors           string  `json:"errors"`
-	Generate         bool    `json:"generate"`
-	GenProcLimit     int32   `json:"genproclimit"`
-	HashesPerSec     int64   `json:"hashespersec"`
-	NetworkHashPS    int64   `json:"networkhashps"`
-	PooledTx         uint64  `json:"pooledtx"`
-	TestNet          bool    `json:"testnet"`
+	Blocks           int64                `json:"blocks"`
+	CurrentBlockSize uint64               `json:"currentblocksize"`
+	CurrentBlockTx   uint64               `json:"currentblocktx"`
+	Difficulty       float64              `json:"difficulty"`
+	StakeDifficulty  int64                `json:"stakedifficulty"`
+	Errors           string               `json:"errors"`
+	Generate         bool                 `json:"generate"`
+	GenProcLimit     int32                `json:"genproclimit"`
+	HashesPerSec     int64                `json:"hashespersec"`
+	NetworkHashPS    int64                `json:"networkhashps"`
+	PooledTx         uint64               `json:"pooledtx"`
+	TestNet          bool                 `json:"testnet"`
+	CPUMiner         *CPUMinerStatsResult `json:"cpuminer,omitempty"`
 }
 
 // GetWorkResult models the data from the getwork command.
@@ -508,8 +605,10 @@ type TxRawDecodeResult struct {
 // ValidateAddressChainResult models the data returned by the chain server
 // validateaddress command.
 type ValidateAddressChainResult struct {
-	IsValid bool   `json:"isvalid"`
-	Address string `json:"address,omitempty"`
+	IsValid      bool   `json:"isvalid"`
+	Address      string `json:"address,omitempty"`
+	ScriptPubKey string `json:"scriptPubKey,omitempty"`
+	Type         string `json:"type,omitempty"`
 }
 
 // GetHeadersResult models the data returned by the chain server getheaders
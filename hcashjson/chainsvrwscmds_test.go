@@ -144,6 +144,60 @@ func TestChainSvrWsCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"stopnotifynewtransactions","params":[],"id":1}`,
 			unmarshalled: &hcashjson.StopNotifyNewTransactionsCmd{},
 		},
+		{
+			name: "notifyreceived",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("notifyreceived", []string{"1Address"})
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewNotifyReceivedCmd([]string{"1Address"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"notifyreceived","params":[["1Address"]],"id":1}`,
+			unmarshalled: &hcashjson.NotifyReceivedCmd{
+				Addresses: []string{"1Address"},
+			},
+		},
+		{
+			name: "stopnotifyreceived",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("stopnotifyreceived", []string{"1Address"})
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewStopNotifyReceivedCmd([]string{"1Address"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"stopnotifyreceived","params":[["1Address"]],"id":1}`,
+			unmarshalled: &hcashjson.StopNotifyReceivedCmd{
+				Addresses: []string{"1Address"},
+			},
+		},
+		{
+			name: "notifyspent",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("notifyspent", `[{"hash":"123","tree":1,"index":0}]`)
+			},
+			staticCmd: func() interface{} {
+				ops := []hcashjson.OutPoint{{Hash: "123", Tree: 1, Index: 0}}
+				return hcashjson.NewNotifySpentCmd(ops)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"notifyspent","params":[[{"hash":"123","tree":1,"index":0}]],"id":1}`,
+			unmarshalled: &hcashjson.NotifySpentCmd{
+				OutPoints: []hcashjson.OutPoint{{Hash: "123", Tree: 1, Index: 0}},
+			},
+		},
+		{
+			name: "stopnotifyspent",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("stopnotifyspent", `[{"hash":"123","tree":1,"index":0}]`)
+			},
+			staticCmd: func() interface{} {
+				ops := []hcashjson.OutPoint{{Hash: "123", Tree: 1, Index: 0}}
+				return hcashjson.NewStopNotifySpentCmd(ops)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"stopnotifyspent","params":[[{"hash":"123","tree":1,"index":0}]],"id":1}`,
+			unmarshalled: &hcashjson.StopNotifySpentCmd{
+				OutPoints: []hcashjson.OutPoint{{Hash: "123", Tree: 1, Index: 0}},
+			},
+		},
 		{
 			name: "rescan",
 			newCmd: func() (interface{}, error) {
@@ -157,6 +211,79 @@ func TestChainSvrWsCmds(t *testing.T) {
 				BlockHashes: "0000000000000000000000000000000000000000000000000000000000000123",
 			},
 		},
+		{
+			name: "getblockstream",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getblockstream", "123")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetBlockStreamCmd("123", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockstream","params":["123"],"id":1}`,
+			unmarshalled: &hcashjson.GetBlockStreamCmd{
+				Hash:      "123",
+				ChunkSize: hcashjson.Int(100),
+			},
+		},
+		{
+			name: "getblockstream optional",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getblockstream", "123", 10)
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetBlockStreamCmd("123", hcashjson.Int(10))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockstream","params":["123",10],"id":1}`,
+			unmarshalled: &hcashjson.GetBlockStreamCmd{
+				Hash:      "123",
+				ChunkSize: hcashjson.Int(10),
+			},
+		},
+		{
+			name: "searchrawtransactionsstream",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("searchrawtransactionsstream", "1Address")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewSearchRawTransactionsStreamCmd("1Address",
+					nil, nil, nil, nil, nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"searchrawtransactionsstream","params":["1Address"],"id":1}`,
+			unmarshalled: &hcashjson.SearchRawTransactionsStreamCmd{
+				Address:     "1Address",
+				Verbose:     hcashjson.Int(1),
+				Skip:        hcashjson.Int(0),
+				Count:       hcashjson.Int(100),
+				VinExtra:    hcashjson.Int(0),
+				Reverse:     hcashjson.Bool(false),
+				FilterAddrs: nil,
+				ChunkSize:   hcashjson.Int(100),
+			},
+		},
+		{
+			name: "searchrawtransactionsstream optional",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("searchrawtransactionsstream",
+					"1Address", 0, 5, 10, 1, true, []string{"1Address"}, 20)
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewSearchRawTransactionsStreamCmd("1Address",
+					hcashjson.Int(0), hcashjson.Int(5), hcashjson.Int(10),
+					hcashjson.Int(1), hcashjson.Bool(true),
+					&[]string{"1Address"}, hcashjson.Int(20))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"searchrawtransactionsstream","params":["1Address",0,5,10,1,true,["1Address"],20],"id":1}`,
+			unmarshalled: &hcashjson.SearchRawTransactionsStreamCmd{
+				Address:     "1Address",
+				Verbose:     hcashjson.Int(0),
+				Skip:        hcashjson.Int(5),
+				Count:       hcashjson.Int(10),
+				VinExtra:    hcashjson.Int(1),
+				Reverse:     hcashjson.Bool(true),
+				FilterAddrs: &[]string{"1Address"},
+				ChunkSize:   hcashjson.Int(20),
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
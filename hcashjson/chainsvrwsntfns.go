@@ -8,6 +8,8 @@
 
 package hcashjson
 
+import "encoding/json"
+
 const (
 	// BlockConnectedNtfnMethod is the method used for notifications from
 	// the chain server that a block has been connected.
@@ -35,6 +37,25 @@ const (
 	// from the chain server that inform a client that a relevant
 	// transaction was accepted by the mempool.
 	RelevantTxAcceptedNtfnMethod = "relevanttxaccepted"
+
+	// RecvTxNtfnMethod is the method used for notifications from the chain
+	// server that a transaction which pays to a registered address has
+	// been processed.
+	RecvTxNtfnMethod = "recvtx"
+
+	// RedeemingTxNtfnMethod is the method used for notifications from the
+	// chain server that a transaction which spends a registered outpoint
+	// has been processed.
+	RedeemingTxNtfnMethod = "redeemingtx"
+
+	// StreamDataNtfnMethod is the method used for notifications carrying a
+	// chunk of the result of a streaming request such as getblockstream.
+	StreamDataNtfnMethod = "streamdata"
+
+	// VerifyChainProgressNtfnMethod is the method used for notifications
+	// that a verifychain request made over a websocket connection has
+	// verified another block.
+	VerifyChainProgressNtfnMethod = "verifychainprogress"
 )
 
 // BlockConnectedNtfn defines the blockconnected JSON-RPC notification.
@@ -125,6 +146,102 @@ func NewRelevantTxAcceptedNtfn(txHex string) *RelevantTxAcceptedNtfn {
 	return &RelevantTxAcceptedNtfn{Transaction: txHex}
 }
 
+// BlockDetails describes details of a tx in a block.
+type BlockDetails struct {
+	Height int32  `json:"height"`
+	Tree   int8   `json:"tree"`
+	Hash   string `json:"hash"`
+	Index  int    `json:"index"`
+	Time   int64  `json:"time"`
+}
+
+// RecvTxNtfn defines the recvtx JSON-RPC notification.
+type RecvTxNtfn struct {
+	HexTx string
+	Block *BlockDetails
+}
+
+// NewRecvTxNtfn returns a new instance which can be used to issue a recvtx
+// JSON-RPC notification.
+func NewRecvTxNtfn(hexTx string, block *BlockDetails) *RecvTxNtfn {
+	return &RecvTxNtfn{
+		HexTx: hexTx,
+		Block: block,
+	}
+}
+
+// RedeemingTxNtfn defines the redeemingtx JSON-RPC notification.
+type RedeemingTxNtfn struct {
+	HexTx string
+	Block *BlockDetails
+}
+
+// NewRedeemingTxNtfn returns a new instance which can be used to issue a
+// redeemingtx JSON-RPC notification.
+func NewRedeemingTxNtfn(hexTx string, block *BlockDetails) *RedeemingTxNtfn {
+	return &RedeemingTxNtfn{
+		HexTx: hexTx,
+		Block: block,
+	}
+}
+
+// StreamDataNtfn defines the streamdata JSON-RPC notification.
+type StreamDataNtfn struct {
+	// ID is the id of the streaming request the chunk belongs to.
+	ID json.RawMessage
+
+	// Sequence is the zero-based position of the chunk in the stream.
+	Sequence int
+
+	// Items are the elements of the result carried by the chunk.
+	Items []json.RawMessage
+}
+
+// NewStreamDataNtfn returns a new instance which can be used to issue a
+// streamdata JSON-RPC notification.
+func NewStreamDataNtfn(id json.RawMessage, sequence int,
+	items []json.RawMessage) *StreamDataNtfn {
+
+	return &StreamDataNtfn{
+		ID:       id,
+		Sequence: sequence,
+		Items:    items,
+	}
+}
+
+// VerifyChainProgressNtfn defines the verifychainprogress JSON-RPC
+// notification.
+type VerifyChainProgressNtfn struct {
+	// ID is the id of the verifychain request the progress belongs to.
+	ID json.RawMessage
+
+	// Level is the check level the blocks are verified at.
+	Level int64
+
+	// Height and Hash identify the block which was verified.
+	Height int64
+	Hash   string
+
+	// Checked is the number of blocks verified so far out of Total.
+	Checked int64
+	Total   int64
+}
+
+// NewVerifyChainProgressNtfn returns a new instance which can be used to issue
+// a verifychainprogress JSON-RPC notification.
+func NewVerifyChainProgressNtfn(id json.RawMessage, level, height int64,
+	hash string, checked, total int64) *VerifyChainProgressNtfn {
+
+	return &VerifyChainProgressNtfn{
+		ID:      id,
+		Level:   level,
+		Height:  height,
+		Hash:    hash,
+		Checked: checked,
+		Total:   total,
+	}
+}
+
 func init() {
 	// The commands in this file are only usable by websockets and are
 	// notifications.
@@ -136,4 +253,8 @@ func init() {
 	MustRegisterCmd(TxAcceptedNtfnMethod, (*TxAcceptedNtfn)(nil), flags)
 	MustRegisterCmd(TxAcceptedVerboseNtfnMethod, (*TxAcceptedVerboseNtfn)(nil), flags)
 	MustRegisterCmd(RelevantTxAcceptedNtfnMethod, (*RelevantTxAcceptedNtfn)(nil), flags)
+	MustRegisterCmd(RecvTxNtfnMethod, (*RecvTxNtfn)(nil), flags)
+	MustRegisterCmd(RedeemingTxNtfnMethod, (*RedeemingTxNtfn)(nil), flags)
+	MustRegisterCmd(StreamDataNtfnMethod, (*StreamDataNtfn)(nil), flags)
+	MustRegisterCmd(VerifyChainProgressNtfnMethod, (*VerifyChainProgressNtfn)(nil), flags)
 }
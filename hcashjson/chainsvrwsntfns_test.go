@@ -69,6 +69,47 @@ func TestChainSvrWsNtfns(t *testing.T) {
 				Transaction: "001122",
 			},
 		},
+		{
+			name: "recvtx",
+			newNtfn: func() (interface{}, error) {
+				return hcashjson.NewCmd("recvtx", "001122", `{"height":100000,"tree":0,"hash":"123","index":0,"time":12345678}`)
+			},
+			staticNtfn: func() interface{} {
+				blockDetails := hcashjson.BlockDetails{
+					Height: 100000,
+					Tree:   0,
+					Hash:   "123",
+					Index:  0,
+					Time:   12345678,
+				}
+				return hcashjson.NewRecvTxNtfn("001122", &blockDetails)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"recvtx","params":["001122",{"height":100000,"tree":0,"hash":"123","index":0,"time":12345678}],"id":null}`,
+			unmarshalled: &hcashjson.RecvTxNtfn{
+				HexTx: "001122",
+				Block: &hcashjson.BlockDetails{
+					Height: 100000,
+					Tree:   0,
+					Hash:   "123",
+					Index:  0,
+					Time:   12345678,
+				},
+			},
+		},
+		{
+			name: "redeemingtx",
+			newNtfn: func() (interface{}, error) {
+				return hcashjson.NewCmd("redeemingtx", "001122")
+			},
+			staticNtfn: func() interface{} {
+				return hcashjson.NewRedeemingTxNtfn("001122", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"redeemingtx","params":["001122"],"id":null}`,
+			unmarshalled: &hcashjson.RedeemingTxNtfn{
+				HexTx: "001122",
+				Block: nil,
+			},
+		},
 		{
 			name: "txaccepted",
 			newNtfn: func() (interface{}, error) {
@@ -117,6 +158,44 @@ func TestChainSvrWsNtfns(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "streamdata",
+			newNtfn: func() (interface{}, error) {
+				return hcashjson.NewCmd("streamdata",
+					json.RawMessage(`7`), 2,
+					[]json.RawMessage{json.RawMessage(`{"txid":"123"}`)})
+			},
+			staticNtfn: func() interface{} {
+				return hcashjson.NewStreamDataNtfn(json.RawMessage(`7`), 2,
+					[]json.RawMessage{json.RawMessage(`{"txid":"123"}`)})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"streamdata","params":[7,2,[{"txid":"123"}]],"id":null}`,
+			unmarshalled: &hcashjson.StreamDataNtfn{
+				ID:       json.RawMessage(`7`),
+				Sequence: 2,
+				Items:    []json.RawMessage{json.RawMessage(`{"txid":"123"}`)},
+			},
+		},
+		{
+			name: "verifychainprogress",
+			newNtfn: func() (interface{}, error) {
+				return hcashjson.NewCmd("verifychainprogress",
+					json.RawMessage(`7`), 4, 100, "123", 2, 288)
+			},
+			staticNtfn: func() interface{} {
+				return hcashjson.NewVerifyChainProgressNtfn(json.RawMessage(`7`),
+					4, 100, "123", 2, 288)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"verifychainprogress","params":[7,4,100,"123",2,288],"id":null}`,
+			unmarshalled: &hcashjson.VerifyChainProgressNtfn{
+				ID:      json.RawMessage(`7`),
+				Level:   4,
+				Height:  100,
+				Hash:    "123",
+				Checked: 2,
+				Total:   288,
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -21,3 +21,20 @@ type RescannedBlock struct {
 	Hash         string   `json:"hash"`
 	Transactions []string `json:"transactions"`
 }
+
+// StreamResult models the summary of a stream of result chunks which is
+// returned once all of the streamdata notifications of a streaming request
+// were sent.
+type StreamResult struct {
+	Chunks int `json:"chunks"`
+	Items  int `json:"items"`
+}
+
+// GetBlockStreamResult models the data returned from the getblockstream
+// command.  The block only includes the hashes of its transactions, the verbose
+// transactions are sent as the items of the stream in the same order, with the
+// regular transactions first.
+type GetBlockStreamResult struct {
+	Block  GetBlockVerboseResult `json:"block"`
+	Stream StreamResult          `json:"stream"`
+}
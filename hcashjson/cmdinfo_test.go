@@ -201,6 +201,24 @@ func TestMethodUsageText(t *testing.T) {
 	}
 }
 
+// TestRegisteredCmdsUsage ensures the usage flags and one-line usage can be
+// generated for every registered command.
+func TestRegisteredCmdsUsage(t *testing.T) {
+	t.Parallel()
+
+	for _, method := range hcashjson.RegisteredCmdMethods() {
+		if _, err := hcashjson.MethodUsageFlags(method); err != nil {
+			t.Errorf("MethodUsageFlags %s: unexpected error: %v",
+				method, err)
+			continue
+		}
+		if _, err := hcashjson.MethodUsageText(method); err != nil {
+			t.Errorf("MethodUsageText %s: unexpected error: %v",
+				method, err)
+		}
+	}
+}
+
 // TestFieldUsage tests the internal fieldUsage function ensure it returns the
 // expected text.
 func TestFieldUsage(t *testing.T) {
@@ -8,6 +8,99 @@
 
 package hcashjson
 
+// AddRevocationScriptCmd defines the addrevocationscript JSON-RPC command.
+type AddRevocationScriptCmd struct {
+	RedeemScript    string
+	SigScriptPrefix *string `jsonrpcdefault:"\"\""`
+}
+
+// NewAddRevocationScriptCmd returns a new instance which can be used to issue
+// an addrevocationscript JSON-RPC command.
+func NewAddRevocationScriptCmd(redeemScript string, sigScriptPrefix *string) *AddRevocationScriptCmd {
+	return &AddRevocationScriptCmd{
+		RedeemScript:    redeemScript,
+		SigScriptPrefix: sigScriptPrefix,
+	}
+}
+
+// AuditChainSubCmd defines the type used in the auditchain JSON-RPC command
+// for the sub command field.
+type AuditChainSubCmd string
+
+const (
+	// ACStart indicates the specified checks should be started in the
+	// background.
+	ACStart AuditChainSubCmd = "start"
+
+	// ACStatus indicates the progress of the current or last audit should
+	// be returned.
+	ACStatus AuditChainSubCmd = "status"
+
+	// ACStop indicates the running audit should be stopped.
+	ACStop AuditChainSubCmd = "stop"
+)
+
+// AuditChainCmd defines the auditchain JSON-RPC command.
+type AuditChainCmd struct {
+	SubCmd      AuditChainSubCmd `jsonrpcusage:"\"start|status|stop\""`
+	Checks      *[]string
+	StartHeight *int64 `jsonrpcdefault:"0"`
+	EndHeight   *int64 `jsonrpcdefault:"-1"`
+}
+
+// NewAuditChainCmd returns a new instance which can be used to issue an
+// auditchain JSON-RPC command.
+func NewAuditChainCmd(subCmd AuditChainSubCmd, checks *[]string, startHeight, endHeight *int64) *AuditChainCmd {
+	return &AuditChainCmd{
+		SubCmd:      subCmd,
+		Checks:      checks,
+		StartHeight: startHeight,
+		EndHeight:   endHeight,
+	}
+}
+
+// DebugScriptCmd defines the debugscript JSON-RPC command.
+type DebugScriptCmd struct {
+	HexTx         string
+	InputIndex    int
+	PkScript      string
+	ScriptVersion *uint16 `jsonrpcdefault:"0"`
+}
+
+// NewDebugScriptCmd returns a new instance which can be used to issue a
+// debugscript JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewDebugScriptCmd(hexTx string, inputIndex int, pkScript string, scriptVersion *uint16) *DebugScriptCmd {
+	return &DebugScriptCmd{
+		HexTx:         hexTx,
+		InputIndex:    inputIndex,
+		PkScript:      pkScript,
+		ScriptVersion: scriptVersion,
+	}
+}
+
+// EstimateFinalityCmd defines the estimatefinality JSON-RPC command.
+type EstimateFinalityCmd struct {
+	TargetProbability *float64 `jsonrpcdefault:"0.001"`
+	HashFraction      *float64 `jsonrpcdefault:"0.1"`
+	StakeFraction     *float64 `jsonrpcdefault:"0.1"`
+}
+
+// NewEstimateFinalityCmd returns a new instance which can be used to issue an
+// estimatefinality JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewEstimateFinalityCmd(targetProbability, hashFraction, stakeFraction *float64) *EstimateFinalityCmd {
+	return &EstimateFinalityCmd{
+		TargetProbability: targetProbability,
+		HashFraction:      hashFraction,
+		StakeFraction:     stakeFraction,
+	}
+}
+
 // EstimateStakeDiffCmd defines the eststakedifficulty JSON-RPC command.
 type EstimateStakeDiffCmd struct {
 	Tickets *uint32
@@ -98,6 +191,16 @@ func NewExistsLiveTicketsCmd(txHashBlob string) *ExistsLiveTicketsCmd {
 	}
 }
 
+// ExpiredTicketsCmd is a type handling custom marshaling and
+// unmarshaling of expiredtickets JSON RPC commands.
+type ExpiredTicketsCmd struct{}
+
+// NewExpiredTicketsCmd returns a new instance which can be used to issue a
+// JSON-RPC expiredtickets command.
+func NewExpiredTicketsCmd() *ExpiredTicketsCmd {
+	return &ExpiredTicketsCmd{}
+}
+
 // ExistsMempoolTxsCmd defines the existsmempooltxs JSON-RPC command.
 type ExistsMempoolTxsCmd struct {
 	TxHashBlob string
@@ -111,6 +214,71 @@ func NewExistsMempoolTxsCmd(txHashBlob string) *ExistsMempoolTxsCmd {
 	}
 }
 
+// GetAddressHistoryCmd defines the getaddresshistory JSON-RPC command.
+type GetAddressHistoryCmd struct {
+	Address string
+	Limit   *int `jsonrpcdefault:"100"`
+	Cursor  *string
+}
+
+// NewGetAddressHistoryCmd returns a new instance which can be used to issue a
+// getaddresshistory JSON-RPC command.
+func NewGetAddressHistoryCmd(address string, limit *int, cursor *string) *GetAddressHistoryCmd {
+	return &GetAddressHistoryCmd{
+		Address: address,
+		Limit:   limit,
+		Cursor:  cursor,
+	}
+}
+
+// GetBlockIndexCmd defines the getblockindex JSON-RPC command.
+type GetBlockIndexCmd struct {
+	StartHeight *int64 `jsonrpcdefault:"0"`
+	Count       *int32 `jsonrpcdefault:"1000"`
+}
+
+// NewGetBlockIndexCmd returns a new instance which can be used to issue a
+// getblockindex JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetBlockIndexCmd(startHeight *int64, count *int32) *GetBlockIndexCmd {
+	return &GetBlockIndexCmd{
+		StartHeight: startHeight,
+		Count:       count,
+	}
+}
+
+// GetCFHeadersCmd defines the getcfheaders JSON-RPC command.
+type GetCFHeadersCmd struct {
+	Hash       string
+	FilterType string
+}
+
+// NewGetCFHeadersCmd returns a new instance which can be used to issue a
+// getcfheaders JSON-RPC command.
+func NewGetCFHeadersCmd(hash string, filterType string) *GetCFHeadersCmd {
+	return &GetCFHeadersCmd{
+		Hash:       hash,
+		FilterType: filterType,
+	}
+}
+
+// GetCFilterCmd defines the getcfilter JSON-RPC command.
+type GetCFilterCmd struct {
+	Hash       string
+	FilterType string
+}
+
+// NewGetCFilterCmd returns a new instance which can be used to issue a
+// getcfilter JSON-RPC command.
+func NewGetCFilterCmd(hash string, filterType string) *GetCFilterCmd {
+	return &GetCFilterCmd{
+		Hash:       hash,
+		FilterType: filterType,
+	}
+}
+
 // GetCoinSupplyCmd defines the getcoinsupply JSON-RPC command.
 type GetCoinSupplyCmd struct{}
 
@@ -120,6 +288,118 @@ func NewGetCoinSupplyCmd() *GetCoinSupplyCmd {
 	return &GetCoinSupplyCmd{}
 }
 
+// GetCPUMinerInfoCmd defines the getcpuminerinfo JSON-RPC command.
+type GetCPUMinerInfoCmd struct{}
+
+// NewGetCPUMinerInfoCmd returns a new instance which can be used to issue a
+// getcpuminerinfo JSON-RPC command.
+func NewGetCPUMinerInfoCmd() *GetCPUMinerInfoCmd {
+	return &GetCPUMinerInfoCmd{}
+}
+
+// GetKeyBlockAttestationCmd defines the getkeyblockattestation JSON-RPC
+// command.
+type GetKeyBlockAttestationCmd struct {
+	KeyHeight int64
+}
+
+// NewGetKeyBlockAttestationCmd returns a new instance which can be used to
+// issue a getkeyblockattestation JSON-RPC command.
+func NewGetKeyBlockAttestationCmd(keyHeight int64) *GetKeyBlockAttestationCmd {
+	return &GetKeyBlockAttestationCmd{
+		KeyHeight: keyHeight,
+	}
+}
+
+// GetKeyBlockInfoCmd defines the getkeyblockinfo JSON-RPC command.
+type GetKeyBlockInfoCmd struct {
+	Hash string
+}
+
+// NewGetKeyBlockInfoCmd returns a new instance which can be used to issue a
+// getkeyblockinfo JSON-RPC command.
+func NewGetKeyBlockInfoCmd(hash string) *GetKeyBlockInfoCmd {
+	return &GetKeyBlockInfoCmd{
+		Hash: hash,
+	}
+}
+
+// GetKnownAddressesCmd defines the getknownaddresses JSON-RPC command.
+type GetKnownAddressesCmd struct {
+	Limit  *int `jsonrpcdefault:"100"`
+	Cursor *string
+}
+
+// NewGetKnownAddressesCmd returns a new instance which can be used to issue a
+// getknownaddresses JSON-RPC command.
+func NewGetKnownAddressesCmd(limit *int, cursor *string) *GetKnownAddressesCmd {
+	return &GetKnownAddressesCmd{
+		Limit:  limit,
+		Cursor: cursor,
+	}
+}
+
+// GetLotteryProofCmd defines the getlotteryproof JSON-RPC command.
+type GetLotteryProofCmd struct {
+	Hash string
+}
+
+// NewGetLotteryProofCmd returns a new instance which can be used to issue a
+// getlotteryproof JSON-RPC command.
+func NewGetLotteryProofCmd(hash string) *GetLotteryProofCmd {
+	return &GetLotteryProofCmd{
+		Hash: hash,
+	}
+}
+
+// GetNoticesCmd defines the getnotices JSON-RPC command.
+type GetNoticesCmd struct{}
+
+// NewGetNoticesCmd returns a new instance which can be used to issue a
+// getnotices JSON-RPC command.
+func NewGetNoticesCmd() *GetNoticesCmd {
+	return &GetNoticesCmd{}
+}
+
+// GetRawTransactionsCmd defines the getrawtransactions JSON-RPC command.
+//
+// NOTE: The verbose field is an int versus a bool to remain consistent with
+// the getrawtransaction command.
+type GetRawTransactionsCmd struct {
+	Txids   []string
+	Verbose *int `jsonrpcdefault:"0"`
+}
+
+// NewGetRawTransactionsCmd returns a new instance which can be used to issue a
+// getrawtransactions JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetRawTransactionsCmd(txids []string, verbose *int) *GetRawTransactionsCmd {
+	return &GetRawTransactionsCmd{
+		Txids:   txids,
+		Verbose: verbose,
+	}
+}
+
+// GetRPCAuthInfoCmd defines the getrpcauthinfo JSON-RPC command.
+type GetRPCAuthInfoCmd struct{}
+
+// NewGetRPCAuthInfoCmd returns a new instance which can be used to issue a
+// getrpcauthinfo JSON-RPC command.
+func NewGetRPCAuthInfoCmd() *GetRPCAuthInfoCmd {
+	return &GetRPCAuthInfoCmd{}
+}
+
+// GetSigCacheInfoCmd defines the getsigcacheinfo JSON-RPC command.
+type GetSigCacheInfoCmd struct{}
+
+// NewGetSigCacheInfoCmd returns a new instance which can be used to issue a
+// getsigcacheinfo JSON-RPC command.
+func NewGetSigCacheInfoCmd() *GetSigCacheInfoCmd {
+	return &GetSigCacheInfoCmd{}
+}
+
 // GetStakeDifficultyCmd is a type handling custom marshaling and
 // unmarshaling of getstakedifficulty JSON RPC commands.
 type GetStakeDifficultyCmd struct{}
@@ -130,6 +410,15 @@ func NewGetStakeDifficultyCmd() *GetStakeDifficultyCmd {
 	return &GetStakeDifficultyCmd{}
 }
 
+// GetStakeNodeCacheInfoCmd defines the getstakenodecacheinfo JSON-RPC command.
+type GetStakeNodeCacheInfoCmd struct{}
+
+// NewGetStakeNodeCacheInfoCmd returns a new instance which can be used to
+// issue a getstakenodecacheinfo JSON-RPC command.
+func NewGetStakeNodeCacheInfoCmd() *GetStakeNodeCacheInfoCmd {
+	return &GetStakeNodeCacheInfoCmd{}
+}
+
 // GetStakeVersionInfoCmd returns stake version info for the current interval.
 // Optionally, Count indicates how many additional intervals to return.
 type GetStakeVersionInfoCmd struct {
@@ -160,6 +449,19 @@ func NewGetStakeVersionsCmd(hash string, count int32) *GetStakeVersionsCmd {
 	}
 }
 
+// GetTemplateDeltaCmd defines the gettemplatedelta JSON-RPC command.
+type GetTemplateDeltaCmd struct {
+	TemplateID string
+}
+
+// NewGetTemplateDeltaCmd returns a new instance which can be used to issue a
+// gettemplatedelta JSON-RPC command.
+func NewGetTemplateDeltaCmd(templateID string) *GetTemplateDeltaCmd {
+	return &GetTemplateDeltaCmd{
+		TemplateID: templateID,
+	}
+}
+
 // GetTicketPoolValueCmd defines the getticketpoolvalue JSON-RPC command.
 type GetTicketPoolValueCmd struct{}
 
@@ -169,6 +471,19 @@ func NewGetTicketPoolValueCmd() *GetTicketPoolValueCmd {
 	return &GetTicketPoolValueCmd{}
 }
 
+// GetTxAcceptanceScoreCmd defines the gettxacceptancescore JSON-RPC command.
+type GetTxAcceptanceScoreCmd struct {
+	Txid string
+}
+
+// NewGetTxAcceptanceScoreCmd returns a new instance which can be used to issue
+// a gettxacceptancescore JSON-RPC command.
+func NewGetTxAcceptanceScoreCmd(txHash string) *GetTxAcceptanceScoreCmd {
+	return &GetTxAcceptanceScoreCmd{
+		Txid: txHash,
+	}
+}
+
 // GetVoteInfoCmd returns voting results over a range of blocks.  Count
 // indicates how many blocks are walked backwards.
 type GetVoteInfoCmd struct {
@@ -183,6 +498,68 @@ func NewGetVoteInfoCmd(version uint32) *GetVoteInfoCmd {
 	}
 }
 
+// GetVoteStatsCmd defines the getvotestats JSON-RPC command.
+type GetVoteStatsCmd struct {
+	Address string
+}
+
+// NewGetVoteStatsCmd returns a new instance which can be used to issue a
+// getvotestats JSON-RPC command.
+func NewGetVoteStatsCmd(address string) *GetVoteStatsCmd {
+	return &GetVoteStatsCmd{
+		Address: address,
+	}
+}
+
+// ListLiveTicketsCmd defines the listlivetickets JSON-RPC command.
+type ListLiveTicketsCmd struct {
+	Limit  *int `jsonrpcdefault:"100"`
+	Cursor *string
+}
+
+// NewListLiveTicketsCmd returns a new instance which can be used to issue a
+// listlivetickets JSON-RPC command.
+func NewListLiveTicketsCmd(limit *int, cursor *string) *ListLiveTicketsCmd {
+	return &ListLiveTicketsCmd{
+		Limit:  limit,
+		Cursor: cursor,
+	}
+}
+
+// ListenerSubCmd defines the type used in the listener JSON-RPC command for the
+// sub command field.
+type ListenerSubCmd string
+
+const (
+	// LAdd indicates a listener for the specified address should be
+	// added.
+	LAdd ListenerSubCmd = "add"
+
+	// LRemove indicates the listeners for the specified address should be
+	// removed.
+	LRemove ListenerSubCmd = "remove"
+
+	// LList indicates the current listeners should be returned.
+	LList ListenerSubCmd = "list"
+)
+
+// ListenerCmd defines the listener JSON-RPC command.
+type ListenerCmd struct {
+	SubCmd  ListenerSubCmd `jsonrpcusage:"\"add|remove|list\""`
+	Service *string        `jsonrpcusage:"\"p2p|rpc\""`
+	Addr    *string
+}
+
+// NewListenerCmd returns a new instance which can be used to issue a listener
+// JSON-RPC command.
+func NewListenerCmd(subCmd ListenerSubCmd, service, addr *string) *ListenerCmd {
+	return &ListenerCmd{
+		SubCmd:  subCmd,
+		Service: service,
+		Addr:    addr,
+	}
+}
+
 // LiveTicketsCmd is a type handling custom marshaling and
 // unmarshaling of livetickets JSON RPC commands.
 type LiveTicketsCmd struct{}
@@ -223,6 +600,37 @@ func NewRebroadcastWinnersCmd() *RebroadcastWinnersCmd {
 	return &RebroadcastWinnersCmd{}
 }
 
+// ReloadConfigCmd defines the reloadconfig JSON-RPC command.
+type ReloadConfigCmd struct{}
+
+// NewReloadConfigCmd returns a new instance which can be used to issue a
+// reloadconfig JSON-RPC command.
+func NewReloadConfigCmd() *ReloadConfigCmd {
+	return &ReloadConfigCmd{}
+}
+
+// RestartCmd defines the restart JSON-RPC command.
+type RestartCmd struct{}
+
+// NewRestartCmd returns a new instance which can be used to issue a restart
+// JSON-RPC command.
+func NewRestartCmd() *RestartCmd {
+	return &RestartCmd{}
+}
+
+// SetMiningAddressesCmd defines the setminingaddresses JSON-RPC command.
+type SetMiningAddressesCmd struct {
+	Payouts map[string]uint32
+}
+
+// NewSetMiningAddressesCmd returns a new instance which can be used to issue a
+// setminingaddresses JSON-RPC command.
+func NewSetMiningAddressesCmd(payouts map[string]uint32) *SetMiningAddressesCmd {
+	return &SetMiningAddressesCmd{
+		Payouts: payouts,
+	}
+}
+
 // TicketFeeInfoCmd defines the ticketsfeeinfo JSON-RPC command.
 type TicketFeeInfoCmd struct {
 	Blocks  *uint32
@@ -294,6 +702,10 @@ func init() {
 	// No special flags for commands in this file.
 	flags := UsageFlag(0)
 
+	MustRegisterCmd("addrevocationscript", (*AddRevocationScriptCmd)(nil), flags)
+	MustRegisterCmd("auditchain", (*AuditChainCmd)(nil), flags)
+	MustRegisterCmd("debugscript", (*DebugScriptCmd)(nil), flags)
+	MustRegisterCmd("estimatefinality", (*EstimateFinalityCmd)(nil), flags)
 	MustRegisterCmd("estimatestakediff", (*EstimateStakeDiffCmd)(nil), flags)
 	MustRegisterCmd("existsaddress", (*ExistsAddressCmd)(nil), flags)
 	MustRegisterCmd("existsaddresses", (*ExistsAddressesCmd)(nil), flags)
@@ -302,16 +714,39 @@ func init() {
 	MustRegisterCmd("existsliveticket", (*ExistsLiveTicketCmd)(nil), flags)
 	MustRegisterCmd("existslivetickets", (*ExistsLiveTicketsCmd)(nil), flags)
 	MustRegisterCmd("existsmempooltxs", (*ExistsMempoolTxsCmd)(nil), flags)
+	MustRegisterCmd("expiredtickets", (*ExpiredTicketsCmd)(nil), flags)
+	MustRegisterCmd("getaddresshistory", (*GetAddressHistoryCmd)(nil), flags)
+	MustRegisterCmd("getblockindex", (*GetBlockIndexCmd)(nil), flags)
+	MustRegisterCmd("getcfheaders", (*GetCFHeadersCmd)(nil), flags)
+	MustRegisterCmd("getcfilter", (*GetCFilterCmd)(nil), flags)
 	MustRegisterCmd("getcoinsupply", (*GetCoinSupplyCmd)(nil), flags)
+	MustRegisterCmd("getcpuminerinfo", (*GetCPUMinerInfoCmd)(nil), flags)
+	MustRegisterCmd("getkeyblockattestation", (*GetKeyBlockAttestationCmd)(nil), flags)
+	MustRegisterCmd("getkeyblockinfo", (*GetKeyBlockInfoCmd)(nil), flags)
+	MustRegisterCmd("getknownaddresses", (*GetKnownAddressesCmd)(nil), flags)
+	MustRegisterCmd("getlotteryproof", (*GetLotteryProofCmd)(nil), flags)
+	MustRegisterCmd("getnotices", (*GetNoticesCmd)(nil), flags)
+	MustRegisterCmd("getrawtransactions", (*GetRawTransactionsCmd)(nil), flags)
+	MustRegisterCmd("getrpcauthinfo", (*GetRPCAuthInfoCmd)(nil), flags)
+	MustRegisterCmd("getsigcacheinfo", (*GetSigCacheInfoCmd)(nil), flags)
 	MustRegisterCmd("getstakedifficulty", (*GetStakeDifficultyCmd)(nil), flags)
+	MustRegisterCmd("getstakenodecacheinfo", (*GetStakeNodeCacheInfoCmd)(nil), flags)
 	MustRegisterCmd("getstakeversioninfo", (*GetStakeVersionInfoCmd)(nil), flags)
 	MustRegisterCmd("getstakeversions", (*GetStakeVersionsCmd)(nil), flags)
+	MustRegisterCmd("gettemplatedelta", (*GetTemplateDeltaCmd)(nil), flags)
 	MustRegisterCmd("getticketpoolvalue", (*GetTicketPoolValueCmd)(nil), flags)
+	MustRegisterCmd("gettxacceptancescore", (*GetTxAcceptanceScoreCmd)(nil), flags)
 	MustRegisterCmd("getvoteinfo", (*GetVoteInfoCmd)(nil), flags)
+	MustRegisterCmd("getvotestats", (*GetVoteStatsCmd)(nil), flags)
+	MustRegisterCmd("listlivetickets", (*ListLiveTicketsCmd)(nil), flags)
+	MustRegisterCmd("listener", (*ListenerCmd)(nil), flags)
 	MustRegisterCmd("livetickets", (*LiveTicketsCmd)(nil), flags)
 	MustRegisterCmd("missedtickets", (*MissedTicketsCmd)(nil), flags)
 	MustRegisterCmd("rebroadcastmissed", (*RebroadcastMissedCmd)(nil), flags)
 	MustRegisterCmd("rebroadcastwinners", (*RebroadcastWinnersCmd)(nil), flags)
+	MustRegisterCmd("reloadconfig", (*ReloadConfigCmd)(nil), flags)
+	MustRegisterCmd("restart", (*RestartCmd)(nil), flags)
+	MustRegisterCmd("setminingaddresses", (*SetMiningAddressesCmd)(nil), flags)
 	MustRegisterCmd("ticketfeeinfo", (*TicketFeeInfoCmd)(nil), flags)
 	MustRegisterCmd("ticketsforaddress", (*TicketsForAddressCmd)(nil), flags)
 	MustRegisterCmd("ticketvwap", (*TicketVWAPCmd)(nil), flags)
@@ -29,6 +29,69 @@ func TestHcashdCmds(t *testing.T) {
 		marshalled   string
 		unmarshalled interface{}
 	}{
+		{
+			name: "addrevocationscript",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("addrevocationscript", "51")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewAddRevocationScriptCmd("51", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"addrevocationscript","params":["51"],"id":1}`,
+			unmarshalled: &hcashjson.AddRevocationScriptCmd{
+				RedeemScript:    "51",
+				SigScriptPrefix: hcashjson.String(""),
+			},
+		},
+		{
+			name: "addrevocationscript optional",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("addrevocationscript", "63ac6751", "00")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewAddRevocationScriptCmd("63ac6751",
+					hcashjson.String("00"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"addrevocationscript","params":["63ac6751","00"],"id":1}`,
+			unmarshalled: &hcashjson.AddRevocationScriptCmd{
+				RedeemScript:    "63ac6751",
+				SigScriptPrefix: hcashjson.String("00"),
+			},
+		},
+		{
+			name: "auditchain",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("auditchain", "status")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewAuditChainCmd(hcashjson.ACStatus, nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"auditchain","params":["status"],"id":1}`,
+			unmarshalled: &hcashjson.AuditChainCmd{
+				SubCmd:      hcashjson.ACStatus,
+				StartHeight: hcashjson.Int64(0),
+				EndHeight:   hcashjson.Int64(-1),
+			},
+		},
+		{
+			name: "auditchain optional",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("auditchain", "start",
+					[]string{"subsidy", "keyheights"}, 100, 200)
+			},
+			staticCmd: func() interface{} {
+				checks := []string{"subsidy", "keyheights"}
+				return hcashjson.NewAuditChainCmd(hcashjson.ACStart,
+					&checks, hcashjson.Int64(100), hcashjson.Int64(200))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"auditchain","params":["start",["subsidy","keyheights"],100,200],"id":1}`,
+			unmarshalled: &hcashjson.AuditChainCmd{
+				SubCmd:      hcashjson.ACStart,
+				Checks:      &[]string{"subsidy", "keyheights"},
+				StartHeight: hcashjson.Int64(100),
+				EndHeight:   hcashjson.Int64(200),
+			},
+		},
 		{
 			name: "debuglevel",
 			newCmd: func() (interface{}, error) {
@@ -42,6 +105,307 @@ func TestHcashdCmds(t *testing.T) {
 				LevelSpec: "trace",
 			},
 		},
+		{
+			name: "debugscript",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("debugscript", "0102", 1, "51")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewDebugScriptCmd("0102", 1, "51", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"debugscript","params":["0102",1,"51"],"id":1}`,
+			unmarshalled: &hcashjson.DebugScriptCmd{
+				HexTx:         "0102",
+				InputIndex:    1,
+				PkScript:      "51",
+				ScriptVersion: hcashjson.Uint16(0),
+			},
+		},
+		{
+			name: "estimatefinality",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("estimatefinality")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewEstimateFinalityCmd(nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"estimatefinality","params":[],"id":1}`,
+			unmarshalled: &hcashjson.EstimateFinalityCmd{
+				TargetProbability: hcashjson.Float64(0.001),
+				HashFraction:      hcashjson.Float64(0.1),
+				StakeFraction:     hcashjson.Float64(0.1),
+			},
+		},
+		{
+			name: "estimatefinality optional",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("estimatefinality", 0.01, 0.25, 0.2)
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewEstimateFinalityCmd(hcashjson.Float64(0.01),
+					hcashjson.Float64(0.25), hcashjson.Float64(0.2))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"estimatefinality","params":[0.01,0.25,0.2],"id":1}`,
+			unmarshalled: &hcashjson.EstimateFinalityCmd{
+				TargetProbability: hcashjson.Float64(0.01),
+				HashFraction:      hcashjson.Float64(0.25),
+				StakeFraction:     hcashjson.Float64(0.2),
+			},
+		},
+		{
+			name: "existsaddress",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("existsaddress", "HsXXX")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewExistsAddressCmd("HsXXX")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"existsaddress","params":["HsXXX"],"id":1}`,
+			unmarshalled: &hcashjson.ExistsAddressCmd{
+				Address: "HsXXX",
+			},
+		},
+		{
+			name: "existsaddresses",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("existsaddresses",
+					[]string{"HsXXX", "HsYYY"})
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewExistsAddressesCmd(
+					[]string{"HsXXX", "HsYYY"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"existsaddresses","params":[["HsXXX","HsYYY"]],"id":1}`,
+			unmarshalled: &hcashjson.ExistsAddressesCmd{
+				Addresses: []string{"HsXXX", "HsYYY"},
+			},
+		},
+		{
+			name: "expiredtickets",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("expiredtickets")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewExpiredTicketsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"expiredtickets","params":[],"id":1}`,
+			unmarshalled: &hcashjson.ExpiredTicketsCmd{},
+		},
+		{
+			name: "getaddresshistory",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getaddresshistory", "1Address")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetAddressHistoryCmd("1Address", nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddresshistory","params":["1Address"],"id":1}`,
+			unmarshalled: &hcashjson.GetAddressHistoryCmd{
+				Address: "1Address",
+				Limit:   hcashjson.Int(100),
+			},
+		},
+		{
+			name: "getaddresshistory optional",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getaddresshistory", "1Address", 10, "20")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetAddressHistoryCmd("1Address",
+					hcashjson.Int(10), hcashjson.String("20"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddresshistory","params":["1Address",10,"20"],"id":1}`,
+			unmarshalled: &hcashjson.GetAddressHistoryCmd{
+				Address: "1Address",
+				Limit:   hcashjson.Int(10),
+				Cursor:  hcashjson.String("20"),
+			},
+		},
+		{
+			name: "getblockindex",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getblockindex")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetBlockIndexCmd(nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockindex","params":[],"id":1}`,
+			unmarshalled: &hcashjson.GetBlockIndexCmd{
+				StartHeight: hcashjson.Int64(0),
+				Count:       hcashjson.Int32(1000),
+			},
+		},
+		{
+			name: "getblockindex optional",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getblockindex", 100, 10)
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetBlockIndexCmd(
+					hcashjson.Int64(100), hcashjson.Int32(10))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockindex","params":[100,10],"id":1}`,
+			unmarshalled: &hcashjson.GetBlockIndexCmd{
+				StartHeight: hcashjson.Int64(100),
+				Count:       hcashjson.Int32(10),
+			},
+		},
+		{
+			name: "getcfheaders",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getcfheaders", "123", "regular")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetCFHeadersCmd("123", "regular")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getcfheaders","params":["123","regular"],"id":1}`,
+			unmarshalled: &hcashjson.GetCFHeadersCmd{
+				Hash:       "123",
+				FilterType: "regular",
+			},
+		},
+		{
+			name: "getcfilter",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getcfilter", "123", "extended")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetCFilterCmd("123", "extended")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getcfilter","params":["123","extended"],"id":1}`,
+			unmarshalled: &hcashjson.GetCFilterCmd{
+				Hash:       "123",
+				FilterType: "extended",
+			},
+		},
+		{
+			name: "getcpuminerinfo",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getcpuminerinfo")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetCPUMinerInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getcpuminerinfo","params":[],"id":1}`,
+			unmarshalled: &hcashjson.GetCPUMinerInfoCmd{},
+		},
+		{
+			name: "getknownaddresses",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getknownaddresses")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetKnownAddressesCmd(nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getknownaddresses","params":[],"id":1}`,
+			unmarshalled: &hcashjson.GetKnownAddressesCmd{
+				Limit: hcashjson.Int(100),
+			},
+		},
+		{
+			name: "getknownaddresses optional",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getknownaddresses", 50, "50")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetKnownAddressesCmd(hcashjson.Int(50),
+					hcashjson.String("50"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getknownaddresses","params":[50,"50"],"id":1}`,
+			unmarshalled: &hcashjson.GetKnownAddressesCmd{
+				Limit:  hcashjson.Int(50),
+				Cursor: hcashjson.String("50"),
+			},
+		},
+		{
+			name: "getlotteryproof",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getlotteryproof", "123")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetLotteryProofCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getlotteryproof","params":["123"],"id":1}`,
+			unmarshalled: &hcashjson.GetLotteryProofCmd{
+				Hash: "123",
+			},
+		},
+		{
+			name: "getnotices",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getnotices")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetNoticesCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getnotices","params":[],"id":1}`,
+			unmarshalled: &hcashjson.GetNoticesCmd{},
+		},
+		{
+			name: "getrawtransactions",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getrawtransactions",
+					[]string{"123", "456"})
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetRawTransactionsCmd(
+					[]string{"123", "456"}, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getrawtransactions","params":[["123","456"]],"id":1}`,
+			unmarshalled: &hcashjson.GetRawTransactionsCmd{
+				Txids:   []string{"123", "456"},
+				Verbose: hcashjson.Int(0),
+			},
+		},
+		{
+			name: "getrawtransactions optional",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getrawtransactions",
+					[]string{"123"}, 1)
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetRawTransactionsCmd(
+					[]string{"123"}, hcashjson.Int(1))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getrawtransactions","params":[["123"],1],"id":1}`,
+			unmarshalled: &hcashjson.GetRawTransactionsCmd{
+				Txids:   []string{"123"},
+				Verbose: hcashjson.Int(1),
+			},
+		},
+		{
+			name: "getrpcauthinfo",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getrpcauthinfo")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetRPCAuthInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getrpcauthinfo","params":[],"id":1}`,
+			unmarshalled: &hcashjson.GetRPCAuthInfoCmd{},
+		},
+		{
+			name: "getsigcacheinfo",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getsigcacheinfo")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetSigCacheInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getsigcacheinfo","params":[],"id":1}`,
+			unmarshalled: &hcashjson.GetSigCacheInfoCmd{},
+		},
+		{
+			name: "getstakenodecacheinfo",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getstakenodecacheinfo")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetStakeNodeCacheInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getstakenodecacheinfo","params":[],"id":1}`,
+			unmarshalled: &hcashjson.GetStakeNodeCacheInfoCmd{},
+		},
 		{
 			name: "getstakeversions",
 			newCmd: func() (interface{}, error) {
@@ -56,6 +420,32 @@ func TestHcashdCmds(t *testing.T) {
 				Count: 1,
 			},
 		},
+		{
+			name: "gettemplatedelta",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("gettemplatedelta", "deadbeef-1")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetTemplateDeltaCmd("deadbeef-1")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettemplatedelta","params":["deadbeef-1"],"id":1}`,
+			unmarshalled: &hcashjson.GetTemplateDeltaCmd{
+				TemplateID: "deadbeef-1",
+			},
+		},
+		{
+			name: "gettxacceptancescore",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("gettxacceptancescore", "123")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetTxAcceptanceScoreCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettxacceptancescore","params":["123"],"id":1}`,
+			unmarshalled: &hcashjson.GetTxAcceptanceScoreCmd{
+				Txid: "123",
+			},
+		},
 		{
 			name: "getvoteinfo",
 			newCmd: func() (interface{}, error) {
@@ -69,6 +459,180 @@ func TestHcashdCmds(t *testing.T) {
 				Version: 1,
 			},
 		},
+		{
+			name: "getvotestats",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getvotestats", "HsXXX")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetVoteStatsCmd("HsXXX")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getvotestats","params":["HsXXX"],"id":1}`,
+			unmarshalled: &hcashjson.GetVoteStatsCmd{
+				Address: "HsXXX",
+			},
+		},
+		{
+			name: "getkeyblockattestation",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getkeyblockattestation", 123)
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetKeyBlockAttestationCmd(123)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getkeyblockattestation","params":[123],"id":1}`,
+			unmarshalled: &hcashjson.GetKeyBlockAttestationCmd{
+				KeyHeight: 123,
+			},
+		},
+		{
+			name: "getkeyblockinfo",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("getkeyblockinfo", "123")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewGetKeyBlockInfoCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getkeyblockinfo","params":["123"],"id":1}`,
+			unmarshalled: &hcashjson.GetKeyBlockInfoCmd{
+				Hash: "123",
+			},
+		},
+		{
+			name: "listlivetickets",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("listlivetickets", 500, "1000")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewListLiveTicketsCmd(hcashjson.Int(500),
+					hcashjson.String("1000"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"listlivetickets","params":[500,"1000"],"id":1}`,
+			unmarshalled: &hcashjson.ListLiveTicketsCmd{
+				Limit:  hcashjson.Int(500),
+				Cursor: hcashjson.String("1000"),
+			},
+		},
+		{
+			name: "listener",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("listener", "list")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewListenerCmd(hcashjson.LList, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"listener","params":["list"],"id":1}`,
+			unmarshalled: &hcashjson.ListenerCmd{
+				SubCmd: hcashjson.LList,
+			},
+		},
+		{
+			name: "listener add",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("listener", "add", "p2p",
+					"10.0.0.1:14008")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewListenerCmd(hcashjson.LAdd,
+					hcashjson.String("p2p"),
+					hcashjson.String("10.0.0.1:14008"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"listener","params":["add","p2p","10.0.0.1:14008"],"id":1}`,
+			unmarshalled: &hcashjson.ListenerCmd{
+				SubCmd:  hcashjson.LAdd,
+				Service: hcashjson.String("p2p"),
+				Addr:    hcashjson.String("10.0.0.1:14008"),
+			},
+		},
+		{
+			name: "reloadconfig",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("reloadconfig")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewReloadConfigCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"reloadconfig","params":[],"id":1}`,
+			unmarshalled: &hcashjson.ReloadConfigCmd{},
+		},
+		{
+			name: "restart",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("restart")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewRestartCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"restart","params":[],"id":1}`,
+			unmarshalled: &hcashjson.RestartCmd{},
+		},
+		{
+			name: "setminingaddresses",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("setminingaddresses",
+					map[string]uint32{"SsXyz": 3, "SsAbc": 1})
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewSetMiningAddressesCmd(
+					map[string]uint32{"SsXyz": 3, "SsAbc": 1})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setminingaddresses","params":[{"SsAbc":1,"SsXyz":3}],"id":1}`,
+			unmarshalled: &hcashjson.SetMiningAddressesCmd{
+				Payouts: map[string]uint32{"SsXyz": 3, "SsAbc": 1},
+			},
+		},
+		{
+			name: "ticketfeeinfo",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("ticketfeeinfo")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewTicketFeeInfoCmd(nil, nil)
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"ticketfeeinfo","params":[],"id":1}`,
+			unmarshalled: &hcashjson.TicketFeeInfoCmd{},
+		},
+		{
+			name: "ticketfeeinfo optional",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("ticketfeeinfo", 5, 3)
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewTicketFeeInfoCmd(hcashjson.Uint32(5),
+					hcashjson.Uint32(3))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"ticketfeeinfo","params":[5,3],"id":1}`,
+			unmarshalled: &hcashjson.TicketFeeInfoCmd{
+				Blocks:  hcashjson.Uint32(5),
+				Windows: hcashjson.Uint32(3),
+			},
+		},
+		{
+			name: "ticketvwap",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("ticketvwap")
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewTicketVWAPCmd(nil, nil, nil)
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"ticketvwap","params":[],"id":1}`,
+			unmarshalled: &hcashjson.TicketVWAPCmd{},
+		},
+		{
+			name: "ticketvwap optional",
+			newCmd: func() (interface{}, error) {
+				return hcashjson.NewCmd("ticketvwap", 100, 200)
+			},
+			staticCmd: func() interface{} {
+				return hcashjson.NewTicketVWAPCmd(hcashjson.Uint32(100),
+					hcashjson.Uint32(200), nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"ticketvwap","params":[100,200],"id":1}`,
+			unmarshalled: &hcashjson.TicketVWAPCmd{
+				Start: hcashjson.Uint32(100),
+				End:   hcashjson.Uint32(200),
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
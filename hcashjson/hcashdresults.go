@@ -5,6 +5,234 @@
 
 package hcashjson
 
+// AuditChainViolation models a single failed invariant reported by the
+// auditchain command.
+type AuditChainViolation struct {
+	Check       string `json:"check"`
+	Height      int64  `json:"height"`
+	Hash        string `json:"hash"`
+	Description string `json:"description"`
+}
+
+// AuditChainResult models the data returned from the auditchain command.
+type AuditChainResult struct {
+	Running       bool                  `json:"running"`
+	Checks        []string              `json:"checks"`
+	StartHeight   int64                 `json:"startheight"`
+	EndHeight     int64                 `json:"endheight"`
+	CurrentHeight int64                 `json:"currentheight"`
+	Progress      float64               `json:"progress"`
+	StartTime     int64                 `json:"starttime"`
+	EndTime       int64                 `json:"endtime,omitempty"`
+	TotalSubsidy  int64                 `json:"totalsubsidy,omitempty"`
+	Violations    []AuditChainViolation `json:"violations"`
+	Error         string                `json:"error,omitempty"`
+}
+
+// LotteryWinnerResult models a winning ticket of the getlotteryproof command
+// along with the proof that it is located at the selected index of the live
+// ticket pool.
+type LotteryWinnerResult struct {
+	Ticket       string   `json:"ticket"`
+	Index        int      `json:"index"`
+	MerkleBranch []string `json:"merklebranch"`
+}
+
+// CPUMinerWorkerResult models a single worker of the CPU miner as returned by
+// the getcpuminerinfo command.
+type CPUMinerWorkerResult struct {
+	ID           uint32  `json:"id"`
+	Uptime       int64   `json:"uptime"`
+	Hashes       uint64  `json:"hashes"`
+	HashesPerSec float64 `json:"hashespersec"`
+	Accepted     uint64  `json:"accepted"`
+	Rejected     uint64  `json:"rejected"`
+}
+
+// AddressHistoryResult models a transaction involving an address as returned
+// by the getaddresshistory command.
+type AddressHistoryResult struct {
+	TxID        string `json:"txid"`
+	BlockHash   string `json:"blockhash"`
+	BlockHeight int64  `json:"blockheight"`
+}
+
+// GetAddressHistoryResult models the data returned from the
+// getaddresshistory command.  It follows the pagination envelope convention
+// described by PageBounds.
+type GetAddressHistoryResult struct {
+	Items      []AddressHistoryResult `json:"items"`
+	NextCursor string                 `json:"nextcursor,omitempty"`
+	Total      int64                  `json:"total"`
+}
+
+// GetCPUMinerInfoResult models the data returned from the getcpuminerinfo
+// command.
+type GetCPUMinerInfoResult struct {
+	Generating     bool                   `json:"generating"`
+	NumWorkers     int32                  `json:"numworkers"`
+	HashesPerSec   float64                `json:"hashespersec"`
+	Accepted       uint64                 `json:"accepted"`
+	Rejected       uint64                 `json:"rejected"`
+	MiningAddrs    []string               `json:"miningaddrs"`
+	NextMiningAddr string                 `json:"nextminingaddr,omitempty"`
+	Workers        []CPUMinerWorkerResult `json:"workers"`
+}
+
+// KnownAddressResult models an address known to the address manager as
+// returned by the getknownaddresses command.
+type KnownAddressResult struct {
+	Addr        string `json:"addr"`
+	Services    string `json:"services"`
+	Timestamp   int64  `json:"timestamp"`
+	Tried       bool   `json:"tried"`
+	Attempts    int    `json:"attempts"`
+	LastAttempt int64  `json:"lastattempt"`
+	LastSuccess int64  `json:"lastsuccess"`
+}
+
+// GetKnownAddressesResult models the data returned from the getknownaddresses
+// command.  It follows the pagination envelope convention described by
+// PageBounds.
+type GetKnownAddressesResult struct {
+	Items      []KnownAddressResult `json:"items"`
+	NextCursor string               `json:"nextcursor,omitempty"`
+	Total      int64                `json:"total"`
+}
+
+// GetLotteryProofResult models the data returned from the getlotteryproof
+// command.
+type GetLotteryProofResult struct {
+	Hash       string                `json:"hash"`
+	Height     int64                 `json:"height"`
+	Seed       string                `json:"seed"`
+	PoolSize   int                   `json:"poolsize"`
+	PoolRoot   string                `json:"poolroot"`
+	Draws      []uint32              `json:"draws"`
+	Winners    []LotteryWinnerResult `json:"winners"`
+	StateHash  string                `json:"statehash"`
+	FinalState string                `json:"finalstate"`
+}
+
+// GetNoticesResult models a single network notice returned from the
+// getnotices command.
+type GetNoticesResult struct {
+	ID         uint64 `json:"id"`
+	Expiration int64  `json:"expiration"`
+	Message    string `json:"message"`
+}
+
+// RawTransactionResult models a transaction as returned by the
+// getrawtransactions command.  Hex is set when the verbose flag is not set and
+// Tx is set when it is, unless the transaction could not be retrieved, in which
+// case Error is set instead.
+type RawTransactionResult struct {
+	Txid  string       `json:"txid"`
+	Hex   string       `json:"hex,omitempty"`
+	Tx    *TxRawResult `json:"tx,omitempty"`
+	Error *RPCError    `json:"error,omitempty"`
+}
+
+// BlockIndexEntryResult models a block in the block index as returned by the
+// getblockindex command.
+type BlockIndexEntryResult struct {
+	Hash         string        `json:"hash"`
+	Height       int64         `json:"height"`
+	KeyHeight    int64         `json:"keyheight"`
+	PrevBlock    string        `json:"previousblockhash"`
+	PrevKeyBlock string        `json:"previouskeyblockhash"`
+	MainChain    bool          `json:"mainchain"`
+	KeyBlock     bool          `json:"keyblock"`
+	StakeNode    bool          `json:"stakenode"`
+	Voters       uint16        `json:"voters"`
+	Votes        []VersionBits `json:"votes"`
+}
+
+// GetBlockIndexResult models the data returned from the getblockindex
+// command.  NextHeight is the start height to request the next page with.
+type GetBlockIndexResult struct {
+	Entries    []BlockIndexEntryResult `json:"entries"`
+	NextHeight int64                   `json:"nextheight"`
+}
+
+// GetKeyBlockAttestationResult models the data returned from the
+// getkeyblockattestation command.  The signature is a base64-encoded compact
+// signature of the message by the attestation key of the node, which may be
+// checked with the verifymessage command and the address.
+type GetKeyBlockAttestationResult struct {
+	Network   string `json:"network"`
+	KeyHeight int64  `json:"keyheight"`
+	Height    int64  `json:"height"`
+	Hash      string `json:"hash"`
+	ChainWork string `json:"chainwork"`
+	Time      int64  `json:"time"`
+	PubKey    string `json:"pubkey"`
+	Address   string `json:"address"`
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+// MicroBlockInfoResult models a microblock anchored by a key block as returned
+// by the getkeyblockinfo command.  Interval is the number of seconds since the
+// previous block.
+type MicroBlockInfoResult struct {
+	Hash     string `json:"hash"`
+	Height   int64  `json:"height"`
+	Time     int64  `json:"time"`
+	Tx       int    `json:"tx"`
+	STx      int    `json:"stx"`
+	Interval int64  `json:"interval"`
+}
+
+// GetKeyBlockInfoResult models the data returned from the getkeyblockinfo
+// command.  The interval statistics are in seconds and cover the time between
+// each block anchored by the key block and the block before it, including the
+// next key block when there is one.
+type GetKeyBlockInfoResult struct {
+	Hash             string                 `json:"hash"`
+	Height           int64                  `json:"height"`
+	KeyHeight        int64                  `json:"keyheight"`
+	Time             int64                  `json:"time"`
+	Tx               int                    `json:"tx"`
+	STx              int                    `json:"stx"`
+	MicroBlocks      []MicroBlockInfoResult `json:"microblocks"`
+	TotalTx          int                    `json:"totaltx"`
+	TotalSTx         int                    `json:"totalstx"`
+	NextKeyBlockHash string                 `json:"nextkeyblockhash,omitempty"`
+	Duration         int64                  `json:"duration"`
+	MinInterval      int64                  `json:"mininterval"`
+	MaxInterval      int64                  `json:"maxinterval"`
+	MeanInterval     float64                `json:"meaninterval"`
+}
+
+// RPCAuthBanResult models a host that is banned from authenticating to the
+// RPC server.
+type RPCAuthBanResult struct {
+	Host        string `json:"host"`
+	Failures    uint32 `json:"failures"`
+	BannedUntil int64  `json:"banneduntil"`
+}
+
+// GetRPCAuthInfoResult models the data returned from the getrpcauthinfo
+// command.
+type GetRPCAuthInfoResult struct {
+	Failures uint64             `json:"failures"`
+	Refused  uint64             `json:"refused"`
+	Bans     uint64             `json:"bans"`
+	Banned   []RPCAuthBanResult `json:"banned"`
+}
+
+// GetSigCacheInfoResult models the data returned from the getsigcacheinfo
+// command.
+type GetSigCacheInfoResult struct {
+	Entries    uint    `json:"entries"`
+	MaxEntries uint    `json:"maxentries"`
+	Hits       uint64  `json:"hits"`
+	Misses     uint64  `json:"misses"`
+	HitRate    float64 `json:"hitrate"`
+	Evictions  uint64  `json:"evictions"`
+}
+
 // GetStakeDifficultyResult models the data returned from the
 // getstakedifficulty command.
 type GetStakeDifficultyResult struct {
@@ -12,6 +240,20 @@ type GetStakeDifficultyResult struct {
 	NextStakeDifficulty    float64 `json:"next"`
 }
 
+// GetStakeNodeCacheInfoResult models the data returned from the
+// getstakenodecacheinfo command.
+type GetStakeNodeCacheInfoResult struct {
+	Hits              uint64  `json:"hits"`
+	ParentConnects    uint64  `json:"parentconnects"`
+	Regenerations     uint64  `json:"regenerations"`
+	HitRate           float64 `json:"hitrate"`
+	ConnectReplays    uint64  `json:"connectreplays"`
+	DisconnectReplays uint64  `json:"disconnectreplays"`
+	DBLookups         uint64  `json:"dblookups"`
+	MaxReplays        uint64  `json:"maxreplays"`
+	RegenerationTime  float64 `json:"regenerationtime"`
+}
+
 // VersionCount models a generic version:count tuple.
 type VersionCount struct {
 	Version uint32 `json:"version"`
@@ -26,6 +268,58 @@ type VersionInterval struct {
 	VoteVersions []VersionCount `json:"voteversions"`
 }
 
+// GetTemplateDeltaResult models the data returned from the gettemplatedelta
+// command.  Stale is set when the template identified by PrevTemplateID is
+// either unknown to the server or was built on a different parent block, in
+// which case the delta is relative to an empty template and any work on the
+// previous template should be abandoned.
+type GetTemplateDeltaResult struct {
+	TemplateID     string                     `json:"templateid"`
+	PrevTemplateID string                     `json:"prevtemplateid"`
+	Stale          bool                       `json:"stale"`
+	Header         string                     `json:"header"`
+	Target         string                     `json:"target"`
+	MinTime        int64                      `json:"mintime"`
+	MaxTime        int64                      `json:"maxtime"`
+	Added          []GetBlockTemplateResultTx `json:"added"`
+	AddedStake     []GetBlockTemplateResultTx `json:"addedstake"`
+	Removed        []string                   `json:"removed"`
+}
+
+// DebugScriptStep models the data of a single step of the script execution
+// returned from the debugscript command.
+type DebugScriptStep struct {
+	Script   int      `json:"script"`
+	Offset   int      `json:"offset"`
+	Opcode   string   `json:"opcode"`
+	Executed bool     `json:"executed"`
+	Stack    []string `json:"stack"`
+	AltStack []string `json:"altstack"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// DebugScriptResult models the data returned from the debugscript command.
+type DebugScriptResult struct {
+	Valid bool              `json:"valid"`
+	Error string            `json:"error,omitempty"`
+	Steps []DebugScriptStep `json:"steps"`
+}
+
+// GetTxAcceptanceScoreResult models the data returned from the
+// gettxacceptancescore command.  The score ranges from 0, for the transactions
+// that are the most likely to be double spent, to 100.
+type GetTxAcceptanceScoreResult struct {
+	Txid                  string  `json:"txid"`
+	Score                 int     `json:"score"`
+	FeeRate               float64 `json:"feerate"`
+	FeeRatePercentile     float64 `json:"feeratepercentile"`
+	PoolBacklog           float64 `json:"poolbacklog"`
+	UnconfirmedInputs     int     `json:"unconfirmedinputs"`
+	MinInputConfirmations int64   `json:"mininputconfirmations"`
+	ConflictingSpends     int     `json:"conflictingspends"`
+	SignalsReplacement    bool    `json:"signalsreplacement"`
+}
+
 // GetStakeVersionInfoResult models the resulting data for getstakeversioninfo
 // command.
 type GetStakeVersionInfoResult struct {
@@ -90,6 +384,29 @@ type GetVoteInfoResult struct {
 	Agendas       []Agenda `json:"agendas,omitempty"`
 }
 
+// GetVoteStatsResult models the data returned from the getvotestats command.
+// The inclusion percentage is the percentage of the tickets of the address
+// which voted out of all of its tickets that either voted or were revoked, and
+// the average latency is in key blocks.
+type GetVoteStatsResult struct {
+	Address             string  `json:"address"`
+	Votes               uint32  `json:"votes"`
+	Missed              uint32  `json:"missed"`
+	Expired             uint32  `json:"expired"`
+	InclusionPercentage float64 `json:"inclusionpercentage"`
+	AverageLatency      float64 `json:"averagelatency"`
+}
+
+// EstimateFinalityResult models the data returned from the estimatefinality
+// command.
+type EstimateFinalityResult struct {
+	KeyBlocks        int64   `json:"keyblocks"`
+	Seconds          int64   `json:"seconds"`
+	ReorgProbability float64 `json:"reorgprobability"`
+	Participation    float64 `json:"participation"`
+	AttackerShare    float64 `json:"attackershare"`
+}
+
 // EstimateStakeDiffResult models the data returned from the estimatestakediff
 // command.
 type EstimateStakeDiffResult struct {
@@ -99,6 +416,21 @@ type EstimateStakeDiffResult struct {
 	User     *float64 `json:"user,omitempty"`
 }
 
+// ListLiveTicketsResult models the data returned from the listlivetickets
+// command.  It follows the pagination envelope convention described by
+// PageBounds.
+type ListLiveTicketsResult struct {
+	Items      []string `json:"items"`
+	NextCursor string   `json:"nextcursor,omitempty"`
+	Total      int64    `json:"total"`
+}
+
+// ListenerResult models the data returned from the listener command.
+type ListenerResult struct {
+	P2P []string `json:"p2p"`
+	RPC []string `json:"rpc"`
+}
+
 // LiveTicketsResult models the data returned from the livetickets
 // command.
 type LiveTicketsResult struct {
@@ -111,6 +443,20 @@ type MissedTicketsResult struct {
 	Tickets []string `json:"tickets"`
 }
 
+// ExpiredTicketsResult models the data returned from the expiredtickets
+// command.
+type ExpiredTicketsResult struct {
+	Tickets []string `json:"tickets"`
+}
+
+// ReloadConfigResult models the data returned from the reloadconfig command.
+// Applied lists the changed options which took effect, while Ignored lists the
+// changed options which only take effect once hcashd is restarted.
+type ReloadConfigResult struct {
+	Applied []string `json:"applied"`
+	Ignored []string `json:"ignored"`
+}
+
 // Ticket is the structure representing a ticket.
 type Ticket struct {
 	Hash  string `json:"hash"`
@@ -148,17 +148,19 @@ func NewNewTicketsNtfn(hash string, height int32, stakeDiff int64, tickets []str
 // StakeDifficultyNtfn is a type handling custom marshaling and
 // unmarshaling of stakedifficulty JSON websocket notifications.
 type StakeDifficultyNtfn struct {
-	BlockHash   string
-	BlockHeight int32
-	StakeDiff   int64
+	BlockHash       string
+	BlockHeight     int32
+	StakeDiff       int64
+	EffectiveHeight int32
 }
 
 // NewStakeDifficultyNtfn creates a new StakeDifficultyNtfn.
-func NewStakeDifficultyNtfn(hash string, height int32, stakeDiff int64) *StakeDifficultyNtfn {
+func NewStakeDifficultyNtfn(hash string, height int32, stakeDiff int64, effectiveHeight int32) *StakeDifficultyNtfn {
 	return &StakeDifficultyNtfn{
-		BlockHash:   hash,
-		BlockHeight: height,
-		StakeDiff:   stakeDiff,
+		BlockHash:       hash,
+		BlockHeight:     height,
+		StakeDiff:       stakeDiff,
+		EffectiveHeight: effectiveHeight,
 	}
 }
 
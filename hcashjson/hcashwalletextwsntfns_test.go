@@ -123,6 +123,22 @@ func TestHcashwalletChainSvrWsNtfns(t *testing.T) {
 				Tickets:   []string{"a", "b"},
 			},
 		},
+		{
+			name: "stakedifficulty",
+			newNtfn: func() (interface{}, error) {
+				return hcashjson.NewCmd("stakedifficulty", "123", 100, 3, 101)
+			},
+			staticNtfn: func() interface{} {
+				return hcashjson.NewStakeDifficultyNtfn("123", 100, 3, 101)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"stakedifficulty","params":["123",100,3,101],"id":null}`,
+			unmarshalled: &hcashjson.StakeDifficultyNtfn{
+				BlockHash:       "123",
+				BlockHeight:     100,
+				StakeDiff:       3,
+				EffectiveHeight: 101,
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
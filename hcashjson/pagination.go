@@ -0,0 +1,68 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hcashjson
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// The commands returning a page of a list follow a common convention so
+// clients can handle pagination uniformly.  The commands accept an optional
+// limit on the number of items to return and an optional cursor, and their
+// results are envelopes with the following fields:
+//
+//	items       the items of the page
+//	nextcursor  the cursor to pass to request the next page, which is omitted
+//	            on the last page
+//	total       the total number of items in the list
+//
+// Cursors are opaque to clients and only valid for the command that returned
+// them.  Items may be skipped or repeated when the list changes between the
+// requests of two pages.
+const (
+	// DefaultPageLimit is the number of items returned per page when the
+	// limit is not specified.
+	DefaultPageLimit = 100
+
+	// MaxPageLimit is the maximum number of items that may be requested
+	// per page.
+	MaxPageLimit = 1000
+)
+
+// PageBounds returns the bounds [start, end) of the page of a list with the
+// passed total number of items that starts at the passed cursor, which is
+// nil or empty for the first page, along with the cursor of the next page.
+// The next cursor is empty when the page is the last one.  The limit defaults
+// to DefaultPageLimit when nil.
+func PageBounds(total int, cursor *string, limit *int) (int, int, string, error) {
+	pageLimit := DefaultPageLimit
+	if limit != nil {
+		pageLimit = *limit
+	}
+	if pageLimit < 1 || pageLimit > MaxPageLimit {
+		return 0, 0, "", fmt.Errorf("limit %d is not in the range 1 to "+
+			"%d", pageLimit, MaxPageLimit)
+	}
+
+	var start int
+	if cursor != nil && *cursor != "" {
+		offset, err := strconv.ParseUint(*cursor, 10, 31)
+		if err != nil {
+			return 0, 0, "", errors.New("invalid cursor")
+		}
+		start = int(offset)
+	}
+	if start > total {
+		start = total
+	}
+
+	end := start + pageLimit
+	if end >= total {
+		return start, total, "", nil
+	}
+	return start, end, strconv.Itoa(end), nil
+}
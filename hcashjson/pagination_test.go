@@ -0,0 +1,66 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hcashjson_test
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcashd/hcashjson"
+)
+
+// TestPageBounds ensures the bounds and next cursors of pages are calculated
+// correctly and invalid limits and cursors are rejected.
+func TestPageBounds(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		total      int
+		cursor     *string
+		limit      *int
+		start      int
+		end        int
+		nextCursor string
+		err        bool
+	}{
+		{"empty", 0, nil, nil, 0, 0, "", false},
+		{"default limit", 250, nil, nil, 0, 100, "100", false},
+		{"middle page", 250, hcashjson.String("100"), hcashjson.Int(100),
+			100, 200, "200", false},
+		{"last page", 250, hcashjson.String("200"), hcashjson.Int(100),
+			200, 250, "", false},
+		{"exact last page", 200, hcashjson.String("100"),
+			hcashjson.Int(100), 100, 200, "", false},
+		{"empty cursor", 5, hcashjson.String(""), hcashjson.Int(2), 0, 2,
+			"2", false},
+		{"cursor past end", 5, hcashjson.String("10"), nil, 5, 5, "",
+			false},
+		{"zero limit", 5, nil, hcashjson.Int(0), 0, 0, "", true},
+		{"limit too large", 5, nil, hcashjson.Int(hcashjson.MaxPageLimit + 1),
+			0, 0, "", true},
+		{"negative cursor", 5, hcashjson.String("-1"), nil, 0, 0, "", true},
+		{"invalid cursor", 5, hcashjson.String("abc"), nil, 0, 0, "", true},
+	}
+
+	for _, test := range tests {
+		start, end, next, err := hcashjson.PageBounds(test.total,
+			test.cursor, test.limit)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if start != test.start || end != test.end || next != test.nextCursor {
+			t.Errorf("%s: got [%d, %d) next %q, want [%d, %d) next %q",
+				test.name, start, end, next, test.start, test.end,
+				test.nextCursor)
+		}
+	}
+}
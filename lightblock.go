@@ -0,0 +1,127 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// pendingLightBlock houses the state of a block which is being reconstructed
+// from a light block announcement.  The transactions are keyed by the hashes
+// of all transactions of the block and are nil while they are still missing.
+type pendingLightBlock struct {
+	msg  *wire.MsgLightBlock
+	txns map[chainhash.Hash]*wire.MsgTx
+}
+
+// newPendingLightBlock returns the reconstruction state for the passed light
+// block.  The prefilled transactions of the light block are used along with
+// the transactions returned by the passed fetch function, which typically
+// looks them up in the memory pool.
+func newPendingLightBlock(msg *wire.MsgLightBlock, fetchTx func(*chainhash.Hash) *wire.MsgTx) *pendingLightBlock {
+	numTxns := len(msg.TxHashes) + len(msg.STxHashes)
+	lb := &pendingLightBlock{
+		msg:  msg,
+		txns: make(map[chainhash.Hash]*wire.MsgTx, numTxns),
+	}
+	for _, hash := range msg.TxHashes {
+		lb.txns[hash] = nil
+	}
+	for _, hash := range msg.STxHashes {
+		lb.txns[hash] = nil
+	}
+	lb.addTxns(msg.PrefilledTxs)
+
+	for hash, tx := range lb.txns {
+		if tx == nil {
+			lb.txns[hash] = fetchTx(&hash)
+		}
+	}
+	return lb
+}
+
+// addTxns adds the passed transactions to the block being reconstructed.
+// Transactions which are not part of the block are ignored.
+func (lb *pendingLightBlock) addTxns(txns []*wire.MsgTx) {
+	for _, tx := range txns {
+		hash := tx.TxHash()
+		if _, ok := lb.txns[hash]; ok {
+			lb.txns[hash] = tx
+		}
+	}
+}
+
+// missingTxns returns the hashes of the transactions of the block which are
+// still unknown in block order.
+func (lb *pendingLightBlock) missingTxns() []chainhash.Hash {
+	var missing []chainhash.Hash
+	for _, hashes := range [][]chainhash.Hash{lb.msg.TxHashes,
+		lb.msg.STxHashes} {
+
+		for _, hash := range hashes {
+			if lb.txns[hash] == nil {
+				missing = append(missing, hash)
+			}
+		}
+	}
+	return missing
+}
+
+// block returns the reconstructed block, or nil when any of its transactions
+// are still missing.  The returned block must be checked with merkleRootsMatch
+// before it is processed.
+func (lb *pendingLightBlock) block() *wire.MsgBlock {
+	block := &wire.MsgBlock{
+		Header:        lb.msg.Header,
+		Transactions:  make([]*wire.MsgTx, 0, len(lb.msg.TxHashes)),
+		STransactions: make([]*wire.MsgTx, 0, len(lb.msg.STxHashes)),
+	}
+	for _, hash := range lb.msg.TxHashes {
+		tx := lb.txns[hash]
+		if tx == nil {
+			return nil
+		}
+		block.Transactions = append(block.Transactions, tx)
+	}
+	for _, hash := range lb.msg.STxHashes {
+		stx := lb.txns[hash]
+		if stx == nil {
+			return nil
+		}
+		block.STransactions = append(block.STransactions, stx)
+	}
+	return block
+}
+
+// merkleRootsMatch returns whether the merkle roots in the header of the passed
+// block reconstructed from a light block commit to its transactions.  Light
+// blocks only list the prefix hashes of the transactions while the merkle roots
+// commit to the full hashes, so a memory pool transaction with the same prefix
+// but a different witness results in a block with an invalid merkle root even
+// though the announcing peer sent a valid block.  The roots are built the same
+// way checkBlockSanity builds them.
+func merkleRootsMatch(block *wire.MsgBlock) bool {
+	header := &block.Header
+	blockHash := block.BlockHash()
+	target := standalone.CompactToBig(header.Bits)
+	isKeyBlock := standalone.HashToBig(&blockHash).Cmp(target) <= 0
+
+	b := hcashutil.NewBlock(block)
+	merkles := blockchain.BuildMerkleTreeStore(b.Transactions(), !isKeyBlock)
+	if !header.MerkleRoot.IsEqual(merkles[len(merkles)-1]) {
+		return false
+	}
+	if isKeyBlock {
+		merkles = blockchain.BuildMerkleTreeStore(b.STransactions(), false)
+		if !header.StakeRoot.IsEqual(merkles[len(merkles)-1]) {
+			return false
+		}
+	}
+	return true
+}
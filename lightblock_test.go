@@ -0,0 +1,74 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// TestLightBlockWitnessMismatch ensures a block reconstructed from a light
+// block is detected as not matching its merkle roots when a memory pool
+// transaction only shares the prefix hash with the transaction of the block.
+func TestLightBlockWitnessMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		bits     uint32
+		keyBlock bool
+	}{
+		// A target above the largest hash makes every block a key block,
+		// while a target of one makes none of them a key block.
+		{"key block", 0x2200ffff, true},
+		{"regular block", 0x03000001, false},
+	}
+
+	for _, test := range tests {
+		coinbase := wire.NewMsgTx()
+		coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+			wire.MaxPrevOutIndex, wire.TxTreeRegular), nil))
+		coinbase.AddTxOut(wire.NewTxOut(0, make([]byte, 38)))
+		coinbase.AddTxOut(wire.NewTxOut(1, nil))
+
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0,
+			wire.TxTreeRegular), []byte{0x01}))
+		tx.AddTxOut(wire.NewTxOut(1, nil))
+
+		block := &wire.MsgBlock{
+			Header:       wire.BlockHeader{Bits: test.bits},
+			Transactions: []*wire.MsgTx{coinbase, tx},
+		}
+		txns := hcashutil.NewBlock(block).Transactions()
+		merkles := blockchain.BuildMerkleTreeStore(txns, !test.keyBlock)
+		block.Header.MerkleRoot = *merkles[len(merkles)-1]
+
+		// The memory pool variant only differs in the witness.
+		mempoolTx := tx.Copy()
+		mempoolTx.TxIn[0].SignatureScript = []byte{0x02}
+		if mempoolTx.TxHash() != tx.TxHash() {
+			t.Fatalf("%s: prefix hashes differ", test.name)
+		}
+
+		msg := wire.NewMsgLightBlockFromBlock(block)
+		for _, fetched := range []*wire.MsgTx{tx, mempoolTx} {
+			lb := newPendingLightBlock(msg, func(*chainhash.Hash) *wire.MsgTx {
+				return fetched
+			})
+			rebuilt := lb.block()
+			if rebuilt == nil {
+				t.Fatalf("%s: block not reconstructed", test.name)
+			}
+			want := fetched == tx
+			if got := merkleRootsMatch(rebuilt); got != want {
+				t.Fatalf("%s: merkle roots match %v, want %v",
+					test.name, got, want)
+			}
+		}
+	}
+}
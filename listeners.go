@@ -0,0 +1,75 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"net"
+)
+
+// listenFunc describes a function that creates a listener on the passed
+// network and address, such as net.Listen.
+type listenFunc func(network, addr string) (net.Listener, error)
+
+// errNoListenerMatch indicates an address passed to remove a listener does not
+// match any of the current listeners.
+var errNoListenerMatch = errors.New("no listener matches the address")
+
+// listenOn creates listeners for the passed address with the passed function.
+// An address with a host that applies to all interfaces results in an IPv4 and
+// an IPv6 listener.  Either all listeners are created or none.
+func listenOn(listen listenFunc, addr string) ([]net.Listener, error) {
+	ipv4Addrs, ipv6Addrs, _, err := parseListeners([]string{addr})
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(ipv4Addrs)+len(ipv6Addrs))
+	closeAll := func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}
+	for _, addr := range ipv4Addrs {
+		listener, err := listen("tcp4", addr)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+	for _, addr := range ipv6Addrs {
+		listener, err := listen("tcp6", addr)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// listenerMatches returns whether or not the passed listener address matches
+// the passed listen address.  The hosts of both addresses are compared as IPs
+// so equivalent notations match, and a listen address with an empty host
+// matches the listeners on all interfaces for its port.
+func listenerMatches(listenerAddr net.Addr, addr string) bool {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	lHost, lPort, err := net.SplitHostPort(listenerAddr.String())
+	if err != nil || lPort != port {
+		return false
+	}
+
+	lIP := net.ParseIP(lHost)
+	if host == "" {
+		return lIP != nil && lIP.IsUnspecified()
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.Equal(lIP)
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+// TestListenerMatches ensures listen addresses passed to remove listeners
+// match the expected listener addresses.
+func TestListenerMatches(t *testing.T) {
+	tests := []struct {
+		listener string
+		addr     string
+		want     bool
+	}{
+		{"127.0.0.1:14008", "127.0.0.1:14008", true},
+		{"127.0.0.1:14008", "127.0.0.1:14009", false},
+		{"127.0.0.1:14008", "127.0.0.2:14008", false},
+		{"[::1]:14008", "[0:0::1]:14008", true},
+		{"0.0.0.0:14008", ":14008", true},
+		{"[::]:14008", ":14008", true},
+		{"127.0.0.1:14008", ":14008", false},
+		{"127.0.0.1:14008", "127.0.0.1", false},
+		{"127.0.0.1:14008", "localhost:14008", false},
+	}
+
+	for i, test := range tests {
+		addr, err := net.ResolveTCPAddr("tcp", test.listener)
+		if err != nil {
+			t.Fatalf("#%d: unable to resolve %s: %v", i, test.listener,
+				err)
+		}
+		got := listenerMatches(addr, test.addr)
+		if got != test.want {
+			t.Errorf("#%d: listenerMatches(%s, %s) = %v, want %v", i,
+				test.listener, test.addr, got, test.want)
+		}
+	}
+}
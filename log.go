@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/btcsuite/btclog"
 	"github.com/HcashOrg/hcashd/addrmgr"
@@ -30,13 +31,25 @@ const (
 )
 
 // logWriter implements an io.Writer that outputs to both standard output and
-// the write-end pipe of an initialized log rotator.
+// the write-end pipe of an initialized log rotator.  The entries are written in
+// the configured log format, and the entries of the subsystems with a separate
+// log file are written to the log rotator of the subsystem instead.
 type logWriter struct{}
 
 func (logWriter) Write(p []byte) (n int, err error) {
+	n = len(p)
+	r := logRotator
+	if len(subsystemLogRotators) > 0 {
+		if sr, ok := subsystemLogRotators[logLineSubsystem(p)]; ok {
+			r = sr
+		}
+	}
+	if logFormat == logFormatJSON {
+		p = formatJSONLogLine(p)
+	}
 	os.Stdout.Write(p)
-	logRotator.Write(p)
-	return len(p), nil
+	r.Write(p)
+	return n, nil
 }
 
 // Loggers per subsystem.  A single backend logger is created and all subsytem
@@ -57,6 +70,15 @@ var (
 	// application shutdown.
 	logRotator *rotator.Rotator
 
+	// subsystemLogRotators houses the log rotators of the subsystems which
+	// are logged to a separate file, keyed by subsystem.  They should be
+	// closed on application shutdown along with logRotator.
+	subsystemLogRotators map[string]*rotator.Rotator
+
+	// logFormat is the format the log entries are written in.  It is set
+	// along with the log rotators and never changes afterwards.
+	logFormat = logFormatText
+
 	adxrLog = backendLog.Logger("ADXR")
 	amgrLog = backendLog.Logger("AMGR")
 	cmgrLog = backendLog.Logger("CMGR")
@@ -68,6 +90,7 @@ var (
 	indxLog = backendLog.Logger("INDX")
 	minrLog = backendLog.Logger("MINR")
 	peerLog = backendLog.Logger("PEER")
+	revkLog = backendLog.Logger("REVK")
 	rpcsLog = backendLog.Logger("RPCS")
 	scrpLog = backendLog.Logger("SCRP")
 	srvrLog = backendLog.Logger("SRVR")
@@ -101,6 +124,7 @@ var subsystemLoggers = map[string]btclog.Logger{
 	"INDX": indxLog,
 	"MINR": minrLog,
 	"PEER": peerLog,
+	"REVK": revkLog,
 	"RPCS": rpcsLog,
 	"SCRP": scrpLog,
 	"SRVR": srvrLog,
@@ -109,24 +133,59 @@ var subsystemLoggers = map[string]btclog.Logger{
 }
 
 // initLogRotator initializes the logging rotater to write logs to logFile and
-// create roll files in the same directory.  It must be called before the
-// package-global log rotater variables are used.
-func initLogRotator(logFile string) {
+// create roll files in the same directory.  The log files are rotated once they
+// reach maxSizeMiB and at most maxRolls roll files are kept.  The entries of
+// the passed subsystems are written to separate log files in the same
+// directory, which are named after the subsystem and rotated independently,
+// instead of logFile.  It must be called before the package-global log rotater
+// variables are used.
+func initLogRotator(logFile string, format string, maxSizeMiB int64, maxRolls int,
+	subsystems []string) {
+
 	logDir, _ := filepath.Split(logFile)
 	err := os.MkdirAll(logDir, 0700)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to create log directory: %v\n", err)
 		os.Exit(1)
 	}
-	r, err := rotator.New(logFile, 10*1024, false, 3)
+	r, err := rotator.New(logFile, maxSizeMiB*1024, false, maxRolls)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to create file rotator: %v\n", err)
 		os.Exit(1)
 	}
 
+	rotators := make(map[string]*rotator.Rotator, len(subsystems))
+	for _, subsystemID := range subsystems {
+		if _, ok := rotators[subsystemID]; ok {
+			continue
+		}
+		filename := filepath.Join(logDir,
+			strings.ToLower(subsystemID)+".log")
+		sr, err := rotator.New(filename, maxSizeMiB*1024, false, maxRolls)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create file rotator: "+
+				"%v\n", err)
+			os.Exit(1)
+		}
+		rotators[subsystemID] = sr
+	}
+
+	logFormat = format
+	subsystemLogRotators = rotators
 	logRotator = r
 }
 
+// closeLogRotators closes the log rotators, if they were initialized.
+func closeLogRotators() {
+	if logRotator == nil {
+		return
+	}
+	for _, r := range subsystemLogRotators {
+		r.Close()
+	}
+	logRotator.Close()
+}
+
 // setLogLevel sets the logging level for provided subsystem.  Invalid
 // subsystems are ignored.  Uninitialized subsystems are dynamically created as
 // needed.
@@ -166,6 +225,6 @@ func directionString(inbound bool) string {
 func fatalf(str string) {
 	hcashdLog.Errorf("Unable to create profiler: %v", str)
 	os.Stdout.Sync()
-	logRotator.Close()
+	closeLogRotators()
 	os.Exit(1)
 }
@@ -0,0 +1,145 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	// logFormatText is the log format which writes the entries as plain
+	// text lines, which is the default.
+	logFormatText = "text"
+
+	// logFormatJSON is the log format which writes each entry as a JSON
+	// object on a line of its own.
+	logFormatJSON = "json"
+
+	// logTimeLayout is the layout of the timestamps of the log entries
+	// written by the logging backend.
+	logTimeLayout = "2006-01-02 15:04:05.000"
+)
+
+// logLevels maps the level tags of the log entries written by the logging
+// backend to the names of the levels in the JSON log entries.
+var logLevels = map[string]string{
+	"TRC": "trace",
+	"DBG": "debug",
+	"INF": "info",
+	"WRN": "warn",
+	"ERR": "error",
+	"CRT": "critical",
+}
+
+var (
+	// logPeerRegexp matches the peers in log messages, which are logged as
+	// their address followed by the direction of the connection.
+	logPeerRegexp = regexp.MustCompile(`(\S+) \((?:inbound|outbound)\)`)
+
+	// logHashRegexp matches the block and transaction hashes in log
+	// messages.
+	logHashRegexp = regexp.MustCompile(`\b[0-9a-f]{64}\b`)
+
+	// logHeightRegexp matches the block heights in log messages.
+	logHeightRegexp = regexp.MustCompile(`\bheight:? (\d+)\b`)
+)
+
+// logEntry is a log entry as written by the JSON log format.  The peer, hash
+// and height fields are only set when the message of the entry refers to them,
+// in which case the first peer, hash and height of the message are used.
+type logEntry struct {
+	Time      string `json:"time"`
+	Level     string `json:"level"`
+	Subsystem string `json:"subsystem"`
+	Message   string `json:"msg"`
+	Peer      string `json:"peer,omitempty"`
+	Hash      string `json:"hash,omitempty"`
+	Height    *int64 `json:"height,omitempty"`
+}
+
+// parseLogLine splits a log line written by the logging backend, which is of
+// the form "2006-01-02 15:04:05.000 [INF] SUBS: message", into its timestamp,
+// level tag, subsystem and message.  The last return value is false when the
+// line is not of that form.
+func parseLogLine(line []byte) (timestamp, level, subsystem, msg string, ok bool) {
+	line = bytes.TrimRight(line, "\n")
+	if len(line) < len(logTimeLayout)+2 || line[len(logTimeLayout)] != ' ' {
+		return "", "", "", "", false
+	}
+	timestamp = string(line[:len(logTimeLayout)])
+	rest := line[len(logTimeLayout)+1:]
+
+	if len(rest) < 6 || rest[0] != '[' || rest[4] != ']' || rest[5] != ' ' {
+		return "", "", "", "", false
+	}
+	level = string(rest[1:4])
+	rest = rest[6:]
+
+	sep := bytes.Index(rest, []byte(": "))
+	if sep <= 0 {
+		return "", "", "", "", false
+	}
+	subsystem = string(rest[:sep])
+	msg = string(rest[sep+2:])
+	return timestamp, level, subsystem, msg, true
+}
+
+// logLineSubsystem returns the subsystem of the passed log line written by the
+// logging backend, or an empty string when it has none.
+func logLineSubsystem(line []byte) string {
+	_, _, subsystem, _, _ := parseLogLine(line)
+	return subsystem
+}
+
+// newLogEntry returns the JSON log entry for the passed log line written by the
+// logging backend.  Lines which are not of the form written by the backend are
+// used as the message of an entry without a level and subsystem.
+func newLogEntry(line []byte) *logEntry {
+	timestamp, level, subsystem, msg, ok := parseLogLine(line)
+	if !ok {
+		return &logEntry{
+			Time:    time.Now().Format(time.RFC3339Nano),
+			Message: string(bytes.TrimRight(line, "\n")),
+		}
+	}
+
+	entry := &logEntry{
+		Time:      timestamp,
+		Level:     logLevels[level],
+		Subsystem: subsystem,
+		Message:   msg,
+	}
+	if t, err := time.ParseInLocation(logTimeLayout, timestamp, time.Local); err == nil {
+		entry.Time = t.Format(time.RFC3339Nano)
+	}
+	if entry.Level == "" {
+		entry.Level = level
+	}
+	if match := logPeerRegexp.FindStringSubmatch(msg); match != nil {
+		entry.Peer = match[1]
+	}
+	entry.Hash = logHashRegexp.FindString(msg)
+	if match := logHeightRegexp.FindStringSubmatch(msg); match != nil {
+		height, err := strconv.ParseInt(match[1], 10, 64)
+		if err == nil {
+			entry.Height = &height
+		}
+	}
+	return entry
+}
+
+// formatJSONLogLine returns the passed log line written by the logging backend
+// as a line with the JSON encoding of its log entry.
+func formatJSONLogLine(line []byte) []byte {
+	b, err := json.Marshal(newLogEntry(line))
+	if err != nil {
+		return line
+	}
+	return append(b, '\n')
+}
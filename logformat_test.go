@@ -0,0 +1,110 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestFormatJSONLogLine ensures the log lines written by the logging backend
+// are converted to JSON log entries with the fields their messages refer to.
+func TestFormatJSONLogLine(t *testing.T) {
+	const hash = "000000000000437482b6d47f82f374cde539440ddb108b0a76886f0d87d126b9"
+	height := int64(1234)
+
+	tests := []struct {
+		name string
+		line string
+		want logEntry
+	}{
+		{
+			name: "plain message",
+			line: "2017-06-01 12:00:00.000 [INF] SRVR: Server listening on [::]:14008\n",
+			want: logEntry{
+				Level:     "info",
+				Subsystem: "SRVR",
+				Message:   "Server listening on [::]:14008",
+			},
+		},
+		{
+			name: "peer",
+			line: "2017-06-01 12:00:00.000 [DBG] PEER: Connected to 10.0.0.1:14008 (outbound)\n",
+			want: logEntry{
+				Level:     "debug",
+				Subsystem: "PEER",
+				Message:   "Connected to 10.0.0.1:14008 (outbound)",
+				Peer:      "10.0.0.1:14008",
+			},
+		},
+		{
+			name: "block",
+			line: "2017-06-01 12:00:00.000 [WRN] BMGR: Rejected block " + hash +
+				" at height 1234 from [::1]:14008 (inbound)\n",
+			want: logEntry{
+				Level:     "warn",
+				Subsystem: "BMGR",
+				Message: "Rejected block " + hash + " at height 1234 " +
+					"from [::1]:14008 (inbound)",
+				Peer:   "[::1]:14008",
+				Hash:   hash,
+				Height: &height,
+			},
+		},
+		{
+			name: "malformed",
+			line: "not a log line\n",
+			want: logEntry{Message: "not a log line"},
+		},
+	}
+
+	for _, test := range tests {
+		var got logEntry
+		line := formatJSONLogLine([]byte(test.line))
+		if len(line) == 0 || line[len(line)-1] != '\n' {
+			t.Errorf("%s: line %q is not terminated by a newline",
+				test.name, line)
+			continue
+		}
+		if err := json.Unmarshal(line, &got); err != nil {
+			t.Errorf("%s: malformed entry %q: %v", test.name, line, err)
+			continue
+		}
+		if got.Time == "" {
+			t.Errorf("%s: entry has no time", test.name)
+		}
+		got.Time = ""
+		if (got.Height == nil) != (test.want.Height == nil) ||
+			(got.Height != nil && *got.Height != *test.want.Height) {
+
+			t.Errorf("%s: got height %v, want %v", test.name,
+				got.Height, test.want.Height)
+		}
+		got.Height, test.want.Height = nil, nil
+		if got != test.want {
+			t.Errorf("%s: got entry %+v, want %+v", test.name, got,
+				test.want)
+		}
+	}
+}
+
+// TestLogLineSubsystem ensures the subsystem is extracted from the log lines
+// written by the logging backend.
+func TestLogLineSubsystem(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"2017-06-01 12:00:00.000 [INF] TXMP: Evicted 2 transactions\n", "TXMP"},
+		{"2017-06-01 12:00:00.000 [INF] HCASHD: Version 0.1.0: beta\n", "HCASHD"},
+		{"2017-06-01 12:00:00.000 INF TXMP: Evicted\n", ""},
+		{"", ""},
+	}
+	for i, test := range tests {
+		if got := logLineSubsystem([]byte(test.line)); got != test.want {
+			t.Errorf("#%d: got subsystem %q, want %q", i, got, test.want)
+		}
+	}
+}
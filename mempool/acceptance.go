@@ -0,0 +1,273 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+const (
+	// MaxAcceptanceScore is the score of unconfirmed transactions which are
+	// considered to be the least likely to be double spent.
+	MaxAcceptanceScore = 100
+
+	// maxTrackedConflicts is the maximum number of distinct conflicting
+	// spends that are tracked for a transaction in the pool.
+	maxTrackedConflicts = 16
+
+	// acceptanceFeeWeight is the maximum penalty for paying a fee rate
+	// which is low compared to the other transactions in the pool when the
+	// pool is congested.
+	acceptanceFeeWeight = 30
+
+	// acceptanceUnconfirmedInputWeight is the penalty for spending the
+	// outputs of transactions which are unconfirmed themselves.
+	acceptanceUnconfirmedInputWeight = 20
+
+	// acceptanceInputAgeWeight is the maximum penalty for spending outputs
+	// with few confirmations.  It decreases linearly until the inputs have
+	// acceptanceMatureConfirmations confirmations.
+	acceptanceInputAgeWeight = 10
+
+	// acceptanceMatureConfirmations is the number of confirmations after
+	// which inputs no longer add to the risk.
+	acceptanceMatureConfirmations = 6
+
+	// acceptanceConflictWeight is the penalty for the first conflicting
+	// spend of the inputs which was seen.
+	acceptanceConflictWeight = 40
+
+	// acceptanceExtraConflictWeight is the additional penalty for every
+	// further conflicting spend which was seen.
+	acceptanceExtraConflictWeight = 10
+
+	// acceptanceReplaceableWeight is the penalty for signaling that the
+	// transaction may be replaced.
+	acceptanceReplaceableWeight = 10
+)
+
+// AcceptanceScore houses the double spend risk assessment of an unconfirmed
+// transaction in the pool along with the factors it was derived from.  The
+// score ranges from 0, which is the riskiest, to MaxAcceptanceScore.
+type AcceptanceScore struct {
+	Score int
+
+	// FeeRate is the fee rate of the transaction in atoms/kB, and
+	// FeeRatePercentile is the percentage of the other transactions of the
+	// same type in the pool which pay the same or a lower fee rate.
+	FeeRate           int64
+	FeeRatePercentile float64
+
+	// PoolBacklog is the size of the transactions of the same type in the
+	// pool expressed as a number of maximum size blocks.  Low fee rates
+	// only add to the risk once the backlog exceeds a single block.
+	PoolBacklog float64
+
+	// UnconfirmedInputs is the number of inputs which spend transactions
+	// in the pool, and MinInputConfirmations is the lowest number of
+	// confirmations of the inputs which spend main chain outputs.
+	UnconfirmedInputs     int
+	MinInputConfirmations int64
+
+	// ConflictingSpends is the number of distinct conflicting spends of
+	// the inputs of the transaction or of its unconfirmed ancestors which
+	// were seen and rejected by the pool.
+	ConflictingSpends int
+
+	// SignalsReplacement is whether any input signals replaceability via
+	// its sequence number, as defined by BIP0125.
+	SignalsReplacement bool
+}
+
+// calcAcceptanceScore returns the score for the passed factors.
+func calcAcceptanceScore(s *AcceptanceScore) int {
+	var penalty float64
+
+	// Low fee rates are only a risk when the pool does not fit into the
+	// next block, and are at their riskiest once the backlog exceeds two
+	// blocks.
+	pressure := math.Min(math.Max(s.PoolBacklog-1, 0), 1)
+	penalty += acceptanceFeeWeight * pressure *
+		(1 - s.FeeRatePercentile/100)
+
+	if s.UnconfirmedInputs > 0 {
+		penalty += acceptanceUnconfirmedInputWeight
+	}
+	if confs := s.MinInputConfirmations; confs > 0 &&
+		confs < acceptanceMatureConfirmations {
+
+		penalty += acceptanceInputAgeWeight *
+			float64(acceptanceMatureConfirmations-confs) /
+			(acceptanceMatureConfirmations - 1)
+	}
+
+	if s.ConflictingSpends > 0 {
+		penalty += acceptanceConflictWeight +
+			acceptanceExtraConflictWeight*float64(s.ConflictingSpends-1)
+	}
+	if s.SignalsReplacement {
+		penalty += acceptanceReplaceableWeight
+	}
+
+	score := MaxAcceptanceScore - int(math.Ceil(penalty))
+	if score < 0 {
+		score = 0
+	}
+	return score
+}
+
+// signalsReplacement returns whether any input of the passed transaction
+// signals replaceability as defined by BIP0125, which is a sequence number
+// less than the maximum sequence number minus one.
+func signalsReplacement(msgTx *wire.MsgTx) bool {
+	for _, txIn := range msgTx.TxIn {
+		if txIn.Sequence < wire.MaxTxInSequenceNum-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// int64s implements sort.Interface to allow a slice of int64s to be sorted in
+// ascending order.
+type int64s []int64
+
+func (s int64s) Len() int           { return len(s) }
+func (s int64s) Less(i, j int) bool { return s[i] < s[j] }
+func (s int64s) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// feeRatePercentile returns the percentage of the passed fee rates which are
+// less than or equal to the passed fee rate.  The fee rates must be sorted in
+// ascending order.  Fee rates are considered to be at the highest percentile
+// when there is nothing to compare them to.
+func feeRatePercentile(feeRate int64, sorted []int64) float64 {
+	if len(sorted) == 0 {
+		return 100
+	}
+	n := sort.Search(len(sorted), func(i int) bool {
+		return sorted[i] > feeRate
+	})
+	return float64(n) * 100 / float64(len(sorted))
+}
+
+// recordConflict records that the passed transaction, which was rejected,
+// attempts to spend an output already spent by the passed pool transaction.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) recordConflict(poolTxHash, txHash *chainhash.Hash) {
+	conflicts := mp.conflicts[*poolTxHash]
+	if conflicts == nil {
+		conflicts = make(map[chainhash.Hash]struct{})
+		mp.conflicts[*poolTxHash] = conflicts
+	}
+	if len(conflicts) < maxTrackedConflicts {
+		conflicts[*txHash] = struct{}{}
+	}
+}
+
+// numConflicts returns the number of distinct conflicting spends seen for the
+// passed pool transaction and its ancestors in the pool.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) numConflicts(txDesc *TxDesc) int {
+	seen := make(map[chainhash.Hash]struct{})
+	numConflicts := 0
+	pending := []*TxDesc{txDesc}
+	for len(pending) > 0 {
+		desc := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if _, ok := seen[*desc.Tx.Hash()]; ok {
+			continue
+		}
+		seen[*desc.Tx.Hash()] = struct{}{}
+		numConflicts += len(mp.conflicts[*desc.Tx.Hash()])
+
+		for _, txIn := range desc.Tx.MsgTx().TxIn {
+			parent, ok := mp.pool[txIn.PreviousOutPoint.Hash]
+			if ok {
+				pending = append(pending, parent)
+			}
+		}
+	}
+	return numConflicts
+}
+
+// AcceptanceScore returns the double spend risk assessment of the transaction
+// with the passed hash in the pool, which is intended to help deciding whether
+// to accept it before it is confirmed.  It considers the fee rate compared to
+// the other transactions in the pool when the pool is congested, the number of
+// confirmations of its inputs, the conflicting spends of its inputs which were
+// seen, and whether it signals replaceability.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) AcceptanceScore(txHash *chainhash.Hash) (*AcceptanceScore, error) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	txDesc, exists := mp.pool[*txHash]
+	if !exists {
+		return nil, fmt.Errorf("transaction %v is not in the pool", txHash)
+	}
+	tx := txDesc.Tx
+	msgTx := tx.MsgTx()
+
+	score := &AcceptanceScore{
+		FeeRate:            txDesc.Fee * 1000 / int64(msgTx.SerializeSize()),
+		ConflictingSpends:  mp.numConflicts(txDesc),
+		SignalsReplacement: signalsReplacement(msgTx),
+	}
+
+	// Compare the fee rate to the fee rates of the other transactions of
+	// the same type in the pool, which compete for the same block space.
+	var poolSize int
+	feeRates := make([]int64, 0, len(mp.pool))
+	for hash, desc := range mp.pool {
+		if desc.Type != txDesc.Type {
+			continue
+		}
+		size := desc.Tx.MsgTx().SerializeSize()
+		poolSize += size
+		if hash != *txHash {
+			feeRates = append(feeRates, desc.Fee*1000/int64(size))
+		}
+	}
+	sort.Sort(int64s(feeRates))
+	score.FeeRatePercentile = feeRatePercentile(score.FeeRate, feeRates)
+	blockSizes := mp.cfg.ChainParams.MaximumBlockSizes
+	score.PoolBacklog = float64(poolSize) / float64(blockSizes[len(blockSizes)-1])
+
+	// Determine the age of the inputs.
+	utxoView, err := mp.fetchInputUtxos(tx)
+	if err != nil {
+		return nil, err
+	}
+	bestHeight := mp.cfg.BestHeight()
+	score.MinInputConfirmations = math.MaxInt64
+	for _, txIn := range msgTx.TxIn {
+		entry := utxoView.LookupEntry(&txIn.PreviousOutPoint.Hash)
+		if entry == nil {
+			continue
+		}
+		if entry.BlockHeight() == mempoolHeight {
+			score.UnconfirmedInputs++
+			continue
+		}
+		confs := bestHeight - entry.BlockHeight() + 1
+		if confs < score.MinInputConfirmations {
+			score.MinInputConfirmations = confs
+		}
+	}
+	if score.MinInputConfirmations == math.MaxInt64 {
+		score.MinInputConfirmations = 0
+	}
+
+	score.Score = calcAcceptanceScore(score)
+	return score, nil
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/mining"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// TestCalcAcceptanceScore ensures the acceptance score penalizes each of the
+// risk factors as intended.
+func TestCalcAcceptanceScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		score AcceptanceScore
+		want  int
+	}{{
+		name:  "no risk factors",
+		score: AcceptanceScore{MinInputConfirmations: 100},
+		want:  MaxAcceptanceScore,
+	}, {
+		name: "low fee rate without congestion",
+		score: AcceptanceScore{PoolBacklog: 0.9,
+			MinInputConfirmations: 100},
+		want: MaxAcceptanceScore,
+	}, {
+		name: "low fee rate with congestion",
+		score: AcceptanceScore{PoolBacklog: 3,
+			MinInputConfirmations: 100},
+		want: MaxAcceptanceScore - acceptanceFeeWeight,
+	}, {
+		name: "median fee rate with moderate congestion",
+		score: AcceptanceScore{FeeRatePercentile: 50, PoolBacklog: 1.5,
+			MinInputConfirmations: 100},
+		want: MaxAcceptanceScore - 8,
+	}, {
+		name: "high fee rate with congestion",
+		score: AcceptanceScore{FeeRatePercentile: 100, PoolBacklog: 3,
+			MinInputConfirmations: 100},
+		want: MaxAcceptanceScore,
+	}, {
+		name:  "unconfirmed inputs",
+		score: AcceptanceScore{UnconfirmedInputs: 2},
+		want:  MaxAcceptanceScore - acceptanceUnconfirmedInputWeight,
+	}, {
+		name:  "single confirmation",
+		score: AcceptanceScore{MinInputConfirmations: 1},
+		want:  MaxAcceptanceScore - acceptanceInputAgeWeight,
+	}, {
+		name:  "mature inputs",
+		score: AcceptanceScore{MinInputConfirmations: 6},
+		want:  MaxAcceptanceScore,
+	}, {
+		name: "conflicting spends",
+		score: AcceptanceScore{ConflictingSpends: 2,
+			MinInputConfirmations: 100},
+		want: MaxAcceptanceScore - acceptanceConflictWeight -
+			acceptanceExtraConflictWeight,
+	}, {
+		name: "signals replacement",
+		score: AcceptanceScore{SignalsReplacement: true,
+			MinInputConfirmations: 100},
+		want: MaxAcceptanceScore - acceptanceReplaceableWeight,
+	}, {
+		name: "all risk factors",
+		score: AcceptanceScore{PoolBacklog: 3, UnconfirmedInputs: 1,
+			MinInputConfirmations: 1, ConflictingSpends: 3,
+			SignalsReplacement: true},
+		want: 0,
+	}}
+
+	for _, test := range tests {
+		got := calcAcceptanceScore(&test.score)
+		if got != test.want {
+			t.Errorf("%s: got score %d, want %d", test.name, got,
+				test.want)
+		}
+	}
+}
+
+// TestFeeRatePercentile ensures fee rate percentiles are calculated properly.
+func TestFeeRatePercentile(t *testing.T) {
+	sorted := []int64{1000, 2000, 2000, 5000}
+	tests := []struct {
+		feeRate int64
+		sorted  []int64
+		want    float64
+	}{
+		{feeRate: 500, sorted: sorted, want: 0},
+		{feeRate: 1000, sorted: sorted, want: 25},
+		{feeRate: 2000, sorted: sorted, want: 75},
+		{feeRate: 4000, sorted: sorted, want: 75},
+		{feeRate: 9000, sorted: sorted, want: 100},
+		{feeRate: 0, sorted: nil, want: 100},
+	}
+
+	for i, test := range tests {
+		got := feeRatePercentile(test.feeRate, test.sorted)
+		if got != test.want {
+			t.Errorf("test #%d: got percentile %v, want %v", i, got,
+				test.want)
+		}
+	}
+}
+
+// TestNumConflicts ensures the distinct conflicting spends of a transaction
+// and its unconfirmed ancestors are counted and are forgotten once the
+// transactions are removed from the pool.
+func TestNumConflicts(t *testing.T) {
+	mp := New(&Config{})
+
+	// addTx adds a transaction spending the passed outpoint to the pool.
+	addTx := func(prevOut wire.OutPoint, lockTime uint32) *hcashutil.Tx {
+		msgTx := wire.NewMsgTx()
+		msgTx.LockTime = lockTime
+		msgTx.AddTxIn(wire.NewTxIn(&prevOut, nil))
+		msgTx.AddTxOut(wire.NewTxOut(1000, nil))
+		tx := hcashutil.NewTx(msgTx)
+		mp.pool[*tx.Hash()] = &TxDesc{TxDesc: mining.TxDesc{Tx: tx}}
+		return tx
+	}
+	parent := addTx(wire.OutPoint{Hash: chainhash.Hash{0x01}}, 1)
+	child := addTx(wire.OutPoint{Hash: *parent.Hash()}, 2)
+
+	conflict1, conflict2 := chainhash.Hash{0x02}, chainhash.Hash{0x03}
+	mp.recordConflict(parent.Hash(), &conflict1)
+	mp.recordConflict(parent.Hash(), &conflict1)
+	mp.recordConflict(child.Hash(), &conflict2)
+
+	if got := mp.numConflicts(mp.pool[*parent.Hash()]); got != 1 {
+		t.Errorf("got %d conflicts for parent, want 1", got)
+	}
+	if got := mp.numConflicts(mp.pool[*child.Hash()]); got != 2 {
+		t.Errorf("got %d conflicts for child, want 2", got)
+	}
+
+	mp.removeTransaction(parent, false)
+	if _, ok := mp.conflicts[*parent.Hash()]; ok {
+		t.Error("conflicts of removed transaction not forgotten")
+	}
+	if got := mp.numConflicts(mp.pool[*child.Hash()]); got != 1 {
+		t.Errorf("got %d conflicts for child, want 1", got)
+	}
+}
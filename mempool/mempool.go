@@ -19,6 +19,7 @@ import (
 	"github.com/HcashOrg/hcashd/blockchain"
 	"github.com/HcashOrg/hcashd/blockchain/indexers"
 	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/hcashjson"
@@ -37,7 +38,9 @@ const (
 
 	// MinHighPriority is the minimum priority value that allows a
 	// transaction to be considered high priority.
-	MinHighPriority = hcashutil.AtomsPerCoin * 144.0 / 250
+	//
+	// Deprecated: Use mining.MinHighPriority instead.
+	MinHighPriority = mining.MinHighPriority
 
 	// mempoolHeight is the height used for the "block" height field of the
 	// contextual transaction information provided in a transaction view.
@@ -62,6 +65,12 @@ const (
 	// of height before SSGen relating to that block are pruned.
 	heightDiffToPruneVotes = 10
 
+	// staleVoteKeyDepth is the number of key blocks below the key block
+	// tip whose votes are kept by PruneStaleVotes.  A key block competing
+	// with the tip at the same key height needs the votes on the key
+	// block before the tip, so those must survive for a reorganization.
+	staleVoteKeyDepth = 1
+
 	// If a vote is on a block whose height is before tip minus this
 	// amount, reject it from being added to the mempool.
 	maximumVoteAgeDelta = 1440
@@ -72,11 +81,7 @@ const (
 )
 
 // VoteTx is a struct describing a block vote (SSGen).
-type VoteTx struct {
-	SsgenHash chainhash.Hash // Vote
-	SstxHash  chainhash.Hash // Ticket
-	Vote      bool
-}
+type VoteTx = mining.VoteDesc
 
 // Config is a descriptor containing the memory pool configuration.
 type Config struct {
@@ -177,6 +182,14 @@ type Policy struct {
 	// of the max signature operations for a block.
 	MaxSigOpsPerTx int
 
+	// MaxScriptCostPerTx is the maximum estimated cost of validating the
+	// input scripts of a single transaction we will relay or mine
+	// according to the script cost model of txscript.EstimateScriptCost.
+	// Unlike the signature operation count, the cost accounts for the
+	// different verification times of the signature suites and for other
+	// expensive opcodes.  Zero disables the limit.
+	MaxScriptCostPerTx int64
+
 	// MinRelayTxFee defines the minimum transaction fee in BTC/kB to be
 	// considered a non-zero fee.
 	MinRelayTxFee hcashutil.Amount
@@ -184,6 +197,12 @@ type Policy struct {
 	// AllowOldVotes defines whether or not votes on old blocks will be
 	// admitted and relayed.
 	AllowOldVotes bool
+
+	// MaxPoolSize is the maximum total serialized size in bytes of the
+	// transactions in the pool.  The transactions paying the lowest fee
+	// rates are evicted once it is exceeded, which raises the dynamic
+	// minimum fee rate.  Zero disables the limit.
+	MaxPoolSize int64
 }
 
 // TxDesc is a descriptor containing a transaction in the mempool along with
@@ -201,7 +220,9 @@ type TxDesc struct {
 // peers.
 type TxPool struct {
 	// The following variables must only be used atomically.
-	lastUpdated int64 // last time pool was updated.
+	lastUpdated       int64  // last time pool was updated.
+	staleVotesEvicted uint64 // number of votes evicted on stale key blocks.
+	sizeEvicted       uint64 // number of txs evicted from the full pool.
 
 	mtx           sync.RWMutex
 	cfg           Config
@@ -211,12 +232,23 @@ type TxPool struct {
 	addrindex     map[string]map[chainhash.Hash]struct{} // maps address to txs
 	outpoints     map[wire.OutPoint]*hcashutil.Tx
 
+	// conflicts houses the distinct conflicting spends which were seen and
+	// rejected for the transactions in the pool.
+	conflicts map[chainhash.Hash]map[chainhash.Hash]struct{}
+
 	// Votes on blocks.
 	votesMtx sync.Mutex
 	votes    map[chainhash.Hash][]*VoteTx
 
 	pennyTotal    float64 // exponentially decaying total for penny spends.
 	lastPennyUnix int64   // unix time of last ``penny spend''
+
+	// poolSize is the total serialized size of the transactions in the
+	// pool, and minFeeRate is the dynamic minimum fee rate in atoms/kB
+	// which rises while the pool is full.
+	poolSize           int64
+	minFeeRate         float64
+	lastMinFeeRateUnix int64
 }
 
 // insertVote inserts a vote into the map of block votes.
@@ -251,7 +283,11 @@ func (mp *TxPool) insertVote(ssgen *hcashutil.Tx) error {
 	// Append the new vote.
 	voteBits := stake.SSGenVoteBits(msgTx)
 	vote := hcashutil.IsFlagSet16(voteBits, hcashutil.BlockValid)
-	mp.votes[blockHash] = append(vts, &VoteTx{*voteHash, *ticketHash, vote})
+	mp.votes[blockHash] = append(vts, &VoteTx{
+		SsgenHash: *voteHash,
+		SstxHash:  *ticketHash,
+		Vote:      vote,
+	})
 
 	log.Debugf("Accepted vote %v for block hash %v (height %v, keyHeight %v), voting "+
 		"%v on the transaction tree", voteHash, blockHash, blockHeight, blockKeyHeight,
@@ -373,7 +409,7 @@ func (mp *TxPool) limitNumOrphans() error {
 			if foundHash == nil {
 				foundHash = &txHash
 			}
-			txHashNum := blockchain.HashToBig(&txHash)
+			txHashNum := standalone.HashToBig(&txHash)
 			if txHashNum.Cmp(randHashNum) > 0 {
 				foundHash = &txHash
 				break
@@ -569,7 +605,9 @@ func (mp *TxPool) removeTransaction(tx *hcashutil.Tx, removeRedeemers bool) {
 		for _, txIn := range txDesc.Tx.MsgTx().TxIn {
 			delete(mp.outpoints, txIn.PreviousOutPoint)
 		}
+		delete(mp.conflicts, *txHash)
 		delete(mp.pool, *txHash)
+		mp.poolSize -= int64(msgTx.SerializeSize())
 		atomic.StoreInt64(&mp.lastUpdated, time.Now().Unix())
 	}
 }
@@ -626,11 +664,12 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint,
 			Height: height,
 			Fee:    fee,
 		},
-		StartingPriority: CalcPriority(msgTx, utxoView, height),
+		StartingPriority: mining.CalcPriority(msgTx, utxoView, height),
 	}
 	for _, txIn := range msgTx.TxIn {
 		mp.outpoints[txIn.PreviousOutPoint] = tx
 	}
+	mp.poolSize += int64(msgTx.SerializeSize())
 	atomic.StoreInt64(&mp.lastUpdated, time.Now().Unix())
 
 	// Add unconfirmed address index entries associated with the transaction
@@ -646,9 +685,10 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint,
 // checkPoolDoubleSpend checks whether or not the passed transaction is
 // attempting to spend coins already spent by other transactions in the pool.
 // Note it does not check for double spends against transactions already in the
-// main chain.
+// main chain.  The conflicting spend is recorded for the transaction in the
+// pool so it contributes to its acceptance score.
 //
-// This function MUST be called with the mempool lock held (for reads).
+// This function MUST be called with the mempool lock held (for writes).
 func (mp *TxPool) checkPoolDoubleSpend(tx *hcashutil.Tx, txType stake.TxType) error {
 	for i, txIn := range tx.MsgTx().TxIn {
 		// We don't care about double spends of stake bases.
@@ -658,6 +698,7 @@ func (mp *TxPool) checkPoolDoubleSpend(tx *hcashutil.Tx, txType stake.TxType) er
 		}
 
 		if txR, exists := mp.outpoints[txIn.PreviousOutPoint]; exists {
+			mp.recordConflict(txR.Hash(), tx.Hash())
 			str := fmt.Sprintf("transaction %v in the pool "+
 				"already spends the same coins", txR.Hash())
 			return txRuleError(wire.RejectDuplicate, str)
@@ -777,7 +818,7 @@ func (mp *TxPool) FetchTransaction(txHash *chainhash.Hash, includeRecentBlock bo
 // so that we can easily pick different stake tx types from the mempool later.
 // This should probably be done at the bottom using "IsSStx" etc functions.
 // It should also set the hcashutil tree type for the tx as well.
-func (mp *TxPool) maybeAcceptTransaction(chain *blockchain.BlockChain, tx *hcashutil.Tx, isNew, rateLimit, allowHighFees bool) ([]*chainhash.Hash, error) {
+func (mp *TxPool) maybeAcceptTransaction(chain *blockchain.BlockChain, tx *hcashutil.Tx, isNew, rateLimit, allowHighFees bool, limiter *PeerRateLimiter) ([]*chainhash.Hash, error) {
 	msgTx := tx.MsgTx()
 	txHash := tx.Hash()
 	// Don't accept the transaction if it already exists in the pool.  This
@@ -1055,6 +1096,18 @@ func (mp *TxPool) maybeAcceptTransaction(chain *blockchain.BlockChain, tx *hcash
 		return nil, txRuleError(wire.RejectNonstandard, str)
 	}
 
+	// Don't allow transactions with input scripts that are estimated to be
+	// too expensive to validate.
+	if mp.cfg.Policy.MaxScriptCostPerTx > 0 {
+		scriptCost := calcTxScriptCost(tx, txType, utxoView)
+		if scriptCost > mp.cfg.Policy.MaxScriptCostPerTx {
+			str := fmt.Sprintf("transaction %v has too expensive "+
+				"scripts: cost %d > %d", txHash, scriptCost,
+				mp.cfg.Policy.MaxScriptCostPerTx)
+			return nil, txRuleError(wire.RejectNonstandard, str)
+		}
+	}
+
 	// Don't allow transactions with fees too low to get into a mined block.
 	//
 	// Most miners allow a free transaction area in blocks they mine to go
@@ -1082,6 +1135,22 @@ func (mp *TxPool) maybeAcceptTransaction(chain *blockchain.BlockChain, tx *hcash
 		}
 	}
 
+	// Don't allow new transactions which pay less than the dynamic minimum
+	// fee rate, which rises while the pool is full, since they would be
+	// evicted right away.  Transactions which are being added back to the
+	// memory pool from blocks that have been disconnected during a reorg
+	// are exempted, as are votes and revocations.
+	if isNew && isEvictable(txType) {
+		dynamicMinFee := calcMinRequiredTxRelayFee(serializedSize,
+			mp.dynamicMinFeeRate(time.Now()))
+		if txFee < dynamicMinFee {
+			str := fmt.Sprintf("transaction %v has %v fees which "+
+				"is under the mempool minimum fee of %v", txHash,
+				txFee, dynamicMinFee)
+			return nil, txRuleError(wire.RejectInsufficientFee, str)
+		}
+	}
+
 	// Require that free transactions have sufficient priority to be mined
 	// in the next block.  Transactions which are being added back to the
 	// memory pool from blocks that have been disconnected during a reorg
@@ -1091,12 +1160,12 @@ func (mp *TxPool) maybeAcceptTransaction(chain *blockchain.BlockChain, tx *hcash
 	if isNew && !mp.cfg.Policy.DisableRelayPriority && txFee < minFee &&
 		txType == stake.TxTypeRegular {
 
-		currentPriority := CalcPriority(msgTx, utxoView,
+		currentPriority := mining.CalcPriority(msgTx, utxoView,
 			nextBlockHeight)
-		if currentPriority <= MinHighPriority {
+		if currentPriority <= mining.MinHighPriority {
 			str := fmt.Sprintf("transaction %v has insufficient "+
 				"priority (%g <= %g)", txHash,
-				currentPriority, MinHighPriority)
+				currentPriority, mining.MinHighPriority)
 			return nil, txRuleError(wire.RejectInsufficientFee, str)
 		}
 	}
@@ -1126,6 +1195,18 @@ func (mp *TxPool) maybeAcceptTransaction(chain *blockchain.BlockChain, tx *hcash
 			mp.cfg.Policy.FreeTxRelayLimit*10*1000)
 	}
 
+	// Limit the rate of transactions accepted from the relaying peer so a
+	// few connections are unable to flood the pool.  Votes are exempted
+	// since they are required for blocks to be valid.
+	if limiter != nil && txType != stake.TxTypeSSGen {
+		lowFee := txFee < minFee && txType == stake.TxTypeRegular
+		err := limiter.checkTransaction(txHash, serializedSize, lowFee,
+			time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Check that tickets also pay the minimum of the relay fee.  This fee is
 	// also performed on regular transactions above, but fees lower than the
 	// miniumum may be allowed when there is sufficient priority, and these
@@ -1180,6 +1261,19 @@ func (mp *TxPool) maybeAcceptTransaction(chain *blockchain.BlockChain, tx *hcash
 		}
 	}
 
+	// Evict the transactions paying the lowest fee rates when the pool
+	// exceeds its maximum size, which may include the new transaction.
+	if mp.cfg.Policy.MaxPoolSize > 0 &&
+		mp.poolSize > mp.cfg.Policy.MaxPoolSize {
+
+		mp.trimToSize(time.Now())
+		if !mp.isTransactionInPool(txHash) {
+			str := fmt.Sprintf("transaction %v was evicted right "+
+				"away since the mempool is full", txHash)
+			return nil, txRuleError(wire.RejectInsufficientFee, str)
+		}
+	}
+
 	log.Debugf("Accepted transaction %v (pool size: %v)", txHash,
 		len(mp.pool))
 
@@ -1197,7 +1291,8 @@ func (mp *TxPool) maybeAcceptTransaction(chain *blockchain.BlockChain, tx *hcash
 func (mp *TxPool) MaybeAcceptTransaction(chain *blockchain.BlockChain, tx *hcashutil.Tx, isNew, rateLimit bool) ([]*chainhash.Hash, error) {
 	// Protect concurrent access.
 	mp.mtx.Lock()
-	hashes, err := mp.maybeAcceptTransaction(chain, tx, isNew, rateLimit, true)
+	hashes, err := mp.maybeAcceptTransaction(chain, tx, isNew, rateLimit, true,
+		nil)
 	mp.mtx.Unlock()
 
 	return hashes, err
@@ -1248,7 +1343,7 @@ func (mp *TxPool) processOrphans(chain *blockchain.BlockChain, hash *chainhash.H
 			// Potentially accept the transaction into the
 			// transaction pool.
 			missingParents, err := mp.maybeAcceptTransaction(chain, tx,
-				true, true, true)
+				true, true, true, nil)
 			if err != nil {
 				// TODO: Remove orphans that depend on this
 				// failed transaction.
@@ -1344,6 +1439,71 @@ func (mp *TxPool) pruneExpiredTx(realKeyHeight int64) {
 	}
 }
 
+// PruneStaleVotes evicts all votes from the mempool which vote on a key block
+// more than staleVoteKeyDepth key blocks below the passed key height of the
+// current key block tip.  A key block at key height K only includes votes on
+// the key block at K-1, so such votes can no longer be included in a block on
+// the main chain and only waste relay bandwidth until their standard expiry.
+// Votes on the key block before the tip are kept since a key block competing
+// with the tip includes them, and votes on the tip are kept since the next key
+// block includes them.  This is intended to be called every time a new key
+// block is connected.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) PruneStaleVotes(tipKeyHeight int64) {
+	// Protect concurrent access.
+	mp.mtx.Lock()
+	mp.pruneStaleVotes(tipKeyHeight)
+	mp.mtx.Unlock()
+}
+
+// pruneStaleVotes is the internal function which implements the public
+// PruneStaleVotes.  See the comment for PruneStaleVotes for more details.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) pruneStaleVotes(tipKeyHeight int64) {
+	staleBlocks := make(map[chainhash.Hash]struct{})
+	var evicted uint64
+	for _, tx := range mp.pool {
+		msgTx := tx.Tx.MsgTx()
+		if stake.DetermineTxType(msgTx) != stake.TxTypeSSGen {
+			continue
+		}
+		blockHash, _, voteKeyHeight, err := stake.SSGenBlockVotedOn(msgTx)
+		if err != nil ||
+			int64(voteKeyHeight)+staleVoteKeyDepth >= tipKeyHeight {
+			continue
+		}
+
+		log.Debugf("Evicting vote %v on stale block %v (key height %v) "+
+			"from the mempool", tx.Tx.Hash(), blockHash, voteKeyHeight)
+		mp.removeTransaction(tx.Tx, true)
+		staleBlocks[blockHash] = struct{}{}
+		evicted++
+	}
+	if evicted == 0 {
+		return
+	}
+
+	// The votes on the stale blocks will never be needed to build a block
+	// template again, so remove them from the map of block votes as well.
+	mp.votesMtx.Lock()
+	for blockHash := range staleBlocks {
+		delete(mp.votes, blockHash)
+	}
+	mp.votesMtx.Unlock()
+
+	atomic.AddUint64(&mp.staleVotesEvicted, evicted)
+}
+
+// StaleVotesEvicted returns the total number of votes that have been evicted
+// from the mempool because they voted on stale key blocks.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) StaleVotesEvicted() uint64 {
+	return atomic.LoadUint64(&mp.staleVotesEvicted)
+}
+
 // ProcessOrphans determines if there are any orphans which depend on the passed
 // transaction hash (it is possible that they are no longer orphans) and
 // potentially accepts them to the memory pool.  It repeats the process for the
@@ -1371,8 +1531,12 @@ func (mp *TxPool) ProcessOrphans(chain *blockchain.BlockChain, hash *chainhash.H
 // with any additional orphan transaactions that were added as a result of
 // the passed one being accepted.
 //
+// The limiter of the peer that relayed the transaction is used to rate limit
+// the transactions accepted from it.  It may be nil for transactions which
+// did not originate from a peer or should not be limited.
+//
 // This function is safe for concurrent access.
-func (mp *TxPool) ProcessTransaction(chain *blockchain.BlockChain, tx *hcashutil.Tx, allowOrphan, rateLimit, allowHighFees bool) ([]*hcashutil.Tx, error) {
+func (mp *TxPool) ProcessTransaction(chain *blockchain.BlockChain, tx *hcashutil.Tx, allowOrphan, rateLimit, allowHighFees bool, limiter *PeerRateLimiter) ([]*hcashutil.Tx, error) {
 	// Protect concurrent access.
 	mp.mtx.Lock()
 	defer mp.mtx.Unlock()
@@ -1387,7 +1551,7 @@ func (mp *TxPool) ProcessTransaction(chain *blockchain.BlockChain, tx *hcashutil
 	// Potentially accept the transaction to the memory pool.
 	var missingParents []*chainhash.Hash
 	missingParents, err = mp.maybeAcceptTransaction(chain, tx, true, rateLimit,
-		allowHighFees)
+		allowHighFees, limiter)
 	if err != nil {
 		return nil, err
 	}
@@ -1444,6 +1608,22 @@ func (mp *TxPool) Count() int {
 	return count
 }
 
+// CountByType returns the number of transactions in the main pool keyed by
+// their stake transaction type.  Types without any transactions in the pool
+// are omitted.  It does not include the orphan pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) CountByType() map[stake.TxType]int {
+	counts := make(map[stake.TxType]int)
+	mp.mtx.RLock()
+	for _, desc := range mp.pool {
+		counts[desc.Type]++
+	}
+	mp.mtx.RUnlock()
+
+	return counts
+}
+
 // TxHashes returns a slice of hashes for all of the transactions in the memory
 // pool.
 //
@@ -1497,6 +1677,15 @@ func (mp *TxPool) MiningDescs() []*mining.TxDesc {
 	return descs
 }
 
+// rawMempoolTxTypes maps the stake transaction types to the names reported in
+// the verbose getrawmempool results.
+var rawMempoolTxTypes = map[stake.TxType]string{
+	stake.TxTypeRegular: "regular",
+	stake.TxTypeSStx:    "ticket",
+	stake.TxTypeSSGen:   "vote",
+	stake.TxTypeSSRtx:   "revocation",
+}
+
 // RawMempoolVerbose returns all of the entries in the mempool filtered by the
 // provided stake type as a fully populated JSON result.  The filter type can be
 // nil in which case all transactions will be returned.
@@ -1524,7 +1713,7 @@ func (mp *TxPool) RawMempoolVerbose(filterType *stake.TxType) map[string]*hcashj
 		var currentPriority float64
 		utxos, err := mp.fetchInputUtxos(tx)
 		if err == nil {
-			currentPriority = CalcPriority(tx.MsgTx(), utxos,
+			currentPriority = mining.CalcPriority(tx.MsgTx(), utxos,
 				bestHeight+1)
 		}
 
@@ -1536,6 +1725,7 @@ func (mp *TxPool) RawMempoolVerbose(filterType *stake.TxType) map[string]*hcashj
 			StartingPriority: desc.StartingPriority,
 			CurrentPriority:  currentPriority,
 			Depends:          make([]string, 0),
+			Type:             rawMempoolTxTypes[desc.Type],
 		}
 		for _, txIn := range tx.MsgTx().TxIn {
 			hash := &txIn.PreviousOutPoint.Hash
@@ -1545,6 +1735,34 @@ func (mp *TxPool) RawMempoolVerbose(filterType *stake.TxType) map[string]*hcashj
 			}
 		}
 
+		// Add the stake metadata.  The price of the ticket spent by a
+		// vote or revocation is the amount of the ticket output it
+		// spends, which is left unset when the ticket can't be found.
+		msgTx := tx.MsgTx()
+		ticketIn := -1
+		switch desc.Type {
+		case stake.TxTypeSStx:
+			mpd.TicketPrice = hcashutil.Amount(msgTx.TxOut[0].Value).ToCoin()
+
+		case stake.TxTypeSSGen:
+			blockHash, blockHeight, _, err := stake.SSGenBlockVotedOn(msgTx)
+			if err == nil {
+				mpd.VoteBlockHash = blockHash.String()
+				mpd.VoteBlockHeight = blockHeight
+			}
+			ticketIn = 1
+
+		case stake.TxTypeSSRtx:
+			ticketIn = 0
+		}
+		if ticketIn >= 0 && utxos != nil {
+			prevOut := &msgTx.TxIn[ticketIn].PreviousOutPoint
+			if entry := utxos.LookupEntry(&prevOut.Hash); entry != nil {
+				amount := entry.AmountByIndex(prevOut.Index)
+				mpd.TicketPrice = hcashutil.Amount(amount).ToCoin()
+			}
+		}
+
 		result[tx.Hash().String()] = mpd
 	}
 
@@ -1559,6 +1777,28 @@ func (mp *TxPool) LastUpdated() time.Time {
 	return time.Unix(atomic.LoadInt64(&mp.lastUpdated), 0)
 }
 
+// Policy returns the policy which is currently used to control the mempool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Policy() Policy {
+	mp.mtx.RLock()
+	policy := mp.cfg.Policy
+	mp.mtx.RUnlock()
+	return policy
+}
+
+// SetPolicy replaces the policy used to control the mempool.  The new policy
+// applies to the transactions processed afterwards, while the transactions
+// already in the pool are kept unless they exceed a lower maximum pool size.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) SetPolicy(policy Policy) {
+	mp.mtx.Lock()
+	mp.cfg.Policy = policy
+	mp.trimToSize(time.Now())
+	mp.mtx.Unlock()
+}
+
 // CheckIfTxsExist checks a list of transaction hashes against the mempool
 // and returns true if they all exist in the mempool, otherwise false.
 //
@@ -1585,6 +1825,7 @@ func New(cfg *Config) *TxPool {
 		orphans:       make(map[chainhash.Hash]*hcashutil.Tx),
 		orphansByPrev: make(map[chainhash.Hash]map[chainhash.Hash]*hcashutil.Tx),
 		outpoints:     make(map[wire.OutPoint]*hcashutil.Tx),
+		conflicts:     make(map[chainhash.Hash]map[chainhash.Hash]struct{}),
 		votes:         make(map[chainhash.Hash][]*VoteTx),
 	}
 }
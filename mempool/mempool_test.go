@@ -6,13 +6,16 @@
 package mempool
 
 import (
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"math"
 	"reflect"
 	"sync"
 	"testing"
 
 	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/blockchain/stake"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainec"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
@@ -419,7 +422,7 @@ func TestSimpleOrphanChain(t *testing.T) {
 	for _, tx := range chainedTxns[1 : maxOrphans+1] {
 		// revised by sammy at 2017-10-27
 		acceptedTxns, err := harness.txPool.ProcessTransaction(bc, tx, true,
-			false, true)
+			false, true, nil)
 		if err != nil {
 			t.Fatalf("ProcessTransaction: failed to accept valid "+
 				"orphan %v", err)
@@ -456,7 +459,7 @@ func TestSimpleOrphanChain(t *testing.T) {
 	//acceptedTxns, err := harness.txPool.ProcessTransaction(chainedTxns[0],
 
 	acceptedTxns, err := harness.txPool.ProcessTransaction(bc, chainedTxns[0],
-		false, false, true)
+		false, false, true, nil)
 	if err != nil {
 		t.Fatalf("ProcessTransaction: failed to accept valid "+
 			"orphan %v", err)
@@ -505,7 +508,7 @@ func TestOrphanReject(t *testing.T) {
 		// revised by sammy at 2017-10-27
 		//acceptedTxns, err := harness.txPool.ProcessTransaction(tx, false,
 		acceptedTxns, err := harness.txPool.ProcessTransaction(bc, tx, false,
-			false, true)
+			false, true, nil)
 		if err == nil {
 			t.Fatalf("ProcessTransaction: did not fail on orphan "+
 				"%v when allow orphans flag is false", tx.Hash())
@@ -575,7 +578,7 @@ func TestOrphanEviction(t *testing.T) {
 		// revised by sammy at 2017-10-27
 		//acceptedTxns, err := harness.txPool.ProcessTransaction(tx, true,
 		acceptedTxns, err := harness.txPool.ProcessTransaction(bc, tx, true,
-			false, true)
+			false, true, nil)
 		if err != nil {
 			t.Fatalf("ProcessTransaction: failed to accept valid "+
 				"orphan %v", err)
@@ -626,3 +629,292 @@ func TestOrphanEviction(t *testing.T) {
 		}
 	}
 }
+
+// TestSetPolicy ensures a replaced policy applies to the transactions processed
+// afterwards.
+func TestSetPolicy(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	bc := FakeChain()
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+
+	// Lower the maximum number of orphans and ensure adding more orphans
+	// than allowed by the new policy evicts the excess.
+	policy := harness.txPool.Policy()
+	policy.MaxOrphanTxs = 2
+	harness.txPool.SetPolicy(policy)
+	if got := harness.txPool.Policy().MaxOrphanTxs; got != 2 {
+		t.Fatalf("Policy: got max orphans %d, want 2", got)
+	}
+	chainedTxns, err := harness.CreateTxChain(outputs[0], 5)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	for _, tx := range chainedTxns[1:] {
+		_, err := harness.txPool.ProcessTransaction(bc, tx, true, false,
+			true, nil)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept valid "+
+				"orphan %v", err)
+		}
+	}
+	var numOrphans int
+	for _, tx := range chainedTxns[1:] {
+		if harness.txPool.IsOrphanInPool(tx.Hash()) {
+			numOrphans++
+		}
+	}
+	if numOrphans != policy.MaxOrphanTxs {
+		t.Fatalf("got %d orphans, want %d", numOrphans,
+			policy.MaxOrphanTxs)
+	}
+}
+
+// CreateVote returns a vote spending the ticket with the passed hash on the
+// block with the passed hash and key height which pays to the address
+// associated with the harness.  It is only valid enough to be recognized as a
+// vote by the pool.
+func (p *poolHarness) CreateVote(ticketHash, blockHash chainhash.Hash, keyHeight uint32) (*hcashutil.Tx, error) {
+	payScript, err := txscript.PayToSSGen(p.payAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	blockRef := make([]byte, 0, stake.SSGenBlockReferenceOutSize)
+	blockRef = append(blockRef, txscript.OP_RETURN, txscript.OP_DATA_40)
+	blockRef = append(blockRef, blockHash[:]...)
+	blockRef = append(blockRef, make([]byte, 8)...)
+	binary.LittleEndian.PutUint32(blockRef[38:], keyHeight)
+
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+			math.MaxUint32, wire.TxTreeRegular),
+		BlockHeight: wire.NullBlockHeight,
+		BlockIndex:  wire.NullBlockIndex,
+		Sequence:    wire.MaxTxInSequenceNum,
+	})
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&ticketHash, 0,
+			wire.TxTreeStake),
+		Sequence: wire.MaxTxInSequenceNum,
+	})
+	tx.AddTxOut(wire.NewTxOut(0, blockRef))
+	tx.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN,
+		txscript.OP_DATA_2, 0x01, 0x00}))
+	tx.AddTxOut(wire.NewTxOut(1e8, payScript))
+	return hcashutil.NewTx(tx), nil
+}
+
+// TestPruneStaleVotes ensures only the votes on key blocks more than
+// staleVoteKeyDepth key blocks below the tip are evicted, so the votes a key
+// block competing with the tip needs are kept along with the votes on the tip.
+func TestPruneStaleVotes(t *testing.T) {
+	t.Parallel()
+
+	harness, _, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	mp := harness.txPool
+
+	// Add a vote on a key block at each key height up to the tip.
+	const tipKeyHeight = 10
+	votes := make(map[uint32]*hcashutil.Tx)
+	blocks := make(map[uint32]chainhash.Hash)
+	for keyHeight := uint32(tipKeyHeight - 3); keyHeight <= tipKeyHeight; keyHeight++ {
+		blocks[keyHeight] = chainhash.Hash{byte(keyHeight)}
+		vote, err := harness.CreateVote(chainhash.Hash{0xff,
+			byte(keyHeight)}, blocks[keyHeight], keyHeight)
+		if err != nil {
+			t.Fatalf("unable to create vote: %v", err)
+		}
+		if txType := stake.DetermineTxType(vote.MsgTx()); txType !=
+			stake.TxTypeSSGen {
+
+			t.Fatalf("CreateVote: got tx type %v, want %v", txType,
+				stake.TxTypeSSGen)
+		}
+		mp.mtx.Lock()
+		mp.addTransaction(blockchain.NewUtxoViewpoint(), vote,
+			stake.TxTypeSSGen, harness.chain.BestHeight(), 0)
+		mp.votesMtx.Lock()
+		err = mp.insertVote(vote)
+		mp.votesMtx.Unlock()
+		mp.mtx.Unlock()
+		if err != nil {
+			t.Fatalf("insertVote: unexpected error: %v", err)
+		}
+		votes[keyHeight] = vote
+	}
+
+	mp.PruneStaleVotes(tipKeyHeight)
+	tests := []struct {
+		keyHeight uint32
+		kept      bool
+	}{
+		{keyHeight: tipKeyHeight - 3, kept: false},
+		{keyHeight: tipKeyHeight - 2, kept: false},
+		{keyHeight: tipKeyHeight - 1, kept: true},
+		{keyHeight: tipKeyHeight, kept: true},
+	}
+	for _, test := range tests {
+		vote := votes[test.keyHeight]
+		if got := mp.HaveTransaction(vote.Hash()); got != test.kept {
+			t.Errorf("vote on key height %d: in pool %v, want %v",
+				test.keyHeight, got, test.kept)
+		}
+		hashes := mp.VoteHashesForBlock(blocks[test.keyHeight])
+		if got := len(hashes) == 1; got != test.kept {
+			t.Errorf("vote on key height %d: got block votes %v, "+
+				"want kept %v", test.keyHeight, hashes, test.kept)
+		}
+	}
+	if got := mp.StaleVotesEvicted(); got != 2 {
+		t.Fatalf("StaleVotesEvicted: got %d, want 2", got)
+	}
+
+	// Pruning again for the same tip evicts nothing further.
+	mp.PruneStaleVotes(tipKeyHeight)
+	if got := mp.StaleVotesEvicted(); got != 2 {
+		t.Fatalf("StaleVotesEvicted after second prune: got %d, want 2",
+			got)
+	}
+}
+
+// TestRawMempoolVerboseStakeInfo ensures the verbose mempool results report the
+// stake type of every transaction along with the block voted on by votes and
+// the price of the tickets purchased, voted with or revoked.
+func TestRawMempoolVerboseStakeInfo(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	mp := harness.txPool
+
+	// newStakeTx returns a transaction spending the passed outpoint with a
+	// single output of the passed amount paying to the passed script.
+	newStakeTx := func(prevOut *wire.OutPoint, amount int64, pkScript []byte) *hcashutil.Tx {
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(wire.NewTxIn(prevOut, nil))
+		tx.AddTxOut(wire.NewTxOut(amount, pkScript))
+		return hcashutil.NewTx(tx)
+	}
+	ticketScript, err := txscript.PayToSStx(harness.payAddr)
+	if err != nil {
+		t.Fatalf("PayToSStx: %v", err)
+	}
+	revokeScript, err := txscript.PayToSSRtx(harness.payAddr)
+	if err != nil {
+		t.Fatalf("PayToSSRtx: %v", err)
+	}
+
+	// The vote spends a ticket which was mined, while the revocation spends
+	// one which can't be found.
+	minedTicket := newStakeTx(wire.NewOutPoint(&chainhash.Hash{0x01}, 0,
+		wire.TxTreeRegular), 3e8, ticketScript)
+	harness.chain.utxos.AddTxOuts(minedTicket, 1, 0)
+	ticket := newStakeTx(wire.NewOutPoint(&chainhash.Hash{0x02}, 0,
+		wire.TxTreeRegular), 2e8, ticketScript)
+	revocation := newStakeTx(wire.NewOutPoint(&chainhash.Hash{0x03}, 0,
+		wire.TxTreeStake), 1e8, revokeScript)
+	regular, err := harness.CreateSignedTx(outputs, 1)
+	if err != nil {
+		t.Fatalf("unable to create signed tx: %v", err)
+	}
+	voteBlockHash := chainhash.Hash{0x04}
+	vote, err := harness.CreateVote(*minedTicket.Hash(), voteBlockHash, 10)
+	if err != nil {
+		t.Fatalf("unable to create vote: %v", err)
+	}
+	voteTx := vote.MsgTx()
+	binary.LittleEndian.PutUint32(voteTx.TxOut[0].PkScript[34:38], 42)
+	vote = hcashutil.NewTx(voteTx)
+
+	tests := []struct {
+		name            string
+		tx              *hcashutil.Tx
+		txType          stake.TxType
+		wantType        string
+		voteBlockHash   string
+		voteBlockHeight uint32
+		ticketPrice     float64
+	}{
+		{
+			name:     "regular",
+			tx:       regular,
+			txType:   stake.TxTypeRegular,
+			wantType: "regular",
+		},
+		{
+			name:        "ticket",
+			tx:          ticket,
+			txType:      stake.TxTypeSStx,
+			wantType:    "ticket",
+			ticketPrice: 2,
+		},
+		{
+			name:            "vote",
+			tx:              vote,
+			txType:          stake.TxTypeSSGen,
+			wantType:        "vote",
+			voteBlockHash:   voteBlockHash.String(),
+			voteBlockHeight: 42,
+			ticketPrice:     3,
+		},
+		{
+			name:     "revocation of an unknown ticket",
+			tx:       revocation,
+			txType:   stake.TxTypeSSRtx,
+			wantType: "revocation",
+		},
+	}
+	mp.mtx.Lock()
+	for _, test := range tests {
+		mp.addTransaction(blockchain.NewUtxoViewpoint(), test.tx,
+			test.txType, harness.chain.BestHeight(), 0)
+	}
+	mp.mtx.Unlock()
+
+	result := mp.RawMempoolVerbose(nil)
+	if len(result) != len(tests) {
+		t.Fatalf("RawMempoolVerbose: got %d results, want %d",
+			len(result), len(tests))
+	}
+	for _, test := range tests {
+		mpd, ok := result[test.tx.Hash().String()]
+		if !ok {
+			t.Errorf("%s: not in the results", test.name)
+			continue
+		}
+		if mpd.Type != test.wantType {
+			t.Errorf("%s: got type %q, want %q", test.name, mpd.Type,
+				test.wantType)
+		}
+		if mpd.VoteBlockHash != test.voteBlockHash ||
+			mpd.VoteBlockHeight != test.voteBlockHeight {
+
+			t.Errorf("%s: got vote block %q (height %d), want %q "+
+				"(height %d)", test.name, mpd.VoteBlockHash,
+				mpd.VoteBlockHeight, test.voteBlockHash,
+				test.voteBlockHeight)
+		}
+		if mpd.TicketPrice != test.ticketPrice {
+			t.Errorf("%s: got ticket price %v, want %v", test.name,
+				mpd.TicketPrice, test.ticketPrice)
+		}
+	}
+
+	// Filtering by stake type only reports the transactions of that type.
+	voteType := stake.TxTypeSSGen
+	result = mp.RawMempoolVerbose(&voteType)
+	if _, ok := result[vote.Hash().String()]; len(result) != 1 || !ok {
+		t.Fatalf("RawMempoolVerbose(vote): got %v, want only the vote",
+			result)
+	}
+}
@@ -97,6 +97,44 @@ func TestCalcMinRequiredTxRelayFee(t *testing.T) {
 	}
 }
 
+// TestCalcTxScriptCost ensures the script cost of a transaction is the sum of
+// the estimated costs of its inputs and inputs spending missing outputs are
+// skipped.
+func TestCalcTxScriptCost(t *testing.T) {
+	pkScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).AddData(make([]byte, 20)).
+		AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).Script()
+	if err != nil {
+		t.Fatalf("failed to create pkscript: %v", err)
+	}
+	prevTx := wire.NewMsgTx()
+	prevTx.AddTxOut(wire.NewTxOut(1e8, pkScript))
+	prevTx.AddTxOut(wire.NewTxOut(1e8, pkScript))
+	utxoView := blockchain.NewUtxoViewpoint()
+	utxoView.AddTxOuts(hcashutil.NewTx(prevTx), 1, wire.NullBlockIndex)
+
+	sigScript, err := txscript.NewScriptBuilder().AddData(make([]byte, 71)).
+		AddData(make([]byte, 33)).Script()
+	if err != nil {
+		t.Fatalf("failed to create sigscript: %v", err)
+	}
+	prevHash := prevTx.TxHash()
+	tx := wire.NewMsgTx()
+	for i := uint32(0); i < 2; i++ {
+		prevOut := wire.NewOutPoint(&prevHash, i, wire.TxTreeRegular)
+		tx.AddTxIn(wire.NewTxIn(prevOut, sigScript))
+	}
+	missingOut := wire.NewOutPoint(&chainhash.Hash{}, 0, wire.TxTreeRegular)
+	tx.AddTxIn(wire.NewTxIn(missingOut, sigScript))
+
+	want := 2 * int64(txscript.EstimateScriptCost(sigScript, pkScript, true))
+	got := calcTxScriptCost(hcashutil.NewTx(tx), stake.TxTypeRegular,
+		utxoView)
+	if got != want {
+		t.Fatalf("calcTxScriptCost: got %d, want %d", got, want)
+	}
+}
+
 // TestCheckPkScriptStandard tests the checkPkScriptStandard API.
 func TestCheckPkScriptStandard(t *testing.T) {
 	var pubKeys [][]byte
@@ -0,0 +1,157 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+// tokenBucket is a token bucket which is refilled with tokens at a constant
+// rate up to a maximum capacity.  Spending tokens is permitted as long as the
+// bucket is not empty, and the cost of the spend may take the bucket into
+// debt.  This allows spends larger than the capacity while still limiting the
+// long term rate to the refill rate.
+type tokenBucket struct {
+	rate     float64 // tokens added per second
+	capacity float64 // maximum number of tokens held
+	tokens   float64
+	last     time.Time
+}
+
+// newTokenBucket returns a full token bucket which refills at the given number
+// of tokens per second and holds at most the given capacity.  A rate of zero
+// or less results in a bucket that never runs out.
+func newTokenBucket(rate, capacity float64, now time.Time) tokenBucket {
+	return tokenBucket{
+		rate:     rate,
+		capacity: capacity,
+		tokens:   capacity,
+		last:     now,
+	}
+}
+
+// unlimited returns whether the bucket never runs out.
+func (b *tokenBucket) unlimited() bool {
+	return b.rate <= 0
+}
+
+// refill adds the tokens accrued since the last refill.
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	b.last = now
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
+// empty returns whether the bucket has run out of tokens as of now.
+func (b *tokenBucket) empty(now time.Time) bool {
+	if b.unlimited() {
+		return false
+	}
+	b.refill(now)
+	return b.tokens <= 0
+}
+
+// spend removes the passed number of tokens from the bucket.
+func (b *tokenBucket) spend(n float64) {
+	if !b.unlimited() {
+		b.tokens -= n
+	}
+}
+
+// PeerRateLimitStats houses the counters of transactions rejected by a
+// PeerRateLimiter.
+type PeerRateLimitStats struct {
+	// LimitedTxns is the number of transactions rejected because the peer
+	// exceeded its overall transaction relay limit.
+	LimitedTxns uint64
+
+	// LimitedLowFeeTxns is the number of transactions rejected because the
+	// peer exceeded its relay limit for free and low-fee transactions.
+	LimitedLowFeeTxns uint64
+}
+
+// PeerRateLimiter limits the rate at which transactions relayed by a single
+// peer are accepted to the memory pool.  Each peer is given its own limiter so
+// a few connections are unable to flood the memory pool and crowd out the
+// transactions of other peers.
+//
+// Two limits are enforced, both in thousands of bytes per minute.  The first
+// applies to all transactions other than votes, while the second applies to
+// regular transactions that pay less than the minimum relay fee, which is
+// tighter than the global limit configured by FreeTxRelayLimit.  Each limiter
+// permits a burst of up to one minute worth of transactions.
+//
+// A limiter is safe for concurrent access.
+type PeerRateLimiter struct {
+	mtx    sync.Mutex
+	all    tokenBucket
+	lowFee tokenBucket
+	stats  PeerRateLimitStats
+}
+
+// NewPeerRateLimiter returns a new limiter that accepts transactions at a rate
+// of up to txRelayLimit and free or low-fee transactions at a rate of up to
+// lowFeeTxRelayLimit, both in thousands of bytes per minute.  A limit of zero
+// disables the respective limit.
+func NewPeerRateLimiter(txRelayLimit, lowFeeTxRelayLimit float64) *PeerRateLimiter {
+	now := time.Now()
+	return &PeerRateLimiter{
+		all: newTokenBucket(txRelayLimit*1000/60,
+			txRelayLimit*1000, now),
+		lowFee: newTokenBucket(lowFeeTxRelayLimit*1000/60,
+			lowFeeTxRelayLimit*1000, now),
+	}
+}
+
+// checkTransaction returns an error when the transaction with the passed hash,
+// serialized size and fee category exceeds the limits as of now.  Otherwise,
+// its size is deducted from the respective limits.
+//
+// This function is safe for concurrent access.
+func (l *PeerRateLimiter) checkTransaction(txHash *chainhash.Hash, serializedSize int64, lowFee bool, now time.Time) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.all.empty(now) {
+		l.stats.LimitedTxns++
+		str := fmt.Sprintf("transaction %v has been rejected by the "+
+			"rate limiter of the relaying peer", txHash)
+		return txRuleError(wire.RejectNonstandard, str)
+	}
+	if lowFee && l.lowFee.empty(now) {
+		l.stats.LimitedLowFeeTxns++
+		str := fmt.Sprintf("transaction %v has been rejected by the "+
+			"rate limiter of the relaying peer due to low fees", txHash)
+		return txRuleError(wire.RejectInsufficientFee, str)
+	}
+
+	l.all.spend(float64(serializedSize))
+	if lowFee {
+		l.lowFee.spend(float64(serializedSize))
+	}
+	return nil
+}
+
+// Stats returns a snapshot of the counters of transactions rejected by the
+// limiter.
+//
+// This function is safe for concurrent access.
+func (l *PeerRateLimiter) Stats() PeerRateLimitStats {
+	l.mtx.Lock()
+	stats := l.stats
+	l.mtx.Unlock()
+	return stats
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+// TestPeerRateLimiter ensures the per-peer transaction rate limiter rejects
+// transactions once its limits are exhausted, refills over time, and keeps
+// accurate counters of the rejected transactions.
+func TestPeerRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	// Allow 60KB per minute overall and 6KB per minute of low-fee
+	// transactions, which refill at 1000 and 100 bytes per second.
+	limiter := NewPeerRateLimiter(60, 6)
+	now := limiter.all.last
+	var hash chainhash.Hash
+
+	// Exhaust the low-fee limit with a transaction larger than it.  The
+	// transaction is accepted since the limit was not yet reached.
+	if err := limiter.checkTransaction(&hash, 7000, true, now); err != nil {
+		t.Fatalf("checkTransaction: unexpected error %v", err)
+	}
+
+	// Further low-fee transactions must be rejected while transactions
+	// paying the relay fee are still accepted.
+	err := limiter.checkTransaction(&hash, 100, true, now)
+	if code, _ := extractRejectCode(err); code != wire.RejectInsufficientFee {
+		t.Fatalf("checkTransaction: unexpected error %v", err)
+	}
+	if err := limiter.checkTransaction(&hash, 53000, false, now); err != nil {
+		t.Fatalf("checkTransaction: unexpected error %v", err)
+	}
+
+	// The overall limit is now exhausted as well.
+	err = limiter.checkTransaction(&hash, 100, false, now)
+	if code, _ := extractRejectCode(err); code != wire.RejectNonstandard {
+		t.Fatalf("checkTransaction: unexpected error %v", err)
+	}
+
+	// The low-fee limit is 1000 bytes in debt, so it must still reject after
+	// 5 seconds and accept again after 11 seconds.
+	now = now.Add(5 * time.Second)
+	if err := limiter.checkTransaction(&hash, 100, true, now); err == nil {
+		t.Fatal("checkTransaction: expected low-fee rejection")
+	}
+	now = now.Add(6 * time.Second)
+	if err := limiter.checkTransaction(&hash, 100, true, now); err != nil {
+		t.Fatalf("checkTransaction: unexpected error %v", err)
+	}
+
+	want := PeerRateLimitStats{LimitedTxns: 1, LimitedLowFeeTxns: 2}
+	if stats := limiter.Stats(); stats != want {
+		t.Fatalf("Stats: got %+v, want %+v", stats, want)
+	}
+
+	// Ensure the limits never accrue beyond one minute worth of tokens.
+	now = now.Add(time.Hour)
+	if err := limiter.checkTransaction(&hash, 60000, false, now); err != nil {
+		t.Fatalf("checkTransaction: unexpected error %v", err)
+	}
+	if err := limiter.checkTransaction(&hash, 1, false, now); err == nil {
+		t.Fatal("checkTransaction: expected rejection after a full burst")
+	}
+
+	// A limit of zero disables it.
+	limiter = NewPeerRateLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		err := limiter.checkTransaction(&hash, 100000, true, now)
+		if err != nil {
+			t.Fatalf("checkTransaction: unexpected error %v", err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashutil"
+)
+
+const (
+	// DefaultMaxPoolSize is the default maximum total serialized size in
+	// bytes of the transactions in the pool.
+	DefaultMaxPoolSize = 300 * 1000 * 1000
+
+	// minFeeRateHalfLife is the time it takes the dynamic minimum fee rate
+	// to decay to half of its value.
+	minFeeRateHalfLife = 12 * time.Hour
+)
+
+// isEvictable returns whether transactions of the passed type may be evicted
+// from the pool when it is full.  Votes and revocations are never evicted since
+// they are required by the stake system regardless of the fees they pay.
+func isEvictable(txType stake.TxType) bool {
+	return txType != stake.TxTypeSSGen && txType != stake.TxTypeSSRtx
+}
+
+// decayMinFeeRate returns the passed dynamic minimum fee rate in atoms/kB after
+// decaying it exponentially for the passed amount of time.  Rates which decay
+// below half of the passed minimum relay fee are reset to zero, so the dynamic
+// rate stops applying once the pressure on the pool is gone.
+func decayMinFeeRate(feeRate float64, elapsed time.Duration,
+	minRelayTxFee hcashutil.Amount) float64 {
+
+	if feeRate == 0 || elapsed <= 0 {
+		return feeRate
+	}
+	feeRate *= math.Pow(0.5, float64(elapsed)/float64(minFeeRateHalfLife))
+	if feeRate < float64(minRelayTxFee)/2 {
+		return 0
+	}
+	return feeRate
+}
+
+// dynamicMinFeeRate returns the dynamic minimum fee rate in atoms/kB which
+// rises whenever transactions are evicted from the full pool and decays over
+// time afterwards.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) dynamicMinFeeRate(now time.Time) hcashutil.Amount {
+	elapsed := now.Sub(time.Unix(mp.lastMinFeeRateUnix, 0))
+	mp.minFeeRate = decayMinFeeRate(mp.minFeeRate, elapsed,
+		mp.cfg.Policy.MinRelayTxFee)
+	mp.lastMinFeeRateUnix = now.Unix()
+	return hcashutil.Amount(mp.minFeeRate)
+}
+
+// MinFeeRate returns the minimum fee rate in atoms/kB that transactions must pay
+// to be accepted into the pool, which is the greater of the minimum relay fee of
+// the policy and the dynamic minimum fee rate that rises while the pool is full.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MinFeeRate() hcashutil.Amount {
+	mp.mtx.Lock()
+	feeRate := mp.dynamicMinFeeRate(time.Now())
+	if feeRate < mp.cfg.Policy.MinRelayTxFee {
+		feeRate = mp.cfg.Policy.MinRelayTxFee
+	}
+	mp.mtx.Unlock()
+	return feeRate
+}
+
+// trimToSize evicts the transactions which pay the lowest fee rates, along with
+// the transactions which redeem them, until the total size of the pool no
+// longer exceeds the maximum size of the policy.  The dynamic minimum fee rate
+// is raised above the fee rate of each evicted transaction by the minimum relay
+// fee, so transactions which would be evicted right away are rejected instead.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) trimToSize(now time.Time) {
+	maxSize := mp.cfg.Policy.MaxPoolSize
+	var evicted uint64
+	for maxSize > 0 && mp.poolSize > maxSize {
+		var lowest *TxDesc
+		var lowestFeeRate float64
+		for _, txDesc := range mp.pool {
+			if !isEvictable(txDesc.Type) {
+				continue
+			}
+			feeRate := float64(txDesc.Fee) * 1000 /
+				float64(txDesc.Tx.MsgTx().SerializeSize())
+			if lowest == nil || feeRate < lowestFeeRate {
+				lowest, lowestFeeRate = txDesc, feeRate
+			}
+		}
+		if lowest == nil {
+			break
+		}
+
+		log.Debugf("Evicting transaction %v with a fee rate of %.0f "+
+			"atoms/kB from the full mempool", lowest.Tx.Hash(),
+			lowestFeeRate)
+		numTxns := len(mp.pool)
+		mp.removeTransaction(lowest.Tx, true)
+		evicted += uint64(numTxns - len(mp.pool))
+
+		feeRate := lowestFeeRate + float64(mp.cfg.Policy.MinRelayTxFee)
+		if feeRate > float64(mp.dynamicMinFeeRate(now)) {
+			mp.minFeeRate = feeRate
+		}
+	}
+
+	atomic.AddUint64(&mp.sizeEvicted, evicted)
+}
+
+// SizeEvicted returns the total number of transactions that have been evicted
+// from the mempool because it exceeded its maximum size.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) SizeEvicted() uint64 {
+	return atomic.LoadUint64(&mp.sizeEvicted)
+}
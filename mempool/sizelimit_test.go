@@ -0,0 +1,77 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/blockchain/stake"
+)
+
+// TestDecayMinFeeRate ensures the dynamic minimum fee rate halves every half
+// life and stops applying once it decays below half of the minimum relay fee.
+func TestDecayMinFeeRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		feeRate float64
+		elapsed time.Duration
+		want    float64
+	}{{
+		name:    "no dynamic fee rate",
+		feeRate: 0,
+		elapsed: minFeeRateHalfLife,
+		want:    0,
+	}, {
+		name:    "no time elapsed",
+		feeRate: 40000,
+		elapsed: 0,
+		want:    40000,
+	}, {
+		name:    "one half life",
+		feeRate: 40000,
+		elapsed: minFeeRateHalfLife,
+		want:    20000,
+	}, {
+		name:    "two half lives",
+		feeRate: 40000,
+		elapsed: 2 * minFeeRateHalfLife,
+		want:    10000,
+	}, {
+		name:    "decayed below half of the minimum relay fee",
+		feeRate: 40000,
+		elapsed: 4 * minFeeRateHalfLife,
+		want:    0,
+	}}
+
+	for _, test := range tests {
+		got := decayMinFeeRate(test.feeRate, test.elapsed, 10000)
+		if got != test.want {
+			t.Errorf("%s: got fee rate %v, want %v", test.name, got,
+				test.want)
+		}
+	}
+}
+
+// TestIsEvictable ensures votes and revocations are never evicted from the full
+// pool.
+func TestIsEvictable(t *testing.T) {
+	tests := []struct {
+		txType stake.TxType
+		want   bool
+	}{
+		{stake.TxTypeRegular, true},
+		{stake.TxTypeSStx, true},
+		{stake.TxTypeSSGen, false},
+		{stake.TxTypeSSRtx, false},
+	}
+
+	for _, test := range tests {
+		if got := isEvictable(test.txType); got != test.want {
+			t.Errorf("isEvictable(%v): got %v, want %v", test.txType,
+				got, test.want)
+		}
+	}
+}
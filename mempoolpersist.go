@@ -0,0 +1,161 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/mempool"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+const (
+	// mempoolFilename is the name of the file in the data directory the
+	// transactions in the memory pool are persisted to when the
+	// persistmempool option is set.
+	mempoolFilename = "mempool.dat"
+
+	// mempoolFileVersion is the version of the format of the persisted
+	// memory pool.
+	mempoolFileVersion = 1
+)
+
+// txDescsByAdded implements sort.Interface to allow a slice of mempool
+// transaction descriptors to be sorted by the time they were added to the pool,
+// so the transactions are restored in an order that mostly adds parents before
+// the transactions which spend them.
+type txDescsByAdded []*mempool.TxDesc
+
+func (s txDescsByAdded) Len() int           { return len(s) }
+func (s txDescsByAdded) Less(i, j int) bool { return s[i].Added.Before(s[j].Added) }
+func (s txDescsByAdded) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// writeMempool writes the transactions of the passed memory pool descriptors to
+// w and returns the number of transactions written.  The format is the version
+// and the number of transactions as little endian uint32s followed by the
+// serialized transactions.  Votes are not written since they only apply to the
+// blocks at the tip at the time they were received.
+func writeMempool(w io.Writer, txDescs []*mempool.TxDesc) (int, error) {
+	sort.Sort(txDescsByAdded(txDescs))
+	txns := make([]*wire.MsgTx, 0, len(txDescs))
+	for _, txDesc := range txDescs {
+		if txDesc.Type == stake.TxTypeSSGen {
+			continue
+		}
+		txns = append(txns, txDesc.Tx.MsgTx())
+	}
+
+	var header [8]byte
+	binary.LittleEndian.PutUint32(header[0:4], mempoolFileVersion)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(txns)))
+	if _, err := w.Write(header[:]); err != nil {
+		return 0, err
+	}
+	for _, msgTx := range txns {
+		if err := msgTx.Serialize(w); err != nil {
+			return 0, err
+		}
+	}
+	return len(txns), nil
+}
+
+// readMempool reads the transactions written by writeMempool from r.
+func readMempool(r io.Reader) ([]*hcashutil.Tx, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	version := binary.LittleEndian.Uint32(header[0:4])
+	if version != mempoolFileVersion {
+		return nil, fmt.Errorf("unsupported version %d", version)
+	}
+	numTxns := binary.LittleEndian.Uint32(header[4:8])
+
+	// Don't trust the count to preallocate since the file may be
+	// corrupted.
+	var txns []*hcashutil.Tx
+	for i := uint32(0); i < numTxns; i++ {
+		var msgTx wire.MsgTx
+		if err := msgTx.Deserialize(r); err != nil {
+			return nil, err
+		}
+		txns = append(txns, hcashutil.NewTx(&msgTx))
+	}
+	return txns, nil
+}
+
+// saveMempool persists the transactions in the memory pool, except votes, to
+// the data directory.  It is intended to be called on shutdown once the peers
+// were disconnected.
+func (s *server) saveMempool() error {
+	path := filepath.Join(cfg.DataDir, mempoolFilename)
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	numTxns, err := writeMempool(w, s.txMemPool.TxDescs())
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	srvrLog.Infof("Saved %d mempool transactions to %s", numTxns, path)
+	return nil
+}
+
+// loadMempool restores the transactions persisted to the data directory by
+// saveMempool into the memory pool.  The transactions are subject to the same
+// rules as when they were first received, except for the rate limits and the
+// check for high fees, so the ones which were mined or became invalid in the
+// meantime are skipped.
+func (s *server) loadMempool() error {
+	path := filepath.Join(cfg.DataDir, mempoolFilename)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	txns, err := readMempool(bufio.NewReader(f))
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+
+	chain := s.blockManager.chain
+	var numAccepted int
+	for _, tx := range txns {
+		acceptedTxs, err := s.txMemPool.ProcessTransaction(chain, tx,
+			true, false, true, nil)
+		if err != nil {
+			srvrLog.Debugf("Skipping persisted mempool transaction "+
+				"%v: %v", tx.Hash(), err)
+			continue
+		}
+		numAccepted += len(acceptedTxs)
+	}
+	srvrLog.Infof("Restored %d of %d persisted mempool transactions",
+		numAccepted, len(txns))
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/mempool"
+	"github.com/HcashOrg/hcashd/mining"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// TestPersistMempool ensures the persisted mempool transactions round trip in
+// the order they were added to the pool and votes are not persisted.
+func TestPersistMempool(t *testing.T) {
+	newTxDesc := func(index uint32, txType stake.TxType, added time.Time) *mempool.TxDesc {
+		msgTx := wire.NewMsgTx()
+		msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+			index, wire.TxTreeRegular), nil))
+		msgTx.AddTxOut(wire.NewTxOut(int64(index), nil))
+		return &mempool.TxDesc{TxDesc: mining.TxDesc{
+			Tx:    hcashutil.NewTx(msgTx),
+			Type:  txType,
+			Added: added,
+		}}
+	}
+	now := time.Now()
+	txDescs := []*mempool.TxDesc{
+		newTxDesc(2, stake.TxTypeRegular, now.Add(time.Second)),
+		newTxDesc(0, stake.TxTypeSSGen, now),
+		newTxDesc(1, stake.TxTypeRegular, now),
+		newTxDesc(3, stake.TxTypeSStx, now.Add(2*time.Second)),
+	}
+
+	var buf bytes.Buffer
+	n, err := writeMempool(&buf, txDescs)
+	if err != nil {
+		t.Fatalf("writeMempool: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("writeMempool: wrote %d transactions, want 3", n)
+	}
+	txns, err := readMempool(&buf)
+	if err != nil {
+		t.Fatalf("readMempool: %v", err)
+	}
+	if len(txns) != 3 {
+		t.Fatalf("readMempool: read %d transactions, want 3", len(txns))
+	}
+	for i, tx := range txns {
+		index := tx.MsgTx().TxIn[0].PreviousOutPoint.Index
+		if index != uint32(i+1) {
+			t.Fatalf("readMempool: transaction #%d spends output %d, "+
+				"want %d", i, index, i+1)
+		}
+	}
+
+	// Ensure truncated files are rejected.
+	buf.Reset()
+	if _, err := writeMempool(&buf, txDescs); err != nil {
+		t.Fatalf("writeMempool: %v", err)
+	}
+	truncated := bytes.NewReader(buf.Bytes()[:buf.Len()-1])
+	if _, err := readMempool(truncated); err == nil {
+		t.Fatal("readMempool: truncated file accepted")
+	}
+}
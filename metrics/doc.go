@@ -0,0 +1,18 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package metrics provides the counters, gauges and histograms which hcashd
+exports for monitoring in the Prometheus text exposition format.
+
+A Registry houses named metric families.  Counters and histograms are updated
+by the code they instrument, while the values of gauges, and of counters kept
+elsewhere, are read from functions each time the metrics are written, so they
+never go stale.  The registry implements http.Handler to serve the metrics to
+Prometheus.
+
+The package has no dependencies outside of the standard library so it may be
+used by any other package without introducing import cycles.
+*/
+package metrics
@@ -0,0 +1,359 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ContentType is the content type of the Prometheus text exposition format
+// written by a registry.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultDurationBuckets are the upper bounds in seconds of the buckets of the
+// histograms of durations, which range from a millisecond to a minute.
+var DefaultDurationBuckets = []float64{.001, .005, .01, .025, .05, .1, .25,
+	.5, 1, 2.5, 5, 10, 30, 60}
+
+// metricNameRegexp matches the valid names of metrics and labels.
+var metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// metricType is the type of a metric family as reported by the TYPE line of
+// the text exposition format.
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// sample is a single value of a metric family along with its labels, which are
+// already formatted for the text exposition format.
+type sample struct {
+	suffix string
+	labels string
+	value  float64
+}
+
+// family is a named metric family of a registry.
+type family struct {
+	name    string
+	help    string
+	typ     metricType
+	collect func() []sample
+}
+
+// Registry houses the metric families exported by the server.  All metric
+// families are created through a registry, which writes their current values
+// in the Prometheus text exposition format.  It is safe for concurrent access.
+type Registry struct {
+	mtx      sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry returns a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// register adds the passed metric family to the registry.  It panics when the
+// name is invalid or already registered since metric families are defined by
+// the code and are not subject to user input.
+func (r *Registry) register(f *family) {
+	if !metricNameRegexp.MatchString(f.name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", f.name))
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.families[f.name]; ok {
+		panic(fmt.Sprintf("metrics: duplicate metric name %q", f.name))
+	}
+	r.families[f.name] = f
+}
+
+// Counter is a metric which only ever increases, such as the number of times an
+// event occurred.  It is safe for concurrent access.
+type Counter struct {
+	value uint64
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	atomic.AddUint64(&c.value, 1)
+}
+
+// Add increments the counter by the passed value.
+func (c *Counter) Add(n uint64) {
+	atomic.AddUint64(&c.value, n)
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() uint64 {
+	return atomic.LoadUint64(&c.value)
+}
+
+// NewCounter registers and returns a new counter with the passed name and help
+// text.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := new(Counter)
+	r.register(&family{
+		name: name,
+		help: help,
+		typ:  typeCounter,
+		collect: func() []sample {
+			return []sample{{value: float64(c.Value())}}
+		},
+	})
+	return c
+}
+
+// NewCounterFunc registers a counter with the passed name and help text whose
+// value is returned by the passed function each time the metrics are written.
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&family{
+		name: name,
+		help: help,
+		typ:  typeCounter,
+		collect: func() []sample {
+			return []sample{{value: fn()}}
+		},
+	})
+}
+
+// NewGaugeFunc registers a gauge with the passed name and help text whose value
+// is returned by the passed function each time the metrics are written.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&family{
+		name: name,
+		help: help,
+		typ:  typeGauge,
+		collect: func() []sample {
+			return []sample{{value: fn()}}
+		},
+	})
+}
+
+// NewGaugeVecFunc registers a gauge with the passed name and help text which is
+// partitioned by a single label.  The passed function returns the values keyed
+// by the value of the label each time the metrics are written.
+func (r *Registry) NewGaugeVecFunc(name, help, label string,
+	fn func() map[string]float64) {
+
+	if !metricNameRegexp.MatchString(label) {
+		panic(fmt.Sprintf("metrics: invalid label name %q", label))
+	}
+	r.register(&family{
+		name: name,
+		help: help,
+		typ:  typeGauge,
+		collect: func() []sample {
+			values := fn()
+			samples := make([]sample, 0, len(values))
+			for labelValue, value := range values {
+				samples = append(samples, sample{
+					labels: formatLabel(label, labelValue),
+					value:  value,
+				})
+			}
+			sort.Sort(samplesByLabels(samples))
+			return samples
+		},
+	})
+}
+
+// Histogram is a metric which counts observed values, such as durations, in
+// buckets of configurable upper bounds along with their total sum.  It is safe
+// for concurrent access.
+type Histogram struct {
+	mtx     sync.Mutex
+	bounds  []float64
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// Observe adds the passed value to the histogram.
+func (h *Histogram) Observe(value float64) {
+	h.mtx.Lock()
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+	h.mtx.Unlock()
+}
+
+// ObserveSince adds the duration in seconds since the passed time to the
+// histogram.
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// samples returns the cumulative buckets, sum and count of the histogram.
+func (h *Histogram) samples() []sample {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	samples := make([]sample, 0, len(h.bounds)+3)
+	for i, bound := range h.bounds {
+		samples = append(samples, sample{
+			suffix: "_bucket",
+			labels: formatLabel("le", formatValue(bound)),
+			value:  float64(h.buckets[i]),
+		})
+	}
+	samples = append(samples, sample{
+		suffix: "_bucket",
+		labels: formatLabel("le", "+Inf"),
+		value:  float64(h.count),
+	})
+	samples = append(samples, sample{suffix: "_sum", value: h.sum})
+	samples = append(samples, sample{suffix: "_count", value: float64(h.count)})
+	return samples
+}
+
+// NewHistogram registers and returns a new histogram with the passed name, help
+// text and upper bounds of its buckets, which must be sorted in increasing
+// order.
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: unsorted buckets for %q", name))
+	}
+	h := &Histogram{
+		bounds:  append([]float64(nil), buckets...),
+		buckets: make([]uint64, len(buckets)),
+	}
+	r.register(&family{
+		name:    name,
+		help:    help,
+		typ:     typeHistogram,
+		collect: h.samples,
+	})
+	return h
+}
+
+// WriteTo writes the current values of all metric families of the registry to
+// the passed writer in the Prometheus text exposition format.  The families are
+// written in order of their names.
+//
+// This is part of the io.WriterTo interface implementation.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mtx.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mtx.Unlock()
+	sort.Sort(familiesByName(families))
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		fmt.Fprintf(cw, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(cw, "# TYPE %s %s\n", f.name, f.typ)
+		for _, s := range f.collect() {
+			fmt.Fprintf(cw, "%s%s%s %s\n", f.name, s.suffix, s.labels,
+				formatValue(s.value))
+		}
+	}
+	if err := cw.w.Flush(); err != nil && cw.err == nil {
+		cw.err = err
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP writes the current values of all metric families of the registry
+// in the Prometheus text exposition format as the response to the request.
+//
+// This is part of the http.Handler interface implementation.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		http.Error(w, "405 Method Not Allowed.",
+			http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	if req.Method == "HEAD" {
+		return
+	}
+	r.WriteTo(w)
+}
+
+// countingWriter is an io.Writer which counts the bytes written to the
+// underlying writer and retains the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+// formatValue returns the passed value formatted for the text exposition
+// format.
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// labelValueReplacer escapes the label values for the text exposition format.
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// formatLabel returns the passed label formatted for the text exposition
+// format.
+func formatLabel(name, value string) string {
+	return "{" + name + `="` + labelValueReplacer.Replace(value) + `"}`
+}
+
+// helpReplacer escapes the help texts for the text exposition format.
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// escapeHelp returns the passed help text escaped for the text exposition
+// format.
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+// familiesByName sorts metric families by their names.
+type familiesByName []*family
+
+func (s familiesByName) Len() int           { return len(s) }
+func (s familiesByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s familiesByName) Less(i, j int) bool { return s[i].name < s[j].name }
+
+// samplesByLabels sorts samples by their formatted labels.
+type samplesByLabels []sample
+
+func (s samplesByLabels) Len() int           { return len(s) }
+func (s samplesByLabels) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s samplesByLabels) Less(i, j int) bool { return s[i].labels < s[j].labels }
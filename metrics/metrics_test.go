@@ -0,0 +1,129 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRegistryWriteTo ensures the metric families of a registry are written in
+// the Prometheus text exposition format.
+func TestRegistryWriteTo(t *testing.T) {
+	r := NewRegistry()
+	bans := r.NewCounter("test_bans_total", "Number of bans.")
+	bans.Inc()
+	bans.Add(2)
+	r.NewGaugeFunc("test_height", "Height of the\nchain.", func() float64 {
+		return 1234
+	})
+	r.NewGaugeVecFunc("test_peers", "Number of peers.", "direction",
+		func() map[string]float64 {
+			return map[string]float64{"outbound": 8, "in\"bound": 2}
+		})
+	h := r.NewHistogram("test_seconds", "Durations.", []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(2)
+
+	const want = `# HELP test_bans_total Number of bans.
+# TYPE test_bans_total counter
+test_bans_total 3
+# HELP test_height Height of the\nchain.
+# TYPE test_height gauge
+test_height 1234
+# HELP test_peers Number of peers.
+# TYPE test_peers gauge
+test_peers{direction="in\"bound"} 2
+test_peers{direction="outbound"} 8
+# HELP test_seconds Durations.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1"} 1
+test_seconds_bucket{le="1"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 2.55
+test_seconds_count 3
+`
+	var buf bytes.Buffer
+	n, err := r.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo: unexpected error: %v", err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("WriteTo: got\n%s\nwant\n%s", got, want)
+	}
+	if n != int64(buf.Len()) {
+		t.Fatalf("WriteTo: got %d bytes written, want %d", n, buf.Len())
+	}
+}
+
+// TestRegistryServeHTTP ensures the registry serves the metrics with the
+// content type of the text exposition format.
+func TestRegistryServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Test.").Inc()
+
+	newRequest := func(method string) *http.Request {
+		req, err := http.NewRequest(method, "/metrics", nil)
+		if err != nil {
+			t.Fatalf("NewRequest: unexpected error: %v", err)
+		}
+		return req
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, newRequest("GET"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET: got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Fatalf("GET: got content type %q, want %q", ct, ContentType)
+	}
+	if !bytes.Contains(rec.Body.Bytes(), []byte("test_total 1\n")) {
+		t.Fatalf("GET: counter missing from %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, newRequest("POST"))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST: got status %d, want %d", rec.Code,
+			http.StatusMethodNotAllowed)
+	}
+}
+
+// TestRegistryInvalidNames ensures registering invalid and duplicate metric
+// names panics.
+func TestRegistryInvalidNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(r *Registry)
+	}{
+		{"invalid name", func(r *Registry) {
+			r.NewCounter("test-total", "Test.")
+		}},
+		{"duplicate name", func(r *Registry) {
+			r.NewCounter("test_total", "Test.")
+			r.NewGaugeFunc("test_total", "Test.", func() float64 { return 0 })
+		}},
+		{"invalid label", func(r *Registry) {
+			r.NewGaugeVecFunc("test_peers", "Test.", "1abel", nil)
+		}},
+		{"unsorted buckets", func(r *Registry) {
+			r.NewHistogram("test_seconds", "Test.", []float64{1, 0.1})
+		}},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: did not panic", test.name)
+				}
+			}()
+			test.register(NewRegistry())
+		}()
+	}
+}
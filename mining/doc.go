@@ -6,12 +6,27 @@
 Package mining includes all mining and policy types, and will
 house all mining code in the future.
 
-Overview
+# Overview
 
-This package currently contains the 3 struct types
-for mining and policy.  In the future it will contain all of the
-pieces of code pertaining to block template creation, CPU mining
-and other various mining code.
+This package contains the block template generator along with the pieces of
+code pertaining to block template creation, so they may be reused by
+alternative miners and tested without depending on the internals of a running
+node:
 
+  - BlkTmplGenerator generates block templates from the transactions of a
+    TxSource according to a Policy, given the block chain and a BlockManager
+    providing the best chain state and the cached templates
+  - TxSource is the interface of the source of transactions, such as the
+    memory pool, which are considered for inclusion in new blocks
+  - Policy houses the configuration parameters which control the generation
+    of block templates
+  - TxPriorityQueue orders the transactions of a block template by their stake
+    priority, fees and priority
+  - BlockTemplate houses a block which has yet to be solved along with the
+    functions for updating the extranonces of its coinbase
+  - CoinbasePayouts splits the work reward of a coinbase across multiple
+    addresses
+
+In the future it will also contain CPU mining and other various mining code.
 */
 package mining
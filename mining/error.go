@@ -4,20 +4,20 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-package main
+package mining
 
 import (
 	"fmt"
 )
 
-// MiningErrorCode identifies a kind of error.
-type MiningErrorCode int
+// ErrorCode identifies a kind of error.
+type ErrorCode int
 
 // These constants are used to identify a specific RuleError.
 const (
 	// ErrNotEnoughVoters indicates that there were not enough voters to
 	// build a block on top of HEAD.
-	ErrNotEnoughVoters MiningErrorCode = iota
+	ErrNotEnoughVoters ErrorCode = iota
 
 	// ErrFailedToGetGeneration specifies that the current generation for
 	// a block could not be obtained from blockchain.
@@ -73,54 +73,54 @@ const (
 	ErrFailedToGetKeyGeneration
 )
 
-// Map of MiningErrorCode values back to their constant names for pretty printing.
-var miningErrorCodeStrings = map[MiningErrorCode]string{
-	ErrNotEnoughVoters:        "ErrNotEnoughVoters",
-	ErrFailedToGetGeneration:  "ErrFailedToGetGeneration",
-	ErrGetStakeDifficulty:     "ErrGetStakeDifficulty",
-	ErrGetTopBlock:            "ErrGetTopBlock",
-	ErrCreatingCoinbase:       "ErrCreatingCoinbase",
-	ErrGettingMedianTime:      "ErrGettingMedianTime",
-	ErrGettingDifficulty:      "ErrGettingDifficulty",
-	ErrTransactionAppend:      "ErrTransactionAppend",
-	ErrCheckBlockSanity:       "ErrCheckBlockSanity",
-	ErrCheckConnectBlock:      "ErrCheckConnectBlock",
-	ErrCoinbaseLengthOverflow: "ErrCoinbaseLengthOverflow",
-	ErrFraudProofIndex:        "ErrFraudProofIndex",
-	ErrFetchTxStore:           "ErrFetchTxStore",
+// Map of ErrorCode values back to their constant names for pretty printing.
+var errorCodeStrings = map[ErrorCode]string{
+	ErrNotEnoughVoters:               "ErrNotEnoughVoters",
+	ErrFailedToGetGeneration:         "ErrFailedToGetGeneration",
+	ErrGetStakeDifficulty:            "ErrGetStakeDifficulty",
+	ErrGetTopBlock:                   "ErrGetTopBlock",
+	ErrCreatingCoinbase:              "ErrCreatingCoinbase",
+	ErrGettingMedianTime:             "ErrGettingMedianTime",
+	ErrGettingDifficulty:             "ErrGettingDifficulty",
+	ErrTransactionAppend:             "ErrTransactionAppend",
+	ErrCheckBlockSanity:              "ErrCheckBlockSanity",
+	ErrCheckConnectBlock:             "ErrCheckConnectBlock",
+	ErrCoinbaseLengthOverflow:        "ErrCoinbaseLengthOverflow",
+	ErrFraudProofIndex:               "ErrFraudProofIndex",
+	ErrFetchTxStore:                  "ErrFetchTxStore",
 	ErrFailedToGetMatchedDescendants: "ErrFailedToGetMatchedDescendants",
-	ErrFailedToGetKeyGeneration:"ErrFailedToGetKeyGeneration",
+	ErrFailedToGetKeyGeneration:      "ErrFailedToGetKeyGeneration",
 }
 
-// String returns the MiningErrorCode as a human-readable name.
-func (e MiningErrorCode) String() string {
-	if s := miningErrorCodeStrings[e]; s != "" {
+// String returns the ErrorCode as a human-readable name.
+func (e ErrorCode) String() string {
+	if s := errorCodeStrings[e]; s != "" {
 		return s
 	}
-	return fmt.Sprintf("Unknown MiningErrorCode (%d)", int(e))
+	return fmt.Sprintf("Unknown ErrorCode (%d)", int(e))
 }
 
-// MiningRuleError identifies a rule violation.  It is used to indicate that
+// RuleError identifies a rule violation.  It is used to indicate that
 // processing of a block or transaction failed due to one of the many validation
 // rules.  The caller can use type assertions to determine if a failure was
-// specifically due to a rule violation and access the MiningErrorCode field to
+// specifically due to a rule violation and access the ErrorCode field to
 // ascertain the specific reason for the rule violation.
-type MiningRuleError struct {
-	ErrorCode   MiningErrorCode // Describes the kind of error
-	Description string          // Human readable description of the issue
+type RuleError struct {
+	ErrorCode   ErrorCode // Describes the kind of error
+	Description string    // Human readable description of the issue
 }
 
 // Error satisfies the error interface and prints human-readable errors.
-func (e MiningRuleError) Error() string {
+func (e RuleError) Error() string {
 	return e.Description
 }
 
 // GetCode satisfies the error interface and prints human-readable errors.
-func (e MiningRuleError) GetCode() MiningErrorCode {
+func (e RuleError) GetCode() ErrorCode {
 	return e.ErrorCode
 }
 
-// miningRuleError creates an RuleError given a set of arguments.
-func miningRuleError(c MiningErrorCode, desc string) MiningRuleError {
-	return MiningRuleError{ErrorCode: c, Description: desc}
+// ruleError creates an RuleError given a set of arguments.
+func ruleError(c ErrorCode, desc string) RuleError {
+	return RuleError{ErrorCode: c, Description: desc}
 }
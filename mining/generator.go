@@ -0,0 +1,2299 @@
+// Copyright (c) 2014-2016 The btcsuite developers
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mining
+
+import (
+	"container/heap"
+	"container/list"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/txscript"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+const (
+	// generatedBlockVersion is the version of the block being generated for
+	// the main network.  It is defined as a constant here rather than using
+	// the wire.BlockVersion constant since a change in the block version
+	// will require changes to the generated block.  Using the wire constant
+	// for generated block version could allow creation of invalid blocks
+	// for the updated version.
+	generatedBlockVersion = 1
+
+	// generatedBlockVersionTest is the version of the block being generated
+	// for networks other than the main network.
+	generatedBlockVersionTest = 5
+
+	// blockHeaderOverhead is the max number of bytes it takes to serialize
+	// a block header and max possible transaction count.
+	blockHeaderOverhead = wire.MaxBlockHeaderPayload + wire.MaxVarIntPayload
+
+	// CoinbaseFlags is some extra data appended to the coinbase script
+	// sig.
+	CoinbaseFlags = "/hcashd/"
+
+	// kilobyte is the size of a kilobyte.
+	kilobyte = 1000
+)
+
+// zeroHash is the zero value hash (all zeros).  It is defined as a convenience.
+var zeroHash chainhash.Hash
+
+// ChainState houses a snapshot of the state of the best chain which is needed
+// to generate block templates.
+type ChainState struct {
+	NewestHash          *chainhash.Hash
+	NewestHeight        int64
+	NewestKeyHeight     int64
+	NewestBits          uint32
+	NextFinalState      [6]byte
+	NextPoolSize        uint32
+	NextStakeDifficulty int64
+	WinningTickets      []chainhash.Hash
+	MissedTickets       []chainhash.Hash
+	CurPrevKeyHash      chainhash.Hash
+	PastMedianTime      time.Time
+}
+
+// BlockManager provides the block template generator with access to the best
+// chain state and the cached block templates kept by the block manager of a
+// node, along with the chain operations which are synchronized by it.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type BlockManager interface {
+	// ChainState returns a snapshot of the current best chain state.
+	ChainState() *ChainState
+
+	// GetCurrentTemplate returns the cached block template which is
+	// currently being mined on, if any.
+	GetCurrentTemplate() *BlockTemplate
+
+	// SetCurrentTemplate replaces the cached current block template.
+	SetCurrentTemplate(bt *BlockTemplate)
+
+	// SetParentTemplate replaces the cached parent block template.
+	SetParentTemplate(bt *BlockTemplate)
+
+	// CalcNextRequiredDiffNode returns the required proof of work
+	// difficulty for a block building on the block with the passed hash
+	// and with the passed timestamp.
+	CalcNextRequiredDiffNode(hash *chainhash.Hash, timestamp time.Time) (uint32, error)
+
+	// ForceReorganization reorganizes the best chain from the former best
+	// block to the new best block, which must share the same parent.
+	ForceReorganization(formerBest, newBest chainhash.Hash) error
+
+	// GetKeyGeneration returns the hashes of the key blocks which build
+	// on the key block with the passed hash.
+	GetKeyGeneration(h chainhash.Hash) ([]chainhash.Hash, error)
+
+	// GetMatchedDescendants returns the hashes of the descendants of the
+	// key block with the passed hash whose vote bits match the passed
+	// vote result.
+	GetMatchedDescendants(h chainhash.Hash, v uint16) ([]chainhash.Hash, error)
+
+	// GetTopBlockFromChain returns the block at the tip of the best chain.
+	GetTopBlockFromChain() (*hcashutil.Block, error)
+}
+
+// Config is a descriptor containing the block template generator
+// configuration.
+type Config struct {
+	// Policy defines the block generation related policy settings.
+	Policy *Policy
+
+	// TxSource is the source of the transactions which are considered
+	// for inclusion in new blocks.
+	TxSource TxSource
+
+	// Chain is the block chain the generated block templates extend.
+	Chain *blockchain.BlockChain
+
+	// ChainParams identifies which chain parameters the generator is
+	// associated with.
+	ChainParams *chaincfg.Params
+
+	// TimeSource defines the median time source which is used to choose
+	// the timestamps of generated blocks.
+	TimeSource blockchain.MedianTimeSource
+
+	// SigCache defines the signature cache to use when validating the
+	// scripts of the transactions considered for inclusion.
+	SigCache *txscript.SigCache
+
+	// BlockManager provides the best chain state and the cached block
+	// templates.
+	BlockManager BlockManager
+
+	// AggressiveMining specifies whether a template building on the
+	// cached parent template is generated when there are too few votes
+	// for the current tip.
+	AggressiveMining bool
+
+	// MiningTimeOffset is the number of seconds to subtract from the
+	// median adjusted time when choosing the timestamps of generated
+	// blocks.
+	MiningTimeOffset int
+}
+
+// BlkTmplGenerator generates block templates based on a given mining policy
+// and a transaction source.  It also houses additional state required in
+// order to ensure the templates are built on top of the current best chain
+// and adhere to the consensus rules.
+type BlkTmplGenerator struct {
+	cfg Config
+}
+
+// NewBlkTmplGenerator returns a new block template generator for the given
+// configuration.
+func NewBlkTmplGenerator(cfg *Config) *BlkTmplGenerator {
+	return &BlkTmplGenerator{cfg: *cfg}
+}
+
+// containsTx is a helper function that checks to see if a list of transactions
+// contains any of the TxIns of some transaction.
+func containsTxIns(txs []*hcashutil.Tx, tx *hcashutil.Tx) bool {
+	for _, txToCheck := range txs {
+		for _, txIn := range tx.MsgTx().TxIn {
+			if txIn.PreviousOutPoint.Hash.IsEqual(txToCheck.Hash()) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// blockWithNumVotes is a block with the number of votes currently present
+// for that block. Just used for sorting.
+type blockWithNumVotes struct {
+	Hash     chainhash.Hash
+	NumVotes uint16
+}
+
+// byNumberOfVotes implements sort.Interface to sort a slice of blocks by their
+// number of votes.
+type byNumberOfVotes []*blockWithNumVotes
+
+// Len returns the number of elements in the slice.  It is part of the
+// sort.Interface implementation.
+func (b byNumberOfVotes) Len() int {
+	return len(b)
+}
+
+// Swap swaps the elements at the passed indices.  It is part of the
+// sort.Interface implementation.
+func (b byNumberOfVotes) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+// Less returns whether the block with index i should sort before the block with
+// index j.  It is part of the sort.Interface implementation.
+func (b byNumberOfVotes) Less(i, j int) bool {
+	return b[i].NumVotes < b[j].NumVotes
+}
+
+// SortParentsByVotes takes a list of block header hashes and sorts them
+// by the number of votes currently available for them in the passed
+// transaction source.  It then returns all blocks that are eligible to be used (have
+// at least a majority number of votes) sorted by number of votes, descending.
+//
+// This function is safe for concurrent access.
+func SortParentsByVotes(txSource TxSource, currentTopBlock chainhash.Hash, blocks []chainhash.Hash, params *chaincfg.Params) []chainhash.Hash {
+	// Return now when no blocks were provided.
+	lenBlocks := len(blocks)
+	if lenBlocks == 0 {
+		return nil
+	}
+
+	// Fetch the vote metadata for the provided block hashes from the
+	// mempool and filter out any blocks that do not have the minimum
+	// required number of votes.
+	minVotesRequired := (params.TicketsPerBlock / 2) + 1
+	voteMetadata := txSource.VotesForBlocks(blocks)
+	filtered := make([]*blockWithNumVotes, 0, lenBlocks)
+	for i := range blocks {
+		voteYea := uint16(0)
+		voteNay := uint16(0)
+		numVotes := uint16(len(voteMetadata[i]))
+		for _, singleVote := range voteMetadata[i]{
+			if singleVote.Vote{
+				voteYea++
+			}else {
+				voteNay++
+			}
+		}
+
+		if voteYea >= minVotesRequired || voteNay >= minVotesRequired{
+			filtered = append(filtered, &blockWithNumVotes{
+				Hash:     blocks[i],
+				NumVotes: numVotes,
+			})
+		}
+	}
+
+	// Return now if there are no blocks with enough votes to be eligible to
+	// build on top of.
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	// Blocks with the most votes appear at the top of the list.
+	sort.Sort(sort.Reverse(byNumberOfVotes(filtered)))
+	sortedUsefulBlocks := make([]chainhash.Hash, 0, len(filtered))
+	for _, bwnv := range filtered {
+		sortedUsefulBlocks = append(sortedUsefulBlocks, bwnv.Hash)
+	}
+
+	// Make sure we don't reorganize the chain needlessly if the top block has
+	// the same amount of votes as the current leader after the sort. After this
+	// point, all blocks listed in sortedUsefulBlocks definitely also have the
+	// minimum number of votes required.
+	curVoteMetadata := txSource.VotesForBlocks([]chainhash.Hash{currentTopBlock})
+	numTopBlockVotes := uint16(len(curVoteMetadata))
+	if filtered[0].NumVotes == numTopBlockVotes && filtered[0].Hash !=
+		currentTopBlock {
+
+		// Attempt to find the position of the current block being built
+		// from in the list.
+		pos := 0
+		for i, bwnv := range filtered {
+			if bwnv.Hash == currentTopBlock {
+				pos = i
+				break
+			}
+		}
+
+		// Swap the top block into the first position. We directly access
+		// sortedUsefulBlocks useful blocks here with the assumption that
+		// since the values were accumulated from filtered, they should be
+		// in the same positions and we shouldn't be able to access anything
+		// out of bounds.
+		if pos != 0 {
+			sortedUsefulBlocks[0], sortedUsefulBlocks[pos] =
+				sortedUsefulBlocks[pos], sortedUsefulBlocks[0]
+		}
+	}
+
+	return sortedUsefulBlocks
+}
+
+// mergeUtxoView adds all of the entries in view to viewA.  The result is that
+// viewA will contain all of its original entries plus all of the entries
+// in viewB.  It will replace any entries in viewB which also exist in viewA
+// if the entry in viewA is fully spent.
+func mergeUtxoView(viewA *blockchain.UtxoViewpoint, viewB *blockchain.UtxoViewpoint) {
+	viewAEntries := viewA.Entries()
+	for hash, entryB := range viewB.Entries() {
+		if entryA, exists := viewAEntries[hash]; !exists ||
+			entryA == nil || entryA.IsFullySpent() {
+			viewAEntries[hash] = entryB
+		}
+	}
+}
+
+// hashExistsInList checks if a hash exists in a list of hash pointers.
+func hashInSlice(h chainhash.Hash, list []chainhash.Hash) bool {
+	for i := range list {
+		if h == list[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// txIndexFromTxList returns a transaction's index in a list, or -1 if it
+// can not be found.
+func txIndexFromTxList(hash chainhash.Hash, list []*hcashutil.Tx) int {
+	for i, tx := range list {
+		h := tx.Hash()
+		if hash == *h {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func GetCoinbaseHash(msgBlock *wire.MsgBlock) chainhash.Hash {
+	if len(msgBlock.Transactions[1].TxOut) < 2 {
+		return zeroHash
+	}
+
+	if len(msgBlock.Transactions[1].TxOut[0].PkScript) < 32 {
+		return zeroHash
+	}
+
+	var ens [chainhash.HashSize]byte
+	PKScript := msgBlock.Transactions[1].TxOut[0].PkScript
+	for i := 0; i < chainhash.HashSize; i ++{
+		ens[i] = PKScript[i]
+	}
+
+	return ens
+}
+
+// CreateCoinbaseTx returns a coinbase transaction paying an appropriate subsidy
+// based on the passed block height to the provided address.  When the address
+// is nil, the coinbase transaction will instead be redeemable by anyone.
+//
+// See the comment for NewBlockTemplate for more information about why the nil
+// address handling is useful.
+func CreateCoinbaseTx(coinbaseScript []byte,
+	opReturnPkScript []byte,
+	addr hcashutil.Address) (*hcashutil.Tx, error) {
+
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(&wire.TxIn{
+		// Coinbase transactions have no inputs, so previous outpoint is
+		// zero hash and max index.
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+			wire.MaxPrevOutIndex, wire.TxTreeRegular),
+		Sequence:        wire.MaxTxInSequenceNum,
+		BlockHeight:     wire.NullBlockHeight,
+		BlockIndex:      wire.NullBlockIndex,
+		SignatureScript: coinbaseScript,
+	})
+
+	// Extranonce.
+	tx.AddTxOut(&wire.TxOut{
+		Value:    0,
+		PkScript: opReturnPkScript,
+	})
+	// ValueIn.
+	tx.TxIn[0].ValueIn = 0
+
+	// Create the script to pay to the provided payment address if one was
+	// specified.  Otherwise create a script that allows the coinbase to be
+	// redeemable by anyone.
+	var pksSubsidy []byte
+	if addr != nil {
+		var err error
+		pksSubsidy, err = txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		scriptBuilder := txscript.NewScriptBuilder()
+		pksSubsidy, err = scriptBuilder.AddOp(txscript.OP_TRUE).Script()
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Fees paid to miner.
+	tx.AddTxOut(&wire.TxOut{
+		Value:    0,
+		PkScript: pksSubsidy,
+	})
+
+	return hcashutil.NewTx(tx), nil
+}
+
+
+func CreateExtraCoinbaseTx(subsidyCache *blockchain.SubsidyCache,
+	coinbaseScript []byte,
+	opReturnPkScript []byte,
+	nextBlockHeight int64,
+	nextBlockKeyHeight int64,
+	addr hcashutil.Address,
+	voters uint16,
+	params *chaincfg.Params) (*hcashutil.Tx, error) {
+
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(&wire.TxIn{
+		// Coinbase transactions have no inputs, so previous outpoint is
+		// zero hash and max index.
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+			wire.MaxPrevOutIndex, wire.TxTreeRegular),
+		Sequence:        wire.MaxTxInSequenceNum,
+		BlockHeight:     wire.NullBlockHeight,
+		BlockIndex:      wire.NullBlockIndex,
+		SignatureScript: coinbaseScript,
+	})
+
+	// Block one is a special block that might pay out tokens to a ledger.
+	if nextBlockKeyHeight == 0 && len(params.BlockOneLedger) != 0 {
+		// Convert the addresses in the ledger into useable format.
+		addrs := make([]hcashutil.Address, len(params.BlockOneLedger))
+		for i, payout := range params.BlockOneLedger {
+			addr, err := hcashutil.DecodeAddress(payout.Address)
+			if err != nil {
+				return nil, err
+			}
+			addrs[i] = addr
+		}
+
+		for i, payout := range params.BlockOneLedger {
+			// Make payout to this address.
+			pks, err := txscript.PayToAddrScript(addrs[i])
+			if err != nil {
+				return nil, err
+			}
+			tx.AddTxOut(&wire.TxOut{
+				Value:    payout.Amount,
+				PkScript: pks,
+			})
+		}
+
+		tx.TxIn[0].ValueIn = params.BlockOneSubsidy()
+
+		return hcashutil.NewTx(tx), nil
+	}
+
+	// Create a coinbase with correct block subsidy and extranonce.
+	subsidy := blockchain.CalcBlockWorkSubsidy(subsidyCache,
+		nextBlockKeyHeight,
+		voters,
+		params)
+
+	tax := blockchain.CalcBlockTaxSubsidy(subsidyCache,
+		nextBlockKeyHeight,
+		voters,
+		params)
+
+	// Tax output.
+	if params.BlockTaxProportion > 0 {
+		tx.AddTxOut(&wire.TxOut{
+			Value:    tax,
+			PkScript: params.OrganizationPkScript,
+		})
+	} else {
+		// Tax disabled.
+		scriptBuilder := txscript.NewScriptBuilder()
+		trueScript, err := scriptBuilder.AddOp(txscript.OP_TRUE).Script()
+		if err != nil {
+			return nil, err
+		}
+		tx.AddTxOut(&wire.TxOut{
+			Value:    tax,
+			PkScript: trueScript,
+		})
+	}
+	// Extranonce.
+	tx.AddTxOut(&wire.TxOut{
+		Value:    0,
+		PkScript: opReturnPkScript,
+	})
+	// ValueIn.
+	tx.TxIn[0].ValueIn = subsidy + tax
+
+	// Create the script to pay to the provided payment address if one was
+	// specified.  Otherwise create a script that allows the coinbase to be
+	// redeemable by anyone.
+	var pksSubsidy []byte
+	if addr != nil {
+		var err error
+		pksSubsidy, err = txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		scriptBuilder := txscript.NewScriptBuilder()
+		pksSubsidy, err = scriptBuilder.AddOp(txscript.OP_TRUE).Script()
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Subsidy paid to miner.
+	tx.AddTxOut(&wire.TxOut{
+		Value:    subsidy,
+		PkScript: pksSubsidy,
+	})
+
+	// Extra Fees and Stake Fees paid to miner.
+	tx.AddTxOut(&wire.TxOut{
+		Value:    0,
+		PkScript: pksSubsidy,
+	})
+
+	return hcashutil.NewTx(tx), nil
+}
+
+// addCoinbasePayoutOutputs appends an output to the passed coinbase transaction
+// for each but the first of the passed payouts, since the outputs of the
+// coinbase already pay to the first one.  The outputs have no value until
+// splitCoinbasePayouts is called once the fees of the block are known, but
+// they are added up front so the size of the coinbase is final.
+func addCoinbasePayoutOutputs(tx *wire.MsgTx, payouts []CoinbasePayout) error {
+	if len(payouts) < 2 {
+		return nil
+	}
+	for _, payout := range payouts[1:] {
+		pkScript, err := txscript.PayToAddrScript(payout.Address)
+		if err != nil {
+			return err
+		}
+		tx.AddTxOut(&wire.TxOut{
+			Value:    0,
+			PkScript: pkScript,
+		})
+	}
+	return nil
+}
+
+// splitCoinbasePayouts splits the value of the outputs of the passed coinbase
+// transaction at the passed indexes, which pay to the first of the passed
+// payouts, across all payouts according to their weights.  The shares of the
+// other payouts are moved to the outputs appended by addCoinbasePayoutOutputs.
+func splitCoinbasePayouts(tx *wire.MsgTx, payouts []CoinbasePayout, indexes ...int) {
+	if len(payouts) < 2 {
+		return
+	}
+	firstPayoutOut := len(tx.TxOut) - (len(payouts) - 1)
+	for _, idx := range indexes {
+		amounts := SplitCoinbaseValue(tx.TxOut[idx].Value, payouts)
+		tx.TxOut[idx].Value = amounts[0]
+		for i, amount := range amounts[1:] {
+			tx.TxOut[firstPayoutOut+i].Value += amount
+		}
+	}
+}
+
+// spendTransaction updates the passed view by marking the inputs to the passed
+// transaction as spent.  It also adds all outputs in the passed transaction
+// which are not provably unspendable as available unspent transaction outputs.
+func spendTransaction(utxoView *blockchain.UtxoViewpoint, tx *hcashutil.Tx,
+	height int64) error {
+	for _, txIn := range tx.MsgTx().TxIn {
+		originHash := &txIn.PreviousOutPoint.Hash
+		originIndex := txIn.PreviousOutPoint.Index
+		entry := utxoView.LookupEntry(originHash)
+		if entry != nil {
+			entry.SpendOutput(originIndex)
+		}
+
+	}
+
+	utxoView.AddTxOuts(tx, height, wire.NullBlockIndex)
+	return nil
+}
+
+// logSkippedDeps logs any dependencies which are also skipped as a result of
+// skipping a transaction while generating a block template at the trace level.
+func logSkippedDeps(tx *hcashutil.Tx, deps *list.List) {
+	if deps == nil {
+		return
+	}
+
+	for e := deps.Front(); e != nil; e = e.Next() {
+		item := e.Value.(*TxPrioItem)
+		log.Tracef("Skipping tx %s since it depends on %s\n",
+			item.Tx.Hash(), tx.Hash())
+	}
+}
+
+// medianAdjustedTime returns the current time adjusted to ensure it is at least
+// one second after the median timestamp of the last several blocks per the
+// chain consensus rules.
+func (g *BlkTmplGenerator) medianAdjustedTime() (time.Time, error) {
+	// The timestamp for the block must not be before the median timestamp
+	// of the last several blocks.  Thus, choose the maximum between the
+	// current time and one second after the past median time.  The current
+	// timestamp is truncated to a second boundary before comparison since a
+	// block timestamp does not supported a precision greater than one
+	// second.
+	newTimestamp := g.cfg.TimeSource.AdjustedTime()
+	minTimestamp := g.cfg.BlockManager.ChainState().PastMedianTime.Add(
+		time.Second)
+	if newTimestamp.Before(minTimestamp) {
+		newTimestamp = minTimestamp
+	}
+
+	// Adjust by the amount requested from the command line argument.
+	newTimestamp = newTimestamp.Add(
+		time.Duration(-g.cfg.MiningTimeOffset) * time.Second)
+
+	return newTimestamp, nil
+}
+
+// maybeInsertStakeTx checks to make sure that a stake tx is
+// valid from the perspective of the mainchain (not necessarily
+// the mempool or block) before inserting into a tx tree.
+// If it fails the check, it returns false; otherwise true.
+func maybeInsertStakeTx(stx *hcashutil.Tx, treeValid bool, view *blockchain.UtxoViewpoint) bool {
+	missingInput := false
+
+	/*view, err := bm.chain.FetchUtxoView(stx, treeValid)
+	if err != nil {
+		log.Warnf("Unable to fetch transaction store for "+
+			"stx %s: %v", stx.Hash(), err)
+		return false
+	}
+	*/
+	mstx := stx.MsgTx()
+	isSSGen, _ := stake.IsSSGen(mstx)
+	for i, txIn := range mstx.TxIn {
+		// Evaluate if this is a stakebase input or not. If it
+		// is, continue without evaluation of the input.
+		// if isStakeBase
+		if isSSGen && (i == 0) {
+			txIn.BlockHeight = wire.NullBlockHeight
+			txIn.BlockIndex = wire.NullBlockIndex
+
+			continue
+		}
+
+		originHash := &txIn.PreviousOutPoint.Hash
+		utxIn := view.LookupEntry(originHash)
+		if utxIn == nil {
+			missingInput = true
+			break
+		} else {
+			originIdx := txIn.PreviousOutPoint.Index
+			txIn.ValueIn = utxIn.AmountByIndex(originIdx)
+			txIn.BlockHeight = uint32(utxIn.BlockHeight())
+			txIn.BlockIndex = utxIn.BlockIndex()
+		}
+	}
+	return !missingInput
+}
+
+// handleTooFewVoters handles the situation in which there are too few voters on
+// of the blockchain. If there are too few voters and a cached parent template to
+// work off of is present, it will return a copy of that template to pass to the
+// miner.
+// Safe for concurrent access.
+func (g *BlkTmplGenerator) handleTooFewVoters(subsidyCache *blockchain.SubsidyCache,
+	nextHeight int64,
+	nextKeyHeight int64,
+	miningAddress hcashutil.Address) (*BlockTemplate, error) {
+	bm := g.cfg.BlockManager
+	chainState := bm.ChainState()
+	stakeValidationHeight := g.cfg.ChainParams.StakeValidationHeight
+	curTemplate := bm.GetCurrentTemplate()
+	// Check to see if we've fallen off the chain, for example if a
+	// reorganization had recently occurred. If this is the case,
+	// nuke the templates.
+	//prevBlockHash := chainState.GetTopPrevHash()
+	targetHash := chainState.CurPrevKeyHash
+	if standalone.HashToBig(chainState.NewestHash).Cmp(standalone.CompactToBig(chainState.NewestBits)) > 0 {
+		targetBlock, err := g.cfg.Chain.FetchBlockFromHash(&targetHash)
+		if err != nil{
+			return nil, err
+		}else {
+			targetHash = targetBlock.MsgBlock().Header.PrevKeyBlock
+		}
+	}
+	targetKeyBlock, err := g.cfg.Chain.FetchBlockFromHash(&targetHash)
+	if err != nil{
+		return nil, err
+	} else {
+		targetHash =  targetKeyBlock.MsgBlock().Header.PrevBlock
+	}
+
+
+	if curTemplate != nil {
+		if !targetHash.IsEqual(
+			&curTemplate.Block.Header.PrevBlock) {
+			log.Debugf("Cached mining templates are no use, " +
+				"resetting")
+			bm.SetCurrentTemplate(nil)
+			bm.SetParentTemplate(nil)
+		}
+	}
+
+	// Handle not enough voters being present if we're set to mine aggressively
+	// (default behaviour).
+	if nextKeyHeight + 1 >= stakeValidationHeight {
+		if g.cfg.AggressiveMining {
+			if curTemplate != nil {
+				cptCopy := DeepCopyBlockTemplate(curTemplate)
+
+				// Update the timestamp of the old template.
+				ts, err := g.medianAdjustedTime()
+				if err != nil {
+					return nil, err
+				}
+				cptCopy.Block.Header.Timestamp = ts
+
+				// If we're on testnet, the time since this last block
+				// listed as the parent must be taken into consideration.
+				if g.cfg.ChainParams.ReduceMinDifficulty {
+					parentHash := cptCopy.Block.Header.PrevBlock
+
+					requiredDifficulty, err :=
+						bm.CalcNextRequiredDiffNode(&parentHash, ts)
+					if err != nil {
+						return nil, ruleError(ErrGettingDifficulty,
+							err.Error())
+					}
+
+					cptCopy.Block.Header.Bits = requiredDifficulty
+				}
+
+				// Choose a new extranonce value that is one greater
+				// than the previous extranonce, so we don't remine the
+				// same block and choose the same winners as before.
+				ens := CoinbaseExtranonces(cptCopy.Block)
+				ens[0]++
+				err = UpdateExtraNonce(cptCopy.Block, uint32(cptCopy.Height),
+					uint32(cptCopy.KeyHeight), ens)
+				if err != nil {
+					return nil, err
+				}
+
+				// Update extranonce of the original template too, so
+				// we keep getting unique numbers.
+				err = UpdateExtraNonce(curTemplate.Block, uint32(curTemplate.Height),
+					uint32(curTemplate.KeyHeight), ens)
+				if err != nil {
+					return nil, err
+				}
+
+				// Make sure the block validates.
+				block := hcashutil.NewBlockDeepCopyCoinbase(cptCopy.Block)
+				if err := blockchain.CheckWorklessBlockSanity(g.cfg.Chain, block,
+					g.cfg.TimeSource,
+					g.cfg.ChainParams); err != nil {
+					return nil, ruleError(ErrCheckConnectBlock,
+						err.Error())
+				}
+
+				if err := g.cfg.Chain.CheckConnectBlock(block, true); err != nil {
+					log.Errorf("failed to check template while "+
+						"duplicating a parent: %v", err.Error())
+					return nil, ruleError(ErrCheckConnectBlock,
+						err.Error())
+				}
+				cptCopy.GenerateKey = true
+				return cptCopy, nil
+			}
+
+			// We may have just started mining and stored the current block
+			// template, so we don't have a parent.
+			if curTemplate == nil {
+				// Fetch the latest block and head and begin working
+				// off of it with an empty transaction tree regular
+				// and the contents of that stake tree. In the future
+				// we should have the option of readding some
+				// transactions from this block, too.
+				//topBlock, err := bm.GetTopBlockFromChain()
+				topKeyBlock, err := bm.GetTopBlockFromChain()
+				if err != nil {
+					return nil, fmt.Errorf("failed to get top block from " +
+						"chain")
+				}
+				topBlockHash := topKeyBlock.Hash()
+				if standalone.HashToBig(topBlockHash).Cmp(standalone.CompactToBig(topKeyBlock.MsgBlock().Header.Bits)) > 0 {
+					topKeyBlock, err = g.cfg.Chain.FetchBlockFromHash(&(topKeyBlock.MsgBlock().Header.PrevKeyBlock))
+					if err != nil{
+						return nil, fmt.Errorf("failed to get top block from chain")
+					}
+				}
+
+
+				btMsgBlock := new(wire.MsgBlock)
+				rand, err := wire.RandomUint64()
+				if err != nil {
+					return nil, err
+				}
+				opReturnPkScript, err :=
+					StandardCoinbaseOpReturn(topKeyBlock.MsgBlock().Header.KeyHeight,
+						[]uint64{0, 0, 0, rand})
+				if err != nil {
+					return nil, err
+				}
+
+				extraCoinbaseTx, err := CreateExtraCoinbaseTx(subsidyCache,
+					[]byte{0x01, 0x02},
+					opReturnPkScript,
+					topKeyBlock.Height(),
+					int64(topKeyBlock.MsgBlock().Header.KeyHeight),
+					miningAddress,
+					topKeyBlock.MsgBlock().Header.Voters,
+					g.cfg.ChainParams)
+				if err != nil {
+					return nil, err
+				}
+				btMsgBlock.AddTransaction(extraCoinbaseTx.MsgTx())
+
+				opReturnExtraPkScript, err :=
+					StandardExtraCoinbaseOpReturn(topKeyBlock.MsgBlock().Header.Height, extraCoinbaseTx.MsgTx().TxHashFull())
+				if err != nil {
+					return nil, err
+				}
+				coinbaseTx, err := CreateCoinbaseTx([]byte{0x01, 0x02},
+					opReturnExtraPkScript,
+					miningAddress)
+
+				if err != nil {
+					return nil, err
+				}
+				btMsgBlock.AddTransaction(coinbaseTx.MsgTx())
+
+				for _, stx := range topKeyBlock.STransactions() {
+					btMsgBlock.AddSTransaction(stx.MsgTx())
+				}
+
+				// Copy the rest of the header.
+				btMsgBlock.Header = topKeyBlock.MsgBlock().Header
+
+				// Set a fresh timestamp.
+				ts, err := g.medianAdjustedTime()
+				if err != nil {
+					return nil, err
+				}
+				btMsgBlock.Header.Timestamp = ts
+
+				// If we're on testnet, the time since this last block
+				// listed as the parent must be taken into consideration.
+				if g.cfg.ChainParams.ReduceMinDifficulty {
+					parentHash := topKeyBlock.MsgBlock().Header.PrevBlock
+
+					requiredDifficulty, err :=
+						bm.CalcNextRequiredDiffNode(&parentHash, ts)
+					if err != nil {
+						return nil, ruleError(ErrGettingDifficulty,
+							err.Error())
+					}
+
+					btMsgBlock.Header.Bits = requiredDifficulty
+				}
+
+				// Recalculate the size.
+				btMsgBlock.Header.Size = uint32(btMsgBlock.SerializeSize())
+
+				bt := &BlockTemplate{
+					Block:           btMsgBlock,
+					Fees:            []int64{0},
+					SigOpCounts:     []int64{0},
+					Height:          int64(topKeyBlock.MsgBlock().Header.Height),
+					ValidPayAddress: miningAddress != nil,
+					GenerateKey:     false,
+				}
+
+				// Recalculate the merkle roots. Use a temporary 'immutable'
+				// block object as we're changing the header contents.
+				btBlockTemp := hcashutil.NewBlockDeepCopyCoinbase(btMsgBlock)
+				merkles :=
+					blockchain.BuildMerkleTreeStore(btBlockTemp.Transactions(), false)
+				merklesStake :=
+					blockchain.BuildMerkleTreeStore(btBlockTemp.STransactions(), false)
+				btMsgBlock.Header.MerkleRoot = *merkles[len(merkles)-1]
+				btMsgBlock.Header.StakeRoot = *merklesStake[len(merklesStake)-1]
+
+				// Make sure the block validates.
+				btBlock := hcashutil.NewBlockDeepCopyCoinbase(btMsgBlock)
+				if err := blockchain.CheckWorklessBlockSanity(g.cfg.Chain, btBlock,
+					g.cfg.TimeSource,
+					g.cfg.ChainParams); err != nil {
+					str := fmt.Sprintf("failed to check sanity of template "+
+						"while constructing a new parent: %v",
+						err.Error())
+					return nil, ruleError(ErrCheckConnectBlock,
+						str)
+				}
+
+				if err := g.cfg.Chain.CheckConnectBlock(btBlock, true); err != nil {
+					str := fmt.Sprintf("failed to check template: %v while "+
+						"constructing a new parent", err.Error())
+					return nil, ruleError(ErrCheckConnectBlock,
+						str)
+				}
+
+				// Make a copy to return.
+				cptCopy := DeepCopyBlockTemplate(bt)
+				cptCopy.GenerateKey = true
+				return cptCopy, nil
+			}
+		}
+	}
+
+	log.Debugf("Not enough voters on top block to generate " +
+		"new block template")
+
+	return nil, nil
+}
+
+// handleCreatedBlockTemplate stores a successfully created block template to
+// the appropriate cache if needed, then returns the template to the miner to
+// work on. The stored template is a copy of the template, to prevent races
+// from occurring in case the template is mined on by the CPUminer.
+func (g *BlkTmplGenerator) handleCreatedBlockTemplate(blockTemplate *BlockTemplate) (*BlockTemplate, error) {
+	bm := g.cfg.BlockManager
+	curTemplate := bm.GetCurrentTemplate()
+
+	nextBlockHeight := blockTemplate.Height
+	nextBlockKeyHeight := blockTemplate.KeyHeight
+	stakeValidationHeight := g.cfg.ChainParams.StakeValidationHeight
+	// This is where we begin storing block templates, when either the
+	// program is freshly started or the chain is matured to stake
+	// validation height.
+	if curTemplate == nil && nextBlockKeyHeight + 1 >= stakeValidationHeight-2 {
+		bm.SetCurrentTemplate(blockTemplate)
+	}
+
+	// We're at the height where the next block needs to include SSGens,
+	// so we check to if CachedCurrentTemplate is out of date. If it is,
+	// we store it as the cached parent template, and store the new block
+	// template as the currenct template.
+	if curTemplate != nil &&
+		nextBlockKeyHeight + 1 >= stakeValidationHeight-1 {
+		if curTemplate.Height < nextBlockHeight {
+			bm.SetParentTemplate(curTemplate)
+			bm.SetCurrentTemplate(blockTemplate)
+		}
+	}
+
+	// Overwrite the old cached block if it's out of date.
+	if curTemplate != nil {
+		if curTemplate.Height == nextBlockHeight {
+			bm.SetCurrentTemplate(blockTemplate)
+		}
+	}
+
+	return blockTemplate, nil
+}
+
+// NewBlockTemplate returns a new block template that is ready to be solved
+// using the transactions from the passed transaction source pool and a coinbase
+// that either pays to the passed address if it is not nil, or a coinbase that
+// is redeemable by anyone if the passed address is nil.  The nil address
+// functionality is useful since there are cases such as the getblocktemplate
+// RPC where external mining software is responsible for creating their own
+// coinbase which will replace the one generated for the block template.  Thus
+// the need to have configured address can be avoided.
+//
+// The transactions selected and included are prioritized according to several
+// factors.  First, each transaction has a priority calculated based on its
+// value, age of inputs, and size.  Transactions which consist of larger
+// amounts, older inputs, and small sizes have the highest priority.  Second, a
+// fee per kilobyte is calculated for each transaction.  Transactions with a
+// higher fee per kilobyte are preferred.  Finally, the block generation related
+// policy settings are all taken into account.
+//
+// Transactions which only spend outputs from other transactions already in the
+// block chain are immediately added to a priority queue which either
+// prioritizes based on the priority (then fee per kilobyte) or the fee per
+// kilobyte (then priority) depending on whether or not the BlockPrioritySize
+// policy setting allots space for high-priority transactions.  Transactions
+// which spend outputs from other transactions in the source pool are added to a
+// dependency map so they can be added to the priority queue once the
+// transactions they depend on have been included.
+//
+// Once the high-priority area (if configured) has been filled with
+// transactions, or the priority falls below what is considered high-priority,
+// the priority queue is updated to prioritize by fees per kilobyte (then
+// priority).
+//
+// When the fees per kilobyte drop below the TxMinFreeFee policy setting, the
+// transaction will be skipped unless the BlockMinSize policy setting is
+// nonzero, in which case the block will be filled with the low-fee/free
+// transactions until the block size reaches that minimum size.
+//
+// Any transactions which would cause the block to exceed the BlockMaxSize
+// policy setting, exceed the maximum allowed signature operations per block, or
+// otherwise cause the block to be invalid are skipped.
+//
+// Given the above, a block generated by this function is of the following form:
+//
+//   -----------------------------------  --  --
+//  |      Coinbase Transaction         |   |   |
+//  |-----------------------------------|   |   |
+//  |                                   |   |   | ----- policy.BlockPrioritySize
+//  |   High-priority Transactions      |   |   |
+//  |                                   |   |   |
+//  |-----------------------------------|   | --
+//  |                                   |   |
+//  |                                   |   |
+//  |                                   |   |--- (policy.BlockMaxSize) / 2
+//  |  Transactions prioritized by fee  |   |
+//  |  until <= policy.TxMinFreeFee     |   |
+//  |                                   |   |
+//  |                                   |   |
+//  |                                   |   |
+//  |-----------------------------------|   |
+//  |  Low-fee/Non high-priority (free) |   |
+//  |  transactions (while block size   |   |
+//  |  <= policy.BlockMinSize)          |   |
+//   -----------------------------------  --
+//
+// TODO - HYPERCASH
+// We also need to include a stake tx tree that looks like the following:
+//
+//   -----------------------------------  --  --
+//  |                                   |   |   |
+//  |           SSGen tx                |   |   | ----- cfg.SSGenAllocatedSize ?
+//  |                                   |   |   |
+//  |-----------------------------------|   | --
+//  |                                   |   |
+//  |            SStx tx                |   |--- (policy.BlockMaxSize) / 2
+//  |                                   |   |
+//  |-----------------------------------|   |
+//  |                                   |   |
+//  |           SSRtx tx                |   |
+//  |                                   |   |
+//   -----------------------------------  --
+//
+//  This function returns nil, nil if there are not enough voters on any of
+//  the current top blocks to create a new block template.
+func (g *BlkTmplGenerator) NewBlockTemplate(payToAddress hcashutil.Address) (*BlockTemplate, error) {
+	policy := g.cfg.Policy
+	txSource := g.cfg.TxSource
+	blockManager := g.cfg.BlockManager
+	timeSource := g.cfg.TimeSource
+	subsidyCache := g.cfg.Chain.FetchSubsidyCache()
+
+	// Extend the most recently known best block.
+	// The most recently known best block is the top block that has the most
+	// ssgen votes for it. We only need this after the height in which stake voting
+	// has kicked in.
+	// To figure out which block has the most ssgen votes, we need to run the
+	// following algorithm:
+	// 1. Acquire the HEAD block and all of its orphans. Record their block header
+	// hashes.
+	// 2. Create a map of [blockHeaderHash] --> [mempoolTxnList].
+	// 3. for blockHeaderHash in candidateBlocks:
+	//		if mempoolTx.StakeDesc == SSGen &&
+	//			mempoolTx.SSGenParseBlockHeader() == blockHeaderHash:
+	//			map[blockHeaderHash].append(mempoolTx)
+	// 4. Check len of each map entry and store.
+	// 5. Query the ticketdb and check how many eligible ticket holders there are
+	//    for the given block you are voting on.
+	// 6. Divide #ofvotes (len(map entry)) / totalPossibleVotes --> penalty ratio
+	// 7. Store penalty ratios for all block candidates.
+	// 8. Select the one with the largest penalty ratio (highest block reward).
+	//    This block is then selected to build upon instead of the others, because
+	//    it yields the greater amount of rewards.
+	chainState := blockManager.ChainState()
+	prevHash := chainState.NewestHash
+	prevKeyHash := &chainState.CurPrevKeyHash
+	nextBlockHeight := chainState.NewestHeight + 1
+	nextBlockKeyHeight := chainState.NewestKeyHeight
+	targetDifficulty := standalone.CompactToBig(chainState.NewestBits)
+
+	if standalone.HashToBig(prevHash).Cmp(targetDifficulty) <= 0 {
+		prevKeyHash = prevHash
+		nextBlockKeyHeight += 1
+	}
+
+	poolSize := chainState.NextPoolSize
+	reqStakeDifficulty := chainState.NextStakeDifficulty
+	finalState := chainState.NextFinalState
+	winningTickets := chainState.WinningTickets
+	missedTickets := chainState.MissedTickets
+
+	chainBest := g.cfg.Chain.BestSnapshot()
+	if *prevHash != *chainBest.Hash ||
+		nextBlockHeight-1 != chainBest.Height {
+		return nil, fmt.Errorf("chain state is not syncronized to the "+
+			"blockchain (got %v:%v, want %v,%v",
+			prevHash, nextBlockHeight-1, chainBest.Hash, chainBest.Height)
+	}
+
+	// Calculate the stake enabled height.
+
+	stakeValidationHeight := g.cfg.ChainParams.StakeValidationHeight
+
+
+	if nextBlockKeyHeight + 1 >= stakeValidationHeight {
+		// Obtain the entire generation of blocks stemming from this parent.
+		targetHashes := make([]chainhash.Hash, 1, 1)
+		targetHashes[0] = *prevKeyHash
+		votes := txSource.VotesForBlocks(targetHashes)
+		voteYea := uint16(0)
+		voteNay := uint16(0)
+		for _, vote := range votes[0]{
+			if vote.Vote {
+				voteYea++
+			} else {
+				voteNay++
+			}
+		}
+		if voteYea <= g.cfg.ChainParams.TicketsPerBlock / 2 && voteNay <= g.cfg.ChainParams.TicketsPerBlock /2 {
+
+			keyChildren, err := blockManager.GetKeyGeneration(*prevKeyHash)
+			if err != nil {
+				return nil, ruleError(ErrFailedToGetKeyGeneration, err.Error())
+			}
+
+			// Get the list of blocks that we can actually build on top of. If we're
+			// not currently on the block that has the most votes, switch to that
+			// block.
+			eligibleParents := SortParentsByVotes(txSource, *prevKeyHash, keyChildren,
+				g.cfg.ChainParams)
+			if len(eligibleParents) == 0 {
+				log.Debugf("Too few voters found on any HEAD block, " +
+					"recycling a parent block to mine on")
+				return g.handleTooFewVoters(subsidyCache, nextBlockHeight, nextBlockKeyHeight,
+					payToAddress)
+			}
+
+			log.Debugf("Found eligible parent %v with enough votes to build "+
+				"block on, proceeding to create a new block template",
+				eligibleParents[0])
+
+			// Force a reorganization to the parent with the most votes if we need
+			// to.
+			if eligibleParents[0] != *prevKeyHash {
+				for _, newHead := range eligibleParents {
+					err := blockManager.ForceReorganization(*prevHash, newHead)
+
+					if err != nil {
+						log.Errorf("failed to reorganize to new parent: %v", err)
+						continue
+					}
+
+					// Check to make sure we actually have the transactions
+					// (votes) we need in the mempool.
+					voteHashes := txSource.VoteHashesForBlock(newHead)
+					if len(voteHashes) == 0 {
+						return nil, fmt.Errorf("no vote metadata for block %v",
+							newHead)
+					}
+
+					if exist := txSource.CheckIfTxsExist(voteHashes); !exist {
+						continue
+					} else {
+						log.Debugf("Reorganization occurs, return from the start")
+						return nil, nil
+					}
+				}
+			}
+		}
+		keyBlockVoteResult := uint16(0)
+		if voteYea > g.cfg.ChainParams.TicketsPerBlock / 2 {
+			keyBlockVoteResult = 1
+		}
+		prevBlock, err := g.cfg.Chain.FetchBlockFromHash(prevHash)
+		if err != nil {
+			return nil, fmt.Errorf("current block %v does not exist", *prevHash)
+		}
+
+		if keyBlockVoteResult != prevBlock.MsgBlock().Header.VoteBits & hcashutil.BlockValid {
+			matchedDescendants, err := blockManager.GetMatchedDescendants(*prevKeyHash, keyBlockVoteResult)
+			if err != nil {
+				return nil, ruleError(ErrFailedToGetMatchedDescendants, err.Error())
+			}
+			log.Debugf("Found eligible parent %v with same votes"+
+				", proceeding to create a new block template",
+				matchedDescendants[0])
+
+			for _, newHead := range matchedDescendants{
+				err := blockManager.ForceReorganization(*prevHash, newHead)
+				if err != nil {
+					log.Errorf("failed to reorganize to new parent: %v", err)
+				} else {
+					break
+				}
+			}
+
+		}
+
+	}
+
+	// Get the current source transactions and create a priority queue to
+	// hold the transactions which are ready for inclusion into a block
+	// along with some priority related and fee metadata.  Reserve the same
+	// number of items that are available for the priority queue.  Also,
+	// choose the initial sort order for the priority queue based on whether
+	// or not there is an area allocated for high-priority transactions.
+	sourceTxns := txSource.MiningDescs()
+	sortedByFee := policy.BlockPrioritySize == 0
+	lessFunc := TxPQByStakeAndFeeAndThenPriority
+	if sortedByFee {
+		lessFunc = TxPQByStakeAndFee
+	}
+	priorityQueue := NewTxPriorityQueue(len(sourceTxns), lessFunc)
+
+	// Create a slice to hold the transactions to be included in the
+	// generated block with reserved space.  Also create a utxo view to
+	// house all of the input transactions so multiple lookups can be
+	// avoided.
+	blockTxns := make([]*hcashutil.Tx, 0, len(sourceTxns))
+	//blockUtxos := blockchain.NewUtxoViewpoint()
+
+
+	// dependers is used to track transactions which depend on another
+	// transaction in the source pool.  This, in conjunction with the
+	// dependsOn map kept with each dependent transaction helps quickly
+	// determine which dependent transactions are now eligible for inclusion
+	// in the block once each transaction has been included.
+	dependers := make(map[chainhash.Hash]*list.List)
+
+	// Create slices to hold the fees and number of signature operations
+	// for each of the selected transactions and add an entry for the
+	// coinbase.  This allows the code below to simply append details about
+	// a transaction as it is selected for inclusion in the final block.
+	// However, since the total fees aren't known yet, use a dummy value for
+	// the coinbase fee which will be updated later.
+	txFees := make([]int64, 0, len(sourceTxns))
+	txFeesMap := make(map[chainhash.Hash]int64)
+	txSigOpCounts := make([]int64, 0, len(sourceTxns))
+	txSigOpCountsMap := make(map[chainhash.Hash]int64)
+	txFees = append(txFees, -1) // Updated once known
+
+	log.Debugf("Considering %d transactions for inclusion to new block",
+		len(sourceTxns))
+	treeValid := txSource.IsTxTreeValid(prevHash)
+	blockUtxos := blockchain.NewUtxoViewpoint()
+	var err error
+	if len(sourceTxns) > 0 {
+		blockUtxos, err = g.cfg.Chain.FetchCurrentUtxoView(treeValid)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to fetch current utxoview")
+		}
+		if *blockUtxos.BestHash() != *prevHash {
+			return nil, fmt.Errorf("Best hash changed from %v to %v", prevHash, blockUtxos.BestHash())
+		}
+	}
+
+	// Limit the block size to the size permitted by the consensus rules for
+	// the next block, which depends on the state of the max block size
+	// agenda.  1000 bytes is subtracted from the max to account for
+	// overhead.
+	maxBlockSize, err := g.cfg.Chain.MaxBlockSize()
+	if err != nil {
+		return nil, err
+	}
+	blockMaxSize := policy.BlockMaxSize
+	if blockMaxSize > uint32(maxBlockSize)-1000 {
+		blockMaxSize = uint32(maxBlockSize) - 1000
+	}
+
+mempoolLoop:
+	for _, txDesc := range sourceTxns {
+		// A block can't have more than one coinbase or contain
+		// non-finalized transactions.
+		tx := txDesc.Tx
+		msgTx := tx.MsgTx()
+		if blockchain.IsCoinBaseTx(msgTx) {
+			log.Tracef("Skipping coinbase tx %s", tx.Hash())
+			continue
+		}
+		if !blockchain.IsFinalizedTransaction(tx, nextBlockKeyHeight,
+			timeSource.AdjustedTime()) {
+			log.Tracef("Skipping non-finalized tx %s", tx.Hash())
+			continue
+		}
+
+		// Need this for a check below for stake base input, and to check
+		// the ticket number.
+		isSSGen := txDesc.Type == stake.TxTypeSSGen
+		if isSSGen {
+			blockHash, _, blockKeyHeight, err := stake.SSGenBlockVotedOn(msgTx)
+			if err != nil { // Should theoretically never fail.
+				log.Tracef("Skipping ssgen tx %s because of failure "+
+					"to extract block voting data", tx.Hash())
+				continue
+			}
+
+			if !((blockHash == *prevKeyHash) && (int64(blockKeyHeight) == nextBlockKeyHeight-1)) {
+				log.Tracef("Skipping ssgen tx %s because it does "+
+					"not vote on the correct block", tx.Hash())
+				continue
+			}
+		}
+
+
+
+		// Fetch all of the utxos referenced by the this transaction.
+		// NOTE: This intentionally does not fetch inputs from the
+		// mempool since a transaction which depends on other
+		// transactions in the mempool must come after those
+		var bestChanged bool
+		blockUtxos, bestChanged, err = g.cfg.Chain.AddTxToUtxoView(blockUtxos, tx)
+		if bestChanged {
+			return nil, fmt.Errorf("Best hash changed when collecting txs from %v to %v", prevHash, blockUtxos.BestHash())
+		}
+
+
+		if err != nil {
+			log.Warnf("Unable to fetch utxo view for tx %s: "+
+				"%v", tx.Hash(), err)
+			continue
+		}
+
+
+		/*
+		utxos, err := g.cfg.Chain.FetchUtxoView(tx, treeValid)
+		if err != nil {
+			log.Warnf("Unable to fetch utxo view for tx %s: "+
+				"%v", tx.Hash(), err)
+			continue
+		}
+		*/
+
+		// Setup dependencies for any transactions which reference
+		// other transactions in the mempool so they can be properly
+		// ordered below.
+		prioItem := &TxPrioItem{Tx: txDesc.Tx, TxType: txDesc.Type}
+		for i, txIn := range tx.MsgTx().TxIn {
+			// Evaluate if this is a stakebase input or not. If it is, continue
+			// without evaluation of the input.
+			// if isStakeBase
+			if isSSGen && (i == 0) {
+				continue
+			}
+
+			originHash := &txIn.PreviousOutPoint.Hash
+			originIndex := txIn.PreviousOutPoint.Index
+			//utxoEntry := utxos.LookupEntry(originHash)
+			utxoEntry := blockUtxos.LookupEntry(originHash)
+
+			if utxoEntry == nil || utxoEntry.IsOutputSpent(originIndex) {
+
+
+				if !txSource.HaveTransaction(originHash) {
+					log.Tracef("Skipping tx %s because "+
+						"it references unspent output "+
+						"%s which is not available",
+						tx.Hash(), txIn.PreviousOutPoint)
+					continue mempoolLoop
+				}
+
+				// The transaction is referencing another
+				// transaction in the source pool, so setup an
+				// ordering dependency.
+				depList, exists := dependers[*originHash]
+				if !exists {
+					depList = list.New()
+					dependers[*originHash] = depList
+				}
+				depList.PushBack(prioItem)
+				if prioItem.DependsOn == nil {
+					prioItem.DependsOn = make(
+						map[chainhash.Hash]struct{})
+				}
+				prioItem.DependsOn[*originHash] = struct{}{}
+
+				// Skip the check below. We already know the
+				// referenced transaction is available.
+				continue
+			}
+		}
+
+		// Calculate the final transaction priority using the input
+		// value age sum as well as the adjusted transaction size.  The
+		// formula is: sum(inputValue * inputAge) / adjustedTxSize
+		prioItem.Priority = CalcPriority(tx.MsgTx(), blockUtxos,
+			nextBlockHeight)
+
+		// Calculate the fee in Atoms/KB.
+		// NOTE: This is a more precise value than the one calculated
+		// during calcMinRelayFee which rounds up to the nearest full
+		// kilobyte boundary.  This is beneficial since it provides an
+		// incentive to create smaller transactions.
+		txSize := tx.MsgTx().SerializeSize()
+		prioItem.FeePerKB = (float64(txDesc.Fee) * float64(kilobyte)) /
+			float64(txSize)
+		prioItem.Fee = txDesc.Fee
+
+		// Add the transaction to the priority queue to mark it ready
+		// for inclusion in the block unless it has dependencies.
+		if prioItem.DependsOn == nil {
+			heap.Push(priorityQueue, prioItem)
+		}
+
+		// Merge the referenced outputs from the input transactions to
+		// this transaction into the block utxo view.  This allows the
+		// code below to avoid a second lookup.
+		//mergeUtxoView(blockUtxos, utxos)
+		/*
+		if *(utxos.BestHash()) != *(blockUtxos2.BestHash()){
+			log.Infof("Oxygen Hash not equal %v %v\n", utxos.BestHash(), blockUtxos2.BestHash())
+		}
+
+		if len(blockUtxos.Entries()) != len(blockUtxos2.Entries()){
+			log.Infof("Oxygen len not equal %v %v\n", len(blockUtxos.Entries()), len(blockUtxos2.Entries()))
+		}
+		for AHash, AA := range blockUtxos.Entries(){
+			_, exists := blockUtxos2.Entries()[AHash]
+			if !exists{
+				log.Infof("Oxygen Not exists %v\n", AA)
+			}
+		}
+		*/
+
+	}
+	blockUtxosCopy := blockchain.DeepCopyUtxoViewpoint(blockUtxos)
+
+	log.Tracef("Priority queue len %d, dependers len %d",
+		priorityQueue.Len(), len(dependers))
+
+	// The starting block size is the size of the block header plus the max
+	// possible transaction count size, plus the size of the coinbase
+	// transaction.
+	blockSize := uint32(blockHeaderOverhead)
+
+	// Guesstimate for sigops based on valid txs in loop below. This number
+	// tends to overestimate sigops because of the way the loop below is
+	// coded and the fact that tx can sometimes be removed from the tx
+	// trees if they fail one of the stake checks below the priorityQueue
+	// pop loop. This is buggy, but not catastrophic behaviour. A future
+	// release should fix it. TODO
+	blockSigOps := int64(0)
+	totalFees := int64(0)
+	extraTotalFees := int64(0)
+
+	numSStx := 0
+
+	foundWinningTickets := make(map[chainhash.Hash]bool, len(winningTickets))
+	for _, ticketHash := range winningTickets {
+		foundWinningTickets[ticketHash] = false
+	}
+
+	// Choose which transactions make it into the block.
+	for priorityQueue.Len() > 0 {
+		// Grab the highest priority (or highest fee per kilobyte
+		// depending on the sort order) transaction.
+		prioItem := heap.Pop(priorityQueue).(*TxPrioItem)
+		tx := prioItem.Tx
+
+		// Store if this is an SStx or not.
+		isSStx := prioItem.TxType == stake.TxTypeSStx
+
+		// Store if this is an SSGen or not.
+		isSSGen := prioItem.TxType == stake.TxTypeSSGen
+
+		// Store if this is an SSRtx or not.
+		isSSRtx := prioItem.TxType == stake.TxTypeSSRtx
+
+		// Grab the list of transactions which depend on this one (if
+		// any) and remove the entry for this transaction as it will
+		// either be included or skipped, but in either case the deps
+		// are no longer needed.
+		deps := dependers[*tx.Hash()]
+		delete(dependers, *tx.Hash())
+
+		// Skip if we already have too many SStx.
+		if isSStx && (numSStx >=
+			int(g.cfg.ChainParams.MaxFreshStakePerBlock)) {
+			log.Tracef("Skipping sstx %s because it would exceed "+
+				"the max number of sstx allowed in a block", tx.Hash())
+			logSkippedDeps(tx, deps)
+			continue
+		}
+
+		// Skip if the SStx commit value is below the value required by the
+		// stake diff.
+		if isSStx && (tx.MsgTx().TxOut[0].Value < reqStakeDifficulty) {
+			continue
+		}
+
+		// Skip all missed tickets that we've never heard of.
+		if isSSRtx {
+			ticketHash := &tx.MsgTx().TxIn[0].PreviousOutPoint.Hash
+
+			if !hashInSlice(*ticketHash, missedTickets) {
+				continue
+			}
+		}
+
+		// Enforce maximum block size.  Also check for overflow.
+		txSize := uint32(tx.MsgTx().SerializeSize())
+		blockPlusTxSize := blockSize + txSize
+		if blockPlusTxSize < blockSize || blockPlusTxSize >= blockMaxSize {
+			log.Tracef("Skipping tx %s (size %v) because it "+
+				"would exceed the max block size; cur block "+
+				"size %v, cur num tx %v", tx.Hash(), txSize,
+				blockSize, len(blockTxns))
+			logSkippedDeps(tx, deps)
+			continue
+		}
+
+		// Enforce maximum signature operations per block.  Also check
+		// for overflow.
+		numSigOps := int64(blockchain.CountSigOps(tx, false, isSSGen))
+		if blockSigOps+numSigOps < blockSigOps ||
+			blockSigOps+numSigOps > blockchain.MaxSigOpsPerBlock {
+			log.Tracef("Skipping tx %s because it would "+
+				"exceed the maximum sigops per block", tx.Hash())
+			logSkippedDeps(tx, deps)
+			continue
+		}
+
+		// This isn't very expensive, but we do this check a number of times.
+		// Consider caching this in the mempool in the future. - Hypercash
+		numP2SHSigOps, err := blockchain.CountP2SHSigOps(tx, false,
+			isSSGen, blockUtxos)
+		if err != nil {
+			log.Tracef("Skipping tx %s due to error in "+
+				"CountP2SHSigOps: %v", tx.Hash(), err)
+			logSkippedDeps(tx, deps)
+			continue
+		}
+		numSigOps += int64(numP2SHSigOps)
+		if blockSigOps+numSigOps < blockSigOps ||
+			blockSigOps+numSigOps > blockchain.MaxSigOpsPerBlock {
+			log.Tracef("Skipping tx %s because it would "+
+				"exceed the maximum sigops per block (p2sh)",
+				tx.Hash())
+			logSkippedDeps(tx, deps)
+			continue
+		}
+
+		// Check to see if the SSGen tx actually uses a ticket that is
+		// valid for the next block.
+		if isSSGen {
+			if foundWinningTickets[tx.MsgTx().TxIn[1].PreviousOutPoint.Hash] {
+				continue
+			}
+			msgTx := tx.MsgTx()
+			isEligible := false
+			for _, sstxHash := range winningTickets {
+				if sstxHash.IsEqual(&msgTx.TxIn[1].PreviousOutPoint.Hash) {
+					isEligible = true
+				}
+			}
+
+			if !isEligible {
+				continue
+			}
+		}
+
+		// Skip free transactions once the block is larger than the
+		// minimum block size, except for stake transactions.
+		if sortedByFee &&
+			(prioItem.FeePerKB < float64(policy.TxMinFreeFee)) &&
+			(tx.Tree() != wire.TxTreeStake) &&
+			(blockPlusTxSize >= policy.BlockMinSize) {
+
+			log.Tracef("Skipping tx %s with feePerKB %.2f "+
+				"< TxMinFreeFee %d and block size %d >= "+
+				"minBlockSize %d", tx.Hash(), prioItem.FeePerKB,
+				policy.TxMinFreeFee, blockPlusTxSize,
+				policy.BlockMinSize)
+			logSkippedDeps(tx, deps)
+			continue
+		}
+
+		// Prioritize by fee per kilobyte once the block is larger than
+		// the priority size or there are no more high-priority
+		// transactions.
+		if !sortedByFee && (blockPlusTxSize >= policy.BlockPrioritySize ||
+			prioItem.Priority <= MinHighPriority) {
+
+			log.Tracef("Switching to sort by fees per "+
+				"kilobyte blockSize %d >= BlockPrioritySize "+
+				"%d || priority %.2f <= minHighPriority %.2f",
+				blockPlusTxSize, policy.BlockPrioritySize,
+				prioItem.Priority, MinHighPriority)
+
+			sortedByFee = true
+			priorityQueue.SetLessFunc(TxPQByStakeAndFee)
+
+			// Put the transaction back into the priority queue and
+			// skip it so it is re-priortized by fees if it won't
+			// fit into the high-priority section or the priority is
+			// too low.  Otherwise this transaction will be the
+			// final one in the high-priority section, so just fall
+			// though to the code below so it is added now.
+			if blockPlusTxSize > policy.BlockPrioritySize ||
+				prioItem.Priority < MinHighPriority {
+
+				heap.Push(priorityQueue, prioItem)
+				dependers[*(tx.Hash())] = deps
+				continue
+			}
+		}
+
+		// Ensure the transaction inputs pass all of the necessary
+		// preconditions before allowing it to be added to the block.
+		// The fraud proof is not checked because it will be filled in
+		// by the miner.
+		keyHeightCache := make(map[int64]int64)
+		keyHeightCache[nextBlockHeight] = nextBlockKeyHeight
+
+
+		_, _, _, err = blockchain.CheckTransactionInputs(g.cfg.Chain, subsidyCache, tx,
+			nextBlockHeight, nextBlockKeyHeight, blockUtxos, blockchain.FraudProofNoCheck, g.cfg.ChainParams, keyHeightCache)
+		if err != nil {
+			log.Tracef("Skipping tx %s due to error in "+
+				"CheckTransactionInputs: %v", tx.Hash(), err)
+			logSkippedDeps(tx, deps)
+			continue
+		}
+		err = blockchain.ValidateTransactionScripts(tx, blockUtxos,
+			txscript.StandardVerifyFlags, g.cfg.SigCache)
+		if err != nil {
+			log.Tracef("Skipping tx %s due to error in "+
+				"ValidateTransactionScripts: %v", tx.Hash(), err)
+			logSkippedDeps(tx, deps)
+			continue
+		}
+
+		// Spend the transaction inputs in the block utxo view and add
+		// an entry for it to ensure any transactions which reference
+		// this one have it available as an input and can ensure they
+		// aren't double spending.
+		err = spendTransaction(blockUtxos, tx, nextBlockHeight)
+		if err != nil {
+			log.Warnf("Unable to spend transaction %v in the preliminary "+
+				"UTXO view for the block template: %v",
+				tx.Hash(), err)
+		}
+
+		// Add the transaction to the block, increment counters, and
+		// save the fees and signature operation counts to the block
+		// template.
+		blockTxns = append(blockTxns, tx)
+		blockSize += txSize
+		blockSigOps += numSigOps
+
+		// Accumulate the SStxs in the block, because only a certain number
+		// are allowed.
+		if isSStx {
+			numSStx++
+		}
+		if isSSGen {
+			foundWinningTickets[tx.MsgTx().TxIn[1].PreviousOutPoint.Hash] = true
+		}
+
+		txFeesMap[*tx.Hash()] = prioItem.Fee
+		txSigOpCountsMap[*tx.Hash()] = numSigOps
+
+		log.Tracef("Adding tx %s (priority %.2f, feePerKB %.2f)",
+			prioItem.Tx.Hash(), prioItem.Priority, prioItem.FeePerKB)
+
+		// Add transactions which depend on this one (and also do not
+		// have any other unsatisified dependencies) to the priority
+		// queue.
+		if deps != nil {
+			for e := deps.Front(); e != nil; e = e.Next() {
+				// Add the transaction to the priority queue if
+				// there are no more dependencies after this
+				// one.
+				item := e.Value.(*TxPrioItem)
+				delete(item.DependsOn, *tx.Hash())
+				if len(item.DependsOn) == 0 {
+					heap.Push(priorityQueue, item)
+				}
+			}
+		}
+	}
+
+	// Build tx list for stake tx.
+	blockTxnsStake := make([]*hcashutil.Tx, 0, len(blockTxns))
+
+	// Stake tx ordering in stake tree:
+	// 1. SSGen (votes).
+	// 2. SStx (fresh stake tickets).
+	// 3. SSRtx (revocations for missed tickets).
+
+	// Get the block votes (SSGen tx) and store them and their number.
+	voters := 0
+	var voteBitsVoters []uint16
+
+	for _, tx := range blockTxns {
+		msgTx := tx.MsgTx()
+		if nextBlockKeyHeight + 1 < stakeValidationHeight {
+			break // No SSGen should be present before this height.
+		}
+
+		if isSSGen, _ := stake.IsSSGen(msgTx); isSSGen {
+			txCopy := hcashutil.NewTxDeepTxIns(msgTx)
+			if maybeInsertStakeTx(txCopy, treeValid, blockUtxosCopy) {
+				vb := stake.SSGenVoteBits(txCopy.MsgTx())
+				voteBitsVoters = append(voteBitsVoters, vb)
+				blockTxnsStake = append(blockTxnsStake, txCopy)
+				voters++
+			}
+		}
+
+		// Don't let this overflow, although probably it's impossible.
+		if voters >= math.MaxUint16 {
+			break
+		}
+	}
+
+	// Set votebits, which determines whether the TxTreeRegular of the previous
+	// block is valid or not.
+	var votebits uint16
+	if nextBlockKeyHeight + 1 < stakeValidationHeight {
+		votebits = uint16(0x0001) // TxTreeRegular enabled pre-staking
+	} else {
+		// Otherwise, we need to check the votes to determine if the tx tree was
+		// validated or not.
+		voteYea := 0
+		totalVotes := 0
+
+		for _, vb := range voteBitsVoters {
+			if hcashutil.IsFlagSet16(vb, hcashutil.BlockValid) {
+				voteYea++
+			}
+			totalVotes++
+		}
+
+		if voteYea == 0 { // Handle zero case for div by zero error prevention.
+			votebits = uint16(0x0000) // TxTreeRegular disabled
+		} else if (totalVotes / voteYea) <= 1 {
+			votebits = uint16(0x0001) // TxTreeRegular enabled
+		} else {
+			votebits = uint16(0x0000) // TxTreeRegular disabled
+		}
+
+		if votebits == uint16(0x0000) {
+			// In the event TxTreeRegular is disabled, we need to remove all tx
+			// in the current block that depend on tx from the TxTreeRegular of
+			// the previous block.
+			// HYPERCASH WARNING: The ideal behaviour should also be that we re-add
+			// all tx that we just removed from the previous block into our
+			// current block template. Right now this code fails to do that;
+			// these tx will then be included in the next block, which isn't
+			// catastrophic but is kind of buggy.
+
+			// Retrieve the current top block, whose TxTreeRegular was voted
+			// out.
+			// Hypercash TODO: This is super inefficient, this block should be
+			// cached and stored somewhere.
+			topBlock, err := blockManager.GetTopBlockFromChain()
+			if err != nil {
+				return nil, ruleError(ErrGetTopBlock, "couldn't get "+
+					"top block")
+			}
+			topBlockRegTx := topBlock.Transactions()
+
+			tempBlockTxns := make([]*hcashutil.Tx, 0, len(sourceTxns))
+			for _, tx := range blockTxns {
+				if tx.Tree() == wire.TxTreeRegular {
+					// Go through all the inputs and check to see if this mempool
+					// tx uses outputs from the parent block. This loop is
+					// probably very expensive.
+					isValid := true
+					for _, txIn := range tx.MsgTx().TxIn {
+						for _, parentTx := range topBlockRegTx {
+							if txIn.PreviousOutPoint.Hash.IsEqual(
+								parentTx.Hash()) {
+								isValid = false
+							}
+						}
+					}
+
+					if isValid {
+						txCopy := hcashutil.NewTxDeepTxIns(tx.MsgTx())
+						tempBlockTxns = append(tempBlockTxns, txCopy)
+					}
+				} else {
+					txCopy := hcashutil.NewTxDeepTxIns(tx.MsgTx())
+					tempBlockTxns = append(tempBlockTxns, txCopy)
+				}
+			}
+
+			// Replace blockTxns with the pruned list of valid mempool tx.
+			blockTxns = tempBlockTxns
+		}
+	}
+
+	// Get the newly purchased tickets (SStx tx) and store them and their number.
+	freshStake := 0
+	for _, tx := range blockTxns {
+		msgTx := tx.MsgTx()
+		isSStx, _ := stake.IsSStx(msgTx)
+		if tx.Tree() == wire.TxTreeStake && isSStx {
+			// A ticket can not spend an input from TxTreeRegular, since it
+			// has not yet been validated.
+			if containsTxIns(blockTxns, tx) {
+				continue
+			}
+
+			// Quick check for difficulty here.
+			if msgTx.TxOut[0].Value >= reqStakeDifficulty {
+				txCopy := hcashutil.NewTxDeepTxIns(msgTx)
+				if maybeInsertStakeTx(txCopy, treeValid, blockUtxosCopy) {
+					blockTxnsStake = append(blockTxnsStake, txCopy)
+					freshStake++
+				}
+			}
+		}
+
+		// Don't let this overflow.
+		if freshStake >= int(g.cfg.ChainParams.MaxFreshStakePerBlock) {
+			break
+		}
+	}
+
+	// Get the ticket revocations (SSRtx tx) and store them and their number.
+	revocations := 0
+	for _, tx := range blockTxns {
+		if nextBlockKeyHeight + 1 < stakeValidationHeight {
+			break // No SSRtx should be present before this height.
+		}
+
+		msgTx := tx.MsgTx()
+		isSSRtx, _ := stake.IsSSRtx(msgTx)
+		if tx.Tree() == wire.TxTreeStake && isSSRtx {
+			txCopy := hcashutil.NewTxDeepTxIns(msgTx)
+			if maybeInsertStakeTx(txCopy, treeValid, blockUtxosCopy) {
+				blockTxnsStake = append(blockTxnsStake, txCopy)
+				revocations++
+			}
+		}
+
+		// Don't let this overflow.
+		if revocations >= math.MaxUint8 {
+			break
+		}
+	}
+
+	// Create a standard coinbase transaction paying to the provided
+	// address.  NOTE: The coinbase value will be updated to include the
+	// fees from the selected transactions later after they have actually
+	// been selected.  It is created here to detect any errors early
+	// before potentially doing a lot of work below.  The extra nonce helps
+	// ensure the transaction is not a duplicate transaction (paying the
+	// same value to the same public key address would otherwise be an
+	// identical transaction for block version 1).
+	// Hypercash: We need to move this downwards because of the requirements
+	// to incorporate voters and potential voters.
+	coinbaseScript := []byte{0x00, 0x00}
+	coinbaseScript = append(coinbaseScript, []byte(CoinbaseFlags)...)
+
+	// Add a random coinbase nonce to ensure that tx prefix hash
+	// so that our merkle root is unique for lookups needed for
+	// getwork, etc.
+	rand, err := wire.RandomUint64()
+	if err != nil {
+		return nil, err
+	}
+	opReturnPkScript, err := StandardCoinbaseOpReturn(uint32(nextBlockKeyHeight),
+		[]uint64{0, 0, 0, rand})
+	if err != nil {
+		return nil, err
+	}
+
+	// Split the coinbase work reward across the payouts of the mining
+	// policy when the caller requested a coinbase paying to an address.
+	// The first payout takes the place of the requested address.  Block
+	// one pays out the ledger instead.
+	coinbaseAddr := payToAddress
+	var payouts []CoinbasePayout
+	isLedgerBlock := nextBlockKeyHeight == 0 &&
+		len(g.cfg.ChainParams.BlockOneLedger) != 0
+	if payToAddress != nil && policy.CoinbasePayouts != nil && !isLedgerBlock {
+		payouts = policy.CoinbasePayouts.Payouts()
+		if len(payouts) > 0 {
+			coinbaseAddr = payouts[0].Address
+		}
+	}
+
+	extraCoinbaseTx, err := CreateExtraCoinbaseTx(subsidyCache,
+		coinbaseScript,
+		opReturnPkScript,
+		nextBlockHeight,
+		nextBlockKeyHeight,
+		coinbaseAddr,
+		uint16(voters),
+		g.cfg.ChainParams)
+	if err != nil {
+		return nil, err
+	}
+	err = addCoinbasePayoutOutputs(extraCoinbaseTx.MsgTx(), payouts)
+	if err != nil {
+		return nil, err
+	}
+
+	extraCoinbaseTx.SetTree(wire.TxTreeRegular) // Coinbase only in regular tx tree
+	if err != nil {
+		return nil, err
+	}
+
+	numCoinbaseSigOps := int64(blockchain.CountSigOps(extraCoinbaseTx, true, false))
+	blockSize += uint32(extraCoinbaseTx.MsgTx().SerializeSize())
+	blockSigOps += numCoinbaseSigOps
+	txFeesMap[*extraCoinbaseTx.Hash()] = 0
+	txSigOpCountsMap[*extraCoinbaseTx.Hash()] = numCoinbaseSigOps
+
+	// Build tx lists for regular tx.
+	blockTxnsRegular := make([]*hcashutil.Tx, 0, len(blockTxns)+1)
+
+	// Append coinbase.
+	blockTxnsRegular = append(blockTxnsRegular, extraCoinbaseTx)
+
+
+	//opExtraReturnPkScript, err := StandardExtraCoinbaseOpReturn(uint32(nextBlockHeight), extraCoinbaseTx.MsgTx().TxHashFull())
+	coinbaseTx, err := CreateCoinbaseTx(coinbaseScript,
+		nil,
+		coinbaseAddr)
+
+	if err != nil {
+		return nil, err
+	}
+	err = addCoinbasePayoutOutputs(coinbaseTx.MsgTx(), payouts)
+	if err != nil {
+		return nil, err
+	}
+
+	coinbaseTx.SetTree(wire.TxTreeRegular) // Coinbase only in regular tx tree
+	if err != nil {
+		return nil, err
+	}
+	numExtraCoinbaseSigOps := int64(blockchain.CountSigOps(coinbaseTx, true, false))
+	blockSize += uint32(coinbaseTx.MsgTx().SerializeSize())
+	blockSigOps += numExtraCoinbaseSigOps
+	txFeesMap[*coinbaseTx.Hash()] = 0
+	txSigOpCountsMap[*coinbaseTx.Hash()] = numExtraCoinbaseSigOps
+	numCoinbaseSigOps += numExtraCoinbaseSigOps
+
+	// Append coinbase.
+	blockTxnsRegular = append(blockTxnsRegular, coinbaseTx)
+
+	// Assemble the two transaction trees.
+	for _, tx := range blockTxns {
+		if tx.Tree() == wire.TxTreeRegular {
+			blockTxnsRegular = append(blockTxnsRegular, tx)
+		} else if tx.Tree() == wire.TxTreeStake {
+			continue
+		} else {
+			log.Tracef("Error adding tx %s to block; invalid tree", tx.Hash())
+			continue
+		}
+	}
+
+	for _, tx := range blockTxnsRegular {
+		fee, ok := txFeesMap[*tx.Hash()]
+		if !ok {
+			return nil, fmt.Errorf("couldn't find fee for tx %v",
+				*tx.Hash())
+		}
+		totalFees += fee
+		txFees = append(txFees, fee)
+
+		tsos, ok := txSigOpCountsMap[*tx.Hash()]
+		if !ok {
+			return nil, fmt.Errorf("couldn't find sig ops count for tx %v",
+				*tx.Hash())
+		}
+		txSigOpCounts = append(txSigOpCounts, tsos)
+	}
+
+	for _, tx := range blockTxnsStake {
+		fee, ok := txFeesMap[*tx.Hash()]
+		if !ok {
+			return nil, fmt.Errorf("couldn't find fee for stx %v",
+				*tx.Hash())
+		}
+		extraTotalFees += fee
+		txFees = append(txFees, fee)
+
+		tsos, ok := txSigOpCountsMap[*tx.Hash()]
+		if !ok {
+			return nil, fmt.Errorf("couldn't find sig ops count for stx %v",
+				*tx.Hash())
+		}
+		txSigOpCounts = append(txSigOpCounts, tsos)
+	}
+
+	txSigOpCounts = append(txSigOpCounts, numCoinbaseSigOps)
+
+	// If we're greater than or equal to stake validation height, scale the
+	// fees according to the number of voters.
+	prevTotalFees, err := g.cfg.Chain.GetTotalFeesByHash(prevHash)
+	if err != nil {
+		prevTotalFees = 0
+	}
+
+	microTotalFees := int64(0)
+	if nextBlockHeight > 1 {
+		curHash := prevHash
+		for {
+			if curHash.IsEqual(&zeroHash) {
+				break
+			}
+			if curHash.IsEqual(prevKeyHash) {
+				prevTotalFees, err := g.cfg.Chain.GetTotalFeesByHash(curHash)
+				if err != nil {
+					prevTotalFees = 0
+					break
+				}
+				microTotalFees += prevTotalFees
+				break
+			}
+			prevTotalFees, err := g.cfg.Chain.GetTotalFeesByHash(curHash)
+			if err != nil {
+				prevTotalFees = 0
+				break
+			}
+			microTotalFees += prevTotalFees
+
+			curHash = g.cfg.Chain.GetPrevHashByHash(curHash)
+		}
+	}
+	if nextBlockKeyHeight+1 >= g.cfg.ChainParams.StakeValidationHeight {
+		microTotalFees *= int64(voters)
+		microTotalFees /= int64(g.cfg.ChainParams.TicketsPerBlock)
+		prevTotalFees *= int64(voters)
+		prevTotalFees /= int64(g.cfg.ChainParams.TicketsPerBlock)
+
+		totalFees *= int64(voters)
+		totalFees /= int64(g.cfg.ChainParams.TicketsPerBlock)
+	}
+
+
+	totalFees = (totalFees * 6 / 10) + (prevTotalFees * 3 / 10)
+	extraTotalFees = extraTotalFees + (microTotalFees / 10)
+
+	txoutValue := int64(0)
+	for _, txout := range coinbaseTx.MsgTx().TxOut {
+		txoutValue += txout.Value
+	}
+
+	txoutValue = int64(0)
+	for _, txout := range extraCoinbaseTx.MsgTx().TxOut {
+		txoutValue += txout.Value
+	}
+
+
+	// Now that the actual transactions have been selected, update the
+	// block size for the real transaction count and coinbase value with
+	// the total fees accordingly.
+	if nextBlockHeight > 1 {
+		blockSize -= wire.MaxVarIntPayload -
+			uint32(wire.VarIntSerializeSize(uint64(len(blockTxnsRegular))+
+				uint64(len(blockTxnsStake))))
+		coinbaseTx.MsgTx().TxOut[1].Value += totalFees
+		extraCoinbaseTx.MsgTx().TxOut[3].Value += extraTotalFees
+
+		txFees[0] = -totalFees
+	}
+
+	// Split the subsidy and fees across the payouts now that the fees are
+	// known.  This must happen before the coinbase commits to the hash of
+	// the extra coinbase below.
+	splitCoinbasePayouts(coinbaseTx.MsgTx(), payouts, 1)
+	splitCoinbasePayouts(extraCoinbaseTx.MsgTx(), payouts, 2, 3)
+
+	opExtraReturnPkScript, err := StandardExtraCoinbaseOpReturn(uint32(nextBlockHeight), extraCoinbaseTx.MsgTx().TxHashFull())
+
+	coinbaseTx.MsgTx().TxOut[0].PkScript = opExtraReturnPkScript
+
+	// Calculate the required difficulty for the block.  The timestamp
+	// is potentially adjusted to ensure it comes after the median time of
+	// the last several blocks per the chain consensus rules.
+	ts, err := g.medianAdjustedTime()
+	if err != nil {
+		return nil, ruleError(ErrGettingMedianTime, err.Error())
+	}
+	reqDifficulty, err := g.cfg.Chain.CalcNextRequiredDifficulty(ts)
+
+	if err != nil {
+		return nil, ruleError(ErrGettingDifficulty, err.Error())
+	}
+
+	// Return nil if we don't yet have enough voters; sometimes it takes a
+	// bit for the mempool to sync with the votes map and we end up down
+	// here despite having the relevant votes available in the votes map.
+	//Merge conflict +1
+	minimumVotesRequired :=
+		int((g.cfg.ChainParams.TicketsPerBlock / 2) + 1)
+	if nextBlockKeyHeight + 1 >= stakeValidationHeight &&
+		voters < minimumVotesRequired {
+		log.Warnf("incongruent number of voters in mempool " +
+			"vs mempool.voters; not enough voters found: %v voters", voters)
+		return g.handleTooFewVoters(subsidyCache, nextBlockHeight, nextBlockKeyHeight,
+			payToAddress)
+	}
+
+	// Correct transaction index fraud proofs for any transactions that
+	// are chains. maybeInsertStakeTx fills this in for stake transactions
+	// already, so only do it for regular transactions.
+	for i, tx := range blockTxnsRegular {
+		// No need to check any of the transactions in the custom first
+		// block.
+		if nextBlockHeight == 1 {
+			break
+		}
+
+		//utxs, err := g.cfg.Chain.FetchUtxoView(tx, treeValid)
+		if err != nil {
+			str := fmt.Sprintf("failed to fetch input utxs for tx %v: %s",
+				tx.Hash(), err.Error())
+			return nil, ruleError(ErrFetchTxStore, str)
+		}
+
+		// Copy the transaction and swap the pointer.
+		txCopy := hcashutil.NewTxDeepTxIns(tx.MsgTx())
+		blockTxnsRegular[i] = txCopy
+		tx = txCopy
+
+		for _, txIn := range tx.MsgTx().TxIn {
+			originHash := &txIn.PreviousOutPoint.Hash
+			utx := blockUtxosCopy.LookupEntry(originHash)
+			if utx == nil {
+				// Set a flag with the index so we can properly set
+				// the fraud proof below.
+				txIn.BlockIndex = wire.NullBlockIndex
+			} else {
+				originIdx := txIn.PreviousOutPoint.Index
+				txIn.ValueIn = utx.AmountByIndex(originIdx)
+				txIn.BlockHeight = uint32(utx.BlockHeight())
+				txIn.BlockIndex = utx.BlockIndex()
+			}
+		}
+	}
+
+	// Fill in locally referenced inputs.
+	for i, tx := range blockTxnsRegular {
+		// Skip coinbase.
+		if i == 0 || i == 1{
+			continue
+		}
+
+		// Copy the transaction and swap the pointer.
+		txCopy := hcashutil.NewTxDeepTxIns(tx.MsgTx())
+		blockTxnsRegular[i] = txCopy
+		tx = txCopy
+
+		for _, txIn := range tx.MsgTx().TxIn {
+			// This tx was at some point 0-conf and now requires the
+			// correct block height and index. Set it here.
+			if txIn.BlockIndex == wire.NullBlockIndex {
+				idx := txIndexFromTxList(txIn.PreviousOutPoint.Hash,
+					blockTxnsRegular)
+
+				// The input is in the block, set it accordingly.
+				if idx != -1 {
+					originIdx := txIn.PreviousOutPoint.Index
+					amt := blockTxnsRegular[idx].MsgTx().TxOut[originIdx].Value
+					txIn.ValueIn = amt
+					txIn.BlockHeight = uint32(nextBlockHeight)
+					txIn.BlockIndex = uint32(idx)
+				} else {
+					str := fmt.Sprintf("failed find hash in tx list "+
+						"for fraud proof; tx in hash %v",
+						txIn.PreviousOutPoint.Hash)
+					return nil, ruleError(ErrFraudProofIndex, str)
+				}
+			}
+		}
+	}
+
+	// Choose the block version to generate based on the network.
+	blockVersion := int32(generatedBlockVersion)
+	if g.cfg.ChainParams.Net != wire.MainNet {
+		blockVersion = generatedBlockVersionTest
+	}
+
+	// Figure out stake version.
+	generatedStakeVersion, err := g.cfg.Chain.CalcStakeVersionByHash(prevHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a new block ready to be solved.
+	merkles := blockchain.BuildMerkleTreeStore(blockTxnsRegular, false)
+	merklesStake := blockchain.BuildMerkleTreeStore(blockTxnsStake, false)
+
+	var msgBlock wire.MsgBlock
+	msgBlock.Header = wire.BlockHeader{
+		Version:      blockVersion,
+		PrevBlock:    *prevHash,
+		PrevKeyBlock: *prevKeyHash,
+		MerkleRoot:   *merkles[len(merkles)-1],
+		StakeRoot:    *merklesStake[len(merklesStake)-1],
+		VoteBits:     votebits,
+		FinalState:   finalState,
+		Voters:       uint16(voters),
+		FreshStake:   uint8(freshStake),
+		Revocations:  uint8(revocations),
+		PoolSize:     poolSize,
+		Timestamp:    ts,
+		SBits:        reqStakeDifficulty,
+		Bits:         reqDifficulty,
+		StakeVersion: generatedStakeVersion,
+		Height:       uint32(nextBlockHeight),
+		KeyHeight:    uint32(nextBlockKeyHeight),
+		// Size declared below
+	}
+
+	for _, tx := range blockTxnsRegular {
+		if err := msgBlock.AddTransaction(tx.MsgTx()); err != nil {
+			return nil, ruleError(ErrTransactionAppend, err.Error())
+		}
+	}
+
+	for _, tx := range blockTxnsStake {
+		if err := msgBlock.AddSTransaction(tx.MsgTx()); err != nil {
+			return nil, ruleError(ErrTransactionAppend, err.Error())
+		}
+	}
+
+	msgBlock.Header.Size = uint32(msgBlock.SerializeSize())
+
+	// Finally, perform a full check on the created block against the chain
+	// consensus rules to ensure it properly connects to the current best
+	// chain with no issues.
+	block := hcashutil.NewBlockDeepCopyCoinbase(&msgBlock)
+
+	if err := blockchain.CheckWorklessBlockSanity(g.cfg.Chain, block,
+		g.cfg.TimeSource,
+		g.cfg.ChainParams); err != nil {
+		str := fmt.Sprintf("failed to do final check for block workless "+
+			"sanity when making new block template: %v",
+			err.Error())
+		return nil, ruleError(ErrCheckConnectBlock, str)
+	}
+
+	if err := g.cfg.Chain.CheckConnectBlock(block, true); err != nil {
+		str := fmt.Sprintf("failed to do final check for check connect "+
+			"block when making new block template: %v",
+			err.Error())
+		return nil, ruleError(ErrCheckConnectBlock, str)
+	}
+
+	log.Debugf("Created new block template (%d transactions, %d "+
+		"stake transactions, %d in fees, %d signature operations, "+
+		"%d bytes, target difficulty %064x, stake difficulty %v)",
+		len(msgBlock.Transactions), len(msgBlock.STransactions),
+		totalFees, blockSigOps, blockSize,
+		standalone.CompactToBig(msgBlock.Header.Bits),
+		hcashutil.Amount(msgBlock.Header.SBits).ToCoin())
+
+	blockTemplate := &BlockTemplate{
+		Block:           &msgBlock,
+		Fees:            txFees,
+		SigOpCounts:     txSigOpCounts,
+		Height:          nextBlockHeight,
+		ValidPayAddress: payToAddress != nil,
+		GenerateKey:     false,
+	}
+
+	return g.handleCreatedBlockTemplate(blockTemplate)
+}
+
+// UpdateBlockTime updates the timestamp in the header of the passed block to
+// the current time while taking into account the median time of the last
+// several blocks to ensure the new time is after that time per the chain
+// consensus rules.  Finally, it will update the target difficulty if needed
+// based on the new time for the test networks since their target difficulty can
+// change based upon time.
+func (g *BlkTmplGenerator) UpdateBlockTime(msgBlock *wire.MsgBlock) error {
+	// The new timestamp is potentially adjusted to ensure it comes after
+	// the median time of the last several blocks per the chain consensus
+	// rules.
+	newTimestamp, err := g.medianAdjustedTime()
+	if err != nil {
+		return ruleError(ErrGettingMedianTime, err.Error())
+	}
+	msgBlock.Header.Timestamp = newTimestamp
+
+	// If running on a network that requires recalculating the difficulty,
+	// do so now.
+	if g.cfg.ChainParams.ReduceMinDifficulty {
+		difficulty, err := g.cfg.Chain.CalcNextRequiredDifficulty(
+			newTimestamp)
+		if err != nil {
+			return ruleError(ErrGettingDifficulty, err.Error())
+		}
+		msgBlock.Header.Bits = difficulty
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mining
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// fakeTxSource is a transaction source which only provides the votes it was
+// created with.
+type fakeTxSource struct {
+	votes map[chainhash.Hash][]*VoteDesc
+}
+
+func (s *fakeTxSource) LastUpdated() time.Time                             { return time.Time{} }
+func (s *fakeTxSource) MiningDescs() []*TxDesc                             { return nil }
+func (s *fakeTxSource) HaveTransaction(hash *chainhash.Hash) bool          { return false }
+func (s *fakeTxSource) CheckIfTxsExist(hashes []chainhash.Hash) bool       { return false }
+func (s *fakeTxSource) IsTxTreeValid(best *chainhash.Hash) bool            { return true }
+func (s *fakeTxSource) VoteHashesForBlock(chainhash.Hash) []chainhash.Hash { return nil }
+
+func (s *fakeTxSource) VotesForBlocks(hashes []chainhash.Hash) [][]*VoteDesc {
+	result := make([][]*VoteDesc, 0, len(hashes))
+	for _, hash := range hashes {
+		result = append(result, s.votes[hash])
+	}
+	return result
+}
+
+// fakeVotes returns the passed number of approving and disapproving votes.
+func fakeVotes(yea, nay int) []*VoteDesc {
+	votes := make([]*VoteDesc, 0, yea+nay)
+	for i := 0; i < yea+nay; i++ {
+		votes = append(votes, &VoteDesc{Vote: i < yea})
+	}
+	return votes
+}
+
+// TestSortParentsByVotes ensures only the blocks with a majority of approving
+// or disapproving votes are eligible parents and they are sorted by their
+// number of votes.
+func TestSortParentsByVotes(t *testing.T) {
+	params := &chaincfg.SimNetParams
+	blocks := []chainhash.Hash{{0x01}, {0x02}, {0x03}, {0x04}}
+	txSource := &fakeTxSource{votes: map[chainhash.Hash][]*VoteDesc{
+		blocks[0]: fakeVotes(4, 0),
+		blocks[1]: fakeVotes(2, 1),
+		blocks[2]: fakeVotes(0, 3),
+		blocks[3]: fakeVotes(5, 0),
+	}}
+
+	got := SortParentsByVotes(txSource, blocks[0], blocks, params)
+	want := []chainhash.Hash{blocks[3], blocks[0], blocks[2]}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got eligible parents %v, want %v", got, want)
+	}
+
+	if got := SortParentsByVotes(txSource, blocks[0], nil, params); got != nil {
+		t.Fatalf("got eligible parents %v for no blocks", got)
+	}
+}
+
+// fakeBlockManager is a block manager which only provides the chain state it
+// was created with.
+type fakeBlockManager struct {
+	chainState ChainState
+}
+
+func (b *fakeBlockManager) ChainState() *ChainState            { return &b.chainState }
+func (b *fakeBlockManager) GetCurrentTemplate() *BlockTemplate { return nil }
+func (b *fakeBlockManager) SetCurrentTemplate(*BlockTemplate)  {}
+func (b *fakeBlockManager) SetParentTemplate(*BlockTemplate)   {}
+
+func (b *fakeBlockManager) CalcNextRequiredDiffNode(*chainhash.Hash, time.Time) (uint32, error) {
+	return 0, nil
+}
+
+func (b *fakeBlockManager) ForceReorganization(formerBest, newBest chainhash.Hash) error {
+	return nil
+}
+
+func (b *fakeBlockManager) GetKeyGeneration(chainhash.Hash) ([]chainhash.Hash, error) {
+	return nil, nil
+}
+
+func (b *fakeBlockManager) GetMatchedDescendants(chainhash.Hash, uint16) ([]chainhash.Hash, error) {
+	return nil, nil
+}
+
+func (b *fakeBlockManager) GetTopBlockFromChain() (*hcashutil.Block, error) {
+	return nil, nil
+}
+
+// TestUpdateBlockTime ensures the timestamp of a block template comes after
+// the median time of the best chain and is offset by the mining time offset.
+func TestUpdateBlockTime(t *testing.T) {
+	const offset = 10
+	now := time.Unix(time.Now().Unix(), 0)
+	tests := []struct {
+		name           string
+		pastMedianTime time.Time
+		min, max       time.Time
+	}{
+		{
+			name:           "median time in the future",
+			pastMedianTime: now.Add(time.Hour),
+			min:            now.Add(time.Hour + time.Second - offset*time.Second),
+			max:            now.Add(time.Hour + time.Second - offset*time.Second),
+		},
+		{
+			name:           "median time in the past",
+			pastMedianTime: now.Add(-time.Hour),
+			min:            now.Add(-offset * time.Second),
+			max:            now.Add(time.Minute - offset*time.Second),
+		},
+	}
+
+	for _, test := range tests {
+		g := NewBlkTmplGenerator(&Config{
+			ChainParams: &chaincfg.SimNetParams,
+			TimeSource:  blockchain.NewMedianTime(),
+			BlockManager: &fakeBlockManager{chainState: ChainState{
+				PastMedianTime: test.pastMedianTime,
+			}},
+			MiningTimeOffset: offset,
+		})
+		var msgBlock wire.MsgBlock
+		if err := g.UpdateBlockTime(&msgBlock); err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		ts := msgBlock.Header.Timestamp
+		if ts.Before(test.min) || ts.After(test.max) {
+			t.Fatalf("%s: got timestamp %v, want between %v and %v",
+				test.name, ts, test.min, test.max)
+		}
+	}
+}
@@ -33,8 +33,18 @@ type TxDesc struct {
 	Fee int64
 }
 
+// VoteDesc is a descriptor about a vote (SSGen) on a block in a transaction
+// source.
+type VoteDesc struct {
+	SsgenHash chainhash.Hash // Vote
+	SstxHash  chainhash.Hash // Ticket
+	Vote      bool
+}
+
 // TxSource represents a source of transactions to consider for inclusion in
-// new blocks.
+// new blocks.  The memory pool is the source used by the node, however any
+// other implementation, such as a fixed set of transactions for tests, may be
+// used to generate block templates.
 //
 // The interface contract requires that all of these methods are safe for
 // concurrent access with respect to the source.
@@ -50,4 +60,22 @@ type TxSource interface {
 	// HaveTransaction returns whether or not the passed transaction hash
 	// exists in the source pool.
 	HaveTransaction(hash *chainhash.Hash) bool
+
+	// CheckIfTxsExist returns whether or not all of the passed transaction
+	// hashes exist in the source pool.
+	CheckIfTxsExist(hashes []chainhash.Hash) bool
+
+	// VoteHashesForBlock returns the hashes for all votes on the provided
+	// block hash that are currently available in the source pool.
+	VoteHashesForBlock(hash chainhash.Hash) []chainhash.Hash
+
+	// VotesForBlocks returns a slice of vote descriptors for all votes on
+	// the provided block hashes that are currently available in the
+	// source pool.
+	VotesForBlocks(hashes []chainhash.Hash) [][]*VoteDesc
+
+	// IsTxTreeValid returns whether or not the votes in the source pool
+	// approve the regular transaction tree of the block with the passed
+	// hash.
+	IsTxTreeValid(best *chainhash.Hash) bool
 }
@@ -0,0 +1,130 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mining
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/HcashOrg/hcashutil"
+)
+
+// MaxCoinbasePayouts is the maximum number of addresses the coinbase work
+// reward may be split across.  It bounds the size of the coinbase
+// transactions.
+const MaxCoinbasePayouts = 100
+
+// CoinbasePayout describes a share of the coinbase work reward of generated
+// blocks.  Each address receives the part of the reward proportional to its
+// weight relative to the total weight of all payouts.
+type CoinbasePayout struct {
+	Address hcashutil.Address
+	Weight  uint32
+}
+
+// CheckCoinbasePayouts returns an error when the passed payouts can not be
+// used to split the coinbase work reward.  The addresses must be unique and
+// every weight must be non-zero.
+func CheckCoinbasePayouts(payouts []CoinbasePayout) error {
+	if len(payouts) > MaxCoinbasePayouts {
+		return fmt.Errorf("%d payouts exceed the maximum of %d",
+			len(payouts), MaxCoinbasePayouts)
+	}
+	seen := make(map[string]struct{}, len(payouts))
+	for _, payout := range payouts {
+		if payout.Address == nil {
+			return errors.New("payout address is missing")
+		}
+		encoded := payout.Address.EncodeAddress()
+		if payout.Weight == 0 {
+			return fmt.Errorf("payout to %s has a zero weight", encoded)
+		}
+		if _, ok := seen[encoded]; ok {
+			return fmt.Errorf("duplicate payout address %s", encoded)
+		}
+		seen[encoded] = struct{}{}
+	}
+	return nil
+}
+
+// SplitCoinbaseValue splits the passed value across the passed payouts in
+// proportion to their weights.  The returned amounts correspond to the payouts
+// by index.  Any remainder from rounding down is added to the first payout so
+// the amounts always sum to the passed value.
+func SplitCoinbaseValue(value int64, payouts []CoinbasePayout) []int64 {
+	if len(payouts) == 0 {
+		return nil
+	}
+
+	var totalWeight int64
+	for _, payout := range payouts {
+		totalWeight += int64(payout.Weight)
+	}
+
+	// Use big integers since the product of the value and a weight may
+	// overflow.
+	amounts := make([]int64, len(payouts))
+	bigValue := big.NewInt(value)
+	bigTotalWeight := big.NewInt(totalWeight)
+	share := new(big.Int)
+	remaining := value
+	for i, payout := range payouts {
+		share.SetInt64(int64(payout.Weight))
+		share.Mul(share, bigValue)
+		share.Quo(share, bigTotalWeight)
+		amounts[i] = share.Int64()
+		remaining -= amounts[i]
+	}
+	amounts[0] += remaining
+	return amounts
+}
+
+// CoinbasePayouts houses the addresses the coinbase work reward of generated
+// blocks is split across.  The payouts may be replaced at any time, for
+// instance by RPC, and the new payouts apply to all block templates generated
+// afterwards.  The zero value has no payouts.
+//
+// It is safe for concurrent access.
+type CoinbasePayouts struct {
+	mtx     sync.RWMutex
+	payouts []CoinbasePayout
+}
+
+// Payouts returns a copy of the current payouts.  It returns nil when the
+// coinbase work reward is not split.
+//
+// This function is safe for concurrent access.
+func (p *CoinbasePayouts) Payouts() []CoinbasePayout {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	if len(p.payouts) == 0 {
+		return nil
+	}
+	payouts := make([]CoinbasePayout, len(p.payouts))
+	copy(payouts, p.payouts)
+	return payouts
+}
+
+// Set replaces the current payouts with the passed ones after checking them
+// with CheckCoinbasePayouts.  Passing no payouts disables splitting the
+// coinbase work reward.
+//
+// This function is safe for concurrent access.
+func (p *CoinbasePayouts) Set(payouts []CoinbasePayout) error {
+	if err := CheckCoinbasePayouts(payouts); err != nil {
+		return err
+	}
+
+	copied := make([]CoinbasePayout, len(payouts))
+	copy(copied, payouts)
+
+	p.mtx.Lock()
+	p.payouts = copied
+	p.mtx.Unlock()
+	return nil
+}
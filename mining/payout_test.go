@@ -0,0 +1,104 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mining
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// newTestPayout returns a payout with the passed weight to a pay-to-pubkey-hash
+// address derived from the passed id.  It panics if an error occurs.
+func newTestPayout(id byte, weight uint32) CoinbasePayout {
+	pkHash := make([]byte, 20)
+	pkHash[0] = id
+	addr, err := hcashutil.NewAddressPubKeyHash(pkHash,
+		&chaincfg.SimNetParams, 0)
+	if err != nil {
+		panic("invalid public key hash in test source")
+	}
+	return CoinbasePayout{Address: addr, Weight: weight}
+}
+
+// TestSplitCoinbaseValue ensures values are split in proportion to the payout
+// weights with the remainder paid to the first payout.
+func TestSplitCoinbaseValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   int64
+		weights []uint32
+		want    []int64
+	}{
+		{"single", 1000, []uint32{7}, []int64{1000}},
+		{"even", 1000, []uint32{1, 1}, []int64{500, 500}},
+		{"weighted", 1000, []uint32{3, 1}, []int64{750, 250}},
+		{"remainder", 1000, []uint32{1, 1, 1}, []int64{334, 333, 333}},
+		{"zero value", 0, []uint32{1, 2}, []int64{0, 0}},
+		{"large", 21e14, []uint32{0xffffffff, 0xffffffff},
+			[]int64{105e13, 105e13}},
+	}
+
+	for _, test := range tests {
+		payouts := make([]CoinbasePayout, len(test.weights))
+		for i, weight := range test.weights {
+			payouts[i] = newTestPayout(byte(i), weight)
+		}
+		got := SplitCoinbaseValue(test.value, payouts)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// TestCoinbasePayouts ensures invalid payouts are rejected and the payouts are
+// not aliased.
+func TestCoinbasePayouts(t *testing.T) {
+	var p CoinbasePayouts
+	if payouts := p.Payouts(); payouts != nil {
+		t.Fatalf("zero value has payouts %v", payouts)
+	}
+
+	invalid := [][]CoinbasePayout{
+		{newTestPayout(1, 0)},
+		{newTestPayout(1, 1), newTestPayout(1, 2)},
+		{{Weight: 1}},
+	}
+	for i, payouts := range invalid {
+		if err := p.Set(payouts); err == nil {
+			t.Errorf("#%d: invalid payouts accepted", i)
+		}
+	}
+	tooMany := make([]CoinbasePayout, MaxCoinbasePayouts+1)
+	for i := range tooMany {
+		tooMany[i] = newTestPayout(byte(i), 1)
+	}
+	if err := p.Set(tooMany); err == nil {
+		t.Error("too many payouts accepted")
+	}
+
+	payouts := []CoinbasePayout{newTestPayout(1, 1), newTestPayout(2, 3)}
+	if err := p.Set(payouts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payouts[0].Weight = 5
+	got := p.Payouts()
+	if len(got) != 2 || got[0].Weight != 1 || got[1].Weight != 3 {
+		t.Fatalf("unexpected payouts %v", got)
+	}
+	got[1].Weight = 5
+	if p.Payouts()[1].Weight != 3 {
+		t.Fatal("payouts are aliased")
+	}
+
+	if err := p.Set(nil); err != nil {
+		t.Fatalf("unexpected error clearing payouts: %v", err)
+	}
+	if payouts := p.Payouts(); payouts != nil {
+		t.Fatalf("cleared payouts remain %v", payouts)
+	}
+}
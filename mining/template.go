@@ -0,0 +1,212 @@
+// Copyright (c) 2014-2016 The btcsuite developers
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mining
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/txscript"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// BlockTemplate houses a block that has yet to be solved along with additional
+// details about the fees and the number of signature operations for each
+// transaction in the block.
+type BlockTemplate struct {
+	// Block is a block that is ready to be solved by miners.  Thus, it is
+	// completely valid with the exception of satisfying the proof-of-work
+	// requirement.
+	Block *wire.MsgBlock
+
+	// Fees contains the amount of fees each transaction in the generated
+	// template pays in base units.  Since the first transaction is the
+	// coinbase, the first entry (offset 0) will contain the negative of the
+	// sum of the fees of all other transactions.
+	Fees []int64
+
+	// SigOpCounts contains the number of signature operations each
+	// transaction in the generated template performs.
+	SigOpCounts []int64
+
+	// Height is the height at which the block template connects to the main
+	// chain.
+	Height int64
+
+	// KeyHeight is the key height of the block template.
+	KeyHeight int64
+
+	// ValidPayAddress indicates whether or not the template coinbase pays
+	// to an address or is redeemable by anyone.  See the documentation on
+	// NewBlockTemplate for details on which this can be useful to generate
+	// templates without a coinbase payment address.
+	ValidPayAddress bool
+
+	// GenerateKey indicates whether or not the block template is for a
+	// key block.
+	GenerateKey bool
+}
+
+// StandardCoinbaseOpReturn creates a standard OP_RETURN output to insert into
+// coinbase to use as extranonces. The OP_RETURN pushes 32 bytes.
+func StandardCoinbaseOpReturn(keyHeight uint32, extraNonces []uint64) ([]byte,
+	error) {
+	if len(extraNonces) != 4 {
+		return nil, fmt.Errorf("extranonces has wrong num uint64s")
+	}
+
+	enData := make([]byte, 36)
+	binary.LittleEndian.PutUint32(enData[0:4], keyHeight)
+	binary.LittleEndian.PutUint64(enData[4:12], extraNonces[0])
+	binary.LittleEndian.PutUint64(enData[12:20], extraNonces[1])
+	binary.LittleEndian.PutUint64(enData[20:28], extraNonces[2])
+	binary.LittleEndian.PutUint64(enData[28:36], extraNonces[3])
+	extraNonceScript, err := txscript.GenerateProvablyPruneableOut(enData)
+	if err != nil {
+		return nil, err
+	}
+
+	return extraNonceScript, nil
+}
+
+// StandardExtraCoinbaseOpReturn creates a standard OP_RETURN output to insert
+// into the extra coinbase which commits to the passed block height and hash of
+// the coinbase.
+func StandardExtraCoinbaseOpReturn(height uint32, hash chainhash.Hash) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("extra hash has wrong num bytes")
+	}
+	enData := make([]byte, 36)
+	binary.LittleEndian.PutUint32(enData[0:4], height)
+	for i := 0; i < len(hash); i++ {
+		enData[i+4] = hash[i]
+	}
+
+	extraHashScript, err := txscript.GenerateProvablyPruneableOut(enData)
+	if err != nil {
+		return nil, err
+	}
+
+	return extraHashScript, nil
+}
+
+// CoinbaseExtranonces extracts the extranonces from the coinbase transaction of
+// the passed block.
+func CoinbaseExtranonces(msgblock *wire.MsgBlock) []uint64 {
+	if len(msgblock.Transactions[0].TxOut) < 4 {
+		return []uint64{0, 0, 0, 0}
+	}
+
+	if len(msgblock.Transactions[0].TxOut[0].PkScript) < 38 {
+		return []uint64{0, 0, 0, 0}
+	}
+
+	ens := make([]uint64, 4) // 32-bytes
+	ens[0] = binary.LittleEndian.Uint64(
+		msgblock.Transactions[0].TxOut[1].PkScript[6:14])
+	ens[1] = binary.LittleEndian.Uint64(
+		msgblock.Transactions[0].TxOut[1].PkScript[14:22])
+	ens[2] = binary.LittleEndian.Uint64(
+		msgblock.Transactions[0].TxOut[1].PkScript[22:30])
+	ens[3] = binary.LittleEndian.Uint64(
+		msgblock.Transactions[0].TxOut[1].PkScript[30:38])
+
+	return ens
+}
+
+// UpdateExtraNonce updates the extra nonce in the coinbase script of the passed
+// block by regenerating the coinbase script with the passed value and block
+// height.  It also recalculates and updates the new merkle root that results
+// from changing the coinbase script.
+func UpdateExtraNonce(msgBlock *wire.MsgBlock, blockHeight uint32, blockKeyHeight uint32,
+	extraNonces []uint64) error {
+	// First block has no extranonce.
+	if blockHeight == 1 {
+		return nil
+	}
+	if len(extraNonces) != 4 {
+		return fmt.Errorf("not enough nonce information passed")
+	}
+
+	coinbaseOpReturn, err := StandardCoinbaseOpReturn(blockKeyHeight,
+		extraNonces)
+	if err != nil {
+		return err
+	}
+	msgBlock.Transactions[0].TxOut[1].PkScript = coinbaseOpReturn
+
+	coinbaseExtraOpReturn, err := StandardExtraCoinbaseOpReturn(blockHeight, msgBlock.Transactions[0].TxHashFull())
+	if err != nil {
+		return err
+	}
+	msgBlock.Transactions[1].TxOut[0].PkScript = coinbaseExtraOpReturn
+
+	// TODO(davec): A hcashutil.Block should use saved in the state to avoid
+	// recalculating all of the other transaction hashes.
+	// block.Transactions[0].InvalidateCache()
+
+	// Recalculate the merkle root with the updated extra nonce.
+	block := hcashutil.NewBlockDeepCopyCoinbase(msgBlock)
+	merkles := blockchain.BuildMerkleTreeStore(block.Transactions(), false)
+	msgBlock.Header.MerkleRoot = *merkles[len(merkles)-1]
+	return nil
+}
+
+// DeepCopyBlockTemplate returns a deeply copied block template that copies all
+// data except a block's references to transactions, which are kept as pointers
+// in the block. This is considered safe because transaction data is generally
+// immutable, with the exception of coinbases which we alternatively also
+// deep copy.
+func DeepCopyBlockTemplate(blockTemplate *BlockTemplate) *BlockTemplate {
+	if blockTemplate == nil {
+		return nil
+	}
+
+	// Deep copy the header, which we hash on.
+	headerCopy := blockTemplate.Block.Header
+
+	// Copy transactions pointers. Duplicate the coinbase
+	// transaction, because it might update it by modifying
+	// the extra nonce.
+	transactionsCopy := make([]*wire.MsgTx, len(blockTemplate.Block.Transactions))
+	coinbaseCopy :=
+		hcashutil.NewTxDeep(blockTemplate.Block.Transactions[0])
+	for i, mtx := range blockTemplate.Block.Transactions {
+		if i == 0 {
+			transactionsCopy[i] = coinbaseCopy.MsgTx()
+		} else {
+			transactionsCopy[i] = mtx
+		}
+	}
+
+	sTransactionsCopy := make([]*wire.MsgTx, len(blockTemplate.Block.STransactions))
+	copy(sTransactionsCopy, blockTemplate.Block.STransactions)
+
+	msgBlockCopy := &wire.MsgBlock{
+		Header:        headerCopy,
+		Transactions:  transactionsCopy,
+		STransactions: sTransactionsCopy,
+	}
+
+	fees := make([]int64, len(blockTemplate.Fees))
+	copy(fees, blockTemplate.Fees)
+
+	sigOps := make([]int64, len(blockTemplate.SigOpCounts))
+	copy(sigOps, blockTemplate.SigOpCounts)
+
+	return &BlockTemplate{
+		Block:           msgBlockCopy,
+		Fees:            fees,
+		SigOpCounts:     sigOps,
+		Height:          blockTemplate.Height,
+		ValidPayAddress: blockTemplate.ValidPayAddress,
+		GenerateKey:     false,
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package mining
 
 import (
 	"container/heap"
@@ -9,22 +9,22 @@ import (
 )
 
 // fakePrioritizedTxes prepares some fake prioritized txes for test
-func fakePrioritizedTxes() []*txPrioItem {
+func fakePrioritizedTxes() []*TxPrioItem {
 	const numRandTx = 1000
 	// some items under the edge condition
-	prioritizedTxes := []*txPrioItem{
-		{feePerKB: 5678, txType: stake.TxTypeRegular, priority: 3},
-		{feePerKB: 5678, txType: stake.TxTypeRegular, priority: 1},
-		{feePerKB: 5678, txType: stake.TxTypeRegular, priority: 1}, // Duplicate fee and prio
-		{feePerKB: 5678, txType: stake.TxTypeRegular, priority: 5},
-		{feePerKB: 5678, txType: stake.TxTypeRegular, priority: 2},
-		{feePerKB: 1234, txType: stake.TxTypeRegular, priority: 3},
-		{feePerKB: 1234, txType: stake.TxTypeRegular, priority: 1},
-		{feePerKB: 1234, txType: stake.TxTypeRegular, priority: 5},
-		{feePerKB: 1234, txType: stake.TxTypeRegular, priority: 5}, // Duplicate fee and prio
-		{feePerKB: 1234, txType: stake.TxTypeRegular, priority: 2},
-		{feePerKB: 10000, txType: stake.TxTypeRegular, priority: 0}, // Higher fee, smaller prio
-		{feePerKB: 0, txType: stake.TxTypeRegular, priority: 10000}, // Higher prio, lower fee
+	prioritizedTxes := []*TxPrioItem{
+		{FeePerKB: 5678, TxType: stake.TxTypeRegular, Priority: 3},
+		{FeePerKB: 5678, TxType: stake.TxTypeRegular, Priority: 1},
+		{FeePerKB: 5678, TxType: stake.TxTypeRegular, Priority: 1}, // Duplicate fee and prio
+		{FeePerKB: 5678, TxType: stake.TxTypeRegular, Priority: 5},
+		{FeePerKB: 5678, TxType: stake.TxTypeRegular, Priority: 2},
+		{FeePerKB: 1234, TxType: stake.TxTypeRegular, Priority: 3},
+		{FeePerKB: 1234, TxType: stake.TxTypeRegular, Priority: 1},
+		{FeePerKB: 1234, TxType: stake.TxTypeRegular, Priority: 5},
+		{FeePerKB: 1234, TxType: stake.TxTypeRegular, Priority: 5}, // Duplicate fee and prio
+		{FeePerKB: 1234, TxType: stake.TxTypeRegular, Priority: 2},
+		{FeePerKB: 10000, TxType: stake.TxTypeRegular, Priority: 0}, // Higher fee, smaller prio
+		{FeePerKB: 0, TxType: stake.TxTypeRegular, Priority: 10000}, // Higher prio, lower fee
 	}
 
 	for i := 0; i < numRandTx; i++ {
@@ -33,11 +33,11 @@ func fakePrioritizedTxes() []*txPrioItem {
 		randPriority := rand.Float64() * 100
 		randFeePerKB := rand.Float64() * 10
 
-		prioritizedTxes = append(prioritizedTxes, &txPrioItem{
-			tx:       nil,
-			txType:   randTxType,
-			feePerKB: randFeePerKB,
-			priority: randPriority,
+		prioritizedTxes = append(prioritizedTxes, &TxPrioItem{
+			Tx:       nil,
+			TxType:   randTxType,
+			FeePerKB: randFeePerKB,
+			Priority: randPriority,
 		})
 	}
 
@@ -49,7 +49,7 @@ func fakePrioritizedTxes() []*txPrioItem {
 func TestTxPQOnStakePriorityAndFeeAndTxPriority(t *testing.T) {
 	// get fake txes as samples
 	prioritizedTxes := fakePrioritizedTxes()
-	pq := newTxPriorityQueue(len(prioritizedTxes), txPQByStakeAndFee)
+	pq := NewTxPriorityQueue(len(prioritizedTxes), TxPQByStakeAndFee)
 
 	// build the priority queue tx by tx
 	for _, tx := range prioritizedTxes {
@@ -62,23 +62,23 @@ func TestTxPQOnStakePriorityAndFeeAndTxPriority(t *testing.T) {
 	}
 
 	// last item popped out
-	prev := &txPrioItem{
-		tx:       nil,
-		txType:   stake.TxTypeSSGen,
-		priority: 10000.0, // since highest priority of the fake tx is 10000
-		feePerKB: 10000.0, // since highest feePerKB of the fake tx is 10000
+	prev := &TxPrioItem{
+		Tx:       nil,
+		TxType:   stake.TxTypeSSGen,
+		Priority: 10000.0, // since highest priority of the fake tx is 10000
+		FeePerKB: 10000.0, // since highest feePerKB of the fake tx is 10000
 	}
 
 	for pq.Len() > 0 {
 		item := heap.Pop(pq)
-		if tx, ok := item.(*txPrioItem); ok {
+		if tx, ok := item.(*TxPrioItem); ok {
 			// check correctness of order
 			// higher stake priority, fee per kb and tx priority comes first
 			if (compareStakePriority(tx, prev) > 0) ||
-				((0 == compareStakePriority(tx, prev)) && (tx.feePerKB > prev.feePerKB)) {
+				((0 == compareStakePriority(tx, prev)) && (tx.FeePerKB > prev.FeePerKB)) {
 				t.Errorf("bad pop: %v fee per KB was more than previous of %v "+
 					"while the txtype was %v but previous was %v",
-					tx.feePerKB, prev.feePerKB, tx.txType, prev.txType)
+					tx.FeePerKB, prev.FeePerKB, tx.TxType, prev.TxType)
 			}
 
 			prev = tx
@@ -92,7 +92,7 @@ func TestTxPQOnStakePriorityAndFeeAndTxPriority(t *testing.T) {
 func TestTxPQOnStakePriorityAndFeeAndConditionalTxPriority(t *testing.T) {
 	// get fake txes as samples
 	prioritizedTxes := fakePrioritizedTxes()
-	pq := newTxPriorityQueue(len(prioritizedTxes), txPQByStakeAndFeeAndThenPriority)
+	pq := NewTxPriorityQueue(len(prioritizedTxes), TxPQByStakeAndFeeAndThenPriority)
 
 	// build the priority queue tx by tx
 	for _, tx := range prioritizedTxes {
@@ -105,40 +105,40 @@ func TestTxPQOnStakePriorityAndFeeAndConditionalTxPriority(t *testing.T) {
 	}
 
 	// last item popped out
-	prev := &txPrioItem{
-		tx:       nil,
-		txType:   stake.TxTypeSSGen,
-		priority: 10000.0, // since highest priority of the fake tx is 10000
-		feePerKB: 10000.0, // since highest feePerKB of the fake tx is 10000
+	prev := &TxPrioItem{
+		Tx:       nil,
+		TxType:   stake.TxTypeSSGen,
+		Priority: 10000.0, // since highest priority of the fake tx is 10000
+		FeePerKB: 10000.0, // since highest feePerKB of the fake tx is 10000
 	}
 
 	for pq.Len() > 0 {
 		item := heap.Pop(pq)
-		if tx, ok := item.(*txPrioItem); ok {
+		if tx, ok := item.(*TxPrioItem); ok {
 			// check correctness of order
 			// higher stake priority, fee per kb
 			// if both tx types are either regular or revocation, plus priority
 			// comes first
 
 			stakePriorityDelta := compareStakePriority(tx, prev)
-			if (txStakePriority(tx.txType) == regOrRevocPriority) &&
-				(txStakePriority(prev.txType) == regOrRevocPriority) {
+			if (txStakePriority(tx.TxType) == regOrRevocPriority) &&
+				(txStakePriority(prev.TxType) == regOrRevocPriority) {
 				// both are of low stake priority
 
 				if (stakePriorityDelta > 0) ||
-					((0 == stakePriorityDelta) && (tx.priority > prev.priority)) {
+					((0 == stakePriorityDelta) && (tx.Priority > prev.Priority)) {
 					t.Errorf("bad pop: %v priority was more than previous of %v "+
 						"while the tx type was %v but previous was %v",
-						tx.priority, prev.priority, tx.txType, prev.txType)
+						tx.Priority, prev.Priority, tx.TxType, prev.TxType)
 				}
 			} else {
 				// neither are of low stake priority
 
 				if (stakePriorityDelta > 0) ||
-					((0 == stakePriorityDelta) && (tx.feePerKB > prev.feePerKB)) {
+					((0 == stakePriorityDelta) && (tx.FeePerKB > prev.FeePerKB)) {
 					t.Errorf("bad pop: %v fee per KB was more than previous of %v "+
 						"while the tx type was %v but previous was %v",
-						tx.feePerKB, prev.feePerKB, tx.txType, prev.txType)
+						tx.FeePerKB, prev.FeePerKB, tx.TxType, prev.TxType)
 				}
 			}
 
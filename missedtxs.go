@@ -0,0 +1,369 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+const (
+	// maxMissedTxsPerRequest is the maximum number of transactions that
+	// are requested from a peer via a single getmissedtxs message.  The
+	// missing transactions of a light block are split into batches of at
+	// most this many transactions so a single slow or failing request only
+	// delays a part of them.
+	maxMissedTxsPerRequest = 500
+
+	// maxMissedTxsAttempts is the maximum number of times the missing
+	// transactions of a batch are requested before falling back to
+	// requesting the full block.
+	maxMissedTxsAttempts = 3
+
+	// maxRecoveringBlocks is the maximum number of light blocks which may
+	// be waiting for their missing transactions at the same time.  Light
+	// blocks announced beyond the limit are requested in full.
+	maxRecoveringBlocks = 8
+
+	// missedTxsRequestTimeout is the duration after which the first
+	// request for missing transactions that has not been answered is
+	// retried.  The timeout doubles with every attempt.
+	missedTxsRequestTimeout = 4 * time.Second
+
+	// missedTxsCheckInterval is the interval at which requests for missing
+	// transactions are checked for timeouts.
+	missedTxsCheckInterval = time.Second
+)
+
+// missedTxsRequest describes an outstanding request for a batch of the missing
+// transactions of a light block.
+type missedTxsRequest struct {
+	blockHash chainhash.Hash
+	txHashes  []chainhash.Hash
+	peer      *serverPeer
+	attempt   int
+	deadline  time.Time
+}
+
+// msg returns the getmissedtxs message for the request.
+func (r *missedTxsRequest) msg() *wire.MsgGetMissedTxs {
+	msg := wire.NewMsgGetMissedTxs(&r.blockHash)
+	for i := range r.txHashes {
+		msg.AddTxHash(&r.txHashes[i])
+	}
+	return msg
+}
+
+// recoveringBlock houses a light block which is waiting for its missing
+// transactions along with the peers which announced it, in the order they
+// did, and the outstanding requests for its transactions.
+type recoveringBlock struct {
+	lightBlock *pendingLightBlock
+	announcers []*serverPeer
+	requests   []*missedTxsRequest
+}
+
+// recoveredBlock is a block which was reconstructed from a light block once
+// all of its missing transactions were received along with the peer it is
+// attributed to, which is the first peer that announced it.
+type recoveredBlock struct {
+	block *wire.MsgBlock
+	peer  *serverPeer
+}
+
+// fullBlockRequest describes a block which could not be reconstructed from a
+// light block and must be requested in full from the peer.
+type fullBlockRequest struct {
+	hash chainhash.Hash
+	peer *serverPeer
+}
+
+// missedTxsActions houses the actions resulting from updating the missing
+// transaction recovery state.
+type missedTxsActions struct {
+	requests   []*missedTxsRequest
+	recovered  []recoveredBlock
+	fullBlocks []fullBlockRequest
+}
+
+// missedTxsRecovery recovers the transactions of light blocks which are not in
+// the memory pool.  The missing transactions of a light block are requested in
+// batches from the peer that announced it.  Batches which are not delivered
+// within the request timeout, or which are delivered without some of their
+// transactions, are requested again from the announcing peer with the fewest
+// outstanding requests other than the one that failed, with the timeout
+// doubling on every attempt.  Once the maximum number of attempts is reached
+// for any batch, the light block is abandoned and the full block is requested
+// instead.
+//
+// The recovery only does the bookkeeping and is not safe for concurrent
+// access.  It is only used from the block handler goroutine.
+type missedTxsRecovery struct {
+	batchSize   int
+	maxAttempts int
+	maxBlocks   int
+	timeout     time.Duration
+
+	blocks      map[chainhash.Hash]*recoveringBlock
+	outstanding map[*serverPeer]int
+}
+
+// newMissedTxsRecovery returns a new missing transaction recovery with the
+// passed batch size, maximum number of attempts per batch, maximum number of
+// blocks being recovered at once, and initial request timeout.
+func newMissedTxsRecovery(batchSize, maxAttempts, maxBlocks int, timeout time.Duration) *missedTxsRecovery {
+	return &missedTxsRecovery{
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+		maxBlocks:   maxBlocks,
+		timeout:     timeout,
+		blocks:      make(map[chainhash.Hash]*recoveringBlock),
+		outstanding: make(map[*serverPeer]int),
+	}
+}
+
+// full returns whether the maximum number of blocks are being recovered.
+func (r *missedTxsRecovery) full() bool {
+	return len(r.blocks) >= r.maxBlocks
+}
+
+// announce records that the passed peer announced the block with the passed
+// hash, so it may be used to request the missing transactions of the block
+// from.  It returns whether the block is being recovered.
+func (r *missedTxsRecovery) announce(hash *chainhash.Hash, sp *serverPeer) bool {
+	rb, exists := r.blocks[*hash]
+	if !exists {
+		return false
+	}
+	for _, announcer := range rb.announcers {
+		if announcer == sp {
+			return true
+		}
+	}
+	rb.announcers = append(rb.announcers, sp)
+	return true
+}
+
+// add starts recovering the missing transactions of the passed light block
+// announced by the passed peer.  It returns the requests for the batches of
+// missing transactions, which must be sent to their peers.
+func (r *missedTxsRecovery) add(lb *pendingLightBlock, sp *serverPeer, now time.Time) []*missedTxsRequest {
+	hash := lb.msg.BlockHash()
+	rb := &recoveringBlock{
+		lightBlock: lb,
+		announcers: []*serverPeer{sp},
+	}
+	r.blocks[hash] = rb
+
+	missing := lb.missingTxns()
+	requests := make([]*missedTxsRequest, 0,
+		(len(missing)+r.batchSize-1)/r.batchSize)
+	for len(missing) > 0 {
+		batch := missing
+		if len(batch) > r.batchSize {
+			batch = batch[:r.batchSize]
+		}
+		missing = missing[len(batch):]
+		requests = append(requests, r.request(rb, hash, batch, sp, 0,
+			now))
+	}
+	return requests
+}
+
+// request records a new outstanding request for the passed batch of missing
+// transactions of the passed block and returns it.
+func (r *missedTxsRecovery) request(rb *recoveringBlock, hash chainhash.Hash, txHashes []chainhash.Hash, sp *serverPeer, attempt int, now time.Time) *missedTxsRequest {
+	req := &missedTxsRequest{
+		blockHash: hash,
+		txHashes:  txHashes,
+		peer:      sp,
+		attempt:   attempt,
+		deadline:  now.Add(r.timeout << uint(attempt)),
+	}
+	rb.requests = append(rb.requests, req)
+	r.outstanding[sp]++
+	return req
+}
+
+// removeRequest removes the passed outstanding request of the passed block.
+func (r *missedTxsRecovery) removeRequest(rb *recoveringBlock, req *missedTxsRequest) {
+	for i, other := range rb.requests {
+		if other == req {
+			rb.requests = append(rb.requests[:i], rb.requests[i+1:]...)
+			break
+		}
+	}
+	if r.outstanding[req.peer]--; r.outstanding[req.peer] <= 0 {
+		delete(r.outstanding, req.peer)
+	}
+}
+
+// removeBlock stops recovering the block with the passed hash and removes all
+// of its outstanding requests.
+func (r *missedTxsRecovery) removeBlock(hash *chainhash.Hash) {
+	rb, exists := r.blocks[*hash]
+	if !exists {
+		return
+	}
+	for len(rb.requests) > 0 {
+		r.removeRequest(rb, rb.requests[0])
+	}
+	delete(r.blocks, *hash)
+}
+
+// alternate returns the announcer of the passed block with the fewest
+// outstanding requests other than the passed peer, or the passed peer itself
+// when it is the only remaining announcer.  It returns nil when the block has
+// no announcers left.
+func (r *missedTxsRecovery) alternate(rb *recoveringBlock, failed *serverPeer) *serverPeer {
+	var best *serverPeer
+	for _, sp := range rb.announcers {
+		if sp == failed {
+			continue
+		}
+		if best == nil || r.outstanding[sp] < r.outstanding[best] {
+			best = sp
+		}
+	}
+	if best == nil {
+		for _, sp := range rb.announcers {
+			if sp == failed {
+				return sp
+			}
+		}
+	}
+	return best
+}
+
+// retry requests the passed transactions of the passed block again from an
+// alternate peer, or abandons the block and falls back to requesting the full
+// block when the maximum number of attempts is reached.  The resulting actions
+// are added to the passed actions.
+func (r *missedTxsRecovery) retry(hash chainhash.Hash, txHashes []chainhash.Hash, failed *serverPeer, attempt int, now time.Time, actions *missedTxsActions) {
+	rb := r.blocks[hash]
+	sp := r.alternate(rb, failed)
+	if attempt < r.maxAttempts && sp != nil {
+		actions.requests = append(actions.requests, r.request(rb, hash,
+			txHashes, sp, attempt, now))
+		return
+	}
+
+	r.removeBlock(&hash)
+	if sp != nil {
+		actions.fullBlocks = append(actions.fullBlocks,
+			fullBlockRequest{hash: hash, peer: sp})
+	}
+}
+
+// received processes the passed missedtxs message from the passed peer.  The
+// message answers the oldest outstanding request of the peer for the block.
+// It returns false when there is no such request.
+func (r *missedTxsRecovery) received(sp *serverPeer, msg *wire.MsgMissedTxs, now time.Time) (*missedTxsActions, bool) {
+	rb, exists := r.blocks[msg.BlockHash]
+	if !exists {
+		return nil, false
+	}
+	var req *missedTxsRequest
+	for _, other := range rb.requests {
+		if other.peer == sp {
+			req = other
+			break
+		}
+	}
+	if req == nil {
+		return nil, false
+	}
+	r.removeRequest(rb, req)
+
+	actions := new(missedTxsActions)
+	rb.lightBlock.addTxns(msg.Transactions)
+	if block := rb.lightBlock.block(); block != nil {
+		r.removeBlock(&msg.BlockHash)
+		actions.recovered = append(actions.recovered,
+			recoveredBlock{block: block, peer: rb.announcers[0]})
+		return actions, true
+	}
+
+	// Request the transactions of the batch which the peer did not deliver
+	// from an alternate peer.
+	var stillMissing []chainhash.Hash
+	for _, hash := range req.txHashes {
+		if rb.lightBlock.txns[hash] == nil {
+			stillMissing = append(stillMissing, hash)
+		}
+	}
+	if len(stillMissing) > 0 {
+		r.retry(msg.BlockHash, stillMissing, sp, req.attempt+1, now,
+			actions)
+	}
+	return actions, true
+}
+
+// expire retries every request with a deadline before the passed time from an
+// alternate peer, or falls back to the full block for the blocks for which the
+// maximum number of attempts is reached.
+func (r *missedTxsRecovery) expire(now time.Time) *missedTxsActions {
+	actions := new(missedTxsActions)
+	for hash, rb := range r.blocks {
+		var expired []*missedTxsRequest
+		for _, req := range rb.requests {
+			if !now.Before(req.deadline) {
+				expired = append(expired, req)
+			}
+		}
+		for _, req := range expired {
+			if _, exists := r.blocks[hash]; !exists {
+				break
+			}
+			r.removeRequest(rb, req)
+			r.retry(hash, req.txHashes, req.peer, req.attempt+1, now,
+				actions)
+		}
+	}
+	return actions
+}
+
+// removePeer removes the passed peer from the announcers of all blocks and
+// requests its outstanding batches from alternate peers without counting it
+// as an attempt.  Blocks without any announcers left are abandoned.
+func (r *missedTxsRecovery) removePeer(sp *serverPeer, now time.Time) *missedTxsActions {
+	actions := new(missedTxsActions)
+	for hash, rb := range r.blocks {
+		for i, announcer := range rb.announcers {
+			if announcer == sp {
+				rb.announcers = append(rb.announcers[:i],
+					rb.announcers[i+1:]...)
+				break
+			}
+		}
+
+		var orphaned []*missedTxsRequest
+		for _, req := range rb.requests {
+			if req.peer == sp {
+				orphaned = append(orphaned, req)
+			}
+		}
+		for _, req := range orphaned {
+			if _, exists := r.blocks[hash]; !exists {
+				break
+			}
+			r.removeRequest(rb, req)
+			r.retry(hash, req.txHashes, sp, req.attempt, now, actions)
+		}
+		if _, exists := r.blocks[hash]; exists && len(rb.announcers) == 0 {
+			r.removeBlock(&hash)
+		}
+	}
+	delete(r.outstanding, sp)
+	return actions
+}
+
+// numOutstanding returns the number of outstanding requests of the passed
+// peer.
+func (r *missedTxsRecovery) numOutstanding(sp *serverPeer) int {
+	return r.outstanding[sp]
+}
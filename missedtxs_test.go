@@ -0,0 +1,215 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+// testLightBlock returns a block with the passed number of distinct regular
+// transactions along with a light block for it whose transactions, except for
+// the prefilled coinbase, are all missing.
+func testLightBlock(numTxns int) (*wire.MsgBlock, *pendingLightBlock) {
+	block := &wire.MsgBlock{}
+	for i := 0; i < numTxns; i++ {
+		tx := wire.NewMsgTx()
+		tx.LockTime = uint32(i)
+		block.Transactions = append(block.Transactions, tx)
+	}
+	msg := wire.NewMsgLightBlockFromBlock(block)
+	lb := newPendingLightBlock(msg, func(*chainhash.Hash) *wire.MsgTx {
+		return nil
+	})
+	return block, lb
+}
+
+// missedTxsFor returns a missedtxs message for the passed block which carries
+// the transactions of the block with the passed hashes.
+func missedTxsFor(block *wire.MsgBlock, hashes []chainhash.Hash) *wire.MsgMissedTxs {
+	blockHash := block.BlockHash()
+	msg := wire.NewMsgMissedTxs(&blockHash)
+	for _, hash := range hashes {
+		for _, tx := range block.Transactions {
+			if tx.TxHash() == hash {
+				msg.AddTransaction(tx)
+			}
+		}
+	}
+	return msg
+}
+
+// TestMissedTxsRecoveryBatches ensures the missing transactions of a light
+// block are requested in batches from the announcing peer and the block is
+// reconstructed once all batches are received.
+func TestMissedTxsRecoveryBatches(t *testing.T) {
+	sp := &serverPeer{}
+	r := newMissedTxsRecovery(2, 3, 8, time.Minute)
+	block, lb := testLightBlock(6)
+
+	// The coinbase is prefilled, so the five remaining transactions must be
+	// split into three batches.
+	now := time.Now()
+	requests := r.add(lb, sp, now)
+	if len(requests) != 3 {
+		t.Fatalf("got %d requests, want 3", len(requests))
+	}
+	for i, req := range requests {
+		if req.peer != sp || req.attempt != 0 {
+			t.Fatalf("request %d: unexpected peer or attempt %d", i,
+				req.attempt)
+		}
+		if len(req.msg().TxHashes) != len(req.txHashes) {
+			t.Fatalf("request %d: got %d hashes in message, want %d",
+				i, len(req.msg().TxHashes), len(req.txHashes))
+		}
+	}
+	if r.numOutstanding(sp) != 3 {
+		t.Fatalf("got %d outstanding requests, want 3",
+			r.numOutstanding(sp))
+	}
+
+	// Unrequested transactions are rejected.
+	other := &serverPeer{}
+	_, ok := r.received(other, missedTxsFor(block, requests[0].txHashes), now)
+	if ok {
+		t.Fatal("accepted missedtxs from peer without requests")
+	}
+
+	for i, req := range requests {
+		actions, ok := r.received(sp, missedTxsFor(block, req.txHashes),
+			now)
+		if !ok {
+			t.Fatalf("request %d: missedtxs not accepted", i)
+		}
+		if len(actions.requests) != 0 || len(actions.fullBlocks) != 0 {
+			t.Fatalf("request %d: unexpected actions %+v", i, actions)
+		}
+		if i < len(requests)-1 {
+			if len(actions.recovered) != 0 {
+				t.Fatalf("request %d: block recovered early", i)
+			}
+			continue
+		}
+		if len(actions.recovered) != 1 || actions.recovered[0].peer != sp {
+			t.Fatalf("block not recovered: %+v", actions)
+		}
+		if actions.recovered[0].block.BlockHash() != block.BlockHash() {
+			t.Fatal("recovered block does not match")
+		}
+	}
+	if r.numOutstanding(sp) != 0 || len(r.blocks) != 0 {
+		t.Fatalf("recovery state not cleaned up: %d outstanding, %d "+
+			"blocks", r.numOutstanding(sp), len(r.blocks))
+	}
+}
+
+// TestMissedTxsRecoveryRetry ensures requests which time out or are answered
+// incompletely are retried from alternate peers with a backoff, and the full
+// block is requested once the attempts are exhausted.
+func TestMissedTxsRecoveryRetry(t *testing.T) {
+	sp1, sp2 := &serverPeer{}, &serverPeer{}
+	r := newMissedTxsRecovery(10, 3, 8, time.Second)
+	block, lb := testLightBlock(4)
+	blockHash := block.BlockHash()
+
+	now := time.Now()
+	requests := r.add(lb, sp1, now)
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	if !r.announce(&blockHash, sp2) {
+		t.Fatal("block not reported as being recovered")
+	}
+
+	// Nothing expires before the deadline.
+	actions := r.expire(now.Add(time.Second - 1))
+	if len(actions.requests) != 0 {
+		t.Fatalf("got %d requests before deadline", len(actions.requests))
+	}
+
+	// The timed out request is retried from the alternate peer with twice
+	// the timeout.
+	now = now.Add(time.Second)
+	actions = r.expire(now)
+	if len(actions.requests) != 1 {
+		t.Fatalf("got %d retries, want 1", len(actions.requests))
+	}
+	retry := actions.requests[0]
+	if retry.peer != sp2 || retry.attempt != 1 {
+		t.Fatalf("unexpected retry peer or attempt %d", retry.attempt)
+	}
+	if !retry.deadline.Equal(now.Add(2 * time.Second)) {
+		t.Fatalf("got deadline %v, want %v", retry.deadline,
+			now.Add(2*time.Second))
+	}
+	if r.numOutstanding(sp1) != 0 || r.numOutstanding(sp2) != 1 {
+		t.Fatal("unexpected outstanding requests after retry")
+	}
+
+	// An incomplete response only retries the transactions which were not
+	// delivered from the other peer.
+	txHashes := retry.txHashes
+	actions, ok := r.received(sp2, missedTxsFor(block, txHashes[:1]), now)
+	if !ok {
+		t.Fatal("missedtxs not accepted")
+	}
+	if len(actions.requests) != 1 {
+		t.Fatalf("got %d retries, want 1", len(actions.requests))
+	}
+	retry = actions.requests[0]
+	if retry.peer != sp1 || retry.attempt != 2 ||
+		!equalHashes(retry.txHashes, txHashes[1:]) {
+
+		t.Fatalf("unexpected retry %+v", retry)
+	}
+
+	// The final attempt timing out falls back to the full block.
+	actions = r.expire(now.Add(4 * time.Second))
+	if len(actions.requests) != 0 || len(actions.fullBlocks) != 1 {
+		t.Fatalf("unexpected actions %+v", actions)
+	}
+	if actions.fullBlocks[0].hash != blockHash {
+		t.Fatal("full block request for wrong block")
+	}
+	if r.announce(&blockHash, sp1) || len(r.outstanding) != 0 {
+		t.Fatal("recovery state not cleaned up")
+	}
+}
+
+// TestMissedTxsRecoveryRemovePeer ensures the requests of a disconnected peer
+// are reassigned to the remaining announcers and blocks without announcers are
+// abandoned.
+func TestMissedTxsRecoveryRemovePeer(t *testing.T) {
+	sp1, sp2 := &serverPeer{}, &serverPeer{}
+	r := newMissedTxsRecovery(10, 3, 1, time.Second)
+	block, lb := testLightBlock(3)
+	blockHash := block.BlockHash()
+
+	now := time.Now()
+	r.add(lb, sp1, now)
+	r.announce(&blockHash, sp2)
+	if !r.full() {
+		t.Fatal("recovery not full")
+	}
+
+	actions := r.removePeer(sp1, now)
+	if len(actions.requests) != 1 || actions.requests[0].peer != sp2 ||
+		actions.requests[0].attempt != 0 {
+
+		t.Fatalf("unexpected actions %+v", actions)
+	}
+
+	actions = r.removePeer(sp2, now)
+	if len(actions.requests) != 0 || len(actions.fullBlocks) != 0 {
+		t.Fatalf("unexpected actions %+v", actions)
+	}
+	if r.full() || len(r.outstanding) != 0 {
+		t.Fatal("recovery state not cleaned up")
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/database"
+	"github.com/HcashOrg/hcashd/metrics"
+)
+
+// metricsTxTypes maps the stake transaction types to the values of the type
+// label of the mempool metrics.
+var metricsTxTypes = map[stake.TxType]string{
+	stake.TxTypeRegular: "regular",
+	stake.TxTypeSStx:    "ticket",
+	stake.TxTypeSSGen:   "vote",
+	stake.TxTypeSSRtx:   "revocation",
+}
+
+// nodeMetrics houses the metrics exported by the Prometheus metrics endpoint
+// along with the HTTP server which serves them.  The histograms and counters
+// are updated by the subsystems they instrument, while the gauges are read from
+// the server each time the metrics are scraped.
+type nodeMetrics struct {
+	registry *metrics.Registry
+
+	blockValidation *metrics.Histogram
+	txValidation    *metrics.Histogram
+	dbView          *metrics.Histogram
+	dbUpdate        *metrics.Histogram
+	peerBans        *metrics.Counter
+
+	listeners  []net.Listener
+	httpServer *http.Server
+	wg         sync.WaitGroup
+}
+
+// newNodeMetrics returns the metrics of the node, which are served on the
+// passed addresses once started.
+func newNodeMetrics(listenAddrs []string) (*nodeMetrics, error) {
+	ipv4ListenAddrs, ipv6ListenAddrs, _, err := parseListeners(listenAddrs)
+	if err != nil {
+		return nil, err
+	}
+	listeners := make([]net.Listener, 0,
+		len(ipv6ListenAddrs)+len(ipv4ListenAddrs))
+	for _, addr := range ipv4ListenAddrs {
+		listener, err := net.Listen("tcp4", addr)
+		if err != nil {
+			srvrLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	for _, addr := range ipv6ListenAddrs {
+		listener, err := net.Listen("tcp6", addr)
+		if err != nil {
+			srvrLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		return nil, errors.New("metrics: no valid listen address")
+	}
+
+	r := metrics.NewRegistry()
+	m := &nodeMetrics{
+		registry: r,
+		blockValidation: r.NewHistogram("hcashd_block_validation_seconds",
+			"Time taken to process blocks, including their validation "+
+				"and connection to the chain.",
+			metrics.DefaultDurationBuckets),
+		txValidation: r.NewHistogram("hcashd_tx_validation_seconds",
+			"Time taken to validate transactions for acceptance to "+
+				"the mempool.", metrics.DefaultDurationBuckets),
+		dbView: r.NewHistogram("hcashd_db_view_seconds",
+			"Time taken by read-only database transactions.",
+			metrics.DefaultDurationBuckets),
+		dbUpdate: r.NewHistogram("hcashd_db_update_seconds",
+			"Time taken by read-write database transactions, "+
+				"including their commit.",
+			metrics.DefaultDurationBuckets),
+		peerBans: r.NewCounter("hcashd_peer_bans_total",
+			"Number of peers banned for misbehaving."),
+		listeners: listeners,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", r)
+	m.httpServer = &http.Server{
+		Handler:     mux,
+		ReadTimeout: time.Second * rpcAuthTimeoutSeconds,
+	}
+	return m, nil
+}
+
+// registerServer registers the gauges which are read from the passed server.
+// It must be called once the server has been fully created.
+func (m *nodeMetrics) registerServer(s *server) {
+	r := m.registry
+	r.NewGaugeFunc("hcashd_chain_height", "Height of the best chain.",
+		func() float64 {
+			return float64(s.blockManager.chain.BestSnapshot().Height)
+		})
+	r.NewGaugeFunc("hcashd_chain_key_height",
+		"Key height of the best chain.", func() float64 {
+			return float64(s.blockManager.chain.BestSnapshot().KeyHeight)
+		})
+	r.NewGaugeVecFunc("hcashd_mempool_transactions",
+		"Number of transactions in the mempool by type.", "type",
+		func() map[string]float64 {
+			counts := s.txMemPool.CountByType()
+			values := make(map[string]float64, len(metricsTxTypes))
+			for txType, label := range metricsTxTypes {
+				values[label] = float64(counts[txType])
+			}
+			return values
+		})
+	r.NewGaugeVecFunc("hcashd_peers", "Number of connected peers by "+
+		"direction.", "direction", func() map[string]float64 {
+		values := map[string]float64{"inbound": 0, "outbound": 0}
+		for _, sp := range s.Peers() {
+			values[directionString(sp.Inbound())]++
+		}
+		return values
+	})
+	r.NewCounterFunc("hcashd_net_received_bytes_total",
+		"Number of bytes received from all peers.", func() float64 {
+			received, _ := s.NetTotals()
+			return float64(received)
+		})
+	r.NewCounterFunc("hcashd_net_sent_bytes_total",
+		"Number of bytes sent to all peers.", func() float64 {
+			_, sent := s.NetTotals()
+			return float64(sent)
+		})
+}
+
+// observeBlockValidation records the time taken to process a block since the
+// passed time.  It does nothing when the metrics are disabled, which is the
+// case for a nil receiver.
+func (m *nodeMetrics) observeBlockValidation(start time.Time) {
+	if m != nil {
+		m.blockValidation.ObserveSince(start)
+	}
+}
+
+// observeTxValidation records the time taken to process a transaction since
+// the passed time.  It does nothing when the metrics are disabled, which is the
+// case for a nil receiver.
+func (m *nodeMetrics) observeTxValidation(start time.Time) {
+	if m != nil {
+		m.txValidation.ObserveSince(start)
+	}
+}
+
+// countPeerBan records the ban of a peer.  It does nothing when the metrics are
+// disabled, which is the case for a nil receiver.
+func (m *nodeMetrics) countPeerBan() {
+	if m != nil {
+		m.peerBans.Inc()
+	}
+}
+
+// Start begins serving the metrics on all listeners.
+func (m *nodeMetrics) Start() {
+	for _, listener := range m.listeners {
+		m.wg.Add(1)
+		go func(listener net.Listener) {
+			srvrLog.Infof("Metrics server listening on %s",
+				listener.Addr())
+			m.httpServer.Serve(listener)
+			srvrLog.Tracef("Metrics listener done for %s",
+				listener.Addr())
+			m.wg.Done()
+		}(listener)
+	}
+}
+
+// Stop closes all listeners and waits for them to finish.
+func (m *nodeMetrics) Stop() {
+	for _, listener := range m.listeners {
+		listener.Close()
+	}
+	m.wg.Wait()
+}
+
+// instrumentDB returns the passed database instrumented to record the time
+// taken by its managed transactions.
+func (m *nodeMetrics) instrumentDB(db database.DB) database.DB {
+	return &metricsDB{DB: db, metrics: m}
+}
+
+// metricsDB wraps a database to record the time taken by its managed
+// transactions in the metrics.  All other methods are passed through to the
+// wrapped database.
+type metricsDB struct {
+	database.DB
+	metrics *nodeMetrics
+}
+
+// View invokes the passed function in the context of a managed read-only
+// transaction of the wrapped database and records the time it took.
+//
+// This is part of the database.DB interface implementation.
+func (db *metricsDB) View(fn func(tx database.Tx) error) error {
+	defer db.metrics.dbView.ObserveSince(time.Now())
+	return db.DB.View(fn)
+}
+
+// Update invokes the passed function in the context of a managed read-write
+// transaction of the wrapped database and records the time it took.
+//
+// This is part of the database.DB interface implementation.
+func (db *metricsDB) Update(fn func(tx database.Tx) error) error {
+	defer db.metrics.dbUpdate.ObserveSince(time.Now())
+	return db.DB.Update(fn)
+}
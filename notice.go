@@ -0,0 +1,175 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+const (
+	// maxActiveNotices is the maximum number of unexpired notices that are
+	// kept and relayed at any one time.  New notices are rejected while
+	// the limit is reached.
+	maxActiveNotices = 16
+
+	// maxNoticeLifetime is the furthest in the future the expiration of an
+	// accepted notice may be.
+	maxNoticeLifetime = 30 * 24 * time.Hour
+
+	// noticeRateInterval is the interval over which at most
+	// maxActiveNotices notices are accepted from a single peer.  Notices
+	// beyond that are dropped and increase the ban score of the peer.
+	noticeRateInterval = time.Minute
+)
+
+var (
+	// errNoticeDisabled is returned when a notice is received on a network
+	// that has no notice keys configured.
+	errNoticeDisabled = errors.New("notices are not enabled on this network")
+
+	// errNoticeKnown is returned when a notice with the same ID has
+	// already been accepted.
+	errNoticeKnown = errors.New("notice already known")
+
+	// errNoticeExpired is returned when a notice has already expired.
+	errNoticeExpired = errors.New("notice has expired")
+
+	// errNoticeLimit is returned when the maximum number of active notices
+	// has been reached.
+	errNoticeLimit = errors.New("too many active notices")
+)
+
+// noticesByID implements sort.Interface to allow a slice of notices to be
+// sorted by their ID.
+type noticesByID []*wire.MsgNotice
+
+// Len returns the number of notices in the slice.  It is part of the
+// sort.Interface implementation.
+func (s noticesByID) Len() int { return len(s) }
+
+// Swap swaps the notices at the passed indices.  It is part of the
+// sort.Interface implementation.
+func (s noticesByID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+// Less returns whether the notice with index i should sort before the notice
+// with index j.  It is part of the sort.Interface implementation.
+func (s noticesByID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+
+// noticeManager validates and stores the signed operator notices received
+// from the network.  It is safe for concurrent access.
+type noticeManager struct {
+	keys []chainec.PublicKey
+
+	mtx     sync.Mutex
+	notices map[uint64]*wire.MsgNotice
+}
+
+// newNoticeManager returns a notice manager which accepts notices signed by
+// the notice keys of the passed network parameters.
+func newNoticeManager(params *chaincfg.Params) (*noticeManager, error) {
+	keys := make([]chainec.PublicKey, 0, len(params.NoticeKeys))
+	for i, serialized := range params.NoticeKeys {
+		key, err := chainec.Secp256k1.ParsePubKey(serialized)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notice key %d: %v", i, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return &noticeManager{
+		keys:    keys,
+		notices: make(map[uint64]*wire.MsgNotice),
+	}, nil
+}
+
+// verify returns an error when msg is not signed by any of the notice keys.
+func (m *noticeManager) verify(msg *wire.MsgNotice) error {
+	hash, err := msg.SignatureHash()
+	if err != nil {
+		return err
+	}
+	sig, err := chainec.Secp256k1.ParseDERSignature(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("malformed notice signature: %v", err)
+	}
+	for _, key := range m.keys {
+		if chainec.Secp256k1.Verify(key, hash[:], sig.GetR(), sig.GetS()) {
+			return nil
+		}
+	}
+	return errors.New("notice signature does not match a notice key")
+}
+
+// prune removes all notices which have expired as of now.
+//
+// This function MUST be called with the manager lock held.
+func (m *noticeManager) prune(now time.Time) {
+	for id, notice := range m.notices {
+		if notice.Expiration <= now.Unix() {
+			delete(m.notices, id)
+		}
+	}
+}
+
+// ProcessNotice validates the passed notice and adds it to the set of active
+// notices.  An error is returned when the notice is invalid, expired, already
+// known, or the maximum number of active notices has been reached.  Only
+// notices for which no error is returned should be relayed.
+func (m *noticeManager) ProcessNotice(msg *wire.MsgNotice, now time.Time) error {
+	if len(m.keys) == 0 {
+		return errNoticeDisabled
+	}
+
+	if msg.Expiration <= now.Unix() {
+		return errNoticeExpired
+	}
+	if msg.Expiration > now.Add(maxNoticeLifetime).Unix() {
+		return fmt.Errorf("notice expiration %v is more than %v in "+
+			"the future", time.Unix(msg.Expiration, 0),
+			maxNoticeLifetime)
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.prune(now)
+	if _, ok := m.notices[msg.ID]; ok {
+		return errNoticeKnown
+	}
+	if len(m.notices) >= maxActiveNotices {
+		return errNoticeLimit
+	}
+
+	// The signature is checked last since it is the most expensive check.
+	if err := m.verify(msg); err != nil {
+		return err
+	}
+
+	m.notices[msg.ID] = msg
+	return nil
+}
+
+// ActiveNotices returns the notices which have not expired as of now, ordered
+// by ID.
+func (m *noticeManager) ActiveNotices(now time.Time) []*wire.MsgNotice {
+	m.mtx.Lock()
+	m.prune(now)
+	notices := make([]*wire.MsgNotice, 0, len(m.notices))
+	for _, notice := range m.notices {
+		notices = append(notices, notice)
+	}
+	m.mtx.Unlock()
+
+	sort.Sort(noticesByID(notices))
+	return notices
+}
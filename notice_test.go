@@ -0,0 +1,190 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+// newTestNoticeManager returns a notice manager accepting notices signed by
+// the returned private key.
+func newTestNoticeManager(t *testing.T) (*noticeManager, chainec.PrivateKey) {
+	priv, pub := chainec.Secp256k1.PrivKeyFromBytes(
+		bytes.Repeat([]byte{0x01}, 32))
+	params := chaincfg.SimNetParams
+	params.NoticeKeys = [][]byte{pub.SerializeCompressed()}
+	m, err := newNoticeManager(&params)
+	if err != nil {
+		t.Fatalf("newNoticeManager: unexpected error: %v", err)
+	}
+	return m, priv
+}
+
+// signedNotice returns a new notice signed by the passed private key.
+func signedNotice(t *testing.T, priv chainec.PrivateKey, id uint64, expiration time.Time) *wire.MsgNotice {
+	msg := wire.NewMsgNotice(id, expiration.Unix(), "upgrade required")
+	hash, err := msg.SignatureHash()
+	if err != nil {
+		t.Fatalf("SignatureHash: unexpected error: %v", err)
+	}
+	r, s, err := chainec.Secp256k1.Sign(priv, hash[:])
+	if err != nil {
+		t.Fatalf("Sign: unexpected error: %v", err)
+	}
+	msg.Signature = chainec.Secp256k1.NewSignature(r, s).Serialize()
+	return msg
+}
+
+// TestProcessNotice ensures only unexpired notices signed by a notice key are
+// accepted, and only once.
+func TestProcessNotice(t *testing.T) {
+	m, priv := newTestNoticeManager(t)
+	otherPriv, _ := chainec.Secp256k1.PrivKeyFromBytes(
+		bytes.Repeat([]byte{0x02}, 32))
+	now := time.Unix(1500000000, 0)
+	expiration := now.Add(time.Hour)
+
+	if err := m.ProcessNotice(signedNotice(t, priv, 1, expiration),
+		now); err != nil {
+		t.Fatalf("ProcessNotice: unexpected error: %v", err)
+	}
+
+	malformed := signedNotice(t, priv, 6, expiration)
+	malformed.Signature = []byte{0x30, 0x01, 0x02}
+	tampered := signedNotice(t, priv, 7, expiration)
+	tampered.Message = "downgrade required"
+	tests := []struct {
+		name    string
+		msg     *wire.MsgNotice
+		wantErr error
+	}{
+		{"known", signedNotice(t, priv, 1, expiration), errNoticeKnown},
+		{"expired", signedNotice(t, priv, 2, now), errNoticeExpired},
+		{"too far in the future", signedNotice(t, priv, 3,
+			now.Add(maxNoticeLifetime+time.Second)), nil},
+		{"other key", signedNotice(t, otherPriv, 4, expiration), nil},
+		{"malformed signature", malformed, nil},
+		{"tampered", tampered, nil},
+	}
+	for _, test := range tests {
+		err := m.ProcessNotice(test.msg, now)
+		if err == nil {
+			t.Errorf("%s: notice was accepted", test.name)
+			continue
+		}
+		if test.wantErr != nil && err != test.wantErr {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				test.wantErr)
+		}
+	}
+	if got := len(m.ActiveNotices(now)); got != 1 {
+		t.Fatalf("got %d active notices, want 1", got)
+	}
+
+	// Networks without notice keys accept no notices.
+	disabled, err := newNoticeManager(&chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("newNoticeManager: unexpected error: %v", err)
+	}
+	err = disabled.ProcessNotice(signedNotice(t, priv, 1, expiration), now)
+	if err != errNoticeDisabled {
+		t.Fatalf("got error %v, want %v", err, errNoticeDisabled)
+	}
+}
+
+// TestNoticeExpiration ensures expired notices are no longer delivered and
+// free up their slot among the maximum number of active notices.
+func TestNoticeExpiration(t *testing.T) {
+	m, priv := newTestNoticeManager(t)
+	now := time.Unix(1500000000, 0)
+
+	// Fill up the active notices with ones expiring in turn.
+	for i := 0; i < maxActiveNotices; i++ {
+		msg := signedNotice(t, priv, uint64(i),
+			now.Add(time.Duration(i+1)*time.Minute))
+		if err := m.ProcessNotice(msg, now); err != nil {
+			t.Fatalf("ProcessNotice(%d): unexpected error: %v", i, err)
+		}
+	}
+	extra := signedNotice(t, priv, maxActiveNotices, now.Add(time.Hour))
+	if err := m.ProcessNotice(extra, now); err != errNoticeLimit {
+		t.Fatalf("got error %v, want %v", err, errNoticeLimit)
+	}
+
+	// Once the first notice expired, the extra notice takes its place.
+	later := now.Add(time.Minute)
+	if got := len(m.ActiveNotices(later)); got != maxActiveNotices-1 {
+		t.Fatalf("got %d active notices after the first one expired, "+
+			"want %d", got, maxActiveNotices-1)
+	}
+	if err := m.ProcessNotice(extra, later); err != nil {
+		t.Fatalf("ProcessNotice: unexpected error: %v", err)
+	}
+
+	// Notices with an expired ID may be accepted again.
+	if err := m.ProcessNotice(signedNotice(t, priv, 0,
+		later.Add(time.Hour)), later.Add(time.Minute)); err != nil {
+		t.Fatalf("ProcessNotice: unexpected error: %v", err)
+	}
+}
+
+// TestActiveNotices ensures the notices delivered to peers and RPC clients
+// are the unexpired ones ordered by ID.
+func TestActiveNotices(t *testing.T) {
+	m, priv := newTestNoticeManager(t)
+	now := time.Unix(1500000000, 0)
+
+	expirations := map[uint64]time.Duration{
+		5: time.Hour,
+		2: time.Minute,
+		9: 2 * time.Hour,
+		7: time.Minute,
+	}
+	for id, lifetime := range expirations {
+		msg := signedNotice(t, priv, id, now.Add(lifetime))
+		if err := m.ProcessNotice(msg, now); err != nil {
+			t.Fatalf("ProcessNotice(%d): unexpected error: %v", id, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want []uint64
+	}{
+		{"all active", now, []uint64{2, 5, 7, 9}},
+		{"some expired", now.Add(time.Minute), []uint64{5, 9}},
+		{"all expired", now.Add(2 * time.Hour), []uint64{}},
+	}
+	for _, test := range tests {
+		notices := m.ActiveNotices(test.now)
+		got := make([]uint64, 0, len(notices))
+		for _, notice := range notices {
+			if err := m.verify(notice); err != nil {
+				t.Errorf("%s: delivered notice %d does not verify: %v",
+					test.name, notice.ID, err)
+			}
+			got = append(got, notice.ID)
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got notices %v, want %v", test.name, got,
+				test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: got notices %v, want %v", test.name,
+					got, test.want)
+				break
+			}
+		}
+	}
+}
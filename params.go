@@ -19,7 +19,8 @@ var activeNetParams = &mainNetParams
 // network and test networks.
 type params struct {
 	*chaincfg.Params
-	rpcPort string
+	rpcPort  string
+	grpcPort string
 }
 
 // mainNetParams contains parameters specific to the main network
@@ -29,22 +30,25 @@ type params struct {
 // it does not handle on to hcashd.  This approach allows the wallet process
 // to emulate the full reference implementation RPC API.
 var mainNetParams = params{
-	Params:  &chaincfg.MainNetParams,
-	rpcPort: "14009",
+	Params:   &chaincfg.MainNetParams,
+	rpcPort:  "14009",
+	grpcPort: "14019",
 }
 
 // testNet2Params contains parameters specific to the test network (version 2)
 // (wire.TestNet2).
 var testNet2Params = params{
-	Params:  &chaincfg.TestNet2Params,
-	rpcPort: "12009",
+	Params:   &chaincfg.TestNet2Params,
+	rpcPort:  "12009",
+	grpcPort: "12019",
 }
 
 // simNetParams contains parameters specific to the simulation test network
 // (wire.SimNet).
 var simNetParams = params{
-	Params:  &chaincfg.SimNetParams,
-	rpcPort: "13009",
+	Params:   &chaincfg.SimNetParams,
+	rpcPort:  "13009",
+	grpcPort: "13019",
 }
 
 // netName returns the name used when referring to a hypercash network.  At the
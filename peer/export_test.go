@@ -12,8 +12,24 @@ The functions are only exported while the tests are being run.
 
 package peer
 
+import "github.com/HcashOrg/hcashd/wire"
+
 // TstAllowSelfConns allows the test package to allow self connections by
 // disabling the detection logic.
 func TstAllowSelfConns() {
 	allowSelfConns = true
 }
+
+// TstMessageHook is invoked with each message sent to or received from a peer
+// and returns the messages to process in its place.  Returning no messages
+// holds the passed message back until the hook returns it with a later call.
+type TstMessageHook func(p *Peer, msg wire.Message) []wire.Message
+
+// TstSetMessageHooks allows the test package to deterministically delay and
+// reorder the messages sent to and received from the passed peer.  Passing nil
+// leaves the respective direction unhooked.  The hooks must be set before the
+// connection is associated with the peer.
+func TstSetMessageHooks(p *Peer, send, recv TstMessageHook) {
+	p.sendHook = messageHook(send)
+	p.recvHook = messageHook(recv)
+}
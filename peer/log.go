@@ -165,6 +165,10 @@ func messageSummary(msg wire.Message) string {
 	case *wire.MsgAlert:
 		// No summary.
 
+	case *wire.MsgNotice:
+		return fmt.Sprintf("id %d, expires %v", msg.ID,
+			time.Unix(msg.Expiration, 0))
+
 	case *wire.MsgMemPool:
 		// No summary.
 
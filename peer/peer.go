@@ -29,7 +29,7 @@ import (
 
 const (
 	// MaxProtocolVersion is the max protocol version the peer supports.
-	MaxProtocolVersion = wire.FeeFilterVersion
+	MaxProtocolVersion = wire.LightBlockVersion
 
 	// outputBufferSize is the number of elements the output channels use.
 	outputBufferSize = 5000
@@ -85,8 +85,16 @@ var (
 	// connection detecting and disconnect logic since they intentionally
 	// do so for testing purposes.
 	allowSelfConns bool
+
 )
 
+// messageHook is invoked with each message sent to or received from a peer.
+// It returns the messages to process in place of the passed one, which allows
+// a hook to hold a message back by returning no messages and to later release
+// it along with, before, or after another message.  A hook may also block to
+// delay the processing of a message.
+type messageHook func(p *Peer, msg wire.Message) []wire.Message
+
 // MessageListeners defines callback function pointers to invoke with message
 // listeners for a peer. Any listener which is not set to a concrete callback
 // during peer initialization is ignored. Execution of multiple message
@@ -151,6 +159,44 @@ type MessageListeners struct {
 	// OnFeeFilter is invoked when a peer receives a feefilter wire message.
 	OnFeeFilter func(p *Peer, msg *wire.MsgFeeFilter)
 
+	// OnNotice is invoked when a peer receives a notice wire message.
+	OnNotice func(p *Peer, msg *wire.MsgNotice)
+
+	// OnGetCFilter is invoked when a peer receives a getcfilter wire
+	// message.
+	OnGetCFilter func(p *Peer, msg *wire.MsgGetCFilter)
+
+	// OnCFilter is invoked when a peer receives a cfilter wire message.
+	OnCFilter func(p *Peer, msg *wire.MsgCFilter)
+
+	// OnGetCFHeaders is invoked when a peer receives a getcfheaders wire
+	// message.
+	OnGetCFHeaders func(p *Peer, msg *wire.MsgGetCFHeaders)
+
+	// OnCFHeaders is invoked when a peer receives a cfheaders wire
+	// message.
+	OnCFHeaders func(p *Peer, msg *wire.MsgCFHeaders)
+
+	// OnGetCFCheckpt is invoked when a peer receives a getcfcheckpt wire
+	// message.
+	OnGetCFCheckpt func(p *Peer, msg *wire.MsgGetCFCheckpt)
+
+	// OnCFCheckpt is invoked when a peer receives a cfcheckpt wire
+	// message.
+	OnCFCheckpt func(p *Peer, msg *wire.MsgCFCheckpt)
+
+	// OnLightBlock is invoked when a peer receives a lightblock wire
+	// message.
+	OnLightBlock func(p *Peer, msg *wire.MsgLightBlock)
+
+	// OnGetMissedTxs is invoked when a peer receives a getmissedtxs wire
+	// message.
+	OnGetMissedTxs func(p *Peer, msg *wire.MsgGetMissedTxs)
+
+	// OnMissedTxs is invoked when a peer receives a missedtxs wire
+	// message.
+	OnMissedTxs func(p *Peer, msg *wire.MsgMissedTxs)
+
 	// OnFilterAdd is invoked when a peer receives a filteradd wire message.
 	OnFilterAdd func(p *Peer, msg *wire.MsgFilterAdd)
 
@@ -237,6 +283,12 @@ type Config struct {
 	// peer.MaxProtocolVersion will be used.
 	ProtocolVersion uint32
 
+	// MinProtocolVersion specifies the minimum protocol version remote
+	// peers must advertise.  Peers advertising an older version are sent
+	// an obsolete reject message and disconnected.  This field can be
+	// omitted in which case wire.InitialProcotolVersion will be used.
+	MinProtocolVersion uint32
+
 	// DisableRelayTx specifies if the remote peer should be informed to
 	// not send inv messages for transactions.
 	DisableRelayTx bool
@@ -342,11 +394,14 @@ type StatsSnap struct {
 	UserAgent      string
 	Inbound        bool
 	StartingHeight int64
-	LastBlock      int64
-	LastKeyBlock   int64
-	LastPingNonce  uint64
-	LastPingTime   time.Time
-	LastPingMicros int64
+	StartingKeyHeight int64
+	LastBlock         int64
+	LastKeyBlock      int64
+	LastPingNonce     uint64
+	LastPingTime      time.Time
+	LastPingMicros    int64
+	BytesSentPerMsg   map[string]uint64
+	BytesRecvPerMsg   map[string]uint64
 }
 
 // HashFunc is a function which returns a block hash, height and error
@@ -430,12 +485,27 @@ type Peer struct {
 	timeOffset         int64
 	timeConnected      time.Time
 	startingHeight     int64
+	startingKeyHeight  int64
 	lastBlock          int64
 	lastKeyBlock	   int64
 	lastAnnouncedBlock *chainhash.Hash
 	lastPingNonce      uint64    // Set to nonce if we have a pending ping.
 	lastPingTime       time.Time // Time we sent last ping.
 	lastPingMicros     int64     // Time for last ping to return.
+	bytesSentPerMsg    map[string]uint64 // Bytes sent by message command.
+	bytesRecvPerMsg    map[string]uint64 // Bytes received by message command.
+
+	// sendHook and recvHook are only used to allow the tests to delay and
+	// reorder the messages sent to and received from the peer in a
+	// deterministic manner.  They are never set outside of the tests and
+	// only before the connection is associated with the peer.
+	sendHook messageHook
+	recvHook messageHook
+
+	// hookedMsgs houses the received messages released by the receive hook
+	// that are yet to be processed.  It is only accessed by the goroutine
+	// reading messages.
+	hookedMsgs []wire.Message
 
 	stallControl  chan stallControlMsg
 	outputQueue   chan outMsg
@@ -498,6 +568,15 @@ func (p *Peer) AddKnownInventory(invVect *wire.InvVect) {
 	p.knownInventory.Add(invVect)
 }
 
+// copyMsgBytes returns a copy of the passed per message command byte counts.
+func copyMsgBytes(counts map[string]uint64) map[string]uint64 {
+	countsCopy := make(map[string]uint64, len(counts))
+	for command, n := range counts {
+		countsCopy[command] = n
+	}
+	return countsCopy
+}
+
 // StatsSnapshot returns a snapshot of the current peer flags and statistics.
 //
 // This function is safe for concurrent access.
@@ -527,11 +606,14 @@ func (p *Peer) StatsSnapshot() *StatsSnap {
 		Version:        protocolVersion,
 		Inbound:        p.inbound,
 		StartingHeight: p.startingHeight,
-		LastBlock:      p.lastBlock,
-		LastKeyBlock:	p.lastKeyBlock,
-		LastPingNonce:  p.lastPingNonce,
-		LastPingMicros: p.lastPingMicros,
-		LastPingTime:   p.lastPingTime,
+		StartingKeyHeight: p.startingKeyHeight,
+		LastBlock:         p.lastBlock,
+		LastKeyBlock:      p.lastKeyBlock,
+		LastPingNonce:     p.lastPingNonce,
+		LastPingMicros:    p.lastPingMicros,
+		LastPingTime:      p.lastPingTime,
+		BytesSentPerMsg:   copyMsgBytes(p.bytesSentPerMsg),
+		BytesRecvPerMsg:   copyMsgBytes(p.bytesRecvPerMsg),
 	}
 
 	p.statsMtx.RUnlock()
@@ -774,6 +856,35 @@ func (p *Peer) WantsHeaders() bool {
 	return sendHeadersPreferred
 }
 
+// IsKnownInventory returns whether the passed inventory is already known to
+// the peer.
+//
+// This function is safe for concurrent access.
+func (p *Peer) IsKnownInventory(invVect *wire.InvVect) bool {
+	return p.knownInventory.Exists(invVect)
+}
+
+// SupportsLightBlocks returns whether the remote peer understands the
+// lightblock, getmissedtxs and missedtxs messages, which requires it to
+// advertise the SFNodeLightBlock service flag and to have negotiated a
+// protocol version of at least wire.LightBlockVersion.
+//
+// This function is safe for concurrent access.
+func (p *Peer) SupportsLightBlocks() bool {
+	return p.ProtocolVersion() >= wire.LightBlockVersion &&
+		p.Services()&wire.SFNodeLightBlock == wire.SFNodeLightBlock
+}
+
+// isLightBlockMsg returns whether the passed message is one of the messages
+// which may only be sent to peers that support light blocks.
+func isLightBlockMsg(msg wire.Message) bool {
+	switch msg.(type) {
+	case *wire.MsgLightBlock, *wire.MsgGetMissedTxs, *wire.MsgMissedTxs:
+		return true
+	}
+	return false
+}
+
 // localVersionMsg creates a version message that can be used to send to the
 // remote peer.
 func (p *Peer) localVersionMsg() (*wire.MsgVersion, error) {
@@ -1021,15 +1132,22 @@ func (p *Peer) handleRemoteVersionMsg(msg *wire.MsgVersion) error {
 
 	// Notify and disconnect clients that have a protocol version that is
 	// too old.
-	if msg.ProtocolVersion < int32(wire.InitialProcotolVersion) {
+	minProtocolVersion := wire.InitialProcotolVersion
+	if p.cfg.MinProtocolVersion > minProtocolVersion {
+		minProtocolVersion = p.cfg.MinProtocolVersion
+	}
+	if msg.ProtocolVersion < int32(minProtocolVersion) {
 		// Send a reject message indicating the protocol version is
 		// obsolete and wait for the message to be sent before
 		// disconnecting.
 		reason := fmt.Sprintf("protocol version must be %d or greater",
-			wire.InitialProcotolVersion)
+			minProtocolVersion)
 		rejectMsg := wire.NewMsgReject(msg.Command(), wire.RejectObsolete,
 			reason)
-		return p.writeMessage(rejectMsg)
+		if err := p.writeMessage(rejectMsg); err != nil {
+			return err
+		}
+		return errors.New(reason)
 	}
 
 	// Limit to one version message per peer.
@@ -1049,6 +1167,7 @@ func (p *Peer) handleRemoteVersionMsg(msg *wire.MsgVersion) error {
 	p.lastBlock = int64(msg.LastBlock)
 	p.lastKeyBlock = int64(msg.LastKeyBlock)
 	p.startingHeight = int64(msg.LastBlock)
+	p.startingKeyHeight = int64(msg.LastKeyBlock)
 
 	// Set the peer's time offset.
 	p.timeOffset = msg.Timestamp.Unix() - time.Now().Unix()
@@ -1093,18 +1212,55 @@ func (p *Peer) handlePongMsg(msg *wire.MsgPong) {
 	// and overlapping pings will be ignored. It is unlikely to occur
 	// without large usage of the ping rpc call since we ping infrequently
 	// enough that if they overlap we would have timed out the peer.
+	p.statsMtx.Lock()
 	if p.lastPingNonce != 0 && msg.Nonce == p.lastPingNonce {
 		p.lastPingMicros = time.Since(p.lastPingTime).Nanoseconds()
 		p.lastPingMicros /= 1000 // convert to usec.
 		p.lastPingNonce = 0
 	}
+	p.statsMtx.Unlock()
 }
 
 // readMessage reads the next wire message from the peer with logging.
 func (p *Peer) readMessage() (wire.Message, []byte, error) {
+	if p.recvHook == nil && len(p.hookedMsgs) == 0 {
+		return p.readMessageNow()
+	}
+
+	for len(p.hookedMsgs) == 0 {
+		msg, buf, err := p.readMessageNow()
+		if err != nil || p.recvHook == nil {
+			return msg, buf, err
+		}
+		released := p.recvHook(p, msg)
+		if len(released) > 0 && released[0] == msg {
+			p.hookedMsgs = append(p.hookedMsgs, released[1:]...)
+			return msg, buf, nil
+		}
+		p.hookedMsgs = append(p.hookedMsgs, released...)
+	}
+
+	// Messages that were held back by the receive hook no longer have their
+	// raw payload, so serialize them again.
+	msg := p.hookedMsgs[0]
+	p.hookedMsgs = p.hookedMsgs[1:]
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, p.ProtocolVersion()); err != nil {
+		return nil, nil, err
+	}
+	return msg, buf.Bytes(), nil
+}
+
+// readMessageNow reads the next wire message from the peer with logging.
+func (p *Peer) readMessageNow() (wire.Message, []byte, error) {
 	n, msg, buf, err := wire.ReadMessageN(p.conn, p.ProtocolVersion(),
 		p.cfg.ChainParams.Net)
 	atomic.AddUint64(&p.bytesReceived, uint64(n))
+	if msg != nil {
+		p.statsMtx.Lock()
+		p.bytesRecvPerMsg[msg.Command()] += uint64(n)
+		p.statsMtx.Unlock()
+	}
 	if p.cfg.Listeners.OnRead != nil {
 		p.cfg.Listeners.OnRead(p, n, msg, err)
 	}
@@ -1135,6 +1291,21 @@ func (p *Peer) readMessage() (wire.Message, []byte, error) {
 
 // writeMessage sends a wire message to the peer with logging.
 func (p *Peer) writeMessage(msg wire.Message) error {
+	if p.sendHook == nil {
+		return p.writeMessageNow(msg)
+	}
+
+	for _, m := range p.sendHook(p, msg) {
+		if err := p.writeMessageNow(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMessageNow sends a wire message to the peer with logging, bypassing the
+// send hook.
+func (p *Peer) writeMessageNow(msg wire.Message) error {
 	// Don't do anything if we're disconnecting.
 	if atomic.LoadInt32(&p.disconnect) != 0 {
 		return nil
@@ -1168,6 +1339,9 @@ func (p *Peer) writeMessage(msg wire.Message) error {
 	n, err := wire.WriteMessageN(p.conn, msg, p.ProtocolVersion(),
 		p.cfg.ChainParams.Net)
 	atomic.AddUint64(&p.bytesSent, uint64(n))
+	p.statsMtx.Lock()
+	p.bytesSentPerMsg[msg.Command()] += uint64(n)
+	p.statsMtx.Unlock()
 	if p.cfg.Listeners.OnWrite != nil {
 		p.cfg.Listeners.OnWrite(p, n, msg, err)
 	}
@@ -1558,6 +1732,56 @@ out:
 				p.cfg.Listeners.OnFeeFilter(p, msg)
 			}
 
+		case *wire.MsgNotice:
+			if p.cfg.Listeners.OnNotice != nil {
+				p.cfg.Listeners.OnNotice(p, msg)
+			}
+
+		case *wire.MsgGetCFilter:
+			if p.cfg.Listeners.OnGetCFilter != nil {
+				p.cfg.Listeners.OnGetCFilter(p, msg)
+			}
+
+		case *wire.MsgCFilter:
+			if p.cfg.Listeners.OnCFilter != nil {
+				p.cfg.Listeners.OnCFilter(p, msg)
+			}
+
+		case *wire.MsgGetCFHeaders:
+			if p.cfg.Listeners.OnGetCFHeaders != nil {
+				p.cfg.Listeners.OnGetCFHeaders(p, msg)
+			}
+
+		case *wire.MsgCFHeaders:
+			if p.cfg.Listeners.OnCFHeaders != nil {
+				p.cfg.Listeners.OnCFHeaders(p, msg)
+			}
+
+		case *wire.MsgGetCFCheckpt:
+			if p.cfg.Listeners.OnGetCFCheckpt != nil {
+				p.cfg.Listeners.OnGetCFCheckpt(p, msg)
+			}
+
+		case *wire.MsgCFCheckpt:
+			if p.cfg.Listeners.OnCFCheckpt != nil {
+				p.cfg.Listeners.OnCFCheckpt(p, msg)
+			}
+
+		case *wire.MsgLightBlock:
+			if p.cfg.Listeners.OnLightBlock != nil {
+				p.cfg.Listeners.OnLightBlock(p, msg)
+			}
+
+		case *wire.MsgGetMissedTxs:
+			if p.cfg.Listeners.OnGetMissedTxs != nil {
+				p.cfg.Listeners.OnGetMissedTxs(p, msg)
+			}
+
+		case *wire.MsgMissedTxs:
+			if p.cfg.Listeners.OnMissedTxs != nil {
+				p.cfg.Listeners.OnMissedTxs(p, msg)
+			}
+
 		case *wire.MsgFilterAdd:
 			if p.cfg.Listeners.OnFilterAdd != nil {
 				p.cfg.Listeners.OnFilterAdd(p, msg)
@@ -1870,14 +2094,24 @@ cleanup:
 	log.Tracef("Peer output handler done for %s", p)
 }
 
-// QueueMessage adds the passed wire message to the peer send queue.
+// QueueMessage adds the passed wire message to the peer send queue.  The light
+// block messages are dropped when the remote peer does not support them since
+// it would be unable to decode them.  See SupportsLightBlocks.
 //
 // This function is safe for concurrent access.
 func (p *Peer) QueueMessage(msg wire.Message, doneChan chan<- struct{}) {
+	// Never send the light block messages to peers which did not
+	// negotiate support for them.
+	dropMsg := isLightBlockMsg(msg) && !p.SupportsLightBlocks()
+	if dropMsg {
+		log.Debugf("Not sending %s message to %s which does not support "+
+			"light blocks", msg.Command(), p)
+	}
+
 	// Avoid risk of deadlock if goroutine already exited.  The goroutine
 	// we will be sending to hangs around until it knows for a fact that
 	// it is marked as disconnected and *then* it drains the channels.
-	if !p.Connected() {
+	if dropMsg || !p.Connected() {
 		if doneChan != nil {
 			go func() {
 				doneChan <- struct{}{}
@@ -2111,6 +2345,8 @@ func newPeerBase(cfg *Config, inbound bool) *Peer {
 		cfg:             *cfg, // Copy so caller can't mutate.
 		services:        cfg.Services,
 		protocolVersion: protocolVersion,
+		bytesSentPerMsg: make(map[string]uint64),
+		bytesRecvPerMsg: make(map[string]uint64),
 	}
 	return &p
 }
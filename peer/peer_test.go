@@ -10,6 +10,7 @@ import (
 	"errors"
 	"io"
 	"net"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -91,20 +92,23 @@ func pipe(c1, c2 *conn) (*conn, *conn) {
 
 // peerStats holds the expected peer stats used for testing peer.
 type peerStats struct {
-	wantUserAgent       string
-	wantServices        wire.ServiceFlag
-	wantProtocolVersion uint32
-	wantConnected       bool
-	wantVersionKnown    bool
-	wantVerAckReceived  bool
-	wantLastBlock       int64
-	wantStartingHeight  int64
-	wantLastPingTime    time.Time
-	wantLastPingNonce   uint64
-	wantLastPingMicros  int64
-	wantTimeOffset      int64
-	wantBytesSent       uint64
-	wantBytesReceived   uint64
+	wantUserAgent         string
+	wantServices          wire.ServiceFlag
+	wantProtocolVersion   uint32
+	wantConnected         bool
+	wantVersionKnown      bool
+	wantVerAckReceived    bool
+	wantLastBlock         int64
+	wantStartingHeight    int64
+	wantStartingKeyHeight int64
+	wantLastPingTime      time.Time
+	wantLastPingNonce     uint64
+	wantLastPingMicros    int64
+	wantTimeOffset        int64
+	wantBytesSent         uint64
+	wantBytesReceived     uint64
+	wantBytesSentPerMsg   map[string]uint64
+	wantBytesRecvPerMsg   map[string]uint64
 }
 
 // testPeer tests the given peer's flags and stats
@@ -203,6 +207,21 @@ func testPeer(t *testing.T, p *peer.Peer, s peerStats) {
 		t.Errorf("testPeer: wrong LastRecv - got %v, want %v", p.LastRecv(), stats.LastRecv)
 		return
 	}
+
+	if stats.StartingKeyHeight != s.wantStartingKeyHeight {
+		t.Errorf("testPeer: wrong StartingKeyHeight - got %v, want %v", stats.StartingKeyHeight, s.wantStartingKeyHeight)
+		return
+	}
+
+	if !reflect.DeepEqual(stats.BytesSentPerMsg, s.wantBytesSentPerMsg) {
+		t.Errorf("testPeer: wrong BytesSentPerMsg - got %v, want %v", stats.BytesSentPerMsg, s.wantBytesSentPerMsg)
+		return
+	}
+
+	if !reflect.DeepEqual(stats.BytesRecvPerMsg, s.wantBytesRecvPerMsg) {
+		t.Errorf("testPeer: wrong BytesRecvPerMsg - got %v, want %v", stats.BytesRecvPerMsg, s.wantBytesRecvPerMsg)
+		return
+	}
 }
 
 // TestPeerConnection tests connection between inbound and outbound peers.
@@ -220,24 +239,32 @@ func TestPeerConnection(t *testing.T) {
 				}
 			},
 		},
+		NewestBlock: func() (*chainhash.Hash, int64, int64, error) {
+			return &chainhash.Hash{}, 234439, 12345, nil
+		},
 		UserAgentName:    "peer",
 		UserAgentVersion: "1.0",
 		ChainParams:      &chaincfg.MainNetParams,
 		Services:         0,
 	}
 	wantStats := peerStats{
-		wantUserAgent:       wire.DefaultUserAgent + "peer:1.0/",
-		wantServices:        0,
-		wantProtocolVersion: peer.MaxProtocolVersion,
-		wantConnected:       true,
-		wantVersionKnown:    true,
-		wantVerAckReceived:  true,
-		wantLastPingTime:    time.Time{},
-		wantLastPingNonce:   uint64(0),
-		wantLastPingMicros:  int64(0),
-		wantTimeOffset:      int64(0),
-		wantBytesSent:       164, // 140 version + 24 verack
-		wantBytesReceived:   164,
+		wantUserAgent:         wire.DefaultUserAgent + "peer:1.0/",
+		wantServices:          0,
+		wantProtocolVersion:   peer.MaxProtocolVersion,
+		wantConnected:         true,
+		wantVersionKnown:      true,
+		wantVerAckReceived:    true,
+		wantLastPingTime:      time.Time{},
+		wantLastPingNonce:     uint64(0),
+		wantLastPingMicros:    int64(0),
+		wantTimeOffset:        int64(0),
+		wantLastBlock:         234439,
+		wantStartingHeight:    234439,
+		wantStartingKeyHeight: 12345,
+		wantBytesSent:         164, // 140 version + 24 verack
+		wantBytesReceived:     164,
+		wantBytesSentPerMsg:   map[string]uint64{"version": 140, "verack": 24},
+		wantBytesRecvPerMsg:   map[string]uint64{"version": 140, "verack": 24},
 	}
 	tests := []struct {
 		name  string
@@ -364,6 +391,36 @@ func TestPeerListeners(t *testing.T) {
 			OnFeeFilter: func(p *peer.Peer, msg *wire.MsgFeeFilter) {
 				ok <- msg
 			},
+			OnNotice: func(p *peer.Peer, msg *wire.MsgNotice) {
+				ok <- msg
+			},
+			OnGetCFilter: func(p *peer.Peer, msg *wire.MsgGetCFilter) {
+				ok <- msg
+			},
+			OnCFilter: func(p *peer.Peer, msg *wire.MsgCFilter) {
+				ok <- msg
+			},
+			OnGetCFHeaders: func(p *peer.Peer, msg *wire.MsgGetCFHeaders) {
+				ok <- msg
+			},
+			OnCFHeaders: func(p *peer.Peer, msg *wire.MsgCFHeaders) {
+				ok <- msg
+			},
+			OnGetCFCheckpt: func(p *peer.Peer, msg *wire.MsgGetCFCheckpt) {
+				ok <- msg
+			},
+			OnCFCheckpt: func(p *peer.Peer, msg *wire.MsgCFCheckpt) {
+				ok <- msg
+			},
+			OnLightBlock: func(p *peer.Peer, msg *wire.MsgLightBlock) {
+				ok <- msg
+			},
+			OnGetMissedTxs: func(p *peer.Peer, msg *wire.MsgGetMissedTxs) {
+				ok <- msg
+			},
+			OnMissedTxs: func(p *peer.Peer, msg *wire.MsgMissedTxs) {
+				ok <- msg
+			},
 			OnFilterAdd: func(p *peer.Peer, msg *wire.MsgFilterAdd) {
 				ok <- msg
 			},
@@ -392,7 +449,7 @@ func TestPeerListeners(t *testing.T) {
 		UserAgentName:    "peer",
 		UserAgentVersion: "1.0",
 		ChainParams:      &chaincfg.MainNetParams,
-		Services:         wire.SFNodeBloom,
+		Services:         wire.SFNodeBloom | wire.SFNodeLightBlock,
 	}
 	inConn, outConn := pipe(
 		&conn{raddr: "10.0.0.1:8333"},
@@ -489,6 +546,54 @@ func TestPeerListeners(t *testing.T) {
 			"OnFeeFilter",
 			wire.NewMsgFeeFilter(15000),
 		},
+		{
+			"OnNotice",
+			wire.NewMsgNotice(1, 0, "notice"),
+		},
+		{
+			"OnGetCFilter",
+			wire.NewMsgGetCFilter(&chainhash.Hash{},
+				wire.GCSFilterRegular),
+		},
+		{
+			"OnCFilter",
+			wire.NewMsgCFilter(&chainhash.Hash{}, wire.GCSFilterRegular,
+				[]byte("payload")),
+		},
+		{
+			"OnGetCFHeaders",
+			wire.NewMsgGetCFHeaders(),
+		},
+		{
+			"OnCFHeaders",
+			wire.NewMsgCFHeaders(),
+		},
+		{
+			"OnGetCFCheckpt",
+			wire.NewMsgGetCFCheckpt(wire.GCSFilterRegular,
+				&chainhash.Hash{}),
+		},
+		{
+			"OnCFCheckpt",
+			wire.NewMsgCFCheckpt(wire.GCSFilterRegular,
+				&chainhash.Hash{}, 0),
+		},
+		{
+			"OnLightBlock",
+			wire.NewMsgLightBlock(wire.NewBlockHeader(0,
+				&chainhash.Hash{}, &chainhash.Hash{}, &chainhash.Hash{},
+				&chainhash.Hash{}, 1, [6]byte{},
+				1, 1, 1, 1, 1, 1, 1, 1, 1, 1, [32]byte{},
+				binary.LittleEndian.Uint32([]byte{0xb0, 0x1d, 0xfa, 0xce}))),
+		},
+		{
+			"OnGetMissedTxs",
+			wire.NewMsgGetMissedTxs(&chainhash.Hash{}),
+		},
+		{
+			"OnMissedTxs",
+			wire.NewMsgMissedTxs(&chainhash.Hash{}),
+		},
 		{
 			"OnFilterAdd",
 			wire.NewMsgFilterAdd([]byte{0x01}),
@@ -678,7 +783,210 @@ func TestOutboundPeer(t *testing.T) {
 	p2.Disconnect()
 }
 
+// TestMessageHooks ensures the message hooks can deterministically reorder the
+// messages sent to and received from a peer.
+func TestMessageHooks(t *testing.T) {
+	// reorderPings returns a hook that holds back the ping with the first
+	// nonce until the ping with the second nonce passes through and then
+	// releases both in reverse order.
+	reorderPings := func(first, second uint64) peer.TstMessageHook {
+		var held wire.Message
+		return func(p *peer.Peer, msg wire.Message) []wire.Message {
+			ping, ok := msg.(*wire.MsgPing)
+			if !ok {
+				return []wire.Message{msg}
+			}
+			switch ping.Nonce {
+			case first:
+				held = msg
+				return nil
+			case second:
+				return []wire.Message{msg, held}
+			}
+			return []wire.Message{msg}
+		}
+	}
+
+	verack := make(chan struct{}, 2)
+	pings := make(chan uint64, 4)
+	peerCfg := &peer.Config{
+		Listeners: peer.MessageListeners{
+			OnPing: func(p *peer.Peer, msg *wire.MsgPing) {
+				pings <- msg.Nonce
+			},
+			OnVerAck: func(p *peer.Peer, msg *wire.MsgVerAck) {
+				verack <- struct{}{}
+			},
+		},
+		UserAgentName:    "peer",
+		UserAgentVersion: "1.0",
+		ChainParams:      &chaincfg.MainNetParams,
+		Services:         0,
+	}
+	inConn, outConn := pipe(
+		&conn{raddr: "10.0.0.1:8333"},
+		&conn{raddr: "10.0.0.2:8333"},
+	)
+	inPeer := peer.NewInboundPeer(peerCfg)
+	peer.TstSetMessageHooks(inPeer, nil, reorderPings(1, 2))
+	inPeer.AssociateConnection(inConn)
+	outPeer, err := peer.NewOutboundPeer(peerCfg, "10.0.0.1:8333")
+	if err != nil {
+		t.Fatalf("NewOutboundPeer: unexpected err %v\n", err)
+	}
+	peer.TstSetMessageHooks(outPeer, reorderPings(3, 4), nil)
+	outPeer.AssociateConnection(outConn)
+	defer func() {
+		inPeer.Disconnect()
+		outPeer.Disconnect()
+		inPeer.WaitForDisconnect()
+		outPeer.WaitForDisconnect()
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-verack:
+		case <-time.After(time.Second * 1):
+			t.Fatal("TestMessageHooks: verack timeout")
+		}
+	}
+
+	// The outbound peer sends the pings in order, the send hook swaps the
+	// last two and the receive hook of the inbound peer swaps the first
+	// two.
+	for nonce := uint64(1); nonce <= 4; nonce++ {
+		outPeer.QueueMessage(wire.NewMsgPing(nonce), nil)
+	}
+	want := []uint64{2, 1, 4, 3}
+	for i, wantNonce := range want {
+		select {
+		case nonce := <-pings:
+			if nonce != wantNonce {
+				t.Fatalf("ping #%d: got nonce %d, want %d", i,
+					nonce, wantNonce)
+			}
+		case <-time.After(time.Second * 1):
+			t.Fatalf("ping #%d: timeout", i)
+		}
+	}
+}
+
 func init() {
 	// Allow self connection when running the tests.
 	peer.TstAllowSelfConns()
 }
+
+// TestLightBlockNegotiation ensures the light block messages are only sent to
+// peers which advertise support for them.
+func TestLightBlockNegotiation(t *testing.T) {
+	verack := make(chan struct{}, 2)
+	received := make(chan wire.Message, 2)
+	peerCfg := &peer.Config{
+		Listeners: peer.MessageListeners{
+			OnGetMissedTxs: func(p *peer.Peer, msg *wire.MsgGetMissedTxs) {
+				received <- msg
+			},
+			OnPing: func(p *peer.Peer, msg *wire.MsgPing) {
+				received <- msg
+			},
+			OnVerAck: func(p *peer.Peer, msg *wire.MsgVerAck) {
+				verack <- struct{}{}
+			},
+		},
+		UserAgentName:    "peer",
+		UserAgentVersion: "1.0",
+		ChainParams:      &chaincfg.MainNetParams,
+		Services:         0,
+	}
+	inConn, outConn := pipe(
+		&conn{raddr: "10.0.0.1:8333"},
+		&conn{raddr: "10.0.0.2:8333"},
+	)
+	inPeer := peer.NewInboundPeer(peerCfg)
+	inPeer.AssociateConnection(inConn)
+	outPeer, err := peer.NewOutboundPeer(peerCfg, "10.0.0.1:8333")
+	if err != nil {
+		t.Fatalf("NewOutboundPeer: unexpected err %v\n", err)
+	}
+	outPeer.AssociateConnection(outConn)
+	defer inPeer.Disconnect()
+	defer outPeer.Disconnect()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-verack:
+		case <-time.After(time.Second * 1):
+			t.Fatal("TestLightBlockNegotiation: verack timeout")
+		}
+	}
+
+	if outPeer.SupportsLightBlocks() {
+		t.Fatal("TestLightBlockNegotiation: peer without the light " +
+			"block service flag reported as supporting light blocks")
+	}
+
+	// The getmissedtxs message must be dropped while the done channel is
+	// still notified, so only the ping is received.
+	done := make(chan struct{}, 1)
+	outPeer.QueueMessage(wire.NewMsgGetMissedTxs(&chainhash.Hash{}), done)
+	select {
+	case <-done:
+	case <-time.After(time.Second * 1):
+		t.Fatal("TestLightBlockNegotiation: done channel not notified")
+	}
+	outPeer.QueueMessage(wire.NewMsgPing(1), nil)
+	select {
+	case msg := <-received:
+		if _, ok := msg.(*wire.MsgPing); !ok {
+			t.Fatalf("TestLightBlockNegotiation: unexpected %s "+
+				"message received", msg.Command())
+		}
+	case <-time.After(time.Second * 1):
+		t.Fatal("TestLightBlockNegotiation: ping timeout")
+	}
+}
+
+// TestMinProtocolVersion ensures peers advertising a protocol version below the
+// configured minimum are disconnected during the version negotiation.
+func TestMinProtocolVersion(t *testing.T) {
+	inPeerCfg := &peer.Config{
+		UserAgentName:      "peer",
+		UserAgentVersion:   "1.0",
+		ChainParams:        &chaincfg.MainNetParams,
+		MinProtocolVersion: wire.LightBlockVersion,
+	}
+	outPeerCfg := &peer.Config{
+		UserAgentName:    "peer",
+		UserAgentVersion: "1.0",
+		ChainParams:      &chaincfg.MainNetParams,
+		ProtocolVersion:  wire.NodeCFVersion,
+	}
+	inConn, outConn := pipe(
+		&conn{raddr: "10.0.0.1:8333"},
+		&conn{raddr: "10.0.0.2:8333"},
+	)
+	inPeer := peer.NewInboundPeer(inPeerCfg)
+	inPeer.AssociateConnection(inConn)
+	outPeer, err := peer.NewOutboundPeer(outPeerCfg, "10.0.0.1:8333")
+	if err != nil {
+		t.Fatalf("NewOutboundPeer: unexpected err %v\n", err)
+	}
+	outPeer.AssociateConnection(outConn)
+	defer outPeer.Disconnect()
+
+	disconnected := make(chan struct{})
+	go func() {
+		inPeer.WaitForDisconnect()
+		close(disconnected)
+	}()
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second * 1):
+		t.Fatal("TestMinProtocolVersion: peer below the minimum " +
+			"protocol version not disconnected")
+	}
+	if inPeer.VersionKnown() {
+		t.Fatal("TestMinProtocolVersion: version of peer below the " +
+			"minimum protocol version accepted")
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// reloadableOptions houses the long names of the options which take effect
+// when the configuration is reloaded via the reloadconfig RPC or a SIGHUP.
+// Changes to any other option only take effect once hcashd is restarted.
+var reloadableOptions = map[string]struct{}{
+	"allowoldvotes":    {},
+	"banduration":      {},
+	"banthreshold":     {},
+	"debuglevel":       {},
+	"limitfreerelay":   {},
+	"maxmempoolsize":   {},
+	"maxorphantx":      {},
+	"maxpeers":         {},
+	"minrelaytxfee":    {},
+	"nobanning":        {},
+	"norelaypriority":  {},
+	"rejectnonstd":     {},
+	"relaynonstd":      {},
+	"rpcmaxclients":    {},
+	"rpcmaxsseclients": {},
+	"rpcmaxwebsockets": {},
+	"whitelist":        {},
+}
+
+// banSettings houses the settings which control the banning of misbehaving
+// peers.  They are replaced as a whole when the configuration is reloaded, so
+// callers always see a consistent set of settings.
+type banSettings struct {
+	disableBanning bool
+	banDuration    time.Duration
+	banThreshold   uint32
+	whitelists     []*net.IPNet
+}
+
+// newBanSettings returns the ban settings of the passed configuration.
+func newBanSettings(cfg *config) *banSettings {
+	return &banSettings{
+		disableBanning: cfg.DisableBanning,
+		banDuration:    cfg.BanDuration,
+		banThreshold:   cfg.BanThreshold,
+		whitelists:     cfg.whitelists,
+	}
+}
+
+// activeBanSettings houses the ban settings of the last reloaded
+// configuration.  It is empty until the configuration is reloaded.
+var activeBanSettings atomic.Value
+
+// currentBanSettings returns the ban settings which are currently in effect.
+//
+// This function is safe for concurrent access.
+func currentBanSettings() *banSettings {
+	if settings, ok := activeBanSettings.Load().(*banSettings); ok {
+		return settings
+	}
+	return newBanSettings(cfg)
+}
+
+// connLimits houses the limits on the number of connections to the server.
+// Like the ban settings, they are replaced as a whole when the configuration is
+// reloaded.
+type connLimits struct {
+	maxPeers         int
+	rpcMaxClients    int
+	rpcMaxWebsockets int
+	rpcMaxSSEClients int
+}
+
+// newConnLimits returns the connection limits of the passed configuration.
+func newConnLimits(cfg *config) *connLimits {
+	return &connLimits{
+		maxPeers:         cfg.MaxPeers,
+		rpcMaxClients:    cfg.RPCMaxClients,
+		rpcMaxWebsockets: cfg.RPCMaxWebsockets,
+		rpcMaxSSEClients: cfg.RPCMaxSSEClients,
+	}
+}
+
+// activeConnLimits houses the connection limits of the last reloaded
+// configuration.  It is empty until the configuration is reloaded.
+var activeConnLimits atomic.Value
+
+// currentConnLimits returns the connection limits which are currently in
+// effect.
+//
+// This function is safe for concurrent access.
+func currentConnLimits() *connLimits {
+	if limits, ok := activeConnLimits.Load().(*connLimits); ok {
+		return limits
+	}
+	return newConnLimits(cfg)
+}
+
+var (
+	// reloadMtx serializes reloads of the configuration.
+	reloadMtx sync.Mutex
+
+	// runningCfg is the configuration loaded on startup with the reloadable
+	// options of the last reloaded configuration applied.  It is nil until
+	// the configuration is reloaded and is protected by reloadMtx.
+	runningCfg *config
+)
+
+// changedOptions returns the long names of the options which differ between
+// the passed configurations in sorted order.
+func changedOptions(oldCfg, newCfg *config) []string {
+	oldVal := reflect.ValueOf(&oldCfg.Config).Elem()
+	newVal := reflect.ValueOf(&newCfg.Config).Elem()
+	var changed []string
+	for i := 0; i < oldVal.NumField(); i++ {
+		name := oldVal.Type().Field(i).Tag.Get("long")
+		if name == "" {
+			continue
+		}
+		if !reflect.DeepEqual(oldVal.Field(i).Interface(),
+			newVal.Field(i).Interface()) {
+
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// applyReloadableOptions sets the reloadable options of the destination
+// configuration, along with the settings derived from them, to the ones of the
+// source configuration.
+func applyReloadableOptions(dst, src *config) {
+	dstVal := reflect.ValueOf(&dst.Config).Elem()
+	srcVal := reflect.ValueOf(&src.Config).Elem()
+	for i := 0; i < dstVal.NumField(); i++ {
+		name := dstVal.Type().Field(i).Tag.Get("long")
+		if _, ok := reloadableOptions[name]; ok {
+			dstVal.Field(i).Set(srcVal.Field(i))
+		}
+	}
+	dst.minRelayTxFee = src.minRelayTxFee
+	dst.whitelists = src.whitelists
+}
+
+// reloadConfig loads the configuration file and command line options again and
+// applies the reloadable options to the running server.  It returns the long
+// names of the changed options which took effect and the long names of the
+// changed options which require a restart.  Nothing is applied when the
+// configuration is invalid.
+//
+// This function is safe for concurrent access.
+func (s *server) reloadConfig() (applied, ignored []string, err error) {
+	reloadMtx.Lock()
+	defer reloadMtx.Unlock()
+
+	if runningCfg == nil {
+		startupCfg := *cfg
+		runningCfg = &startupCfg
+	}
+
+	// Loading the configuration selects the active network and sets the
+	// debug levels as a side effect.  The network can not change while
+	// running, and the debug levels are restored when the configuration
+	// is invalid.
+	netParams := activeNetParams
+	newCfg, _, err := loadConfig()
+	activeNetParams = netParams
+	if err != nil {
+		parseAndSetDebugLevels(runningCfg.DebugLevel)
+		return nil, nil, err
+	}
+
+	for _, name := range changedOptions(runningCfg, newCfg) {
+		if _, ok := reloadableOptions[name]; ok {
+			applied = append(applied, name)
+		} else {
+			ignored = append(ignored, name)
+		}
+	}
+	applyReloadableOptions(runningCfg, newCfg)
+	activeBanSettings.Store(newBanSettings(runningCfg))
+	activeConnLimits.Store(newConnLimits(runningCfg))
+	s.txMemPool.SetPolicy(mempoolPolicy(runningCfg))
+
+	if len(applied) > 0 {
+		srvrLog.Infof("Reloaded configuration options: %s",
+			strings.Join(applied, ", "))
+	}
+	if len(ignored) > 0 {
+		srvrLog.Warnf("Changed configuration options which require a "+
+			"restart: %s", strings.Join(ignored, ", "))
+	}
+	return applied, ignored, nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/sampleconfig"
+)
+
+// TestApplyReloadableOptions ensures all changed options are reported, while
+// only the reloadable ones, along with the settings derived from them, are
+// applied to the running configuration.
+func TestApplyReloadableOptions(t *testing.T) {
+	running := &config{Config: *sampleconfig.DefaultConfig()}
+	running.MaxPeers = 125
+	running.DataDir = "data"
+	reloaded := &config{Config: running.Config}
+	reloaded.MaxPeers = 8
+	reloaded.RPCMaxWebsockets = 3
+	reloaded.DataDir = "otherdata"
+	_, ipNet, err := net.ParseCIDR("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("ParseCIDR: %v", err)
+	}
+	reloaded.Whitelists = []string{ipNet.String()}
+	reloaded.whitelists = []*net.IPNet{ipNet}
+
+	changed := changedOptions(running, reloaded)
+	want := []string{"datadir", "maxpeers", "rpcmaxwebsockets", "whitelist"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Fatalf("changedOptions: got %v, want %v", changed, want)
+	}
+
+	applyReloadableOptions(running, reloaded)
+	if running.DataDir != "data" {
+		t.Fatalf("applyReloadableOptions: datadir changed to %q",
+			running.DataDir)
+	}
+	if changed := changedOptions(running, reloaded); !reflect.DeepEqual(
+		changed, []string{"datadir"}) {
+
+		t.Fatalf("applyReloadableOptions: options %v still differ, "+
+			"want only datadir", changed)
+	}
+	if !reflect.DeepEqual(running.whitelists, reloaded.whitelists) {
+		t.Fatalf("applyReloadableOptions: got whitelists %v, want %v",
+			running.whitelists, reloaded.whitelists)
+	}
+
+	limits := newConnLimits(running)
+	if limits.maxPeers != 8 || limits.rpcMaxWebsockets != 3 {
+		t.Fatalf("newConnLimits: got %+v, want the reloaded limits",
+			limits)
+	}
+	if settings := newBanSettings(running); len(settings.whitelists) != 1 {
+		t.Fatalf("newBanSettings: got whitelists %v, want %v",
+			settings.whitelists, reloaded.whitelists)
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync/atomic"
+)
+
+var (
+	// restartFlag is set to 1 once a restart was requested via the restart
+	// RPC.  The new instance is started after the running one is shut down.
+	restartFlag int32
+
+	// runningAsService is set when hcashd is running as a Windows service,
+	// which has to be restarted by the service control manager instead.
+	runningAsService bool
+)
+
+// requestRestart flags that a new instance of hcashd is to be started once the
+// running instance is shut down.  The caller is responsible for initiating the
+// shutdown.
+func requestRestart() {
+	atomic.StoreInt32(&restartFlag, 1)
+}
+
+// restartRequested returns whether a restart was requested via requestRestart.
+func restartRequested() bool {
+	return atomic.LoadInt32(&restartFlag) == 1
+}
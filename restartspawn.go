@@ -0,0 +1,30 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// restartProcess starts a new instance of hcashd with the same command line
+// arguments and environment as the process, which is expected to exit once it
+// returns.  Platforms which do not support replacing the process image spawn a
+// child process instead, which inherits the standard streams.
+func restartProcess() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = os.Environ()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Start()
+}
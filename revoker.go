@@ -0,0 +1,417 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/mempool"
+	"github.com/HcashOrg/hcashd/txscript"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+const (
+	// revocationScriptsFilename is the name of the file in the data
+	// directory the registered revocation scripts are persisted to.
+	revocationScriptsFilename = "revocationscripts.json"
+
+	// maxRevocationScripts is the maximum number of revocation scripts
+	// that may be registered.
+	maxRevocationScripts = 1000
+)
+
+// revocationScript houses a pay-to-script-hash redeem script registered with
+// the ticket revoker along with the push-only signature script prefix that
+// satisfies it without any private keys.
+type revocationScript struct {
+	redeemScript    []byte
+	sigScriptPrefix []byte
+}
+
+// serializedRevocationScript is the format revocation scripts are persisted
+// in.
+type serializedRevocationScript struct {
+	RedeemScript    string `json:"redeemscript"`
+	SigScriptPrefix string `json:"sigscriptprefix"`
+}
+
+// ticketRevoker automatically creates and broadcasts revocations for missed
+// and expired tickets whose voting rights are held by a registered
+// pay-to-script-hash redeem script.  Since the outputs of a revocation are
+// fixed by consensus to the commitments of the ticket, it is safe for any
+// node to revoke a ticket whose redeem script can be satisfied without a
+// signature, such as a script that only requires signatures to vote.  This
+// keeps the funds of users without an always-on wallet from being locked
+// forever.
+type ticketRevoker struct {
+	server   *server
+	filePath string
+
+	mtx     sync.Mutex
+	scripts map[[20]byte]*revocationScript
+
+	// revoked houses the missed tickets a revocation was already
+	// submitted for, or that can not be revoked, so they are only
+	// processed once.
+	revoked map[chainhash.Hash]struct{}
+
+	notify chan struct{}
+	wg     sync.WaitGroup
+	quit   chan struct{}
+}
+
+// newTicketRevoker returns a new ticket revoker for the passed server with the
+// revocation scripts persisted in the data directory loaded.
+func newTicketRevoker(s *server) (*ticketRevoker, error) {
+	r := &ticketRevoker{
+		server:   s,
+		filePath: filepath.Join(cfg.DataDir, revocationScriptsFilename),
+		scripts:  make(map[[20]byte]*revocationScript),
+		revoked:  make(map[chainhash.Hash]struct{}),
+		notify:   make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+	}
+	if err := r.loadScripts(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// loadScripts loads the revocation scripts persisted in the data directory.
+func (r *ticketRevoker) loadScripts() error {
+	f, err := os.Open(r.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var serialized []serializedRevocationScript
+	if err := json.NewDecoder(f).Decode(&serialized); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", r.filePath, err)
+	}
+	for _, ss := range serialized {
+		redeemScript, err := hex.DecodeString(ss.RedeemScript)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %v", r.filePath,
+				err)
+		}
+		sigScriptPrefix, err := hex.DecodeString(ss.SigScriptPrefix)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %v", r.filePath,
+				err)
+		}
+		r.scripts[revocationScriptKey(redeemScript)] = &revocationScript{
+			redeemScript:    redeemScript,
+			sigScriptPrefix: sigScriptPrefix,
+		}
+	}
+	revkLog.Infof("Loaded %d revocation scripts", len(r.scripts))
+	return nil
+}
+
+// saveScripts persists the registered revocation scripts to the data
+// directory.
+//
+// This function MUST be called with the revoker lock held.
+func (r *ticketRevoker) saveScripts() error {
+	serialized := make([]serializedRevocationScript, 0, len(r.scripts))
+	for _, script := range r.scripts {
+		serialized = append(serialized, serializedRevocationScript{
+			RedeemScript:    hex.EncodeToString(script.redeemScript),
+			SigScriptPrefix: hex.EncodeToString(script.sigScriptPrefix),
+		})
+	}
+
+	tmpPath := r.filePath + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(serialized); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, r.filePath)
+}
+
+// revocationScriptKey returns the key the passed redeem script is registered
+// under, which is the hash committed to by pay-to-script-hash outputs.
+func revocationScriptKey(redeemScript []byte) [20]byte {
+	var key [20]byte
+	copy(key[:], hcashutil.Hash160(redeemScript))
+	return key
+}
+
+// AddScript registers the passed redeem script so the tickets whose voting
+// rights are held by it are revoked once they are missed or expire.  The
+// signature script prefix is placed before the redeem script in the signature
+// scripts of the revocations and must be push only.  It returns the
+// pay-to-script-hash address of the script.
+//
+// This function is safe for concurrent access.
+func (r *ticketRevoker) AddScript(redeemScript, sigScriptPrefix []byte) (hcashutil.Address, error) {
+	if len(redeemScript) == 0 {
+		return nil, errors.New("empty redeem script")
+	}
+	if len(redeemScript) > txscript.MaxScriptElementSize {
+		return nil, fmt.Errorf("redeem script is %d bytes, which is more "+
+			"than the maximum of %d bytes", len(redeemScript),
+			txscript.MaxScriptElementSize)
+	}
+	if !txscript.IsPushOnlyScript(sigScriptPrefix) {
+		return nil, errors.New("signature script prefix is not push only")
+	}
+	addr, err := hcashutil.NewAddressScriptHash(redeemScript,
+		r.server.chainParams)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mtx.Lock()
+	key := revocationScriptKey(redeemScript)
+	if _, ok := r.scripts[key]; !ok && len(r.scripts) >= maxRevocationScripts {
+		r.mtx.Unlock()
+		return nil, fmt.Errorf("the maximum of %d revocation scripts "+
+			"is already registered", maxRevocationScripts)
+	}
+	r.scripts[key] = &revocationScript{
+		redeemScript:    redeemScript,
+		sigScriptPrefix: sigScriptPrefix,
+	}
+
+	// Reconsider the missed tickets that could not be revoked so far since
+	// some of them might be revocable with the new script.
+	r.revoked = make(map[chainhash.Hash]struct{})
+	err = r.saveScripts()
+	r.mtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	revkLog.Infof("Registered revocation script for %v", addr)
+	r.NotifyBlockConnected()
+	return addr, nil
+}
+
+// NotifyBlockConnected signals the revoker that a new block was connected so
+// it checks for new missed and expired tickets.
+//
+// This function is safe for concurrent access.
+func (r *ticketRevoker) NotifyBlockConnected() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// createRevocation returns a revocation for the passed missed or expired
+// ticket that is signed with the passed revocation script.  No fee is paid
+// since revocations are not subject to the minimum relay fee and an unchanged
+// payout is valid regardless of the fee limits of the ticket.
+func createRevocation(ticketHash *chainhash.Hash, ticket *blockchain.UtxoEntry, script *revocationScript) (*wire.MsgTx, error) {
+	minOuts := blockchain.ConvertUtxosToMinimalOutputs(ticket)
+	isP2SH, hash160s, amounts, _, _, _ := stake.SStxStakeOutputInfo(minOuts)
+	ticketValue := ticket.AmountByIndex(0)
+	payouts := stake.CalculateRewards(amounts, ticketValue, 0)
+
+	revocation := wire.NewMsgTx()
+	txIn := wire.NewTxIn(wire.NewOutPoint(ticketHash, 0, wire.TxTreeStake),
+		nil)
+	txIn.ValueIn = ticketValue
+	txIn.BlockHeight = uint32(ticket.BlockHeight())
+	txIn.BlockIndex = ticket.BlockIndex()
+	revocation.AddTxIn(txIn)
+	for i, hash160 := range hash160s {
+		scriptFn := txscript.PayToSSRtxPKHDirect
+		if isP2SH[i] {
+			scriptFn = txscript.PayToSSRtxSHDirect
+		}
+		pkScript, err := scriptFn(hash160)
+		if err != nil {
+			return nil, err
+		}
+		revocation.AddTxOut(wire.NewTxOut(payouts[i], pkScript))
+	}
+
+	sigScript, err := txscript.NewScriptBuilder().
+		AddOps(script.sigScriptPrefix).
+		AddData(script.redeemScript).Script()
+	if err != nil {
+		return nil, err
+	}
+	revocation.TxIn[0].SignatureScript = sigScript
+
+	// Ensure the revocation is valid, which is only the case when the
+	// redeem script is satisfied by the signature script prefix.
+	vm, err := txscript.NewEngine(ticket.PkScriptByIndex(0), revocation, 0,
+		txscript.StandardVerifyFlags, ticket.ScriptVersionByIndex(0), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := vm.Execute(); err != nil {
+		return nil, fmt.Errorf("revocation script does not satisfy the "+
+			"redeem script: %v", err)
+	}
+
+	return revocation, nil
+}
+
+// revokeTicket creates and submits a revocation for the passed missed or
+// expired ticket when its voting rights are held by a registered script.  It
+// returns whether the ticket needs to be considered again later.
+func (r *ticketRevoker) revokeTicket(ticketHash *chainhash.Hash) bool {
+	chain := r.server.blockManager.chain
+	ticket, err := chain.FetchUtxoEntry(ticketHash)
+	if err != nil {
+		revkLog.Warnf("Failed to fetch ticket %v: %v", ticketHash, err)
+		return true
+	}
+	if ticket == nil || ticket.TransactionType() != stake.TxTypeSStx ||
+		ticket.IsOutputSpent(0) {
+		return false
+	}
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		ticket.ScriptVersionByIndex(0), ticket.PkScriptByIndex(0),
+		r.server.chainParams)
+	if err != nil || len(addrs) != 1 {
+		return false
+	}
+	if _, ok := addrs[0].(*hcashutil.AddressScriptHash); !ok {
+		return false
+	}
+	var key [20]byte
+	copy(key[:], addrs[0].ScriptAddress())
+	r.mtx.Lock()
+	script, ok := r.scripts[key]
+	r.mtx.Unlock()
+	if !ok {
+		return false
+	}
+
+	revocation, err := createRevocation(ticketHash, ticket, script)
+	if err != nil {
+		revkLog.Warnf("Unable to revoke ticket %v: %v", ticketHash, err)
+		return false
+	}
+
+	tx := hcashutil.NewTx(revocation)
+	acceptedTxs, err := r.server.blockManager.ProcessTransaction(tx, false,
+		false, true)
+	if err != nil {
+		if _, ok := err.(mempool.RuleError); ok {
+			revkLog.Debugf("Rejected revocation %v for ticket %v: %v",
+				tx.Hash(), ticketHash, err)
+			return false
+		}
+		revkLog.Errorf("Failed to process revocation %v for ticket "+
+			"%v: %v", tx.Hash(), ticketHash, err)
+		return true
+	}
+	r.server.AnnounceNewTransactions(acceptedTxs)
+	if !cfg.DisableRPC {
+		iv := wire.NewInvVect(wire.InvTypeTx, tx.Hash())
+		r.server.AddRebroadcastInventory(iv, tx)
+	}
+
+	revkLog.Infof("Revoked ticket %v with revocation %v", ticketHash,
+		tx.Hash())
+	return false
+}
+
+// revokeMissedTickets revokes all missed and expired tickets that were not
+// processed yet.
+func (r *ticketRevoker) revokeMissedTickets() {
+	// Revocations can only be validated against the current chain state.
+	if !r.server.blockManager.IsCurrent() {
+		return
+	}
+
+	missed, err := r.server.blockManager.chain.MissedTickets()
+	if err != nil {
+		revkLog.Warnf("Failed to fetch missed tickets: %v", err)
+		return
+	}
+
+	// Forget the tickets that are no longer missed, which is the case once
+	// they are revoked.
+	missedSet := make(map[chainhash.Hash]struct{}, len(missed))
+	for _, hash := range missed {
+		missedSet[hash] = struct{}{}
+	}
+	r.mtx.Lock()
+	for hash := range r.revoked {
+		if _, ok := missedSet[hash]; !ok {
+			delete(r.revoked, hash)
+		}
+	}
+	r.mtx.Unlock()
+
+	for i := range missed {
+		select {
+		case <-r.quit:
+			return
+		default:
+		}
+
+		hash := &missed[i]
+		r.mtx.Lock()
+		_, done := r.revoked[*hash]
+		r.mtx.Unlock()
+		if done {
+			continue
+		}
+		if !r.revokeTicket(hash) {
+			r.mtx.Lock()
+			r.revoked[*hash] = struct{}{}
+			r.mtx.Unlock()
+		}
+	}
+}
+
+// revokeHandler revokes missed and expired tickets whenever a block is
+// connected.
+//
+// It must be run as a goroutine.
+func (r *ticketRevoker) revokeHandler() {
+out:
+	for {
+		select {
+		case <-r.notify:
+			r.revokeMissedTickets()
+		case <-r.quit:
+			break out
+		}
+	}
+	r.wg.Done()
+}
+
+// Start begins revoking missed and expired tickets.
+func (r *ticketRevoker) Start() {
+	r.wg.Add(1)
+	go r.revokeHandler()
+}
+
+// Stop stops revoking tickets and waits for the revoker to finish.
+func (r *ticketRevoker) Stop() {
+	close(r.quit)
+	r.wg.Wait()
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/btcsuite/btclog"
+)
+
+// TestChainAuditWaitGroup ensures the background audit is tracked by the wait
+// group passed to start, so waiting on it at shutdown also waits for the audit.
+func TestChainAuditWaitGroup(t *testing.T) {
+	// The chain and the audit log through every subsystem, which have no
+	// log rotator to write to in tests.
+	for _, logger := range subsystemLoggers {
+		defer logger.SetLevel(logger.Level())
+		logger.SetLevel(btclog.LevelOff)
+	}
+
+	chain, teardownFunc, err := blockchain.SetupTestChain("chainaudit",
+		&chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	var wg sync.WaitGroup
+	var audit chainAudit
+	for i := 0; i < 2; i++ {
+		err := audit.start(&wg, chain, blockchain.AuditChecks, 0, -1)
+		if err != nil {
+			t.Fatalf("start #%d: unexpected error: %v", i, err)
+		}
+		wg.Wait()
+
+		status := audit.status()
+		if status.Running || status.EndTime == 0 || status.Error != "" {
+			t.Fatalf("start #%d: audit not finished after wait: %+v",
+				i, status)
+		}
+		if len(status.Violations) != 0 {
+			t.Fatalf("start #%d: unexpected violations: %v", i,
+				status.Violations)
+		}
+	}
+
+	if err := audit.start(&wg, chain, blockchain.AuditChecks, 1, -1); err == nil {
+		t.Fatal("start: start height after the best block accepted")
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// rpcAuthBackoffBase is the time authentication attempts from a host
+	// are refused after its first authentication failure.  The time
+	// doubles with every consecutive failure up to rpcAuthBackoffMax.
+	rpcAuthBackoffBase = time.Second
+
+	// rpcAuthBackoffMax is the maximum time authentication attempts from
+	// a host are refused after an authentication failure.
+	rpcAuthBackoffMax = time.Minute
+
+	// rpcAuthBanThreshold is the number of consecutive authentication
+	// failures after which a host is banned.
+	rpcAuthBanThreshold = 10
+
+	// rpcAuthBanDuration is the time a host is banned for.
+	rpcAuthBanDuration = time.Hour
+
+	// rpcAuthFailureExpiry is the time after which the authentication
+	// failures of a host are forgotten when there are no further failures.
+	rpcAuthFailureExpiry = 24 * time.Hour
+
+	// rpcAuthMaxTrackedHosts is the number of tracked hosts after which
+	// the hosts that are no longer refused are pruned when a failure from
+	// a new host is recorded.
+	rpcAuthMaxTrackedHosts = 1000
+)
+
+// rpcAuthHost houses the authentication failure state of a single host.
+type rpcAuthHost struct {
+	failures    uint32
+	lastFailure time.Time
+	refuseUntil time.Time
+	banned      bool
+}
+
+// rpcAuthBan describes a host that is currently banned from authenticating.
+type rpcAuthBan struct {
+	host     string
+	failures uint32
+	until    time.Time
+}
+
+// rpcAuthStats houses the counters of an rpcAuthLimiter.
+type rpcAuthStats struct {
+	failures uint64
+	refused  uint64
+	bans     uint64
+	banned   []rpcAuthBan
+}
+
+// rpcAuthLimiter tracks the RPC authentication failures of each host to slow
+// down credential guessing.  After a failure, further attempts from the host
+// are refused for a period that doubles with each consecutive failure, and a
+// host is banned for rpcAuthBanDuration once it reaches rpcAuthBanThreshold
+// consecutive failures.  A successful authentication resets the state of a
+// host.
+type rpcAuthLimiter struct {
+	mtx   sync.Mutex
+	hosts map[string]*rpcAuthHost
+	stats rpcAuthStats
+}
+
+// newRPCAuthLimiter returns a new authentication failure limiter.
+func newRPCAuthLimiter() *rpcAuthLimiter {
+	return &rpcAuthLimiter{hosts: make(map[string]*rpcAuthHost)}
+}
+
+// rpcAuthHostKey returns the host part of the passed remote address, which is
+// used to track the failures of a client regardless of its source port.
+func rpcAuthHostKey(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// refused returns whether authentication attempts from the passed host are
+// currently refused, along with whether that is because the host is banned.
+//
+// This function is safe for concurrent access.
+func (l *rpcAuthLimiter) refused(host string, now time.Time) (bool, bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	h, ok := l.hosts[host]
+	if !ok || !now.Before(h.refuseUntil) {
+		return false, false
+	}
+	l.stats.refused++
+	return true, h.banned
+}
+
+// failure records an authentication failure from the passed host.  It returns
+// the total number of consecutive failures from the host and whether the
+// failure caused the host to be banned.
+//
+// This function is safe for concurrent access.
+func (l *rpcAuthLimiter) failure(host string, now time.Time) (uint32, bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.stats.failures++
+
+	h, ok := l.hosts[host]
+	if ok && now.Sub(h.lastFailure) >= rpcAuthFailureExpiry {
+		ok = false
+	}
+	if !ok {
+		if len(l.hosts) >= rpcAuthMaxTrackedHosts {
+			l.prune(now)
+		}
+		h = &rpcAuthHost{}
+		l.hosts[host] = h
+	}
+
+	h.failures++
+	h.lastFailure = now
+	if h.failures >= rpcAuthBanThreshold {
+		l.stats.bans++
+		h.banned = true
+		h.refuseUntil = now.Add(rpcAuthBanDuration)
+		return h.failures, true
+	}
+
+	backoff := rpcAuthBackoffMax
+	if shift := h.failures - 1; shift < 16 {
+		backoff = rpcAuthBackoffBase << shift
+		if backoff > rpcAuthBackoffMax {
+			backoff = rpcAuthBackoffMax
+		}
+	}
+	h.refuseUntil = now.Add(backoff)
+	return h.failures, false
+}
+
+// success records a successful authentication from the passed host, which
+// forgets its previous failures.
+//
+// This function is safe for concurrent access.
+func (l *rpcAuthLimiter) success(host string) {
+	l.mtx.Lock()
+	delete(l.hosts, host)
+	l.mtx.Unlock()
+}
+
+// prune removes the hosts that are no longer refused.
+//
+// This function MUST be called with the limiter lock held.
+func (l *rpcAuthLimiter) prune(now time.Time) {
+	for host, h := range l.hosts {
+		if !now.Before(h.refuseUntil) {
+			delete(l.hosts, host)
+		}
+	}
+}
+
+// rpcAuthBans implements sort.Interface to sort bans by host.
+type rpcAuthBans []rpcAuthBan
+
+func (s rpcAuthBans) Len() int           { return len(s) }
+func (s rpcAuthBans) Less(i, j int) bool { return s[i].host < s[j].host }
+func (s rpcAuthBans) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// snapshot returns the counters of the limiter and the hosts that are
+// currently banned, sorted by host.
+//
+// This function is safe for concurrent access.
+func (l *rpcAuthLimiter) snapshot(now time.Time) rpcAuthStats {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	stats := l.stats
+	stats.banned = nil
+	for host, h := range l.hosts {
+		if h.banned && now.Before(h.refuseUntil) {
+			stats.banned = append(stats.banned, rpcAuthBan{
+				host:     host,
+				failures: h.failures,
+				until:    h.refuseUntil,
+			})
+		}
+	}
+	sort.Sort(rpcAuthBans(stats.banned))
+	return stats
+}
+
+// rpcLimitedMethods returns the set of methods available to limited users,
+// which are the passed methods when any are configured and the default set of
+// methods which do not change the state of the server otherwise.
+func rpcLimitedMethods(methods []string) map[string]struct{} {
+	if len(methods) == 0 {
+		return rpcLimited
+	}
+	limited := make(map[string]struct{}, len(methods))
+	for _, method := range methods {
+		limited[method] = struct{}{}
+	}
+	return limited
+}
+
+// authorized returns whether a client with the passed privileges may invoke
+// the passed method.  Admin users may invoke all methods, while limited users
+// may only invoke the methods available to them.
+func (s *rpcServer) authorized(method string, isAdmin bool) bool {
+	if isAdmin {
+		return true
+	}
+	_, ok := s.limitedMethods[method]
+	return ok
+}
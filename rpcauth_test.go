@@ -0,0 +1,375 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btclog"
+)
+
+// TestRPCAuthLimiter ensures hosts are refused with an exponential backoff
+// after authentication failures and banned after repeated failures.
+func TestRPCAuthLimiter(t *testing.T) {
+	l := newRPCAuthLimiter()
+	host := rpcAuthHostKey("127.0.0.1:18555")
+	if host != "127.0.0.1" {
+		t.Fatalf("rpcAuthHostKey: got %q, want %q", host, "127.0.0.1")
+	}
+
+	now := time.Unix(1500000000, 0)
+	if refused, _ := l.refused(host, now); refused {
+		t.Fatal("refused host without failures")
+	}
+
+	// Every failure must double the backoff up to the maximum.
+	wantBackoff := rpcAuthBackoffBase
+	for i := uint32(1); i < rpcAuthBanThreshold; i++ {
+		failures, banned := l.failure(host, now)
+		if failures != i || banned {
+			t.Fatalf("failure %d: got %d failures, banned %v", i,
+				failures, banned)
+		}
+		if refused, _ := l.refused(host, now.Add(wantBackoff-1)); !refused {
+			t.Fatalf("failure %d: not refused before backoff %v", i,
+				wantBackoff)
+		}
+		now = now.Add(wantBackoff)
+		if refused, _ := l.refused(host, now); refused {
+			t.Fatalf("failure %d: refused after backoff %v", i,
+				wantBackoff)
+		}
+
+		wantBackoff *= 2
+		if wantBackoff > rpcAuthBackoffMax {
+			wantBackoff = rpcAuthBackoffMax
+		}
+	}
+
+	// Reaching the threshold bans the host.
+	failures, banned := l.failure(host, now)
+	if failures != rpcAuthBanThreshold || !banned {
+		t.Fatalf("got %d failures, banned %v", failures, banned)
+	}
+	refused, isBan := l.refused(host, now.Add(rpcAuthBanDuration-1))
+	if !refused || !isBan {
+		t.Fatalf("banned host: refused %v, banned %v", refused, isBan)
+	}
+	stats := l.snapshot(now)
+	if len(stats.banned) != 1 || stats.banned[0].host != host ||
+		!stats.banned[0].until.Equal(now.Add(rpcAuthBanDuration)) {
+
+		t.Fatalf("unexpected banned hosts %+v", stats.banned)
+	}
+	if stats.failures != rpcAuthBanThreshold || stats.bans != 1 ||
+		stats.refused != rpcAuthBanThreshold {
+
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// Other hosts are unaffected and a success forgets the failures.
+	if refused, _ := l.refused("127.0.0.2", now); refused {
+		t.Fatal("refused unrelated host")
+	}
+	l.success(host)
+	if refused, _ := l.refused(host, now); refused {
+		t.Fatal("refused host after success")
+	}
+	if failures, _ := l.failure(host, now); failures != 1 {
+		t.Fatalf("got %d failures after success, want 1", failures)
+	}
+
+	// Failures expire when there are no further failures.
+	now = now.Add(rpcAuthFailureExpiry)
+	if failures, _ := l.failure(host, now); failures != 1 {
+		t.Fatalf("got %d failures after expiry, want 1", failures)
+	}
+}
+
+// TestRPCLimitedMethods ensures limited users may only invoke the configured
+// methods, or the default set of methods when none are configured, while admin
+// users may invoke all methods.
+func TestRPCLimitedMethods(t *testing.T) {
+	s := &rpcServer{limitedMethods: rpcLimitedMethods(nil)}
+	if !s.authorized("getblockcount", false) {
+		t.Fatal("limited user not authorized for a default method")
+	}
+	if s.authorized("stop", false) {
+		t.Fatal("limited user authorized for stop")
+	}
+
+	s.limitedMethods = rpcLimitedMethods([]string{"getmempoolinfo"})
+	if !s.authorized("getmempoolinfo", false) {
+		t.Fatal("limited user not authorized for a configured method")
+	}
+	if s.authorized("getblockcount", false) {
+		t.Fatal("limited user authorized for a method which is not " +
+			"configured")
+	}
+	if !s.authorized("stop", true) {
+		t.Fatal("admin user not authorized for stop")
+	}
+}
+
+// TestCheckAuth ensures only wrong credentials count as authentication
+// failures, and that correct credentials are accepted while the host is
+// refused due to earlier failures.
+func TestCheckAuth(t *testing.T) {
+	oldCfg := cfg
+	cfg = &config{}
+	defer func() { cfg = oldCfg }()
+
+	// The failures are logged, which requires a log rotator otherwise.
+	oldLevel := rpcsLog.Level()
+	rpcsLog.SetLevel(btclog.LevelOff)
+	defer rpcsLog.SetLevel(oldLevel)
+
+	basicAuth := func(user, pass string) string {
+		login := base64.StdEncoding.EncodeToString([]byte(user + ":" +
+			pass))
+		return "Basic " + login
+	}
+	s := &rpcServer{
+		authsha:      sha256.Sum256([]byte(basicAuth("user", "pass"))),
+		limitauthsha: sha256.Sum256([]byte(basicAuth("limit", "pass"))),
+		authLimiter:  newRPCAuthLimiter(),
+	}
+	newRequest := func(auth string) *http.Request {
+		r := httptest.NewRequest("POST", "/", nil)
+		r.RemoteAddr = "127.0.0.1:18555"
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		return r
+	}
+	failures := func() uint64 {
+		return s.authLimiter.snapshot(time.Now()).failures
+	}
+
+	// Requests without credentials are not failures.
+	if ok, _, err := s.checkAuth(newRequest(""), false); ok || err != nil {
+		t.Fatalf("no credentials: got %v, %v", ok, err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, _, err := s.checkAuth(newRequest(""), true); err == nil {
+			t.Fatal("no credentials: required auth accepted")
+		}
+	}
+	if got := failures(); got != 0 {
+		t.Fatalf("no credentials: got %d failures, want 0", got)
+	}
+
+	// Wrong credentials are failures which refuse further wrong ones
+	// without counting them.
+	wrong := newRequest(basicAuth("user", "wrong"))
+	if _, _, err := s.checkAuth(wrong, true); err == nil {
+		t.Fatal("wrong credentials accepted")
+	}
+	if _, _, err := s.checkAuth(wrong, true); err == nil ||
+		err.Error() != "auth refused" {
+
+		t.Fatalf("wrong credentials while refused: got %v, want "+
+			"auth refused", err)
+	}
+	if got := failures(); got != 1 {
+		t.Fatalf("wrong credentials: got %d failures, want 1", got)
+	}
+
+	// Correct credentials are accepted while refused and reset the host.
+	ok, isAdmin, err := s.checkAuth(newRequest(basicAuth("limit", "pass")),
+		true)
+	if !ok || isAdmin || err != nil {
+		t.Fatalf("limited credentials: got %v, %v, %v", ok, isAdmin, err)
+	}
+	if _, _, err := s.checkAuth(wrong, true); err == nil ||
+		err.Error() != "auth failure" {
+
+		t.Fatalf("wrong credentials after success: got %v, want "+
+			"auth failure", err)
+	}
+	ok, isAdmin, err = s.checkAuth(newRequest(basicAuth("user", "pass")),
+		true)
+	if !ok || !isAdmin || err != nil {
+		t.Fatalf("admin credentials: got %v, %v, %v", ok, isAdmin, err)
+	}
+}
+
+// newTestCert returns a certificate for a client, or for a certificate
+// authority when parent is nil, along with its key.  Client certificates are
+// signed by the passed parent.
+func newTestCert(t *testing.T, serial int64, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{Organization: []string{"hcashd test"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent,
+		&key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return cert, key
+}
+
+// TestRPCClientCert ensures clients presenting a certificate signed by the
+// configured client certificate authority are authenticated as the admin user,
+// clients without a certificate fall back to the credentials, and certificates
+// signed by any other authority are rejected during the handshake.
+func TestRPCClientCert(t *testing.T) {
+	oldLevel := rpcsLog.Level()
+	rpcsLog.SetLevel(btclog.LevelOff)
+	defer rpcsLog.SetLevel(oldLevel)
+
+	dir, err := ioutil.TempDir("", "rpcclientcert")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, caKey := newTestCert(t, 1, nil, nil)
+	client, clientKey := newTestCert(t, 2, ca, caKey)
+	otherCA, otherCAKey := newTestCert(t, 3, nil, nil)
+	untrusted, untrustedKey := newTestCert(t, 4, otherCA, otherCAKey)
+
+	caFile := filepath.Join(dir, "clientca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE",
+		Bytes: ca.Raw})
+	if err := ioutil.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	oldCfg := cfg
+	cfg = &config{}
+	cfg.RPCCert = filepath.Join(dir, "rpc.cert")
+	cfg.RPCKey = filepath.Join(dir, "rpc.key")
+	cfg.RPCClientCA = caFile
+	defer func() { cfg = oldCfg }()
+
+	// Certificates are not trusted without a client certificate authority.
+	r := httptest.NewRequest("POST", "/", nil)
+	r.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{client, ca}},
+	}
+	if !hasVerifiedClientCert(r) {
+		t.Fatal("hasVerifiedClientCert: verified chain not accepted")
+	}
+	cfg.RPCClientCA = ""
+	if hasVerifiedClientCert(r) {
+		t.Fatal("hasVerifiedClientCert: accepted without a client CA")
+	}
+	cfg.RPCClientCA = caFile
+
+	listen, err := rpcListenFunc()
+	if err != nil {
+		t.Fatalf("rpcListenFunc: unexpected error: %v", err)
+	}
+	listener, err := listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &rpcServer{
+		authsha:     sha256.Sum256([]byte("Basic dXNlcjpwYXNz")),
+		authLimiter: newRPCAuthLimiter(),
+	}
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authenticated, isAdmin, _ := s.checkAuth(r, true)
+			switch {
+			case isAdmin:
+				w.Write([]byte("admin"))
+			case authenticated:
+				w.Write([]byte("limited"))
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}),
+		// The rejected handshake is logged otherwise.
+		ErrorLog: log.New(ioutil.Discard, "", 0),
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	// request makes a request presenting the passed certificate, if any,
+	// and returns the response body or the error.
+	request := func(cert *x509.Certificate, key *ecdsa.PrivateKey) (string, error) {
+		tlsConfig := &tls.Config{InsecureSkipVerify: true}
+		if cert != nil {
+			tlsConfig.Certificates = []tls.Certificate{{
+				Certificate: [][]byte{cert.Raw},
+				PrivateKey:  key,
+			}}
+		}
+		httpClient := &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		}
+		resp, err := httpClient.Post("https://"+listener.Addr().String(),
+			"application/json", nil)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			return resp.Status, err
+		}
+		return string(body), err
+	}
+
+	if got, err := request(client, clientKey); err != nil || got != "admin" {
+		t.Fatalf("verified cert: got %q, %v, want admin", got, err)
+	}
+	got, err := request(nil, nil)
+	if err != nil || got != "401 Unauthorized" {
+		t.Fatalf("missing cert: got %q, %v, want 401 Unauthorized", got,
+			err)
+	}
+	if got, err := request(untrusted, untrustedKey); err == nil {
+		t.Fatalf("untrusted CA: got %q, want a handshake error", got)
+	}
+
+	// A client CA file without certificates is rejected.
+	if err := ioutil.WriteFile(caFile, []byte("none"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := rpcListenFunc(); err == nil {
+		t.Fatal("rpcListenFunc: client CA file without certificates " +
+			"accepted")
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/HcashOrg/hcashd/hcashjson"
+)
+
+// isBatchRequest returns whether or not the passed HTTP POST body is a JSON-RPC
+// batch request, which is a JSON array of requests.
+func isBatchRequest(body []byte) bool {
+	body = bytes.TrimLeft(body, " \t\r\n")
+	return len(body) > 0 && body[0] == '['
+}
+
+// processBatch determines the responses for the requests of the passed
+// JSON-RPC batch request and returns them marshalled as a JSON array in the
+// order of the requests.  Per the JSON-RPC 2.0 spec, a batch that can't be
+// parsed or is empty results in a single error response instead, an invalid
+// request in the batch results in an error response with a null ID, and no
+// responses are returned for notifications.  Nil is returned when there are no
+// responses at all.
+func (s *rpcServer) processBatch(body []byte, isAdmin bool, closeChan <-chan struct{}) []byte {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		jsonErr := &hcashjson.RPCError{
+			Code:    hcashjson.ErrRPCParse.Code,
+			Message: fmt.Sprintf("Failed to parse request: %v", err),
+		}
+		return marshalledErrorReply(jsonErr)
+	}
+	if len(batch) == 0 {
+		jsonErr := &hcashjson.RPCError{
+			Code:    hcashjson.ErrRPCInvalidRequest.Code,
+			Message: "Invalid request: empty batch",
+		}
+		return marshalledErrorReply(jsonErr)
+	}
+
+	replies := make([]json.RawMessage, 0, len(batch))
+	for _, rawRequest := range batch {
+		var request hcashjson.Request
+		if err := json.Unmarshal(rawRequest, &request); err != nil {
+			jsonErr := &hcashjson.RPCError{
+				Code: hcashjson.ErrRPCInvalidRequest.Code,
+				Message: fmt.Sprintf("Invalid request: %v",
+					err),
+			}
+			if reply := marshalledErrorReply(jsonErr); reply != nil {
+				replies = append(replies, reply)
+			}
+			continue
+		}
+
+		reply := s.processRequest(&request, isAdmin, closeChan)
+		if reply != nil {
+			replies = append(replies, reply)
+		}
+	}
+	if len(replies) == 0 {
+		return nil
+	}
+
+	msg, err := json.Marshal(replies)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal batch reply: %v", err)
+		return nil
+	}
+	return msg
+}
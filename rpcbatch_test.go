@@ -0,0 +1,138 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/hcashjson"
+)
+
+// TestProcessBatch ensures batch requests result in responses in the order of
+// their requests with matching IDs, that notifications are not responded to,
+// and that malformed batches and requests result in the expected errors.
+func TestProcessBatch(t *testing.T) {
+	s := &rpcServer{}
+
+	// reply describes an expected response by its ID, which is nil for a
+	// null ID, and error code.
+	type reply struct {
+		id   interface{}
+		code hcashjson.RPCErrorCode
+	}
+	tests := []struct {
+		name    string
+		body    string
+		isAdmin bool
+		batch   bool
+		want    []reply
+	}{
+		{
+			name:    "ordered responses",
+			body:    `[{"method":"nosuchmethod","id":"a"},{"method":"nosuchmethod","id":2}]`,
+			isAdmin: true,
+			batch:   true,
+			want: []reply{
+				{"a", hcashjson.ErrRPCMethodNotFound.Code},
+				{2.0, hcashjson.ErrRPCMethodNotFound.Code},
+			},
+		},
+		{
+			name:  "limited user",
+			body:  `[{"method":"stop","id":1}]`,
+			batch: true,
+			want:  []reply{{1.0, hcashjson.ErrRPCInvalidParameter}},
+		},
+		{
+			name:    "notifications and invalid requests",
+			body:    `[{"method":"nosuchmethod"},1,{"method":"nosuchmethod","id":3}]`,
+			isAdmin: true,
+			batch:   true,
+			want: []reply{
+				{nil, hcashjson.ErrRPCInvalidRequest.Code},
+				{3.0, hcashjson.ErrRPCMethodNotFound.Code},
+			},
+		},
+		{
+			name:    "only notifications",
+			body:    `[{"method":"nosuchmethod"}]`,
+			isAdmin: true,
+			batch:   true,
+		},
+		{
+			name:    "empty batch",
+			body:    ` []`,
+			isAdmin: true,
+			want:    []reply{{nil, hcashjson.ErrRPCInvalidRequest.Code}},
+		},
+		{
+			name:    "malformed batch",
+			body:    `[{"method":`,
+			isAdmin: true,
+			want:    []reply{{nil, hcashjson.ErrRPCParse.Code}},
+		},
+	}
+
+	for _, test := range tests {
+		body := []byte(test.body)
+		if !isBatchRequest(body) {
+			t.Errorf("%s: not detected as a batch request", test.name)
+			continue
+		}
+		msg := s.processBatch(body, test.isAdmin, nil)
+		if len(test.want) == 0 {
+			if msg != nil {
+				t.Errorf("%s: unexpected reply %s", test.name, msg)
+			}
+			continue
+		}
+
+		// Replies which are not for a valid batch are a single
+		// response rather than an array of them.
+		var responses []hcashjson.Response
+		if test.batch {
+			err := json.Unmarshal(msg, &responses)
+			if err != nil {
+				t.Errorf("%s: failed to unmarshal reply %s: %v",
+					test.name, msg, err)
+				continue
+			}
+		} else {
+			var response hcashjson.Response
+			if err := json.Unmarshal(msg, &response); err != nil {
+				t.Errorf("%s: failed to unmarshal reply %s: %v",
+					test.name, msg, err)
+				continue
+			}
+			responses = append(responses, response)
+		}
+		if len(responses) != len(test.want) {
+			t.Errorf("%s: got %d responses, want %d", test.name,
+				len(responses), len(test.want))
+			continue
+		}
+		for i, response := range responses {
+			var id interface{}
+			if response.ID != nil {
+				id = *response.ID
+			}
+			if id != test.want[i].id {
+				t.Errorf("%s: response %d has ID %v, want %v",
+					test.name, i, id, test.want[i].id)
+			}
+			if response.Error == nil ||
+				response.Error.Code != test.want[i].code {
+				t.Errorf("%s: response %d has error %v, want code "+
+					"%d", test.name, i, response.Error,
+					test.want[i].code)
+			}
+		}
+	}
+
+	if isBatchRequest([]byte(`{"method":"getinfo","id":1}`)) {
+		t.Error("single request detected as a batch request")
+	}
+}
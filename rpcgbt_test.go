@@ -0,0 +1,170 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/hcashjson"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// TestGetBlockTemplateRegistered ensures getblocktemplate, and thereby its long
+// polling, is handled rather than reported as unimplemented.
+func TestGetBlockTemplateRegistered(t *testing.T) {
+	if _, ok := rpcHandlers["getblocktemplate"]; !ok {
+		t.Fatal("getblocktemplate has no handler")
+	}
+	if _, ok := rpcUnimplemented["getblocktemplate"]; ok {
+		t.Fatal("getblocktemplate is unimplemented")
+	}
+}
+
+// TestTemplateID ensures long poll IDs carry the best block, the key block and
+// the generation time of a template, and malformed IDs are rejected.
+func TestTemplateID(t *testing.T) {
+	prevHash := chainhash.Hash{0x01}
+	prevKeyHash := chainhash.Hash{0x02}
+	lastGenerated := time.Unix(1500000000, 0)
+	id := encodeTemplateID(&prevHash, &prevKeyHash, lastGenerated)
+	gotPrev, gotPrevKey, gotGenerated, err := decodeTemplateID(id)
+	if err != nil {
+		t.Fatalf("decodeTemplateID(%q): unexpected error: %v", id, err)
+	}
+	if *gotPrev != prevHash || *gotPrevKey != prevKeyHash ||
+		gotGenerated != lastGenerated.Unix() {
+
+		t.Fatalf("decodeTemplateID(%q): got %v, %v, %d", id, gotPrev,
+			gotPrevKey, gotGenerated)
+	}
+
+	hash := prevHash.String()
+	tests := []string{
+		"",
+		hash + "-1500000000",
+		hash + "-" + hash + "-1500000000-1",
+		"zz-" + hash + "-1500000000",
+		hash + "-zz-1500000000",
+		hash + "-" + hash + "-now",
+	}
+	for _, id := range tests {
+		if _, _, _, err := decodeTemplateID(id); err != ErrInvalidLongPoll {
+			t.Errorf("decodeTemplateID(%q): got error %v, want %v", id,
+				err, ErrInvalidLongPoll)
+		}
+	}
+}
+
+// isClosed returns whether the passed channel is closed.
+func isClosed(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+// TestNotifyLongPollers ensures long polls are released when the best block
+// changes or a newer template was generated for the same best block, and are
+// kept waiting otherwise.
+func TestNotifyLongPollers(t *testing.T) {
+	state := newGbtWorkState(blockchain.NewMedianTime())
+	tip := chainhash.Hash{0x01}
+	oldTip := chainhash.Hash{0x02}
+
+	state.Lock()
+	defer state.Unlock()
+	onOldTip := state.templateUpdateChan(&oldTip, 100)
+	older := state.templateUpdateChan(&tip, 100)
+	current := state.templateUpdateChan(&tip, 200)
+	if c := state.templateUpdateChan(&tip, 200); c != current {
+		t.Fatal("templateUpdateChan: got a new channel for the same " +
+			"template")
+	}
+
+	// Only the templates on other blocks are stale when the generation
+	// time is not known yet.
+	state.notifyLongPollers(&tip, time.Time{})
+	if !isClosed(onOldTip) || isClosed(older) || isClosed(current) {
+		t.Fatalf("unknown generation time: closed %v, %v, %v",
+			isClosed(onOldTip), isClosed(older), isClosed(current))
+	}
+
+	// Templates generated before the latest one are stale.
+	state.notifyLongPollers(&tip, time.Unix(200, 0))
+	if !isClosed(older) || isClosed(current) {
+		t.Fatalf("newer template: closed %v, %v", isClosed(older),
+			isClosed(current))
+	}
+
+	// All templates are stale once a new block is connected.
+	state.notifyLongPollers(&oldTip, time.Unix(200, 0))
+	if !isClosed(current) {
+		t.Fatal("new block: template not released")
+	}
+	if len(state.notifyMap) != 0 {
+		t.Fatalf("new block: %d hashes still registered",
+			len(state.notifyMap))
+	}
+}
+
+// TestNotifyMempoolTxDeferred ensures a memory pool update which arrives while
+// the current template is too recent releases the long polls once the template
+// may be regenerated instead of waiting for another update.
+func TestNotifyMempoolTxDeferred(t *testing.T) {
+	state := newGbtWorkState(blockchain.NewMedianTime())
+	tip := chainhash.Hash{0x01}
+
+	state.Lock()
+	state.prevHash = &tip
+	state.lastGenerated = time.Now().Add(-gbtRegenerateSeconds*time.Second +
+		500*time.Millisecond)
+	c := state.templateUpdateChan(&tip, state.lastGenerated.Unix())
+	state.Unlock()
+
+	state.NotifyMempoolTx(time.Now().Add(time.Second))
+	time.Sleep(50 * time.Millisecond)
+	if isClosed(c) {
+		t.Fatal("long poll released before the regeneration time")
+	}
+	select {
+	case <-c:
+	case <-time.After(5 * time.Second):
+		t.Fatal("long poll not released after the regeneration time")
+	}
+
+	state.Lock()
+	defer state.Unlock()
+	if state.pendingNotify != nil || !state.pendingTxUpdate.IsZero() {
+		t.Fatalf("pending update not cleared: %v, %v", state.pendingNotify,
+			state.pendingTxUpdate)
+	}
+}
+
+// TestGetTemplateDeltaInvalidID ensures gettemplatedelta rejects template IDs
+// which are not long poll IDs before building a template.
+func TestGetTemplateDeltaInvalidID(t *testing.T) {
+	oldCfg := cfg
+	cfg = &config{}
+	defer func() { cfg = oldCfg }()
+	addr, err := hcashutil.NewAddressPubKeyHash(make([]byte, 20),
+		&chaincfg.SimNetParams, chainec.ECTypeSecp256k1)
+	if err != nil {
+		t.Fatalf("NewAddressPubKeyHash: %v", err)
+	}
+	cfg.miningAddrs = []hcashutil.Address{addr}
+
+	s := &rpcServer{server: &server{cpuMiner: &CPUMiner{}}}
+	_, err = handleGetTemplateDelta(s,
+		hcashjson.NewGetTemplateDeltaCmd("deadbeef"), nil)
+	checkRPCErrorCode(t, "gettemplatedelta", err,
+		hcashjson.ErrRPCInvalidParameter)
+}
@@ -0,0 +1,261 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/blockchain/chaingen"
+	"github.com/HcashOrg/hcashd/blockchain/indexers"
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	"github.com/HcashOrg/hcashd/hcashjson"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+	"github.com/btcsuite/btclog"
+)
+
+// newTestRPCChain returns a simnet chain extended by the premine block, which
+// the RPC handlers may be run against, along with a teardown function the
+// caller must invoke when done testing.  The active network is switched to
+// simnet until the teardown.
+func newTestRPCChain(t *testing.T, dbName string) (*blockchain.BlockChain, func()) {
+	chain, _, teardown := newTestRPCChainGenerator(t, dbName)
+	return chain, teardown
+}
+
+// newTestRPCChainGenerator returns the same chain as newTestRPCChain along
+// with the generator of its blocks, which is at the premine block, so callers
+// can extend the chain.
+func newTestRPCChainGenerator(t *testing.T, dbName string) (*blockchain.BlockChain, *chaingen.Generator, func()) {
+	// The chain logs through its subsystem, which has no log rotator to
+	// write to in tests.
+	oldLevels := make(map[string]btclog.Level)
+	for id, logger := range subsystemLoggers {
+		oldLevels[id] = logger.Level()
+		logger.SetLevel(btclog.LevelOff)
+	}
+	oldNetParams := activeNetParams
+	activeNetParams = &simNetParams
+
+	chain, teardownFunc, err := blockchain.SetupTestChain(dbName,
+		simNetParams.Params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	teardown := func() {
+		teardownFunc()
+		activeNetParams = oldNetParams
+		for id, logger := range subsystemLoggers {
+			logger.SetLevel(oldLevels[id])
+		}
+	}
+
+	g, err := chaingen.MakeGenerator(simNetParams.Params)
+	if err != nil {
+		teardown()
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+	g.CreatePremineBlock("bp", 0)
+	_, _, err = chain.ProcessBlock(hcashutil.NewBlock(g.Tip()),
+		blockchain.BFNone)
+	if err != nil {
+		teardown()
+		t.Fatalf("premine block should have been accepted: %v", err)
+	}
+
+	return chain, &g, teardown
+}
+
+// checkRPCErrorCode ensures the passed error is a RPC error with the passed
+// code.
+func checkRPCErrorCode(t *testing.T, name string, err error, code hcashjson.RPCErrorCode) {
+	rpcErr, ok := err.(*hcashjson.RPCError)
+	if !ok || rpcErr.Code != code {
+		t.Fatalf("%s: got error %v, want code %v", name, err, code)
+	}
+}
+
+// TestSearchRawTransactionsWrongNetwork ensures addresses for another network
+// are rejected as invalid addresses.
+func TestSearchRawTransactionsWrongNetwork(t *testing.T) {
+	addr, err := hcashutil.NewAddressPubKeyHash(make([]byte, 20),
+		&chaincfg.MainNetParams, chainec.ECTypeSecp256k1)
+	if err != nil {
+		t.Fatalf("NewAddressPubKeyHash: %v", err)
+	}
+	s := &rpcServer{server: &server{
+		addrIndex:   &indexers.AddrIndex{},
+		chainParams: &chaincfg.SimNetParams,
+	}}
+	cmd := hcashjson.NewSearchRawTransactionsCmd(addr.EncodeAddress(), nil,
+		nil, nil, nil, nil, nil)
+	_, err = handleSearchRawTransactions(s, cmd, nil)
+	checkRPCErrorCode(t, "searchrawtransactions", err,
+		hcashjson.ErrRPCInvalidAddressOrKey)
+	if msg := err.(*hcashjson.RPCError).Message; !strings.HasPrefix(msg,
+		"Wrong network") {
+
+		t.Fatalf("searchrawtransactions: got error %q, want a wrong "+
+			"network error", msg)
+	}
+}
+
+// TestGetDifficulty ensures getdifficulty reports the proof-of-work difficulty
+// of key blocks, the lower one of micro blocks and the stake difficulty
+// relative to the minimum one.
+func TestGetDifficulty(t *testing.T) {
+	chain, teardown := newTestRPCChain(t, "getdifficulty")
+	defer teardown()
+
+	s := &rpcServer{chain: chain}
+	result, err := handleGetDifficulty(s, &hcashjson.GetDifficultyCmd{}, nil)
+	if err != nil {
+		t.Fatalf("getdifficulty: unexpected error: %v", err)
+	}
+
+	// The premine block is at the minimum difficulties.
+	params := simNetParams.Params
+	want := &hcashjson.GetDifficultyResult{
+		Difficulty:           1,
+		MicroDifficulty:      1 / float64(params.DifficultyRate),
+		DifficultyRate:       params.DifficultyRate,
+		StakeDifficulty:      hcashutil.Amount(params.MinimumStakeDiff).ToCoin(),
+		MinStakeDifficulty:   hcashutil.Amount(params.MinimumStakeDiff).ToCoin(),
+		StakeDifficultyRatio: 1,
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("getdifficulty: got %+v, want %+v", result, want)
+	}
+}
+
+// TestVersion ensures version reports the hcashd version along with the JSON-RPC
+// API and peer-to-peer protocol versions.
+func TestVersion(t *testing.T) {
+	result, err := handleVersion(&rpcServer{}, &hcashjson.VersionCmd{}, nil)
+	if err != nil {
+		t.Fatalf("version: unexpected error: %v", err)
+	}
+
+	want := map[string]hcashjson.VersionResult{
+		"hcashd": {
+			VersionString: fmt.Sprintf("%d.%d.%d+%s", appMajor, appMinor,
+				appPatch, appBuild),
+			Major:         uint32(appMajor),
+			Minor:         uint32(appMinor),
+			Patch:         uint32(appPatch),
+			BuildMetadata: appBuild,
+		},
+		"hcashdjsonrpcapi": {
+			VersionString: jsonrpcSemverString,
+			Major:         jsonrpcSemverMajor,
+			Minor:         jsonrpcSemverMinor,
+			Patch:         jsonrpcSemverPatch,
+		},
+		"hcashdp2pprotocol": {
+			VersionString: fmt.Sprintf("%d.0.0", wire.ProtocolVersion),
+			Major:         wire.ProtocolVersion,
+		},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("version: got %+v, want %+v", result, want)
+	}
+}
+
+// TestGetBlockSBits ensures the verbose getblock result reports the stake
+// difficulty of key blocks in coins.
+func TestGetBlockSBits(t *testing.T) {
+	chain, teardown := newTestRPCChain(t, "getblocksbits")
+	defer teardown()
+
+	s := &rpcServer{
+		chain:  chain,
+		server: &server{blockManager: &blockManager{chain: chain}},
+	}
+	best := chain.BestSnapshot()
+	verbose := true
+	result, err := handleGetBlock(s, hcashjson.NewGetBlockCmd(
+		best.Hash.String(), &verbose, nil), nil)
+	if err != nil {
+		t.Fatalf("getblock: unexpected error: %v", err)
+	}
+	reply, ok := result.(hcashjson.GetBlockVerboseResult)
+	if !ok {
+		t.Fatalf("getblock: got result type %T", result)
+	}
+
+	// The premine block is a key block at the minimum stake difficulty.
+	want := hcashutil.Amount(simNetParams.MinimumStakeDiff).ToCoin()
+	if reply.SBits != want {
+		t.Fatalf("getblock: got sbits %v, want %v", reply.SBits, want)
+	}
+}
+
+// TestTicketVWAP ensures ticketvwap rejects an end beyond the key height of
+// the best block and includes the key block at the end of the range.
+func TestTicketVWAP(t *testing.T) {
+	chain, g, teardown := newTestRPCChainGenerator(t, "ticketvwap")
+	defer teardown()
+
+	// Extend the chain with enough blocks to have mature coinbase outputs
+	// followed by blocks purchasing tickets with them.
+	processTip := func() {
+		_, _, err := chain.ProcessBlock(hcashutil.NewBlock(g.Tip()),
+			blockchain.BFNone)
+		if err != nil {
+			t.Fatalf("block %s should have been accepted: %v",
+				g.TipName(), err)
+		}
+	}
+	for i := uint16(0); i < simNetParams.CoinbaseMaturity; i++ {
+		g.NextBlock(fmt.Sprintf("bm%d", i), nil, nil)
+		g.SaveTipCoinbaseOuts()
+		processTip()
+	}
+	for i := 0; i < 3; i++ {
+		outs := g.OldestCoinbaseOuts()
+		g.NextBlock(fmt.Sprintf("bse%d", i), nil, outs[1:])
+		g.SaveTipCoinbaseOuts()
+		processTip()
+	}
+
+	best := chain.BestSnapshot()
+	s := &rpcServer{
+		chain: chain,
+		server: &server{blockManager: &blockManager{
+			chain: chain,
+			chainState: chainState{
+				newestHash:      best.Hash,
+				newestHeight:    best.Height,
+				newestKeyHeight: best.KeyHeight,
+			},
+		}},
+	}
+	ticketVWAP := func(start, end uint32) (interface{}, error) {
+		return handleTicketVWAP(s, hcashjson.NewTicketVWAPCmd(&start,
+			&end, nil), nil)
+	}
+
+	tipKeyHeight := uint32(best.KeyHeight)
+	_, err := ticketVWAP(tipKeyHeight, tipKeyHeight+1)
+	checkRPCErrorCode(t, "ticketvwap past the tip", err,
+		hcashjson.ErrRPCInvalidParameter)
+
+	// A range of only the key block before the tip must include its
+	// ticket purchases, all of which are at the minimum stake difficulty.
+	want := hcashutil.Amount(simNetParams.MinimumStakeDiff).ToCoin()
+	result, err := ticketVWAP(tipKeyHeight-1, tipKeyHeight-1)
+	if err != nil {
+		t.Fatalf("ticketvwap: unexpected error: %v", err)
+	}
+	if result != want {
+		t.Fatalf("ticketvwap: got %v for the inclusive end, want %v",
+			result, want)
+	}
+}
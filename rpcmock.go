@@ -0,0 +1,383 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// This file is only included when hcashd is built with the following build tag
+// in order to run it as a mock RPC server.
+//go:build rpcmock
+// +build rpcmock
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/websocket"
+
+	"github.com/HcashOrg/hcashd/hcashjson"
+)
+
+const (
+	// mockRPCCallsEnv is the environment variable which may be set to the
+	// path of a golden file with recorded responses which are served by
+	// the mock RPC server instead of synthesized ones.
+	mockRPCCallsEnv = "HCASHD_RPCMOCK_CALLS"
+
+	// maxMockResultDepth is the maximum depth of the nested values of the
+	// synthesized results.  Values nested deeper are left at their zero
+	// value, which keeps self referencing result types finite.
+	maxMockResultDepth = 8
+)
+
+// mockCall is an RPC request along with the recorded response to it.  It uses
+// the same format as the golden files written by rpctest.WriteGoldenFile.
+type mockCall struct {
+	Method string              `json:"method"`
+	Params []json.RawMessage   `json:"params"`
+	Result json.RawMessage     `json:"result,omitempty"`
+	Error  *hcashjson.RPCError `json:"error,omitempty"`
+}
+
+// mockCallKey returns the key used to match requests to the recorded calls.
+// Requests match when they invoke the same method with the same parameters
+// regardless of their formatting.
+func mockCallKey(method string, params []json.RawMessage) string {
+	keyParams := make([]string, 0, len(params))
+	for _, param := range params {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, param); err != nil {
+			keyParams = append(keyParams, string(param))
+			continue
+		}
+		keyParams = append(keyParams, buf.String())
+	}
+	return method + "(" + strings.Join(keyParams, ",") + ")"
+}
+
+// loadMockCalls returns the calls recorded in the passed golden file keyed by
+// their requests.  The first response is used for requests which were recorded
+// several times so the responses are deterministic.
+func loadMockCalls(goldenFile string) (map[string]*mockCall, error) {
+	data, err := ioutil.ReadFile(goldenFile)
+	if err != nil {
+		return nil, err
+	}
+	var calls []*mockCall
+	if err := json.Unmarshal(data, &calls); err != nil {
+		return nil, fmt.Errorf("malformed golden file %s: %v",
+			goldenFile, err)
+	}
+	recorded := make(map[string]*mockCall, len(calls))
+	for _, call := range calls {
+		key := mockCallKey(call.Method, call.Params)
+		if _, ok := recorded[key]; !ok {
+			recorded[key] = call
+		}
+	}
+	return recorded, nil
+}
+
+// mockVerbose returns whether the passed command requests the verbose variant
+// of its result, which is the case when it has a Verbose or DNS field which is
+// set to true or a non-zero value.
+func mockVerbose(cmd interface{}) bool {
+	v := reflect.ValueOf(cmd)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	for _, name := range []string{"Verbose", "DNS"} {
+		field := v.Elem().FieldByName(name)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+		switch field.Kind() {
+		case reflect.Bool:
+			return field.Bool()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+			reflect.Int64:
+			return field.Int() != 0
+		}
+	}
+	return false
+}
+
+// mockResultType returns the type of the result of the passed command as
+// documented by the help for its method, or nil when the method only ever
+// returns null.  The verbose variant is selected for commands with both a
+// plain and a verbose result when verbose output is requested.  The second
+// return value is false when the method has no documented result.
+func mockResultType(method string, cmd interface{}) (reflect.Type, bool) {
+	types, ok := rpcResultTypes[method]
+	if !ok {
+		return nil, false
+	}
+	if len(types) == 0 {
+		return nil, true
+	}
+	idx := 0
+	if len(types) > 1 && mockVerbose(cmd) {
+		idx = 1
+	}
+	if types[idx] == nil {
+		return nil, true
+	}
+	return reflect.TypeOf(types[idx]).Elem(), true
+}
+
+// mockValue returns a deterministic value of the passed type to use as a
+// synthesized result.  All pointers are set and all slices contain a single
+// element so the result includes every field of the schema, while the values
+// themselves are zero.
+func mockValue(t reflect.Type, depth int) reflect.Value {
+	v := reflect.New(t).Elem()
+	if depth >= maxMockResultDepth {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(t.Elem()))
+		v.Elem().Set(mockValue(t.Elem(), depth+1))
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			v.Field(i).Set(mockValue(t.Field(i).Type, depth+1))
+		}
+
+	case reflect.Slice:
+		// Raw bytes such as json.RawMessage are left nil as they must
+		// hold valid JSON.
+		if t.Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		v.Set(reflect.MakeSlice(t, 1, 1))
+		v.Index(0).Set(mockValue(t.Elem(), depth+1))
+
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+	}
+	return v
+}
+
+// mockRPCServer serves canned responses to RPC clients so the client libraries
+// may be tested without a running node.  Requests which were recorded in the
+// optional golden file are answered with the recorded response.  All other
+// requests are parsed just like the RPC server does, and answered with a
+// synthesized result of the type documented by the help for their method, so
+// the responses always match the schema of the real ones.
+//
+// Both websocket and HTTP POST clients are supported.  The server accepts any
+// credentials and doesn't send notifications.
+type mockRPCServer struct {
+	calls map[string]*mockCall
+
+	mtx   sync.Mutex
+	conns map[*websocket.Conn]struct{}
+}
+
+// result returns the result of the passed request or the error to reply with.
+func (s *mockRPCServer) result(request *hcashjson.Request) (interface{}, error) {
+	if call, ok := s.calls[mockCallKey(request.Method, request.Params)]; ok {
+		if call.Error != nil {
+			return nil, call.Error
+		}
+		if call.Result == nil {
+			return nil, nil
+		}
+		return call.Result, nil
+	}
+
+	parsedCmd := parseCmd(request)
+	if parsedCmd.err != nil {
+		return nil, parsedCmd.err
+	}
+	if _, ok := rpcAskWallet[request.Method]; ok {
+		return nil, ErrRPCNoWallet
+	}
+	if _, ok := rpcUnimplemented[request.Method]; ok {
+		return nil, ErrRPCUnimplemented
+	}
+	resultType, ok := mockResultType(request.Method, parsedCmd.cmd)
+	if !ok {
+		return nil, hcashjson.ErrRPCMethodNotFound
+	}
+	if resultType == nil {
+		return nil, nil
+	}
+	return mockValue(resultType, 0).Interface(), nil
+}
+
+// respond returns the marshalled response to the passed marshalled JSON-RPC
+// request.  Nil is returned for requests with no ID (notifications) since they
+// must not have a response per the JSON-RPC spec.
+func (s *mockRPCServer) respond(body []byte) []byte {
+	var request hcashjson.Request
+	if err := json.Unmarshal(body, &request); err != nil {
+		return marshalledErrorReply(&hcashjson.RPCError{
+			Code:    hcashjson.ErrRPCParse.Code,
+			Message: fmt.Sprintf("Failed to parse request: %v", err),
+		})
+	}
+	if request.ID == nil {
+		return nil
+	}
+
+	result, err := s.result(&request)
+	msg, err := createMarshalledReply(request.ID, result, err)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal reply: %v", err)
+		return nil
+	}
+	return msg
+}
+
+// handlePost responds to a HTTP POST request.
+func (s *mockRPCServer) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, "400 Bad Request.", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.respond(body))
+}
+
+// handleWebsocket responds to the requests of a websocket client until it
+// disconnects or the server is stopped.
+func (s *mockRPCServer) handleWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Upgrade(w, r, nil, 0, 0)
+	if err != nil {
+		if _, ok := err.(websocket.HandshakeError); !ok {
+			rpcsLog.Errorf("Unexpected websocket error: %v", err)
+		}
+		http.Error(w, "400 Bad Request.", http.StatusBadRequest)
+		return
+	}
+	s.mtx.Lock()
+	if s.conns == nil {
+		s.mtx.Unlock()
+		conn.Close()
+		return
+	}
+	s.conns[conn] = struct{}{}
+	s.mtx.Unlock()
+	defer conn.Close()
+
+	// Clear the read deadline of the initial request since clients may
+	// idle between their requests.
+	conn.SetReadDeadline(timeZeroVal)
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		reply := s.respond(msg)
+		if reply == nil {
+			continue
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, reply); err != nil {
+			return
+		}
+	}
+}
+
+// closeConns closes all websocket connections and prevents new ones.
+func (s *mockRPCServer) closeConns() {
+	s.mtx.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+	s.mtx.Unlock()
+}
+
+// mockRPCServerMain serves canned responses to RPC clients on the configured
+// RPC listeners until the passed channel is closed.
+func mockRPCServerMain(interrupt <-chan struct{}) error {
+	if cfg.DisableRPC {
+		err := errors.New("the mock RPC server requires the RPC " +
+			"server to be enabled")
+		rpcsLog.Error(err)
+		return err
+	}
+
+	s := &mockRPCServer{conns: make(map[*websocket.Conn]struct{})}
+	if goldenFile := os.Getenv(mockRPCCallsEnv); goldenFile != "" {
+		calls, err := loadMockCalls(goldenFile)
+		if err != nil {
+			rpcsLog.Errorf("Unable to load recorded calls: %v", err)
+			return err
+		}
+		rpcsLog.Infof("Loaded %d recorded calls from %s", len(calls),
+			goldenFile)
+		s.calls = calls
+	}
+
+	listen, err := rpcListenFunc()
+	if err != nil {
+		rpcsLog.Errorf("Unable to setup RPC listeners: %v", err)
+		return err
+	}
+	var listeners []net.Listener
+	closeListeners := func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}
+	for _, addr := range cfg.RPCListeners {
+		l, err := listenOn(listen, addr)
+		if err != nil {
+			closeListeners()
+			rpcsLog.Errorf("Can't listen on %s: %v", addr, err)
+			return err
+		}
+		listeners = append(listeners, l...)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handlePost)
+	mux.HandleFunc("/ws", s.handleWebsocket)
+	httpServer := &http.Server{
+		Handler:     mux,
+		ReadTimeout: time.Second * rpcAuthTimeoutSeconds,
+	}
+	var wg sync.WaitGroup
+	for _, listener := range listeners {
+		wg.Add(1)
+		go func(listener net.Listener) {
+			defer wg.Done()
+			rpcsLog.Infof("Mock RPC server listening on %s",
+				listener.Addr())
+			httpServer.Serve(listener)
+		}(listener)
+	}
+
+	<-interrupt
+	closeListeners()
+	s.closeConns()
+	wg.Wait()
+	return nil
+}
+
+func init() {
+	mockRPCMain = mockRPCServerMain
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// This file is ignored during the regular tests due to the following build tag.
+//go:build rpcmock
+// +build rpcmock
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/hcashjson"
+)
+
+// TestMockResults ensures the mock RPC server synthesizes a result which can be
+// marshalled and unmarshalled into the documented result type for every method
+// with a documented result.
+func TestMockResults(t *testing.T) {
+	for method, types := range rpcResultTypes {
+		for _, verbose := range []bool{false, true} {
+			cmd := &hcashjson.GetBlockCmd{Verbose: &verbose}
+			resultType, ok := mockResultType(method, cmd)
+			if !ok {
+				t.Fatalf("%s: no result type", method)
+			}
+			if resultType == nil {
+				continue
+			}
+			idx := 0
+			if verbose && len(types) > 1 {
+				idx = 1
+			}
+			want := reflect.TypeOf(types[idx]).Elem()
+			if resultType != want {
+				t.Fatalf("%s: got result type %v, want %v", method,
+					resultType, want)
+			}
+			result, err := json.Marshal(mockValue(resultType, 0).Interface())
+			if err != nil {
+				t.Fatalf("%s: unable to marshal result: %v", method, err)
+			}
+			err = json.Unmarshal(result, reflect.New(want).Interface())
+			if err != nil {
+				t.Fatalf("%s: result %s does not match the documented "+
+					"type: %v", method, result, err)
+			}
+		}
+	}
+}
+
+// TestMockVerbose ensures the verbose variant of a result is selected when the
+// command requests verbose output.
+func TestMockVerbose(t *testing.T) {
+	verbose := true
+	resultType, _ := mockResultType("getblock",
+		hcashjson.NewGetBlockCmd("", &verbose, nil))
+	if resultType.Name() != "GetBlockVerboseResult" {
+		t.Fatalf("got result type %v, want GetBlockVerboseResult",
+			resultType)
+	}
+	verbose = false
+	resultType, _ = mockResultType("getblock",
+		hcashjson.NewGetBlockCmd("", &verbose, nil))
+	if resultType.Name() != "string" {
+		t.Fatalf("got result type %v, want string", resultType)
+	}
+}
+
+// TestMockRecordedCalls ensures requests which were recorded are answered with
+// the recorded response regardless of their formatting.
+func TestMockRecordedCalls(t *testing.T) {
+	s := &mockRPCServer{calls: map[string]*mockCall{
+		mockCallKey("getblockcount", nil): {
+			Method: "getblockcount",
+			Result: json.RawMessage(`100`),
+		},
+		mockCallKey("getblockhash", []json.RawMessage{json.RawMessage(`5`)}): {
+			Method: "getblockhash",
+			Params: []json.RawMessage{json.RawMessage(`5`)},
+			Error: hcashjson.NewRPCError(hcashjson.ErrRPCOutOfRange,
+				"out of range"),
+		},
+	}}
+
+	tests := []struct {
+		request string
+		result  string
+		code    hcashjson.RPCErrorCode
+	}{
+		{`{"jsonrpc":"1.0","method":"getblockcount","params":[],"id":1}`, `100`, 0},
+		{`{"jsonrpc":"1.0","method":"getblockhash","params":[ 5 ],"id":2}`, ``, hcashjson.ErrRPCOutOfRange},
+		{`{"jsonrpc":"1.0","method":"getblockhash","params":[6],"id":3}`, `""`, 0},
+		{`{"jsonrpc":"1.0","method":"getblockhash","params":["x"],"id":4}`, ``, hcashjson.ErrRPCInvalidParameter},
+		{`{"jsonrpc":"1.0","method":"nosuchmethod","params":[],"id":5}`, ``, hcashjson.ErrRPCMethodNotFound.Code},
+	}
+	for i, test := range tests {
+		var resp hcashjson.Response
+		if err := json.Unmarshal(s.respond([]byte(test.request)), &resp); err != nil {
+			t.Fatalf("#%d: malformed response: %v", i, err)
+		}
+		if test.code != 0 {
+			if resp.Error == nil || resp.Error.Code != test.code {
+				t.Fatalf("#%d: got error %v, want code %d", i,
+					resp.Error, test.code)
+			}
+			continue
+		}
+		if resp.Error != nil || string(resp.Result) != test.result {
+			t.Fatalf("#%d: got result %s (error %v), want %s", i,
+				resp.Result, resp.Error, test.result)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/hcashjson"
+)
+
+const (
+	// restPathPrefix is the path prefix of all REST endpoints.
+	restPathPrefix = "/rest/"
+
+	// restMaxHeaders is the maximum number of headers which may be
+	// requested from the headers REST endpoint at once.
+	restMaxHeaders = 2000
+)
+
+// Formats of the responses of the REST endpoints, which are selected by the
+// extension of the request path.
+const (
+	restFormatHex  = "hex"
+	restFormatJSON = "json"
+)
+
+// restUsage describes the path expected by each REST endpoint.
+var restUsage = map[string]string{
+	"block":   "block/<hash>.<hex|json>",
+	"headers": "headers/<count>/<hash>.<hex|json>",
+	"tx":      "tx/<hash>.<hex|json>",
+}
+
+// restRequest describes a parsed request to one of the REST endpoints.
+type restRequest struct {
+	resource string
+	params   []string
+	format   string
+}
+
+// parseRESTPath parses the passed URL path of a REST request of the form
+// /rest/<resource>/<params...>[.<format>].  The format defaults to JSON when
+// it is omitted.
+func parseRESTPath(path string) (*restRequest, error) {
+	if !strings.HasPrefix(path, restPathPrefix) {
+		return nil, errors.New("not a REST path")
+	}
+	path = path[len(restPathPrefix):]
+
+	format := restFormatJSON
+	if i := strings.LastIndex(path, "."); i != -1 {
+		format = path[i+1:]
+		path = path[:i]
+		if format != restFormatHex && format != restFormatJSON {
+			return nil, fmt.Errorf("unsupported format %q "+
+				"(available formats: %s, %s)", format,
+				restFormatHex, restFormatJSON)
+		}
+	}
+
+	parts := strings.Split(path, "/")
+	req := &restRequest{resource: parts[0], params: parts[1:], format: format}
+	var wantParams int
+	switch req.resource {
+	case "block", "tx":
+		wantParams = 1
+	case "headers":
+		wantParams = 2
+	default:
+		return nil, fmt.Errorf("unknown resource %q", req.resource)
+	}
+	invalid := len(req.params) != wantParams
+	for _, param := range req.params {
+		invalid = invalid || param == ""
+	}
+	if invalid {
+		return nil, fmt.Errorf("invalid path, expected %s%s",
+			restPathPrefix, restUsage[req.resource])
+	}
+
+	return req, nil
+}
+
+// restStatusCode returns the HTTP status code the passed error returned by an
+// RPC handler is reported with.
+func restStatusCode(err error) int {
+	jsonErr, ok := err.(*hcashjson.RPCError)
+	if !ok {
+		return http.StatusInternalServerError
+	}
+	switch jsonErr.Code {
+	case hcashjson.ErrRPCBlockNotFound:
+		// This is also the code of ErrRPCNoTxInfo.
+		return http.StatusNotFound
+	case hcashjson.ErrRPCDecodeHexString, hcashjson.ErrRPCInvalidParameter:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// restError responds to a REST request with the passed error.
+func restError(w http.ResponseWriter, err error, code int) {
+	if jsonErr, ok := err.(*hcashjson.RPCError); ok {
+		http.Error(w, jsonErr.Message, code)
+		return
+	}
+	http.Error(w, err.Error(), code)
+}
+
+// RESTHandler serves the read-only REST endpoints, which provide blocks,
+// headers, and transactions without requiring authentication.  The responses
+// are produced by the handlers of the corresponding RPC commands.
+func (s *rpcServer) RESTHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "405 Method not allowed.",
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Limit the number of connections to max allowed.
+	if s.limitConnections(w, r.RemoteAddr) {
+		return
+	}
+	s.incrementClients()
+	defer s.decrementClients()
+
+	req, err := parseRESTPath(r.URL.Path)
+	if err != nil {
+		restError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	verbose := req.format == restFormatJSON
+	var result interface{}
+	switch req.resource {
+	case "block":
+		result, err = handleGetBlock(s, &hcashjson.GetBlockCmd{
+			Hash:    req.params[0],
+			Verbose: &verbose,
+		}, nil)
+
+	case "headers":
+		result, err = s.restHeaders(req.params[0], req.params[1],
+			verbose)
+
+	case "tx":
+		verboseInt := 0
+		if verbose {
+			verboseInt = 1
+		}
+		result, err = handleGetRawTransaction(s,
+			&hcashjson.GetRawTransactionCmd{
+				Txid:    req.params[0],
+				Verbose: &verboseInt,
+			}, nil)
+	}
+	if err != nil {
+		restError(w, err, restStatusCode(err))
+		return
+	}
+
+	if req.format == restFormatHex {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(result.(string) + "\n"))
+		return
+	}
+	marshalled, err := json.Marshal(result)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal REST response: %v", err)
+		restError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(marshalled, '\n'))
+}
+
+// restHeaders returns up to the passed number of main chain headers starting
+// with the header of the block with the passed hash.  They are returned as a
+// single hex string of the concatenated serialized headers, or as a slice of
+// verbose header results when verbose is true.
+func (s *rpcServer) restHeaders(countStr, hashStr string, verbose bool) (interface{}, error) {
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 1 || count > restMaxHeaders {
+		return nil, hcashjson.NewRPCError(hcashjson.ErrRPCInvalidParameter,
+			fmt.Sprintf("Header count must be between 1 and %d",
+				restMaxHeaders))
+	}
+	hash, err := chainhash.NewHashFromStr(hashStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hashStr)
+	}
+	height, err := s.chain.BlockHeightByHash(hash)
+	if err != nil {
+		return nil, hcashjson.NewRPCError(hcashjson.ErrRPCBlockNotFound,
+			fmt.Sprintf("Block not found in the main chain: %v", hash))
+	}
+
+	best := s.chain.BestSnapshot()
+	if end := best.Height - height + 1; int64(count) > end {
+		count = int(end)
+	}
+	hexHeaders := make([]string, 0, count)
+	verboseHeaders := make([]interface{}, 0, count)
+	for i := 0; i < count; i++ {
+		hash, err := s.chain.BlockHashByHeight(height + int64(i))
+		if err != nil {
+			// The chain was reorganized while fetching the headers.
+			break
+		}
+		header, err := handleGetBlockHeader(s, &hcashjson.GetBlockHeaderCmd{
+			Hash:    hash.String(),
+			Verbose: &verbose,
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if verbose {
+			verboseHeaders = append(verboseHeaders, header)
+		} else {
+			hexHeaders = append(hexHeaders, header.(string))
+		}
+	}
+
+	if verbose {
+		return verboseHeaders, nil
+	}
+	return strings.Join(hexHeaders, ""), nil
+}
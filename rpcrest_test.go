@@ -0,0 +1,64 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseRESTPath ensures REST request paths are split into their resource,
+// parameters, and format, and that malformed paths are rejected.
+func TestParseRESTPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want *restRequest
+	}{
+		{
+			path: "/rest/block/abcd.hex",
+			want: &restRequest{"block", []string{"abcd"}, restFormatHex},
+		},
+		{
+			path: "/rest/block/abcd.json",
+			want: &restRequest{"block", []string{"abcd"}, restFormatJSON},
+		},
+		{
+			path: "/rest/tx/abcd",
+			want: &restRequest{"tx", []string{"abcd"}, restFormatJSON},
+		},
+		{
+			path: "/rest/headers/5/abcd.hex",
+			want: &restRequest{"headers", []string{"5", "abcd"},
+				restFormatHex},
+		},
+		{path: "/rest/block/abcd.bin"},
+		{path: "/rest/block/abcd/efgh.hex"},
+		{path: "/rest/block/.hex"},
+		{path: "/rest/headers/abcd.json"},
+		{path: "/rest/headers//abcd.json"},
+		{path: "/rest/utxos/abcd.json"},
+		{path: "/rest/"},
+		{path: "/block/abcd.hex"},
+	}
+
+	for _, test := range tests {
+		got, err := parseRESTPath(test.path)
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("%s: parsed invalid path as %+v", test.path,
+					got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.path, got,
+				test.want)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"crypto/sha256"
 	"crypto/subtle"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/hex"
@@ -36,12 +37,15 @@ import (
 	"github.com/btcsuite/websocket"
 
 	"github.com/HcashOrg/bitset"
+	"github.com/HcashOrg/hcashd/addrmgr"
 	"github.com/HcashOrg/hcashd/blockchain"
 	"github.com/HcashOrg/hcashd/blockchain/stake"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainec"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/database"
+	"github.com/HcashOrg/hcashd/hcashec/secp256k1"
 	"github.com/HcashOrg/hcashd/hcashjson"
 	"github.com/HcashOrg/hcashd/mempool"
 	"github.com/HcashOrg/hcashd/mining"
@@ -99,6 +103,23 @@ const (
 	// in the memory pool.
 	gbtRegenerateSeconds = 60
 
+	// gbtDeltaHistorySize is the maximum number of recently generated block
+	// templates for which the transaction set is retained so the
+	// gettemplatedelta RPC can compute changes relative to them.
+	gbtDeltaHistorySize = 16
+
+	// maxBlockIndexEntries is the maximum number of entries that may be
+	// requested by a single getblockindex request.
+	maxBlockIndexEntries = 10000
+
+	// maxGetRawTransactions is the maximum number of transactions that may
+	// be requested by a single getrawtransactions request.
+	maxGetRawTransactions = 1000
+
+	// maxExistsAddresses is the maximum number of addresses that may be
+	// queried by a single existsaddresses request.
+	maxExistsAddresses = 10000
+
 	// merkleRootPairSize
 	merkleRootPairSize = 64
 
@@ -139,7 +160,7 @@ var (
 	// data.
 	gbtCoinbaseAux = &hcashjson.GetBlockTemplateResultAux{
 		Flags: hex.EncodeToString(builderScript(txscript.
-			NewScriptBuilder().AddData([]byte(coinbaseFlags)))),
+			NewScriptBuilder().AddData([]byte(mining.CoinbaseFlags)))),
 	}
 
 	// gbtCapabilities describes additional capabilities returned with a
@@ -178,14 +199,18 @@ type commandHandler func(*rpcServer, interface{}, <-chan struct{}) (interface{},
 var rpcHandlers map[string]commandHandler
 var rpcHandlersBeforeInit = map[string]commandHandler{
 	"addnode":               handleAddNode,
+	"addrevocationscript":   handleAddRevocationScript,
+	"auditchain":            handleAuditChain,
 	"createrawsstx":         handleCreateRawSStx,
 	"createrawssgentx":      handleCreateRawSSGenTx,
 	"createrawssrtx":        handleCreateRawSSRtx,
 	"createrawtransaction":  handleCreateRawTransaction,
 	"debuglevel":            handleDebugLevel,
+	"debugscript":           handleDebugScript,
 	"decoderawtransaction":  handleDecodeRawTransaction,
 	"decodescript":          handleDecodeScript,
 	"estimatefee":           handleEstimateFee,
+	"estimatefinality":      handleEstimateFinality,
 	"estimatestakediff":     handleEstimateStakeDiff,
 	"existsaddress":         handleExistsAddress,
 	"existsaddresses":       handleExistsAddresses,
@@ -194,18 +219,29 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"existsliveticket":      handleExistsLiveTicket,
 	"existslivetickets":     handleExistsLiveTickets,
 	"existsmempooltxs":      handleExistsMempoolTxs,
+	"expiredtickets":        handleExpiredTickets,
 	"generate":              handleGenerate,
 	"getaddednodeinfo":      handleGetAddedNodeInfo,
+	"getaddresshistory":     handleGetAddressHistory,
 	"getbestblock":          handleGetBestBlock,
 	"getbestblockhash":      handleGetBestBlockHash,
 	"getblock":              handleGetBlock,
+	"getblockchaininfo":     handleGetBlockchainInfo,
 	"getblockcount":         handleGetBlockCount,
 	"getblockhash":          handleGetBlockHash,
-	"getkeyblockhash":		 handleGetKeyBlockHash,
+	"getkeyblockattestation": handleGetKeyBlockAttestation,
+	"getkeyblockhash":       handleGetKeyBlockHash,
+	"getkeyblockinfo":       handleGetKeyBlockInfo,
 	"getblockheader":        handleGetBlockHeader,
+	"getblockindex":         handleGetBlockIndex,
 	"getblockkeyheight":	 handleGetBlockKeyHeight,
 	"getblocksubsidy":       handleGetBlockSubsidy,
+	"getblocktemplate":      handleGetBlockTemplate,
+	"getcfheaders":          handleGetCFHeaders,
+	"getcfilter":            handleGetCFilter,
+	"getchaintips":          handleGetChainTips,
 	"getcoinsupply":         handleGetCoinSupply,
+	"getcpuminerinfo":       handleGetCPUMinerInfo,
 	"getconnectioncount":    handleGetConnectionCount,
 	"getcurrentnet":         handleGetCurrentNet,
 	"getdifficulty":         handleGetDifficulty,
@@ -217,17 +253,31 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getmininginfo":         handleGetMiningInfo,
 	"getnettotals":          handleGetNetTotals,
 	"getnetworkhashps":      handleGetNetworkHashPS,
+	"getknownaddresses":     handleGetKnownAddresses,
+	"getlotteryproof":       handleGetLotteryProof,
+	"getnotices":            handleGetNotices,
+	"getrpcauthinfo":        handleGetRPCAuthInfo,
+	"getsigcacheinfo":       handleGetSigCacheInfo,
 	"getpeerinfo":           handleGetPeerInfo,
 	"getrawmempool":         handleGetRawMempool,
 	"getrawtransaction":     handleGetRawTransaction,
+	"getrawtransactions":    handleGetRawTransactions,
 	"getstakedifficulty":    handleGetStakeDifficulty,
+	"getstakenodecacheinfo": handleGetStakeNodeCacheInfo,
 	"getstakeversioninfo":   handleGetStakeVersionInfo,
 	"getstakeversions":      handleGetStakeVersions,
+	"gettemplatedelta":      handleGetTemplateDelta,
 	"getticketpoolvalue":    handleGetTicketPoolValue,
+	"gettxacceptancescore":  handleGetTxAcceptanceScore,
 	"getvoteinfo":           handleGetVoteInfo,
+	"getvotestats":          handleGetVoteStats,
 	"gettxout":              handleGetTxOut,
+	"gettxoutsetinfo":       handleGetTxOutSetInfo,
 	"getwork":               handleGetWork,
 	"help":                  handleHelp,
+	"listbanned":            handleListBanned,
+	"listlivetickets":       handleListLiveTickets,
+	"listener":              handleListener,
 	"livetickets":           handleLiveTickets,
 	"missedtickets":         handleMissedTickets,
 	"node":                  handleNode,
@@ -235,8 +285,12 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"searchrawtransactions": handleSearchRawTransactions,
 	"rebroadcastmissed":     handleRebroadcastMissed,
 	"rebroadcastwinners":    handleRebroadcastWinners,
+	"reloadconfig":          handleReloadConfig,
+	"restart":               handleRestart,
 	"sendrawtransaction":    handleSendRawTransaction,
+	"setban":                handleSetBan,
 	"setgenerate":           handleSetGenerate,
+	"setminingaddresses":    handleSetMiningAddresses,
 	"stop":                  handleStop,
 	"submitblock":           handleSubmitBlock,
 	"ticketfeeinfo":         handleTicketFeeInfo,
@@ -272,7 +326,6 @@ var rpcAskWallet = map[string]struct{}{
 	"getstakeinfo":            {},
 	"getvotechoices":          {},
 	"gettransaction":          {},
-	"gettxoutsetinfo":         {},
 	"getunconfirmedbalance":   {},
 	"importprivkey":           {},
 	"keypoolrefill":           {},
@@ -304,51 +357,66 @@ var rpcAskWallet = map[string]struct{}{
 
 // Commands that are currently unimplemented, but should ultimately be.
 var rpcUnimplemented = map[string]struct{}{
-	"estimatefee":       {},
-	"estimatepriority":  {},
-	"getblocktemplate":  {},
-	"getblockchaininfo": {},
-	"getchaintips":      {},
-	"getnetworkinfo":    {},
+	"estimatefee":      {},
+	"estimatepriority": {},
+	"getnetworkinfo":   {},
 }
 
-// Commands that are available to a limited user
+// Commands that are available to a limited user unless the methods available
+// to it are configured with the rpclimitmethod option.
 var rpcLimited = map[string]struct{}{
 	// Websockets commands
-	"notifyblocks":          {},
-	"notifynewtransactions": {},
-	"notifyreceived":        {},
-	"notifyspent":           {},
-	"rescan":                {},
-	"session":               {},
+	"getblockstream":              {},
+	"notifyblocks":                {},
+	"notifynewtransactions":       {},
+	"notifyreceived":              {},
+	"notifyspent":                 {},
+	"rescan":                      {},
+	"searchrawtransactionsstream": {},
+	"session":                     {},
 
 	// Websockets AND HTTP/S commands
 	"help": {},
 
 	// HTTP/S-only commands
-	"createrawtransaction":  {},
-	"decoderawtransaction":  {},
-	"decodescript":          {},
-	"getbestblock":          {},
-	"getbestblockhash":      {},
-	"getblock":              {},
-	"getblockcount":         {},
-	"getblockhash":          {},
-	"getcurrentnet":         {},
-	"getdifficulty":         {},
-	"getinfo":               {},
-	"getnettotals":          {},
-	"getnetworkhashps":      {},
-	"getrawmempool":         {},
-	"getrawtransaction":     {},
-	"gettxout":              {},
-	"searchrawtransactions": {},
-	"sendrawtransaction":    {},
-	"submitblock":           {},
-	"validateaddress":       {},
-	"verifymessage":         {},
-	"verifyblissmessage":    {},
-	"version":               {},
+	"createrawtransaction":   {},
+	"decoderawtransaction":   {},
+	"decodescript":           {},
+	"existsaddress":          {},
+	"existsaddresses":        {},
+	"getbestblock":           {},
+	"getbestblockhash":       {},
+	"getblock":               {},
+	"getblockchaininfo":      {},
+	"getblockcount":          {},
+	"getblockhash":           {},
+	"getblockindex":          {},
+	"getcfheaders":           {},
+	"getcfilter":             {},
+	"getchaintips":           {},
+	"getcurrentnet":          {},
+	"getdifficulty":          {},
+	"getinfo":                {},
+	"getkeyblockattestation": {},
+	"getkeyblockinfo":        {},
+	"getnettotals":           {},
+	"getnetworkhashps":       {},
+	"getlotteryproof":        {},
+	"getnotices":             {},
+	"getrawmempool":          {},
+	"getrawtransaction":      {},
+	"getrawtransactions":     {},
+	"gettxacceptancescore":   {},
+	"gettxout":               {},
+	"gettxoutsetinfo":        {},
+	"getvotestats":           {},
+	"searchrawtransactions":  {},
+	"sendrawtransaction":     {},
+	"submitblock":            {},
+	"validateaddress":        {},
+	"verifymessage":          {},
+	"verifyblissmessage":     {},
+	"version":                {},
 }
 
 // builderScript is a convenience function which is used for hard-coded scripts
@@ -457,18 +525,34 @@ type gbtWorkState struct {
 	lastTxUpdate  time.Time
 	lastGenerated time.Time
 	prevHash      *chainhash.Hash
+	prevKeyHash   *chainhash.Hash
 	minTimestamp  time.Time
-	template      *BlockTemplate
+	template      *mining.BlockTemplate
 	notifyMap     map[chainhash.Hash]map[int64]chan struct{}
 	timeSource    blockchain.MedianTimeSource
+
+	// pendingTxUpdate is the most recent memory pool update that has not
+	// yet been sent to long polling clients because the current template
+	// was generated less than gbtRegenerateSeconds ago.  pendingNotify is
+	// the timer that notifies them once that is no longer the case.
+	pendingTxUpdate time.Time
+	pendingNotify   *time.Timer
+
+	// templateTxs houses the hashes of the non-coinbase transactions of the
+	// most recently generated templates keyed by their template ID.  The
+	// template IDs are also tracked in generation order so the oldest entry
+	// can be evicted once gbtDeltaHistorySize is exceeded.
+	templateTxs   map[string]map[chainhash.Hash]struct{}
+	templateOrder []string
 }
 
 // newGbtWorkState returns a new instance of a gbtWorkState with all internal
 // fields initialized and ready to use.
 func newGbtWorkState(timeSource blockchain.MedianTimeSource) *gbtWorkState {
 	return &gbtWorkState{
-		notifyMap:  make(map[chainhash.Hash]map[int64]chan struct{}),
-		timeSource: timeSource,
+		notifyMap:   make(map[chainhash.Hash]map[int64]chan struct{}),
+		timeSource:  timeSource,
+		templateTxs: make(map[string]map[chainhash.Hash]struct{}),
 	}
 }
 
@@ -613,6 +697,231 @@ func messageToHex(msg wire.Message) (string, error) {
 	return hex.EncodeToString(buf.Bytes()), nil
 }
 
+// chainAudit houses the state of the background chain audit started through
+// the auditchain RPC.  Only one audit may run at a time.
+type chainAudit struct {
+	sync.Mutex
+	running       bool
+	checks        []string
+	startHeight   int64
+	endHeight     int64
+	currentHeight int64
+	startTime     time.Time
+	endTime       time.Time
+	result        *blockchain.AuditResult
+	err           error
+	quit          chan struct{}
+}
+
+// start launches a background audit of the passed checks over the given
+// height range.  The audit goroutine is tracked by the passed wait group.  An
+// error is returned if an audit is already running.
+func (a *chainAudit) start(wg *sync.WaitGroup, chain *blockchain.BlockChain, checks []blockchain.AuditCheck, startHeight, endHeight int64) error {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.running {
+		return errors.New("a chain audit is already running")
+	}
+
+	// Resolve the end height now so progress can be reported against it.
+	best := chain.BestSnapshot()
+	if endHeight < 0 || endHeight > best.Height {
+		endHeight = best.Height
+	}
+	if startHeight < 0 || startHeight > endHeight {
+		return fmt.Errorf("start height %d is not in the range 0-%d",
+			startHeight, endHeight)
+	}
+
+	names := make([]string, 0, len(checks))
+	for _, check := range checks {
+		names = append(names, string(check))
+	}
+
+	a.running = true
+	a.checks = names
+	a.startHeight = startHeight
+	a.endHeight = endHeight
+	a.currentHeight = startHeight - 1
+	a.startTime = time.Now()
+	a.endTime = time.Time{}
+	a.result = nil
+	a.err = nil
+	a.quit = make(chan struct{})
+
+	wg.Add(1)
+	go func(quit chan struct{}) {
+		defer wg.Done()
+
+		rpcsLog.Infof("Starting chain audit (%s) of heights %d-%d",
+			strings.Join(names, ", "), startHeight, endHeight)
+
+		progress := func(height int64) {
+			a.Lock()
+			a.currentHeight = height
+			a.Unlock()
+		}
+		result, err := chain.AuditChain(checks, startHeight, endHeight,
+			progress, quit)
+
+		a.Lock()
+		a.running = false
+		a.endTime = time.Now()
+		a.result = result
+		a.err = err
+		a.Unlock()
+
+		switch {
+		case err != nil:
+			rpcsLog.Warnf("Chain audit failed: %v", err)
+		case len(result.Violations) > 0:
+			for _, v := range result.Violations {
+				rpcsLog.Warnf("Chain audit: %v", v)
+			}
+		default:
+			rpcsLog.Infof("Chain audit of heights %d-%d completed "+
+				"without violations", startHeight, endHeight)
+		}
+	}(a.quit)
+
+	return nil
+}
+
+// stop interrupts the running audit, if any.
+func (a *chainAudit) stop() {
+	a.Lock()
+	if a.running && a.quit != nil {
+		close(a.quit)
+		a.quit = nil
+	}
+	a.Unlock()
+}
+
+// status returns the progress of the running or most recent audit.
+func (a *chainAudit) status() *hcashjson.AuditChainResult {
+	a.Lock()
+	defer a.Unlock()
+
+	status := &hcashjson.AuditChainResult{
+		Running:       a.running,
+		Checks:        a.checks,
+		StartHeight:   a.startHeight,
+		EndHeight:     a.endHeight,
+		CurrentHeight: a.currentHeight,
+		Violations:    []hcashjson.AuditChainViolation{},
+	}
+	if status.Checks == nil {
+		status.Checks = []string{}
+	}
+	if !a.startTime.IsZero() {
+		status.StartTime = a.startTime.Unix()
+	}
+	if !a.endTime.IsZero() {
+		status.EndTime = a.endTime.Unix()
+	}
+	if !a.startTime.IsZero() {
+		done := a.currentHeight - a.startHeight + 1
+		total := a.endHeight - a.startHeight + 1
+		status.Progress = float64(done) / float64(total)
+	}
+	if a.err != nil {
+		status.Error = a.err.Error()
+	}
+	if a.result != nil {
+		status.TotalSubsidy = a.result.TotalSubsidy
+		for _, v := range a.result.Violations {
+			status.Violations = append(status.Violations,
+				hcashjson.AuditChainViolation{
+					Check:       string(v.Check),
+					Height:      v.Height,
+					Hash:        v.Hash.String(),
+					Description: v.Description,
+				})
+		}
+	}
+	return status
+}
+
+// handleAddRevocationScript implements the addrevocationscript command.
+func handleAddRevocationScript(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.AddRevocationScriptCmd)
+
+	if s.server.revoker == nil {
+		return nil, rpcMiscError("Automatic revocations are disabled -- " +
+			"restart with --autorevoke to enable them")
+	}
+
+	redeemScript, err := hex.DecodeString(c.RedeemScript)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.RedeemScript)
+	}
+	var sigScriptPrefix []byte
+	if c.SigScriptPrefix != nil {
+		sigScriptPrefix, err = hex.DecodeString(*c.SigScriptPrefix)
+		if err != nil {
+			return nil, rpcDecodeHexError(*c.SigScriptPrefix)
+		}
+	}
+
+	addr, err := s.server.revoker.AddScript(redeemScript, sigScriptPrefix)
+	if err != nil {
+		return nil, rpcInvalidError("Unable to register revocation "+
+			"script: %v", err)
+	}
+	return addr.EncodeAddress(), nil
+}
+
+// handleAuditChain implements the auditchain command.
+func handleAuditChain(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.AuditChainCmd)
+
+	switch c.SubCmd {
+	case hcashjson.ACStart:
+		checks := blockchain.AuditChecks
+		if c.Checks != nil && len(*c.Checks) > 0 {
+			checks = make([]blockchain.AuditCheck, 0, len(*c.Checks))
+			for _, name := range *c.Checks {
+				check := blockchain.AuditCheck(name)
+				known := false
+				for _, supported := range blockchain.AuditChecks {
+					if check == supported {
+						known = true
+						break
+					}
+				}
+				if !known {
+					return nil, rpcInvalidError("Unknown audit "+
+						"check %q", name)
+				}
+				checks = append(checks, check)
+			}
+		}
+
+		startHeight, endHeight := int64(0), int64(-1)
+		if c.StartHeight != nil {
+			startHeight = *c.StartHeight
+		}
+		if c.EndHeight != nil {
+			endHeight = *c.EndHeight
+		}
+		err := s.chainAudit.start(&s.wg, s.chain, checks, startHeight, endHeight)
+		if err != nil {
+			return nil, rpcMiscError(err.Error())
+		}
+
+	case hcashjson.ACStop:
+		s.chainAudit.stop()
+
+	case hcashjson.ACStatus:
+
+	default:
+		return nil, rpcInvalidError("Invalid subcommand for auditchain")
+	}
+
+	return s.chainAudit.status(), nil
+}
+
 // handleCreateRawTransaction handles createrawtransaction commands.
 func handleCreateRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*hcashjson.CreateRawTransactionCmd)
@@ -1403,20 +1712,35 @@ func getTxType(tx *wire.MsgTx) hcashjson.ListTransactionsTxType {
 	return hcashjson.LTTTRegular
 }
 
-func calcFee(tx *wire.MsgTx) float64 {
-	var outputTotal hcashutil.Amount
-	for _, output := range tx.TxOut {
-		outputTotal += hcashutil.Amount(output.Value)
-	}
-	var inputTotal hcashutil.Amount
+// txFee returns the difference between the input and output amounts of a
+// transaction that has its fraud proofs properly set.  An error is returned
+// when the totals do not fit in an amount.
+func txFee(tx *wire.MsgTx) (hcashutil.Amount, error) {
+	var inputTotal, outputTotal hcashutil.Amount
+	var err error
 	for _, input := range tx.TxIn {
-		inputTotal += hcashutil.Amount(input.ValueIn)
+		inputTotal, err = addAmounts(inputTotal,
+			hcashutil.Amount(input.ValueIn))
+		if err != nil {
+			return 0, err
+		}
+	}
+	for _, output := range tx.TxOut {
+		outputTotal, err = addAmounts(outputTotal,
+			hcashutil.Amount(output.Value))
+		if err != nil {
+			return 0, err
+		}
 	}
-	fee64 := (inputTotal - outputTotal).ToCoin()
-	if fee64 < 0 {
-		fee64 = 0
+	return subAmounts(inputTotal, outputTotal)
+}
+
+func calcFee(tx *wire.MsgTx) float64 {
+	fee, err := txFee(tx)
+	if err != nil || fee < 0 {
+		return 0
 	}
-	return fee64
+	return fee.ToCoin()
 }
 
 // handleDecodeRawTransaction handles decoderawtransaction commands.
@@ -1470,13 +1794,21 @@ func handleDecodeScript(s *rpcServer, cmd interface{}, closeChan <-chan struct{}
 	disbuf, _ := txscript.DisasmString(script)
 
 	// Get information about the script.
-	// Ignore the error here since an error means the script couldn't parse
-	// and there is no additinal information about it anyways.
+	// An error means the script couldn't parse and there is no additional
+	// information about it anyways, so it is reported as nonstandard.
 	// TODO Replace magic version with argument passed to RPC call
-	scriptClass, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(
-		txscript.DefaultScriptVersion, script, s.server.chainParams)
-	addresses := make([]string, len(addrs))
-	for i, addr := range addrs {
+	details, err := txscript.CalcScriptDetails(txscript.DefaultScriptVersion,
+		script, s.server.chainParams)
+	if err != nil {
+		details = &txscript.ScriptDetails{
+			Class:    txscript.NonStandardTy,
+			StakeTag: txscript.NonStandardTy,
+			SubClass: txscript.NonStandardTy,
+			SigType:  -1,
+		}
+	}
+	addresses := make([]string, len(details.Addresses))
+	for i, addr := range details.Addresses {
 		addresses[i] = addr.EncodeAddress()
 	}
 
@@ -1489,11 +1821,108 @@ func handleDecodeScript(s *rpcServer, cmd interface{}, closeChan <-chan struct{}
 
 	// Generate and return the reply.
 	reply := hcashjson.DecodeScriptResult{
-		Asm:       disbuf,
-		ReqSigs:   int32(reqSigs),
-		Type:      scriptClass.String(),
-		Addresses: addresses,
-		P2sh:      p2sh.EncodeAddress(),
+		Asm:          disbuf,
+		ReqSigs:      int32(details.RequiredSigs),
+		Type:         details.Class.String(),
+		DetailedType: details.TypeString(),
+		Addresses:    addresses,
+		P2sh:         p2sh.EncodeAddress(),
+	}
+
+	// Add the annotated disassembly when the verbose flag is set.  Like
+	// above, it contains [error] inline when the script doesn't parse.
+	if c.Verbose != nil && *c.Verbose {
+		reply.AsmAnnotated, _ = txscript.DisasmStringAnnotated(script,
+			s.server.chainParams)
+	}
+	return reply, nil
+}
+
+// debugScriptTracer is a txscript.Tracer which records the steps of a script
+// execution for the debugscript command.
+type debugScriptTracer struct {
+	steps []hcashjson.DebugScriptStep
+}
+
+// hexStack returns the hex encoding of the passed stack contents.
+func hexStack(items [][]byte) []string {
+	stack := make([]string, len(items))
+	for i, item := range items {
+		stack[i] = hex.EncodeToString(item)
+	}
+	return stack
+}
+
+// TraceStep records the passed step.
+//
+// This is part of the txscript.Tracer interface.
+func (t *debugScriptTracer) TraceStep(step *txscript.TraceStep) {
+	debugStep := hcashjson.DebugScriptStep{
+		Script:   step.ScriptIdx,
+		Offset:   step.ScriptOff,
+		Opcode:   step.Opcode,
+		Executed: step.Executed,
+		Stack:    hexStack(step.Stack),
+		AltStack: hexStack(step.AltStack),
+	}
+	if step.Err != nil {
+		debugStep.Error = step.Err.Error()
+	}
+	t.steps = append(t.steps, debugStep)
+}
+
+// handleDebugScript implements the debugscript command.
+func handleDebugScript(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.DebugScriptCmd)
+
+	// Deserialize the transaction.
+	hexStr := c.HexTx
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	serializedTx, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+	var mtx wire.MsgTx
+	err = mtx.Deserialize(bytes.NewReader(serializedTx))
+	if err != nil {
+		return nil, rpcDeserializationError("Could not decode Tx: %v",
+			err)
+	}
+
+	// Convert the hex public key script to bytes.
+	hexStr = c.PkScript
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	pkScript, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+
+	// Execute the scripts with the flags used for standard transactions
+	// while tracing every step.  The signature cache is not used so every
+	// signature is really verified.
+	vm, err := txscript.NewEngine(pkScript, &mtx, c.InputIndex,
+		txscript.StandardVerifyFlags, *c.ScriptVersion, nil)
+	if err != nil {
+		return nil, rpcInvalidError("Could not create script engine: %v",
+			err)
+	}
+	var tracer debugScriptTracer
+	vm.SetTracer(&tracer)
+	err = vm.Execute()
+
+	reply := &hcashjson.DebugScriptResult{
+		Valid: err == nil,
+		Steps: tracer.steps,
+	}
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	if reply.Steps == nil {
+		reply.Steps = []hcashjson.DebugScriptStep{}
 	}
 	return reply, nil
 }
@@ -1502,7 +1931,27 @@ func handleDecodeScript(s *rpcServer, cmd interface{}, closeChan <-chan struct{}
 // TODO this is a very basic implementation.  It should be
 // modified to match the bitcoin-core one.
 func handleEstimateFee(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	return cfg.minRelayTxFee.ToCoin(), nil
+	return s.server.txMemPool.Policy().MinRelayTxFee.ToCoin(), nil
+}
+
+// handleEstimateFinality implements the estimatefinality command.
+func handleEstimateFinality(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.EstimateFinalityCmd)
+
+	est, err := s.chain.EstimateTimeToFinality(*c.HashFraction,
+		*c.StakeFraction, *c.TargetProbability)
+	if err != nil {
+		return nil, rpcInvalidError("Could not estimate time to "+
+			"finality: %v", err)
+	}
+
+	return &hcashjson.EstimateFinalityResult{
+		KeyBlocks:        est.KeyBlocks,
+		Seconds:          int64(est.Duration / time.Second),
+		ReorgProbability: est.ReorgProbability,
+		Participation:    est.Participation,
+		AttackerShare:    est.AttackerShare,
+	}, nil
 }
 
 // handleEstimateStakeDiff implements the estimatestakediff command.
@@ -1592,6 +2041,9 @@ func handleExistsAddress(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 		return nil, rpcAddressKeyError("Could not decode address: %v",
 			err)
 	}
+	if !addr.IsForNet(s.server.chainParams) {
+		return nil, rpcAddressKeyError("Wrong network: %v", addr)
+	}
 
 	exists, err := existsAddrIndex.ExistsAddress(addr)
 	if err != nil {
@@ -1610,6 +2062,10 @@ func handleExistsAddresses(s *rpcServer, cmd interface{}, closeChan <-chan struc
 	}
 
 	c := cmd.(*hcashjson.ExistsAddressesCmd)
+	if len(c.Addresses) > maxExistsAddresses {
+		return nil, rpcInvalidError("Too many addresses: %d > %d",
+			len(c.Addresses), maxExistsAddresses)
+	}
 	addresses := make([]hcashutil.Address, len(c.Addresses))
 	for i := range c.Addresses {
 		// Attempt to decode the supplied address.
@@ -1618,6 +2074,10 @@ func handleExistsAddresses(s *rpcServer, cmd interface{}, closeChan <-chan struc
 			return nil, rpcAddressKeyError("Could not decode "+
 				"address: %v", err)
 		}
+		if !addr.IsForNet(s.server.chainParams) {
+			return nil, rpcAddressKeyError("Wrong network: %v",
+				addr)
+		}
 		addresses[i] = addr
 	}
 
@@ -1884,6 +2344,85 @@ func handleGetAddedNodeInfo(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return results, nil
 }
 
+// handleGetAddressHistory implements the getaddresshistory command.  It returns
+// a page of the transactions involving an address that are confirmed in
+// blocks, ordered from the oldest to the newest.
+func handleGetAddressHistory(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Respond with an error if the address index is not enabled.
+	addrIndex := s.server.addrIndex
+	if addrIndex == nil {
+		return nil, rpcInternalError("Address index must be "+
+			"enabled (--addrindex)", "Configuration")
+	}
+
+	// Attempt to decode the supplied address.
+	c := cmd.(*hcashjson.GetAddressHistoryCmd)
+	addr, err := hcashutil.DecodeAddress(c.Address)
+	if err != nil {
+		return nil, rpcAddressKeyError("Could not decode address: %v",
+			err)
+	}
+	if !addr.IsForNet(s.server.chainParams) {
+		return nil, rpcAddressKeyError("Wrong network: %v", addr)
+	}
+
+	numTxns, err := addrIndex.NumTxnsForAddress(addr)
+	if err != nil {
+		context := "Failed to count address index entries"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+	start, end, nextCursor, err := hcashjson.PageBounds(int(numTxns),
+		c.Cursor, c.Limit)
+	if err != nil {
+		return nil, rpcInvalidError("%v", err)
+	}
+
+	// Load the transactions of the page from the database.
+	items := make([]hcashjson.AddressHistoryResult, 0, end-start)
+	if end > start {
+		var regions []database.BlockRegion
+		var serializedTxns [][]byte
+		err = s.server.db.View(func(dbTx database.Tx) error {
+			var err error
+			regions, _, err = addrIndex.TxRegionsForAddress(dbTx, addr,
+				uint32(start), uint32(end-start), false)
+			if err != nil {
+				return err
+			}
+			serializedTxns, err = dbTx.FetchBlockRegions(regions)
+			return err
+		})
+		if err != nil {
+			context := "Failed to load address index entries"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+
+		for i, serializedTx := range serializedTxns {
+			var mtx wire.MsgTx
+			if err := mtx.FromBytes(serializedTx); err != nil {
+				context := "Failed to deserialize transaction"
+				return nil, rpcInternalError(err.Error(), context)
+			}
+			height, err := s.chain.BlockHeightByHash(regions[i].Hash)
+			if err != nil {
+				context := "Failed to obtain block height"
+				return nil, rpcInternalError(err.Error(), context)
+			}
+			items = append(items, hcashjson.AddressHistoryResult{
+				TxID:        mtx.TxHash().String(),
+				BlockHash:   regions[i].Hash.String(),
+				BlockHeight: height,
+			})
+		}
+	}
+
+	return &hcashjson.GetAddressHistoryResult{
+		Items:      items,
+		NextCursor: nextCursor,
+		Total:      int64(numTxns),
+	}, nil
+}
+
 // handleGetBestBlock implements the getbestblock command.
 func handleGetBestBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// All other "get block" commands give either the height, the hash, or
@@ -1906,16 +2445,7 @@ func handleGetBestBlockHash(s *rpcServer, cmd interface{}, closeChan <-chan stru
 // getDifficultyRatio returns the proof-of-work difficulty as a multiple of the
 // minimum difficulty using the passed bits field from the header of a block.
 func getDifficultyRatio(bits uint32) float64 {
-	// The minimum difficulty is the max possible proof-of-work limit bits
-	// converted back to a number.  Note this is not the same as the proof
-	// of work limit directly because the block difficulty is encoded in a
-	// block with the compact form which loses precision.
-	max := blockchain.CompactToBig(activeNetParams.PowLimitBits)
-	target := blockchain.CompactToBig(bits)
-
-	difficulty := new(big.Rat).SetFrac(max, target)
-	outString := difficulty.FloatString(8)
-	diff, err := strconv.ParseFloat(outString, 64)
+	diff, err := standalone.DifficultyRatio(bits, activeNetParams.PowLimitBits)
 	if err != nil {
 		rpcsLog.Errorf("Cannot get difficulty: %v", err)
 		return 0
@@ -1923,40 +2453,40 @@ func getDifficultyRatio(bits uint32) float64 {
 	return diff
 }
 
-// getDifficultyRatio returns the proof-of-work difficulty as a multiple of the
-// minimum difficulty using the passed bits field from the header of a block.
+// getDifficultyRatioAndHashCount returns the proof-of-work difficulty as a
+// multiple of the minimum difficulty along with the expected number of hashes
+// needed to meet the target using the passed bits field from the header of a
+// block.
 func getDifficultyRatioAndHashCount(bits uint32) (float64, float64) {
-	// The minimum difficulty is the max possible proof-of-work limit bits
-	// converted back to a number.  Note this is not the same as the proof
-	// of work limit directly because the block difficulty is encoded in a
-	// block with the compact form which loses precision.
-	max := blockchain.CompactToBig(activeNetParams.PowLimitBits)
-	target := blockchain.CompactToBig(bits)
-
-	difficulty := new(big.Rat).SetFrac(max, target)
-	outString := difficulty.FloatString(8)
-	diff, err := strconv.ParseFloat(outString, 64)
+	diff := getDifficultyRatio(bits)
+	hashcount, err := standalone.HashCount(bits)
 	if err != nil {
-		rpcsLog.Errorf("Cannot get difficulty: %v", err)
-		return 0,0
-	}
-
-	limit,_ := new(big.Int).SetString("ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",16)
-	count := new(big.Rat).SetFrac(limit, target)
-	hashcountString := count.FloatString(8)
-	hashcount, error := strconv.ParseFloat(hashcountString, 64)
-	if error != nil {
 		rpcsLog.Errorf("Cannot get hashcount: %v", err)
-		return 0,0
+		return 0, 0
 	}
 
-	return diff,hashcount
+	return diff, hashcount
 }
 
 // handleGetBlock implements the getblock command.
 func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*hcashjson.GetBlockCmd)
+	return getBlock(s, c, nil)
+}
 
+// rpcResultStream receives the elements of a large result one at a time so
+// they can be sent to the client as they are created instead of the whole
+// result being built in memory first.
+type rpcResultStream interface {
+	// Send sends the passed element of the result to the client.
+	Send(elem interface{}) error
+}
+
+// getBlock returns the result of the passed getblock command.  When a stream
+// is passed, the verbose transactions of the block are sent to it one by one,
+// with the regular transactions first, and only their hashes are included in
+// the result.
+func getBlock(s *rpcServer, c *hcashjson.GetBlockCmd, stream rpcResultStream) (interface{}, error) {
 	// Load the raw block bytes from the database.
 	hash, err := chainhash.NewHashFromStr(c.Hash)
 	if err != nil {
@@ -2003,13 +2533,13 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 		}
 		//confirmations = 1 + best.Height - int64(blockHeader.Height)
 		confirmations = best.KeyHeight - int64(blockHeader.KeyHeight)
-		if blockchain.HashToBig(best.Hash).Cmp(blockchain.CompactToBig(best.Bits)) <= 0{
+		if standalone.HashToBig(best.Hash).Cmp(standalone.CompactToBig(best.Bits)) <= 0 {
 			confirmations++
 		}
 	}
 
 	isKeyBlock := false
-	if blockchain.HashToBig(blk.Hash()).Cmp(blockchain.CompactToBig(blockHeader.Bits)) <= 0{
+	if standalone.HashToBig(blk.Hash()).Cmp(standalone.CompactToBig(blockHeader.Bits)) <= 0 {
 		isKeyBlock = true
 	}
 
@@ -2038,7 +2568,7 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 	}
 
 	if isKeyBlock {
-		sbitsFloat := float64(blockHeader.SBits) / hcashutil.AtomsPerCoin
+		sbitsFloat := hcashutil.Amount(blockHeader.SBits).ToCoin()
 		blockReply.StakeRoot = blockHeader.StakeRoot.String()
 		blockReply.Voters = blockHeader.Voters
 		blockReply.FreshStake = blockHeader.FreshStake
@@ -2072,7 +2602,13 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 		blockReply.STx = stxNames
 	} else {
 		txns := blk.Transactions()
-		rawTxns := make([]hcashjson.TxRawResult, len(txns))
+		var rawTxns []hcashjson.TxRawResult
+		var txNames []string
+		if stream == nil {
+			rawTxns = make([]hcashjson.TxRawResult, 0, len(txns))
+		} else {
+			txNames = make([]string, 0, len(txns))
+		}
 		for i, tx := range txns {
 			rawTxn, err := createTxRawResult(s.server.chainParams,
 				tx.MsgTx(), tx.Hash().String(), uint32(i),
@@ -2082,15 +2618,29 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 				return nil, rpcInternalError(err.Error(),
 					"Could not create transaction")
 			}
-			rawTxns[i] = *rawTxn
+			if stream != nil {
+				if err := stream.Send(rawTxn); err != nil {
+					return nil, err
+				}
+				txNames = append(txNames, rawTxn.Txid)
+			} else {
+				rawTxns = append(rawTxns, *rawTxn)
+			}
 			if isCoinbase := blockchain.IsCoinBaseTx(tx.MsgTx()); isCoinbase && isKeyBlock {
 				rewardF64 += hcashutil.Amount(tx.MsgTx().TxIn[0].ValueIn).ToCoin()
 			}
 		}
 		blockReply.RawTx = rawTxns
+		blockReply.Tx = txNames
 
 		stxns := blk.STransactions()
-		rawSTxns := make([]hcashjson.TxRawResult, len(stxns))
+		var rawSTxns []hcashjson.TxRawResult
+		var stxNames []string
+		if stream == nil {
+			rawSTxns = make([]hcashjson.TxRawResult, 0, len(stxns))
+		} else {
+			stxNames = make([]string, 0, len(stxns))
+		}
 		for i, tx := range stxns {
 			rawSTxn, err := createTxRawResult(s.server.chainParams,
 				tx.MsgTx(), tx.Hash().String(), uint32(i),
@@ -2100,12 +2650,20 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 				return nil, rpcInternalError(err.Error(),
 					"Could not create stake transaction")
 			}
-			rawSTxns[i] = *rawSTxn
+			if stream != nil {
+				if err := stream.Send(rawSTxn); err != nil {
+					return nil, err
+				}
+				stxNames = append(stxNames, rawSTxn.Txid)
+			} else {
+				rawSTxns = append(rawSTxns, *rawSTxn)
+			}
 			if isSSGen, _ := stake.IsSSGen(tx.MsgTx()); isSSGen && isKeyBlock {
 				rewardF64 += hcashutil.Amount(tx.MsgTx().TxIn[0].ValueIn).ToCoin()
 			}
 		}
 		blockReply.RawSTx = rawSTxns
+		blockReply.STx = stxNames
 	}
 
 	blockReply.Reward = rewardF64
@@ -2118,6 +2676,59 @@ func handleGetBlockCount(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 	return best.Height, nil
 }
 
+// handleGetBlockchainInfo implements the getblockchaininfo command.
+func handleGetBlockchainInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	best := s.chain.BestSnapshot()
+
+	// The chain is only considered fully verified once it believes it is
+	// current.  Otherwise, report the progress as the fraction of the
+	// expected number of blocks given the time elapsed since the best
+	// block.
+	progress := 1.0
+	if !s.chain.IsCurrent() {
+		params := s.server.chainParams
+		elapsed := time.Since(best.MedianTime)
+		remaining := float64(elapsed / params.TargetTimePerBlock)
+		if best.Height > 0 && remaining > 0 {
+			progress = float64(best.Height) /
+				(float64(best.Height) + remaining)
+		}
+	}
+
+	// Report the threshold state of every agenda for the next block.
+	deployments := make(map[string]hcashjson.AgendaInfo)
+	for version, agendas := range s.server.chainParams.Deployments {
+		for _, agenda := range agendas {
+			state, err := s.chain.NextThresholdState(best.Hash, version,
+				agenda.Vote.Id)
+			if err != nil {
+				return nil, rpcInternalError(err.Error(),
+					"Could not fetch threshold state")
+			}
+			deployments[agenda.Vote.Id] = hcashjson.AgendaInfo{
+				StakeVersion: version,
+				Status:       state.String(),
+				StartTime:    agenda.StartTime,
+				ExpireTime:   agenda.ExpireTime,
+			}
+		}
+	}
+
+	return &hcashjson.GetBlockChainInfoResult{
+		Chain:                s.server.chainParams.Name,
+		Blocks:               int32(best.Height),
+		Headers:              int32(best.Height),
+		BestBlockHash:        best.Hash.String(),
+		Difficulty:           getDifficultyRatio(best.Bits),
+		VerificationProgress: progress,
+		ChainWork:            fmt.Sprintf("%064x", s.chain.BestChainWork()),
+		Deployments:          deployments,
+		SigHashOptimization:  cfg.SigHashOptimization,
+		CheckDuplicateHashes: cfg.CheckDuplicateHashes,
+		Warnings:             s.chain.UnknownVoteVersionWarning(),
+	}, nil
+}
+
 // handleGetBlockHash implements the getblockhash command.
 func handleGetBlockHash(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*hcashjson.GetBlockHashCmd)
@@ -2155,7 +2766,7 @@ func handleGetKeyBlockHash(s *rpcServer, cmd interface{}, closeChan <-chan struc
 					keyHeight),
 			}
 		}
-		if blockchain.HashToBig(bestBlock.Hash()).Cmp(blockchain.CompactToBig(bestBlock.MsgBlock().Header.Bits)) > 0{
+		if standalone.HashToBig(bestBlock.Hash()).Cmp(standalone.CompactToBig(bestBlock.MsgBlock().Header.Bits)) > 0 {
 			return nil, &hcashjson.RPCError{
 				Code: hcashjson.ErrRPCOutOfRange,
 				Message: fmt.Sprintf("Key Height out of range: %v",
@@ -2236,35 +2847,183 @@ func handleGetKeyBlockHash(s *rpcServer, cmd interface{}, closeChan <-chan struc
 	return hash.String(), nil
 }
 
-// handleGetBlockHeader implements the getblockheader command.
-func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c := cmd.(*hcashjson.GetBlockHeaderCmd)
-
-	// Load the raw header bytes from the database.
-	hash, err := chainhash.NewHashFromStr(c.Hash)
-	if err != nil {
-		return nil, rpcDecodeHexError(c.Hash)
+// handleGetKeyBlockAttestation implements the getkeyblockattestation command.
+// It returns the main chain key block at the requested key height along with
+// the cumulative work of the main chain up to and including it, signed by the
+// attestation key of the node.
+func handleGetKeyBlockAttestation(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.GetKeyBlockAttestationCmd)
+	if c.KeyHeight < 0 || c.KeyHeight > s.chain.BestSnapshot().KeyHeight {
+		return nil, &hcashjson.RPCError{
+			Code: hcashjson.ErrRPCOutOfRange,
+			Message: fmt.Sprintf("Key Height out of range: %v",
+				c.KeyHeight),
+		}
 	}
-	var headerBytes []byte
-	err = s.server.db.View(func(dbTx database.Tx) error {
-		var err error
-		headerBytes, err = dbTx.FetchBlockHeader(hash)
-		return err
-	})
+
+	keyBlock, err := s.chain.MainChainKeyBlockWork(c.KeyHeight)
 	if err != nil {
 		return nil, &hcashjson.RPCError{
-			Code:    hcashjson.ErrRPCBlockNotFound,
-			Message: fmt.Sprintf("Block not found: %v", c.Hash),
+			Code: hcashjson.ErrRPCOutOfRange,
+			Message: fmt.Sprintf("Key block not found at key height "+
+				"%v: %v", c.KeyHeight, err),
 		}
 	}
 
-	// When the verbose flag isn't set, simply return the serialized block
-	// header as a hex-encoded string.
-	if c.Verbose != nil && !*c.Verbose {
-		return hex.EncodeToString(headerBytes), nil
+	attestation := &keyBlockAttestation{
+		network:   activeNetParams.Name,
+		keyHeight: c.KeyHeight,
+		height:    keyBlock.Height,
+		hash:      keyBlock.Hash,
+		chainWork: keyBlock.WorkSum,
+		timestamp: time.Now().Unix(),
+	}
+	sig, err := attestation.sign(s.attestationKey)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Could not sign attestation")
 	}
 
-	// The verbose flag is set, so generate the JSON object and return it.
+	pubKey := secp256k1.PublicKey(s.attestationKey.PublicKey)
+	serializedPK := pubKey.SerializeCompressed()
+	addr, err := hcashutil.NewAddressSecpPubKey(serializedPK,
+		activeNetParams.Params)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Could not encode attestation address")
+	}
+
+	return &hcashjson.GetKeyBlockAttestationResult{
+		Network:   attestation.network,
+		KeyHeight: attestation.keyHeight,
+		Height:    attestation.height,
+		Hash:      attestation.hash.String(),
+		ChainWork: fmt.Sprintf("%064x", attestation.chainWork),
+		Time:      attestation.timestamp,
+		PubKey:    hex.EncodeToString(serializedPK),
+		Address:   addr.EncodeAddress(),
+		Message:   attestation.message(),
+		Signature: base64.StdEncoding.EncodeToString(sig),
+	}, nil
+}
+
+// handleGetKeyBlockInfo implements the getkeyblockinfo command.  It returns the
+// microblocks anchored by a main chain key block along with their transaction
+// counts and the intervals between them.  When the passed hash identifies a
+// microblock, the key block anchoring it is described.
+func handleGetKeyBlockInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.GetKeyBlockInfoCmd)
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+
+	mainChain, err := s.chain.MainChainHasBlock(hash)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Could not look up block")
+	}
+	if !mainChain {
+		return nil, &hcashjson.RPCError{
+			Code:    hcashjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found in the main chain: %v", c.Hash),
+		}
+	}
+
+	info, err := s.chain.KeyBlockInfo(hash)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Could not obtain key block info")
+	}
+
+	result := &hcashjson.GetKeyBlockInfoResult{
+		Hash:        info.Hash.String(),
+		Height:      info.Height,
+		KeyHeight:   info.KeyHeight,
+		Time:        info.Timestamp.Unix(),
+		Tx:          info.NumTxns,
+		STx:         info.NumStakeTxns,
+		MicroBlocks: make([]hcashjson.MicroBlockInfoResult, 0, len(info.MicroBlocks)),
+		TotalTx:     info.NumTxns,
+		TotalSTx:    info.NumStakeTxns,
+	}
+
+	// Gather the intervals between each anchored block and the one before
+	// it, including the next key block which ends the anchored range.
+	var intervals []int64
+	prevTime := info.Timestamp
+	for _, micro := range info.MicroBlocks {
+		interval := int64(micro.Timestamp.Sub(prevTime).Seconds())
+		intervals = append(intervals, interval)
+		prevTime = micro.Timestamp
+
+		result.MicroBlocks = append(result.MicroBlocks, hcashjson.MicroBlockInfoResult{
+			Hash:     micro.Hash.String(),
+			Height:   micro.Height,
+			Time:     micro.Timestamp.Unix(),
+			Tx:       micro.NumTxns,
+			STx:      micro.NumStakeTxns,
+			Interval: interval,
+		})
+		result.TotalTx += micro.NumTxns
+		result.TotalSTx += micro.NumStakeTxns
+	}
+	if info.NextKeyBlock != nil {
+		result.NextKeyBlockHash = info.NextKeyBlock.Hash.String()
+		intervals = append(intervals,
+			int64(info.NextKeyBlock.Timestamp.Sub(prevTime).Seconds()))
+		prevTime = info.NextKeyBlock.Timestamp
+	}
+	result.Duration = int64(prevTime.Sub(info.Timestamp).Seconds())
+
+	if len(intervals) > 0 {
+		var sum int64
+		result.MinInterval = intervals[0]
+		result.MaxInterval = intervals[0]
+		for _, interval := range intervals {
+			sum += interval
+			if interval < result.MinInterval {
+				result.MinInterval = interval
+			}
+			if interval > result.MaxInterval {
+				result.MaxInterval = interval
+			}
+		}
+		result.MeanInterval = float64(sum) / float64(len(intervals))
+	}
+
+	return result, nil
+}
+
+// handleGetBlockHeader implements the getblockheader command.
+func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.GetBlockHeaderCmd)
+
+	// Load the raw header bytes from the database.
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+	var headerBytes []byte
+	err = s.server.db.View(func(dbTx database.Tx) error {
+		var err error
+		headerBytes, err = dbTx.FetchBlockHeader(hash)
+		return err
+	})
+	if err != nil {
+		return nil, &hcashjson.RPCError{
+			Code:    hcashjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found: %v", c.Hash),
+		}
+	}
+
+	// When the verbose flag isn't set, simply return the serialized block
+	// header as a hex-encoded string.
+	if c.Verbose != nil && !*c.Verbose {
+		return hex.EncodeToString(headerBytes), nil
+	}
+
+	// The verbose flag is set, so generate the JSON object and return it.
 
 	// Deserialize the header.
 	var blockHeader wire.BlockHeader
@@ -2323,6 +3082,56 @@ func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct
 	return blockHeaderReply, nil
 }
 
+// handleGetBlockIndex implements the getblockindex command.  It returns a page
+// of the memory block index, including side chain blocks, without the block
+// bodies so external tools can reconstruct the fork topology.
+func handleGetBlockIndex(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.GetBlockIndexCmd)
+
+	startHeight := *c.StartHeight
+	if startHeight < 0 {
+		return nil, rpcInvalidError("Invalid parameter, startheight "+
+			"must be >= 0: %d", startHeight)
+	}
+	count := *c.Count
+	if count <= 0 || count > maxBlockIndexEntries {
+		return nil, rpcInvalidError("Invalid parameter, count must "+
+			"be between 1 and %d: %d", maxBlockIndexEntries, count)
+	}
+
+	entries := s.chain.BlockIndex(startHeight, int(count))
+	result := hcashjson.GetBlockIndexResult{
+		Entries:    make([]hcashjson.BlockIndexEntryResult, 0, len(entries)),
+		NextHeight: startHeight,
+	}
+	for _, entry := range entries {
+		votes := make([]hcashjson.VersionBits, 0, len(entry.Votes))
+		for _, vote := range entry.Votes {
+			votes = append(votes, hcashjson.VersionBits{
+				Version: vote.Version,
+				Bits:    vote.Bits,
+			})
+		}
+		result.Entries = append(result.Entries, hcashjson.BlockIndexEntryResult{
+			Hash:         entry.Hash.String(),
+			Height:       entry.Height,
+			KeyHeight:    entry.KeyHeight,
+			PrevBlock:    entry.PrevBlock.String(),
+			PrevKeyBlock: entry.PrevKeyBlock.String(),
+			MainChain:    entry.Flags&blockchain.BIFMainChain != 0,
+			KeyBlock:     entry.Flags&blockchain.BIFKeyBlock != 0,
+			StakeNode:    entry.Flags&blockchain.BIFStakeNode != 0,
+			Voters:       entry.Voters,
+			Votes:        votes,
+		})
+	}
+	if len(entries) > 0 {
+		result.NextHeight = entries[len(entries)-1].Height + 1
+	}
+
+	return result, nil
+}
+
 func handleGetBlockKeyHeight(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*hcashjson.GetBlockKeyHeightCmd)
 	height := c.Height
@@ -2362,31 +3171,37 @@ func handleGetBlockSubsidy(s *rpcServer, cmd interface{}, closeChan <-chan struc
 
 // encodeTemplateID encodes the passed details into an ID that can be used to
 // uniquely identify a block template.
-func encodeTemplateID(prevHash *chainhash.Hash, lastGenerated time.Time) string {
-	return fmt.Sprintf("%s-%d", prevHash.String(), lastGenerated.Unix())
+func encodeTemplateID(prevHash, prevKeyHash *chainhash.Hash, lastGenerated time.Time) string {
+	return fmt.Sprintf("%s-%s-%d", prevHash.String(), prevKeyHash.String(),
+		lastGenerated.Unix())
 }
 
 // decodeTemplateID decodes an ID that is used to uniquely identify a block
 // template.  This is mainly used as a mechanism to track when to update
 // clients that are using long polling for block templates.  The ID consists of
-// the previous block hash for the associated template and the time the
-// associated template was generated.
-func decodeTemplateID(templateID string) (*chainhash.Hash, int64, error) {
+// the best block hash the associated template was generated against, which
+// changes with every new key block or microblock, the hash of the key block
+// the template builds on, and the time the associated template was generated.
+func decodeTemplateID(templateID string) (*chainhash.Hash, *chainhash.Hash, int64, error) {
 	fields := strings.Split(templateID, "-")
-	if len(fields) != 2 {
-		return nil, 0, ErrInvalidLongPoll
+	if len(fields) != 3 {
+		return nil, nil, 0, ErrInvalidLongPoll
 	}
 
 	prevHash, err := chainhash.NewHashFromStr(fields[0])
 	if err != nil {
-		return nil, 0, ErrInvalidLongPoll
+		return nil, nil, 0, ErrInvalidLongPoll
+	}
+	prevKeyHash, err := chainhash.NewHashFromStr(fields[1])
+	if err != nil {
+		return nil, nil, 0, ErrInvalidLongPoll
 	}
-	lastGenerated, err := strconv.ParseInt(fields[1], 10, 64)
+	lastGenerated, err := strconv.ParseInt(fields[2], 10, 64)
 	if err != nil {
-		return nil, 0, ErrInvalidLongPoll
+		return nil, nil, 0, ErrInvalidLongPoll
 	}
 
-	return prevHash, lastGenerated, nil
+	return prevHash, prevKeyHash, lastGenerated, nil
 }
 
 // notifyLongPollers notifies any channels that have been registered to be
@@ -2452,7 +3267,9 @@ func (state *gbtWorkState) NotifyBlockConnected(blockHash *chainhash.Hash) {
 // NotifyMempoolTx uses the new last updated time for the transaction memory
 // pool to notify any long poll clients with a new block template when their
 // existing block template is stale due to enough time passing and the contents
-// of the memory pool changing.
+// of the memory pool changing.  When the current template is too recent, the
+// notification is deferred until it has been long enough instead of waiting
+// for another transaction to show up.
 func (state *gbtWorkState) NotifyMempoolTx(lastUpdated time.Time) {
 	go func() {
 		state.Lock()
@@ -2464,14 +3281,35 @@ func (state *gbtWorkState) NotifyMempoolTx(lastUpdated time.Time) {
 			return
 		}
 
-		if time.Now().After(state.lastGenerated.Add(time.Second *
-			gbtRegenerateSeconds)) {
-
+		regenerateTime := state.lastGenerated.Add(time.Second *
+			gbtRegenerateSeconds)
+		if time.Now().After(regenerateTime) {
 			state.notifyLongPollers(state.prevHash, lastUpdated)
+			return
+		}
+
+		state.pendingTxUpdate = lastUpdated
+		if state.pendingNotify == nil {
+			state.pendingNotify = time.AfterFunc(regenerateTime.Sub(time.Now()),
+				state.notifyPendingTxUpdate)
 		}
 	}()
 }
 
+// notifyPendingTxUpdate notifies any long poll clients about the memory pool
+// update that was deferred by NotifyMempoolTx.
+func (state *gbtWorkState) notifyPendingTxUpdate() {
+	state.Lock()
+	defer state.Unlock()
+
+	state.pendingNotify = nil
+	if state.prevHash == nil || state.pendingTxUpdate.IsZero() {
+		return
+	}
+	state.notifyLongPollers(state.prevHash, state.pendingTxUpdate)
+	state.pendingTxUpdate = time.Time{}
+}
+
 // templateUpdateChan returns a channel that will be closed once the block
 // template associated with the passed previous hash and last generated time
 // is stale.  The function will return existing channels for duplicate
@@ -2500,6 +3338,34 @@ func (state *gbtWorkState) templateUpdateChan(prevHash *chainhash.Hash, lastGene
 	return c
 }
 
+// recordTemplateTxs stores the hashes of all transactions other than the
+// coinbase in the passed block under the provided template ID so later
+// gettemplatedelta requests can determine which transactions were added or
+// removed since.  The oldest recorded template is evicted when the history
+// exceeds gbtDeltaHistorySize entries.
+//
+// This function MUST be called with the state locked.
+func (state *gbtWorkState) recordTemplateTxs(templateID string, msgBlock *wire.MsgBlock) {
+	if _, ok := state.templateTxs[templateID]; !ok {
+		state.templateOrder = append(state.templateOrder, templateID)
+	}
+
+	txns := make(map[chainhash.Hash]struct{}, len(msgBlock.Transactions)+
+		len(msgBlock.STransactions)-1)
+	for _, tx := range msgBlock.Transactions[1:] {
+		txns[tx.TxHashFull()] = struct{}{}
+	}
+	for _, stx := range msgBlock.STransactions {
+		txns[stx.TxHashFull()] = struct{}{}
+	}
+	state.templateTxs[templateID] = txns
+
+	for len(state.templateOrder) > gbtDeltaHistorySize {
+		delete(state.templateTxs, state.templateOrder[0])
+		state.templateOrder = state.templateOrder[1:]
+	}
+}
+
 // updateBlockTemplate creates or updates a block template for the work state.
 // A new block template will be generated when the current best block has
 // changed or the transactions in the memory pool have been updated and it has
@@ -2550,7 +3416,7 @@ func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bo
 		// block template doesn't include the coinbase, so the caller
 		// will ultimately create their own coinbase which pays to the
 		// appropriate address(es).
-		blkTemplate, err := NewBlockTemplate(s.policy, s.server, payAddr)
+		blkTemplate, err := s.server.blkTmplGenerator.NewBlockTemplate(payAddr)
 		if err != nil {
 			return rpcInternalError("Failed to create new block "+
 				"template: "+err.Error(), "")
@@ -2563,7 +3429,7 @@ func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bo
 		template = blkTemplate
 		msgBlock = template.Block
 		targetDifficulty = fmt.Sprintf("%064x",
-			blockchain.CompactToBig(msgBlock.Header.Bits))
+			standalone.CompactToBig(msgBlock.Header.Bits))
 
 		// Find the minimum allowed timestamp for the block based on the
 		// median timestamp of the last several blocks per the chain
@@ -2577,11 +3443,14 @@ func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bo
 
 		// Update work state to ensure another block template isn't
 		// generated until needed.
-		state.template = deepCopyBlockTemplate(template)
+		state.template = mining.DeepCopyBlockTemplate(template)
 		state.lastGenerated = time.Now()
 		state.lastTxUpdate = lastTxUpdate
 		state.prevHash = latestHash
+		state.prevKeyHash = &template.Block.Header.PrevKeyBlock
 		state.minTimestamp = minTimestamp
+		state.recordTemplateTxs(encodeTemplateID(latestHash,
+			state.prevKeyHash, state.lastGenerated), msgBlock)
 
 		rpcsLog.Debugf("Generated block template (timestamp %v, "+
 			"target %s, merkle root %s)",
@@ -2627,12 +3496,12 @@ func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bo
 		// Set locals for convenience.
 		msgBlock = template.Block
 		targetDifficulty = fmt.Sprintf("%064x",
-			blockchain.CompactToBig(msgBlock.Header.Bits))
+			standalone.CompactToBig(msgBlock.Header.Bits))
 
 		// Update the time of the block template to the current time
 		// while accounting for the median time of the past several
 		// blocks per the chain consensus rules.
-		err := UpdateBlockTime(msgBlock, s.server.blockManager)
+		err := s.server.blkTmplGenerator.UpdateBlockTime(msgBlock)
 		if err != nil {
 			context := "Failed to update timestamp"
 			return rpcInternalError(err.Error(), context)
@@ -2657,7 +3526,7 @@ func (state *gbtWorkState) blockTemplateResult(bm *blockManager, useCoinbaseValu
 	// This should really only ever happen if the local clock is changed
 	// after the template is generated, but it's important to avoid serving
 	// invalid block templates.
-	template := deepCopyBlockTemplate(state.template)
+	template := mining.DeepCopyBlockTemplate(state.template)
 	msgBlock := template.Block
 	header := &msgBlock.Header
 	adjustedTime := state.timeSource.AdjustedTime()
@@ -2950,8 +3819,9 @@ func (state *gbtWorkState) blockTemplateResult(bm *blockManager, useCoinbaseValu
 	// are implied by the included or omission of fields:
 	//  Including MinTime -> time/decrement
 	//  Omitting CoinbaseTxn -> coinbase, generation
-	targetDifficulty := fmt.Sprintf("%064x", blockchain.CompactToBig(header.Bits))
-	templateID := encodeTemplateID(state.prevHash, state.lastGenerated)
+	targetDifficulty := fmt.Sprintf("%064x", standalone.CompactToBig(header.Bits))
+	templateID := encodeTemplateID(state.prevHash, state.prevKeyHash,
+		state.lastGenerated)
 	reply := hcashjson.GetBlockTemplateResult{
 		Header:        hex.EncodeToString(headerBytes),
 		SigOpLimit:    blockchain.MaxSigOpsPerBlock,
@@ -3009,9 +3879,9 @@ func (state *gbtWorkState) blockTemplateResult(bm *blockManager, useCoinbaseValu
 // sends a request with a long poll ID that was previously returned, a response
 // is not sent until the caller should stop working on the previous block
 // template in favor of the new one.  In particular, this is the case when the
-// old block template is no longer valid due to a solution already being found
-// and added to the block chain, or new transactions have shown up and some time
-// has passed without finding a solution.
+// old block template is no longer valid due to a new key block or microblock
+// being added to the block chain, or new transactions have shown up and some
+// time has passed without finding a solution.
 //
 // See https://en.bitcoin.it/wiki/BIP_0022 for more details.
 func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbaseValue bool, closeChan <-chan struct{}) (interface{}, error) {
@@ -3028,7 +3898,7 @@ func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbase
 
 	// Just return the current block template if the long poll ID provided by
 	// the caller is invalid.
-	prevHash, lastGenerated, err := decodeTemplateID(longPollID)
+	prevHash, prevKeyHash, lastGenerated, err := decodeTemplateID(longPollID)
 	if err != nil {
 		result, err := state.blockTemplateResult(s.server.blockManager,
 			useCoinbaseValue, nil)
@@ -3043,15 +3913,18 @@ func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbase
 
 	// Return the block template now if the specific block template
 	// identified by the long poll ID no longer matches the current block
-	// template as this means the provided template is stale.
-	prevTemplateHash := &state.template.Block.Header.PrevBlock
-	if !prevHash.IsEqual(prevTemplateHash) ||
+	// template as this means the provided template is stale.  Note that
+	// the ID is compared against the best block the current template was
+	// generated against rather than its parent since templates are built
+	// on the parent of the best block when it does not have enough votes.
+	if !prevHash.IsEqual(state.prevHash) ||
+		!prevKeyHash.IsEqual(state.prevKeyHash) ||
 		lastGenerated != state.lastGenerated.Unix() {
 
 		// Include whether or not it is valid to submit work against the
 		// old block template depending on whether or not a solution has
 		// already been found and added to the block chain.
-		submitOld := prevHash.IsEqual(prevTemplateHash)
+		submitOld := prevHash.IsEqual(state.prevHash)
 		result, err := state.blockTemplateResult(s.server.blockManager,
 			useCoinbaseValue, &submitOld)
 		if err != nil {
@@ -3092,7 +3965,7 @@ func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbase
 	// Include whether or not it is valid to submit work against the old
 	// block template depending on whether or not a solution has already
 	// been found and added to the block chain.
-	submitOld := prevHash.IsEqual(&state.template.Block.Header.PrevBlock)
+	submitOld := prevHash.IsEqual(state.prevHash)
 	result, err := state.blockTemplateResult(s.server.blockManager,
 		useCoinbaseValue, &submitOld)
 	if err != nil {
@@ -3270,6 +4143,8 @@ func chainErrToGBTErrString(err error) string {
 		return "bad-script-malformed"
 	case blockchain.ErrScriptValidation:
 		return "bad-script-validate"
+	case blockchain.ErrInvalidTemplateParent:
+		return "bad-prevblk"
 	}
 
 	return "rejected: " + err.Error()
@@ -3302,16 +4177,10 @@ func handleGetBlockTemplateProposal(s *rpcServer, request *hcashjson.TemplateReq
 	}
 	block := hcashutil.NewBlock(&msgBlock)
 
-	// Ensure the block is building from the expected previous block.
-	expectedPrevHash, _, _:= s.server.blockManager.chainState.Best()
-	prevHash := &block.MsgBlock().Header.PrevBlock
-	if expectedPrevHash == nil || !expectedPrevHash.IsEqual(prevHash) {
-		return "bad-prevblk", nil
-	}
-
-	flags := blockchain.BFDryRun | blockchain.BFNoPoWCheck
-	isOrphan, err := s.server.blockManager.ProcessBlock(block, flags)
-	if err != nil {
+	// Fully validate the block against the current tip without connecting
+	// it.  A block which does not build from the tip is rejected with
+	// bad-prevblk.
+	if err := s.chain.CheckConnectBlockTemplate(block); err != nil {
 		if _, ok := err.(blockchain.RuleError); !ok {
 			errStr := fmt.Sprintf("Failed to process block "+
 				"proposal: %v", err)
@@ -3323,9 +4192,6 @@ func handleGetBlockTemplateProposal(s *rpcServer, request *hcashjson.TemplateReq
 		rpcsLog.Infof("Rejected block proposal: %v", err)
 		return chainErrToGBTErrString(err), nil
 	}
-	if isOrphan {
-		return "orphan", nil
-	}
 
 	return nil, nil
 }
@@ -3367,6 +4233,103 @@ func handleGetBlockTemplate(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return nil, rpcInvalidError("Invalid mode: %v", mode)
 }
 
+// parseFilterType returns the committed filter type for the passed filter type
+// name as accepted by the getcfilter and getcfheaders commands.
+func parseFilterType(filterType string) (wire.FilterType, error) {
+	switch filterType {
+	case "regular":
+		return wire.GCSFilterRegular, nil
+	case "extended":
+		return wire.GCSFilterExtended, nil
+	}
+	return 0, rpcInvalidError("Unknown filter type: %q", filterType)
+}
+
+// handleGetCFilter implements the getcfilter command.
+func handleGetCFilter(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	cfIndex := s.server.cfIndex
+	if cfIndex == nil {
+		return nil, rpcInternalError("Committed filter index must be "+
+			"enabled (--cfindex)", "Configuration")
+	}
+
+	c := cmd.(*hcashjson.GetCFilterCmd)
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+	filterType, err := parseFilterType(c.FilterType)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := cfIndex.FilterByBlockHash(hash, filterType)
+	if err != nil {
+		context := "Failed to load committed filter"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+	if filter == nil {
+		return nil, &hcashjson.RPCError{
+			Code:    hcashjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found: %v", c.Hash),
+		}
+	}
+
+	return hex.EncodeToString(filter), nil
+}
+
+// handleGetCFHeaders implements the getcfheaders command.
+func handleGetCFHeaders(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	cfIndex := s.server.cfIndex
+	if cfIndex == nil {
+		return nil, rpcInternalError("Committed filter index must be "+
+			"enabled (--cfindex)", "Configuration")
+	}
+
+	c := cmd.(*hcashjson.GetCFHeadersCmd)
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+	filterType, err := parseFilterType(c.FilterType)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := cfIndex.FilterHeaderByBlockHash(hash, filterType)
+	if err != nil {
+		context := "Failed to load committed filter header"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+	if header == nil {
+		return nil, &hcashjson.RPCError{
+			Code:    hcashjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found: %v", c.Hash),
+		}
+	}
+
+	return header.String(), nil
+}
+
+// handleGetChainTips implements the getchaintips command.
+func handleGetChainTips(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	tips, err := s.chain.ChainTips()
+	if err != nil {
+		return nil, rpcInternalError(err.Error(), "Could not fetch chain tips")
+	}
+
+	results := make([]hcashjson.GetChainTipsResult, 0, len(tips))
+	for _, tip := range tips {
+		results = append(results, hcashjson.GetChainTipsResult{
+			Height:    tip.Height,
+			Hash:      tip.Hash.String(),
+			BranchLen: tip.BranchLen,
+			Status:    tip.Status.String(),
+		})
+	}
+	return results, nil
+}
+
 // handleGetCoinSupply implements the getcoinsupply command.
 func handleGetCoinSupply(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	return s.chain.TotalSubsidy(), nil
@@ -3385,7 +4348,39 @@ func handleGetCurrentNet(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 // handleGetDifficulty implements the getdifficulty command.
 func handleGetDifficulty(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	best := s.chain.BestSnapshot()
-	return getDifficultyRatio(best.Bits), nil
+	blockHeader, err := s.chain.HeaderByHeight(best.Height)
+	if err != nil {
+		rpcsLog.Errorf("Error getting block: %v", err)
+		return nil, &hcashjson.RPCError{
+			Code:    hcashjson.ErrRPCDifficulty,
+			Message: "Error getting stake difficulty: " + err.Error(),
+		}
+	}
+
+	// Micro blocks must only meet the proof-of-work target multiplied by
+	// the difficulty rate, so their difficulty is lower by that factor.
+	diff := getDifficultyRatio(best.Bits)
+	diffRate := activeNetParams.DifficultyRate
+	microDiff := diff
+	if diffRate > 0 {
+		microDiff = diff / float64(diffRate)
+	}
+
+	stakeDiff := hcashutil.Amount(blockHeader.SBits)
+	minStakeDiff := hcashutil.Amount(activeNetParams.MinimumStakeDiff)
+	var stakeDiffRatio float64
+	if minStakeDiff > 0 {
+		stakeDiffRatio = float64(stakeDiff) / float64(minStakeDiff)
+	}
+
+	return &hcashjson.GetDifficultyResult{
+		Difficulty:           diff,
+		MicroDifficulty:      microDiff,
+		DifficultyRate:       diffRate,
+		StakeDifficulty:      stakeDiff.ToCoin(),
+		MinStakeDifficulty:   minStakeDiff.ToCoin(),
+		StakeDifficultyRatio: stakeDiffRatio,
+	}, nil
 }
 
 // handleGetGenerate implements the getgenerate command.
@@ -3470,8 +4465,9 @@ func handleGetInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (in
 		Proxy:           cfg.Proxy,
 		Difficulty:      difficulty,
 		TestNet:         cfg.TestNet,
-		RelayFee:        cfg.minRelayTxFee.ToCoin(),
-		HashCount:		 hashcount,
+		RelayFee:        s.server.txMemPool.Policy().MinRelayTxFee.ToCoin(),
+		Errors:          startupTimings.warning(),
+		HashCount:       hashcount,
 	}
 
 	return ret, nil
@@ -3487,8 +4483,12 @@ func handleGetMempoolInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct
 	}
 
 	ret := &hcashjson.GetMempoolInfoResult{
-		Size:  int64(len(mempoolTxns)),
-		Bytes: numBytes,
+		Size:              int64(len(mempoolTxns)),
+		Bytes:             numBytes,
+		MaxMempool:        s.server.txMemPool.Policy().MaxPoolSize,
+		MempoolMinFee:     s.server.txMemPool.MinFeeRate().ToCoin(),
+		SizeEvicted:       s.server.txMemPool.SizeEvicted(),
+		StaleVotesEvicted: s.server.txMemPool.StaleVotesEvicted(),
 	}
 
 	return ret, nil
@@ -3531,6 +4531,7 @@ func handleGetMiningInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 		NetworkHashPS:    networkHashesPerSec,
 		PooledTx:         uint64(s.server.txMemPool.Count()),
 		TestNet:          cfg.TestNet,
+		CPUMiner:         s.server.cpuMiner.Stats(),
 	}
 	return &result, nil
 }
@@ -3618,12 +4619,12 @@ func handleGetNetworkHashPS(s *rpcServer, cmd interface{}, closeChan <-chan stru
 		}
 
 		if i == 1{
-			totalWork.Add(totalWork, blockchain.CalcWork(header.Bits))
+			totalWork.Add(totalWork, standalone.CalcWork(header.Bits))
 			minTimestamp = header.Timestamp
 			maxTimestamp = header.Timestamp
 		}else{
 			if i != numBlocks{
-				totalWork.Add(totalWork, blockchain.CalcWork(header.Bits))
+				totalWork.Add(totalWork, standalone.CalcWork(header.Bits))
 			}
 			if minTimestamp.After(header.Timestamp) {
 				minTimestamp = header.Timestamp
@@ -3649,6 +4650,160 @@ func handleGetNetworkHashPS(s *rpcServer, cmd interface{}, closeChan <-chan stru
 
 }
 
+// handleGetKnownAddresses implements the getknownaddresses command.  It returns
+// a page of the addresses known to the address manager ordered by address.
+func handleGetKnownAddresses(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.GetKnownAddressesCmd)
+	known := s.server.addrManager.KnownAddresses()
+	start, end, nextCursor, err := hcashjson.PageBounds(len(known),
+		c.Cursor, c.Limit)
+	if err != nil {
+		return nil, rpcInvalidError("%v", err)
+	}
+
+	// unixOrZero returns the passed time as a Unix timestamp or zero when
+	// the time is not set.
+	unixOrZero := func(t time.Time) int64 {
+		if t.IsZero() {
+			return 0
+		}
+		return t.Unix()
+	}
+
+	items := make([]hcashjson.KnownAddressResult, 0, end-start)
+	for _, ka := range known[start:end] {
+		na := ka.NetAddress()
+		items = append(items, hcashjson.KnownAddressResult{
+			Addr:        addrmgr.NetAddressKey(na),
+			Services:    fmt.Sprintf("%08d", uint64(na.Services)),
+			Timestamp:   na.Timestamp.Unix(),
+			Tried:       ka.Tried(),
+			Attempts:    ka.Attempts(),
+			LastAttempt: unixOrZero(ka.LastAttempt()),
+			LastSuccess: unixOrZero(ka.LastSuccess()),
+		})
+	}
+
+	return &hcashjson.GetKnownAddressesResult{
+		Items:      items,
+		NextCursor: nextCursor,
+		Total:      int64(len(known)),
+	}, nil
+}
+
+// handleGetLotteryProof implements the getlotteryproof command.
+func handleGetLotteryProof(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.GetLotteryProofCmd)
+
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+
+	proof, err := s.chain.LotteryProofForBlock(hash)
+	if err != nil {
+		return nil, rpcMiscError(err.Error())
+	}
+
+	var header wire.BlockHeader
+	if err := header.FromBytes(proof.Seed); err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Could not deserialize block header")
+	}
+
+	winners := make([]hcashjson.LotteryWinnerResult, 0, len(proof.Winners))
+	for _, winner := range proof.Winners {
+		branch := make([]string, 0, len(winner.MerkleBranch))
+		for i := range winner.MerkleBranch {
+			branch = append(branch, winner.MerkleBranch[i].String())
+		}
+		winners = append(winners, hcashjson.LotteryWinnerResult{
+			Ticket:       winner.Ticket.String(),
+			Index:        winner.Index,
+			MerkleBranch: branch,
+		})
+	}
+
+	return hcashjson.GetLotteryProofResult{
+		Hash:       hash.String(),
+		Height:     int64(header.Height),
+		Seed:       hex.EncodeToString(proof.Seed),
+		PoolSize:   proof.PoolSize,
+		PoolRoot:   proof.PoolRoot.String(),
+		Draws:      proof.Draws,
+		Winners:    winners,
+		StateHash:  proof.StateHash.String(),
+		FinalState: hex.EncodeToString(proof.FinalState[:]),
+	}, nil
+}
+
+// handleGetNotices implements the getnotices command.
+func handleGetNotices(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	notices := s.server.noticeManager.ActiveNotices(time.Now())
+	results := make([]hcashjson.GetNoticesResult, 0, len(notices))
+	for _, notice := range notices {
+		results = append(results, hcashjson.GetNoticesResult{
+			ID:         notice.ID,
+			Expiration: notice.Expiration,
+			Message:    notice.Message,
+		})
+	}
+	return results, nil
+}
+
+// handleGetCPUMinerInfo implements the getcpuminerinfo command.
+func handleGetCPUMinerInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return s.server.cpuMiner.Info(), nil
+}
+
+// handleGetRPCAuthInfo implements the getrpcauthinfo command.
+func handleGetRPCAuthInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	stats := s.authLimiter.snapshot(time.Now())
+	banned := make([]hcashjson.RPCAuthBanResult, 0, len(stats.banned))
+	for _, ban := range stats.banned {
+		banned = append(banned, hcashjson.RPCAuthBanResult{
+			Host:        ban.host,
+			Failures:    ban.failures,
+			BannedUntil: ban.until.Unix(),
+		})
+	}
+	return &hcashjson.GetRPCAuthInfoResult{
+		Failures: stats.failures,
+		Refused:  stats.refused,
+		Bans:     stats.bans,
+		Banned:   banned,
+	}, nil
+}
+
+// handleGetSigCacheInfo implements the getsigcacheinfo command.
+func handleGetSigCacheInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	stats := s.server.sigCache.Stats()
+	return &hcashjson.GetSigCacheInfoResult{
+		Entries:    stats.Entries,
+		MaxEntries: stats.MaxEntries,
+		Hits:       stats.Hits,
+		Misses:     stats.Misses,
+		HitRate:    stats.HitRate(),
+		Evictions:  stats.Evictions,
+	}, nil
+}
+
+// handleGetStakeNodeCacheInfo implements the getstakenodecacheinfo command.
+func handleGetStakeNodeCacheInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	stats := s.chain.StakeNodeCacheStats()
+	return &hcashjson.GetStakeNodeCacheInfoResult{
+		Hits:              stats.Hits,
+		ParentConnects:    stats.ParentConnects,
+		Regenerations:     stats.Regenerations,
+		HitRate:           stats.HitRate(),
+		ConnectReplays:    stats.ConnectReplays,
+		DisconnectReplays: stats.DisconnectReplays,
+		DBLookups:         stats.DBLookups,
+		MaxReplays:        stats.MaxReplays,
+		RegenerationTime:  stats.RegenerationTime.Seconds(),
+	}, nil
+}
+
 // handleGetPeerInfo implements the getpeerinfo command.
 func handleGetPeerInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	peers := s.server.Peers()
@@ -3656,25 +4811,31 @@ func handleGetPeerInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	infos := make([]*hcashjson.GetPeerInfoResult, 0, len(peers))
 	for _, p := range peers {
 		statsSnap := p.StatsSnapshot()
+		limitStats := p.txLimiter.Stats()
 		info := &hcashjson.GetPeerInfoResult{
-			ID:             statsSnap.ID,
-			Addr:           statsSnap.Addr,
-			Services:       fmt.Sprintf("%08d", uint64(statsSnap.Services)),
-			LastSend:       statsSnap.LastSend.Unix(),
-			LastRecv:       statsSnap.LastRecv.Unix(),
-			BytesSent:      statsSnap.BytesSent,
-			BytesRecv:      statsSnap.BytesRecv,
-			ConnTime:       statsSnap.ConnTime.Unix(),
-			PingTime:       float64(statsSnap.LastPingMicros),
-			TimeOffset:     statsSnap.TimeOffset,
-			Version:        statsSnap.Version,
-			SubVer:         statsSnap.UserAgent,
-			Inbound:        statsSnap.Inbound,
-			StartingHeight: statsSnap.StartingHeight,
-			CurrentHeight:  statsSnap.LastBlock,
+			ID:                   statsSnap.ID,
+			Addr:                 statsSnap.Addr,
+			Services:             fmt.Sprintf("%08d", uint64(statsSnap.Services)),
+			LastSend:             statsSnap.LastSend.Unix(),
+			LastRecv:             statsSnap.LastRecv.Unix(),
+			BytesSent:            statsSnap.BytesSent,
+			BytesRecv:            statsSnap.BytesRecv,
+			ConnTime:             statsSnap.ConnTime.Unix(),
+			PingTime:             float64(statsSnap.LastPingMicros),
+			TimeOffset:           statsSnap.TimeOffset,
+			Version:              statsSnap.Version,
+			SubVer:               statsSnap.UserAgent,
+			Inbound:              statsSnap.Inbound,
+			StartingHeight:       statsSnap.StartingHeight,
+			StartingKeyHeight:    statsSnap.StartingKeyHeight,
+			CurrentHeight:        statsSnap.LastBlock,
 			CurrentRealKeyHeight: statsSnap.LastKeyBlock,
-			BanScore:       int32(p.banScore.Int()),
-			SyncNode:       p == syncPeer,
+			BanScore:             int32(p.banScore.Int()),
+			SyncNode:             p == syncPeer,
+			LimitedTxns:          limitStats.LimitedTxns,
+			LimitedLowFeeTxns:    limitStats.LimitedLowFeeTxns,
+			BytesSentPerMsg:      statsSnap.BytesSentPerMsg,
+			BytesRecvPerMsg:      statsSnap.BytesRecvPerMsg,
 		}
 		if p.LastPingNonce() != 0 {
 			wait := float64(time.Since(statsSnap.LastPingTime).Nanoseconds())
@@ -3752,8 +4913,6 @@ func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan str
 	// try the block database.
 	var mtx *wire.MsgTx
 	var blkHash *chainhash.Hash
-	var blkHeight int64
-	var blkKeyHeight int64
 	tx, err := s.server.txMemPool.FetchTransaction(txHash, true)
 	if err != nil {
 		txIndex := s.server.txIndex
@@ -3791,14 +4950,6 @@ func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan str
 			return hex.EncodeToString(txBytes), nil
 		}
 
-		// Grab the block height.
-		blkHash = blockRegion.Hash
-		blkHeight, err = s.chain.BlockHeightByHash(blkHash)
-		if err != nil {
-			context := "Failed to retrieve block height"
-			return nil, rpcInternalError(err.Error(), context)
-		}
-
 		// Deserialize the transaction
 		var msgTx wire.MsgTx
 		err = msgTx.Deserialize(bytes.NewReader(txBytes))
@@ -3807,6 +4958,7 @@ func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan str
 			return nil, rpcInternalError(err.Error(), context)
 		}
 		mtx = &msgTx
+		blkHash = blockRegion.Hash
 	} else {
 		// When the verbose flag isn't set, simply return the
 		// network-serialized transaction as a hex-encoded string.
@@ -3827,15 +4979,36 @@ func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan str
 	}
 
 	// The verbose flag is set, so generate the JSON object and return it.
+	rawTxn, err := s.verboseTxResult(mtx, txHash, blkHash)
+	if err != nil {
+		return nil, err
+	}
+	return *rawTxn, nil
+}
+
+// verboseTxResult returns the verbose result of the getrawtransaction and
+// getrawtransactions commands for the passed transaction, which is either in
+// the block with the passed hash or in the memory pool when the hash is nil.
+func (s *rpcServer) verboseTxResult(mtx *wire.MsgTx, txHash, blkHash *chainhash.Hash) (*hcashjson.TxRawResult, error) {
 	var (
 		blkHeader     *wire.BlockHeader
 		blkHashStr    string
+		blkHeight     int64
+		blkKeyHeight  int64
 		confirmations int64
 	)
 	if blkHash != nil {
+		// Grab the block height.
+		var err error
+		blkHeight, err = s.chain.BlockHeightByHash(blkHash)
+		if err != nil {
+			context := "Failed to retrieve block height"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+
 		// Load the raw header bytes.
 		var headerBytes []byte
-		err := s.server.db.View(func(dbTx database.Tx) error {
+		err = s.server.db.View(func(dbTx database.Tx) error {
 			var err error
 			headerBytes, err = dbTx.FetchBlockHeader(blkHash)
 			return err
@@ -3863,29 +5036,164 @@ func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan str
 		confirmations = s.chain.BestRealKeyHeight() - blkKeyHeight
 	}
 
-	rawTxn, err := createTxRawResult(s.server.chainParams, mtx,
-		txHash.String(), 0, blkHeader, blkHashStr, blkHeight, blkKeyHeight,
-		confirmations)
-	if err != nil {
-		return nil, err
-	}
-	return *rawTxn, nil
+	return createTxRawResult(s.server.chainParams, mtx, txHash.String(), 0,
+		blkHeader, blkHashStr, blkHeight, blkKeyHeight, confirmations)
 }
 
-// handleGetStakeDifficulty implements the getstakedifficulty command.
-func handleGetStakeDifficulty(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	best := s.chain.BestSnapshot()
-	blockHeader, err := s.chain.HeaderByHeight(best.Height)
-	if err != nil {
-		rpcsLog.Errorf("Error getting block: %v", err)
-		return nil, &hcashjson.RPCError{
-			Code:    hcashjson.ErrRPCDifficulty,
-			Message: "Error getting stake difficulty: " + err.Error(),
-		}
+// handleGetRawTransactions implements the getrawtransactions command.
+func handleGetRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.GetRawTransactionsCmd)
+	if len(c.Txids) > maxGetRawTransactions {
+		return nil, rpcInvalidError("Too many transactions requested: "+
+			"%d > %d", len(c.Txids), maxGetRawTransactions)
 	}
-	currentSdiff := hcashutil.Amount(blockHeader.SBits)
 
-	nextSdiff, err := s.server.blockManager.CalcNextRequiredStakeDifficulty()
+	verbose := false
+	if c.Verbose != nil {
+		verbose = *c.Verbose != 0
+	}
+
+	// Try to fetch the transactions from the memory pool first and look up
+	// the locations of the remaining ones in the transaction index.
+	results := make([]hcashjson.RawTransactionResult, len(c.Txids))
+	txHashes := make([]*chainhash.Hash, len(c.Txids))
+	mtxs := make([]*wire.MsgTx, len(c.Txids))
+	var minedHashes []chainhash.Hash
+	var minedIdxs []int
+	for i, txid := range c.Txids {
+		results[i].Txid = txid
+		txHash, err := chainhash.NewHashFromStr(txid)
+		if err != nil {
+			results[i].Error = rpcDecodeHexError(txid)
+			continue
+		}
+		txHashes[i] = txHash
+
+		tx, err := s.server.txMemPool.FetchTransaction(txHash, true)
+		if err == nil {
+			mtxs[i] = tx.MsgTx()
+			continue
+		}
+		minedHashes = append(minedHashes, *txHash)
+		minedIdxs = append(minedIdxs, i)
+	}
+
+	// Load the raw bytes of the transactions which are not in the memory
+	// pool from the database in bulk.
+	blkHashes := make([]*chainhash.Hash, len(c.Txids))
+	txBytes := make([][]byte, len(c.Txids))
+	if len(minedHashes) > 0 {
+		txIndex := s.server.txIndex
+		if txIndex == nil {
+			return nil, rpcInternalError("The transaction index "+
+				"must be enabled to query the blockchain "+
+				"(specify --txindex)", "Configuration")
+		}
+
+		blockRegions, err := txIndex.TxBlockRegions(minedHashes)
+		if err != nil {
+			context := "Failed to retrieve transaction locations"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+		regions := make([]database.BlockRegion, 0, len(blockRegions))
+		regionIdxs := make([]int, 0, len(blockRegions))
+		for j, blockRegion := range blockRegions {
+			i := minedIdxs[j]
+			if blockRegion == nil {
+				results[i].Error = rpcNoTxInfoError(txHashes[i])
+				continue
+			}
+			blkHashes[i] = blockRegion.Hash
+			regions = append(regions, *blockRegion)
+			regionIdxs = append(regionIdxs, i)
+		}
+
+		// Fall back to loading the transactions individually when
+		// loading them in bulk fails, so only the transactions that
+		// can't be loaded result in errors.
+		err = s.server.db.View(func(dbTx database.Tx) error {
+			rawTxns, err := dbTx.FetchBlockRegions(regions)
+			if err == nil {
+				for j, rawTx := range rawTxns {
+					txBytes[regionIdxs[j]] = rawTx
+				}
+				return nil
+			}
+			for j := range regions {
+				i := regionIdxs[j]
+				rawTx, err := dbTx.FetchBlockRegion(&regions[j])
+				if err != nil {
+					results[i].Error = rpcNoTxInfoError(txHashes[i])
+					continue
+				}
+				txBytes[i] = rawTx
+			}
+			return nil
+		})
+		if err != nil {
+			context := "Failed to load transactions"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+	}
+
+	for i := range results {
+		if results[i].Error != nil {
+			continue
+		}
+
+		// When the verbose flag isn't set, simply return the serialized
+		// transaction as a hex-encoded string.
+		mtx := mtxs[i]
+		if !verbose {
+			if mtx == nil {
+				results[i].Hex = hex.EncodeToString(txBytes[i])
+				continue
+			}
+			mtxHex, err := messageToHex(mtx)
+			if err != nil {
+				return nil, err
+			}
+			results[i].Hex = mtxHex
+			continue
+		}
+
+		if mtx == nil {
+			var msgTx wire.MsgTx
+			err := msgTx.Deserialize(bytes.NewReader(txBytes[i]))
+			if err != nil {
+				context := "Failed to deserialize transaction"
+				return nil, rpcInternalError(err.Error(), context)
+			}
+			mtx = &msgTx
+		}
+		rawTxn, err := s.verboseTxResult(mtx, txHashes[i], blkHashes[i])
+		if err != nil {
+			if jsonErr, ok := err.(*hcashjson.RPCError); ok {
+				results[i].Error = jsonErr
+				continue
+			}
+			return nil, err
+		}
+		results[i].Tx = rawTxn
+	}
+
+	return results, nil
+}
+
+// handleGetStakeDifficulty implements the getstakedifficulty command.
+func handleGetStakeDifficulty(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	best := s.chain.BestSnapshot()
+	blockHeader, err := s.chain.HeaderByHeight(best.Height)
+	if err != nil {
+		rpcsLog.Errorf("Error getting block: %v", err)
+		return nil, &hcashjson.RPCError{
+			Code:    hcashjson.ErrRPCDifficulty,
+			Message: "Error getting stake difficulty: " + err.Error(),
+		}
+	}
+	currentSdiff := hcashutil.Amount(blockHeader.SBits)
+
+	nextSdiff, err := s.server.blockManager.CalcNextRequiredStakeDifficulty()
 	if err != nil {
 		return nil, rpcInternalError("Could not calculate next stake "+
 			"difficulty "+err.Error(), "")
@@ -4034,6 +5342,100 @@ func handleGetStakeVersions(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return result, nil
 }
 
+// handleGetTemplateDelta implements the gettemplatedelta command.  It returns
+// the current block template expressed as the set of transactions added and
+// removed relative to a previously returned template along with the header
+// fields of the current one, which allows pool software to update its jobs
+// without downloading the full template on every refresh.
+func handleGetTemplateDelta(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.GetTemplateDeltaCmd)
+
+	if s.server.cpuMiner.IsMining() {
+		return nil, rpcMiscError("Block template production is " +
+			"disallowed while CPU mining is enabled. " +
+			"Please disable CPU mining and try again.")
+	}
+	if len(cfg.miningAddrs) == 0 {
+		return nil, rpcInternalError("No payment addresses specified "+
+			"via --miningaddr", "Configuration")
+	}
+
+	prevHash, _, _, err := decodeTemplateID(c.TemplateID)
+	if err != nil {
+		return nil, rpcInvalidError("Invalid template id %q: %v",
+			c.TemplateID, err)
+	}
+
+	state := s.gbtWorkState
+	state.Lock()
+	defer state.Unlock()
+
+	if err := state.updateBlockTemplate(s, true); err != nil {
+		return nil, err
+	}
+	template, err := state.blockTemplateResult(s.server.blockManager, true,
+		nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The previous template is treated as empty when it is no longer known
+	// or was built on top of a different parent since none of its work can
+	// be carried over in that case.
+	prevTxns, known := state.templateTxs[c.TemplateID]
+	stale := !known || !prevHash.IsEqual(state.prevHash)
+	if stale {
+		prevTxns = nil
+	}
+
+	// Determine which transactions are new to the current template and
+	// which ones from the previous template are no longer present.
+	curTxns := make(map[string]struct{}, len(template.Transactions)+
+		len(template.STransactions))
+	added := make([]hcashjson.GetBlockTemplateResultTx, 0)
+	for _, tx := range template.Transactions {
+		curTxns[tx.Hash] = struct{}{}
+		txHash, err := chainhash.NewHashFromStr(tx.Hash)
+		if err != nil {
+			return nil, rpcInternalError(err.Error(), "")
+		}
+		if _, ok := prevTxns[*txHash]; !ok {
+			added = append(added, tx)
+		}
+	}
+	addedStake := make([]hcashjson.GetBlockTemplateResultTx, 0)
+	for _, stx := range template.STransactions {
+		curTxns[stx.Hash] = struct{}{}
+		txHash, err := chainhash.NewHashFromStr(stx.Hash)
+		if err != nil {
+			return nil, rpcInternalError(err.Error(), "")
+		}
+		if _, ok := prevTxns[*txHash]; !ok {
+			addedStake = append(addedStake, stx)
+		}
+	}
+	removed := make([]string, 0)
+	for txHash := range prevTxns {
+		if _, ok := curTxns[txHash.String()]; !ok {
+			removed = append(removed, txHash.String())
+		}
+	}
+	sort.Strings(removed)
+
+	return &hcashjson.GetTemplateDeltaResult{
+		TemplateID:     template.LongPollID,
+		PrevTemplateID: c.TemplateID,
+		Stale:          stale,
+		Header:         template.Header,
+		Target:         template.Target,
+		MinTime:        template.MinTime,
+		MaxTime:        template.MaxTime,
+		Added:          added,
+		AddedStake:     addedStake,
+		Removed:        removed,
+	}, nil
+}
+
 // handleGetTicketPoolValue implements the getticketpoolvalue command.
 func handleGetTicketPoolValue(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	amt, err := s.server.blockManager.TicketPoolValue()
@@ -4045,6 +5447,37 @@ func handleGetTicketPoolValue(s *rpcServer, cmd interface{}, closeChan <-chan st
 	return amt.ToCoin(), nil
 }
 
+// handleGetTxAcceptanceScore implements the gettxacceptancescore command.
+func handleGetTxAcceptanceScore(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.GetTxAcceptanceScoreCmd)
+	txHash, err := chainhash.NewHashFromStr(c.Txid)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Txid)
+	}
+
+	// Only unconfirmed transactions in the memory pool are scored.
+	if !s.server.txMemPool.IsTransactionInPool(txHash) {
+		return nil, rpcNoTxInfoError(txHash)
+	}
+	score, err := s.server.txMemPool.AcceptanceScore(txHash)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Could not score transaction")
+	}
+
+	return &hcashjson.GetTxAcceptanceScoreResult{
+		Txid:                  c.Txid,
+		Score:                 score.Score,
+		FeeRate:               hcashutil.Amount(score.FeeRate).ToCoin(),
+		FeeRatePercentile:     score.FeeRatePercentile,
+		PoolBacklog:           score.PoolBacklog,
+		UnconfirmedInputs:     score.UnconfirmedInputs,
+		MinInputConfirmations: score.MinInputConfirmations,
+		ConflictingSpends:     score.ConflictingSpends,
+		SignalsReplacement:    score.SignalsReplacement,
+	}, nil
+}
+
 // handleGetVoteInfo implements the getvoteinfo command.
 func handleGetVoteInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c, ok := cmd.(*hcashjson.GetVoteInfoCmd)
@@ -4107,7 +5540,7 @@ func handleGetVoteInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 		}
 
 		// Obtain status of agenda.
-		state, err := s.chain.ThresholdState(snapshot.Hash, c.Version,
+		state, err := s.chain.NextThresholdState(snapshot.Hash, c.Version,
 			agenda.Vote.Id)
 		if err != nil {
 			return nil, err
@@ -4150,6 +5583,42 @@ func handleGetVoteInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	return result, nil
 }
 
+// handleGetVoteStats implements the getvotestats command.
+func handleGetVoteStats(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	voteStatsIndex := s.server.voteStatsIndex
+	if voteStatsIndex == nil {
+		return nil, rpcInternalError("Vote stats index must be "+
+			"enabled (--votestatsindex)", "Configuration")
+	}
+
+	c := cmd.(*hcashjson.GetVoteStatsCmd)
+
+	// Attempt to decode the supplied address.
+	addr, err := hcashutil.DecodeAddress(c.Address)
+	if err != nil {
+		return nil, rpcAddressKeyError("Could not decode address: %v",
+			err)
+	}
+	if !addr.IsForNet(s.server.chainParams) {
+		return nil, rpcAddressKeyError("Wrong network: %v", addr)
+	}
+
+	stats, err := voteStatsIndex.VoteStats(addr)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(), "Could not fetch vote "+
+			"stats")
+	}
+
+	return &hcashjson.GetVoteStatsResult{
+		Address:             c.Address,
+		Votes:               stats.Votes,
+		Missed:              stats.Missed,
+		Expired:             stats.Expired,
+		InclusionPercentage: stats.InclusionRatio() * 100,
+		AverageLatency:      stats.AverageLatency(),
+	}, nil
+}
+
 // bigToLEUint256 returns the passed big integer as an unsigned 256-bit integer
 // encoded as little-endian bytes.  Numbers which are larger than the max
 // unsigned 256-bit integer are truncated.
@@ -4251,7 +5720,7 @@ func handleGetTxOut(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 			return nil, rpcInternalError("can not get key height of tx", "")
 		}
 		confirmations = best.KeyHeight - entryKeyHeight
-		if blockchain.HashToBig(best.Hash).Cmp(blockchain.CompactToBig(best.Bits)) <= 0{
+		if standalone.HashToBig(best.Hash).Cmp(standalone.CompactToBig(best.Bits)) <= 0 {
 			confirmations++
 		}
 
@@ -4295,6 +5764,25 @@ func handleGetTxOut(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 	return txOutReply, nil
 }
 
+// handleGetTxOutSetInfo handles gettxoutsetinfo commands.
+func handleGetTxOutSetInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	stats, err := s.chain.UtxoSetStats()
+	if err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Could not fetch utxo set statistics")
+	}
+
+	return &hcashjson.GetTxOutSetInfoResult{
+		Height:         stats.Height,
+		BestBlock:      stats.Hash.String(),
+		Transactions:   stats.Transactions,
+		TxOuts:         stats.Utxos,
+		SerializedSize: stats.SerializedSize,
+		SetHash:        stats.SetHash.String(),
+		TotalAmount:    hcashutil.Amount(stats.TotalAmount).ToCoin(),
+	}, nil
+}
+
 // pruneOldBlockTemplates prunes all old block templates from the templatePool
 // map. Must be called with the RPC workstate locked to avoid races to the map.
 func pruneOldBlockTemplates(s *rpcServer, bestHeight int64) {
@@ -4347,7 +5835,7 @@ func handleGetWorkRequest(s *rpcServer) (interface{}, error) {
 		// Choose a payment address at random.
 		payToAddr := cfg.miningAddrs[rand.Intn(len(cfg.miningAddrs))]
 
-		template, err := NewBlockTemplate(s.policy, s.server, payToAddr)
+		template, err := s.server.blkTmplGenerator.NewBlockTemplate(payToAddr)
 		if err != nil {
 			context := "Failed to create new block template"
 			return nil, rpcInternalError(err.Error(), context)
@@ -4362,7 +5850,7 @@ func handleGetWorkRequest(s *rpcServer) (interface{}, error) {
 				"parent template to build from"
 			return nil, rpcInternalError("internal error", context)
 		}
-		templateCopy := deepCopyBlockTemplate(template)
+		templateCopy := mining.DeepCopyBlockTemplate(template)
 		msgBlock = templateCopy.Block
 
 		// Update work state to ensure another block template isn't
@@ -4375,7 +5863,7 @@ func handleGetWorkRequest(s *rpcServer) (interface{}, error) {
 		rpcsLog.Debugf("Generated block template (timestamp %v, extra "+
 			"nonce %d, target %064x, merkle root %s)",
 			msgBlock.Header.Timestamp, state.extraNonce,
-			blockchain.CompactToBig(msgBlock.Header.Bits),
+			standalone.CompactToBig(msgBlock.Header.Bits),
 			msgBlock.Header.MerkleRoot)
 	} else {
 		if msgBlock == nil {
@@ -4391,7 +5879,7 @@ func handleGetWorkRequest(s *rpcServer) (interface{}, error) {
 		// existing block template and track the variations so each
 		// variation can be regenerated if a caller finds an answer and
 		// makes a submission against it.
-		templateCopy := deepCopyBlockTemplate(&BlockTemplate{
+		templateCopy := mining.DeepCopyBlockTemplate(&mining.BlockTemplate{
 			Block: msgBlock,
 		})
 		msgBlock = templateCopy.Block
@@ -4399,16 +5887,16 @@ func handleGetWorkRequest(s *rpcServer) (interface{}, error) {
 		// Update the time of the block template to the current time
 		// while accounting for the median time of the past several
 		// blocks per the chain consensus rules.
-		UpdateBlockTime(msgBlock, s.server.blockManager)
+		s.server.blkTmplGenerator.UpdateBlockTime(msgBlock)
 
 		if templateCopy.Height > 1 {
 			// Increment the extra nonce and update the block template
 			// with the new value by regenerating the coinbase script and
 			// setting the merkle root to the new value.
-			ens := getCoinbaseExtranonces(msgBlock)
+			ens := mining.CoinbaseExtranonces(msgBlock)
 			state.extraNonce++
 			ens[0]++
-			err := UpdateExtraNonce(msgBlock, uint32(latestHeight+1), uint32(latestKeyHeight), ens)
+			err := mining.UpdateExtraNonce(msgBlock, uint32(latestHeight+1), uint32(latestKeyHeight), ens)
 			if err != nil {
 				errStr := fmt.Sprintf("Failed to update extra nonce: "+
 					"%v", err)
@@ -4420,7 +5908,7 @@ func handleGetWorkRequest(s *rpcServer) (interface{}, error) {
 			"nonce %d, target %064x, merkle root %s)",
 			msgBlock.Header.Timestamp,
 			state.extraNonce,
-			blockchain.CompactToBig(msgBlock.Header.Bits),
+			standalone.CompactToBig(msgBlock.Header.Bits),
 			msgBlock.Header.MerkleRoot)
 	}
 
@@ -4481,7 +5969,7 @@ func handleGetWorkRequest(s *rpcServer) (interface{}, error) {
 	// The fact the fields are reversed in this way is rather odd and likey
 	// an artifact of some legacy internal state in the reference
 	// implementation, but it is required for compatibility.
-	target := bigToLEUint256(blockchain.CompactToBig(msgBlock.Header.Bits))
+	target := bigToLEUint256(standalone.CompactToBig(msgBlock.Header.Bits))
 	reply := &hcashjson.GetWorkResult{
 		Data:   hex.EncodeToString(data),
 		Target: hex.EncodeToString(target[:]),
@@ -4542,10 +6030,10 @@ func handleGetWorkSubmission(s *rpcServer, hexData string) (interface{}, error)
 	msgBlock := tempBlock.MsgBlock()
 	msgBlock.Header = submittedHeader
 	if msgBlock.Header.Height > 1 {
-		//targetDifficulty := blockchain.CompactToBig(msgBlock.Header.Bits)
+		//targetDifficulty := standalone.CompactToBig(msgBlock.Header.Bits)
 		//isKeyBlock := false
 		//hash := msgBlock.Header.BlockHash()
-		//if blockchain.HashToBig(&hash).Cmp(targetDifficulty) <= 0 {
+		//if standalone.HashToBig(&hash).Cmp(targetDifficulty) <= 0 {
 		//	isKeyBlock = true
 		//}
 		pkScriptCopy := make([]byte, len(blockInfo.pkScript))
@@ -4567,12 +6055,12 @@ func handleGetWorkSubmission(s *rpcServer, hexData string) (interface{}, error)
 
 	//delete
 	hash := block.MsgBlock().Header.BlockHash()
-	hardTargetDifficulty := blockchain.CompactToBig(block.MsgBlock().Header.Bits)
+	hardTargetDifficulty := standalone.CompactToBig(block.MsgBlock().Header.Bits)
 	targetDifficulty := big.NewInt(0)
 	targetDifficulty.Mul(hardTargetDifficulty, big.NewInt(int64(activeNetParams.DifficultyRate)))
 
-	if blockchain.HashToBig(&hash).Cmp(targetDifficulty) <= 0 {
-		if blockchain.HashToBig(&hash).Cmp(hardTargetDifficulty) > 0 {
+	if standalone.HashToBig(&hash).Cmp(targetDifficulty) <= 0 {
+		if standalone.HashToBig(&hash).Cmp(hardTargetDifficulty) > 0 {
 			//Microblock delete extracoinbase
 			transactions := make([]*wire.MsgTx, len(msgBlock.Transactions) - 1)
 			for i, tx := range msgBlock.Transactions {
@@ -4715,6 +6203,101 @@ func handleHelp(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (inter
 	return help, nil
 }
 
+// handleListBanned implements the listbanned command.
+func handleListBanned(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	banned := s.server.BannedHosts()
+	hosts := make([]string, 0, len(banned))
+	for host := range banned {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	results := make([]hcashjson.ListBannedResult, 0, len(hosts))
+	for _, host := range hosts {
+		results = append(results, hcashjson.ListBannedResult{
+			Address:     host,
+			BannedUntil: banned[host].Unix(),
+		})
+	}
+	return results, nil
+}
+
+// handleListLiveTickets implements the listlivetickets command.  It returns a
+// page of the hashes of the live tickets ordered by hash.
+func handleListLiveTickets(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.ListLiveTicketsCmd)
+	lt, err := s.server.blockManager.chain.LiveTickets()
+	if err != nil {
+		return nil, rpcInternalError("Could not get live tickets "+
+			err.Error(), "")
+	}
+
+	ltString := make([]string, len(lt))
+	for i := range lt {
+		ltString[i] = lt[i].String()
+	}
+	sort.Strings(ltString)
+
+	start, end, nextCursor, err := hcashjson.PageBounds(len(ltString),
+		c.Cursor, c.Limit)
+	if err != nil {
+		return nil, rpcInvalidError("%v", err)
+	}
+
+	return &hcashjson.ListLiveTicketsResult{
+		Items:      ltString[start:end],
+		NextCursor: nextCursor,
+		Total:      int64(len(ltString)),
+	}, nil
+}
+
+// handleListener implements the listener command.
+func handleListener(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.ListenerCmd)
+
+	switch c.SubCmd {
+	case hcashjson.LAdd, hcashjson.LRemove:
+		if c.Service == nil || c.Addr == nil {
+			return nil, rpcInvalidError("%v: the service and address "+
+				"must be specified", c.SubCmd)
+		}
+
+		var defaultPort string
+		var add, remove func(string) ([]string, error)
+		switch *c.Service {
+		case "p2p":
+			defaultPort = activeNetParams.DefaultPort
+			add, remove = s.server.AddListener, s.server.RemoveListener
+		case "rpc":
+			defaultPort = activeNetParams.rpcPort
+			add, remove = s.AddListener, s.RemoveListener
+		default:
+			return nil, rpcInvalidError("invalid service %q", *c.Service)
+		}
+
+		addr := normalizeAddress(*c.Addr, defaultPort)
+		var err error
+		if c.SubCmd == hcashjson.LAdd {
+			_, err = add(addr)
+		} else {
+			_, err = remove(addr)
+		}
+		if err != nil {
+			return nil, rpcMiscError(fmt.Sprintf("unable to %s %s "+
+				"listener for %s: %v", c.SubCmd, *c.Service, addr,
+				err))
+		}
+	case hcashjson.LList:
+	default:
+		return nil, rpcInvalidError("invalid subcommand %q", c.SubCmd)
+	}
+
+	return &hcashjson.ListenerResult{
+		P2P: s.server.ListenAddrs(),
+		RPC: s.ListenAddrs(),
+	}, nil
+}
+
 // handleLiveTickets implements the livetickets command.
 func handleLiveTickets(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	lt, err := s.server.blockManager.chain.LiveTickets()
@@ -4747,6 +6330,22 @@ func handleMissedTickets(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 	return hcashjson.MissedTicketsResult{Tickets: mtString}, nil
 }
 
+// handleExpiredTickets implements the expiredtickets command.
+func handleExpiredTickets(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	et, err := s.server.blockManager.chain.ExpiredTickets()
+	if err != nil {
+		return nil, rpcInternalError("Could not get expired tickets "+
+			err.Error(), "")
+	}
+
+	etString := make([]string, len(et))
+	for i, hash := range et {
+		etString[i] = hash.String()
+	}
+
+	return hcashjson.ExpiredTicketsResult{Tickets: etString}, nil
+}
+
 // handlePing implements the ping command.
 func handlePing(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// Ask server to ping \o_
@@ -4794,7 +6393,7 @@ func handleRebroadcastWinners(s *rpcServer, cmd interface{}, closeChan <-chan st
 	hash, height, keyHeight:= s.server.blockManager.chainState.Best()
 	chain := s.server.blockManager.chain
 	var err error
-	if blockchain.HashToBig(hash).Cmp(blockchain.CompactToBig(s.server.blockManager.chainState.newestBits)) > 0{
+	if standalone.HashToBig(hash).Cmp(standalone.CompactToBig(s.server.blockManager.chainState.newestBits)) > 0 {
 		hash = &(s.server.blockManager.chainState.curPrevKeyHash)
 		height, err = chain.BlockHeightByHash(&(s.server.blockManager.chainState.curPrevKeyHash))
 		if err != nil{
@@ -5060,8 +6659,49 @@ func fetchMempoolTxnsForAddress(s *rpcServer, addr hcashutil.Address, numToSkip,
 	return mpTxns[numToSkip:rangeEnd], numToSkip
 }
 
+// handleReloadConfig implements the reloadconfig command.
+func handleReloadConfig(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	applied, ignored, err := s.server.reloadConfig()
+	if err != nil {
+		return nil, rpcMiscError(fmt.Sprintf("Invalid configuration: %v",
+			err))
+	}
+	if applied == nil {
+		applied = []string{}
+	}
+	if ignored == nil {
+		ignored = []string{}
+	}
+	return &hcashjson.ReloadConfigResult{
+		Applied: applied,
+		Ignored: ignored,
+	}, nil
+}
+
+// handleRestart implements the restart command.
+func handleRestart(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if runningAsService {
+		return nil, rpcMiscError("Restart is not supported when running " +
+			"as a service")
+	}
+	requestRestart()
+	select {
+	case s.requestProcessShutdown <- struct{}{}:
+	default:
+	}
+	return "hcashd restarting.", nil
+}
+
 // handleSearchRawTransactions implements the searchrawtransactions command.
 func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.SearchRawTransactionsCmd)
+	return searchRawTransactions(s, c, nil)
+}
+
+// searchRawTransactions returns the result of the passed searchrawtransactions
+// command.  When a stream is passed, the transactions are sent to it one by
+// one instead of being included in the result.
+func searchRawTransactions(s *rpcServer, c *hcashjson.SearchRawTransactionsCmd, stream rpcResultStream) (interface{}, error) {
 	// Respond with an error if the address index is not enabled.
 	addrIndex := s.server.addrIndex
 	if addrIndex == nil {
@@ -5071,7 +6711,6 @@ func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan
 
 	// Override the flag for including extra previous output information in
 	// each input if needed.
-	c := cmd.(*hcashjson.SearchRawTransactionsCmd)
 	vinExtra := false
 	if c.VinExtra != nil {
 		vinExtra = *c.VinExtra != 0
@@ -5092,6 +6731,9 @@ func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan
 		return nil, rpcAddressKeyError("Could not decode address: %v",
 			err)
 	}
+	if !addr.IsForNet(s.server.chainParams) {
+		return nil, rpcAddressKeyError("Wrong network: %v", addr)
+	}
 
 	// Override the default number of requested entries if needed.  Also,
 	// just return now if the number of requested entries is zero to avoid
@@ -5199,8 +6841,10 @@ func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan
 	}
 
 	// Address has never been used if neither source yielded any results.
+	// This is not an internal error, so it is not logged as one.
 	if len(addressTxns) == 0 {
-		return nil, rpcInternalError("No Txns available", "")
+		return nil, hcashjson.NewRPCError(hcashjson.ErrRPCNoTxInfo,
+			"No information available about address")
 	}
 
 	// Serialize all of the transactions to hex.
@@ -5224,6 +6868,14 @@ func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan
 
 	// When not in verbose mode, simply return a list of serialized txns.
 	if c.Verbose != nil && *c.Verbose == 0 {
+		if stream != nil {
+			for _, hexTxn := range hexTxns {
+				if err := stream.Send(hexTxn); err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		}
 		return hexTxns, nil
 	}
 
@@ -5239,7 +6891,11 @@ func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan
 	// The verbose flag is set, so generate the JSON object and return it.
 	best := s.chain.BestSnapshot()
 	chainParams := s.server.chainParams
-	srtList := make([]hcashjson.SearchRawTransactionsResult, len(addressTxns))
+	var srtList []hcashjson.SearchRawTransactionsResult
+	if stream == nil {
+		srtList = make([]hcashjson.SearchRawTransactionsResult,
+			len(addressTxns))
+	}
 	for i := range addressTxns {
 		// The deserialized transaction is needed, so deserialize the
 		// retrieved transaction if it's in serialized form (which will
@@ -5260,7 +6916,12 @@ func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan
 			mtx = rtx.tx.MsgTx()
 		}
 
-		result := &srtList[i]
+		var result *hcashjson.SearchRawTransactionsResult
+		if stream != nil {
+			result = new(hcashjson.SearchRawTransactionsResult)
+		} else {
+			result = &srtList[i]
+		}
 		result.Hex = hexTxns[i]
 		result.Txid = mtx.TxHash().String()
 		result.Vin, err = createVinListPrevOut(s, mtx, chainParams,
@@ -5324,6 +6985,12 @@ func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan
 			result.BlockHash = blkHashStr
 			result.Confirmations = uint64(1 + best.Height - blkHeight)
 		}
+
+		if stream != nil {
+			if err := stream.Send(result); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return srtList, nil
@@ -5380,6 +7047,49 @@ func handleSendRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan st
 	return tx.Hash().String(), nil
 }
 
+// handleSetBan implements the setban command.
+func handleSetBan(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.SetBanCmd)
+
+	// Bans are tracked by host, so normalize the provided address to the
+	// same form the server uses for connected peers.
+	ip := net.ParseIP(c.Addr)
+	if ip == nil {
+		return nil, rpcInvalidError("Invalid IP address: %v", c.Addr)
+	}
+	host := ip.String()
+
+	switch c.SubCmd {
+	case hcashjson.SBAdd:
+		now := time.Now()
+		until := now.Add(currentBanSettings().banDuration)
+		if c.BanTime != nil && *c.BanTime != 0 {
+			if c.Absolute != nil && *c.Absolute {
+				until = time.Unix(*c.BanTime, 0)
+			} else {
+				until = now.Add(time.Duration(*c.BanTime) *
+					time.Second)
+			}
+		}
+		if !until.After(now) {
+			return nil, rpcInvalidError("Ban time must be in the "+
+				"future: %v", until)
+		}
+		s.server.BanHost(host, until)
+
+	case hcashjson.SBRemove:
+		if err := s.server.UnbanHost(host); err != nil {
+			return nil, rpcInvalidError("%v: %v", err, host)
+		}
+
+	default:
+		return nil, rpcInvalidError("Invalid subcommand for setban: %v",
+			c.SubCmd)
+	}
+
+	return nil, nil
+}
+
 // handleSetGenerate implements the setgenerate command.
 func handleSetGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*hcashjson.SetGenerateCmd)
@@ -5413,6 +7123,41 @@ func handleSetGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	return nil, nil
 }
 
+// handleSetMiningAddresses implements the setminingaddresses command.
+func handleSetMiningAddresses(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.SetMiningAddressesCmd)
+
+	// Sort the addresses so the first payout, which receives any remainder
+	// from rounding, does not depend on the order of the map.
+	strAddrs := make([]string, 0, len(c.Payouts))
+	for strAddr := range c.Payouts {
+		strAddrs = append(strAddrs, strAddr)
+	}
+	sort.Strings(strAddrs)
+
+	payouts := make([]mining.CoinbasePayout, 0, len(strAddrs))
+	for _, strAddr := range strAddrs {
+		addr, err := hcashutil.DecodeAddress(strAddr)
+		if err != nil {
+			return nil, rpcAddressKeyError("Could not decode address: %v",
+				err)
+		}
+		if !addr.IsForNet(s.server.chainParams) {
+			return nil, rpcAddressKeyError("Wrong network: %v",
+				addr.EncodeAddress())
+		}
+		payouts = append(payouts, mining.CoinbasePayout{
+			Address: addr,
+			Weight:  c.Payouts[strAddr],
+		})
+	}
+
+	if err := s.policy.CoinbasePayouts.Set(payouts); err != nil {
+		return nil, rpcInvalidError("Invalid payouts: %v", err)
+	}
+	return nil, nil
+}
+
 // handleStop implements the stop command.
 func handleStop(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	select {
@@ -5556,16 +7301,12 @@ func feeInfoForMempool(s *rpcServer, txType stake.TxType) *hcashjson.FeeInfoMemp
 // calcFee calculates the fee of a transaction that has its fraud proofs
 // properly set.
 func calcFeePerKb(tx *hcashutil.Tx) hcashutil.Amount {
-	var in hcashutil.Amount
-	for _, txIn := range tx.MsgTx().TxIn {
-		in += hcashutil.Amount(txIn.ValueIn)
-	}
-	var out hcashutil.Amount
-	for _, txOut := range tx.MsgTx().TxOut {
-		out += hcashutil.Amount(txOut.Value)
+	fee, err := txFee(tx.MsgTx())
+	if err != nil {
+		return 0
 	}
 
-	return ((in - out) * 1000) / hcashutil.Amount(tx.MsgTx().SerializeSize())
+	return (fee * 1000) / hcashutil.Amount(tx.MsgTx().SerializeSize())
 }
 
 // feeInfoForBlock fetches the ticket fee information for a given tx type in a
@@ -5758,6 +7499,9 @@ func handleTicketsForAddress(s *rpcServer, cmd interface{}, closeChan <-chan str
 	if err != nil {
 		return nil, rpcInvalidError("Invalid address: %v", err)
 	}
+	if !addr.IsForNet(s.server.chainParams) {
+		return nil, rpcAddressKeyError("Wrong network: %v", addr)
+	}
 
 	tickets, err := s.server.blockManager.chain.TicketsWithAddress(addr)
 	if err != nil {
@@ -5810,7 +7554,7 @@ func handleTicketVWAP(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 		return nil, rpcInvalidError("Start keyHeight %v is beyond end "+
 			"keyHeight %v", start, end)
 	}
-	if end > uint32(bestHeight) {
+	if end > uint32(bestKeyHeight) {
 		return nil, rpcInvalidError("End keyHeight %v is beyond "+
 			"blockchain tip keyHeight %v", end, bestKeyHeight)
 	}
@@ -5831,9 +7575,10 @@ func handleTicketVWAP(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 		if blockKeyHeight <= uint32(activeNetParams.CoinbaseMaturity) || blockKeyHeight < start {
 			break
 		}
-		//if this block is a keyBlock,then calculate it
+		//if this block is a keyBlock within the requested range,then
+		//calculate it
 		blockHash := blockHeader.BlockHash()
-		if blockchain.HashToBig(&blockHash).Cmp(blockchain.CompactToBig(blockHeader.Bits)) <= 0{
+		if blockKeyHeight <= end && standalone.HashToBig(&blockHash).Cmp(standalone.CompactToBig(blockHeader.Bits)) <= 0 {
 			ticketNum += int64(blockHeader.FreshStake)
 			totalValue += blockHeader.SBits * int64(blockHeader.FreshStake)
 		}
@@ -5949,49 +7694,38 @@ func handleValidateAddress(s *rpcServer, cmd interface{}, closeChan <-chan struc
 	result.Address = addr.EncodeAddress()
 	result.IsValid = true
 
-	return result, nil
-}
-
-func verifyChain(s *rpcServer, level, depth int64) error {
-	best := s.chain.BestSnapshot()
-	finishHeight := best.Height - depth
-	if finishHeight < 0 {
-		finishHeight = 0
+	// Describe the script paying to the address.  Addresses which can't be
+	// paid to, such as those of unknown signature schemes, have no script.
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return result, nil
 	}
-	rpcsLog.Infof("Verifying chain for %d blocks at level %d",
-		best.Height-finishHeight, level)
-
-	for height := best.Height; height > finishHeight; height-- {
-		// Level 0 just looks up the block.
-		block, err := s.chain.BlockByHeight(height)
-		if err != nil {
-			rpcsLog.Errorf("Verify is unable to fetch block at "+
-				"height %d: %v", height, err)
-			return err
-		}
-
-		// Level 1 does basic chain sanity checks.
-		if level > 0 {
-			err := blockchain.CheckBlockSanity(s.chain, block,
-				s.server.timeSource,
-				activeNetParams.Params)
-			if err != nil {
-				rpcsLog.Errorf("Verify is unable to validate "+
-					"block at hash %v height %d: %v",
-					block.Hash(), height, err)
-				return err
-			}
-		}
+	details, err := txscript.CalcScriptDetails(txscript.DefaultScriptVersion,
+		pkScript, s.server.chainParams)
+	if err != nil {
+		return result, nil
 	}
-	rpcsLog.Infof("Chain verify completed successfully")
+	result.ScriptPubKey = hex.EncodeToString(pkScript)
+	result.Type = details.TypeString()
 
-	return nil
+	return result, nil
 }
 
-// handleVerifyChain implements the verifychain command.
-func handleVerifyChain(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c := cmd.(*hcashjson.VerifyChainCmd)
+// verifyChain verifies the passed number of most recent blocks of the main
+// chain at the passed check level.  The progress function, when non-nil, is
+// called after each block is verified.
+func verifyChain(s *rpcServer, level, depth int64, progress blockchain.VerifyProgressFunc, closeChan <-chan struct{}) error {
+	err := s.chain.VerifyChain(blockchain.VerifyLevel(level), depth,
+		progress, closeChan)
+	if err != nil {
+		rpcsLog.Errorf("Verify chain failed: %v", err)
+	}
+	return err
+}
 
+// verifyChainParams returns the check level and depth of the passed verifychain
+// command.
+func verifyChainParams(c *hcashjson.VerifyChainCmd) (int64, int64) {
 	var checkLevel, checkDepth int64
 	if c.CheckLevel != nil {
 		checkLevel = *c.CheckLevel
@@ -5999,8 +7733,15 @@ func handleVerifyChain(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	if c.CheckDepth != nil {
 		checkDepth = *c.CheckDepth
 	}
+	return checkLevel, checkDepth
+}
+
+// handleVerifyChain implements the verifychain command.
+func handleVerifyChain(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcashjson.VerifyChainCmd)
+	checkLevel, checkDepth := verifyChainParams(c)
 
-	err := verifyChain(s, checkLevel, checkDepth)
+	err := verifyChain(s, checkLevel, checkDepth, nil, closeChan)
 	return err == nil, nil
 }
 
@@ -6034,10 +7775,7 @@ func handleVerifyMessage(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 
 	// Validate the signature - this just shows that it was valid at all.
 	// we will compare it with the key next.
-	var buf bytes.Buffer
-	wire.WriteVarString(&buf, 0, "Hypercash Signed Message:\n")
-	wire.WriteVarString(&buf, 0, c.Message)
-	expectedMessageHash := chainhash.HashB(buf.Bytes())
+	expectedMessageHash := signedMessageHash(c.Message)
 	pk, wasCompressed, err := chainec.Secp256k1.RecoverCompact(sig,
 		expectedMessageHash)
 	if err != nil {
@@ -6101,12 +7839,26 @@ func handleVerifyBlissMessage(s *rpcServer, cmd interface{}, closeChan <-chan st
 // handleVersion implements the version command.
 func handleVersion(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	result := map[string]hcashjson.VersionResult{
+		"hcashd": {
+			VersionString: version(),
+			Major:         uint32(appMajor),
+			Minor:         uint32(appMinor),
+			Patch:         uint32(appPatch),
+			Prerelease:    normalizeVerString(appPreRelease),
+			BuildMetadata: normalizeVerString(appBuild),
+		},
 		"hcashdjsonrpcapi": {
 			VersionString: jsonrpcSemverString,
 			Major:         jsonrpcSemverMajor,
 			Minor:         jsonrpcSemverMinor,
 			Patch:         jsonrpcSemverPatch,
 		},
+		// The peer-to-peer protocol version is a single number, so it
+		// is reported as the major version.
+		"hcashdp2pprotocol": {
+			VersionString: fmt.Sprintf("%d.0.0", wire.ProtocolVersion),
+			Major:         wire.ProtocolVersion,
+		},
 	}
 	return result, nil
 }
@@ -6121,12 +7873,16 @@ type rpcServer struct {
 	chain                  *blockchain.BlockChain
 	authsha                [sha256.Size]byte
 	limitauthsha           [sha256.Size]byte
+	limitedMethods         map[string]struct{}
 	ntfnMgr                *wsNotificationManager
 	numClients             int32
 	statusLines            map[int]string
 	statusLock             sync.RWMutex
 	wg                     sync.WaitGroup
+	listenersMtx           sync.Mutex
 	listeners              []net.Listener
+	listenFunc             listenFunc
+	httpServer             *http.Server
 	workState              *workState
 	gbtWorkState           *gbtWorkState
 	templatePool           map[[merkleRootPairSize]byte]*workStateBlockInfo
@@ -6138,6 +7894,21 @@ type rpcServer struct {
 	coinSupplyMtx    sync.Mutex
 	coinSupplyHeight int64
 	coinSupplyTotal  int64
+
+	// chainAudit tracks the background audit started by auditchain.
+	chainAudit chainAudit
+
+	// authLimiter tracks authentication failures to refuse and ban hosts
+	// that repeatedly fail to authenticate.
+	authLimiter *rpcAuthLimiter
+
+	// sseEvents relays notifications to the clients of the server-sent
+	// events endpoint.
+	sseEvents *sseEventManager
+
+	// attestationKey is the key of the node used to sign the attestations
+	// returned by the getkeyblockattestation command.
+	attestationKey *secp256k1.PrivateKey
 }
 
 // httpStatusLine returns a response Status-Line (RFC 2616 Section 6.1) for the
@@ -6203,15 +7974,19 @@ func (s *rpcServer) Stop() error {
 		return nil
 	}
 	rpcsLog.Warnf("RPC server shutting down")
+	s.listenersMtx.Lock()
 	for _, listener := range s.listeners {
 		err := listener.Close()
 		if err != nil {
+			s.listenersMtx.Unlock()
 			rpcsLog.Errorf("Problem shutting down rpc: %v", err)
 			return err
 		}
 	}
+	s.listenersMtx.Unlock()
 	s.ntfnMgr.Shutdown()
 	s.ntfnMgr.WaitForShutdown()
+	s.chainAudit.stop()
 	close(s.quit)
 	s.wg.Wait()
 	rpcsLog.Infof("RPC server shutdown complete")
@@ -6230,10 +8005,10 @@ func (s *rpcServer) RequestedProcessShutdown() <-chan struct{} {
 //
 // This function is safe for concurrent access.
 func (s *rpcServer) limitConnections(w http.ResponseWriter, remoteAddr string) bool {
-	if int(atomic.LoadInt32(&s.numClients)+1) > cfg.RPCMaxClients {
+	maxClients := currentConnLimits().rpcMaxClients
+	if int(atomic.LoadInt32(&s.numClients)+1) > maxClients {
 		rpcsLog.Infof("Max RPC clients exceeded [%d] - "+
-			"disconnecting client %s", cfg.RPCMaxClients,
-			remoteAddr)
+			"disconnecting client %s", maxClients, remoteAddr)
 		http.Error(w, "503 Too busy.  Try again later.",
 			http.StatusServiceUnavailable)
 		return true
@@ -6259,9 +8034,54 @@ func (s *rpcServer) decrementClients() {
 	atomic.AddInt32(&s.numClients, -1)
 }
 
+// authRefused returns whether authentication attempts from the host of the
+// passed remote address are currently refused due to its earlier
+// authentication failures.
+//
+// This function is safe for concurrent access.
+func (s *rpcServer) authRefused(remoteAddr, method string) bool {
+	host := rpcAuthHostKey(remoteAddr)
+	refused, banned := s.authLimiter.refused(host, time.Now())
+	if refused {
+		rpcsLog.Debugf("RPC authentication refused: client=%s "+
+			"method=%s banned=%v", host, method, banned)
+	}
+	return refused
+}
+
+// authFailure records an authentication failure from the host of the passed
+// remote address and logs it along with the supplied username and the method
+// used to authenticate.  The host is banned when it reaches the maximum number
+// of consecutive failures.
+//
+// This function is safe for concurrent access.
+func (s *rpcServer) authFailure(remoteAddr, user, method string) {
+	host := rpcAuthHostKey(remoteAddr)
+	failures, banned := s.authLimiter.failure(host, time.Now())
+	rpcsLog.Warnf("RPC authentication failure: client=%s user=%q "+
+		"method=%s failures=%d", host, user, method, failures)
+	if banned {
+		rpcsLog.Warnf("RPC authentication ban: client=%s "+
+			"failures=%d duration=%v", host, failures,
+			rpcAuthBanDuration)
+	}
+}
+
+// authSuccess records a successful authentication from the host of the passed
+// remote address.
+//
+// This function is safe for concurrent access.
+func (s *rpcServer) authSuccess(remoteAddr string) {
+	s.authLimiter.success(rpcAuthHostKey(remoteAddr))
+}
+
 // checkAuth checks the HTTP Basic authentication supplied by a wallet or RPC
 // client in the HTTP request r.  If the supplied authentication does not match
-// the username and password expected, a non-nil error is returned.
+// the username and password expected, a non-nil error is returned.  Only
+// supplied credentials which do not match count as authentication failures,
+// and while the client is refused due to earlier failures, credentials which
+// do not match are rejected without being counted.  Matching credentials are
+// always accepted.
 //
 // This check is time-constant.
 //
@@ -6270,11 +8090,25 @@ func (s *rpcServer) decrementClients() {
 // of the server (true) or whether the user is limited (false). The second is
 // always false if the first is.
 func (s *rpcServer) checkAuth(r *http.Request, require bool) (bool, bool, error) {
+	method := "http"
+	switch r.URL.Path {
+	case "/ws":
+		method = "websocket"
+	case "/events":
+		method = "sse"
+	}
+
+	// Clients presenting a certificate which was verified against the
+	// configured client certificate authorities during the TLS handshake
+	// are authenticated as the admin user.
+	if hasVerifiedClientCert(r) {
+		s.authSuccess(r.RemoteAddr)
+		return true, true, nil
+	}
+
 	authhdr := r.Header["Authorization"]
 	if len(authhdr) <= 0 {
 		if require {
-			rpcsLog.Warnf("RPC authentication failure from %s",
-				r.RemoteAddr)
 			return false, false, errors.New("auth failure")
 		}
 
@@ -6287,20 +8121,34 @@ func (s *rpcServer) checkAuth(r *http.Request, require bool) (bool, bool, error)
 	// those are probably expected to have a higher volume of calls
 	limitcmp := subtle.ConstantTimeCompare(authsha[:], s.limitauthsha[:])
 	if limitcmp == 1 {
+		s.authSuccess(r.RemoteAddr)
 		return true, false, nil
 	}
 
 	// Check for admin-level auth
 	cmp := subtle.ConstantTimeCompare(authsha[:], s.authsha[:])
 	if cmp == 1 {
+		s.authSuccess(r.RemoteAddr)
 		return true, true, nil
 	}
 
 	// Request's auth doesn't match either user
-	rpcsLog.Warnf("RPC authentication failure from %s", r.RemoteAddr)
+	if s.authRefused(r.RemoteAddr, method) {
+		return false, false, errors.New("auth refused")
+	}
+	user, _, _ := r.BasicAuth()
+	s.authFailure(r.RemoteAddr, user, method)
 	return false, false, errors.New("auth failure")
 }
 
+// hasVerifiedClientCert returns whether the passed request was made over a TLS
+// connection on which the client presented a certificate signed by one of the
+// configured client certificate authorities.
+func hasVerifiedClientCert(r *http.Request) bool {
+	return cfg.RPCClientCA != "" && r.TLS != nil &&
+		len(r.TLS.VerifiedChains) > 0
+}
+
 // parsedRPCCmd represents a JSON-RPC request object that has been parsed into
 // a known concrete command along with any error that might have happened while
 // parsing it.
@@ -6382,6 +8230,56 @@ func createMarshalledReply(id, result interface{}, replyErr error) ([]byte, erro
 	return hcashjson.MarshalResponse(id, result, jsonErr)
 }
 
+// marshalledErrorReply returns a new marshalled JSON-RPC response with a null
+// ID for the passed error, which is used when the ID of a request is unknown
+// because it could not be parsed.  Nil is returned when marshalling fails.
+func marshalledErrorReply(jsonErr *hcashjson.RPCError) []byte {
+	msg, err := createMarshalledReply(nil, nil, jsonErr)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal reply: %v", err)
+		return nil
+	}
+	return msg
+}
+
+// processRequest determines the response for the passed JSON-RPC request and
+// returns it marshalled.  Nil is returned for requests with no ID
+// (notifications) since they must not have a response per the JSON-RPC spec,
+// as well as when marshalling the response fails.
+func (s *rpcServer) processRequest(request *hcashjson.Request, isAdmin bool, closeChan <-chan struct{}) []byte {
+	if request.ID == nil {
+		return nil
+	}
+
+	// Check if the user is limited and set error if method unauthorized.
+	var jsonErr error
+	var result interface{}
+	if !s.authorized(request.Method, isAdmin) {
+		jsonErr = rpcInvalidError("limited user not " +
+			"authorized for this method")
+	}
+
+	if jsonErr == nil {
+		// Attempt to parse the JSON-RPC request into a known concrete
+		// command.
+		parsedCmd := parseCmd(request)
+		if parsedCmd.err != nil {
+			jsonErr = parsedCmd.err
+		} else {
+			result, jsonErr = s.standardCmdResult(parsedCmd,
+				closeChan)
+		}
+	}
+
+	// Marshal the response.
+	msg, err := createMarshalledReply(request.ID, result, jsonErr)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal reply: %v", err)
+		return nil
+	}
+	return msg
+}
+
 // jsonRPCRead handles reading and responding to RPC messages.
 func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin bool) {
 	if atomic.LoadInt32(&s.shutdown) != 0 {
@@ -6426,65 +8324,35 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 	defer buf.Flush()
 	conn.SetReadDeadline(timeZeroVal)
 
-	// Attempt to parse the raw body into a JSON-RPC request.
-	var responseID interface{}
-	var jsonErr error
-	var result interface{}
-	var request hcashjson.Request
-	if err := json.Unmarshal(body, &request); err != nil {
-		jsonErr = &hcashjson.RPCError{
-			Code: hcashjson.ErrRPCParse.Code,
-			Message: fmt.Sprintf("Failed to parse request: %v",
-				err),
-		}
-	}
-	if jsonErr == nil {
-		// Requests with no ID (notifications) must not have a response
-		// per the JSON-RPC spec.
-		if request.ID == nil {
-			return
-		}
-
-		// The parse was at least successful enough to have an ID so
-		// set it for the response.
-		responseID = request.ID
-
-		// Setup a close notifier.  Since the connection is hijacked,
-		// the CloseNotifer on the ResponseWriter is not available.
-		closeChan := make(chan struct{}, 1)
-		go func() {
-			_, err := conn.Read(make([]byte, 1))
-			if err != nil {
-				close(closeChan)
-			}
-		}()
-
-		// Check if the user is limited and set error if method
-		// unauthorized
-		if !isAdmin {
-			if _, ok := rpcLimited[request.Method]; !ok {
-				jsonErr = rpcInvalidError("limited user not " +
-					"authorized for this method")
-			}
+	// Setup a close notifier.  Since the connection is hijacked, the
+	// CloseNotifer on the ResponseWriter is not available.
+	closeChan := make(chan struct{}, 1)
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		if err != nil {
+			close(closeChan)
 		}
+	}()
 
-		if jsonErr == nil {
-			// Attempt to parse the JSON-RPC request into a known
-			// concrete command.
-			parsedCmd := parseCmd(&request)
-			if parsedCmd.err != nil {
-				jsonErr = parsedCmd.err
-			} else {
-				result, jsonErr = s.standardCmdResult(parsedCmd,
-					closeChan)
+	// Attempt to parse the raw body into a JSON-RPC request or a batch of
+	// them and determine the response.
+	var msg []byte
+	if isBatchRequest(body) {
+		msg = s.processBatch(body, isAdmin, closeChan)
+	} else {
+		var request hcashjson.Request
+		if err := json.Unmarshal(body, &request); err != nil {
+			jsonErr := &hcashjson.RPCError{
+				Code: hcashjson.ErrRPCParse.Code,
+				Message: fmt.Sprintf("Failed to parse request: %v",
+					err),
 			}
+			msg = marshalledErrorReply(jsonErr)
+		} else {
+			msg = s.processRequest(&request, isAdmin, closeChan)
 		}
 	}
-
-	// Marshal the response.
-	msg, err := createMarshalledReply(responseID, result, jsonErr)
-	if err != nil {
-		rpcsLog.Errorf("Failed to marshal reply: %v", err)
+	if msg == nil {
 		return
 	}
 
@@ -6565,21 +8433,124 @@ func (s *rpcServer) Start() {
 		s.WebsocketHandler(ws, r.RemoteAddr, authenticated, isAdmin)
 	})
 
+	// Server-sent events endpoint.
+	if cfg.RPCSSE {
+		rpcServeMux.HandleFunc("/events", s.SSEHandler)
+	}
+
+	// Unauthenticated read-only REST endpoints.
+	if cfg.RPCREST {
+		rpcServeMux.HandleFunc(restPathPrefix, s.RESTHandler)
+	}
+
+	s.listenersMtx.Lock()
+	s.httpServer = httpServer
 	for _, listener := range s.listeners {
-		s.wg.Add(1)
-		go func(listener net.Listener) {
-			rpcsLog.Infof("RPC server listening on %s",
-				listener.Addr())
-			httpServer.Serve(listener)
-			rpcsLog.Tracef("RPC listener done for %s",
-				listener.Addr())
-			s.wg.Done()
-		}(listener)
+		s.serveListener(listener)
 	}
+	s.listenersMtx.Unlock()
 
 	s.ntfnMgr.Start()
 }
 
+// serveListener serves RPC requests accepted from the passed listener until it
+// is closed.
+//
+// This function MUST be called with the listeners mutex held and after the
+// HTTP server has been created.
+func (s *rpcServer) serveListener(listener net.Listener) {
+	s.wg.Add(1)
+	go func() {
+		rpcsLog.Infof("RPC server listening on %s", listener.Addr())
+		s.httpServer.Serve(listener)
+		rpcsLog.Tracef("RPC listener done for %s", listener.Addr())
+		s.wg.Done()
+	}()
+}
+
+// AddListener starts listening for RPC connections on the passed address,
+// which includes the port.  An address with an empty host listens on all IPv4
+// and IPv6 interfaces.  The listeners use TLS unless it is disabled.  It
+// returns the addresses of the new listeners.
+func (s *rpcServer) AddListener(addr string) ([]string, error) {
+	listeners, err := listenOn(s.listenFunc, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s.listenersMtx.Lock()
+	defer s.listenersMtx.Unlock()
+	if atomic.LoadInt32(&s.shutdown) != 0 {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+		return nil, errors.New("RPC server is shutting down")
+	}
+
+	addrs := make([]string, 0, len(listeners))
+	for _, listener := range listeners {
+		s.listeners = append(s.listeners, listener)
+		if s.httpServer != nil {
+			s.serveListener(listener)
+		}
+		addrs = append(addrs, listener.Addr().String())
+	}
+	return addrs, nil
+}
+
+// RemoveListener stops listening for RPC connections on the passed address.
+// An address with an empty host removes the listeners on all interfaces for
+// its port.  Requests on connections that were accepted from the removed
+// listeners, such as the request to remove them and websocket clients, are
+// still served until the connections are closed.  Removing all listeners is
+// refused since the server would become unreachable.  It returns the
+// addresses of the removed listeners.
+func (s *rpcServer) RemoveListener(addr string) ([]string, error) {
+	s.listenersMtx.Lock()
+	defer s.listenersMtx.Unlock()
+
+	remaining := make([]net.Listener, 0, len(s.listeners))
+	var removed []net.Listener
+	for _, listener := range s.listeners {
+		if listenerMatches(listener.Addr(), addr) {
+			removed = append(removed, listener)
+			continue
+		}
+		remaining = append(remaining, listener)
+	}
+	if len(removed) == 0 {
+		return nil, errNoListenerMatch
+	}
+	if len(remaining) == 0 {
+		return nil, errors.New("refusing to remove the last RPC listener")
+	}
+
+	s.listeners = remaining
+	addrs := make([]string, 0, len(removed))
+	for _, listener := range removed {
+		if err := listener.Close(); err != nil {
+			rpcsLog.Warnf("Unable to close RPC listener %s: %v",
+				listener.Addr(), err)
+		}
+		rpcsLog.Infof("RPC server no longer listening on %s",
+			listener.Addr())
+		addrs = append(addrs, listener.Addr().String())
+	}
+	return addrs, nil
+}
+
+// ListenAddrs returns the addresses RPC connections are accepted on.
+func (s *rpcServer) ListenAddrs() []string {
+	s.listenersMtx.Lock()
+	defer s.listenersMtx.Unlock()
+
+	addrs := make([]string, 0, len(s.listeners))
+	for _, listener := range s.listeners {
+		addrs = append(addrs, listener.Addr().String())
+	}
+	return addrs
+}
+
 // genCertPair generates a key/cert pair to the paths provided.
 func genCertPair(certFile, keyFile string) error {
 	rpcsLog.Infof("Generating TLS certificates...")
@@ -6605,6 +8576,58 @@ func genCertPair(certFile, keyFile string) error {
 	return nil
 }
 
+// rpcListenFunc returns the function used to create the listeners of the RPC
+// server, which sets up TLS unless it is disabled.  The TLS certificate and key
+// are generated when both of them don't already exist.
+func rpcListenFunc() (listenFunc, error) {
+	// Setup TLS if not disabled.
+	listen := net.Listen
+	if !cfg.DisableTLS {
+		// Generate the TLS cert and key file if both don't already
+		// exist.
+		if !fileExists(cfg.RPCKey) && !fileExists(cfg.RPCCert) {
+			err := genCertPair(cfg.RPCCert, cfg.RPCKey)
+			if err != nil {
+				return nil, err
+			}
+		}
+		keypair, err := tls.LoadX509KeyPair(cfg.RPCCert, cfg.RPCKey)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig := tls.Config{
+			Certificates: []tls.Certificate{keypair},
+			MinVersion:   tls.VersionTLS12,
+		}
+
+		// Verify the certificates of the clients which present one
+		// against the configured certificate authorities.  Clients
+		// without a certificate may still authenticate with a username
+		// and password.
+		if cfg.RPCClientCA != "" {
+			pem, err := ioutil.ReadFile(cfg.RPCClientCA)
+			if err != nil {
+				return nil, err
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s",
+					cfg.RPCClientCA)
+			}
+			tlsConfig.ClientCAs = pool
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+
+		// Change the standard net.Listen function to the tls one.
+		listen = func(net string, laddr string) (net.Listener, error) {
+			return tls.Listen(net, laddr, &tlsConfig)
+		}
+	}
+
+	return listen, nil
+}
+
 // newRPCServer returns a new instance of the rpcServer struct.
 func newRPCServer(listenAddrs []string, policy *mining.Policy, s *server) (*rpcServer, error) {
 	rpc := rpcServer{
@@ -6618,6 +8641,8 @@ func newRPCServer(listenAddrs []string, policy *mining.Policy, s *server) (*rpcS
 		helpCacher:             newHelpCacher(),
 		requestProcessShutdown: make(chan struct{}),
 		quit: make(chan int),
+		authLimiter:            newRPCAuthLimiter(),
+		sseEvents:              newSSEEventManager(),
 	}
 	if cfg.RPCUser != "" && cfg.RPCPass != "" {
 		login := cfg.RPCUser + ":" + cfg.RPCPass
@@ -6631,33 +8656,24 @@ func newRPCServer(listenAddrs []string, policy *mining.Policy, s *server) (*rpcS
 			base64.StdEncoding.EncodeToString([]byte(login))
 		rpc.limitauthsha = sha256.Sum256([]byte(auth))
 	}
+	rpc.limitedMethods = rpcLimitedMethods(cfg.RPCLimitMethods)
 	rpc.ntfnMgr = newWsNotificationManager(&rpc)
 
-	// Setup TLS if not disabled.
-	listenFunc := net.Listen
-	if !cfg.DisableTLS {
-		// Generate the TLS cert and key file if both don't already
-		// exist.
-		if !fileExists(cfg.RPCKey) && !fileExists(cfg.RPCCert) {
-			err := genCertPair(cfg.RPCCert, cfg.RPCKey)
-			if err != nil {
-				return nil, err
-			}
-		}
-		keypair, err := tls.LoadX509KeyPair(cfg.RPCCert, cfg.RPCKey)
-		if err != nil {
-			return nil, err
-		}
-
-		tlsConfig := tls.Config{
-			Certificates: []tls.Certificate{keypair},
-			MinVersion:   tls.VersionTLS12,
-		}
+	// Load the key used to sign key block attestations, generating it when
+	// it doesn't already exist.
+	attestationKey, generated, err := loadAttestationKey(cfg.AttestationKey)
+	if err != nil {
+		return nil, err
+	}
+	if generated {
+		rpcsLog.Infof("Generated key block attestation key %s",
+			cfg.AttestationKey)
+	}
+	rpc.attestationKey = attestationKey
 
-		// Change the standard net.Listen function to the tls one.
-		listenFunc = func(net string, laddr string) (net.Listener, error) {
-			return tls.Listen(net, laddr, &tlsConfig)
-		}
+	listenFunc, err := rpcListenFunc()
+	if err != nil {
+		return nil, err
 	}
 
 	// TODO(oga) this code is similar to that in server, should be
@@ -6690,6 +8706,7 @@ func newRPCServer(listenAddrs []string, policy *mining.Policy, s *server) (*rpcS
 	}
 
 	rpc.listeners = listeners
+	rpc.listenFunc = listenFunc
 
 	return &rpc, nil
 }
@@ -10,12 +10,14 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"runtime/debug"
 	"testing"
 
 	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/hcashjson"
 	"github.com/HcashOrg/hcashd/rpctest"
 )
 
@@ -95,10 +97,65 @@ func testGetBlockHash(r *rpctest.Harness, t *testing.T) {
 	}
 }
 
+func testGetTemplateDelta(r *rpctest.Harness, t *testing.T) {
+	getTemplateDelta := func(templateID string) (*hcashjson.GetTemplateDeltaResult, error) {
+		param, err := json.Marshal(templateID)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := r.Node.RawRequest("gettemplatedelta",
+			[]json.RawMessage{param})
+		if err != nil {
+			return nil, err
+		}
+		var delta hcashjson.GetTemplateDeltaResult
+		err = json.Unmarshal(raw, &delta)
+		return &delta, err
+	}
+
+	raw, err := r.Node.RawRequest("getblocktemplate", nil)
+	if err != nil {
+		t.Fatalf("Call to `getblocktemplate` failed: %v", err)
+	}
+	var template hcashjson.GetBlockTemplateResult
+	if err := json.Unmarshal(raw, &template); err != nil {
+		t.Fatalf("Unable to decode block template: %v", err)
+	}
+
+	// The delta to the current template must not change any transactions.
+	delta, err := getTemplateDelta(template.LongPollID)
+	if err != nil {
+		t.Fatalf("Call to `gettemplatedelta` failed: %v", err)
+	}
+	if delta.Stale || len(delta.Added) != 0 || len(delta.AddedStake) != 0 ||
+		len(delta.Removed) != 0 {
+		t.Fatalf("Delta to the current template is not empty: %+v", delta)
+	}
+
+	// A new block makes the previous template stale.
+	if _, err := r.Node.Generate(1); err != nil {
+		t.Fatalf("Unable to generate block: %v", err)
+	}
+	delta, err = getTemplateDelta(template.LongPollID)
+	if err != nil {
+		t.Fatalf("Call to `gettemplatedelta` failed: %v", err)
+	}
+	if !delta.Stale || delta.TemplateID == template.LongPollID {
+		t.Fatalf("Delta to the template of the previous block is not "+
+			"stale: %+v", delta)
+	}
+
+	if _, err := getTemplateDelta("deadbeef"); err == nil {
+		t.Fatalf("Call to `gettemplatedelta` accepted an invalid " +
+			"template ID")
+	}
+}
+
 var rpcTestCases = []rpctest.HarnessTestCase{
 	testGetBestBlock,
 	testGetBlockCount,
 	testGetBlockHash,
+	testGetTemplateDelta,
 }
 
 var primaryHarness *rpctest.Harness
@@ -110,7 +167,7 @@ func TestMain(m *testing.M) {
 	// ensure that non-standard transactions aren't accepted into the
 	// mempool or relayed.
 	args := []string{"--rejectnonstd"}
-	primaryHarness, err = rpctest.New(&chaincfg.SimNetParams, nil, args)
+	primaryHarness, err = rpctest.New(nil, &chaincfg.SimNetParams, nil, args)
 	if err != nil {
 		fmt.Println("unable to create primary harness: ", err)
 		os.Exit(1)
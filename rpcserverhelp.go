@@ -40,6 +40,38 @@ var helpDescsEnUS = map[string]string{
 	"node-target":        "Either the IP address and port of the peer to operate on, or a valid peer ID.",
 	"node-connectsubcmd": "'perm' to make the connected peer a permanent one, 'temp' to try a single connect to a peer",
 
+	// AddRevocationScriptCmd help.
+	"addrevocationscript--synopsis":       "Registers a pay-to-script-hash redeem script so the missed and expired tickets whose voting rights are held by it are automatically revoked.\nThe node does not hold any private keys, so the script must be satisfied by the push-only signature script prefix alone.\nRequires the --autorevoke option.",
+	"addrevocationscript-redeemscript":    "The hex-encoded redeem script",
+	"addrevocationscript-sigscriptprefix": "The hex-encoded push-only data placed before the redeem script in the signature script of revocations",
+	"addrevocationscript--result0":        "The pay-to-script-hash address of the redeem script",
+
+	// AuditChainCmd help.
+	"auditchain--synopsis":   "Runs invariant checks over a range of the main chain in the background.\nThe audit checks that the sum of block subsidies matches the recorded total subsidy (subsidy), that the pool size committed to by each key block matches the live ticket count (ticketpool), and that key heights advance monotonically (keyheights).",
+	"auditchain-subcmd":      "'start' to start an audit, 'status' to report the progress of the running or most recent audit, or 'stop' to interrupt the running audit",
+	"auditchain-checks":      "The checks to run when starting an audit (subsidy, ticketpool, keyheights); all checks by default",
+	"auditchain-startheight": "The first height to audit when starting an audit",
+	"auditchain-endheight":   "The last height to audit when starting an audit, or -1 for the best block",
+
+	// AuditChainResult help.
+	"auditchainresult-running":       "Whether or not an audit is currently running",
+	"auditchainresult-checks":        "The checks performed by the audit",
+	"auditchainresult-startheight":   "The first height of the audited range",
+	"auditchainresult-endheight":     "The last height of the audited range",
+	"auditchainresult-currentheight": "The last height that has been audited",
+	"auditchainresult-progress":      "The fraction of the range that has been audited",
+	"auditchainresult-starttime":     "The time the audit was started in seconds since 1 Jan 1970 GMT",
+	"auditchainresult-endtime":       "The time the audit finished in seconds since 1 Jan 1970 GMT",
+	"auditchainresult-totalsubsidy":  "The sum of block subsidies up to the end height when the subsidy check is performed",
+	"auditchainresult-violations":    "The invariants that did not hold",
+	"auditchainresult-error":         "The reason the audit could not be completed, if any",
+
+	// AuditChainViolation help.
+	"auditchainviolation-check":       "The check that failed",
+	"auditchainviolation-height":      "The height of the offending block",
+	"auditchainviolation-hash":        "The hash of the offending block",
+	"auditchainviolation-description": "A description of the violation",
+
 	// TransactionInput help.
 	"transactioninput-txid": "The hash of the input transaction",
 	"transactioninput-vout": "The specific output of the input transaction to redeem",
@@ -151,25 +183,49 @@ var helpDescsEnUS = map[string]string{
 	"decoderawtransaction-hextx":     "Serialized, hex-encoded transaction",
 
 	// DecodeScriptResult help.
-	"decodescriptresult-asm":       "Disassembly of the script",
-	"decodescriptresult-reqSigs":   "The number of required signatures",
-	"decodescriptresult-type":      "The type of the script (e.g. 'pubkeyhash')",
-	"decodescriptresult-addresses": "The hypercash addresses associated with this script",
-	"decodescriptresult-p2sh":      "The script hash for use in pay-to-script-hash transactions",
+	"decodescriptresult-asm":          "Disassembly of the script",
+	"decodescriptresult-asmannotated": "Disassembly of the script with annotations of the public keys and their addresses, hashes, numbers, vote block references, and stake opcodes in square brackets (verbose only)",
+	"decodescriptresult-reqSigs":      "The number of required signatures",
+	"decodescriptresult-type":         "The type of the script (e.g. 'pubkeyhash')",
+	"decodescriptresult-detailedtype": "The type of the script including the type of the script following a stake opcode and the signature scheme of alternative signature scripts (e.g. 'stakegen-pubkeyhash', 'stakesubmission-multisig' or 'pubkeyhashalt-bliss')",
+	"decodescriptresult-addresses":    "The hypercash addresses associated with this script",
+	"decodescriptresult-p2sh":         "The script hash for use in pay-to-script-hash transactions",
 
 	// DecodeScriptCmd help.
 	"decodescript--synopsis": "Returns a JSON object with information about the provided hex-encoded script.",
 	"decodescript-hexscript": "Hex-encoded script",
+	"decodescript-verbose":   "Also return the annotated disassembly of the script",
+
+	// DebugScriptCmd help.
+	"debugscript--synopsis":     "Executes the signature script of a transaction input and the public key script it spends step by step with the flags used for standard transactions and returns the opcodes and stacks of every step, to help diagnosing failing scripts.",
+	"debugscript-hextx":         "Serialized, hex-encoded transaction",
+	"debugscript-inputindex":    "The index of the input to execute the scripts of",
+	"debugscript-pkscript":      "The hex-encoded public key script of the output spent by the input",
+	"debugscript-scriptversion": "The version of the public key script",
+
+	// DebugScriptResult help.
+	"debugscriptresult-valid": "Whether or not the scripts executed successfully",
+	"debugscriptresult-error": "The error the execution failed with (only when valid is false)",
+	"debugscriptresult-steps": "The steps of the execution in order",
+
+	// DebugScriptStep help.
+	"debugscriptstep-script":   "The index of the script of the opcode: 0 for the signature script, 1 for the public key script, and 2 for the redeem script of pay-to-script-hash outputs",
+	"debugscriptstep-offset":   "The offset of the opcode in its script",
+	"debugscriptstep-opcode":   "The disassembly of the opcode",
+	"debugscriptstep-executed": "Whether or not the opcode was executed, which is false for opcodes in conditional branches which are not taken",
+	"debugscriptstep-stack":    "The hex-encoded data stack after the opcode with the top of the stack last",
+	"debugscriptstep-altstack": "The hex-encoded alternate stack after the opcode with the top of the stack last",
+	"debugscriptstep-error":    "The error the opcode failed with, if any",
 
 	// ExistsAddressCmd help.
-	"existsaddress--synopsis": "Test for the existance of the provided address",
+	"existsaddress--synopsis": "Test for the existance of the provided address in the blockchain or memory pool (requires the exists address index)",
 	"existsaddress-address":   "The address to check",
 	"existsaddress--result0":  "Bool showing if address exists or not",
 
 	// ExistsAddressesCmd help.
-	"existsaddresses--synopsis": "Test for the existance of the provided addresses in the blockchain or memory pool",
-	"existsaddresses-addresses": "The addresses to check",
-	"existsaddresses--result0":  "Bitset of bools showing if addresses exist or not",
+	"existsaddresses--synopsis": "Test for the existance of the provided addresses in the blockchain or memory pool (requires the exists address index)",
+	"existsaddresses-addresses": "The addresses to check (maximum 10000)",
+	"existsaddresses--result0":  "Hex-encoded bitset of bools showing if addresses exist or not, with the first address in the least significant bit of the first byte",
 
 	// ExitsMissedTicketsCmd help.
 	"existsmissedtickets--synopsis":  "Test for the existance of the provided tickets in the missed ticket map",
@@ -224,6 +280,23 @@ var helpDescsEnUS = map[string]string{
 	"getbestblockresult-height": "Height of the best block",
 	"getbestblockresult-keyheight": "Key Height of the best block",
 
+	// GetAddressHistoryCmd help.
+	"getaddresshistory--synopsis": "Returns a page of the transactions involving an address that are confirmed in blocks, ordered from the oldest to the newest.\n" +
+		"The rest of the history is retrieved by passing the returned nextcursor until it is omitted.  Requires the address index (--addrindex).",
+	"getaddresshistory-address": "The address to return the history of",
+	"getaddresshistory-limit":   "The maximum number of transactions to return, at most 1000",
+	"getaddresshistory-cursor":  "The nextcursor of the previous page, or omitted for the first page",
+
+	// GetAddressHistoryResult help.
+	"getaddresshistoryresult-items":      "The transactions of the page",
+	"getaddresshistoryresult-nextcursor": "The cursor to request the next page with, omitted on the last page",
+	"getaddresshistoryresult-total":      "The total number of transactions involving the address",
+
+	// AddressHistoryResult help.
+	"addresshistoryresult-txid":        "The hash of the transaction",
+	"addresshistoryresult-blockhash":   "The hash of the block containing the transaction",
+	"addresshistoryresult-blockheight": "The height of the block containing the transaction",
+
 	// GetBestBlockCmd help.
 	"getbestblock--synopsis": "Get block height and hash of best block in the main chain.",
 	"getbestblock--result0":  "Get block height and hash of best block in the main chain.",
@@ -308,6 +381,23 @@ var helpDescsEnUS = map[string]string{
 	"getblockverboseresult-stakeversion":      "Stake Version of the block",
 	"getblockverboseresult-reward":      	   "Reward of the block",
 
+	// GetBlockChainInfoCmd help.
+	"getblockchaininfo--synopsis":                  "Returns information about the current state of the block chain.",
+	"getblockchaininforesult-chain":                "The name of the network the node is running on",
+	"getblockchaininforesult-blocks":               "The height of the best block in the main chain",
+	"getblockchaininforesult-headers":              "The height of the best known header",
+	"getblockchaininforesult-bestblockhash":        "The hash of the best block in the main chain",
+	"getblockchaininforesult-difficulty":           "The proof-of-work difficulty as a multiple of the minimum difficulty",
+	"getblockchaininforesult-verificationprogress": "An estimate of the fraction of the chain that has been verified",
+	"getblockchaininforesult-chainwork":            "The total amount of work in the main chain as a hex-encoded 256-bit number",
+	"getblockchaininforesult-deployments":          "The consensus deployments of all agendas by agenda ID",
+	"getblockchaininforesult-deployments--key":     "agenda",
+	"getblockchaininforesult-deployments--value":   `{"stakeversion": n, "status": "status", "starttime": n, "expiretime": n}`,
+	"getblockchaininforesult-deployments--desc":    "The agenda ID as the key and the stake version that defines the agenda, the threshold state of the agenda for the next block (defined, started, lockedin, active, or failed), and the median block times voting starts and expires as the value",
+	"getblockchaininforesult-sighashoptimization":  "Whether the experimental reuse of cached transaction hashes when verifying signatures of blocks is enabled (--sighashoptimization)",
+	"getblockchaininforesult-checkduplicatehashes": "Whether blocks are checked for transactions which overwrite unspent transactions with the same hash (--checkduplicatehashes)",
+	"getblockchaininforesult-warnings":             "Any network or upgrade warnings, such as a stake majority voting with a newer vote version",
+
 	// GetBlockCountCmd help.
 	"getblockcount--synopsis": "Returns the number of blocks in the longest block chain.",
 	"getblockcount--result0":  "The current block count",
@@ -335,6 +425,28 @@ var helpDescsEnUS = map[string]string{
 	"getblockheader--condition1": "verbose=true",
 	"getblockheader--result0":    "The block header hash",
 
+	// GetBlockIndexCmd help.
+	"getblockindex--synopsis": "Returns a page of the memory block index, including side chain blocks, without the block bodies.\n" +
+		"The entries are ordered by height and all blocks at the height of the last entry are always included, so the whole index can be retrieved by requesting the next page starting at nextheight until no entries are returned.",
+	"getblockindex-startheight": "The minimum height of the returned blocks",
+	"getblockindex-count":       "The maximum number of blocks to return, which may be exceeded to include all blocks at the height of the last block",
+
+	// GetBlockIndexResult help.
+	"getblockindexresult-entries":    "The blocks in the block index",
+	"getblockindexresult-nextheight": "The start height to request the next page with",
+
+	// BlockIndexEntryResult help.
+	"blockindexentryresult-hash":                 "The hash of the block",
+	"blockindexentryresult-height":               "The height of the block",
+	"blockindexentryresult-keyheight":            "The key height of the block",
+	"blockindexentryresult-previousblockhash":    "The hash of the parent block",
+	"blockindexentryresult-previouskeyblockhash": "The hash of the previous key block",
+	"blockindexentryresult-mainchain":            "Whether the block is part of the main chain",
+	"blockindexentryresult-keyblock":             "Whether the block is a key block",
+	"blockindexentryresult-stakenode":            "Whether the stake node of the block is loaded in memory",
+	"blockindexentryresult-voters":               "The number of votes included in the block",
+	"blockindexentryresult-votes":                "The version and vote bits of the votes included in the block",
+
 	// GetBlockHeaderVerboseResult help.
 	"getblockheaderverboseresult-hash":              "The hash of the block (same as provided)",
 	"getblockheaderverboseresult-confirmations":     "The number of confirmations",
@@ -437,8 +549,15 @@ var helpDescsEnUS = map[string]string{
 	"getcurrentnet--result0":  "The network identifer",
 
 	// GetDifficultyCmd help.
-	"getdifficulty--synopsis": "Returns the proof-of-work difficulty as a multiple of the minimum difficulty.",
-	"getdifficulty--result0":  "The difficulty",
+	"getdifficulty--synopsis": "Returns the proof-of-work and proof-of-stake difficulties of the best block.",
+
+	// GetDifficultyResult help.
+	"getdifficultyresult-difficulty":           "The proof-of-work difficulty as a multiple of the minimum difficulty",
+	"getdifficultyresult-microdifficulty":      "The proof-of-work difficulty of micro blocks as a multiple of the minimum difficulty",
+	"getdifficultyresult-difficultyrate":       "The factor by which the proof-of-work target of micro blocks exceeds the one of key blocks",
+	"getdifficultyresult-stakedifficulty":      "The stake difficulty of the best block",
+	"getdifficultyresult-minstakedifficulty":   "The minimum stake difficulty of the network",
+	"getdifficultyresult-stakedifficultyratio": "The stake difficulty as a multiple of the minimum stake difficulty",
 
 	// GetStakeDifficultyCmd help.
 	"getstakedifficulty--synopsis":     "Returns the proof-of-stake difficulty.",
@@ -471,6 +590,21 @@ var helpDescsEnUS = map[string]string{
 	"versionbits-version":                  "The version of the vote.",
 	"versionbits-bits":                     "The bits assigned by the vote.",
 
+	// GetTemplateDeltaCmd help.
+	"gettemplatedelta--synopsis": "Returns the changes to the current block template relative to a previously returned template.\n" +
+		"This allows pool software to update the jobs it serves without downloading the full template on every refresh.",
+	"gettemplatedelta-templateid":           "The template ID (longpollid) of the template previously obtained by the client",
+	"gettemplatedeltaresult-templateid":     "The template ID of the current block template",
+	"gettemplatedeltaresult-prevtemplateid": "The template ID the delta was computed against",
+	"gettemplatedeltaresult-stale":          "Whether the previous template is unknown or was built on a different parent, in which case all transactions are reported as added",
+	"gettemplatedeltaresult-header":         "Hex-encoded block header of the current template",
+	"gettemplatedeltaresult-target":         "Hex-encoded target difficulty of the current template",
+	"gettemplatedeltaresult-mintime":        "Minimum allowed timestamp for the block as a Unix timestamp",
+	"gettemplatedeltaresult-maxtime":        "Maximum allowed timestamp for the block as a Unix timestamp",
+	"gettemplatedeltaresult-added":          "Regular tree transactions that were not part of the previous template",
+	"gettemplatedeltaresult-addedstake":     "Stake tree transactions that were not part of the previous template",
+	"gettemplatedeltaresult-removed":        "Hashes of transactions from the previous template that are no longer included",
+
 	// GetVoteInfo
 	"getvoteinfo--synopsis":           "Returns the vote info statistics.",
 	"getvoteinfo-version":             "The stake version.",
@@ -517,8 +651,8 @@ var helpDescsEnUS = map[string]string{
 	"infochainresult-difficulty":      "The current target difficulty",
 	"infochainresult-testnet":         "Whether or not server is using testnet",
 	"infochainresult-relayfee":        "The minimum relay fee for non-free transactions in HCASH/KB",
-	"infochainresult-errors":          "Any current errors",
-	"infochainresult-hashcount":      "Hashes needed to perform to get a new block according to current difficulty",
+	"infochainresult-errors":          "Any current errors and warnings, such as the time taken by each startup phase when starting up was slow",
+	"infochainresult-hashcount":       "Hashes needed to perform to get a new block according to current difficulty",
 
 	// InfoWalletResult help.
 	"infowalletresult-version":         "The version of the server",
@@ -551,8 +685,12 @@ var helpDescsEnUS = map[string]string{
 	"getmempoolinfo--synopsis": "Returns memory pool information",
 
 	// GetMempoolInfoResult help.
-	"getmempoolinforesult-bytes": "Size in bytes of the mempool",
-	"getmempoolinforesult-size":  "Number of transactions in the mempool",
+	"getmempoolinforesult-bytes":             "Size in bytes of the mempool",
+	"getmempoolinforesult-size":              "Number of transactions in the mempool",
+	"getmempoolinforesult-maxmempool":        "Maximum size in bytes of the mempool (0 when unlimited)",
+	"getmempoolinforesult-mempoolminfee":     "Minimum fee rate in HCASH/kB for transactions to be accepted into the mempool, which rises above the minimum relay fee while the mempool is full",
+	"getmempoolinforesult-sizeevicted":       "Number of transactions evicted from the mempool since startup because it exceeded its maximum size",
+	"getmempoolinforesult-stalevotesevicted": "Number of votes evicted from the mempool since startup because they voted on stale key blocks",
 
 	// GetMiningInfoResult help.
 	"getmininginforesult-blocks":           "Height of the latest best block",
@@ -567,6 +705,25 @@ var helpDescsEnUS = map[string]string{
 	"getmininginforesult-networkhashps":    "Estimated network hashes per second for the most recent blocks",
 	"getmininginforesult-pooledtx":         "Number of transactions in the memory pool",
 	"getmininginforesult-testnet":          "Whether or not server is using testnet",
+	"getmininginforesult-cpuminer":         "Statistics of the built-in CPU miner",
+
+	// CPUMinerStatsResult help.
+	"cpuminerstatsresult-activeworkers": "Number of CPU mining workers currently running",
+	"cpuminerstatsresult-cpubudget":     "Percentage of the total CPU capacity the number of workers is tuned against (0 when tuning is disabled)",
+	"cpuminerstatsresult-cpuusage":      "Most recently measured CPU usage of the process as a percentage of the total CPU capacity (0 when tuning is disabled)",
+	"cpuminerstatsresult-microblocks":   "Distribution of the time taken to solve micro blocks",
+	"cpuminerstatsresult-keyblocks":     "Distribution of the time taken to solve key blocks",
+
+	// MiningLatencyResult help.
+	"mininglatencyresult-count":   "Number of solved blocks",
+	"mininglatencyresult-mean":    "Mean time to solve a block in seconds",
+	"mininglatencyresult-min":     "Minimum time to solve a block in seconds",
+	"mininglatencyresult-max":     "Maximum time to solve a block in seconds",
+	"mininglatencyresult-buckets": "Histogram buckets of the time to solve a block",
+
+	// MiningLatencyBucket help.
+	"mininglatencybucket-upperbound": "Upper bound of the bucket in seconds (-1 for the final unbounded bucket)",
+	"mininglatencybucket-count":      "Number of solved blocks in the bucket",
 
 	// GetMiningInfoCmd help.
 	"getmininginfo--synopsis": "Returns a JSON object containing mining-related information.",
@@ -586,26 +743,37 @@ var helpDescsEnUS = map[string]string{
 	"getnettotalsresult-timemillis":     "Number of milliseconds since 1 Jan 1970 GMT",
 
 	// GetPeerInfoResult help.
-	"getpeerinforesult-id":             "A unique node ID",
-	"getpeerinforesult-addr":           "The ip address and port of the peer",
-	"getpeerinforesult-addrlocal":      "Local address",
-	"getpeerinforesult-services":       "Services bitmask which represents the services supported by the peer",
-	"getpeerinforesult-lastsend":       "Time the last message was received in seconds since 1 Jan 1970 GMT",
-	"getpeerinforesult-lastrecv":       "Time the last message was sent in seconds since 1 Jan 1970 GMT",
-	"getpeerinforesult-bytessent":      "Total bytes sent",
-	"getpeerinforesult-bytesrecv":      "Total bytes received",
-	"getpeerinforesult-conntime":       "Time the connection was made in seconds since 1 Jan 1970 GMT",
-	"getpeerinforesult-timeoffset":     "The time offset of the peer",
-	"getpeerinforesult-pingtime":       "Number of microseconds the last ping took",
-	"getpeerinforesult-pingwait":       "Number of microseconds a queued ping has been waiting for a response",
-	"getpeerinforesult-version":        "The protocol version of the peer",
-	"getpeerinforesult-subver":         "The user agent of the peer",
-	"getpeerinforesult-inbound":        "Whether or not the peer is an inbound connection",
-	"getpeerinforesult-startingheight": "The latest block height the peer knew about when the connection was established",
-	"getpeerinforesult-currentheight":  "The current height of the peer",
-	"getpeerinforesult-currentrealkeyheight": "The current number of key blocks of the peer",
-	"getpeerinforesult-banscore":       "The ban score",
-	"getpeerinforesult-syncnode":       "Whether or not the peer is the sync peer",
+	"getpeerinforesult-id":                       "A unique node ID",
+	"getpeerinforesult-addr":                     "The ip address and port of the peer",
+	"getpeerinforesult-addrlocal":                "Local address",
+	"getpeerinforesult-services":                 "Services bitmask which represents the services supported by the peer",
+	"getpeerinforesult-lastsend":                 "Time the last message was received in seconds since 1 Jan 1970 GMT",
+	"getpeerinforesult-lastrecv":                 "Time the last message was sent in seconds since 1 Jan 1970 GMT",
+	"getpeerinforesult-bytessent":                "Total bytes sent",
+	"getpeerinforesult-bytesrecv":                "Total bytes received",
+	"getpeerinforesult-conntime":                 "Time the connection was made in seconds since 1 Jan 1970 GMT",
+	"getpeerinforesult-timeoffset":               "The time offset of the peer",
+	"getpeerinforesult-pingtime":                 "Number of microseconds the last ping took",
+	"getpeerinforesult-pingwait":                 "Number of microseconds a queued ping has been waiting for a response",
+	"getpeerinforesult-version":                  "The protocol version of the peer",
+	"getpeerinforesult-subver":                   "The user agent of the peer",
+	"getpeerinforesult-inbound":                  "Whether or not the peer is an inbound connection",
+	"getpeerinforesult-startingheight":           "The latest block height the peer knew about when the connection was established",
+	"getpeerinforesult-startingkeyheight":        "The latest key block height the peer knew about when the connection was established",
+	"getpeerinforesult-currentheight":            "The current height of the peer",
+	"getpeerinforesult-currentrealkeyheight":     "The current number of key blocks of the peer",
+	"getpeerinforesult-banscore":                 "The ban score",
+	"getpeerinforesult-syncnode":                 "Whether or not the peer is the sync peer",
+	"getpeerinforesult-limitedtxns":              "The number of transactions from the peer rejected for exceeding its transaction relay limit",
+	"getpeerinforesult-limitedlowfeetxns":        "The number of transactions from the peer rejected for exceeding its free and low-fee transaction relay limit",
+	"getpeerinforesult-bytessent_per_msg":        "The total bytes sent by message type",
+	"getpeerinforesult-bytessent_per_msg--key":   "command",
+	"getpeerinforesult-bytessent_per_msg--value": "n",
+	"getpeerinforesult-bytessent_per_msg--desc":  "The message command as the key and the total bytes sent for it as the value",
+	"getpeerinforesult-bytesrecv_per_msg":        "The total bytes received by message type",
+	"getpeerinforesult-bytesrecv_per_msg--key":   "command",
+	"getpeerinforesult-bytesrecv_per_msg--value": "n",
+	"getpeerinforesult-bytesrecv_per_msg--desc":  "The message command as the key and the total bytes received for it as the value",
 
 	// GetPeerInfoCmd help.
 	"getpeerinfo--synopsis": "Returns data about each connected network peer as an array of json objects.",
@@ -618,6 +786,10 @@ var helpDescsEnUS = map[string]string{
 	"getrawmempoolverboseresult-startingpriority": "Priority when transaction entered the pool",
 	"getrawmempoolverboseresult-currentpriority":  "Current priority",
 	"getrawmempoolverboseresult-depends":          "Unconfirmed transactions used as inputs for this transaction",
+	"getrawmempoolverboseresult-type":             "The type of the transaction (regular, ticket, vote, or revocation)",
+	"getrawmempoolverboseresult-voteblockhash":    "The hash of the block a vote votes on (votes only)",
+	"getrawmempoolverboseresult-voteblockheight":  "The height of the block a vote votes on (votes only)",
+	"getrawmempoolverboseresult-ticketprice":      "The price of the purchased ticket or of the ticket spent by a vote or revocation in hypercash (stake transactions only)",
 
 	// GetRawMempoolCmd help.
 	"getrawmempool--synopsis":   "Returns information about all of the transactions currently in the memory pool.",
@@ -639,6 +811,22 @@ var helpDescsEnUS = map[string]string{
 	"getticketpoolvalue--synopsis": "Return the current value of all locked funds in the ticket pool",
 	"getticketpoolvalue--result0":  "Total value of ticket pool",
 
+	// GetTxAcceptanceScoreCmd help.
+	"gettxacceptancescore--synopsis": "Returns an assessment of the double spend risk of an unconfirmed transaction in the memory pool to help deciding whether to accept it before it is confirmed.\n" +
+		"The score considers the fee rate compared to the memory pool when it exceeds the size of a block, the confirmations of the inputs, the conflicting spends of the inputs seen by the node, and replaceability signaling.",
+	"gettxacceptancescore-txid": "The hash of the transaction",
+
+	// GetTxAcceptanceScoreResult help.
+	"gettxacceptancescoreresult-txid":                  "The hash of the transaction",
+	"gettxacceptancescoreresult-score":                 "The score from 0, for the transactions most likely to be double spent, to 100",
+	"gettxacceptancescoreresult-feerate":               "The fee rate of the transaction in HCASH/kB",
+	"gettxacceptancescoreresult-feeratepercentile":     "The percentage of the other transactions of the same type in the memory pool which pay the same or a lower fee rate",
+	"gettxacceptancescoreresult-poolbacklog":           "The size of the transactions of the same type in the memory pool as a number of maximum size blocks",
+	"gettxacceptancescoreresult-unconfirmedinputs":     "The number of inputs which spend unconfirmed transactions",
+	"gettxacceptancescoreresult-mininputconfirmations": "The lowest number of confirmations of the inputs which spend confirmed outputs",
+	"gettxacceptancescoreresult-conflictingspends":     "The number of distinct conflicting spends of the inputs of the transaction or its unconfirmed ancestors seen by the node",
+	"gettxacceptancescoreresult-signalsreplacement":    "Whether any input signals replaceability as defined by BIP0125",
+
 	// GetTxOutResult help.
 	"gettxoutresult-bestblock":     "The block hash that contains the transaction output",
 	"gettxoutresult-confirmations": "The number of confirmations",
@@ -653,6 +841,18 @@ var helpDescsEnUS = map[string]string{
 	"gettxout-vout":           "The index of the output",
 	"gettxout-includemempool": "Include the mempool when true",
 
+	// GetTxOutSetInfoCmd help.
+	"gettxoutsetinfo--synopsis": "Returns statistics about the unspent transaction output set of the current best block.",
+
+	// GetTxOutSetInfoResult help.
+	"gettxoutsetinforesult-height":         "The height of the current best block",
+	"gettxoutsetinforesult-bestblock":      "The hash of the current best block",
+	"gettxoutsetinforesult-transactions":   "The number of transactions with unspent outputs",
+	"gettxoutsetinforesult-txouts":         "The number of unspent transaction outputs",
+	"gettxoutsetinforesult-serializedsize": "The size of the serialized unspent transaction output set in bytes",
+	"gettxoutsetinforesult-sethash":        "The hash of the unspent transaction output set, independent of the order the outputs were created in",
+	"gettxoutsetinforesult-totalamount":    "The total amount of all unspent transaction outputs in HCASH",
+
 	// GetWorkResult help.
 	"getworkresult-data":     "Hex-encoded block data",
 	"getworkresult-hash1":    "(DEPRECATED) Hex-encoded formatted hash buffer",
@@ -674,6 +874,14 @@ var helpDescsEnUS = map[string]string{
 	"help--result0":    "List of commands",
 	"help--result1":    "Help for specified command",
 
+	// ListBannedCmd help.
+	"listbanned--synopsis": "Returns the hosts that are currently banned from connecting to the server.",
+	"listbanned--result0":  "List of banned hosts",
+
+	// ListBannedResult help.
+	"listbannedresult-address":     "The banned host",
+	"listbannedresult-banneduntil": "The time the ban expires in seconds since 1 Jan 1970 GMT",
+
 	// PingCmd help.
 	"ping--synopsis": "Queues a ping to be sent to each connected peer.\n" +
 		"Ping times are provided by getpeerinfo via the pingtime and pingwait fields.",
@@ -684,6 +892,20 @@ var helpDescsEnUS = map[string]string{
 	// RebroadcastWinnerCmd help.
 	"rebroadcastwinners--synopsis": "Asks the daemon to rebroadcast the winners of the voting lottery.\n",
 
+	// ReloadConfigCmd help.
+	"reloadconfig--synopsis": "Loads the configuration file and command line options again and applies the changes to the options which can be changed at runtime.\n" +
+		"These are the logging levels, the banning of misbehaving peers, the mempool relay policy and the connection limits.\n" +
+		"Nothing is applied when the configuration is invalid.  Sending hcashd a SIGHUP reloads the configuration as well.",
+
+	// ReloadConfigResult help.
+	"reloadconfigresult-applied": "The changed options which took effect",
+	"reloadconfigresult-ignored": "The changed options which only take effect once hcashd is restarted",
+
+	// RestartCmd help.
+	"restart--synopsis": "Shutdown hcashd and start it again with the same command line options.\n" +
+		"Not supported when running as a Windows service.",
+	"restart--result0": "The string 'hcashd restarting.'",
+
 	// SearchRawTransactionsCmd help.
 	"searchrawtransactions--synopsis": "Returns raw data for transactions involving the passed address.\n" +
 		"Returned transactions are pulled from both the database, and transactions currently in the mempool.\n" +
@@ -707,11 +929,26 @@ var helpDescsEnUS = map[string]string{
 	"sendrawtransaction-allowhighfees": "Whether or not to allow insanely high fees (hcashd does not yet implement this parameter, so it has no effect)",
 	"sendrawtransaction--result0":      "The hash of the transaction",
 
+	// SetBanCmd help.
+	"setban--synopsis": "Bans a host from connecting to the server or removes an existing ban.\n" +
+		"Connected peers from a newly banned host are disconnected.",
+	"setban-addr":     "IP address of the host",
+	"setban-subcmd":   "'add' to ban the host or 'remove' to remove the ban",
+	"setban-bantime":  "Duration of the ban in seconds, or the time the ban expires when absolute is set (0 uses the --banduration setting)",
+	"setban-absolute": "Whether bantime is an absolute time in seconds since 1 Jan 1970 GMT",
+
 	// SetGenerateCmd help.
 	"setgenerate--synopsis":    "Set the server to generate coins (mine) or not.",
 	"setgenerate-generate":     "Use true to enable generation, false to disable it",
 	"setgenerate-genproclimit": "The number of processors (cores) to limit generation to or -1 for default",
 
+	// SetMiningAddressesCmd help.
+	"setminingaddresses--synopsis":      "Splits the coinbase work reward of blocks generated afterwards across the passed addresses.\nEach address receives the part of the subsidy and fees proportional to its weight relative to the total weight of all addresses.\nPassing an empty object pays the entire reward to one of the addresses specified via --miningaddr again.",
+	"setminingaddresses-payouts":        "JSON object with the payout addresses as keys and their weights as values",
+	"setminingaddresses-payouts--key":   "address",
+	"setminingaddresses-payouts--value": "n",
+	"setminingaddresses-payouts--desc":  "The payout address as the key and its non-zero weight as the value",
+
 	// StopCmd help.
 	"stop--synopsis": "Shutdown hcashd.",
 	"stop--result0":  "The string 'hcashd stopping.'",
@@ -728,8 +965,10 @@ var helpDescsEnUS = map[string]string{
 	"submitblock--result1":    "The reason the block was rejected",
 
 	// ValidateAddressResult help.
-	"validateaddresschainresult-isvalid": "Whether or not the address is valid",
-	"validateaddresschainresult-address": "The hypercash address (only when isvalid is true)",
+	"validateaddresschainresult-isvalid":      "Whether or not the address is valid",
+	"validateaddresschainresult-address":      "The hypercash address (only when isvalid is true)",
+	"validateaddresschainresult-scriptPubKey": "The hex-encoded public key script paying to the address (only when isvalid is true)",
+	"validateaddresschainresult-type":         "The detailed type of the public key script paying to the address, such as 'pubkeyhashalt-lms' (only when isvalid is true)",
 
 	// ValidateAddressCmd help.
 	"validateaddress--synopsis": "Verify an address is valid.",
@@ -740,9 +979,14 @@ var helpDescsEnUS = map[string]string{
 		"The actual checks performed by the checklevel parameter are implementation specific.\n" +
 		"For hcashd this is:\n" +
 		"checklevel=0 - Look up each block and ensure it can be loaded from the database.\n" +
-		"checklevel=1 - Perform basic context-free sanity checks on each block.",
+		"checklevel=1 - Perform the context-free sanity checks on the header of each block.\n" +
+		"checklevel=2 - Perform the context-free sanity checks on each whole block.\n" +
+		"checklevel=3 - Ensure the undo data of each block is consistent with the outputs it spends.\n" +
+		"checklevel=4 - Execute the input scripts of the transactions of each block again.\n" +
+		"Higher levels are treated as level 4 and a checkdepth of 0 verifies the whole chain.\n" +
+		"Over websockets, a verifychainprogress notification with the id of the request is sent after each block is verified.",
 	"verifychain-checklevel": "How thorough the block verification is",
-	"verifychain-checkdepth": "The number of blocks to check",
+	"verifychain-checkdepth": "The number of blocks to check (0 for all)",
 	"verifychain--result0":   "Whether or not the chain verified",
 
 	// VerifyMessageCmd help.
@@ -754,13 +998,18 @@ var helpDescsEnUS = map[string]string{
 
 	// VerifyBlissMessageCmd help.
 	"verifyblissmessage--synopsis": "Verify a signed message.",
-	"verifyblissmessage-pubKey":    "The hypercash bliss public key to use for the signature",
+	"verifyblissmessage-pubkey":    "The hypercash bliss public key to use for the signature",
 	"verifyblissmessage-signature": "The base-64 encoded signature provided by the signer",
 	"verifyblissmessage-message":   "The signed message",
 	"verifyblissmessage--result0":  "Whether or not the signature verified",
 
 	// -------- Websocket-specific help --------
 
+	// AuthenticateCmd help.
+	"authenticate--synopsis":  "Authenticate the websocket connection with the RPC server.  It must be the first command of connections which did not authenticate via HTTP headers.",
+	"authenticate-username":   "The RPC username",
+	"authenticate-passphrase": "The RPC password",
+
 	// Session help.
 	"session--synopsis":       "Return details regarding a websocket client's current connection session.",
 	"sessionresult-sessionid": "The unique session ID for a client's websocket connection.",
@@ -772,7 +1021,7 @@ var helpDescsEnUS = map[string]string{
 	"notifynewtickets--synopsis": "Request notifications for whenever new tickets are found.",
 
 	// NotifyStakeDifficultyCmd help
-	"notifystakedifficulty--synopsis": "Request notifications for whenever stake difficulty goes up.",
+	"notifystakedifficulty--synopsis": "Request notifications for whenever the stake difficulty changes at the end of a stake difficulty window.",
 
 	// NotifyWinningTicketsCmd help
 	"notifywinningtickets--synopsis": "Request notifications for whenever any tickets is chosen to vote.",
@@ -790,6 +1039,23 @@ var helpDescsEnUS = map[string]string{
 	// StopNotifyNewTransactionsCmd help.
 	"stopnotifynewtransactions--synopsis": "Stop sending either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool.",
 
+	// NotifyReceivedCmd help.
+	"notifyreceived--synopsis": "Send a recvtx notification when a transaction added to mempool or appearing in a newly-attached block contains a txout pkScript sending to any of the passed addresses.\n" +
+		"Matching outpoints are automatically registered for redeemingtx notifications.",
+	"notifyreceived-addresses": "List of addresses to register for receive notifications",
+
+	// StopNotifyReceivedCmd help.
+	"stopnotifyreceived--synopsis": "Cancel registered receive notifications for each passed address.",
+	"stopnotifyreceived-addresses": "List of addresses to cancel receive notifications for",
+
+	// NotifySpentCmd help.
+	"notifyspent--synopsis": "Send a redeemingtx notification when a transaction spending an outpoint of either transaction tree appears in mempool (if relayed to this hcashd instance) and when such a transaction first appears in a newly-attached block.",
+	"notifyspent-outpoints": "List of outpoints to register for spend notifications",
+
+	// StopNotifySpentCmd help.
+	"stopnotifyspent--synopsis": "Cancel registered spending notifications for each passed outpoint.",
+	"stopnotifyspent-outpoints": "List of outpoints to cancel spend notifications for",
+
 	// OutPoint help.
 	"outpoint-hash":  "The hex-encoded bytes of the outpoint hash",
 	"outpoint-index": "The index of the outpoint",
@@ -805,6 +1071,30 @@ var helpDescsEnUS = map[string]string{
 	"rescan--synopsis":   "Rescan blocks for transactions matching the loaded transaction filter.",
 	"rescan-blockhashes": "Concatenated block hashes to rescan.  Each next block must be a child of the previous.",
 
+	// GetBlockStreamCmd help.
+	"getblockstream--synopsis": "Returns information about a block given its hash like getblock with verbosetx set, but the verbose transactions are sent as the items of streamdata notifications, regular transactions first, before the reply instead of being included in it.",
+	"getblockstream-hash":      "The hash of the block",
+	"getblockstream-chunksize": "The maximum number of transactions per streamdata notification (1 to 1000)",
+
+	// GetBlockStreamResult help.
+	"getblockstreamresult-block":  "The block information like getblock with only the hashes of the transactions",
+	"getblockstreamresult-stream": "The summary of the stream of transactions",
+
+	// StreamResult help.
+	"streamresult-chunks": "The number of streamdata notifications sent",
+	"streamresult-items":  "The total number of items in the streamdata notifications",
+
+	// SearchRawTransactionsStreamCmd help.
+	"searchrawtransactionsstream--synopsis":   "Returns the summary of a stream of the transactions searchrawtransactions returns, which are sent as the items of streamdata notifications before the reply instead of being included in it.",
+	"searchrawtransactionsstream-address":     "The Hypercash address to search for",
+	"searchrawtransactionsstream-verbose":     "Specifies the transactions are streamed as JSON objects instead of hex-encoded strings",
+	"searchrawtransactionsstream-skip":        "The number of leading transactions to leave out of the results",
+	"searchrawtransactionsstream-count":       "The maximum number of transactions to return",
+	"searchrawtransactionsstream-vinextra":    "Specify that extra data from previous output will be returned in vin",
+	"searchrawtransactionsstream-reverse":     "Specifies that the transactions should be returned in reverse chronological order",
+	"searchrawtransactionsstream-filteraddrs": "Address list.  Only inputs or outputs with matching address will be returned",
+	"searchrawtransactionsstream-chunksize":   "The maximum number of transactions per streamdata notification (1 to 1000)",
+
 	// -------- Hypercash-specific help --------
 
 	// EstimateFee help.
@@ -812,6 +1102,17 @@ var helpDescsEnUS = map[string]string{
 	"estimatefee-numblocks": "(unused)",
 	"estimatefee--result0":  "Estimated fee.",
 
+	// EstimateFinality help.
+	"estimatefinality--synopsis":              "Estimates the number of key blocks and the time a transaction has to be buried under for the probability of an attacker reorganizing it out to drop below a target, based on the vote participation and key block interval of the recent key blocks.",
+	"estimatefinality-targetprobability":      "The reorg probability to reach",
+	"estimatefinality-hashfraction":           "The fraction of the hash rate controlled by the attacker",
+	"estimatefinality-stakefraction":          "The fraction of the live ticket pool controlled by the attacker",
+	"estimatefinalityresult-keyblocks":        "The number of key blocks, including the one the transaction is mined in, required to reach the target probability",
+	"estimatefinalityresult-seconds":          "The expected time in seconds to mine the required key blocks",
+	"estimatefinalityresult-reorgprobability": "The probability of the attacker reorganizing the transaction out after the required key blocks",
+	"estimatefinalityresult-participation":    "The fraction of the tickets selected to vote which voted on the recent key blocks",
+	"estimatefinalityresult-attackershare":    "The expected fraction of the key blocks found by the attacker, accounting for the votes each key block requires",
+
 	// EstimateStakeDiff help.
 	"estimatestakediff--synopsis":      "Estimate the next minimum, maximum, expected, and user-specified stake difficulty",
 	"estimatestakediff-tickets":        "Use this number of new tickets in blocks to estimate the next difficulty",
@@ -820,10 +1121,233 @@ var helpDescsEnUS = map[string]string{
 	"estimatestakediffresult-expected": "Expected estimate for stake difficulty",
 	"estimatestakediffresult-user":     "Estimate for stake difficulty with the passed user amount of tickets",
 
+	// GetCFilter help.
+	"getcfilter--synopsis":  "Returns the committed filter of a block, which requires the committed filter index to be enabled (--cfindex).",
+	"getcfilter-hash":       "The hash of the block",
+	"getcfilter-filtertype": "The type of the filter to return (regular or extended)",
+	"getcfilter--result0":   "The hex-encoded filter serialized with its 32-bit big-endian member count prefix",
+
+	// GetCFHeaders help.
+	"getcfheaders--synopsis":  "Returns the committed filter header of a block, which commits to the filter of the block and the filter headers of all of its ancestors. Requires the committed filter index to be enabled (--cfindex).",
+	"getcfheaders-hash":       "The hash of the block",
+	"getcfheaders-filtertype": "The type of the filter header to return (regular or extended)",
+	"getcfheaders--result0":   "The hash of the filter header",
+
+	// GetChainTipsCmd help.
+	"getchaintips--synopsis": "Returns information about the tip of the main chain and the tips of all side chains known to the block index.",
+
+	// GetChainTipsResult help.
+	"getchaintipsresult-height":    "The height of the chain tip",
+	"getchaintipsresult-hash":      "The hash of the chain tip",
+	"getchaintipsresult-branchlen": "The number of blocks between the chain tip and the main chain, zero for the main chain tip",
+	"getchaintipsresult-status":    "The status of the chain tip (active, valid-fork, invalid, or headers-only)",
+
 	// GetCoinSupply help
 	"getcoinsupply--synopsis": "Returns current total coin supply in atoms",
 	"getcoinsupply--result0":  "Current coin supply in atoms",
 
+	// GetCPUMinerInfoCmd help.
+	"getcpuminerinfo--synopsis": "Returns the state of the CPU miner, the hashing speed of every running worker and the number of blocks it solved that were accepted or rejected.",
+
+	// GetCPUMinerInfoResult help.
+	"getcpuminerinforesult-generating":     "Whether or not the CPU miner is running",
+	"getcpuminerinforesult-numworkers":     "The configured number of workers, which is the maximum when the workers are tuned against a CPU budget",
+	"getcpuminerinforesult-hashespersec":   "The recent total hashes per second of all workers",
+	"getcpuminerinforesult-accepted":       "The number of solved blocks that were accepted since the server started",
+	"getcpuminerinforesult-rejected":       "The number of solved blocks that were rejected since the server started",
+	"getcpuminerinforesult-miningaddrs":    "The addresses the miner rotates through to pay generated blocks to",
+	"getcpuminerinforesult-nextminingaddr": "The address the next block template will pay to",
+	"getcpuminerinforesult-workers":        "The running workers ordered by id",
+
+	// CPUMinerWorkerResult help.
+	"cpuminerworkerresult-id":           "The id of the worker",
+	"cpuminerworkerresult-uptime":       "The number of seconds since the worker started",
+	"cpuminerworkerresult-hashes":       "The number of hashes the worker performed",
+	"cpuminerworkerresult-hashespersec": "The recent hashes per second of the worker",
+	"cpuminerworkerresult-accepted":     "The number of blocks solved by the worker that were accepted",
+	"cpuminerworkerresult-rejected":     "The number of blocks solved by the worker that were rejected",
+
+	// GetKeyBlockAttestationCmd help.
+	"getkeyblockattestation--synopsis": "Returns the main chain key block at a key height along with the cumulative proof of work of the main chain up to and including it, signed by the attestation key of the node.\n" +
+		"Services cross-checking the attestations of independent nodes may use them to detect chain splits.  The signature may be checked with verifymessage using the returned address and message.",
+	"getkeyblockattestation-keyheight": "The key height of the key block",
+
+	// GetKeyBlockAttestationResult help.
+	"getkeyblockattestationresult-network":   "The network the node is on",
+	"getkeyblockattestationresult-keyheight": "The key height of the key block",
+	"getkeyblockattestationresult-height":    "The height of the key block",
+	"getkeyblockattestationresult-hash":      "The hash of the key block",
+	"getkeyblockattestationresult-chainwork": "The hex-encoded cumulative proof of work of the main chain up to and including the key block",
+	"getkeyblockattestationresult-time":      "The time of the attestation as the number of seconds since 1 Jan 1970 GMT",
+	"getkeyblockattestationresult-pubkey":    "The hex-encoded compressed public key of the attestation key of the node",
+	"getkeyblockattestationresult-address":   "The pay-to-pubkey-hash address of the attestation key of the node",
+	"getkeyblockattestationresult-message":   "The signed attestation message, which includes all of the above except for the key",
+	"getkeyblockattestationresult-signature": "The base64-encoded compact signature of the message",
+
+	// GetKeyBlockInfoCmd help.
+	"getkeyblockinfo--synopsis": "Returns the microblocks anchored by a main chain key block, which are the blocks following it up to the next key block, along with their transaction counts and timing statistics.\n" +
+		"When the hash identifies a microblock, the key block anchoring it is described.",
+	"getkeyblockinfo-hash": "The hash of the key block, or of a microblock anchored by it",
+
+	// GetKeyBlockInfoResult help.
+	"getkeyblockinforesult-hash":             "The hash of the key block",
+	"getkeyblockinforesult-height":           "The height of the key block",
+	"getkeyblockinforesult-keyheight":        "The key height of the key block",
+	"getkeyblockinforesult-time":             "The timestamp of the key block as the number of seconds since 1 Jan 1970 GMT",
+	"getkeyblockinforesult-tx":               "The number of regular transactions in the key block",
+	"getkeyblockinforesult-stx":              "The number of stake transactions in the key block",
+	"getkeyblockinforesult-microblocks":      "The microblocks anchored by the key block in ascending order of height",
+	"getkeyblockinforesult-totaltx":          "The number of regular transactions in the key block and its microblocks",
+	"getkeyblockinforesult-totalstx":         "The number of stake transactions in the key block and its microblocks",
+	"getkeyblockinforesult-nextkeyblockhash": "The hash of the next key block, omitted when the key block is the most recent one",
+	"getkeyblockinforesult-duration":         "The number of seconds from the key block to the next key block, or to the last microblock when there is no next key block",
+	"getkeyblockinforesult-mininterval":      "The minimum number of seconds between an anchored block, including the next key block, and the block before it",
+	"getkeyblockinforesult-maxinterval":      "The maximum number of seconds between an anchored block, including the next key block, and the block before it",
+	"getkeyblockinforesult-meaninterval":     "The mean number of seconds between an anchored block, including the next key block, and the block before it",
+
+	// MicroBlockInfoResult help.
+	"microblockinforesult-hash":     "The hash of the microblock",
+	"microblockinforesult-height":   "The height of the microblock",
+	"microblockinforesult-time":     "The timestamp of the microblock as the number of seconds since 1 Jan 1970 GMT",
+	"microblockinforesult-tx":       "The number of regular transactions in the microblock",
+	"microblockinforesult-stx":      "The number of stake transactions in the microblock",
+	"microblockinforesult-interval": "The number of seconds since the block before the microblock",
+
+	// GetVoteStatsCmd help.
+	"getvotestats--synopsis": "Returns the vote statistics of a voting address, which is the address the tickets of the address commit to for voting, such as the one of a stake pool.\n" +
+		"Tickets are counted as missed or expired once they are revoked.  Requires the vote stats index to be enabled (--votestatsindex).",
+	"getvotestats-address": "The voting address to return the statistics of",
+
+	// GetVoteStatsResult help.
+	"getvotestatsresult-address":             "The voting address",
+	"getvotestatsresult-votes":               "The number of votes cast by tickets of the address",
+	"getvotestatsresult-missed":              "The number of revoked tickets of the address which missed their vote",
+	"getvotestatsresult-expired":             "The number of revoked tickets of the address which expired without being selected",
+	"getvotestatsresult-inclusionpercentage": "The percentage of the tickets of the address which voted out of all of its tickets that either voted or were revoked",
+	"getvotestatsresult-averagelatency":      "The average number of key blocks between a ticket maturing and the key block it voted on",
+
+	// GetKnownAddressesCmd help.
+	"getknownaddresses--synopsis": "Returns a page of the addresses known to the address manager, ordered by address, along with their connection state.\n" +
+		"The remaining addresses are retrieved by passing the returned nextcursor until it is omitted.",
+	"getknownaddresses-limit":  "The maximum number of addresses to return, at most 1000",
+	"getknownaddresses-cursor": "The nextcursor of the previous page, or omitted for the first page",
+
+	// GetKnownAddressesResult help.
+	"getknownaddressesresult-items":      "The known addresses of the page",
+	"getknownaddressesresult-nextcursor": "The cursor to request the next page with, omitted on the last page",
+	"getknownaddressesresult-total":      "The total number of known addresses",
+
+	// KnownAddressResult help.
+	"knownaddressresult-addr":        "The IP address and port of the peer",
+	"knownaddressresult-services":    "Services bitmask advertised for the peer",
+	"knownaddressresult-timestamp":   "The time the peer was last seen in seconds since 1 Jan 1970 GMT",
+	"knownaddressresult-tried":       "Whether or not a connection to the peer was ever established",
+	"knownaddressresult-attempts":    "The number of connection attempts since the last successful connection",
+	"knownaddressresult-lastattempt": "The time of the last connection attempt in seconds since 1 Jan 1970 GMT, or 0 if never attempted",
+	"knownaddressresult-lastsuccess": "The time of the last successful connection in seconds since 1 Jan 1970 GMT, or 0 if never connected",
+
+	// GetLotteryProof help.
+	"getlotteryproof--synopsis":        "Returns the inputs and intermediate values of the ticket lottery seeded by a key block so the selection of its winning tickets can be independently audited.",
+	"getlotteryproof-hash":             "The hash of the key block",
+	"getlotteryproofresult-hash":       "The hash of the key block",
+	"getlotteryproofresult-height":     "The height of the key block",
+	"getlotteryproofresult-seed":       "The hex-encoded serialized key block header used to seed the lottery PRNG",
+	"getlotteryproofresult-poolsize":   "The number of live tickets the winners were drawn from",
+	"getlotteryproofresult-poolroot":   "The merkle root of the live ticket hashes in ascending order",
+	"getlotteryproofresult-draws":      "All numbers drawn from the PRNG in order, including those discarded for repeating an already selected index",
+	"getlotteryproofresult-winners":    "The winning tickets in selection order",
+	"getlotteryproofresult-statehash":  "The hash of the PRNG state after the last draw",
+	"getlotteryproofresult-finalstate": "The hex-encoded final state checksum committed to by the headers of the children of the key block",
+	"lotterywinnerresult-ticket":       "The hash of the winning ticket",
+	"lotterywinnerresult-index":        "The index of the ticket in the live ticket pool",
+	"lotterywinnerresult-merklebranch": "The sibling hashes connecting the ticket to the pool merkle root, starting at the leaves",
+
+	// GetNotices help.
+	"getnotices--synopsis":        "Returns the unexpired network notices that were signed by one of the notice keys of the active network.",
+	"getnoticesresult-id":         "The unique ID of the notice",
+	"getnoticesresult-expiration": "The time the notice expires in seconds since 1 Jan 1970 GMT",
+	"getnoticesresult-message":    "The text of the notice",
+
+	// GetRawTransactionsCmd help.
+	"getrawtransactions--synopsis": "Returns information about multiple transactions given their hashes in the order of the hashes.\n" +
+		"Transactions which can't be retrieved have an error set instead of the transaction.",
+	"getrawtransactions-txids":   "The hashes of the transactions (maximum 1000)",
+	"getrawtransactions-verbose": "Specifies the transactions are returned as JSON objects instead of hex-encoded strings",
+
+	// RawTransactionResult help.
+	"rawtransactionresult-txid":  "The requested hash of the transaction",
+	"rawtransactionresult-hex":   "Hex-encoded bytes of the serialized transaction (verbose=false)",
+	"rawtransactionresult-tx":    "The transaction (verbose=true)",
+	"rawtransactionresult-error": "The error that prevented retrieving the transaction",
+
+	// RPCError help.
+	"rpcerror-code":    "The numeric error code",
+	"rpcerror-message": "The error message",
+
+	// GetRPCAuthInfoCmd help.
+	"getrpcauthinfo--synopsis": "Returns counters of the RPC authentication failures and the hosts that are banned due to repeated failures.\n" +
+		"A host is refused for a period that doubles with each consecutive failure, starting at one second and up to one minute, and is banned for an hour after ten consecutive failures.",
+
+	// GetRPCAuthInfoResult help.
+	"getrpcauthinforesult-failures": "The number of authentication failures since the server started",
+	"getrpcauthinforesult-refused":  "The number of authentication attempts refused due to earlier failures",
+	"getrpcauthinforesult-bans":     "The number of times a host was banned",
+	"getrpcauthinforesult-banned":   "The hosts that are currently banned",
+
+	// GetSigCacheInfoCmd help.
+	"getsigcacheinfo--synopsis": "Returns statistics on the signature cache, which is shared by the mempool and block validation so the signatures of transactions accepted to the mempool are not verified again when they are included in a block.",
+
+	// GetSigCacheInfoResult help.
+	"getsigcacheinforesult-entries":    "The number of signatures in the cache",
+	"getsigcacheinforesult-maxentries": "The maximum number of signatures in the cache",
+	"getsigcacheinforesult-hits":       "The number of signature checks that were satisfied by the cache since the server started",
+	"getsigcacheinforesult-misses":     "The number of signature checks that were not satisfied by the cache since the server started",
+	"getsigcacheinforesult-hitrate":    "The ratio of hits to all signature checks",
+	"getsigcacheinforesult-evictions":  "The number of signatures evicted to make room for new ones",
+
+	// GetStakeNodeCacheInfoCmd help.
+	"getstakenodecacheinfo--synopsis": "Returns statistics on how the stake nodes, which hold the ticket pool state of blocks, were obtained since the server started.\n" +
+		"Stake nodes are cached in the block index, connected from the cached stake node of the parent block, or regenerated by replaying the ticket changes of every block between the best chain tip and the requested block, which is expensive for blocks deep in side chains.",
+
+	// GetStakeNodeCacheInfoResult help.
+	"getstakenodecacheinforesult-hits":              "The number of requested stake nodes that were cached",
+	"getstakenodecacheinforesult-parentconnects":    "The number of requested stake nodes that were connected from the cached stake node of the parent block",
+	"getstakenodecacheinforesult-regenerations":     "The number of requested stake nodes that had to be regenerated from the best chain tip",
+	"getstakenodecacheinforesult-hitrate":           "The ratio of requested stake nodes that did not have to be regenerated to all requested stake nodes",
+	"getstakenodecacheinforesult-connectreplays":    "The total number of blocks connected to stake nodes during regenerations",
+	"getstakenodecacheinforesult-disconnectreplays": "The total number of blocks disconnected from stake nodes during regenerations",
+	"getstakenodecacheinforesult-dblookups":         "The total number of disconnected blocks whose ticket undo data had to be loaded from the database",
+	"getstakenodecacheinforesult-maxreplays":        "The largest number of blocks connected and disconnected by a single regeneration",
+	"getstakenodecacheinforesult-regenerationtime":  "The total time spent regenerating stake nodes in seconds",
+
+	// RPCAuthBanResult help.
+	"rpcauthbanresult-host":        "The banned host",
+	"rpcauthbanresult-failures":    "The number of consecutive authentication failures of the host",
+	"rpcauthbanresult-banneduntil": "The time the ban expires in seconds since 1 Jan 1970 GMT",
+
+	// ListLiveTicketsCmd help.
+	"listlivetickets--synopsis": "Returns a page of the hashes of the live tickets ordered by hash.\n" +
+		"The remaining tickets are retrieved by passing the returned nextcursor until it is omitted.",
+	"listlivetickets-limit":  "The maximum number of tickets to return, at most 1000",
+	"listlivetickets-cursor": "The nextcursor of the previous page, or omitted for the first page",
+
+	// ListLiveTicketsResult help.
+	"listliveticketsresult-items":      "The hashes of the live tickets of the page",
+	"listliveticketsresult-nextcursor": "The cursor to request the next page with, omitted on the last page",
+	"listliveticketsresult-total":      "The total number of live tickets",
+
+	// ListenerCmd help.
+	"listener--synopsis": "Adds or removes peer-to-peer or RPC listen addresses without restarting, or lists the current ones.\n" +
+		"Connections accepted from removed listeners are not interrupted and drain as they are closed.\n" +
+		"The last RPC listener can not be removed.",
+	"listener-subcmd":  "'add' to listen on an address, 'remove' to stop listening on an address, or 'list' to only return the current listen addresses",
+	"listener-service": "The listeners to operate on, 'p2p' for peer-to-peer connections or 'rpc' for RPC connections (required for 'add' and 'remove')",
+	"listener-addr":    "The address to listen on or stop listening on, with an optional port and an empty host for all interfaces (required for 'add' and 'remove')",
+
+	// ListenerResult help.
+	"listenerresult-p2p": "The addresses peer-to-peer connections are accepted on",
+	"listenerresult-rpc": "The addresses RPC connections are accepted on",
+
 	// LiveTickets help.
 	"livetickets--synopsis":     "Request tickets the live ticket hashes from the ticket database",
 	"liveticketsresult-tickets": "List of live tickets",
@@ -832,6 +1356,10 @@ var helpDescsEnUS = map[string]string{
 	"missedtickets--synopsis":     "Request tickets the client missed",
 	"missedticketsresult-tickets": "List of missed tickets",
 
+	// ExpiredTickets help.
+	"expiredtickets--synopsis":     "Request the hashes of all tickets which expired, whether or not they were revoked, from the ticket database",
+	"expiredticketsresult-tickets": "List of expired tickets",
+
 	// TicketBuckets help.
 	"ticketbuckets--synopsis": "Request for the number of tickets currently in each bucket of the ticket database.",
 	"ticketbucket-tickets":    "Number of tickets in bucket.",
@@ -883,8 +1411,8 @@ var helpDescsEnUS = map[string]string{
 
 	// TicketVWAP help.
 	"ticketvwap--synopsis": "Calculate the volume weighted average price of tickets for a range of blocks (default: full PoS difficulty adjustment depth)",
-	"ticketvwap-start":     "The start height to begin calculating the VWAP from",
-	"ticketvwap-end":       "The end height to begin calculating the VWAP from",
+	"ticketvwap-start":     "The start key height to begin calculating the VWAP from",
+	"ticketvwap-end":       "The end key height to stop calculating the VWAP at (inclusive, default: the key height of the chain tip)",
 	"ticketvwap--result0":  "The volume weighted average price",
 	"ticketvwap-height":    "The height",
 
@@ -905,7 +1433,7 @@ var helpDescsEnUS = map[string]string{
 	"feeinforange-stddev": "Standard deviation of transaction fees in the window",
 
 	// Version help
-	"version--synopsis":       "Returns the JSON-RPC API version (semver)",
+	"version--synopsis":       "Returns the semantic versions of hcashd, the JSON-RPC API, and the peer-to-peer protocol",
 	"version--result0--desc":  "Version objects keyed by the program or API name",
 	"version--result0--key":   "Program or API name",
 	"version--result0--value": "Object containing the semantic version",
@@ -916,14 +1444,18 @@ var helpDescsEnUS = map[string]string{
 // pointer to the type (or nil to indicate no return value).
 var rpcResultTypes = map[string][]interface{}{
 	"addnode":               nil,
+	"addrevocationscript":   {(*string)(nil)},
+	"auditchain":            {(*hcashjson.AuditChainResult)(nil)},
 	"createrawsstx":         {(*string)(nil)},
 	"createrawssgentx":      {(*string)(nil)},
 	"createrawssrtx":        {(*string)(nil)},
 	"createrawtransaction":  {(*string)(nil)},
 	"debuglevel":            {(*string)(nil), (*string)(nil)},
+	"debugscript":                 {(*hcashjson.DebugScriptResult)(nil)},
 	"decoderawtransaction":  {(*hcashjson.TxRawDecodeResult)(nil)},
 	"decodescript":          {(*hcashjson.DecodeScriptResult)(nil)},
 	"estimatefee":           {(*float64)(nil)},
+	"estimatefinality":      {(*hcashjson.EstimateFinalityResult)(nil)},
 	"estimatestakediff":     {(*hcashjson.EstimateStakeDiffResult)(nil)},
 	"existsaddress":         {(*bool)(nil)},
 	"existsaddresses":       {(*string)(nil)},
@@ -932,24 +1464,29 @@ var rpcResultTypes = map[string][]interface{}{
 	"existsliveticket":      {(*bool)(nil)},
 	"existslivetickets":     {(*string)(nil)},
 	"existsmempooltxs":      {(*string)(nil)},
+	"expiredtickets":        {(*hcashjson.ExpiredTicketsResult)(nil)},
 	"getaddednodeinfo":      {(*[]string)(nil), (*[]hcashjson.GetAddedNodeInfoResult)(nil)},
+	"getaddresshistory":     {(*hcashjson.GetAddressHistoryResult)(nil)},
 	"getbestblock":          {(*hcashjson.GetBestBlockResult)(nil)},
 	"generate":              {(*[]string)(nil)},
 	"getbestblockhash":      {(*string)(nil)},
 	"getblock":              {(*string)(nil), (*hcashjson.GetBlockVerboseResult)(nil)},
+	"getblockchaininfo":     {(*hcashjson.GetBlockChainInfoResult)(nil)},
 	"getblockcount":         {(*int64)(nil)},
 	"getblockhash":          {(*string)(nil)},
 	"getkeyblockhash":       {(*string)(nil)},
 	"getblockkeyheight":     {(*int64)(nil)},
 	"getblockheader":        {(*string)(nil), (*hcashjson.GetBlockHeaderVerboseResult)(nil)},
+	"getblockindex":         {(*hcashjson.GetBlockIndexResult)(nil)},
 	"getblocksubsidy":       {(*hcashjson.GetBlockSubsidyResult)(nil)},
 	"getblocktemplate":      {(*hcashjson.GetBlockTemplateResult)(nil), (*string)(nil), nil},
 	"getconnectioncount":    {(*int32)(nil)},
 	"getcurrentnet":         {(*uint32)(nil)},
-	"getdifficulty":         {(*float64)(nil)},
+	"getdifficulty":         {(*hcashjson.GetDifficultyResult)(nil)},
 	"getstakedifficulty":    {(*hcashjson.GetStakeDifficultyResult)(nil)},
 	"getstakeversioninfo":   {(*hcashjson.GetStakeVersionInfoResult)(nil)},
 	"getstakeversions":      {(*hcashjson.GetStakeVersionsResult)(nil)},
+	"gettemplatedelta":      {(*hcashjson.GetTemplateDeltaResult)(nil)},
 	"getgenerate":           {(*bool)(nil)},
 	"gethashespersec":       {(*float64)(nil)},
 	"getheaders":            {(*hcashjson.GetHeadersResult)(nil)},
@@ -958,24 +1495,47 @@ var rpcResultTypes = map[string][]interface{}{
 	"getmininginfo":         {(*hcashjson.GetMiningInfoResult)(nil)},
 	"getnettotals":          {(*hcashjson.GetNetTotalsResult)(nil)},
 	"getnetworkhashps":      {(*int64)(nil)},
+	"getkeyblockattestation":      {(*hcashjson.GetKeyBlockAttestationResult)(nil)},
+	"getkeyblockinfo":       {(*hcashjson.GetKeyBlockInfoResult)(nil)},
+	"getknownaddresses":     {(*hcashjson.GetKnownAddressesResult)(nil)},
+	"getlotteryproof":       {(*hcashjson.GetLotteryProofResult)(nil)},
+	"getnotices":            {(*[]hcashjson.GetNoticesResult)(nil)},
+	"getrawtransactions":    {(*[]hcashjson.RawTransactionResult)(nil)},
+	"getrpcauthinfo":        {(*hcashjson.GetRPCAuthInfoResult)(nil)},
+	"getsigcacheinfo":       {(*hcashjson.GetSigCacheInfoResult)(nil)},
+	"getstakenodecacheinfo": {(*hcashjson.GetStakeNodeCacheInfoResult)(nil)},
 	"getpeerinfo":           {(*[]hcashjson.GetPeerInfoResult)(nil)},
 	"getrawmempool":         {(*[]string)(nil), (*hcashjson.GetRawMempoolVerboseResult)(nil)},
 	"getrawtransaction":     {(*string)(nil), (*hcashjson.TxRawResult)(nil)},
 	"getticketpoolvalue":    {(*float64)(nil)},
+	"gettxacceptancescore":        {(*hcashjson.GetTxAcceptanceScoreResult)(nil)},
 	"gettxout":              {(*hcashjson.GetTxOutResult)(nil)},
+	"gettxoutsetinfo":             {(*hcashjson.GetTxOutSetInfoResult)(nil)},
 	"getvoteinfo":           {(*hcashjson.GetVoteInfoResult)(nil)},
+	"getvotestats":          {(*hcashjson.GetVoteStatsResult)(nil)},
 	"getwork":               {(*hcashjson.GetWorkResult)(nil), (*bool)(nil)},
+	"getcfheaders":          {(*string)(nil)},
+	"getcfilter":            {(*string)(nil)},
+	"getchaintips":          {(*[]hcashjson.GetChainTipsResult)(nil)},
 	"getcoinsupply":         {(*int64)(nil)},
+	"getcpuminerinfo":       {(*hcashjson.GetCPUMinerInfoResult)(nil)},
 	"help":                  {(*string)(nil), (*string)(nil)},
+	"listbanned":            {(*[]hcashjson.ListBannedResult)(nil)},
+	"listlivetickets":       {(*hcashjson.ListLiveTicketsResult)(nil)},
+	"listener":              {(*hcashjson.ListenerResult)(nil)},
 	"livetickets":           {(*hcashjson.LiveTicketsResult)(nil)},
 	"missedtickets":         {(*hcashjson.MissedTicketsResult)(nil)},
 	"node":                  nil,
 	"ping":                  nil,
 	"rebroadcastmissed":     nil,
 	"rebroadcastwinners":    nil,
+	"reloadconfig":                {(*hcashjson.ReloadConfigResult)(nil)},
+	"restart":                     {(*string)(nil)},
 	"searchrawtransactions": {(*string)(nil), (*[]hcashjson.SearchRawTransactionsResult)(nil)},
 	"sendrawtransaction":    {(*string)(nil)},
+	"setban":                nil,
 	"setgenerate":           nil,
+	"setminingaddresses":    nil,
 	"stop":                  {(*string)(nil)},
 	"submitblock":           {nil, (*string)(nil)},
 	"ticketfeeinfo":         {(*hcashjson.TicketFeeInfoResult)(nil)},
@@ -989,6 +1549,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"version":               {(*map[string]hcashjson.VersionResult)(nil)},
 
 	// Websocket commands.
+	"authenticate":                nil,
 	"loadtxfilter":                nil,
 	"session":                     {(*hcashjson.SessionResult)(nil)},
 	"notifywinningtickets":        nil,
@@ -1000,6 +1561,8 @@ var rpcResultTypes = map[string][]interface{}{
 	"notifyreceived":              nil,
 	"notifyspent":                 nil,
 	"rescan":                      nil,
+	"getblockstream":              {(*hcashjson.GetBlockStreamResult)(nil)},
+	"searchrawtransactionsstream": {(*hcashjson.StreamResult)(nil)},
 	"stopnotifyblocks":            nil,
 	"stopnotifynewtransactions":   nil,
 	"stopnotifyreceived":          nil,
@@ -1073,6 +1636,18 @@ func (c *helpCacher) rpcUsage(includeWebsockets bool) (string, error) {
 			}
 			usageTexts = append(usageTexts, usage)
 		}
+		for k := range wsStreamHandlers {
+			// Streaming extensions of standard commands already
+			// have their usage included.
+			if _, ok := rpcHandlers[k]; ok {
+				continue
+			}
+			usage, err := hcashjson.MethodUsageText(k)
+			if err != nil {
+				return "", err
+			}
+			usageTexts = append(usageTexts, usage)
+		}
 	}
 
 	sort.Sort(sort.StringSlice(usageTexts))
@@ -5,7 +5,11 @@
 
 package main
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcashd/hcashjson"
+)
 
 // TestHelp ensures the help is reasonably accurate by checking that every
 // command specified also has result types defined and the one-line usage and
@@ -28,6 +32,13 @@ func TestHelp(t *testing.T) {
 		}
 
 	}
+	for k := range wsStreamHandlers {
+		if _, ok := rpcResultTypes[k]; !ok {
+			t.Errorf("RPC handler defined for method '%v' without "+
+				"also specifying result types", k)
+			continue
+		}
+	}
 
 	// Ensure the usage for every command can be generated without errors.
 	helpCacher := newHelpCacher()
@@ -63,4 +74,43 @@ func TestHelp(t *testing.T) {
 			continue
 		}
 	}
+	for k := range wsStreamHandlers {
+		if _, err := helpCacher.rpcMethodHelp(k); err != nil {
+			t.Errorf("Failed to generate help for method '%v': %v",
+				k, err)
+			continue
+		}
+	}
+}
+
+// TestHelpRegisteredCmds ensures help is provided for every registered command
+// served by the RPC server, so commands can't be registered without adding
+// their usage and result descriptions.  Wallet commands and notifications are
+// not served by the RPC server, and unimplemented commands have no help.
+func TestHelpRegisteredCmds(t *testing.T) {
+	helpCacher := newHelpCacher()
+	for _, method := range hcashjson.RegisteredCmdMethods() {
+		flags, err := hcashjson.MethodUsageFlags(method)
+		if err != nil {
+			t.Errorf("Failed to get usage flags for method '%v': %v",
+				method, err)
+			continue
+		}
+		if flags&(hcashjson.UFWalletOnly|hcashjson.UFNotification) != 0 {
+			continue
+		}
+		if _, ok := rpcUnimplemented[method]; ok {
+			continue
+		}
+
+		if _, ok := rpcResultTypes[method]; !ok {
+			t.Errorf("Command registered for method '%v' without "+
+				"also specifying result types", method)
+			continue
+		}
+		if _, err := helpCacher.rpcMethodHelp(method); err != nil {
+			t.Errorf("Failed to generate help for method '%v': %v",
+				method, err)
+		}
+	}
 }
@@ -0,0 +1,297 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
+	"github.com/HcashOrg/hcashutil"
+)
+
+const (
+	// sseClientBufferSize is the number of events that may be queued for
+	// a server-sent events client before it is considered too slow and
+	// disconnected.
+	sseClientBufferSize = 100
+
+	// sseKeepAliveInterval is the interval at which a comment is sent to
+	// idle server-sent events clients so intermediate proxies do not time
+	// out the connection.
+	sseKeepAliveInterval = 30 * time.Second
+
+	// sseRetryMillis is the reconnection delay in milliseconds suggested
+	// to server-sent events clients.
+	sseRetryMillis = 5000
+)
+
+// Server-sent event types.
+const (
+	// sseEventBlockConnected is sent for every block connected to the main
+	// chain.
+	sseEventBlockConnected = "blockconnected"
+
+	// sseEventKeyBlockConnected is sent in addition to
+	// sseEventBlockConnected when the connected block is a key block.
+	sseEventKeyBlockConnected = "keyblockconnected"
+
+	// sseEventTxAccepted is sent for every new transaction accepted to the
+	// memory pool.
+	sseEventTxAccepted = "txaccepted"
+)
+
+// sseBlockEvent is the data of the block connected server-sent events.
+type sseBlockEvent struct {
+	Hash      string `json:"hash"`
+	Height    int64  `json:"height"`
+	KeyHeight int64  `json:"keyheight"`
+	KeyBlock  bool   `json:"keyblock"`
+	Time      int64  `json:"time"`
+}
+
+// sseTxEvent is the data of the transaction accepted server-sent events.
+type sseTxEvent struct {
+	TxID   string  `json:"txid"`
+	Amount float64 `json:"amount"`
+}
+
+// sseClient houses the queue of encoded events for a single server-sent
+// events client.  The events channel is closed when the client falls too far
+// behind.
+type sseClient struct {
+	events chan []byte
+}
+
+// sseEventManager relays a subset of the websocket notifications to the
+// clients of the server-sent events endpoint.  Events are encoded once and
+// queued for every client without blocking, so a slow client can not delay
+// the notification manager or the other clients.
+type sseEventManager struct {
+	mtx     sync.Mutex
+	clients map[*sseClient]struct{}
+	nextID  uint64
+}
+
+// newSSEEventManager returns a new server-sent events manager with no
+// clients.
+func newSSEEventManager() *sseEventManager {
+	return &sseEventManager{clients: make(map[*sseClient]struct{})}
+}
+
+// addClient registers a new client and returns it.  It returns nil when the
+// maximum number of clients is already connected.
+//
+// This function is safe for concurrent access.
+func (m *sseEventManager) addClient(maxClients int) *sseClient {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if len(m.clients) >= maxClients {
+		return nil
+	}
+	c := &sseClient{events: make(chan []byte, sseClientBufferSize)}
+	m.clients[c] = struct{}{}
+	return c
+}
+
+// removeClient unregisters the passed client.
+//
+// This function is safe for concurrent access.
+func (m *sseEventManager) removeClient(c *sseClient) {
+	m.mtx.Lock()
+	if _, ok := m.clients[c]; ok {
+		delete(m.clients, c)
+		close(c.events)
+	}
+	m.mtx.Unlock()
+}
+
+// broadcast encodes the passed event and queues it for all clients.  Clients
+// whose queue is full are disconnected.
+//
+// This function is safe for concurrent access.
+func (m *sseEventManager) broadcast(event string, data interface{}) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if len(m.clients) == 0 {
+		return
+	}
+
+	marshalled, err := json.Marshal(data)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal %s event: %v", event, err)
+		return
+	}
+	m.nextID++
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "id: %d\nevent: %s\ndata: %s\n\n", m.nextID, event,
+		marshalled)
+	encoded := buf.Bytes()
+
+	for c := range m.clients {
+		select {
+		case c.events <- encoded:
+		default:
+			rpcsLog.Debugf("Disconnecting slow server-sent events " +
+				"client")
+			delete(m.clients, c)
+			close(c.events)
+		}
+	}
+}
+
+// notifyBlockConnected sends the block connected events for the passed block.
+//
+// This function is safe for concurrent access.
+func (m *sseEventManager) notifyBlockConnected(block *hcashutil.Block) {
+	header := &block.MsgBlock().Header
+	isKeyBlock := standalone.HashToBig(block.Hash()).Cmp(
+		standalone.CompactToBig(header.Bits)) <= 0
+	keyHeight := int64(header.KeyHeight)
+	if isKeyBlock {
+		keyHeight++
+	}
+
+	event := &sseBlockEvent{
+		Hash:      block.Hash().String(),
+		Height:    int64(header.Height),
+		KeyHeight: keyHeight,
+		KeyBlock:  isKeyBlock,
+		Time:      header.Timestamp.Unix(),
+	}
+	m.broadcast(sseEventBlockConnected, event)
+	if isKeyBlock {
+		m.broadcast(sseEventKeyBlockConnected, event)
+	}
+}
+
+// notifyTxAccepted sends the transaction accepted event for the passed
+// transaction.
+//
+// This function is safe for concurrent access.
+func (m *sseEventManager) notifyTxAccepted(tx *hcashutil.Tx) {
+	var amount int64
+	for _, txOut := range tx.MsgTx().TxOut {
+		amount += txOut.Value
+	}
+	m.broadcast(sseEventTxAccepted, &sseTxEvent{
+		TxID:   tx.Hash().String(),
+		Amount: hcashutil.Amount(amount).ToCoin(),
+	})
+}
+
+// sseAllowedOrigin returns the value of the Access-Control-Allow-Origin header
+// for a request from the passed origin, or an empty string when cross-origin
+// requests from it are not allowed.
+func sseAllowedOrigin(origin string, allowed []string) string {
+	if origin == "" {
+		return ""
+	}
+	for _, allowedOrigin := range allowed {
+		if allowedOrigin == "*" || allowedOrigin == origin {
+			return origin
+		}
+	}
+	return ""
+}
+
+// SSEHandler handles requests to the server-sent events endpoint.  After the
+// client is authenticated, the connection is kept open and events are streamed
+// to it until either the client disconnects, it falls too far behind, or the
+// server shuts down.  Cross-origin requests are allowed from the origins
+// configured via --rpcsseorigin, including the credentials of the request so
+// browsers can authenticate with HTTP basic authentication.
+func (s *rpcServer) SSEHandler(w http.ResponseWriter, r *http.Request) {
+	if origin := sseAllowedOrigin(r.Header.Get("Origin"),
+		cfg.RPCSSEOrigins); origin != "" {
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Add("Vary", "Origin")
+	}
+
+	// Answer preflight requests of clients that send the authorization
+	// header explicitly.
+	if r.Method == "OPTIONS" {
+		w.Header().Set("Access-Control-Allow-Methods", "GET")
+		w.Header().Set("Access-Control-Allow-Headers",
+			"Authorization, Last-Event-ID")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "405 Method Not Allowed.",
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, _, err := s.checkAuth(r, true); err != nil {
+		jsonAuthFail(w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "500 Streaming Unsupported.",
+			http.StatusInternalServerError)
+		return
+	}
+
+	maxClients := currentConnLimits().rpcMaxSSEClients
+	client := s.sseEvents.addClient(maxClients)
+	if client == nil {
+		rpcsLog.Infof("Max server-sent events clients exceeded [%d] - "+
+			"disconnecting client %s", maxClients, r.RemoteAddr)
+		http.Error(w, "503 Too busy.  Try again later.",
+			http.StatusServiceUnavailable)
+		return
+	}
+	defer s.sseEvents.removeClient(client)
+
+	rpcsLog.Infof("New server-sent events client %s", r.RemoteAddr)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("retry: " + strconv.Itoa(sseRetryMillis) + "\n\n"))
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+	closeChan := r.Context().Done()
+out:
+	for {
+		select {
+		case event, ok := <-client.events:
+			if !ok {
+				break out
+			}
+			if _, err := w.Write(event); err != nil {
+				break out
+			}
+			flusher.Flush()
+
+		case <-keepAlive.C:
+			if _, err := w.Write([]byte(": keepalive\n\n")); err != nil {
+				break out
+			}
+			flusher.Flush()
+
+		case <-closeChan:
+			break out
+
+		case <-s.quit:
+			break out
+		}
+	}
+	rpcsLog.Infof("Disconnected server-sent events client %s",
+		r.RemoteAddr)
+}
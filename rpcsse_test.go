@@ -0,0 +1,74 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+)
+
+// TestSSEEventManager ensures events are encoded and queued for all clients
+// and that clients which fall behind are disconnected.
+func TestSSEEventManager(t *testing.T) {
+	m := newSSEEventManager()
+	fast := m.addClient(2)
+	slow := m.addClient(2)
+	if fast == nil || slow == nil {
+		t.Fatal("failed to add clients")
+	}
+	if m.addClient(2) != nil {
+		t.Fatal("added client beyond the maximum")
+	}
+
+	m.broadcast(sseEventTxAccepted, &sseTxEvent{TxID: "abcd", Amount: 1.5})
+	want := "id: 1\nevent: txaccepted\n" +
+		"data: {\"txid\":\"abcd\",\"amount\":1.5}\n\n"
+	if got := string(<-fast.events); got != want {
+		t.Fatalf("unexpected event %q, want %q", got, want)
+	}
+
+	// Fill the queue of the slow client while draining the fast one.
+	for i := 0; i < sseClientBufferSize; i++ {
+		m.broadcast(sseEventTxAccepted, &sseTxEvent{TxID: "abcd"})
+		<-fast.events
+	}
+	for range slow.events {
+	}
+	if _, ok := m.clients[slow]; ok {
+		t.Fatal("slow client was not disconnected")
+	}
+	if _, ok := m.clients[fast]; !ok {
+		t.Fatal("fast client was disconnected")
+	}
+
+	// Removing a disconnected client must not close its queue again.
+	m.removeClient(slow)
+	m.removeClient(fast)
+	if len(m.clients) != 0 {
+		t.Fatalf("unexpected clients %v", m.clients)
+	}
+}
+
+// TestSSEAllowedOrigin ensures only the configured origins are allowed to make
+// cross-origin requests.
+func TestSSEAllowedOrigin(t *testing.T) {
+	tests := []struct {
+		origin  string
+		allowed []string
+		want    string
+	}{
+		{"", []string{"*"}, ""},
+		{"https://a.example", nil, ""},
+		{"https://a.example", []string{"https://b.example"}, ""},
+		{"https://a.example", []string{"https://b.example",
+			"https://a.example"}, "https://a.example"},
+		{"https://a.example", []string{"*"}, "https://a.example"},
+	}
+	for i, test := range tests {
+		got := sseAllowedOrigin(test.origin, test.allowed)
+		if got != test.want {
+			t.Errorf("test #%d: got %q, want %q", i, got, test.want)
+		}
+	}
+}
@@ -9,4 +9,13 @@
 // `hcashd`. However, the constructs presented are general enough to be adapted to
 // any project wishing to programmatically drive a `hcashd` instance of its
 // systems/integration tests.
+//
+// Competing branches of the chain may be mined via ForkAt and ExtendFork and
+// announced to chosen nodes via AnnounceFork, which allows deterministic tests
+// of reorganizations and double spends.
+//
+// The RPC calls made to a harness node may be recorded to a golden file via
+// RecordRPC.  A MockServer replays the recorded responses, which allows client
+// libraries built on hcashrpcclient to run their test suites against the
+// golden files without a live node.
 package rpctest
@@ -0,0 +1,196 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpctest
+
+import (
+	"fmt"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashrpcclient"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// chainFork is a competing branch of the chain of a harness.  The blocks of the
+// branch are mined by a separate node which is not connected to any other
+// node, so they are only announced to the nodes chosen by the test.
+type chainFork struct {
+	// miner is the harness mining the blocks of the branch.
+	miner *Harness
+
+	// forkHeight is the height of the last block shared with the chain of
+	// the harness the branch was forked from.
+	forkHeight int64
+
+	// blocks are the blocks of the branch after the fork height in the
+	// order they were mined.
+	blocks []*hcashutil.Block
+}
+
+// ForkAt starts a competing branch of the chain of the harness which shares the
+// blocks of the harness up to and including the passed height, and returns the
+// ID of the branch to pass to ExtendFork and AnnounceFork.  No blocks of the
+// branch exist until it is extended.
+//
+// The branch is mined by a separate hcashd node which is not connected to any
+// other node, so the blocks of the branch are only known to the nodes they are
+// announced to, which allows tests to deterministically control which node
+// sees which branch.  The node is torn down along with the harness.
+//
+// This function is safe for concurrent access.
+func (h *Harness) ForkAt(height int64) (int, error) {
+	_, bestHeight, err := h.Node.GetBestBlock()
+	if err != nil {
+		return 0, err
+	}
+	if height < 0 || height > bestHeight {
+		return 0, fmt.Errorf("fork height %d is not in the main chain "+
+			"with height %d", height, bestHeight)
+	}
+
+	miner, err := New(nil, h.ActiveNet, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	if err := miner.SetUp(false, 0); err != nil {
+		miner.TearDown()
+		return 0, err
+	}
+
+	// Provide the miner with the shared blocks.  The genesis block is
+	// known to every node already.
+	for i := int64(1); i <= height; i++ {
+		hash, err := h.Node.GetBlockHash(i)
+		if err != nil {
+			miner.TearDown()
+			return 0, err
+		}
+		block, err := h.Node.GetBlock(hash)
+		if err != nil {
+			miner.TearDown()
+			return 0, err
+		}
+		err = miner.Node.SubmitBlock(hcashutil.NewBlock(block), nil)
+		if err != nil {
+			miner.TearDown()
+			return 0, fmt.Errorf("unable to submit block %v to fork "+
+				"miner: %v", hash, err)
+		}
+	}
+
+	h.Lock()
+	defer h.Unlock()
+	h.forks = append(h.forks, &chainFork{
+		miner:      miner,
+		forkHeight: height,
+	})
+	return len(h.forks) - 1, nil
+}
+
+// fork returns the branch with the passed ID.
+//
+// This function MUST be called with the harness lock held.
+func (h *Harness) fork(forkID int) (*chainFork, error) {
+	if forkID < 0 || forkID >= len(h.forks) {
+		return nil, fmt.Errorf("unknown fork %d", forkID)
+	}
+	return h.forks[forkID], nil
+}
+
+// ExtendFork mines the passed number of blocks on top of the branch with the
+// passed ID and returns their hashes.  The blocks are not announced to any
+// node until AnnounceFork is called.  The blocks include the transactions sent
+// to the node returned by ForkNode, which allows creating double spends of
+// transactions mined by the harness.
+//
+// This function is safe for concurrent access.
+func (h *Harness) ExtendFork(forkID int, n uint32) ([]*chainhash.Hash, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	fork, err := h.fork(forkID)
+	if err != nil {
+		return nil, err
+	}
+	hashes, err := fork.miner.Node.Generate(n)
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range hashes {
+		block, err := fork.miner.Node.GetBlock(hash)
+		if err != nil {
+			return nil, err
+		}
+		fork.blocks = append(fork.blocks, hcashutil.NewBlock(block))
+	}
+	return hashes, nil
+}
+
+// AnnounceFork submits the blocks of the branch with the passed ID to the
+// passed harness nodes in the order they were mined, skipping the blocks a node
+// already has.  Each node must have the block at the fork height.  The nodes
+// switch to the branch once it has more proof of work than their current chain
+// and relay the blocks to their peers like any other block.
+//
+// This function is safe for concurrent access.
+func (h *Harness) AnnounceFork(forkID int, nodes ...*Harness) error {
+	h.Lock()
+	fork, err := h.fork(forkID)
+	var blocks []*hcashutil.Block
+	if err == nil {
+		blocks = append(blocks, fork.blocks...)
+	}
+	h.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		for _, block := range blocks {
+			if _, err := node.Node.GetBlock(block.Hash()); err == nil {
+				continue
+			}
+			if err := node.Node.SubmitBlock(block, nil); err != nil {
+				return fmt.Errorf("unable to submit block %v: %v",
+					block.Hash(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// ForkNode returns the RPC client of the node mining the branch with the passed
+// ID, or nil when there is no such branch.  Transactions sent to the node are
+// included in the blocks mined by ExtendFork.
+//
+// This function is safe for concurrent access.
+func (h *Harness) ForkNode(forkID int) *hcashrpcclient.Client {
+	h.Lock()
+	defer h.Unlock()
+
+	fork, err := h.fork(forkID)
+	if err != nil {
+		return nil
+	}
+	return fork.miner.Node
+}
+
+// tearDownForks tears down the nodes mining the branches of the harness which
+// were not torn down already.
+func (h *Harness) tearDownForks() error {
+	h.Lock()
+	forks := h.forks
+	h.forks = nil
+	h.Unlock()
+
+	for _, fork := range forks {
+		if _, active := testInstances[fork.miner.testNodeDir]; !active {
+			continue
+		}
+		if err := fork.miner.TearDown(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
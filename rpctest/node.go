@@ -14,6 +14,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,6 +24,62 @@ import (
 	"github.com/HcashOrg/hcashutil"
 )
 
+// NodePolicy houses the policy settings of a harness node which may be changed
+// between restarts via Harness.RestartWithPolicy.
+type NodePolicy struct {
+	// MinRelayTxFee is passed as --minrelaytxfee.  A nil value leaves the
+	// hcashd default in place.
+	MinRelayTxFee *hcashutil.Amount
+
+	// BlocksOnly is passed as --blocksonly.
+	BlocksOnly bool
+
+	// MaxPeers is passed as --maxpeers.  Zero leaves the hcashd default in
+	// place.
+	MaxPeers int
+
+	// TxIndex and AddrIndex are passed as --txindex and --addrindex.
+	// Note that hcashd enables the transaction index whenever the address
+	// index is enabled.
+	TxIndex   bool
+	AddrIndex bool
+}
+
+// DefaultNodePolicy returns the policy harness nodes are launched with.
+func DefaultNodePolicy() NodePolicy {
+	return NodePolicy{
+		TxIndex:   true,
+		AddrIndex: true,
+	}
+}
+
+// arguments returns the hcashd arguments which implement the policy.
+func (p *NodePolicy) arguments() []string {
+	args := []string{}
+	if p.MinRelayTxFee != nil {
+		// --minrelaytxfee
+		args = append(args, fmt.Sprintf("--minrelaytxfee=%s",
+			strconv.FormatFloat(p.MinRelayTxFee.ToCoin(), 'f', -1, 64)))
+	}
+	if p.BlocksOnly {
+		// --blocksonly
+		args = append(args, "--blocksonly")
+	}
+	if p.MaxPeers != 0 {
+		// --maxpeers
+		args = append(args, fmt.Sprintf("--maxpeers=%d", p.MaxPeers))
+	}
+	if p.TxIndex {
+		// --txindex
+		args = append(args, "--txindex")
+	}
+	if p.AddrIndex {
+		// --addrindex
+		args = append(args, "--addrindex")
+	}
+	return args
+}
+
 // nodeConfig contains all the args, and data required to launch a hcashd process
 // and connect the rpc client to it.
 type nodeConfig struct {
@@ -35,6 +92,7 @@ type nodeConfig struct {
 	logDir     string
 	profile    string
 	debugLevel string
+	policy     NodePolicy
 	extra      []string
 	prefix     string
 
@@ -52,6 +110,7 @@ func newConfig(prefix, certFile, keyFile string, extra []string) (*nodeConfig, e
 		rpcListen: "127.0.0.1:18556",
 		rpcUser:   "user",
 		rpcPass:   "pass",
+		policy:    DefaultNodePolicy(),
 		extra:     extra,
 		prefix:    prefix,
 
@@ -110,10 +169,7 @@ func (n *nodeConfig) arguments() []string {
 	args = append(args, fmt.Sprintf("--rpccert=%s", n.certFile))
 	// --rpckey
 	args = append(args, fmt.Sprintf("--rpckey=%s", n.keyFile))
-	// --txindex
-	args = append(args, "--txindex")
-	// --addrindex
-	args = append(args, "--addrindex")
+	args = append(args, n.policy.arguments()...)
 	if n.dataDir != "" {
 		// --datadir
 		args = append(args, fmt.Sprintf("--datadir=%s", n.dataDir))
@@ -237,6 +293,12 @@ func (n *node) cleanup() error {
 	return nil
 }
 
+// logFile returns the path of the log file written by the hcashd process.
+func (n *node) logFile() string {
+	return filepath.Join(n.config.logDir,
+		strings.ToLower(wire.SimNet.String()), "hcashd.log")
+}
+
 // shutdown terminates the running hcashd process, and cleans up all
 // file/directories created by node.
 func (n *node) shutdown() error {
@@ -0,0 +1,528 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpctest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/websocket"
+
+	"github.com/HcashOrg/hcashd/hcashjson"
+	"github.com/HcashOrg/hcashrpcclient"
+)
+
+// RecordedCall is an RPC request along with the response of the server to it
+// as stored in golden files.
+type RecordedCall struct {
+	Method string              `json:"method"`
+	Params []json.RawMessage   `json:"params"`
+	Result json.RawMessage     `json:"result,omitempty"`
+	Error  *hcashjson.RPCError `json:"error,omitempty"`
+
+	// answered is whether the response to the request was seen.
+	answered bool
+}
+
+// key returns the key used to match replayed requests to the call.  Requests
+// match when they invoke the same method with the same parameters regardless
+// of their formatting.
+func (c *RecordedCall) key() string {
+	params := make([]string, 0, len(c.Params))
+	for _, param := range c.Params {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, param); err != nil {
+			params = append(params, string(param))
+			continue
+		}
+		params = append(params, buf.String())
+	}
+	return c.Method + "(" + strings.Join(params, ",") + ")"
+}
+
+// ReadGoldenFile returns the calls recorded in the passed golden file.
+func ReadGoldenFile(goldenFile string) ([]*RecordedCall, error) {
+	data, err := ioutil.ReadFile(goldenFile)
+	if err != nil {
+		return nil, err
+	}
+	var calls []*RecordedCall
+	if err := json.Unmarshal(data, &calls); err != nil {
+		return nil, fmt.Errorf("malformed golden file %s: %v",
+			goldenFile, err)
+	}
+	return calls, nil
+}
+
+// WriteGoldenFile writes the passed calls to the passed golden file.  The calls
+// are indented so changes to golden files are easy to review.
+func WriteGoldenFile(goldenFile string, calls []*RecordedCall) error {
+	if calls == nil {
+		calls = []*RecordedCall{}
+	}
+	data, err := json.MarshalIndent(calls, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(goldenFile, append(data, '\n'), 0644)
+}
+
+// newRecordedCall returns a call for the passed marshalled JSON-RPC request,
+// or nil when it is not a valid request.
+func newRecordedCall(data []byte) (*RecordedCall, interface{}) {
+	var request hcashjson.Request
+	if err := json.Unmarshal(data, &request); err != nil ||
+		request.Method == "" {
+
+		return nil, nil
+	}
+	return &RecordedCall{
+		Method: request.Method,
+		Params: request.Params,
+	}, request.ID
+}
+
+// answer records the passed marshalled JSON-RPC response as the response to
+// the call.  It returns false when the data is not a response.
+func (c *RecordedCall) answer(data []byte) bool {
+	var response hcashjson.Response
+	if err := json.Unmarshal(data, &response); err != nil {
+		return false
+	}
+	c.Result = response.Result
+	c.Error = response.Error
+	c.answered = true
+	return true
+}
+
+// requestID returns the key of an id of a JSON-RPC request or response used to
+// match them.
+func requestID(id interface{}) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
+
+// rpcEndpoint describes the RPC server of a node.
+type rpcEndpoint struct {
+	host         string
+	user         string
+	pass         string
+	certificates []byte
+	disableTLS   bool
+}
+
+// tlsConfig returns the TLS configuration used to connect to the endpoint.
+func (e *rpcEndpoint) tlsConfig() *tls.Config {
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(e.certificates)
+	return &tls.Config{RootCAs: pool}
+}
+
+// authHeader returns the HTTP headers authenticating to the endpoint.
+func (e *rpcEndpoint) authHeader() http.Header {
+	login := e.user + ":" + e.pass
+	header := make(http.Header)
+	header.Set("Authorization", "Basic "+
+		base64.StdEncoding.EncodeToString([]byte(login)))
+	return header
+}
+
+// rpcRecorder is a proxy between RPC clients and the RPC server of a node which
+// records every request along with the response to it.  Both websocket and
+// HTTP POST clients are supported.  The proxy doesn't use TLS and
+// authenticates to the node on behalf of the clients.
+type rpcRecorder struct {
+	target   rpcEndpoint
+	listener net.Listener
+
+	mtx   sync.Mutex
+	calls []*RecordedCall
+	conns map[*websocket.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// newRPCRecorder returns a new recorder proxying the passed endpoint and
+// starts serving clients.
+func newRPCRecorder(target rpcEndpoint) (*rpcRecorder, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	r := &rpcRecorder{
+		target:   target,
+		listener: listener,
+		conns:    make(map[*websocket.Conn]struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", r.handleWebsocket)
+	mux.HandleFunc("/", r.handlePost)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		http.Serve(listener, mux)
+	}()
+	return r, nil
+}
+
+// addr returns the address clients connect to.
+func (r *rpcRecorder) addr() string {
+	return r.listener.Addr().String()
+}
+
+// addCall adds the passed call to the recorded calls in the order the
+// requests were made.
+func (r *rpcRecorder) addCall(call *RecordedCall) {
+	r.mtx.Lock()
+	r.calls = append(r.calls, call)
+	r.mtx.Unlock()
+}
+
+// recordedCalls returns the recorded calls which were answered.
+func (r *rpcRecorder) recordedCalls() []*RecordedCall {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	calls := make([]*RecordedCall, 0, len(r.calls))
+	for _, call := range r.calls {
+		if call.answered {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// handlePost forwards a HTTP POST request to the node and records it along
+// with the response.
+func (r *rpcRecorder) handlePost(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	scheme := "https"
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: r.target.tlsConfig()},
+	}
+	if r.target.disableTLS {
+		scheme = "http"
+		client = http.DefaultClient
+	}
+	nodeReq, err := http.NewRequest("POST", scheme+"://"+r.target.host,
+		bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	nodeReq.Header = r.target.authHeader()
+	nodeReq.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(nodeReq)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if call, _ := newRecordedCall(body); call != nil && call.answer(respBody) {
+		r.addCall(call)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+}
+
+// trackConn adds the passed websocket connection to the connections closed
+// when the recorder is stopped.  It returns false when the recorder is
+// stopped already.
+func (r *rpcRecorder) trackConn(conn *websocket.Conn) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.conns == nil {
+		return false
+	}
+	r.conns[conn] = struct{}{}
+	return true
+}
+
+// handleWebsocket relays the messages between a websocket client and the node
+// and records the requests of the client along with the responses.
+// Notifications are relayed without being recorded.
+func (r *rpcRecorder) handleWebsocket(w http.ResponseWriter, req *http.Request) {
+	clientConn, err := websocket.Upgrade(w, req, nil, 0, 0)
+	if err != nil {
+		return
+	}
+	scheme := "wss"
+	dialer := websocket.Dialer{TLSClientConfig: r.target.tlsConfig()}
+	if r.target.disableTLS {
+		scheme = "ws"
+	}
+	nodeConn, _, err := dialer.Dial(scheme+"://"+r.target.host+"/ws",
+		r.target.authHeader())
+	if err != nil {
+		clientConn.Close()
+		return
+	}
+	if !r.trackConn(clientConn) || !r.trackConn(nodeConn) {
+		clientConn.Close()
+		nodeConn.Close()
+		return
+	}
+
+	var pendingMtx sync.Mutex
+	pending := make(map[string]*RecordedCall)
+	onRequest := func(msg []byte) {
+		call, id := newRecordedCall(msg)
+		if call == nil || id == nil {
+			return
+		}
+		pendingMtx.Lock()
+		pending[requestID(id)] = call
+		pendingMtx.Unlock()
+		r.addCall(call)
+	}
+	onResponse := func(msg []byte) {
+		var response struct {
+			ID interface{} `json:"id"`
+		}
+		if err := json.Unmarshal(msg, &response); err != nil ||
+			response.ID == nil {
+
+			return
+		}
+		pendingMtx.Lock()
+		call, ok := pending[requestID(response.ID)]
+		delete(pending, requestID(response.ID))
+		pendingMtx.Unlock()
+		if ok {
+			r.mtx.Lock()
+			call.answer(msg)
+			r.mtx.Unlock()
+		}
+	}
+
+	// Relay the messages in both directions until either side closes
+	// the connection.
+	done := make(chan struct{}, 2)
+	relay := func(src, dst *websocket.Conn, observe func([]byte)) {
+		defer func() { done <- struct{}{} }()
+		for {
+			msgType, msg, err := src.ReadMessage()
+			if err != nil {
+				return
+			}
+			observe(msg)
+			if err := dst.WriteMessage(msgType, msg); err != nil {
+				return
+			}
+		}
+	}
+	go relay(clientConn, nodeConn, onRequest)
+	go relay(nodeConn, clientConn, onResponse)
+	<-done
+	clientConn.Close()
+	nodeConn.Close()
+	<-done
+}
+
+// stop stops serving clients, closes all websocket connections, and waits for
+// the server to exit.
+func (r *rpcRecorder) stop() {
+	r.listener.Close()
+	r.mtx.Lock()
+	for conn := range r.conns {
+		conn.Close()
+	}
+	r.conns = nil
+	r.mtx.Unlock()
+	r.wg.Wait()
+}
+
+// MockServer is a JSON-RPC server which replays the responses recorded in a
+// golden file so RPC clients may be tested without a running hcashd node.
+// Requests are matched to the recorded calls by their method and parameters.
+// Calls which were recorded several times are replayed in the order they were
+// recorded, with the last one being repeated once all of them were replayed.
+// Requests without a recorded call result in an error response.
+//
+// Both websocket and HTTP POST clients are supported.  The server doesn't use
+// TLS, accepts any credentials, and doesn't send notifications.
+type MockServer struct {
+	listener net.Listener
+
+	mtx      sync.Mutex
+	calls    map[string][]*RecordedCall
+	replayed map[string]int
+	conns    map[*websocket.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+// NewMockServer returns a new mock server replaying the calls recorded in the
+// passed golden file and starts serving clients.  Close must be called once
+// the server is no longer needed.
+func NewMockServer(goldenFile string) (*MockServer, error) {
+	calls, err := ReadGoldenFile(goldenFile)
+	if err != nil {
+		return nil, err
+	}
+	return newMockServer(calls)
+}
+
+// newMockServer returns a new mock server replaying the passed calls and
+// starts serving clients.
+func newMockServer(calls []*RecordedCall) (*MockServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &MockServer{
+		listener: listener,
+		calls:    make(map[string][]*RecordedCall),
+		replayed: make(map[string]int),
+		conns:    make(map[*websocket.Conn]struct{}),
+	}
+	for _, call := range calls {
+		key := call.key()
+		s.calls[key] = append(s.calls[key], call)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.handleWebsocket)
+	mux.HandleFunc("/", s.handlePost)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		http.Serve(listener, mux)
+	}()
+	return s, nil
+}
+
+// Addr returns the address clients connect to.
+func (s *MockServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// ConnConfig returns the configuration RPC clients use to connect to the
+// server.
+func (s *MockServer) ConnConfig() hcashrpcclient.ConnConfig {
+	return hcashrpcclient.ConnConfig{
+		Host:                 s.Addr(),
+		Endpoint:             "ws",
+		User:                 "user",
+		Pass:                 "pass",
+		DisableTLS:           true,
+		DisableAutoReconnect: true,
+	}
+}
+
+// respond returns the marshalled response to the passed marshalled JSON-RPC
+// request.
+func (s *MockServer) respond(data []byte) []byte {
+	req, id := newRecordedCall(data)
+	if req == nil {
+		rpcErr := hcashjson.NewRPCError(hcashjson.ErrRPCParse.Code,
+			"malformed request")
+		resp, _ := hcashjson.MarshalResponse(nil, nil, rpcErr)
+		return resp
+	}
+
+	key := req.key()
+	s.mtx.Lock()
+	var call *RecordedCall
+	if calls := s.calls[key]; len(calls) > 0 {
+		n := s.replayed[key]
+		if n >= len(calls) {
+			n = len(calls) - 1
+		}
+		call = calls[n]
+		s.replayed[key] = n + 1
+	}
+	s.mtx.Unlock()
+
+	if call == nil {
+		rpcErr := hcashjson.NewRPCError(hcashjson.ErrRPCMisc,
+			fmt.Sprintf("no recorded response for %s", key))
+		resp, _ := hcashjson.MarshalResponse(id, nil, rpcErr)
+		return resp
+	}
+	var result interface{}
+	if call.Result != nil {
+		result = call.Result
+	}
+	resp, err := hcashjson.MarshalResponse(id, result, call.Error)
+	if err != nil {
+		rpcErr := hcashjson.NewRPCError(hcashjson.ErrRPCMisc,
+			err.Error())
+		resp, _ = hcashjson.MarshalResponse(id, nil, rpcErr)
+	}
+	return resp
+}
+
+// handlePost responds to a HTTP POST request.
+func (s *MockServer) handlePost(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.respond(body))
+}
+
+// handleWebsocket responds to the requests of a websocket client until it
+// disconnects.
+func (s *MockServer) handleWebsocket(w http.ResponseWriter, req *http.Request) {
+	conn, err := websocket.Upgrade(w, req, nil, 0, 0)
+	if err != nil {
+		return
+	}
+	s.mtx.Lock()
+	if s.conns == nil {
+		s.mtx.Unlock()
+		conn.Close()
+		return
+	}
+	s.conns[conn] = struct{}{}
+	s.mtx.Unlock()
+	defer conn.Close()
+
+	for {
+		msgType, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := conn.WriteMessage(msgType, s.respond(msg)); err != nil {
+			return
+		}
+	}
+}
+
+// Close stops serving clients, closes all websocket connections, and waits for
+// the server to exit.
+func (s *MockServer) Close() error {
+	err := s.listener.Close()
+	s.mtx.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+	s.mtx.Unlock()
+	s.wg.Wait()
+	return err
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpctest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/websocket"
+
+	"github.com/HcashOrg/hcashd/hcashjson"
+)
+
+// postRequest sends the passed request to the passed address via HTTP POST
+// and returns the response.
+func postRequest(t *testing.T, addr, request string) *hcashjson.Response {
+	resp, err := http.Post("http://"+addr, "application/json",
+		bytes.NewBufferString(request))
+	if err != nil {
+		t.Fatalf("unable to post request: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("unable to read response: %v", err)
+	}
+	var response hcashjson.Response
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("malformed response %q: %v", body, err)
+	}
+	return &response
+}
+
+// wsRequest sends the passed request over the passed websocket connection and
+// returns the response.
+func wsRequest(t *testing.T, conn *websocket.Conn, request string) *hcashjson.Response {
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(request)); err != nil {
+		t.Fatalf("unable to send request: %v", err)
+	}
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("unable to read response: %v", err)
+	}
+	var response hcashjson.Response
+	if err := json.Unmarshal(msg, &response); err != nil {
+		t.Fatalf("malformed response %q: %v", msg, err)
+	}
+	return &response
+}
+
+// TestRecordAndReplay ensures the calls relayed by the recorder are recorded
+// and the responses are replayed by a mock server reading the golden file.
+func TestRecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpctest-recorder")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Stand in for a node with a mock server of its own.
+	node, err := newMockServer([]*RecordedCall{{
+		Method: "getblockcount",
+		Result: json.RawMessage(`100`),
+	}, {
+		Method: "getblockhash",
+		Params: []json.RawMessage{json.RawMessage(`50`)},
+		Result: json.RawMessage(`"00ab"`),
+	}, {
+		Method: "getblockhash",
+		Params: []json.RawMessage{json.RawMessage(`51`)},
+		Error:  hcashjson.NewRPCError(hcashjson.ErrRPCInvalidParameter, "out of range"),
+	}})
+	if err != nil {
+		t.Fatalf("unable to start node: %v", err)
+	}
+	defer node.Close()
+
+	recorder, err := newRPCRecorder(rpcEndpoint{
+		host:       node.Addr(),
+		disableTLS: true,
+	})
+	if err != nil {
+		t.Fatalf("unable to start recorder: %v", err)
+	}
+	resp := postRequest(t, recorder.addr(),
+		`{"jsonrpc":"1.0","method":"getblockcount","params":[],"id":1}`)
+	if string(resp.Result) != "100" {
+		t.Fatalf("got result %s, want 100", resp.Result)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+recorder.addr()+"/ws",
+		nil)
+	if err != nil {
+		t.Fatalf("unable to connect to recorder: %v", err)
+	}
+	resp = wsRequest(t, conn,
+		`{"jsonrpc":"1.0","method":"getblockhash","params":[ 50 ],"id":2}`)
+	if string(resp.Result) != `"00ab"` {
+		t.Fatalf("got result %s, want \"00ab\"", resp.Result)
+	}
+	resp = wsRequest(t, conn,
+		`{"jsonrpc":"1.0","method":"getblockhash","params":[51],"id":3}`)
+	if resp.Error == nil || resp.Error.Code != hcashjson.ErrRPCInvalidParameter {
+		t.Fatalf("got error %v, want out of range error", resp.Error)
+	}
+	conn.Close()
+	recorder.stop()
+
+	calls := recorder.recordedCalls()
+	if len(calls) != 3 {
+		t.Fatalf("got %d recorded calls, want 3", len(calls))
+	}
+	wantMethods := []string{"getblockcount", "getblockhash", "getblockhash"}
+	for i, call := range calls {
+		if call.Method != wantMethods[i] {
+			t.Fatalf("call #%d: got method %s, want %s", i, call.Method,
+				wantMethods[i])
+		}
+	}
+
+	goldenFile := filepath.Join(dir, "calls.json")
+	if err := WriteGoldenFile(goldenFile, calls); err != nil {
+		t.Fatalf("unable to write golden file: %v", err)
+	}
+	mock, err := NewMockServer(goldenFile)
+	if err != nil {
+		t.Fatalf("unable to start mock server: %v", err)
+	}
+	defer mock.Close()
+
+	// Replayed requests match regardless of the id and formatting.
+	resp = postRequest(t, mock.Addr(),
+		`{"jsonrpc":"1.0","method":"getblockhash","params":[50],"id":7}`)
+	if string(resp.Result) != `"00ab"` {
+		t.Fatalf("got result %s, want \"00ab\"", resp.Result)
+	}
+	if resp.ID == nil || (*resp.ID).(float64) != 7 {
+		t.Fatalf("got id %v, want 7", resp.ID)
+	}
+	conn, _, err = websocket.DefaultDialer.Dial("ws://"+mock.Addr()+"/ws", nil)
+	if err != nil {
+		t.Fatalf("unable to connect to mock server: %v", err)
+	}
+	defer conn.Close()
+	resp = wsRequest(t, conn,
+		`{"jsonrpc":"1.0","method":"getblockhash","params":[51],"id":8}`)
+	if resp.Error == nil || resp.Error.Code != hcashjson.ErrRPCInvalidParameter {
+		t.Fatalf("got error %v, want out of range error", resp.Error)
+	}
+
+	// Requests which were not recorded result in an error.
+	resp = wsRequest(t, conn,
+		`{"jsonrpc":"1.0","method":"getblockhash","params":[52],"id":9}`)
+	if resp.Error == nil || resp.Error.Code != hcashjson.ErrRPCMisc {
+		t.Fatalf("got error %v, want misc error", resp.Error)
+	}
+}
@@ -6,6 +6,7 @@
 package rpctest
 
 import (
+	"bufio"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -31,6 +32,12 @@ const (
 	maxPeerPort = 35000
 	minRPCPort  = maxPeerPort
 	maxRPCPort  = 60000
+
+	// deadlineMargin is the duration before the deadline of a test at
+	// which the setup of a harness tied to the test gives up.  It leaves
+	// time for the harness to be torn down before the test binary is
+	// terminated.
+	deadlineMargin = 10 * time.Second
 )
 
 var (
@@ -87,10 +94,23 @@ type Harness struct {
 
 	wallet *memWallet
 
+	// goldenFile is the file the RPC calls made to the node are recorded
+	// to by recorder when recording was requested via RecordRPC.
+	goldenFile string
+	recorder   *rpcRecorder
+
+	// forks are the competing branches of the chain created by ForkAt and
+	// indexed by their IDs.  They are protected by the harness lock.
+	forks []*chainFork
+
 	testNodeDir    string
 	maxConnRetries int
 	nodeNum        int
 
+	// setUpDeadline is the time at which SetUp gives up.  It is the zero
+	// time when the harness is not tied to a test with a deadline.
+	setUpDeadline time.Time
+
 	sync.Mutex
 }
 
@@ -99,8 +119,19 @@ type Harness struct {
 // In the case that a nil config is passed, a default configuration will be
 // used.
 //
+// When a test is passed, the harness is tied to its lifecycle.  The harness is
+// torn down once the test and all of its subtests complete, the log of the
+// hcashd process is written to the test log when the test failed, and SetUp
+// gives up shortly before the deadline of the test.  A nil test may be passed
+// to manage the harness manually, such as from TestMain, in which case
+// TearDown must be called once the harness is no longer needed.
+//
 // NOTE: This function is safe for concurrent access.
-func New(activeNet *chaincfg.Params, handlers *hcashrpcclient.NotificationHandlers, extraArgs []string) (*Harness, error) {
+func New(t testing.TB, activeNet *chaincfg.Params, handlers *hcashrpcclient.NotificationHandlers, extraArgs []string) (*Harness, error) {
+	if t != nil {
+		t.Helper()
+	}
+
 	harnessStateMtx.Lock()
 	defer harnessStateMtx.Unlock()
 
@@ -183,9 +214,87 @@ func New(activeNet *chaincfg.Params, handlers *hcashrpcclient.NotificationHandle
 	// global map of all active test instances.
 	testInstances[h.testNodeDir] = h
 
+	if t != nil {
+		// Only *testing.T provides a deadline.
+		type deadliner interface {
+			Deadline() (time.Time, bool)
+		}
+		if d, ok := t.(deadliner); ok {
+			if deadline, ok := d.Deadline(); ok {
+				h.setUpDeadline = deadline.Add(-deadlineMargin)
+			}
+		}
+		t.Cleanup(func() { h.cleanup(t) })
+	}
+
 	return h, nil
 }
 
+// NewSetUp creates a new instance of the rpc test harness tied to the passed
+// test the same way as New and sets it up as described by SetUp.  The test is
+// failed immediately when either step fails.
+func NewSetUp(t testing.TB, activeNet *chaincfg.Params, handlers *hcashrpcclient.NotificationHandlers, extraArgs []string, createTestChain bool, numMatureOutputs uint32) *Harness {
+	t.Helper()
+
+	h, err := New(t, activeNet, handlers, extraArgs)
+	if err != nil {
+		t.Fatalf("unable to create rpc test harness: %v", err)
+	}
+	if err := h.SetUp(createTestChain, numMatureOutputs); err != nil {
+		t.Fatalf("unable to set up rpc test harness: %v", err)
+	}
+	return h
+}
+
+// cleanup tears down the harness once the passed test it is tied to completes
+// unless it was already torn down.  The log of the hcashd process is written
+// to the test log first when the test failed.
+func (h *Harness) cleanup(t testing.TB) {
+	harnessStateMtx.Lock()
+	defer harnessStateMtx.Unlock()
+
+	if _, active := testInstances[h.testNodeDir]; !active {
+		return
+	}
+	if t.Failed() {
+		h.logNodeOutput(t)
+	}
+	if err := h.TearDown(); err != nil {
+		t.Errorf("unable to tear down rpc test harness: %v", err)
+	}
+}
+
+// logNodeOutput writes the log of the hcashd process to the passed test log.
+func (h *Harness) logNodeOutput(t testing.TB) {
+	logFile := h.node.logFile()
+	f, err := os.Open(logFile)
+	if err != nil {
+		t.Logf("unable to open hcashd log %s: %v", logFile, err)
+		return
+	}
+	defer f.Close()
+
+	t.Logf("hcashd log of harness %d (%s):", h.nodeNum, logFile)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		t.Log(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Logf("unable to read hcashd log %s: %v", logFile, err)
+	}
+}
+
+// checkSetUpDeadline returns an error when the setup deadline of the harness
+// has passed.
+func (h *Harness) checkSetUpDeadline() error {
+	if !h.setUpDeadline.IsZero() && !time.Now().Before(h.setUpDeadline) {
+		return fmt.Errorf("rpc test harness setup did not complete "+
+			"before the test deadline %v",
+			h.setUpDeadline.Add(deadlineMargin))
+	}
+	return nil
+}
+
 // SetUp initializes the rpc test state. Initialization includes: starting up a
 // simnet node, creating a websockets client and connecting to the started
 // node, and finally: optionally generating and submitting a testchain with a
@@ -199,6 +308,19 @@ func (h *Harness) SetUp(createTestChain bool, numMatureOutputs uint32) error {
 	if err := h.node.start(); err != nil {
 		return err
 	}
+	if h.goldenFile != "" && h.recorder == nil {
+		conf := h.node.config.rpcConnConfig()
+		recorder, err := newRPCRecorder(rpcEndpoint{
+			host:         conf.Host,
+			user:         conf.User,
+			pass:         conf.Pass,
+			certificates: conf.Certificates,
+		})
+		if err != nil {
+			return err
+		}
+		h.recorder = recorder
+	}
 	if err := h.connectRPCClient(); err != nil {
 		return err
 	}
@@ -237,6 +359,7 @@ func (h *Harness) SetUp(createTestChain bool, numMatureOutputs uint32) error {
 		return err
 	}
 	ticker := time.NewTicker(time.Millisecond * 100)
+	defer ticker.Stop()
 out:
 	for {
 		select {
@@ -245,6 +368,9 @@ out:
 			if walletHeight == height {
 				break out
 			}
+			if err := h.checkSetUpDeadline(); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -257,10 +383,23 @@ out:
 // NOTE: This method and SetUp should always be called from the same goroutine
 // as they are not concurrent safe.
 func (h *Harness) TearDown() error {
+	if err := h.tearDownForks(); err != nil {
+		return err
+	}
+
 	if h.Node != nil {
 		h.Node.Shutdown()
 	}
 
+	if h.recorder != nil {
+		h.recorder.stop()
+		calls := h.recorder.recordedCalls()
+		h.recorder = nil
+		if err := WriteGoldenFile(h.goldenFile, calls); err != nil {
+			return err
+		}
+	}
+
 	if err := h.node.shutdown(); err != nil {
 		return err
 	}
@@ -274,6 +413,55 @@ func (h *Harness) TearDown() error {
 	return nil
 }
 
+// Policy returns the policy settings the harness node is currently launched
+// with.
+//
+// This function is safe for concurrent access.
+func (h *Harness) Policy() NodePolicy {
+	h.Lock()
+	defer h.Unlock()
+	return h.node.config.policy
+}
+
+// RestartWithPolicy stops the running hcashd process, applies the passed
+// policy and relaunches the process in place.  The data directory, and thus
+// the chain, as well as the in-memory wallet are kept, so policy dependent
+// behaviour may be compared within a single test.  The RPC client is
+// reconnected and the wallet's notifications are registered again before
+// returning.
+//
+// NOTE: This method must not be called concurrently with SetUp or TearDown.
+func (h *Harness) RestartWithPolicy(policy NodePolicy) error {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.Node != nil {
+		h.Node.Shutdown()
+		h.Node.WaitForShutdown()
+		h.Node = nil
+	}
+	if err := h.node.shutdown(); err != nil {
+		return err
+	}
+
+	// An exec.Cmd may only be started once, so a new one is created with
+	// the updated arguments.
+	h.node.config.policy = policy
+	h.node.cmd = h.node.config.command()
+	if err := h.node.start(); err != nil {
+		return err
+	}
+	if err := h.connectRPCClient(); err != nil {
+		return err
+	}
+
+	filterAddrs := []hcashutil.Address{h.wallet.coinbaseAddr}
+	if err := h.Node.LoadTxFilter(true, filterAddrs, nil); err != nil {
+		return err
+	}
+	return h.Node.NotifyBlocks()
+}
+
 // connectRPCClient attempts to establish an RPC connection to the created hcashd
 // process belonging to this Harness instance. If the initial connection
 // attempt fails, this function will retry h.maxConnRetries times, backing off
@@ -284,8 +472,11 @@ func (h *Harness) connectRPCClient() error {
 	var client *hcashrpcclient.Client
 	var err error
 
-	rpcConf := h.node.config.rpcConnConfig()
+	rpcConf := h.RPCConfig()
 	for i := 0; i < h.maxConnRetries; i++ {
+		if err := h.checkSetUpDeadline(); err != nil {
+			return err
+		}
 		if client, err = hcashrpcclient.New(&rpcConf, h.handlers); err != nil {
 			time.Sleep(time.Duration(i) * 50 * time.Millisecond)
 			continue
@@ -352,9 +543,27 @@ func (h *Harness) UnlockOutputs(inputs []*wire.TxIn) {
 
 // RPCConfig returns the harnesses current rpc configuration. This allows other
 // potential RPC clients created within tests to connect to a given test
-// harness instance.
+// harness instance.  The configuration connects through the recorder while
+// the RPC calls are recorded so the calls of these clients are recorded too.
 func (h *Harness) RPCConfig() hcashrpcclient.ConnConfig {
-	return h.node.config.rpcConnConfig()
+	conf := h.node.config.rpcConnConfig()
+	if h.recorder != nil {
+		conf.Host = h.recorder.addr()
+		conf.Certificates = nil
+		conf.DisableTLS = true
+	}
+	return conf
+}
+
+// RecordRPC requests every RPC request made to the node by the harness and the
+// clients created from RPCConfig to be recorded along with the response to it.
+// The calls are written to the passed golden file when the harness is torn
+// down, which may then be replayed by a MockServer to test RPC clients without
+// a running node.
+//
+// NOTE: This method must be called before SetUp.
+func (h *Harness) RecordRPC(goldenFile string) {
+	h.goldenFile = goldenFile
 }
 
 // generateListeningAddresses returns two strings representing listening
@@ -117,14 +117,7 @@ func assertConnectedTo(t *testing.T, nodeA *Harness, nodeB *Harness) {
 
 func testConnectNode(r *Harness, t *testing.T) {
 	// Create a fresh test harness.
-	harness, err := New(&chaincfg.SimNetParams, nil, nil)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if err := harness.SetUp(false, 0); err != nil {
-		t.Fatalf("unable to complete rpctest setup: %v", err)
-	}
-	defer harness.TearDown()
+	harness := NewSetUp(t, &chaincfg.SimNetParams, nil, nil, false, 0)
 
 	// Establish a p2p connection from our new local harness to the main
 	// harness.
@@ -165,11 +158,10 @@ func testActiveHarnesses(r *Harness, t *testing.T) {
 	numInitialHarnesses := len(ActiveHarnesses())
 
 	// Create a single test harness.
-	harness1, err := New(&chaincfg.SimNetParams, nil, nil)
+	harness1, err := New(t, &chaincfg.SimNetParams, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer harness1.TearDown()
 
 	// With the harness created above, a single harness should be detected
 	// as active.
@@ -193,14 +185,7 @@ func testJoinMempools(r *Harness, t *testing.T) {
 	// Create a local test harness with only the genesis block.  The nodes
 	// will be synced below so the same transaction can be sent to both
 	// nodes without it being an orphan.
-	harness, err := New(&chaincfg.SimNetParams, nil, nil)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if err := harness.SetUp(false, 0); err != nil {
-		t.Fatalf("unable to complete rpctest setup: %v", err)
-	}
-	defer harness.TearDown()
+	harness := NewSetUp(t, &chaincfg.SimNetParams, nil, nil, false, 0)
 
 	nodeSlice := []*Harness{r, harness}
 
@@ -293,14 +278,7 @@ func testJoinMempools(r *Harness, t *testing.T) {
 func testJoinBlocks(r *Harness, t *testing.T) {
 	// Create a second harness with only the genesis block so it is behind
 	// the main harness.
-	harness, err := New(&chaincfg.SimNetParams, nil, nil)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if err := harness.SetUp(false, 0); err != nil {
-		t.Fatalf("unable to complete rpctest setup: %v", err)
-	}
-	defer harness.TearDown()
+	harness := NewSetUp(t, &chaincfg.SimNetParams, nil, nil, false, 0)
 
 	nodeSlice := []*Harness{r, harness}
 	blocksSynced := make(chan struct{})
@@ -340,14 +318,7 @@ func testJoinBlocks(r *Harness, t *testing.T) {
 func testMemWalletReorg(r *Harness, t *testing.T) {
 	// Create a fresh harness, we'll be using the main harness to force a
 	// re-org on this local harness.
-	harness, err := New(&chaincfg.SimNetParams, nil, nil)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if err := harness.SetUp(true, 5); err != nil {
-		t.Fatalf("unable to complete rpctest setup: %v", err)
-	}
-	defer harness.TearDown()
+	harness := NewSetUp(t, &chaincfg.SimNetParams, nil, nil, true, 5)
 
 	// Ensure the internal wallet has the expected balance.
 	expectedBalance := hcashutil.Amount(5 * 300 * hcashutil.AtomsPerCoin)
@@ -417,6 +388,102 @@ func testMemWalletLockedOutputs(r *Harness, t *testing.T) {
 	}
 }
 
+func testRestartWithPolicy(r *Harness, t *testing.T) {
+	_, startHeight, err := r.Node.GetBestBlock()
+	if err != nil {
+		t.Fatalf("unable to get best block: %v", err)
+	}
+	startingBalance := r.ConfirmedBalance()
+
+	// Restart the node with a stricter policy and ensure the chain and
+	// wallet state survived the restart.
+	fee := hcashutil.Amount(hcashutil.AtomsPerCoin / 10)
+	policy := DefaultNodePolicy()
+	policy.MinRelayTxFee = &fee
+	policy.BlocksOnly = true
+	policy.MaxPeers = 4
+	if err := r.RestartWithPolicy(policy); err != nil {
+		t.Fatalf("unable to restart node: %v", err)
+	}
+	if r.Policy().MinRelayTxFee != &fee || !r.Policy().BlocksOnly {
+		t.Fatalf("policy was not applied: %+v", r.Policy())
+	}
+	_, height, err := r.Node.GetBestBlock()
+	if err != nil {
+		t.Fatalf("unable to get best block: %v", err)
+	}
+	if height != startHeight {
+		t.Fatalf("chain height changed across restart: got %v, want %v",
+			height, startHeight)
+	}
+	if balance := r.ConfirmedBalance(); balance != startingBalance {
+		t.Fatalf("wallet balance changed across restart: got %v, "+
+			"want %v", balance, startingBalance)
+	}
+
+	// Ensure blocks are still delivered to the wallet after the restart.
+	if _, err := r.Node.Generate(1); err != nil {
+		t.Fatalf("unable to generate block: %v", err)
+	}
+
+	// Restore the default policy for any remaining tests.
+	if err := r.RestartWithPolicy(DefaultNodePolicy()); err != nil {
+		t.Fatalf("unable to restart node: %v", err)
+	}
+}
+
+func testForkReorg(r *Harness, t *testing.T) {
+	_, forkHeight, err := r.Node.GetBestBlock()
+	if err != nil {
+		t.Fatalf("unable to get best block: %v", err)
+	}
+	forkID, err := r.ForkAt(forkHeight)
+	if err != nil {
+		t.Fatalf("unable to fork chain: %v", err)
+	}
+	forkHashes, err := r.ExtendFork(forkID, 3)
+	if err != nil {
+		t.Fatalf("unable to extend fork: %v", err)
+	}
+
+	// Extend the main chain by a single block.  The fork must not be seen
+	// by the harness until it is announced.
+	mainHashes, err := r.Node.Generate(1)
+	if err != nil {
+		t.Fatalf("unable to generate block: %v", err)
+	}
+	bestHash, _, err := r.Node.GetBestBlock()
+	if err != nil {
+		t.Fatalf("unable to get best block: %v", err)
+	}
+	if *bestHash != *mainHashes[0] {
+		t.Fatalf("unexpected best block before announcing fork: got "+
+			"%v, want %v", bestHash, mainHashes[0])
+	}
+
+	// Announce the longer fork and ensure the harness reorganizes to it.
+	if err := r.AnnounceFork(forkID, r); err != nil {
+		t.Fatalf("unable to announce fork: %v", err)
+	}
+	bestHash, bestHeight, err := r.Node.GetBestBlock()
+	if err != nil {
+		t.Fatalf("unable to get best block: %v", err)
+	}
+	if *bestHash != *forkHashes[2] || bestHeight != forkHeight+3 {
+		t.Fatalf("harness did not reorganize to fork: got %v (height "+
+			"%d), want %v (height %d)", bestHash, bestHeight,
+			forkHashes[2], forkHeight+3)
+	}
+
+	// Announcing the fork again is a no-op.
+	if err := r.AnnounceFork(forkID, r); err != nil {
+		t.Fatalf("unable to announce fork again: %v", err)
+	}
+	if _, err := r.ExtendFork(forkID+1, 1); err == nil {
+		t.Fatalf("extended unknown fork")
+	}
+}
+
 var harnessTestCases = []HarnessTestCase{
 	testSendOutputs,
 	testConnectNode,
@@ -425,13 +492,15 @@ var harnessTestCases = []HarnessTestCase{
 	testJoinMempools, // Depends on results of testJoinBlocks
 	testMemWalletReorg,
 	testMemWalletLockedOutputs,
+	testRestartWithPolicy,
+	testForkReorg,
 }
 
 var mainHarness *Harness
 
 func TestMain(m *testing.M) {
 	var err error
-	mainHarness, err = New(&chaincfg.SimNetParams, nil, nil)
+	mainHarness, err = New(nil, &chaincfg.SimNetParams, nil, nil)
 	if err != nil {
 		fmt.Println("unable to create main harness: ", err)
 		os.Exit(1)
@@ -40,6 +40,10 @@ const (
 	// handler since notifications have their own queuing mechanism
 	// independent of the send channel buffer.
 	websocketSendBufferSize = 50
+
+	// maxStreamChunkSize is the maximum number of result elements a
+	// streaming request may ask to be sent per streamdata notification.
+	maxStreamChunkSize = 1000
 )
 
 type semaphore chan struct{}
@@ -71,11 +75,29 @@ var wsHandlersBeforeInit = map[string]wsCommandHandler{
 	"notifynewtickets":            handleNewTickets,
 	"notifystakedifficulty":       handleStakeDifficulty,
 	"notifynewtransactions":       handleNotifyNewTransactions,
+	"notifyreceived":              handleNotifyReceived,
+	"notifyspent":                 handleNotifySpent,
 	"session":                     handleSession,
 	"help":                        handleWebsocketHelp,
 	"rescan":                      handleRescan,
 	"stopnotifyblocks":            handleStopNotifyBlocks,
 	"stopnotifynewtransactions":   handleStopNotifyNewTransactions,
+	"stopnotifyreceived":          handleStopNotifyReceived,
+	"stopnotifyspent":             handleStopNotifySpent,
+}
+
+// wsStreamHandler describes a callback function used to handle a specific
+// streaming command, which sends notifications tied to the request before
+// replying to it.  It is passed the id of the request so the chunks of the
+// result or progress it streams can be matched with the request by the client.
+type wsStreamHandler func(*wsClient, interface{}, interface{}) (interface{}, error)
+
+// wsStreamHandlers maps the streaming RPC command strings to the appropriate
+// websocket handler functions.
+var wsStreamHandlers = map[string]wsStreamHandler{
+	"getblockstream":              handleGetBlockStream,
+	"searchrawtransactionsstream": handleSearchRawTransactionsStream,
+	"verifychain":                 handleWebsocketVerifyChain,
 }
 
 // WebsocketHandler handles a new websocket client by creating a new wsClient,
@@ -92,10 +114,10 @@ func (s *rpcServer) WebsocketHandler(conn *websocket.Conn, remoteAddr string,
 
 	// Limit max number of websocket clients.
 	rpcsLog.Infof("New websocket client %s", remoteAddr)
-	if s.ntfnMgr.NumClients()+1 > cfg.RPCMaxWebsockets {
+	maxWebsockets := currentConnLimits().rpcMaxWebsockets
+	if s.ntfnMgr.NumClients()+1 > maxWebsockets {
 		rpcsLog.Infof("Max websocket clients exceeded [%d] - "+
-			"disconnecting client %s", cfg.RPCMaxWebsockets,
-			remoteAddr)
+			"disconnecting client %s", maxWebsockets, remoteAddr)
 		conn.Close()
 		return
 	}
@@ -327,7 +349,8 @@ type WinningTicketsNtfnData struct {
 }
 
 // StakeDifficultyNtfnData is the data that is used to generate
-// stake difficulty notifications.
+// stake difficulty notifications.  StakeDifficulty is the stake difficulty
+// required by the block after the passed block.
 type StakeDifficultyNtfnData struct {
 	BlockHash       chainhash.Hash
 	BlockHeight     int64
@@ -542,6 +565,16 @@ func (m *wsNotificationManager) notificationHandler() {
 	stakeDifficultyNotifications := make(map[chan struct{}]*wsClient)
 	txNotifications := make(map[chan struct{}]*wsClient)
 
+	// The stake difficulty only changes at the key blocks ending a stake
+	// difficulty window, so clients are only notified when it differs from
+	// the last notified stake difficulty.  Start from the stake difficulty
+	// of the next block so a restart does not produce a notification.
+	lastStakeDiff, err := m.server.chain.CalcNextRequiredStakeDifficulty()
+	if err != nil {
+		rpcsLog.Warnf("Failed to get next stake difficulty: %v", err)
+		lastStakeDiff = -1
+	}
+
 out:
 	for {
 		select {
@@ -553,6 +586,12 @@ out:
 			switch n := n.(type) {
 			case *notificationBlockConnected:
 				block := (*hcashutil.Block)(n)
+				m.server.sseEvents.notifyBlockConnected(block)
+
+				// Transaction notifications are sent before the
+				// block connected notification so clients know
+				// when they received all transactions of a block.
+				m.notifySubscribedBlock(clients, block)
 
 				// Skip iterating through all txs if no tx
 				// notification requests exist.
@@ -583,14 +622,24 @@ out:
 					(*blockchain.TicketNotificationsData)(n))
 
 			case *notificationStakeDifficulty:
-				m.notifyStakeDifficulty(stakeDifficultyNotifications,
-					(*StakeDifficultyNtfnData)(n))
+				if n.StakeDifficulty == lastStakeDiff {
+					continue
+				}
+				lastStakeDiff = n.StakeDifficulty
+				if len(stakeDifficultyNotifications) != 0 {
+					m.notifyStakeDifficulty(stakeDifficultyNotifications,
+						(*StakeDifficultyNtfnData)(n))
+				}
 
 			case *notificationTxAcceptedByMempool:
 				if n.isNew && len(txNotifications) != 0 {
 					m.notifyForNewTx(txNotifications, n.tx)
 				}
+				if n.isNew {
+					m.server.sseEvents.notifyTxAccepted(n.tx)
+				}
 				m.notifyRelevantTxAccepted(n.tx, clients)
+				m.notifySubscribedTx(clients, n.tx, nil)
 
 			case *notificationRegisterBlocks:
 				wsc := (*wsClient)(n)
@@ -641,6 +690,10 @@ out:
 				// Remove any requests made by the client as well as
 				// the client itself.
 				delete(blockNotifications, wsc.quit)
+				delete(winningTicketNotifications, wsc.quit)
+				delete(ticketSMNotifications, wsc.quit)
+				delete(ticketNewNotifications, wsc.quit)
+				delete(stakeDifficultyNotifications, wsc.quit)
 				delete(txNotifications, wsc.quit)
 				delete(clients, wsc.quit)
 
@@ -994,7 +1047,8 @@ func (*wsNotificationManager) notifyNewTickets(clients map[chan struct{}]*wsClie
 }
 
 // notifyStakeDifficulty notifies websocket clients that have registered for
-// maturing ticket updates.
+// stake difficulty updates of the new stake difficulty and the height of the
+// first block it applies to.
 func (*wsNotificationManager) notifyStakeDifficulty(
 	clients map[chan struct{}]*wsClient,
 	sdnd *StakeDifficultyNtfnData) {
@@ -1002,7 +1056,8 @@ func (*wsNotificationManager) notifyStakeDifficulty(
 	// Notify interested websocket clients about the connected block.
 	ntfn := hcashjson.NewStakeDifficultyNtfn(sdnd.BlockHash.String(),
 		int32(sdnd.BlockHeight),
-		sdnd.StakeDifficulty)
+		sdnd.StakeDifficulty,
+		int32(sdnd.BlockHeight+1))
 
 	marshalledJSON, err := hcashjson.MarshalCmd(nil, ntfn)
 	if err != nil {
@@ -1155,6 +1210,125 @@ func (m *wsNotificationManager) notifyRelevantTxAccepted(tx *hcashutil.Tx,
 	}
 }
 
+// notifySubscribedTx notifies websocket clients which registered for the passed
+// transaction with notifyreceived or notifyspent.  A recvtx notification is
+// sent to clients for which the transaction pays to a registered address, and
+// a redeemingtx notification to clients for which it spends a registered
+// outpoint.  The outputs paying to a registered address are registered for
+// spend notifications as well, while outpoints spent by a transaction in a
+// block are no longer watched.  The block details are nil for transactions
+// accepted by the mempool.
+func (m *wsNotificationManager) notifySubscribedTx(clients map[chan struct{}]*wsClient,
+	tx *hcashutil.Tx, block *hcashjson.BlockDetails) {
+
+	var recvClients, redeemClients []*wsClient
+
+	msgTx := tx.MsgTx()
+	for _, c := range clients {
+		c.Lock()
+		f := c.ntfnFilter
+		c.Unlock()
+		if f == nil {
+			continue
+		}
+		f.mu.Lock()
+
+		var redeems, receives bool
+		for _, input := range msgTx.TxIn {
+			if !f.existsUnspentOutPoint(&input.PreviousOutPoint) {
+				continue
+			}
+			redeems = true
+			if block != nil {
+				f.removeUnspentOutPoint(&input.PreviousOutPoint)
+			}
+		}
+
+		for i, output := range msgTx.TxOut {
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+				output.Version, output.PkScript,
+				m.server.server.chainParams)
+			if err != nil {
+				continue
+			}
+			for _, a := range addrs {
+				if !f.existsAddress(a) {
+					continue
+				}
+				receives = true
+				op := wire.OutPoint{
+					Hash:  *tx.Hash(),
+					Index: uint32(i),
+					Tree:  tx.Tree(),
+				}
+				f.addUnspentOutPoint(&op)
+			}
+		}
+
+		f.mu.Unlock()
+
+		if redeems {
+			redeemClients = append(redeemClients, c)
+		}
+		if receives {
+			recvClients = append(recvClients, c)
+		}
+	}
+
+	if len(recvClients) == 0 && len(redeemClients) == 0 {
+		return
+	}
+	txHex := txHexString(msgTx)
+	if len(redeemClients) != 0 {
+		n := hcashjson.NewRedeemingTxNtfn(txHex, block)
+		marshalled, err := hcashjson.MarshalCmd(nil, n)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal redeemingtx "+
+				"notification: %v", err)
+			return
+		}
+		for _, c := range redeemClients {
+			c.QueueNotification(marshalled)
+		}
+	}
+	if len(recvClients) != 0 {
+		n := hcashjson.NewRecvTxNtfn(txHex, block)
+		marshalled, err := hcashjson.MarshalCmd(nil, n)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal recvtx "+
+				"notification: %v", err)
+			return
+		}
+		for _, c := range recvClients {
+			c.QueueNotification(marshalled)
+		}
+	}
+}
+
+// notifySubscribedBlock sends the recvtx and redeemingtx notifications for all
+// transactions of both transaction trees of the passed block connected to the
+// main chain.  See notifySubscribedTx for more details.
+func (m *wsNotificationManager) notifySubscribedBlock(clients map[chan struct{}]*wsClient,
+	block *hcashutil.Block) {
+
+	header := &block.MsgBlock().Header
+	details := func(tree int8, index int) *hcashjson.BlockDetails {
+		return &hcashjson.BlockDetails{
+			Height: int32(header.Height),
+			Tree:   tree,
+			Hash:   block.Hash().String(),
+			Index:  index,
+			Time:   header.Timestamp.Unix(),
+		}
+	}
+	for i, tx := range block.STransactions() {
+		m.notifySubscribedTx(clients, tx, details(wire.TxTreeStake, i))
+	}
+	for i, tx := range block.Transactions() {
+		m.notifySubscribedTx(clients, tx, details(wire.TxTreeRegular, i))
+	}
+}
+
 // AddClient adds the passed websocket client to the notification manager.
 func (m *wsNotificationManager) AddClient(wsc *wsClient) {
 	m.queueNotification <- (*notificationRegisterClient)(wsc)
@@ -1258,6 +1432,10 @@ type wsClient struct {
 
 	filterData *wsClientFilter
 
+	// ntfnFilter holds the addresses and outpoints the client registered
+	// for with the notifyreceived and notifyspent commands.
+	ntfnFilter *wsClientFilter
+
 	// Networking infrastructure.
 	serviceRequestSem semaphore
 	ntfnChan          chan []byte
@@ -1351,16 +1529,23 @@ out:
 				"received")
 			break out
 		case !c.authenticated:
-			// Check credentials.
+			// Check credentials.  Wrong credentials are refused
+			// without being counted as a failure when the client
+			// failed to authenticate too often, while correct ones
+			// are always accepted.
 			login := authCmd.Username + ":" + authCmd.Passphrase
 			auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
 			authSha := sha256.Sum256([]byte(auth))
 			cmp := subtle.ConstantTimeCompare(authSha[:], c.server.authsha[:])
 			limitcmp := subtle.ConstantTimeCompare(authSha[:], c.server.limitauthsha[:])
 			if cmp != 1 && limitcmp != 1 {
-				rpcsLog.Warnf("Auth failure.")
+				if !c.server.authRefused(c.addr, "authenticate") {
+					c.server.authFailure(c.addr,
+						authCmd.Username, "authenticate")
+				}
 				break out
 			}
+			c.server.authSuccess(c.addr)
 			c.authenticated = true
 			c.isAdmin = cmp == 1
 
@@ -1377,22 +1562,20 @@ out:
 
 		// Check if the client is using limited RPC credentials and
 		// error when not authorized to call this RPC.
-		if !c.isAdmin {
-			if _, ok := rpcLimited[request.Method]; !ok {
-				jsonErr := &hcashjson.RPCError{
-					Code:    hcashjson.ErrRPCInvalidParams.Code,
-					Message: "limited user not authorized for this method",
-				}
-				// Marshal and send response.
-				reply, err := createMarshalledReply(request.ID, nil, jsonErr)
-				if err != nil {
-					rpcsLog.Errorf("Failed to marshal parse failure "+
-						"reply: %v", err)
-					continue
-				}
-				c.SendMessage(reply, nil)
+		if !c.server.authorized(request.Method, c.isAdmin) {
+			jsonErr := &hcashjson.RPCError{
+				Code:    hcashjson.ErrRPCInvalidParams.Code,
+				Message: "limited user not authorized for this method",
+			}
+			// Marshal and send response.
+			reply, err := createMarshalledReply(request.ID, nil, jsonErr)
+			if err != nil {
+				rpcsLog.Errorf("Failed to marshal parse failure "+
+					"reply: %v", err)
 				continue
 			}
+			c.SendMessage(reply, nil)
+			continue
 		}
 
 		// Asynchronously handle the request.  A semaphore is used to
@@ -1439,8 +1622,9 @@ func (c *wsClient) serviceRequest(r *parsedRPCCmd) {
 
 	// Lookup the websocket extension for the command and if it doesn't
 	// exist fallback to handling the command as a standard command.
-	wsHandler, ok := wsHandlers[r.method]
-	if ok {
+	if streamHandler, ok := wsStreamHandlers[r.method]; ok {
+		result, err = streamHandler(c, r.id, r.cmd)
+	} else if wsHandler, ok := wsHandlers[r.method]; ok {
 		result, err = wsHandler(c, r.cmd)
 	} else {
 		result, err = c.server.standardCmdResult(r, nil)
@@ -1708,9 +1892,9 @@ func handleWebsocketHelp(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	// handlers since help should only be provided for those cases.
 	valid := true
 	if _, ok := rpcHandlers[command]; !ok {
-		if _, ok := wsHandlers[command]; !ok {
-			valid = false
-		}
+		_, isWS := wsHandlers[command]
+		_, isStream := wsStreamHandlers[command]
+		valid = isWS || isStream
 	}
 	if !valid {
 		return nil, &hcashjson.RPCError{
@@ -1733,20 +1917,9 @@ func handleWebsocketHelp(wsc *wsClient, icmd interface{}) (interface{}, error) {
 func handleLoadTxFilter(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	cmd := icmd.(*hcashjson.LoadTxFilterCmd)
 
-	outPoints := make([]*wire.OutPoint, len(cmd.OutPoints))
-	for i := range cmd.OutPoints {
-		hash, err := chainhash.NewHashFromStr(cmd.OutPoints[i].Hash)
-		if err != nil {
-			return nil, &hcashjson.RPCError{
-				Code:    hcashjson.ErrRPCInvalidParameter,
-				Message: err.Error(),
-			}
-		}
-		outPoints[i] = &wire.OutPoint{
-			Hash:  *hash,
-			Index: cmd.OutPoints[i].Index,
-			Tree:  cmd.OutPoints[i].Tree,
-		}
+	outPoints, err := deserializeOutPoints(cmd.OutPoints)
+	if err != nil {
+		return nil, err
 	}
 
 	wsc.Lock()
@@ -1770,6 +1943,124 @@ func handleLoadTxFilter(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	return nil, nil
 }
 
+// deserializeOutPoints converts the passed JSON outpoints to outpoints.
+func deserializeOutPoints(serializedOuts []hcashjson.OutPoint) ([]*wire.OutPoint, error) {
+	outPoints := make([]*wire.OutPoint, len(serializedOuts))
+	for i := range serializedOuts {
+		hash, err := chainhash.NewHashFromStr(serializedOuts[i].Hash)
+		if err != nil {
+			return nil, &hcashjson.RPCError{
+				Code:    hcashjson.ErrRPCInvalidParameter,
+				Message: err.Error(),
+			}
+		}
+		outPoints[i] = &wire.OutPoint{
+			Hash:  *hash,
+			Index: serializedOuts[i].Index,
+			Tree:  serializedOuts[i].Tree,
+		}
+	}
+	return outPoints, nil
+}
+
+// notificationFilter returns the filter of the addresses and outpoints the
+// client registered for with the notifyreceived and notifyspent commands,
+// creating it when the client has not registered for any yet.
+func (c *wsClient) notificationFilter() *wsClientFilter {
+	c.Lock()
+	defer c.Unlock()
+	if c.ntfnFilter == nil {
+		c.ntfnFilter = makeWSClientFilter(nil, nil)
+	}
+	return c.ntfnFilter
+}
+
+// handleNotifyReceived implements the notifyreceived command extension for
+// websocket connections.
+func handleNotifyReceived(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*hcashjson.NotifyReceivedCmd)
+
+	// Decode the addresses up front so that invalid addresses are reported
+	// instead of silently never matching.
+	addrs := make([]hcashutil.Address, 0, len(cmd.Addresses))
+	for _, addrStr := range cmd.Addresses {
+		addr, err := hcashutil.DecodeAddress(addrStr)
+		if err != nil {
+			return nil, rpcAddressKeyError("Invalid address %v: %v",
+				addrStr, err)
+		}
+		if !addr.IsForNet(wsc.server.server.chainParams) {
+			return nil, rpcAddressKeyError("Wrong network: %v",
+				addr)
+		}
+		addrs = append(addrs, addr)
+	}
+
+	filter := wsc.notificationFilter()
+	filter.mu.Lock()
+	for _, addr := range addrs {
+		filter.addAddress(addr)
+	}
+	filter.mu.Unlock()
+
+	return nil, nil
+}
+
+// handleStopNotifyReceived implements the stopnotifyreceived command extension
+// for websocket connections.
+func handleStopNotifyReceived(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*hcashjson.StopNotifyReceivedCmd)
+
+	filter := wsc.notificationFilter()
+	filter.mu.Lock()
+	for _, addrStr := range cmd.Addresses {
+		filter.removeAddressStr(addrStr)
+	}
+	filter.mu.Unlock()
+
+	return nil, nil
+}
+
+// handleNotifySpent implements the notifyspent command extension for
+// websocket connections.
+func handleNotifySpent(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*hcashjson.NotifySpentCmd)
+
+	outPoints, err := deserializeOutPoints(cmd.OutPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := wsc.notificationFilter()
+	filter.mu.Lock()
+	for _, op := range outPoints {
+		filter.addUnspentOutPoint(op)
+	}
+	filter.mu.Unlock()
+
+	return nil, nil
+}
+
+// handleStopNotifySpent implements the stopnotifyspent command extension for
+// websocket connections.
+func handleStopNotifySpent(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*hcashjson.StopNotifySpentCmd)
+
+	outPoints, err := deserializeOutPoints(cmd.OutPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := wsc.notificationFilter()
+	filter.mu.Lock()
+	for _, op := range outPoints {
+		filter.removeUnspentOutPoint(op)
+	}
+	filter.mu.Unlock()
+
+	return nil, nil
+}
+
 // handleNotifyBlocks implements the notifyblocks command extension for
 // websocket connections.
 func handleNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
@@ -1984,6 +2275,200 @@ func handleRescan(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	return &hcashjson.RescanResult{DiscoveredData: discoveredData}, nil
 }
 
+// wsResultStream sends the elements of the result of a streaming request to a
+// websocket client as chunks of streamdata notifications.  The chunks are sent
+// through the same channel as the replies to requests, so they arrive before
+// the reply to the streaming request, and each chunk is written to the
+// connection before the next one is created, so at most one chunk of the
+// result is held in memory at a time.  It implements the rpcResultStream
+// interface.
+type wsResultStream struct {
+	wsc       *wsClient
+	id        json.RawMessage
+	chunkSize int
+	items     []json.RawMessage
+	chunks    int
+	numItems  int
+}
+
+// newWSResultStream returns a new stream of the result of the request with the
+// passed id which sends chunks of the passed number of elements.  A nil chunk
+// size selects the default of 100 elements.
+func newWSResultStream(wsc *wsClient, id interface{}, chunkSize *int) (*wsResultStream, error) {
+	size := 100
+	if chunkSize != nil {
+		size = *chunkSize
+	}
+	if size < 1 || size > maxStreamChunkSize {
+		return nil, rpcInvalidError("Chunk size %d is not between 1 "+
+			"and %d", size, maxStreamChunkSize)
+	}
+	marshalledID, err := json.Marshal(id)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Failed to marshal request id")
+	}
+	return &wsResultStream{
+		wsc:       wsc,
+		id:        marshalledID,
+		chunkSize: size,
+		items:     make([]json.RawMessage, 0, size),
+	}, nil
+}
+
+// Send adds the passed element of the result to the current chunk and sends
+// the chunk once it is full.  It returns ErrClientQuit when the client
+// disconnected.
+//
+// This is part of the rpcResultStream interface implementation.
+func (s *wsResultStream) Send(elem interface{}) error {
+	marshalled, err := json.Marshal(elem)
+	if err != nil {
+		return rpcInternalError(err.Error(), "Failed to marshal result")
+	}
+	s.items = append(s.items, marshalled)
+	s.numItems++
+	if len(s.items) < s.chunkSize {
+		return nil
+	}
+	return s.flush()
+}
+
+// flush sends the current chunk when it contains any elements and waits until
+// it is written to the connection.
+func (s *wsResultStream) flush() error {
+	if len(s.items) == 0 {
+		return nil
+	}
+	ntfn := hcashjson.NewStreamDataNtfn(s.id, s.chunks, s.items)
+	marshalled, err := hcashjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		return rpcInternalError(err.Error(), "Failed to marshal chunk")
+	}
+	done := make(chan bool, 1)
+	s.wsc.SendMessage(marshalled, done)
+	select {
+	case sent := <-done:
+		if !sent {
+			return ErrClientQuit
+		}
+	case <-s.wsc.quit:
+		return ErrClientQuit
+	}
+	s.chunks++
+	s.items = s.items[:0]
+	return nil
+}
+
+// finish sends the last chunk of the stream and returns the summary of the
+// stream for the reply to the request.
+func (s *wsResultStream) finish() (*hcashjson.StreamResult, error) {
+	if err := s.flush(); err != nil {
+		return nil, err
+	}
+	return &hcashjson.StreamResult{
+		Chunks: s.chunks,
+		Items:  s.numItems,
+	}, nil
+}
+
+// handleGetBlockStream implements the getblockstream command extension for
+// websocket connections.  It streams the verbose transactions of a block
+// instead of including them in the reply like getblock does.
+func handleGetBlockStream(wsc *wsClient, id interface{}, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*hcashjson.GetBlockStreamCmd)
+	if !ok {
+		return nil, hcashjson.ErrRPCInternal
+	}
+
+	stream, err := newWSResultStream(wsc, id, cmd.ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	verbose := true
+	result, err := getBlock(wsc.server, &hcashjson.GetBlockCmd{
+		Hash:      cmd.Hash,
+		Verbose:   &verbose,
+		VerboseTx: &verbose,
+	}, stream)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := stream.finish()
+	if err != nil {
+		return nil, err
+	}
+
+	return &hcashjson.GetBlockStreamResult{
+		Block:  result.(hcashjson.GetBlockVerboseResult),
+		Stream: *summary,
+	}, nil
+}
+
+// handleSearchRawTransactionsStream implements the searchrawtransactionsstream
+// command extension for websocket connections.  It streams the transactions
+// searchrawtransactions would return and replies with the summary of the
+// stream.
+func handleSearchRawTransactionsStream(wsc *wsClient, id interface{}, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*hcashjson.SearchRawTransactionsStreamCmd)
+	if !ok {
+		return nil, hcashjson.ErrRPCInternal
+	}
+
+	stream, err := newWSResultStream(wsc, id, cmd.ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	_, err = searchRawTransactions(wsc.server,
+		&hcashjson.SearchRawTransactionsCmd{
+			Address:     cmd.Address,
+			Verbose:     cmd.Verbose,
+			Skip:        cmd.Skip,
+			Count:       cmd.Count,
+			VinExtra:    cmd.VinExtra,
+			Reverse:     cmd.Reverse,
+			FilterAddrs: cmd.FilterAddrs,
+		}, stream)
+	if err != nil {
+		return nil, err
+	}
+	return stream.finish()
+}
+
+// handleWebsocketVerifyChain implements the verifychain command extension for
+// websocket connections.  It verifies the chain like verifychain does over
+// HTTP and additionally sends a verifychainprogress notification carrying the
+// id of the request after each block is verified.
+func handleWebsocketVerifyChain(wsc *wsClient, id interface{}, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*hcashjson.VerifyChainCmd)
+	if !ok {
+		return nil, hcashjson.ErrRPCInternal
+	}
+
+	marshalledID, err := json.Marshal(id)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Failed to marshal request id")
+	}
+	progress := func(p *blockchain.VerifyProgress) {
+		ntfn := hcashjson.NewVerifyChainProgressNtfn(marshalledID,
+			int64(p.Level), p.Height, p.Hash.String(), p.Checked,
+			p.Total)
+		marshalled, err := hcashjson.MarshalCmd(nil, ntfn)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal verifychainprogress "+
+				"notification: %v", err)
+			return
+		}
+		wsc.SendMessage(marshalled, nil)
+	}
+
+	checkLevel, checkDepth := verifyChainParams(cmd)
+	err = verifyChain(wsc.server, checkLevel, checkDepth, progress,
+		wsc.quit)
+	return err == nil, nil
+}
+
 func init() {
 	wsHandlers = wsHandlersBeforeInit
 }
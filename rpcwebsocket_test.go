@@ -0,0 +1,98 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/blockchain"
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/btcsuite/btclog"
+)
+
+// TestRemoveClientTicketNotifications ensures removing a websocket client also
+// drops its ticket notification registrations, while the registrations of the
+// other clients are kept.
+func TestRemoveClientTicketNotifications(t *testing.T) {
+	// The chain logs through its subsystem, which has no log rotator to
+	// write to in tests.
+	for _, logger := range subsystemLoggers {
+		defer logger.SetLevel(logger.Level())
+		logger.SetLevel(btclog.LevelOff)
+	}
+
+	chain, teardownFunc, err := blockchain.SetupTestChain("wsntfn",
+		&chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+	stakeDiff, err := chain.CalcNextRequiredStakeDifficulty()
+	if err != nil {
+		t.Fatalf("CalcNextRequiredStakeDifficulty: %v", err)
+	}
+
+	m := newWsNotificationManager(&rpcServer{chain: chain})
+	m.Start()
+	defer m.WaitForShutdown()
+	defer m.Shutdown()
+
+	const numNtfns = 4
+	newClient := func() *wsClient {
+		wsc := &wsClient{
+			quit:     make(chan struct{}),
+			ntfnChan: make(chan []byte, numNtfns),
+		}
+		m.AddClient(wsc)
+		m.RegisterWinningTickets(wsc)
+		m.RegisterSpentAndMissedTickets(wsc)
+		m.RegisterNewTickets(wsc)
+		m.RegisterStakeDifficulty(wsc)
+		return wsc
+	}
+	removed := newClient()
+	kept := newClient()
+	m.RemoveClient(removed)
+
+	// The clients have no connection to close when the manager disconnects
+	// them on shutdown.
+	defer func() {
+		kept.Lock()
+		kept.disconnected = true
+		kept.Unlock()
+	}()
+
+	tickets := []chainhash.Hash{{0x01}}
+	m.NotifyWinningTickets(&WinningTicketsNtfnData{Tickets: tickets})
+	m.NotifySpentAndMissedTickets(&blockchain.TicketNotificationsData{
+		TicketsSpent: tickets,
+	})
+	m.NotifyNewTickets(&blockchain.TicketNotificationsData{
+		TicketsNew: tickets,
+	})
+	m.NotifyStakeDifficulty(&StakeDifficultyNtfnData{
+		StakeDifficulty: stakeDiff + 1,
+	})
+
+	// All but the last notification were sent to every registered client
+	// once the kept client received all of them, so a removed client which
+	// is still registered has received some of them as well.
+	for i := 0; i < numNtfns; i++ {
+		select {
+		case <-kept.ntfnChan:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("kept client: got %d notifications, want %d", i,
+				numNtfns)
+		}
+	}
+	if n := len(removed.ntfnChan); n != 0 {
+		t.Fatalf("removed client: got %d notifications, want 0", n)
+	}
+	if n := m.NumClients(); n != 1 {
+		t.Fatalf("NumClients: got %d, want 1", n)
+	}
+}
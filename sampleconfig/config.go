@@ -0,0 +1,593 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sampleconfig
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HcashOrg/hcashd/appdata"
+	"github.com/HcashOrg/hcashd/blockchain"
+)
+
+const (
+	defaultConfigFilename        = "hcashd.conf"
+	defaultDataDirname           = "data"
+	defaultLogLevel              = "info"
+	defaultLogDirname            = "logs"
+	defaultLogFormat             = logFormatText
+	defaultLogMaxSize            = 10
+	defaultLogMaxRolls           = 3
+	defaultMaxPeers              = 125
+	defaultBanDuration           = time.Hour * 24
+	defaultBanThreshold          = 100
+	defaultMaxRPCClients         = 10
+	defaultMaxRPCWebsockets      = 25
+	defaultMaxRPCSSEClients      = 25
+	defaultMaxRPCConcurrentReqs  = 20
+	defaultMaxGRPCStreams        = 25
+	defaultDbType                = "ffldb"
+	defaultMinRelayTxFee         = 0.001
+	defaultFreeTxRelayLimit      = 15.0
+	defaultPeerTxRelayLimit      = 1000.0
+	defaultPeerFreeTxRelayLimit  = 5.0
+	defaultBlockMinSize          = 0
+	defaultBlockMaxSize          = 2000000
+	defaultBlockPrioritySize     = 20000
+	defaultCPUMinerThreads       = 1
+	defaultMaxOrphanTransactions = 1000
+	defaultMaxMempoolSize        = 300 * 1000 * 1000
+	defaultSigCacheMaxSize       = 100000
+	defaultPruneDepth            = blockchain.MinPruneDepth
+	defaultColdDepth             = 8640
+	minPruneTarget               = 1024
+
+	logFormatText = "text"
+	logFormatJSON = "json"
+
+	// envPrefix is the prefix of the names of the environment variables
+	// which override the options.  The rest of the name is the upper case
+	// long name of the option, for example HCASHD_RPCUSER.
+	envPrefix = "HCASHD_"
+)
+
+// Config defines the configuration options for hcashd.  The long names of
+// the options are the keys of the configuration file and the names of the
+// command line flags.
+//
+// The zero value is not a usable configuration.  Use DefaultConfig to obtain
+// one with the same defaults as hcashd.
+type Config struct {
+	HomeDir              string        `short:"A" long:"appdata" description:"Path to application home directory"`
+	ShowVersion          bool          `short:"V" long:"version" description:"Display version information and exit"`
+	ConfigFile           string        `short:"C" long:"configfile" description:"Path to configuration file"`
+	DataDir              string        `short:"b" long:"datadir" description:"Directory to store data"`
+	LogDir               string        `long:"logdir" description:"Directory to log output."`
+	LogFormat            string        `long:"logformat" description:"Format of the log output {text, json} -- The json format writes each entry as a JSON object with the time, level, subsystem and message along with the peer, hash and height the message refers to"`
+	LogMaxSize           int64         `long:"logmaxsize" description:"Maximum size in MiB of a log file before it is rotated"`
+	LogMaxRolls          int           `long:"logmaxrolls" description:"Maximum number of rotated log files to keep -- 0 keeps all of them"`
+	LogSeparate          []string      `long:"logseparate" description:"Add a subsystem which is logged to a separate, independently rotated, log file named after it in the log directory instead of the main log file"`
+	AddPeers             []string      `short:"a" long:"addpeer" description:"Add a peer to connect with at startup"`
+	ConnectPeers         []string      `long:"connect" description:"Connect only to the specified peers at startup"`
+	DisableListen        bool          `long:"nolisten" description:"Disable listening for incoming connections -- NOTE: Listening is automatically disabled if the --connect or --proxy options are used without also specifying listen interfaces via --listen"`
+	Listeners            []string      `long:"listen" description:"Add an interface/port to listen for connections (default all interfaces port: 14008, testnet: 14008)"`
+	MaxPeers             int           `long:"maxpeers" description:"Max number of inbound and outbound peers"`
+	DisableBanning       bool          `long:"nobanning" description:"Disable banning of misbehaving peers"`
+	BanDuration          time.Duration `long:"banduration" description:"How long to ban misbehaving peers.  Valid time units are {s, m, h}.  Minimum 1 second"`
+	BanThreshold         uint32        `long:"banthreshold" description:"Maximum allowed ban score before disconnecting and banning misbehaving peers."`
+	Whitelists           []string      `long:"whitelist" description:"Add an IP network or IP that will not be banned. (eg. 192.168.1.0/24 or ::1)"`
+	RPCUser              string        `short:"u" long:"rpcuser" description:"Username for RPC connections"`
+	RPCPass              string        `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
+	RPCLimitUser         string        `long:"rpclimituser" description:"Username for limited RPC connections"`
+	RPCLimitPass         string        `long:"rpclimitpass" default-mask:"-" description:"Password for limited RPC connections"`
+	RPCLimitMethods      []string      `long:"rpclimitmethod" description:"Add an RPC method to the methods available to limited RPC connections -- Replaces the default set of methods which do not change the state of the server when specified"`
+	RPCListeners         []string      `long:"rpclisten" description:"Add an interface/port to listen for RPC connections (default port: 14009, testnet: 12009)"`
+	RPCCert              string        `long:"rpccert" description:"File containing the certificate file"`
+	RPCKey               string        `long:"rpckey" description:"File containing the certificate key"`
+	RPCClientCA          string        `long:"rpcclientca" description:"File containing the certificates of the authorities which sign the client certificates accepted for RPC connections -- Clients presenting a valid certificate are authenticated as the admin user without a username and password"`
+	AttestationKey       string        `long:"attestationkey" description:"File containing the key used to sign key block attestations, which is generated when it does not exist (default: attestation.key in the data directory)"`
+	RPCMaxClients        int           `long:"rpcmaxclients" description:"Max number of RPC clients for standard connections"`
+	RPCMaxWebsockets     int           `long:"rpcmaxwebsockets" description:"Max number of RPC websocket connections"`
+	RPCSSE               bool          `long:"rpcsse" description:"Enable the server-sent events endpoint (/events) of the RPC server"`
+	RPCSSEOrigins        []string      `long:"rpcsseorigin" description:"Add an origin allowed to make cross-origin requests to the server-sent events endpoint, or * to allow any origin"`
+	RPCMaxSSEClients     int           `long:"rpcmaxsseclients" description:"Max number of RPC server-sent events connections"`
+	RPCREST              bool          `long:"rpcrest" description:"Enable the unauthenticated read-only REST endpoints (/rest/) of the RPC server"`
+	RPCMaxConcurrentReqs int           `long:"rpcmaxconcurrentreqs" description:"Max number of concurrent RPC requests that may be processed concurrently"`
+	GRPCListeners        []string      `long:"grpclisten" description:"Add an interface/port to listen for gRPC connections, which enables the gRPC server (default port: 14019, testnet: 12019)"`
+	GRPCTokenDir         string        `long:"grpctokendir" description:"Directory to store the root key and the authentication tokens of the gRPC server in (default: grpc in the data directory)"`
+	GRPCMaxStreams       int           `long:"grpcmaxstreams" description:"Max number of gRPC notification streams"`
+	DisableRPC           bool          `long:"norpc" description:"Disable built-in RPC server -- NOTE: The RPC server is disabled by default if no rpcuser/rpcpass, rpclimituser/rpclimitpass or rpcclientca is specified"`
+	DisableTLS           bool          `long:"notls" description:"Disable TLS for the RPC server -- NOTE: This is only allowed if the RPC server is bound to localhost"`
+	DisableDNSSeed       bool          `long:"nodnsseed" description:"Disable DNS seeding for peers"`
+	ExternalIPs          []string      `long:"externalip" description:"Add an ip to the list of local addresses we claim to listen on to peers"`
+	Proxy                string        `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
+	ProxyUser            string        `long:"proxyuser" description:"Username for proxy server"`
+	ProxyPass            string        `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
+	OnionProxy           string        `long:"onion" description:"Connect to tor hidden services via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
+	OnionProxyUser       string        `long:"onionuser" description:"Username for onion proxy server"`
+	OnionProxyPass       string        `long:"onionpass" default-mask:"-" description:"Password for onion proxy server"`
+	NoOnion              bool          `long:"noonion" description:"Disable connecting to tor hidden services"`
+	TorIsolation         bool          `long:"torisolation" description:"Enable Tor stream isolation by randomizing user credentials for each connection."`
+	TestNet              bool          `long:"testnet" description:"Use the test network"`
+	SimNet               bool          `long:"simnet" description:"Use the simulation test network"`
+	DisableCheckpoints   bool          `long:"nocheckpoints" description:"Disable built-in checkpoints.  Don't do this unless you know what you're doing."`
+	DbType               string        `long:"dbtype" description:"Database backend to use for the Block Chain"`
+	DBCheck              bool          `long:"dbcheck" description:"Verify the integrity of all stored blocks, rebuild the block index to exclude corrupt or missing blocks, compact the database on start up and then exit."`
+	Prune                uint          `long:"prune" description:"Delete the data of old blocks which are before the latest checkpoint until the block data is at most the given size in MiB -- Headers and the utxo set are retained, 0 to disable, minimum 1024"`
+	PruneDepth           uint          `long:"prunedepth" description:"The number of key blocks below the best chain tip whose data is never pruned"`
+	ColdDataDir          string        `long:"colddatadir" description:"Directory to move the data of old blocks to, such as one on slower and cheaper storage than the data directory -- Moved blocks are still served"`
+	ColdDepth            uint          `long:"colddepth" description:"The number of key blocks below the best chain tip whose data is kept in the data directory when --colddatadir is set"`
+	MaxReorgDepth        uint          `long:"maxreorgdepth" description:"Reject blocks which would cause a reorganization that disconnects more than the given number of blocks from the main chain -- 0 to disable"`
+	Profile              string        `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
+	MetricsListeners     []string      `long:"metricslisten" description:"Add an interface:port to serve the Prometheus metrics endpoint (/metrics) on, which enables it -- NOTE: The endpoint is not authenticated"`
+	CPUProfile           string        `long:"cpuprofile" description:"Write CPU profile to the specified file"`
+	MemProfile           string        `long:"memprofile" description:"Write mem profile to the specified file"`
+	DumpBlockchain       string        `long:"dumpblockchain" description:"Write blockchain as a flat file of blocks for use with addblock, to the specified filename"`
+	MiningTimeOffset     int           `long:"miningtimeoffset" description:"Offset the mining timestamp of a block by this many seconds (positive values are in the past)"`
+	DebugLevel           string        `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
+	Upnp                 bool          `long:"upnp" description:"Use UPnP to map our listening port outside of NAT"`
+	MinRelayTxFee        float64       `long:"minrelaytxfee" description:"The minimum transaction fee in HCASH/kB to be considered a non-zero fee."`
+	FreeTxRelayLimit     float64       `long:"limitfreerelay" description:"Limit relay of transactions with no transaction fee to the given amount in thousands of bytes per minute"`
+	PeerTxRelayLimit     float64       `long:"limitpeerrelay" description:"Limit the transactions accepted from a single peer to the given amount in thousands of bytes per minute -- 0 to disable"`
+	PeerFreeTxRelayLimit float64       `long:"limitpeerfreerelay" description:"Limit the free and low-fee transactions accepted from a single peer to the given amount in thousands of bytes per minute -- 0 to disable"`
+	NoRelayPriority      bool          `long:"norelaypriority" description:"Do not require free or low-fee transactions to have high priority for relaying"`
+	MaxOrphanTxs         int           `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
+	PersistMempool       bool          `long:"persistmempool" description:"Save the transactions in the memory pool, except votes, to the data directory on shutdown and restore them on startup"`
+	MaxMempoolSize       int64         `long:"maxmempoolsize" description:"Maximum size in bytes of the transactions in the memory pool -- The transactions paying the lowest fee rates are evicted once it is exceeded -- 0 to disable"`
+	Generate             bool          `long:"generate" description:"Generate (mine) coins using the CPU"`
+	MiningAddrs          []string      `long:"miningaddr" description:"Add the specified payment address to the list of addresses to use for generated blocks -- At least one address is required if the generate option is set"`
+	MiningPayouts        []string      `long:"miningpayout" description:"Split the coinbase work reward of generated blocks across multiple addresses by weight -- Specify as address:weight, one address per option"`
+	MinerCPUBudget       float64       `long:"minercpubudget" description:"Tune the number of active CPU mining threads so hcashd uses at most the given percentage of the total CPU capacity -- 0 to disable"`
+	CPUMinerThreads      uint32        `long:"cpuminerthreads" description:"The number of CPU mining threads to use unless the number is specified via the setgenerate RPC"`
+	BlockMinSize         uint32        `long:"blockminsize" description:"Mininum block size in bytes to be used when creating a block"`
+	BlockMaxSize         uint32        `long:"blockmaxsize" description:"Maximum block size in bytes to be used when creating a block"`
+	BlockPrioritySize    uint32        `long:"blockprioritysize" description:"Size in bytes for high-priority/low-fee transactions when creating a block"`
+	GetWorkKeys          []string      `long:"getworkkey" description:"DEPRECATED -- Use the --miningaddr option instead"`
+	NoPeerBloomFilters   bool          `long:"nopeerbloomfilters" description:"Disable bloom filtering support"`
+	NoLightBlocks        bool          `long:"nolightblocks" description:"Disable support for light blocks, which announce new blocks without the transactions peers already have"`
+	MinProtocolVersion   uint32        `long:"minprotocolversion" description:"Reject peers advertising a protocol version below the given version"`
+	SigCacheMaxSize      uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
+	SigVerifyConcurrency uint          `long:"sigverifyconcurrency" description:"The maximum number of goroutines used to validate the input scripts of a block -- 0 uses a default based on the number of processor cores"`
+	SigHashOptimization  bool          `long:"sighashoptimization" description:"EXPERIMENTAL: Reuse the cached transaction hash when verifying the SIGHASH_ALL signatures of blocks -- Do not enable when mining or running a wallet with the node"`
+	CheckDuplicateHashes bool          `long:"checkduplicatehashes" description:"Reject blocks with transactions that overwrite unspent transactions with the same hash -- The height committed to by coinbases should make such duplicates impossible"`
+	NonAggressive        bool          `long:"nonaggressive" description:"Disable mining off of the parent block of the blockchain if there aren't enough voters"`
+	NoMiningStateSync    bool          `long:"nominingstatesync" description:"Disable synchronizing the mining state with other nodes"`
+	AllowOldVotes        bool          `long:"allowoldvotes" description:"Enable the addition of very old votes to the mempool"`
+	AutoRevoke           bool          `long:"autorevoke" description:"Automatically create and broadcast revocations for missed and expired tickets whose voting script was registered with the addrevocationscript RPC"`
+	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
+	RelayNonStd          bool          `long:"relaynonstd" description:"Relay non-standard transactions regardless of the default settings for the active network."`
+	RejectNonStd         bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
+	TxIndex              bool          `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
+	DropTxIndex          bool          `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
+	AddrIndex            bool          `long:"addrindex" description:"Maintain a full address-based transaction index which makes the searchrawtransactions RPC available"`
+	DropAddrIndex        bool          `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
+	NoExistsAddrIndex    bool          `long:"noexistsaddrindex" description:"Disable the exists address index, which tracks whether or not an address has even been used."`
+	DropExistsAddrIndex  bool          `long:"dropexistsaddrindex" description:"Deletes the exists address index from the database on start up and then exits."`
+	CfIndex              bool          `long:"cfindex" description:"Maintain a committed filter index for every block which makes the getcfilter and getcfheaders RPCs available to light clients"`
+	DropCfIndex          bool          `long:"dropcfindex" description:"Deletes the committed filter index from the database on start up and then exits."`
+	VoteStatsIndex       bool          `long:"votestatsindex" description:"Maintain vote statistics for every voting address which makes the getvotestats RPC available"`
+	DropVoteStatsIndex   bool          `long:"dropvotestatsindex" description:"Deletes the vote stats index from the database on start up and then exits."`
+	PipeRx               uint          `long:"piperx" description:"File descriptor of read end pipe to enable parent -> child process communication"`
+	PipeTx               uint          `long:"pipetx" description:"File descriptor of write end pipe to enable parent <- child process communication"`
+	LifetimeEvents       bool          `long:"lifetimeevents" description:"Send lifetime notifications over the TX pipe"`
+}
+
+// DefaultConfig returns a configuration with the defaults hcashd uses for the
+// options which are not specified.  The paths are in the default application
+// data directory of hcashd.
+func DefaultConfig() *Config {
+	homeDir := appdata.Dir("hcashd", false)
+	return &Config{
+		HomeDir:              homeDir,
+		ConfigFile:           filepath.Join(homeDir, defaultConfigFilename),
+		DebugLevel:           defaultLogLevel,
+		MaxPeers:             defaultMaxPeers,
+		BanDuration:          defaultBanDuration,
+		BanThreshold:         defaultBanThreshold,
+		RPCMaxClients:        defaultMaxRPCClients,
+		RPCMaxWebsockets:     defaultMaxRPCWebsockets,
+		RPCMaxSSEClients:     defaultMaxRPCSSEClients,
+		RPCMaxConcurrentReqs: defaultMaxRPCConcurrentReqs,
+		GRPCMaxStreams:       defaultMaxGRPCStreams,
+		DataDir:              filepath.Join(homeDir, defaultDataDirname),
+		LogDir:               filepath.Join(homeDir, defaultLogDirname),
+		LogFormat:            defaultLogFormat,
+		LogMaxSize:           defaultLogMaxSize,
+		LogMaxRolls:          defaultLogMaxRolls,
+		DbType:               defaultDbType,
+		RPCKey:               filepath.Join(homeDir, "rpc.key"),
+		RPCCert:              filepath.Join(homeDir, "rpc.cert"),
+		MinRelayTxFee:        defaultMinRelayTxFee,
+		FreeTxRelayLimit:     defaultFreeTxRelayLimit,
+		PeerTxRelayLimit:     defaultPeerTxRelayLimit,
+		PeerFreeTxRelayLimit: defaultPeerFreeTxRelayLimit,
+		BlockMinSize:         defaultBlockMinSize,
+		BlockMaxSize:         defaultBlockMaxSize,
+		BlockPrioritySize:    defaultBlockPrioritySize,
+		MaxOrphanTxs:         defaultMaxOrphanTransactions,
+		MaxMempoolSize:       defaultMaxMempoolSize,
+		SigCacheMaxSize:      defaultSigCacheMaxSize,
+		CPUMinerThreads:      defaultCPUMinerThreads,
+		PruneDepth:           defaultPruneDepth,
+		ColdDepth:            defaultColdDepth,
+	}
+}
+
+// ParseWhitelists returns the IP networks of the whitelist option.  Single IP
+// addresses are returned as networks which only contain them.
+func (c *Config) ParseWhitelists() ([]*net.IPNet, error) {
+	if len(c.Whitelists) == 0 {
+		return nil, nil
+	}
+	whitelists := make([]*net.IPNet, 0, len(c.Whitelists))
+	for _, addr := range c.Whitelists {
+		_, ipnet, err := net.ParseCIDR(addr)
+		if err != nil {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				str := "the whitelist value of '%s' is invalid"
+				return nil, fmt.Errorf(str, addr)
+			}
+			var bits int
+			if ip.To4() == nil {
+				// IPv6
+				bits = 128
+			} else {
+				bits = 32
+			}
+			ipnet = &net.IPNet{
+				IP:   ip,
+				Mask: net.CIDRMask(bits, bits),
+			}
+		}
+		whitelists = append(whitelists, ipnet)
+	}
+	return whitelists, nil
+}
+
+// Validate returns an error describing the first invalid option or
+// combination of options of the configuration.
+//
+// Only the checks which do not depend on the network or on the state of hcashd
+// are performed, so hcashd may still reject a configuration which passes, for
+// example one with a mining address of another network, an unknown database
+// type or an unknown debug level subsystem.
+func (c *Config) Validate() error {
+	// Multiple networks can't be selected simultaneously.
+	if c.TestNet && c.SimNet {
+		return errors.New("the testnet and simnet params can't be " +
+			"used together -- choose one of the three")
+	}
+
+	if c.RelayNonStd && c.RejectNonStd {
+		return errors.New("rejectnonstd and relaynonstd cannot be " +
+			"used together -- choose only one")
+	}
+
+	// Validate the log format and rotation options.
+	if c.LogFormat != logFormatText && c.LogFormat != logFormatJSON {
+		str := "the specified log format [%v] is invalid -- " +
+			"supported formats %v"
+		return fmt.Errorf(str, c.LogFormat,
+			[]string{logFormatText, logFormatJSON})
+	}
+	if c.LogMaxSize <= 0 {
+		str := "the logmaxsize option must be greater than 0 -- " +
+			"parsed [%d]"
+		return fmt.Errorf(str, c.LogMaxSize)
+	}
+	if c.LogMaxRolls < 0 {
+		str := "the logmaxrolls option may not be less than 0 -- " +
+			"parsed [%d]"
+		return fmt.Errorf(str, c.LogMaxRolls)
+	}
+
+	// Validate the metrics listen addresses, which have no default port.
+	for _, addr := range c.MetricsListeners {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			str := "metrics listen interface '%s' is invalid: %v"
+			return fmt.Errorf(str, addr, err)
+		}
+	}
+
+	// Validate profile port number
+	if c.Profile != "" {
+		profilePort, err := strconv.Atoi(c.Profile)
+		if err != nil || profilePort < 1024 || profilePort > 65535 {
+			return errors.New("The profile port must be between " +
+				"1024 and 65535")
+		}
+	}
+
+	// Don't allow ban durations that are too short.
+	if c.BanDuration < time.Second {
+		str := "the banduration option may not be less than 1s -- " +
+			"parsed [%v]"
+		return fmt.Errorf(str, c.BanDuration)
+	}
+
+	// Validate any given whitelisted IP addresses and networks.
+	if _, err := c.ParseWhitelists(); err != nil {
+		return err
+	}
+
+	// --addPeer and --connect do not mix.
+	if len(c.AddPeers) > 0 && len(c.ConnectPeers) > 0 {
+		return errors.New("the --addpeer and --connect options can " +
+			"not be mixed")
+	}
+
+	// Check to make sure limited and admin users don't have the same
+	// username and password.
+	if c.RPCUser == c.RPCLimitUser && c.RPCUser != "" {
+		return errors.New("--rpcuser and --rpclimituser must not " +
+			"specify the same username")
+	}
+	if c.RPCPass == c.RPCLimitPass && c.RPCPass != "" {
+		return errors.New("--rpcpass and --rpclimitpass must not " +
+			"specify the same password")
+	}
+
+	// The RPC client certificate authorities may only be used with TLS.
+	if c.RPCClientCA != "" && c.DisableTLS {
+		return errors.New("the --rpcclientca and --notls options may " +
+			"not be used together")
+	}
+
+	if c.RPCMaxConcurrentReqs < 0 {
+		str := "the rpcmaxwebsocketconcurrentrequests option may not " +
+			"be less than 0 -- parsed [%d]"
+		return fmt.Errorf(str, c.RPCMaxConcurrentReqs)
+	}
+
+	// Limit the max orphan count to a sane vlue.
+	if c.MaxOrphanTxs < 0 {
+		str := "the maxorphantx option may not be less than 0 -- " +
+			"parsed [%d]"
+		return fmt.Errorf(str, c.MaxOrphanTxs)
+	}
+
+	// The maximum mempool size may not be negative.
+	if c.MaxMempoolSize < 0 {
+		str := "the maxmempoolsize option may not be less than 0 -- " +
+			"parsed [%d]"
+		return fmt.Errorf(str, c.MaxMempoolSize)
+	}
+
+	// Indexes which are maintained may not be dropped at the same time.
+	if c.TxIndex && c.DropTxIndex {
+		return errors.New("the --txindex and --droptxindex options " +
+			"may  not be activated at the same time")
+	}
+	if c.AddrIndex && c.DropAddrIndex {
+		return errors.New("the --addrindex and --dropaddrindex " +
+			"options may not be activated at the same time")
+	}
+	if c.AddrIndex && c.DropTxIndex {
+		return errors.New("the --addrindex and --droptxindex options " +
+			"may not be activated at the same time because the " +
+			"address index relies on the transaction index")
+	}
+	if !c.NoExistsAddrIndex && c.DropExistsAddrIndex {
+		return errors.New("dropexistsaddrindex cannot be activated " +
+			"when existsaddressindex is on (try setting " +
+			"--noexistsaddrindex)")
+	}
+	if c.CfIndex && c.DropCfIndex {
+		return errors.New("the --cfindex and --dropcfindex options " +
+			"may not be activated at the same time")
+	}
+	if c.VoteStatsIndex && c.DropVoteStatsIndex {
+		return errors.New("the --votestatsindex and " +
+			"--dropvotestatsindex options may not be activated at " +
+			"the same time")
+	}
+
+	// Validate the pruning options.  They do not mix with the indexes
+	// since the indexes make the transactions of every block available.
+	if c.Prune != 0 && c.Prune < minPruneTarget {
+		str := "the prune target must be at least %d MiB -- parsed [%d]"
+		return fmt.Errorf(str, minPruneTarget, c.Prune)
+	}
+	if c.Prune != 0 && (c.TxIndex || c.AddrIndex) {
+		return errors.New("the --prune option may not be activated " +
+			"at the same time as the --txindex or --addrindex " +
+			"options")
+	}
+
+	// Validate the cold storage options.  The in-memory database has no
+	// block files which could be moved.
+	if c.ColdDataDir != "" && c.DbType == "memdb" {
+		return errors.New("the --colddatadir option may not be used " +
+			"with the memdb database")
+	}
+	if c.ColdDataDir != "" && c.ColdDepth == 0 {
+		str := "the cold storage depth must be at least 1 -- parsed [%d]"
+		return fmt.Errorf(str, c.ColdDepth)
+	}
+
+	// Ensure there is at least one CPU mining thread.
+	if c.CPUMinerThreads == 0 {
+		return errors.New("the cpuminerthreads option must be at least 1")
+	}
+
+	// Ensure the CPU budget for mining is a valid percentage.
+	if c.MinerCPUBudget < 0 || c.MinerCPUBudget > 100 {
+		str := "the minercpubudget option must be between 0 and 100 " +
+			"-- parsed [%v]"
+		return fmt.Errorf(str, c.MinerCPUBudget)
+	}
+
+	// Ensure there is at least one mining address when the generate flag is
+	// set.
+	if c.Generate && len(c.MiningAddrs) == 0 && len(c.MiningPayouts) == 0 {
+		return errors.New("the generate flag is set, but there are no " +
+			"mining addresses specified ")
+	}
+
+	// Tor stream isolation requires either proxy or onion proxy to be set.
+	if c.TorIsolation && c.Proxy == "" && c.OnionProxy == "" {
+		return errors.New("Tor stream isolation requires either proxy " +
+			"or onionproxy to be set")
+	}
+
+	return nil
+}
+
+// EnvVar returns the name of the environment variable which overrides the
+// option with the passed long name, for example HCASHD_RPCUSER for rpcuser.
+func EnvVar(option string) string {
+	return envPrefix + strings.ToUpper(option)
+}
+
+// ApplyEnv sets the options for which an environment variable named by EnvVar
+// is set to the value of the variable.  Options which take multiple values are
+// set to the comma separated values of the variable, and boolean options
+// accept the values strconv.ParseBool does.  An error is returned for the
+// first variable with an invalid value.
+func (c *Config) ApplyEnv() error {
+	return c.applyEnv(os.LookupEnv)
+}
+
+// applyEnv is ApplyEnv with the environment variables looked up by the passed
+// function.
+func (c *Config) applyEnv(lookupEnv func(string) (string, bool)) error {
+	val := reflect.ValueOf(c).Elem()
+	for i := 0; i < val.NumField(); i++ {
+		name := val.Type().Field(i).Tag.Get("long")
+		if name == "" {
+			continue
+		}
+		env := EnvVar(name)
+		str, ok := lookupEnv(env)
+		if !ok {
+			continue
+		}
+		if err := setOption(val.Field(i), str); err != nil {
+			return fmt.Errorf("invalid value of the %s environment "+
+				"variable: %v", env, err)
+		}
+	}
+	return nil
+}
+
+// durationType is the type of the options which are durations.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setOption sets the passed option to the value parsed from the passed
+// string.
+func setOption(field reflect.Value, str string) error {
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+
+	case field.Kind() == reflect.Slice:
+		var values []string
+		if str != "" {
+			values = strings.Split(str, ",")
+		}
+		field.Set(reflect.ValueOf(values))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(str)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(str, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(str, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported option type %v", field.Type())
+	}
+	return nil
+}
+
+// formatOption returns the passed option formatted the way the configuration
+// file expects it.  Options which take multiple values return one string per
+// value.
+func formatOption(field reflect.Value) []string {
+	switch {
+	case field.Type() == durationType:
+		return []string{time.Duration(field.Int()).String()}
+
+	case field.Kind() == reflect.Slice:
+		return field.Interface().([]string)
+	}
+
+	switch field.Kind() {
+	case reflect.Bool:
+		if field.Bool() {
+			return []string{"1"}
+		}
+		return []string{"0"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(field.Int(), 10)}
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return []string{strconv.FormatUint(field.Uint(), 10)}
+	case reflect.Float64:
+		return []string{strconv.FormatFloat(field.Float(), 'f', -1, 64)}
+	}
+	return []string{field.String()}
+}
+
+// WriteConfig writes the configuration as a configuration file hcashd can
+// load.  Only the options which differ from the ones of DefaultConfig are
+// written, so the file keeps following the defaults of hcashd for the rest of
+// them.
+func (c *Config) WriteConfig(w io.Writer) error {
+	val := reflect.ValueOf(c).Elem()
+	defaultVal := reflect.ValueOf(DefaultConfig()).Elem()
+	lines := []string{"[Application Options]"}
+	for i := 0; i < val.NumField(); i++ {
+		name := val.Type().Field(i).Tag.Get("long")
+		if name == "" {
+			continue
+		}
+		if reflect.DeepEqual(val.Field(i).Interface(),
+			defaultVal.Field(i).Interface()) {
+
+			continue
+		}
+		for _, value := range formatOption(val.Field(i)) {
+			lines = append(lines, name+"="+value)
+		}
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
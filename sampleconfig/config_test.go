@@ -0,0 +1,188 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sampleconfig
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestValidate ensures the default configuration is valid and invalid options
+// and combinations of options are rejected.
+func TestValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("Validate: default config rejected: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		valid  bool
+	}{
+		{
+			name: "testnet and simnet",
+			modify: func(c *Config) {
+				c.TestNet = true
+				c.SimNet = true
+			},
+		},
+		{
+			name:   "json log format",
+			modify: func(c *Config) { c.LogFormat = logFormatJSON },
+			valid:  true,
+		},
+		{
+			name:   "unknown log format",
+			modify: func(c *Config) { c.LogFormat = "xml" },
+		},
+		{
+			name:   "short ban duration",
+			modify: func(c *Config) { c.BanDuration = time.Millisecond },
+		},
+		{
+			name: "whitelists",
+			modify: func(c *Config) {
+				c.Whitelists = []string{"192.168.1.0/24", "::1"}
+			},
+			valid: true,
+		},
+		{
+			name:   "invalid whitelist",
+			modify: func(c *Config) { c.Whitelists = []string{"host"} },
+		},
+		{
+			name:   "profile port",
+			modify: func(c *Config) { c.Profile = "80" },
+		},
+		{
+			name: "addpeer and connect",
+			modify: func(c *Config) {
+				c.AddPeers = []string{"127.0.0.1"}
+				c.ConnectPeers = []string{"127.0.0.2"}
+			},
+		},
+		{
+			name: "same rpc users",
+			modify: func(c *Config) {
+				c.RPCUser = "user"
+				c.RPCLimitUser = "user"
+			},
+		},
+		{
+			name: "prune",
+			modify: func(c *Config) {
+				c.Prune = minPruneTarget
+			},
+			valid: true,
+		},
+		{
+			name:   "small prune target",
+			modify: func(c *Config) { c.Prune = minPruneTarget - 1 },
+		},
+		{
+			name: "prune with txindex",
+			modify: func(c *Config) {
+				c.Prune = minPruneTarget
+				c.TxIndex = true
+			},
+		},
+		{
+			name:   "generate without addresses",
+			modify: func(c *Config) { c.Generate = true },
+		},
+		{
+			name: "generate",
+			modify: func(c *Config) {
+				c.Generate = true
+				c.MiningAddrs = []string{"SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc"}
+			},
+			valid: true,
+		},
+		{
+			name:   "tor isolation without proxy",
+			modify: func(c *Config) { c.TorIsolation = true },
+		},
+	}
+	for _, test := range tests {
+		c := DefaultConfig()
+		test.modify(c)
+		err := c.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: invalid config accepted", test.name)
+		}
+	}
+}
+
+// TestApplyEnv ensures the options are set from the environment variables
+// named after them.
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"HCASHD_RPCUSER":       "user",
+		"HCASHD_TESTNET":       "1",
+		"HCASHD_MAXPEERS":      "8",
+		"HCASHD_BANTHRESHOLD":  "50",
+		"HCASHD_BANDURATION":   "1h",
+		"HCASHD_LOGMAXSIZE":    "64",
+		"HCASHD_MINRELAYTXFEE": "0.01",
+		"HCASHD_ADDPEER":       "127.0.0.1,127.0.0.2",
+	}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	c := DefaultConfig()
+	if err := c.applyEnv(lookupEnv); err != nil {
+		t.Fatalf("applyEnv: unexpected error: %v", err)
+	}
+	want := DefaultConfig()
+	want.RPCUser = "user"
+	want.TestNet = true
+	want.MaxPeers = 8
+	want.BanThreshold = 50
+	want.BanDuration = time.Hour
+	want.LogMaxSize = 64
+	want.MinRelayTxFee = 0.01
+	want.AddPeers = []string{"127.0.0.1", "127.0.0.2"}
+	if !reflect.DeepEqual(c, want) {
+		t.Fatalf("applyEnv: got %+v, want %+v", c, want)
+	}
+
+	env = map[string]string{"HCASHD_MAXPEERS": "many"}
+	if err := DefaultConfig().applyEnv(lookupEnv); err == nil {
+		t.Fatal("applyEnv: invalid value accepted")
+	}
+}
+
+// TestWriteConfig ensures only the options which differ from the defaults are
+// written.
+func TestWriteConfig(t *testing.T) {
+	c := DefaultConfig()
+	c.SimNet = true
+	c.BanDuration = time.Hour
+	c.MaxPeers = 8
+	c.MinRelayTxFee = 0.01
+	c.AddPeers = []string{"127.0.0.1", "127.0.0.2"}
+
+	var buf bytes.Buffer
+	if err := c.WriteConfig(&buf); err != nil {
+		t.Fatalf("WriteConfig: unexpected error: %v", err)
+	}
+	want := "[Application Options]\n" +
+		"addpeer=127.0.0.1\n" +
+		"addpeer=127.0.0.2\n" +
+		"maxpeers=8\n" +
+		"banduration=1h0m0s\n" +
+		"simnet=1\n" +
+		"minrelaytxfee=0.01\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("WriteConfig: got %q, want %q", got, want)
+	}
+}
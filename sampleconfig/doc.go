@@ -1,12 +1,33 @@
 // Copyright (c) 2017 The Decred developers
+// Copyright (c) 2017 The Hcash developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
 /*
-Package sampleconfig provides a single constant that contains the contents of
-the sample configuration file for hcashd.  This is provided for tools that perform
-automatic configuration and would like to ensure the generated configuration
-file not only includes the specifically configured values, but also provides
-samples of other configuration options.
+Package sampleconfig provides the configuration options of hcashd along with a
+constant that contains the contents of the sample configuration file for
+hcashd.  This is provided for tools that perform automatic configuration and
+would like to ensure the generated configuration file not only includes the
+specifically configured values, but also provides samples of other
+configuration options.
+
+Config houses the options with the same names and defaults hcashd uses, so
+tools such as test harnesses can build a configuration programmatically,
+check it with Validate and write it as a configuration file with WriteConfig
+instead of templating the file:
+
+	cfg := sampleconfig.DefaultConfig()
+	cfg.SimNet = true
+	cfg.RPCUser = "user"
+	cfg.RPCPass = "pass"
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	err := cfg.WriteConfig(file)
+
+Every option may also be set with an environment variable named after its
+long name, such as HCASHD_RPCUSER for rpcuser.  hcashd applies them with
+ApplyEnv, so they override the configuration file while the command line
+options still override them.
 */
 package sampleconfig
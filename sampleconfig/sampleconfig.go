@@ -8,6 +8,10 @@ package sampleconfig
 // FileContents is a string containing the commented example config for hcashd.
 const FileContents = `[Application Options]
 
+; Every option may also be set with an environment variable named after it,
+; such as HCASHD_RPCUSER for rpcuser.  The environment variables override the
+; options in this file, and the command line options override both.
+
 ; ------------------------------------------------------------------------------
 ; Data settings
 ; ------------------------------------------------------------------------------
@@ -18,7 +22,7 @@ const FileContents = `[Application Options]
 ; ~/Library/Application Support/Hcashd/data on Mac OS, and $homed/hcashd/data on
 ; Plan9.  Environment variables are expanded so they may be used.  NOTE: Windows
 ; environment variables are typically %VARIABLE%, but they must be accessed with
-; $VARIABLE here.  Also, ~ is expanded to $LOCALAPPDATA on Windows.
+; $VARIABLE here.  Also, ~ is expanded to the home directory of the user.
 ; datadir=~/.hcashd/data
 
 
@@ -171,6 +175,15 @@ const FileContents = `[Application Options]
 ; Disable peer bloom filtering.  See BIP0111.
 ; nopeerbloomfilters=1
 
+; Disable support for light blocks, which announce new blocks to peers without
+; the transactions they already have in their memory pool.
+; nolightblocks=1
+
+; Reject peers advertising a protocol version below the given version.  Peers
+; which do not support the features introduced by newer protocol versions, such
+; as light blocks with version 4, can be refused this way.
+; minprotocolversion=4
+
 
 ; ------------------------------------------------------------------------------
 ; RPC server options - The following options control the built-in RPC server
@@ -185,6 +198,24 @@ const FileContents = `[Application Options]
 ; rpcuser=whatever_username_you_want
 ; rpcpass=
 
+; Optionally specify a second username and password for limited RPC connections,
+; such as monitoring dashboards, which may only invoke the methods that do not
+; change the state of the server.  They must differ from the ones above.
+; rpclimituser=whatever_limited_username_you_want
+; rpclimitpass=
+
+; Replace the default set of methods available to limited RPC connections with
+; the specified ones.  One method per line.
+; rpclimitmethod=getblockcount
+; rpclimitmethod=getinfo
+; rpclimitmethod=getmempoolinfo
+
+; Accept the TLS client certificates signed by the certificate authorities in
+; the specified file.  Clients presenting a valid certificate are authenticated
+; as the admin user without a username and password, which is useful for
+; automated infrastructure.  This option requires TLS.
+; rpcclientca=~/.hcashd/rpcclientca.cert
+
 ; Specify the interfaces for the RPC server listen on.  One listen address per
 ; line.  NOTE: The default port is modified by some options such as 'testnet',
 ; so it is recommended to not specify a port and allow a proper default to be
@@ -221,6 +252,43 @@ const FileContents = `[Application Options]
 ; Specify the maximum number of concurrent RPC websocket clients.
 ; rpcmaxwebsockets=25
 
+; Enable the server-sent events endpoint at /events, which streams block and
+; transaction notifications to authenticated clients such as web dashboards.
+; rpcsse=1
+
+; Allow cross-origin requests to the server-sent events endpoint from the
+; given origins.  Use * to allow any origin.  To add multiple origins, use
+; multiple lines.
+; rpcsseorigin=https://dashboard.example.com
+
+; Specify the maximum number of concurrent RPC server-sent events clients.
+; rpcmaxsseclients=25
+
+; Enable the read-only REST endpoints under /rest/, which serve blocks, headers,
+; and transactions WITHOUT authentication.  Only enable this when the RPC
+; listeners are not reachable by untrusted clients.
+; rpcrest=1
+
+; Specify the interfaces for the gRPC server to listen on, which enables the
+; gRPC server.  One listen address per line.  The gRPC server always uses TLS
+; with the RPC certificate and authenticates clients with the tokens written to
+; grpctokendir.  The default port is 14019 on mainnet and 12019 on testnet.
+; Only ipv4 localhost on the default port:
+;   grpclisten=127.0.0.1
+
+; Specify the directory the gRPC root key and the admin.token and
+; readonly.token authentication tokens are stored in.  Removing the root key
+; revokes all tokens.  Defaults to the grpc directory in the data directory.
+; grpctokendir=~/.hcashd/data/mainnet/grpc
+
+; Specify the maximum number of concurrent gRPC notification streams.
+; grpcmaxstreams=25
+
+; Specify the file containing the key used to sign the key block attestations
+; returned by getkeyblockattestation.  A new key is generated when the file does
+; not exist.  Defaults to attestation.key in the data directory.
+; attestationkey=~/.hcashd/data/mainnet/attestation.key
+
 ; Use the following setting to disable the RPC server even if the rpcuser and
 ; rpcpass are specified above.  This allows one to quickly disable the RPC
 ; server without having to remove credentials from the config file.
@@ -239,12 +307,30 @@ const FileContents = `[Application Options]
 ; minute.
 ; limitfreerelay=15
 
+; Rate-limit the transactions accepted from a single peer to the value
+; 1000 * 1000 bytes per minute, and the free and low-fee transactions among
+; them to 5 * 1000 bytes per minute.  Whitelisted peers are not limited.
+; limitpeerrelay=1000
+; limitpeerfreerelay=5
+
 ; Require high priority for relaying free or low-fee transactions.
 ; norelaypriority=0
 
 ; Limit orphan transaction pool to 1000 transactions.
 ; maxorphantx=1000
 
+; Save the transactions in the memory pool, except votes, to mempool.dat in the
+; data directory on shutdown and restore them on startup, so they are not lost
+; when the node is restarted.
+; persistmempool=1
+
+; Limit the transactions in the memory pool to 300 MB.  The transactions paying
+; the lowest fee rates are evicted once the limit is exceeded, and the minimum
+; fee rate required for new transactions is raised above the evicted ones until
+; it decays again.  Votes and revocations are never evicted.  Set to 0 to
+; disable the limit.
+; maxmempoolsize=300000000
+
 ; Do not accept transactions from remote peers.
 ; blocksonly=1
 
@@ -262,6 +348,12 @@ const FileContents = `[Application Options]
 ; Delete the entire address index on start up, then exit.
 ; dropaddrindex=0
 
+; Delete the entire committed filter index on start up, then exit.
+; dropcfindex=0
+
+; Delete the entire vote stats index on start up, then exit.
+; dropvotestatsindex=0
+
 
 ; ------------------------------------------------------------------------------
 ; Optional Indexes
@@ -275,14 +367,76 @@ const FileContents = `[Application Options]
 ; searchrawtransactions RPC available.
 ; addrindex=1
 
+; Build and maintain a committed filter index for every block which makes the
+; getcfilter and getcfheaders RPCs available to light clients.
+; cfindex=1
+
+; Build and maintain vote statistics for every voting address which makes the
+; getvotestats RPC available.
+; votestatsindex=1
 
+
+; ------------------------------------------------------------------------------
+; Pruning
+; ------------------------------------------------------------------------------
+
+; Delete the data of old blocks to limit the block data stored on disk to the
+; given size in MiB.  Only blocks before the latest checkpoint which are at
+; least prunedepth key blocks below the best chain tip are deleted, so the limit
+; may be exceeded.  Block headers, the utxo set, and the stake undo data are
+; always retained.  Pruned nodes do not advertise that they serve the full block
+; chain and can not be used with the txindex or addrindex options.
+; prune=10240
+
+; The number of key blocks below the best chain tip whose data is never pruned.
+; prunedepth=2880
+
+; Move the data of old blocks to the given directory, such as one on slower and
+; cheaper storage than the data directory, so archival nodes can keep recent
+; blocks on fast storage.  Moved blocks are read from the directory as needed
+; and are still served to peers.  A subdirectory is used for each network.
+; colddatadir=/mnt/archive/hcashd
+
+; The number of key blocks below the best chain tip whose data is kept in the
+; data directory when colddatadir is set.
+; colddepth=8640
+
+
+; ------------------------------------------------------------------------------
+; Reorganizations
 ; ------------------------------------------------------------------------------
-; Signature Verification Cache
+
+; Reject blocks which would cause a reorganization that disconnects more than
+; the given number of blocks from the main chain.  This protects a node that is
+; already in sync with the network against long range attacks, but it also
+; prevents the node from following the network after a legitimate deep
+; reorganization, which then requires manual intervention.  Disabled by default.
+; maxreorgdepth=0
+
+
+; ------------------------------------------------------------------------------
+; Signature Verification
 ; ------------------------------------------------------------------------------
 
 ; Limit the signature cache to a max of 50000 entries.
 ; sigcachemaxsize=50000
 
+; Limit the number of goroutines used to validate the input scripts of a block.
+; By default a few goroutines are used per processor core.  Lower this to keep
+; some cores idle while syncing the chain.
+; sigverifyconcurrency=4
+
+; Reuse the cached hash of transactions as the prefix hash when verifying the
+; SIGHASH_ALL signatures of blocks.  This feature is EXPERIMENTAL and could cause
+; database corruption when transactions are mutated after they were hashed, so
+; do not enable it when mining or running a wallet with the node.
+; sighashoptimization=1
+
+; Reject blocks with transactions that overwrite unspent transactions with the
+; same hash.  Since coinbases commit to the block height, such duplicates should
+; be impossible, however, the paranoid may wish to enable the check.
+; checkduplicatehashes=1
+
 
 ; ------------------------------------------------------------------------------
 ; Coin Generation (Mining) Settings - The following options control the
@@ -298,11 +452,35 @@ const FileContents = `[Application Options]
 ; generate=false
 
 ; Add addresses to pay mined blocks to for CPU mining and the block templates
-; generated for the getwork RPC as desired.  One address per line.
+; generated for the getwork RPC as desired.  The CPU miner rotates through the
+; addresses in round robin order.  One address per line.
 ; miningaddr=youraddress
 ; miningaddr=youraddress2
 ; miningaddr=youraddress3
 
+; Split the coinbase work reward of generated blocks across multiple addresses
+; instead of paying it to a single mining address.  Each address receives the
+; part of the subsidy and fees proportional to its weight relative to the total
+; weight of all payouts, which is useful for pools.  The payouts may be changed
+; at runtime with the setminingaddresses RPC.  One address:weight per line.
+; miningpayout=youraddress:3
+; miningpayout=youraddress2:1
+
+; Automatically tune the number of active CPU mining threads so hcashd uses at
+; most the given percentage of the total CPU capacity of the system.  This is
+; useful on machines that mine continuously such as testnet or simnet faucets.
+; minercpubudget=50
+
+; The number of CPU mining threads to use unless the number is specified via the
+; setgenerate RPC.  The default is 1.
+; cpuminerthreads=4
+
+; Automatically create and broadcast revocations for missed and expired tickets
+; whose voting rights are held by a pay-to-script-hash script registered with
+; the addrevocationscript RPC.  Only scripts that can be satisfied without any
+; private keys can be revoked this way since the node does not hold any keys.
+; autorevoke=1
+
 ; Specify the minimum block size in bytes to create.  By default, only
 ; transactions which have enough fees or a high enough priority will be included
 ; in generated block templates.  Specifying a minimum block size will instead
@@ -334,8 +512,37 @@ const FileContents = `[Application Options]
 ; available subsystems.
 ; debuglevel=info
 
+; The format of the log output.  Valid formats are {text, json}.  The json
+; format writes each entry as a JSON object on a line of its own with the time,
+; level, subsystem and message of the entry, along with the peer, hash and height
+; the message refers to, which is suitable for log processing pipelines.  The
+; format applies to both the standard output and the log files.
+; logformat=text
+
+; The maximum size in MiB of the log files before they are rotated and the
+; maximum number of rotated log files to keep.  A value of 0 for logmaxrolls
+; keeps all of the rotated log files.
+; logmaxsize=10
+; logmaxrolls=3
+
+; Log the entries of a subsystem to a separate log file named after it, such as
+; peer.log for the PEER subsystem, in the log directory instead of the main log
+; file.  The separate log files are rotated independently.  One subsystem per
+; line.
+; logseparate=PEER
+; logseparate=SRVR
+
 ; The port used to listen for HTTP profile requests.  The profile server will
 ; be disabled if this option is not specified.  The profile information can be
 ; accessed at http://localhost:<profileport>/debug/pprof once running.
 ; profile=6061
+
+; Specify the interfaces to serve the Prometheus metrics endpoint on, which is
+; disabled if this option is not specified.  The metrics, such as the chain
+; height, the mempool transactions by type, the connected peers and the block
+; validation and database times, can be scraped from
+; http://<metricslisten>/metrics once running.  One listen address per line.
+; The endpoint is not authenticated, so it should only listen on localhost or
+; an internal network.
+; metricslisten=127.0.0.1:14029
 `
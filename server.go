@@ -15,6 +15,7 @@ import (
 	"math"
 	"net"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -24,6 +25,7 @@ import (
 	"github.com/HcashOrg/hcashd/addrmgr"
 	"github.com/HcashOrg/hcashd/blockchain"
 	"github.com/HcashOrg/hcashd/blockchain/indexers"
+	"github.com/HcashOrg/hcashd/blockchain/standalone"
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/connmgr"
@@ -40,7 +42,8 @@ import (
 const (
 	// defaultServices describes the default services that are supported by
 	// the server.
-	defaultServices = wire.SFNodeNetwork | wire.SFNodeBloom
+	defaultServices = wire.SFNodeNetwork | wire.SFNodeBloom |
+		wire.SFNodeLightBlock
 
 	// defaultRequiredServices describes the default services that are
 	// required to be supported by outbound peers.
@@ -55,8 +58,13 @@ const (
 	// number of retries such that there is a retry backoff.
 	connectionRetryInterval = time.Second * 5
 
+	// peerMigrationInterval is the interval at which an outbound peer that
+	// was connected during the initial block download is migrated to a
+	// preferred peer once the chain is current.
+	peerMigrationInterval = time.Minute * 10
+
 	// maxProtocolVersion is the max protocol version the server supports.
-	maxProtocolVersion = wire.MaxBlockSizeVersion
+	maxProtocolVersion = wire.LightBlockVersion
 )
 
 var (
@@ -112,6 +120,12 @@ type peerState struct {
 	persistentPeers map[int32]*serverPeer
 	banned          map[string]time.Time
 	outboundGroups  map[string]int
+
+	// currentSince is the time the chain was first found to be current
+	// and migrations is the number of outbound peers connected before then
+	// that were migrated to preferred peers.
+	currentSince time.Time
+	migrations   int
 }
 
 // Count returns the count of all known peers.
@@ -156,9 +170,13 @@ type server struct {
 	connManager          *connmgr.ConnManager
 	sigCache             *txscript.SigCache
 	rpcServer            *rpcServer
+	grpcServer           *grpcServer
+	metrics              *nodeMetrics
 	blockManager         *blockManager
 	txMemPool            *mempool.TxPool
+	blkTmplGenerator     *mining.BlkTmplGenerator
 	cpuMiner             *CPUMiner
+	revoker              *ticketRevoker
 	modifyRebroadcastInv chan interface{}
 	newPeers             chan *serverPeer
 	donePeers            chan *serverPeer
@@ -167,12 +185,15 @@ type server struct {
 	relayInv             chan relayMsg
 	broadcast            chan broadcastMsg
 	peerHeightsUpdate    chan updatePeerHeightsMsg
+	migrateAddrs         chan net.Addr
+	targetOutbound       int
 	wg                   sync.WaitGroup
 	quit                 chan struct{}
 	nat                  NAT
 	db                   database.DB
 	timeSource           blockchain.MedianTimeSource
 	services             wire.ServiceFlag
+	noticeManager        *noticeManager
 
 	// The following fields are used for optional indexes.  They will be nil
 	// if the associated index is not enabled.  These fields are set during
@@ -181,6 +202,8 @@ type server struct {
 	txIndex         *indexers.TxIndex
 	addrIndex       *indexers.AddrIndex
 	existsAddrIndex *indexers.ExistsAddrIndex
+	cfIndex         *indexers.CFIndex
+	voteStatsIndex  *indexers.VoteStatsIndex
 }
 
 // serverPeer extends the peer to maintain state shared by the server and
@@ -201,6 +224,9 @@ type serverPeer struct {
 	filter          *bloom.Filter
 	knownAddresses  map[string]struct{}
 	banScore        connmgr.DynamicBanScore
+	txLimiter       *mempool.PeerRateLimiter
+	noticeWindow    time.Time
+	noticeCount     int
 	quit            chan struct{}
 
 	// The following chans are used to sync blockmanager and server.
@@ -218,9 +244,11 @@ func newServerPeer(s *server, isPersistent bool) *serverPeer {
 		requestedBlocks: make(map[chainhash.Hash]struct{}),
 		filter:          bloom.LoadFilter(nil),
 		knownAddresses:  make(map[string]struct{}),
-		quit:            make(chan struct{}),
-		txProcessed:     make(chan struct{}, 1),
-		blockProcessed:  make(chan struct{}, 1),
+		txLimiter: mempool.NewPeerRateLimiter(cfg.PeerTxRelayLimit,
+			cfg.PeerFreeTxRelayLimit),
+		quit:           make(chan struct{}),
+		txProcessed:    make(chan struct{}, 1),
+		blockProcessed: make(chan struct{}, 1),
 	}
 }
 
@@ -229,7 +257,7 @@ func newServerPeer(s *server, isPersistent bool) *serverPeer {
 func (sp *serverPeer) newestBlock() (*chainhash.Hash, int64, int64, error) {
 	best := sp.server.blockManager.chain.BestSnapshot()
 	realKeyHeight := best.KeyHeight
-	if blockchain.HashToBig(best.Hash).Cmp(blockchain.CompactToBig(best.Bits)) <=0 {
+	if standalone.HashToBig(best.Hash).Cmp(standalone.CompactToBig(best.Bits)) <= 0 {
 		realKeyHeight++
 	}
 
@@ -296,7 +324,8 @@ func (sp *serverPeer) pushAddrMsg(addresses []*wire.NetAddress) {
 // disconnected.
 func (sp *serverPeer) addBanScore(persistent, transient uint32, reason string) {
 	// No warning is logged and no score is calculated if banning is disabled.
-	if cfg.DisableBanning {
+	banSettings := currentBanSettings()
+	if banSettings.disableBanning {
 		return
 	}
 	if sp.isWhitelisted {
@@ -304,7 +333,7 @@ func (sp *serverPeer) addBanScore(persistent, transient uint32, reason string) {
 		return
 	}
 
-	warnThreshold := cfg.BanThreshold >> 1
+	warnThreshold := banSettings.banThreshold >> 1
 	if transient == 0 && persistent == 0 {
 		// The score is not being increased, but a warning message is still
 		// logged if the score is above the warn threshold.
@@ -319,7 +348,7 @@ func (sp *serverPeer) addBanScore(persistent, transient uint32, reason string) {
 	if score > warnThreshold {
 		peerLog.Warnf("Misbehaving peer %s: %s -- ban score increased to %d",
 			sp, reason, score)
-		if score > cfg.BanThreshold {
+		if score > banSettings.banThreshold {
 			peerLog.Warnf("Misbehaving peer %s -- banning and disconnecting",
 				sp)
 			sp.server.BanPeer(sp)
@@ -370,8 +399,18 @@ func (sp *serverPeer) OnVersion(p *peer.Peer, msg *wire.MsgVersion) {
 				p.QueueMessage(wire.NewMsgGetAddr(), nil)
 			}
 
-			// Mark the address as a known good address.
+			// Mark the address as a known good address and remember
+			// the services it advertised.
 			addrManager.Good(p.NA())
+			addrManager.SetServices(p.NA(), msg.Services)
+		}
+	}
+
+	// Send the active notices to peers which understand them.
+	if p.ProtocolVersion() >= wire.NoticeVersion {
+		now := time.Now()
+		for _, notice := range sp.server.noticeManager.ActiveNotices(now) {
+			p.QueueMessage(notice, nil)
 		}
 	}
 
@@ -474,7 +513,7 @@ func (sp *serverPeer) OnGetMiningState(p *peer.Peer, msg *wire.MsgGetMiningState
 	// the current tip.
 
 	curPrevKey := bm.chainState.curPrevKeyHash
-	if blockchain.HashToBig(bm.chainState.newestHash).Cmp(blockchain.CompactToBig(bm.chainState.newestBits)) <= 0{
+	if standalone.HashToBig(bm.chainState.newestHash).Cmp(standalone.CompactToBig(bm.chainState.newestBits)) <= 0 {
 		curPrevKey = *(bm.chainState.newestHash)
 	}
 
@@ -490,7 +529,7 @@ func (sp *serverPeer) OnGetMiningState(p *peer.Peer, msg *wire.MsgGetMiningState
 	// limit the list to the maximum number of allowed eligible block hashes
 	// per mining state message.  There is nothing to send when there are no
 	// eligible blocks.
-	keyBlockHashes := SortParentsByVotes(mp, curPrevKey, keyChildren,
+	keyBlockHashes := mining.SortParentsByVotes(mp, curPrevKey, keyChildren,
 		bm.server.chainParams)
 	if len(keyBlockHashes) == 0{
 		return
@@ -602,6 +641,11 @@ func (sp *serverPeer) OnBlock(p *peer.Peer, msg *wire.MsgBlock, buf []byte) {
 // accordingly.  We pass the message down to blockmanager which will call
 // QueueMessage with any appropriate responses.
 func (sp *serverPeer) OnInv(p *peer.Peer, msg *wire.MsgInv) {
+	// A decaying ban score increase is applied to prevent flooding with
+	// unusually large inventory announcements in the same way as for
+	// getdata requests.
+	sp.addBanScore(0, uint32(len(msg.InvList))*99/wire.MaxInvPerMsg, "inv")
+
 	if !cfg.BlocksOnly {
 		if len(msg.InvList) > 0 {
 			sp.server.blockManager.QueueInv(msg, sp)
@@ -885,6 +929,251 @@ func (sp *serverPeer) OnGetHeaders(p *peer.Peer, msg *wire.MsgGetHeaders) {
 	p.QueueMessage(&wire.MsgHeaders{Headers: blockHeaders}, nil)
 }
 
+// enforceNodeCFFlag bans and disconnects the peer if the server is not
+// configured to serve committed filters.  The committed filter messages only
+// exist in protocol versions that also define the committed filter service
+// flag, so a peer sending them to a server that does not advertise the flag is
+// intentionally violating the protocol.
+func (sp *serverPeer) enforceNodeCFFlag(cmd string) bool {
+	if sp.server.services&wire.SFNodeCF != wire.SFNodeCF {
+		// Disconnect the peer regardless of whether it was banned.
+		peerLog.Debugf("%s sent an unsupported %s request -- "+
+			"disconnecting", sp, cmd)
+		sp.addBanScore(100, 0, cmd)
+		sp.Disconnect()
+		return false
+	}
+
+	return true
+}
+
+// OnGetCFilter is invoked when a peer receives a getcfilter wire message.  The
+// committed filter of the requested type for the block is served from the
+// committed filter index.
+func (sp *serverPeer) OnGetCFilter(p *peer.Peer, msg *wire.MsgGetCFilter) {
+	// Disconnect and/or ban depending on the node cf services flag.
+	if !sp.enforceNodeCFFlag(msg.Command()) {
+		return
+	}
+
+	filter, err := sp.server.cfIndex.FilterByBlockHash(&msg.BlockHash,
+		msg.FilterType)
+	if err != nil {
+		peerLog.Errorf("OnGetCFilter: failed to fetch %v filter for "+
+			"block %v: %v", msg.FilterType, msg.BlockHash, err)
+		return
+	}
+	if filter == nil {
+		peerLog.Debugf("OnGetCFilter: no %v filter for block %v "+
+			"requested by %v", msg.FilterType, msg.BlockHash, sp)
+		return
+	}
+
+	p.QueueMessage(wire.NewMsgCFilter(&msg.BlockHash, msg.FilterType,
+		filter), nil)
+}
+
+// OnGetCFHeaders is invoked when a peer receives a getcfheaders wire message.
+// The committed filter headers of the requested type are located the same way
+// as the block headers of a getheaders message.
+func (sp *serverPeer) OnGetCFHeaders(p *peer.Peer, msg *wire.MsgGetCFHeaders) {
+	// Disconnect and/or ban depending on the node cf services flag.
+	if !sp.enforceNodeCFFlag(msg.Command()) {
+		return
+	}
+
+	// Ignore getcfheaders requests if not in sync.
+	if !sp.server.blockManager.IsCurrent() {
+		return
+	}
+
+	blockHashes, err := sp.server.locateBlocks(msg.BlockLocatorHashes,
+		&msg.HashStop)
+	if err != nil {
+		peerLog.Errorf("OnGetCFHeaders: failed to fetch hashes: %v", err)
+		return
+	}
+	if len(blockHashes) > wire.MaxCFHeadersPerMsg {
+		blockHashes = blockHashes[:wire.MaxCFHeadersPerMsg]
+	}
+
+	headersMsg := wire.NewMsgCFHeaders()
+	headersMsg.FilterType = msg.FilterType
+	for i := range blockHashes {
+		header, err := sp.server.cfIndex.FilterHeaderByBlockHash(
+			&blockHashes[i], msg.FilterType)
+		if err != nil {
+			peerLog.Errorf("OnGetCFHeaders: failed to fetch %v "+
+				"filter header for block %v: %v", msg.FilterType,
+				blockHashes[i], err)
+			return
+		}
+		if header == nil {
+			// The block was disconnected while the headers were
+			// being fetched, so stop at the last known header.
+			break
+		}
+		headersMsg.AddCFHeader(header)
+		headersMsg.StopHash = blockHashes[i]
+	}
+
+	p.QueueMessage(headersMsg, nil)
+}
+
+// OnGetCFCheckpt is invoked when a peer receives a getcfcheckpt wire message.
+// The committed filter headers of the requested type at every
+// wire.CFCheckptInterval blocks of the main chain up to the stop hash are
+// served so the peer can fetch the headers in between from multiple peers.
+func (sp *serverPeer) OnGetCFCheckpt(p *peer.Peer, msg *wire.MsgGetCFCheckpt) {
+	// Disconnect and/or ban depending on the node cf services flag.
+	if !sp.enforceNodeCFFlag(msg.Command()) {
+		return
+	}
+
+	// Ignore getcfcheckpt requests if not in sync.
+	if !sp.server.blockManager.IsCurrent() {
+		return
+	}
+
+	chain := sp.server.blockManager.chain
+	stopHeight, err := chain.BlockHeightByHash(&msg.StopHash)
+	if err != nil {
+		peerLog.Debugf("OnGetCFCheckpt: stop hash %v requested by %v "+
+			"is not in the main chain", msg.StopHash, sp)
+		return
+	}
+
+	numCheckpts := stopHeight / wire.CFCheckptInterval
+	if numCheckpts > wire.MaxCFCheckptsPerMsg {
+		numCheckpts = wire.MaxCFCheckptsPerMsg
+	}
+	checkptMsg := wire.NewMsgCFCheckpt(msg.FilterType, &msg.StopHash,
+		int(numCheckpts))
+	for i := int64(1); i <= numCheckpts; i++ {
+		height := i * wire.CFCheckptInterval
+		hash, err := chain.BlockHashByHeight(height)
+		if err != nil {
+			peerLog.Debugf("OnGetCFCheckpt: failed to fetch block "+
+				"hash at height %d: %v", height, err)
+			return
+		}
+		header, err := sp.server.cfIndex.FilterHeaderByBlockHash(hash,
+			msg.FilterType)
+		if err != nil {
+			peerLog.Errorf("OnGetCFCheckpt: failed to fetch %v "+
+				"filter header for block %v: %v", msg.FilterType,
+				hash, err)
+			return
+		}
+		if header == nil {
+			peerLog.Debugf("OnGetCFCheckpt: no %v filter header "+
+				"for block %v", msg.FilterType, hash)
+			return
+		}
+		checkptMsg.AddCFHeader(header)
+	}
+
+	p.QueueMessage(checkptMsg, nil)
+}
+
+// enforceNodeLightBlockFlag bans and disconnects the peer if the server is not
+// configured to support light blocks.  The light block messages only exist in
+// protocol versions that also define the light block service flag, so a peer
+// sending them to a server that does not advertise the flag is intentionally
+// violating the protocol.
+func (sp *serverPeer) enforceNodeLightBlockFlag(cmd string) bool {
+	if sp.server.services&wire.SFNodeLightBlock != wire.SFNodeLightBlock {
+		// Disconnect the peer regardless of whether it was banned.
+		peerLog.Debugf("%s sent an unsupported %s message -- "+
+			"disconnecting", sp, cmd)
+		sp.addBanScore(100, 0, cmd)
+		sp.Disconnect()
+		return false
+	}
+
+	return true
+}
+
+// OnLightBlock is invoked when a peer receives a lightblock wire message.  It
+// queues the light block to the block manager, which reconstructs the block
+// from the memory pool and recovers the transactions that are still missing.
+func (sp *serverPeer) OnLightBlock(p *peer.Peer, msg *wire.MsgLightBlock) {
+	// Disconnect and/or ban depending on the node light block services
+	// flag.
+	if !sp.enforceNodeLightBlockFlag(msg.Command()) {
+		return
+	}
+
+	// Add the block to the known inventory for the peer.
+	hash := msg.BlockHash()
+	p.AddKnownInventory(wire.NewInvVect(wire.InvTypeBlock, &hash))
+
+	// Queue the light block to the block manager and intentionally block
+	// further receives until it is handled the same way as for block
+	// messages, since it might complete the block.
+	sp.server.blockManager.QueueLightBlock(msg, sp)
+	<-sp.blockProcessed
+}
+
+// OnGetMissedTxs is invoked when a peer receives a getmissedtxs wire message.
+// The requested transactions of the block are sent back via a missedtxs
+// message.  Transactions which are not part of the block are omitted.
+func (sp *serverPeer) OnGetMissedTxs(p *peer.Peer, msg *wire.MsgGetMissedTxs) {
+	// Disconnect and/or ban depending on the node light block services
+	// flag.
+	if !sp.enforceNodeLightBlockFlag(msg.Command()) {
+		return
+	}
+
+	// A decaying ban score increase is applied to prevent exhausting
+	// resources with unusually large requests in the same way as for
+	// getdata requests.
+	sp.addBanScore(0, uint32(len(msg.TxHashes))*99/wire.MaxInvPerMsg,
+		msg.Command())
+
+	block, err := sp.server.blockManager.chain.BlockByHash(&msg.BlockHash)
+	if err != nil {
+		peerLog.Debugf("OnGetMissedTxs: unable to fetch block %v "+
+			"requested by %v: %v", msg.BlockHash, sp, err)
+		return
+	}
+
+	msgBlock := block.MsgBlock()
+	txns := make(map[chainhash.Hash]*wire.MsgTx,
+		len(msgBlock.Transactions)+len(msgBlock.STransactions))
+	for _, tx := range msgBlock.Transactions {
+		txns[tx.TxHash()] = tx
+	}
+	for _, stx := range msgBlock.STransactions {
+		txns[stx.TxHash()] = stx
+	}
+
+	missedTxs := wire.NewMsgMissedTxs(&msg.BlockHash)
+	for i := range msg.TxHashes {
+		if tx, ok := txns[msg.TxHashes[i]]; ok {
+			missedTxs.AddTransaction(tx)
+		}
+	}
+	p.QueueMessage(missedTxs, nil)
+}
+
+// OnMissedTxs is invoked when a peer receives a missedtxs wire message.  It
+// queues the transactions to the block manager to complete the block being
+// reconstructed from a light block.
+func (sp *serverPeer) OnMissedTxs(p *peer.Peer, msg *wire.MsgMissedTxs) {
+	// Disconnect and/or ban depending on the node light block services
+	// flag.
+	if !sp.enforceNodeLightBlockFlag(msg.Command()) {
+		return
+	}
+
+	// Queue the transactions to the block manager and intentionally block
+	// further receives until they are handled, since they might complete
+	// the block.
+	sp.server.blockManager.QueueMissedTxs(msg, sp)
+	<-sp.blockProcessed
+}
+
 // enforceNodeBloomFlag disconnects the peer if the server is not configured to
 // allow bloom filters.  Additionally, if the peer has negotiated to a protocol
 // version  that is high enough to observe the bloom filter service support bit,
@@ -900,7 +1189,7 @@ func (sp *serverPeer) enforceNodeBloomFlag(cmd string) bool {
 		// to ensure the violation is logged and the peer is
 		// disconnected regardless.
 		if sp.ProtocolVersion() >= wire.BIP0111Version &&
-			!cfg.DisableBanning {
+			!currentBanSettings().disableBanning {
 
 			// Disonnect the peer regardless of whether it was
 			// banned.
@@ -1046,6 +1335,47 @@ func (sp *serverPeer) OnAddr(p *peer.Peer, msg *wire.MsgAddr) {
 	sp.server.addrManager.AddAddresses(msg.AddrList, p.NA())
 }
 
+// OnNotice is invoked when a peer receives a notice wire message.  Notices
+// which are properly signed by one of the network notice keys are logged for
+// the operator and relayed to all other peers which understand them.  Peers
+// are limited to maxActiveNotices notices per noticeRateInterval, which is
+// enough for a peer to send all of its active notices after connecting, to
+// prevent them from using the relatively expensive signature checks to
+// exhaust resources.
+func (sp *serverPeer) OnNotice(p *peer.Peer, msg *wire.MsgNotice) {
+	now := time.Now()
+	if now.Sub(sp.noticeWindow) >= noticeRateInterval {
+		sp.noticeWindow = now
+		sp.noticeCount = 0
+	}
+	sp.noticeCount++
+	if sp.noticeCount > maxActiveNotices {
+		sp.addBanScore(0, 25, "notice")
+		return
+	}
+
+	err := sp.server.noticeManager.ProcessNotice(msg, now)
+	switch err {
+	case nil:
+	case errNoticeKnown, errNoticeExpired, errNoticeLimit:
+		peerLog.Debugf("Ignoring notice %d from %s: %v", msg.ID, p, err)
+		return
+	case errNoticeDisabled:
+		// Peers on networks without notice keys have no reason to
+		// send notices.
+		sp.addBanScore(0, 25, "notice")
+		return
+	default:
+		peerLog.Debugf("Rejected notice %d from %s: %v", msg.ID, p, err)
+		sp.addBanScore(0, 50, "invalid notice")
+		return
+	}
+
+	srvrLog.Warnf("Network notice %d (expires %v): %q", msg.ID,
+		time.Unix(msg.Expiration, 0), msg.Message)
+	sp.server.BroadcastMessage(msg, sp)
+}
+
 // OnRead is invoked when a peer receives a message and it is used to update
 // the bytes received by the server.
 func (sp *serverPeer) OnRead(p *peer.Peer, bytesRead int, msg wire.Message, err error) {
@@ -1120,6 +1450,11 @@ func (s *server) AnnounceNewTransactions(newTxs []*hcashutil.Tx) {
 			s.rpcServer.gbtWorkState.NotifyMempoolTx(
 				s.txMemPool.LastUpdated())
 		}
+
+		// Notify gRPC transaction notification streams.
+		if s.grpcServer != nil {
+			s.grpcServer.notifyTxAccepted(tx)
+		}
 	}
 }
 
@@ -1321,9 +1656,10 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 	// TODO: Check for max peers from a single IP.
 
 	// Limit max number of total peers.
-	if state.Count() >= cfg.MaxPeers {
+	maxPeers := currentConnLimits().maxPeers
+	if state.Count() >= maxPeers {
 		srvrLog.Infof("Max peers reached [%d] - disconnecting peer %s",
-			cfg.MaxPeers, sp)
+			maxPeers, sp)
 		sp.Disconnect()
 		// TODO(oga) how to handle permanent peers here?
 		// they should be rescheduled.
@@ -1377,12 +1713,159 @@ func (s *server) handleDonePeerMsg(state *peerState, sp *serverPeer) {
 	// our version and has sent us its version as well.
 	if sp.VerAckReceived() && sp.VersionKnown() && sp.NA() != nil {
 		s.addrManager.Connected(sp.NA())
+		s.recordPeerLatency(sp)
 	}
 
 	// If we get here it means that either we didn't know about the peer
 	// or we purposefully deleted it.
 }
 
+// recordPeerLatency stores the last measured ping latency of the passed outbound
+// peer in the address manager so it persists across restarts.
+func (s *server) recordPeerLatency(sp *serverPeer) {
+	if sp.Inbound() {
+		return
+	}
+	if micros := sp.LastPingMicros(); micros > 0 {
+		s.addrManager.SetLatency(sp.NA(),
+			time.Duration(micros)*time.Microsecond)
+	}
+}
+
+// peersByLatency implements sort.Interface to sort peers by their last measured
+// ping latency.  Peers without a measured latency are sorted last.
+type peersByLatency []*serverPeer
+
+func (s peersByLatency) Len() int      { return len(s) }
+func (s peersByLatency) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s peersByLatency) Less(i, j int) bool {
+	li, lj := s[i].LastPingMicros(), s[j].LastPingMicros()
+	if li <= 0 || lj <= 0 {
+		return lj <= 0 && li > 0
+	}
+	return li < lj
+}
+
+// saveAnchors remembers the outbound peers with the lowest latency that are
+// currently connected as the anchors to reconnect to first on the next start.
+// Persistent peers are excluded since they are reconnected to regardless.
+// It is invoked from the peerHandler goroutine on shutdown.
+func (s *server) saveAnchors(state *peerState) {
+	var anchors []*serverPeer
+	for _, sp := range state.outboundPeers {
+		if !sp.VerAckReceived() || sp.NA() == nil {
+			continue
+		}
+		s.recordPeerLatency(sp)
+		anchors = append(anchors, sp)
+	}
+
+	// Prefer the peers with the lowest latency.
+	sort.Sort(peersByLatency(anchors))
+
+	addrs := make([]*wire.NetAddress, 0, len(anchors))
+	for _, sp := range anchors {
+		addrs = append(addrs, sp.NA())
+	}
+	s.addrManager.SetAnchors(addrs)
+}
+
+// connectAnchors initiates connections to the anchor addresses remembered by
+// the previous run so the node reconnects to previously good peers before any
+// addresses picked from the address manager, which improves resilience against
+// eclipse attacks after restarts.
+func (s *server) connectAnchors() {
+	for _, ka := range s.addrManager.Anchors() {
+		addrString := addrmgr.NetAddressKey(ka.NetAddress())
+		addr, err := addrStringToNetAddr(addrString)
+		if err != nil {
+			srvrLog.Debugf("Cannot connect to anchor peer %s: %v",
+				addrString, err)
+			continue
+		}
+
+		srvrLog.Debugf("Connecting to anchor peer %s", addrString)
+		go s.connManager.Connect(&connmgr.ConnReq{Addr: addr})
+	}
+}
+
+// migrateOutboundPeer replaces the outbound peer with the highest latency among
+// the ones that were connected during the initial block download with a
+// preferred peer from the address manager that has a lower latency.  The peers
+// selected during the initial block download are not necessarily well suited
+// for quickly relaying new blocks and votes, so once the chain is current, up
+// to half of the target outbound peers are migrated, one at a time.  It is
+// invoked from the peerHandler goroutine.
+func (s *server) migrateOutboundPeer(state *peerState) {
+	if !s.blockManager.IsCurrent() {
+		return
+	}
+	if state.currentSince.IsZero() {
+		state.currentSince = time.Now()
+	}
+	if state.migrations >= s.targetOutbound/2 ||
+		len(state.outboundPeers) < s.targetOutbound {
+		return
+	}
+
+	var worst *serverPeer
+	for _, sp := range state.outboundPeers {
+		if sp.connReq == nil || !sp.VerAckReceived() || sp.NA() == nil ||
+			sp.LastPingMicros() <= 0 ||
+			!sp.TimeConnected().Before(state.currentSince) {
+			continue
+		}
+		if worst == nil || sp.LastPingMicros() > worst.LastPingMicros() {
+			worst = sp
+		}
+	}
+	if worst == nil {
+		return
+	}
+	worstLatency := time.Duration(worst.LastPingMicros()) * time.Microsecond
+	worstKey := addrmgr.GroupKey(worst.NA())
+
+	for _, ka := range s.addrManager.PreferredAddresses(s.targetOutbound) {
+		na := ka.NetAddress()
+		if ka.Latency() >= worstLatency {
+			continue
+		}
+
+		// Don't connect to a network segment that is already used by
+		// another outbound peer.
+		key := addrmgr.GroupKey(na)
+		if state.outboundGroups[key] != 0 && key != worstKey {
+			continue
+		}
+		if cfg.NoOnion && addrmgr.IsOnionCatTor(na) {
+			continue
+		}
+		addrString := addrmgr.NetAddressKey(na)
+		if addrString == addrmgr.NetAddressKey(worst.NA()) {
+			continue
+		}
+		addr, err := addrStringToNetAddr(addrString)
+		if err != nil {
+			continue
+		}
+
+		// The connection manager replaces the disconnected peer with
+		// a new connection to the preferred peer.  Nothing is done
+		// while a previous migration is still pending.
+		select {
+		case s.migrateAddrs <- addr:
+		default:
+			return
+		}
+		srvrLog.Debugf("Migrating outbound peer %s (latency %v) to "+
+			"preferred peer %s (latency %v)", worst, worstLatency,
+			addrString, ka.Latency())
+		state.migrations++
+		s.connManager.Disconnect(worst.connReq.ID())
+		return
+	}
+}
+
 // handleBanPeerMsg deals with banning peers.  It is invoked from the
 // peerHandler goroutine.
 func (s *server) handleBanPeerMsg(state *peerState, sp *serverPeer) {
@@ -1392,9 +1875,11 @@ func (s *server) handleBanPeerMsg(state *peerState, sp *serverPeer) {
 		return
 	}
 	direction := directionString(sp.Inbound())
+	banDuration := currentBanSettings().banDuration
 	srvrLog.Infof("Banned peer %s (%s) for %v", host, direction,
-		cfg.BanDuration)
-	state.banned[host] = time.Now().Add(cfg.BanDuration)
+		banDuration)
+	state.banned[host] = time.Now().Add(banDuration)
+	s.metrics.countPeerBan()
 }
 
 // handleRelayInvMsg deals with relaying inventory to peers that are not already
@@ -1405,12 +1890,36 @@ func (s *server) handleRelayInvMsg(state *peerState, msg relayMsg) {
 			return
 		}
 
+		// If the inventory is a block and both the server and the peer
+		// support light blocks, announce the block via a light block so
+		// the peer only has to fetch the transactions it does not have.
+		if msg.invVect.Type == wire.InvTypeBlock &&
+			s.services&wire.SFNodeLightBlock == wire.SFNodeLightBlock &&
+			sp.SupportsLightBlocks() {
+
+			if sp.IsKnownInventory(msg.invVect) {
+				return
+			}
+			block, ok := msg.data.(*hcashutil.Block)
+			if ok {
+				sp.AddKnownInventory(msg.invVect)
+				sp.QueueMessage(wire.NewMsgLightBlockFromBlock(
+					block.MsgBlock()), nil)
+				return
+			}
+		}
+
 		// If the inventory is a block and the peer prefers headers,
 		// generate and send a headers message instead of an inventory
 		// message.
 		if msg.invVect.Type == wire.InvTypeBlock && sp.WantsHeaders() {
-			blockHeader, ok := msg.data.(wire.BlockHeader)
-			if !ok {
+			var blockHeader wire.BlockHeader
+			switch data := msg.data.(type) {
+			case wire.BlockHeader:
+				blockHeader = data
+			case *hcashutil.Block:
+				blockHeader = data.MsgBlock().Header
+			default:
 				peerLog.Warnf("Underlying data for headers" +
 					" is not a block header")
 				return
@@ -1468,6 +1977,13 @@ func (s *server) handleBroadcastMsg(state *peerState, bmsg *broadcastMsg) {
 			}
 		}
 
+		// Don't send notices to peers which are too old to decode
+		// them since they would disconnect.
+		_, isNotice := bmsg.message.(*wire.MsgNotice)
+		if isNotice && sp.ProtocolVersion() < wire.NoticeVersion {
+			return
+		}
+
 		sp.QueueMessage(bmsg.message, nil)
 	})
 }
@@ -1505,6 +2021,21 @@ type removeNodeMsg struct {
 	reply chan error
 }
 
+type banHostMsg struct {
+	host  string
+	until time.Time
+	reply chan struct{}
+}
+
+type unbanHostMsg struct {
+	host  string
+	reply chan error
+}
+
+type getBannedMsg struct {
+	reply chan map[string]time.Time
+}
+
 // handleQuery is the central handler for all queries and commands from other
 // goroutines related to peer state.
 func (s *server) handleQuery(state *peerState, querymsg interface{}) {
@@ -1531,7 +2062,7 @@ func (s *server) handleQuery(state *peerState, querymsg interface{}) {
 	case connectNodeMsg:
 		// XXX(oga) duplicate oneshots?
 		// Limit max number of total peers.
-		if state.Count() >= cfg.MaxPeers {
+		if state.Count() >= currentConnLimits().maxPeers {
 			msg.reply <- errors.New("max peers reached")
 			return
 		}
@@ -1614,6 +2145,39 @@ func (s *server) handleQuery(state *peerState, querymsg interface{}) {
 		}
 
 		msg.reply <- errors.New("peer not found")
+
+	case banHostMsg:
+		state.banned[msg.host] = msg.until
+		state.forAllPeers(func(sp *serverPeer) {
+			host, _, err := net.SplitHostPort(sp.Addr())
+			if err == nil && host == msg.host {
+				sp.Disconnect()
+			}
+		})
+		srvrLog.Infof("Banned host %s until %v", msg.host, msg.until)
+		msg.reply <- struct{}{}
+
+	case unbanHostMsg:
+		if _, ok := state.banned[msg.host]; !ok {
+			msg.reply <- errors.New("host is not banned")
+			return
+		}
+		delete(state.banned, msg.host)
+		srvrLog.Infof("Lifted ban of host %s", msg.host)
+		msg.reply <- nil
+
+	case getBannedMsg:
+		// Respond with a copy of the bans that have not expired yet.
+		now := time.Now()
+		banned := make(map[string]time.Time, len(state.banned))
+		for host, banEnd := range state.banned {
+			if !now.Before(banEnd) {
+				delete(state.banned, host)
+				continue
+			}
+			banned[host] = banEnd
+		}
+		msg.reply <- banned
 	}
 }
 
@@ -1662,18 +2226,26 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 			OnFilterLoad:     sp.OnFilterLoad,
 			OnGetAddr:        sp.OnGetAddr,
 			OnAddr:           sp.OnAddr,
+			OnNotice:         sp.OnNotice,
+			OnGetCFilter:     sp.OnGetCFilter,
+			OnGetCFHeaders:   sp.OnGetCFHeaders,
+			OnGetCFCheckpt:   sp.OnGetCFCheckpt,
+			OnLightBlock:     sp.OnLightBlock,
+			OnGetMissedTxs:   sp.OnGetMissedTxs,
+			OnMissedTxs:      sp.OnMissedTxs,
 			OnRead:           sp.OnRead,
 			OnWrite:          sp.OnWrite,
 		},
-		NewestBlock:      sp.newestBlock,
-		HostToNetAddress: sp.server.addrManager.HostToNetAddress,
-		Proxy:            cfg.Proxy,
-		UserAgentName:    userAgentName,
-		UserAgentVersion: userAgentVersion,
-		ChainParams:      sp.server.chainParams,
-		Services:         sp.server.services,
-		DisableRelayTx:   cfg.BlocksOnly,
-		ProtocolVersion:  maxProtocolVersion,
+		NewestBlock:        sp.newestBlock,
+		HostToNetAddress:   sp.server.addrManager.HostToNetAddress,
+		Proxy:              cfg.Proxy,
+		UserAgentName:      userAgentName,
+		UserAgentVersion:   userAgentVersion,
+		ChainParams:        sp.server.chainParams,
+		Services:           sp.server.services,
+		DisableRelayTx:     cfg.BlocksOnly,
+		ProtocolVersion:    maxProtocolVersion,
+		MinProtocolVersion: cfg.MinProtocolVersion,
 	}
 }
 
@@ -1755,6 +2327,20 @@ func (s *server) peerHandler() {
 			s.addrManager.AddAddresses(addrs, addrs[0])
 		})
 	}
+
+	// Reconnect to the anchors of the previous run first.  Like other
+	// automatic connections, this is skipped on the simulation test network
+	// and when only connecting to specified peers.
+	// Outbound peers are only migrated to preferred peers under the same
+	// conditions.
+	var migrationTicks <-chan time.Time
+	if !cfg.SimNet && len(cfg.ConnectPeers) == 0 {
+		s.connectAnchors()
+
+		migrationTicker := time.NewTicker(peerMigrationInterval)
+		defer migrationTicker.Stop()
+		migrationTicks = migrationTicker.C
+	}
 	go s.connManager.Start()
 
 out:
@@ -1788,8 +2374,14 @@ out:
 		case qmsg := <-s.query:
 			s.handleQuery(state, qmsg)
 
+		case <-migrationTicks:
+			s.migrateOutboundPeer(state)
+
 		case <-s.quit:
-			// Disconnect all peers on server shutdown.
+			// Remember the best outbound peers to reconnect to on
+			// the next start and disconnect all peers on server
+			// shutdown.
+			s.saveAnchors(state)
 			state.forAllPeers(func(sp *serverPeer) {
 				srvrLog.Tracef("Shutdown peer %s", sp)
 				sp.Disconnect()
@@ -1934,6 +2526,36 @@ func (s *server) RemoveNodeByID(id int32) error {
 	return <-replyChan
 }
 
+// BanHost bans the passed host until the passed time and disconnects all peers
+// connected from it.  An existing ban of the host is replaced.
+func (s *server) BanHost(host string, until time.Time) {
+	replyChan := make(chan struct{})
+
+	s.query <- banHostMsg{host: host, until: until, reply: replyChan}
+
+	<-replyChan
+}
+
+// UnbanHost lifts the ban of the passed host.  An error is returned if the
+// host is not banned.
+func (s *server) UnbanHost(host string) error {
+	replyChan := make(chan error)
+
+	s.query <- unbanHostMsg{host: host, reply: replyChan}
+
+	return <-replyChan
+}
+
+// BannedHosts returns the currently banned hosts along with the time their
+// bans end.
+func (s *server) BannedHosts() map[string]time.Time {
+	replyChan := make(chan map[string]time.Time)
+
+	s.query <- getBannedMsg{reply: replyChan}
+
+	return <-replyChan
+}
+
 // ConnectNode adds `addr' as a new outbound peer. If permanent is true then the
 // peer will be persistent and reconnect if the connection is lost.
 // It is an error to call this with an already existing peer.
@@ -2044,6 +2666,14 @@ func (s *server) Start() {
 
 	srvrLog.Trace("Starting server")
 
+	// Restore the transactions persisted to the data directory on the last
+	// shutdown before any peers add new ones.
+	if cfg.PersistMempool {
+		if err := s.loadMempool(); err != nil {
+			srvrLog.Errorf("Unable to restore the mempool: %v", err)
+		}
+	}
+
 	// Start the peer handler which in turn starts the address and block
 	// managers.
 	s.wg.Add(1)
@@ -2064,10 +2694,25 @@ func (s *server) Start() {
 		s.rpcServer.Start()
 	}
 
+	// Start the gRPC server if it's enabled.
+	if s.grpcServer != nil {
+		s.grpcServer.Start()
+	}
+
+	// Start the metrics server if it's enabled.
+	if s.metrics != nil {
+		s.metrics.Start()
+	}
+
 	// Start the CPU miner if generation is enabled.
 	if cfg.Generate {
 		s.cpuMiner.Start()
 	}
+
+	// Start the ticket revoker if automatic revocations are enabled.
+	if s.revoker != nil {
+		s.revoker.Start()
+	}
 }
 
 // Stop gracefully shuts down the server by stopping and disconnecting all
@@ -2086,11 +2731,26 @@ func (s *server) Stop() error {
 		s.cpuMiner.Stop()
 	}
 
+	// Stop the ticket revoker if needed.
+	if s.revoker != nil {
+		s.revoker.Stop()
+	}
+
 	// Shutdown the RPC server if it's not disabled.
 	if !cfg.DisableRPC && s.rpcServer != nil {
 		s.rpcServer.Stop()
 	}
 
+	// Shutdown the gRPC server if it's enabled.
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+
+	// Shutdown the metrics server if it's enabled.
+	if s.metrics != nil {
+		s.metrics.Stop()
+	}
+
 	// Signal the remaining goroutines to quit.
 	close(s.quit)
 	return nil
@@ -2191,6 +2851,78 @@ func parseListeners(addrs []string) ([]string, []string, bool, error) {
 	return ipv4ListenAddrs, ipv6ListenAddrs, haveWildcard, nil
 }
 
+// AddListener starts listening for inbound peer connections on the passed
+// address, which includes the port.  An address with an empty host listens on
+// all IPv4 and IPv6 interfaces.  The bound address is advertised to peers
+// unless external IPs were specified.  It returns the addresses of the new
+// listeners.
+func (s *server) AddListener(addr string) ([]string, error) {
+	listeners, err := listenOn(net.Listen, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]string, 0, len(listeners))
+	for i, listener := range listeners {
+		if err := s.connManager.AddListener(listener); err != nil {
+			for _, listener := range listeners[i:] {
+				listener.Close()
+			}
+			return addrs, err
+		}
+		addrs = append(addrs, listener.Addr().String())
+	}
+
+	if len(cfg.ExternalIPs) == 0 {
+		if na, err := s.addrManager.DeserializeNetAddress(addr); err == nil {
+			err = s.addrManager.AddLocalAddress(na, addrmgr.BoundPrio)
+			if err != nil {
+				amgrLog.Debugf("Skipping bound address: %v", err)
+			}
+		}
+	}
+
+	return addrs, nil
+}
+
+// RemoveListener stops listening for inbound peer connections on the passed
+// address and stops advertising it.  An address with an empty host removes the
+// listeners on all interfaces for its port.  Peers that connected through the
+// removed listeners remain connected until they disconnect.  It returns the
+// addresses of the removed listeners.
+func (s *server) RemoveListener(addr string) ([]string, error) {
+	var addrs []string
+	for _, listenAddr := range s.connManager.ListenAddrs() {
+		if !listenerMatches(listenAddr, addr) {
+			continue
+		}
+		err := s.connManager.RemoveListener(listenAddr.String())
+		if err != nil {
+			return addrs, err
+		}
+		addrs = append(addrs, listenAddr.String())
+	}
+	if len(addrs) == 0 {
+		return nil, errNoListenerMatch
+	}
+
+	if na, err := s.addrManager.DeserializeNetAddress(addr); err == nil {
+		s.addrManager.RemoveLocalAddress(na)
+	}
+
+	return addrs, nil
+}
+
+// ListenAddrs returns the addresses inbound peer connections are accepted on.
+func (s *server) ListenAddrs() []string {
+	listenAddrs := s.connManager.ListenAddrs()
+	addrs := make([]string, 0, len(listenAddrs))
+	for _, addr := range listenAddrs {
+		addrs = append(addrs, addr.String())
+	}
+	return addrs
+}
+
 func (s *server) upnpUpdateThread() {
 	// Go off immediately to prevent code duplication, thereafter we renew
 	// lease every 15 minutes.
@@ -2247,12 +2979,23 @@ out:
 
 // newServer returns a new hcashd server configured to listen on addr for the
 // hypercash network type specified by chainParams.  Use start to begin accepting
-// connections from peers.
-func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Params) (*server, error) {
+// connections from peers.  Closing the interrupt channel aborts catching up
+// the optional indexes while the server is being created.
+func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Params, interrupt <-chan struct{}) (*server, error) {
 	services := defaultServices
 	if cfg.NoPeerBloomFilters {
 		services &^= wire.SFNodeBloom
 	}
+	if cfg.NoLightBlocks {
+		services &^= wire.SFNodeLightBlock
+	}
+	if cfg.CfIndex {
+		services |= wire.SFNodeCF
+	}
+	if cfg.Prune != 0 {
+		// Pruned nodes are unable to serve the full block chain.
+		services &^= wire.SFNodeNetwork
+	}
 
 	amgr := addrmgr.New(cfg.DataDir, hcashdLookup)
 
@@ -2380,6 +3123,22 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		}
 	}
 
+	noticeManager, err := newNoticeManager(chainParams)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the metrics and instrument the database with them when the
+	// metrics endpoint is enabled.
+	var serverMetrics *nodeMetrics
+	if len(cfg.MetricsListeners) > 0 {
+		serverMetrics, err = newNodeMetrics(cfg.MetricsListeners)
+		if err != nil {
+			return nil, err
+		}
+		db = serverMetrics.instrumentDB(db)
+	}
+
 	s := server{
 		chainParams:          chainParams,
 		addrManager:          amgr,
@@ -2392,11 +3151,14 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		quit:                 make(chan struct{}),
 		modifyRebroadcastInv: make(chan interface{}),
 		peerHeightsUpdate:    make(chan updatePeerHeightsMsg),
+		migrateAddrs:         make(chan net.Addr, 1),
 		nat:                  nat,
 		db:                   db,
 		timeSource:           blockchain.NewMedianTime(),
 		services:             services,
 		sigCache:             txscript.NewSigCache(cfg.SigCacheMaxSize),
+		noticeManager:        noticeManager,
+		metrics:              serverMetrics,
 	}
 
 	// Create the transaction and address indexes if needed.
@@ -2430,13 +3192,23 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		s.existsAddrIndex = indexers.NewExistsAddrIndex(db, chainParams)
 		indexes = append(indexes, s.existsAddrIndex)
 	}
+	if cfg.CfIndex {
+		indxLog.Info("Committed filter index is enabled")
+		s.cfIndex = indexers.NewCfIndex(db, chainParams)
+		indexes = append(indexes, s.cfIndex)
+	}
+	if cfg.VoteStatsIndex {
+		indxLog.Info("Vote stats index is enabled")
+		s.voteStatsIndex = indexers.NewVoteStatsIndex(db, chainParams)
+		indexes = append(indexes, s.voteStatsIndex)
+	}
 
 	// Create an index manager if any of the optional indexes are enabled.
 	var indexManager blockchain.IndexManager
 	if len(indexes) > 0 {
 		indexManager = indexers.NewManager(db, indexes, chainParams)
 	}
-	bm, err := newBlockManager(&s, indexManager)
+	bm, err := newBlockManager(&s, indexManager, interrupt)
 
 	if err != nil {
 		return nil, err
@@ -2444,17 +3216,7 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	s.blockManager = bm
 	SetBlockManager(bm)
 	txC := mempool.Config{
-		Policy: mempool.Policy{
-			MaxTxVersion:         1,
-			DisableRelayPriority: cfg.NoRelayPriority,
-			RelayNonStd:          cfg.RelayNonStd,
-			FreeTxRelayLimit:     cfg.FreeTxRelayLimit,
-			MaxOrphanTxs:         cfg.MaxOrphanTxs,
-			MaxOrphanTxSize:      defaultMaxOrphanTxSize,
-			MaxSigOpsPerTx:       blockchain.MaxSigOpsPerBlock / 5,
-			MinRelayTxFee:        cfg.minRelayTxFee,
-			AllowOldVotes:        cfg.AllowOldVotes,
-		},
+		Policy:      mempoolPolicy(cfg),
 		ChainParams: chainParams,
 		NextStakeDifficulty: func() (int64, error) {
 			bm.chainState.Lock()
@@ -2472,7 +3234,7 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 			keyHeight := snapshot.KeyHeight
 			hash := snapshot.Hash
 			bits := snapshot.Bits
-			if blockchain.HashToBig(hash).Cmp(blockchain.CompactToBig(bits)) <= 0{
+			if standalone.HashToBig(hash).Cmp(standalone.CompactToBig(bits)) <= 0 {
 				keyHeight++
 			}
 			return keyHeight
@@ -2492,16 +3254,40 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	}
 	s.txMemPool = mempool.New(&txC)
 
-	// Create the mining policy based on the configuration options.
-	// NOTE: The CPU miner relies on the mempool, so the mempool has to be
-	// created before calling the function to create the CPU miner.
+	// Create the mining policy and the block template generator based on
+	// the configuration options.
+	// NOTE: The block template generator relies on the mempool and the
+	// block manager, so both have to be created before the generator and
+	// the CPU miner which uses it.
 	policy := mining.Policy{
 		BlockMinSize:      cfg.BlockMinSize,
 		BlockMaxSize:      cfg.BlockMaxSize,
 		BlockPrioritySize: cfg.BlockPrioritySize,
 		TxMinFreeFee:      cfg.minRelayTxFee,
+		CoinbasePayouts:   &mining.CoinbasePayouts{},
+	}
+	if err := policy.CoinbasePayouts.Set(cfg.miningPayouts); err != nil {
+		return nil, err
+	}
+	s.blkTmplGenerator = mining.NewBlkTmplGenerator(&mining.Config{
+		Policy:           &policy,
+		TxSource:         s.txMemPool,
+		Chain:            bm.chain,
+		ChainParams:      s.chainParams,
+		TimeSource:       s.timeSource,
+		SigCache:         s.sigCache,
+		BlockManager:     bm,
+		AggressiveMining: bm.AggressiveMining,
+		MiningTimeOffset: cfg.MiningTimeOffset,
+	})
+	s.cpuMiner = newCPUMiner(&s, cfg.CPUMinerThreads)
+
+	if cfg.AutoRevoke {
+		s.revoker, err = newTicketRevoker(&s)
+		if err != nil {
+			return nil, err
+		}
 	}
-	s.cpuMiner = newCPUMiner(&policy, &s)
 
 	// Only setup a function to return new addresses to connect to when
 	// not running in connect-only mode.  The simulation network is always
@@ -2512,6 +3298,14 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	var newAddressFunc func() (net.Addr, error)
 	if !cfg.SimNet && len(cfg.ConnectPeers) == 0 {
 		newAddressFunc = func() (net.Addr, error) {
+			// Connect to the preferred peer an outbound peer is
+			// being migrated to first.
+			select {
+			case addr := <-s.migrateAddrs:
+				return addr, nil
+			default:
+			}
+
 			for tries := 0; tries < 100; tries++ {
 				addr := s.addrManager.GetAddress()
 				if addr == nil {
@@ -2529,6 +3323,12 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 					continue
 				}
 
+				// Tor addresses can't be reached when connecting to
+				// tor hidden services is disabled.
+				if cfg.NoOnion && addrmgr.IsOnionCatTor(addr.NetAddress()) {
+					continue
+				}
+
 				// only allow recent nodes (10mins) after we failed 30
 				// times
 				if tries < 30 && time.Now().Sub(addr.LastAttempt()) < 10*time.Minute {
@@ -2554,6 +3354,7 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	if cfg.MaxPeers < targetOutbound {
 		targetOutbound = cfg.MaxPeers
 	}
+	s.targetOutbound = targetOutbound
 	cmgr, err := connmgr.New(&connmgr.Config{
 		Listeners:      listeners,
 		OnAccept:       s.inboundPeerConnected,
@@ -2598,18 +3399,61 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		}()
 	}
 
+	if len(cfg.GRPCListeners) > 0 {
+		s.grpcServer, err = newGRPCServer(cfg.GRPCListeners, &s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.metrics != nil {
+		s.metrics.registerServer(&s)
+	}
+
 	return &s, nil
 }
 
+// onionAddr implements the net.Addr interface and represents a tor hidden
+// service address which, unlike other host names, can't be resolved to an IP
+// address and must be passed to the proxy as is.
+type onionAddr struct {
+	addr string
+}
+
+// String returns the onion address.
+//
+// This is part of the net.Addr interface.
+func (oa *onionAddr) String() string {
+	return oa.addr
+}
+
+// Network returns "onion".
+//
+// This is part of the net.Addr interface.
+func (oa *onionAddr) Network() string {
+	return "onion"
+}
+
+// Ensure onionAddr implements the net.Addr interface.
+var _ net.Addr = (*onionAddr)(nil)
+
 // addrStringToNetAddr takes an address in the form of 'host:port' and returns
 // a net.Addr which maps to the original address with any host names resolved
-// to IP addresses.
+// to IP addresses.  Tor addresses are returned as an onionAddr since they are
+// resolved by the proxy when the connection is made.
 func addrStringToNetAddr(addr string) (net.Addr, error) {
 	host, strPort, err := net.SplitHostPort(addr)
 	if err != nil {
 		return nil, err
 	}
 
+	if strings.HasSuffix(host, ".onion") {
+		if cfg.NoOnion {
+			return nil, errors.New("tor has been disabled")
+		}
+		return &onionAddr{addr: addr}, nil
+	}
+
 	// Attempt to look up an IP address associated with the parsed host.
 	// The hcashdLookup function will transparently handle performing the
 	// lookup over Tor if necessary.
@@ -2654,10 +3498,28 @@ func dynamicTickDuration(remaining time.Duration) time.Duration {
 	return time.Hour
 }
 
+// mempoolPolicy returns the mempool policy for the passed configuration.
+func mempoolPolicy(cfg *config) mempool.Policy {
+	return mempool.Policy{
+		MaxTxVersion:         1,
+		DisableRelayPriority: cfg.NoRelayPriority,
+		RelayNonStd:          cfg.RelayNonStd,
+		FreeTxRelayLimit:     cfg.FreeTxRelayLimit,
+		MaxOrphanTxs:         cfg.MaxOrphanTxs,
+		MaxOrphanTxSize:      defaultMaxOrphanTxSize,
+		MaxSigOpsPerTx:       blockchain.MaxSigOpsPerBlock / 5,
+		MaxScriptCostPerTx:   blockchain.MaxSigOpsPerBlock / 5 * txscript.AltSigCheckCost,
+		MinRelayTxFee:        cfg.minRelayTxFee,
+		AllowOldVotes:        cfg.AllowOldVotes,
+		MaxPoolSize:          cfg.MaxMempoolSize,
+	}
+}
+
 // isWhitelisted returns whether the IP address is included in the whitelisted
 // networks and IPs.
 func isWhitelisted(addr net.Addr) bool {
-	if len(cfg.whitelists) == 0 {
+	whitelists := currentBanSettings().whitelists
+	if len(whitelists) == 0 {
 		return false
 	}
 
@@ -2672,7 +3534,7 @@ func isWhitelisted(addr net.Addr) bool {
 		return false
 	}
 
-	for _, ipnet := range cfg.whitelists {
+	for _, ipnet := range whitelists {
 		if ipnet.Contains(ip) {
 			return true
 		}
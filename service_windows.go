@@ -62,6 +62,7 @@ func (s *hcashdService) Execute(args []string, r <-chan svc.ChangeRequest, chang
 	// quickly.  Shutdown (along with a potential error) is reported via
 	// doneChan.  serverChan is notified with the main server instance once
 	// it is started so it can be gracefully stopped.
+	runningAsService = true
 	doneChan := make(chan error)
 	serverChan := make(chan *server)
 	go func() {
@@ -18,6 +18,11 @@ var shutdownRequestChannel = make(chan struct{})
 // shutdown.  This may be modified during init depending on the platform.
 var interruptSignals = []os.Signal{os.Interrupt}
 
+// reloadSignals defines the signals to catch in order to reload the
+// configuration.  There are none by default, which may be modified during init
+// depending on the platform.
+var reloadSignals []os.Signal
+
 // interruptListener listens for OS Signals such as SIGINT (Ctrl+C) and shutdown
 // requests from shutdownRequestChannel.  It returns a channel that is closed
 // when either signal is received.
@@ -70,3 +75,34 @@ func interruptRequested(interrupted <-chan struct{}) bool {
 
 	return false
 }
+
+// reloadListener listens for OS signals such as SIGHUP and reloads the
+// configuration of the passed server each time one is received until the
+// passed channel is closed.  Invalid configurations are logged and otherwise
+// ignored so the server keeps running with the current configuration.
+func reloadListener(s *server, interrupted <-chan struct{}) {
+	if len(reloadSignals) == 0 {
+		return
+	}
+
+	go func() {
+		reloadChannel := make(chan os.Signal, 1)
+		signal.Notify(reloadChannel, reloadSignals...)
+		defer signal.Stop(reloadChannel)
+
+		for {
+			select {
+			case sig := <-reloadChannel:
+				hcashdLog.Infof("Received signal (%s).  Reloading "+
+					"configuration...", sig)
+				if _, _, err := s.reloadConfig(); err != nil {
+					hcashdLog.Errorf("Unable to reload the "+
+						"configuration: %v", err)
+				}
+
+			case <-interrupted:
+				return
+			}
+		}
+	}()
+}
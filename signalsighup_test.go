@@ -0,0 +1,81 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/sampleconfig"
+	"github.com/btcsuite/btclog"
+)
+
+// TestReloadListenerSIGHUP ensures a SIGHUP reloads the configuration, and that
+// an invalid configuration leaves the running one in place.
+func TestReloadListenerSIGHUP(t *testing.T) {
+	// The reload is logged, but there is no log rotator to write to in
+	// tests.
+	for _, logger := range subsystemLoggers {
+		defer logger.SetLevel(logger.Level())
+		logger.SetLevel(btclog.LevelOff)
+	}
+
+	// Make the configuration invalid before anything else is loaded so the
+	// reload fails without any side effects.
+	env := sampleconfig.EnvVar("maxpeers")
+	os.Setenv(env, "invalid")
+	defer os.Unsetenv(env)
+
+	oldCfg := cfg
+	cfg = &config{}
+	cfg.MaxPeers = 5
+	defer func() {
+		cfg = oldCfg
+		reloadMtx.Lock()
+		runningCfg = nil
+		reloadMtx.Unlock()
+	}()
+
+	// Keep catching SIGHUP for the whole test so a signal sent before the
+	// listener is registered does not terminate the process.
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
+
+	interrupted := make(chan struct{})
+	defer close(interrupted)
+	reloadListener(&server{}, interrupted)
+
+	// reloaded returns the running configuration once a reload happened.
+	reloaded := func() *config {
+		reloadMtx.Lock()
+		defer reloadMtx.Unlock()
+		return runningCfg
+	}
+	// The signal is only sent again when it was not handled for a while,
+	// which only happens when it arrived before the listener registered
+	// for it, so no further reload runs once the test ends.
+	for i := 0; reloaded() == nil; i++ {
+		if i == 10 {
+			t.Fatal("configuration not reloaded on SIGHUP")
+		}
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatalf("Kill: %v", err)
+		}
+		for j := 0; j < 50 && reloaded() == nil; j++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if running := reloaded(); running.MaxPeers != 5 {
+		t.Fatalf("invalid reload: got max peers %d, want 5",
+			running.MaxPeers)
+	}
+}
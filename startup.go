@@ -0,0 +1,101 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// slowStartupThreshold is the total startup time after which the time taken
+// by each startup phase is reported as a warning by the getinfo RPC.
+const slowStartupThreshold = time.Minute
+
+// startupPhase houses the name and the time taken by a startup phase.
+type startupPhase struct {
+	name     string
+	duration time.Duration
+}
+
+// startupTimer tracks the time taken by each phase of the startup process so
+// slow startups can be diagnosed.
+type startupTimer struct {
+	sync.Mutex
+	start     time.Time
+	lastPhase time.Time
+	phases    []startupPhase
+	complete  bool
+}
+
+// startupTimings tracks the startup phases of the running process.
+var startupTimings = newStartupTimer()
+
+// newStartupTimer returns a new startup timer starting at the current time.
+func newStartupTimer() *startupTimer {
+	now := time.Now()
+	return &startupTimer{start: now, lastPhase: now}
+}
+
+// phaseDone records the time taken by the named phase, which is the time
+// since the previous phase completed or the timer was created.
+//
+// This function is safe for concurrent access.
+func (t *startupTimer) phaseDone(name string) {
+	t.Lock()
+	now := time.Now()
+	t.phases = append(t.phases, startupPhase{name, now.Sub(t.lastPhase)})
+	t.lastPhase = now
+	t.Unlock()
+}
+
+// done marks the startup process as complete, logging the time taken by each
+// phase.
+//
+// This function is safe for concurrent access.
+func (t *startupTimer) done() {
+	t.Lock()
+	t.complete = true
+	summary := t.summary()
+	t.Unlock()
+
+	hcashdLog.Infof("Startup complete (%s)", summary)
+}
+
+// summary returns a human readable description of the time taken by each
+// completed phase along with the total time.
+//
+// This function MUST be called with the startup timer lock held.
+func (t *startupTimer) summary() string {
+	parts := make([]string, 0, len(t.phases)+1)
+	for _, phase := range t.phases {
+		parts = append(parts, fmt.Sprintf("%s %v", phase.name,
+			roundDuration(phase.duration)))
+	}
+	parts = append(parts, fmt.Sprintf("total %v",
+		roundDuration(t.lastPhase.Sub(t.start))))
+	return strings.Join(parts, ", ")
+}
+
+// warning returns a warning describing the time taken by each startup phase
+// when the startup process completed and took longer than the slow startup
+// threshold, or an empty string otherwise.
+//
+// This function is safe for concurrent access.
+func (t *startupTimer) warning() string {
+	t.Lock()
+	defer t.Unlock()
+
+	if !t.complete || t.lastPhase.Sub(t.start) < slowStartupThreshold {
+		return ""
+	}
+	return "Slow startup: " + t.summary()
+}
+
+// roundDuration rounds the passed duration to milliseconds for display.
+func roundDuration(d time.Duration) time.Duration {
+	return d / time.Millisecond * time.Millisecond
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btclog"
+)
+
+// TestStartupTimerWarning ensures a startup warning listing every phase is only
+// reported once a startup which took longer than the slow startup threshold
+// completed.
+func TestStartupTimerWarning(t *testing.T) {
+	// The completed startup is logged, but there is no log rotator to write
+	// to in tests.
+	defer hcashdLog.SetLevel(hcashdLog.Level())
+	hcashdLog.SetLevel(btclog.LevelOff)
+
+	fast := newStartupTimer()
+	fast.phaseDone("database")
+	fast.done()
+	if warning := fast.warning(); warning != "" {
+		t.Fatalf("fast startup: got warning %q, want none", warning)
+	}
+
+	slow := newStartupTimer()
+	slow.start = slow.start.Add(-2 * slowStartupThreshold)
+	slow.lastPhase = slow.start
+	slow.phaseDone("database")
+	slow.phaseDone("chain")
+	if warning := slow.warning(); warning != "" {
+		t.Fatalf("incomplete startup: got warning %q, want none",
+			warning)
+	}
+	slow.done()
+	warning := slow.warning()
+	if !strings.HasPrefix(warning, "Slow startup: database ") ||
+		!strings.Contains(warning, ", chain ") ||
+		!strings.Contains(warning, ", total ") {
+
+		t.Fatalf("slow startup: got warning %q, want the time taken by "+
+			"each phase", warning)
+	}
+	if got := slow.phases[0].duration; got < 2*slowStartupThreshold {
+		t.Fatalf("slow startup: database phase took %v, want at least %v",
+			got, 2*slowStartupThreshold)
+	}
+
+	if got := roundDuration(1500*time.Microsecond + 7); got !=
+		time.Millisecond {
+
+		t.Fatalf("roundDuration: got %v, want %v", got, time.Millisecond)
+	}
+}
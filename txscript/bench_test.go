@@ -0,0 +1,262 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	bs "github.com/HcashOrg/hcashd/crypto/bliss"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// The benchmarks in this file measure the execution times the script cost
+// model in cost.go is derived from.  The opcode benchmarks execute a single
+// opcode on prepared stack items, so their times relative to BenchmarkOpDup
+// are the opcode costs in cost units.  The script class benchmarks create an
+// engine for and fully execute the scripts of a standard input, which is what
+// the signature checking costs are derived from.
+
+// newBenchSpendingTx returns a transaction spending the output of a coinbase
+// transaction paying to the passed public key script with the passed signature
+// script.  It mirrors createSpendingTx of the external test package, which is
+// not available to the benchmarks since they need access to the opcodes.
+func newBenchSpendingTx(sigScript, pkScript []byte) *wire.MsgTx {
+	coinbaseTx := wire.NewMsgTx()
+	outPoint := wire.NewOutPoint(&chainhash.Hash{}, ^uint32(0),
+		wire.TxTreeRegular)
+	coinbaseTx.AddTxIn(wire.NewTxIn(outPoint, []byte{OP_0, OP_0}))
+	coinbaseTx.AddTxOut(wire.NewTxOut(0, pkScript))
+
+	spendingTx := wire.NewMsgTx()
+	coinbaseTxHash := coinbaseTx.TxHash()
+	outPoint = wire.NewOutPoint(&coinbaseTxHash, 0, wire.TxTreeRegular)
+	spendingTx.AddTxIn(wire.NewTxIn(outPoint, sigScript))
+	spendingTx.AddTxOut(wire.NewTxOut(0, nil))
+	return spendingTx
+}
+
+// benchmarkOpcode benchmarks executing the passed opcode with the passed items
+// pushed to the data stack before every execution.
+func benchmarkOpcode(b *testing.B, op byte, items ...[]byte) {
+	pkScript := []byte{OP_TRUE}
+	vm, err := NewEngine(pkScript, newBenchSpendingTx(nil, pkScript), 0, 0,
+		0, nil)
+	if err != nil {
+		b.Fatalf("failed to create engine: %v", err)
+	}
+	pop := parsedOpcode{opcode: &opcodeArray[op]}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, item := range items {
+			vm.dstack.PushByteArray(item)
+		}
+		if err := pop.opcode.opfunc(&pop, vm); err != nil {
+			b.Fatalf("failed to execute %s: %v", pop.opcode.name, err)
+		}
+		vm.dstack.stk = vm.dstack.stk[:0]
+	}
+}
+
+// maxSizeElement is a stack item of the maximum script element size, which is
+// the most expensive input of the hashing opcodes.
+var maxSizeElement = bytes.Repeat([]byte{0x5a}, MaxScriptElementSize)
+
+// BenchmarkOpDup benchmarks OP_DUP, which defines one cost unit.
+func BenchmarkOpDup(b *testing.B) {
+	benchmarkOpcode(b, OP_DUP, []byte{0x01})
+}
+
+// BenchmarkOpAdd benchmarks OP_ADD.
+func BenchmarkOpAdd(b *testing.B) {
+	benchmarkOpcode(b, OP_ADD, []byte{0x01}, []byte{0x02})
+}
+
+// BenchmarkOpEqual benchmarks OP_EQUAL on two hash sized items.
+func BenchmarkOpEqual(b *testing.B) {
+	item := bytes.Repeat([]byte{0x5a}, 32)
+	benchmarkOpcode(b, OP_EQUAL, item, item)
+}
+
+// BenchmarkOpRipemd160 benchmarks OP_RIPEMD160 on a maximum size element.
+func BenchmarkOpRipemd160(b *testing.B) {
+	benchmarkOpcode(b, OP_RIPEMD160, maxSizeElement)
+}
+
+// BenchmarkOpSha1 benchmarks OP_SHA1 on a maximum size element.
+func BenchmarkOpSha1(b *testing.B) {
+	benchmarkOpcode(b, OP_SHA1, maxSizeElement)
+}
+
+// BenchmarkOpSha256 benchmarks OP_SHA256 on a maximum size element.
+func BenchmarkOpSha256(b *testing.B) {
+	benchmarkOpcode(b, OP_SHA256, maxSizeElement)
+}
+
+// BenchmarkOpHash160 benchmarks OP_HASH160 on a maximum size element.
+func BenchmarkOpHash160(b *testing.B) {
+	benchmarkOpcode(b, OP_HASH160, maxSizeElement)
+}
+
+// BenchmarkOpHash256 benchmarks OP_HASH256 on a maximum size element.
+func BenchmarkOpHash256(b *testing.B) {
+	benchmarkOpcode(b, OP_HASH256, maxSizeElement)
+}
+
+// benchmarkScript benchmarks creating an engine for and executing the
+// signature script of the first input of the passed transaction against the
+// passed public key script.
+func benchmarkScript(b *testing.B, tx *wire.MsgTx, pkScript []byte) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vm, err := NewEngine(pkScript, tx, 0, ScriptBip16, 0, nil)
+		if err != nil {
+			b.Fatalf("failed to create engine: %v", err)
+		}
+		if err := vm.Execute(); err != nil {
+			b.Fatalf("failed to execute script: %v", err)
+		}
+	}
+}
+
+// newBenchSecpKey returns a new secp256k1 private key along with its
+// serialized compressed public key.
+func newBenchSecpKey(b *testing.B) (chainec.PrivateKey, []byte) {
+	privBytes, pubX, pubY, err := chainec.Secp256k1.GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatalf("failed to generate key: %v", err)
+	}
+	priv := chainec.Secp256k1.NewPrivateKey(new(big.Int).SetBytes(privBytes))
+	pub := chainec.Secp256k1.NewPublicKey(pubX, pubY)
+	return priv, pub.SerializeCompressed()
+}
+
+// BenchmarkScriptPubKeyHash benchmarks validating a pay-to-pubkey-hash input.
+func BenchmarkScriptPubKeyHash(b *testing.B) {
+	priv, pubKey := newBenchSecpKey(b)
+	pkScript, err := payToPubKeyHashScript(hcashutil.Hash160(pubKey))
+	if err != nil {
+		b.Fatalf("failed to create script: %v", err)
+	}
+	tx := newBenchSpendingTx(nil, pkScript)
+	sigScript, err := SignatureScript(tx, 0, pkScript, SigHashAll, priv,
+		true)
+	if err != nil {
+		b.Fatalf("failed to sign input: %v", err)
+	}
+	tx.TxIn[0].SignatureScript = sigScript
+
+	benchmarkScript(b, tx, pkScript)
+}
+
+// BenchmarkScriptMultiSigP2SH benchmarks validating a pay-to-script-hash input
+// that redeems a 2-of-3 multisig script.
+func BenchmarkScriptMultiSigP2SH(b *testing.B) {
+	priv1, pubKey1 := newBenchSecpKey(b)
+	priv2, pubKey2 := newBenchSecpKey(b)
+	_, pubKey3 := newBenchSecpKey(b)
+	redeemScript, err := NewScriptBuilder().AddOp(OP_2).AddData(pubKey1).
+		AddData(pubKey2).AddData(pubKey3).AddOp(OP_3).
+		AddOp(OP_CHECKMULTISIG).Script()
+	if err != nil {
+		b.Fatalf("failed to create redeem script: %v", err)
+	}
+	pkScript, err := PayToScriptHashScript(hcashutil.Hash160(redeemScript))
+	if err != nil {
+		b.Fatalf("failed to create script: %v", err)
+	}
+
+	tx := newBenchSpendingTx(nil, pkScript)
+	sig1, err := RawTxInSignature(tx, 0, redeemScript, SigHashAll, priv1)
+	if err != nil {
+		b.Fatalf("failed to sign input: %v", err)
+	}
+	sig2, err := RawTxInSignature(tx, 0, redeemScript, SigHashAll, priv2)
+	if err != nil {
+		b.Fatalf("failed to sign input: %v", err)
+	}
+	sigScript, err := NewScriptBuilder().AddData(sig1).AddData(sig2).
+		AddData(redeemScript).Script()
+	if err != nil {
+		b.Fatalf("failed to create signature script: %v", err)
+	}
+	tx.TxIn[0].SignatureScript = sigScript
+
+	benchmarkScript(b, tx, pkScript)
+}
+
+// benchmarkScriptPubKeyHashAlt benchmarks validating a pay-to-pubkey-hash
+// input of an alternative signature suite with the passed private key and
+// serialized public key.
+func benchmarkScriptPubKeyHashAlt(b *testing.B, sigType sigTypes, priv chainec.PrivateKey, pubKey []byte) {
+	var pkScript []byte
+	var err error
+	pubKeyHash := hcashutil.Hash160(pubKey)
+	switch sigType {
+	case edwards:
+		pkScript, err = payToPubKeyHashEdwardsScript(pubKeyHash)
+	case secSchnorr:
+		pkScript, err = payToPubKeyHashSchnorrScript(pubKeyHash)
+	case bliss:
+		pkScript, err = payToPubKeyHashBlissScript(pubKeyHash)
+	}
+	if err != nil {
+		b.Fatalf("failed to create script: %v", err)
+	}
+
+	tx := newBenchSpendingTx(nil, pkScript)
+	sigScript, err := SignatureScriptAlt(tx, 0, pkScript, SigHashAll, priv,
+		true, int(sigType))
+	if err != nil {
+		b.Fatalf("failed to sign input: %v", err)
+	}
+	tx.TxIn[0].SignatureScript = sigScript
+
+	benchmarkScript(b, tx, pkScript)
+}
+
+// BenchmarkScriptPubKeyHashEdwards benchmarks validating a pay-to-pubkey-hash
+// input with an Ed25519 signature.
+func BenchmarkScriptPubKeyHashEdwards(b *testing.B) {
+	keyBytes, _, _, err := chainec.Edwards.GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatalf("failed to generate key: %v", err)
+	}
+	priv, _ := chainec.Edwards.PrivKeyFromBytes(keyBytes)
+	pubKey := chainec.Edwards.NewPublicKey(priv.Public()).Serialize()
+	benchmarkScriptPubKeyHashAlt(b, edwards, priv, pubKey)
+}
+
+// BenchmarkScriptPubKeyHashSchnorr benchmarks validating a pay-to-pubkey-hash
+// input with a secp256k1 Schnorr signature.
+func BenchmarkScriptPubKeyHashSchnorr(b *testing.B) {
+	keyBytes, _, _, err := chainec.SecSchnorr.GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatalf("failed to generate key: %v", err)
+	}
+	priv, _ := chainec.SecSchnorr.PrivKeyFromBytes(keyBytes)
+	pubKey := chainec.SecSchnorr.NewPublicKey(priv.Public()).Serialize()
+	benchmarkScriptPubKeyHashAlt(b, secSchnorr, priv, pubKey)
+}
+
+// BenchmarkScriptPubKeyHashBliss benchmarks validating a pay-to-pubkey-hash
+// input with a BLISS signature, which is the most expensive benchmarked
+// alternative signature suite and thus defines AltSigCheckCost.
+func BenchmarkScriptPubKeyHashBliss(b *testing.B) {
+	key, _, err := bs.Bliss.GenerateKey(rand.Reader)
+	if err != nil {
+		b.Fatalf("failed to generate key: %v", err)
+	}
+	priv := *key.(*bs.PrivateKey)
+	benchmarkScriptPubKeyHashAlt(b, bliss, priv, priv.PublicKey().Serialize())
+}
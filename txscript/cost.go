@@ -0,0 +1,101 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+// The script cost model approximates the time needed to validate scripts in
+// abstract cost units.  One unit is roughly the time needed to execute an
+// opcode that only manipulates the stack or small numbers, such as OP_DUP or
+// OP_ADD.  The costs of the other opcodes are their execution times relative
+// to those opcodes as measured by the opcode and script class benchmarks in
+// bench_test.go, rounded up.  The benchmarks should be rerun and the costs
+// updated whenever the implementation of an expensive opcode changes.
+const (
+	// BaseOpcodeCost is the cost of executing an opcode that only
+	// manipulates the stack or small numbers, including data pushes.
+	BaseOpcodeCost = 1
+
+	// HashOpcodeCost is the cost of executing one of the hashing opcodes
+	// on an element of the maximum script element size.
+	HashOpcodeCost = 20
+
+	// SigCheckCost is the cost of verifying a secp256k1 signature with
+	// OP_CHECKSIG or OP_CHECKSIGVERIFY, including calculating the
+	// signature hash of the transaction.  The opcodes OP_CHECKMULTISIG and
+	// OP_CHECKMULTISIGVERIFY cost this per public key since every public
+	// key may have to be checked against a signature.
+	SigCheckCost = 800
+
+	// AltSigCheckCost is the cost of verifying a signature with
+	// OP_CHECKSIGALT or OP_CHECKSIGALTVERIFY.  The signature suite is only
+	// known when the script is executed, so this is the cost of the BLISS
+	// suite, which is the most expensive benchmarked suite.
+	AltSigCheckCost = 2400
+)
+
+// opcodeCosts houses the cost of the opcodes that cost more than
+// BaseOpcodeCost.  The cost of multisig opcodes depends on the number of
+// public keys and is calculated by getScriptCost.
+var opcodeCosts = map[byte]int{
+	OP_RIPEMD160:         HashOpcodeCost,
+	OP_SHA1:              HashOpcodeCost,
+	OP_SHA256:            HashOpcodeCost,
+	OP_HASH160:           HashOpcodeCost,
+	OP_HASH256:           HashOpcodeCost,
+	OP_CHECKSIG:          SigCheckCost,
+	OP_CHECKSIGVERIFY:    SigCheckCost,
+	OP_CHECKSIGALT:       AltSigCheckCost,
+	OP_CHECKSIGALTVERIFY: AltSigCheckCost,
+}
+
+// getScriptCost returns the estimated cost of executing every opcode in the
+// script provided by pops.  Like getSigOpCount in precise mode, the number of
+// public keys of multisig opcodes is taken from a preceding small integer when
+// present and is assumed to be the maximum otherwise.
+func getScriptCost(pops []parsedOpcode) int {
+	cost := 0
+	for i, pop := range pops {
+		switch pop.opcode.value {
+		case OP_CHECKMULTISIG, OP_CHECKMULTISIGVERIFY:
+			numPubKeys := MaxPubKeysPerMultiSig
+			if i > 0 && isSmallInt(pops[i-1].opcode) {
+				numPubKeys = asSmallInt(pops[i-1].opcode)
+			}
+			cost += numPubKeys * SigCheckCost
+		default:
+			opCost, ok := opcodeCosts[pop.opcode.value]
+			if !ok {
+				opCost = BaseOpcodeCost
+			}
+			cost += opCost
+		}
+	}
+
+	return cost
+}
+
+// EstimateScriptCost returns the estimated cost of validating the passed
+// signature script against the passed public key script according to the
+// script cost model.  If bip16 is true and the public key script is a
+// pay-to-script-hash, the cost of executing the redeem script pushed by the
+// signature script is included as well.  The estimate is an upper bound on the
+// cost since every opcode is assumed to be executed regardless of conditional
+// branches.  If a script fails to parse, the cost of the opcodes up to the
+// point of failure is counted just like for signature operations.
+func EstimateScriptCost(scriptSig, scriptPubKey []byte, bip16 bool) int {
+	// Don't check errors since parseScript returns the parsed-up-to-error
+	// list of pops.
+	sigPops, _ := parseScript(scriptSig)
+	pops, _ := parseScript(scriptPubKey)
+	cost := getScriptCost(sigPops) + getScriptCost(pops)
+
+	// The redeem script of a pay-to-script-hash is the last item the
+	// signature script pushes to the stack.
+	if !bip16 || !isAnyKindOfScriptHash(pops) || len(sigPops) == 0 ||
+		!isPushOnly(sigPops) {
+		return cost
+	}
+	shPops, _ := parseScript(sigPops[len(sigPops)-1].data)
+	return cost + getScriptCost(shPops)
+}
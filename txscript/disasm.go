@@ -0,0 +1,128 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// voteBlockRefLen is the length of the data pushed by the OP_RETURN output of
+// a vote, which references the voted on block by its hash, height, and key
+// height.
+const voteBlockRefLen = chainhash.HashSize + 4 + 4
+
+// stakeOpcodeLabels are the annotations of the opcodes which tag the outputs
+// of stake transactions.
+var stakeOpcodeLabels = map[byte]string{
+	OP_SSTX:       "stake:ticket",
+	OP_SSGEN:      "stake:vote",
+	OP_SSRTX:      "stake:revocation",
+	OP_SSTXCHANGE: "stake:ticketchange",
+}
+
+// annotation returns the annotation of the opcode, which is parsed after the
+// passed previous opcode (nil for the first opcode), or an empty string when
+// there is nothing to annotate.  Public keys are annotated with the pay-to-
+// pubkey-hash address they derive for the passed network parameters, which
+// may be nil to skip the address derivation.
+func (pop *parsedOpcode) annotation(prev *parsedOpcode, chainParams *chaincfg.Params) string {
+	if label, ok := stakeOpcodeLabels[pop.opcode.value]; ok {
+		return label
+	}
+
+	// Nothing more to do for non-data push opcodes.
+	if pop.opcode.length == 1 {
+		return ""
+	}
+
+	data := pop.data
+	switch {
+	// The block a vote votes on.
+	case prev != nil && prev.opcode.value == OP_RETURN &&
+		len(data) == voteBlockRefLen:
+
+		var hash chainhash.Hash
+		copy(hash[:], data[:chainhash.HashSize])
+		height := binary.LittleEndian.Uint32(data[chainhash.HashSize:])
+		keyHeight := binary.LittleEndian.Uint32(data[chainhash.HashSize+4:])
+		return fmt.Sprintf("voteblock:%v height:%d keyheight:%d", hash,
+			height, keyHeight)
+
+	case len(data) == 33 || len(data) == 65:
+		pubKey, err := chainec.Secp256k1.ParsePubKey(data)
+		if err != nil {
+			break
+		}
+		if chainParams == nil {
+			return "pubkey"
+		}
+		addr, err := hcashutil.NewAddressSecpPubKeyCompressed(pubKey,
+			chainParams)
+		if err != nil {
+			return "pubkey"
+		}
+		return "pubkey:" + addr.EncodeAddress()
+
+	case len(data) == 20:
+		return "hash160"
+
+	case len(data) == chainhash.HashSize:
+		return "hash"
+
+	// Small pushes are usually numbers, such as block heights and lock
+	// times.
+	case len(data) <= 4:
+		num, err := makeScriptNum(data, false, 4)
+		if err != nil {
+			break
+		}
+		return fmt.Sprintf("int:%d", num)
+	}
+
+	return ""
+}
+
+// DisasmStringAnnotated formats a disassembled script for one line printing
+// like DisasmString, but additionally labels the opcodes in square brackets
+// following them.  The labels identify public keys along with the addresses
+// they derive for the passed network parameters, hashes, numbers such as
+// heights, the blocks referenced by votes, and the opcodes tagging stake
+// outputs.  The network parameters may be nil, in which case no addresses are
+// derived.
+//
+// The returned string is intended for humans and its format may change.
+func DisasmStringAnnotated(buf []byte, chainParams *chaincfg.Params) (string, error) {
+	var disbuf bytes.Buffer
+	opcodes, err := parseScript(buf)
+	for i := range opcodes {
+		pop := &opcodes[i]
+		disbuf.WriteString(pop.print(true))
+
+		var prev *parsedOpcode
+		if i > 0 {
+			prev = &opcodes[i-1]
+		}
+		if label := pop.annotation(prev, chainParams); label != "" {
+			disbuf.WriteByte('[')
+			disbuf.WriteString(label)
+			disbuf.WriteByte(']')
+		}
+		disbuf.WriteByte(' ')
+	}
+	if disbuf.Len() > 0 {
+		disbuf.Truncate(disbuf.Len() - 1)
+	}
+	if err != nil {
+		disbuf.WriteString("[error]")
+	}
+	return disbuf.String(), err
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript_test
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/txscript"
+)
+
+// TestDisasmStringAnnotated ensures the annotated disassembly labels public
+// keys, hashes, numbers, vote block references, and stake opcodes.
+func TestDisasmStringAnnotated(t *testing.T) {
+	t.Parallel()
+
+	pubKey := "02192d74d0cb94344c9569c2e77901573d8d7903c3ebec3a957724895dca52c6b4"
+	pubKeyAddr := newAddressPubKey(decodeHex(pubKey)).EncodeAddress()
+
+	tests := []struct {
+		name   string
+		script string
+		params *chaincfg.Params
+		want   string
+		err    bool
+	}{
+		{
+			name:   "p2pk",
+			script: "DATA_33 0x" + pubKey + " CHECKSIG",
+			params: &chaincfg.MainNetParams,
+			want: pubKey + "[pubkey:" + pubKeyAddr + "] " +
+				"OP_CHECKSIG",
+		},
+		{
+			name:   "p2pk without params",
+			script: "DATA_33 0x" + pubKey + " CHECKSIG",
+			want:   pubKey + "[pubkey] OP_CHECKSIG",
+		},
+		{
+			name: "ticket p2pkh",
+			script: "SSTX DUP HASH160 DATA_20 0x" +
+				"0102030405060708090a0b0c0d0e0f1011121314 " +
+				"EQUALVERIFY CHECKSIG",
+			want: "OP_SSTX[stake:ticket] OP_DUP OP_HASH160 " +
+				"0102030405060708090a0b0c0d0e0f1011121314" +
+				"[hash160] OP_EQUALVERIFY OP_CHECKSIG",
+		},
+		{
+			name: "vote block reference",
+			script: "RETURN DATA_40 0x" +
+				"0100000000000000000000000000000000000000" +
+				"000000000000000000000000" + "e8030000" +
+				"64000000",
+			want: "OP_RETURN " +
+				"0100000000000000000000000000000000000000" +
+				"000000000000000000000000e803000064000000" +
+				"[voteblock:0000000000000000000000000000000" +
+				"000000000000000000000000000000001 " +
+				"height:1000 keyheight:100]",
+		},
+		{
+			name: "hash and number",
+			script: "DATA_32 0x" +
+				"0000000000000000000000000000000000000000" +
+				"000000000000000000000000 DATA_2 0xe803 DROP",
+			want: "0000000000000000000000000000000000000000" +
+				"000000000000000000000000[hash] e803[int:1000] " +
+				"OP_DROP",
+		},
+		{
+			name:   "parse failure",
+			script: "SSGEN DATA_2 0x01",
+			want:   "OP_SSGEN[stake:vote][error]",
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := txscript.DisasmStringAnnotated(
+			mustParseShortForm(test.script), test.params)
+		if (err != nil) != test.err {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got,
+				test.want)
+		}
+	}
+}
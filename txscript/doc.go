@@ -27,6 +27,14 @@ is used to prove the the spender is authorized to perform the transaction.
 One benefit of using a scripting language is added flexibility in specifying
 what conditions must be met in order to spend hypercashs.
 
+Script Costs
+
+The time needed to validate scripts varies greatly between opcodes, and in
+particular between the signature suites.  EstimateScriptCost estimates the cost
+of validating a script pair from a cost model derived from the benchmarks of
+the package, which allows policy limits to account for more than the number of
+signature operations.
+
 Errors
 
 Errors returned by this package are of the form txscript.ErrStackX where X
@@ -10,6 +10,7 @@ import (
 	"math/big"
 
 	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	hcashcrypto "github.com/HcashOrg/hcashd/crypto"
 	"github.com/HcashOrg/hcashd/wire"
 )
 
@@ -70,6 +71,15 @@ const (
 	// ScriptVerifyStrictEncoding defines that signature scripts and
 	// public keys must follow the strict encoding requirements.
 	ScriptVerifyStrictEncoding
+
+	// ScriptSigHashOptimization defines whether the cached hash of the
+	// transaction is used as the prefix hash when verifying signatures with
+	// hashType SIGHASH_ALL.  Although there should be no consequences to
+	// daemons that are simply running a node, the cached hash is stale
+	// when a transaction is mutated after it was hashed, so this flag must
+	// not be used for transactions which may be modified, such as when
+	// mining or running a wallet.  This flag is EXPERIMENTAL.
+	ScriptSigHashOptimization
 )
 
 const (
@@ -103,10 +113,52 @@ type Engine struct {
 	numOps          int
 	flags           ScriptFlags
 	sigCache        *SigCache
+	sigAggregator   SigAggregator
+	tracer          Tracer
 	bip16           bool     // treat execution as pay-to-script-hash
 	savedFirstStack [][]byte // stack from first script for bip16 scripts
 }
 
+// SigAggregator defers the verification of signatures checked by the engine
+// so they can be verified together as an aggregate once all of the scripts
+// they belong to were executed.
+//
+// This is an experimental research hook for compressing the vote signatures of
+// key blocks.
+type SigAggregator interface {
+	// AddSig adds the signature of hash by pubKey with the passed signature
+	// type to the aggregate and returns whether it was accepted.  A
+	// signature which is not accepted is verified by the engine right
+	// away.
+	AddSig(sigType int, pubKey hcashcrypto.PublicKey, hash []byte,
+		sig hcashcrypto.Signature) bool
+}
+
+// SetSigAggregator sets the aggregator used to defer the verification of the
+// signatures checked by OP_CHECKSIGALT and OP_CHECKSIGALTVERIFY.  Only the
+// signatures whose result can not be observed by the script other than by
+// failing it are deferred, that is those checked by OP_CHECKSIGALTVERIFY or by
+// the final opcode of the execution, since they are assumed valid until the
+// aggregate is verified.  It MUST be called before Execute.
+//
+// The caller is responsible for verifying the aggregate and treating the
+// execution as failed when the aggregate is invalid.
+func (vm *Engine) SetSigAggregator(agg SigAggregator) {
+	vm.sigAggregator = agg
+}
+
+// isFinalOpcode returns whether the opcode being executed is the last one of
+// the execution.
+func (vm *Engine) isFinalOpcode() bool {
+	// The redeem script of pay-to-script-hash executions follows the
+	// public key script.
+	if vm.bip16 && vm.scriptIdx < 2 {
+		return false
+	}
+	return vm.scriptIdx == len(vm.scripts)-1 &&
+		vm.scriptOff == len(vm.scripts[vm.scriptIdx])-1
+}
+
 // hasFlag returns whether the script engine instance has the passed flag set.
 func (vm *Engine) hasFlag(flag ScriptFlags) bool {
 	return vm.flags&flag == flag
@@ -272,11 +324,15 @@ func (vm *Engine) Step() (done bool, err error) {
 		return true, err
 	}
 	opcode := &vm.scripts[vm.scriptIdx][vm.scriptOff]
+	executed := vm.isBranchExecuting() || opcode.isConditional()
 
 	// Execute the opcode while taking into account several things such as
 	// disabled opcodes, illegal opcodes, maximum allowed operations per
 	// script, maximum script element sizes, and conditionals.
 	err = vm.executeOpcode(opcode)
+	if vm.tracer != nil {
+		vm.traceStep(opcode, executed, err)
+	}
 	if err != nil {
 		return true, err
 	}
@@ -15,16 +15,14 @@ import (
 
 	"golang.org/x/crypto/ripemd160"
 
-	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainec"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/wire"
+	hcashcrypto "github.com/HcashOrg/hcashd/crypto"
 	bs "github.com/HcashOrg/hcashd/crypto/bliss"
 	"github.com/HcashOrg/hcashd/crypto/lms"
 )
 
-var optimizeSigVerification = chaincfg.SigHashOptimization
-
 // An opcode defines the information related to a txscript opcode.  opfunc, if
 // present, is the function to call to perform the opcode on the script.  The
 // current script is passed in as a slice with the first member being the opcode
@@ -2445,7 +2443,7 @@ func opcodeCheckSig(op *parsedOpcode, vm *Engine) error {
 	// Generate the signature hash based on the signature hash type.
 	var prefixHash *chainhash.Hash
 	if hashType&sigHashMask == SigHashAll {
-		if optimizeSigVerification {
+		if vm.hasFlag(ScriptSigHashOptimization) {
 			ph := vm.tx.CachedTxHash()
 			prefixHash = ph
 		}
@@ -2667,7 +2665,7 @@ func opcodeCheckMultiSig(op *parsedOpcode, vm *Engine) error {
 		// Generate the signature hash based on the signature hash type.
 		var prefixHash *chainhash.Hash
 		if hashType&sigHashMask == SigHashAll {
-			if optimizeSigVerification {
+			if vm.hasFlag(ScriptSigHashOptimization) {
 				ph := vm.tx.CachedTxHash()
 				prefixHash = ph
 			}
@@ -2730,6 +2728,23 @@ var secSchnorr = sigTypes(chainec.ECTypeSecSchnorr)
 var bliss = sigTypes(bs.BSTypeBliss)
 var lm = sigTypes(lms.LMSTypeLMS)
 
+// sigAltDSA returns the signature suite OP_CHECKSIGALT verifies signatures of
+// the passed type with, or nil if the type has none.  The suites are fixed here
+// rather than looked up with hcashcrypto.DSAByType, since the suites registered
+// there can be changed by any imported package and must not alter consensus.
+func sigAltDSA(sigType sigTypes) hcashcrypto.DSA {
+	switch sigType {
+	case edwards, secSchnorr:
+		dsa, _ := hcashcrypto.ECDSAByType(int(sigType))
+		return dsa
+	case bliss:
+		return bs.Bliss
+	case lm:
+		return lms.LMS
+	}
+	return nil
+}
+
 // opcodeCheckSigAlt accepts a three item stack and pops off the first three
 // items. The first item is a signature type (1-255, can not be zero or the
 // soft fork will fail). Any unused signature types return true, so that future
@@ -2793,8 +2808,7 @@ func opcodeCheckSigAlt(op *parsedOpcode, vm *Engine) error {
 		}
 		//TODO: the size of pk should alter later
 	case lm:
-		if len(pkBytes) !=  32 {
-			fmt.Printf("pub key length is not 32, length:%v\n", len(pkBytes))
+		if len(pkBytes) != lms.LMSPubKeyLen {
 			vm.dstack.PushBool(false)
 			return nil
 		}
@@ -2818,6 +2832,13 @@ func opcodeCheckSigAlt(op *parsedOpcode, vm *Engine) error {
 			vm.dstack.PushBool(false)
 			return nil
 		}
+	case lm:
+		// LMS signatures are variable length, but must at least
+		// contain one byte besides the hashType.
+		if len(fullSigBytes) < 2 {
+			vm.dstack.PushBool(false)
+			return nil
+		}
 	}
 
 	// Trim off hashtype from the signature string and check if the
@@ -2848,7 +2869,7 @@ func opcodeCheckSigAlt(op *parsedOpcode, vm *Engine) error {
 	// Generate the signature hash based on the signature hash type.
 	var prefixHash *chainhash.Hash
 	if hashType&sigHashMask == SigHashAll {
-		if optimizeSigVerification {
+		if vm.hasFlag(ScriptSigHashOptimization) {
 			ph := vm.tx.CachedTxHash()
 			prefixHash = ph
 		}
@@ -2860,101 +2881,55 @@ func opcodeCheckSigAlt(op *parsedOpcode, vm *Engine) error {
 		return nil
 	}
 
+	// Look up the signature suite for the signature type.
+	dsa := sigAltDSA(sigTypes(sigType))
+	if dsa == nil {
+		vm.dstack.PushBool(false)
+		return nil
+	}
+
 	// Get the public key from bytes.
-	var pubKey chainec.PublicKey
-	switch sigTypes(sigType) {
-	case edwards:
-		pubKeyEd, err := chainec.Edwards.ParsePubKey(pkBytes)
-		if err != nil {
-			vm.dstack.PushBool(false)
-			return nil
-		}
-		pubKey = pubKeyEd
-	case secSchnorr:
-		pubKeySec, err := chainec.SecSchnorr.ParsePubKey(pkBytes)
-		if err != nil {
-			vm.dstack.PushBool(false)
-			return nil
-		}
-		pubKey = pubKeySec
-	case bliss:
-		pubKeyBliss, err := bs.Bliss.ParsePubKey(pkBytes)
-		if err != nil {
-			vm.dstack.PushBool(false)
-			return nil
-		}
-		pubKey = pubKeyBliss
-	case lm:
-		pubKeyMss, err := lms.LMS.ParsePubKey(pkBytes)
-		if err != nil {
-			vm.dstack.PushBool(false)
-			return nil
-		}
-		pubKey = pubKeyMss
+	pubKey, err := dsa.ParsePubKey(pkBytes)
+	if err != nil {
+		vm.dstack.PushBool(false)
+		return nil
 	}
 
 	// Get the signature from bytes.
-	var signature chainec.Signature
-	
-	switch sigTypes(sigType) {
-	case edwards:
-		sigEd, err := chainec.Edwards.ParseSignature(sigBytes)
-		if err != nil {
-			vm.dstack.PushBool(false)
-			return nil
-		}
-		signature = sigEd
-	case secSchnorr:
-		sigSec, err := chainec.SecSchnorr.ParseSignature(sigBytes)
-		if err != nil {
-			vm.dstack.PushBool(false)
-			return nil
-		}
-		signature = sigSec
-	case bliss:
-		sigBliss, err := bs.Bliss.ParseSignature(sigBytes)
-		if err != nil {
-			vm.dstack.PushBool(false)
-			return nil
-		}
-		signature = sigBliss
-	case lm:
-		sigMss, err := lms.LMS.ParseSignature(sigBytes)
-		if err != nil {
-			vm.dstack.PushBool(false)
-			return nil
-		}
-		signature = sigMss
-	default:
+	signature, err := dsa.ParseSignature(sigBytes)
+	if err != nil {
 		vm.dstack.PushBool(false)
 		return nil
 	}
 
-	// Attempt to validate the signature.
-	switch sigTypes(sigType) {
-	case edwards:
-		ok := chainec.Edwards.Verify(pubKey, hash, signature.GetR(),
-			signature.GetS())
-		vm.dstack.PushBool(ok)
-		return nil
-	case secSchnorr:
-		ok := chainec.SecSchnorr.Verify(pubKey, hash, signature.GetR(),
-			signature.GetS())
-		vm.dstack.PushBool(ok)
-		return nil
-	case bliss:
-		ok := bs.Bliss.Verify(pubKey, hash, signature)
-		vm.dstack.PushBool(ok)
-		return nil
-	case lm:
-		ok := lms.LMS.Verify(pubKey, hash, signature)
-		vm.dstack.PushBool(ok)
+	// Attempt to validate the signature, using the signature cache when
+	// available just like opcodeCheckSig does.  Signatures which are not
+	// cached are deferred to the aggregator when one is set and the
+	// result can only fail the script.
+	var sigHash chainhash.Hash
+	copy(sigHash[:], hash)
+	cached := vm.sigCache != nil &&
+		vm.sigCache.Exists(sigHash, signature, pubKey)
+	if !cached && vm.sigAggregator != nil &&
+		(op.opcode.value == OP_CHECKSIGALTVERIFY || vm.isFinalOpcode()) &&
+		vm.sigAggregator.AddSig(int(sigType), pubKey, hash, signature) {
+
+		vm.dstack.PushBool(true)
 		return nil
 	}
 
-	// Fallthrough of somekind automatically results in false, but
-	// this should never be hit.
-	vm.dstack.PushBool(false)
+	var valid bool
+	if vm.sigCache != nil {
+		valid = cached
+		if !valid && dsa.Verify(pubKey, hash, signature) {
+			vm.sigCache.Add(sigHash, signature, pubKey)
+			valid = true
+		}
+	} else {
+		valid = dsa.Verify(pubKey, hash, signature)
+	}
+
+	vm.dstack.PushBool(valid)
 	return nil
 }
 
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"testing"
 
+	hcashcrypto "github.com/HcashOrg/hcashd/crypto"
 	"github.com/HcashOrg/hcashd/wire"
 )
 
@@ -554,3 +555,29 @@ func TestForVMFailure(t *testing.T) {
 		}
 	}
 }
+
+// TestSigAltDSA ensures OP_CHECKSIGALT verifies the signatures of every
+// supported signature type with a fixed suite which agrees with the registered
+// one, and none for the other types.
+func TestSigAltDSA(t *testing.T) {
+	for _, sigType := range []sigTypes{edwards, secSchnorr, bliss, lm} {
+		dsa := sigAltDSA(sigType)
+		if dsa == nil {
+			t.Fatalf("sigAltDSA(%d): no suite", sigType)
+		}
+		registered, err := hcashcrypto.DSAByType(int(sigType))
+		if err != nil {
+			t.Fatalf("DSAByType(%d): %v", sigType, err)
+		}
+		if dsa.PubKeyBytesLen() != registered.PubKeyBytesLen() {
+			t.Fatalf("sigAltDSA(%d): public key length %d, registered "+
+				"suite %d", sigType, dsa.PubKeyBytesLen(),
+				registered.PubKeyBytesLen())
+		}
+	}
+	for _, sigType := range []sigTypes{0, 200} {
+		if dsa := sigAltDSA(sigType); dsa != nil {
+			t.Fatalf("sigAltDSA(%d): got %v, want nil", sigType, dsa)
+		}
+	}
+}
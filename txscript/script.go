@@ -10,7 +10,6 @@ import (
 	"encoding/binary"
 	"fmt"
 
-	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcashd/wire"
 )
@@ -486,12 +485,12 @@ func calcSignatureHash(script []parsedOpcode, hashType SigHashType,
 	// been modified, so don't bother to do the wasteful O(N^2) extra
 	// hash here.
 	// The caching only works if the "anyone can pay flag" is also
-	// disabled.
+	// disabled.  The script engine only provides the cached prefix when
+	// the ScriptSigHashOptimization flag is set.
 	var prefixHash chainhash.Hash
 	if cachedPrefix != nil &&
 		(hashType&sigHashMask == SigHashAll) &&
-		(hashType&SigHashAnyOneCanPay == 0) &&
-		chaincfg.SigHashOptimization {
+		(hashType&SigHashAnyOneCanPay == 0) {
 		prefixHash = *cachedPrefix
 	} else {
 		prefixHash = txCopy.TxHash()
@@ -190,6 +190,79 @@ func TestGetPreciseSigOps(t *testing.T) {
 	}
 }
 
+// TestEstimateScriptCost ensures the estimated costs of validating scripts
+// follow the script cost model.
+func TestEstimateScriptCost(t *testing.T) {
+	t.Parallel()
+
+	p2pkhScript := "DUP HASH160 DATA_20 0x433ec2ac1ffa1b7b7d027f564529c57197" +
+		"f9ae88 EQUALVERIFY CHECKSIG"
+	p2shScript := "HASH160 DATA_20 0x433ec2ac1ffa1b7b7d027f564529c57197f9ae" +
+		"88 EQUAL"
+	// 2 <pubkey> <pubkey> <pubkey> 3 CHECKMULTISIG with nonsensical keys.
+	p2shSigScript := "DATA_2 0x0102 DATA_2 0x0304 DATA_12 " +
+		"0x5202010102020202030353ae"
+	tests := []struct {
+		name      string
+		scriptSig string
+		pkScript  string
+		bip16     bool
+		cost      int
+	}{
+		{
+			name:      "pay-to-pubkey-hash",
+			scriptSig: "DATA_2 0x0102 DATA_2 0x0304",
+			pkScript:  p2pkhScript,
+			bip16:     true,
+			cost: 5*txscript.BaseOpcodeCost + txscript.HashOpcodeCost +
+				txscript.SigCheckCost,
+		},
+		{
+			name:      "pay-to-script-hash multisig",
+			scriptSig: p2shSigScript,
+			pkScript:  p2shScript,
+			bip16:     true,
+			cost: 10*txscript.BaseOpcodeCost + txscript.HashOpcodeCost +
+				3*txscript.SigCheckCost,
+		},
+		{
+			name:      "pay-to-script-hash without bip16",
+			scriptSig: p2shSigScript,
+			pkScript:  p2shScript,
+			bip16:     false,
+			cost:      5*txscript.BaseOpcodeCost + txscript.HashOpcodeCost,
+		},
+		{
+			name:     "multisig without number of public keys",
+			pkScript: "CHECKMULTISIG",
+			bip16:    true,
+			cost:     txscript.MaxPubKeysPerMultiSig * txscript.SigCheckCost,
+		},
+		{
+			name:     "alternative signature check",
+			pkScript: "DATA_1 0x02 CHECKSIGALT",
+			bip16:    true,
+			cost:     txscript.BaseOpcodeCost + txscript.AltSigCheckCost,
+		},
+		{
+			name:     "public key script doesn't parse",
+			pkScript: "CHECKSIG PUSHDATA1 0x02",
+			bip16:    true,
+			cost:     txscript.SigCheckCost,
+		},
+	}
+
+	for _, test := range tests {
+		scriptSig := mustParseShortForm(test.scriptSig)
+		pkScript := mustParseShortForm(test.pkScript)
+		cost := txscript.EstimateScriptCost(scriptSig, pkScript, test.bip16)
+		if cost != test.cost {
+			t.Errorf("%s: expected cost of %d, got %d", test.name,
+				test.cost, cost)
+		}
+	}
+}
+
 // TestRemoveOpcodes ensures that removing opcodes from scripts behaves as
 // expected.
 func TestRemoveOpcodes(t *testing.T) {
@@ -0,0 +1,149 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"fmt"
+
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	bs "github.com/HcashOrg/hcashd/crypto/bliss"
+	"github.com/HcashOrg/hcashd/crypto/lms"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// stakeTagClasses maps the opcodes which tag the outputs of stake transactions
+// to the class of the standard stake output they start.
+var stakeTagClasses = map[byte]ScriptClass{
+	OP_SSTX:       StakeSubmissionTy,
+	OP_SSGEN:      StakeGenTy,
+	OP_SSRTX:      StakeRevocationTy,
+	OP_SSTXCHANGE: StakeSubChangeTy,
+}
+
+// sigTypeNames maps the signature schemes to the names used in the detailed
+// type of a script.
+var sigTypeNames = map[int]string{
+	chainec.ECTypeSecp256k1:  "secp256k1",
+	chainec.ECTypeEdwards:    "edwards",
+	chainec.ECTypeSecSchnorr: "schnorr",
+	bs.BSTypeBliss:           "bliss",
+	lms.LMSTypeLMS:           "lms",
+}
+
+// isStakeClass returns whether or not the passed class is one of the stake
+// output classes.
+func isStakeClass(class ScriptClass) bool {
+	return class == StakeSubmissionTy ||
+		class == StakeGenTy ||
+		class == StakeRevocationTy ||
+		class == StakeSubChangeTy
+}
+
+// ScriptDetails describes a public key script in more detail than its class.
+// In particular, the script following a stake tag is classified on its own, so
+// stake outputs paying to scripts which are not standard as stake outputs,
+// such as bare multisig, are still described along with their addresses.
+type ScriptDetails struct {
+	// Class is the class of the script and is equivalent to calling
+	// GetScriptClass on it.
+	Class ScriptClass
+
+	// StakeTag is the class of the stake output started by the stake tag
+	// opcode the script begins with.  It is NonStandardTy when the script
+	// is not tagged.
+	StakeTag ScriptClass
+
+	// SubClass is the class of the script following the stake tag, or of
+	// the entire script when it is not tagged.
+	SubClass ScriptClass
+
+	// SigType is the signature scheme of the public keys the script pays
+	// to.  It is -1 when the script does not pay to public keys or the
+	// scheme is unknown.
+	SigType int
+
+	// RequiredSigs is the number of signatures required to spend the
+	// script.
+	RequiredSigs int
+
+	// Addresses are the addresses the script pays to.
+	Addresses []hcashutil.Address
+}
+
+// TypeString returns the detailed type of the script.  It is made up of the
+// class of the stake tag, if any, the class of the script following it, and
+// the signature scheme for alternative signature scripts, separated by dashes,
+// for example "stakegen-pubkeyhash", "stakesubmission-multisig", or
+// "pubkeyhashalt-bliss".
+func (d *ScriptDetails) TypeString() string {
+	typ := d.SubClass.String()
+	if d.SubClass == PubkeyAltTy || d.SubClass == PubkeyHashAltTy {
+		if name, ok := sigTypeNames[d.SigType]; ok {
+			typ += "-" + name
+		}
+	}
+	if d.StakeTag != NonStandardTy {
+		typ = d.StakeTag.String() + "-" + typ
+	}
+	return typ
+}
+
+// CalcScriptDetails returns the details of the passed public key script.  An
+// error is returned when the script version is unsupported or the script does
+// not parse.
+func CalcScriptDetails(version uint16, pkScript []byte,
+	chainParams *chaincfg.Params) (*ScriptDetails, error) {
+
+	if version != DefaultScriptVersion {
+		return nil, fmt.Errorf("invalid script version")
+	}
+	pops, err := parseScript(pkScript)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &ScriptDetails{
+		Class:    typeOfScript(pops),
+		StakeTag: NonStandardTy,
+		SigType:  -1,
+	}
+
+	// Classify the script following the stake tag on its own.  The tag is
+	// a single opcode, so the subscript starts at the second byte.
+	subPops, subScript := pops, pkScript
+	if len(pops) > 0 {
+		if class, ok := stakeTagClasses[pops[0].opcode.value]; ok {
+			details.StakeTag = class
+			subPops = pops[1:]
+			subScript = getStakeOutSubscript(pkScript)
+		}
+	}
+	details.SubClass = typeOfScript(subPops)
+
+	// Stake outputs can't be nested.
+	if isStakeClass(details.SubClass) {
+		details.SubClass = NonStandardTy
+		return details, nil
+	}
+
+	switch details.SubClass {
+	case PubKeyTy, PubKeyHashTy, MultiSigTy:
+		details.SigType = chainec.ECTypeSecp256k1
+
+	case PubkeyAltTy, PubkeyHashAltTy:
+		sigType, err := ExtractPkScriptAltSigType(subScript)
+		if err == nil {
+			details.SigType = sigType
+		}
+	}
+
+	_, details.Addresses, details.RequiredSigs, err =
+		ExtractPkScriptAddrs(version, subScript, chainParams)
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
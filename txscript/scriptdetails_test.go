@@ -0,0 +1,144 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript_test
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg"
+	"github.com/HcashOrg/hcashd/crypto/lms"
+	"github.com/HcashOrg/hcashd/txscript"
+	"github.com/HcashOrg/hcashutil"
+)
+
+// TestCalcScriptDetails ensures stake tagged scripts, multisig inside stake
+// outputs, and alternative signature scripts are classified with the expected
+// detailed type and addresses.
+func TestCalcScriptDetails(t *testing.T) {
+	t.Parallel()
+
+	pkHash := "e34cce70c86373273efcc54ce7d2a491bb4a0e84"
+	pubKey1 := "02ce0b14fb842b1ba549fdd675c98075f12e9c510f8ef52bd021a9a1f4809d3b4d"
+	pubKey2 := "032689c7c2dab13309fb143e0e8fe396342521887e976690b6b47f5b2a4b7d448e"
+	p2pkh := "DUP HASH160 DATA_20 0x" + pkHash + " EQUALVERIFY CHECKSIG"
+	p2sh := "HASH160 DATA_20 0x" + pkHash + " EQUAL"
+	multiSig := "1 DATA_33 0x" + pubKey1 + " DATA_33 0x" + pubKey2 +
+		" 2 CHECKMULTISIG"
+
+	p2pkhLms, err := hcashutil.NewAddressPubKeyHash(decodeHex(pkHash),
+		&chaincfg.MainNetParams, lms.LMSTypeLMS)
+	if err != nil {
+		t.Fatalf("Unable to create LMS public key hash address: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		script  string
+		class   txscript.ScriptClass
+		typ     string
+		reqSigs int
+		addrs   []hcashutil.Address
+	}{
+		{
+			name:    "p2pkh",
+			script:  p2pkh,
+			class:   txscript.PubKeyHashTy,
+			typ:     "pubkeyhash",
+			reqSigs: 1,
+			addrs:   []hcashutil.Address{newAddressPubKeyHash(decodeHex(pkHash))},
+		},
+		{
+			name:    "stake generation p2pkh",
+			script:  "SSGEN " + p2pkh,
+			class:   txscript.StakeGenTy,
+			typ:     "stakegen-pubkeyhash",
+			reqSigs: 1,
+			addrs:   []hcashutil.Address{newAddressPubKeyHash(decodeHex(pkHash))},
+		},
+		{
+			name:    "stake submission p2sh",
+			script:  "SSTX " + p2sh,
+			class:   txscript.StakeSubmissionTy,
+			typ:     "stakesubmission-scripthash",
+			reqSigs: 1,
+			addrs:   []hcashutil.Address{newAddressScriptHash(decodeHex(pkHash))},
+		},
+		{
+			name:    "stake revocation multisig",
+			script:  "SSRTX " + multiSig,
+			class:   txscript.NonStandardTy,
+			typ:     "stakerevoke-multisig",
+			reqSigs: 1,
+			addrs: []hcashutil.Address{
+				newAddressPubKey(decodeHex(pubKey1)),
+				newAddressPubKey(decodeHex(pubKey2)),
+			},
+		},
+		{
+			name: "lms p2pkh",
+			script: "DUP HASH160 DATA_20 0x" + pkHash +
+				" EQUALVERIFY 5 CHECKSIGALT",
+			class:   txscript.PubkeyHashAltTy,
+			typ:     "pubkeyhashalt-lms",
+			reqSigs: 1,
+			addrs:   []hcashutil.Address{p2pkhLms},
+		},
+		{
+			name:   "nested stake tags",
+			script: "SSGEN SSGEN " + p2pkh,
+			class:  txscript.NonStandardTy,
+			typ:    "stakegen-nonstandard",
+		},
+		{
+			name:   "nonstandard",
+			script: "TRUE",
+			class:  txscript.NonStandardTy,
+			typ:    "nonstandard",
+		},
+	}
+
+	for _, test := range tests {
+		details, err := txscript.CalcScriptDetails(
+			txscript.DefaultScriptVersion,
+			mustParseShortForm(test.script), &chaincfg.MainNetParams)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if details.Class != test.class {
+			t.Errorf("%s: unexpected class - got %v, want %v",
+				test.name, details.Class, test.class)
+		}
+		if got := details.TypeString(); got != test.typ {
+			t.Errorf("%s: unexpected type - got %s, want %s",
+				test.name, got, test.typ)
+		}
+		if details.RequiredSigs != test.reqSigs {
+			t.Errorf("%s: unexpected required signatures - got %d, "+
+				"want %d", test.name, details.RequiredSigs,
+				test.reqSigs)
+		}
+		if len(details.Addresses) != len(test.addrs) {
+			t.Errorf("%s: unexpected number of addresses - got %d, "+
+				"want %d", test.name, len(details.Addresses),
+				len(test.addrs))
+			continue
+		}
+		for i, addr := range details.Addresses {
+			if addr.EncodeAddress() != test.addrs[i].EncodeAddress() {
+				t.Errorf("%s: unexpected address #%d - got %s, "+
+					"want %s", test.name, i, addr.EncodeAddress(),
+					test.addrs[i].EncodeAddress())
+			}
+		}
+	}
+
+	// Unsupported script versions are rejected.
+	_, err = txscript.CalcScriptDetails(1, mustParseShortForm(p2pkh),
+		&chaincfg.MainNetParams)
+	if err == nil {
+		t.Error("CalcScriptDetails: accepted unsupported script version")
+	}
+}
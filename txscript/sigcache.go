@@ -8,6 +8,7 @@ package txscript
 import (
 	"bytes"
 	"sync"
+	"sync/atomic"
 
 	"github.com/HcashOrg/hcashd/chaincfg/chainec"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
@@ -34,12 +35,54 @@ type sigCacheEntry struct {
 // Secondly, usage of the SigCache introduces a signature verification
 // optimization which speeds up the validation of transactions within a block,
 // if they've already been seen and verified within the mempool.
+//
+// A single SigCache is meant to be shared by the mempool and block validation
+// so signatures verified when a transaction is accepted to the mempool are not
+// verified again when the transaction is included in a block.  It caches the
+// signatures of every signature suite checked by the engine, and keeps
+// statistics on its effectiveness which are available through Stats.
 type SigCache struct {
+	// The following variables must only be used atomically.
+	hits      uint64
+	misses    uint64
+	evictions uint64
+
 	sync.RWMutex
 	validSigs  map[chainhash.Hash]sigCacheEntry
 	maxEntries uint
 }
 
+// SigCacheStats houses statistics on the usage of a SigCache.
+type SigCacheStats struct {
+	// Entries is the number of signatures in the cache.
+	Entries uint
+
+	// MaxEntries is the maximum number of signatures in the cache.
+	MaxEntries uint
+
+	// Hits is the number of signature lookups that found a cached
+	// signature and thus did not need to verify it.
+	Hits uint64
+
+	// Misses is the number of signature lookups that did not find a
+	// cached signature.
+	Misses uint64
+
+	// Evictions is the number of signatures that were evicted to make room
+	// for new ones.
+	Evictions uint64
+}
+
+// HitRate returns the ratio of signature lookups that found a cached signature
+// to all lookups.  It is zero when there have not been any lookups.
+func (s *SigCacheStats) HitRate() float64 {
+	lookups := s.Hits + s.Misses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(lookups)
+}
+
 // NewSigCache creates and initializes a new instance of SigCache. Its sole
 // parameter 'maxEntries' represents the maximum number of entries allowed to
 // exist in the SigCache at any particular moment. Random entries are evicted
@@ -54,21 +97,27 @@ func NewSigCache(maxEntries uint) *SigCache {
 
 // Exists returns true if an existing entry of 'sig' over 'sigHash' for public
 // key 'pubKey' is found within the SigCache. Otherwise, false is returned.
+// The signature and public key must also be of the same signature suite as
+// the cached ones, so signatures of any suite may share the cache.
 //
 // NOTE: This function is safe for concurrent access. Readers won't be blocked
 // unless there exists a writer, adding an entry to the SigCache.
 func (s *SigCache) Exists(sigHash chainhash.Hash, sig chainec.Signature, pubKey chainec.PublicKey) bool {
 	s.RLock()
-	defer s.RUnlock()
+	entry, ok := s.validSigs[sigHash]
+	s.RUnlock()
 
-	if entry, ok := s.validSigs[sigHash]; ok {
-		pkEqual := bytes.Equal(entry.pubKey.SerializeCompressed(),
-			pubKey.SerializeCompressed())
-		sigEqual := bytes.Equal(entry.sig.Serialize(), sig.Serialize())
-		return pkEqual && sigEqual
+	found := ok && entry.pubKey.GetType() == pubKey.GetType() &&
+		entry.sig.GetType() == sig.GetType() &&
+		bytes.Equal(entry.pubKey.SerializeCompressed(),
+			pubKey.SerializeCompressed()) &&
+		bytes.Equal(entry.sig.Serialize(), sig.Serialize())
+	if found {
+		atomic.AddUint64(&s.hits, 1)
+	} else {
+		atomic.AddUint64(&s.misses, 1)
 	}
-
-	return false
+	return found
 }
 
 // Add adds an entry for a signature over 'sigHash' under public key 'pubKey'
@@ -100,8 +149,27 @@ func (s *SigCache) Add(sigHash chainhash.Hash, sig chainec.Signature, pubKey cha
 		// entry.
 		for sigEntry := range s.validSigs {
 			delete(s.validSigs, sigEntry)
+			atomic.AddUint64(&s.evictions, 1)
 			break
 		}
 	}
 	s.validSigs[sigHash] = sigCacheEntry{sig, pubKey}
 }
+
+// Stats returns statistics on the usage of the signature cache since it was
+// created.
+//
+// NOTE: This function is safe for concurrent access.
+func (s *SigCache) Stats() SigCacheStats {
+	s.RLock()
+	entries := uint(len(s.validSigs))
+	s.RUnlock()
+
+	return SigCacheStats{
+		Entries:    entries,
+		MaxEntries: s.maxEntries,
+		Hits:       atomic.LoadUint64(&s.hits),
+		Misses:     atomic.LoadUint64(&s.misses),
+		Evictions:  atomic.LoadUint64(&s.evictions),
+	}
+}
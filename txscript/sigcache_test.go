@@ -144,3 +144,42 @@ func TestSigCacheAddMaxEntriesZeroOrNegative(t *testing.T) {
 			"been added", len(sigCache.validSigs))
 	}
 }
+
+// TestSigCacheStats tests that the signature cache statistics account for
+// hits, misses and evictions.
+func TestSigCacheStats(t *testing.T) {
+	sigCache := NewSigCache(1)
+
+	msg1, sig1, key1, err := genRandomSig()
+	if err != nil {
+		t.Fatalf("unable to generate random signature test data")
+	}
+	msg2, sig2, key2, err := genRandomSig()
+	if err != nil {
+		t.Fatalf("unable to generate random signature test data")
+	}
+
+	// Look up the first triplet before and after adding it, and add the
+	// second triplet to evict the first one.
+	sigCache.Exists(*msg1, sig1, key1)
+	sigCache.Add(*msg1, sig1, key1)
+	sigCache.Exists(*msg1, sig1, key1)
+	sigCache.Exists(*msg1, sig1, key1)
+	sigCache.Add(*msg2, sig2, key2)
+	sigCache.Exists(*msg1, sig1, key1)
+
+	stats := sigCache.Stats()
+	want := SigCacheStats{
+		Entries:    1,
+		MaxEntries: 1,
+		Hits:       2,
+		Misses:     2,
+		Evictions:  1,
+	}
+	if stats != want {
+		t.Fatalf("unexpected stats: got %+v, want %+v", stats, want)
+	}
+	if rate := stats.HitRate(); rate != 0.5 {
+		t.Fatalf("unexpected hit rate: got %v, want 0.5", rate)
+	}
+}
@@ -11,10 +11,11 @@ import (
 
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainec"
-	"github.com/HcashOrg/hcashd/wire"
-	"github.com/HcashOrg/hcashutil"
+	hcashcrypto "github.com/HcashOrg/hcashd/crypto"
 	bs "github.com/HcashOrg/hcashd/crypto/bliss"
 	"github.com/HcashOrg/hcashd/crypto/lms"
+	"github.com/HcashOrg/hcashd/wire"
+	"github.com/HcashOrg/hcashutil"
 )
 
 // RawTxInSignature returns the serialized ECDSA signature for the input idx of
@@ -75,7 +76,14 @@ func RawTxInSignatureAlt(tx *wire.MsgTx, idx int, subScript []byte,
 			return nil, fmt.Errorf("cannot sign tx input: %s", err)
 		}
 	case lm:
-		sig, err = lms.LMS.Sign(key.(lms.PrivateKey), hash)
+		// LMS keys are stateful, so the key is passed through as is
+		// to let a *lms.PrivateKey record the consumed one-time key.
+		lmsKey, ok := key.(hcashcrypto.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("cannot sign tx input: %s",
+				lms.ErrInvalidPrivateKey)
+		}
+		sig, err = lms.LMS.Sign(lmsKey, hash)
 		if err != nil {
 			return nil, fmt.Errorf("cannot sign tx input: %s", err)
 		}
@@ -142,7 +150,7 @@ func SignatureScriptAlt(tx *wire.MsgTx, idx int, subscript []byte,
 	case bliss:
 		pub = privKey.(bs.PrivateKey).PublicKey()
 	case lm:
-		pub = privKey.(lms.PrivateKey).PublicKey()
+		pub = privKey.(hcashcrypto.PrivateKey).PublicKey()
 	}
 	pkData := pub.Serialize()
 
@@ -265,6 +273,17 @@ func sign(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
 		}
 	}
 
+	// The signature suite of alternative signature scripts is committed
+	// to by the script itself, so it takes precedence over the requested
+	// one.
+	if class == PubkeyAltTy || class == PubkeyHashAltTy {
+		suite, err := ExtractPkScriptAltSigType(subScript)
+		if err != nil {
+			return nil, class, nil, 0, err
+		}
+		sigType = sigTypes(suite)
+	}
+
 	switch class {
 	case PubKeyTy:
 		// look up key for address
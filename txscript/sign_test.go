@@ -6,6 +6,7 @@
 package txscript_test
 
 import (
+	"bytes"
 	"crypto/rand"
 	"errors"
 	"fmt"
@@ -15,9 +16,12 @@ import (
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainec"
 	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcashd/crypto/lms"
 	"github.com/HcashOrg/hcashd/txscript"
 	"github.com/HcashOrg/hcashd/wire"
 	"github.com/HcashOrg/hcashutil"
+	"github.com/LoCCS/lmots"
+	merkle "github.com/LoCCS/lms"
 )
 
 // testingParams defines the chain params to use throughout these tests so it
@@ -1991,6 +1995,100 @@ func TestSignTxOutput(t *testing.T) {
 	}
 }
 
+// TestSignTxOutputLMS ensures pay-to-pubkey-hash outputs to LMS addresses are
+// classified as standard alternative signature scripts, can be signed with
+// SignTxOutput and are verified by the script engine.
+func TestSignTxOutputLMS(t *testing.T) {
+	t.Parallel()
+
+	seed := make([]byte, lmots.N)
+	if _, err := rand.Read(seed); err != nil {
+		t.Fatalf("failed to read seed: %v", err)
+	}
+	agent, err := merkle.NewMerkleAgent(4, seed)
+	if err != nil {
+		t.Fatalf("failed to create merkle agent: %v", err)
+	}
+	key := &lms.PrivateKey{MerkleAgent: *agent}
+
+	pkBytes := key.PublicKey().Serialize()
+	address, err := hcashutil.NewAddressPubKeyHash(hcashutil.Hash160(pkBytes),
+		testingParams, lms.LMSTypeLMS)
+	if err != nil {
+		t.Fatalf("failed to make address: %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		t.Fatalf("failed to make pkscript: %v", err)
+	}
+
+	class := txscript.GetScriptClass(txscript.DefaultScriptVersion, pkScript)
+	if class != txscript.PubkeyHashAltTy {
+		t.Fatalf("unexpected script class: got %v, want %v", class,
+			txscript.PubkeyHashAltTy)
+	}
+	suite, err := txscript.ExtractPkScriptAltSigType(pkScript)
+	if err != nil || suite != lms.LMSTypeLMS {
+		t.Fatalf("unexpected signature suite: got %d (err %v), want %d",
+			suite, err, lms.LMSTypeLMS)
+	}
+
+	kdb := txscript.KeyClosure(func(addr hcashutil.Address) (chainec.PrivateKey,
+		bool, error) {
+		if addr.EncodeAddress() != address.EncodeAddress() {
+			return nil, false, errors.New("nope")
+		}
+		return key, false, nil
+	})
+
+	tx := &wire.MsgTx{
+		SerType: wire.TxSerializeFull,
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{},
+			Sequence:         4294967295,
+			ValueIn:          testValueIn,
+			BlockHeight:      78901,
+			BlockIndex:       23456,
+		}},
+		TxOut: []*wire.TxOut{{
+			Version: wire.DefaultPkScriptVersion,
+			Value:   1,
+		}},
+	}
+
+	// Sign the same input twice.  The suite committed to by the script
+	// must be used regardless of the requested one, and since every
+	// signature consumes a one-time key, the two signature scripts must
+	// differ while both remaining valid.
+	var sigScripts [2][]byte
+	for i := range sigScripts {
+		sigScript, err := txscript.SignTxOutput(testingParams, tx, 0,
+			pkScript, txscript.SigHashAll, kdb, mkGetScript(nil), nil,
+			secp)
+		if err != nil {
+			t.Fatalf("failed to sign output %d: %v", i, err)
+		}
+		if err := checkScripts("lms", tx, 0, sigScript, pkScript); err != nil {
+			t.Fatal(err)
+		}
+		sigScripts[i] = sigScript
+	}
+	if bytes.Equal(sigScripts[0], sigScripts[1]) {
+		t.Fatal("LMS one-time key was reused")
+	}
+
+	// Remove the signature and ensure the script no longer validates.
+	emptySig, err := txscript.NewScriptBuilder().AddData([]byte{0x01}).
+		AddData(pkBytes).Script()
+	if err != nil {
+		t.Fatalf("failed to build script: %v", err)
+	}
+	if err := checkScripts("lms", tx, 0, emptySig, pkScript); err == nil {
+		t.Fatal("empty LMS signature validated")
+	}
+}
+
 type tstInput struct {
 	txout              *wire.TxOut
 	sigscriptGenerates bool
@@ -2326,3 +2424,48 @@ nexttest:
 		}
 	}
 }
+
+// TestSigHashOptimization ensures the cached hash of a transaction is only used
+// as the prefix hash of SIGHASH_ALL signatures when the engine is created with
+// the ScriptSigHashOptimization flag.
+func TestSigHashOptimization(t *testing.T) {
+	t.Parallel()
+	privKey, _ := chainec.Secp256k1.PrivKeyFromBytes(privKeyD)
+
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(wire.NewTxIn(coinbaseOutPoint, nil))
+	tx.AddTxOut(wire.NewTxOut(500, []byte{txscript.OP_RETURN}))
+	sigScript, err := txscript.SignatureScript(tx, 0, uncompressedPkScript,
+		txscript.SigHashAll, privKey, false)
+	if err != nil {
+		t.Fatalf("SignatureScript: unexpected error: %v", err)
+	}
+	tx.TxIn[0].SignatureScript = sigScript
+
+	// Mutate the transaction after its hash was cached, so only the stale
+	// cached hash matches the signature.
+	tx.CachedTxHash()
+	tx.TxOut[0].Value = 400
+
+	tests := []struct {
+		name  string
+		flags txscript.ScriptFlags
+		valid bool
+	}{
+		{"without optimization", txscript.ScriptBip16, false},
+		{"with optimization", txscript.ScriptBip16 |
+			txscript.ScriptSigHashOptimization, true},
+	}
+	for _, test := range tests {
+		vm, err := txscript.NewEngine(uncompressedPkScript, tx, 0,
+			test.flags, 0, nil)
+		if err != nil {
+			t.Fatalf("%s: cannot create script vm: %v", test.name, err)
+		}
+		err = vm.Execute()
+		if (err == nil) != test.valid {
+			t.Errorf("%s: got error %v, want valid %v", test.name, err,
+				test.valid)
+		}
+	}
+}
@@ -1525,6 +1525,52 @@ func ExtractPkScriptAltSigType(pkScript []byte) (int, error) {
 	return -1, fmt.Errorf("bad signature scheme type")
 }
 
+// ExtractAddrSigType returns the signature scheme required to spend outputs
+// paying to the passed address, so that callers can dispatch on it without
+// decoding the address prefix again.  Pay-to-script-hash addresses do not
+// commit to a signature scheme and return an error.
+func ExtractAddrSigType(addr hcashutil.Address) (int, error) {
+	switch addr := addr.(type) {
+	case *hcashutil.AddressPubKeyHash:
+		if addr == nil {
+			return -1, ErrUnsupportedAddress
+		}
+		return addr.DSA(addr.Net()), nil
+
+	case *hcashutil.AddressSecpPubKey:
+		if addr == nil {
+			return -1, ErrUnsupportedAddress
+		}
+		return chainec.ECTypeSecp256k1, nil
+
+	case *hcashutil.AddressEdwardsPubKey:
+		if addr == nil {
+			return -1, ErrUnsupportedAddress
+		}
+		return chainec.ECTypeEdwards, nil
+
+	case *hcashutil.AddressSecSchnorrPubKey:
+		if addr == nil {
+			return -1, ErrUnsupportedAddress
+		}
+		return chainec.ECTypeSecSchnorr, nil
+
+	case *hcashutil.AddressBlissPubKey:
+		if addr == nil {
+			return -1, ErrUnsupportedAddress
+		}
+		return bs.BSTypeBliss, nil
+
+	case *hcashutil.AddressLmsPubKey:
+		if addr == nil {
+			return -1, ErrUnsupportedAddress
+		}
+		return lms.LMSTypeLMS, nil
+	}
+
+	return -1, ErrUnsupportedAddress
+}
+
 // GetNullDataContent returns the content of a NullData (OP_RETURN) data push
 // and an error if the script is not a NullData script.
 func GetNullDataContent(version uint16, pkScript []byte) ([]byte, error) {
@@ -13,6 +13,7 @@ import (
 
 	"github.com/HcashOrg/hcashd/chaincfg"
 	"github.com/HcashOrg/hcashd/chaincfg/chainec"
+	"github.com/HcashOrg/hcashd/crypto/lms"
 	"github.com/HcashOrg/hcashd/txscript"
 	"github.com/HcashOrg/hcashutil"
 )
@@ -664,6 +665,63 @@ func TestPayToAddrScript(t *testing.T) {
 	}
 }
 
+// TestExtractAddrSigType ensures the ExtractAddrSigType function returns the
+// signature scheme committed to by each address type.
+func TestExtractAddrSigType(t *testing.T) {
+	t.Parallel()
+
+	pkHash := decodeHex("e34cce70c86373273efcc54ce7d2a491bb4a0e84")
+	p2pkhSecp, err := hcashutil.NewAddressPubKeyHash(pkHash,
+		&chaincfg.MainNetParams, secp)
+	if err != nil {
+		t.Fatalf("Unable to create public key hash address: %v", err)
+	}
+	p2pkhLms, err := hcashutil.NewAddressPubKeyHash(pkHash,
+		&chaincfg.MainNetParams, lms.LMSTypeLMS)
+	if err != nil {
+		t.Fatalf("Unable to create LMS public key hash address: %v", err)
+	}
+	p2pkLms, err := hcashutil.NewAddressLmsPubKey(decodeHex("e34cce70c863"+
+		"73273efcc54ce7d2a491bb4a0e84e34cce70c86373273efcc54c"),
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("Unable to create LMS pubkey address: %v", err)
+	}
+	p2sh, err := hcashutil.NewAddressScriptHashFromHash(pkHash,
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("Unable to create script hash address: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		in      hcashutil.Address
+		sigType int
+		err     error
+	}{
+		{"p2pkh secp256k1", p2pkhSecp, secp, nil},
+		{"p2pkh lms", p2pkhLms, lms.LMSTypeLMS, nil},
+		{"p2pk lms", p2pkLms, lms.LMSTypeLMS, nil},
+		{"p2sh", p2sh, -1, txscript.ErrUnsupportedAddress},
+		{"nil p2pkh", (*hcashutil.AddressPubKeyHash)(nil), -1,
+			txscript.ErrUnsupportedAddress},
+		{"bogus", &bogusAddress{}, -1, txscript.ErrUnsupportedAddress},
+	}
+
+	for _, test := range tests {
+		sigType, err := txscript.ExtractAddrSigType(test.in)
+		if err != test.err {
+			t.Errorf("%s: unexpected error - got %v, want %v",
+				test.name, err, test.err)
+			continue
+		}
+		if sigType != test.sigType {
+			t.Errorf("%s: unexpected signature type - got %d, "+
+				"want %d", test.name, sigType, test.sigType)
+		}
+	}
+}
+
 // TestMultiSigScript ensures the MultiSigScript function returns the expected
 // scripts and errors.
 func TestMultiSigScript(t *testing.T) {
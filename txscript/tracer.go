@@ -0,0 +1,74 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+// TraceStep describes the execution of a single opcode by the engine along
+// with the state of the stacks right after it.
+type TraceStep struct {
+	// ScriptIdx is the index of the script the opcode belongs to.  Index 0
+	// is the signature script, 1 is the public key script, and 2 is the
+	// redeem script of pay-to-script-hash executions.
+	ScriptIdx int
+
+	// ScriptOff is the offset of the opcode in its script.
+	ScriptOff int
+
+	// Opcode is the disassembly of the opcode.
+	Opcode string
+
+	// Executed is whether or not the opcode was executed.  Opcodes in
+	// conditional branches which are not executing are skipped.
+	Executed bool
+
+	// Stack and AltStack are the contents of the data and alternate stacks
+	// after the opcode where the last item is the top of the stack.
+	Stack    [][]byte
+	AltStack [][]byte
+
+	// Err is the error the opcode failed with, if any.
+	Err error
+}
+
+// Tracer is the interface for receiving the steps of an execution by the
+// engine.  It is intended for diagnosing failing scripts, such as those with
+// signatures of the alternative signature schemes, and slows down execution
+// considerably.
+type Tracer interface {
+	// TraceStep is called after each opcode the engine steps over,
+	// including the opcode execution failed at.  The step is owned by the
+	// tracer.
+	TraceStep(step *TraceStep)
+}
+
+// SetTracer sets the tracer which receives every step of the execution.  It
+// MUST be called before Execute or Step.
+func (vm *Engine) SetTracer(tracer Tracer) {
+	vm.tracer = tracer
+}
+
+// copyStack returns a deep copy of the passed stack contents so later opcodes
+// can't modify the items passed to the tracer.
+func copyStack(items [][]byte) [][]byte {
+	cpy := make([][]byte, len(items))
+	for i, item := range items {
+		cpy[i] = make([]byte, len(item))
+		copy(cpy[i], item)
+	}
+	return cpy
+}
+
+// traceStep passes the step over the passed opcode, which failed with the
+// passed error when it is not nil, to the tracer.
+func (vm *Engine) traceStep(pop *parsedOpcode, executed bool, err error) {
+	vm.tracer.TraceStep(&TraceStep{
+		ScriptIdx: vm.scriptIdx,
+		ScriptOff: vm.scriptOff,
+		Opcode:    pop.print(false),
+		Executed:  executed,
+		Stack:     copyStack(vm.GetStack()),
+		AltStack:  copyStack(vm.GetAltStack()),
+		Err:       err,
+	})
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/txscript"
+	"github.com/HcashOrg/hcashd/wire"
+)
+
+// stepRecorder is a txscript.Tracer which records all of the steps.
+type stepRecorder struct {
+	steps []*txscript.TraceStep
+}
+
+// TraceStep records the passed step.
+func (r *stepRecorder) TraceStep(step *txscript.TraceStep) {
+	r.steps = append(r.steps, step)
+}
+
+// traceScripts executes the passed short form signature and public key
+// scripts with a tracer and returns the recorded steps and the execution
+// result.
+func traceScripts(t *testing.T, sigScript, pkScript string) ([]*txscript.TraceStep, error) {
+	tx := &wire.MsgTx{
+		SerType: wire.TxSerializeFull,
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			SignatureScript: mustParseShortForm(sigScript),
+			Sequence:        wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{Value: 1000000000}},
+	}
+	vm, err := txscript.NewEngine(mustParseShortForm(pkScript), tx, 0, 0, 0,
+		nil)
+	if err != nil {
+		t.Fatalf("NewEngine: unexpected error: %v", err)
+	}
+	var recorder stepRecorder
+	vm.SetTracer(&recorder)
+	err = vm.Execute()
+	return recorder.steps, err
+}
+
+// TestTracer ensures the tracer receives every step of an execution with the
+// expected position, opcode, and stack contents, including skipped opcodes
+// and the opcode the execution fails at.
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	steps, err := traceScripts(t, "1 2", "ADD 3 EQUAL IF 7 ELSE 8 ENDIF")
+	if err != nil {
+		t.Fatalf("Execute: unexpected error: %v", err)
+	}
+	wantOpcodes := []string{"OP_1", "OP_2", "OP_ADD", "OP_3", "OP_EQUAL",
+		"OP_IF", "OP_7", "OP_ELSE", "OP_8", "OP_ENDIF"}
+	if len(steps) != len(wantOpcodes) {
+		t.Fatalf("unexpected number of steps - got %d, want %d",
+			len(steps), len(wantOpcodes))
+	}
+	for i, step := range steps {
+		if step.Opcode != wantOpcodes[i] {
+			t.Errorf("step %d: unexpected opcode - got %s, want %s",
+				i, step.Opcode, wantOpcodes[i])
+		}
+		wantIdx, wantOff := 1, i-2
+		if i < 2 {
+			wantIdx, wantOff = 0, i
+		}
+		if step.ScriptIdx != wantIdx || step.ScriptOff != wantOff {
+			t.Errorf("step %d: unexpected position - got %d:%d, "+
+				"want %d:%d", i, step.ScriptIdx, step.ScriptOff,
+				wantIdx, wantOff)
+		}
+		if wantExecuted := step.Opcode != "OP_8"; step.Executed != wantExecuted {
+			t.Errorf("step %d: unexpected executed flag - got %v, "+
+				"want %v", i, step.Executed, wantExecuted)
+		}
+		if step.Err != nil {
+			t.Errorf("step %d: unexpected error: %v", i, step.Err)
+		}
+	}
+	if stack := steps[2].Stack; len(stack) != 1 ||
+		!bytes.Equal(stack[0], []byte{3}) {
+
+		t.Errorf("unexpected stack after OP_ADD: %x", stack)
+	}
+	if stack := steps[len(steps)-1].Stack; len(stack) != 1 ||
+		!bytes.Equal(stack[0], []byte{7}) {
+
+		t.Errorf("unexpected final stack: %x", stack)
+	}
+
+	// The step of the opcode the execution fails at carries its error.
+	steps, err = traceScripts(t, "0", "VERIFY 1")
+	if err == nil {
+		t.Fatal("Execute: unexpected success")
+	}
+	if len(steps) != 2 {
+		t.Fatalf("unexpected number of steps - got %d, want 2",
+			len(steps))
+	}
+	if steps[1].Opcode != "OP_VERIFY" || steps[1].Err != err {
+		t.Errorf("unexpected failing step - got %s with error %v, "+
+			"want OP_VERIFY with error %v", steps[1].Opcode,
+			steps[1].Err, err)
+	}
+}
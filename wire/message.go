@@ -54,6 +54,16 @@ const (
 	CmdReject         = "reject"
 	CmdSendHeaders    = "sendheaders"
 	CmdFeeFilter      = "feefilter"
+	CmdNotice         = "notice"
+	CmdGetCFilter     = "getcfilter"
+	CmdCFilter        = "cfilter"
+	CmdGetCFHeaders   = "getcfheaders"
+	CmdCFHeaders      = "cfheaders"
+	CmdGetCFCheckpt   = "getcfcheckpt"
+	CmdCFCheckpt      = "cfcheckpt"
+	CmdLightBlock     = "lightblock"
+	CmdGetMissedTxs   = "getmissedtxs"
+	CmdMissedTxs      = "missedtxs"
 )
 
 // Message is an interface that describes a hypercash message.  A type that
@@ -147,6 +157,36 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdFeeFilter:
 		msg = &MsgFeeFilter{}
 
+	case CmdNotice:
+		msg = &MsgNotice{}
+
+	case CmdGetCFilter:
+		msg = &MsgGetCFilter{}
+
+	case CmdCFilter:
+		msg = &MsgCFilter{}
+
+	case CmdGetCFHeaders:
+		msg = &MsgGetCFHeaders{}
+
+	case CmdCFHeaders:
+		msg = &MsgCFHeaders{}
+
+	case CmdGetCFCheckpt:
+		msg = &MsgGetCFCheckpt{}
+
+	case CmdCFCheckpt:
+		msg = &MsgCFCheckpt{}
+
+	case CmdLightBlock:
+		msg = &MsgLightBlock{}
+
+	case CmdGetMissedTxs:
+		msg = &MsgGetMissedTxs{}
+
+	case CmdMissedTxs:
+		msg = &MsgMissedTxs{}
+
 	default:
 		return nil, fmt.Errorf("unhandled command [%s]", command)
 	}
@@ -99,6 +99,16 @@ func TestMessage(t *testing.T) {
 	)
 	msgMerkleBlock := NewMsgMerkleBlock(bh)
 	msgReject := NewMsgReject("block", RejectDuplicate, "duplicate block")
+	msgGetCFilter := NewMsgGetCFilter(&chainhash.Hash{}, GCSFilterExtended)
+	msgCFilter := NewMsgCFilter(&chainhash.Hash{}, GCSFilterExtended,
+		[]byte("payload"))
+	msgGetCFHeaders := NewMsgGetCFHeaders()
+	msgCFHeaders := NewMsgCFHeaders()
+	msgGetCFCheckpt := NewMsgGetCFCheckpt(GCSFilterExtended, &chainhash.Hash{})
+	msgCFCheckpt := NewMsgCFCheckpt(GCSFilterExtended, &chainhash.Hash{}, 0)
+	msgLightBlock := NewMsgLightBlockFromBlock(&testBlock)
+	msgGetMissedTxs := NewMsgGetMissedTxs(&chainhash.Hash{})
+	msgMissedTxs := NewMsgMissedTxs(&chainhash.Hash{})
 
 	tests := []struct {
 		in       Message     // Value to encode
@@ -107,27 +117,36 @@ func TestMessage(t *testing.T) {
 		hcashnet CurrencyNet // Network to use for wire encoding
 		bytes    int         // Expected num bytes read/written
 	}{
-		{msgVersion, msgVersion, pver, MainNet, 131},         // [0]
-		{msgVerack, msgVerack, pver, MainNet, 24},            // [1]
-		{msgGetAddr, msgGetAddr, pver, MainNet, 24},          // [2]
-		{msgAddr, msgAddr, pver, MainNet, 25},                // [3]
-		{msgGetBlocks, msgGetBlocks, pver, MainNet, 61},      // [4]
-		{msgBlock, msgBlock, pver, MainNet, 558},             // [5]
-		{msgInv, msgInv, pver, MainNet, 25},                  // [6]
-		{msgGetData, msgGetData, pver, MainNet, 25},          // [7]
-		{msgNotFound, msgNotFound, pver, MainNet, 25},        // [8]
-		{msgTx, msgTx, pver, MainNet, 39},                    // [9]
-		{msgPing, msgPing, pver, MainNet, 32},                // [10]
-		{msgPong, msgPong, pver, MainNet, 32},                // [11]
-		{msgGetHeaders, msgGetHeaders, pver, MainNet, 61},    // [12]
-		{msgHeaders, msgHeaders, pver, MainNet, 25},          // [13]
-		{msgAlert, msgAlert, pver, MainNet, 42},              // [14]
-		{msgMemPool, msgMemPool, pver, MainNet, 24},          // [15]
-		{msgFilterAdd, msgFilterAdd, pver, MainNet, 26},      // [16]
-		{msgFilterClear, msgFilterClear, pver, MainNet, 24},  // [17]
-		{msgFilterLoad, msgFilterLoad, pver, MainNet, 35},    // [18]
-		{msgMerkleBlock, msgMerkleBlock, pver, MainNet, 251}, // [19]
-		{msgReject, msgReject, pver, MainNet, 79},            // [20]
+		{msgVersion, msgVersion, pver, MainNet, 131},          // [0]
+		{msgVerack, msgVerack, pver, MainNet, 24},             // [1]
+		{msgGetAddr, msgGetAddr, pver, MainNet, 24},           // [2]
+		{msgAddr, msgAddr, pver, MainNet, 25},                 // [3]
+		{msgGetBlocks, msgGetBlocks, pver, MainNet, 61},       // [4]
+		{msgBlock, msgBlock, pver, MainNet, 558},              // [5]
+		{msgInv, msgInv, pver, MainNet, 25},                   // [6]
+		{msgGetData, msgGetData, pver, MainNet, 25},           // [7]
+		{msgNotFound, msgNotFound, pver, MainNet, 25},         // [8]
+		{msgTx, msgTx, pver, MainNet, 39},                     // [9]
+		{msgPing, msgPing, pver, MainNet, 32},                 // [10]
+		{msgPong, msgPong, pver, MainNet, 32},                 // [11]
+		{msgGetHeaders, msgGetHeaders, pver, MainNet, 61},     // [12]
+		{msgHeaders, msgHeaders, pver, MainNet, 25},           // [13]
+		{msgAlert, msgAlert, pver, MainNet, 42},               // [14]
+		{msgMemPool, msgMemPool, pver, MainNet, 24},           // [15]
+		{msgFilterAdd, msgFilterAdd, pver, MainNet, 26},       // [16]
+		{msgFilterClear, msgFilterClear, pver, MainNet, 24},   // [17]
+		{msgFilterLoad, msgFilterLoad, pver, MainNet, 35},     // [18]
+		{msgMerkleBlock, msgMerkleBlock, pver, MainNet, 251},  // [19]
+		{msgReject, msgReject, pver, MainNet, 79},             // [20]
+		{msgGetCFilter, msgGetCFilter, pver, MainNet, 57},     // [21]
+		{msgCFilter, msgCFilter, pver, MainNet, 65},           // [22]
+		{msgGetCFHeaders, msgGetCFHeaders, pver, MainNet, 58}, // [23]
+		{msgCFHeaders, msgCFHeaders, pver, MainNet, 58},       // [24]
+		{msgGetCFCheckpt, msgGetCFCheckpt, pver, MainNet, 57}, // [25]
+		{msgCFCheckpt, msgCFCheckpt, pver, MainNet, 58},       // [26]
+		{msgLightBlock, msgLightBlock, pver, MainNet, 465},    // [27]
+		{msgGetMissedTxs, msgGetMissedTxs, pver, MainNet, 57}, // [28]
+		{msgMissedTxs, msgMissedTxs, pver, MainNet, 57},       // [29]
 	}
 
 	t.Logf("Running %d tests", len(tests))
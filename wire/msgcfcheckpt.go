@@ -0,0 +1,155 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+const (
+	// CFCheckptInterval is the gap in blocks between each committed filter
+	// header checkpoint.
+	CFCheckptInterval = 1000
+
+	// MaxCFCheckptsPerMsg is the maximum number of committed filter
+	// header checkpoints that can be in a single cfcheckpt message.
+	MaxCFCheckptsPerMsg = 10000
+)
+
+// MsgCFCheckpt implements the Message interface and represents a cfcheckpt
+// message.  It is used to deliver committed filter header checkpoints in
+// response to a getcfcheckpt message (MsgGetCFCheckpt).  The headers are those
+// of the blocks at heights CFCheckptInterval, 2*CFCheckptInterval and so on up
+// to the block with the stop hash.
+//
+// This message was not added until protocol versions starting with
+// NodeCFVersion.
+type MsgCFCheckpt struct {
+	FilterType    FilterType
+	StopHash      chainhash.Hash
+	FilterHeaders []*chainhash.Hash
+}
+
+// AddCFHeader adds a new committed filter header checkpoint to the message.
+func (msg *MsgCFCheckpt) AddCFHeader(header *chainhash.Hash) error {
+	if len(msg.FilterHeaders)+1 > MaxCFCheckptsPerMsg {
+		str := fmt.Sprintf("too many committed filter header "+
+			"checkpoints in message [max %v]", MaxCFCheckptsPerMsg)
+		return messageError("MsgCFCheckpt.AddCFHeader", str)
+	}
+
+	msg.FilterHeaders = append(msg.FilterHeaders, header)
+	return nil
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCFCheckpt) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("cfcheckpt message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCFCheckpt.BtcDecode", str)
+	}
+
+	err := readElements(r, &msg.FilterType, &msg.StopHash)
+	if err != nil {
+		return err
+	}
+
+	// Read num committed filter header checkpoints and limit to max.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > MaxCFCheckptsPerMsg {
+		str := fmt.Sprintf("too many committed filter header "+
+			"checkpoints for message [count %v, max %v]", count,
+			MaxCFCheckptsPerMsg)
+		return messageError("MsgCFCheckpt.BtcDecode", str)
+	}
+
+	// Create a contiguous slice of hashes to deserialize into in order to
+	// reduce the number of allocations.
+	headers := make([]chainhash.Hash, count)
+	msg.FilterHeaders = make([]*chainhash.Hash, 0, count)
+	for i := uint64(0); i < count; i++ {
+		hash := &headers[i]
+		err := readElement(r, hash)
+		if err != nil {
+			return err
+		}
+		msg.AddCFHeader(hash)
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCFCheckpt) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("cfcheckpt message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCFCheckpt.BtcEncode", str)
+	}
+
+	// Limit to max committed filter header checkpoints per message.
+	count := len(msg.FilterHeaders)
+	if count > MaxCFCheckptsPerMsg {
+		str := fmt.Sprintf("too many committed filter header "+
+			"checkpoints for message [count %v, max %v]", count,
+			MaxCFCheckptsPerMsg)
+		return messageError("MsgCFCheckpt.BtcEncode", str)
+	}
+
+	err := writeElements(w, msg.FilterType, &msg.StopHash)
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range msg.FilterHeaders {
+		err := writeElement(w, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCFCheckpt) Command() string {
+	return CmdCFCheckpt
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCFCheckpt) MaxPayloadLength(pver uint32) uint32 {
+	// Filter type 1 byte + stop hash + num committed filter header
+	// checkpoints (varInt) + max allowed checkpoints.
+	return 1 + chainhash.HashSize + MaxVarIntPayload +
+		(MaxCFCheckptsPerMsg * chainhash.HashSize)
+}
+
+// NewMsgCFCheckpt returns a new cfcheckpt message that conforms to the
+// Message interface.  See MsgCFCheckpt for details.  The headersCount
+// parameter is used as a hint for the number of checkpoints the message
+// will hold.
+func NewMsgCFCheckpt(filterType FilterType, stopHash *chainhash.Hash, headersCount int) *MsgCFCheckpt {
+	return &MsgCFCheckpt{
+		FilterType:    filterType,
+		StopHash:      *stopHash,
+		FilterHeaders: make([]*chainhash.Hash, 0, headersCount),
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestCFCheckptLatest tests the MsgCFCheckpt API against the latest protocol
+// version.
+func TestCFCheckptLatest(t *testing.T) {
+	pver := ProtocolVersion
+
+	stopHash := chainhash.Hash{0x01}
+	msg := NewMsgCFCheckpt(GCSFilterExtended, &stopHash, 2)
+	if msg.StopHash != stopHash || msg.FilterType != GCSFilterExtended ||
+		cap(msg.FilterHeaders) != 2 {
+
+		t.Errorf("NewMsgCFCheckpt: wrong fields - got %v",
+			spew.Sdump(msg))
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := "cfcheckpt"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgCFCheckpt: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	// Filter type + stop hash + num headers (varInt) + max allowed
+	// headers.
+	wantPayload := uint32(320042)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure adding more than the max allowed headers per message returns
+	// an error.
+	hash := chainhash.Hash{0x02}
+	var err error
+	for i := 0; i < MaxCFCheckptsPerMsg+1; i++ {
+		err = msg.AddCFHeader(&hash)
+	}
+	if err == nil {
+		t.Errorf("AddCFHeader: expected error on too many headers " +
+			"not received")
+	}
+}
+
+// TestCFCheckptWire tests the MsgCFCheckpt wire encode and decode.
+func TestCFCheckptWire(t *testing.T) {
+	hash1 := chainhash.Hash{0x01}
+	hash2 := chainhash.Hash{0x02}
+	stopHash := chainhash.Hash{0x03}
+
+	msg := NewMsgCFCheckpt(GCSFilterExtended, &stopHash, 2)
+	msg.AddCFHeader(&hash1)
+	msg.AddCFHeader(&hash2)
+	msgEncoded := []byte{0x01} // Filter type
+	msgEncoded = append(msgEncoded, stopHash[:]...)
+	msgEncoded = append(msgEncoded, 0x02) // Varint for number of headers
+	msgEncoded = append(msgEncoded, hash1[:]...)
+	msgEncoded = append(msgEncoded, hash2[:]...)
+
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, NodeCFVersion)
+	if err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), msgEncoded) {
+		t.Fatalf("BtcEncode\n got: %s want: %s",
+			spew.Sdump(buf.Bytes()), spew.Sdump(msgEncoded))
+	}
+
+	var readMsg MsgCFCheckpt
+	err = readMsg.BtcDecode(bytes.NewReader(msgEncoded), NodeCFVersion)
+	if err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+	if !reflect.DeepEqual(readMsg.FilterHeaders, msg.FilterHeaders) ||
+		readMsg.StopHash != msg.StopHash ||
+		readMsg.FilterType != msg.FilterType {
+
+		t.Fatalf("BtcDecode\n got: %s want: %s", spew.Sdump(&readMsg),
+			spew.Sdump(msg))
+	}
+}
+
+// TestCFCheckptWireErrors performs negative tests against wire encode and
+// decode of MsgCFCheckpt to confirm error paths work correctly.
+func TestCFCheckptWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	pverNoCF := NodeCFVersion - 1
+	wireErr := &MessageError{}
+
+	hash := chainhash.Hash{0x01}
+	stopHash := chainhash.Hash{0x02}
+	baseCFCheckpt := NewMsgCFCheckpt(GCSFilterRegular, &stopHash, 1)
+	baseCFCheckpt.AddCFHeader(&hash)
+	baseCFCheckptEncoded := append([]byte{0x00}, stopHash[:]...)
+	baseCFCheckptEncoded = append(baseCFCheckptEncoded, 0x01)
+	baseCFCheckptEncoded = append(baseCFCheckptEncoded, hash[:]...)
+
+	// Message that forces an error by having more than the max allowed
+	// headers.
+	maxCFCheckpt := NewMsgCFCheckpt(GCSFilterRegular, &stopHash, 0)
+	for i := 0; i < MaxCFCheckptsPerMsg; i++ {
+		maxCFCheckpt.AddCFHeader(&hash)
+	}
+	maxCFCheckpt.FilterHeaders = append(maxCFCheckpt.FilterHeaders, &hash)
+	maxCFCheckptEncoded := append([]byte{0x00}, stopHash[:]...)
+	maxCFCheckptEncoded = append(maxCFCheckptEncoded,
+		0xfd, 0x11, 0x27) // Varint for number of headers (10001)
+
+	tests := []struct {
+		in       *MsgCFCheckpt // Value to encode
+		buf      []byte        // Wire encoding
+		pver     uint32        // Protocol version for wire encoding
+		max      int           // Max size of fixed buffer to induce errors
+		writeErr error         // Expected write error
+		readErr  error         // Expected read error
+	}{
+		// Force error in filter type.
+		{baseCFCheckpt, baseCFCheckptEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in stop hash.
+		{baseCFCheckpt, baseCFCheckptEncoded, pver, 1, io.ErrShortWrite, io.EOF},
+		// Force error in header count.
+		{baseCFCheckpt, baseCFCheckptEncoded, pver, 33, io.ErrShortWrite, io.EOF},
+		// Force error in headers.
+		{baseCFCheckpt, baseCFCheckptEncoded, pver, 34, io.ErrShortWrite, io.EOF},
+		// Force error with greater than max headers.
+		{maxCFCheckpt, maxCFCheckptEncoded, pver, 36, wireErr, wireErr},
+		// Force error due to unsupported protocol version.
+		{baseCFCheckpt, baseCFCheckptEncoded, pverNoCF, 66, wireErr, wireErr},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.writeErr {
+				t.Errorf("BtcEncode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.writeErr)
+				continue
+			}
+		}
+
+		// Decode from wire format.
+		var msg MsgCFCheckpt
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.readErr {
+				t.Errorf("BtcDecode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.readErr)
+				continue
+			}
+		}
+	}
+}
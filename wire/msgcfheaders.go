@@ -0,0 +1,143 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// MaxCFHeadersPerMsg is the maximum number of committed filter headers that
+// can be in a single cfheaders message.
+const MaxCFHeadersPerMsg = 2000
+
+// MsgCFHeaders implements the Message interface and represents a cfheaders
+// message.  It is used to deliver committed filter headers in response to a
+// getcfheaders message (MsgGetCFHeaders).  The headers are ordered by block
+// height and StopHash is the hash of the block of the last header, or the
+// zero hash when there are no headers.
+//
+// This message was not added until protocol versions starting with
+// NodeCFVersion.
+type MsgCFHeaders struct {
+	StopHash     chainhash.Hash
+	FilterType   FilterType
+	HeaderHashes []*chainhash.Hash
+}
+
+// AddCFHeader adds a new committed filter header to the message.
+func (msg *MsgCFHeaders) AddCFHeader(headerHash *chainhash.Hash) error {
+	if len(msg.HeaderHashes)+1 > MaxCFHeadersPerMsg {
+		str := fmt.Sprintf("too many committed filter headers in "+
+			"message [max %v]", MaxCFHeadersPerMsg)
+		return messageError("MsgCFHeaders.AddCFHeader", str)
+	}
+
+	msg.HeaderHashes = append(msg.HeaderHashes, headerHash)
+	return nil
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCFHeaders) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("cfheaders message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCFHeaders.BtcDecode", str)
+	}
+
+	err := readElements(r, &msg.StopHash, &msg.FilterType)
+	if err != nil {
+		return err
+	}
+
+	// Read num committed filter headers and limit to max.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > MaxCFHeadersPerMsg {
+		str := fmt.Sprintf("too many committed filter headers for "+
+			"message [count %v, max %v]", count, MaxCFHeadersPerMsg)
+		return messageError("MsgCFHeaders.BtcDecode", str)
+	}
+
+	// Create a contiguous slice of hashes to deserialize into in order to
+	// reduce the number of allocations.
+	headerHashes := make([]chainhash.Hash, count)
+	msg.HeaderHashes = make([]*chainhash.Hash, 0, count)
+	for i := uint64(0); i < count; i++ {
+		hash := &headerHashes[i]
+		err := readElement(r, hash)
+		if err != nil {
+			return err
+		}
+		msg.AddCFHeader(hash)
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCFHeaders) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("cfheaders message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCFHeaders.BtcEncode", str)
+	}
+
+	// Limit to max committed filter headers per message.
+	count := len(msg.HeaderHashes)
+	if count > MaxCFHeadersPerMsg {
+		str := fmt.Sprintf("too many committed filter headers for "+
+			"message [count %v, max %v]", count, MaxCFHeadersPerMsg)
+		return messageError("MsgCFHeaders.BtcEncode", str)
+	}
+
+	err := writeElements(w, &msg.StopHash, msg.FilterType)
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range msg.HeaderHashes {
+		err := writeElement(w, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCFHeaders) Command() string {
+	return CmdCFHeaders
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCFHeaders) MaxPayloadLength(pver uint32) uint32 {
+	// Stop hash + filter type 1 byte + num committed filter headers
+	// (varInt) + max allowed committed filter headers.
+	return chainhash.HashSize + 1 + MaxVarIntPayload +
+		(MaxCFHeadersPerMsg * chainhash.HashSize)
+}
+
+// NewMsgCFHeaders returns a new cfheaders message that conforms to the
+// Message interface.  See MsgCFHeaders for details.
+func NewMsgCFHeaders() *MsgCFHeaders {
+	return &MsgCFHeaders{
+		HeaderHashes: make([]*chainhash.Hash, 0, MaxCFHeadersPerMsg),
+	}
+}
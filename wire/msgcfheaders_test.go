@@ -0,0 +1,186 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestCFHeadersLatest tests the MsgCFHeaders API against the latest protocol
+// version.
+func TestCFHeadersLatest(t *testing.T) {
+	pver := ProtocolVersion
+
+	msg := NewMsgCFHeaders()
+
+	// Ensure the command is expected value.
+	wantCmd := "cfheaders"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgCFHeaders: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	// Stop hash + filter type + num headers (varInt) + max allowed
+	// headers.
+	wantPayload := uint32(64042)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure adding more than the max allowed headers per message returns
+	// an error.
+	hash := chainhash.Hash{0x01}
+	var err error
+	for i := 0; i < MaxCFHeadersPerMsg+1; i++ {
+		err = msg.AddCFHeader(&hash)
+	}
+	if err == nil {
+		t.Errorf("AddCFHeader: expected error on too many headers " +
+			"not received")
+	}
+}
+
+// TestCFHeadersWire tests the MsgCFHeaders wire encode and decode.
+func TestCFHeadersWire(t *testing.T) {
+	hash1 := chainhash.Hash{0x01}
+	hash2 := chainhash.Hash{0x02}
+	stopHash := chainhash.Hash{0x03}
+
+	msg := NewMsgCFHeaders()
+	msg.StopHash = stopHash
+	msg.FilterType = GCSFilterExtended
+	msg.AddCFHeader(&hash1)
+	msg.AddCFHeader(&hash2)
+	msgEncoded := append([]byte{}, stopHash[:]...)
+	msgEncoded = append(msgEncoded, 0x01) // Filter type
+	msgEncoded = append(msgEncoded, 0x02) // Varint for number of headers
+	msgEncoded = append(msgEncoded, hash1[:]...)
+	msgEncoded = append(msgEncoded, hash2[:]...)
+
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, NodeCFVersion)
+	if err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), msgEncoded) {
+		t.Fatalf("BtcEncode\n got: %s want: %s",
+			spew.Sdump(buf.Bytes()), spew.Sdump(msgEncoded))
+	}
+
+	var readMsg MsgCFHeaders
+	err = readMsg.BtcDecode(bytes.NewReader(msgEncoded), NodeCFVersion)
+	if err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+	if !reflect.DeepEqual(readMsg.HeaderHashes, msg.HeaderHashes) ||
+		readMsg.StopHash != msg.StopHash ||
+		readMsg.FilterType != msg.FilterType {
+
+		t.Fatalf("BtcDecode\n got: %s want: %s", spew.Sdump(&readMsg),
+			spew.Sdump(msg))
+	}
+}
+
+// TestCFHeadersWireErrors performs negative tests against wire encode and
+// decode of MsgCFHeaders to confirm error paths work correctly.
+func TestCFHeadersWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	pverNoCF := NodeCFVersion - 1
+	wireErr := &MessageError{}
+
+	hash := chainhash.Hash{0x01}
+	stopHash := chainhash.Hash{0x02}
+	baseCFHeaders := NewMsgCFHeaders()
+	baseCFHeaders.StopHash = stopHash
+	baseCFHeaders.AddCFHeader(&hash)
+	baseCFHeadersEncoded := append([]byte{}, stopHash[:]...)
+	baseCFHeadersEncoded = append(baseCFHeadersEncoded, 0x00, 0x01)
+	baseCFHeadersEncoded = append(baseCFHeadersEncoded, hash[:]...)
+
+	// Message that forces an error by having more than the max allowed
+	// headers.
+	maxCFHeaders := NewMsgCFHeaders()
+	for i := 0; i < MaxCFHeadersPerMsg; i++ {
+		maxCFHeaders.AddCFHeader(&hash)
+	}
+	maxCFHeaders.HeaderHashes = append(maxCFHeaders.HeaderHashes, &hash)
+	maxCFHeadersEncoded := append([]byte{}, stopHash[:]...)
+	maxCFHeadersEncoded = append(maxCFHeadersEncoded, 0x00,
+		0xfd, 0xd1, 0x07) // Varint for number of headers (2001)
+
+	tests := []struct {
+		in       *MsgCFHeaders // Value to encode
+		buf      []byte        // Wire encoding
+		pver     uint32        // Protocol version for wire encoding
+		max      int           // Max size of fixed buffer to induce errors
+		writeErr error         // Expected write error
+		readErr  error         // Expected read error
+	}{
+		// Force error in stop hash.
+		{baseCFHeaders, baseCFHeadersEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in filter type.
+		{baseCFHeaders, baseCFHeadersEncoded, pver, 32, io.ErrShortWrite, io.EOF},
+		// Force error in header count.
+		{baseCFHeaders, baseCFHeadersEncoded, pver, 33, io.ErrShortWrite, io.EOF},
+		// Force error in headers.
+		{baseCFHeaders, baseCFHeadersEncoded, pver, 34, io.ErrShortWrite, io.EOF},
+		// Force error with greater than max headers.
+		{maxCFHeaders, maxCFHeadersEncoded, pver, 36, wireErr, wireErr},
+		// Force error due to unsupported protocol version.
+		{baseCFHeaders, baseCFHeadersEncoded, pverNoCF, 66, wireErr, wireErr},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.writeErr {
+				t.Errorf("BtcEncode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.writeErr)
+				continue
+			}
+		}
+
+		// Decode from wire format.
+		var msg MsgCFHeaders
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.readErr {
+				t.Errorf("BtcDecode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.readErr)
+				continue
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// MaxCFilterDataSize is the maximum number of bytes allowed in the data of a
+// committed filter.
+const MaxCFilterDataSize = 256 * 1024
+
+// MsgCFilter implements the Message interface and represents a cfilter
+// message.  It is used to deliver the committed filter of a block in response
+// to a getcfilter message (MsgGetCFilter).  The data is the filter serialized
+// with its number of members as a big-endian uint32 prefix.
+//
+// This message was not added until protocol versions starting with
+// NodeCFVersion.
+type MsgCFilter struct {
+	BlockHash  chainhash.Hash
+	FilterType FilterType
+	Data       []byte
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCFilter) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("cfilter message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCFilter.BtcDecode", str)
+	}
+
+	err := readElements(r, &msg.BlockHash, &msg.FilterType)
+	if err != nil {
+		return err
+	}
+
+	msg.Data, err = ReadVarBytes(r, pver, MaxCFilterDataSize,
+		"cfilter data")
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCFilter) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("cfilter message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCFilter.BtcEncode", str)
+	}
+
+	size := len(msg.Data)
+	if size > MaxCFilterDataSize {
+		str := fmt.Sprintf("cfilter size too large for message "+
+			"[size %v, max %v]", size, MaxCFilterDataSize)
+		return messageError("MsgCFilter.BtcEncode", str)
+	}
+
+	err := writeElements(w, &msg.BlockHash, msg.FilterType)
+	if err != nil {
+		return err
+	}
+
+	return WriteVarBytes(w, pver, msg.Data)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCFilter) Command() string {
+	return CmdCFilter
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCFilter) MaxPayloadLength(pver uint32) uint32 {
+	// Block hash + filter type 1 byte + filter data length varint +
+	// filter data.
+	return chainhash.HashSize + 1 +
+		uint32(VarIntSerializeSize(MaxCFilterDataSize)) +
+		MaxCFilterDataSize
+}
+
+// NewMsgCFilter returns a new cfilter message that conforms to the Message
+// interface.  See MsgCFilter for details.
+func NewMsgCFilter(blockHash *chainhash.Hash, filterType FilterType, data []byte) *MsgCFilter {
+	return &MsgCFilter{
+		BlockHash:  *blockHash,
+		FilterType: filterType,
+		Data:       data,
+	}
+}
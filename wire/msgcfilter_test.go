@@ -0,0 +1,157 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestCFilterLatest tests the MsgCFilter API against the latest protocol
+// version.
+func TestCFilterLatest(t *testing.T) {
+	pver := ProtocolVersion
+
+	hash := chainhash.Hash{0x01}
+	data := []byte{0x01, 0x02}
+	msg := NewMsgCFilter(&hash, GCSFilterRegular, data)
+	if msg.BlockHash != hash || msg.FilterType != GCSFilterRegular ||
+		!bytes.Equal(msg.Data, data) {
+
+		t.Errorf("NewMsgCFilter: wrong fields - got %v", spew.Sdump(msg))
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := "cfilter"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgCFilter: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	wantPayload := uint32(32 + 1 + 5 + MaxCFilterDataSize)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+}
+
+// TestCFilterWire tests the MsgCFilter wire encode and decode.
+func TestCFilterWire(t *testing.T) {
+	hash := chainhash.Hash{0x01, 0x02}
+	msg := NewMsgCFilter(&hash, GCSFilterExtended, []byte{0xaa, 0xbb})
+	msgEncoded := append(append([]byte{}, hash[:]...), 0x01, 0x02, 0xaa,
+		0xbb)
+
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, NodeCFVersion)
+	if err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), msgEncoded) {
+		t.Fatalf("BtcEncode\n got: %s want: %s",
+			spew.Sdump(buf.Bytes()), spew.Sdump(msgEncoded))
+	}
+
+	var readMsg MsgCFilter
+	err = readMsg.BtcDecode(bytes.NewReader(msgEncoded), NodeCFVersion)
+	if err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&readMsg, msg) {
+		t.Fatalf("BtcDecode\n got: %s want: %s", spew.Sdump(&readMsg),
+			spew.Sdump(msg))
+	}
+}
+
+// TestCFilterWireErrors performs negative tests against wire encode and
+// decode of MsgCFilter to confirm error paths work correctly.
+func TestCFilterWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	pverNoCF := NodeCFVersion - 1
+	wireErr := &MessageError{}
+
+	hash := chainhash.Hash{0x01, 0x02}
+	baseCFilter := NewMsgCFilter(&hash, GCSFilterRegular, []byte{0xaa})
+	baseCFilterEncoded := append(append([]byte{}, hash[:]...), 0x00, 0x01,
+		0xaa)
+
+	// Message with data that exceeds the max allowed size.
+	maxData := make([]byte, MaxCFilterDataSize+1)
+	maxCFilter := NewMsgCFilter(&hash, GCSFilterRegular, maxData)
+	maxCFilterEncoded := append(append([]byte{}, hash[:]...), 0x00, 0xfe,
+		0x01, 0x00, 0x04, 0x00) // Varint for data size (262145)
+
+	tests := []struct {
+		in       *MsgCFilter // Value to encode
+		buf      []byte      // Wire encoding
+		pver     uint32      // Protocol version for wire encoding
+		max      int         // Max size of fixed buffer to induce errors
+		writeErr error       // Expected write error
+		readErr  error       // Expected read error
+	}{
+		// Force error in block hash.
+		{baseCFilter, baseCFilterEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in filter type.
+		{baseCFilter, baseCFilterEncoded, pver, 32, io.ErrShortWrite, io.EOF},
+		// Force error in data size.
+		{baseCFilter, baseCFilterEncoded, pver, 33, io.ErrShortWrite, io.EOF},
+		// Force error in data.
+		{baseCFilter, baseCFilterEncoded, pver, 34, io.ErrShortWrite, io.EOF},
+		// Force error with greater than max data size.
+		{maxCFilter, maxCFilterEncoded, pver, 38, wireErr, wireErr},
+		// Force error due to unsupported protocol version.
+		{baseCFilter, baseCFilterEncoded, pverNoCF, 35, wireErr, wireErr},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.writeErr {
+				t.Errorf("BtcEncode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.writeErr)
+				continue
+			}
+		}
+
+		// Decode from wire format.
+		var msg MsgCFilter
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.readErr {
+				t.Errorf("BtcDecode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.readErr)
+				continue
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// MsgGetCFCheckpt implements the Message interface and represents a
+// getcfcheckpt message.  It is used to request the committed filter headers
+// of the passed type at every CFCheckptInterval blocks of the main chain up
+// to the block with the stop hash.  The headers are returned via a cfcheckpt
+// message (MsgCFCheckpt) and allow a client to download the remaining filter
+// headers from multiple peers in parallel.
+//
+// This message was not added until protocol versions starting with
+// NodeCFVersion.
+type MsgGetCFCheckpt struct {
+	FilterType FilterType
+	StopHash   chainhash.Hash
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFCheckpt) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("getcfcheckpt message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetCFCheckpt.BtcDecode", str)
+	}
+
+	return readElements(r, &msg.FilterType, &msg.StopHash)
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFCheckpt) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("getcfcheckpt message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetCFCheckpt.BtcEncode", str)
+	}
+
+	return writeElements(w, msg.FilterType, &msg.StopHash)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetCFCheckpt) Command() string {
+	return CmdGetCFCheckpt
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetCFCheckpt) MaxPayloadLength(pver uint32) uint32 {
+	// Filter type 1 byte + stop hash.
+	return 1 + chainhash.HashSize
+}
+
+// NewMsgGetCFCheckpt returns a new getcfcheckpt message that conforms to the
+// Message interface.  See MsgGetCFCheckpt for details.
+func NewMsgGetCFCheckpt(filterType FilterType, stopHash *chainhash.Hash) *MsgGetCFCheckpt {
+	return &MsgGetCFCheckpt{
+		FilterType: filterType,
+		StopHash:   *stopHash,
+	}
+}
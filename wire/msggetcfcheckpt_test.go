@@ -0,0 +1,141 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestGetCFCheckptLatest tests the MsgGetCFCheckpt API against the latest
+// protocol version.
+func TestGetCFCheckptLatest(t *testing.T) {
+	pver := ProtocolVersion
+
+	hash := chainhash.Hash{0x01}
+	msg := NewMsgGetCFCheckpt(GCSFilterExtended, &hash)
+	if msg.StopHash != hash || msg.FilterType != GCSFilterExtended {
+		t.Errorf("NewMsgGetCFCheckpt: wrong fields - got %v",
+			spew.Sdump(msg))
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := "getcfcheckpt"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgGetCFCheckpt: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	wantPayload := uint32(33)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+}
+
+// TestGetCFCheckptWire tests the MsgGetCFCheckpt wire encode and decode.
+func TestGetCFCheckptWire(t *testing.T) {
+	hash := chainhash.Hash{0x01, 0x02}
+	msg := NewMsgGetCFCheckpt(GCSFilterExtended, &hash)
+	msgEncoded := append([]byte{0x01}, hash[:]...)
+
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, NodeCFVersion)
+	if err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), msgEncoded) {
+		t.Fatalf("BtcEncode\n got: %s want: %s",
+			spew.Sdump(buf.Bytes()), spew.Sdump(msgEncoded))
+	}
+
+	var readMsg MsgGetCFCheckpt
+	err = readMsg.BtcDecode(bytes.NewReader(msgEncoded), NodeCFVersion)
+	if err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&readMsg, msg) {
+		t.Fatalf("BtcDecode\n got: %s want: %s", spew.Sdump(&readMsg),
+			spew.Sdump(msg))
+	}
+}
+
+// TestGetCFCheckptWireErrors performs negative tests against wire encode and
+// decode of MsgGetCFCheckpt to confirm error paths work correctly.
+func TestGetCFCheckptWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	pverNoCF := NodeCFVersion - 1
+	wireErr := &MessageError{}
+
+	hash := chainhash.Hash{0x01, 0x02}
+	baseGetCFCheckpt := NewMsgGetCFCheckpt(GCSFilterRegular, &hash)
+	baseGetCFCheckptEncoded := append([]byte{0x00}, hash[:]...)
+
+	tests := []struct {
+		in       *MsgGetCFCheckpt // Value to encode
+		buf      []byte           // Wire encoding
+		pver     uint32           // Protocol version for wire encoding
+		max      int              // Max size of fixed buffer to induce errors
+		writeErr error            // Expected write error
+		readErr  error            // Expected read error
+	}{
+		// Force error in filter type.
+		{baseGetCFCheckpt, baseGetCFCheckptEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in stop hash.
+		{baseGetCFCheckpt, baseGetCFCheckptEncoded, pver, 1, io.ErrShortWrite, io.EOF},
+		// Force error due to unsupported protocol version.
+		{baseGetCFCheckpt, baseGetCFCheckptEncoded, pverNoCF, 33, wireErr, wireErr},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.writeErr {
+				t.Errorf("BtcEncode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.writeErr)
+				continue
+			}
+		}
+
+		// Decode from wire format.
+		var msg MsgGetCFCheckpt
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.readErr {
+				t.Errorf("BtcDecode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.readErr)
+				continue
+			}
+		}
+	}
+}
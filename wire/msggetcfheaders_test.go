@@ -0,0 +1,199 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestGetCFHeadersLatest tests the MsgGetCFHeaders API against the latest
+// protocol version.
+func TestGetCFHeadersLatest(t *testing.T) {
+	pver := ProtocolVersion
+
+	msg := NewMsgGetCFHeaders()
+
+	// Ensure the command is expected value.
+	wantCmd := "getcfheaders"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgGetCFHeaders: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	// Num block locator hashes (varInt) + max allowed block locators +
+	// hash stop + filter type.
+	wantPayload := uint32(16042)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure block locator hashes are added properly.
+	hash := chainhash.Hash{0x01}
+	err := msg.AddBlockLocatorHash(&hash)
+	if err != nil {
+		t.Errorf("AddBlockLocatorHash: %v", err)
+	}
+	if msg.BlockLocatorHashes[0] != &hash {
+		t.Errorf("AddBlockLocatorHash: wrong block locator added - "+
+			"got %v, want %v",
+			spew.Sprint(msg.BlockLocatorHashes[0]),
+			spew.Sprint(hash))
+	}
+
+	// Ensure adding more than the max allowed block locator hashes per
+	// message returns an error.
+	for i := 0; i < MaxBlockLocatorsPerMsg; i++ {
+		err = msg.AddBlockLocatorHash(&hash)
+	}
+	if err == nil {
+		t.Errorf("AddBlockLocatorHash: expected error on too many " +
+			"block locator hashes not received")
+	}
+}
+
+// TestGetCFHeadersWire tests the MsgGetCFHeaders wire encode and decode.
+func TestGetCFHeadersWire(t *testing.T) {
+	hash1 := chainhash.Hash{0x01}
+	hash2 := chainhash.Hash{0x02}
+	hashStop := chainhash.Hash{0x03}
+
+	msg := NewMsgGetCFHeaders()
+	msg.AddBlockLocatorHash(&hash1)
+	msg.AddBlockLocatorHash(&hash2)
+	msg.HashStop = hashStop
+	msg.FilterType = GCSFilterExtended
+	msgEncoded := []byte{0x02} // Varint for number of block locator hashes
+	msgEncoded = append(msgEncoded, hash1[:]...)
+	msgEncoded = append(msgEncoded, hash2[:]...)
+	msgEncoded = append(msgEncoded, hashStop[:]...)
+	msgEncoded = append(msgEncoded, 0x01) // Filter type
+
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, NodeCFVersion)
+	if err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), msgEncoded) {
+		t.Fatalf("BtcEncode\n got: %s want: %s",
+			spew.Sdump(buf.Bytes()), spew.Sdump(msgEncoded))
+	}
+
+	var readMsg MsgGetCFHeaders
+	err = readMsg.BtcDecode(bytes.NewReader(msgEncoded), NodeCFVersion)
+	if err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+	if !reflect.DeepEqual(readMsg.BlockLocatorHashes,
+		msg.BlockLocatorHashes) || readMsg.HashStop != msg.HashStop ||
+		readMsg.FilterType != msg.FilterType {
+
+		t.Fatalf("BtcDecode\n got: %s want: %s", spew.Sdump(&readMsg),
+			spew.Sdump(msg))
+	}
+}
+
+// TestGetCFHeadersWireErrors performs negative tests against wire encode and
+// decode of MsgGetCFHeaders to confirm error paths work correctly.
+func TestGetCFHeadersWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	pverNoCF := NodeCFVersion - 1
+	wireErr := &MessageError{}
+
+	hash := chainhash.Hash{0x01}
+	hashStop := chainhash.Hash{0x02}
+	baseGetCFHeaders := NewMsgGetCFHeaders()
+	baseGetCFHeaders.AddBlockLocatorHash(&hash)
+	baseGetCFHeaders.HashStop = hashStop
+	baseGetCFHeadersEncoded := []byte{0x01}
+	baseGetCFHeadersEncoded = append(baseGetCFHeadersEncoded, hash[:]...)
+	baseGetCFHeadersEncoded = append(baseGetCFHeadersEncoded, hashStop[:]...)
+	baseGetCFHeadersEncoded = append(baseGetCFHeadersEncoded, 0x00)
+
+	// Message that forces an error by having more than the max allowed
+	// block locator hashes.
+	maxGetCFHeaders := NewMsgGetCFHeaders()
+	for i := 0; i < MaxBlockLocatorsPerMsg; i++ {
+		maxGetCFHeaders.AddBlockLocatorHash(&hash)
+	}
+	maxGetCFHeaders.BlockLocatorHashes = append(
+		maxGetCFHeaders.BlockLocatorHashes, &hash)
+	maxGetCFHeadersEncoded := []byte{
+		0xfd, 0xf5, 0x01, // Varint for number of block loc hashes (501)
+	}
+
+	tests := []struct {
+		in       *MsgGetCFHeaders // Value to encode
+		buf      []byte           // Wire encoding
+		pver     uint32           // Protocol version for wire encoding
+		max      int              // Max size of fixed buffer to induce errors
+		writeErr error            // Expected write error
+		readErr  error            // Expected read error
+	}{
+		// Force error in block locator hash count.
+		{baseGetCFHeaders, baseGetCFHeadersEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in block locator hashes.
+		{baseGetCFHeaders, baseGetCFHeadersEncoded, pver, 1, io.ErrShortWrite, io.EOF},
+		// Force error in hash stop.
+		{baseGetCFHeaders, baseGetCFHeadersEncoded, pver, 33, io.ErrShortWrite, io.EOF},
+		// Force error in filter type.
+		{baseGetCFHeaders, baseGetCFHeadersEncoded, pver, 65, io.ErrShortWrite, io.EOF},
+		// Force error with greater than max block locator hashes.
+		{maxGetCFHeaders, maxGetCFHeadersEncoded, pver, 3, wireErr, wireErr},
+		// Force error due to unsupported protocol version.
+		{baseGetCFHeaders, baseGetCFHeadersEncoded, pverNoCF, 66, wireErr, wireErr},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.writeErr {
+				t.Errorf("BtcEncode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.writeErr)
+				continue
+			}
+		}
+
+		// Decode from wire format.
+		var msg MsgGetCFHeaders
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.readErr {
+				t.Errorf("BtcDecode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.readErr)
+				continue
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// MsgGetCFilter implements the Message interface and represents a getcfilter
+// message.  It is used to request the committed filter of the passed type for
+// a block.  The filter is returned via a cfilter message (MsgCFilter).
+//
+// This message was not added until protocol versions starting with
+// NodeCFVersion.
+type MsgGetCFilter struct {
+	BlockHash  chainhash.Hash
+	FilterType FilterType
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFilter) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("getcfilter message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetCFilter.BtcDecode", str)
+	}
+
+	return readElements(r, &msg.BlockHash, &msg.FilterType)
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFilter) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("getcfilter message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetCFilter.BtcEncode", str)
+	}
+
+	return writeElements(w, &msg.BlockHash, msg.FilterType)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetCFilter) Command() string {
+	return CmdGetCFilter
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetCFilter) MaxPayloadLength(pver uint32) uint32 {
+	// Block hash + filter type 1 byte.
+	return chainhash.HashSize + 1
+}
+
+// NewMsgGetCFilter returns a new getcfilter message that conforms to the
+// Message interface.  See MsgGetCFilter for details.
+func NewMsgGetCFilter(blockHash *chainhash.Hash, filterType FilterType) *MsgGetCFilter {
+	return &MsgGetCFilter{
+		BlockHash:  *blockHash,
+		FilterType: filterType,
+	}
+}
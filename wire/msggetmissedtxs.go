@@ -0,0 +1,102 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// MsgGetMissedTxs implements the Message interface and represents a
+// getmissedtxs message.  It is used to request the transactions of a block
+// announced by a lightblock message (MsgLightBlock) which the requester could
+// not find in its memory pool.  The transactions are returned via a missedtxs
+// message (MsgMissedTxs).
+//
+// Use AddTxHash to build up the list of transaction hashes.
+//
+// This message was not added until protocol versions starting with
+// LightBlockVersion and must only be sent to peers which advertise the
+// SFNodeLightBlock service flag.
+type MsgGetMissedTxs struct {
+	BlockHash chainhash.Hash
+	TxHashes  []chainhash.Hash
+}
+
+// AddTxHash adds a new transaction hash to the message.
+func (msg *MsgGetMissedTxs) AddTxHash(hash *chainhash.Hash) error {
+	maxHashes := 2 * MaxTxPerTxTree(ProtocolVersion)
+	if uint64(len(msg.TxHashes))+1 > maxHashes {
+		str := fmt.Sprintf("too many transaction hashes for message "+
+			"[max %v]", maxHashes)
+		return messageError("MsgGetMissedTxs.AddTxHash", str)
+	}
+
+	msg.TxHashes = append(msg.TxHashes, *hash)
+	return nil
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetMissedTxs) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < LightBlockVersion {
+		str := fmt.Sprintf("getmissedtxs message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetMissedTxs.BtcDecode", str)
+	}
+
+	err := readElement(r, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	msg.TxHashes, err = readTxHashes(r, pver, 2*MaxTxPerTxTree(pver),
+		"MsgGetMissedTxs.BtcDecode")
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetMissedTxs) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < LightBlockVersion {
+		str := fmt.Sprintf("getmissedtxs message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetMissedTxs.BtcEncode", str)
+	}
+
+	err := writeElement(w, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	return writeTxHashes(w, pver, msg.TxHashes, 2*MaxTxPerTxTree(pver),
+		"MsgGetMissedTxs.BtcEncode")
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetMissedTxs) Command() string {
+	return CmdGetMissedTxs
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetMissedTxs) MaxPayloadLength(pver uint32) uint32 {
+	// Block hash + num transaction hashes (varInt) + max allowed
+	// transaction hashes.
+	return chainhash.HashSize + MaxVarIntPayload +
+		uint32(2*MaxTxPerTxTree(pver))*chainhash.HashSize
+}
+
+// NewMsgGetMissedTxs returns a new getmissedtxs message that conforms to the
+// Message interface.  See MsgGetMissedTxs for details.
+func NewMsgGetMissedTxs(blockHash *chainhash.Hash) *MsgGetMissedTxs {
+	return &MsgGetMissedTxs{
+		BlockHash: *blockHash,
+		TxHashes:  make([]chainhash.Hash, 0),
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestGetMissedTxsLatest tests the MsgGetMissedTxs API against the latest
+// protocol version.
+func TestGetMissedTxsLatest(t *testing.T) {
+	pver := ProtocolVersion
+
+	blockHash := chainhash.Hash{0x01}
+	msg := NewMsgGetMissedTxs(&blockHash)
+	if msg.BlockHash != blockHash || len(msg.TxHashes) != 0 {
+		t.Errorf("NewMsgGetMissedTxs: wrong fields - got %v",
+			spew.Sdump(msg))
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := "getmissedtxs"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgGetMissedTxs: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	maxHashes := 2 * MaxTxPerTxTree(pver)
+	wantPayload := uint32(32 + 9 + maxHashes*32)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure transaction hashes are added properly.
+	txHash := chainhash.Hash{0x02}
+	err := msg.AddTxHash(&txHash)
+	if err != nil {
+		t.Errorf("AddTxHash: %v", err)
+	}
+	if msg.TxHashes[0] != txHash {
+		t.Errorf("AddTxHash: wrong hash added - got %v, want %v",
+			msg.TxHashes[0], txHash)
+	}
+
+	// Ensure adding more than the max allowed hashes per message returns
+	// an error.
+	msg.TxHashes = make([]chainhash.Hash, maxHashes)
+	err = msg.AddTxHash(&txHash)
+	if err == nil {
+		t.Errorf("AddTxHash: expected error on too many transaction " +
+			"hashes not received")
+	}
+}
+
+// TestGetMissedTxsWire tests the MsgGetMissedTxs wire encode and decode.
+func TestGetMissedTxsWire(t *testing.T) {
+	blockHash := chainhash.Hash{0x01}
+	txHash := chainhash.Hash{0x02}
+	msg := NewMsgGetMissedTxs(&blockHash)
+	msg.AddTxHash(&txHash)
+	msgEncoded := append(append(append([]byte{}, blockHash[:]...), 0x01),
+		txHash[:]...)
+
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, LightBlockVersion)
+	if err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), msgEncoded) {
+		t.Fatalf("BtcEncode\n got: %s want: %s",
+			spew.Sdump(buf.Bytes()), spew.Sdump(msgEncoded))
+	}
+
+	var readMsg MsgGetMissedTxs
+	err = readMsg.BtcDecode(bytes.NewReader(msgEncoded), LightBlockVersion)
+	if err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&readMsg, msg) {
+		t.Fatalf("BtcDecode\n got: %s want: %s", spew.Sdump(&readMsg),
+			spew.Sdump(msg))
+	}
+}
+
+// TestGetMissedTxsWireErrors performs negative tests against wire encode and
+// decode of MsgGetMissedTxs to confirm error paths work correctly.
+func TestGetMissedTxsWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	pverNoLightBlock := LightBlockVersion - 1
+	wireErr := &MessageError{}
+
+	blockHash := chainhash.Hash{0x01}
+	txHash := chainhash.Hash{0x02}
+	baseGetMissedTxs := NewMsgGetMissedTxs(&blockHash)
+	baseGetMissedTxs.AddTxHash(&txHash)
+	baseGetMissedTxsEncoded := append(append(append([]byte{},
+		blockHash[:]...), 0x01), txHash[:]...)
+
+	// Message with more hashes than could possibly fit into a block.
+	maxHashes := 2 * MaxTxPerTxTree(pver)
+	maxGetMissedTxs := NewMsgGetMissedTxs(&blockHash)
+	maxGetMissedTxs.TxHashes = make([]chainhash.Hash, maxHashes+1)
+	maxGetMissedTxsEncoded := append(append([]byte{}, blockHash[:]...),
+		0xfe, 0xff, 0xff, 0x00, 0x00)
+
+	tests := []struct {
+		in       *MsgGetMissedTxs // Value to encode
+		buf      []byte           // Wire encoding
+		pver     uint32           // Protocol version for wire encoding
+		max      int              // Max size of fixed buffer to induce errors
+		writeErr error            // Expected write error
+		readErr  error            // Expected read error
+	}{
+		// Force error in block hash.
+		{baseGetMissedTxs, baseGetMissedTxsEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in transaction hash count.
+		{baseGetMissedTxs, baseGetMissedTxsEncoded, pver, 32, io.ErrShortWrite, io.EOF},
+		// Force error in transaction hashes.
+		{baseGetMissedTxs, baseGetMissedTxsEncoded, pver, 33, io.ErrShortWrite, io.EOF},
+		// Force error with greater than max transaction hashes.
+		{maxGetMissedTxs, maxGetMissedTxsEncoded, pver, 37, wireErr, wireErr},
+		// Force error due to unsupported protocol version.
+		{baseGetMissedTxs, baseGetMissedTxsEncoded, pverNoLightBlock, 65, wireErr, wireErr},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.writeErr {
+				t.Errorf("BtcEncode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.writeErr)
+				continue
+			}
+		}
+
+		// Decode from wire format.
+		var msg MsgGetMissedTxs
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.readErr {
+				t.Errorf("BtcDecode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.readErr)
+				continue
+			}
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// MsgLightBlock implements the Message interface and represents a lightblock
+// message.  It is used to announce a new block to peers which already have
+// most of its transactions in their memory pool.  Instead of the transactions,
+// the message carries the header of the block along with the hashes of the
+// transactions of both of its trees in block order.  The transactions which
+// the receiver can not possibly know about, such as the coinbase, are included
+// in full as prefilled transactions.
+//
+// The receiver reconstructs the block from the prefilled transactions and its
+// memory pool and requests the transactions it is still missing via a
+// getmissedtxs message (MsgGetMissedTxs).
+//
+// This message was not added until protocol versions starting with
+// LightBlockVersion and must only be sent to peers which advertise the
+// SFNodeLightBlock service flag.
+type MsgLightBlock struct {
+	Header       BlockHeader
+	TxHashes     []chainhash.Hash
+	STxHashes    []chainhash.Hash
+	PrefilledTxs []*MsgTx
+}
+
+// readTxHashes reads a list of transaction hashes which is limited to the
+// passed maximum count from r.
+func readTxHashes(r io.Reader, pver uint32, maxCount uint64, funcName string) ([]chainhash.Hash, error) {
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+
+	// Limit to max transaction hashes per message.  It would be possible
+	// to cause memory exhaustion without a sane upper bound on the count.
+	if count > maxCount {
+		str := fmt.Sprintf("too many transaction hashes for message "+
+			"[count %v, max %v]", count, maxCount)
+		return nil, messageError(funcName, str)
+	}
+
+	hashes := make([]chainhash.Hash, count)
+	for i := range hashes {
+		err := readElement(r, &hashes[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+// writeTxHashes writes a list of transaction hashes which is limited to the
+// passed maximum count to w.
+func writeTxHashes(w io.Writer, pver uint32, hashes []chainhash.Hash, maxCount uint64, funcName string) error {
+	count := uint64(len(hashes))
+	if count > maxCount {
+		str := fmt.Sprintf("too many transaction hashes for message "+
+			"[count %v, max %v]", count, maxCount)
+		return messageError(funcName, str)
+	}
+
+	err := WriteVarInt(w, pver, count)
+	if err != nil {
+		return err
+	}
+	for i := range hashes {
+		err := writeElement(w, &hashes[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgLightBlock) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < LightBlockVersion {
+		str := fmt.Sprintf("lightblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgLightBlock.BtcDecode", str)
+	}
+
+	err := readBlockHeader(r, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	msg.TxHashes, err = readTxHashes(r, pver, maxTxPerTree,
+		"MsgLightBlock.BtcDecode")
+	if err != nil {
+		return err
+	}
+	msg.STxHashes, err = readTxHashes(r, pver, maxTxPerTree,
+		"MsgLightBlock.BtcDecode")
+	if err != nil {
+		return err
+	}
+
+	// Prevent more prefilled transactions than the block has.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	numTxns := uint64(len(msg.TxHashes) + len(msg.STxHashes))
+	if count > numTxns {
+		str := fmt.Sprintf("too many prefilled transactions for "+
+			"message [count %v, max %v]", count, numTxns)
+		return messageError("MsgLightBlock.BtcDecode", str)
+	}
+
+	msg.PrefilledTxs = make([]*MsgTx, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var tx MsgTx
+		err := tx.BtcDecode(r, pver)
+		if err != nil {
+			return err
+		}
+		msg.PrefilledTxs = append(msg.PrefilledTxs, &tx)
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgLightBlock) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < LightBlockVersion {
+		str := fmt.Sprintf("lightblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgLightBlock.BtcEncode", str)
+	}
+
+	err := writeBlockHeader(w, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	err = writeTxHashes(w, pver, msg.TxHashes, maxTxPerTree,
+		"MsgLightBlock.BtcEncode")
+	if err != nil {
+		return err
+	}
+	err = writeTxHashes(w, pver, msg.STxHashes, maxTxPerTree,
+		"MsgLightBlock.BtcEncode")
+	if err != nil {
+		return err
+	}
+
+	count := len(msg.PrefilledTxs)
+	numTxns := len(msg.TxHashes) + len(msg.STxHashes)
+	if count > numTxns {
+		str := fmt.Sprintf("too many prefilled transactions for "+
+			"message [count %v, max %v]", count, numTxns)
+		return messageError("MsgLightBlock.BtcEncode", str)
+	}
+
+	err = WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+	for _, tx := range msg.PrefilledTxs {
+		err := tx.BtcEncode(w, pver)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BlockHash computes the block identifier hash for this light block.
+func (msg *MsgLightBlock) BlockHash() chainhash.Hash {
+	return msg.Header.BlockHash()
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgLightBlock) Command() string {
+	return CmdLightBlock
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgLightBlock) MaxPayloadLength(pver uint32) uint32 {
+	// A light block is never larger than the block it represents.
+	return MaxBlockPayload
+}
+
+// NewMsgLightBlock returns a new lightblock message that conforms to the
+// Message interface.  See MsgLightBlock for details.
+func NewMsgLightBlock(header *BlockHeader) *MsgLightBlock {
+	return &MsgLightBlock{
+		Header:       *header,
+		TxHashes:     make([]chainhash.Hash, 0, defaultTransactionAlloc),
+		STxHashes:    make([]chainhash.Hash, 0, defaultTransactionAlloc),
+		PrefilledTxs: make([]*MsgTx, 0, 1),
+	}
+}
+
+// NewMsgLightBlockFromBlock returns a new lightblock message for the passed
+// block.  The coinbase of the block is the only prefilled transaction since
+// it can not be in the memory pool of the receiver.
+func NewMsgLightBlockFromBlock(block *MsgBlock) *MsgLightBlock {
+	msg := &MsgLightBlock{
+		Header:    block.Header,
+		TxHashes:  make([]chainhash.Hash, 0, len(block.Transactions)),
+		STxHashes: make([]chainhash.Hash, 0, len(block.STransactions)),
+	}
+	for i, tx := range block.Transactions {
+		msg.TxHashes = append(msg.TxHashes, tx.TxHash())
+		if i == 0 {
+			msg.PrefilledTxs = append(msg.PrefilledTxs, tx)
+		}
+	}
+	for _, stx := range block.STransactions {
+		msg.STxHashes = append(msg.STxHashes, stx.TxHash())
+	}
+	return msg
+}
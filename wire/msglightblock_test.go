@@ -0,0 +1,226 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestLightBlockLatest tests the MsgLightBlock API against the latest protocol
+// version.
+func TestLightBlockLatest(t *testing.T) {
+	pver := ProtocolVersion
+
+	msg := NewMsgLightBlockFromBlock(&testBlock)
+	if msg.Header != testBlock.Header {
+		t.Errorf("NewMsgLightBlockFromBlock: wrong header - got %v, "+
+			"want %v", spew.Sdump(msg.Header),
+			spew.Sdump(testBlock.Header))
+	}
+	if len(msg.TxHashes) != len(testBlock.Transactions) ||
+		len(msg.STxHashes) != len(testBlock.STransactions) {
+
+		t.Errorf("NewMsgLightBlockFromBlock: wrong number of hashes - "+
+			"got %d/%d, want %d/%d", len(msg.TxHashes),
+			len(msg.STxHashes), len(testBlock.Transactions),
+			len(testBlock.STransactions))
+	}
+	for i, tx := range testBlock.Transactions {
+		if msg.TxHashes[i] != tx.TxHash() {
+			t.Errorf("NewMsgLightBlockFromBlock: wrong hash for "+
+				"transaction %d - got %v, want %v", i,
+				msg.TxHashes[i], tx.TxHash())
+		}
+	}
+	for i, stx := range testBlock.STransactions {
+		if msg.STxHashes[i] != stx.TxHash() {
+			t.Errorf("NewMsgLightBlockFromBlock: wrong hash for "+
+				"stake transaction %d - got %v, want %v", i,
+				msg.STxHashes[i], stx.TxHash())
+		}
+	}
+
+	// Ensure the coinbase is the only prefilled transaction.
+	if len(msg.PrefilledTxs) != 1 ||
+		msg.PrefilledTxs[0] != testBlock.Transactions[0] {
+
+		t.Errorf("NewMsgLightBlockFromBlock: wrong prefilled "+
+			"transactions - got %v", spew.Sdump(msg.PrefilledTxs))
+	}
+
+	// Ensure the hash is the one of the block.
+	if msg.BlockHash() != testBlock.BlockHash() {
+		t.Errorf("BlockHash: wrong hash - got %v, want %v",
+			msg.BlockHash(), testBlock.BlockHash())
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := "lightblock"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgLightBlock: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	wantPayload := uint32(MaxBlockPayload)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+}
+
+// TestLightBlockWire tests the MsgLightBlock wire encode and decode.
+func TestLightBlockWire(t *testing.T) {
+	msg := NewMsgLightBlockFromBlock(&testBlock)
+
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, LightBlockVersion)
+	if err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+
+	// The encoding is the header, the varint prefixed hashes of both
+	// trees, and the varint prefixed prefilled coinbase.
+	numHashes := len(msg.TxHashes) + len(msg.STxHashes)
+	wantLen := blockHeaderLen + 3 + numHashes*chainhash.HashSize +
+		msg.PrefilledTxs[0].SerializeSize()
+	if buf.Len() != wantLen {
+		t.Fatalf("BtcEncode: wrong length - got %d, want %d",
+			buf.Len(), wantLen)
+	}
+
+	var readMsg MsgLightBlock
+	err = readMsg.BtcDecode(bytes.NewReader(buf.Bytes()), LightBlockVersion)
+	if err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&readMsg, msg) {
+		t.Fatalf("BtcDecode\n got: %s want: %s", spew.Sdump(&readMsg),
+			spew.Sdump(msg))
+	}
+}
+
+// TestLightBlockWireErrors performs negative tests against wire encode and
+// decode of MsgLightBlock to confirm error paths work correctly.
+func TestLightBlockWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	pverNoLightBlock := LightBlockVersion - 1
+	wireErr := &MessageError{}
+
+	baseLightBlock := NewMsgLightBlockFromBlock(&testBlock)
+	var buf bytes.Buffer
+	if err := baseLightBlock.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	baseLightBlockEncoded := buf.Bytes()
+
+	// Offsets of the hash counts and the prefilled transactions.
+	txHashCountOffset := blockHeaderLen
+	stxHashCountOffset := txHashCountOffset + 1 +
+		len(baseLightBlock.TxHashes)*chainhash.HashSize
+	prefilledCountOffset := stxHashCountOffset + 1 +
+		len(baseLightBlock.STxHashes)*chainhash.HashSize
+
+	// Message with more prefilled transactions than the block has.
+	maxPrefilled := NewMsgLightBlock(&testBlock.Header)
+	maxPrefilled.PrefilledTxs = append(maxPrefilled.PrefilledTxs,
+		testBlock.Transactions[0])
+	maxPrefilledEncoded := make([]byte, blockHeaderLen+3)
+	copy(maxPrefilledEncoded, baseLightBlockEncoded[:blockHeaderLen])
+	maxPrefilledEncoded[blockHeaderLen+2] = 0x01
+
+	// Message with more hashes than could possibly fit into a block.
+	maxHashesEncoded := make([]byte, blockHeaderLen+9)
+	copy(maxHashesEncoded, baseLightBlockEncoded[:blockHeaderLen])
+	maxHashesEncoded[blockHeaderLen] = 0xff
+	maxHashesEncoded[blockHeaderLen+8] = 0x01
+
+	tests := []struct {
+		in       *MsgLightBlock // Value to encode
+		buf      []byte         // Wire encoding
+		pver     uint32         // Protocol version for wire encoding
+		max      int            // Max size of fixed buffer to induce errors
+		writeErr error          // Expected write error
+		readErr  error          // Expected read error
+	}{
+		// Force error in header.
+		{baseLightBlock, baseLightBlockEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in transaction hash count.
+		{baseLightBlock, baseLightBlockEncoded, pver, txHashCountOffset, io.ErrShortWrite, io.EOF},
+		// Force error in transaction hashes.
+		{baseLightBlock, baseLightBlockEncoded, pver, txHashCountOffset + 1, io.ErrShortWrite, io.EOF},
+		// Force error in stake transaction hash count.
+		{baseLightBlock, baseLightBlockEncoded, pver, stxHashCountOffset, io.ErrShortWrite, io.EOF},
+		// Force error in stake transaction hashes.
+		{baseLightBlock, baseLightBlockEncoded, pver, stxHashCountOffset + 1, io.ErrShortWrite, io.EOF},
+		// Force error in prefilled transaction count.
+		{baseLightBlock, baseLightBlockEncoded, pver, prefilledCountOffset, io.ErrShortWrite, io.EOF},
+		// Force error in prefilled transactions.
+		{baseLightBlock, baseLightBlockEncoded, pver, prefilledCountOffset + 1, io.ErrShortWrite, io.EOF},
+		// Force error with more prefilled transactions than hashes.
+		{maxPrefilled, maxPrefilledEncoded, pver, len(maxPrefilledEncoded), wireErr, wireErr},
+		// Force error due to unsupported protocol version.
+		{baseLightBlock, baseLightBlockEncoded, pverNoLightBlock, len(baseLightBlockEncoded), wireErr, wireErr},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.writeErr {
+				t.Errorf("BtcEncode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.writeErr)
+				continue
+			}
+		}
+
+		// Decode from wire format.
+		var msg MsgLightBlock
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.readErr {
+				t.Errorf("BtcDecode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.readErr)
+				continue
+			}
+		}
+	}
+
+	// Ensure decoding more hashes than could possibly fit into a block
+	// fails.
+	var msg MsgLightBlock
+	err := msg.BtcDecode(bytes.NewReader(maxHashesEncoded), pver)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode wrong error for too many hashes got: %v, "+
+			"want: %v", err, wireErr)
+	}
+}
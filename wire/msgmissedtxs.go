@@ -0,0 +1,140 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// MsgMissedTxs implements the Message interface and represents a missedtxs
+// message.  It is used to deliver the transactions of a block in response to
+// a getmissedtxs message (MsgGetMissedTxs).  Transactions which are not part
+// of the block are omitted, so the receiver must fall back to requesting the
+// full block when any of the requested transactions are missing.
+//
+// Use AddTransaction to build up the list of transactions.
+//
+// This message was not added until protocol versions starting with
+// LightBlockVersion and must only be sent to peers which advertise the
+// SFNodeLightBlock service flag.
+type MsgMissedTxs struct {
+	BlockHash    chainhash.Hash
+	Transactions []*MsgTx
+}
+
+// AddTransaction adds a transaction to the message.
+func (msg *MsgMissedTxs) AddTransaction(tx *MsgTx) error {
+	maxTxns := 2 * MaxTxPerTxTree(ProtocolVersion)
+	if uint64(len(msg.Transactions))+1 > maxTxns {
+		str := fmt.Sprintf("too many transactions for message "+
+			"[max %v]", maxTxns)
+		return messageError("MsgMissedTxs.AddTransaction", str)
+	}
+
+	msg.Transactions = append(msg.Transactions, tx)
+	return nil
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgMissedTxs) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < LightBlockVersion {
+		str := fmt.Sprintf("missedtxs message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgMissedTxs.BtcDecode", str)
+	}
+
+	err := readElement(r, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	// Prevent more transactions than could possibly fit into a block.
+	// It would be possible to cause memory exhaustion and panics without
+	// a sane upper bound on this count.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	maxTxns := 2 * MaxTxPerTxTree(pver)
+	if count > maxTxns {
+		str := fmt.Sprintf("too many transactions for message "+
+			"[count %v, max %v]", count, maxTxns)
+		return messageError("MsgMissedTxs.BtcDecode", str)
+	}
+
+	msg.Transactions = make([]*MsgTx, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var tx MsgTx
+		err := tx.BtcDecode(r, pver)
+		if err != nil {
+			return err
+		}
+		msg.Transactions = append(msg.Transactions, &tx)
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgMissedTxs) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < LightBlockVersion {
+		str := fmt.Sprintf("missedtxs message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgMissedTxs.BtcEncode", str)
+	}
+
+	count := uint64(len(msg.Transactions))
+	maxTxns := 2 * MaxTxPerTxTree(pver)
+	if count > maxTxns {
+		str := fmt.Sprintf("too many transactions for message "+
+			"[count %v, max %v]", count, maxTxns)
+		return messageError("MsgMissedTxs.BtcEncode", str)
+	}
+
+	err := writeElement(w, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, pver, count)
+	if err != nil {
+		return err
+	}
+	for _, tx := range msg.Transactions {
+		err := tx.BtcEncode(w, pver)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgMissedTxs) Command() string {
+	return CmdMissedTxs
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgMissedTxs) MaxPayloadLength(pver uint32) uint32 {
+	// The transactions are never larger than the block they are part of.
+	return MaxBlockPayload
+}
+
+// NewMsgMissedTxs returns a new missedtxs message that conforms to the
+// Message interface.  See MsgMissedTxs for details.
+func NewMsgMissedTxs(blockHash *chainhash.Hash) *MsgMissedTxs {
+	return &MsgMissedTxs{
+		BlockHash:    *blockHash,
+		Transactions: make([]*MsgTx, 0),
+	}
+}
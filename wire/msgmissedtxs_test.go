@@ -0,0 +1,174 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestMissedTxsLatest tests the MsgMissedTxs API against the latest protocol
+// version.
+func TestMissedTxsLatest(t *testing.T) {
+	pver := ProtocolVersion
+
+	blockHash := chainhash.Hash{0x01}
+	msg := NewMsgMissedTxs(&blockHash)
+	if msg.BlockHash != blockHash || len(msg.Transactions) != 0 {
+		t.Errorf("NewMsgMissedTxs: wrong fields - got %v",
+			spew.Sdump(msg))
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := "missedtxs"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgMissedTxs: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	wantPayload := uint32(MaxBlockPayload)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure transactions are added properly.
+	tx := testBlock.Transactions[0]
+	err := msg.AddTransaction(tx)
+	if err != nil {
+		t.Errorf("AddTransaction: %v", err)
+	}
+	if msg.Transactions[0] != tx {
+		t.Errorf("AddTransaction: wrong transaction added - got %v, "+
+			"want %v", spew.Sdump(msg.Transactions[0]), spew.Sdump(tx))
+	}
+
+	// Ensure adding more than the max allowed transactions per message
+	// returns an error.
+	msg.Transactions = make([]*MsgTx, 2*MaxTxPerTxTree(pver))
+	err = msg.AddTransaction(tx)
+	if err == nil {
+		t.Errorf("AddTransaction: expected error on too many " +
+			"transactions not received")
+	}
+}
+
+// TestMissedTxsWire tests the MsgMissedTxs wire encode and decode.
+func TestMissedTxsWire(t *testing.T) {
+	blockHash := testBlock.BlockHash()
+	msg := NewMsgMissedTxs(&blockHash)
+	for _, stx := range testBlock.STransactions {
+		msg.AddTransaction(stx)
+	}
+
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, LightBlockVersion)
+	if err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+
+	var readMsg MsgMissedTxs
+	err = readMsg.BtcDecode(bytes.NewReader(buf.Bytes()), LightBlockVersion)
+	if err != nil {
+		t.Fatalf("BtcDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&readMsg, msg) {
+		t.Fatalf("BtcDecode\n got: %s want: %s", spew.Sdump(&readMsg),
+			spew.Sdump(msg))
+	}
+}
+
+// TestMissedTxsWireErrors performs negative tests against wire encode and
+// decode of MsgMissedTxs to confirm error paths work correctly.
+func TestMissedTxsWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	pverNoLightBlock := LightBlockVersion - 1
+	wireErr := &MessageError{}
+
+	blockHash := chainhash.Hash{0x01}
+	baseMissedTxs := NewMsgMissedTxs(&blockHash)
+	baseMissedTxs.AddTransaction(testBlock.Transactions[0])
+	var buf bytes.Buffer
+	if err := baseMissedTxs.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	baseMissedTxsEncoded := buf.Bytes()
+
+	// Message with more transactions than could possibly fit into a block.
+	maxMissedTxs := NewMsgMissedTxs(&blockHash)
+	maxMissedTxs.Transactions = make([]*MsgTx, 2*MaxTxPerTxTree(pver)+1)
+	maxMissedTxsEncoded := append(append([]byte{}, blockHash[:]...),
+		0xfe, 0xff, 0xff, 0x00, 0x00)
+
+	tests := []struct {
+		in       *MsgMissedTxs // Value to encode
+		buf      []byte        // Wire encoding
+		pver     uint32        // Protocol version for wire encoding
+		max      int           // Max size of fixed buffer to induce errors
+		writeErr error         // Expected write error
+		readErr  error         // Expected read error
+	}{
+		// Force error in block hash.
+		{baseMissedTxs, baseMissedTxsEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in transaction count.
+		{baseMissedTxs, baseMissedTxsEncoded, pver, 32, io.ErrShortWrite, io.EOF},
+		// Force error in transactions.
+		{baseMissedTxs, baseMissedTxsEncoded, pver, 33, io.ErrShortWrite, io.EOF},
+		// Force error with greater than max transactions.
+		{maxMissedTxs, maxMissedTxsEncoded, pver, 37, wireErr, wireErr},
+		// Force error due to unsupported protocol version.
+		{baseMissedTxs, baseMissedTxsEncoded, pverNoLightBlock, len(baseMissedTxsEncoded), wireErr, wireErr},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.writeErr {
+				t.Errorf("BtcEncode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.writeErr)
+				continue
+			}
+		}
+
+		// Decode from wire format.
+		var msg MsgMissedTxs
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.readErr {
+				t.Errorf("BtcDecode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.readErr)
+				continue
+			}
+		}
+	}
+}
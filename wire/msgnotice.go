@@ -0,0 +1,161 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+const (
+	// MaxNoticeMessageLen is the maximum number of bytes allowed in the
+	// text of a notice.
+	MaxNoticeMessageLen = 1024
+
+	// MaxNoticeSignatureLen is the maximum number of bytes allowed in the
+	// signature of a notice.  This is the length of the largest DER
+	// encoded secp256k1 signature.
+	MaxNoticeSignatureLen = 72
+)
+
+// MsgNotice implements the Message interface and represents a notice
+// message.  It is used to broadcast a message to node operators, such as a
+// warning about a required upgrade, that is signed by one of the notice keys
+// configured in the network parameters.  Nodes verify the signature before
+// relaying the message.
+//
+// Unlike the legacy alert message, a notice carries no instructions for the
+// node software and is only ever displayed.
+//
+// This message was not added until protocol versions starting with
+// NoticeVersion.
+type MsgNotice struct {
+	// Version is the version of the notice format.
+	Version int32
+
+	// ID uniquely identifies the notice.  Nodes ignore notices with an ID
+	// they have already seen.
+	ID uint64
+
+	// Expiration is the unix time after which the notice is no longer
+	// relayed or displayed.
+	Expiration int64
+
+	// Message is the text displayed to node operators.
+	Message string
+
+	// Signature is the DER encoded signature of SignatureHash by one of
+	// the network notice keys.
+	Signature []byte
+}
+
+// writePayload serializes all notice fields that are covered by the
+// signature to w.
+func (msg *MsgNotice) writePayload(w io.Writer, pver uint32) error {
+	if len(msg.Message) > MaxNoticeMessageLen {
+		str := fmt.Sprintf("notice message is too long [len %d, max %d]",
+			len(msg.Message), MaxNoticeMessageLen)
+		return messageError("MsgNotice.BtcEncode", str)
+	}
+
+	err := writeElements(w, msg.Version, msg.ID, msg.Expiration)
+	if err != nil {
+		return err
+	}
+
+	return WriteVarString(w, pver, msg.Message)
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgNotice) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < NoticeVersion {
+		str := fmt.Sprintf("notice message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgNotice.BtcDecode", str)
+	}
+
+	err := readElements(r, &msg.Version, &msg.ID, &msg.Expiration)
+	if err != nil {
+		return err
+	}
+
+	text, err := ReadVarBytes(r, pver, MaxNoticeMessageLen,
+		"notice message")
+	if err != nil {
+		return err
+	}
+	msg.Message = string(text)
+
+	msg.Signature, err = ReadVarBytes(r, pver, MaxNoticeSignatureLen,
+		"notice signature")
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgNotice) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < NoticeVersion {
+		str := fmt.Sprintf("notice message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgNotice.BtcEncode", str)
+	}
+
+	if len(msg.Signature) > MaxNoticeSignatureLen {
+		str := fmt.Sprintf("notice signature is too long [len %d, "+
+			"max %d]", len(msg.Signature), MaxNoticeSignatureLen)
+		return messageError("MsgNotice.BtcEncode", str)
+	}
+
+	err := msg.writePayload(w, pver)
+	if err != nil {
+		return err
+	}
+
+	return WriteVarBytes(w, pver, msg.Signature)
+}
+
+// SignatureHash returns the hash that is signed by the notice keys.  It
+// commits to every field of the notice except the signature itself.
+func (msg *MsgNotice) SignatureHash() (chainhash.Hash, error) {
+	var buf bytes.Buffer
+	buf.Grow(int(msg.MaxPayloadLength(ProtocolVersion)))
+	if err := msg.writePayload(&buf, ProtocolVersion); err != nil {
+		return chainhash.Hash{}, err
+	}
+	return chainhash.HashH(buf.Bytes()), nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgNotice) Command() string {
+	return CmdNotice
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgNotice) MaxPayloadLength(pver uint32) uint32 {
+	// Version 4 bytes + id 8 bytes + expiration 8 bytes + message text
+	// length varint + message text + signature length varint + signature.
+	return 20 +
+		uint32(VarIntSerializeSize(MaxNoticeMessageLen)) +
+		MaxNoticeMessageLen +
+		uint32(VarIntSerializeSize(MaxNoticeSignatureLen)) +
+		MaxNoticeSignatureLen
+}
+
+// NewMsgNotice returns a new notice message that conforms to the Message
+// interface.  See MsgNotice for details.
+func NewMsgNotice(id uint64, expiration int64, message string) *MsgNotice {
+	return &MsgNotice{
+		Version:    1,
+		ID:         id,
+		Expiration: expiration,
+		Message:    message,
+	}
+}
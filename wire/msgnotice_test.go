@@ -0,0 +1,248 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestNoticeLatest tests the MsgNotice API against the latest protocol
+// version.
+func TestNoticeLatest(t *testing.T) {
+	pver := ProtocolVersion
+
+	msg := NewMsgNotice(7, 1500000000, "upgrade required")
+	msg.Signature = []byte{0x30, 0x01, 0x02}
+
+	// Ensure the command is expected value.
+	wantCmd := "notice"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgNotice: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	// Version 4 bytes + id 8 bytes + expiration 8 bytes + message varint
+	// 3 bytes + message 1024 bytes + signature varint 1 byte + signature
+	// 72 bytes.
+	wantPayload := uint32(1120)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Test encode with latest protocol version.
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, pver)
+	if err != nil {
+		t.Errorf("encode of MsgNotice failed %v err <%v>", msg, err)
+	}
+
+	// Test decode with latest protocol version.
+	var readmsg MsgNotice
+	err = readmsg.BtcDecode(&buf, pver)
+	if err != nil {
+		t.Errorf("decode of MsgNotice failed [%v] err <%v>", buf, err)
+	}
+	if !reflect.DeepEqual(msg, &readmsg) {
+		t.Errorf("decoded notice mismatch - got %v, want %v",
+			spew.Sdump(&readmsg), spew.Sdump(msg))
+	}
+
+	// Ensure the signature hash does not commit to the signature.
+	hash, err := msg.SignatureHash()
+	if err != nil {
+		t.Fatalf("SignatureHash: unexpected error %v", err)
+	}
+	readmsg.Signature = nil
+	hash2, err := readmsg.SignatureHash()
+	if err != nil {
+		t.Fatalf("SignatureHash: unexpected error %v", err)
+	}
+	if hash != hash2 {
+		t.Errorf("SignatureHash: hash changed with signature - got %v, "+
+			"want %v", hash2, hash)
+	}
+
+	// Ensure the signature hash commits to the content.
+	readmsg.Message = "upgrade optional"
+	hash2, err = readmsg.SignatureHash()
+	if err != nil {
+		t.Fatalf("SignatureHash: unexpected error %v", err)
+	}
+	if hash == hash2 {
+		t.Errorf("SignatureHash: hash did not change with message")
+	}
+}
+
+// TestNoticeWire tests the MsgNotice wire encode and decode for various
+// protocol versions.
+func TestNoticeWire(t *testing.T) {
+	notice := MsgNotice{
+		Version:    1,
+		ID:         0x0102030405060708,
+		Expiration: 0x59682f00,
+		Message:    "hi",
+		Signature:  []byte{0xaa, 0xbb},
+	}
+	noticeEncoded := []byte{
+		0x01, 0x00, 0x00, 0x00, // Version
+		0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, // ID
+		0x00, 0x2f, 0x68, 0x59, 0x00, 0x00, 0x00, 0x00, // Expiration
+		0x02, 'h', 'i', // Message
+		0x02, 0xaa, 0xbb, // Signature
+	}
+
+	tests := []struct {
+		in   MsgNotice // Message to encode
+		out  MsgNotice // Expected decoded message
+		buf  []byte    // Wire encoding
+		pver uint32    // Protocol version for wire encoding
+	}{
+		// Latest protocol version.
+		{notice, notice, noticeEncoded, ProtocolVersion},
+
+		// Protocol version NoticeVersion.
+		{notice, notice, noticeEncoded, NoticeVersion},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, test.pver)
+		if err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d\n got: %s want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		// Decode the message from wire format.
+		var msg MsgNotice
+		rbuf := bytes.NewReader(test.buf)
+		err = msg.BtcDecode(rbuf, test.pver)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(msg, test.out) {
+			t.Errorf("BtcDecode #%d\n got: %s want: %s", i,
+				spew.Sdump(msg), spew.Sdump(test.out))
+			continue
+		}
+	}
+}
+
+// TestNoticeWireErrors performs negative tests against wire encode and decode
+// of MsgNotice to confirm error paths work correctly.
+func TestNoticeWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	pverNoNotice := NoticeVersion - 1
+	wireErr := &MessageError{}
+
+	baseNotice := NewMsgNotice(1, 2, "hi")
+	baseNotice.Signature = []byte{0xaa}
+	baseNoticeEncoded := []byte{
+		0x01, 0x00, 0x00, 0x00, // Version
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // ID
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Expiration
+		0x02, 'h', 'i', // Message
+		0x01, 0xaa, // Signature
+	}
+
+	// Notice with a message that exceeds the maximum allowed length.
+	longNotice := NewMsgNotice(1, 2, strings.Repeat("x",
+		MaxNoticeMessageLen+1))
+	longNoticeEncoded := make([]byte, 20, 23)
+	longNoticeEncoded = append(longNoticeEncoded, 0xfd, 0x01, 0x04)
+
+	// Notice with a signature that exceeds the maximum allowed length.
+	longSigNotice := NewMsgNotice(1, 2, "")
+	longSigNotice.Signature = make([]byte, MaxNoticeSignatureLen+1)
+	longSigNoticeEncoded := make([]byte, 21, 22)
+	longSigNoticeEncoded = append(longSigNoticeEncoded,
+		MaxNoticeSignatureLen+1)
+
+	tests := []struct {
+		in       *MsgNotice // Value to encode
+		buf      []byte     // Wire encoding
+		pver     uint32     // Protocol version for wire encoding
+		max      int        // Max size of fixed buffer to induce errors
+		writeErr error      // Expected write error
+		readErr  error      // Expected read error
+	}{
+		// Latest protocol version with intentional read/write errors.
+		// Force error in version.
+		{baseNotice, baseNoticeEncoded, pver, 0, io.ErrShortWrite, io.EOF},
+		// Force error in id.
+		{baseNotice, baseNoticeEncoded, pver, 4, io.ErrShortWrite, io.EOF},
+		// Force error in expiration.
+		{baseNotice, baseNoticeEncoded, pver, 12, io.ErrShortWrite, io.EOF},
+		// Force error in message.
+		{baseNotice, baseNoticeEncoded, pver, 20, io.ErrShortWrite, io.EOF},
+		// Force error in signature.
+		{baseNotice, baseNoticeEncoded, pver, 23, io.ErrShortWrite, io.EOF},
+		// Force error due to unsupported protocol version.
+		{baseNotice, baseNoticeEncoded, pverNoNotice, 26, wireErr, wireErr},
+		// Force error with message that exceeds the max length.
+		{longNotice, longNoticeEncoded, pver, 2000, wireErr, wireErr},
+		// Force error with signature that exceeds the max length.
+		{longSigNotice, longSigNoticeEncoded, pver, 200, wireErr, wireErr},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode to wire format.
+		w := newFixedWriter(test.max)
+		err := test.in.BtcEncode(w, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.writeErr) {
+			t.Errorf("BtcEncode #%d wrong error got: %v, want: %v",
+				i, err, test.writeErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.writeErr {
+				t.Errorf("BtcEncode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.writeErr)
+				continue
+			}
+		}
+
+		// Decode from wire format.
+		var msg MsgNotice
+		r := newFixedReader(test.max, test.buf)
+		err = msg.BtcDecode(r, test.pver)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.readErr) {
+			t.Errorf("BtcDecode #%d wrong error got: %v, want: %v",
+				i, err, test.readErr)
+			continue
+		}
+
+		// For errors which are not of type MessageError, check them for
+		// equality.
+		if _, ok := err.(*MessageError); !ok {
+			if err != test.readErr {
+				t.Errorf("BtcDecode #%d wrong error got: %v, "+
+					"want: %v", i, err, test.readErr)
+				continue
+			}
+		}
+	}
+}
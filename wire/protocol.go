@@ -17,7 +17,7 @@ const (
 	InitialProcotolVersion uint32 = 1
 
 	// ProtocolVersion is the latest protocol version this package supports.
-	ProtocolVersion uint32 = 1
+	ProtocolVersion uint32 = 4
 
 	// BIP0111Version is the protocol version which added the SFNodeBloom
 	// service flag.
@@ -34,6 +34,20 @@ const (
 	// FeeFilterVersion is the protocol version which added a new
 	// feefilter message.
 	FeeFilterVersion uint32 = 1
+
+	// NoticeVersion is the protocol version which added a new notice
+	// message.
+	NoticeVersion uint32 = 2
+
+	// NodeCFVersion is the protocol version which adds the SFNodeCF
+	// service flag and the getcfilter, cfilter, getcfheaders, cfheaders,
+	// getcfcheckpt and cfcheckpt messages.
+	NodeCFVersion uint32 = 3
+
+	// LightBlockVersion is the protocol version which adds the
+	// SFNodeLightBlock service flag and the lightblock, getmissedtxs and
+	// missedtxs messages.
+	LightBlockVersion uint32 = 4
 )
 
 // ServiceFlag identifies services supported by a hypercash peer.
@@ -46,12 +60,22 @@ const (
 	// SFNodeBloom is a flag used to indiciate a peer supports bloom
 	// filtering.
 	SFNodeBloom
+
+	// SFNodeCF is a flag used to indicate a peer supports committed
+	// filters (CFs).
+	SFNodeCF
+
+	// SFNodeLightBlock is a flag used to indicate a peer supports the
+	// lightblock, getmissedtxs and missedtxs messages.
+	SFNodeLightBlock
 )
 
 // Map of service flags back to their constant names for pretty printing.
 var sfStrings = map[ServiceFlag]string{
-	SFNodeNetwork: "SFNodeNetwork",
-	SFNodeBloom:   "SFNodeBloom",
+	SFNodeNetwork:    "SFNodeNetwork",
+	SFNodeBloom:      "SFNodeBloom",
+	SFNodeCF:         "SFNodeCF",
+	SFNodeLightBlock: "SFNodeLightBlock",
 }
 
 // orderedSFStrings is an ordered list of service flags from highest to
@@ -59,6 +83,8 @@ var sfStrings = map[ServiceFlag]string{
 var orderedSFStrings = []ServiceFlag{
 	SFNodeNetwork,
 	SFNodeBloom,
+	SFNodeCF,
+	SFNodeLightBlock,
 }
 
 // String returns the ServiceFlag in human-readable form.
@@ -124,3 +150,32 @@ func (n CurrencyNet) String() string {
 
 	return fmt.Sprintf("Unknown CurrencyNet (%d)", uint32(n))
 }
+
+// FilterType identifies the type of a committed block filter.
+type FilterType uint8
+
+const (
+	// GCSFilterRegular is the regular filter type, which commits to the
+	// outpoints spent and the output scripts created by a block.
+	GCSFilterRegular FilterType = iota
+
+	// GCSFilterExtended is the extended filter type, which commits to the
+	// transaction hashes and input signature scripts of a block.
+	GCSFilterExtended
+)
+
+// ftStrings is a map of filter types back to their constant names for pretty
+// printing.
+var ftStrings = map[FilterType]string{
+	GCSFilterRegular:  "GCSFilterRegular",
+	GCSFilterExtended: "GCSFilterExtended",
+}
+
+// String returns the FilterType in human-readable form.
+func (t FilterType) String() string {
+	if s, ok := ftStrings[t]; ok {
+		return s
+	}
+
+	return fmt.Sprintf("Unknown FilterType (%d)", uint8(t))
+}
@@ -16,7 +16,9 @@ func TestServiceFlagStringer(t *testing.T) {
 		{0, "0x0"},
 		{SFNodeNetwork, "SFNodeNetwork"},
 		{SFNodeBloom, "SFNodeBloom"},
-		{0xffffffff, "SFNodeNetwork|SFNodeBloom|0xfffffffc"},
+		{SFNodeCF, "SFNodeCF"},
+		{SFNodeLightBlock, "SFNodeLightBlock"},
+		{0xffffffff, "SFNodeNetwork|SFNodeBloom|SFNodeCF|SFNodeLightBlock|0xfffffff0"},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -52,3 +54,25 @@ func TestCurrencyNetStringer(t *testing.T) {
 		}
 	}
 }
+
+// TestFilterTypeStringer tests the stringized output for filter types.
+func TestFilterTypeStringer(t *testing.T) {
+	tests := []struct {
+		in   FilterType
+		want string
+	}{
+		{GCSFilterRegular, "GCSFilterRegular"},
+		{GCSFilterExtended, "GCSFilterExtended"},
+		{0xff, "Unknown FilterType (255)"},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		result := test.in.String()
+		if result != test.want {
+			t.Errorf("String #%d\n got: %s want: %s", i, result,
+				test.want)
+			continue
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2017 The Hcash developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcashd/chaincfg/chainhash"
+)
+
+// pverMatrixMessage is a message exercised by the protocol version
+// compatibility matrix along with the first protocol version it is defined in.
+type pverMatrixMessage struct {
+	msg     Message
+	minPver uint32
+}
+
+// pverMatrixMessages returns a message for every command along with the first
+// protocol version the message is defined in.
+func pverMatrixMessages(t *testing.T) []pverMatrixMessage {
+	addr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8333}
+	na, err := NewNetAddress(addr, SFNodeNetwork)
+	if err != nil {
+		t.Fatalf("NewNetAddress: %v", err)
+	}
+	na.Timestamp = time.Unix(0x495fab29, 0)
+
+	return []pverMatrixMessage{
+		{NewMsgVersion(na, na, 123123, 0, 0), InitialProcotolVersion},
+		{NewMsgVerAck(), InitialProcotolVersion},
+		{NewMsgGetAddr(), InitialProcotolVersion},
+		{NewMsgAddr(), InitialProcotolVersion},
+		{NewMsgGetBlocks(&chainhash.Hash{}), InitialProcotolVersion},
+		{NewMsgInv(), InitialProcotolVersion},
+		{NewMsgGetData(), InitialProcotolVersion},
+		{NewMsgNotFound(), InitialProcotolVersion},
+		{&testBlock, InitialProcotolVersion},
+		{NewMsgTx(), InitialProcotolVersion},
+		{NewMsgGetHeaders(), InitialProcotolVersion},
+		{NewMsgHeaders(), InitialProcotolVersion},
+		{NewMsgPing(123123), InitialProcotolVersion},
+		{NewMsgPong(123123), InitialProcotolVersion},
+		{NewMsgAlert([]byte("payload"), []byte("signature")),
+			InitialProcotolVersion},
+		{NewMsgMemPool(), InitialProcotolVersion},
+		{NewMsgMiningState(), InitialProcotolVersion},
+		{NewMsgGetMiningState(), InitialProcotolVersion},
+		{NewMsgFilterAdd([]byte{0x01}), InitialProcotolVersion},
+		{NewMsgFilterClear(), InitialProcotolVersion},
+		{NewMsgFilterLoad([]byte{0x01}, 10, 0, BloomUpdateNone),
+			InitialProcotolVersion},
+		{NewMsgMerkleBlock(&testBlock.Header), InitialProcotolVersion},
+		{NewMsgReject("block", RejectDuplicate, "duplicate block"),
+			InitialProcotolVersion},
+		{NewMsgSendHeaders(), SendHeadersVersion},
+		{NewMsgFeeFilter(123123), FeeFilterVersion},
+		{NewMsgNotice(1, 2, "notice"), NoticeVersion},
+		{NewMsgGetCFilter(&chainhash.Hash{}, GCSFilterExtended),
+			NodeCFVersion},
+		{NewMsgCFilter(&chainhash.Hash{}, GCSFilterExtended,
+			[]byte("payload")), NodeCFVersion},
+		{NewMsgGetCFHeaders(), NodeCFVersion},
+		{NewMsgCFHeaders(), NodeCFVersion},
+		{NewMsgGetCFCheckpt(GCSFilterExtended, &chainhash.Hash{}),
+			NodeCFVersion},
+		{NewMsgCFCheckpt(GCSFilterExtended, &chainhash.Hash{}, 0),
+			NodeCFVersion},
+		{NewMsgLightBlockFromBlock(&testBlock), LightBlockVersion},
+		{NewMsgGetMissedTxs(&chainhash.Hash{}), LightBlockVersion},
+		{NewMsgMissedTxs(&chainhash.Hash{}), LightBlockVersion},
+	}
+}
+
+// TestProtocolVersionMatrix ensures every message is encoded and decoded
+// consistently for every pair of supported protocol versions two peers may
+// advertise.  A message must round trip at the negotiated version when it is
+// defined in that version, and otherwise must be refused by both the sender
+// and the receiver rather than being silently misencoded.
+func TestProtocolVersionMatrix(t *testing.T) {
+	messages := pverMatrixMessages(t)
+
+	// Ensure every command is covered so messages added in the future are
+	// not forgotten.
+	covered := make(map[string]struct{}, len(messages))
+	for _, m := range messages {
+		covered[m.msg.Command()] = struct{}{}
+	}
+	for _, cmd := range []string{CmdVersion, CmdVerAck, CmdGetAddr, CmdAddr,
+		CmdGetBlocks, CmdInv, CmdGetData, CmdNotFound, CmdBlock, CmdTx,
+		CmdGetHeaders, CmdHeaders, CmdPing, CmdPong, CmdAlert,
+		CmdMemPool, CmdMiningState, CmdGetMiningState, CmdFilterAdd,
+		CmdFilterClear, CmdFilterLoad, CmdMerkleBlock, CmdReject,
+		CmdSendHeaders, CmdFeeFilter, CmdNotice, CmdGetCFilter,
+		CmdCFilter, CmdGetCFHeaders, CmdCFHeaders, CmdGetCFCheckpt,
+		CmdCFCheckpt, CmdLightBlock, CmdGetMissedTxs, CmdMissedTxs} {
+
+		if _, ok := covered[cmd]; !ok {
+			t.Errorf("command %q is not covered by the protocol "+
+				"version matrix", cmd)
+		}
+	}
+
+	for local := InitialProcotolVersion; local <= ProtocolVersion; local++ {
+		for remote := InitialProcotolVersion; remote <= ProtocolVersion; remote++ {
+			// Peers always communicate with the lower of the
+			// advertised versions.
+			pver := local
+			if remote < pver {
+				pver = remote
+			}
+
+			for _, m := range messages {
+				testPverMatrixMessage(t, m, local, remote, pver)
+			}
+		}
+	}
+}
+
+// testPverMatrixMessage tests the passed message between peers advertising the
+// passed local and remote protocol versions which negotiated the passed
+// version.
+func testPverMatrixMessage(t *testing.T, m pverMatrixMessage, local, remote, pver uint32) {
+	cmd := m.msg.Command()
+
+	var buf bytes.Buffer
+	_, err := WriteMessageN(&buf, m.msg, pver, MainNet)
+	if pver < m.minPver {
+		if _, ok := err.(*MessageError); !ok {
+			t.Errorf("%s [local %d, remote %d]: sending message "+
+				"which is not defined in version %d: got error "+
+				"%v, want MessageError", cmd, local, remote, pver,
+				err)
+		}
+
+		// The receiver must refuse the message when it is encoded at
+		// a version the receiver does not speak.
+		buf.Reset()
+		_, err = WriteMessageN(&buf, m.msg, m.minPver, MainNet)
+		if err != nil {
+			t.Errorf("%s: unable to encode message at version "+
+				"%d: %v", cmd, m.minPver, err)
+			return
+		}
+		_, _, _, err = ReadMessageN(&buf, pver, MainNet)
+		if _, ok := err.(*MessageError); !ok {
+			t.Errorf("%s [local %d, remote %d]: receiving message "+
+				"which is not defined in version %d: got error "+
+				"%v, want MessageError", cmd, local, remote, pver,
+				err)
+		}
+		return
+	}
+	if err != nil {
+		t.Errorf("%s [local %d, remote %d]: unable to send message at "+
+			"version %d: %v", cmd, local, remote, pver, err)
+		return
+	}
+	encoded := append([]byte(nil), buf.Bytes()...)
+
+	_, msg, _, err := ReadMessageN(&buf, pver, MainNet)
+	if err != nil {
+		t.Errorf("%s [local %d, remote %d]: unable to receive message "+
+			"at version %d: %v", cmd, local, remote, pver, err)
+		return
+	}
+
+	// The decoded message must encode to the same bytes.
+	var reencoded bytes.Buffer
+	_, err = WriteMessageN(&reencoded, msg, pver, MainNet)
+	if err != nil {
+		t.Errorf("%s [local %d, remote %d]: unable to encode received "+
+			"message at version %d: %v", cmd, local, remote, pver,
+			err)
+		return
+	}
+	if !bytes.Equal(reencoded.Bytes(), encoded) {
+		t.Errorf("%s [local %d, remote %d]: message does not round "+
+			"trip at version %d", cmd, local, remote, pver)
+	}
+}